@@ -0,0 +1,127 @@
+package bytespool
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxSize is the capacity beyond which buffers are not returned to a pool
+const DefaultMaxSize = 64 << 10
+
+// Pool recycles bytes.Buffers and counts their usage
+type Pool struct {
+	name    string
+	maxSize int
+	pool    sync.Pool
+
+	gets      atomic.Int64
+	puts      atomic.Int64
+	news      atomic.Int64
+	discarded atomic.Int64
+}
+
+// PoolStats is a snapshot of a pool's counters
+type PoolStats struct {
+	Name      string `json:"name"`
+	Gets      int64  `json:"gets"`
+	Puts      int64  `json:"puts"`
+	News      int64  `json:"news"`      // Buffers allocated because the pool was empty
+	Discarded int64  `json:"discarded"` // Buffers dropped on Put for exceeding MaxSize
+	InUse     int64  `json:"in_use"`    // Gets minus puts
+}
+
+var (
+	mu    sync.Mutex
+	pools = map[string]*Pool{}
+
+	defaultPool = New("default", DefaultMaxSize)
+)
+
+// New returns the pool registered under name, creating it if needed. Buffers
+// with a capacity above maxSize are discarded on Put, DefaultMaxSize if maxSize <= 0.
+func New(name string, maxSize int) *Pool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if p, ok := pools[name]; ok {
+		return p
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	p := &Pool{name: name, maxSize: maxSize}
+	p.pool.New = func() any {
+		p.news.Add(1)
+		return new(bytes.Buffer)
+	}
+	pools[name] = p
+	return p
+}
+
+// Name returns the pool name
+func (p *Pool) Name() string { return p.name }
+
+// MaxSize returns the largest capacity kept by the pool
+func (p *Pool) MaxSize() int { return p.maxSize }
+
+// Get returns an empty buffer
+func (p *Pool) Get() *bytes.Buffer {
+	p.gets.Add(1)
+	buf := p.pool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// Put returns buf to the pool, it must not be used afterwards. Nil is ignored.
+func (p *Pool) Put(buf *bytes.Buffer) {
+	if buf == nil {
+		return
+	}
+	p.puts.Add(1)
+	if buf.Cap() > p.maxSize {
+		p.discarded.Add(1)
+		return
+	}
+	buf.Reset()
+	p.pool.Put(buf)
+}
+
+// Stats returns a snapshot of the pool's counters
+func (p *Pool) Stats() PoolStats {
+	puts := p.puts.Load()
+	gets := p.gets.Load()
+	return PoolStats{
+		Name:      p.name,
+		Gets:      gets,
+		Puts:      puts,
+		News:      p.news.Load(),
+		Discarded: p.discarded.Load(),
+		InUse:     gets - puts,
+	}
+}
+
+// Get returns an empty buffer from the default pool
+func Get() *bytes.Buffer { return defaultPool.Get() }
+
+// Put returns buf to the default pool
+func Put(buf *bytes.Buffer) { defaultPool.Put(buf) }
+
+// Stats returns the counters of every pool, sorted by name
+func Stats() []PoolStats {
+	mu.Lock()
+	list := make([]*Pool, 0, len(pools))
+	for _, p := range pools {
+		list = append(list, p)
+	}
+	mu.Unlock()
+
+	stats := make([]PoolStats, len(list))
+	for i, p := range list {
+		stats[i] = p.Stats()
+	}
+	slices.SortFunc(stats, func(a, b PoolStats) int { return strings.Compare(a.Name, b.Name) })
+	return stats
+}
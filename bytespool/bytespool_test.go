@@ -0,0 +1,90 @@
+package bytespool
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPoolCounters(t *testing.T) {
+	p := New("test.counters", 1024)
+	if New("test.counters", 0) != p {
+		t.Fatal("New should return the registered pool")
+	}
+
+	buf := p.Get()
+	buf.WriteString("hello")
+	p.Put(buf)
+
+	reused := p.Get()
+	if reused.Len() != 0 {
+		t.Fatalf("buffer not reset: %q", reused.String())
+	}
+
+	big := bytes.NewBuffer(make([]byte, 0, 4096))
+	p.Put(big)
+
+	s := p.Stats()
+	if s.Gets != 2 || s.Puts != 2 || s.Discarded != 1 || s.InUse != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if s.News < 1 {
+		t.Fatalf("expected at least one allocation, got %+v", s)
+	}
+
+	p.Put(reused)
+	p.Put(nil)
+	if s := p.Stats(); s.InUse != -1 || s.Puts != 3 {
+		t.Fatalf("stats after extra put = %+v", s)
+	}
+}
+
+func TestStatsListsPools(t *testing.T) {
+	New("test.b", 0)
+	New("test.a", 0)
+
+	var names []string
+	for _, s := range Stats() {
+		names = append(names, s.Name)
+	}
+	ia, ib := -1, -1
+	for i, n := range names {
+		switch n {
+		case "test.a":
+			ia = i
+		case "test.b":
+			ib = i
+		}
+	}
+	if ia < 0 || ib < 0 || ia > ib {
+		t.Fatalf("names = %v", names)
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	p := New("test.concurrent", 0)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 1000 {
+				buf := p.Get()
+				buf.WriteString("payload")
+				p.Put(buf)
+			}
+		})
+	}
+	wg.Wait()
+
+	if s := p.Stats(); s.Gets != 8000 || s.InUse != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		buf := Get()
+		buf.WriteString("a small response body")
+		Put(buf)
+	}
+}
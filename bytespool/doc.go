@@ -0,0 +1,34 @@
+// Package bytespool provides sync.Pool backed byte buffers for hot paths such
+// as response encoding, search bulk bodies and log formatting, with usage
+// counters to spot leaks.
+//
+// # Basic Usage
+//
+//	buf := bytespool.Get()
+//	defer bytespool.Put(buf)
+//
+//	buf.WriteString("hello")
+//	w.Write(buf.Bytes())
+//
+// A buffer must not be used after it is returned, including slices obtained
+// from Bytes. Copy the bytes first if they outlive the buffer.
+//
+// # Named Pools
+//
+// Subsystems with different buffer sizes use their own pool, so a few large
+// bulk bodies do not bloat buffers handed to small responses:
+//
+//	var bulkPool = bytespool.New("search.bulk", 4<<20)
+//
+// Buffers grown beyond the pool's MaxSize are dropped on Put instead of being
+// kept alive by the pool.
+//
+// # Metrics
+//
+// Every pool counts gets, puts, allocations and discarded buffers. InUse is
+// gets minus puts; if it keeps growing, some caller does not return buffers:
+//
+//	for _, s := range bytespool.Stats() {
+//	    log.Printf("%s in_use=%d new=%d", s.Name, s.InUse, s.News)
+//	}
+package bytespool
@@ -0,0 +1,3 @@
+module github.com/ncobase/ncore/bytespool
+
+go 1.25.3
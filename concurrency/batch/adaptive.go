@@ -0,0 +1,177 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AdaptiveOptions configures a Controller
+type AdaptiveOptions struct {
+	MinSize        int           // smallest batch, default 1
+	MaxSize        int           // largest batch, default 1000
+	InitialSize    int           // starting batch size, default MinSize
+	MinParallelism int           // fewest concurrent batches, default 1
+	MaxParallelism int           // most concurrent batches, default MinParallelism
+	TargetLatency  time.Duration // batches slower than this shrink the size, default 1s
+	Step           int           // additive size increase per good batch, default MaxSize/50, at least 1
+	Backoff        float64       // multiplicative decrease on errors and slow batches, default 0.5
+	ErrorThreshold float64       // no growth while the error rate is above this, default 0.1
+}
+
+// AdaptiveStats is a snapshot of a Controller
+type AdaptiveStats struct {
+	Size        int           `json:"size"`
+	Parallelism int           `json:"parallelism"`
+	ErrorRate   float64       `json:"error_rate"` // Exponentially weighted, recent batches count most
+	Batches     int64         `json:"batches"`
+	Errors      int64         `json:"errors"`
+	LastLatency time.Duration `json:"last_latency"`
+}
+
+// errorRateWeight is the weight of the latest batch in the error rate
+const errorRateWeight = 0.2
+
+// Controller sizes batches with additive increase, multiplicative decrease
+// (AIMD). Every fast, successful batch grows the size by Step; once the size
+// is at MaxSize, a full round of good batches adds one to the parallelism.
+// A failed batch halves (Backoff) both, a batch slower than TargetLatency
+// halves the size. A Controller is safe for concurrent use and is meant to
+// live as long as the sink it sizes batches for.
+type Controller struct {
+	opts AdaptiveOptions
+
+	mu          sync.Mutex
+	size        int
+	parallelism int
+	good        int
+	errorRate   float64
+	batches     int64
+	errors      int64
+	lastLatency time.Duration
+}
+
+// NewController creates a Controller
+func NewController(opts AdaptiveOptions) *Controller {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1000
+	}
+	opts.MaxSize = max(opts.MaxSize, opts.MinSize)
+	if opts.InitialSize <= 0 {
+		opts.InitialSize = opts.MinSize
+	}
+	if opts.MinParallelism <= 0 {
+		opts.MinParallelism = 1
+	}
+	opts.MaxParallelism = max(opts.MaxParallelism, opts.MinParallelism)
+	if opts.TargetLatency <= 0 {
+		opts.TargetLatency = time.Second
+	}
+	if opts.Step <= 0 {
+		opts.Step = max(opts.MaxSize/50, 1)
+	}
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.5
+	}
+	if opts.ErrorThreshold <= 0 {
+		opts.ErrorThreshold = 0.1
+	}
+	return &Controller{
+		opts:        opts,
+		size:        min(max(opts.InitialSize, opts.MinSize), opts.MaxSize),
+		parallelism: opts.MinParallelism,
+	}
+}
+
+// Size returns the current batch size
+func (c *Controller) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Parallelism returns the current number of concurrent batches
+func (c *Controller) Parallelism() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.parallelism
+}
+
+// Observe records the outcome of a batch of items handled in latency
+func (c *Controller) Observe(items int, latency time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batches++
+	c.lastLatency = latency
+	failed := 0.0
+	if err != nil {
+		c.errors++
+		failed = 1
+	}
+	c.errorRate = c.errorRate*(1-errorRateWeight) + failed*errorRateWeight
+
+	switch {
+	case err != nil:
+		c.size = c.decrease(c.size, c.opts.MinSize)
+		c.parallelism = c.decrease(c.parallelism, c.opts.MinParallelism)
+		c.good = 0
+	case latency > c.opts.TargetLatency:
+		c.size = c.decrease(c.size, c.opts.MinSize)
+		c.good = 0
+	case c.errorRate > c.opts.ErrorThreshold:
+		// Recovering from errors, hold until the rate settles
+	case c.size < c.opts.MaxSize:
+		// A short batch says nothing about larger ones
+		if items >= c.size {
+			c.size = min(c.size+c.opts.Step, c.opts.MaxSize)
+		}
+	default:
+		c.good++
+		if c.good >= c.parallelism && c.parallelism < c.opts.MaxParallelism {
+			c.parallelism++
+			c.good = 0
+		}
+	}
+}
+
+// decrease applies the multiplicative decrease, not going below floor
+func (c *Controller) decrease(v, floor int) int {
+	return max(int(float64(v)*c.opts.Backoff), floor)
+}
+
+// Stats returns a snapshot of the controller
+func (c *Controller) Stats() AdaptiveStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return AdaptiveStats{
+		Size:        c.size,
+		Parallelism: c.parallelism,
+		ErrorRate:   c.errorRate,
+		Batches:     c.batches,
+		Errors:      c.errors,
+		LastLatency: c.lastLatency,
+	}
+}
+
+// ProcessAdaptive splits items into batches sized by c and runs handler on
+// them with c's parallelism, feeding every outcome back into c. Like Process,
+// all batches run and the failed ones are returned as *Error[T] joined together.
+func ProcessAdaptive[T any](ctx context.Context, items []T, c *Controller, handler Handler[T]) error {
+	b := New(handler, Options{Interval: time.Hour, Adaptive: c, Context: ctx})
+	for start := 0; start < len(items); {
+		// Size the batch once a slot is free, so it reflects the batches before it
+		if err := b.acquire(ctx); err != nil {
+			b.record(&Error[T]{Items: items[start:], Err: err})
+			break
+		}
+		end := min(start+c.Size(), len(items))
+		b.run(items[start:end:end])
+		start = end
+	}
+	// Wait for started batches even when ctx is done, they see it themselves
+	return b.Flush(context.Background())
+}
@@ -0,0 +1,285 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when adding to a closed batcher
+var ErrClosed = errors.New("batch: batcher is closed")
+
+// Handler processes one batch
+type Handler[T any] func(ctx context.Context, items []T) error
+
+// Options configures a Batcher
+type Options struct {
+	Size        int             // flush when this many items are collected, default 100
+	Interval    time.Duration   // flush a partial batch this long after its first item, default 1s
+	Parallelism int             // concurrent handler calls, default 1
+	OnError     func(err error) // called for every failed batch, optional
+	Context     context.Context // passed to the handler, default context.Background
+	Adaptive    *Controller     // sizes batches and parallelism from their outcomes, overrides Size and Parallelism
+}
+
+// Error is a failed batch
+type Error[T any] struct {
+	Items []T
+	Err   error
+}
+
+func (e *Error[T]) Error() string {
+	return fmt.Sprintf("batch of %d items failed: %v", len(e.Items), e.Err)
+}
+
+func (e *Error[T]) Unwrap() error { return e.Err }
+
+// Batcher collects items into batches by size or time window and flushes
+// them through a handler with bounded parallelism
+type Batcher[T any] struct {
+	handler  Handler[T]
+	size     int
+	interval time.Duration
+	onError  func(error)
+	ctx      context.Context
+	adaptive *Controller
+
+	mu      sync.Mutex
+	pending []T
+	timer   *time.Timer
+	closed  bool
+
+	slotMu      sync.Mutex
+	running     int
+	parallelism int
+	released    chan struct{} // closed and replaced whenever a batch finishes
+	inflight    sync.WaitGroup
+
+	errMu sync.Mutex
+	errs  []error
+}
+
+// New creates a Batcher
+func New[T any](handler Handler[T], opts Options) *Batcher[T] {
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = 1
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+	return &Batcher[T]{
+		handler:     handler,
+		size:        opts.Size,
+		interval:    opts.Interval,
+		onError:     opts.OnError,
+		ctx:         opts.Context,
+		adaptive:    opts.Adaptive,
+		parallelism: opts.Parallelism,
+		released:    make(chan struct{}),
+	}
+}
+
+// Add adds items. When a batch fills up Add dispatches it, waiting for a
+// free handler slot until ctx is done.
+func (b *Batcher[T]) Add(ctx context.Context, items ...T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	var full [][]T
+	for _, item := range items {
+		b.pending = append(b.pending, item)
+		if len(b.pending) >= b.batchSize() {
+			full = append(full, b.take())
+		}
+	}
+	if len(b.pending) > 0 && b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.expire)
+	}
+	b.mu.Unlock()
+
+	for _, batch := range full {
+		if err := b.dispatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of items waiting for a flush
+func (b *Batcher[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush dispatches the pending items, waits for every running batch and
+// returns the errors collected since the last Flush
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	if len(batch) > 0 {
+		if err := b.dispatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+	err := errors.Join(b.errs...)
+	b.errs = nil
+	return err
+}
+
+// Close flushes the pending items and rejects further adds
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return b.Flush(ctx)
+}
+
+// take removes the pending items, the caller holds b.mu
+func (b *Batcher[T]) take() []T {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
+
+// expire flushes a partial batch when its window ends
+func (b *Batcher[T]) expire() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	if len(batch) > 0 {
+		_ = b.dispatch(context.Background(), batch)
+	}
+}
+
+// batchSize returns the size at which a batch is dispatched
+func (b *Batcher[T]) batchSize() int {
+	if b.adaptive != nil {
+		return b.adaptive.Size()
+	}
+	return b.size
+}
+
+// acquire waits for a free handler slot
+func (b *Batcher[T]) acquire(ctx context.Context) error {
+	for {
+		b.slotMu.Lock()
+		limit := b.parallelism
+		if b.adaptive != nil {
+			limit = b.adaptive.Parallelism()
+		}
+		if b.running < limit {
+			b.running++
+			b.slotMu.Unlock()
+			return nil
+		}
+		released := b.released
+		b.slotMu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees a handler slot and wakes the waiting dispatches
+func (b *Batcher[T]) release() {
+	b.slotMu.Lock()
+	b.running--
+	close(b.released)
+	b.released = make(chan struct{})
+	b.slotMu.Unlock()
+}
+
+// dispatch runs the handler for a batch once a slot is free
+func (b *Batcher[T]) dispatch(ctx context.Context, batch []T) error {
+	if err := b.acquire(ctx); err != nil {
+		b.record(&Error[T]{Items: batch, Err: err})
+		return err
+	}
+	b.run(batch)
+	return nil
+}
+
+// run handles a batch in a slot already acquired
+func (b *Batcher[T]) run(batch []T) {
+	b.inflight.Add(1)
+	go func() {
+		start := time.Now()
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				b.record(&Error[T]{Items: batch, Err: err})
+			}
+			if b.adaptive != nil {
+				b.adaptive.Observe(len(batch), time.Since(start), err)
+			}
+			b.release()
+			b.inflight.Done()
+		}()
+		if err = b.handler(b.ctx, batch); err != nil {
+			b.record(&Error[T]{Items: batch, Err: err})
+		}
+	}()
+}
+
+// record keeps a batch error for the next Flush
+func (b *Batcher[T]) record(err error) {
+	b.errMu.Lock()
+	b.errs = append(b.errs, err)
+	b.errMu.Unlock()
+	if b.onError != nil {
+		b.onError(err)
+	}
+}
+
+// Process splits items into batches of size and runs handler on them with
+// at most parallelism batches at a time. All batches run; the failed ones
+// are returned as *Error[T] joined together.
+func Process[T any](ctx context.Context, items []T, size, parallelism int, handler Handler[T]) error {
+	if size <= 0 {
+		size = len(items)
+	}
+	b := New(handler, Options{Size: max(size, 1), Interval: time.Hour, Parallelism: parallelism, Context: ctx})
+	for start := 0; start < len(items); start += size {
+		end := min(start+size, len(items))
+		if err := b.dispatch(ctx, items[start:end:end]); err != nil {
+			break
+		}
+	}
+	// Wait for started batches even when ctx is done, they see it themselves
+	return b.Flush(context.Background())
+}
@@ -0,0 +1,159 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchBySize(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	b := New(func(_ context.Context, items []int) error {
+		mu.Lock()
+		sizes = append(sizes, len(items))
+		mu.Unlock()
+		return nil
+	}, Options{Size: 3, Interval: time.Hour})
+
+	for i := range 7 {
+		if err := b.Add(context.Background(), i); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	total := 0
+	for _, n := range sizes {
+		total += n
+	}
+	if len(sizes) != 3 || total != 7 {
+		t.Fatalf("batch sizes = %v, want two of 3 and one of 1", sizes)
+	}
+	if err := b.Add(context.Background(), 8); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Add after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestBatchByInterval(t *testing.T) {
+	flushed := make(chan []string, 1)
+	b := New(func(_ context.Context, items []string) error {
+		flushed <- items
+		return nil
+	}, Options{Size: 100, Interval: 20 * time.Millisecond})
+
+	_ = b.Add(context.Background(), "a", "b")
+	select {
+	case items := <-flushed:
+		if len(items) != 2 {
+			t.Fatalf("items = %v", items)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed")
+	}
+}
+
+func TestProcessParallelismAndErrors(t *testing.T) {
+	var running, peak atomic.Int64
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	err := Process(context.Background(), items, 2, 3, func(_ context.Context, batch []int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		if batch[0] == 4 {
+			return errors.New("write failed")
+		}
+		return nil
+	})
+
+	if peak.Load() > 3 {
+		t.Fatalf("peak parallelism = %d, want at most 3", peak.Load())
+	}
+	var be *Error[int]
+	if !errors.As(err, &be) || len(be.Items) != 2 || be.Items[0] != 4 {
+		t.Fatalf("err = %v, want failed batch starting at 4", err)
+	}
+}
+
+func TestControllerAIMD(t *testing.T) {
+	c := NewController(AdaptiveOptions{MinSize: 10, MaxSize: 40, InitialSize: 20, Step: 10, MaxParallelism: 3, TargetLatency: 100 * time.Millisecond})
+
+	c.Observe(20, time.Millisecond, nil)
+	c.Observe(30, time.Millisecond, nil)
+	c.Observe(5, time.Millisecond, nil) // short batch, no growth
+	if s := c.Size(); s != 40 {
+		t.Fatalf("size after growth = %d, want 40", s)
+	}
+
+	// At MaxSize a round of good batches adds a worker
+	c.Observe(40, time.Millisecond, nil)
+	c.Observe(40, time.Millisecond, nil)
+	c.Observe(40, time.Millisecond, nil)
+	if p := c.Parallelism(); p != 3 {
+		t.Fatalf("parallelism = %d, want 3", p)
+	}
+
+	c.Observe(40, time.Second, nil)
+	if s, p := c.Size(), c.Parallelism(); s != 20 || p != 3 {
+		t.Fatalf("after slow batch size = %d parallelism = %d, want 20 and 3", s, p)
+	}
+
+	c.Observe(20, time.Millisecond, errors.New("rejected"))
+	if s, p := c.Size(), c.Parallelism(); s != 10 || p != 1 {
+		t.Fatalf("after error size = %d parallelism = %d, want 10 and 1", s, p)
+	}
+
+	// The error rate has to settle before growing again
+	c.Observe(10, time.Millisecond, nil)
+	if s := c.Size(); s != 10 {
+		t.Fatalf("size while recovering = %d, want 10", s)
+	}
+	for range 10 {
+		c.Observe(c.Size(), time.Millisecond, nil)
+	}
+	if st := c.Stats(); st.Size != 40 || st.Errors != 1 || st.ErrorRate > 0.1 {
+		t.Fatalf("stats after recovery = %+v", st)
+	}
+}
+
+func TestProcessAdaptive(t *testing.T) {
+	c := NewController(AdaptiveOptions{MinSize: 2, MaxSize: 16, Step: 2, TargetLatency: time.Second})
+
+	var mu sync.Mutex
+	var sizes []int
+	var seen int
+	items := make([]int, 100)
+	err := ProcessAdaptive(context.Background(), items, c, func(_ context.Context, batch []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(batch))
+		seen += len(batch)
+		if len(sizes) == 5 {
+			return errors.New("too large")
+		}
+		return nil
+	})
+
+	var be *Error[int]
+	if !errors.As(err, &be) || seen != len(items) {
+		t.Fatalf("err = %v, seen = %d", err, seen)
+	}
+	if sizes[0] != 2 || sizes[4] <= sizes[0] || sizes[5] >= sizes[4] {
+		t.Fatalf("batch sizes = %v, want growth then a cut after the failure", sizes)
+	}
+}
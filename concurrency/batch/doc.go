@@ -0,0 +1,43 @@
+// Package batch groups items into batches for bulk work such as search
+// indexing or multi-row inserts.
+//
+// A Batcher collects items and flushes a batch when it reaches Size items or
+// Interval after its first item, whichever comes first. At most Parallelism
+// batches are handled at a time; Add blocks while all slots are busy, which
+// pushes back on fast producers.
+//
+//	b := batch.New(func(ctx context.Context, docs []Doc) error {
+//	    return search.BulkIndex(ctx, "docs", docs)
+//	}, batch.Options{Size: 500, Interval: 2 * time.Second, Parallelism: 4})
+//
+//	for _, doc := range docs {
+//	    if err := b.Add(ctx, doc); err != nil {
+//	        return err
+//	    }
+//	}
+//	if err := b.Close(ctx); err != nil {
+//	    // errors.As(err, &batchErr) yields the failed items
+//	}
+//
+// Failed batches do not stop the others. Their errors are returned as
+// *Error[T], carrying the items, joined together by the next Flush or Close.
+//
+// Process runs the same flow over a slice that is already in memory.
+//
+// # Adaptive Sizing
+//
+// A Controller tunes batch size and parallelism from observed latency and
+// errors (AIMD). Successful batches faster than TargetLatency grow the size by
+// Step up to MaxSize, then add workers up to MaxParallelism. Slow batches halve
+// the size, failed batches halve size and parallelism, and growth waits while
+// the recent error rate is above ErrorThreshold:
+//
+//	ctrl := batch.NewController(batch.AdaptiveOptions{
+//	    MinSize: 50, MaxSize: 5000, MaxParallelism: 4, TargetLatency: 2 * time.Second,
+//	})
+//	b := batch.New(indexDocs, batch.Options{Interval: time.Second, Adaptive: ctrl})
+//	// or for a slice
+//	err := batch.ProcessAdaptive(ctx, docs, ctrl, indexDocs)
+//
+// Keep one Controller per sink, it carries what it learned across batches.
+package batch
@@ -2,4 +2,13 @@ module github.com/ncobase/ncore/concurrency
 
 go 1.25.3
 
-require github.com/google/wire v0.7.0
+require (
+	github.com/google/wire v0.7.0
+	github.com/ncobase/ncore/data v0.2.2
+	github.com/redis/go-redis/v9 v9.17.3
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+)
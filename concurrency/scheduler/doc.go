@@ -0,0 +1,29 @@
+// Package scheduler runs background jobs on cron expressions and fixed
+// intervals, complementing the worker pool in concurrency/worker.
+//
+//	s := scheduler.New(scheduler.Options{Locker: locker})
+//	err := s.Add(scheduler.Job{
+//	    Name:      "cleanup",
+//	    Spec:      "0 3 * * *", // or "@every 5m", "@hourly"
+//	    Func:      cleanup,
+//	    Jitter:    time.Minute,
+//	    Timeout:   10 * time.Minute,
+//	    Singleton: true,
+//	    Misfire:   scheduler.MisfireRunOnce,
+//	})
+//	s.Start()
+//	defer s.Stop(context.Background())
+//
+// Singleton jobs run on one node at a time: each run takes a data/lock lock
+// named after the job, and nodes that fail to acquire it skip the run. The
+// lock is renewed while the job runs and the job context is cancelled if it
+// is lost.
+//
+// A run that cannot start on time, because the job was paused, the previous
+// run is still going or the process was suspended, is handled by the job's
+// MisfirePolicy: skipped, or coalesced into one run as soon as possible.
+//
+// The last HistorySize finished runs of each job are kept for History.
+// RegisterRoutes mounts routes to list, pause, resume and trigger jobs and to
+// read their run history.
+package scheduler
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// RegisterRoutes mounts job management routes:
+//
+//	GET  /jobs               list jobs
+//	GET  /jobs/:name         get a job
+//	GET  /jobs/:name/history recent runs of a job
+//	POST /jobs/:name/pause   pause a job
+//	POST /jobs/:name/resume  resume a job
+//	POST /jobs/:name/trigger run a job now
+func (s *Scheduler) RegisterRoutes(r *gin.RouterGroup) {
+	jobs := r.Group("/jobs")
+
+	jobs.GET("", func(c *gin.Context) {
+		resp.Success(c.Writer, s.Jobs())
+	})
+
+	jobs.GET("/:name", func(c *gin.Context) {
+		info, err := s.Job(c.Param("name"))
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, info)
+	})
+
+	jobs.GET("/:name/history", func(c *gin.Context) {
+		runs, err := s.History(c.Param("name"))
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, runs)
+	})
+
+	jobs.POST("/:name/pause", func(c *gin.Context) {
+		s.respond(c, s.Pause(c.Param("name")))
+	})
+
+	jobs.POST("/:name/resume", func(c *gin.Context) {
+		s.respond(c, s.Resume(c.Param("name")))
+	})
+
+	jobs.POST("/:name/trigger", func(c *gin.Context) {
+		s.respond(c, s.Trigger(c.Param("name")))
+	})
+}
+
+// respond writes the job state after an action, or the action's error
+func (s *Scheduler) respond(c *gin.Context, err error) {
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	info, err := s.Job(c.Param("name"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.Success(c.Writer, info)
+}
+
+// fail maps scheduler errors to responses
+func fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		resp.Fail(c.Writer, resp.NotFound(err.Error()))
+	case errors.Is(err, ErrJobRunning):
+		resp.Fail(c.Writer, resp.Conflict(err.Error()))
+	default:
+		resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+	}
+}
@@ -0,0 +1,472 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/data/lock"
+)
+
+var (
+	// ErrJobNotFound is returned for unknown job names
+	ErrJobNotFound = errors.New("scheduler: job not found")
+	// ErrJobExists is returned when a job name is already registered
+	ErrJobExists = errors.New("scheduler: job already exists")
+	// ErrJobRunning is returned by Trigger while the job is running
+	ErrJobRunning = errors.New("scheduler: job is running")
+	// ErrStopped is returned by Trigger after Stop
+	ErrStopped = errors.New("scheduler: stopped")
+)
+
+// MisfirePolicy decides what happens to runs that could not start on time,
+// because the job was paused, still running, or the process was suspended
+type MisfirePolicy int
+
+const (
+	// MisfireSkip drops missed runs and waits for the next scheduled time
+	MisfireSkip MisfirePolicy = iota
+	// MisfireRunOnce coalesces missed runs into a single run as soon as possible
+	MisfireRunOnce
+)
+
+// Job is a scheduled background job
+type Job struct {
+	Name string
+	// Spec is a cron expression, a descriptor like @daily, or "@every 30s"
+	Spec    string
+	Func    func(ctx context.Context) error
+	Jitter  time.Duration // Random delay added to every run
+	Timeout time.Duration // Cancels a run after this long, 0 for no limit
+	// Singleton runs the job on one node at a time using the scheduler's Locker
+	Singleton bool
+	LockTTL   time.Duration // Singleton lock TTL, renewed while running, defaults to 1m
+	Misfire   MisfirePolicy
+	Paused    bool // Register the job paused
+}
+
+// JobInfo is a snapshot of a job's state
+type JobInfo struct {
+	Name         string        `json:"name"`
+	Spec         string        `json:"spec"`
+	Paused       bool          `json:"paused"`
+	Running      bool          `json:"running"`
+	NextRun      time.Time     `json:"next_run"`
+	LastRun      time.Time     `json:"last_run,omitzero"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	Runs         int64         `json:"runs"`
+	Failures     int64         `json:"failures"`
+	Skipped      int64         `json:"skipped"` // Missed runs and runs held by another node
+}
+
+// Run is a finished run of a job
+type Run struct {
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Options configures a scheduler
+type Options struct {
+	Location     *time.Location // Time zone of cron expressions, defaults to time.Local
+	Locker       lock.Locker    // Required for singleton jobs
+	LockPrefix   string         // Defaults to "scheduler:"
+	MisfireGrace time.Duration  // Lateness after which a run counts as missed, defaults to 1s
+	HistorySize  int            // Finished runs kept per job, defaults to 20
+	OnError      func(job string, err error)
+}
+
+// Scheduler runs jobs on cron and interval schedules
+type Scheduler struct {
+	opts Options
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+	started bool
+}
+
+// entry is a registered job and its runtime state
+type entry struct {
+	job      Job
+	schedule Schedule
+	wake     chan struct{}
+	stop     chan struct{}
+
+	mu      sync.Mutex
+	info    JobInfo
+	history []Run // Oldest first, at most Options.HistorySize
+	pending bool  // A coalesced missed run waits for the current run
+}
+
+// New creates a scheduler
+func New(opts Options) *Scheduler {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.LockPrefix == "" {
+		opts.LockPrefix = "scheduler:"
+	}
+	if opts.MisfireGrace <= 0 {
+		opts.MisfireGrace = time.Second
+	}
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 20
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		opts:   opts,
+		jobs:   make(map[string]*entry),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add registers a job, scheduling it immediately if the scheduler is started
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" {
+		return errors.New("job name is required")
+	}
+	if job.Func == nil {
+		return fmt.Errorf("job %s has no func", job.Name)
+	}
+	if job.Singleton && s.opts.Locker == nil {
+		return fmt.Errorf("job %s is a singleton but the scheduler has no locker", job.Name)
+	}
+	schedule, err := Parse(job.Spec, s.opts.Location)
+	if err != nil {
+		return fmt.Errorf("job %s: %v", job.Name, err)
+	}
+
+	e := &entry{
+		job:      job,
+		schedule: schedule,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		info: JobInfo{
+			Name:    job.Name,
+			Spec:    job.Spec,
+			Paused:  job.Paused,
+			NextRun: schedule.Next(time.Now()),
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+	}
+	s.jobs[job.Name] = e
+	if s.started {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Remove unschedules a job, a run in progress finishes
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	e, ok := s.jobs[name]
+	delete(s.jobs, name)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	close(e.stop)
+	return nil
+}
+
+// Start schedules all registered jobs
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.ctx.Err() != nil {
+		return
+	}
+	s.started = true
+	for _, e := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return or ctx to be done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.loops.Wait()
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause stops scheduling a job until Resume, a run in progress finishes
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume schedules a paused job again, applying its misfire policy to runs missed while paused
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+// Trigger runs a job now, regardless of its schedule or pause state
+func (s *Scheduler) Trigger(name string) error {
+	e, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	if s.ctx.Err() != nil {
+		return ErrStopped
+	}
+	if !s.start(e) {
+		return fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+	return nil
+}
+
+// Job returns a snapshot of one job
+func (s *Scheduler) Job(name string) (JobInfo, error) {
+	e, err := s.get(name)
+	if err != nil {
+		return JobInfo{}, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.info, nil
+}
+
+// History returns the most recent finished runs of a job, newest first
+func (s *Scheduler) History(name string) ([]Run, error) {
+	e, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	runs := make([]Run, len(e.history))
+	for i, r := range e.history {
+		runs[len(runs)-1-i] = r
+	}
+	return runs, nil
+}
+
+// Jobs returns snapshots of all jobs sorted by name
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	entries := make([]*entry, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	infos := make([]JobInfo, len(entries))
+	for i, e := range entries {
+		e.mu.Lock()
+		infos[i] = e.info
+		e.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// get returns a registered job
+func (s *Scheduler) get(name string) (*entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	return e, nil
+}
+
+// setPaused updates the pause flag and wakes the job loop
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	e, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.info.Paused = paused
+	e.mu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// loop waits for each activation time of a job and starts its runs
+func (s *Scheduler) loop(e *entry) {
+	defer s.loops.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		e.mu.Lock()
+		paused, due := e.info.Paused, e.info.NextRun
+		e.mu.Unlock()
+
+		var fire <-chan time.Time
+		if !paused && !due.IsZero() {
+			wait := time.Until(due)
+			if e.job.Jitter > 0 {
+				wait += rand.N(e.job.Jitter)
+			}
+			timer.Reset(max(wait, 0))
+			fire = timer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-e.stop:
+			return
+		case <-e.wake:
+			timer.Stop()
+			e.mu.Lock()
+			paused = e.info.Paused
+			e.mu.Unlock()
+			if paused || due.IsZero() || time.Now().Before(due) {
+				continue
+			}
+		case <-fire:
+		}
+
+		s.activate(e, due)
+	}
+}
+
+// activate handles an activation that was due at the given time
+func (s *Scheduler) activate(e *entry, due time.Time) {
+	now := time.Now()
+	missed := now.Sub(due) > s.opts.MisfireGrace+e.job.Jitter
+
+	e.mu.Lock()
+	e.info.NextRun = e.schedule.Next(now)
+	running := e.info.Running
+	if missed || running {
+		if e.job.Misfire == MisfireSkip {
+			e.info.Skipped++
+			e.mu.Unlock()
+			return
+		}
+		if running {
+			e.pending = true
+			e.mu.Unlock()
+			return
+		}
+	}
+	e.mu.Unlock()
+
+	s.start(e)
+}
+
+// start begins a run unless one is in progress
+func (s *Scheduler) start(e *entry) bool {
+	e.mu.Lock()
+	if e.info.Running {
+		e.mu.Unlock()
+		return false
+	}
+	e.info.Running = true
+	e.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		for s.run(e) {
+		}
+	}()
+	return true
+}
+
+// run executes the job once, returning true if a coalesced run is pending
+func (s *Scheduler) run(e *entry) bool {
+	started := time.Now()
+	ran, err := s.execute(e)
+	if err != nil && s.opts.OnError != nil {
+		s.opts.OnError(e.job.Name, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ran {
+		e.info.Runs++
+		e.info.LastRun = started
+		e.info.LastDuration = time.Since(started)
+		e.info.LastError = ""
+		if err != nil {
+			e.info.Failures++
+			e.info.LastError = err.Error()
+		}
+		if len(e.history) >= s.opts.HistorySize {
+			e.history = append(e.history[:0], e.history[len(e.history)-s.opts.HistorySize+1:]...)
+		}
+		e.history = append(e.history, Run{Started: started, Duration: e.info.LastDuration, Error: e.info.LastError})
+	} else {
+		e.info.Skipped++
+	}
+
+	if e.pending && s.ctx.Err() == nil {
+		e.pending = false
+		return true
+	}
+	e.info.Running = false
+	return false
+}
+
+// execute runs the job function under its lock and timeout.
+// ran is false when another node holds the singleton lock.
+func (s *Scheduler) execute(e *entry) (ran bool, err error) {
+	ctx := s.ctx
+	if e.job.Singleton {
+		ttl := e.job.LockTTL
+		if ttl <= 0 {
+			ttl = time.Minute
+		}
+		l, err := s.opts.Locker.TryLock(ctx, s.opts.LockPrefix+e.job.Name, lock.WithTTL(ttl))
+		if errors.Is(err, lock.ErrNotAcquired) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire job lock: %v", err)
+		}
+		defer func() { _ = l.Unlock(context.WithoutCancel(ctx)) }()
+		ctx = l.Context()
+	}
+
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", e.job.Name, r)
+		}
+	}()
+	return true, e.job.Func(ctx)
+}
@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data/lock"
+)
+
+func TestParseCron(t *testing.T) {
+	loc := time.UTC
+	from := time.Date(2025, 1, 15, 10, 7, 30, 0, loc) // Wednesday
+
+	cases := map[string]time.Time{
+		"*/15 * * * *":       time.Date(2025, 1, 15, 10, 15, 0, 0, loc),
+		"0 9-17 * * mon-fri": time.Date(2025, 1, 15, 11, 0, 0, 0, loc),
+		"30 2 1 * *":         time.Date(2025, 2, 1, 2, 30, 0, 0, loc),
+		"0 0 * * 7":          time.Date(2025, 1, 19, 0, 0, 0, 0, loc),
+		"0 0 13 * fri":       time.Date(2025, 1, 17, 0, 0, 0, 0, loc), // day-of-month or day-of-week
+		"0 0 29 feb *":       time.Date(2028, 2, 29, 0, 0, 0, 0, loc),
+		"@hourly":            time.Date(2025, 1, 15, 11, 0, 0, 0, loc),
+		"@every 90s":         time.Date(2025, 1, 15, 10, 9, 0, 0, loc),
+	}
+	for spec, want := range cases {
+		s, err := Parse(spec, loc)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(want) {
+			t.Errorf("Parse(%q).Next = %v, want %v", spec, got, want)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every 10ms"} {
+		if _, err := Parse(spec, loc); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestTriggerAndPause(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	s := New(Options{})
+	if err := s.Add(Job{Name: "report", Spec: "@daily", Func: func(ctx context.Context) error {
+		runs.Add(1)
+		<-release
+		return errors.New("boom")
+	}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	s.Start()
+	defer s.Stop(context.Background())
+
+	if err := s.Trigger("report"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if err := s.Trigger("report"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("second Trigger = %v, want ErrJobRunning", err)
+	}
+	close(release)
+
+	waitFor(t, func() bool {
+		info, _ := s.Job("report")
+		return !info.Running
+	})
+	info, _ := s.Job("report")
+	if runs.Load() != 1 || info.Runs != 1 || info.Failures != 1 || info.LastError != "boom" {
+		t.Errorf("unexpected job info %+v", info)
+	}
+
+	if err := s.Pause("report"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if info, _ := s.Job("report"); !info.Paused {
+		t.Error("expected job to be paused")
+	}
+	if err := s.Trigger("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Trigger(missing) = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var n atomic.Int32
+	s := New(Options{HistorySize: 2})
+	_ = s.Add(Job{Name: "sync", Spec: "@daily", Func: func(ctx context.Context) error {
+		if n.Add(1) == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	}})
+	s.Start()
+	defer s.Stop(context.Background())
+
+	for i := 1; i <= 3; i++ {
+		if err := s.Trigger("sync"); err != nil {
+			t.Fatalf("Trigger: %v", err)
+		}
+		waitFor(t, func() bool {
+			info, _ := s.Job("sync")
+			return info.Runs == int64(i) && !info.Running
+		})
+	}
+
+	runs, err := s.History("sync")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Error != "" || runs[1].Error != "boom" || runs[0].Started.Before(runs[1].Started) {
+		t.Errorf("unexpected history %+v", runs)
+	}
+	if _, err := s.History("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("History(missing) = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestIntervalAndMisfire(t *testing.T) {
+	var runs atomic.Int32
+	s := New(Options{})
+	_ = s.Add(Job{Name: "tick", Spec: "@every 1s", Misfire: MisfireRunOnce, Func: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	s.Start()
+	defer s.Stop(context.Background())
+
+	waitFor(t, func() bool { return runs.Load() >= 1 })
+
+	// Runs missed while paused are coalesced into one on resume
+	_ = s.Pause("tick")
+	time.Sleep(2500 * time.Millisecond)
+	before := runs.Load()
+	_ = s.Resume("tick")
+	waitFor(t, func() bool { return runs.Load() == before+1 })
+}
+
+func TestSingletonSkipsWhenLockHeld(t *testing.T) {
+	locker := &fakeLocker{held: true}
+	var runs atomic.Int32
+	s := New(Options{Locker: locker})
+	_ = s.Add(Job{Name: "cleanup", Spec: "@hourly", Singleton: true, Func: func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	_ = s.Trigger("cleanup")
+	waitFor(t, func() bool {
+		info, _ := s.Job("cleanup")
+		return info.Skipped == 1 && !info.Running
+	})
+
+	locker.held = false
+	_ = s.Trigger("cleanup")
+	waitFor(t, func() bool { return runs.Load() == 1 })
+	if locker.key != "scheduler:cleanup" {
+		t.Errorf("lock key = %q", locker.key)
+	}
+
+	if err := New(Options{}).Add(Job{Name: "x", Spec: "@daily", Singleton: true, Func: func(context.Context) error { return nil }}); err == nil {
+		t.Error("expected singleton job without locker to be rejected")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type fakeLocker struct {
+	held bool
+	key  string
+}
+
+func (f *fakeLocker) TryLock(ctx context.Context, key string, _ ...lock.Option) (lock.Lock, error) {
+	if f.held {
+		return nil, lock.ErrNotAcquired
+	}
+	f.key = key
+	return &fakeLock{key: key, ctx: ctx}, nil
+}
+
+func (f *fakeLocker) Lock(ctx context.Context, key string, opts ...lock.Option) (lock.Lock, error) {
+	return f.TryLock(ctx, key, opts...)
+}
+
+type fakeLock struct {
+	key string
+	ctx context.Context
+}
+
+func (l *fakeLock) Key() string                       { return l.key }
+func (l *fakeLock) Context() context.Context          { return l.ctx }
+func (l *fakeLock) Refresh(ctx context.Context) error { return nil }
+func (l *fakeLock) Unlock(ctx context.Context) error  { return nil }
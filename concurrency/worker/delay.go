@@ -0,0 +1,223 @@
+package worker
+
+import (
+	"container/heap"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Task is a serializable task. Tasks submitted to a pool with a Store are
+// persisted until processed, and are handed to the processor as *Task.
+type Task struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Payload  []byte    `json:"payload,omitempty"`
+	Priority int       `json:"priority"` // Higher runs first among due tasks
+	RunAt    time.Time `json:"run_at"`   // Zero runs as soon as possible
+	Attempts int       `json:"attempts"` // Failed attempts so far
+
+	claimed bool // Loaded from the store, acknowledged after processing
+}
+
+// SubmitAt submits a task to run at the given time
+func (p *Pool) SubmitAt(task any, at time.Time) error {
+	return p.schedule(task, at, 0)
+}
+
+// SubmitAfter submits a task to run after the given delay
+func (p *Pool) SubmitAfter(task any, delay time.Duration) error {
+	return p.schedule(task, time.Now().Add(delay), 0)
+}
+
+// SubmitWithPriority submits a task ahead of due tasks with a lower priority
+func (p *Pool) SubmitWithPriority(task any, priority int) error {
+	return p.schedule(task, time.Time{}, priority)
+}
+
+// SubmitTask submits a task using its RunAt and Priority.
+// With a configured Store the task is persisted and survives restarts.
+func (p *Pool) SubmitTask(t *Task) error {
+	return p.schedule(t, t.RunAt, t.Priority)
+}
+
+// queued is a task waiting in the delayed or ready queue
+type queued struct {
+	task     any
+	runAt    time.Time
+	priority int
+	seq      uint64
+	index    int
+}
+
+// taskHeap is a heap of queued tasks ordered by less
+type taskHeap struct {
+	items []*queued
+	less  func(a, b *queued) bool
+}
+
+func (h *taskHeap) Len() int           { return len(h.items) }
+func (h *taskHeap) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *taskHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+func (h *taskHeap) Push(x any) {
+	q := x.(*queued)
+	q.index = len(h.items)
+	h.items = append(h.items, q)
+}
+func (h *taskHeap) Pop() any {
+	n := len(h.items) - 1
+	q := h.items[n]
+	h.items[n] = nil
+	h.items = h.items[:n]
+	return q
+}
+
+// byRunAt orders the delayed queue
+func byRunAt(a, b *queued) bool {
+	if !a.runAt.Equal(b.runAt) {
+		return a.runAt.Before(b.runAt)
+	}
+	return a.seq < b.seq
+}
+
+// byPriority orders the ready queue
+func byPriority(a, b *queued) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return byRunAt(a, b)
+}
+
+// schedule queues a task in memory, or persists it when the pool has a store
+func (p *Pool) schedule(task any, runAt time.Time, priority int) error {
+	if t, ok := task.(*Task); ok && p.store != nil {
+		if t.ID == "" {
+			t.ID = newTaskID()
+		}
+		t.RunAt, t.Priority = runAt, priority
+		if t.RunAt.IsZero() {
+			t.RunAt = time.Now()
+		}
+		return p.store.Push(p.ctx, t)
+	}
+
+	p.mu.Lock()
+	if p.delayed.Len()+p.ready.Len() >= p.queueSize {
+		p.mu.Unlock()
+		return ErrQueueFull
+	}
+	p.seq++
+	q := &queued{task: task, runAt: runAt, priority: priority, seq: p.seq}
+	if runAt.After(time.Now()) {
+		heap.Push(&p.delayed, q)
+	} else {
+		heap.Push(&p.ready, q)
+	}
+	p.metrics.ScheduledTasks.Add(1)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// dispatch moves due tasks to the workers in priority order and claims
+// persisted tasks from the store
+func (p *Pool) dispatch() {
+	defer p.dispatcher.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		p.mu.Lock()
+		now := time.Now()
+		for p.delayed.Len() > 0 && !p.delayed.items[0].runAt.After(now) {
+			heap.Push(&p.ready, heap.Pop(&p.delayed))
+		}
+
+		var (
+			next *queued
+			out  chan<- any
+			task any
+		)
+		if p.ready.Len() > 0 {
+			next = p.ready.items[0]
+			out, task = p.tasks, next.task
+		}
+		wait := time.Minute
+		if p.store != nil {
+			wait = p.pollInterval
+		}
+		if p.delayed.Len() > 0 {
+			wait = min(wait, p.delayed.items[0].runAt.Sub(now))
+		}
+		p.mu.Unlock()
+
+		timer.Reset(max(wait, 0))
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.wake:
+		case <-timer.C:
+			if p.store != nil {
+				p.claim()
+			}
+		case out <- task:
+			p.mu.Lock()
+			heap.Remove(&p.ready, next.index)
+			p.mu.Unlock()
+			p.metrics.ScheduledTasks.Add(-1)
+			p.metrics.PendingTasks.Add(1)
+		}
+	}
+}
+
+// claim loads due persisted tasks up to the free queue capacity
+func (p *Pool) claim() {
+	p.mu.Lock()
+	free := p.queueSize - len(p.tasks) - p.ready.Len()
+	p.mu.Unlock()
+	if free <= 0 {
+		return
+	}
+
+	tasks, err := p.store.Claim(p.ctx, time.Now(), free, p.lease)
+	if err != nil {
+		p.metrics.StoreErrors.Add(1)
+		return
+	}
+
+	p.mu.Lock()
+	for _, t := range tasks {
+		t.claimed = true
+		p.seq++
+		heap.Push(&p.ready, &queued{task: t, runAt: t.RunAt, priority: t.Priority, seq: p.seq})
+	}
+	p.mu.Unlock()
+	p.metrics.ScheduledTasks.Add(int64(len(tasks)))
+}
+
+// ack removes a processed task from the store. Tasks interrupted by Stop are
+// kept and claimed again once their lease expires.
+func (p *Pool) ack(t *Task) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	if err := p.store.Ack(p.ctx, t.ID); err != nil {
+		p.metrics.StoreErrors.Add(1)
+	}
+}
+
+// newTaskID returns a random task ID
+func newTaskID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingProcessor struct {
+	mu    sync.Mutex
+	order []string
+	done  chan struct{}
+}
+
+func (r *recordingProcessor) Process(task any) error {
+	name, ok := task.(string)
+	if t, isTask := task.(*Task); isTask {
+		name, ok = t.Type, true
+	}
+	if !ok {
+		return nil
+	}
+	r.mu.Lock()
+	r.order = append(r.order, name)
+	r.mu.Unlock()
+	r.done <- struct{}{}
+	return nil
+}
+
+func (r *recordingProcessor) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for range n {
+		select {
+		case <-r.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for tasks")
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.order...)
+}
+
+func TestDelayedAndPriority(t *testing.T) {
+	proc := &recordingProcessor{done: make(chan struct{}, 10)}
+	p := NewPool(&Config{MaxWorkers: 1, QueueSize: 10, TaskTimeout: time.Second}, proc)
+
+	// Queue before starting so priorities decide the order
+	_ = p.SubmitAfter("late", 100*time.Millisecond)
+	_ = p.SubmitWithPriority("low", 1)
+	_ = p.SubmitWithPriority("high", 5)
+	_ = p.SubmitAt("due", time.Now().Add(-time.Second))
+
+	p.Start()
+	defer p.Stop(context.Background())
+
+	got := proc.wait(t, 4)
+	want := []string{"high", "low", "due", "late"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order = %v, want %v", got, want)
+		}
+	}
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	tasks map[string]*Task
+	acked []string
+}
+
+func (m *memoryStore) Push(_ context.Context, t *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *t
+	m.tasks[t.ID] = &c
+	return nil
+}
+
+func (m *memoryStore) Claim(_ context.Context, now time.Time, limit int, lease time.Duration) ([]*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*Task
+	for _, t := range m.tasks {
+		if len(out) < limit && !t.RunAt.After(now) {
+			c := *t
+			out = append(out, &c)
+			t.RunAt = now.Add(lease)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryStore) Ack(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tasks, id)
+	m.acked = append(m.acked, id)
+	return nil
+}
+
+func TestPersistentTasks(t *testing.T) {
+	store := &memoryStore{tasks: make(map[string]*Task)}
+	proc := &recordingProcessor{done: make(chan struct{}, 10)}
+	p := NewPool(&Config{MaxWorkers: 1, QueueSize: 10, TaskTimeout: time.Second, Store: store, PollInterval: 10 * time.Millisecond}, proc)
+
+	task := &Task{Type: "email", RunAt: time.Now().Add(50 * time.Millisecond)}
+	if err := p.SubmitTask(task); err != nil {
+		t.Fatalf("SubmitTask: %v", err)
+	}
+	if task.ID == "" || len(store.tasks) != 1 {
+		t.Fatal("expected task to be persisted with an ID")
+	}
+
+	p.Start()
+	defer p.Stop(context.Background())
+	proc.wait(t, 1)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.Lock()
+		acked := len(store.acked) == 1 && len(store.tasks) == 0
+		store.mu.Unlock()
+		if acked {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("task was not acknowledged")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// claimScript leases due task IDs by moving their score past the lease and
+// returns the task bodies
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #ids == 0 then
+	return {}
+end
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[3], id)
+end
+return redis.call('HMGET', KEYS[2], unpack(ids))
+`)
+
+// RedisStore persists tasks in a sorted set scored by run time and a hash of task bodies
+type RedisStore struct {
+	client redis.UniversalClient
+	queue  string
+	data   string
+}
+
+// NewRedisStore creates a Redis backed task store, key defaults to "worker:tasks"
+func NewRedisStore(client redis.UniversalClient, key string) *RedisStore {
+	if key == "" {
+		key = "worker:tasks"
+	}
+	// Hash tags keep both keys in one cluster slot for the claim script
+	return &RedisStore{
+		client: client,
+		queue:  "{" + key + "}:queue",
+		data:   "{" + key + "}:data",
+	}
+}
+
+// Push saves a task
+func (s *RedisStore) Push(ctx context.Context, t *Task) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.data, t.ID, body)
+		pipe.ZAdd(ctx, s.queue, redis.Z{Score: float64(t.RunAt.UnixMilli()), Member: t.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist task %s: %v", t.ID, err)
+	}
+	return nil
+}
+
+// Claim leases the earliest due tasks, ordered by priority
+func (s *RedisStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Task, error) {
+	res, err := claimScript.Run(ctx, s.client, []string{s.queue, s.data},
+		now.UnixMilli(), limit, now.Add(lease).UnixMilli()).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim tasks: %v", err)
+	}
+
+	tasks := make([]*Task, 0, len(res))
+	for _, v := range res {
+		body, ok := v.(string)
+		if !ok {
+			continue // Body missing, nothing to run
+		}
+		var t Task
+		if err := json.Unmarshal([]byte(body), &t); err != nil {
+			return nil, fmt.Errorf("invalid task body: %v", err)
+		}
+		tasks = append(tasks, &t)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Priority > tasks[j].Priority })
+	return tasks, nil
+}
+
+// Ack deletes a task
+func (s *RedisStore) Ack(ctx context.Context, id string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.queue, id)
+		pipe.HDel(ctx, s.data, id)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,119 @@
+package worker
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrTaskTimeout is passed to the retry policy when a task exceeds TaskTimeout
+var ErrTaskTimeout = errors.New("task timed out")
+
+// Backoff strategies
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+// RetryPolicy controls how failed tasks are retried
+type RetryPolicy struct {
+	MaxAttempts int                  // total attempts including the first, 1 disables retries
+	Backoff     string               // BackoffFixed or BackoffExponential, default exponential
+	Delay       time.Duration        // fixed delay or exponential base, default 1s
+	MaxDelay    time.Duration        // exponential cap, default 5m
+	Jitter      float64              // random fraction in [0, 1] added to exponential delays
+	Retryable   func(err error) bool // classifies errors, nil retries every error
+}
+
+// DeadLetterFunc receives tasks whose attempts are exhausted or whose error
+// is not retryable
+type DeadLetterFunc func(task any, attempts int, err error)
+
+// retried wraps an in-memory task with its attempt count
+type retried struct {
+	task     any
+	attempts int
+}
+
+// delay returns the wait before the given retry, attempt starts at 1
+func (r *RetryPolicy) delay(attempt int) time.Duration {
+	base := r.Delay
+	if base <= 0 {
+		base = time.Second
+	}
+	if r.Backoff == BackoffFixed {
+		return base
+	}
+
+	limit := r.MaxDelay
+	if limit <= 0 {
+		limit = 5 * time.Minute
+	}
+	d := base
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	d = min(d, limit)
+	if r.Jitter > 0 {
+		d += time.Duration(rand.Float64() * min(r.Jitter, 1) * float64(d))
+	}
+	return d
+}
+
+// retryable reports whether a task that failed on its attempts-th try runs again
+func (r *RetryPolicy) retryable(attempts int, err error) bool {
+	if r == nil || attempts >= r.MaxAttempts {
+		return false
+	}
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+// fail retries a failed task or hands it to the dead letter handler
+func (p *Pool) fail(task any, attempts int, err error) {
+	t, isTask := task.(*Task)
+	claimed := isTask && t.claimed
+
+	// Interrupted by Stop: claimed tasks stay in the store for the next lease
+	if p.ctx.Err() != nil {
+		return
+	}
+
+	if p.retry.retryable(attempts, err) {
+		runAt := time.Now().Add(p.retry.delay(attempts))
+		var serr error
+		if isTask {
+			// Copy, a timed out processor may still hold t. A claimed task is
+			// saved under a new ID before the old one is acknowledged, so a
+			// failed write leaves it in the store to run again after its lease.
+			next := *t
+			next.Attempts, next.claimed = attempts, false
+			if claimed {
+				next.ID = ""
+			}
+			serr = p.schedule(&next, runAt, t.Priority)
+		} else {
+			serr = p.schedule(&retried{task: task, attempts: attempts}, runAt, 0)
+		}
+		if serr == nil {
+			if claimed {
+				p.ack(t)
+			}
+			p.metrics.RetriedTasks.Add(1)
+			return
+		}
+		if isTask && p.store != nil {
+			p.metrics.StoreErrors.Add(1)
+		}
+		if claimed {
+			return
+		}
+		err = errors.Join(err, serr)
+	} else if claimed {
+		p.ack(t)
+	}
+
+	p.metrics.DeadLetterTasks.Add(1)
+	if p.deadLetter != nil {
+		p.deadLetter(task, attempts, err)
+	}
+}
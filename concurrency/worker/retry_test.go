@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type failingProcessor struct {
+	calls atomic.Int64
+	fails int64
+	err   error
+}
+
+func (f *failingProcessor) Process(task any) error {
+	if f.calls.Add(1) <= f.fails {
+		return f.err
+	}
+	return nil
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	r := &RetryPolicy{Delay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	want := []time.Duration{10, 20, 40, 50, 50}
+	for i, w := range want {
+		if got := r.delay(i + 1); got != w*time.Millisecond {
+			t.Fatalf("delay(%d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+
+	r.Jitter = 0.5
+	if got := r.delay(2); got < 20*time.Millisecond || got > 30*time.Millisecond {
+		t.Fatalf("jittered delay = %v, want within [20ms, 30ms]", got)
+	}
+
+	fixed := &RetryPolicy{Backoff: BackoffFixed, Delay: time.Second}
+	if got := fixed.delay(5); got != time.Second {
+		t.Fatalf("fixed delay = %v, want 1s", got)
+	}
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	proc := &failingProcessor{fails: 2, err: errors.New("boom")}
+	p := NewPool(&Config{
+		MaxWorkers: 1, QueueSize: 10, TaskTimeout: time.Second,
+		Retry: &RetryPolicy{MaxAttempts: 3, Backoff: BackoffFixed, Delay: time.Millisecond},
+	}, proc)
+	p.Start()
+	defer p.Stop(context.Background())
+
+	if err := p.Submit("task"); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	waitFor(t, func() bool { return p.GetMetrics()["completed_tasks"] == 1 })
+
+	m := p.GetMetrics()
+	if m["retried_tasks"] != 2 || m["failed_tasks"] != 2 || m["dead_letter_tasks"] != 0 {
+		t.Fatalf("metrics = %v", m)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	permanent := errors.New("permanent")
+	proc := &failingProcessor{fails: 10, err: permanent}
+	dead := make(chan int, 1)
+	p := NewPool(&Config{
+		MaxWorkers: 1, QueueSize: 10, TaskTimeout: time.Second,
+		Retry: &RetryPolicy{
+			MaxAttempts: 5,
+			Delay:       time.Millisecond,
+			Retryable:   func(err error) bool { return !errors.Is(err, permanent) },
+		},
+		DeadLetter: func(task any, attempts int, err error) {
+			if task.(*Task).Type == "email" && errors.Is(err, permanent) {
+				dead <- attempts
+			}
+		},
+	}, proc)
+	p.Start()
+	defer p.Stop(context.Background())
+
+	if err := p.SubmitTask(&Task{Type: "email"}); err != nil {
+		t.Fatalf("SubmitTask: %v", err)
+	}
+	select {
+	case attempts := <-dead:
+		if attempts != 1 {
+			t.Fatalf("attempts = %d, want 1", attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not dead lettered")
+	}
+	if m := p.GetMetrics(); m["retried_tasks"] != 0 || m["dead_letter_tasks"] != 1 {
+		t.Fatalf("metrics = %v", m)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// flakyStore fails pushes while fail is set
+type flakyStore struct {
+	memoryStore
+	fail atomic.Bool
+}
+
+func (f *flakyStore) Push(ctx context.Context, t *Task) error {
+	if f.fail.Load() {
+		return errors.New("store unavailable")
+	}
+	return f.memoryStore.Push(ctx, t)
+}
+
+func TestRetryKeepsClaimedTaskUntilRescheduled(t *testing.T) {
+	store := &flakyStore{memoryStore: memoryStore{tasks: make(map[string]*Task)}}
+	proc := &failingProcessor{fails: 2, err: errors.New("boom")}
+	p := NewPool(&Config{
+		MaxWorkers: 1, QueueSize: 10, TaskTimeout: time.Second, Lease: 50 * time.Millisecond,
+		Store: store, PollInterval: 5 * time.Millisecond,
+		Retry: &RetryPolicy{MaxAttempts: 5, Backoff: BackoffFixed, Delay: time.Millisecond},
+	}, proc)
+
+	task := &Task{Type: "email"}
+	if err := p.SubmitTask(task); err != nil {
+		t.Fatalf("SubmitTask: %v", err)
+	}
+
+	// The first retry cannot be saved, the claimed task stays in the store
+	store.fail.Store(true)
+	p.Start()
+	defer p.Stop(context.Background())
+	waitFor(t, func() bool { return p.GetMetrics()["store_errors"] >= 1 })
+	store.mu.Lock()
+	_, kept := store.tasks[task.ID]
+	acked := len(store.acked)
+	store.mu.Unlock()
+	if !kept || acked != 0 {
+		t.Fatalf("claimed task kept = %v, acked %d, want kept and unacknowledged", kept, acked)
+	}
+
+	// Once the store recovers the task is claimed again, retried and completed
+	store.fail.Store(false)
+	waitFor(t, func() bool { return p.GetMetrics()["completed_tasks"] == 1 })
+	waitFor(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.tasks) == 0
+	})
+	if m := p.GetMetrics(); m["retried_tasks"] != 1 || m["dead_letter_tasks"] != 0 {
+		t.Fatalf("metrics = %v", m)
+	}
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// Store persists tasks so pending and in-flight tasks survive restarts
+type Store interface {
+	// Push saves a task
+	Push(ctx context.Context, t *Task) error
+	// Claim returns up to limit tasks due at now, hiding them from other
+	// claims for the lease duration
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Task, error)
+	// Ack deletes a processed task
+	Ack(ctx context.Context, id string) error
+}
+
+// SQLStore persists tasks in a SQL table
+type SQLStore struct {
+	db       *sql.DB
+	table    string
+	driver   string
+	postgres bool
+}
+
+// NewSQLStore creates a SQL backed task store.
+// driver selects the placeholder style: "postgres" uses $n, others use ?.
+func NewSQLStore(db *sql.DB, driver, table string) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+	if table == "" {
+		table = "worker_tasks"
+	}
+	if !sqlq.ValidIdentifier(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+
+	return &SQLStore{
+		db:       db,
+		table:    table,
+		driver:   driver,
+		postgres: driver == "postgres" || driver == "pgx",
+	}, nil
+}
+
+// Migrate creates the table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	payload := "BLOB"
+	if s.postgres {
+		payload = "BYTEA"
+	}
+
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			type VARCHAR(255) NOT NULL,
+			payload %s,
+			priority INTEGER NOT NULL DEFAULT 0,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			run_at TIMESTAMP NOT NULL
+		)`, s.table, payload)); err != nil {
+		return fmt.Errorf("failed to create task table: %v", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS idx_%s_run_at ON %s (run_at)",
+		strings.ReplaceAll(s.table, ".", "_"), s.table)); err != nil {
+		return fmt.Errorf("failed to create task index: %v", err)
+	}
+	return nil
+}
+
+// Push inserts a task
+func (s *SQLStore) Push(ctx context.Context, t *Task) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf(
+		"INSERT INTO %s (id, type, payload, priority, attempts, run_at) VALUES (?, ?, ?, ?, ?, ?)", s.table)),
+		t.ID, t.Type, t.Payload, t.Priority, t.Attempts, t.RunAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to persist task %s: %v", t.ID, err)
+	}
+	return nil
+}
+
+// Claim selects due tasks by priority and moves their run_at past the lease.
+// On Postgres and MySQL concurrent claims skip each other's rows.
+func (s *SQLStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := fmt.Sprintf(
+		"SELECT id, type, payload, priority, attempts, run_at FROM %s WHERE run_at <= ? ORDER BY priority DESC, run_at LIMIT ?", s.table)
+	switch s.driver {
+	case "postgres", "pgx", "mysql":
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+
+	rows, err := tx.QueryContext(ctx, s.rebind(query), now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim tasks: %v", err)
+	}
+	var tasks []*Task
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.ID, &t.Type, &t.Payload, &t.Priority, &t.Attempts, &t.RunAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tasks = append(tasks, &t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	update := s.rebind(fmt.Sprintf("UPDATE %s SET run_at = ? WHERE id = ?", s.table))
+	for _, t := range tasks {
+		if _, err := tx.ExecContext(ctx, update, now.Add(lease).UTC(), t.ID); err != nil {
+			return nil, fmt.Errorf("failed to lease task %s: %v", t.ID, err)
+		}
+	}
+	return tasks, tx.Commit()
+}
+
+// Ack deletes a task
+func (s *SQLStore) Ack(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.table)), id)
+	return err
+}
+
+// rebind converts ? placeholders to $n for Postgres
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
@@ -13,8 +13,15 @@ var ErrQueueFull = errors.New("task queue is full")
 // Config represents pool configuration
 type Config struct {
 	MaxWorkers  int           // maximum number of workers
-	QueueSize   int           // task queue size
+	QueueSize   int           // task queue size, also the limit of delayed tasks held in memory
 	TaskTimeout time.Duration // timeout for single task
+
+	Store        Store         // persists *Task submissions, optional
+	PollInterval time.Duration // how often the store is polled for due tasks, default 1s
+	Lease        time.Duration // how long a claimed task is hidden from other pools, default 2x TaskTimeout
+
+	Retry      *RetryPolicy   // retries failed tasks, optional
+	DeadLetter DeadLetterFunc // receives tasks that failed for good, optional
 }
 
 // DefaultConfig returns default configuration
@@ -37,6 +44,20 @@ func (cfg *Config) Validate() error {
 	if cfg.TaskTimeout < 0 {
 		return errors.New("task timeout must be greater than or equal to 0")
 	}
+	if cfg.PollInterval < 0 || cfg.Lease < 0 {
+		return errors.New("poll interval and lease must be greater than or equal to 0")
+	}
+	if r := cfg.Retry; r != nil {
+		if r.MaxAttempts < 1 {
+			return errors.New("retry max attempts must be greater than 0")
+		}
+		if r.Backoff != "" && r.Backoff != BackoffFixed && r.Backoff != BackoffExponential {
+			return errors.New("retry backoff must be fixed or exponential")
+		}
+		if r.Delay < 0 || r.MaxDelay < 0 || r.Jitter < 0 {
+			return errors.New("retry delay, max delay and jitter must be greater than or equal to 0")
+		}
+	}
 	return nil
 }
 
@@ -62,11 +83,15 @@ func (p *defaultProcessor) Process(task any) error {
 
 // Metrics tracks pool's operational metrics
 type Metrics struct {
-	ActiveWorkers  atomic.Int64
-	PendingTasks   atomic.Int64
-	CompletedTasks atomic.Int64
-	FailedTasks    atomic.Int64
-	ProcessingTime atomic.Int64 // nanoseconds
+	ActiveWorkers   atomic.Int64
+	PendingTasks    atomic.Int64
+	CompletedTasks  atomic.Int64
+	FailedTasks     atomic.Int64
+	ProcessingTime  atomic.Int64 // nanoseconds
+	ScheduledTasks  atomic.Int64 // delayed or prioritized tasks not yet queued
+	StoreErrors     atomic.Int64
+	RetriedTasks    atomic.Int64 // failed tasks scheduled for another attempt
+	DeadLetterTasks atomic.Int64 // failed tasks given up on
 }
 
 // Reset resets all metrics to zero
@@ -76,6 +101,10 @@ func (m *Metrics) Reset() {
 	m.CompletedTasks.Store(0)
 	m.FailedTasks.Store(0)
 	m.ProcessingTime.Store(0)
+	m.ScheduledTasks.Store(0)
+	m.StoreErrors.Store(0)
+	m.RetriedTasks.Store(0)
+	m.DeadLetterTasks.Store(0)
 }
 
 // Pool represents a worker pool
@@ -92,6 +121,21 @@ type Pool struct {
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
+	// Delayed and prioritized tasks
+	store        Store
+	pollInterval time.Duration
+	lease        time.Duration
+	mu           sync.Mutex
+	delayed      taskHeap
+	ready        taskHeap
+	seq          uint64
+	wake         chan struct{}
+	dispatcher   sync.WaitGroup
+
+	// Retries
+	retry      *RetryPolicy
+	deadLetter DeadLetterFunc
+
 	// Metrics
 	metrics *Metrics
 }
@@ -159,15 +203,32 @@ func NewPool(cfg *Config, processors ...Processor) *Pool {
 		processor = &defaultProcessor{}
 	}
 
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = max(2*cfg.TaskTimeout, time.Minute)
+	}
+
 	return &Pool{
-		maxWorkers:  cfg.MaxWorkers,
-		queueSize:   cfg.QueueSize,
-		taskTimeout: cfg.TaskTimeout,
-		processor:   processor,
-		tasks:       make(chan any, cfg.QueueSize),
-		ctx:         ctx,
-		cancel:      cancel,
-		metrics:     &Metrics{},
+		maxWorkers:   cfg.MaxWorkers,
+		queueSize:    cfg.QueueSize,
+		taskTimeout:  cfg.TaskTimeout,
+		processor:    processor,
+		tasks:        make(chan any, cfg.QueueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+		metrics:      &Metrics{},
+		store:        cfg.Store,
+		pollInterval: pollInterval,
+		lease:        lease,
+		delayed:      taskHeap{less: byRunAt},
+		ready:        taskHeap{less: byPriority},
+		wake:         make(chan struct{}, 1),
+		retry:        cfg.Retry,
+		deadLetter:   cfg.DeadLetter,
 	}
 }
 
@@ -177,11 +238,15 @@ func (p *Pool) Start() {
 		p.wg.Add(1)
 		go p.worker()
 	}
+
+	p.dispatcher.Add(1)
+	go p.dispatch()
 }
 
 // Stop stops the worker pool
 func (p *Pool) Stop(ctx context.Context) {
 	p.cancel()
+	p.dispatcher.Wait()
 	close(p.tasks)
 
 	// Wait for all workers to finish with timeout
@@ -233,6 +298,15 @@ func (p *Pool) processTask(task any) {
 	p.metrics.ActiveWorkers.Add(1)
 	p.metrics.PendingTasks.Add(-1)
 
+	attempts := 1
+	if r, ok := task.(*retried); ok {
+		task, attempts = r.task, r.attempts+1
+	}
+	t, isTask := task.(*Task)
+	if isTask {
+		attempts = t.Attempts + 1
+	}
+
 	defer func() {
 		p.metrics.ActiveWorkers.Add(-1)
 		p.metrics.ProcessingTime.Add(time.Since(start).Nanoseconds())
@@ -257,22 +331,31 @@ func (p *Pool) processTask(task any) {
 	case err := <-doneCh:
 		if err != nil {
 			p.metrics.FailedTasks.Add(1)
-		} else {
-			p.metrics.CompletedTasks.Add(1)
+			p.fail(task, attempts, err)
+			return
+		}
+		p.metrics.CompletedTasks.Add(1)
+		if isTask && t.claimed {
+			p.ack(t)
 		}
 	case <-taskCtx.Done():
 		p.metrics.FailedTasks.Add(1)
+		p.fail(task, attempts, ErrTaskTimeout)
 	}
 }
 
 // GetMetrics returns the current metrics
 func (p *Pool) GetMetrics() map[string]int64 {
 	return map[string]int64{
-		"active_workers":  p.metrics.ActiveWorkers.Load(),
-		"pending_tasks":   p.metrics.PendingTasks.Load(),
-		"completed_tasks": p.metrics.CompletedTasks.Load(),
-		"failed_tasks":    p.metrics.FailedTasks.Load(),
-		"processing_time": p.metrics.ProcessingTime.Load(),
+		"active_workers":    p.metrics.ActiveWorkers.Load(),
+		"pending_tasks":     p.metrics.PendingTasks.Load(),
+		"completed_tasks":   p.metrics.CompletedTasks.Load(),
+		"failed_tasks":      p.metrics.FailedTasks.Load(),
+		"processing_time":   p.metrics.ProcessingTime.Load(),
+		"scheduled_tasks":   p.metrics.ScheduledTasks.Load(),
+		"store_errors":      p.metrics.StoreErrors.Load(),
+		"retried_tasks":     p.metrics.RetriedTasks.Load(),
+		"dead_letter_tasks": p.metrics.DeadLetterTasks.Load(),
 	}
 }
 
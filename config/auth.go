@@ -11,6 +11,10 @@ import (
 type Auth struct {
 	JWT                    *JWT     `json:"jwt" yaml:"jwt"`
 	Casbin                 *Casbin  `json:"casbin" yaml:"casbin"`
+	LDAP                   *LDAP    `json:"ldap" yaml:"ldap"`
+	SAML                   *SAML    `json:"saml" yaml:"saml"`
+	Session                *Session `json:"session" yaml:"session"`
+	Refresh                *Refresh `json:"refresh" yaml:"refresh"`
 	Whitelist              []string `json:"whitelist" yaml:"whitelist"`
 	MaxSessions            int      `json:"max_sessions" yaml:"max_sessions"`
 	SessionCleanupInterval int      `json:"session_cleanup_interval" yaml:"session_cleanup_interval"`
@@ -21,6 +25,10 @@ func getAuth(v *viper.Viper) *Auth {
 	return &Auth{
 		JWT:                    getJWT(v),
 		Casbin:                 getCasbin(v),
+		LDAP:                   getLDAP(v),
+		SAML:                   getSAML(v),
+		Session:                getSession(v),
+		Refresh:                getRefresh(v),
 		Whitelist:              getWhitelist(v),
 		MaxSessions:            v.GetInt("auth.max_sessions"),
 		SessionCleanupInterval: v.GetInt("auth.session_cleanup_interval"),
@@ -2,23 +2,31 @@ package config
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
 var (
-	config *Config
-	path   string
-	once   sync.Once
-	mu     sync.Mutex
-	v      *viper.Viper
+	current  atomic.Pointer[Config]
+	path     string
+	once     sync.Once
+	mu       sync.Mutex
+	watchers []*viper.Viper
+
+	hooksMu        sync.RWMutex
+	validators     []func(*Config) error
+	errorHandlers  []func(error)
+	changeHandlers []func(*Config, *Diff)
 )
 
 // Config represents the configuration implementation.
@@ -40,6 +48,8 @@ type Config struct {
 	Storage     *Storage     `yaml:"storage" json:"storage"`
 	OAuth       *OAuth       `yaml:"oauth" json:"oauth"`
 	Email       *Email       `yaml:"email" json:"email"`
+	Notify      *Notify      `yaml:"notify" json:"notify"`
+	Remote      *Remote      `yaml:"remote" json:"remote"`
 	Viper       *viper.Viper `yaml:"-" json:"-"`
 }
 
@@ -66,9 +76,6 @@ func init() {
 
 // Init initializes and loads the configuration.
 func Init() (cfg *Config, err error) {
-	// Initialize viper instance
-	v = viper.New()
-
 	// Ensure configuration is loaded only once using sync.Once
 	once.Do(func() {
 		cfg, err = loadConfiguration()
@@ -83,19 +90,25 @@ func Init() (cfg *Config, err error) {
 // GetConfig returns the configuration.
 // It does not handle errors internally; instead, it returns the error for the caller to handle.
 func GetConfig() (*Config, error) {
-	if config == nil {
-		var err error
-		config, err = Init()
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize config: %w", err)
+	if cfg := current.Load(); cfg != nil {
+		return cfg, nil
+	}
+	cfg, err := Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+	if cfg == nil {
+		// Init ran before, possibly concurrently
+		if cfg = current.Load(); cfg == nil {
+			return nil, errors.New("config is not loaded")
 		}
 	}
-	return config, nil
+	return cfg, nil
 }
 
 // BindConfigToContext binds the configuration to the context.
 func BindConfigToContext(ctx context.Context) context.Context {
-	return context.WithValue(ctx, "config", config)
+	return context.WithValue(ctx, "config", current.Load())
 }
 
 // loadConfiguration loads the configuration from the file and sets it globally.
@@ -104,16 +117,27 @@ func loadConfiguration() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error loading config: %w", err)
 	}
-	config = cfg
+	current.Store(cfg)
 	return cfg, nil
 }
 
-// LoadConfig loads the configuration from the file.
+// LoadConfig loads and validates the configuration from the file.
+// Every call reads into a new viper instance, so a failed load leaves
+// previously returned configurations untouched.
 func LoadConfig(configPath string) (*Config, error) {
-	// Initialize viper instance if not already initialized
-	if v == nil {
-		v = viper.New()
+	cfg, err := load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
+
+// load reads the configuration from the file without validating it
+func load(configPath string) (*Config, error) {
+	v := viper.New()
 
 	if configPath != "" {
 		v.SetConfigFile(configPath)
@@ -133,7 +157,23 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	cfg := &Config{
+	if err := mergeOverlays(v); err != nil {
+		return nil, err
+	}
+
+	remote, err := getRemote(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeRemote(v, remote); err != nil {
+		return nil, err
+	}
+
+	if err := decryptSettings(v); err != nil {
+		return nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
+
+	return &Config{
 		AppName:     v.GetString("app_name"),
 		Environment: v.GetString("environment"),
 		Protocol:    v.GetString("server.protocol"),
@@ -151,34 +191,142 @@ func LoadConfig(configPath string) (*Config, error) {
 		Storage:     getStorageConfig(v),
 		OAuth:       getOAuthConfig(v),
 		Email:       getEmailConfig(v),
+		Notify:      getNotifyConfig(v),
+		Remote:      remote,
 		Viper:       v,
-	}
-
-	return cfg, nil
+	}, nil
 }
 
-// Reload reloads the configuration from the file.
+// Reload reloads the configuration from the file. An invalid file is
+// rejected: the last known good configuration stays active and the error is
+// passed to the OnReloadError handlers. A loaded file is compared with the
+// previous configuration and the OnChange handlers receive the differences.
 func Reload() error {
 	mu.Lock()
 	defer mu.Unlock()
 
 	newConfig, err := LoadConfig(path)
 	if err != nil {
-		return fmt.Errorf("failed to reload config: %w", err)
+		err = fmt.Errorf("failed to reload config, keeping last known good: %w", err)
+		hooksMu.RLock()
+		handlers := errorHandlers
+		hooksMu.RUnlock()
+		if len(handlers) == 0 {
+			log.Printf("%v", err)
+		}
+		for _, h := range handlers {
+			h(err)
+		}
+		return err
 	}
 
-	config = newConfig
+	prev := current.Swap(newConfig)
+	diff := Compare(prev, newConfig)
+	hooksMu.RLock()
+	handlers := changeHandlers
+	hooksMu.RUnlock()
+	for _, h := range handlers {
+		h(newConfig, diff)
+	}
 	return nil
 }
 
-// Watch watches the configuration file and reloads it when it changes.
+// Watch watches the configuration file, its profile and local overlays and
+// the remote sources and reloads the configuration when they change.
+// callback only receives configurations that loaded and validated.
 func Watch(callback func(*Config)) {
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(watchers) > 0 {
+		return
+	}
+
+	reload := func() {
 		if err := Reload(); err != nil {
-			fmt.Printf("Error reloading config: %v\n", err)
 			return
 		}
-		callback(config)
-	})
+		callback(current.Load())
+	}
+
+	file := path
+	cfg := current.Load()
+	if cfg != nil && cfg.Viper != nil {
+		file = cfg.Viper.ConfigFileUsed()
+	}
+	for _, layer := range LayerFiles(file, Profile()) {
+		w := viper.New()
+		w.SetConfigFile(layer)
+		w.OnConfigChange(func(e fsnotify.Event) { reload() })
+		w.WatchConfig()
+		watchers = append(watchers, w)
+	}
+
+	if cfg != nil && cfg.Remote != nil {
+		watchRemote(context.Background(), cfg.Remote, reload)
+	}
+}
+
+// OnReloadError registers a handler for rejected reloads. Without handlers
+// the error goes to the standard logger since this package cannot import
+// logging/logger which depends on it. Pass Manager.ReloadFailed to log it with
+// the application logger and publish it as a config.reload_failed event.
+func OnReloadError(handler func(err error)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	errorHandlers = append(errorHandlers, handler)
+}
+
+// OnChange registers a handler receiving every reloaded configuration with
+// the settings that changed, secrets masked. It runs before the Watch
+// callback, react to parts selectively with Diff.Changed:
+//
+//	config.OnChange(func(cfg *config.Config, diff *config.Diff) {
+//	    if diff.Changed("data.database") {
+//	        reconnect(cfg.Data.Database)
+//	    }
+//	})
+func OnChange(handler func(cfg *Config, diff *Diff)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	changeHandlers = append(changeHandlers, handler)
+}
+
+// RegisterValidator adds a check run on every load and reload, e.g. for
+// application specific settings
+func RegisterValidator(fn func(*Config) error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	validators = append(validators, fn)
+}
+
+// Validate checks the configuration and runs registered validators. All
+// issues are reported together, see Issues.
+func (c *Config) Validate() error {
+	issues := c.Issues()
+	if len(issues) == 0 {
+		return nil
+	}
+	errs := make([]error, len(issues))
+	for i, issue := range issues {
+		errs[i] = issue
+	}
+	return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+}
+
+// Check loads the configuration at configPath like LoadConfig and returns
+// its issues instead of failing on them. The error is only set when the
+// configuration cannot be read.
+func Check(configPath string) ([]Issue, error) {
+	_, issues, err := Inspect(configPath)
+	return issues, err
+}
+
+// Inspect loads the configuration at configPath without validating it and
+// returns it along with its issues, for tools that go on despite them
+func Inspect(configPath string) (*Config, []Issue, error) {
+	cfg, err := load(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, cfg.Issues(), nil
 }
@@ -5,7 +5,7 @@ import "github.com/spf13/viper"
 // Consul config struct
 type Consul struct {
 	Address   string `yaml:"address" json:"address"`
-	Scheme    string `yaml:"scheme" json:"scheme"`
+	Scheme    string `yaml:"scheme" json:"scheme" validate:"omitempty,oneof=http https"`
 	Discovery struct {
 		DefaultTags   []string          `yaml:"default_tags" json:"default_tags"`
 		DefaultMeta   map[string]string `yaml:"default_meta" json:"default_meta"`
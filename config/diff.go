@@ -0,0 +1,145 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Masked replaces the values of secret settings in a Diff
+const Masked = "[REDACTED]"
+
+// sensitiveKeys mask the values of settings whose key contains one of them
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"private_key", "access_key", "credential", "dsn", "master_key",
+}
+
+// RegisterSensitiveKeys masks the values of settings whose key contains one
+// of names in every Diff, e.g. "license"
+func RegisterSensitiveKeys(names ...string) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	for _, name := range names {
+		sensitiveKeys = append(sensitiveKeys, strings.ToLower(name))
+	}
+}
+
+// Change is a setting that differs between two configurations
+type Change struct {
+	Key    string `json:"key"`              // Dotted key, e.g. data.database.master.driver
+	Old    any    `json:"old,omitempty"`    // Nil when the key was added
+	New    any    `json:"new,omitempty"`    // Nil when the key was removed
+	Masked bool   `json:"masked,omitempty"` // Whether Old and New are masked secrets
+}
+
+// Diff lists the settings changed by a reload, sorted by key
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Compare returns the settings that differ between prev and next, with the
+// values of secrets masked
+func Compare(prev, next *Config) *Diff {
+	before, after := settings(prev), settings(next)
+
+	keys := make(map[string]struct{}, len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+
+	hooksMu.RLock()
+	sensitive := sensitiveKeys
+	hooksMu.RUnlock()
+
+	d := &Diff{}
+	for k := range keys {
+		a, b := before[k], after[k]
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		c := Change{Key: k, Old: a, New: b}
+		if isSensitive(k, sensitive) {
+			c.Masked = true
+			if c.Old != nil {
+				c.Old = Masked
+			}
+			if c.New != nil {
+				c.New = Masked
+			}
+		}
+		d.Changes = append(d.Changes, c)
+	}
+	sort.Slice(d.Changes, func(i, j int) bool { return d.Changes[i].Key < d.Changes[j].Key })
+	return d
+}
+
+// Empty reports whether nothing changed
+func (d *Diff) Empty() bool {
+	return d == nil || len(d.Changes) == 0
+}
+
+// Keys returns the changed keys
+func (d *Diff) Keys() []string {
+	if d == nil {
+		return nil
+	}
+	keys := make([]string, len(d.Changes))
+	for i, c := range d.Changes {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// Changed reports whether any of the keys, or a key below them, changed.
+// A trailing ".*" is optional, data.database and data.database.* both match
+// data.database.master.driver.
+func (d *Diff) Changed(keys ...string) bool {
+	return len(d.Under(keys...)) > 0
+}
+
+// Under returns the changes of the keys and the keys below them
+func (d *Diff) Under(keys ...string) []Change {
+	if d == nil {
+		return nil
+	}
+	var changes []Change
+	for _, c := range d.Changes {
+		for _, k := range keys {
+			k = strings.ToLower(strings.TrimSuffix(k, ".*"))
+			if c.Key == k || strings.HasPrefix(c.Key, k+".") {
+				changes = append(changes, c)
+				break
+			}
+		}
+	}
+	return changes
+}
+
+// settings returns the leaf settings of c by dotted key
+func settings(c *Config) map[string]any {
+	if c == nil || c.Viper == nil {
+		return nil
+	}
+	keys := c.Viper.AllKeys()
+	m := make(map[string]any, len(keys))
+	for _, k := range keys {
+		m[k] = c.Viper.Get(k)
+	}
+	return m
+}
+
+// isSensitive reports whether a segment of key contains a sensitive name
+func isSensitive(key string, names []string) bool {
+	for _, segment := range strings.Split(key, ".") {
+		for _, name := range names {
+			if strings.Contains(segment, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"old.yaml": "app_name: app\nserver:\n  port: 8000\ndata:\n  database:\n    master:\n      driver: postgres\n      dsn: postgres://a\nauth:\n  jwt:\n    secret: one\nfeature:\n  removed: true\n",
+		"new.yaml": "app_name: app\nserver:\n  port: 8100\ndata:\n  database:\n    master:\n      driver: mysql\n      dsn: mysql://b\nauth:\n  jwt:\n    secret: two\nlicense: abc\n",
+	})
+	t.Setenv(ProfileEnv, "")
+	prev, err := LoadConfig(filepath.Join(dir, "old.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := LoadConfig(filepath.Join(dir, "new.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooksMu.RLock()
+	prevSensitive := sensitiveKeys
+	hooksMu.RUnlock()
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		sensitiveKeys = prevSensitive
+		hooksMu.Unlock()
+	})
+	RegisterSensitiveKeys("License")
+
+	d := Compare(prev, next)
+	want := []string{"auth.jwt.secret", "data.database.master.driver", "data.database.master.dsn", "feature.removed", "license", "server.port"}
+	if !slices.Equal(d.Keys(), want) {
+		t.Fatalf("Keys() = %v, want %v", d.Keys(), want)
+	}
+
+	byKey := make(map[string]Change)
+	for _, c := range d.Changes {
+		byKey[c.Key] = c
+	}
+	for key, want := range map[string]Change{
+		"server.port":                 {Key: "server.port", Old: 8000, New: 8100},
+		"data.database.master.driver": {Key: "data.database.master.driver", Old: "postgres", New: "mysql"},
+		"data.database.master.dsn":    {Key: "data.database.master.dsn", Old: Masked, New: Masked, Masked: true},
+		"auth.jwt.secret":             {Key: "auth.jwt.secret", Old: Masked, New: Masked, Masked: true},
+		"license":                     {Key: "license", New: Masked, Masked: true},
+		"feature.removed":             {Key: "feature.removed", Old: true},
+	} {
+		if got := byKey[key]; got != want {
+			t.Errorf("change of %s = %+v, want %+v", key, got, want)
+		}
+	}
+
+	if !d.Changed("data.database") || !d.Changed("Data.Database.*") || !d.Changed("server.port") {
+		t.Error("Changed should match the changed keys and their parents")
+	}
+	if d.Changed("data.redis", "server.port.x", "serv") {
+		t.Error("Changed matched unchanged keys")
+	}
+	if under := d.Under("data.database"); len(under) != 2 {
+		t.Errorf("Under(data.database) = %v, want the driver and dsn", under)
+	}
+
+	if !Compare(prev, prev).Empty() || !(*Diff)(nil).Empty() || (*Diff)(nil).Changed("server") {
+		t.Error("identical configurations should not differ")
+	}
+	if d := Compare(nil, next); d.Empty() || byKeyOf(d, "server.port").Old != nil {
+		t.Errorf("a first load should add every key, got %v", d.Keys())
+	}
+}
+
+func byKeyOf(d *Diff, key string) Change {
+	for _, c := range d.Changes {
+		if c.Key == key {
+			return c
+		}
+	}
+	return Change{}
+}
+
+func TestReloadNotifiesChanges(t *testing.T) {
+	dir := writeLayers(t, map[string]string{"config.yaml": "app_name: app\nserver:\n  port: 8000\n"})
+	t.Setenv(ProfileEnv, "")
+	file := filepath.Join(dir, "config.yaml")
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevPath, prevCfg := path, current.Load()
+	hooksMu.RLock()
+	prevHandlers, prevErrorHandlers := changeHandlers, errorHandlers
+	hooksMu.RUnlock()
+	path = file
+	current.Store(cfg)
+	t.Cleanup(func() {
+		path = prevPath
+		current.Store(prevCfg)
+		hooksMu.Lock()
+		changeHandlers, errorHandlers = prevHandlers, prevErrorHandlers
+		hooksMu.Unlock()
+	})
+
+	var diffs []*Diff
+	OnChange(func(cfg *Config, d *Diff) {
+		if cfg.Port != 8100 {
+			t.Errorf("handler received port %d, want 8100", cfg.Port)
+		}
+		diffs = append(diffs, d)
+	})
+	var rejected []error
+	OnReloadError(func(err error) { rejected = append(rejected, err) })
+
+	writeLayer(t, file, "app_name: app\nserver:\n  port: 8100\n")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || !slices.Equal(diffs[0].Keys(), []string{"server.port"}) {
+		t.Fatalf("OnChange received %v", diffs)
+	}
+
+	// A rejected reload is not a change
+	writeLayer(t, file, "app_name: app\nserver:\n  port: 70000\n")
+	if err := Reload(); err == nil {
+		t.Fatal("Reload should reject an invalid file")
+	}
+	if len(diffs) != 1 || len(rejected) != 1 || current.Load().Port != 8100 {
+		t.Fatalf("rejected reload: %d diffs, %d errors, port %d", len(diffs), len(rejected), current.Load().Port)
+	}
+}
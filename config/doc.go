@@ -54,15 +54,127 @@
 //
 // Environment variables take precedence over file configuration.
 //
+// # Profiles
+//
+// Overlays next to the base file are deep merged over it: the file named by
+// NCORE_PROFILE, then local, with the base file's extension:
+//
+//	config.yaml       base settings
+//	production.yaml   NCORE_PROFILE=production
+//	local.yaml        uncommitted developer overrides
+//
+// Maps merge key by key, scalars and lists are replaced. Print the effective
+// configuration with the file each key came from:
+//
+//	ncore config resolve -conf config.yaml -profile production
+//
+// # Encrypted Values
+//
+// Secrets can be committed as sops-style encrypted values, which are
+// decrypted while loading:
+//
+//	data:
+//	  database:
+//	    master:
+//	      password: ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]
+//
+// The 32-byte master key is read from NCORE_CONFIG_KEY (hex or base64) or
+// the file named by NCORE_CONFIG_KEY_FILE. Use SetKeyProvider to fetch it
+// from a KMS instead. Values are produced with EncryptValue; the dotted key
+// path is authenticated, so an encrypted value only decrypts under its key.
+// As in sops, the path is case sensitive and spelled as in the file, e.g.
+// "Data.Database.Master.Password" for a file using capitalized keys. Values
+// from remote sources are authenticated under their lowercased keys.
+//
+// Only the sops value format is compatible. The sops metadata section, with
+// its data key wrapped by a KMS, PGP or age, is not read; values must be
+// encrypted with the master key itself, so files encrypted by sops need to be
+// encrypted again with EncryptValue.
+//
+// # Remote Sources
+//
+// Settings can be read from Consul KV, etcd or Vault, merged over the local
+// files in order. The sources are declared in the local file:
+//
+//	remote:
+//	  refresh_interval: 1m
+//	  sources:
+//	    - type: consul
+//	      address: http://127.0.0.1:8500
+//	      path: ncore/app/          # app/server/port becomes server.port
+//	      watch: true               # blocking queries instead of polling
+//	    - type: etcd
+//	      address: http://127.0.0.1:2379
+//	      path: /ncore/app/config
+//	      format: yaml              # a single key holding a document
+//	    - type: vault
+//	      address: https://vault:8200
+//	      path: secret/data/ncore/app
+//	      key: data.database.master # secret keys merged below this key
+//
+// Tokens default to CONSUL_HTTP_TOKEN and VAULT_TOKEN. Vault secret keys may be
+// dotted, e.g. auth.jwt.secret, so JWT secrets and database passwords are
+// only held in memory. Remote values may be ENC[...] encrypted as well. A
+// source that fails stops loading unless it is optional. Other source types
+// can be added with RegisterRemoteProvider.
+//
 // # Hot Reloading
 //
 // Watch configuration file for changes:
 //
-//	config.WatchConfig(func(cfg *config.Config) {
+//	config.Watch(func(cfg *config.Config) {
 //	    log.Println("Configuration reloaded")
 //	    // React to configuration changes
 //	})
 //
+// Remote sources are watched too, and the configuration is reloaded when
+// their settings change. Reloaded files are validated before they replace the
+// active configuration, which is swapped atomically. An invalid file is
+// rejected and the last known good configuration stays in use:
+//
+//	config.RegisterValidator(func(cfg *config.Config) error {
+//	    if cfg.AppName == "" {
+//	        return errors.New("app_name is required")
+//	    }
+//	    return nil
+//	})
+//	config.OnReloadError(func(err error) {
+//	    log.Printf("config rejected: %v", err)
+//	})
+//
+// OnChange handlers receive the changed keys with their old and new values.
+// Values of keys naming secrets, such as password, token or dsn, are masked:
+//
+//	config.OnChange(func(cfg *config.Config, diff *config.Diff) {
+//	    if diff.Changed("data.database") {
+//	        reconnect(cfg)
+//	    }
+//	})
+//
+// # Extension Sections
+//
+// Extensions keep their settings under extension.plugin_config.<name> and
+// decode them into a struct with yaml tags. EXTENSION_<NAME>_<KEY> variables
+// override the keys of the struct and its validate tags are checked:
+//
+//	var cfg PaymentsConfig
+//	err := conf.UnmarshalExtension("payments", &cfg) // EXTENSION_PAYMENTS_API_KEY
+//
+// ChangedExtensions lists the sections that differ after a reload.
+//
+// # Validation
+//
+// Loading fails with all issues found at once. Fields of the sections carry
+// validate tags with required, omitempty, min=n, max=n and oneof=a b c rules,
+// checked next to the registered validators:
+//
+//	Port int `yaml:"port" validate:"omitempty,min=1,max=65535"`
+//
+// Config.Issues returns them with a suggestion each, e.g. a close match for
+// a mistyped value. Check a file before deploying it:
+//
+//	ncore config validate -conf config.yaml -profile production
+//
 // # Default Values
 //
 // The package provides sensible defaults for all settings:
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// extensionsKey holds the config sections of extensions keyed by name
+const extensionsKey = "extension.plugin_config"
+
+// ExtensionEnvPrefix returns the prefix of environment variables overriding
+// the section of an extension, e.g. EXTENSION_PAYMENTS_ for payments
+func ExtensionEnvPrefix(name string) string {
+	return "EXTENSION_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "_"
+}
+
+// UnmarshalExtension decodes the section of an extension, found under
+// extension.plugin_config.<name>, into out, a pointer to a struct with yaml
+// tags. Environment variables named after the prefix and the yaml keys of out
+// take precedence, e.g. EXTENSION_PAYMENTS_RETRY_MAX_ATTEMPTS for
+// retry.max_attempts. The validate tags of out are checked as in Issues.
+func (c *Config) UnmarshalExtension(name string, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("extension %s: config target must be a pointer to a struct", name)
+	}
+
+	section := viper.New()
+	if raw := c.ExtensionSection(name); raw != nil {
+		if err := section.MergeConfigMap(raw); err != nil {
+			return fmt.Errorf("extension %s: %w", name, err)
+		}
+	}
+	prefix := ExtensionEnvPrefix(name)
+	for _, key := range envKeys(rv.Elem().Type(), "") {
+		if value, ok := os.LookupEnv(prefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))); ok {
+			section.Set(key, value)
+		}
+	}
+
+	if err := section.Unmarshal(out, func(c *mapstructure.DecoderConfig) { c.TagName = "yaml" }); err != nil {
+		return fmt.Errorf("extension %s: invalid config: %w", name, err)
+	}
+
+	var issues []Issue
+	walkRules(rv.Elem(), joinKey(extensionsKey, name), &issues)
+	if len(issues) > 0 {
+		errs := make([]error, len(issues))
+		for i, issue := range issues {
+			errs[i] = issue
+		}
+		return fmt.Errorf("extension %s: invalid config: %w", name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// ExtensionSection returns the raw section of an extension, nil when it has none
+func (c *Config) ExtensionSection(name string) map[string]any {
+	if c == nil || c.Viper == nil {
+		return nil
+	}
+	key := joinKey(extensionsKey, name)
+	if !c.Viper.IsSet(key) {
+		return nil
+	}
+	return c.Viper.GetStringMap(key)
+}
+
+// ChangedExtensions returns the names of extensions whose section differs
+// between two configurations, e.g. before and after a reload
+func ChangedExtensions(prev, next *Config) []string {
+	names := make(map[string]struct{})
+	for _, c := range []*Config{prev, next} {
+		if c != nil && c.Viper != nil {
+			for name := range c.Viper.GetStringMap(extensionsKey) {
+				names[name] = struct{}{}
+			}
+		}
+	}
+
+	var changed []string
+	for name := range names {
+		if !reflect.DeepEqual(prev.ExtensionSection(name), next.ExtensionSection(name)) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// envKeys returns the dotted yaml keys of the fields of t that environment
+// variables can override, nested structs are walked
+func envKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name := fieldKey(f)
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		key := joinKey(prefix, name)
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			keys = append(keys, envKeys(ft, key)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type paymentsConfig struct {
+	Provider string        `yaml:"provider" validate:"oneof=stripe adyen"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Retry    struct {
+		MaxAttempts int `yaml:"max_attempts" validate:"min=1"`
+	} `yaml:"retry"`
+	Internal string `yaml:"-"`
+}
+
+// loadLayers loads config.yaml of the layers
+func loadLayers(t *testing.T, layers map[string]string) *Config {
+	t.Helper()
+	t.Setenv(ProfileEnv, "")
+	conf, err := LoadConfig(filepath.Join(writeLayers(t, layers), "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestUnmarshalExtension(t *testing.T) {
+	conf := loadLayers(t, map[string]string{"config.yaml": `
+extension:
+  plugin_config:
+    payments:
+      provider: stripe
+      timeout: 5s
+      retry:
+        max_attempts: 2
+`})
+
+	var cfg paymentsConfig
+	if err := conf.UnmarshalExtension("payments", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Provider != "stripe" || cfg.Timeout != 5*time.Second || cfg.Retry.MaxAttempts != 2 {
+		t.Fatalf("decoded %+v", cfg)
+	}
+
+	// Environment variables take precedence over the file
+	t.Setenv("EXTENSION_PAYMENTS_PROVIDER", "adyen")
+	t.Setenv("EXTENSION_PAYMENTS_RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("EXTENSION_PAYMENTS_INTERNAL", "ignored")
+	cfg = paymentsConfig{}
+	if err := conf.UnmarshalExtension("payments", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Provider != "adyen" || cfg.Retry.MaxAttempts != 5 || cfg.Timeout != 5*time.Second || cfg.Internal != "" {
+		t.Fatalf("decoded with env overrides %+v", cfg)
+	}
+
+	// Validate tags are checked under the key of the section
+	t.Setenv("EXTENSION_PAYMENTS_RETRY_MAX_ATTEMPTS", "0")
+	err := conf.UnmarshalExtension("payments", &paymentsConfig{})
+	if err == nil || !strings.Contains(err.Error(), "extension.plugin_config.payments.retry.max_attempts") {
+		t.Fatalf("err = %v, want the invalid max_attempts", err)
+	}
+
+	if err := conf.UnmarshalExtension("payments", cfg); err == nil {
+		t.Fatal("UnmarshalExtension accepted a non-pointer target")
+	}
+}
+
+func TestUnmarshalExtensionWithoutSection(t *testing.T) {
+	conf := loadLayers(t, map[string]string{"config.yaml": "app_name: app\n"})
+	if section := conf.ExtensionSection("payments"); section != nil {
+		t.Fatalf("section = %v, want nil", section)
+	}
+
+	// Only environment variables apply
+	t.Setenv(ExtensionEnvPrefix("payments")+"PROVIDER", "stripe")
+	t.Setenv(ExtensionEnvPrefix("payments")+"RETRY_MAX_ATTEMPTS", "1")
+	var cfg paymentsConfig
+	if err := conf.UnmarshalExtension("payments", &cfg); err != nil || cfg.Provider != "stripe" {
+		t.Fatalf("decoded %+v, %v", cfg, err)
+	}
+	if p := ExtensionEnvPrefix("user-profile.v2"); p != "EXTENSION_USER_PROFILE_V2_" {
+		t.Fatalf("env prefix = %s", p)
+	}
+}
+
+func TestChangedExtensions(t *testing.T) {
+	prev := loadLayers(t, map[string]string{"config.yaml": `
+extension:
+  plugin_config:
+    payments:
+      provider: stripe
+    search:
+      engine: meili
+    audit:
+      retention: 30d
+`})
+	next := loadLayers(t, map[string]string{"config.yaml": `
+extension:
+  plugin_config:
+    payments:
+      provider: adyen
+    search:
+      engine: meili
+    notify:
+      channel: email
+`})
+
+	if changed := ChangedExtensions(prev, next); !slices.Equal(changed, []string{"audit", "notify", "payments"}) {
+		t.Fatalf("changed = %v", changed)
+	}
+	if changed := ChangedExtensions(prev, prev); len(changed) != 0 {
+		t.Fatalf("unchanged config reported %v", changed)
+	}
+}
@@ -9,7 +9,7 @@ import (
 type GRPC struct {
 	Enabled bool   `yaml:"enabled" json:"enabled"`
 	Host    string `yaml:"host" json:"host"`
-	Port    int    `yaml:"port" json:"port"`
+	Port    int    `yaml:"port" json:"port" validate:"omitempty,min=1,max=65535"`
 
 	// TLS Configuration
 	TLSEnabled bool   `yaml:"tls_enabled" json:"tls_enabled"`
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+
+	"github.com/ncobase/ncore/security/ldap"
+	"github.com/spf13/viper"
+)
+
+// LDAP represents the LDAP / Active Directory login configuration
+type LDAP = ldap.Config
+
+// getLDAP returns the LDAP configuration, nil when auth.ldap is not set
+func getLDAP(v *viper.Viper) *LDAP {
+	if !v.IsSet("auth.ldap") {
+		return nil
+	}
+	password := os.Getenv("LDAP_BIND_PASSWORD")
+	if password == "" {
+		password = v.GetString("auth.ldap.bind_password")
+	}
+
+	cfg := &LDAP{
+		URL:      v.GetString("auth.ldap.url"),
+		StartTLS: v.GetBool("auth.ldap.start_tls"),
+		TLS: ldap.TLS{
+			CAFile:             v.GetString("auth.ldap.tls.ca_file"),
+			ServerName:         v.GetString("auth.ldap.tls.server_name"),
+			InsecureSkipVerify: v.GetBool("auth.ldap.tls.insecure_skip_verify"),
+		},
+		BindDN:          v.GetString("auth.ldap.bind_dn"),
+		BindPassword:    password,
+		BaseDN:          v.GetString("auth.ldap.base_dn"),
+		UserFilter:      v.GetString("auth.ldap.user_filter"),
+		GroupBaseDN:     v.GetString("auth.ldap.group_base_dn"),
+		GroupFilter:     v.GetString("auth.ldap.group_filter"),
+		NestedGroups:    v.GetBool("auth.ldap.nested_groups"),
+		MaxNestingDepth: v.GetInt("auth.ldap.max_nesting_depth"),
+		ActiveDirectory: v.GetBool("auth.ldap.active_directory"),
+		Attributes: ldap.Attributes{
+			ID:       v.GetString("auth.ldap.attributes.id"),
+			Username: v.GetString("auth.ldap.attributes.username"),
+			Email:    v.GetString("auth.ldap.attributes.email"),
+			Name:     v.GetString("auth.ldap.attributes.name"),
+			MemberOf: v.GetString("auth.ldap.attributes.member_of"),
+			Extra:    v.GetStringSlice("auth.ldap.attributes.extra"),
+		},
+		DefaultRoles: v.GetStringSlice("auth.ldap.default_roles"),
+		PoolSize:     v.GetInt("auth.ldap.pool_size"),
+		DialTimeout:  v.GetDuration("auth.ldap.dial_timeout"),
+		Timeout:      v.GetDuration("auth.ldap.timeout"),
+		IdleTimeout:  v.GetDuration("auth.ldap.idle_timeout"),
+	}
+	// Viper lowercases the group names, which is harmless as they match case-insensitively
+	if v.IsSet("auth.ldap.role_mapping") {
+		_ = v.UnmarshalKey("auth.ldap.role_mapping", &cfg.RoleMapping)
+	}
+	return cfg
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/ncobase/ncore/messaging/notify"
+	"github.com/spf13/viper"
+)
+
+// Notify represents the SMS and push notification configuration
+type Notify = notify.Config
+
+// getNotifyConfig returns the notify configuration, providers without a
+// section stay disabled
+func getNotifyConfig(v *viper.Viper) *Notify {
+	cfg := &Notify{Limits: make(map[notify.Channel]notify.Limit)}
+
+	if v.IsSet("notify.twilio") {
+		cfg.Twilio = &notify.TwilioConfig{
+			AccountSID:          v.GetString("notify.twilio.account_sid"),
+			AuthToken:           v.GetString("notify.twilio.auth_token"),
+			From:                v.GetString("notify.twilio.from"),
+			MessagingServiceSID: v.GetString("notify.twilio.messaging_service_sid"),
+			StatusCallback:      v.GetString("notify.twilio.status_callback"),
+		}
+	}
+	if v.IsSet("notify.aliyun_sms") {
+		cfg.AliyunSMS = &notify.AliyunSMSConfig{
+			AccessKeyID:     v.GetString("notify.aliyun_sms.access_key_id"),
+			AccessKeySecret: v.GetString("notify.aliyun_sms.access_key_secret"),
+			SignName:        v.GetString("notify.aliyun_sms.sign_name"),
+			RegionID:        v.GetString("notify.aliyun_sms.region_id"),
+			CallbackToken:   v.GetString("notify.aliyun_sms.callback_token"),
+		}
+	}
+	if v.IsSet("notify.fcm") {
+		cfg.FCM = &notify.FCMConfig{
+			CredentialsFile: v.GetString("notify.fcm.credentials_file"),
+			CredentialsJSON: v.GetString("notify.fcm.credentials_json"),
+			ProjectID:       v.GetString("notify.fcm.project_id"),
+			Platforms:       v.GetStringSlice("notify.fcm.platforms"),
+		}
+	}
+	if v.IsSet("notify.apns") {
+		cfg.APNs = &notify.APNsConfig{
+			KeyID:      v.GetString("notify.apns.key_id"),
+			TeamID:     v.GetString("notify.apns.team_id"),
+			Topic:      v.GetString("notify.apns.topic"),
+			KeyFile:    v.GetString("notify.apns.key_file"),
+			PrivateKey: v.GetString("notify.apns.private_key"),
+			Production: v.GetBool("notify.apns.production"),
+		}
+	}
+
+	for _, ch := range []notify.Channel{notify.ChannelPush, notify.ChannelSMS, notify.ChannelEmail} {
+		key := "notify.limits." + string(ch)
+		if !v.IsSet(key) {
+			continue
+		}
+		cfg.Limits[ch] = notify.Limit{
+			Limit:        v.GetInt(key + ".limit"),
+			Window:       v.GetDuration(key + ".window"),
+			PerRecipient: v.GetBool(key + ".per_recipient"),
+		}
+	}
+
+	if v.IsSet("notify.templates") {
+		_ = v.UnmarshalKey("notify.templates", &cfg.Templates)
+	}
+	if v.IsSet("notify.rules") {
+		// Rules are read by their yaml keys, e.g. throttle.per_recipient
+		_ = v.UnmarshalKey("notify.rules", &cfg.Rules, func(c *mapstructure.DecoderConfig) { c.TagName = "yaml" })
+	}
+	return cfg
+}
@@ -11,7 +11,8 @@ type Sentry struct {
 	Endpoint    string  `json:"endpoint" yaml:"endpoint"`
 	Environment string  `json:"environment" yaml:"environment"`
 	Release     string  `json:"release" yaml:"release"`
-	SampleRate  float64 `json:"sample_rate" yaml:"sample_rate"`
+	SampleRate  float64 `json:"sample_rate" yaml:"sample_rate" validate:"min=0,max=1"`
+	LogLevel    string  `json:"log_level" yaml:"log_level" validate:"omitempty,oneof=trace debug info warn warning error fatal panic none"` // Log entries at or above the level are sent, "none" sends none
 }
 
 // getSentryConfig get sentry config
@@ -21,12 +22,14 @@ func getSentryConfig(v *viper.Viper) *Sentry {
 		Environment: v.GetString("observes.sentry.environment"),
 		Release:     v.GetString("observes.sentry.release"),
 		SampleRate:  getFloat64OrDefault(v, "observes.sentry.sample_rate", 1.0),
+		LogLevel:    getStringOrDefault(v, "observes.sentry.log_level", "error"),
 	}
 }
 
 // Tracer config struct for OpenTelemetry
 type Tracer struct {
-	Endpoint string `json:"endpoint" yaml:"endpoint"` // OTLP gRPC endpoint
+	Endpoint string `json:"endpoint" yaml:"endpoint"`                                                      // OTLP endpoint, tracing is off when empty unless exporting to stdout
+	Exporter string `json:"exporter" yaml:"exporter" validate:"omitempty,oneof=otlp otlphttp stdout none"` // "otlp" over gRPC, "otlphttp", "stdout" or "none"
 
 	// Service identification
 	ServiceName    string `json:"service_name" yaml:"service_name"`
@@ -34,7 +37,8 @@ type Tracer struct {
 	Environment    string `json:"environment" yaml:"environment"`
 
 	// Sampling configuration
-	SamplingRate float64 `json:"sampling_rate" yaml:"sampling_rate"` // 0.0 to 1.0
+	Sampler      string  `json:"sampler" yaml:"sampler"`                                    // OTEL_TRACES_SAMPLER name, "parentbased_traceidratio" by default
+	SamplingRate float64 `json:"sampling_rate" yaml:"sampling_rate" validate:"min=0,max=1"` // 0.0 to 1.0
 
 	// Performance tuning
 	MaxExportBatchSize int           `json:"max_export_batch_size" yaml:"max_export_batch_size"`
@@ -63,6 +67,7 @@ type Tracer struct {
 func getTracerConfig(v *viper.Viper) *Tracer {
 	return &Tracer{
 		Endpoint: v.GetString("observes.tracer.endpoint"),
+		Exporter: getStringOrDefault(v, "observes.tracer.exporter", "otlp"),
 
 		// Service identification
 		ServiceName:    v.GetString("observes.tracer.service_name"),
@@ -70,6 +75,7 @@ func getTracerConfig(v *viper.Viper) *Tracer {
 		Environment:    v.GetString("observes.tracer.environment"),
 
 		// Sampling with default to 100% in development
+		Sampler:      getStringOrDefault(v, "observes.tracer.sampler", "parentbased_traceidratio"),
 		SamplingRate: getFloat64OrDefault(v, "observes.tracer.sampling_rate", 1.0),
 
 		// Performance tuning with sensible defaults
@@ -96,10 +102,44 @@ func getTracerConfig(v *viper.Viper) *Tracer {
 	}
 }
 
+// APM config struct for New Relic and Elastic APM agents
+type APM struct {
+	Provider string `json:"provider" yaml:"provider" validate:"omitempty,oneof=newrelic elastic"` // "newrelic" or "elastic", empty disables APM
+
+	// Service identification
+	ServiceName    string `json:"service_name" yaml:"service_name"`
+	ServiceVersion string `json:"service_version" yaml:"service_version"`
+	Environment    string `json:"environment" yaml:"environment"`
+
+	// Agent connection
+	ServerURL   string `json:"server_url" yaml:"server_url"`     // Elastic APM server URL
+	LicenseKey  string `json:"license_key" yaml:"license_key"`   // New Relic license key
+	SecretToken string `json:"secret_token" yaml:"secret_token"` // Elastic APM secret token
+	APIKey      string `json:"api_key" yaml:"api_key"`           // Elastic APM API key
+
+	SampleRate float64 `json:"sample_rate" yaml:"sample_rate" validate:"min=0,max=1"` // 0.0 to 1.0, Elastic APM only
+}
+
+// getAPMConfig get APM config
+func getAPMConfig(v *viper.Viper) *APM {
+	return &APM{
+		Provider:       v.GetString("observes.apm.provider"),
+		ServiceName:    v.GetString("observes.apm.service_name"),
+		ServiceVersion: v.GetString("observes.apm.service_version"),
+		Environment:    v.GetString("observes.apm.environment"),
+		ServerURL:      v.GetString("observes.apm.server_url"),
+		LicenseKey:     v.GetString("observes.apm.license_key"),
+		SecretToken:    v.GetString("observes.apm.secret_token"),
+		APIKey:         v.GetString("observes.apm.api_key"),
+		SampleRate:     getFloat64OrDefault(v, "observes.apm.sample_rate", 1.0),
+	}
+}
+
 // Observes config struct
 type Observes struct {
 	Sentry *Sentry
 	Tracer *Tracer
+	APM    *APM
 }
 
 // get Observes config
@@ -107,5 +147,6 @@ func getObservesConfig(v *viper.Viper) *Observes {
 	return &Observes{
 		Sentry: getSentryConfig(v),
 		Tracer: getTracerConfig(v),
+		APM:    getAPMConfig(v),
 	}
 }
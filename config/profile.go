@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProfileEnv selects the profile overlay, e.g. NCORE_PROFILE=production
+const ProfileEnv = "NCORE_PROFILE"
+
+// Profile returns the active profile from NCORE_PROFILE
+func Profile() string {
+	return strings.TrimSpace(os.Getenv(ProfileEnv))
+}
+
+// LayerFiles returns base followed by the overlays found next to it: the
+// profile file and a local file with the same extension, e.g.
+// base.yaml, production.yaml, local.yaml. Later files override earlier ones.
+func LayerFiles(base, profile string) []string {
+	files := []string{base}
+	dir, ext := filepath.Dir(base), filepath.Ext(base)
+
+	seen := map[string]bool{filepath.Clean(base): true}
+	for _, name := range []string{profile, "local"} {
+		path := filepath.Join(dir, name+ext)
+		if name == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// Resolved is a merged layered configuration
+type Resolved struct {
+	Files    []string          `json:"files"`    // Layer files in merge order
+	Settings map[string]any    `json:"settings"` // Merged settings with lowercased keys
+	Sources  map[string]string `json:"sources"`  // Dotted leaf key to the file that set it
+}
+
+// Resolve reads base and its overlays for profile and deep merges them.
+// Maps merge key by key; scalars and lists are replaced by later layers.
+func Resolve(base, profile string) (*Resolved, error) {
+	r := &Resolved{
+		Files:    LayerFiles(base, profile),
+		Settings: make(map[string]any),
+		Sources:  make(map[string]string),
+	}
+	for _, file := range r.Files {
+		settings, err := readLayer(file)
+		if err != nil {
+			return nil, err
+		}
+		mergeLayer(r.Settings, settings, "", file, r.Sources)
+	}
+	return r, nil
+}
+
+// mergeOverlays merges the overlays of the config file used by v into v
+func mergeOverlays(v *viper.Viper) error {
+	files := LayerFiles(v.ConfigFileUsed(), Profile())
+	for _, file := range files[1:] {
+		settings, err := readLayer(file)
+		if err != nil {
+			return err
+		}
+		if err := v.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// readLayer reads one configuration file
+func readLayer(file string) (map[string]any, error) {
+	lv := viper.New()
+	lv.SetConfigFile(file)
+	if err := lv.ReadInConfig(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("config file not found: %s", file)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return lv.AllSettings(), nil
+}
+
+// mergeLayer deep merges src into dst, recording the file of every leaf it sets
+func mergeLayer(dst, src map[string]any, prefix, file string, sources map[string]string) {
+	for k, sv := range src {
+		path := prefix + k
+		srcMap, srcIsMap := sv.(map[string]any)
+		if dstMap, ok := dst[k].(map[string]any); ok && srcIsMap {
+			mergeLayer(dstMap, srcMap, path+".", file, sources)
+			continue
+		}
+
+		// Replaced values drop the sources of what they replace
+		delete(sources, path)
+		for key := range sources {
+			if strings.HasPrefix(key, path+".") {
+				delete(sources, key)
+			}
+		}
+		if srcIsMap {
+			m := make(map[string]any, len(srcMap))
+			mergeLayer(m, srcMap, path+".", file, sources)
+			dst[k] = m
+			continue
+		}
+		dst[k] = sv
+		sources[path] = file
+	}
+}
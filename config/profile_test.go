@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeLayers writes the named layer files to a new directory and returns it
+func writeLayers(t *testing.T, layers map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range layers {
+		writeLayer(t, filepath.Join(dir, name), content)
+	}
+	return dir
+}
+
+func writeLayer(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLayerFiles(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml":     "app_name: base\n",
+		"production.yaml": "app_name: production\n",
+		"local.yaml":      "app_name: local\n",
+	})
+	base := filepath.Join(dir, "config.yaml")
+	prod, local := filepath.Join(dir, "production.yaml"), filepath.Join(dir, "local.yaml")
+
+	for _, tc := range []struct {
+		base, profile string
+		want          []string
+	}{
+		{base, "production", []string{base, prod, local}},
+		{base, "", []string{base, local}},
+		{base, "staging", []string{base, local}},
+		{base, "local", []string{base, local}},
+		{local, "local", []string{local}},
+		{prod, "production", []string{prod, local}},
+	} {
+		if got := LayerFiles(tc.base, tc.profile); !slices.Equal(got, tc.want) {
+			t.Errorf("LayerFiles(%s, %q) = %v, want %v", filepath.Base(tc.base), tc.profile, got, tc.want)
+		}
+	}
+}
+
+func TestResolveOverlayOrder(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml":     "app_name: base\nserver:\n  port: 8000\n  host: 0.0.0.0\ntags: [a, b]\n",
+		"production.yaml": "app_name: production\nserver:\n  port: 8100\ntags: [c]\n",
+		"local.yaml":      "server:\n  port: 8200\n",
+	})
+	base := filepath.Join(dir, "config.yaml")
+
+	r, err := Resolve(base, "production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := r.Settings["server"].(map[string]any)
+	if r.Settings["app_name"] != "production" || server["port"] != 8200 || server["host"] != "0.0.0.0" {
+		t.Fatalf("unexpected settings %v", r.Settings)
+	}
+	if tags := r.Settings["tags"].([]any); len(tags) != 1 || tags[0] != "c" {
+		t.Fatalf("lists should be replaced, got %v", tags)
+	}
+	for key, file := range map[string]string{
+		"app_name":    "production.yaml",
+		"server.port": "local.yaml",
+		"server.host": "config.yaml",
+		"tags":        "production.yaml",
+	} {
+		if got := filepath.Base(r.Sources[key]); got != file {
+			t.Errorf("source of %s = %s, want %s", key, got, file)
+		}
+	}
+}
+
+func TestLoadConfigMergesOverlays(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml":     "app_name: base\nserver:\n  port: 8000\n",
+		"production.yaml": "app_name: production\nserver:\n  port: 8100\n",
+		"local.yaml":      "server:\n  port: 8200\n",
+	})
+	t.Setenv(ProfileEnv, "production")
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AppName != "production" || cfg.Port != 8200 {
+		t.Fatalf("got app %q port %d, want production 8200", cfg.AppName, cfg.Port)
+	}
+
+	t.Setenv(ProfileEnv, "")
+	if cfg, err = LoadConfig(filepath.Join(dir, "config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AppName != "base" || cfg.Port != 8200 {
+		t.Fatalf("got app %q port %d without profile, want base 8200", cfg.AppName, cfg.Port)
+	}
+}
+
+func TestWatchReloadsOverlays(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml":     "app_name: base\nserver:\n  port: 8000\n",
+		"production.yaml": "app_name: production\n",
+		"local.yaml":      "server:\n  port: 8200\n",
+	})
+	t.Setenv(ProfileEnv, "production")
+	base := filepath.Join(dir, "config.yaml")
+
+	cfg, err := LoadConfig(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevPath, prevCfg := path, current.Load()
+	path = base
+	current.Store(cfg)
+	t.Cleanup(func() {
+		path = prevPath
+		current.Store(prevCfg)
+	})
+
+	reloaded := make(chan *Config, 16)
+	Watch(func(cfg *Config) { reloaded <- cfg })
+
+	wait := func(ok func(*Config) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case cfg := <-reloaded:
+				if ok(cfg) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("configuration was not reloaded, current %+v", current.Load())
+			}
+		}
+	}
+
+	writeLayer(t, filepath.Join(dir, "local.yaml"), "server:\n  port: 8300\n")
+	wait(func(cfg *Config) bool { return cfg.Port == 8300 && cfg.AppName == "production" })
+
+	writeLayer(t, filepath.Join(dir, "production.yaml"), "app_name: renamed\n")
+	wait(func(cfg *Config) bool { return cfg.AppName == "renamed" && cfg.Port == 8300 })
+
+	// An invalid overlay keeps the last known good configuration
+	writeLayer(t, filepath.Join(dir, "local.yaml"), "server:\n  port: 70000\n")
+	time.Sleep(200 * time.Millisecond)
+	if got := current.Load(); got.Port != 8300 || got.AppName != "renamed" {
+		t.Fatalf("invalid overlay replaced the configuration: port %d app %q", got.Port, got.AppName)
+	}
+}
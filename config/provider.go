@@ -19,8 +19,13 @@ import "github.com/google/wire"
 //   - *Data: Data layer configuration
 //   - *Extension: Extension system configuration
 //   - *Auth: Authentication configuration
+//   - *LDAP: LDAP / Active Directory configuration
+//   - *SAML: SAML 2.0 single sign-on configuration
+//   - *Session: Device session configuration
+//   - *Refresh: Refresh token rotation configuration
 //   - *Storage: Storage configuration
 //   - *Email: Email configuration
+//   - *Notify: SMS and push notification configuration
 //   - *OAuth: OAuth configuration
 var ProviderSet = wire.NewSet(
 	GetConfig,
@@ -28,8 +33,13 @@ var ProviderSet = wire.NewSet(
 	ProvideDataConfig,
 	ProvideExtensionConfig,
 	ProvideAuthConfig,
+	ProvideLDAPConfig,
+	ProvideSAMLConfig,
+	ProvideSessionConfig,
+	ProvideRefreshConfig,
 	ProvideStorageConfig,
 	ProvideEmailConfig,
+	ProvideNotifyConfig,
 	ProvideOAuthConfig,
 )
 
@@ -65,6 +75,38 @@ func ProvideAuthConfig(cfg *Config) *Auth {
 	return cfg.Auth
 }
 
+// ProvideLDAPConfig provides the LDAP configuration, nil when LDAP is not configured.
+func ProvideLDAPConfig(cfg *Config) *LDAP {
+	if cfg == nil || cfg.Auth == nil {
+		return nil
+	}
+	return cfg.Auth.LDAP
+}
+
+// ProvideSAMLConfig provides the SAML configuration, nil when SAML is not configured.
+func ProvideSAMLConfig(cfg *Config) *SAML {
+	if cfg == nil || cfg.Auth == nil {
+		return nil
+	}
+	return cfg.Auth.SAML
+}
+
+// ProvideSessionConfig provides the device session configuration.
+func ProvideSessionConfig(cfg *Config) *Session {
+	if cfg == nil || cfg.Auth == nil {
+		return nil
+	}
+	return cfg.Auth.Session
+}
+
+// ProvideRefreshConfig provides the refresh token rotation configuration.
+func ProvideRefreshConfig(cfg *Config) *Refresh {
+	if cfg == nil || cfg.Auth == nil {
+		return nil
+	}
+	return cfg.Auth.Refresh
+}
+
 // ProvideStorageConfig provides the storage configuration.
 func ProvideStorageConfig(cfg *Config) *Storage {
 	if cfg == nil {
@@ -81,6 +123,14 @@ func ProvideEmailConfig(cfg *Config) *Email {
 	return cfg.Email
 }
 
+// ProvideNotifyConfig provides the SMS and push notification configuration.
+func ProvideNotifyConfig(cfg *Config) *Notify {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Notify
+}
+
 // ProvideOAuthConfig provides the OAuth configuration.
 func ProvideOAuthConfig(cfg *Config) *OAuth {
 	if cfg == nil {
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Refresh represents the refresh token rotation configuration, see
+// security/refresh for the defaults
+type Refresh struct {
+	// TTL is the lifetime of each refresh token
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+	// MaxLifetime caps a token family from its first token, 0 lets families
+	// live as long as they rotate
+	MaxLifetime time.Duration `json:"max_lifetime" yaml:"max_lifetime"`
+	// ReuseGrace tolerates a rotated token presented again this soon after
+	// its rotation, 0 treats any reuse as theft
+	ReuseGrace time.Duration `json:"reuse_grace" yaml:"reuse_grace"`
+}
+
+// getRefresh returns the refresh token rotation configuration
+func getRefresh(v *viper.Viper) *Refresh {
+	return &Refresh{
+		TTL:         v.GetDuration("auth.refresh.ttl"),
+		MaxLifetime: v.GetDuration("auth.refresh.max_lifetime"),
+		ReuseGrace:  v.GetDuration("auth.refresh.reuse_grace"),
+	}
+}
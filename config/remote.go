@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Remote holds the remote configuration sources. It is read from the local
+// file only, remote sources cannot add further sources.
+type Remote struct {
+	RefreshInterval time.Duration   `yaml:"refresh_interval" json:"refresh_interval"` // Polling interval of sources without watch, default 1m
+	Sources         []*RemoteSource `yaml:"sources" json:"sources"`
+}
+
+// RemoteSource is a remote configuration source, merged over the local
+// files in order
+type RemoteSource struct {
+	Type      string        `yaml:"type" json:"type" validate:"required"`                               // consul, etcd or vault
+	Address   string        `yaml:"address" json:"address" validate:"required"`                         // e.g. http://127.0.0.1:8500
+	Path      string        `yaml:"path" json:"path"`                                                   // Key prefix, document key or secret path
+	Key       string        `yaml:"key" json:"key"`                                                     // Dotted key the settings are merged under, empty for the root
+	Format    string        `yaml:"format" json:"format" validate:"omitempty,oneof=yaml yml json toml"` // yaml, json or toml when Path is a single key holding a document
+	Token     string        `yaml:"token" json:"-"`                                                     // ACL or Vault token, defaults to CONSUL_HTTP_TOKEN or VAULT_TOKEN
+	Username  string        `yaml:"username" json:"username"`                                           // etcd
+	Password  string        `yaml:"password" json:"-"`                                                  // etcd
+	Namespace string        `yaml:"namespace" json:"namespace"`                                         // Vault Enterprise namespace
+	Watch     bool          `yaml:"watch" json:"watch"`                                                 // Wait for changes instead of polling, Consul only
+	Optional  bool          `yaml:"optional" json:"optional"`                                           // Load without the source when it fails
+	Timeout   time.Duration `yaml:"timeout" json:"timeout"`                                             // Request timeout, default 10s
+}
+
+// String names the source in errors
+func (s *RemoteSource) String() string {
+	return s.Type + " " + s.Path
+}
+
+// RemoteProvider reads settings from a remote source
+type RemoteProvider interface {
+	// Load returns the settings of the source as a nested map
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// RemoteWatcher is implemented by providers that can wait for changes
+type RemoteWatcher interface {
+	// Wait blocks until the settings may have changed since the last Load,
+	// returning early when ctx is done
+	Wait(ctx context.Context) error
+}
+
+// RemoteProviderFactory creates the provider of a source
+type RemoteProviderFactory func(src *RemoteSource) (RemoteProvider, error)
+
+var (
+	remoteMu        sync.RWMutex
+	remoteFactories = map[string]RemoteProviderFactory{
+		"consul": newConsulProvider,
+		"etcd":   newEtcdProvider,
+		"vault":  newVaultProvider,
+	}
+)
+
+// RegisterRemoteProvider adds a remote source type, e.g. for a secrets manager
+func RegisterRemoteProvider(typ string, factory RemoteProviderFactory) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+	remoteFactories[typ] = factory
+}
+
+// newRemoteProvider creates the provider of a source
+func newRemoteProvider(src *RemoteSource) (RemoteProvider, error) {
+	remoteMu.RLock()
+	factory, ok := remoteFactories[src.Type]
+	remoteMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown remote config source type %q", src.Type)
+	}
+	if src.Address == "" {
+		return nil, fmt.Errorf("remote config source %s: address is required", src)
+	}
+	return factory(src)
+}
+
+// getRemote reads the remote sources of the local configuration
+func getRemote(v *viper.Viper) (*Remote, error) {
+	r := &Remote{RefreshInterval: getDurationOrDefault(v, "remote.refresh_interval", time.Minute)}
+	if !v.IsSet("remote.sources") {
+		return r, nil
+	}
+	err := v.UnmarshalKey("remote.sources", &r.Sources, func(c *mapstructure.DecoderConfig) { c.TagName = "yaml" })
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote.sources: %w", err)
+	}
+	for _, src := range r.Sources {
+		if src.Timeout <= 0 {
+			src.Timeout = 10 * time.Second
+		}
+	}
+	return r, nil
+}
+
+// loadRemote reads a source, nesting its settings under its key
+func loadRemote(ctx context.Context, src *RemoteSource, p RemoteProvider) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, src.Timeout)
+	defer cancel()
+	settings, err := p.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("remote config source %s: %w", src, err)
+	}
+	if src.Key != "" {
+		parts := strings.Split(strings.ToLower(src.Key), ".")
+		for i := len(parts) - 1; i >= 0; i-- {
+			settings = map[string]any{parts[i]: settings}
+		}
+	}
+	return settings, nil
+}
+
+// mergeRemote merges the remote sources of v into v, before encrypted values
+// are decrypted so remote values may be encrypted too
+func mergeRemote(v *viper.Viper, remote *Remote) error {
+	for _, src := range remote.Sources {
+		p, err := newRemoteProvider(src)
+		if err != nil {
+			return err
+		}
+		settings, err := loadRemote(context.Background(), src, p)
+		if err != nil {
+			if src.Optional {
+				fmt.Printf("Skipping optional %v\n", err)
+				continue
+			}
+			return err
+		}
+		if err := v.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("failed to merge remote config source %s: %w", src, err)
+		}
+	}
+	return nil
+}
+
+// watchRemote calls changed when a remote source changes, until ctx is done.
+// Sources are watched if they support it and polled otherwise.
+func watchRemote(ctx context.Context, remote *Remote, changed func()) {
+	for _, src := range remote.Sources {
+		p, err := newRemoteProvider(src)
+		if err != nil {
+			continue
+		}
+		go watchSource(ctx, src, p, remote.RefreshInterval, changed)
+	}
+}
+
+// watchSource calls changed whenever the settings of a source differ from
+// the ones read before
+func watchSource(ctx context.Context, src *RemoteSource, p RemoteProvider, interval time.Duration, changed func()) {
+	last, _ := loadRemote(ctx, src, p)
+	w, watch := p.(RemoteWatcher)
+	watch = watch && src.Watch
+
+	for {
+		if watch {
+			if err := w.Wait(ctx); err != nil && ctx.Err() == nil {
+				// Back off before waiting again, e.g. while the agent restarts
+				if !sleep(ctx, interval) {
+					return
+				}
+			}
+		} else if !sleep(ctx, interval) {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		settings, err := loadRemote(ctx, src, p)
+		if err != nil || reflect.DeepEqual(settings, last) {
+			continue
+		}
+		last = settings
+		changed()
+	}
+}
+
+// sleep waits for d, false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// parseDocument parses a document held by a single key
+func parseDocument(format string, data []byte) (map[string]any, error) {
+	dv := viper.New()
+	dv.SetConfigType(format)
+	if err := dv.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to parse %s document: %w", format, err)
+	}
+	return dv.AllSettings(), nil
+}
+
+// nestKeys turns keys relative to a prefix into nested settings, with sep
+// separating levels, e.g. "auth/jwt/secret" into auth.jwt.secret
+func nestKeys(kvs map[string]any, sep string) map[string]any {
+	settings := make(map[string]any)
+	for k, val := range kvs {
+		parts := strings.Split(strings.Trim(strings.ToLower(k), sep), sep)
+		if parts[0] == "" {
+			continue
+		}
+		m := settings
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := m[part].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				m[part] = sub
+			}
+			m = sub
+		}
+		// A key with children keeps them over its own value
+		if _, isMap := m[parts[len(parts)-1]].(map[string]any); !isMap {
+			m[parts[len(parts)-1]] = val
+		}
+	}
+	return settings
+}
+
+// errRemoteNotFound is returned by remoteJSON for a 404 response
+var errRemoteNotFound = errors.New("not found")
+
+// remoteJSON sends a request with an optional JSON body and decodes the JSON
+// response into out, returning the response headers
+func remoteJSON(ctx context.Context, method, url string, header http.Header, body, out any) (http.Header, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return resp.Header, errRemoteNotFound
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.Header, fmt.Errorf("%s %s: status %d: %s", method, req.URL.Redacted(), resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("invalid response from %s: %w", req.URL.Redacted(), err)
+	}
+	return resp.Header, nil
+}
+
+// baseURL returns an address with a scheme and without a trailing slash
+func baseURL(address string) string {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return strings.TrimSuffix(address, "/")
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// consulProvider reads a Consul KV prefix, or a single key holding a document
+// when a format is set. Wait uses blocking queries.
+type consulProvider struct {
+	src    *RemoteSource
+	url    string
+	header http.Header
+	index  atomic.Uint64 // X-Consul-Index of the last response
+}
+
+type consulKV struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"`
+}
+
+func newConsulProvider(src *RemoteSource) (RemoteProvider, error) {
+	p := &consulProvider{
+		src:    src,
+		url:    baseURL(src.Address) + "/v1/kv/" + strings.TrimPrefix(src.Path, "/"),
+		header: http.Header{},
+	}
+	token := src.Token
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	if token != "" {
+		p.header.Set("X-Consul-Token", token)
+	}
+	return p, nil
+}
+
+// get reads the keys, blocking until they change past index when it is set
+func (p *consulProvider) get(ctx context.Context, index uint64) ([]consulKV, error) {
+	q := url.Values{}
+	if p.src.Format == "" {
+		q.Set("recurse", "true")
+	}
+	if index > 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", "5m")
+	}
+
+	var kvs []consulKV
+	header, err := remoteJSON(ctx, http.MethodGet, p.url+"?"+q.Encode(), p.header, nil, &kvs)
+	if err != nil && !errors.Is(err, errRemoteNotFound) {
+		return nil, err
+	}
+	if header != nil {
+		if idx, perr := strconv.ParseUint(header.Get("X-Consul-Index"), 10, 64); perr == nil {
+			// The index going backwards means the KV store was reset
+			if idx < index {
+				idx = 0
+			}
+			p.index.Store(idx)
+		}
+	}
+	return kvs, nil
+}
+
+func (p *consulProvider) Load(ctx context.Context) (map[string]any, error) {
+	kvs, err := p.get(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.src.Format != "" {
+		if len(kvs) == 0 {
+			return nil, fmt.Errorf("key %s not found", p.src.Path)
+		}
+		return parseDocument(p.src.Format, kvs[0].Value)
+	}
+
+	prefix := strings.TrimPrefix(p.src.Path, "/")
+	values := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		// Folders are keys ending with a slash
+		if strings.HasSuffix(kv.Key, "/") {
+			continue
+		}
+		values[strings.TrimPrefix(kv.Key, prefix)] = string(kv.Value)
+	}
+	return nestKeys(values, "/"), nil
+}
+
+func (p *consulProvider) Wait(ctx context.Context) error {
+	_, err := p.get(ctx, max(p.index.Load(), 1))
+	return err
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// etcdProvider reads a key prefix through the etcd v3 JSON gateway, or a
+// single key holding a document when a format is set
+type etcdProvider struct {
+	src *RemoteSource
+	url string
+}
+
+type etcdRange struct {
+	Kvs []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+func newEtcdProvider(src *RemoteSource) (RemoteProvider, error) {
+	return &etcdProvider{src: src, url: baseURL(src.Address)}, nil
+}
+
+// authenticate returns the header of requests, with a token when a user is set
+func (p *etcdProvider) authenticate(ctx context.Context) (http.Header, error) {
+	header := http.Header{}
+	if p.src.Username == "" {
+		return header, nil
+	}
+	var auth struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"name": p.src.Username, "password": p.src.Password}
+	if _, err := remoteJSON(ctx, http.MethodPost, p.url+"/v3/auth/authenticate", header, body, &auth); err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	header.Set("Authorization", auth.Token)
+	return header, nil
+}
+
+func (p *etcdProvider) Load(ctx context.Context) (map[string]any, error) {
+	header, err := p.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := []byte(p.src.Path)
+	req := map[string][]byte{"key": key}
+	if p.src.Format == "" {
+		req["range_end"] = prefixEnd(key)
+	}
+	var resp etcdRange
+	if _, err := remoteJSON(ctx, http.MethodPost, p.url+"/v3/kv/range", header, req, &resp); err != nil {
+		return nil, err
+	}
+
+	if p.src.Format != "" {
+		if len(resp.Kvs) == 0 {
+			return nil, fmt.Errorf("key %s not found", p.src.Path)
+		}
+		return parseDocument(p.src.Format, resp.Kvs[0].Value)
+	}
+
+	values := make(map[string]any, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[strings.TrimPrefix(string(kv.Key), p.src.Path)] = string(kv.Value)
+	}
+	return nestKeys(values, "/"), nil
+}
+
+// prefixEnd returns the end of the range of keys starting with prefix
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// All keys
+	return []byte{0}
+}
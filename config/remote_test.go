@@ -0,0 +1,244 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// consulServer serves a KV prefix, blocking queries wait for the next version
+func consulServer(t *testing.T, token string, kvs func() map[string]string, version *atomic.Uint64) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); index > 0 {
+			for version.Load() <= index {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(5 * time.Millisecond):
+				}
+			}
+		}
+
+		var out []map[string]any
+		for k, v := range kvs() {
+			if strings.HasPrefix(k, strings.TrimPrefix(r.URL.Path, "/v1/kv/")) {
+				out = append(out, map[string]any{"Key": k, "Value": []byte(v)})
+			}
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(version.Load(), 10))
+		if len(out) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLoadConfigMergesRemoteSources(t *testing.T) {
+	var version atomic.Uint64
+	version.Store(1)
+	consul := consulServer(t, "consul-token", func() map[string]string {
+		return map[string]string{
+			"ncore/app/":            "",
+			"ncore/app/app_name":    "remote",
+			"ncore/app/server/port": "8300",
+			"ncore/doc":             "server:\n  host: 10.0.0.1\n",
+		}
+	}, &version)
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/app" || r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"auth.jwt.secret": "vault-jwt"}, "metadata": {"version": 3}}}`)
+	}))
+	defer vault.Close()
+
+	dir := writeLayers(t, map[string]string{"config.yaml": fmt.Sprintf(`app_name: local
+server:
+  port: 8000
+remote:
+  sources:
+    - type: consul
+      address: %[1]s
+      path: ncore/app/
+      token: consul-token
+    - type: consul
+      address: %[1]s
+      path: ncore/doc
+      format: yaml
+      token: consul-token
+    - type: vault
+      address: %[2]s
+      path: secret/data/app
+      token: vault-token
+    - type: etcd
+      address: http://127.0.0.1:1
+      path: missing/
+      optional: true
+      timeout: 1s
+`, consul.URL, vault.URL)})
+	t.Setenv(ProfileEnv, "")
+
+	cfg, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AppName != "remote" || cfg.Port != 8300 || cfg.Host != "10.0.0.1" {
+		t.Fatalf("got app %q port %d host %q, want remote 8300 10.0.0.1", cfg.AppName, cfg.Port, cfg.Host)
+	}
+	if cfg.Auth.JWT.Secret != "vault-jwt" {
+		t.Fatalf("JWT secret = %q, want the Vault secret", cfg.Auth.JWT.Secret)
+	}
+	if len(cfg.Remote.Sources) != 4 || cfg.Remote.Sources[0].Timeout != 10*time.Second {
+		t.Fatalf("unexpected remote sources %+v", cfg.Remote.Sources)
+	}
+
+	// A required source failing fails the load
+	writeLayer(t, filepath.Join(dir, "config.yaml"), fmt.Sprintf(`app_name: local
+remote:
+  sources:
+    - type: consul
+      address: %s
+      path: ncore/app/
+      token: wrong
+`, consul.URL))
+	if _, err := LoadConfig(filepath.Join(dir, "config.yaml")); err == nil || !strings.Contains(err.Error(), "consul ncore/app/") {
+		t.Fatalf("expected an error naming the source, got %v", err)
+	}
+}
+
+func TestEtcdProviderLoad(t *testing.T) {
+	etcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "root" || body["password"] != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "etcd-token"}`)
+		case "/v3/kv/range":
+			if r.Header.Get("Authorization") != "etcd-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var body map[string][]byte
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if string(body["key"]) != "ncore/" || string(body["range_end"]) != "ncore0" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string][]byte{
+				{"key": []byte("ncore/server/port"), "value": []byte("8400")},
+				{"key": []byte("ncore/Auth/JWT/Expiry"), "value": []byte("2h")},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer etcd.Close()
+
+	src := &RemoteSource{Type: "etcd", Address: etcd.URL, Path: "ncore/", Key: "data.overrides", Username: "root", Password: "pass", Timeout: time.Second}
+	p, err := newRemoteProvider(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := loadRemote(context.Background(), src, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := settings["data"].(map[string]any)["overrides"].(map[string]any)
+	if overrides["server"].(map[string]any)["port"] != "8400" || overrides["auth"].(map[string]any)["jwt"].(map[string]any)["expiry"] != "2h" {
+		t.Fatalf("unexpected settings %v", settings)
+	}
+
+	src.Password = "wrong"
+	if _, err := loadRemote(context.Background(), src, p); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestNewRemoteProviderErrors(t *testing.T) {
+	if _, err := newRemoteProvider(&RemoteSource{Type: "zookeeper", Address: "localhost:2181"}); err == nil {
+		t.Error("expected an error for an unknown source type")
+	}
+	if _, err := newRemoteProvider(&RemoteSource{Type: "consul"}); err == nil {
+		t.Error("expected an error for a source without address")
+	}
+}
+
+// staticProvider returns settings set by the test
+type staticProvider struct{ value atomic.Value }
+
+func (p *staticProvider) Load(context.Context) (map[string]any, error) {
+	return map[string]any{"app_name": p.value.Load()}, nil
+}
+
+func TestWatchRemoteDetectsChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Polled custom provider
+	static := &staticProvider{}
+	static.value.Store("one")
+	RegisterRemoteProvider("static", func(*RemoteSource) (RemoteProvider, error) { return static, nil })
+
+	// Consul source waiting with blocking queries
+	var version atomic.Uint64
+	var name atomic.Value
+	version.Store(1)
+	name.Store("one")
+	consul := consulServer(t, "", func() map[string]string {
+		return map[string]string{"ncore/app_name": name.Load().(string)}
+	}, &version)
+
+	polled, watched := make(chan struct{}, 8), make(chan struct{}, 8)
+	watchRemote(ctx, &Remote{RefreshInterval: 20 * time.Millisecond, Sources: []*RemoteSource{
+		{Type: "static", Address: "memory", Timeout: time.Second},
+	}}, func() { polled <- struct{}{} })
+	watchRemote(ctx, &Remote{RefreshInterval: time.Hour, Sources: []*RemoteSource{
+		{Type: "consul", Address: consul.URL, Path: "ncore/", Watch: true, Timeout: time.Second},
+	}}, func() { watched <- struct{}{} })
+
+	expect := func(ch chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s change was not detected", what)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	static.value.Store("two")
+	expect(polled, "polled")
+
+	name.Store("two")
+	version.Add(1)
+	expect(watched, "watched")
+
+	// Unchanged settings are not reported
+	version.Add(1)
+	time.Sleep(100 * time.Millisecond)
+	if len(watched) != 0 || len(polled) != 0 {
+		t.Fatal("unchanged settings were reported as changes")
+	}
+}
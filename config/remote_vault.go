@@ -0,0 +1,53 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// vaultProvider reads a Vault KV secret. Secret keys are dotted config keys,
+// e.g. "auth.jwt.secret", or keys below the source key.
+type vaultProvider struct {
+	src    *RemoteSource
+	url    string
+	header http.Header
+}
+
+func newVaultProvider(src *RemoteSource) (RemoteProvider, error) {
+	p := &vaultProvider{
+		src:    src,
+		url:    baseURL(src.Address) + "/v1/" + strings.TrimPrefix(src.Path, "/"),
+		header: http.Header{},
+	}
+	token := src.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token != "" {
+		p.header.Set("X-Vault-Token", token)
+	}
+	if src.Namespace != "" {
+		p.header.Set("X-Vault-Namespace", src.Namespace)
+	}
+	return p, nil
+}
+
+func (p *vaultProvider) Load(ctx context.Context) (map[string]any, error) {
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if _, err := remoteJSON(ctx, http.MethodGet, p.url, p.header, nil, &secret); err != nil {
+		return nil, err
+	}
+
+	// KV version 2 nests the secret with its metadata
+	data := secret.Data
+	if inner, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	return nestKeys(data, "."), nil
+}
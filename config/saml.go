@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/ncobase/ncore/security/saml"
+	"github.com/spf13/viper"
+)
+
+// SAML represents the SAML 2.0 single sign-on configuration
+type SAML = saml.Config
+
+// getSAML returns the SAML configuration, nil when auth.saml is not set
+func getSAML(v *viper.Viper) *SAML {
+	if !v.IsSet("auth.saml") {
+		return nil
+	}
+	cfg := &SAML{
+		EntityID:          v.GetString("auth.saml.entity_id"),
+		RootURL:           v.GetString("auth.saml.root_url"),
+		CertFile:          v.GetString("auth.saml.cert_file"),
+		KeyFile:           v.GetString("auth.saml.key_file"),
+		IDPMetadataURL:    v.GetString("auth.saml.idp_metadata_url"),
+		IDPMetadataFile:   v.GetString("auth.saml.idp_metadata_file"),
+		IDPMetadataXML:    v.GetString("auth.saml.idp_metadata_xml"),
+		SignRequests:      v.GetBool("auth.saml.sign_requests"),
+		AllowIDPInitiated: v.GetBool("auth.saml.allow_idp_initiated"),
+		NameIDFormat:      v.GetString("auth.saml.name_id_format"),
+		ClockSkew:         v.GetDuration("auth.saml.clock_skew"),
+		RequestTTL:        v.GetDuration("auth.saml.request_ttl"),
+		Attributes: saml.Attributes{
+			ID:       v.GetString("auth.saml.attributes.id"),
+			Username: v.GetString("auth.saml.attributes.username"),
+			Email:    v.GetString("auth.saml.attributes.email"),
+			Name:     v.GetString("auth.saml.attributes.name"),
+			Groups:   v.GetString("auth.saml.attributes.groups"),
+			Extra:    v.GetStringSlice("auth.saml.attributes.extra"),
+		},
+		DefaultRoles: v.GetStringSlice("auth.saml.default_roles"),
+	}
+	// Viper lowercases the group names, which is harmless as they match case-insensitively
+	if v.IsSet("auth.saml.role_mapping") {
+		_ = v.UnmarshalKey("auth.saml.role_mapping", &cfg.RoleMapping)
+	}
+	return cfg
+}
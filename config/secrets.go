@@ -0,0 +1,290 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// KeyEnv holds the base64 or hex encoded master key for encrypted values
+	KeyEnv = "NCORE_CONFIG_KEY"
+	// KeyFileEnv names a file holding the master key
+	KeyFileEnv = "NCORE_CONFIG_KEY_FILE"
+)
+
+// KeyProvider returns the 32-byte master key, e.g. by decrypting a data key with a KMS
+type KeyProvider func() ([]byte, error)
+
+var (
+	keyMu       sync.Mutex
+	keyProvider KeyProvider = envKey
+)
+
+// SetKeyProvider replaces the master key source, nil restores the environment lookup
+func SetKeyProvider(p KeyProvider) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if p == nil {
+		p = envKey
+	}
+	keyProvider = p
+}
+
+// IsEncrypted reports whether a value uses the ENC[...] marker
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, "ENC[") && strings.HasSuffix(value, "]")
+}
+
+// EncryptValue encrypts a value into the sops format
+// ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]. path is the dotted key
+// of the value as written in the file, bound as authenticated data so values
+// cannot be swapped. Like sops, keys are case sensitive.
+func EncryptValue(key []byte, path, plaintext string) (string, error) {
+	gcm, err := newGCM(key, 32)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), additionalData(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]", enc(data), enc(iv), enc(tag)), nil
+}
+
+// DecryptValue decrypts an ENC[...] value stored under the dotted key path.
+// Values typed int, float or bool are converted.
+func DecryptValue(key []byte, path, value string) (any, error) {
+	if !IsEncrypted(value) {
+		return nil, errors.New("value is not encrypted")
+	}
+
+	fields := make(map[string]string)
+	parts := strings.Split(value[len("ENC["):len(value)-1], ",")
+	if parts[0] != "AES256_GCM" {
+		return nil, fmt.Errorf("unsupported cipher %q", parts[0])
+	}
+	for _, p := range parts[1:] {
+		k, v, ok := strings.Cut(p, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed encrypted value")
+		}
+		fields[k] = v
+	}
+
+	var raw [3][]byte
+	for i, name := range []string{"data", "iv", "tag"} {
+		b, err := base64.StdEncoding.DecodeString(fields[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in encrypted value: %v", name, err)
+		}
+		raw[i] = b
+	}
+	data, iv, tag := raw[0], raw[1], raw[2]
+	if len(iv) == 0 {
+		return nil, errors.New("missing iv in encrypted value")
+	}
+
+	gcm, err := newGCM(key, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	if len(tag) != gcm.Overhead() {
+		return nil, errors.New("invalid tag in encrypted value")
+	}
+	plain, err := gcm.Open(nil, iv, append(data, tag...), additionalData(path))
+	if err != nil {
+		return nil, errors.New("failed to decrypt value: wrong key or tampered data")
+	}
+
+	s := string(plain)
+	switch fields["type"] {
+	case "", "str", "bytes":
+		return s, nil
+	case "int":
+		return strconv.Atoi(s)
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	default:
+		return nil, fmt.Errorf("unsupported value type %q", fields["type"])
+	}
+}
+
+// decryptSettings replaces ENC[...] values in v with their plaintext.
+// The key is only loaded when an encrypted value is present.
+func decryptSettings(v *viper.Viper) error {
+	var key []byte
+	decrypt := func(path, value string) (any, error) {
+		if key == nil {
+			keyMu.Lock()
+			p := keyProvider
+			keyMu.Unlock()
+
+			k, err := p()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load config key: %w", err)
+			}
+			key = k
+		}
+		plain, err := DecryptValue(key, path, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return plain, nil
+	}
+
+	// Settings keys are lowercased, values are authenticated under the keys as written
+	keys := fileKeys(v)
+	decrypted, err := decryptMap(v.AllSettings(), "", func(path, value string) (any, error) {
+		if key, ok := keys[path]; ok {
+			path = key
+		}
+		return decrypt(path, value)
+	})
+	if err != nil || len(decrypted) == 0 {
+		return err
+	}
+	return v.MergeConfigMap(decrypted)
+}
+
+// decryptMap returns the subset of m containing decrypted values
+func decryptMap(m map[string]any, prefix string, decrypt func(path, value string) (any, error)) (map[string]any, error) {
+	out := make(map[string]any)
+	for k, val := range m {
+		path := prefix + k
+		switch t := val.(type) {
+		case string:
+			if !IsEncrypted(t) {
+				continue
+			}
+			plain, err := decrypt(path, t)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = plain
+		case map[string]any:
+			sub, err := decryptMap(t, path+".", decrypt)
+			if err != nil {
+				return nil, err
+			}
+			if len(sub) > 0 {
+				out[k] = sub
+			}
+		case []any:
+			// List items share the key path of the list, as in sops
+			var items []any
+			for i, item := range t {
+				s, ok := item.(string)
+				if !ok || !IsEncrypted(s) {
+					continue
+				}
+				plain, err := decrypt(path, s)
+				if err != nil {
+					return nil, err
+				}
+				if items == nil {
+					items = append([]any(nil), t...)
+				}
+				items[i] = plain
+			}
+			if items != nil {
+				out[k] = items
+			}
+		}
+	}
+	return out, nil
+}
+
+// fileKeys maps the lowercased dotted keys of the files v is layered from to the
+// keys as written, later layers win. Keys of remote sources are not included.
+func fileKeys(v *viper.Viper) map[string]string {
+	keys := make(map[string]string)
+	codecs := viper.NewCodecRegistry()
+	for _, file := range LayerFiles(v.ConfigFileUsed(), Profile()) {
+		decoder, err := codecs.Decoder(strings.TrimPrefix(filepath.Ext(file), "."))
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		raw := make(map[string]any)
+		if err := decoder.Decode(b, raw); err != nil {
+			continue
+		}
+		collectKeys(raw, "", "", keys)
+	}
+	return keys
+}
+
+// collectKeys adds the dotted keys of m to keys, by their lowercased form
+func collectKeys(m map[string]any, lower, written string, keys map[string]string) {
+	for k, val := range m {
+		l, w := lower+strings.ToLower(k), written+k
+		keys[l] = w
+		if sub, ok := val.(map[string]any); ok {
+			collectKeys(sub, l+".", w+".", keys)
+		}
+	}
+}
+
+// additionalData renders a dotted path as the sops authenticated data "a:b:c:"
+func additionalData(path string) []byte {
+	return []byte(strings.ReplaceAll(path, ".", ":") + ":")
+}
+
+// newGCM creates an AES-256-GCM cipher with the given nonce size
+func newGCM(key []byte, nonceSize int) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("config key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
+
+// envKey reads the master key from NCORE_CONFIG_KEY or NCORE_CONFIG_KEY_FILE
+func envKey() ([]byte, error) {
+	raw := os.Getenv(KeyEnv)
+	if raw == "" {
+		if file := os.Getenv(KeyFileEnv); file != "" {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			raw = string(b)
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("encrypted values found but neither %s nor %s is set", KeyEnv, KeyFileEnv)
+	}
+
+	if b, err := hex.DecodeString(raw); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(raw); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	return nil, fmt.Errorf("config key must be 32 bytes encoded as hex or base64")
+}
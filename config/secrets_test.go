@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	testKey  = bytes.Repeat([]byte{1}, 32)
+	otherKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestEncryptValueRoundTrip(t *testing.T) {
+	enc, err := EncryptValue(testKey, "data.database.master.password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(enc) || strings.Contains(enc, "s3cret") {
+		t.Fatalf("unexpected encrypted value %s", enc)
+	}
+
+	if plain, err := DecryptValue(testKey, "data.database.master.password", enc); err != nil || plain != "s3cret" {
+		t.Fatalf("DecryptValue() = %v, %v", plain, err)
+	}
+
+	// Keys are case sensitive as in sops
+	enc, err = EncryptValue(testKey, "Auth.JWT.Secret", "jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := DecryptValue(testKey, "Auth.JWT.Secret", enc); err != nil || plain != "jwt" {
+		t.Fatalf("DecryptValue of a mixed case path = %v, %v", plain, err)
+	}
+	if _, err := DecryptValue(testKey, "auth.jwt.secret", enc); err == nil {
+		t.Fatal("value decrypted under the lowercased path")
+	}
+}
+
+func TestDecryptValueRejects(t *testing.T) {
+	enc, err := EncryptValue(testKey, "auth.jwt.secret", "jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a byte of the ciphertext
+	start := strings.Index(enc, "data:") + len("data:")
+	end := start + strings.Index(enc[start:], ",")
+	data, _ := base64.StdEncoding.DecodeString(enc[start:end])
+	data[0] ^= 0xff
+	tampered := enc[:start] + base64.StdEncoding.EncodeToString(data) + enc[end:]
+
+	for name, tc := range map[string]struct {
+		key   []byte
+		path  string
+		value string
+	}{
+		"tampered":   {testKey, "auth.jwt.secret", tampered},
+		"wrong key":  {otherKey, "auth.jwt.secret", enc},
+		"wrong path": {testKey, "data.database.master.password", enc},
+		"short key":  {testKey[:16], "auth.jwt.secret", enc},
+		"plaintext":  {testKey, "auth.jwt.secret", "jwt"},
+		"cipher":     {testKey, "auth.jwt.secret", strings.Replace(enc, "AES256_GCM", "AES128_GCM", 1)},
+		"type":       {testKey, "auth.jwt.secret", strings.Replace(enc, "type:str", "type:time", 1)},
+	} {
+		if plain, err := DecryptValue(tc.key, tc.path, tc.value); err == nil {
+			t.Errorf("%s: DecryptValue = %v, want an error", name, plain)
+		}
+	}
+}
+
+func TestLoadConfigDecryptsValues(t *testing.T) {
+	// Values are authenticated under the keys as written in the file
+	secret, err := EncryptValue(testKey, "Auth.JWT.Secret", "jwt-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := EncryptValue(testKey, "data.database.master.password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeLayers(t, map[string]string{
+		"config.yaml": fmt.Sprintf("app_name: app\nAuth:\n  JWT:\n    Secret: %s\n", secret),
+		"local.yaml":  fmt.Sprintf("data:\n  database:\n    master:\n      password: %s\n", password),
+	})
+	file := filepath.Join(dir, "config.yaml")
+	t.Setenv(ProfileEnv, "")
+
+	SetKeyProvider(func() ([]byte, error) { return testKey, nil })
+	t.Cleanup(func() { SetKeyProvider(nil) })
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.JWT.Secret != "jwt-secret" {
+		t.Fatalf("JWT secret = %q", cfg.Auth.JWT.Secret)
+	}
+	if got := cfg.Viper.GetString("data.database.master.password"); got != "s3cret" {
+		t.Fatalf("overlay password = %q", got)
+	}
+
+	SetKeyProvider(func() ([]byte, error) { return otherKey, nil })
+	_, err = LoadConfig(file)
+	if err == nil || !strings.Contains(err.Error(), "Auth.JWT.Secret") && !strings.Contains(err.Error(), "data.database.master.password") {
+		t.Fatalf("expected a decryption error naming the key, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Session represents the device session configuration, see security/session
+// for the defaults
+type Session struct {
+	// TTL is the absolute lifetime of a session
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+	// IdleTimeout expires sessions unused for this long, 0 disables
+	IdleTimeout time.Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	// TouchInterval is the least time between last seen updates
+	TouchInterval time.Duration `json:"touch_interval" yaml:"touch_interval"`
+	// MaxSessions caps the sessions of a user, 0 is unlimited
+	MaxSessions int `json:"max_sessions" yaml:"max_sessions"`
+	// DeviceTTL is how long a device is remembered after its last login
+	DeviceTTL time.Duration `json:"device_ttl" yaml:"device_ttl"`
+}
+
+// getSession returns the session configuration. auth.max_sessions applies
+// when auth.session.max_sessions is not set.
+func getSession(v *viper.Viper) *Session {
+	cfg := &Session{
+		TTL:           v.GetDuration("auth.session.ttl"),
+		IdleTimeout:   v.GetDuration("auth.session.idle_timeout"),
+		TouchInterval: v.GetDuration("auth.session.touch_interval"),
+		MaxSessions:   v.GetInt("auth.session.max_sessions"),
+		DeviceTTL:     v.GetDuration("auth.session.device_ttl"),
+	}
+	if !v.IsSet("auth.session.max_sessions") {
+		cfg.MaxSessions = v.GetInt("auth.max_sessions")
+	}
+	return cfg
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue is a misconfigured setting found by Validate
+type Issue struct {
+	Key        string `json:"key"`                  // Dotted key, e.g. grpc.port, empty for checks spanning keys
+	Message    string `json:"message"`              // What is wrong
+	Suggestion string `json:"suggestion,omitempty"` // How to fix it
+}
+
+// Error implements error
+func (i Issue) Error() string {
+	msg := i.Message
+	if i.Key != "" {
+		msg = i.Key + ": " + msg
+	}
+	if i.Suggestion != "" {
+		msg += " (" + i.Suggestion + ")"
+	}
+	return msg
+}
+
+// Issues checks the configuration without failing on the first problem. It
+// applies the validate struct tags of all sections, the checks spanning
+// several keys and the registered validators.
+//
+// The validate tag holds comma separated rules:
+//
+//	required     the value must be set
+//	omitempty    skip the other rules when the value is not set
+//	min=n,max=n  bounds of numbers and durations, lengths of strings and lists
+//	oneof=a b c  allowed values of a string
+func (c *Config) Issues() []Issue {
+	var issues []Issue
+	walkRules(reflect.ValueOf(c).Elem(), "", &issues)
+
+	if c.Port < 0 || c.Port > 65535 {
+		issues = append(issues, Issue{Key: "server.port", Message: fmt.Sprintf("%d is out of range", c.Port), Suggestion: "use a port between 1 and 65535"})
+	}
+	if c.GRPC != nil && c.GRPC.Enabled && c.GRPC.Port <= 0 {
+		issues = append(issues, Issue{Key: "grpc.port", Message: "is required when grpc is enabled", Suggestion: "set grpc.port, e.g. 9090"})
+	}
+	if c.GRPC != nil && c.GRPC.TLSEnabled && (c.GRPC.CertFile == "" || c.GRPC.KeyFile == "") {
+		issues = append(issues, Issue{Key: "grpc.tls_enabled", Message: "grpc.cert_file and grpc.key_file are required with TLS", Suggestion: "set both files or disable grpc.tls_enabled"})
+	}
+
+	hooksMu.RLock()
+	checks := validators
+	hooksMu.RUnlock()
+	for _, fn := range checks {
+		if err := fn(c); err != nil {
+			if issue, ok := err.(Issue); ok {
+				issues = append(issues, issue)
+				continue
+			}
+			issues = append(issues, Issue{Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// walkRules applies the validate tags of v and its nested sections
+func walkRules(v reflect.Value, prefix string, issues *[]Issue) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walkRules(v.Elem(), prefix, issues)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			walkRules(v.Index(i), fmt.Sprintf("%s[%d]", prefix, i), issues)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			walkRules(v.MapIndex(k), joinKey(prefix, k.String()), issues)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name := fieldKey(f)
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			key := joinKey(prefix, name)
+			if rules := f.Tag.Get("validate"); rules != "" {
+				if issue, ok := checkRules(v.Field(i), key, rules); !ok {
+					*issues = append(*issues, issue)
+				}
+			}
+			walkRules(v.Field(i), key, issues)
+		}
+	}
+}
+
+// fieldKey returns the yaml name of a field, lowercased field name by default
+func fieldKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// checkRules applies the rules of a validate tag to v
+func checkRules(v reflect.Value, key, rules string) (Issue, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+			break
+		}
+		v = v.Elem()
+	}
+
+	for rule := range strings.SplitSeq(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "omitempty":
+			if v.IsZero() {
+				return Issue{}, true
+			}
+		case "required":
+			if v.IsZero() {
+				return Issue{Key: key, Message: "is required", Suggestion: "set " + key}, false
+			}
+		case "min", "max":
+			n, ok := measure(v)
+			if !ok {
+				continue
+			}
+			limit, err := parseLimit(v, arg)
+			if err != nil {
+				return Issue{Key: key, Message: fmt.Sprintf("invalid rule %q: %v", rule, err)}, false
+			}
+			if name == "min" && n < limit {
+				return Issue{Key: key, Message: fmt.Sprintf("%s is below the minimum %s", describe(v), arg), Suggestion: "use at least " + arg}, false
+			}
+			if name == "max" && n > limit {
+				return Issue{Key: key, Message: fmt.Sprintf("%s is above the maximum %s", describe(v), arg), Suggestion: "use at most " + arg}, false
+			}
+		case "oneof":
+			if v.Kind() != reflect.String {
+				continue
+			}
+			allowed := strings.Fields(arg)
+			s := v.String()
+			if !containsFold(allowed, s) {
+				issue := Issue{Key: key, Message: fmt.Sprintf("%q is not supported", s), Suggestion: "use one of " + strings.Join(allowed, ", ")}
+				if match := closest(s, allowed); match != "" {
+					issue.Suggestion = fmt.Sprintf("did you mean %q?", match)
+				}
+				return issue, false
+			}
+		}
+	}
+	return Issue{}, true
+}
+
+// measure returns the number min and max compare: the value of numbers and
+// durations, the length of strings, lists and maps
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// parseLimit parses a min or max argument, durations as e.g. 1s
+func parseLimit(v reflect.Value, arg string) (float64, error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(d), err
+	}
+	return strconv.ParseFloat(arg, 64)
+}
+
+// describe formats v for messages
+func describe(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.String:
+		return fmt.Sprintf("length %d", v.Len())
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Map:
+		return fmt.Sprintf("%d entries", v.Len())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// closest returns the allowed value nearest to s, empty when none is close
+// enough to be a typo
+func closest(s string, allowed []string) string {
+	best, bestDist := "", len(s)/2+1
+	for _, a := range allowed {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(a)); d < bestDist {
+			best, bestDist = a, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance of a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// issueKeys indexes issues by key
+func issueKeys(issues []Issue) map[string]Issue {
+	keys := make(map[string]Issue, len(issues))
+	for _, issue := range issues {
+		keys[issue.Key] = issue
+	}
+	return keys
+}
+
+func TestIssuesAppliesTagRules(t *testing.T) {
+	cfg := &Config{
+		Port:     70000,
+		GRPC:     &GRPC{Enabled: true, TLSEnabled: true, CertFile: "cert.pem"},
+		Observes: &Observes{Tracer: &Tracer{Exporter: "otlpp"}, Sentry: &Sentry{SampleRate: 1.5}},
+		Logger:   &Logger{Level: 7, Format: "xml"},
+		Remote:   &Remote{Sources: []*RemoteSource{{Type: "consul", Address: "localhost:8500"}, {Type: "vault"}}},
+	}
+
+	issues := issueKeys(cfg.Issues())
+	for key, want := range map[string]string{
+		"server.port":                 "70000 is out of range",
+		"grpc.port":                   "is required when grpc is enabled",
+		"grpc.tls_enabled":            "grpc.cert_file and grpc.key_file are required with TLS",
+		"observes.tracer.exporter":    `"otlpp" is not supported`,
+		"observes.sentry.sample_rate": "1.5 is above the maximum 1",
+		"logger.level":                "7 is above the maximum 6",
+		"logger.format":               `"xml" is not supported`,
+		"remote.sources[1].address":   "is required",
+	} {
+		if issue, ok := issues[key]; !ok || issue.Message != want {
+			t.Errorf("issue of %s = %+v, want %q", key, issue, want)
+		}
+	}
+	if len(issues) != 8 {
+		t.Errorf("got %d issues, want 8: %v", len(issues), cfg.Issues())
+	}
+
+	// Close values are suggested
+	if s := issues["observes.tracer.exporter"].Suggestion; s != `did you mean "otlp"?` {
+		t.Errorf("exporter suggestion = %q", s)
+	}
+	if s := issues["logger.format"].Suggestion; s != "use one of json, text" {
+		t.Errorf("format suggestion = %q", s)
+	}
+
+	// Validate reports all issues together
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "logger.level: 7 is above the maximum 6 (use at most 6)") || !strings.Contains(err.Error(), "remote.sources[1].address") {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	if issues := (&Config{Port: 8080, Logger: &Logger{Format: "JSON"}}).Issues(); len(issues) != 0 {
+		t.Fatalf("valid config has issues %v", issues)
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	prev := validators
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		validators = prev
+		hooksMu.Unlock()
+	})
+
+	RegisterValidator(func(c *Config) error {
+		if c.AppName == "" {
+			return Issue{Key: "app_name", Message: "is required", Suggestion: "name the application"}
+		}
+		return nil
+	})
+	RegisterValidator(func(c *Config) error {
+		if c.Environment == "prod" {
+			return errors.New("use production, not prod")
+		}
+		return nil
+	})
+
+	issues := (&Config{Environment: "prod"}).Issues()
+	if len(issues) != 2 || issues[0].Key != "app_name" || issues[0].Suggestion != "name the application" ||
+		issues[1].Key != "" || issues[1].Message != "use production, not prod" {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+}
+
+func TestCheckReportsIssuesOfFile(t *testing.T) {
+	dir := writeLayers(t, map[string]string{
+		"config.yaml": "app_name: app\nserver:\n  port: 8080\nlogger:\n  output: stdot\ngrpc:\n  enabled: true\n",
+	})
+	t.Setenv(ProfileEnv, "")
+	file := filepath.Join(dir, "config.yaml")
+
+	issues, err := Check(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := issueKeys(issues)
+	if len(issues) != 2 || keys["logger.output"].Suggestion != `did you mean "stdout"?` || keys["grpc.port"].Message == "" {
+		t.Fatalf("unexpected issues %+v", issues)
+	}
+
+	if _, err := LoadConfig(file); err == nil || !strings.Contains(err.Error(), "logger.output") {
+		t.Fatalf("LoadConfig should reject the file, got %v", err)
+	}
+	if _, err := Check(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("Check should fail on an unreadable file")
+	}
+}
@@ -13,11 +13,12 @@
 // Standard keys for storing values in context.Context:
 //
 //	const (
-//	    GinContextKey = "GinContext"     // Gin context
-//	    UserKey       = "UserID"         // User ID
-//	    UsernameKey   = "Username"       // Username
-//	    TraceIDKey    = "TraceID"        // Trace ID for logging
-//	    SpanIDKey     = "SpanID"         // Span ID for tracing
+//	    GinContextKey = "gin-context" // Gin context
+//	    UserKey       = "x-md-uid"    // User ID
+//	    UsernameKey   = "x-md-uname"  // Username
+//	    TraceIDKey    = "trace_id"    // Trace ID for logging
+//	    SpanIDKey     = "span_id"     // Span ID for tracing
+//	    RequestIDKey  = "request_id"  // Request ID echoed to clients
 //	)
 //
 // Usage with ctxutil:
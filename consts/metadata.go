@@ -12,6 +12,15 @@ const GinContextKey = "gin-context"
 // TraceKey global trace id
 const TraceKey string = "x-md-trace"
 
+// TraceIDKey trace id of a request, W3C trace context compatible
+const TraceIDKey string = "trace_id"
+
+// SpanIDKey span id of a request within its trace
+const SpanIDKey string = "span_id"
+
+// RequestIDKey request id, echoed in the X-Request-ID header
+const RequestIDKey string = "request_id"
+
 // UserKey global user id
 const UserKey string = "x-md-uid"
 
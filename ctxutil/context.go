@@ -23,7 +23,9 @@ const (
 	configKey       = "config"
 	emailSender     = "email_sender"
 	storageKey      = "storage"
-	TraceIDKey      = "trace_id"
+	TraceIDKey      = consts.TraceIDKey
+	SpanIDKey       = consts.SpanIDKey
+	RequestIDKey    = consts.RequestIDKey
 	userRolesKey    = "user_roles"
 	userPermissions = "user_permissions"
 	userIsAdminKey  = "user_is_admin"
@@ -223,6 +225,32 @@ func EnsureTraceID(ctx context.Context) (context.Context, string) {
 	return SetTraceID(ctx, traceID), traceID
 }
 
+// GetSpanID gets the span id of the current request.
+func GetSpanID(ctx context.Context) string {
+	if spanID, ok := GetValue(ctx, SpanIDKey).(string); ok {
+		return spanID
+	}
+	return ""
+}
+
+// SetSpanID sets span id to context.Context and gin.Context if available.
+func SetSpanID(ctx context.Context, spanID string) context.Context {
+	return SetValue(ctx, SpanIDKey, spanID)
+}
+
+// GetRequestID gets request id from context.Context or gin.Context.
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := GetValue(ctx, RequestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// SetRequestID sets request id to context.Context and gin.Context if available.
+func SetRequestID(ctx context.Context, requestID string) context.Context {
+	return SetValue(ctx, RequestIDKey, requestID)
+}
+
 // ExtractContext extracts context from payload map safely
 func ExtractContext(payload *map[string]any) context.Context {
 	if payload == nil {
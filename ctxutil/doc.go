@@ -27,6 +27,18 @@
 //	    ginCtx.JSON(200, data)
 //	}
 //
+// # Request Tracing
+//
+// TraceMiddleware assigns each request an X-Request-ID, echoed in the
+// response, and continues the W3C traceparent of the caller with a new span,
+// or starts a trace. The IDs are stored under consts.TraceIDKey, SpanIDKey
+// and RequestIDKey, so logger calls with the request context carry them:
+//
+//	r.Use(ctxutil.TraceMiddleware())
+//	requestID := ctxutil.GetRequestID(ctx)
+//
+// TraceHandler does the same for net/http handlers.
+//
 // # Business Code Generation
 //
 // Generate unique business tracking codes:
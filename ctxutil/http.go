@@ -302,30 +302,31 @@ func ParseUserAgent(userAgent string) *UserAgentInfo {
 		}
 	}
 
-	// Detect operating system
-	if strings.Contains(ua, "windows") {
+	// Detect operating system, mobile systems first as their user agents
+	// also mention Linux and Mac OS
+	if strings.Contains(ua, "android") {
+		info.OS = "Android"
+	} else if strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") {
+		info.OS = "iOS"
+	} else if strings.Contains(ua, "windows") {
 		info.OS = "Windows"
 	} else if strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os") {
 		info.OS = "macOS"
 	} else if strings.Contains(ua, "linux") {
 		info.OS = "Linux"
-	} else if strings.Contains(ua, "android") {
-		info.OS = "Android"
-	} else if strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") {
-		info.OS = "iOS"
 	}
 
-	// Detect browser
-	if strings.Contains(ua, "edge") {
+	// Detect browser, Chromium based browsers before Chrome
+	if strings.Contains(ua, "edge") || strings.Contains(ua, "edg/") {
 		info.Browser = "Microsoft Edge"
-	} else if strings.Contains(ua, "chrome") {
+	} else if strings.Contains(ua, "opera") || strings.Contains(ua, "opr/") {
+		info.Browser = "Opera"
+	} else if strings.Contains(ua, "chrome") || strings.Contains(ua, "crios") {
 		info.Browser = "Google Chrome"
-	} else if strings.Contains(ua, "firefox") {
+	} else if strings.Contains(ua, "firefox") || strings.Contains(ua, "fxios") {
 		info.Browser = "Mozilla Firefox"
-	} else if strings.Contains(ua, "safari") && !strings.Contains(ua, "chrome") {
+	} else if strings.Contains(ua, "safari") {
 		info.Browser = "Safari"
-	} else if strings.Contains(ua, "opera") {
-		info.Browser = "Opera"
 	}
 
 	return info
@@ -0,0 +1,152 @@
+package ctxutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/utils/uuid"
+)
+
+// Trace headers
+const (
+	// HeaderRequestID carries the request ID, echoed in responses
+	HeaderRequestID = "X-Request-ID"
+	// HeaderTraceID carries a trace ID from clients without W3C trace context
+	HeaderTraceID = "X-Trace-Id"
+	// HeaderTraceParent is the W3C trace context header
+	HeaderTraceParent = "traceparent"
+)
+
+// maxRequestIDLen bounds client supplied request IDs, which end up in logs
+const maxRequestIDLen = 128
+
+// TraceContext is the trace state of a request
+type TraceContext struct {
+	RequestID    string
+	TraceID      string // 32 hex digits
+	SpanID       string // 16 hex digits, the span of this request
+	ParentSpanID string // Span of the caller, empty at the root
+	Sampled      bool
+}
+
+// TraceParent returns the traceparent header value of the request span, to
+// propagate to downstream calls
+func (t TraceContext) TraceParent() string {
+	flags := "00"
+	if t.Sampled {
+		flags = "01"
+	}
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + flags
+}
+
+// ParseTraceParent parses a W3C traceparent header, reporting false for
+// malformed values and the invalid all-zero IDs
+func ParseTraceParent(header string) (traceID, parentID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false, false
+	}
+	// Version 00 has exactly four fields, later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false, false
+	}
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !isHex(parts[0]) || len(traceID) != 32 || !isHex(traceID) || len(parentID) != 16 || !isHex(parentID) ||
+		len(flags) != 2 || !isHex(flags) {
+		return "", "", false, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", "", false, false
+	}
+	b, _ := hex.DecodeString(flags)
+	return traceID, parentID, b[0]&1 == 1, true
+}
+
+// NewTraceContext derives the trace state of a request from its headers: the
+// trace of an incoming traceparent is continued with a new span, X-Trace-Id
+// is honored without one, and missing IDs are generated.
+func NewTraceContext(h http.Header) TraceContext {
+	t := TraceContext{
+		RequestID: h.Get(HeaderRequestID),
+		SpanID:    randomHex(8),
+		Sampled:   true,
+	}
+	if !validRequestID(t.RequestID) {
+		t.RequestID = uuid.NewString()
+	}
+	if traceID, parentID, sampled, ok := ParseTraceParent(h.Get(HeaderTraceParent)); ok {
+		t.TraceID, t.ParentSpanID, t.Sampled = traceID, parentID, sampled
+	} else if id := strings.ToLower(strings.ReplaceAll(h.Get(HeaderTraceID), "-", "")); len(id) == 32 && isHex(id) && strings.Trim(id, "0") != "" {
+		t.TraceID = id
+	} else {
+		t.TraceID = randomHex(16)
+	}
+	return t
+}
+
+// WithTraceContext stores the IDs of t in ctx
+func WithTraceContext(ctx context.Context, t TraceContext) context.Context {
+	ctx = SetTraceID(ctx, t.TraceID)
+	ctx = SetSpanID(ctx, t.SpanID)
+	return SetRequestID(ctx, t.RequestID)
+}
+
+// TraceMiddleware is gin middleware assigning each request a request ID, a
+// trace ID and a span ID. They are stored in the request context, so logger
+// calls with it carry them, and the request ID is echoed in X-Request-ID.
+//
+//	r.Use(ctxutil.TraceMiddleware())
+func TraceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := NewTraceContext(c.Request.Header)
+		c.Set(TraceIDKey, t.TraceID)
+		c.Set(SpanIDKey, t.SpanID)
+		c.Set(RequestIDKey, t.RequestID)
+		c.Request = c.Request.WithContext(WithTraceContext(c.Request.Context(), t))
+		c.Header(HeaderRequestID, t.RequestID)
+		c.Next()
+	}
+}
+
+// TraceHandler is TraceMiddleware for net/http handlers
+func TraceHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := NewTraceContext(r.Header)
+		w.Header().Set(HeaderRequestID, t.RequestID)
+		next.ServeHTTP(w, r.WithContext(WithTraceContext(r.Context(), t)))
+	})
+}
+
+// validRequestID accepts client request IDs of printable ASCII within
+// maxRequestIDLen, so they cannot forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,129 @@
+package ctxutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	for _, tc := range []struct {
+		header  string
+		sampled bool
+		ok      bool
+	}{
+		{"00-" + testTraceID + "-" + testSpanID + "-01", true, true},
+		{" 00-" + testTraceID + "-" + testSpanID + "-00 ", false, true},
+		// Later versions may append fields
+		{"01-" + testTraceID + "-" + testSpanID + "-03-extra", true, true},
+		{"00-" + testTraceID + "-" + testSpanID + "-01-extra", false, false},
+		{"ff-" + testTraceID + "-" + testSpanID + "-01", false, false},
+		{"00-" + strings.Repeat("0", 32) + "-" + testSpanID + "-01", false, false},
+		{"00-" + testTraceID + "-" + strings.Repeat("0", 16) + "-01", false, false},
+		{"00-" + strings.ToUpper(testTraceID) + "-" + testSpanID + "-01", false, false},
+		{"00-" + testTraceID[:30] + "-" + testSpanID + "-01", false, false},
+		{"00-" + testTraceID + "-" + testSpanID + "-1", false, false},
+		{"", false, false},
+	} {
+		traceID, parentID, sampled, ok := ParseTraceParent(tc.header)
+		if ok != tc.ok || sampled != tc.sampled {
+			t.Errorf("ParseTraceParent(%q) = %v, %v, want %v, %v", tc.header, sampled, ok, tc.sampled, tc.ok)
+			continue
+		}
+		if ok && (traceID != testTraceID || parentID != testSpanID) {
+			t.Errorf("ParseTraceParent(%q) = %s, %s", tc.header, traceID, parentID)
+		}
+	}
+}
+
+func TestNewTraceContext(t *testing.T) {
+	// An incoming trace is continued with a new span
+	h := http.Header{}
+	h.Set(HeaderTraceParent, "00-"+testTraceID+"-"+testSpanID+"-00")
+	h.Set(HeaderTraceID, "ignored-with-traceparent")
+	h.Set(HeaderRequestID, "req-1")
+	tc := NewTraceContext(h)
+	if tc.TraceID != testTraceID || tc.ParentSpanID != testSpanID || tc.Sampled || tc.RequestID != "req-1" {
+		t.Fatalf("trace context = %+v", tc)
+	}
+	if len(tc.SpanID) != 16 || tc.SpanID == testSpanID {
+		t.Fatalf("span ID = %q, want a new span", tc.SpanID)
+	}
+	if want := "00-" + testTraceID + "-" + tc.SpanID + "-00"; tc.TraceParent() != want {
+		t.Fatalf("TraceParent() = %q, want %q", tc.TraceParent(), want)
+	}
+
+	// X-Trace-Id is honored without traceparent, e.g. as a UUID
+	h = http.Header{}
+	h.Set(HeaderTraceID, "4BF92F35-77B3-4DA6-A3CE-929D0E0E4736")
+	if tc := NewTraceContext(h); tc.TraceID != testTraceID || tc.ParentSpanID != "" || !tc.Sampled {
+		t.Fatalf("trace context from X-Trace-Id = %+v", tc)
+	}
+
+	// Missing and invalid IDs are generated
+	h = http.Header{}
+	h.Set(HeaderRequestID, "forged\nlog line")
+	h.Set(HeaderTraceID, "not-a-trace")
+	tc = NewTraceContext(h)
+	if tc.RequestID == "" || strings.ContainsAny(tc.RequestID, "\n ") {
+		t.Fatalf("request ID = %q, want a generated one", tc.RequestID)
+	}
+	if len(tc.TraceID) != 32 || !isHex(tc.TraceID) || tc.ParentSpanID != "" {
+		t.Fatalf("trace context = %+v, want a generated root trace", tc)
+	}
+	h.Set(HeaderRequestID, strings.Repeat("a", maxRequestIDLen+1))
+	if tc := NewTraceContext(h); len(tc.RequestID) > maxRequestIDLen {
+		t.Fatal("overlong request ID was kept")
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TraceMiddleware())
+
+	var got TraceContext
+	r.GET("/ping", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		got = TraceContext{RequestID: GetRequestID(ctx), TraceID: GetTraceID(ctx), SpanID: GetSpanID(ctx)}
+		if id, _ := c.Get(TraceIDKey); id != got.TraceID {
+			t.Errorf("gin context trace ID = %v, want %s", id, got.TraceID)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(HeaderRequestID, "req-1")
+	req.Header.Set(HeaderTraceParent, "00-"+testTraceID+"-"+testSpanID+"-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got.RequestID != "req-1" || got.TraceID != testTraceID || len(got.SpanID) != 16 {
+		t.Fatalf("request context carries %+v", got)
+	}
+	if id := w.Header().Get(HeaderRequestID); id != "req-1" {
+		t.Fatalf("echoed request ID = %q", id)
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	var traceID, requestID string
+	h := TraceHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, requestID = GetTraceID(r.Context()), GetRequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(traceID) != 32 || requestID == "" || w.Header().Get(HeaderRequestID) != requestID {
+		t.Fatalf("trace ID %q, request ID %q, echoed %q", traceID, requestID, w.Header().Get(HeaderRequestID))
+	}
+}
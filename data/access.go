@@ -61,6 +61,16 @@ func (d *Data) GetMongoManager() any {
 	return d.Conn.MGM
 }
 
+func (d *Data) GetClickHouse() any {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed || d.Conn == nil {
+		return nil
+	}
+	return d.Conn.CH
+}
+
 func (d *Data) GetElasticsearch() any {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
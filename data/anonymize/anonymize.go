@@ -0,0 +1,225 @@
+package anonymize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrNoSalt is returned for a plan without a salt, hashes of unsalted values
+// can be reversed by hashing candidate values
+var ErrNoSalt = errors.New("anonymize: plan has no salt")
+
+// Plan lists the tables to anonymize and the transform of their fields
+type Plan struct {
+	// Salt keys the hashes and fake values; ${VAR} references are expanded
+	Salt      string  `json:"salt" yaml:"salt"`
+	BatchSize int     `json:"batch_size,omitempty" yaml:"batch_size,omitempty"` // Defaults to 1000
+	Tables    []Table `json:"tables" yaml:"tables"`
+}
+
+// Table is a table or collection and the transforms of its fields
+type Table struct {
+	Name string `json:"name" yaml:"name"`
+	// Key is a unique, sortable column reading the table in batches, defaults
+	// to "id", or "_id" for MongoDB. It cannot be transformed.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Where restricts the rows, a SQL condition or a MongoDB extended JSON filter
+	Where string `json:"where,omitempty" yaml:"where,omitempty"`
+	// Fields maps columns to transforms, e.g. {"email": "fake:email"}
+	Fields map[string]string `json:"fields" yaml:"fields"`
+}
+
+// Columns returns the transformed columns, sorted
+func (t *Table) Columns() []string {
+	cols := make([]string, 0, len(t.Fields))
+	for c := range t.Fields {
+		cols = append(cols, c)
+	}
+	slices.Sort(cols)
+	return cols
+}
+
+// Row is a row read from a store, with the original key and the values of the
+// transformed columns
+type Row struct {
+	Key    any
+	Values map[string]any
+}
+
+// Store reads and rewrites the rows of tables or collections
+type Store interface {
+	// Scan returns up to limit rows of t ordered by key, after the key after,
+	// nil for the first batch
+	Scan(ctx context.Context, t *Table, after any, limit int) ([]Row, error)
+	// Update writes the values of rows, identified by their key, atomically
+	// where the store supports it
+	Update(ctx context.Context, t *Table, rows []Row) error
+}
+
+// Options configures an anonymizer
+type Options struct {
+	BatchSize int  // Overrides the plan batch size
+	DryRun    bool // Reads and transforms rows without writing them
+	// Transforms adds named transforms usable in plans, e.g. {"iban": maskIBAN}
+	Transforms map[string]TransformFunc
+	// Progress is called after each batch with the rows done in the table
+	Progress func(table string, rows int64)
+}
+
+// Result is the outcome of anonymizing a table
+type Result struct {
+	Table   string `json:"table"`
+	Rows    int64  `json:"rows"`
+	Batches int    `json:"batches"`
+}
+
+// Anonymizer applies a plan to a store
+type Anonymizer struct {
+	store      Store
+	tables     []Table
+	transforms [][]TransformFunc // Per table, in the order of Columns
+	batchSize  int
+	opts       Options
+}
+
+// LoadPlan reads a YAML or JSON plan, by the file extension
+func LoadPlan(path string) (*Plan, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(b, plan)
+	} else {
+		err = yaml.Unmarshal(b, plan)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %v", path, err)
+	}
+	return plan, nil
+}
+
+// New checks the plan and creates an anonymizer for store
+func New(store Store, plan *Plan, opts ...Options) (*Anonymizer, error) {
+	if store == nil || plan == nil {
+		return nil, errors.New("anonymize: store and plan are required")
+	}
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	salt := os.ExpandEnv(plan.Salt)
+	if salt == "" {
+		return nil, ErrNoSalt
+	}
+
+	a := &Anonymizer{
+		store:     store,
+		tables:    slices.Clone(plan.Tables),
+		batchSize: o.BatchSize,
+		opts:      o,
+	}
+	if a.batchSize <= 0 {
+		a.batchSize = plan.BatchSize
+	}
+	if a.batchSize <= 0 {
+		a.batchSize = 1000
+	}
+
+	for i := range a.tables {
+		t := &a.tables[i]
+		if t.Name == "" {
+			return nil, fmt.Errorf("anonymize: table %d has no name", i)
+		}
+		if len(t.Fields) == 0 {
+			return nil, fmt.Errorf("anonymize: table %s has no fields", t.Name)
+		}
+		keys := []string{t.Key}
+		if t.Key == "" {
+			keys = []string{"id", "_id"}
+		}
+		for _, key := range keys {
+			if _, ok := t.Fields[key]; ok {
+				return nil, fmt.Errorf("anonymize: table %s: key column %s cannot be transformed", t.Name, key)
+			}
+		}
+
+		cols := t.Columns()
+		fns := make([]TransformFunc, len(cols))
+		for j, col := range cols {
+			fn, err := parseTransform(t.Fields[col], []byte(salt), o.Transforms)
+			if err != nil {
+				return nil, fmt.Errorf("anonymize: %s.%s: %w", t.Name, col, err)
+			}
+			fns[j] = fn
+		}
+		a.transforms = append(a.transforms, fns)
+	}
+	return a, nil
+}
+
+// Run anonymizes the tables in plan order. It stops at the first error,
+// returning the results so far; batches written before it stay written.
+func (a *Anonymizer) Run(ctx context.Context) ([]Result, error) {
+	results := make([]Result, 0, len(a.tables))
+	for i := range a.tables {
+		res, err := a.table(ctx, &a.tables[i], a.transforms[i])
+		results = append(results, res)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// table anonymizes a table batch by batch
+func (a *Anonymizer) table(ctx context.Context, t *Table, fns []TransformFunc) (Result, error) {
+	res := Result{Table: t.Name}
+	cols := t.Columns()
+
+	var after any
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		rows, err := a.store.Scan(ctx, t, after, a.batchSize)
+		if err != nil {
+			return res, fmt.Errorf("failed to read %s: %v", t.Name, err)
+		}
+		if len(rows) == 0 {
+			return res, nil
+		}
+
+		for _, row := range rows {
+			for j, col := range cols {
+				row.Values[col] = fns[j](row.Values[col])
+			}
+		}
+		if !a.opts.DryRun {
+			if err := a.store.Update(ctx, t, rows); err != nil {
+				return res, fmt.Errorf("failed to update %s: %v", t.Name, err)
+			}
+		}
+
+		res.Rows += int64(len(rows))
+		res.Batches++
+		if a.opts.Progress != nil {
+			a.opts.Progress(t.Name, res.Rows)
+		}
+		if len(rows) < a.batchSize {
+			return res, nil
+		}
+		after = rows[len(rows)-1].Key
+	}
+}
@@ -0,0 +1,203 @@
+package anonymize
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// memStore holds tables as rows keyed by int
+type memStore struct {
+	tables  map[string]map[int]map[string]any
+	updates int
+}
+
+func (m *memStore) Scan(_ context.Context, t *Table, after any, limit int) ([]Row, error) {
+	rows := m.tables[t.Name]
+	keys := make([]int, 0, len(rows))
+	for k := range rows {
+		if after == nil || k > after.(int) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	out := make([]Row, len(keys))
+	for i, k := range keys {
+		values := make(map[string]any)
+		for c := range t.Fields {
+			values[c] = rows[k][c]
+		}
+		out[i] = Row{Key: k, Values: values}
+	}
+	return out, nil
+}
+
+func (m *memStore) Update(_ context.Context, t *Table, rows []Row) error {
+	m.updates++
+	for _, row := range rows {
+		for c, v := range row.Values {
+			m.tables[t.Name][row.Key.(int)][c] = v
+		}
+	}
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	store := &memStore{tables: map[string]map[int]map[string]any{
+		"users": {
+			1: {"email": "ann@corp.com", "name": "Ann", "phone": "+44 20 7946 0958", "password": "x", "note": nil},
+			2: {"email": "bob@corp.com", "name": "Bob", "phone": "+44 20 7946 0959", "password": "y", "note": "vip"},
+			3: {"email": "cid@corp.com", "name": "Cid", "phone": "", "password": "z", "note": nil},
+		},
+		"orders": {
+			10: {"customer_email": "bob@corp.com"},
+			11: {"customer_email": []byte("ann@corp.com")},
+		},
+	}}
+	plan := &Plan{
+		Salt: "s3cret",
+		Tables: []Table{
+			{Name: "users", Fields: map[string]string{
+				"email": "fake:email", "name": "fake:name", "phone": "mask", "password": "null", "note": "hash",
+			}},
+			{Name: "orders", Fields: map[string]string{"customer_email": "fake:email"}},
+		},
+	}
+
+	a, err := New(store, plan, Options{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := a.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Rows != 3 || results[0].Batches != 2 || results[1].Rows != 2 {
+		t.Errorf("results = %+v", results)
+	}
+
+	users, orders := store.tables["users"], store.tables["orders"]
+	email := regexp.MustCompile(`^[a-z]+\.[a-z]+\.[0-9a-f]{8}@example\.com$`)
+	for k, u := range users {
+		if !email.MatchString(u["email"].(string)) {
+			t.Errorf("user %d email = %v", k, u["email"])
+		}
+		if u["password"] != nil {
+			t.Errorf("user %d password = %v, want NULL", k, u["password"])
+		}
+	}
+	if users[1]["email"] == users[2]["email"] {
+		t.Error("different emails got the same fake")
+	}
+	// The same value gets the same fake in every table
+	if orders[10]["customer_email"] != users[2]["email"] || orders[11]["customer_email"] != users[1]["email"] {
+		t.Errorf("order emails %v, %v do not match user emails %v, %v",
+			orders[10]["customer_email"], orders[11]["customer_email"], users[2]["email"], users[1]["email"])
+	}
+	if users[1]["phone"] != "************0958" || users[3]["phone"] != "" {
+		t.Errorf("masked phones = %q, %q", users[1]["phone"], users[3]["phone"])
+	}
+	if users[1]["note"] != nil || len(users[2]["note"].(string)) != 32 {
+		t.Errorf("hashed notes = %v, %v", users[1]["note"], users[2]["note"])
+	}
+
+	// Another run with the same salt is stable on the original values
+	again, _ := New(&memStore{tables: map[string]map[int]map[string]any{
+		"orders": {1: {"customer_email": "bob@corp.com"}},
+	}}, &Plan{Salt: "s3cret", Tables: plan.Tables[1:]})
+	fn := again.transforms[0][0]
+	if fn("bob@corp.com") != users[2]["email"] {
+		t.Error("fake email differs between runs with the same salt")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	store := &memStore{tables: map[string]map[int]map[string]any{
+		"users": {1: {"email": "ann@corp.com"}},
+	}}
+	a, err := New(store, &Plan{Salt: "s", Tables: []Table{{Name: "users", Fields: map[string]string{"email": "null"}}}},
+		Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := a.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Rows != 1 || store.updates != 0 || store.tables["users"][1]["email"] != "ann@corp.com" {
+		t.Errorf("dry run wrote rows: results = %+v, updates = %d", results, store.updates)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	store := &memStore{}
+	tests := []struct {
+		name string
+		plan *Plan
+		want string
+	}{
+		{"no salt", &Plan{Tables: []Table{{Name: "t", Fields: map[string]string{"a": "null"}}}}, ErrNoSalt.Error()},
+		{"unknown transform", &Plan{Salt: "s", Tables: []Table{{Name: "t", Fields: map[string]string{"a": "shuffle"}}}}, `unknown transform "shuffle"`},
+		{"unknown fake", &Plan{Salt: "s", Tables: []Table{{Name: "t", Fields: map[string]string{"a": "fake:ssn"}}}}, `unknown fake kind "ssn"`},
+		{"key transformed", &Plan{Salt: "s", Tables: []Table{{Name: "t", Fields: map[string]string{"id": "hash"}}}}, "key column id cannot be transformed"},
+		{"no fields", &Plan{Salt: "s", Tables: []Table{{Name: "t"}}}, "has no fields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(store, tt.plan)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	_, err := New(store, &Plan{Salt: "s", Tables: []Table{{Name: "t", Fields: map[string]string{"iban": "iban"}}}},
+		Options{Transforms: map[string]TransformFunc{"iban": func(any) any { return "XX00" }}})
+	if err != nil {
+		t.Errorf("custom transform: %v", err)
+	}
+	if !errors.Is(func() error { _, err := New(store, &Plan{}); return err }(), ErrNoSalt) {
+		t.Error("empty plan should fail with ErrNoSalt")
+	}
+}
+
+func TestLoadPlan(t *testing.T) {
+	t.Setenv("ANONYMIZE_TEST_SALT", "from-env")
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	err := os.WriteFile(path, []byte(`
+salt: ${ANONYMIZE_TEST_SALT}
+batch_size: 50
+tables:
+  - name: users
+    where: deleted_at IS NULL
+    fields:
+      email: fake:email
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.BatchSize != 50 || len(plan.Tables) != 1 || plan.Tables[0].Fields["email"] != "fake:email" {
+		t.Errorf("plan = %+v", plan)
+	}
+	a, err := New(&memStore{}, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.batchSize != 50 {
+		t.Errorf("batch size = %d, want 50", a.batchSize)
+	}
+}
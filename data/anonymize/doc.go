@@ -0,0 +1,39 @@
+// Package anonymize rewrites personal data in a copy of a database, so staging
+// environments can run on production-shaped data safely.
+//
+// A plan lists the tables, or collections, and the transformation of each
+// field:
+//
+//	salt: ${ANONYMIZE_SALT}
+//	batch_size: 500
+//	tables:
+//	  - name: users
+//	    key: id
+//	    fields:
+//	      email: fake:email
+//	      name: fake:name
+//	      phone: mask
+//	      tax_number: hash
+//	      password_hash: "null"
+//	  - name: orders
+//	    where: created_at > '2024-01-01'
+//	    fields:
+//	      customer_email: fake:email
+//
+// Transforms are "null", "hash", "mask", "set:<value>" and "fake:<kind>" with
+// the kinds of FakeKinds; Options.Transforms adds others. Hashes and fake
+// values are derived from the salted HMAC of the original value, so a value
+// maps to the same output in every table and run with the same salt: columns
+// joining tables, e.g. users.email and orders.customer_email, stay consistent.
+// Key columns are never rewritten, foreign keys referencing them stay valid.
+//
+// Rows are read in batches ordered by key and each batch is written in one
+// transaction:
+//
+//	plan, err := anonymize.LoadPlan("anonymize.yaml")
+//	a, err := anonymize.New(anonymize.NewSQLStore(db, "postgres"), plan)
+//	results, err := a.Run(ctx)
+//
+// The mongodb driver provides a Store for collections. The ncore command runs
+// a plan against data.database.master: ncore anonymize -plan anonymize.yaml.
+package anonymize
@@ -0,0 +1,132 @@
+package anonymize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// SQLStore reads and rewrites tables of a database/sql database
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqlq.Dialect
+	mysql   bool
+}
+
+// NewSQLStore creates a store for db, opened with driver, e.g. "postgres"
+func NewSQLStore(db *sql.DB, driver string) *SQLStore {
+	return &SQLStore{db: db, dialect: sqlq.DialectOf(driver), mysql: driver == "mysql"}
+}
+
+// Scan implements Store
+func (s *SQLStore) Scan(ctx context.Context, t *Table, after any, limit int) ([]Row, error) {
+	key := s.key(t)
+	cols := t.Columns()
+
+	var (
+		conds []string
+		args  []any
+	)
+	if t.Where != "" {
+		conds = append(conds, "("+t.Where+")")
+	}
+	if after != nil {
+		conds = append(conds, s.quote(key)+" > ?")
+		args = append(args, after)
+	}
+
+	var q strings.Builder
+	q.WriteString("SELECT " + s.quote(key))
+	for _, c := range cols {
+		q.WriteString(", " + s.quote(c))
+	}
+	q.WriteString(" FROM " + s.quote(t.Name))
+	if len(conds) > 0 {
+		q.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+	fmt.Fprintf(&q, " ORDER BY %s LIMIT %d", s.quote(key), limit)
+
+	rows, err := s.db.QueryContext(ctx, sqlq.Rebind(s.dialect, q.String()), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Row
+	for rows.Next() {
+		values := make([]any, len(cols)+1)
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := Row{Key: values[0], Values: make(map[string]any, len(cols))}
+		for i, c := range cols {
+			row.Values[c] = values[i+1]
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// Update implements Store, writing rows in one transaction
+func (s *SQLStore) Update(ctx context.Context, t *Table, rows []Row) error {
+	cols := t.Columns()
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = s.quote(c) + " = ?"
+	}
+	query := sqlq.Rebind(s.dialect, fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?",
+		s.quote(t.Name), strings.Join(sets, ", "), s.quote(s.key(t))))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	args := make([]any, len(cols)+1)
+	for _, row := range rows {
+		for i, c := range cols {
+			args[i] = row.Values[c]
+		}
+		args[len(cols)] = row.Key
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// key returns the key column of t
+func (s *SQLStore) key(t *Table) string {
+	if t.Key == "" {
+		return "id"
+	}
+	return t.Key
+}
+
+// quote quotes an identifier, each part of a qualified one, e.g. public.users
+func (s *SQLStore) quote(name string) string {
+	q := `"`
+	if s.mysql {
+		q = "`"
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
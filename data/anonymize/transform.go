@@ -0,0 +1,157 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"unicode/utf8"
+)
+
+// TransformFunc replaces the value of a field. Values are read as the driver
+// returns them, e.g. string, []byte or int64; nil is a NULL.
+type TransformFunc func(v any) any
+
+// FakeKinds are the kinds of fake:<kind> transforms
+var FakeKinds = []string{
+	"email", "name", "first_name", "last_name", "username",
+	"phone", "address", "city", "company", "ip", "uuid",
+}
+
+// parseTransform returns the transform of a plan spec
+func parseTransform(spec string, salt []byte, custom map[string]TransformFunc) (TransformFunc, error) {
+	if fn, ok := custom[spec]; ok {
+		return fn, nil
+	}
+
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "null":
+		return func(any) any { return nil }, nil
+	case "set":
+		return func(any) any { return arg }, nil
+	case "hash":
+		return keepNull(func(s string) any {
+			return hex.EncodeToString(digest(salt, "hash", s)[:16])
+		}), nil
+	case "mask":
+		return keepNull(func(s string) any { return mask(s) }), nil
+	case "fake":
+		gen, ok := fakers[arg]
+		if !ok {
+			return nil, fmt.Errorf("unknown fake kind %q, want one of %s", arg, strings.Join(FakeKinds, ", "))
+		}
+		return keepNull(func(s string) any {
+			sum := digest(salt, arg, s)
+			r := rand.New(rand.NewPCG(binary.BigEndian.Uint64(sum), binary.BigEndian.Uint64(sum[8:])))
+			return gen(r, sum)
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown transform %q", spec)
+}
+
+// keepNull applies fn to the text of non-NULL values
+func keepNull(fn func(string) any) TransformFunc {
+	return func(v any) any {
+		switch x := v.(type) {
+		case nil:
+			return nil
+		case string:
+			return fn(x)
+		case []byte:
+			return fn(string(x))
+		default:
+			return fn(fmt.Sprint(x))
+		}
+	}
+}
+
+// digest is the salted HMAC of a value, scoped to a transform so a hash does
+// not reveal the fake values of the same input
+func digest(salt []byte, scope, s string) []byte {
+	h := hmac.New(sha256.New, salt)
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// mask replaces all but the last four characters with *, all of them when the
+// value is eight characters or shorter
+func mask(s string) string {
+	n := utf8.RuneCountInString(s)
+	keep := 0
+	if n > 8 {
+		keep = 4
+	}
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if i < n-keep {
+			b.WriteByte('*')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var (
+	firstNames = []string{
+		"Alex", "Blake", "Casey", "Dana", "Eden", "Finley", "Gray", "Harper",
+		"Indy", "Jordan", "Kai", "Logan", "Morgan", "Noa", "Oakley", "Parker",
+		"Quinn", "Riley", "Sage", "Taylor", "Umi", "Val", "Wren", "Yael",
+	}
+	lastNames = []string{
+		"Adams", "Brooks", "Carter", "Diaz", "Ellis", "Foster", "Garcia", "Hughes",
+		"Ito", "James", "Kim", "Lopez", "Miller", "Nguyen", "Owens", "Patel",
+		"Reed", "Silva", "Turner", "Usman", "Vega", "Wang", "Young", "Zhang",
+	}
+	streets   = []string{"Oak", "Maple", "Cedar", "Elm", "Pine", "Birch", "Willow", "Lake", "Hill", "Park"}
+	cities    = []string{"Springfield", "Riverton", "Fairview", "Lakeside", "Greenville", "Kingston", "Milton", "Ashford"}
+	companies = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay"}
+)
+
+// fakers generate fake values from a seeded source and the value digest,
+// which makes emails, usernames and UUIDs unique in practice
+var fakers = map[string]func(r *rand.Rand, sum []byte) string{
+	"email": func(r *rand.Rand, sum []byte) string {
+		return strings.ToLower(pick(r, firstNames)+"."+pick(r, lastNames)) + "." + hex.EncodeToString(sum[16:20]) + "@example.com"
+	},
+	"name": func(r *rand.Rand, _ []byte) string {
+		return pick(r, firstNames) + " " + pick(r, lastNames)
+	},
+	"first_name": func(r *rand.Rand, _ []byte) string { return pick(r, firstNames) },
+	"last_name":  func(r *rand.Rand, _ []byte) string { return pick(r, lastNames) },
+	"username": func(r *rand.Rand, sum []byte) string {
+		return strings.ToLower(pick(r, firstNames)) + "_" + hex.EncodeToString(sum[16:20])
+	},
+	"phone": func(r *rand.Rand, _ []byte) string {
+		// 555-01xx numbers are reserved for fiction
+		return fmt.Sprintf("+1-%03d-555-01%02d", 200+r.IntN(800), r.IntN(100))
+	},
+	"address": func(r *rand.Rand, _ []byte) string {
+		return fmt.Sprintf("%d %s Street", 1+r.IntN(9999), pick(r, streets))
+	},
+	"city": func(r *rand.Rand, _ []byte) string { return pick(r, cities) },
+	"company": func(r *rand.Rand, _ []byte) string {
+		return pick(r, companies) + " " + pick(r, []string{"Inc", "LLC", "Ltd", "Group"})
+	},
+	"ip": func(r *rand.Rand, _ []byte) string {
+		// 198.18.0.0/15 is reserved for benchmarking
+		return fmt.Sprintf("198.%d.%d.%d", 18+r.IntN(2), r.IntN(256), 1+r.IntN(254))
+	},
+	"uuid": func(_ *rand.Rand, sum []byte) string {
+		b := make([]byte, 16)
+		copy(b, sum[16:])
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+	},
+}
+
+func pick(r *rand.Rand, words []string) string {
+	return words[r.IntN(len(words))]
+}
@@ -0,0 +1,241 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ncobase/ncore/data/sqlq"
+	"github.com/ncobase/ncore/data/sqlscan"
+)
+
+// Dialect selects the upsert syntax
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres" // INSERT ... ON CONFLICT (...) DO UPDATE
+	MySQL    Dialect = "mysql"    // INSERT ... ON DUPLICATE KEY UPDATE
+	SQLite   Dialect = "sqlite"   // INSERT ... ON CONFLICT (...) DO UPDATE
+)
+
+// maxParams is the placeholder limit per statement
+var maxParams = map[Dialect]int{
+	Postgres: 65535,
+	MySQL:    65535,
+	SQLite:   32766,
+}
+
+// DialectFor returns the dialect of a database/sql driver name
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return Postgres, nil
+	case "mysql":
+		return MySQL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported bulk driver: %s", driver)
+	}
+}
+
+// Execer runs statements, implemented by *sql.DB, *sql.Tx and *sql.Conn
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Options configures a bulk write
+type Options struct {
+	Dialect Dialect
+	Table   string
+	Columns []string // Inserted columns, defaults to the struct columns for Structs
+	// Conflict lists the unique key columns. Empty performs a plain insert.
+	Conflict []string
+	// Update lists the columns overwritten on conflict, defaults to all non-conflict columns
+	Update    []string
+	DoNothing bool // Keep existing rows on conflict
+	BatchSize int  // Rows per statement, defaults to 500 and reduced to fit placeholder limits
+}
+
+// Upsert writes rows in batches and returns the total affected count.
+// Batches are separate statements, pass a *sql.Tx to make them atomic.
+// MySQL counts an updated row as 2 affected rows.
+func Upsert(ctx context.Context, db Execer, opts Options, rows [][]any) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if err := opts.validate(); err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		if len(row) != len(opts.Columns) {
+			return 0, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(opts.Columns))
+		}
+	}
+
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = 500
+	}
+	batch = min(batch, maxParams[opts.Dialect]/len(opts.Columns))
+
+	var total int64
+	for chunk := range slices.Chunk(rows, batch) {
+		query, args := opts.build(chunk)
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return total, fmt.Errorf("bulk upsert into %s failed: %v", opts.Table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Insert writes rows in batches without conflict handling
+func Insert(ctx context.Context, db Execer, opts Options, rows [][]any) (int64, error) {
+	opts.Conflict, opts.Update, opts.DoNothing = nil, nil, false
+	return Upsert(ctx, db, opts, rows)
+}
+
+// Structs upserts items, mapping fields to columns with the data/sqlscan rules
+func Structs[T any](ctx context.Context, db Execer, opts Options, items []T) (int64, error) {
+	columns := sqlscan.Columns(reflect.TypeFor[T]())
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("%s has no mapped fields", reflect.TypeFor[T]())
+	}
+
+	index := make(map[string][]int, len(columns))
+	for _, c := range columns {
+		index[c.Name] = c.Index
+	}
+	if len(opts.Columns) == 0 {
+		for _, c := range columns {
+			opts.Columns = append(opts.Columns, c.Name)
+		}
+	}
+
+	paths := make([][]int, len(opts.Columns))
+	for i, name := range opts.Columns {
+		path, ok := index[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("%s has no field for column %s", reflect.TypeFor[T](), name)
+		}
+		paths[i] = path
+	}
+
+	rows := make([][]any, len(items))
+	for i := range items {
+		v := reflect.Indirect(reflect.ValueOf(&items[i]).Elem())
+		if !v.IsValid() {
+			return 0, fmt.Errorf("item %d is nil", i)
+		}
+		row := make([]any, len(paths))
+		for j, path := range paths {
+			if f, err := v.FieldByIndexErr(path); err == nil {
+				row[j] = f.Interface()
+			}
+		}
+		rows[i] = row
+	}
+	return Upsert(ctx, db, opts, rows)
+}
+
+// validate checks identifiers and applies defaults
+func (o *Options) validate() error {
+	switch o.Dialect {
+	case Postgres, MySQL, SQLite:
+	default:
+		return fmt.Errorf("unsupported bulk dialect: %q", o.Dialect)
+	}
+	if len(o.Columns) == 0 {
+		return fmt.Errorf("bulk columns are required")
+	}
+	for _, name := range slices.Concat([]string{o.Table}, o.Columns, o.Conflict, o.Update) {
+		if !sqlq.ValidIdentifier(name) {
+			return fmt.Errorf("invalid identifier: %q", name)
+		}
+	}
+	if len(o.Conflict) == 0 {
+		return nil
+	}
+
+	if len(o.Update) == 0 && !o.DoNothing {
+		for _, c := range o.Columns {
+			if !slices.Contains(o.Conflict, c) {
+				o.Update = append(o.Update, c)
+			}
+		}
+		// Only key columns, nothing to overwrite
+		o.DoNothing = len(o.Update) == 0
+	}
+	return nil
+}
+
+// build renders one batch
+func (o *Options) build(rows [][]any) (string, []any) {
+	var b strings.Builder
+	args := make([]any, 0, len(rows)*len(o.Columns))
+
+	b.WriteString("INSERT INTO " + o.Table + " (" + strings.Join(o.Columns, ", ") + ") VALUES ")
+	n := 0
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			n++
+			if o.Dialect == Postgres {
+				b.WriteString("$" + strconv.Itoa(n))
+			} else {
+				b.WriteByte('?')
+			}
+		}
+		b.WriteByte(')')
+		args = append(args, row...)
+	}
+
+	if len(o.Conflict) == 0 {
+		return b.String(), args
+	}
+
+	switch o.Dialect {
+	case MySQL:
+		b.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if o.DoNothing {
+			b.WriteString(o.Conflict[0] + " = " + o.Conflict[0])
+			break
+		}
+		for i, c := range o.Update {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(c + " = VALUES(" + c + ")")
+		}
+	default:
+		b.WriteString(" ON CONFLICT (" + strings.Join(o.Conflict, ", ") + ")")
+		if o.DoNothing {
+			b.WriteString(" DO NOTHING")
+			break
+		}
+		b.WriteString(" DO UPDATE SET ")
+		for i, c := range o.Update {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(c + " = excluded." + c)
+		}
+	}
+	return b.String(), args
+}
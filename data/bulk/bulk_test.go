@@ -0,0 +1,90 @@
+package bulk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+type recordingExecer struct {
+	queries []string
+	args    [][]any
+}
+
+func (e *recordingExecer) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	return driver.RowsAffected(len(args) / 3), nil
+}
+
+func TestUpsertDialects(t *testing.T) {
+	rows := [][]any{{1, "a", 10}, {2, "b", 20}}
+	cases := map[Dialect]string{
+		Postgres: "INSERT INTO items (id, name, qty) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT (id) DO UPDATE SET name = excluded.name, qty = excluded.qty",
+		SQLite:   "INSERT INTO items (id, name, qty) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name, qty = excluded.qty",
+		MySQL:    "INSERT INTO items (id, name, qty) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name), qty = VALUES(qty)",
+	}
+	for dialect, want := range cases {
+		db := &recordingExecer{}
+		n, err := Upsert(context.Background(), db, Options{
+			Dialect:  dialect,
+			Table:    "items",
+			Columns:  []string{"id", "name", "qty"},
+			Conflict: []string{"id"},
+		}, rows)
+		if err != nil {
+			t.Fatalf("%s: Upsert: %v", dialect, err)
+		}
+		if n != 2 || len(db.queries) != 1 || db.queries[0] != want {
+			t.Errorf("%s: got %d %q, want %q", dialect, n, db.queries, want)
+		}
+	}
+}
+
+func TestUpsertDoNothingAndBatching(t *testing.T) {
+	db := &recordingExecer{}
+	rows := [][]any{{1, "a", 1}, {2, "b", 2}, {3, "c", 3}}
+	n, err := Upsert(context.Background(), db, Options{
+		Dialect:   Postgres,
+		Table:     "items",
+		Columns:   []string{"id", "name", "qty"},
+		Conflict:  []string{"id"},
+		DoNothing: true,
+		BatchSize: 2,
+	}, rows)
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if n != 3 || len(db.queries) != 2 {
+		t.Fatalf("expected 2 batches affecting 3 rows, got %d batches and %d rows", len(db.queries), n)
+	}
+	if want := "INSERT INTO items (id, name, qty) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING"; db.queries[1] != want {
+		t.Errorf("second batch = %q, want %q", db.queries[1], want)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	type Item struct {
+		ID   int
+		Name string `db:"title"`
+		Qty  int
+	}
+
+	db := &recordingExecer{}
+	_, err := Structs(context.Background(), db, Options{Dialect: SQLite, Table: "items", Conflict: []string{"id"}},
+		[]*Item{{ID: 1, Name: "a", Qty: 5}})
+	if err != nil {
+		t.Fatalf("Structs: %v", err)
+	}
+	if want := "INSERT INTO items (id, title, qty) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET title = excluded.title, qty = excluded.qty"; db.queries[0] != want {
+		t.Errorf("query = %q, want %q", db.queries[0], want)
+	}
+	if args := db.args[0]; args[0] != 1 || args[1] != "a" || args[2] != 5 {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	if _, err := Upsert(context.Background(), db, Options{Dialect: Postgres, Table: "items; --", Columns: []string{"id"}}, [][]any{{1}}); err == nil {
+		t.Error("expected unsafe table name to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ module github.com/ncobase/ncore/data/cache
 go 1.25.3
 
 require (
+	github.com/alicebob/miniredis/v2 v2.37.0
 	github.com/ncobase/ncore/data v0.2.2
 	github.com/redis/go-redis/v9 v9.17.3
 )
@@ -10,4 +11,6 @@ require (
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/klauspost/compress v1.18.4 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 )
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/data/tenancy"
+)
+
+// defaultRefreshTimeout bounds a background refresh of a stale value
+const defaultRefreshTimeout = 30 * time.Second
+
+var (
+	// loadFlight collapses concurrent loads of a key
+	loadFlight flightGroup[any]
+	// refreshing holds the keys being refreshed in the background
+	refreshing sync.Map
+)
+
+// LoadOption configures GetOrLoad
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	stale          time.Duration
+	refreshTimeout time.Duration
+}
+
+// WithStaleWhileRevalidate keeps values cached for window past their TTL. A value read
+// within that window is returned immediately while it is reloaded in the background.
+func WithStaleWhileRevalidate(window time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.stale = window
+	}
+}
+
+// WithRefreshTimeout bounds background refreshes, 30s by default
+func WithRefreshTimeout(timeout time.Duration) LoadOption {
+	return func(o *loadOptions) {
+		o.refreshTimeout = timeout
+	}
+}
+
+// GetOrLoad returns the value cached under key, or loads and caches it for ttl on a
+// miss. Concurrent misses of a key in a process share one load. Cache errors fall back
+// to the loader, loader errors are returned and not cached.
+func GetOrLoad[T any](ctx context.Context, c ICache[T], key string, ttl time.Duration, loader func(context.Context) (T, error), opts ...LoadOption) (T, error) {
+	o := loadOptions{refreshTimeout: defaultRefreshTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if ttl <= 0 {
+		// Values without expiration never become stale
+		o.stale = 0
+	}
+
+	if cached, err := c.Get(ctx, key); err == nil && cached != nil {
+		if o.stale > 0 && isStale(ctx, c, key, o.stale) {
+			if _, running := refreshing.Load(flightKey(ctx, c, key)); !running {
+				go refresh(context.WithoutCancel(ctx), c, key, ttl, loader, o)
+			}
+		}
+		return *cached, nil
+	}
+
+	v, err, _ := loadFlight.do(flightKey(ctx, c, key), func() (any, error) {
+		value, err := load(ctx, c, key, ttl+o.stale, loader)
+		return value, err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	value, _ := v.(T)
+	return value, nil
+}
+
+// isStale reports whether a value entered its stale window
+func isStale[T any](ctx context.Context, c ICache[T], key string, window time.Duration) bool {
+	remaining, err := c.TTL(ctx, key)
+	return err == nil && remaining > 0 && remaining <= window
+}
+
+// refresh reloads a stale value unless a refresh of the key is already running in this
+// process. ctx keeps the values of the request, e.g. its tenant, but not its deadline.
+func refresh[T any](ctx context.Context, c ICache[T], key string, ttl time.Duration, loader func(context.Context) (T, error), o loadOptions) {
+	fk := flightKey(ctx, c, key)
+	if _, running := refreshing.LoadOrStore(fk, struct{}{}); running {
+		return
+	}
+	defer refreshing.Delete(fk)
+
+	ctx, cancel := context.WithTimeout(ctx, o.refreshTimeout)
+	defer cancel()
+
+	if _, err := load(ctx, c, key, ttl+o.stale, loader); err != nil {
+		log.Printf("failed to refresh cache key: %s, error: %v", key, err)
+	}
+}
+
+// load calls the loader and caches its value
+func load[T any](ctx context.Context, c ICache[T], key string, ttl time.Duration, loader func(context.Context) (T, error)) (T, error) {
+	value, err := loader(ctx)
+	if err != nil {
+		return value, err
+	}
+	if err := c.Set(ctx, key, &value, ttl); err != nil {
+		log.Printf("failed to cache loaded key: %s, error: %v", key, err)
+	}
+	return value, nil
+}
+
+// flightKey scopes a key to its cache and the tenant in ctx, so tenants never share a load
+func flightKey[T any](ctx context.Context, c ICache[T], key string) string {
+	return fmt.Sprintf("%p:%s", c, tenancy.ScopeKey(ctx, key))
+}
@@ -0,0 +1,265 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data/tenancy"
+)
+
+// memCache is an in-memory ICache for tests, scoping keys to the tenant in the context
+type memCache[T any] struct {
+	mu      sync.Mutex
+	values  map[string]T
+	expires map[string]time.Time
+}
+
+func newMemCache[T any]() *memCache[T] {
+	return &memCache[T]{values: map[string]T{}, expires: map[string]time.Time{}}
+}
+
+func (m *memCache[T]) Get(ctx context.Context, key string) (*T, error) {
+	key = tenancy.ScopeKey(ctx, key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.values[key]
+	if !ok || (!m.expires[key].IsZero() && time.Now().After(m.expires[key])) {
+		return nil, errors.New("cache miss")
+	}
+	return &v, nil
+}
+
+func (m *memCache[T]) Set(ctx context.Context, key string, v *T, expire ...time.Duration) error {
+	key = tenancy.ScopeKey(ctx, key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = *v
+	delete(m.expires, key)
+	if len(expire) > 0 && expire[0] > 0 {
+		m.expires[key] = time.Now().Add(expire[0])
+	}
+	return nil
+}
+
+func (m *memCache[T]) Delete(ctx context.Context, key string) error {
+	key = tenancy.ScopeKey(ctx, key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	delete(m.expires, key)
+	return nil
+}
+
+func (m *memCache[T]) TTL(ctx context.Context, key string) (time.Duration, error) {
+	key = tenancy.ScopeKey(ctx, key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.expires[key].IsZero() {
+		return -1, nil
+	}
+	return time.Until(m.expires[key]), nil
+}
+
+func (m *memCache[T]) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	key = tenancy.ScopeKey(ctx, key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expires[key] = time.Now().Add(expiration)
+	return nil
+}
+
+func (m *memCache[T]) Exists(ctx context.Context, key string) (bool, error) {
+	v, _ := m.Get(ctx, key)
+	return v != nil, nil
+}
+
+func (m *memCache[T]) GetArray(context.Context, string, any) error { return errors.ErrUnsupported }
+func (m *memCache[T]) SetArray(context.Context, string, any, ...time.Duration) error {
+	return errors.ErrUnsupported
+}
+func (m *memCache[T]) GetMultiple(context.Context, []string) (map[string]*T, error) {
+	return nil, errors.ErrUnsupported
+}
+func (m *memCache[T]) SetMultiple(context.Context, map[string]*T, ...time.Duration) error {
+	return errors.ErrUnsupported
+}
+
+func TestGetOrLoadCachesValues(t *testing.T) {
+	ctx := context.Background()
+	c := newMemCache[string]()
+	var loads atomic.Int32
+	loader := func(context.Context) (string, error) {
+		loads.Add(1)
+		return "value", nil
+	}
+
+	for range 3 {
+		v, err := GetOrLoad[string](ctx, c, "key", time.Minute, loader)
+		if err != nil || v != "value" {
+			t.Fatalf("GetOrLoad = %q, %v", v, err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want 1", n)
+	}
+	if ttl, _ := c.TTL(ctx, "key"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("cached with TTL %v, want up to a minute", ttl)
+	}
+}
+
+func TestGetOrLoadSharesConcurrentLoads(t *testing.T) {
+	c := newMemCache[int]()
+	release := make(chan struct{})
+	var loads atomic.Int32
+	loader := func(context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := GetOrLoad[int](context.Background(), c, "answer", time.Minute, loader)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != 42 {
+			t.Fatalf("GetOrLoad = %d, want 42", v)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want 1", n)
+	}
+}
+
+func TestGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newMemCache[string]()
+	boom := errors.New("boom")
+
+	if _, err := GetOrLoad[string](ctx, c, "key", time.Minute, func(context.Context) (string, error) {
+		return "", boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("GetOrLoad error = %v, want %v", err, boom)
+	}
+	if ok, _ := c.Exists(ctx, "key"); ok {
+		t.Fatal("a failed load was cached")
+	}
+
+	v, err := GetOrLoad[string](ctx, c, "key", time.Minute, func(context.Context) (string, error) {
+		return "recovered", nil
+	})
+	if err != nil || v != "recovered" {
+		t.Fatalf("GetOrLoad after a failure = %q, %v", v, err)
+	}
+}
+
+func TestGetOrLoadStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	c := newMemCache[int]()
+	var version atomic.Int32
+	refreshed := make(chan struct{}, 1)
+	loader := func(context.Context) (int, error) {
+		v := int(version.Add(1))
+		if v > 1 {
+			refreshed <- struct{}{}
+		}
+		return v, nil
+	}
+	swr := WithStaleWhileRevalidate(time.Hour)
+
+	if v, err := GetOrLoad[int](ctx, c, "key", 50*time.Millisecond, loader, swr); err != nil || v != 1 {
+		t.Fatalf("first GetOrLoad = %d, %v", v, err)
+	}
+	// Kept for the TTL plus the stale window
+	if ttl, _ := c.TTL(ctx, "key"); ttl <= time.Hour {
+		t.Fatalf("cached with TTL %v, want more than the stale window", ttl)
+	}
+
+	// Still fresh, no refresh
+	if v, _ := GetOrLoad[int](ctx, c, "key", 50*time.Millisecond, loader, swr); v != 1 {
+		t.Fatalf("fresh GetOrLoad = %d, want 1", v)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if v, _ := GetOrLoad[int](ctx, c, "key", 50*time.Millisecond, loader, swr); v != 1 {
+		t.Fatalf("stale GetOrLoad = %d, want the stale value 1", v)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale value was not refreshed")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if v, _ := c.Get(ctx, "key"); v != nil && *v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refreshed value was not cached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGetOrLoadSeparatesTenants(t *testing.T) {
+	c := newMemCache[string]()
+	release := make(chan struct{})
+	var loads atomic.Int32
+	loader := func(ctx context.Context) (string, error) {
+		loads.Add(1)
+		<-release
+		return "data of " + tenancy.FromContext(ctx), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(map[string]string)
+	var mu sync.Mutex
+	for _, tenant := range []string{"acme", "globex", "acme", "globex"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := tenancy.WithTenant(context.Background(), tenant)
+			v, err := GetOrLoad[string](ctx, c, "profile", time.Minute, loader)
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if prev, ok := results[tenant]; ok && prev != v {
+				t.Errorf("tenant %s got %q and %q", tenant, prev, v)
+			}
+			results[tenant] = v
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, tenant := range []string{"acme", "globex"} {
+		if results[tenant] != "data of "+tenant {
+			t.Errorf("tenant %s got %q", tenant, results[tenant])
+		}
+	}
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("loader ran %d times, want once per tenant", n)
+	}
+}
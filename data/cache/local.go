@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// localEntry is a value held by the local tier, nil data marks a cached miss
+type localEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+// localLRU is a size bounded in-process LRU with per-entry TTL
+type localLRU struct {
+	mu       sync.Mutex
+	size     int
+	ll       *list.List
+	items    map[string]*list.Element
+	gen      uint64 // Incremented by every invalidation
+	evicted  int64
+	disabled bool
+}
+
+func newLocalLRU(size int) *localLRU {
+	return &localLRU{
+		size:     size,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		disabled: size <= 0,
+	}
+}
+
+// get returns an unexpired entry and marks it recently used
+func (l *localLRU) get(key string) (localEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return localEntry{}, false
+	}
+	e := el.Value.(*localEntry)
+	if time.Now().After(e.expires) {
+		l.removeElement(el)
+		return localEntry{}, false
+	}
+	l.ll.MoveToFront(el)
+	return *e, true
+}
+
+// generation returns the invalidation generation, taken before reading the remote tier
+func (l *localLRU) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gen
+}
+
+// set stores an entry
+func (l *localLRU) set(key string, data []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setLocked(key, data, ttl)
+}
+
+// setIfCurrent stores an entry unless an invalidation happened since gen was taken,
+// so a value read before a concurrent write or delete is not cached
+func (l *localLRU) setIfCurrent(key string, data []byte, ttl time.Duration, gen uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.gen != gen {
+		return
+	}
+	l.setLocked(key, data, ttl)
+}
+
+func (l *localLRU) setLocked(key string, data []byte, ttl time.Duration) {
+	if l.disabled || ttl <= 0 {
+		return
+	}
+
+	expires := time.Now().Add(ttl)
+	if el, ok := l.items[key]; ok {
+		e := el.Value.(*localEntry)
+		e.data = data
+		e.expires = expires
+		l.ll.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.ll.PushFront(&localEntry{key: key, data: data, expires: expires})
+	for l.ll.Len() > l.size {
+		l.removeElement(l.ll.Back())
+		l.evicted++
+	}
+}
+
+// delete invalidates keys
+func (l *localLRU) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.removeElement(el)
+		}
+	}
+}
+
+// purge invalidates all keys
+func (l *localLRU) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.gen++
+	l.ll.Init()
+	l.items = make(map[string]*list.Element)
+}
+
+// stats returns the number of entries and evictions
+func (l *localLRU) stats() (int, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len(), l.evicted
+}
+
+func (l *localLRU) removeElement(el *list.Element) {
+	l.ll.Remove(el)
+	delete(l.items, el.Value.(*localEntry).key)
+}
+
+// errLoadPanicked is returned to callers sharing a load that panicked
+var errLoadPanicked = errors.New("cache load panicked")
+
+// flightCall is a load in progress
+type flightCall[V any] struct {
+	wg   sync.WaitGroup
+	data V
+	err  error
+}
+
+// flightGroup collapses concurrent loads of a key into one
+type flightGroup[V any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[V]
+}
+
+// do runs fn once for concurrent callers of the same key and reports whether the
+// result was shared with another caller
+func (g *flightGroup[V]) do(key string, fn func() (V, error)) (V, error, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.data, c.err, true
+	}
+	c := &flightCall[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	// Reported to waiters if fn panics
+	c.err = errLoadPanicked
+	c.data, c.err = fn()
+	return c.data, c.err, false
+}
@@ -0,0 +1,324 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/tenancy"
+	"github.com/redis/go-redis/v9"
+)
+
+// QueryStore holds cached query results and the versions of their tags. Bumping a
+// tag's version orphans every result cached under the previous one.
+type QueryStore interface {
+	Get(ctx context.Context, key string) ([]byte, error) // nil on a miss
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Versions(ctx context.Context, tags []string) ([]string, error)
+	Bump(ctx context.Context, tags []string) error
+}
+
+// queryCacheCollector is implemented by metrics collectors recording query cache hits
+type queryCacheCollector interface {
+	QueryCache(hit bool)
+}
+
+// QueryCacheOptions configures a QueryCache
+type QueryCacheOptions struct {
+	TTL       time.Duration // TTL of cached results, default 5m
+	Collector any           // Records hits and misses if it has a QueryCache(hit bool) method
+}
+
+// QueryCacheStats reports query cache usage
+type QueryCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// QueryCache caches query results keyed by the normalized query, its arguments and
+// the versions of the tables or entities it reads. Writes invalidate tags once their
+// transaction commits.
+type QueryCache struct {
+	store     QueryStore
+	ttl       time.Duration
+	collector queryCacheCollector
+	flight    flightGroup[[]byte]
+
+	hits, misses, invalidations atomic.Int64
+}
+
+// NewQueryCache creates a query cache on store
+func NewQueryCache(store QueryStore, opts ...QueryCacheOptions) *QueryCache {
+	var o QueryCacheOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.TTL <= 0 {
+		o.TTL = 5 * time.Minute
+	}
+
+	q := &QueryCache{store: store, ttl: o.TTL}
+	if c, ok := o.Collector.(queryCacheCollector); ok {
+		q.collector = c
+	}
+	return q
+}
+
+// CachedQuery returns the cached result of query with args, or loads and caches it.
+// tags name the tables or entities the query reads. Inside a data.WithTx transaction
+// the cache is bypassed, so uncommitted reads are never cached. Results are stored as
+// JSON, so T must round trip through encoding/json.
+func CachedQuery[T any](ctx context.Context, q *QueryCache, tags []string, query string, args []any, load func(ctx context.Context) (T, error)) (T, error) {
+	if q == nil {
+		return load(ctx)
+	}
+	if _, err := data.GetTx(ctx); err == nil {
+		return load(ctx)
+	}
+
+	key, err := q.key(ctx, tags, query, args)
+	if err != nil {
+		log.Printf("failed to build query cache key, error: %v", err)
+		return load(ctx)
+	}
+
+	var result T
+	if cached, err := q.store.Get(ctx, key); err == nil && cached != nil {
+		if err := json.Unmarshal(cached, &result); err == nil {
+			q.record(true)
+			return result, nil
+		}
+	}
+	q.record(false)
+
+	encoded, err, _ := q.flight.do(key, func() ([]byte, error) {
+		value, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal query result: %w", err)
+		}
+		if err := q.store.Set(ctx, key, encoded, q.ttl); err != nil {
+			log.Printf("failed to cache query result, error: %v", err)
+		}
+		return encoded, nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Each caller decodes its own copy of a shared load
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return result, fmt.Errorf("failed to unmarshal query result: %w", err)
+	}
+	return result, nil
+}
+
+// Invalidate drops results cached for tags once the transaction in ctx commits, or
+// right away outside a transaction
+func (q *QueryCache) Invalidate(ctx context.Context, tags ...string) {
+	if q == nil || len(tags) == 0 {
+		return
+	}
+
+	data.AfterCommit(ctx, func(ctx context.Context) {
+		q.invalidations.Add(1)
+		if err := q.store.Bump(ctx, tags); err != nil {
+			log.Printf("failed to invalidate query cache tags %v, error: %v", tags, err)
+		}
+	})
+}
+
+// Stats returns query cache usage
+func (q *QueryCache) Stats() QueryCacheStats {
+	return QueryCacheStats{
+		Hits:          q.hits.Load(),
+		Misses:        q.misses.Load(),
+		Invalidations: q.invalidations.Load(),
+	}
+}
+
+// key hashes the normalized query, its arguments, the current tag versions and
+// the tenant in ctx, whose schema the query may be routed to
+func (q *QueryCache) key(ctx context.Context, tags []string, query string, args []any) (string, error) {
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	versions, err := q.store.Versions(ctx, tags)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(normalizeQuery(query)))
+	h.Write([]byte{0})
+	h.Write(encodedArgs)
+	for i, tag := range tags {
+		h.Write([]byte{0})
+		h.Write([]byte(tag + "=" + versions[i]))
+	}
+	if tenant := tenancy.FromContext(ctx); tenant != "" {
+		h.Write([]byte{0})
+		h.Write([]byte("tenant=" + tenant))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (q *QueryCache) record(hit bool) {
+	if hit {
+		q.hits.Add(1)
+	} else {
+		q.misses.Add(1)
+	}
+	if q.collector != nil {
+		q.collector.QueryCache(hit)
+	}
+}
+
+// normalizeQuery collapses whitespace so formatting does not split cache entries
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// newTagVersion returns a version never used before, so a tag whose version was
+// evicted cannot revive results cached under an old one
+func newTagVersion() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// redisQueryStore keeps query results and tag versions in Redis
+type redisQueryStore struct {
+	rc     *redis.Client
+	prefix string
+}
+
+// NewRedisQueryStore creates a query store in Redis under prefix, shared by all nodes
+func NewRedisQueryStore(rc *redis.Client, prefix string) QueryStore {
+	return &redisQueryStore{rc: rc, prefix: prefix}
+}
+
+func (s *redisQueryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	result, err := s.rc.Get(ctx, s.prefix+":q:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return result, err
+}
+
+func (s *redisQueryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.rc.Set(ctx, s.prefix+":q:"+key, value, ttl).Err()
+}
+
+func (s *redisQueryStore) Versions(ctx context.Context, tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = s.prefix + ":tag:" + tag
+	}
+	values, err := s.rc.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, len(tags))
+	for i, val := range values {
+		if v, ok := val.(string); ok {
+			versions[i] = v
+			continue
+		}
+		// Seed a missing version, another node may win the race
+		v := newTagVersion()
+		if ok, err := s.rc.SetNX(ctx, keys[i], v, 0).Result(); err != nil {
+			return nil, err
+		} else if !ok {
+			if v, err = s.rc.Get(ctx, keys[i]).Result(); err != nil {
+				return nil, err
+			}
+		}
+		versions[i] = v
+	}
+	return versions, nil
+}
+
+func (s *redisQueryStore) Bump(ctx context.Context, tags []string) error {
+	pipe := s.rc.Pipeline()
+	for _, tag := range tags {
+		pipe.Set(ctx, s.prefix+":tag:"+tag, newTagVersion(), 0)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// memoryQueryStore keeps query results in a local LRU, for single node deployments
+type memoryQueryStore struct {
+	results  *localLRU
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// NewMemoryQueryStore creates an in-process query store holding up to size results
+func NewMemoryQueryStore(size int) QueryStore {
+	if size <= 0 {
+		size = 10000
+	}
+	return &memoryQueryStore{
+		results:  newLocalLRU(size),
+		versions: make(map[string]string),
+	}
+}
+
+func (s *memoryQueryStore) Get(_ context.Context, key string) ([]byte, error) {
+	if e, ok := s.results.get(key); ok {
+		return e.data, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryQueryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.results.set(key, value, ttl)
+	return nil
+}
+
+func (s *memoryQueryStore) Versions(_ context.Context, tags []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := make([]string, len(tags))
+	for i, tag := range tags {
+		v, ok := s.versions[tag]
+		if !ok {
+			v = newTagVersion()
+			s.versions[tag] = v
+		}
+		versions[i] = v
+	}
+	return versions, nil
+}
+
+func (s *memoryQueryStore) Bump(_ context.Context, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		s.versions[tag] = newTagVersion()
+	}
+	return nil
+}
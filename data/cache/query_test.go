@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/tenancy"
+)
+
+type hitCounter struct{ hits, misses int }
+
+func (c *hitCounter) QueryCache(hit bool) {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestCachedQueryHitsAndInvalidation(t *testing.T) {
+	ctx := context.Background()
+	collector := &hitCounter{}
+	q := NewQueryCache(NewMemoryQueryStore(0), QueryCacheOptions{Collector: collector})
+
+	loads := 0
+	load := func(context.Context) ([]user, error) {
+		loads++
+		return []user{{ID: loads, Name: "ada"}}, nil
+	}
+	query := func(sql string, args ...any) []user {
+		t.Helper()
+		users, err := CachedQuery(ctx, q, []string{"users"}, sql, args, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return users
+	}
+
+	first := query("SELECT * FROM users WHERE id = ?", 1)
+	// Formatting does not split entries
+	if got := query("SELECT *\n  FROM users\tWHERE id = ?", 1); got[0].ID != first[0].ID || loads != 1 {
+		t.Fatalf("reformatted query loaded again: %v, %d loads", got, loads)
+	}
+	// Other arguments are other entries
+	if query("SELECT * FROM users WHERE id = ?", 2); loads != 2 {
+		t.Fatalf("got %d loads for different arguments, want 2", loads)
+	}
+
+	q.Invalidate(ctx, "users")
+	if got := query("SELECT * FROM users WHERE id = ?", 1); got[0].ID != 3 || loads != 3 {
+		t.Fatalf("invalidated query returned %v after %d loads", got, loads)
+	}
+
+	// Other tags are unaffected
+	q.Invalidate(ctx, "orders")
+	if query("SELECT * FROM users WHERE id = ?", 1); loads != 3 {
+		t.Fatalf("invalidating another tag reloaded the query, %d loads", loads)
+	}
+
+	want := QueryCacheStats{Hits: 2, Misses: 3, Invalidations: 2}
+	if got := q.Stats(); got != want {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+	if collector.hits != 2 || collector.misses != 3 {
+		t.Fatalf("collector recorded %d hits and %d misses, want 2 and 3", collector.hits, collector.misses)
+	}
+}
+
+func TestCachedQueryScopesTenants(t *testing.T) {
+	q := NewQueryCache(NewMemoryQueryStore(10))
+	load := func(ctx context.Context) (string, error) {
+		return "rows of " + tenancy.FromContext(ctx), nil
+	}
+
+	for _, tenant := range []string{"acme", "globex", "acme"} {
+		ctx := tenancy.WithTenant(context.Background(), tenant)
+		got, err := CachedQuery(ctx, q, []string{"orders"}, "SELECT * FROM orders", nil, load)
+		if err != nil || got != "rows of "+tenant {
+			t.Fatalf("tenant %s got %q, %v", tenant, got, err)
+		}
+	}
+	if stats := q.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("Stats() = %+v, want one hit and two misses", stats)
+	}
+}
+
+func TestCachedQueryBypasses(t *testing.T) {
+	loads := 0
+	load := func(context.Context) (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	// A nil cache loads every time
+	for range 2 {
+		if _, err := CachedQuery[int](context.Background(), nil, []string{"users"}, "SELECT 1", nil, load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("nil cache loaded %d times, want 2", loads)
+	}
+
+	// Reads inside a transaction are not cached
+	q := NewQueryCache(NewMemoryQueryStore(10))
+	txCtx := context.WithValue(context.Background(), data.ContextKeyTransaction, &sql.Tx{})
+	for range 2 {
+		if _, err := CachedQuery(txCtx, q, []string{"users"}, "SELECT 1", nil, load); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 4 || q.Stats() != (QueryCacheStats{}) {
+		t.Fatalf("transaction reads used the cache: %d loads, %+v", loads, q.Stats())
+	}
+
+	// Load errors are returned and not cached
+	boom := errors.New("boom")
+	if _, err := CachedQuery(context.Background(), q, []string{"users"}, "SELECT 2", nil, func(context.Context) (int, error) {
+		return 0, boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("CachedQuery error = %v, want %v", err, boom)
+	}
+	if got, err := CachedQuery(context.Background(), q, []string{"users"}, "SELECT 2", nil, load); err != nil || got != 5 {
+		t.Fatalf("CachedQuery after a failed load = %d, %v, want 5", got, err)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/ncobase/ncore/data/compress"
 	"github.com/ncobase/ncore/data/metrics"
+	"github.com/ncobase/ncore/data/tenancy"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -31,7 +33,9 @@ type Cache[T any] struct {
 	rc        *redis.Client
 	key       string
 	useHash   bool
+	tenant    bool
 	collector metrics.CacheMetricsCollector
+	cp        *compress.Compressor
 }
 
 // Key defines the cache key
@@ -45,6 +49,46 @@ func (c *Cache[T]) Key(field string) string {
 	return field
 }
 
+// TenantScoped prefixes keys with the tenant in the context, keeping tenants
+// apart in a shared Redis. Keys used without a tenant are left unscoped.
+func (c *Cache[T]) TenantScoped() *Cache[T] {
+	c.tenant = true
+	return c
+}
+
+// Compressed compresses values over the compressor's threshold. Values
+// written before, or uncompressed, stay readable.
+func (c *Cache[T]) Compressed(cp *compress.Compressor) *Cache[T] {
+	c.cp = cp
+	return c
+}
+
+// marshal encodes a value for Redis
+func (c *Cache[T]) marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.cp.Encode(data)
+}
+
+// unmarshal decodes a value read from Redis
+func (c *Cache[T]) unmarshal(data []byte, dest any) error {
+	data, err := c.cp.Decode(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// scopedKey returns the key of field, scoped to the tenant in ctx if enabled
+func (c *Cache[T]) scopedKey(ctx context.Context, field string) string {
+	if c.tenant {
+		return tenancy.ScopeKey(ctx, c.Key(field))
+	}
+	return c.Key(field)
+}
+
 // NewCache creates a new Cache instance
 func NewCache[T any](rc *redis.Client, key string, useHash ...bool) *Cache[T] {
 	hash := false
@@ -82,10 +126,10 @@ func (c *Cache[T]) Get(ctx context.Context, field string) (*T, error) {
 
 	if c.useHash {
 		command = "hget"
-		result, err = c.rc.HGet(ctx, c.Key(field), field).Result()
+		result, err = c.rc.HGet(ctx, c.scopedKey(ctx, field), field).Result()
 	} else {
 		command = "get"
-		result, err = c.rc.Get(ctx, c.Key(field)).Result()
+		result, err = c.rc.Get(ctx, c.scopedKey(ctx, field)).Result()
 	}
 
 	c.collector.RedisCommand(command, err)
@@ -98,7 +142,7 @@ func (c *Cache[T]) Get(ctx context.Context, field string) (*T, error) {
 	}
 
 	var row T
-	if err = json.Unmarshal([]byte(result), &row); err != nil {
+	if err = c.unmarshal([]byte(result), &row); err != nil {
 		c.collector.RedisCommand("unmarshal", err)
 		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
 	}
@@ -113,7 +157,7 @@ func (c *Cache[T]) Set(ctx context.Context, field string, data *T, expire ...tim
 		return err
 	}
 
-	bytes, err := json.Marshal(data)
+	bytes, err := c.marshal(data)
 	if err != nil {
 		c.collector.RedisCommand("marshal", err)
 		return fmt.Errorf("failed to marshal data: %w", err)
@@ -122,14 +166,14 @@ func (c *Cache[T]) Set(ctx context.Context, field string, data *T, expire ...tim
 	var command string
 	if c.useHash {
 		command = "hset"
-		err = c.rc.HSet(ctx, c.Key(field), field, bytes).Err()
+		err = c.rc.HSet(ctx, c.scopedKey(ctx, field), field, bytes).Err()
 	} else {
 		command = "set"
 		exp := time.Duration(0)
 		if len(expire) > 0 {
 			exp = expire[0]
 		}
-		err = c.rc.Set(ctx, c.Key(field), bytes, exp).Err()
+		err = c.rc.Set(ctx, c.scopedKey(ctx, field), bytes, exp).Err()
 	}
 
 	c.collector.RedisCommand(command, err)
@@ -154,10 +198,10 @@ func (c *Cache[T]) GetArray(ctx context.Context, field string, dest any) error {
 
 	if c.useHash {
 		command = "hget"
-		result, err = c.rc.HGet(ctx, c.Key(field), field).Result()
+		result, err = c.rc.HGet(ctx, c.scopedKey(ctx, field), field).Result()
 	} else {
 		command = "get"
-		result, err = c.rc.Get(ctx, c.Key(field)).Result()
+		result, err = c.rc.Get(ctx, c.scopedKey(ctx, field)).Result()
 	}
 
 	c.collector.RedisCommand(command, err)
@@ -169,7 +213,7 @@ func (c *Cache[T]) GetArray(ctx context.Context, field string, dest any) error {
 		return fmt.Errorf("failed to get array cache: %w", err)
 	}
 
-	if err = json.Unmarshal([]byte(result), dest); err != nil {
+	if err = c.unmarshal([]byte(result), dest); err != nil {
 		c.collector.RedisCommand("unmarshal_array", err)
 		return fmt.Errorf("failed to unmarshal array cache data: %w", err)
 	}
@@ -185,7 +229,7 @@ func (c *Cache[T]) SetArray(ctx context.Context, field string, data any, expire
 		return err
 	}
 
-	bytes, err := json.Marshal(data)
+	bytes, err := c.marshal(data)
 	if err != nil {
 		c.collector.RedisCommand("marshal_array", err)
 		return fmt.Errorf("failed to marshal array data: %w", err)
@@ -194,14 +238,14 @@ func (c *Cache[T]) SetArray(ctx context.Context, field string, data any, expire
 	var command string
 	if c.useHash {
 		command = "hset"
-		err = c.rc.HSet(ctx, c.Key(field), field, bytes).Err()
+		err = c.rc.HSet(ctx, c.scopedKey(ctx, field), field, bytes).Err()
 	} else {
 		command = "set"
 		exp := time.Duration(0)
 		if len(expire) > 0 {
 			exp = expire[0]
 		}
-		err = c.rc.Set(ctx, c.Key(field), bytes, exp).Err()
+		err = c.rc.Set(ctx, c.scopedKey(ctx, field), bytes, exp).Err()
 	}
 
 	c.collector.RedisCommand(command, err)
@@ -225,10 +269,10 @@ func (c *Cache[T]) Delete(ctx context.Context, field string) error {
 
 	if c.useHash {
 		command = "hdel"
-		err = c.rc.HDel(ctx, c.Key(field), field).Err()
+		err = c.rc.HDel(ctx, c.scopedKey(ctx, field), field).Err()
 	} else {
 		command = "del"
-		err = c.rc.Del(ctx, c.Key(field)).Err()
+		err = c.rc.Del(ctx, c.scopedKey(ctx, field)).Err()
 	}
 
 	c.collector.RedisCommand(command, err)
@@ -256,7 +300,7 @@ func (c *Cache[T]) GetMultiple(ctx context.Context, fields []string) (map[string
 		copy(keys, fields)
 
 		// Get the first field's key for the hash
-		hashKey := c.Key(fields[0])
+		hashKey := c.scopedKey(ctx, fields[0])
 		values, err := c.rc.HMGet(ctx, hashKey, keys...).Result()
 		c.collector.RedisCommand("hmget", err)
 
@@ -268,7 +312,7 @@ func (c *Cache[T]) GetMultiple(ctx context.Context, fields []string) (map[string
 			if val != nil {
 				if strVal, ok := val.(string); ok && strVal != "" {
 					var item T
-					if err := json.Unmarshal([]byte(strVal), &item); err == nil {
+					if err := c.unmarshal([]byte(strVal), &item); err == nil {
 						result[fields[i]] = &item
 					}
 				}
@@ -278,7 +322,7 @@ func (c *Cache[T]) GetMultiple(ctx context.Context, fields []string) (map[string
 		// Use MGET for key-based cache
 		keys := make([]string, len(fields))
 		for i, field := range fields {
-			keys[i] = c.Key(field)
+			keys[i] = c.scopedKey(ctx, field)
 		}
 
 		values, err := c.rc.MGet(ctx, keys...).Result()
@@ -292,7 +336,7 @@ func (c *Cache[T]) GetMultiple(ctx context.Context, fields []string) (map[string
 			if val != nil {
 				if strVal, ok := val.(string); ok && strVal != "" {
 					var item T
-					if err := json.Unmarshal([]byte(strVal), &item); err == nil {
+					if err := c.unmarshal([]byte(strVal), &item); err == nil {
 						result[fields[i]] = &item
 					}
 				}
@@ -323,10 +367,10 @@ func (c *Cache[T]) SetMultiple(ctx context.Context, items map[string]*T, expire
 
 		for field, data := range items {
 			if hashKey == "" {
-				hashKey = c.Key(field)
+				hashKey = c.scopedKey(ctx, field)
 			}
 
-			bytes, err := json.Marshal(data)
+			bytes, err := c.marshal(data)
 			if err != nil {
 				c.collector.RedisCommand("marshal_multiple", err)
 				return fmt.Errorf("failed to marshal data for field %s: %w", field, err)
@@ -349,12 +393,12 @@ func (c *Cache[T]) SetMultiple(ctx context.Context, items map[string]*T, expire
 		}
 
 		for field, data := range items {
-			bytes, err := json.Marshal(data)
+			bytes, err := c.marshal(data)
 			if err != nil {
 				c.collector.RedisCommand("marshal_multiple", err)
 				return fmt.Errorf("failed to marshal data for field %s: %w", field, err)
 			}
-			pipe.Set(ctx, c.Key(field), bytes, exp)
+			pipe.Set(ctx, c.scopedKey(ctx, field), bytes, exp)
 		}
 
 		_, err := pipe.Exec(ctx)
@@ -382,10 +426,10 @@ func (c *Cache[T]) Exists(ctx context.Context, field string) (bool, error) {
 
 	if c.useHash {
 		command = "hexists"
-		result, err = c.rc.HExists(ctx, c.Key(field), field).Result()
+		result, err = c.rc.HExists(ctx, c.scopedKey(ctx, field), field).Result()
 	} else {
 		command = "exists"
-		count, existsErr := c.rc.Exists(ctx, c.Key(field)).Result()
+		count, existsErr := c.rc.Exists(ctx, c.scopedKey(ctx, field)).Result()
 		result = count > 0
 		err = existsErr
 	}
@@ -407,7 +451,7 @@ func (c *Cache[T]) TTL(ctx context.Context, field string) (time.Duration, error)
 		return 0, err
 	}
 
-	duration, err := c.rc.TTL(ctx, c.Key(field)).Result()
+	duration, err := c.rc.TTL(ctx, c.scopedKey(ctx, field)).Result()
 	c.collector.RedisCommand("ttl", err)
 
 	if err != nil {
@@ -425,7 +469,7 @@ func (c *Cache[T]) Expire(ctx context.Context, field string, expiration time.Dur
 		return err
 	}
 
-	err := c.rc.Expire(ctx, c.Key(field), expiration).Err()
+	err := c.rc.Expire(ctx, c.scopedKey(ctx, field), expiration).Err()
 	c.collector.RedisCommand("expire", err)
 
 	if err != nil {
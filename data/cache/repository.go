@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/data/sqlrepo"
+)
+
+// CachedRepository caches the reads of a sqlrepo.Base, for read heavy list endpoints.
+// Writes invalidate its tags, which default to the table name.
+type CachedRepository[T any, ID comparable] struct {
+	*sqlrepo.Base[T, ID]
+	cache  *QueryCache
+	tags   []string
+	bypass bool
+}
+
+// NewCachedRepository wraps base with query caching on qc
+func NewCachedRepository[T any, ID comparable](base *sqlrepo.Base[T, ID], qc *QueryCache, tags ...string) *CachedRepository[T, ID] {
+	if len(tags) == 0 {
+		tags = []string{base.Table()}
+	}
+	return &CachedRepository[T, ID]{Base: base, cache: qc, tags: tags}
+}
+
+// WithDB returns a copy running on db, e.g. a *sql.Tx. Its reads skip the cache,
+// its writes still invalidate it.
+func (r *CachedRepository[T, ID]) WithDB(db sqlrepo.DB) *CachedRepository[T, ID] {
+	return &CachedRepository[T, ID]{Base: r.Base.WithDB(db), cache: r.cache, tags: r.tags, bypass: true}
+}
+
+// Invalidate drops the cached reads of the repository
+func (r *CachedRepository[T, ID]) Invalidate(ctx context.Context) {
+	r.cache.Invalidate(ctx, r.tags...)
+}
+
+// Get returns the record with id, cached
+func (r *CachedRepository[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	return CachedQuery(ctx, r.queryCache(), r.tags, r.Table()+":get", []any{r.Tenant(ctx), id}, func(ctx context.Context) (*T, error) {
+		return r.Base.Get(ctx, id)
+	})
+}
+
+// Count counts records matching filter, cached
+func (r *CachedRepository[T, ID]) Count(ctx context.Context, filter sqlrepo.Filter) (int, error) {
+	return CachedQuery(ctx, r.queryCache(), r.tags, r.Table()+":count", []any{r.Tenant(ctx), filter}, func(ctx context.Context) (int, error) {
+		return r.Base.Count(ctx, filter)
+	})
+}
+
+// List returns a page of records, cached
+func (r *CachedRepository[T, ID]) List(ctx context.Context, opts *sqlrepo.ListOptions) (*sqlrepo.Page[T], error) {
+	return CachedQuery(ctx, r.queryCache(), r.tags, r.Table()+":list", []any{r.Tenant(ctx), opts}, func(ctx context.Context) (*sqlrepo.Page[T], error) {
+		return r.Base.List(ctx, opts)
+	})
+}
+
+// Create inserts entity and invalidates cached reads
+func (r *CachedRepository[T, ID]) Create(ctx context.Context, entity *T) error {
+	return r.invalidateOn(ctx, r.Base.Create(ctx, entity))
+}
+
+// Update writes entity and invalidates cached reads
+func (r *CachedRepository[T, ID]) Update(ctx context.Context, entity *T) error {
+	return r.invalidateOn(ctx, r.Base.Update(ctx, entity))
+}
+
+// Delete deletes the record with id and invalidates cached reads
+func (r *CachedRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	return r.invalidateOn(ctx, r.Base.Delete(ctx, id))
+}
+
+// HardDelete removes the record with id and invalidates cached reads
+func (r *CachedRepository[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	return r.invalidateOn(ctx, r.Base.HardDelete(ctx, id))
+}
+
+// Restore undoes a soft delete and invalidates cached reads
+func (r *CachedRepository[T, ID]) Restore(ctx context.Context, id ID) error {
+	return r.invalidateOn(ctx, r.Base.Restore(ctx, id))
+}
+
+// queryCache returns the cache reads go through, nil to skip it
+func (r *CachedRepository[T, ID]) queryCache() *QueryCache {
+	if r.bypass {
+		return nil
+	}
+	return r.cache
+}
+
+func (r *CachedRepository[T, ID]) invalidateOn(ctx context.Context, err error) error {
+	if err == nil && r.cache != nil {
+		r.cache.Invalidate(ctx, r.tags...)
+	}
+	return err
+}
@@ -0,0 +1,638 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/data/compress"
+	"github.com/ncobase/ncore/data/metrics"
+	"github.com/ncobase/ncore/data/tenancy"
+	"github.com/redis/go-redis/v9"
+)
+
+// negativeMarker is stored in Redis for a cached miss, JSON never starts with NUL
+const negativeMarker = "\x00nil"
+
+// TieredOptions configures a TieredCache
+type TieredOptions struct {
+	LocalSize   int           // Max entries in the local tier, default 10000, negative disables it
+	LocalTTL    time.Duration // TTL of local entries, bounds staleness if an invalidation is lost, default 1m
+	TTL         time.Duration // Default TTL of Redis entries, 0 for no expiration
+	NegativeTTL time.Duration // TTL of misses cached by GetOrLoad, 0 disables negative caching
+	Channel     string        // Invalidation channel, default "<key>:invalidate"
+	Collector   metrics.CacheMetricsCollector
+	// TenantScoped prefixes keys with the tenant in the context, see Cache.TenantScoped
+	TenantScoped bool
+	// Compressor compresses values over its threshold in both tiers, see Cache.Compressed
+	Compressor *compress.Compressor
+}
+
+// TieredStats reports local tier usage
+type TieredStats struct {
+	LocalEntries   int   `json:"local_entries"`
+	LocalHits      int64 `json:"local_hits"`
+	LocalMisses    int64 `json:"local_misses"`
+	LocalEvictions int64 `json:"local_evictions"`
+	Loads          int64 `json:"loads"`
+	SharedLoads    int64 `json:"shared_loads"`
+	Invalidations  int64 `json:"invalidations"`
+}
+
+var _ ICache[any] = (*TieredCache[any])(nil)
+
+// invalidation is published when keys change so other nodes drop their local copies
+type invalidation struct {
+	Node string   `json:"node"`
+	Keys []string `json:"keys,omitempty"` // Empty for all keys
+}
+
+// TieredCache is a two level cache with an in-process LRU in front of Redis. Writes go
+// to both tiers and are fanned out to other nodes over Redis pub/sub, which drop their
+// local copies. Without a Redis client it is a local cache only.
+type TieredCache[T any] struct {
+	rc        *redis.Client
+	key       string
+	opts      TieredOptions
+	collector metrics.CacheMetricsCollector
+	node      string
+
+	local  *localLRU
+	flight flightGroup[[]byte]
+
+	hits, misses, loads, shared, invalidations atomic.Int64
+
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewTieredCache creates a tiered cache for keys prefixed with key and subscribes to
+// invalidations from other nodes
+func NewTieredCache[T any](rc *redis.Client, key string, opts ...TieredOptions) *TieredCache[T] {
+	var o TieredOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.LocalSize == 0 {
+		o.LocalSize = 10000
+	}
+	if o.LocalTTL <= 0 {
+		o.LocalTTL = time.Minute
+	}
+	if o.Channel == "" {
+		o.Channel = key + ":invalidate"
+	}
+	if o.Collector == nil {
+		o.Collector = metrics.NoOpCollector{}
+	}
+
+	c := &TieredCache[T]{
+		rc:        rc,
+		key:       key,
+		opts:      o,
+		collector: o.Collector,
+		node:      newNodeID(),
+		local:     newLocalLRU(o.LocalSize),
+	}
+
+	if rc != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancel = cancel
+		c.wg.Add(1)
+		go c.subscribe(ctx)
+	}
+
+	return c
+}
+
+// Key defines the cache key
+func (c *TieredCache[T]) Key(field string) string {
+	if c.key != "" {
+		return fmt.Sprintf("%s:%s", c.key, field)
+	}
+	return field
+}
+
+// scopedKey returns the key of field, scoped to the tenant in ctx if enabled
+func (c *TieredCache[T]) scopedKey(ctx context.Context, field string) string {
+	if c.opts.TenantScoped {
+		return tenancy.ScopeKey(ctx, c.Key(field))
+	}
+	return c.Key(field)
+}
+
+// Get retrieves a single item, nil on a miss
+func (c *TieredCache[T]) Get(ctx context.Context, field string) (*T, error) {
+	data, err := c.getBytes(ctx, field)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return c.decode(data)
+}
+
+// GetOrLoad retrieves a single item, loading it on a miss. Concurrent misses of a key
+// on this node share one load. A loader returning nil is cached as a miss for
+// NegativeTTL.
+func (c *TieredCache[T]) GetOrLoad(ctx context.Context, field string, loader func(context.Context) (*T, error)) (*T, error) {
+	if e, ok := c.local.get(c.scopedKey(ctx, field)); ok {
+		c.hits.Add(1)
+		if e.data == nil {
+			return nil, nil
+		}
+		return c.decode(e.data)
+	}
+	c.misses.Add(1)
+
+	// Loads of other tenants must not be shared, the loader runs with the caller's tenant
+	data, err, shared := c.flight.do("load:"+c.scopedKey(ctx, field), func() ([]byte, error) {
+		gen := c.local.generation()
+		data, found, err := c.getRemote(ctx, field, gen)
+		if err != nil || found {
+			return data, err
+		}
+
+		c.loads.Add(1)
+		value, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			if c.opts.NegativeTTL > 0 {
+				c.store(ctx, field, nil, c.opts.NegativeTTL, gen)
+			}
+			return nil, nil
+		}
+
+		data, err = c.marshal(value)
+		if err != nil {
+			c.collector.RedisCommand("marshal", err)
+			return nil, fmt.Errorf("failed to marshal data: %w", err)
+		}
+		c.store(ctx, field, data, c.opts.TTL, gen)
+		return data, nil
+	})
+	if shared {
+		c.shared.Add(1)
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return c.decode(data)
+}
+
+// Set saves a single item into both tiers and invalidates it on other nodes
+func (c *TieredCache[T]) Set(ctx context.Context, field string, data *T, expire ...time.Duration) error {
+	bytes, err := c.marshal(data)
+	if err != nil {
+		c.collector.RedisCommand("marshal", err)
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	return c.setBytes(ctx, field, bytes, expire...)
+}
+
+// GetArray retrieves an array of items, dest is left untouched on a miss
+func (c *TieredCache[T]) GetArray(ctx context.Context, field string, dest any) error {
+	data, err := c.getBytes(ctx, field)
+	if err != nil || data == nil {
+		return err
+	}
+	if err := c.unmarshal(data, dest); err != nil {
+		c.collector.RedisCommand("unmarshal_array", err)
+		return fmt.Errorf("failed to unmarshal array cache data: %w", err)
+	}
+	return nil
+}
+
+// SetArray saves an array of items into both tiers and invalidates it on other nodes
+func (c *TieredCache[T]) SetArray(ctx context.Context, field string, data any, expire ...time.Duration) error {
+	bytes, err := c.marshal(data)
+	if err != nil {
+		c.collector.RedisCommand("marshal_array", err)
+		return fmt.Errorf("failed to marshal array data: %w", err)
+	}
+	return c.setBytes(ctx, field, bytes, expire...)
+}
+
+// Delete removes an item from both tiers and invalidates it on other nodes
+func (c *TieredCache[T]) Delete(ctx context.Context, field string) error {
+	key := c.scopedKey(ctx, field)
+	c.local.delete(key)
+
+	if c.rc == nil {
+		return nil
+	}
+
+	err := c.rc.Del(ctx, key).Err()
+	c.collector.RedisCommand("del", err)
+	if err != nil {
+		return fmt.Errorf("failed to delete cache: %w", err)
+	}
+
+	c.publish(ctx, key)
+	return nil
+}
+
+// GetMultiple retrieves multiple items, misses are omitted from the result
+func (c *TieredCache[T]) GetMultiple(ctx context.Context, fields []string) (map[string]*T, error) {
+	result := make(map[string]*T)
+
+	var remote []string
+	for _, field := range fields {
+		e, ok := c.local.get(c.scopedKey(ctx, field))
+		if !ok {
+			c.misses.Add(1)
+			remote = append(remote, field)
+			continue
+		}
+		c.hits.Add(1)
+		if e.data == nil {
+			continue
+		}
+		if item, err := c.decode(e.data); err == nil {
+			result[field] = item
+		}
+	}
+
+	if len(remote) == 0 || c.rc == nil {
+		return result, nil
+	}
+
+	gen := c.local.generation()
+	keys := make([]string, len(remote))
+	for i, field := range remote {
+		keys[i] = c.scopedKey(ctx, field)
+	}
+
+	values, err := c.rc.MGet(ctx, keys...).Result()
+	c.collector.RedisCommand("mget", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multiple cache: %w", err)
+	}
+
+	for i, val := range values {
+		strVal, ok := val.(string)
+		if !ok || strVal == "" {
+			continue
+		}
+		if strVal == negativeMarker {
+			c.local.setIfCurrent(keys[i], nil, c.localTTL(c.opts.NegativeTTL), gen)
+			continue
+		}
+		data := []byte(strVal)
+		item, err := c.decode(data)
+		if err != nil {
+			continue
+		}
+		c.local.setIfCurrent(keys[i], data, c.opts.LocalTTL, gen)
+		result[remote[i]] = item
+	}
+
+	return result, nil
+}
+
+// SetMultiple saves multiple items into both tiers and invalidates them on other nodes
+func (c *TieredCache[T]) SetMultiple(ctx context.Context, items map[string]*T, expire ...time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	encoded := make(map[string][]byte, len(items))
+	keys := make([]string, 0, len(items))
+	for field, data := range items {
+		bytes, err := c.marshal(data)
+		if err != nil {
+			c.collector.RedisCommand("marshal_multiple", err)
+			return fmt.Errorf("failed to marshal data for field %s: %w", field, err)
+		}
+		key := c.scopedKey(ctx, field)
+		encoded[key] = bytes
+		keys = append(keys, key)
+	}
+
+	// Drop stale local copies before writing so concurrent reads do not cache them
+	c.local.delete(keys...)
+
+	if c.rc != nil {
+		pipe := c.rc.Pipeline()
+		exp := c.expiration(expire)
+		for key, bytes := range encoded {
+			pipe.Set(ctx, key, bytes, exp)
+		}
+
+		_, err := pipe.Exec(ctx)
+		c.collector.RedisCommand("pipeline_set", err)
+		if err != nil {
+			return fmt.Errorf("failed to set multiple cache: %w", err)
+		}
+		c.publish(ctx, keys...)
+	}
+
+	for key, bytes := range encoded {
+		c.local.set(key, bytes, c.opts.LocalTTL)
+	}
+	return nil
+}
+
+// Exists checks if an item is cached, cached misses do not exist
+func (c *TieredCache[T]) Exists(ctx context.Context, field string) (bool, error) {
+	data, err := c.getBytes(ctx, field)
+	if err != nil {
+		return false, fmt.Errorf("failed to check cache existence: %w", err)
+	}
+	return data != nil, nil
+}
+
+// TTL gets the time to live of an item in Redis
+func (c *TieredCache[T]) TTL(ctx context.Context, field string) (time.Duration, error) {
+	if c.rc == nil {
+		return 0, errors.New("redis client is nil, cannot get TTL")
+	}
+
+	duration, err := c.rc.TTL(ctx, c.scopedKey(ctx, field)).Result()
+	c.collector.RedisCommand("ttl", err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cache TTL: %w", err)
+	}
+	return duration, nil
+}
+
+// Expire sets the expiration of an item in Redis, local copies are invalidated so
+// they do not outlive it
+func (c *TieredCache[T]) Expire(ctx context.Context, field string, expiration time.Duration) error {
+	key := c.scopedKey(ctx, field)
+	c.local.delete(key)
+
+	if c.rc == nil {
+		return nil
+	}
+
+	err := c.rc.Expire(ctx, key, expiration).Err()
+	c.collector.RedisCommand("expire", err)
+	if err != nil {
+		return fmt.Errorf("failed to set cache expiration: %w", err)
+	}
+
+	c.publish(ctx, key)
+	return nil
+}
+
+// Invalidate drops local copies of fields on all nodes without touching Redis, e.g.
+// after the source of a value changed. Without fields all local copies are dropped.
+func (c *TieredCache[T]) Invalidate(ctx context.Context, fields ...string) {
+	if len(fields) == 0 {
+		c.local.purge()
+		c.publish(ctx)
+		return
+	}
+
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = c.scopedKey(ctx, field)
+	}
+	c.local.delete(keys...)
+	c.publish(ctx, keys...)
+}
+
+// Stats returns local tier usage
+func (c *TieredCache[T]) Stats() TieredStats {
+	entries, evictions := c.local.stats()
+	return TieredStats{
+		LocalEntries:   entries,
+		LocalHits:      c.hits.Load(),
+		LocalMisses:    c.misses.Load(),
+		LocalEvictions: evictions,
+		Loads:          c.loads.Load(),
+		SharedLoads:    c.shared.Load(),
+		Invalidations:  c.invalidations.Load(),
+	}
+}
+
+// Close stops receiving invalidations
+func (c *TieredCache[T]) Close() error {
+	c.closeOnce.Do(func() {
+		if c.cancel != nil {
+			c.cancel()
+		}
+		c.wg.Wait()
+	})
+	return nil
+}
+
+// getBytes reads an item from the local tier, then Redis, nil on a miss
+func (c *TieredCache[T]) getBytes(ctx context.Context, field string) ([]byte, error) {
+	if e, ok := c.local.get(c.scopedKey(ctx, field)); ok {
+		c.hits.Add(1)
+		return e.data, nil
+	}
+	c.misses.Add(1)
+
+	data, err, _ := c.flight.do("get:"+c.scopedKey(ctx, field), func() ([]byte, error) {
+		data, _, err := c.getRemote(ctx, field, c.local.generation())
+		return data, err
+	})
+	return data, err
+}
+
+// getRemote reads an item from Redis and caches it locally unless invalidated since gen.
+// found is true for values and cached misses.
+func (c *TieredCache[T]) getRemote(ctx context.Context, field string, gen uint64) ([]byte, bool, error) {
+	if c.rc == nil {
+		return nil, false, nil
+	}
+
+	key := c.scopedKey(ctx, field)
+	result, err := c.rc.Get(ctx, key).Result()
+	c.collector.RedisCommand("get", err)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get cache: %w", err)
+	}
+
+	if result == negativeMarker {
+		c.local.setIfCurrent(key, nil, c.localTTL(c.opts.NegativeTTL), gen)
+		return nil, true, nil
+	}
+
+	data := []byte(result)
+	c.local.setIfCurrent(key, data, c.opts.LocalTTL, gen)
+	return data, true, nil
+}
+
+// store writes a loaded value, or a miss when data is nil, to both tiers. Loads
+// follow a miss in Redis, a value written since by another node is kept.
+func (c *TieredCache[T]) store(ctx context.Context, field string, data []byte, ttl time.Duration, gen uint64) {
+	key := c.scopedKey(ctx, field)
+
+	if c.rc != nil {
+		var value any = data
+		if data == nil {
+			value = negativeMarker
+		}
+		stored, err := c.rc.SetNX(ctx, key, value, ttl).Result()
+		c.collector.RedisCommand("setnx", err)
+		if err != nil {
+			log.Printf("failed to cache loaded field: %s, error: %v", field, err)
+		} else if !stored {
+			return
+		}
+	}
+
+	localTTL := c.opts.LocalTTL
+	if data == nil {
+		localTTL = c.localTTL(ttl)
+	}
+	c.local.setIfCurrent(key, data, localTTL, gen)
+}
+
+// setBytes writes an encoded item to both tiers and invalidates it on other nodes
+func (c *TieredCache[T]) setBytes(ctx context.Context, field string, data []byte, expire ...time.Duration) error {
+	key := c.scopedKey(ctx, field)
+	exp := c.expiration(expire)
+
+	// Drop the stale local copy before writing so concurrent reads do not cache it
+	c.local.delete(key)
+
+	if c.rc != nil {
+		err := c.rc.Set(ctx, key, data, exp).Err()
+		c.collector.RedisCommand("set", err)
+		if err != nil {
+			return fmt.Errorf("failed to set cache: %w", err)
+		}
+		c.publish(ctx, key)
+	}
+
+	localTTL := c.opts.LocalTTL
+	if exp > 0 {
+		localTTL = c.localTTL(exp)
+	}
+	c.local.set(key, data, localTTL)
+	return nil
+}
+
+// publish fans out an invalidation of keys to other nodes, all keys when empty
+func (c *TieredCache[T]) publish(ctx context.Context, keys ...string) {
+	if c.rc == nil {
+		return
+	}
+
+	payload, err := json.Marshal(invalidation{Node: c.node, Keys: keys})
+	if err != nil {
+		return
+	}
+	err = c.rc.Publish(ctx, c.opts.Channel, payload).Err()
+	c.collector.RedisCommand("publish", err)
+	if err != nil {
+		log.Printf("failed to publish cache invalidation on %s, error: %v", c.opts.Channel, err)
+	}
+}
+
+// subscribe applies invalidations published by other nodes. Messages may be missed
+// while the connection is down, so the local tier is purged on every resubscription.
+func (c *TieredCache[T]) subscribe(ctx context.Context) {
+	defer c.wg.Done()
+
+	ps := c.rc.Subscribe(ctx, c.opts.Channel)
+	defer ps.Close()
+	// Receive blocks on the connection regardless of ctx, closing it unblocks Close
+	stop := context.AfterFunc(ctx, func() { _ = ps.Close() })
+	defer stop()
+
+	subscribed := false
+	for {
+		msg, err := ps.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind != "subscribe" {
+				continue
+			}
+			if subscribed {
+				c.local.purge()
+			}
+			subscribed = true
+		case *redis.Message:
+			var inv invalidation
+			if err := json.Unmarshal([]byte(m.Payload), &inv); err != nil || inv.Node == c.node {
+				continue
+			}
+			c.invalidations.Add(1)
+			if len(inv.Keys) == 0 {
+				c.local.purge()
+			} else {
+				c.local.delete(inv.Keys...)
+			}
+		}
+	}
+}
+
+// decode unmarshals an item, each caller gets its own copy
+func (c *TieredCache[T]) decode(data []byte) (*T, error) {
+	var row T
+	if err := c.unmarshal(data, &row); err != nil {
+		c.collector.RedisCommand("unmarshal", err)
+		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
+	}
+	return &row, nil
+}
+
+// marshal encodes a value for both tiers
+func (c *TieredCache[T]) marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.opts.Compressor.Encode(data)
+}
+
+// unmarshal decodes a value read from either tier
+func (c *TieredCache[T]) unmarshal(data []byte, dest any) error {
+	data, err := c.opts.Compressor.Decode(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// expiration returns the Redis TTL of a write
+func (c *TieredCache[T]) expiration(expire []time.Duration) time.Duration {
+	if len(expire) > 0 {
+		return expire[0]
+	}
+	return c.opts.TTL
+}
+
+// localTTL caps the local TTL of an entry expiring sooner in Redis
+func (c *TieredCache[T]) localTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.opts.LocalTTL {
+		return ttl
+	}
+	return c.opts.LocalTTL
+}
+
+// newNodeID returns a random ID identifying this cache instance's invalidations
+func newNodeID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
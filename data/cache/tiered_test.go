@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ncobase/ncore/data/tenancy"
+	"github.com/redis/go-redis/v9"
+)
+
+type profile struct {
+	Tenant string `json:"tenant"`
+}
+
+// newTieredNodes creates tiered caches sharing a Redis server, as on n nodes, and
+// waits until all of them receive invalidations
+func newTieredNodes(t *testing.T, opts TieredOptions, n int) (*miniredis.Miniredis, []*TieredCache[profile]) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	nodes := make([]*TieredCache[profile], n)
+	for i := range nodes {
+		rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { rc.Close() })
+		c := NewTieredCache[profile](rc, "profiles", opts)
+		t.Cleanup(func() { c.Close() })
+		nodes[i] = c
+	}
+	waitFor(t, "subscriptions", func() bool {
+		return mr.PubSubNumSub("profiles:invalidate")["profiles:invalidate"] == n
+	})
+	return mr, nodes
+}
+
+// waitFor fails the test unless cond becomes true within a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// waitInvalidations waits until c received n invalidations from other nodes
+func waitInvalidations(t *testing.T, c *TieredCache[profile], n int64) {
+	t.Helper()
+	waitFor(t, "invalidations", func() bool { return c.Stats().Invalidations >= n })
+}
+
+func TestTieredReadsThroughTiers(t *testing.T) {
+	ctx := context.Background()
+	mr, nodes := newTieredNodes(t, TieredOptions{}, 2)
+	a, b := nodes[0], nodes[1]
+
+	if err := a.Set(ctx, "current", &profile{Tenant: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mr.Get("profiles:current"); got != `{"tenant":"acme"}` {
+		t.Fatalf("redis holds %q", got)
+	}
+	waitInvalidations(t, b, 1)
+
+	// The first read goes to Redis, the next ones are served locally
+	for range 2 {
+		if p, err := b.Get(ctx, "current"); err != nil || p == nil || p.Tenant != "acme" {
+			t.Fatalf("Get() = %+v, %v", p, err)
+		}
+	}
+	if s := b.Stats(); s.LocalMisses != 1 || s.LocalHits != 1 || s.LocalEntries != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+
+	// Local copies are served without Redis until invalidated
+	mr.Del("profiles:current")
+	if p, _ := b.Get(ctx, "current"); p == nil || p.Tenant != "acme" {
+		t.Fatalf("local copy = %+v", p)
+	}
+	if p, err := NewTieredCache[profile](nil, "profiles").Get(ctx, "current"); p != nil || err != nil {
+		t.Fatalf("local only cache shares values: %+v, %v", p, err)
+	}
+}
+
+func TestTieredInvalidationFanOut(t *testing.T) {
+	ctx := context.Background()
+	mr, nodes := newTieredNodes(t, TieredOptions{}, 3)
+	a, b, c := nodes[0], nodes[1], nodes[2]
+
+	if err := a.Set(ctx, "current", &profile{Tenant: "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	waitInvalidations(t, b, 1)
+	waitInvalidations(t, c, 1)
+	for _, n := range []*TieredCache[profile]{b, c} {
+		if p, _ := n.Get(ctx, "current"); p == nil || p.Tenant != "acme" {
+			t.Fatalf("Get() = %+v", p)
+		}
+	}
+
+	// A write drops the local copies of the other nodes
+	if err := a.Set(ctx, "current", &profile{Tenant: "globex"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*TieredCache[profile]{b, c} {
+		waitInvalidations(t, n, 2)
+		if p, _ := n.Get(ctx, "current"); p == nil || p.Tenant != "globex" {
+			t.Fatalf("Get() after the write = %+v", p)
+		}
+	}
+	if n := a.Stats().Invalidations; n != 0 {
+		t.Fatalf("writer applied %d of its own invalidations", n)
+	}
+
+	// Invalidating all keys purges every node but keeps Redis
+	b.Invalidate(ctx)
+	waitInvalidations(t, c, 3)
+	if s := c.Stats(); s.LocalEntries != 0 {
+		t.Fatalf("local entries after invalidating all = %d", s.LocalEntries)
+	}
+	if !mr.Exists("profiles:current") {
+		t.Fatal("Invalidate removed the Redis value")
+	}
+
+	// A delete reaches the other nodes too
+	if err := c.Delete(ctx, "current"); err != nil {
+		t.Fatal(err)
+	}
+	waitInvalidations(t, a, 2)
+	if ok, err := a.Exists(ctx, "current"); ok || err != nil {
+		t.Fatalf("Exists() after delete = %v, %v", ok, err)
+	}
+}
+
+func TestTieredNegativeTTL(t *testing.T) {
+	ctx := context.Background()
+	ttl := 50 * time.Millisecond
+	mr, nodes := newTieredNodes(t, TieredOptions{NegativeTTL: ttl}, 2)
+
+	var loads atomic.Int32
+	missing := func(context.Context) (*profile, error) {
+		loads.Add(1)
+		return nil, nil
+	}
+
+	// A miss is cached in Redis for the other nodes as well
+	for _, n := range nodes {
+		if p, err := n.GetOrLoad(ctx, "deleted", missing); p != nil || err != nil {
+			t.Fatalf("GetOrLoad() = %+v, %v", p, err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want once", n)
+	}
+	if got, _ := mr.Get("profiles:deleted"); got != negativeMarker || mr.TTL("profiles:deleted") != ttl {
+		t.Fatalf("redis holds %q for %v", got, mr.TTL("profiles:deleted"))
+	}
+	if ok, _ := nodes[0].Exists(ctx, "deleted"); ok {
+		t.Fatal("cached miss exists")
+	}
+
+	// Misses expire in both tiers
+	time.Sleep(ttl + 10*time.Millisecond)
+	mr.FastForward(ttl + 10*time.Millisecond)
+	if _, err := nodes[1].GetOrLoad(ctx, "deleted", missing); err != nil {
+		t.Fatal(err)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("loader ran %d times after the miss expired, want twice", n)
+	}
+
+	// Without a negative TTL misses are loaded every time
+	local := NewTieredCache[profile](nil, "profiles")
+	defer local.Close()
+	for range 2 {
+		_, _ = local.GetOrLoad(ctx, "deleted", missing)
+	}
+	if n := loads.Load(); n != 4 {
+		t.Fatalf("loader ran %d times without negative caching, want 4", n)
+	}
+}
+
+func TestTieredLoadAfterInvalidateIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	mr, nodes := newTieredNodes(t, TieredOptions{}, 2)
+	a, b := nodes[0], nodes[1]
+
+	loading, release := make(chan struct{}), make(chan struct{})
+	done := make(chan *profile, 1)
+	go func() {
+		p, err := b.GetOrLoad(ctx, "current", func(context.Context) (*profile, error) {
+			close(loading)
+			<-release
+			return &profile{Tenant: "stale"}, nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- p
+	}()
+
+	// Another node writes while the load reads the source
+	<-loading
+	if err := a.Set(ctx, "current", &profile{Tenant: "fresh"}); err != nil {
+		t.Fatal(err)
+	}
+	waitInvalidations(t, b, 1)
+	close(release)
+	if p := <-done; p == nil || p.Tenant != "stale" {
+		t.Fatalf("load returned %+v", p)
+	}
+
+	// The stale load neither replaces the write in Redis nor is cached locally
+	if got, _ := mr.Get("profiles:current"); got != `{"tenant":"fresh"}` {
+		t.Fatalf("redis holds %q", got)
+	}
+	for _, n := range nodes {
+		if p, _ := n.Get(ctx, "current"); p == nil || p.Tenant != "fresh" {
+			t.Fatalf("Get() after the stale load = %+v", p)
+		}
+	}
+}
+
+func TestTieredGetOrLoadSeparatesTenants(t *testing.T) {
+	c := NewTieredCache[profile](nil, "profiles", TieredOptions{TenantScoped: true})
+	defer c.Close()
+
+	release := make(chan struct{})
+	var loads atomic.Int32
+	loader := func(ctx context.Context) (*profile, error) {
+		loads.Add(1)
+		<-release
+		return &profile{Tenant: tenancy.FromContext(ctx)}, nil
+	}
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"acme", "globex", "acme", "globex"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := tenancy.WithTenant(context.Background(), tenant)
+			p, err := c.GetOrLoad(ctx, "current", loader)
+			if err != nil || p == nil || p.Tenant != tenant {
+				t.Errorf("tenant %s loaded %+v, %v", tenant, p, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("loader ran %d times, want once per tenant", n)
+	}
+	for _, tenant := range []string{"acme", "globex"} {
+		p, err := c.Get(tenancy.WithTenant(context.Background(), tenant), "current")
+		if err != nil || p == nil || p.Tenant != tenant {
+			t.Errorf("cached value of %s = %+v, %v", tenant, p, err)
+		}
+	}
+}
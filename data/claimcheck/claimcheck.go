@@ -0,0 +1,169 @@
+package claimcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// marker starts every reference, so it is readable JSON that payloads are
+// unlikely to start with
+var marker = []byte(`{"$claim_check":`)
+
+// ErrNoStore is returned when a reference is claimed without a store
+var ErrNoStore = errors.New("claimcheck: no store to claim the payload from")
+
+// Store keeps offloaded payloads, e.g. an object storage bucket
+type Store interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Reference is passed through the queue in place of an offloaded payload
+type Reference struct {
+	Key    string `json:"key"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// envelope is the JSON form of a reference
+type envelope struct {
+	Ref Reference `json:"$claim_check"`
+}
+
+// IsReference reports whether body is a claim check
+func IsReference(body []byte) bool {
+	return bytes.HasPrefix(body, marker)
+}
+
+// Parse returns the reference of a claim check
+func Parse(body []byte) (*Reference, error) {
+	if !IsReference(body) {
+		return nil, errors.New("claimcheck: not a reference")
+	}
+	var e envelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("claimcheck: invalid reference: %w", err)
+	}
+	if e.Ref.Key == "" {
+		return nil, errors.New("claimcheck: reference without key")
+	}
+	return &e.Ref, nil
+}
+
+// Stats reports the work of a Checker
+type Stats struct {
+	Offloaded      int64 `json:"offloaded"`
+	OffloadedBytes int64 `json:"offloaded_bytes"`
+	Claimed        int64 `json:"claimed"`
+	Failures       int64 `json:"failures"`
+}
+
+// Options configures a Checker
+type Options struct {
+	Threshold int    // Payloads larger than this many bytes are offloaded, default 1MB
+	Prefix    string // Key prefix in the store, default "claimcheck/"
+}
+
+// Checker offloads payloads over a size threshold to a store and claims
+// them back from their reference. A nil Checker passes payloads through.
+type Checker struct {
+	store     Store
+	threshold int
+	prefix    string
+
+	offloaded, offloadedBytes, claimed, failures atomic.Int64
+}
+
+// New creates a checker offloading to store
+func New(store Store, opts Options) *Checker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 1 << 20
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "claimcheck/"
+	}
+	return &Checker{store: store, threshold: opts.Threshold, prefix: opts.Prefix}
+}
+
+// Offload stores body and returns its reference when it exceeds the
+// threshold, returning it unchanged otherwise
+func (c *Checker) Offload(ctx context.Context, body []byte) ([]byte, error) {
+	if c == nil || len(body) <= c.threshold {
+		return body, nil
+	}
+
+	key, err := c.newKey()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	if err := c.store.Put(ctx, key, body); err != nil {
+		c.failures.Add(1)
+		return nil, fmt.Errorf("claimcheck: failed to offload %d bytes: %w", len(body), err)
+	}
+
+	ref, err := json.Marshal(envelope{Ref: Reference{Key: key, Size: len(body), SHA256: hex.EncodeToString(sum[:])}})
+	if err != nil {
+		return nil, err
+	}
+	c.offloaded.Add(1)
+	c.offloadedBytes.Add(int64(len(body)))
+	return ref, nil
+}
+
+// Claim fetches the payload of a reference and returns other bodies unchanged
+func (c *Checker) Claim(ctx context.Context, body []byte) ([]byte, error) {
+	if !IsReference(body) {
+		return body, nil
+	}
+	ref, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.store == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoStore, ref.Key)
+	}
+
+	payload, err := c.store.Get(ctx, ref.Key)
+	if err != nil {
+		c.failures.Add(1)
+		return nil, fmt.Errorf("claimcheck: failed to claim %s: %w", ref.Key, err)
+	}
+	if sum := sha256.Sum256(payload); ref.SHA256 != "" && hex.EncodeToString(sum[:]) != ref.SHA256 {
+		c.failures.Add(1)
+		return nil, fmt.Errorf("claimcheck: checksum mismatch for %s", ref.Key)
+	}
+	c.claimed.Add(1)
+	return payload, nil
+}
+
+// Stats returns the offload counters
+func (c *Checker) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	return Stats{
+		Offloaded:      c.offloaded.Load(),
+		OffloadedBytes: c.offloadedBytes.Load(),
+		Claimed:        c.claimed.Load(),
+		Failures:       c.failures.Load(),
+	}
+}
+
+// newKey returns a unique key below the prefix, grouped by day so expired
+// payloads can be removed with a bucket lifecycle rule
+func (c *Checker) newKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return c.prefix + time.Now().UTC().Format("2006/01/02/") + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,76 @@
+package claimcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type memStore map[string][]byte
+
+func (m memStore) Put(_ context.Context, key string, body []byte) error {
+	m[key] = append([]byte(nil), body...)
+	return nil
+}
+
+func (m memStore) Get(_ context.Context, key string) ([]byte, error) {
+	body, ok := m[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return body, nil
+}
+
+func TestOffloadAndClaim(t *testing.T) {
+	ctx := context.Background()
+	store := memStore{}
+	c := New(store, Options{Threshold: 16, Prefix: "cc/"})
+
+	small := []byte(`{"id":1}`)
+	if out, _ := c.Offload(ctx, small); !bytes.Equal(out, small) {
+		t.Fatalf("expected small payload unchanged, got %s", out)
+	}
+
+	large := []byte(strings.Repeat("x", 64))
+	ref, err := c.Offload(ctx, large)
+	if err != nil || !IsReference(ref) {
+		t.Fatalf("expected reference, got %s, %v", ref, err)
+	}
+	r, err := Parse(ref)
+	if err != nil || !strings.HasPrefix(r.Key, "cc/") || r.Size != len(large) {
+		t.Fatalf("unexpected reference %+v, %v", r, err)
+	}
+
+	body, err := c.Claim(ctx, ref)
+	if err != nil || !bytes.Equal(body, large) {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if out, _ := c.Claim(ctx, small); !bytes.Equal(out, small) {
+		t.Fatalf("expected plain payload unchanged, got %s", out)
+	}
+
+	store[r.Key] = []byte("tampered")
+	if _, err := c.Claim(ctx, ref); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+
+	if s := c.Stats(); s.Offloaded != 1 || s.Claimed != 1 || s.Failures != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestNilChecker(t *testing.T) {
+	ctx := context.Background()
+	var c *Checker
+	large := []byte(strings.Repeat("x", 64))
+	if out, _ := c.Offload(ctx, large); !bytes.Equal(out, large) {
+		t.Fatal("expected nil checker to pass through")
+	}
+
+	ref, _ := New(memStore{}, Options{Threshold: 1}).Offload(ctx, large)
+	if _, err := c.Claim(ctx, ref); !errors.Is(err, ErrNoStore) {
+		t.Fatalf("expected ErrNoStore, got %v", err)
+	}
+}
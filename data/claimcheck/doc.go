@@ -0,0 +1,21 @@
+// Package claimcheck implements the claim-check pattern for payloads larger
+// than brokers accept: the body is offloaded to a store, such as object
+// storage, and a small reference travels through the queue in its place.
+//
+// The data layer offloads RabbitMQ and Kafka payloads, events included, once
+// data.messaging.claim_check is set and a store is attached, and consumers
+// claim them back before their handler runs:
+//
+//	data:
+//	  messaging:
+//	    claim_check:
+//	      threshold: 262144   # bytes, payloads above are offloaded
+//	      prefix: claimcheck/
+//
+//	d.SetClaimStore(store) // the extension manager attaches the storage section
+//
+// References are JSON, e.g. {"$claim_check":{"key":"claimcheck/2026/10/16/…",
+// "size":5242880,"sha256":"…"}}, and are verified when claimed. Payloads are
+// not deleted on consume since several consumers may claim them; keys are
+// grouped by day for a bucket lifecycle rule.
+package claimcheck
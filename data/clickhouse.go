@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ncobase/ncore/data/metrics"
+)
+
+// errClickHouseUnavailable is returned when ClickHouse is not configured
+var errClickHouseUnavailable = errors.New("clickhouse client not available")
+
+// ClickHouseExec runs a statement on ClickHouse, e.g. DDL or INSERT ... SELECT
+func (d *Data) ClickHouseExec(ctx context.Context, query string, args ...any) error {
+	start := time.Now()
+	client, ok := d.GetClickHouse().(interface {
+		Exec(ctx context.Context, query string, args ...any) error
+	})
+	if !ok {
+		d.observeClickHouse("exec", 0, errClickHouseUnavailable)
+		return errClickHouseUnavailable
+	}
+
+	err := client.Exec(ctx, query, args...)
+	d.observeClickHouse("exec", time.Since(start), err)
+	return err
+}
+
+// ClickHouseInsert sends rows to ClickHouse in one batch, one value per column in
+// each row, e.g. ClickHouseInsert(ctx, "INSERT INTO events (ts, name)", rows)
+func (d *Data) ClickHouseInsert(ctx context.Context, query string, rows [][]any) error {
+	start := time.Now()
+	client, ok := d.GetClickHouse().(interface {
+		InsertBatch(ctx context.Context, query string, rows [][]any) error
+	})
+	if !ok {
+		d.observeClickHouse("insert", 0, errClickHouseUnavailable)
+		return errClickHouseUnavailable
+	}
+
+	err := client.InsertBatch(ctx, query, rows)
+	d.observeClickHouse("insert", time.Since(start), err)
+	return err
+}
+
+// ClickHouseSelect runs a query on ClickHouse and scans all rows into dest, a pointer
+// to a slice of structs with ch tags
+func (d *Data) ClickHouseSelect(ctx context.Context, dest any, query string, args ...any) error {
+	start := time.Now()
+	client, ok := d.GetClickHouse().(interface {
+		Select(ctx context.Context, dest any, query string, args ...any) error
+	})
+	if !ok {
+		d.observeClickHouse("select", 0, errClickHouseUnavailable)
+		return errClickHouseUnavailable
+	}
+
+	err := client.Select(ctx, dest, query, args...)
+	d.observeClickHouse("select", time.Since(start), err)
+	return err
+}
+
+func (d *Data) ClickHouseHealthCheck(ctx context.Context) error {
+	start := time.Now()
+	client, ok := d.GetClickHouse().(interface {
+		Ping(context.Context) error
+	})
+	if !ok {
+		d.collector.HealthCheck("clickhouse", false)
+		return errClickHouseUnavailable
+	}
+
+	err := client.Ping(ctx)
+	d.collector.HealthCheck("clickhouse", err == nil)
+	d.observeClickHouse("health_check", time.Since(start), err)
+	return err
+}
+
+// observeClickHouse records a ClickHouse operation if the collector supports it
+func (d *Data) observeClickHouse(operation string, duration time.Duration, err error) {
+	d.mu.RLock()
+	collector := d.collector
+	d.mu.RUnlock()
+
+	if cc, ok := collector.(metrics.ClickHouseCollector); ok {
+		cc.ClickHouseOperation(operation, duration, err)
+	}
+}
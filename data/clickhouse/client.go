@@ -0,0 +1,146 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	chdriver "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ncobase/ncore/data/config"
+)
+
+// Pool defaults, matching clickhouse-go
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultMaxOpenConn = 10
+	defaultMaxIdleConn = 5
+	defaultMaxLifeTime = time.Hour
+)
+
+// Client is a ClickHouse connection pool
+type Client struct {
+	conn chdriver.Conn
+}
+
+// New opens a connection pool, connecting lazily on first use
+func New(cfg *config.ClickHouse) (*Client, error) {
+	if cfg == nil || len(cfg.Addrs) == 0 {
+		return nil, errors.New("addrs are empty")
+	}
+
+	compression, err := compressionMethod(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &ch.Options{
+		Addr: cfg.Addrs,
+		Auth: ch.Auth{
+			Database: cfg.Database,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		},
+		Compression:     &ch.Compression{Method: compression},
+		DialTimeout:     orDefault(cfg.DialTimeout, defaultDialTimeout),
+		MaxOpenConns:    orDefault(cfg.MaxOpenConn, defaultMaxOpenConn),
+		MaxIdleConns:    orDefault(cfg.MaxIdleConn, defaultMaxIdleConn),
+		ConnMaxLifetime: orDefault(cfg.ConnMaxLifeTime, defaultMaxLifeTime),
+		Debug:           cfg.Debug,
+	}
+	if cfg.Secure {
+		opts.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	conn, err := ch.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %w", err)
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Conn returns the clickhouse-go connection for queries the client does not wrap
+func (c *Client) Conn() chdriver.Conn {
+	return c.conn
+}
+
+// Exec runs a statement, e.g. DDL or INSERT ... SELECT
+func (c *Client) Exec(ctx context.Context, query string, args ...any) error {
+	return c.conn.Exec(ctx, query, args...)
+}
+
+// Select runs a query and scans all rows into dest, a pointer to a slice of structs
+// with ch tags
+func (c *Client) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return c.conn.Select(ctx, dest, query, args...)
+}
+
+// InsertBatch sends rows in one batch, e.g. InsertBatch(ctx, "INSERT INTO events", rows)
+// with one value per column in each row
+func (c *Client) InsertBatch(ctx context.Context, query string, rows [][]any) error {
+	batch, err := c.conn.PrepareBatch(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+	for _, row := range rows {
+		if err := batch.Append(row...); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("failed to append row: %w", err)
+		}
+	}
+	return batch.Send()
+}
+
+// Insert sends structs with ch tags in one batch
+func Insert[T any](ctx context.Context, c *Client, query string, rows []T) error {
+	batch, err := c.conn.PrepareBatch(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare batch: %w", err)
+	}
+	for i := range rows {
+		if err := batch.AppendStruct(&rows[i]); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("failed to append row: %w", err)
+		}
+	}
+	return batch.Send()
+}
+
+// Ping verifies the server is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	return c.conn.Ping(ctx)
+}
+
+// PoolStats returns the number of open and idle connections
+func (c *Client) PoolStats() (open, idle int) {
+	stats := c.conn.Stats()
+	return stats.Open, stats.Idle
+}
+
+// Close closes the connection pool
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func compressionMethod(name string) (ch.CompressionMethod, error) {
+	switch name {
+	case "", "lz4":
+		return ch.CompressionLZ4, nil
+	case "zstd":
+		return ch.CompressionZSTD, nil
+	case "none":
+		return ch.CompressionNone, nil
+	default:
+		return 0, fmt.Errorf("unsupported compression %q", name)
+	}
+}
+
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
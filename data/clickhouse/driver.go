@@ -0,0 +1,96 @@
+// Package clickhouse provides a ClickHouse driver for ncore/data.
+//
+// This driver uses clickhouse-go (github.com/ClickHouse/clickhouse-go/v2) over the
+// native protocol. It registers itself automatically when imported:
+//
+//	import _ "github.com/ncobase/ncore/data/clickhouse"
+//
+// With data.clickhouse configured, the connection is opened with the data layer and
+// shares its lifecycle, health checks and metrics:
+//
+//	ch, err := clickhouse.FromData(d)
+//	if err != nil {
+//	    return err
+//	}
+//	err = ch.InsertBatch(ctx, "INSERT INTO events", rows)
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/config"
+)
+
+// driver implements data.DatabaseDriver for ClickHouse.
+type driver struct{}
+
+// Name returns the driver identifier used in configuration files.
+func (d *driver) Name() string {
+	return "clickhouse"
+}
+
+// Connect opens a connection pool using a *config.ClickHouse and verifies it with a
+// ping. Returns a *Client.
+func (d *driver) Connect(ctx context.Context, cfg any) (any, error) {
+	chCfg, ok := cfg.(*config.ClickHouse)
+	if !ok {
+		return nil, fmt.Errorf("clickhouse: invalid configuration type, expected *config.ClickHouse")
+	}
+
+	client, err := New(chCfg)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse: %w", err)
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("clickhouse: ping failed: %w", err)
+	}
+
+	return client, nil
+}
+
+// Close closes the connection pool.
+func (d *driver) Close(conn any) error {
+	client, ok := conn.(*Client)
+	if !ok {
+		return fmt.Errorf("clickhouse: invalid connection type, expected *clickhouse.Client")
+	}
+
+	if err := client.Close(); err != nil {
+		return fmt.Errorf("clickhouse: failed to close connection: %w", err)
+	}
+
+	return nil
+}
+
+// Ping verifies the ClickHouse connection is alive.
+func (d *driver) Ping(ctx context.Context, conn any) error {
+	client, ok := conn.(*Client)
+	if !ok {
+		return fmt.Errorf("clickhouse: invalid connection type, expected *clickhouse.Client")
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		return fmt.Errorf("clickhouse: ping failed: %w", err)
+	}
+
+	return nil
+}
+
+// FromData returns the ClickHouse client of the data layer
+func FromData(d *data.Data) (*Client, error) {
+	client, ok := d.GetClickHouse().(*Client)
+	if !ok || client == nil {
+		return nil, fmt.Errorf("clickhouse: not configured, set data.clickhouse.addrs")
+	}
+	return client, nil
+}
+
+// init registers the ClickHouse driver with the data package.
+// This function is called automatically when the package is imported.
+func init() {
+	data.RegisterDatabaseDriver(&driver{})
+}
@@ -0,0 +1,47 @@
+package clickhouse_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ncobase/ncore/data"
+	_ "github.com/ncobase/ncore/data/clickhouse" // Register driver
+	"github.com/ncobase/ncore/data/config"
+)
+
+func TestDriverRegistration(t *testing.T) {
+	driver, err := data.GetDatabaseDriver("clickhouse")
+	if err != nil {
+		t.Fatalf("Failed to get clickhouse driver: %v", err)
+	}
+
+	if driver.Name() != "clickhouse" {
+		t.Errorf("Expected driver name 'clickhouse', got '%s'", driver.Name())
+	}
+}
+
+func TestDriverConnect(t *testing.T) {
+	driver, err := data.GetDatabaseDriver("clickhouse")
+	if err != nil {
+		t.Fatalf("Failed to get clickhouse driver: %v", err)
+	}
+
+	t.Run("EmptyAddrs", func(t *testing.T) {
+		if _, err := driver.Connect(context.Background(), &config.ClickHouse{}); err == nil {
+			t.Error("Expected error for empty addrs, got nil")
+		}
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		if _, err := driver.Connect(context.Background(), "invalid"); err == nil {
+			t.Error("Expected error for invalid config type, got nil")
+		}
+	})
+
+	t.Run("InvalidCompression", func(t *testing.T) {
+		cfg := &config.ClickHouse{Addrs: []string{"localhost:9000"}, Compression: "gzip"}
+		if _, err := driver.Connect(context.Background(), cfg); err == nil {
+			t.Error("Expected error for unsupported compression, got nil")
+		}
+	})
+}
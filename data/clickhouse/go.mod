@@ -0,0 +1,10 @@
+module github.com/ncobase/ncore/data/clickhouse
+
+go 1.25.3
+
+require (
+	github.com/ClickHouse/clickhouse-go/v2 v2.40.3
+	github.com/ncobase/ncore/data v0.2.2
+)
+
+replace github.com/ncobase/ncore/data => ../
@@ -0,0 +1,117 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Built-in codec IDs
+const (
+	GzipID   byte = 1
+	SnappyID byte = 2
+	ZstdID   byte = 3
+)
+
+type gzipCodec struct {
+	writers sync.Pool
+}
+
+// Gzip returns the gzip codec
+func Gzip() Codec {
+	return &gzipCodec{}
+}
+
+func (*gzipCodec) Name() string { return "gzip" }
+func (*gzipCodec) ID() byte     { return GzipID }
+
+func (g *gzipCodec) Encode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, ok := g.writers.Get().(*gzip.Writer)
+	if ok {
+		w.Reset(&buf)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	defer g.writers.Put(w)
+
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (*gzipCodec) Decode(src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type snappyCodec struct{}
+
+// Snappy returns the Snappy block format codec
+func Snappy() Codec {
+	return snappyCodec{}
+}
+
+func (snappyCodec) Name() string { return "snappy" }
+func (snappyCodec) ID() byte     { return SnappyID }
+
+func (snappyCodec) Encode(src []byte) ([]byte, error) {
+	return s2.EncodeSnappy(nil, src), nil
+}
+
+func (snappyCodec) Decode(src []byte) ([]byte, error) {
+	return s2.Decode(nil, src)
+}
+
+// zstdCodec shares one encoder and decoder, their EncodeAll and DecodeAll
+// are safe for concurrent use
+type zstdCodec struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+// Zstd returns the Zstandard codec
+func Zstd() Codec {
+	return &zstdCodec{}
+}
+
+func (*zstdCodec) Name() string { return "zstd" }
+func (*zstdCodec) ID() byte     { return ZstdID }
+
+func (z *zstdCodec) init() error {
+	z.once.Do(func() {
+		if z.encoder, z.err = zstd.NewWriter(nil); z.err != nil {
+			return
+		}
+		z.decoder, z.err = zstd.NewReader(nil)
+	})
+	return z.err
+}
+
+func (z *zstdCodec) Encode(src []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.encoder.EncodeAll(src, nil), nil
+}
+
+func (z *zstdCodec) Decode(src []byte) ([]byte, error) {
+	if err := z.init(); err != nil {
+		return nil, err
+	}
+	return z.decoder.DecodeAll(src, nil)
+}
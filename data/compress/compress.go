@@ -0,0 +1,215 @@
+package compress
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// magic starts a compressed payload, followed by the codec ID. Payloads
+// without it are passed through, so values written before compression was
+// enabled, or below the threshold, stay readable. JSON never starts with 0xff.
+var magic = [2]byte{0xff, 'Z'}
+
+// headerSize is the length of magic and the codec ID
+const headerSize = len(magic) + 1
+
+// textPrefix marks a compressed payload encoded with base64 for text columns
+const textPrefix = "~z:"
+
+// ErrUnknownCodec is returned for payloads compressed with an unregistered codec
+var ErrUnknownCodec = errors.New("compress: unknown codec")
+
+// Codec compresses payloads
+type Codec interface {
+	// Name identifies the codec in configuration, e.g. "zstd"
+	Name() string
+	// ID is written to the header of every payload, unique per codec
+	ID() byte
+	Encode(src []byte) ([]byte, error)
+	Decode(src []byte) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	byName   = make(map[string]Codec)
+	byID     = make(map[byte]Codec)
+)
+
+func init() {
+	Register(Gzip())
+	Register(Snappy())
+	Register(Zstd())
+}
+
+// Register adds a codec. IDs 1 to 15 are reserved for built-in codecs.
+func Register(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	byName[c.Name()] = c
+	byID[c.ID()] = c
+}
+
+// Lookup returns the codec registered under name
+func Lookup(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := byName[name]
+	return c, ok
+}
+
+// Codecs returns the names of the registered codecs
+func Codecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsCompressed reports whether data starts with a compression header
+func IsCompressed(data []byte) bool {
+	return len(data) >= headerSize && data[0] == magic[0] && data[1] == magic[1]
+}
+
+// Stats reports the work of a Compressor
+type Stats struct {
+	Codec    string  `json:"codec"`
+	Encoded  int64   `json:"encoded"` // Payloads compressed
+	Skipped  int64   `json:"skipped"` // Payloads below the threshold or not smaller compressed
+	Decoded  int64   `json:"decoded"`
+	BytesIn  int64   `json:"bytes_in"`  // Size of compressed payloads before compression
+	BytesOut int64   `json:"bytes_out"` // Size of compressed payloads after compression
+	Ratio    float64 `json:"ratio"`     // BytesOut / BytesIn, lower is better
+}
+
+// Options configures a Compressor
+type Options struct {
+	Codec     string // Registered codec name, default "zstd"
+	Threshold int    // Payloads smaller than this are stored as is, default 1024
+}
+
+// Compressor compresses payloads over a size threshold with one codec and
+// decompresses payloads of any registered codec. A nil Compressor passes
+// payloads through both ways, to stop compressing while compressed payloads
+// are still stored or queued raise the threshold instead.
+type Compressor struct {
+	codec     Codec
+	threshold int
+
+	encoded, skipped, decoded, bytesIn, bytesOut atomic.Int64
+}
+
+// New creates a compressor
+func New(opts Options) (*Compressor, error) {
+	if opts.Codec == "" {
+		opts.Codec = "zstd"
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 1024
+	}
+	codec, ok := Lookup(opts.Codec)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, opts.Codec)
+	}
+	return &Compressor{codec: codec, threshold: opts.Threshold}, nil
+}
+
+// Encode compresses data when it reaches the threshold and gets smaller,
+// returning it unchanged otherwise
+func (c *Compressor) Encode(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+	if len(data) < c.threshold {
+		c.skipped.Add(1)
+		return data, nil
+	}
+
+	body, err := c.codec.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("compress: %s: %w", c.codec.Name(), err)
+	}
+	if len(body)+headerSize >= len(data) {
+		c.skipped.Add(1)
+		return data, nil
+	}
+
+	out := make([]byte, headerSize+len(body))
+	out[0], out[1], out[2] = magic[0], magic[1], c.codec.ID()
+	copy(out[headerSize:], body)
+
+	c.encoded.Add(1)
+	c.bytesIn.Add(int64(len(data)))
+	c.bytesOut.Add(int64(len(out)))
+	return out, nil
+}
+
+// Decode decompresses data written by Encode with any registered codec and
+// returns other data unchanged. A nil Compressor decodes nothing, so binary
+// payloads starting like the header pass through where compression is off.
+func (c *Compressor) Decode(data []byte) ([]byte, error) {
+	if c == nil || !IsCompressed(data) {
+		return data, nil
+	}
+	codecsMu.RLock()
+	codec, ok := byID[data[2]]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCodec, data[2])
+	}
+
+	out, err := codec.Decode(data[headerSize:])
+	if err != nil {
+		return nil, fmt.Errorf("compress: %s: %w", codec.Name(), err)
+	}
+	c.decoded.Add(1)
+	return out, nil
+}
+
+// EncodeString is Encode for text storage, compressed payloads are base64
+// encoded behind a prefix
+func (c *Compressor) EncodeString(data []byte) (string, error) {
+	out, err := c.Encode(data)
+	if err != nil || !IsCompressed(out) {
+		return string(out), err
+	}
+	return textPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// DecodeString decodes a value written by EncodeString
+func (c *Compressor) DecodeString(s string) ([]byte, error) {
+	if c == nil || len(s) < len(textPrefix) || s[:len(textPrefix)] != textPrefix {
+		return []byte(s), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(s[len(textPrefix):])
+	if err != nil {
+		return nil, fmt.Errorf("compress: invalid text payload: %w", err)
+	}
+	return c.Decode(data)
+}
+
+// Stats returns the compression counters
+func (c *Compressor) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	s := Stats{
+		Codec:    c.codec.Name(),
+		Encoded:  c.encoded.Load(),
+		Skipped:  c.skipped.Load(),
+		Decoded:  c.decoded.Load(),
+		BytesIn:  c.bytesIn.Load(),
+		BytesOut: c.bytesOut.Load(),
+	}
+	if s.BytesIn > 0 {
+		s.Ratio = float64(s.BytesOut) / float64(s.BytesIn)
+	}
+	return s
+}
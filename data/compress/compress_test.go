@@ -0,0 +1,103 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var payload = []byte(strings.Repeat(`{"id":"42","name":"order","status":"paid"},`, 100))
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range Codecs() {
+		c, err := New(Options{Codec: name})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		enc, err := c.Encode(payload)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", name, err)
+		}
+		if !IsCompressed(enc) || len(enc) >= len(payload) {
+			t.Fatalf("%s: expected compressed payload, got %d bytes", name, len(enc))
+		}
+		dec, err := c.Decode(enc)
+		if err != nil || !bytes.Equal(dec, payload) {
+			t.Fatalf("%s: round trip failed: %v", name, err)
+		}
+	}
+}
+
+func TestThresholdAndPassthrough(t *testing.T) {
+	c, err := New(Options{Codec: "snappy", Threshold: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	small := []byte(`{"id":"42"}`)
+	if out, _ := c.Encode(small); !bytes.Equal(out, small) {
+		t.Fatalf("expected small payload unchanged, got %q", out)
+	}
+	if out, _ := c.Decode(small); !bytes.Equal(out, small) {
+		t.Fatalf("expected legacy payload unchanged, got %q", out)
+	}
+
+	// Any registered codec decodes, whatever the compressor writes
+	gz, _ := New(Options{Codec: "gzip"})
+	enc, _ := gz.Encode(payload)
+	if dec, err := c.Decode(enc); err != nil || !bytes.Equal(dec, payload) {
+		t.Fatalf("expected gzip payload decoded, got %v", err)
+	}
+
+	var nilc *Compressor
+	if out, _ := nilc.Encode(payload); !bytes.Equal(out, payload) {
+		t.Fatal("expected nil compressor to pass through")
+	}
+	// Without compression payloads that look compressed are not decoded
+	if dec, err := nilc.Decode(enc); err != nil || !bytes.Equal(dec, enc) {
+		t.Fatalf("expected nil compressor to pass through, got %v", err)
+	}
+	if dec, err := nilc.DecodeString(textPrefix + "AAAA"); err != nil || string(dec) != textPrefix+"AAAA" {
+		t.Fatalf("expected nil compressor to pass text through, got %q, %v", dec, err)
+	}
+}
+
+func TestUnknownCodec(t *testing.T) {
+	if _, err := New(Options{Codec: "lz4"}); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("expected ErrUnknownCodec, got %v", err)
+	}
+	c, _ := New(Options{})
+	if _, err := c.Decode([]byte{magic[0], magic[1], 99, 0}); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("expected ErrUnknownCodec, got %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	c, _ := New(Options{})
+	s, err := c.EncodeString(payload)
+	if err != nil || !strings.HasPrefix(s, textPrefix) {
+		t.Fatalf("expected text payload, got %q, %v", s[:min(len(s), 8)], err)
+	}
+	dec, err := c.DecodeString(s)
+	if err != nil || !bytes.Equal(dec, payload) {
+		t.Fatalf("string round trip failed: %v", err)
+	}
+	if dec, _ := c.DecodeString(`{"id":"42"}`); string(dec) != `{"id":"42"}` {
+		t.Fatalf("expected plain text unchanged, got %q", dec)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c, _ := New(Options{Codec: "zstd", Threshold: 64})
+	enc, _ := c.Encode(payload)
+	_, _ = c.Encode([]byte("tiny"))
+	_, _ = c.Decode(enc)
+
+	s := c.Stats()
+	if s.Codec != "zstd" || s.Encoded != 1 || s.Skipped != 1 || s.Decoded != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.BytesIn != int64(len(payload)) || s.BytesOut != int64(len(enc)) || s.Ratio <= 0 || s.Ratio >= 1 {
+		t.Fatalf("unexpected sizes %+v", s)
+	}
+}
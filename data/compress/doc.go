@@ -0,0 +1,17 @@
+// Package compress compresses queue payloads, cache values and stored event
+// payloads over a size threshold with gzip, snappy, zstd or a registered codec.
+//
+// Compressed payloads start with a three byte header naming the codec, so a
+// Compressor decodes payloads of every registered codec, and payloads without
+// the header, written before compression was enabled or below the threshold,
+// are returned unchanged:
+//
+//	cp, err := compress.New(compress.Options{Codec: "zstd", Threshold: 1024})
+//	body, err := cp.Encode(payload)
+//	payload, err = cp.Decode(body)
+//
+// A nil Compressor neither encodes nor decodes, so payloads that only look
+// compressed are left alone where compression is off. Messaging compression is
+// configured under data.messaging.compression, optionally for some topics, and
+// exposed by data.Data.Compressor. Stats reports the compression ratio.
+package compress
@@ -0,0 +1,20 @@
+package config
+
+import "github.com/spf13/viper"
+
+// ClaimCheck represents the offloading of large queue payloads to object storage
+type ClaimCheck struct {
+	Threshold int    `yaml:"threshold" json:"threshold" validate:"min=0"` // Payloads larger than this many bytes are offloaded
+	Prefix    string `yaml:"prefix" json:"prefix"`                        // Key prefix in the bucket
+}
+
+// getClaimCheckConfig reads the claim check settings, nil when they are not set
+func getClaimCheckConfig(v *viper.Viper) *ClaimCheck {
+	if !v.IsSet("data.messaging.claim_check") {
+		return nil
+	}
+	return &ClaimCheck{
+		Threshold: getIntOrDefault(v, "data.messaging.claim_check.threshold", 1<<20),
+		Prefix:    getStringOrDefault(v, "data.messaging.claim_check.prefix", "claimcheck/"),
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ClickHouse clickhouse config struct
+type ClickHouse struct {
+	Addrs           []string      `json:"addrs" yaml:"addrs"` // host:port of the native protocol, e.g. localhost:9000
+	Database        string        `json:"database" yaml:"database"`
+	Username        string        `json:"username" yaml:"username"`
+	Password        string        `json:"password" yaml:"password"`
+	Secure          bool          `json:"secure" yaml:"secure"`           // TLS
+	Compression     string        `json:"compression" yaml:"compression"` // lz4, zstd or none, default lz4
+	DialTimeout     time.Duration `json:"dial_timeout" yaml:"dial_timeout"`
+	MaxIdleConn     int           `json:"max_idle_conn" yaml:"max_idle_conn"`
+	MaxOpenConn     int           `json:"max_open_conn" yaml:"max_open_conn"`
+	ConnMaxLifeTime time.Duration `json:"conn_max_life_time" yaml:"conn_max_life_time"`
+	Debug           bool          `json:"debug" yaml:"debug"`
+}
+
+// getClickHouseConfigs reads ClickHouse configurations
+func getClickHouseConfigs(v *viper.Viper) *ClickHouse {
+	return &ClickHouse{
+		Addrs:           v.GetStringSlice("data.clickhouse.addrs"),
+		Database:        v.GetString("data.clickhouse.database"),
+		Username:        v.GetString("data.clickhouse.username"),
+		Password:        v.GetString("data.clickhouse.password"),
+		Secure:          v.GetBool("data.clickhouse.secure"),
+		Compression:     v.GetString("data.clickhouse.compression"),
+		DialTimeout:     v.GetDuration("data.clickhouse.dial_timeout"),
+		MaxIdleConn:     v.GetInt("data.clickhouse.max_idle_conn"),
+		MaxOpenConn:     v.GetInt("data.clickhouse.max_open_conn"),
+		ConnMaxLifeTime: v.GetDuration("data.clickhouse.conn_max_life_time"),
+		Debug:           v.GetBool("data.clickhouse.debug"),
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import "github.com/spf13/viper"
+
+// Compression represents payload compression settings
+type Compression struct {
+	Codec     string `yaml:"codec" json:"codec"`                          // gzip, snappy, zstd or a registered codec
+	Threshold int    `yaml:"threshold" json:"threshold" validate:"min=0"` // Payloads smaller than this many bytes stay uncompressed
+	// Topics limits compression to these Kafka topics, RabbitMQ exchanges,
+	// routing keys and queues, all when empty. Consumers of other queues never
+	// decode, so binary payloads of other producers are left alone.
+	Topics []string `yaml:"topics" json:"topics,omitempty"`
+}
+
+// getCompressionConfig reads the compression settings under key, nil when they are not set
+func getCompressionConfig(v *viper.Viper, key string) *Compression {
+	if !v.IsSet(key) {
+		return nil
+	}
+	return &Compression{
+		Codec:     getStringOrDefault(v, key+".codec", "zstd"),
+		Threshold: getIntOrDefault(v, key+".threshold", 1024),
+		Topics:    v.GetStringSlice(key + ".topics"),
+	}
+}
@@ -12,8 +12,15 @@ type Database struct {
 	Master   *DBNode   `json:"master" yaml:"master"`
 	Slaves   []*DBNode `json:"slaves" yaml:"slaves"`
 	Migrate  bool      `json:"migrate" yaml:"migrate"`
-	Strategy string    `json:"strategy" yaml:"strategy"`
-	MaxRetry int       `json:"max_retry" yaml:"max_retry"`
+	Strategy string    `json:"strategy" yaml:"strategy" validate:"omitempty,oneof=round_robin random weight least_lag"` // round_robin, random, weight or least_lag
+	MaxRetry int       `json:"max_retry" yaml:"max_retry" validate:"min=0"`
+	// MaxLag excludes slaves further behind from reads, 0 disables the check
+	MaxLag           time.Duration `json:"max_lag" yaml:"max_lag"`
+	LagProbeInterval time.Duration `json:"lag_probe_interval" yaml:"lag_probe_interval"`
+	// ReadYourWrites keeps reads of a session on the master this long after it writes
+	ReadYourWrites time.Duration `json:"read_your_writes" yaml:"read_your_writes"`
+	// Hedge sends slow reads to a second slave as well
+	Hedge *Hedge `json:"hedge,omitempty" yaml:"hedge,omitempty"`
 }
 
 // DBNode represents a single database node configuration
@@ -21,10 +28,11 @@ type DBNode struct {
 	Driver          string        `json:"driver" yaml:"driver"`
 	Source          string        `json:"source" yaml:"source"`
 	Logging         bool          `json:"logging" yaml:"logging"`
-	MaxIdleConn     int           `json:"max_idle_conn" yaml:"max_idle_conn"`
-	MaxOpenConn     int           `json:"max_open_conn" yaml:"max_open_conn"`
+	MaxIdleConn     int           `json:"max_idle_conn" yaml:"max_idle_conn" validate:"min=0"`
+	MaxOpenConn     int           `json:"max_open_conn" yaml:"max_open_conn" validate:"min=0"`
 	ConnMaxLifeTime time.Duration `json:"conn_max_life_time" yaml:"conn_max_life_time"`
-	Weight          int           `json:"weight" yaml:"weight"`
+	Weight          int           `json:"weight" yaml:"weight" validate:"min=0"`
+	SQLite          *SQLite       `json:"sqlite,omitempty" yaml:"sqlite,omitempty"` // Pragmas of the sqlite driver
 }
 
 // getDatabaseConfig reads database configurations
@@ -35,6 +43,11 @@ func getDatabaseConfig(v *viper.Viper) *Database {
 		Migrate:  v.GetBool("data.database.migrate"),
 		Strategy: v.GetString("data.database.strategy"),
 		MaxRetry: v.GetInt("data.database.max_retry"),
+
+		MaxLag:           v.GetDuration("data.database.max_lag"),
+		LagProbeInterval: v.GetDuration("data.database.lag_probe_interval"),
+		ReadYourWrites:   v.GetDuration("data.database.read_your_writes"),
+		Hedge:            getHedgeConfig(v, "data.database.hedge"),
 	}
 }
 
@@ -48,6 +61,7 @@ func getMasterConfig(v *viper.Viper) *DBNode {
 		MaxOpenConn:     v.GetInt("data.database.master.max_open_conn"),
 		ConnMaxLifeTime: v.GetDuration("data.database.master.max_life_time"),
 		Weight:          v.GetInt("data.database.master.weight"),
+		SQLite:          getSQLiteConfig(v, "data.database.master.sqlite"),
 	}
 }
 
@@ -75,6 +89,7 @@ func getSlaveConfigs(v *viper.Viper) []*DBNode {
 			MaxOpenConn:     v.GetInt(fmt.Sprintf("data.database.slaves.%d.max_open_conn", i)),
 			ConnMaxLifeTime: v.GetDuration(fmt.Sprintf("data.database.slaves.%d.max_life_time", i)),
 			Weight:          v.GetInt(fmt.Sprintf("data.database.slaves.%d.weight", i)),
+			SQLite:          getSQLiteConfig(v, fmt.Sprintf("data.database.slaves.%d.sqlite", i)),
 		}
 		slaves = append(slaves, slave)
 	}
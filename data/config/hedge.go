@@ -0,0 +1,34 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Hedge represents hedged read configuration. A read that has not answered within
+// the latency percentile is sent to a second replica or engine as well, the first
+// answer wins. Zero values use the defaults of the hedge package.
+type Hedge struct {
+	Enabled    bool          `yaml:"enabled" json:"enabled"`
+	Percentile float64       `yaml:"percentile" json:"percentile"` // Latency percentile after which a read is hedged, e.g. 0.95
+	MinDelay   time.Duration `yaml:"min_delay" json:"min_delay"`
+	MaxDelay   time.Duration `yaml:"max_delay" json:"max_delay"`
+	MaxRate    float64       `yaml:"max_rate" json:"max_rate"` // Share of reads that may be hedged, e.g. 0.1
+	Window     int           `yaml:"window" json:"window"`     // Number of recent latencies the percentile is taken over
+}
+
+// getHedgeConfig reads the hedged read settings under key, nil when they are not set
+func getHedgeConfig(v *viper.Viper, key string) *Hedge {
+	if !v.IsSet(key) {
+		return nil
+	}
+	return &Hedge{
+		Enabled:    v.GetBool(key + ".enabled"),
+		Percentile: v.GetFloat64(key + ".percentile"),
+		MinDelay:   v.GetDuration(key + ".min_delay"),
+		MaxDelay:   v.GetDuration(key + ".max_delay"),
+		MaxRate:    v.GetFloat64(key + ".max_rate"),
+		Window:     v.GetInt(key + ".window"),
+	}
+}
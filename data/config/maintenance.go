@@ -0,0 +1,98 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Maintenance represents the built-in maintenance tasks of the data layer.
+// A task is scheduled when its section is set.
+type Maintenance struct {
+	Enabled        bool                `yaml:"enabled" json:"enabled"`
+	Vacuum         *VacuumTask         `yaml:"vacuum" json:"vacuum"`
+	SearchOptimize *SearchOptimizeTask `yaml:"search_optimize" json:"search_optimize"`
+	RedisMemory    *RedisMemoryTask    `yaml:"redis_memory" json:"redis_memory"`
+	SessionPurge   *SessionPurgeTask   `yaml:"session_purge" json:"session_purge"`
+}
+
+// VacuumTask vacuums and analyzes Postgres tables
+type VacuumTask struct {
+	Schedule    string        `yaml:"schedule" json:"schedule"`
+	Timeout     time.Duration `yaml:"timeout" json:"timeout"`
+	Tables      []string      `yaml:"tables" json:"tables"`             // Tables to process, empty for tables over DeadRatio
+	AnalyzeOnly bool          `yaml:"analyze_only" json:"analyze_only"` // Refresh planner statistics without vacuuming
+	DeadRatio   float64       `yaml:"dead_ratio" json:"dead_ratio" validate:"min=0,max=1"`
+}
+
+// SearchOptimizeTask merges the segments of search indices
+type SearchOptimizeTask struct {
+	Schedule    string        `yaml:"schedule" json:"schedule"`
+	Timeout     time.Duration `yaml:"timeout" json:"timeout"`
+	Indices     []string      `yaml:"indices" json:"indices"` // Index names, patterns like logs-* allowed
+	MaxSegments int           `yaml:"max_segments" json:"max_segments" validate:"min=0"`
+}
+
+// RedisMemoryTask analyzes Redis memory usage
+type RedisMemoryTask struct {
+	Schedule string        `yaml:"schedule" json:"schedule"`
+	Timeout  time.Duration `yaml:"timeout" json:"timeout"`
+	Sample   int           `yaml:"sample" json:"sample" validate:"min=0"`             // Keys sampled for the largest keys
+	Top      int           `yaml:"top" json:"top" validate:"min=0"`                   // Largest keys reported
+	MaxUsage float64       `yaml:"max_usage" json:"max_usage" validate:"min=0,max=1"` // Share of maxmemory that fails the run
+}
+
+// SessionPurgeTask deletes expired rows, e.g. of a sessions table
+type SessionPurgeTask struct {
+	Schedule  string        `yaml:"schedule" json:"schedule"`
+	Timeout   time.Duration `yaml:"timeout" json:"timeout"`
+	Table     string        `yaml:"table" json:"table"`
+	Column    string        `yaml:"column" json:"column"` // Expiry timestamp column
+	BatchSize int           `yaml:"batch_size" json:"batch_size" validate:"min=0"`
+}
+
+// getMaintenanceConfig reads data.maintenance, nil when it is not set
+func getMaintenanceConfig(v *viper.Viper) *Maintenance {
+	const key = "data.maintenance"
+	if !v.IsSet(key) {
+		return nil
+	}
+
+	m := &Maintenance{Enabled: v.GetBool(key + ".enabled")}
+	if v.IsSet(key + ".vacuum") {
+		m.Vacuum = &VacuumTask{
+			Schedule:    getStringOrDefault(v, key+".vacuum.schedule", "0 3 * * *"),
+			Timeout:     v.GetDuration(key + ".vacuum.timeout"),
+			Tables:      v.GetStringSlice(key + ".vacuum.tables"),
+			AnalyzeOnly: v.GetBool(key + ".vacuum.analyze_only"),
+			DeadRatio:   getFloat64OrDefault(v, key+".vacuum.dead_ratio", 0.1),
+		}
+	}
+	if v.IsSet(key + ".search_optimize") {
+		m.SearchOptimize = &SearchOptimizeTask{
+			Schedule:    getStringOrDefault(v, key+".search_optimize.schedule", "0 4 * * 0"),
+			Timeout:     v.GetDuration(key + ".search_optimize.timeout"),
+			Indices:     v.GetStringSlice(key + ".search_optimize.indices"),
+			MaxSegments: getIntOrDefault(v, key+".search_optimize.max_segments", 1),
+		}
+	}
+	if v.IsSet(key + ".redis_memory") {
+		m.RedisMemory = &RedisMemoryTask{
+			Schedule: getStringOrDefault(v, key+".redis_memory.schedule", "@every 15m"),
+			Timeout:  v.GetDuration(key + ".redis_memory.timeout"),
+			Sample:   getIntOrDefault(v, key+".redis_memory.sample", 1000),
+			Top:      getIntOrDefault(v, key+".redis_memory.top", 10),
+			MaxUsage: getFloat64OrDefault(v, key+".redis_memory.max_usage", 0.9),
+		}
+	}
+	if v.IsSet(key + ".session_purge") {
+		m.SessionPurge = &SessionPurgeTask{
+			Schedule:  getStringOrDefault(v, key+".session_purge.schedule", "@hourly"),
+			Timeout:   v.GetDuration(key + ".session_purge.timeout"),
+			Table:     getStringOrDefault(v, key+".session_purge.table", "sessions"),
+			Column:    getStringOrDefault(v, key+".session_purge.column", "expires_at"),
+			BatchSize: getIntOrDefault(v, key+".session_purge.batch_size", 1000),
+		}
+	}
+	return m
+}
@@ -14,6 +14,10 @@ type Messaging struct {
 	RetryAttempts    int           `json:"retry_attempts" yaml:"retry_attempts"`
 	RetryBackoffMax  time.Duration `json:"retry_backoff_max" yaml:"retry_backoff_max"`
 	FallbackToMemory bool          `json:"fallback_to_memory" yaml:"fallback_to_memory"`
+	// Compression compresses queue payloads, consumers decode them transparently
+	Compression *Compression `json:"compression,omitempty" yaml:"compression,omitempty"`
+	// ClaimCheck offloads payloads too large for the brokers to object storage
+	ClaimCheck *ClaimCheck `json:"claim_check,omitempty" yaml:"claim_check,omitempty"`
 }
 
 // getMessagingConfig reads messaging config
@@ -25,6 +29,8 @@ func getMessagingConfig(v *viper.Viper) *Messaging {
 		RetryAttempts:    getMessagingRetryAttempts(v),
 		RetryBackoffMax:  getMessagingRetryBackoffMax(v),
 		FallbackToMemory: getMessagingFallbackToMemory(v),
+		Compression:      getCompressionConfig(v, "data.messaging.compression"),
+		ClaimCheck:       getClaimCheckConfig(v),
 	}
 }
 
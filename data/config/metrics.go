@@ -1,6 +1,10 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Metrics data metrics config
 type Metrics struct {
@@ -47,3 +51,19 @@ func getIntOrDefault(v *viper.Viper, key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getDurationOrDefault returns duration value or default
+func getDurationOrDefault(v *viper.Viper, key string, defaultValue time.Duration) time.Duration {
+	if v.IsSet(key) {
+		return v.GetDuration(key)
+	}
+	return defaultValue
+}
+
+// getFloat64OrDefault returns float64 value or default
+func getFloat64OrDefault(v *viper.Viper, key string, defaultValue float64) float64 {
+	if v.IsSet(key) {
+		return v.GetFloat64(key)
+	}
+	return defaultValue
+}
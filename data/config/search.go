@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,6 +17,27 @@ type Search struct {
 	Meilisearch     *Meilisearch   `yaml:"meilisearch" json:"meilisearch"`
 	Elasticsearch   *Elasticsearch `yaml:"elasticsearch" json:"elasticsearch"`
 	OpenSearch      *OpenSearch    `yaml:"opensearch" json:"opensearch"`
+	Memory          *MemorySearch  `yaml:"memory" json:"memory"`
+	Failover        *Failover      `yaml:"failover" json:"failover"`
+	Hedge           *Hedge         `yaml:"hedge" json:"hedge"` // Sends slow searches to a second engine as well
+}
+
+// MemorySearch represents the in-memory search engine configuration. The engine
+// serves search when no other engine is configured or default_engine is "memory".
+type MemorySearch struct {
+	Enabled       bool          `yaml:"enabled" json:"enabled"`
+	Path          string        `yaml:"path" json:"path"` // Snapshot directory, in memory only when empty
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval"`
+}
+
+// Failover represents runtime search engine failover configuration
+type Failover struct {
+	Enabled        bool          `yaml:"enabled" json:"enabled"`
+	ErrorThreshold int           `yaml:"error_threshold" json:"error_threshold"`
+	ProbeInterval  time.Duration `yaml:"probe_interval" json:"probe_interval"`
+	MinScore       float64       `yaml:"min_score" json:"min_score"`           // Health score below which the engine is failed over
+	FailbackScore  float64       `yaml:"failback_score" json:"failback_score"` // Health score a preferred engine needs to be failed back to
+	SlowThreshold  time.Duration `yaml:"slow_threshold" json:"slow_threshold"` // Health check latency above which scores drop
 }
 
 // IndexSettings represents default index configuration
@@ -38,6 +60,9 @@ func getSearchConfig(v *viper.Viper) *Search {
 			Meilisearch:     getMeilisearchConfigs(v),
 			Elasticsearch:   getElasticsearchConfigs(v),
 			OpenSearch:      getOpenSearchConfigs(v),
+			Memory:          getMemorySearchConfig(v),
+			Failover:        getSearchFailover(v),
+			Hedge:           getHedgeConfig(v, "data.search.hedge"),
 		}
 	}
 
@@ -49,6 +74,34 @@ func getSearchConfig(v *viper.Viper) *Search {
 		Meilisearch:     getMeilisearchConfigs(v),
 		Elasticsearch:   getElasticsearchConfigs(v),
 		OpenSearch:      getOpenSearchConfigs(v),
+		Memory:          getMemorySearchConfig(v),
+		Failover:        getSearchFailover(v),
+		Hedge:           getHedgeConfig(v, "data.search.hedge"),
+	}
+}
+
+// getSearchFailover gets search engine failover settings
+func getSearchFailover(v *viper.Viper) *Failover {
+	return &Failover{
+		Enabled:        v.GetBool("data.search.failover.enabled"),
+		ErrorThreshold: getIntOrDefault(v, "data.search.failover.error_threshold", 3),
+		ProbeInterval:  getDurationOrDefault(v, "data.search.failover.probe_interval", 30*time.Second),
+		MinScore:       v.GetFloat64("data.search.failover.min_score"),
+		FailbackScore:  v.GetFloat64("data.search.failover.failback_score"),
+		SlowThreshold:  v.GetDuration("data.search.failover.slow_threshold"),
+	}
+}
+
+// getMemorySearchConfig gets in-memory search engine settings
+func getMemorySearchConfig(v *viper.Viper) *MemorySearch {
+	enabled := true
+	if v.IsSet("data.search.memory.enabled") {
+		enabled = v.GetBool("data.search.memory.enabled")
+	}
+	return &MemorySearch{
+		Enabled:       enabled,
+		Path:          v.GetString("data.search.memory.path"),
+		FlushInterval: getDurationOrDefault(v, "data.search.memory.flush_interval", 30*time.Second),
 	}
 }
 
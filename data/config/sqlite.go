@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SQLite sqlite pragmas applied to every connection of a sqlite node
+type SQLite struct {
+	JournalMode string        `json:"journal_mode" yaml:"journal_mode"` // DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF, default WAL
+	BusyTimeout time.Duration `json:"busy_timeout" yaml:"busy_timeout"` // Wait on a locked database, default 5s
+	ForeignKeys bool          `json:"foreign_keys" yaml:"foreign_keys"`
+	Synchronous string        `json:"synchronous" yaml:"synchronous"` // OFF, NORMAL, FULL or EXTRA, default NORMAL in WAL mode
+	CacheSize   int           `json:"cache_size" yaml:"cache_size"`   // Pages, or KiB when negative, sqlite default when 0
+}
+
+// getSQLiteConfig reads the sqlite options of the database node at key, nil when unset
+func getSQLiteConfig(v *viper.Viper, key string) *SQLite {
+	if !v.IsSet(key) {
+		return nil
+	}
+	return &SQLite{
+		JournalMode: v.GetString(key + ".journal_mode"),
+		BusyTimeout: v.GetDuration(key + ".busy_timeout"),
+		ForeignKeys: v.GetBool(key + ".foreign_keys"),
+		Synchronous: v.GetString(key + ".synchronous"),
+		CacheSize:   v.GetInt(key + ".cache_size"),
+	}
+}
@@ -0,0 +1,26 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ncobase/ncore/data/config"
+)
+
+func newClickHouseClient(conf *config.ClickHouse) (any, error) {
+	if driverRegistry == nil {
+		return nil, fmt.Errorf("driver registry not initialized, ensure drivers are imported")
+	}
+
+	driver, err := driverRegistry.GetDatabaseDriver("clickhouse")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clickhouse driver: %w", err)
+	}
+
+	conn, err := driver.Connect(context.Background(), conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect using clickhouse driver: %w", err)
+	}
+
+	return conn, nil
+}
@@ -18,6 +18,7 @@ type Connections struct {
 	OS     any
 	MGM    any
 	Neo    any
+	CH     any
 	RMQ    any
 	KFK    any
 	closed bool
@@ -85,6 +86,13 @@ func New(conf *config.Config) (*Connections, error) {
 		}
 	}
 
+	if conf.ClickHouse != nil && len(conf.ClickHouse.Addrs) > 0 {
+		c.CH, err = newClickHouseClient(conf.ClickHouse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if conf.Messaging != nil && conf.Messaging.IsEnabled() {
 		if conf.RabbitMQ != nil && conf.RabbitMQ.URL != "" {
 			c.RMQ, err = newRabbitMQConnection(conf.RabbitMQ)
@@ -150,6 +158,15 @@ func (d *Connections) Close() (errs []error) {
 		d.Neo = nil
 	}
 
+	if d.CH != nil {
+		if closer, ok := d.CH.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, errors.New("clickhouse close error: "+err.Error()))
+			}
+		}
+		d.CH = nil
+	}
+
 	if d.RMQ != nil {
 		if conn, ok := d.RMQ.(interface {
 			IsClosed() bool
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ncobase/ncore/data/config"
 )
@@ -69,6 +70,15 @@ type DBManager struct {
 	mutex      sync.RWMutex
 	maxRetry   int
 	currentIdx uint64 // for round robin
+
+	// Replica lag awareness
+	replicas       map[*sql.DB]*replica
+	maxLag         time.Duration
+	readYourWrites time.Duration
+	pins           sync.Map // session -> time.Time reads stay on master until
+	lagObserver    atomic.Pointer[LagObserver]
+	stopProbe      chan struct{}
+	probeDone      chan struct{}
 }
 
 // LoadBalancer LoadBalancer interface
@@ -172,13 +182,15 @@ func NewDBManager(conf *config.Database) (*DBManager, error) {
 
 	// Initialize slave database connections
 	var slaves []*sql.DB
-	for _, slaveCfg := range conf.Slaves {
+	replicas := make(map[*sql.DB]*replica)
+	for i, slaveCfg := range conf.Slaves {
 		slave, err := newDBClient(slaveCfg)
 		if err != nil {
 			fmt.Printf("Failed to connect to slave DB: %v", err)
 			continue
 		}
 		slaves = append(slaves, slave)
+		replicas[slave] = newReplica(fmt.Sprintf("slave-%d", i), slaveCfg.Driver)
 	}
 
 	// if no slave database is available, use master
@@ -195,16 +207,31 @@ func NewDBManager(conf *config.Database) (*DBManager, error) {
 		strategy = &RandomBalancer{}
 	case "weight":
 		strategy = NewWeightBalancer(conf.Slaves)
+	case "least_lag":
+		// Set once the manager exists, the balancer reads lag from it
 	default:
 		return nil, ErrInvalidStrategy
 	}
 
-	return &DBManager{
-		master:   master,
-		slaves:   slaves,
-		strategy: strategy,
-		maxRetry: conf.MaxRetry,
-	}, nil
+	dm := &DBManager{
+		master:         master,
+		slaves:         slaves,
+		strategy:       strategy,
+		maxRetry:       conf.MaxRetry,
+		replicas:       replicas,
+		maxLag:         conf.MaxLag,
+		readYourWrites: conf.ReadYourWrites,
+	}
+	if conf.Strategy == "least_lag" {
+		dm.strategy = NewLeastLagBalancer(dm.Lag)
+	}
+
+	// Probe replica lag when a setting depends on it
+	if len(replicas) > 0 && (conf.Strategy == "least_lag" || conf.MaxLag > 0 || conf.LagProbeInterval > 0) {
+		dm.startLagProbe(conf.LagProbeInterval)
+	}
+
+	return dm, nil
 }
 
 func newDBClient(conf *config.DBNode) (*sql.DB, error) {
@@ -235,7 +262,8 @@ func (dm *DBManager) Master() *sql.DB {
 	return dm.master
 }
 
-// Slave returns a slave database connection based on the load balancing strategy
+// Slave returns a slave database connection based on the load balancing strategy.
+// With a max lag set, a slave behind by more falls back to the freshest one or the master.
 func (dm *DBManager) Slave() (*sql.DB, error) {
 	dm.mutex.RLock()
 	defer dm.mutex.RUnlock()
@@ -254,6 +282,9 @@ func (dm *DBManager) Slave() (*sql.DB, error) {
 			continue
 		}
 
+		if !dm.withinLag(slave) {
+			return dm.freshest(), nil
+		}
 		return slave, nil
 	}
 
@@ -265,6 +296,8 @@ func (dm *DBManager) Slave() (*sql.DB, error) {
 func (dm *DBManager) Close() error {
 	var errs []error
 
+	dm.stopLagProbe()
+
 	// Close master database
 	if err := dm.master.Close(); err != nil {
 		errs = append(errs, fmt.Errorf("error closing master connection: %v", err))
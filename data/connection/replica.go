@@ -0,0 +1,300 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultLagProbeInterval is used when lag awareness is enabled without an interval
+	defaultLagProbeInterval = 5 * time.Second
+	// lagProbeTimeout bounds a single replica probe
+	lagProbeTimeout = 5 * time.Second
+)
+
+// LagObserver receives the result of every replica lag probe
+type LagObserver func(replica string, lag time.Duration, err error)
+
+// replica tracks the replication lag of a slave database
+type replica struct {
+	name   string
+	driver string
+	lag    atomic.Int64 // nanoseconds, negative unless the last probe succeeded
+}
+
+func newReplica(name, driver string) *replica {
+	r := &replica{name: name, driver: driver}
+	r.lag.Store(-1)
+	return r
+}
+
+// LeastLagBalancer picks the slave with the lowest replication lag, slaves with
+// equal lag are taken in turn. Without lag data it falls back to round robin.
+type LeastLagBalancer struct {
+	lag     func(*sql.DB) (time.Duration, bool)
+	current *uint64
+}
+
+// NewLeastLagBalancer creates a balancer reading lag from lag, e.g. DBManager.Lag
+func NewLeastLagBalancer(lag func(*sql.DB) (time.Duration, bool)) *LeastLagBalancer {
+	var counter uint64
+	return &LeastLagBalancer{
+		lag:     lag,
+		current: &counter,
+	}
+}
+
+func (lb *LeastLagBalancer) Next(slaves []*sql.DB) (*sql.DB, error) {
+	if len(slaves) == 0 {
+		return nil, ErrNoAvailableSlaves
+	}
+
+	start := atomic.AddUint64(lb.current, 1)
+	var (
+		best    *sql.DB
+		bestLag time.Duration = math.MaxInt64
+	)
+	for i := range slaves {
+		slave := slaves[(start+uint64(i))%uint64(len(slaves))]
+		if lag, ok := lb.lag(slave); ok && lag < bestLag {
+			best, bestLag = slave, lag
+		}
+	}
+	if best == nil {
+		return slaves[start%uint64(len(slaves))], nil
+	}
+	return best, nil
+}
+
+// Lag returns the last probed replication lag of db, false if it is unknown or the
+// last probe failed. The master has no lag.
+func (dm *DBManager) Lag(db *sql.DB) (time.Duration, bool) {
+	if db == dm.master {
+		return 0, true
+	}
+	r, ok := dm.replicas[db]
+	if !ok {
+		return 0, false
+	}
+	lag := r.lag.Load()
+	if lag < 0 {
+		return 0, false
+	}
+	return time.Duration(lag), true
+}
+
+// SetLagObserver sets the function receiving replica lag probe results, e.g. for metrics
+func (dm *DBManager) SetLagObserver(observer LagObserver) {
+	dm.lagObserver.Store(&observer)
+}
+
+// Pin routes reads of session to the master for the read-your-writes window. It does
+// nothing when read-your-writes is disabled or session is empty.
+func (dm *DBManager) Pin(session string) {
+	if dm.readYourWrites <= 0 || session == "" {
+		return
+	}
+	dm.pins.Store(session, time.Now().Add(dm.readYourWrites))
+}
+
+// Pinned reports whether reads of session go to the master
+func (dm *DBManager) Pinned(session string) bool {
+	if session == "" {
+		return false
+	}
+	until, ok := dm.pins.Load(session)
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until.(time.Time)) {
+		return true
+	}
+	dm.pins.CompareAndDelete(session, until)
+	return false
+}
+
+// SlaveFor returns the master while session is pinned after a write, otherwise a slave
+func (dm *DBManager) SlaveFor(session string) (*sql.DB, error) {
+	if dm.Pinned(session) {
+		return dm.master, nil
+	}
+	return dm.Slave()
+}
+
+// HedgeTarget returns a slave other than db to hedge a read on db with, nil when
+// there is none. The master is never returned, hedges only spread load over slaves.
+func (dm *DBManager) HedgeTarget(db *sql.DB) *sql.DB {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	n := uint64(len(dm.slaves))
+	start := atomic.AddUint64(&dm.currentIdx, 1)
+	for i := range n {
+		slave := dm.slaves[(start+i)%n]
+		if slave != db && slave != dm.master && dm.withinLag(slave) {
+			return slave
+		}
+	}
+	return nil
+}
+
+// ReplicaName returns the name of db in metrics, "master" or "slave-<index>"
+func (dm *DBManager) ReplicaName(db *sql.DB) string {
+	if r, ok := dm.replicas[db]; ok {
+		return r.name
+	}
+	return "master"
+}
+
+// withinLag reports whether db may serve reads under the max lag setting
+func (dm *DBManager) withinLag(db *sql.DB) bool {
+	if dm.maxLag <= 0 {
+		return true
+	}
+	lag, ok := dm.Lag(db)
+	return ok && lag <= dm.maxLag
+}
+
+// freshest returns the least lagging slave within the max lag, or the master
+func (dm *DBManager) freshest() *sql.DB {
+	best, bestLag := dm.master, time.Duration(math.MaxInt64)
+	for _, slave := range dm.slaves {
+		if lag, ok := dm.Lag(slave); ok && lag <= dm.maxLag && slave != dm.master && lag < bestLag {
+			best, bestLag = slave, lag
+		}
+	}
+	return best
+}
+
+// startLagProbe probes replica lag every interval until Close
+func (dm *DBManager) startLagProbe(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultLagProbeInterval
+	}
+	dm.stopProbe = make(chan struct{})
+	dm.probeDone = make(chan struct{})
+
+	go func() {
+		defer close(dm.probeDone)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			dm.probeLag()
+			select {
+			case <-dm.stopProbe:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopLagProbe stops the probe loop and waits for it
+func (dm *DBManager) stopLagProbe() {
+	if dm.stopProbe == nil {
+		return
+	}
+	close(dm.stopProbe)
+	<-dm.probeDone
+	dm.stopProbe = nil
+}
+
+// probeLag measures the lag of every replica
+func (dm *DBManager) probeLag() {
+	observer := dm.lagObserver.Load()
+
+	for db, r := range dm.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), lagProbeTimeout)
+		lag, err := replicationLag(ctx, db, r.driver)
+		cancel()
+
+		if err != nil {
+			r.lag.Store(-1)
+		} else {
+			r.lag.Store(int64(lag))
+		}
+
+		if observer != nil {
+			(*observer)(r.name, lag, err)
+		}
+	}
+}
+
+// replicationLag queries how far a replica is behind its source
+func replicationLag(ctx context.Context, db *sql.DB, driver string) (time.Duration, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return postgresLag(ctx, db)
+	case "mysql":
+		return mysqlLag(ctx, db)
+	default:
+		// Drivers without replication are never behind
+		return 0, db.PingContext(ctx)
+	}
+}
+
+// postgresLag reads the replay delay of a standby, zero once it replayed all received WAL
+func postgresLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	const query = `SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+	var seconds float64
+	if err := db.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("failed to query postgres replication lag: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// mysqlLag reads Seconds_Behind_Source, falling back to SHOW SLAVE STATUS before MySQL 8.0.22
+func mysqlLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return 0, fmt.Errorf("failed to query mysql replication status: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		// Not a replica
+		return 0, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("mysql replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid replication lag %q: %w", values[i], err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("mysql replication status has no lag column")
+}
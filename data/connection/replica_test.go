@@ -0,0 +1,175 @@
+package connection
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openMemory opens an in-memory sqlite database closed with the test
+func openMemory(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newLagManager creates a manager over a master and n slaves with the given lags,
+// a negative lag is unknown
+func newLagManager(t *testing.T, maxLag time.Duration, lags ...time.Duration) (*DBManager, []*sql.DB) {
+	t.Helper()
+	dm := &DBManager{
+		master:   openMemory(t),
+		strategy: NewRoundRobinBalancer(),
+		replicas: make(map[*sql.DB]*replica),
+		maxLag:   maxLag,
+	}
+	for i, lag := range lags {
+		slave := openMemory(t)
+		r := newReplica(fmt.Sprintf("slave-%d", i), "sqlite3")
+		r.lag.Store(int64(lag))
+		dm.slaves = append(dm.slaves, slave)
+		dm.replicas[slave] = r
+	}
+	return dm, dm.slaves
+}
+
+func TestLeastLagBalancer(t *testing.T) {
+	dm, slaves := newLagManager(t, 0, 3*time.Second, time.Second, time.Second, -1)
+	lb := NewLeastLagBalancer(dm.Lag)
+
+	// Slaves with the same lowest lag share the reads
+	seen := make(map[*sql.DB]int)
+	for range 8 {
+		db, err := lb.Next(slaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[db]++
+	}
+	if len(seen) != 2 || seen[slaves[1]] == 0 || seen[slaves[2]] == 0 {
+		t.Fatalf("picked %v, want only slaves 1 and 2", seen)
+	}
+
+	// Without lag data slaves are taken round robin
+	unknown, slaves := newLagManager(t, 0, -1, -1)
+	lb = NewLeastLagBalancer(unknown.Lag)
+	first, _ := lb.Next(slaves)
+	second, _ := lb.Next(slaves)
+	if first == second {
+		t.Fatal("slaves without lag data were not taken in turn")
+	}
+
+	if _, err := lb.Next(nil); err != ErrNoAvailableSlaves {
+		t.Fatalf("err = %v, want ErrNoAvailableSlaves", err)
+	}
+}
+
+func TestSlaveSkipsLaggingReplicas(t *testing.T) {
+	dm, slaves := newLagManager(t, time.Second, 5*time.Second, 200*time.Millisecond, 100*time.Millisecond)
+
+	// Reads of the slave too far behind go to the freshest one
+	for range 6 {
+		db, err := dm.Slave()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if db == slaves[0] {
+			t.Fatal("slave behind the max lag served a read")
+		}
+		if db != slaves[1] && db != slaves[2] {
+			t.Fatal("read did not go to a slave within the max lag")
+		}
+	}
+
+	// Slaves behind or without lag data leave reads to the master
+	dm, _ = newLagManager(t, time.Second, 5*time.Second, -1)
+	for range 2 {
+		if db, err := dm.Slave(); err != nil || db != dm.master {
+			t.Fatalf("Slave() = %v, %v, want the master", db, err)
+		}
+	}
+	if name := dm.ReplicaName(dm.master); name != "master" {
+		t.Fatalf("master named %q", name)
+	}
+}
+
+func TestReadYourWrites(t *testing.T) {
+	dm, slaves := newLagManager(t, 0, 0)
+	dm.readYourWrites = 50 * time.Millisecond
+
+	dm.Pin("session-1")
+	if db, _ := dm.SlaveFor("session-1"); db != dm.master {
+		t.Fatal("read after a write did not go to the master")
+	}
+	if db, _ := dm.SlaveFor("session-2"); db != slaves[0] {
+		t.Fatal("read of another session did not go to the slave")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if dm.Pinned("session-1") {
+		t.Fatal("session still pinned after the read-your-writes window")
+	}
+	if db, _ := dm.SlaveFor("session-1"); db != slaves[0] {
+		t.Fatal("read after the window did not go to the slave")
+	}
+
+	// Pinning is disabled without a window
+	dm.readYourWrites = 0
+	dm.Pin("session-3")
+	if dm.Pinned("session-3") || dm.Pinned("") {
+		t.Fatal("session pinned with read-your-writes disabled")
+	}
+}
+
+func TestHedgeTarget(t *testing.T) {
+	dm, slaves := newLagManager(t, time.Second, 0, 5*time.Second, 0)
+
+	for range 4 {
+		if db := dm.HedgeTarget(slaves[0]); db != slaves[2] {
+			t.Fatal("hedge did not go to the other slave within the max lag")
+		}
+	}
+
+	single, slaves := newLagManager(t, 0, 0)
+	if db := single.HedgeTarget(slaves[0]); db != nil {
+		t.Fatal("hedge target returned for the only slave")
+	}
+}
+
+func TestProbeLag(t *testing.T) {
+	dm, slaves := newLagManager(t, 0, -1, -1)
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]error)
+	)
+	dm.SetLagObserver(func(replica string, lag time.Duration, err error) {
+		mu.Lock()
+		results[replica] = err
+		mu.Unlock()
+	})
+
+	// A closed replica fails its probe and its lag becomes unknown
+	slaves[1].Close()
+	dm.probeLag()
+
+	if lag, ok := dm.Lag(slaves[0]); !ok || lag != 0 {
+		t.Fatalf("lag of a replica without replication = %v, %v, want 0", lag, ok)
+	}
+	if _, ok := dm.Lag(slaves[1]); ok {
+		t.Fatal("lag of a failed replica is known")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != 2 || results["slave-0"] != nil || results["slave-1"] == nil {
+		t.Fatalf("probe results = %v", results)
+	}
+}
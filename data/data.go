@@ -3,15 +3,22 @@ package data
 import (
 	"sync"
 
+	"github.com/ncobase/ncore/data/claimcheck"
+	"github.com/ncobase/ncore/data/compress"
 	"github.com/ncobase/ncore/data/config"
 	"github.com/ncobase/ncore/data/connection"
+	"github.com/ncobase/ncore/data/hedge"
 	"github.com/ncobase/ncore/data/metrics"
+	"github.com/ncobase/ncore/data/search/memory"
 )
 
 type ContextKey string
 
 const (
 	ContextKeyTransaction ContextKey = "tx"
+
+	contextKeyTxHooks ContextKey = "tx_hooks"
+	contextKeySession ContextKey = "session"
 )
 
 var sharedInstance *Data
@@ -22,6 +29,11 @@ type Data struct {
 	conf      *config.Config
 	closed    bool
 	mu        sync.RWMutex
+
+	memorySearch *memory.Adapter      // Created by GetMemorySearch
+	hedger       *hedge.Hedger        // Hedges slave reads, nil unless enabled
+	compressor   *compress.Compressor // Compresses queue payloads, nil unless enabled
+	claims       *claimcheck.Checker  // Offloads large queue payloads, nil until a store is attached
 }
 
 type Option func(*Data)
@@ -1,12 +1,15 @@
 package elasticsearch
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ncobase/ncore/bytespool"
 	"github.com/ncobase/ncore/data/elasticsearch/client"
 	"github.com/ncobase/ncore/data/search"
 )
@@ -21,6 +24,12 @@ func init() {
 	})
 }
 
+// searchableFields are the fields the query text is matched against
+var searchableFields = []string{"title^2", "content", "details", "name", "description"}
+
+// bulkPool holds bulk request bodies, shared with the OpenSearch driver
+var bulkPool = bytespool.New("search.bulk", 4<<20)
+
 type Adapter struct {
 	client *client.Client
 }
@@ -38,15 +47,24 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 		return nil, errors.New("elasticsearch client not available")
 	}
 
-	query := a.buildQuery(req)
-	resp, err := a.client.Search(ctx, req.Index, query)
+	trace := search.StartTrace(req)
+	body := search.QueryBody(req, searchableFields)
+	trace.Built()
+	query, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	trace.Serialized(query)
+
+	resp, err := a.client.Search(ctx, req.Index, string(query))
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	trace.Executed()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("elasticsearch returned status: %d", resp.StatusCode)
+		return nil, &search.StatusError{Engine: search.Elasticsearch, StatusCode: resp.StatusCode}
 	}
 
 	var esResp struct {
@@ -55,11 +73,14 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 				Value int64 `json:"value"`
 			} `json:"total"`
 			Hits []struct {
-				ID     string         `json:"_id"`
-				Score  float64        `json:"_score"`
-				Source map[string]any `json:"_source"`
+				ID          string              `json:"_id"`
+				Score       float64             `json:"_score"`
+				Source      map[string]any      `json:"_source"`
+				Highlight   map[string][]string `json:"highlight"`
+				Explanation *search.Explanation `json:"_explanation"`
 			} `json:"hits"`
 		} `json:"hits"`
+		Profile json.RawMessage `json:"profile"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&esResp); err != nil {
@@ -69,16 +90,22 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 	hits := make([]search.Hit, len(esResp.Hits.Hits))
 	for i, hit := range esResp.Hits.Hits {
 		hits[i] = search.Hit{
-			ID:     hit.ID,
-			Score:  hit.Score,
-			Source: hit.Source,
+			ID:          hit.ID,
+			Score:       hit.Score,
+			Source:      hit.Source,
+			Highlight:   hit.Highlight,
+			Explanation: hit.Explanation,
 		}
 	}
 
-	return &search.Response{
+	trace.Parsed(esResp.Profile)
+
+	response := &search.Response{
 		Total: esResp.Hits.Total.Value,
 		Hits:  hits,
-	}, nil
+	}
+	trace.Attach(response)
+	return response, nil
 }
 
 func (a *Adapter) Index(ctx context.Context, req *search.IndexRequest) error {
@@ -105,20 +132,20 @@ func (a *Adapter) BulkIndex(ctx context.Context, index string, documents []any)
 		return errors.New("elasticsearch raw client is nil")
 	}
 
-	var bulkBody strings.Builder
-	for _, doc := range documents {
-		bulkBody.WriteString(fmt.Sprintf(`{"index":{"_index":"%s"}}`, index))
-		bulkBody.WriteString("\n")
+	bulkBody := bulkPool.Get()
+	defer bulkPool.Put(bulkBody)
 
-		docBytes, err := json.Marshal(doc)
-		if err != nil {
+	action := fmt.Sprintf(`{"index":{"_index":"%s"}}`, index) + "\n"
+	enc := json.NewEncoder(bulkBody)
+	for _, doc := range documents {
+		bulkBody.WriteString(action)
+		// Encode terminates the document line with a newline
+		if err := enc.Encode(doc); err != nil {
 			return err
 		}
-		bulkBody.Write(docBytes)
-		bulkBody.WriteString("\n")
 	}
 
-	res, err := client.Bulk(strings.NewReader(bulkBody.String()),
+	res, err := client.Bulk(bytes.NewReader(bulkBody.Bytes()),
 		client.Bulk.WithIndex(index),
 		client.Bulk.WithRefresh("true"))
 	if err != nil {
@@ -127,7 +154,7 @@ func (a *Adapter) BulkIndex(ctx context.Context, index string, documents []any)
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("bulk index error: %s", res.Status())
+		return fmt.Errorf("bulk index error: %w", &search.StatusError{Engine: search.Elasticsearch, StatusCode: res.StatusCode})
 	}
 
 	return nil
@@ -143,13 +170,14 @@ func (a *Adapter) BulkDelete(ctx context.Context, index string, documentIDs []st
 		return errors.New("elasticsearch raw client is nil")
 	}
 
-	var bulkBody strings.Builder
+	bulkBody := bulkPool.Get()
+	defer bulkPool.Put(bulkBody)
+
 	for _, docID := range documentIDs {
-		bulkBody.WriteString(fmt.Sprintf(`{"delete":{"_index":"%s","_id":"%s"}}`, index, docID))
-		bulkBody.WriteString("\n")
+		fmt.Fprintf(bulkBody, `{"delete":{"_index":"%s","_id":"%s"}}`+"\n", index, docID)
 	}
 
-	res, err := client.Bulk(strings.NewReader(bulkBody.String()),
+	res, err := client.Bulk(bytes.NewReader(bulkBody.Bytes()),
 		client.Bulk.WithIndex(index),
 		client.Bulk.WithRefresh("true"))
 	if err != nil {
@@ -158,7 +186,7 @@ func (a *Adapter) BulkDelete(ctx context.Context, index string, documentIDs []st
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("bulk delete error: %s", res.Status())
+		return fmt.Errorf("bulk delete error: %w", &search.StatusError{Engine: search.Elasticsearch, StatusCode: res.StatusCode})
 	}
 
 	return nil
@@ -207,6 +235,75 @@ func (a *Adapter) CreateIndex(ctx context.Context, indexName string, settings *s
 	return nil
 }
 
+// ApplyRelevance applies the stopwords and synonyms of r through the index's
+// default_search analyzer. Boosts and typo tolerance apply at query time.
+func (a *Adapter) ApplyRelevance(ctx context.Context, indexName string, r *search.Relevance) error {
+	if a.client == nil {
+		return errors.New("elasticsearch client not available")
+	}
+	settings, err := search.AnalysisSettings(r)
+	if err != nil {
+		return err
+	}
+	return a.client.UpdateAnalysis(ctx, indexName, string(settings))
+}
+
+// Export streams the documents matching req from a point in time with search_after
+func (a *Adapter) Export(ctx context.Context, req *search.Request, fn func([]search.Hit) error) error {
+	if a.client == nil {
+		return errors.New("elasticsearch client not available")
+	}
+	return search.ExportPointInTime(ctx, &pointInTime{client: a.client}, req, searchableFields, "_shard_doc", time.Minute, fn)
+}
+
+// pointInTime reads Elasticsearch points in time for exports
+type pointInTime struct {
+	client *client.Client
+}
+
+func (p *pointInTime) OpenPointInTime(ctx context.Context, index string, keepAlive time.Duration) (string, error) {
+	return p.client.OpenPointInTime(ctx, index, fmt.Sprintf("%ds", int(keepAlive.Seconds())))
+}
+
+func (p *pointInTime) SearchPointInTime(ctx context.Context, body []byte) (*search.PITPage, error) {
+	resp, err := p.client.SearchPointInTime(ctx, string(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var esResp struct {
+		PitID string `json:"pit_id"`
+		Hits  struct {
+			Hits []struct {
+				ID     string         `json:"_id"`
+				Score  float64        `json:"_score"`
+				Source map[string]any `json:"_source"`
+				// Kept raw, numbers like _shard_doc exceed float64 precision
+				Sort []json.RawMessage `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+
+	page := &search.PITPage{PitID: esResp.PitID, Hits: make([]search.Hit, len(esResp.Hits.Hits))}
+	for i, hit := range esResp.Hits.Hits {
+		page.Hits[i] = search.Hit{ID: hit.ID, Score: hit.Score, Source: hit.Source}
+	}
+	if n := len(esResp.Hits.Hits); n > 0 {
+		for _, v := range esResp.Hits.Hits[n-1].Sort {
+			page.SearchAfter = append(page.SearchAfter, v)
+		}
+	}
+	return page, nil
+}
+
+func (p *pointInTime) ClosePointInTime(ctx context.Context, id string) error {
+	return p.client.ClosePointInTime(ctx, id)
+}
+
 func (a *Adapter) Health(ctx context.Context) error {
 	if a.client == nil {
 		return errors.New("elasticsearch client not available")
@@ -228,53 +325,6 @@ func (a *Adapter) Health(ctx context.Context) error {
 	return nil
 }
 
-func (a *Adapter) buildQuery(req *search.Request) string {
-	// For now use defaults matching original code:
-	searchableFields := []string{"title^2", "content", "details", "name", "description"}
-
-	if len(req.Filter) == 0 {
-		return fmt.Sprintf(`{
-			"query": {
-				"multi_match": {
-					"query": "%s",
-					"fields": %s
-				}
-			},
-			"from": %d,
-			"size": %d
-		}`, req.Query, a.buildFieldsArray(searchableFields), req.From, req.Size)
-	}
-
-	filterQueries := make([]string, 0, len(req.Filter))
-	for field, value := range req.Filter {
-		filterQueries = append(filterQueries, fmt.Sprintf(`{"term": {"%s": "%v"}}`, field, value))
-	}
-
-	return fmt.Sprintf(`{
-		"query": {
-			"bool": {
-				"must": {
-					"multi_match": {
-						"query": "%s",
-						"fields": %s
-					}
-				},
-				"filter": [%s]
-			}
-		},
-		"from": %d,
-		"size": %d
-	}`, req.Query, a.buildFieldsArray(searchableFields), strings.Join(filterQueries, ","), req.From, req.Size)
-}
-
-func (a *Adapter) buildFieldsArray(fields []string) string {
-	quotedFields := make([]string, len(fields))
-	for i, field := range fields {
-		quotedFields[i] = fmt.Sprintf(`"%s"`, field)
-	}
-	return fmt.Sprintf("[%s]", strings.Join(quotedFields, ","))
-}
-
 func (a *Adapter) buildSettings(settings *search.IndexSettings) string {
 	shards := 1
 	replicas := 0
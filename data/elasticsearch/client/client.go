@@ -38,7 +38,7 @@ func NewClient(addresses []string, username, password string) (*Client, error) {
 	return &Client{client: es}, nil
 }
 
-// Search search from Elasticsearch
+// Search search from Elasticsearch, the caller closes the response body
 func (c *Client) Search(ctx context.Context, indexName, query string) (*esapi.Response, error) {
 	if c == nil || c.client == nil {
 		return nil, errors.New("elasticsearch client is nil, cannot perform search")
@@ -49,23 +49,13 @@ func (c *Client) Search(ctx context.Context, indexName, query string) (*esapi.Re
 		c.client.Search.WithIndex(indexName),
 		c.client.Search.WithBody(strings.NewReader(query)),
 		c.client.Search.WithTrackTotalHits(true),
-		c.client.Search.WithPretty(),
 	)
 	if err != nil {
 		log.Printf("Elasticsearch search error: %s", err)
 		return nil, err
 	}
 
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(res.Body)
-
-	var sr esapi.Response
-	if err := json.NewDecoder(res.Body).Decode(&sr); err != nil {
-		return nil, fmt.Errorf("elasticsearch parsing error: %s", err)
-	}
-
-	return &sr, nil
+	return res, nil
 }
 
 // IndexDocument index document to Elasticsearch
@@ -144,6 +134,152 @@ func (c *Client) DeleteDocument(ctx context.Context, indexName, documentID strin
 	return nil
 }
 
+// OpenPointInTime open a point in time on an index, returning its ID
+func (c *Client) OpenPointInTime(ctx context.Context, indexName, keepAlive string) (string, error) {
+	if c == nil || c.client == nil {
+		return "", errors.New("elasticsearch client is nil, cannot open point in time")
+	}
+
+	req := esapi.OpenPointInTimeRequest{
+		Index:     []string{indexName},
+		KeepAlive: keepAlive,
+	}
+
+	res, err := req.Do(ctx, c.client)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch open point in time error: %s", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(res.Body)
+
+	if res.IsError() {
+		return "", fmt.Errorf("elasticsearch open point in time error: %s", res.Status())
+	}
+
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("elasticsearch parsing error: %s", err)
+	}
+
+	return body.ID, nil
+}
+
+// SearchPointInTime search a point in time referenced in the query body, the caller closes the response body
+func (c *Client) SearchPointInTime(ctx context.Context, query string) (*esapi.Response, error) {
+	if c == nil || c.client == nil {
+		return nil, errors.New("elasticsearch client is nil, cannot perform search")
+	}
+
+	req := esapi.SearchRequest{
+		Body: strings.NewReader(query),
+	}
+
+	res, err := req.Do(ctx, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch search error: %s", err)
+	}
+
+	if res.IsError() {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("elasticsearch search error: %s", res.Status())
+	}
+
+	return res, nil
+}
+
+// ClosePointInTime close a point in time
+func (c *Client) ClosePointInTime(ctx context.Context, id string) error {
+	if c == nil || c.client == nil {
+		return errors.New("elasticsearch client is nil, cannot close point in time")
+	}
+
+	body, err := json.Marshal(map[string]string{"id": id})
+	if err != nil {
+		return err
+	}
+
+	req := esapi.ClosePointInTimeRequest{
+		Body: strings.NewReader(string(body)),
+	}
+
+	res, err := req.Do(ctx, c.client)
+	if err != nil {
+		return fmt.Errorf("elasticsearch close point in time error: %s", err)
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(res.Body)
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch close point in time error: %s", res.Status())
+	}
+
+	return nil
+}
+
+// UpdateAnalysis updates the analysis settings of an index, closing it while the
+// settings change as Elasticsearch requires
+func (c *Client) UpdateAnalysis(ctx context.Context, indexName, settings string) error {
+	if c == nil || c.client == nil {
+		return errors.New("elasticsearch client is nil, cannot update settings")
+	}
+
+	reqs := []struct {
+		op  string
+		req esapi.Request
+	}{
+		{"close", esapi.IndicesCloseRequest{Index: []string{indexName}}},
+		{"put settings", esapi.IndicesPutSettingsRequest{Index: []string{indexName}, Body: strings.NewReader(settings)}},
+		{"open", esapi.IndicesOpenRequest{Index: []string{indexName}}},
+	}
+	for i, r := range reqs {
+		res, err := r.req.Do(ctx, c.client)
+		if err == nil {
+			_ = res.Body.Close()
+			if res.IsError() {
+				err = errors.New(res.Status())
+			}
+		}
+		if err != nil {
+			if i == 1 {
+				// Reopen the index with its previous settings
+				if res, openErr := (esapi.IndicesOpenRequest{Index: []string{indexName}}).Do(ctx, c.client); openErr == nil {
+					_ = res.Body.Close()
+				}
+			}
+			return fmt.Errorf("elasticsearch %s error: %s", r.op, err)
+		}
+	}
+	return nil
+}
+
+// ForceMerge merges the segments of the indices matching pattern, down to
+// maxSegments when it is positive
+func (c *Client) ForceMerge(ctx context.Context, pattern string, maxSegments int) error {
+	if c == nil || c.client == nil {
+		return errors.New("elasticsearch client is nil, cannot force merge")
+	}
+
+	req := esapi.IndicesForcemergeRequest{Index: []string{pattern}}
+	if maxSegments > 0 {
+		req.MaxNumSegments = &maxSegments
+	}
+	res, err := req.Do(ctx, c.client)
+	if err != nil {
+		return fmt.Errorf("elasticsearch force merge error: %s", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch force merge error: %s", res.Status())
+	}
+	return nil
+}
+
 // GetClient get Elasticsearch client
 func (c *Client) GetClient() *elasticsearch.Client {
 	return c.client
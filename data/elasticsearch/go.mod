@@ -4,6 +4,7 @@ go 1.25.3
 
 require (
 	github.com/elastic/go-elasticsearch/v8 v8.19.3
+	github.com/ncobase/ncore/bytespool v0.2.2
 	github.com/ncobase/ncore/data v0.2.2
 )
 
@@ -33,3 +34,5 @@ require (
 )
 
 replace github.com/ncobase/ncore/data => ../
+
+replace github.com/ncobase/ncore/bytespool => ../../bytespool
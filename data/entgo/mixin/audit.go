@@ -0,0 +1,51 @@
+package mixin
+
+import (
+	"context"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/mixin"
+)
+
+// AuditBy adds the created_by and updated_by fields and fills them with the user
+// in the context on create and update, unless the mutation sets them.
+type AuditBy struct {
+	mixin.Schema
+	UserFunc func(ctx context.Context) string // e.g. ctxutil.GetUserID
+}
+
+// Fields of the AuditBy mixin.
+func (AuditBy) Fields() []ent.Field {
+	return Operator.Fields()
+}
+
+// Hooks of the AuditBy mixin.
+func (m AuditBy) Hooks() []ent.Hook {
+	return []ent.Hook{
+		func(next ent.Mutator) ent.Mutator {
+			return ent.MutateFunc(func(ctx context.Context, mu ent.Mutation) (ent.Value, error) {
+				var user string
+				if m.UserFunc != nil {
+					user = m.UserFunc(ctx)
+				}
+				if user == "" {
+					return next.Mutate(ctx, mu)
+				}
+				if mu.Op().Is(ent.OpCreate) {
+					if _, set := mu.Field(CreatedBy.Field); !set {
+						_ = mu.SetField(CreatedBy.Field, user)
+					}
+				}
+				if mu.Op().Is(ent.OpCreate | ent.OpUpdate | ent.OpUpdateOne) {
+					if _, set := mu.Field(UpdatedBy.Field); !set {
+						_ = mu.SetField(UpdatedBy.Field, user)
+					}
+				}
+				return next.Mutate(ctx, mu)
+			})
+		},
+	}
+}
+
+// Ensure AuditBy implements the Mixin interface.
+var _ ent.Mixin = (*AuditBy)(nil)
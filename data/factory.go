@@ -3,8 +3,10 @@ package data
 import (
 	"fmt"
 
+	"github.com/ncobase/ncore/data/compress"
 	"github.com/ncobase/ncore/data/config"
 	"github.com/ncobase/ncore/data/connection"
+	"github.com/ncobase/ncore/data/hedge"
 	"github.com/ncobase/ncore/data/metrics"
 )
 
@@ -25,15 +27,25 @@ func New(cfg *config.Config, createNewInstance ...bool) (*Data, func(name ...str
 		return sharedInstance, cleanup, nil
 	}
 
+	var compressor *compress.Compressor
+	if cfg.Messaging != nil && cfg.Messaging.Compression != nil {
+		c := cfg.Messaging.Compression
+		var err error
+		if compressor, err = compress.New(compress.Options{Codec: c.Codec, Threshold: c.Threshold}); err != nil {
+			return nil, nil, fmt.Errorf("invalid messaging compression: %w", err)
+		}
+	}
+
 	conn, err := connection.New(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	d := &Data{
-		Conn:      conn,
-		conf:      cfg,
-		collector: metrics.NoOpCollector{},
+		Conn:       conn,
+		conf:       cfg,
+		collector:  metrics.NoOpCollector{},
+		compressor: compressor,
 	}
 
 	// Initialize metrics collector if enabled
@@ -43,6 +55,13 @@ func New(cfg *config.Config, createNewInstance ...bool) (*Data, func(name ...str
 		}
 	}
 
+	if conn.DBM != nil {
+		conn.DBM.SetLagObserver(d.observeReplicaLag)
+		if cfg.Database != nil {
+			d.hedger = hedge.New(adaptHedge(cfg.Database.Hedge))
+		}
+	}
+
 	// Set as shared instance if not creating new
 	if !createNew {
 		sharedInstance = d
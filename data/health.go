@@ -32,6 +32,11 @@ func (d *Data) Health(ctx context.Context) map[string]any {
 		overallHealthy = false
 	}
 
+	// ClickHouse health
+	if healthy := d.checkClickHouseHealth(ctx, services); !healthy {
+		overallHealthy = false
+	}
+
 	// Messaging health
 	if healthy := d.checkMessagingHealth(services); !healthy {
 		overallHealthy = false
@@ -128,6 +133,27 @@ func (d *Data) checkMongoHealth(ctx context.Context, services map[string]any) bo
 	return healthy
 }
 
+// checkClickHouseHealth checks ClickHouse health and reports its connection pool
+func (d *Data) checkClickHouseHealth(ctx context.Context, services map[string]any) bool {
+	if d.Conn == nil || d.Conn.CH == nil {
+		return true // No ClickHouse configured
+	}
+
+	err := d.ClickHouseHealthCheck(ctx)
+	healthy := err == nil
+
+	status := map[string]any{
+		"healthy": healthy,
+		"error":   getErrorString(err),
+	}
+	if pool, ok := d.Conn.CH.(interface{ PoolStats() (open, idle int) }); ok {
+		status["open_conns"], status["idle_conns"] = pool.PoolStats()
+	}
+	services["clickhouse"] = status
+
+	return healthy
+}
+
 // checkMessagingHealth checks messaging systems health
 func (d *Data) checkMessagingHealth(services map[string]any) bool {
 	overallHealthy := true
@@ -0,0 +1,274 @@
+// Package hedge cuts the tail latency of reads served by interchangeable backends,
+// such as database replicas or search engines.
+//
+// A hedged request goes to a first backend and, when that has not answered within
+// a latency percentile of recent requests, also to a second one. The first success
+// wins and the other request is cancelled. A budget bounds the share of requests
+// that are hedged, so a slow period does not double the load on every backend.
+package hedge
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// minSamples is the number of latencies needed before requests are hedged
+const minSamples = 20
+
+// maxTokens bounds the hedges a quiet period saves up for a burst
+const maxTokens = 10
+
+// Policy controls when requests are hedged
+type Policy struct {
+	Enabled    bool
+	Percentile float64       // Latency percentile after which a request is hedged, default 0.95
+	MinDelay   time.Duration // Lower bound of the hedging delay, default 5ms
+	MaxDelay   time.Duration // Upper bound of the hedging delay, default 1s
+	MaxRate    float64       // Share of requests that may be hedged, default 0.1
+	Window     int           // Number of recent latencies the percentile is taken over, default 1000
+}
+
+func (p *Policy) percentile() float64 {
+	if p.Percentile <= 0 || p.Percentile >= 1 {
+		return 0.95
+	}
+	return p.Percentile
+}
+
+func (p *Policy) minDelay() time.Duration {
+	if p.MinDelay <= 0 {
+		return 5 * time.Millisecond
+	}
+	return p.MinDelay
+}
+
+func (p *Policy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return max(time.Second, p.minDelay())
+	}
+	return max(p.MaxDelay, p.minDelay())
+}
+
+func (p *Policy) maxRate() float64 {
+	if p.MaxRate <= 0 {
+		return 0.1
+	}
+	return min(p.MaxRate, 1)
+}
+
+func (p *Policy) window() int {
+	if p.Window <= 0 {
+		return 1000
+	}
+	return max(p.Window, minSamples)
+}
+
+// Outcome tells how a request was served
+type Outcome int
+
+const (
+	NotHedged  Outcome = iota // The first backend answered within the delay, or no hedge was sent
+	PrimaryWon                // A hedge was sent, the first backend still answered first
+	HedgeWon                  // The second backend answered first
+	BothFailed                // A hedge was sent and both backends failed
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case PrimaryWon:
+		return "primary_won"
+	case HedgeWon:
+		return "hedge_won"
+	case BothFailed:
+		return "both_failed"
+	default:
+		return "not_hedged"
+	}
+}
+
+// Stats are the counters of a Hedger
+type Stats struct {
+	Requests int64         `json:"requests"`
+	Hedged   int64         `json:"hedged"`
+	Won      int64         `json:"won"`   // Hedges that answered first
+	Delay    time.Duration `json:"delay"` // Current hedging delay, 0 until enough latencies are known
+}
+
+// Hedger tracks the latency of requests to decide when to hedge them. A nil
+// Hedger never hedges.
+type Hedger struct {
+	p Policy
+
+	mu      sync.Mutex
+	samples []time.Duration // Ring buffer of recent latencies
+	next    int
+	stale   int // Latencies recorded since the delay was computed
+	tokens  float64
+
+	delay                 atomic.Int64
+	requests, hedged, won atomic.Int64
+}
+
+// New creates a Hedger, nil when the policy is disabled
+func New(p *Policy) *Hedger {
+	if p == nil || !p.Enabled {
+		return nil
+	}
+	return &Hedger{p: *p, samples: make([]time.Duration, 0, p.window())}
+}
+
+// Delay returns how long a request runs before it is hedged, false while too few
+// latencies are known
+func (h *Hedger) Delay() (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	d := h.delay.Load()
+	return time.Duration(d), d > 0
+}
+
+// Stats returns the counters of the hedger
+func (h *Hedger) Stats() Stats {
+	if h == nil {
+		return Stats{}
+	}
+	d, _ := h.Delay()
+	return Stats{
+		Requests: h.requests.Load(),
+		Hedged:   h.hedged.Load(),
+		Won:      h.won.Load(),
+		Delay:    d,
+	}
+}
+
+// Observe records the latency of a request, recomputing the delay every twentieth
+// of the window
+func (h *Hedger) Observe(latency time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, latency)
+	} else {
+		h.samples[h.next] = latency
+	}
+	h.next = (h.next + 1) % cap(h.samples)
+	h.stale++
+
+	if len(h.samples) < minSamples || (h.delay.Load() > 0 && h.stale < max(cap(h.samples)/20, 1)) {
+		return
+	}
+	h.stale = 0
+	sorted := slices.Clone(h.samples)
+	slices.Sort(sorted)
+	d := sorted[int(h.p.percentile()*float64(len(sorted)-1))]
+	h.delay.Store(int64(min(max(d, h.p.minDelay()), h.p.maxDelay())))
+}
+
+// earn adds the hedging budget of a request
+func (h *Hedger) earn() {
+	h.mu.Lock()
+	h.tokens = min(h.tokens+h.p.maxRate(), maxTokens)
+	h.mu.Unlock()
+}
+
+// spend takes a hedge from the budget, false when it is exhausted
+func (h *Hedger) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+type result[T any] struct {
+	v     T
+	err   error
+	hedge bool
+}
+
+// Do runs primary and, when it has not answered within the hedger's delay and the
+// budget allows, hedge as well. The first success is returned and the context of
+// the other call is cancelled. When both fail the error of primary is returned.
+// Without a hedger or a hedge function Do only runs primary.
+func Do[T any](ctx context.Context, h *Hedger, primary, hedge func(context.Context) (T, error)) (T, Outcome, error) {
+	if h == nil || hedge == nil {
+		v, err := primary(ctx)
+		return v, NotHedged, err
+	}
+
+	h.requests.Add(1)
+	h.earn()
+	start := time.Now()
+
+	delay, ok := h.Delay()
+	if !ok {
+		v, err := primary(ctx)
+		if err == nil {
+			h.Observe(time.Since(start))
+		}
+		return v, NotHedged, err
+	}
+
+	results := make(chan result[T], 2)
+	pctx, pcancel := context.WithCancel(ctx)
+	defer pcancel()
+	go func() {
+		v, err := primary(pctx)
+		results <- result[T]{v: v, err: err}
+	}()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case r := <-results:
+		if r.err == nil {
+			h.Observe(time.Since(start))
+		}
+		return r.v, NotHedged, r.err
+	case <-timer.C:
+	}
+
+	if ctx.Err() != nil || !h.spend() {
+		r := <-results
+		if r.err == nil {
+			h.Observe(time.Since(start))
+		}
+		return r.v, NotHedged, r.err
+	}
+
+	h.hedged.Add(1)
+	hctx, hcancel := context.WithCancel(ctx)
+	defer hcancel()
+	go func() {
+		v, err := hedge(hctx)
+		results <- result[T]{v: v, err: err, hedge: true}
+	}()
+
+	var primaryResult result[T]
+	for range 2 {
+		r := <-results
+		if r.err != nil {
+			if !r.hedge {
+				primaryResult = r
+			}
+			continue
+		}
+		// The latency of a primary that lost is at least this long
+		h.Observe(time.Since(start))
+		if r.hedge {
+			h.won.Add(1)
+			return r.v, HedgeWon, nil
+		}
+		return r.v, PrimaryWon, nil
+	}
+	return primaryResult.v, BothFailed, primaryResult.err
+}
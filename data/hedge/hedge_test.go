@@ -0,0 +1,124 @@
+package hedge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// warm returns a hedger that has seen enough latencies to hedge after delay
+func warm(p Policy, delay time.Duration) *Hedger {
+	p.Enabled = true
+	p.MinDelay = delay
+	h := New(&p)
+	for range minSamples {
+		h.Observe(time.Millisecond)
+	}
+	return h
+}
+
+func value(v string, after time.Duration, err error) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(after):
+			return v, err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	fail := errors.New("replica down")
+
+	tests := []struct {
+		name           string
+		primary, hedge func(context.Context) (string, error)
+		want           string
+		outcome        Outcome
+		err            error
+	}{
+		{"fast primary", value("primary", 0, nil), value("hedge", 0, nil), "primary", NotHedged, nil},
+		{"slow primary", value("primary", time.Second, nil), value("hedge", 0, nil), "hedge", HedgeWon, nil},
+		{"primary fails fast", value("", 0, fail), value("hedge", 0, nil), "", NotHedged, fail},
+		{"hedge fails", value("primary", 50*time.Millisecond, nil), value("", 0, fail), "primary", PrimaryWon, nil},
+		{"both fail", value("", 50*time.Millisecond, fail), value("", 0, errors.New("other")), "", BothFailed, fail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := warm(Policy{MaxRate: 1}, 10*time.Millisecond)
+			h.tokens = maxTokens
+
+			v, outcome, err := Do(ctx, h, tt.primary, tt.hedge)
+			if v != tt.want || outcome != tt.outcome || !errors.Is(err, tt.err) {
+				t.Errorf("Do() = %q, %v, %v, want %q, %v, %v", v, outcome, err, tt.want, tt.outcome, tt.err)
+			}
+		})
+	}
+}
+
+func TestDoCancelsLoser(t *testing.T) {
+	h := warm(Policy{MaxRate: 1}, 5*time.Millisecond)
+	h.tokens = maxTokens
+
+	cancelled := make(chan struct{})
+	primary := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	}
+	if _, outcome, _ := Do(context.Background(), h, primary, value("hedge", 0, nil)); outcome != HedgeWon {
+		t.Fatalf("outcome = %v, want hedge_won", outcome)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("primary was not cancelled")
+	}
+	if s := h.Stats(); s.Requests != 1 || s.Hedged != 1 || s.Won != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	h := warm(Policy{MaxRate: 0.25}, time.Millisecond)
+	slow := value("primary", 5*time.Millisecond, nil)
+	for range 20 {
+		_, _, _ = Do(context.Background(), h, slow, value("hedge", 0, nil))
+	}
+	if s := h.Stats(); s.Hedged != 5 {
+		t.Errorf("hedged %d of %d requests, want 5", s.Hedged, s.Requests)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	h := New(&Policy{Enabled: true, Percentile: 0.9, MinDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond, Window: 100})
+	for i := range 10 {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+	if _, ok := h.Delay(); ok {
+		t.Fatal("hedging before enough latencies are known")
+	}
+	for i := range 90 {
+		h.Observe(time.Duration(i%10) * time.Millisecond)
+	}
+	if d, _ := h.Delay(); d != 8*time.Millisecond {
+		t.Errorf("delay = %v, want the 90th percentile 8ms", d)
+	}
+	for range 100 {
+		h.Observe(time.Second)
+	}
+	if d, _ := h.Delay(); d != 50*time.Millisecond {
+		t.Errorf("delay = %v, want it capped at 50ms", d)
+	}
+
+	if New(&Policy{}) != nil {
+		t.Error("disabled policy created a hedger")
+	}
+	v, outcome, err := Do(context.Background(), nil, value("primary", 0, nil), value("hedge", 0, nil))
+	if v != "primary" || outcome != NotHedged || err != nil {
+		t.Errorf("Do() without hedger = %q, %v, %v", v, outcome, err)
+	}
+}
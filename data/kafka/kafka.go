@@ -121,6 +121,88 @@ func (s *Kafka) PublishMessage(ctx context.Context, topic string, key, value []b
 	return fmt.Errorf("failed to write message after %d attempts", maxRetries+1)
 }
 
+// BatchController sizes produce batches from the outcome of earlier ones,
+// batch.Controller from the concurrency module implements it
+type BatchController interface {
+	Size() int
+	Parallelism() int
+	Observe(items int, latency time.Duration, err error)
+}
+
+// PublishMessages publishes messages to topic in batches of ctrl.Size(), writing
+// ctrl.Parallelism() batches at a time and reporting each one back to ctrl, so
+// producers grow batches while the brokers keep up and back off on slow or
+// failed writes. A nil ctrl writes every message in a single call. It stops
+// after the first round with a failed batch, earlier batches stay published.
+func (s *Kafka) PublishMessages(ctx context.Context, topic string, messages []kafka.Message, ctrl BatchController) error {
+	if !s.IsConnected() {
+		return fmt.Errorf("kafka connection is not available")
+	}
+
+	writer := s.getWriter()
+	if writer == nil {
+		return errors.New("kafka writer is not initialized")
+	}
+
+	now := time.Now()
+	msgs := make([]kafka.Message, len(messages))
+	for i, msg := range messages {
+		msg.Topic = topic
+		if msg.Time.IsZero() {
+			msg.Time = now
+		}
+		msgs[i] = msg
+	}
+
+	write := func(batch []kafka.Message) error {
+		timeoutCtx, cancel := context.WithTimeout(ctx, s.messaging.PublishTimeout)
+		defer cancel()
+		return writer.WriteMessages(timeoutCtx, batch...)
+	}
+
+	if ctrl == nil {
+		return write(msgs)
+	}
+
+	for start := 0; start < len(msgs); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size, parallelism := max(ctrl.Size(), 1), max(ctrl.Parallelism(), 1)
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+		for range parallelism {
+			if start >= len(msgs) {
+				break
+			}
+			end := min(start+size, len(msgs))
+			batch := msgs[start:end:end]
+			start = end
+
+			wg.Go(func() {
+				begin := time.Now()
+				err := write(batch)
+				ctrl.Observe(len(batch), time.Since(begin), err)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to publish messages: %w", errors.Join(errs...))
+		}
+	}
+	return nil
+}
+
 // getWriter ensures a valid writer exists and returns it
 func (s *Kafka) getWriter() *kafka.Writer {
 	s.mu.Lock()
@@ -0,0 +1,141 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Collection stores JSON documents of type T by ID, in a bucket of its own
+// apart from the keys of the DB. Documents do not expire.
+type Collection[T any] struct {
+	db     *DB
+	bucket []byte
+}
+
+// NewCollection returns the collection name of db, creating it if needed
+func NewCollection[T any](db *DB, name string) (*Collection[T], error) {
+	bucket := []byte("doc:" + name)
+	if err := db.bolt.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create collection %s: %v", name, err)
+	}
+	return &Collection[T]{db: db, bucket: bucket}, nil
+}
+
+// Put creates or replaces a document
+func (c *Collection[T]) Put(ctx context.Context, id string, doc T) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return c.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Put([]byte(id), data)
+	})
+}
+
+// Get returns a document, ErrNotFound if missing
+func (c *Collection[T]) Get(ctx context.Context, id string) (T, error) {
+	var doc T
+	if err := ctx.Err(); err != nil {
+		return doc, err
+	}
+	err := c.db.bolt.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(c.bucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &doc)
+	})
+	return doc, err
+}
+
+// Update atomically modifies a document, fn receives the current document
+// and whether it exists. Returning ErrKeep leaves it unchanged.
+func (c *Collection[T]) Update(ctx context.Context, id string, fn func(doc *T, found bool) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := c.db.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.bucket)
+		var doc T
+		data := b.Get([]byte(id))
+		if data != nil {
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return err
+			}
+		}
+		if err := fn(&doc, data != nil); err != nil {
+			return err
+		}
+		updated, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(id), updated)
+	})
+	if errors.Is(err, ErrKeep) {
+		return nil
+	}
+	return err
+}
+
+// Delete removes documents, missing IDs are ignored
+func (c *Collection[T]) Delete(ctx context.Context, ids ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.db.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.bucket)
+		for _, id := range ids {
+			if err := b.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Find returns the documents matching filter in ID order, all of them when
+// filter is nil. It reads the whole collection, keep collections small or
+// maintain index keys in the DB.
+func (c *Collection[T]) Find(ctx context.Context, filter func(id string, doc T) bool) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var out []T
+	err := c.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).ForEach(func(k, data []byte) error {
+			var doc T
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return fmt.Errorf("failed to decode document %s: %v", k, err)
+			}
+			if filter == nil || filter(string(k), doc) {
+				out = append(out, doc)
+			}
+			return nil
+		})
+	})
+	return out, err
+}
+
+// Count returns the number of documents
+func (c *Collection[T]) Count(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var n int
+	err := c.db.bolt.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(c.bucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,39 @@
+// Package kv is an embedded key-value store on a single bbolt file, so small
+// single-binary deployments can run without Redis. Sessions, idempotency
+// keys and rate limits have stores on it:
+//
+//	db, err := kv.Open("data/app.db", nil)
+//	if err != nil {
+//	    return err
+//	}
+//	defer db.Close()
+//
+//	sessions := session.New(session.FromConfig(cfg.Auth.Session), session.NewKVStore(db, "session"))
+//	limiter, _ := ratelimit.NewKVLimiter(db, ratelimit.Config{Limit: 100, Window: time.Minute})
+//	idem := idempotency.NewKVStore(db, "idempotency")
+//
+// # Keys
+//
+// Get, Set, SetNX and Delete work like their cache counterparts, with an
+// optional TTL per key. Update is an atomic read-modify-write, the building
+// block of counters and compare-and-set:
+//
+//	err := db.Update(ctx, "visits", func(v []byte, found bool) ([]byte, time.Duration, error) {
+//	    n, _ := strconv.Atoi(string(v))
+//	    return []byte(strconv.Itoa(n + 1)), 0, nil
+//	})
+//
+// Scan iterates keys by prefix in order. Expired keys are never returned and
+// are purged every SweepInterval.
+//
+// # Documents
+//
+// Collection stores JSON documents by ID:
+//
+//	users, _ := kv.NewCollection[User](db, "users")
+//	_ = users.Put(ctx, u.ID, u)
+//	admins, _ := users.Find(ctx, func(_ string, u User) bool { return u.Admin })
+//
+// The file is locked by the process that opened it; instances sharing state
+// still need Redis or a database.
+package kv
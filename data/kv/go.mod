@@ -0,0 +1,7 @@
+module github.com/ncobase/ncore/data/kv
+
+go 1.25.3
+
+require go.etcd.io/bbolt v1.4.3
+
+require golang.org/x/sys v0.29.0 // indirect
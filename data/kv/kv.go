@@ -0,0 +1,317 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned for missing and expired keys
+var ErrNotFound = errors.New("kv: key not found")
+
+// Default settings
+const (
+	DefaultSweepInterval = time.Minute
+	DefaultTimeout       = time.Second
+)
+
+var keysBucket = []byte("kv")
+
+// Options configures a DB
+type Options struct {
+	// SweepInterval is how often expired keys are purged, defaults to 1m.
+	// Negative disables the sweeper, expired keys are still never returned.
+	SweepInterval time.Duration
+	// Timeout is how long Open waits for the file lock held by another
+	// process, defaults to 1s
+	Timeout time.Duration
+	// NoSync skips fsync after each write, trading durability of the last
+	// writes on power loss for speed
+	NoSync bool
+}
+
+// DB is an embedded key-value store in a single file, for single-binary
+// deployments without Redis. Values carry an optional TTL.
+type DB struct {
+	bolt *bolt.DB
+	now  func() time.Time
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// Open opens or creates the store at path. Only one process may open a file
+// at a time.
+func Open(path string, opts *Options) (*DB, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.SweepInterval == 0 {
+		o.SweepInterval = DefaultSweepInterval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	b, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: o.Timeout, NoSync: o.NoSync})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open kv store %s: %v", path, err)
+	}
+	if err := b.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(keysBucket)
+		return err
+	}); err != nil {
+		_ = b.Close()
+		return nil, fmt.Errorf("failed to open kv store %s: %v", path, err)
+	}
+
+	db := &DB{bolt: b, now: time.Now, stop: make(chan struct{})}
+	if o.SweepInterval > 0 {
+		db.wg.Add(1)
+		go db.sweeper(o.SweepInterval)
+	}
+	return db, nil
+}
+
+// Close stops the sweeper and closes the file
+func (db *DB) Close() error {
+	db.once.Do(func() { close(db.stop) })
+	db.wg.Wait()
+	return db.bolt.Close()
+}
+
+// Path returns the file of the store
+func (db *DB) Path() string { return db.bolt.Path() }
+
+func (db *DB) sweeper(interval time.Duration) {
+	defer db.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			_, _ = db.DeleteExpired(context.Background())
+		}
+	}
+}
+
+// encode prefixes a value with its expiry in Unix nanoseconds, 0 for none
+func encode(value []byte, expires time.Time) []byte {
+	buf := make([]byte, 8+len(value))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(buf, uint64(expires.UnixNano()))
+	}
+	copy(buf[8:], value)
+	return buf
+}
+
+// decode returns a copy of a stored value, false if it is malformed or
+// expired at now
+func decode(raw []byte, now time.Time) ([]byte, bool) {
+	if len(raw) < 8 {
+		return nil, false
+	}
+	if exp := int64(binary.BigEndian.Uint64(raw)); exp != 0 && now.UnixNano() >= exp {
+		return nil, false
+	}
+	return bytes.Clone(raw[8:]), true
+}
+
+func (db *DB) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return db.now().Add(ttl)
+}
+
+// Get returns the value of key, ErrNotFound if missing or expired
+func (db *DB) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var value []byte
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		v, ok := decode(tx.Bucket(keysBucket).Get([]byte(key)), db.now())
+		if !ok {
+			return ErrNotFound
+		}
+		value = v
+		return nil
+	})
+	return value, err
+}
+
+// Set stores a value, ttl 0 keeps it until deleted
+func (db *DB) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).Put([]byte(key), encode(value, db.expiry(ttl)))
+	})
+}
+
+// SetNX stores a value unless key holds an unexpired one, reporting whether
+// it was stored
+func (db *DB) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	stored := false
+	err := db.Update(ctx, key, func(_ []byte, found bool) ([]byte, time.Duration, error) {
+		if found {
+			return nil, 0, ErrKeep
+		}
+		stored = true
+		return value, ttl, nil
+	})
+	return stored, err
+}
+
+// Delete removes keys, missing keys are ignored
+func (db *DB) Delete(ctx context.Context, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		for _, key := range keys {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ErrKeep is returned by an Update function to leave the value unchanged,
+// Update then returns nil
+var ErrKeep = errors.New("kv: keep value")
+
+// UpdateFunc computes the new value of a key from the current one, found is
+// false for missing and expired keys. Returning a nil value deletes the key,
+// ErrKeep leaves it unchanged and other errors abort the update.
+type UpdateFunc func(value []byte, found bool) ([]byte, time.Duration, error)
+
+// Update atomically replaces the value of key with the result of fn, the
+// building block of counters, rate limiters and compare-and-set. Updates are
+// serialized, so fn should be quick.
+func (db *DB) Update(ctx context.Context, key string, fn UpdateFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := db.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		current, found := decode(b.Get([]byte(key)), db.now())
+		value, ttl, err := fn(current, found)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			return b.Delete([]byte(key))
+		}
+		return b.Put([]byte(key), encode(value, db.expiry(ttl)))
+	})
+	if errors.Is(err, ErrKeep) {
+		return nil
+	}
+	return err
+}
+
+// TTL returns the remaining lifetime of key, 0 for keys without expiry
+func (db *DB) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var ttl time.Duration
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(keysBucket).Get([]byte(key))
+		now := db.now()
+		if _, ok := decode(raw, now); !ok {
+			return ErrNotFound
+		}
+		if exp := int64(binary.BigEndian.Uint64(raw)); exp != 0 {
+			ttl = time.Duration(exp - now.UnixNano())
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+// Scan calls fn with the unexpired keys starting with prefix in key order,
+// until fn returns false. The value is only valid during the call.
+func (db *DB) Scan(ctx context.Context, prefix string, fn func(key string, value []byte) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p := []byte(prefix)
+	now := db.now()
+	return db.bolt.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(keysBucket).Cursor()
+		for k, raw := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, raw = c.Next() {
+			v, ok := decode(raw, now)
+			if !ok {
+				continue
+			}
+			if !fn(string(k), v) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteExpired purges expired keys, returning how many were removed. The
+// sweeper calls it every SweepInterval.
+func (db *DB) DeleteExpired(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var expired [][]byte
+	now := db.now()
+	if err := db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).ForEach(func(k, raw []byte) error {
+			if _, ok := decode(raw, now); !ok {
+				expired = append(expired, bytes.Clone(k))
+			}
+			return nil
+		})
+	}); err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	var n int64
+	err := db.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		for _, k := range expired {
+			// Keys may have been rewritten or deleted since the scan
+			raw := b.Get(k)
+			if raw == nil {
+				continue
+			}
+			if _, ok := decode(raw, now); ok {
+				continue
+			}
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,113 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func open(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "kv.db"), &Options{SweepInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	db := open(t)
+	now := time.Now()
+	db.now = func() time.Time { return now }
+
+	if err := db.Set(ctx, "a:1", []byte("one"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Set(ctx, "a:2", []byte("two"), 0)
+	_ = db.Set(ctx, "b:1", []byte("other"), 0)
+
+	if v, err := db.Get(ctx, "a:1"); err != nil || string(v) != "one" {
+		t.Fatalf("get = %q, %v", v, err)
+	}
+	if ok, err := db.SetNX(ctx, "a:1", []byte("again"), 0); ok || err != nil {
+		t.Errorf("setnx existing = %v, %v", ok, err)
+	}
+	if ttl, _ := db.TTL(ctx, "a:1"); ttl != time.Minute {
+		t.Errorf("ttl = %v", ttl)
+	}
+
+	var keys []string
+	_ = db.Scan(ctx, "a:", func(key string, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != "a:1" || keys[1] != "a:2" {
+		t.Errorf("scan = %v", keys)
+	}
+
+	// Expired keys read as missing until swept
+	now = now.Add(2 * time.Minute)
+	if _, err := db.Get(ctx, "a:1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired get: %v", err)
+	}
+	if ok, _ := db.SetNX(ctx, "a:1", []byte("new"), time.Minute); !ok {
+		t.Error("setnx over expired key failed")
+	}
+	now = now.Add(2 * time.Minute)
+	if n, err := db.DeleteExpired(ctx); n != 1 || err != nil {
+		t.Errorf("delete expired = %d, %v", n, err)
+	}
+
+	for range 3 {
+		err := db.Update(ctx, "counter", func(v []byte, found bool) ([]byte, time.Duration, error) {
+			return append(v, 'x'), 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, _ := db.Get(ctx, "counter"); string(v) != "xxx" {
+		t.Errorf("counter = %q", v)
+	}
+	_ = db.Delete(ctx, "counter", "missing")
+	if _, err := db.Get(ctx, "counter"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted get: %v", err)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	type user struct {
+		Name  string
+		Admin bool
+	}
+	ctx := context.Background()
+	db := open(t)
+	users, err := NewCollection[user](db, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = users.Put(ctx, "1", user{Name: "ann", Admin: true})
+	_ = users.Put(ctx, "2", user{Name: "bob"})
+	if u, err := users.Get(ctx, "1"); err != nil || u.Name != "ann" {
+		t.Fatalf("get = %+v, %v", u, err)
+	}
+	if _, err := users.Get(ctx, "3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing: %v", err)
+	}
+	_ = users.Update(ctx, "2", func(u *user, found bool) error {
+		u.Admin = found
+		return nil
+	})
+	admins, err := users.Find(ctx, func(_ string, u user) bool { return u.Admin })
+	if err != nil || len(admins) != 2 {
+		t.Errorf("admins = %+v, %v", admins, err)
+	}
+	if n, _ := users.Count(ctx); n != 2 {
+		t.Errorf("count = %d", n)
+	}
+}
@@ -49,6 +49,14 @@ func (d *Data) Close() []error {
 		}
 	}
 
+	// Flush the in-memory search engine
+	if d.memorySearch != nil {
+		if err := d.memorySearch.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		d.memorySearch = nil
+	}
+
 	// Close connections through connection manager
 	if d.Conn != nil {
 		if connErrs := d.Conn.Close(); len(connErrs) > 0 {
@@ -0,0 +1,25 @@
+// Package lock provides distributed locks for coordinating leader-only work
+// across instances, backed by Redis or Postgres advisory locks.
+//
+//	locker, err := lock.NewRedisLocker("myapp", redisClient)
+//	// or lock.NewPostgresLocker(db)
+//
+//	l, err := locker.TryLock(ctx, "jobs:cleanup", lock.WithTTL(30*time.Second))
+//	if errors.Is(err, lock.ErrNotAcquired) {
+//	    return nil // another instance is running it
+//	}
+//	if err != nil {
+//	    return err
+//	}
+//	defer l.Unlock(context.Background())
+//
+//	return runCleanup(l.Context())
+//
+// Locks are renewed every TTL/3 unless WithAutoRenew(false) is given. The lock
+// context is cancelled once the lock is released, cannot be renewed for a full
+// TTL, or the acquiring context is done, so guarded work stops when leadership
+// may have moved. Lock blocks with jittered retries until acquired or ctx is done.
+//
+// Passing several independent Redis clients to NewRedisLocker uses the Redlock
+// algorithm: a lock is held only while a majority of the servers hold it.
+package lock
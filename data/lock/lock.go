@@ -0,0 +1,234 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotAcquired is returned by TryLock when the lock is held elsewhere
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrLockLost is returned when a held lock expired or was taken over
+	ErrLockLost = errors.New("lock: lost")
+)
+
+// Locker acquires named locks
+type Locker interface {
+	// TryLock acquires the lock without waiting, returning ErrNotAcquired if it is held
+	TryLock(ctx context.Context, key string, opts ...Option) (Lock, error)
+	// Lock waits until the lock is acquired or ctx is done
+	Lock(ctx context.Context, key string, opts ...Option) (Lock, error)
+}
+
+// Lock is a held lock
+type Lock interface {
+	// Key returns the lock name
+	Key() string
+	// Context is cancelled when the lock is released, lost, or the acquiring context is done.
+	// Work guarded by the lock should run with it.
+	Context() context.Context
+	// Refresh extends the lock TTL, returning ErrLockLost if it is no longer held
+	Refresh(ctx context.Context) error
+	// Unlock releases the lock
+	Unlock(ctx context.Context) error
+}
+
+// Option configures lock acquisition
+type Option func(*options)
+
+type options struct {
+	ttl           time.Duration
+	retryInterval time.Duration
+	autoRenew     bool
+}
+
+// WithTTL sets how long the lock is held without renewal, defaults to 30s
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval sets how often Lock retries, defaults to 100ms
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.retryInterval = interval
+		}
+	}
+}
+
+// WithAutoRenew enables or disables renewing the lock every TTL/3, enabled by default
+func WithAutoRenew(enabled bool) Option {
+	return func(o *options) {
+		o.autoRenew = enabled
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ttl:           30 * time.Second,
+		retryInterval: 100 * time.Millisecond,
+		autoRenew:     true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// backend acquires leases from a lock service
+type backend interface {
+	// tryAcquire returns nil without error when the lock is held elsewhere
+	tryAcquire(ctx context.Context, key string, ttl time.Duration) (lease, error)
+}
+
+// lease is a lock held in a backend
+type lease interface {
+	refresh(ctx context.Context, ttl time.Duration) error
+	release(ctx context.Context) error
+}
+
+// locker implements Locker on top of a backend
+type locker struct {
+	backend backend
+}
+
+// TryLock acquires the lock without waiting
+func (l *locker) TryLock(ctx context.Context, key string, opts ...Option) (Lock, error) {
+	return l.tryLock(ctx, key, newOptions(opts))
+}
+
+// Lock waits until the lock is acquired or ctx is done
+func (l *locker) Lock(ctx context.Context, key string, opts ...Option) (Lock, error) {
+	o := newOptions(opts)
+	for {
+		lk, err := l.tryLock(ctx, key, o)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lk, err
+		}
+
+		// Jitter retries so waiters do not stampede the backend
+		wait := o.retryInterval/2 + rand.N(o.retryInterval)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *locker) tryLock(ctx context.Context, key string, o *options) (Lock, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ls, err := l.backend.tryAcquire(ctx, key, o.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if ls == nil {
+		return nil, ErrNotAcquired
+	}
+	return newHeld(ctx, key, ls, o), nil
+}
+
+// held is an acquired lock
+type held struct {
+	key   string
+	lease lease
+	ttl   time.Duration
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	released bool
+}
+
+func newHeld(parent context.Context, key string, ls lease, o *options) *held {
+	ctx, cancel := context.WithCancelCause(parent)
+	h := &held{key: key, lease: ls, ttl: o.ttl, ctx: ctx, cancel: cancel}
+	if o.autoRenew {
+		go h.heartbeat()
+	}
+	return h
+}
+
+// Key returns the lock name
+func (h *held) Key() string {
+	return h.key
+}
+
+// Context is cancelled when the lock is released or lost
+func (h *held) Context() context.Context {
+	return h.ctx
+}
+
+// Refresh extends the lock TTL
+func (h *held) Refresh(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.released {
+		return ErrLockLost
+	}
+	err := h.lease.refresh(ctx, h.ttl)
+	if errors.Is(err, ErrLockLost) {
+		h.released = true
+		h.cancel(ErrLockLost)
+	}
+	return err
+}
+
+// Unlock releases the lock
+func (h *held) Unlock(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.released {
+		return nil
+	}
+	h.released = true
+	h.cancel(nil)
+	return h.lease.release(ctx)
+}
+
+// heartbeat renews the lock every TTL/3 until it is released or lost.
+// Transient failures are retried until a full TTL has passed without a renewal.
+func (h *held) heartbeat() {
+	ticker := time.NewTicker(h.ttl / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-h.ctx.Done():
+			// The acquiring context ended without Unlock, release best-effort
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(h.ctx), h.ttl/3)
+			_ = h.Unlock(ctx)
+			cancel()
+			return
+		case <-ticker.C:
+			err := h.Refresh(h.ctx)
+			switch {
+			case err == nil:
+				renewed = time.Now()
+			case errors.Is(err, ErrLockLost):
+				return
+			case time.Since(renewed) >= h.ttl:
+				h.mu.Lock()
+				h.released = true
+				h.mu.Unlock()
+				h.cancel(ErrLockLost)
+				return
+			}
+		}
+	}
+}
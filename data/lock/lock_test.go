@@ -0,0 +1,188 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryBackend is an in-process backend for testing the shared lock logic
+type memoryBackend struct {
+	mu       sync.Mutex
+	holders  map[string]*memoryLease
+	refreshs atomic.Int32
+	failWith error // returned by refresh when set
+}
+
+type memoryLease struct {
+	b   *memoryBackend
+	key string
+}
+
+func newMemoryLocker() (*locker, *memoryBackend) {
+	b := &memoryBackend{holders: map[string]*memoryLease{}}
+	return &locker{backend: b}, b
+}
+
+func (b *memoryBackend) tryAcquire(_ context.Context, key string, _ time.Duration) (lease, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.holders[key] != nil {
+		return nil, nil
+	}
+	l := &memoryLease{b: b, key: key}
+	b.holders[key] = l
+	return l, nil
+}
+
+func (l *memoryLease) refresh(context.Context, time.Duration) error {
+	l.b.refreshs.Add(1)
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.b.failWith != nil {
+		return l.b.failWith
+	}
+	if l.b.holders[l.key] != l {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (l *memoryLease) release(context.Context) error {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.b.holders[l.key] == l {
+		delete(l.b.holders, l.key)
+	}
+	return nil
+}
+
+func TestTryLockIsExclusive(t *testing.T) {
+	l, _ := newMemoryLocker()
+	ctx := context.Background()
+
+	first, err := l.TryLock(ctx, "job", WithAutoRenew(false))
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	if _, err := l.TryLock(ctx, "job"); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("expected ErrNotAcquired, got %v", err)
+	}
+	if _, err := l.TryLock(ctx, "other", WithAutoRenew(false)); err != nil {
+		t.Fatalf("other key should be free: %v", err)
+	}
+
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if first.Context().Err() == nil {
+		t.Error("lock context should be cancelled after Unlock")
+	}
+	if _, err := l.TryLock(ctx, "job", WithAutoRenew(false)); err != nil {
+		t.Fatalf("lock should be free after Unlock: %v", err)
+	}
+}
+
+func TestLockWaitsForRelease(t *testing.T) {
+	l, _ := newMemoryLocker()
+	ctx := context.Background()
+
+	held, err := l.TryLock(ctx, "job", WithAutoRenew(false))
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	time.AfterFunc(30*time.Millisecond, func() { _ = held.Unlock(ctx) })
+
+	got, err := l.Lock(ctx, "job", WithRetryInterval(5*time.Millisecond), WithAutoRenew(false))
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	_ = got.Unlock(ctx)
+
+	_, _ = l.TryLock(ctx, "job", WithAutoRenew(false))
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(timeout, "job", WithRetryInterval(5*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAutoRenewAndLoss(t *testing.T) {
+	l, b := newMemoryLocker()
+	ctx := context.Background()
+
+	held, err := l.TryLock(ctx, "job", WithTTL(30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if b.refreshs.Load() == 0 {
+		t.Fatal("expected the lock to be renewed")
+	}
+	if held.Context().Err() != nil {
+		t.Fatal("renewed lock should still be held")
+	}
+
+	// Another holder takes over, the next renewal detects the loss
+	b.mu.Lock()
+	b.holders["job"] = &memoryLease{b: b, key: "job"}
+	b.mu.Unlock()
+
+	select {
+	case <-held.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock context should be cancelled when the lock is lost")
+	}
+	if cause := context.Cause(held.Context()); !errors.Is(cause, ErrLockLost) {
+		t.Errorf("expected ErrLockLost cause, got %v", cause)
+	}
+}
+
+func TestRenewalFailuresExpireLock(t *testing.T) {
+	l, b := newMemoryLocker()
+	b.failWith = errors.New("connection refused")
+
+	held, err := l.TryLock(context.Background(), "job", WithTTL(30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	select {
+	case <-held.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock should be given up after a TTL without renewal")
+	}
+}
+
+func TestCancelledContextReleasesLock(t *testing.T) {
+	l, b := newMemoryLocker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if _, err := l.TryLock(ctx, "job", WithTTL(30*time.Millisecond)); err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		free := b.holders["job"] == nil
+		b.mu.Unlock()
+		if free {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("lock should be released when its context is done")
+}
+
+func TestAdvisoryIDIsStable(t *testing.T) {
+	if advisoryID("jobs:cleanup") != advisoryID("jobs:cleanup") {
+		t.Fatal("advisory id should be deterministic")
+	}
+	if advisoryID("a") == advisoryID("b") {
+		t.Fatal("different keys should map to different ids")
+	}
+}
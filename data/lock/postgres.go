@@ -0,0 +1,77 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// NewPostgresLocker creates a locker on Postgres session advisory locks.
+// A lock holds a dedicated connection until it is released, so the TTL is not
+// used; renewal checks the session is alive instead, and a dropped session
+// releases the lock on the server.
+func NewPostgresLocker(db *sql.DB) (Locker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+	return &locker{backend: &postgresBackend{db: db}}, nil
+}
+
+type postgresBackend struct {
+	db *sql.DB
+}
+
+func (b *postgresBackend) tryAcquire(ctx context.Context, key string, _ time.Duration) (lease, error) {
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %v", key, err)
+	}
+
+	id := advisoryID(key)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&ok); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to acquire lock %s: %v", key, err)
+	}
+	if !ok {
+		_ = conn.Close()
+		return nil, nil
+	}
+	return &postgresLease{conn: conn, key: key, id: id}, nil
+}
+
+type postgresLease struct {
+	conn *sql.Conn
+	key  string
+	id   int64
+}
+
+func (l *postgresLease) refresh(ctx context.Context, _ time.Duration) error {
+	if err := l.conn.PingContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The session is gone and the server released the lock with it
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (l *postgresLease) release(ctx context.Context) error {
+	// Closing the connection ends the session, which releases the lock even if unlocking fails
+	defer l.conn.Close()
+
+	if _, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.id); err != nil {
+		return fmt.Errorf("failed to release lock %s: %v", l.key, err)
+	}
+	return nil
+}
+
+// advisoryID maps a lock name to an advisory lock key
+func advisoryID(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,140 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// refreshScript extends the TTL only if the lock still holds our token
+var refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock only if it still holds our token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// NewRedisLocker creates a Redis backed locker.
+// With several independent clients it follows Redlock, requiring a majority to hold the lock.
+func NewRedisLocker(prefix string, clients ...redis.UniversalClient) (Locker, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("at least one redis client is required")
+	}
+	if prefix == "" {
+		prefix = "lock"
+	}
+	return &locker{backend: &redisBackend{clients: clients, prefix: prefix}}, nil
+}
+
+type redisBackend struct {
+	clients []redis.UniversalClient
+	prefix  string
+}
+
+func (b *redisBackend) tryAcquire(ctx context.Context, key string, ttl time.Duration) (lease, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	l := &redisLease{backend: b, key: b.prefix + ":" + key, token: token}
+
+	start := time.Now()
+	var (
+		acquired, refused int
+		lastErr           error
+	)
+	for _, c := range b.clients {
+		ok, err := c.SetNX(ctx, l.key, token, ttl).Result()
+		switch {
+		case err != nil:
+			lastErr = err
+		case ok:
+			acquired++
+		default:
+			refused++
+		}
+	}
+
+	// Account for the time spent acquiring and for clock drift between servers
+	drift := ttl/100 + 2*time.Millisecond
+	if acquired >= b.quorum() && ttl-time.Since(start)-drift > 0 {
+		return l, nil
+	}
+
+	_ = l.release(context.WithoutCancel(ctx))
+	if refused == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %v", key, lastErr)
+	}
+	return nil, nil
+}
+
+// quorum returns how many clients must agree
+func (b *redisBackend) quorum() int {
+	return len(b.clients)/2 + 1
+}
+
+type redisLease struct {
+	backend *redisBackend
+	key     string
+	token   string
+}
+
+func (l *redisLease) refresh(ctx context.Context, ttl time.Duration) error {
+	var (
+		extended, failed int
+		lastErr          error
+	)
+	for _, c := range l.backend.clients {
+		n, err := refreshScript.Run(ctx, c, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+		switch {
+		case err != nil:
+			lastErr = err
+		case n == 1:
+			extended++
+		default:
+			failed++
+		}
+	}
+
+	if extended >= l.backend.quorum() {
+		return nil
+	}
+	if failed > len(l.backend.clients)-l.backend.quorum() {
+		return ErrLockLost
+	}
+	return fmt.Errorf("failed to refresh lock %s: %v", l.key, lastErr)
+}
+
+func (l *redisLease) release(ctx context.Context) error {
+	var lastErr error
+	for _, c := range l.backend.clients {
+		if err := releaseScript.Run(ctx, c, []string{l.key}, l.token).Err(); err != nil {
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("failed to release lock %s: %v", l.key, lastErr)
+	}
+	return nil
+}
+
+// newToken returns a random value identifying the lock holder
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
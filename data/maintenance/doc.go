@@ -0,0 +1,34 @@
+// Package maintenance provides recurring maintenance tasks of the data layer:
+// Postgres vacuum and analyze, search index force merges, Redis memory
+// analysis and purges of expired rows such as sessions.
+//
+// The tasks are declared under data.maintenance and scheduled by the
+// extension manager on the elected node, next to the extension tasks:
+//
+//	data:
+//	  maintenance:
+//	    enabled: true
+//	    vacuum:
+//	      schedule: "0 3 * * *"
+//	      dead_ratio: 0.1       # tables with 10% dead tuples, or list tables
+//	    search_optimize:
+//	      schedule: "0 4 * * 0"
+//	      indices: [logs-*]
+//	      max_segments: 1
+//	    redis_memory:
+//	      schedule: "@every 15m"
+//	      sample: 1000          # keys sampled for the largest keys
+//	      max_usage: 0.9        # fail the run above 90% of maxmemory
+//	    session_purge:
+//	      schedule: "@hourly"
+//	      table: sessions
+//	      column: expires_at
+//
+// Runs appear as maintenance.<task> in the task routes of the management
+// API, with their history. Tasks can also be built directly and scheduled
+// with concurrency/scheduler:
+//
+//	for _, t := range maintenance.Tasks(cfg, maintenance.Sources{DB: db, Driver: "postgres"}) {
+//	    s.Add(scheduler.Job{Name: t.Name, Spec: t.Schedule, Func: t.Run, Timeout: t.Timeout, Singleton: true})
+//	}
+package maintenance
@@ -0,0 +1,106 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/config"
+)
+
+// Task is a recurring maintenance task, scheduled by the caller
+type Task struct {
+	Name     string
+	Schedule string        // Cron expression, a descriptor like @daily, or "@every 30s"
+	Timeout  time.Duration // 0 for no limit
+	Run      func(ctx context.Context) error
+}
+
+// Sources are the connections the tasks work on, tasks whose connection is
+// missing are left out
+type Sources struct {
+	DB       *sql.DB // Master database
+	Driver   string  // Driver of DB, e.g. "postgres"
+	Redis    MemoryInspector
+	Search   []IndexOptimizer
+	OnReport func(MemoryReport) // Receives every Redis memory report
+}
+
+// Tasks returns the tasks enabled in cfg that sources can run
+func Tasks(cfg *config.Maintenance, src Sources) []Task {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	var tasks []Task
+	postgres := src.DB != nil && isPostgres(src.Driver)
+	if c := cfg.Vacuum; c != nil && postgres {
+		tasks = append(tasks, Task{
+			Name:     "vacuum",
+			Schedule: c.Schedule,
+			Timeout:  c.Timeout,
+			Run:      Vacuum(src.DB, VacuumOptions{Tables: c.Tables, AnalyzeOnly: c.AnalyzeOnly, DeadRatio: c.DeadRatio}),
+		})
+	}
+	if c := cfg.SearchOptimize; c != nil && len(src.Search) > 0 {
+		tasks = append(tasks, Task{
+			Name:     "search_optimize",
+			Schedule: c.Schedule,
+			Timeout:  c.Timeout,
+			Run:      OptimizeIndices(src.Search, c.Indices, c.MaxSegments),
+		})
+	}
+	if c := cfg.RedisMemory; c != nil && src.Redis != nil {
+		tasks = append(tasks, Task{
+			Name:     "redis_memory",
+			Schedule: c.Schedule,
+			Timeout:  c.Timeout,
+			Run:      AnalyzeMemory(src.Redis, MemoryOptions{Sample: c.Sample, Top: c.Top, MaxUsage: c.MaxUsage, OnReport: src.OnReport}),
+		})
+	}
+	if c := cfg.SessionPurge; c != nil && src.DB != nil {
+		tasks = append(tasks, Task{
+			Name:     "session_purge",
+			Schedule: c.Schedule,
+			Timeout:  c.Timeout,
+			Run:      PurgeExpired(src.DB, src.Driver, PurgeOptions{Table: c.Table, Column: c.Column, BatchSize: c.BatchSize}),
+		})
+	}
+	return tasks
+}
+
+func isPostgres(driver string) bool {
+	switch driver {
+	case "postgres", "pgx", "pgx/v5":
+		return true
+	}
+	return false
+}
+
+// quoteIdent quotes a possibly schema qualified identifier
+func quoteIdent(name string, mysql bool) string {
+	q := `"`
+	if mysql {
+		q = "`"
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// joinErrors prefixes the errors of items that failed
+func joinErrors(what string, errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	list := make([]error, 0, len(errs))
+	for name, err := range errs {
+		list = append(list, fmt.Errorf("%s %s: %w", what, name, err))
+	}
+	return errors.Join(list...)
+}
@@ -0,0 +1,111 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ncobase/ncore/data/config"
+)
+
+type fakeRedis struct {
+	info map[string]string
+	keys map[string]int64
+}
+
+func (f *fakeRedis) MemoryInfo(context.Context) (map[string]string, error) { return f.info, nil }
+
+func (f *fakeRedis) SampleKeys(_ context.Context, n int) ([]string, error) {
+	var keys []string
+	for k := range f.keys {
+		if len(keys) == n {
+			break
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (f *fakeRedis) KeyMemory(_ context.Context, key string) (int64, error) {
+	n, ok := f.keys[key]
+	if !ok {
+		return 0, errors.New("no such key")
+	}
+	return n, nil
+}
+
+func TestAnalyzeMemory(t *testing.T) {
+	r := &fakeRedis{
+		info: map[string]string{"used_memory": "950", "maxmemory": "1000", "mem_fragmentation_ratio": "1.5"},
+		keys: map[string]int64{"a": 10, "b": 300, "c": 20},
+	}
+
+	var report MemoryReport
+	run := AnalyzeMemory(r, MemoryOptions{Sample: 10, Top: 2, MaxUsage: 0.9, OnReport: func(m MemoryReport) { report = m }})
+	err := run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "95%") {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+	if report.Used != 950 || report.Fragmentation != 1.5 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.Largest) != 2 || report.Largest[0].Key != "b" || report.Largest[1].Key != "c" {
+		t.Fatalf("unexpected largest keys %+v", report.Largest)
+	}
+
+	r.info["used_memory"] = "100"
+	if err := run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type fakeEngine struct {
+	merged []string
+	fail   bool
+}
+
+func (f *fakeEngine) ForceMerge(_ context.Context, pattern string, _ int) error {
+	if f.fail {
+		return errors.New("unavailable")
+	}
+	f.merged = append(f.merged, pattern)
+	return nil
+}
+
+func TestOptimizeIndices(t *testing.T) {
+	ok, bad := &fakeEngine{}, &fakeEngine{fail: true}
+	err := OptimizeIndices([]IndexOptimizer{ok, bad}, nil, 1)(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("expected engine error, got %v", err)
+	}
+	if len(ok.merged) != 1 || ok.merged[0] != "*" {
+		t.Fatalf("expected all indices merged, got %v", ok.merged)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	cfg := &config.Maintenance{
+		Enabled:        true,
+		Vacuum:         &config.VacuumTask{Schedule: "@daily"},
+		SearchOptimize: &config.SearchOptimizeTask{Schedule: "@weekly"},
+		RedisMemory:    &config.RedisMemoryTask{Schedule: "@hourly"},
+		SessionPurge:   &config.SessionPurgeTask{Schedule: "@hourly", Table: "sessions", Column: "expires_at"},
+	}
+
+	tasks := Tasks(cfg, Sources{DB: &sql.DB{}, Driver: "mysql", Redis: &fakeRedis{}})
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	// Vacuum needs Postgres and search optimization an engine
+	if strings.Join(names, ",") != "redis_memory,session_purge" {
+		t.Fatalf("unexpected tasks %v", names)
+	}
+
+	cfg.Enabled = false
+	if tasks := Tasks(cfg, Sources{Redis: &fakeRedis{}}); len(tasks) != 0 {
+		t.Fatalf("expected no tasks when disabled, got %d", len(tasks))
+	}
+}
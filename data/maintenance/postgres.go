@@ -0,0 +1,115 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// VacuumOptions selects the Postgres tables to vacuum
+type VacuumOptions struct {
+	Tables      []string // Tables to process, empty for those over DeadRatio
+	AnalyzeOnly bool     // Run ANALYZE instead of VACUUM (ANALYZE)
+	DeadRatio   float64  // Share of dead tuples that selects a table, default 0.1
+}
+
+// Vacuum returns a task running VACUUM (ANALYZE) on Postgres tables. Without
+// explicit tables it picks those whose dead tuples exceed DeadRatio of their
+// rows, according to pg_stat_user_tables.
+func Vacuum(db *sql.DB, opts VacuumOptions) func(ctx context.Context) error {
+	if opts.DeadRatio <= 0 {
+		opts.DeadRatio = 0.1
+	}
+	return func(ctx context.Context) error {
+		tables := opts.Tables
+		if len(tables) == 0 {
+			var err error
+			if tables, err = bloatedTables(ctx, db, opts.DeadRatio); err != nil {
+				return fmt.Errorf("failed to list tables: %w", err)
+			}
+		}
+
+		stmt := "VACUUM (ANALYZE) "
+		if opts.AnalyzeOnly {
+			stmt = "ANALYZE "
+		}
+		errs := make(map[string]error)
+		for _, table := range tables {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// VACUUM cannot run in a transaction, ExecContext runs it on its own
+			if _, err := db.ExecContext(ctx, stmt+quoteIdent(table, false)); err != nil {
+				errs[table] = err
+			}
+		}
+		return joinErrors("table", errs)
+	}
+}
+
+// bloatedTables returns the tables whose dead tuples exceed ratio of their rows
+func bloatedTables(ctx context.Context, db *sql.DB, ratio float64) ([]string, error) {
+	q := sqlq.Rebind(sqlq.DialectOf("postgres"), `SELECT schemaname || '.' || relname FROM pg_stat_user_tables
+		WHERE n_dead_tup > 0 AND n_dead_tup >= ? * GREATEST(n_live_tup, 1)
+		ORDER BY n_dead_tup DESC`)
+	rows, err := db.QueryContext(ctx, q, ratio)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// PurgeOptions selects the expired rows to delete
+type PurgeOptions struct {
+	Table     string
+	Column    string // Expiry timestamp column, rows before now are deleted
+	BatchSize int    // Rows deleted per statement, default 1000
+}
+
+// PurgeExpired returns a task deleting the rows of a table whose expiry has
+// passed, e.g. sessions, in batches so locks stay short
+func PurgeExpired(db *sql.DB, driver string, opts PurgeOptions) func(ctx context.Context) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	mysql := driver == "mysql"
+	table, column := quoteIdent(opts.Table, mysql), quoteIdent(opts.Column, mysql)
+
+	var q string
+	switch {
+	case mysql:
+		q = fmt.Sprintf("DELETE FROM %s WHERE %s < ? LIMIT %d", table, column, opts.BatchSize)
+	case isPostgres(driver):
+		q = fmt.Sprintf("DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s < ? LIMIT %[3]d)", table, column, opts.BatchSize)
+	default:
+		q = fmt.Sprintf("DELETE FROM %[1]s WHERE rowid IN (SELECT rowid FROM %[1]s WHERE %[2]s < ? LIMIT %[3]d)", table, column, opts.BatchSize)
+	}
+	q = sqlq.Rebind(sqlq.DialectOf(driver), q)
+
+	return func(ctx context.Context) error {
+		now := time.Now()
+		for {
+			res, err := db.ExecContext(ctx, q, now)
+			if err != nil {
+				return fmt.Errorf("failed to purge %s: %w", opts.Table, err)
+			}
+			n, err := res.RowsAffected()
+			if err != nil || n < int64(opts.BatchSize) {
+				return err
+			}
+		}
+	}
+}
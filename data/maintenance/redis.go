@@ -0,0 +1,105 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MemoryInspector reads the memory usage of a Redis server
+type MemoryInspector interface {
+	// MemoryInfo returns the fields of INFO memory, e.g. used_memory
+	MemoryInfo(ctx context.Context) (map[string]string, error)
+	// SampleKeys returns up to n keys, e.g. with SCAN
+	SampleKeys(ctx context.Context, n int) ([]string, error)
+	// KeyMemory returns the bytes used by key, MEMORY USAGE
+	KeyMemory(ctx context.Context, key string) (int64, error)
+}
+
+// KeyUsage is the memory used by one key
+type KeyUsage struct {
+	Key   string `json:"key"`
+	Bytes int64  `json:"bytes"`
+}
+
+// MemoryReport is the result of a Redis memory analysis
+type MemoryReport struct {
+	Time          time.Time  `json:"time"`
+	Used          int64      `json:"used"`
+	Peak          int64      `json:"peak"`
+	Max           int64      `json:"max"`   // maxmemory, 0 when unlimited
+	Usage         float64    `json:"usage"` // Used share of Max
+	Fragmentation float64    `json:"fragmentation"`
+	Largest       []KeyUsage `json:"largest,omitempty"` // Largest sampled keys, largest first
+}
+
+// MemoryOptions configures a Redis memory analysis
+type MemoryOptions struct {
+	Sample   int     // Keys sampled for Largest, 0 skips sampling
+	Top      int     // Largest keys kept, default 10
+	MaxUsage float64 // Usage above which the run fails, 0 never fails
+	OnReport func(MemoryReport)
+}
+
+// AnalyzeMemory returns a task reporting Redis memory usage and its largest
+// keys. The run fails when usage exceeds MaxUsage, so it shows in the task
+// history.
+func AnalyzeMemory(inspector MemoryInspector, opts MemoryOptions) func(ctx context.Context) error {
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	return func(ctx context.Context) error {
+		info, err := inspector.MemoryInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read memory info: %w", err)
+		}
+		report := MemoryReport{
+			Time:          time.Now(),
+			Used:          parseInt(info["used_memory"]),
+			Peak:          parseInt(info["used_memory_peak"]),
+			Max:           parseInt(info["maxmemory"]),
+			Fragmentation: parseFloat(info["mem_fragmentation_ratio"]),
+		}
+		if report.Max > 0 {
+			report.Usage = float64(report.Used) / float64(report.Max)
+		}
+
+		if opts.Sample > 0 {
+			keys, err := inspector.SampleKeys(ctx, opts.Sample)
+			if err != nil {
+				return fmt.Errorf("failed to sample keys: %w", err)
+			}
+			for _, key := range keys {
+				n, err := inspector.KeyMemory(ctx, key)
+				if err != nil {
+					continue // Expired since it was sampled
+				}
+				report.Largest = append(report.Largest, KeyUsage{Key: key, Bytes: n})
+			}
+			sort.Slice(report.Largest, func(i, j int) bool { return report.Largest[i].Bytes > report.Largest[j].Bytes })
+			if len(report.Largest) > opts.Top {
+				report.Largest = report.Largest[:opts.Top]
+			}
+		}
+
+		if opts.OnReport != nil {
+			opts.OnReport(report)
+		}
+		if opts.MaxUsage > 0 && report.Usage > opts.MaxUsage {
+			return fmt.Errorf("redis uses %.0f%% of maxmemory, above %.0f%%", report.Usage*100, opts.MaxUsage*100)
+		}
+		return nil
+	}
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,33 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+)
+
+// IndexOptimizer merges the segments of search indices. The Elasticsearch and
+// OpenSearch clients implement it.
+type IndexOptimizer interface {
+	// ForceMerge merges the segments of the indices matching pattern down to
+	// maxSegments, 0 lets the engine decide
+	ForceMerge(ctx context.Context, pattern string, maxSegments int) error
+}
+
+// OptimizeIndices returns a task force merging indices on every engine. A
+// pattern of "*" is used when indices is empty.
+func OptimizeIndices(engines []IndexOptimizer, indices []string, maxSegments int) func(ctx context.Context) error {
+	if len(indices) == 0 {
+		indices = []string{"*"}
+	}
+	return func(ctx context.Context) error {
+		errs := make(map[string]error)
+		for i, engine := range engines {
+			for _, index := range indices {
+				if err := engine.ForceMerge(ctx, index, maxSegments); err != nil {
+					errs[fmt.Sprintf("%s on engine %d", index, i)] = err
+				}
+			}
+		}
+		return joinErrors("index", errs)
+	}
+}
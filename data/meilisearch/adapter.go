@@ -2,11 +2,16 @@ package meilisearch
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/meilisearch/meilisearch-go"
 	"github.com/ncobase/ncore/data/meilisearch/client"
 	"github.com/ncobase/ncore/data/search"
 )
@@ -38,24 +43,55 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 		return nil, errors.New("meilisearch client not available")
 	}
 
+	trace := search.StartTrace(req)
 	searchReq := &client.SearchParams{
 		Offset: int64(req.From),
 		Limit:  int64(req.Size),
 	}
 
 	if len(req.Filter) > 0 {
-		filters := make([]string, 0, len(req.Filter))
-		for field, value := range req.Filter {
-			filters = append(filters, fmt.Sprintf("%s = '%v'", field, value))
+		searchReq.Filter = buildFilter(req.Filter)
+	}
+	for _, s := range req.Sort {
+		order := "asc"
+		if s.Desc {
+			order = "desc"
 		}
-		filterStr := strings.Join(filters, " AND ")
-		searchReq.Filter = filterStr
+		searchReq.Sort = append(searchReq.Sort, s.Field+":"+order)
+	}
+	if len(req.Source) > 0 {
+		searchReq.AttributesToRetrieve = req.Source
+	}
+	if h := req.Highlight; h != nil && len(h.Fields) > 0 {
+		// Highlighted values are returned in the hit's _formatted field
+		searchReq.AttributesToHighlight = h.Fields
+		searchReq.HighlightPreTag = h.PreTag
+		searchReq.HighlightPostTag = h.PostTag
+	}
+	for _, f := range req.Fields {
+		// Boosts are the searchable attributes order set by ApplyRelevance
+		name, _ := search.ParseField(f)
+		searchReq.AttributesToSearchOn = append(searchReq.AttributesToSearchOn, name)
+	}
+	if req.Explain {
+		searchReq.ShowRankingScore = true
+		searchReq.ShowRankingScoreDetails = true
+	}
+
+	trace.Built()
+	if trace != nil {
+		// The SDK encodes the request itself, this is the equivalent body
+		traced := *searchReq
+		traced.Query = req.Query
+		query, _ := json.Marshal(&traced)
+		trace.Serialized(query)
 	}
 
 	msResp, err := a.client.Search(req.Index, req.Query, searchReq)
 	if err != nil {
 		return nil, err
 	}
+	trace.Executed()
 
 	hits := make([]search.Hit, len(msResp.Hits))
 	for i, hit := range msResp.Hits {
@@ -73,12 +109,29 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 			Score:  1.0,
 			Source: hitMap,
 		}
+		if req.Explain {
+			var details map[string]map[string]any
+			_ = hitField(hitMap, "_rankingScore", &hits[i].Score)
+			_ = hitField(hitMap, "_rankingScoreDetails", &details)
+			hits[i].Explanation = rankingExplanation(hits[i].Score, details)
+		}
+		if h := req.Highlight; h != nil && len(h.Fields) > 0 {
+			hits[i].Highlight = formattedHighlights(hitMap, h)
+			delete(hitMap, "_formatted")
+		}
+	}
+
+	if trace != nil {
+		profile, _ := json.Marshal(map[string]int64{"processing_time_ms": msResp.ProcessingTimeMs})
+		trace.Parsed(profile)
 	}
 
-	return &search.Response{
+	resp := &search.Response{
 		Total: int64(msResp.EstimatedTotalHits),
 		Hits:  hits,
-	}, nil
+	}
+	trace.Attach(resp)
+	return resp, nil
 }
 
 func (a *Adapter) Index(ctx context.Context, req *search.IndexRequest) error {
@@ -178,6 +231,74 @@ func (a *Adapter) CreateIndex(ctx context.Context, indexName string, settings *s
 	return nil
 }
 
+// ApplyRelevance sets the searchable attributes ordered by boost, stopwords, synonyms
+// and typo tolerance of an index, waiting for the settings task
+func (a *Adapter) ApplyRelevance(ctx context.Context, indexName string, r *search.Relevance) error {
+	if a.client == nil {
+		return errors.New("meilisearch client not available")
+	}
+
+	settings := &meilisearch.Settings{
+		StopWords: r.Stopwords,
+		Synonyms:  make(map[string][]string),
+	}
+	for _, f := range r.Fields() {
+		name, _ := search.ParseField(f)
+		settings.SearchableAttributes = append(settings.SearchableAttributes, name)
+	}
+	for _, set := range r.Synonyms {
+		for _, term := range set {
+			for _, other := range set {
+				if other != term {
+					settings.Synonyms[term] = append(settings.Synonyms[term], other)
+				}
+			}
+		}
+	}
+	if t := r.Typo; t != nil {
+		one, two := t.Sizes()
+		settings.TypoTolerance = &meilisearch.TypoTolerance{
+			Enabled: t.Enabled,
+			MinWordSizeForTypos: meilisearch.MinWordSizeForTypos{
+				OneTypo:  int64(one),
+				TwoTypos: int64(two),
+			},
+		}
+	}
+
+	if err := a.waitTask(a.client.UpdateSettings(indexName, settings)); err != nil {
+		return err
+	}
+	// Empty settings are omitted from updates, reset them instead
+	index := a.client.GetClient().Index(indexName)
+	if len(r.Stopwords) == 0 {
+		if err := a.waitTask(index.ResetStopWords()); err != nil {
+			return err
+		}
+	}
+	if len(r.Synonyms) == 0 {
+		if err := a.waitTask(index.ResetSynonyms()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitTask waits for an enqueued task, returning its error if it failed
+func (a *Adapter) waitTask(info *meilisearch.TaskInfo, err error) error {
+	if err != nil {
+		return err
+	}
+	task, err := a.client.WaitForTask(info.TaskUID)
+	if err != nil {
+		return err
+	}
+	if task.Status == meilisearch.TaskStatusFailed {
+		return fmt.Errorf("meilisearch task %d failed: %s", task.UID, task.Error.Message)
+	}
+	return nil
+}
+
 func (a *Adapter) Health(ctx context.Context) error {
 	if a.client == nil {
 		return errors.New("meilisearch client not available")
@@ -185,3 +306,158 @@ func (a *Adapter) Health(ctx context.Context) error {
 	_, err := a.client.Health()
 	return err
 }
+
+// hitField decodes a field of a hit
+func hitField(hit map[string]any, name string, v any) error {
+	raw, ok := hit[name]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// formattedHighlights collects the highlighted values of the requested fields
+// from the hit's _formatted field, fields without a match are left out
+func formattedHighlights(hit map[string]any, h *search.Highlight) map[string][]string {
+	var formatted map[string]any
+	if err := hitField(hit, "_formatted", &formatted); err != nil || formatted == nil {
+		return nil
+	}
+	preTag := h.PreTag
+	if preTag == "" {
+		preTag = "<em>"
+	}
+
+	highlights := make(map[string][]string)
+	for _, field := range h.Fields {
+		var value any = formatted
+		for _, key := range strings.Split(field, ".") {
+			m, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[key]
+		}
+		var fragments []string
+		collectHighlights(value, preTag, &fragments)
+		if len(fragments) > 0 {
+			highlights[field] = fragments
+		}
+	}
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// collectHighlights appends the strings of a formatted value that contain the pre tag
+func collectHighlights(value any, preTag string, fragments *[]string) {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, preTag) {
+			*fragments = append(*fragments, v)
+		}
+	case []any:
+		for _, item := range v {
+			collectHighlights(item, preTag, fragments)
+		}
+	}
+}
+
+// rankingExplanation converts ranking score details to an explanation with a detail
+// per ranking rule, in rule order
+func rankingExplanation(score float64, details map[string]map[string]any) *search.Explanation {
+	exp := &search.Explanation{Value: score, Description: "ranking score of rules:"}
+
+	rules := make([]string, 0, len(details))
+	for rule := range details {
+		rules = append(rules, rule)
+	}
+	order := func(rule string) float64 {
+		o, _ := details[rule]["order"].(float64)
+		return o
+	}
+	sort.Slice(rules, func(i, j int) bool { return order(rules[i]) < order(rules[j]) })
+
+	for _, rule := range rules {
+		d := details[rule]
+		value, _ := d["score"].(float64)
+
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			if k != "order" && k != "score" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		desc := rule
+		for _, k := range keys {
+			desc += fmt.Sprintf(" %s=%v", k, d[k])
+		}
+		exp.Details = append(exp.Details, &search.Explanation{Value: value, Description: desc})
+	}
+	return exp
+}
+
+// buildFilter converts Request.Filter to a filter expression with quoted values
+func buildFilter(filter map[string]any) string {
+	fields := make([]string, 0, len(filter))
+	for field := range filter {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	conditions := make([]string, 0, len(fields))
+	for _, field := range fields {
+		switch v := filter[field].(type) {
+		case search.Range:
+			conditions = append(conditions, rangeConditions(field, v)...)
+		case *search.Range:
+			conditions = append(conditions, rangeConditions(field, *v)...)
+		default:
+			rv := reflect.ValueOf(v)
+			if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+				values := make([]string, rv.Len())
+				for i := range values {
+					values[i] = filterValue(rv.Index(i).Interface())
+				}
+				conditions = append(conditions, fmt.Sprintf("%s IN [%s]", field, strings.Join(values, ", ")))
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("%s = %s", field, filterValue(v)))
+		}
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// rangeConditions converts a range to comparisons
+func rangeConditions(field string, r search.Range) []string {
+	var conditions []string
+	for _, c := range []struct {
+		op    string
+		value any
+	}{{">", r.GT}, {">=", r.GTE}, {"<", r.LT}, {"<=", r.LTE}} {
+		if c.value != nil {
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", field, c.op, filterValue(c.value)))
+		}
+	}
+	return conditions
+}
+
+// filterValue formats a filter value, quoting and escaping strings
+func filterValue(v any) string {
+	switch x := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprintf("%v", x)
+	case time.Time:
+		return strconv.FormatInt(x.Unix(), 10)
+	default:
+		s := strings.ReplaceAll(fmt.Sprintf("%v", x), `\`, `\\`)
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+}
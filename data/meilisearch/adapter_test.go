@@ -0,0 +1,42 @@
+package meilisearch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ncobase/ncore/data/search"
+)
+
+func TestFormattedHighlights(t *testing.T) {
+	hit := map[string]any{
+		"id": json.RawMessage(`"1"`),
+		"_formatted": json.RawMessage(`{
+			"title": "The <em>quick</em> fox",
+			"body": "nothing matched here",
+			"tags": ["<em>quick</em>", "slow", "<em>quick</em>er"],
+			"author": {"name": "<em>Quick</em>sey"}
+		}`),
+	}
+
+	got := formattedHighlights(hit, &search.Highlight{Fields: []string{"title", "body", "tags", "author.name", "missing"}})
+	want := map[string][]string{
+		"title":       {"The <em>quick</em> fox"},
+		"tags":        {"<em>quick</em>", "<em>quick</em>er"},
+		"author.name": {"<em>Quick</em>sey"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("formattedHighlights = %v, want %v", got, want)
+	}
+
+	hit["_formatted"] = json.RawMessage(`{"title": "The [quick] fox"}`)
+	got = formattedHighlights(hit, &search.Highlight{Fields: []string{"title"}, PreTag: "[", PostTag: "]"})
+	if !reflect.DeepEqual(got, map[string][]string{"title": {"The [quick] fox"}}) {
+		t.Fatalf("formattedHighlights with custom tags = %v", got)
+	}
+
+	delete(hit, "_formatted")
+	if got := formattedHighlights(hit, &search.Highlight{Fields: []string{"title"}}); got != nil {
+		t.Fatalf("formattedHighlights without _formatted = %v, want nil", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/meilisearch/meilisearch-go"
+	"github.com/ncobase/ncore/data/search"
 )
 
 // Client Meilisearch client wrapper
@@ -38,11 +39,28 @@ func (c *Client) Search(index, query string, options *meilisearch.SearchRequest)
 	}
 	resp, err := c.client.Index(index).Search(query, options)
 	if err != nil {
-		return nil, fmt.Errorf("meilisearch search error: %v", err)
+		return nil, fmt.Errorf("meilisearch search error: %w", statusError(err))
 	}
 	return resp, nil
 }
 
+// statusError returns err as a search.StatusError when Meilisearch answered with
+// an error status, or the transport error of a request that did not reach it,
+// so failover tells engine faults from rejected queries
+func statusError(err error) error {
+	var msErr *meilisearch.Error
+	if !errors.As(err, &msErr) {
+		return err
+	}
+	if msErr.StatusCode != 0 {
+		return &search.StatusError{Engine: search.Meilisearch, StatusCode: msErr.StatusCode, Err: err}
+	}
+	if msErr.OriginError != nil {
+		return fmt.Errorf("meilisearch unreachable: %w", msErr.OriginError)
+	}
+	return err
+}
+
 // IndexDocuments indexes documents to Meilisearch (alias for AddDocuments)
 func (c *Client) IndexDocuments(index string, documents any, primaryKey ...string) error {
 	return c.AddDocuments(index, documents, primaryKey...)
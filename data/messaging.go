@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"time"
+
+	"github.com/ncobase/ncore/data/claimcheck"
+	"github.com/ncobase/ncore/data/compress"
 )
 
 type rabbitMQ interface {
@@ -13,12 +18,88 @@ type rabbitMQ interface {
 	ConsumeMessages(queue string, handler func([]byte) error) error
 }
 
+// rabbitMQContext is implemented by RabbitMQ clients whose consumers stop with a context
+type rabbitMQContext interface {
+	ConsumeMessagesContext(ctx context.Context, queue string, handler func([]byte) error) error
+}
+
 type kafkaMQ interface {
 	IsConnected() bool
 	PublishMessage(ctx context.Context, topic string, key, value []byte) error
 	ConsumeMessages(ctx context.Context, topic, groupID string, handler func([]byte) error) error
 }
 
+// Compressor returns the compressor of queue payloads, nil when
+// data.messaging.compression is not set. Its Stats report compression ratios.
+func (d *Data) Compressor() *compress.Compressor {
+	return d.compressor
+}
+
+// SetClaimStore attaches the store of offloaded queue payloads. Payloads are
+// offloaded over the threshold of data.messaging.claim_check, without that
+// section references published by other services are only claimed.
+func (d *Data) SetClaimStore(store claimcheck.Store) {
+	var checker *claimcheck.Checker
+	if store != nil {
+		opts := claimcheck.Options{Threshold: math.MaxInt}
+		if d.conf != nil && d.conf.Messaging != nil && d.conf.Messaging.ClaimCheck != nil {
+			c := d.conf.Messaging.ClaimCheck
+			opts = claimcheck.Options{Threshold: c.Threshold, Prefix: c.Prefix}
+		}
+		checker = claimcheck.New(store, opts)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.claims = checker
+}
+
+// ClaimCheck returns the claim checker of queue payloads, nil until a store
+// is attached. Its Stats report offloaded payloads.
+func (d *Data) ClaimCheck() *claimcheck.Checker {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.claims
+}
+
+// compressorFor returns the compressor of payloads sent through the named
+// topics, exchanges or queues, nil when compression is off for all of them
+func (d *Data) compressorFor(names ...string) *compress.Compressor {
+	if d.compressor == nil || d.conf == nil || d.conf.Messaging == nil || d.conf.Messaging.Compression == nil {
+		return d.compressor
+	}
+	topics := d.conf.Messaging.Compression.Topics
+	if len(topics) == 0 {
+		return d.compressor
+	}
+	for _, name := range names {
+		if slices.Contains(topics, name) {
+			return d.compressor
+		}
+	}
+	return nil
+}
+
+// encodePayload compresses a queue payload for the named topics and offloads
+// it when still too large
+func (d *Data) encodePayload(ctx context.Context, body []byte, names ...string) ([]byte, error) {
+	body, err := d.compressorFor(names...).Encode(body)
+	if err != nil {
+		return nil, err
+	}
+	return d.ClaimCheck().Offload(ctx, body)
+}
+
+// decodePayload reverses encodePayload, payloads of topics without compression
+// are not decoded
+func (d *Data) decodePayload(ctx context.Context, body []byte, names ...string) ([]byte, error) {
+	body, err := d.ClaimCheck().Claim(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.compressorFor(names...).Decode(body)
+}
+
 // IsMessagingEnabled checks if messaging services
 func (d *Data) IsMessagingEnabled() bool {
 	d.mu.RLock()
@@ -104,7 +185,9 @@ func (d *Data) PublishToRabbitMQ(exchange, routingKey string, body []byte) error
 			if !rmq.IsConnected() {
 				err = fmt.Errorf("RabbitMQ connection is not active")
 			} else {
-				err = rmq.PublishMessage(exchange, routingKey, body)
+				if body, err = d.encodePayload(context.Background(), body, exchange, routingKey); err == nil {
+					err = rmq.PublishMessage(exchange, routingKey, body)
+				}
 			}
 		}
 	}
@@ -121,6 +204,11 @@ func (d *Data) PublishToRabbitMQ(exchange, routingKey string, body []byte) error
 
 // ConsumeFromRabbitMQ consumes messages from RabbitMQ with metrics
 func (d *Data) ConsumeFromRabbitMQ(queue string, handler func([]byte) error) error {
+	return d.ConsumeFromRabbitMQContext(context.Background(), queue, handler)
+}
+
+// ConsumeFromRabbitMQContext consumes messages from RabbitMQ with metrics until ctx is done
+func (d *Data) ConsumeFromRabbitMQContext(ctx context.Context, queue string, handler func([]byte) error) error {
 	if !d.IsMessagingEnabled() {
 		return errors.New("messaging is disabled")
 	}
@@ -151,7 +239,10 @@ func (d *Data) ConsumeFromRabbitMQ(queue string, handler func([]byte) error) err
 
 	wrappedHandler := func(data []byte) error {
 		start := time.Now()
-		err := handler(data)
+		data, err := d.decodePayload(ctx, data, queue)
+		if err == nil {
+			err = handler(data)
+		}
 		duration := time.Since(start)
 
 		d.collector.MQConsume("rabbitmq", err)
@@ -163,6 +254,9 @@ func (d *Data) ConsumeFromRabbitMQ(queue string, handler func([]byte) error) err
 		return err
 	}
 
+	if rc, ok := rmq.(rabbitMQContext); ok {
+		return rc.ConsumeMessagesContext(ctx, queue, wrappedHandler)
+	}
 	return rmq.ConsumeMessages(queue, wrappedHandler)
 }
 
@@ -188,7 +282,9 @@ func (d *Data) PublishToKafka(ctx context.Context, topic string, key, value []by
 			if !kfk.IsConnected() {
 				err = fmt.Errorf("kafka connection is not active")
 			} else {
-				err = kfk.PublishMessage(ctx, topic, key, value)
+				if value, err = d.encodePayload(ctx, value, topic); err == nil {
+					err = kfk.PublishMessage(ctx, topic, key, value)
+				}
 			}
 		}
 	}
@@ -235,7 +331,10 @@ func (d *Data) ConsumeFromKafka(ctx context.Context, topic, groupID string, hand
 
 	wrappedHandler := func(data []byte) error {
 		start := time.Now()
-		err := handler(data)
+		data, err := d.decodePayload(ctx, data, topic)
+		if err == nil {
+			err = handler(data)
+		}
 		duration := time.Since(start)
 
 		d.collector.MQConsume("kafka", err)
@@ -0,0 +1,78 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ncobase/ncore/data/compress"
+	"github.com/ncobase/ncore/data/config"
+	"github.com/ncobase/ncore/data/connection"
+	"github.com/ncobase/ncore/data/metrics"
+)
+
+// fakeKafka keeps the last value published to each topic and consumes the
+// values queued for a topic
+type fakeKafka struct {
+	published map[string][]byte
+	queued    map[string][]byte
+}
+
+func (k *fakeKafka) IsConnected() bool { return true }
+
+func (k *fakeKafka) PublishMessage(_ context.Context, topic string, _, value []byte) error {
+	k.published[topic] = value
+	return nil
+}
+
+func (k *fakeKafka) ConsumeMessages(_ context.Context, topic, _ string, handler func([]byte) error) error {
+	return handler(k.queued[topic])
+}
+
+func TestMessagingCompressionTopics(t *testing.T) {
+	cp, err := compress.New(compress.Options{Codec: "gzip", Threshold: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kfk := &fakeKafka{published: map[string][]byte{}, queued: map[string][]byte{}}
+	d := &Data{
+		Conn:      &connection.Connections{KFK: kfk},
+		collector: metrics.NoOpCollector{},
+		conf: &config.Config{Messaging: &config.Messaging{
+			Enabled:     true,
+			Compression: &config.Compression{Codec: "gzip", Threshold: 64, Topics: []string{"orders"}},
+		}},
+		compressor: cp,
+	}
+	ctx := context.Background()
+	payload := []byte(strings.Repeat(`{"id":"42","status":"paid"},`, 20))
+
+	for _, topic := range []string{"orders", "audit"} {
+		if err := d.PublishToKafka(ctx, topic, nil, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !compress.IsCompressed(kfk.published["orders"]) || !bytes.Equal(kfk.published["audit"], payload) {
+		t.Fatal("only the topic with compression enabled was expected compressed")
+	}
+
+	consume := func(topic string, value []byte) []byte {
+		t.Helper()
+		kfk.queued[topic] = value
+		var got []byte
+		if err := d.ConsumeFromKafka(ctx, topic, "g", func(b []byte) error { got = b; return nil }); err != nil {
+			t.Fatalf("consume %s: %v", topic, err)
+		}
+		return got
+	}
+	if got := consume("orders", kfk.published["orders"]); !bytes.Equal(got, payload) {
+		t.Fatalf("compressed payload not decoded: %q", got)
+	}
+
+	// Binary payloads of other topics are passed as they are, whatever they start with
+	binary := []byte{0xff, 'Z', 1, 0x08, 0x96, 0x01}
+	if got := consume("audit", binary); !bytes.Equal(got, binary) {
+		t.Fatalf("payload of a topic without compression changed to %v", got)
+	}
+}
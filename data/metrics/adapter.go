@@ -89,3 +89,10 @@ func (a *ExtensionCollectorAdapter) HealthCheck(component string, healthy bool)
 		a.collector.HealthCheck(component, healthy)
 	}
 }
+
+// ClickHouseOperation forwards to the extension collector if it records ClickHouse operations
+func (a *ExtensionCollectorAdapter) ClickHouseOperation(operation string, duration time.Duration, err error) {
+	if cc, ok := a.collector.(ClickHouseCollector); ok {
+		cc.ClickHouseOperation(operation, duration, err)
+	}
+}
@@ -24,6 +24,22 @@ type CacheMetricsCollector interface {
 	RedisCommand(command string, err error)
 }
 
+// ReplicaLagCollector is implemented by collectors recording database replica lag
+type ReplicaLagCollector interface {
+	ReplicaLag(replica string, lag time.Duration, err error)
+}
+
+// HedgeCollector is implemented by collectors recording hedged database reads,
+// won reports whether the hedge replica answered first
+type HedgeCollector interface {
+	DBHedge(primary, hedge string, won bool)
+}
+
+// ClickHouseCollector is implemented by collectors recording ClickHouse operations
+type ClickHouseCollector interface {
+	ClickHouseOperation(operation string, duration time.Duration, err error)
+}
+
 type NoOpCollector struct{}
 
 func (NoOpCollector) DBQuery(time.Duration, error) {}
@@ -53,9 +69,20 @@ type DataCollector struct {
 	mongoOperations atomic.Int64
 	mongoErrors     atomic.Int64
 
-	searchQueries  atomic.Int64
-	searchErrors   atomic.Int64
-	searchIndexOps atomic.Int64
+	clickhouseOperations atomic.Int64
+	clickhouseErrors     atomic.Int64
+	clickhouseSlowOps    atomic.Int64
+
+	searchQueries   atomic.Int64
+	searchErrors    atomic.Int64
+	searchIndexOps  atomic.Int64
+	searchFailovers atomic.Int64
+
+	queryCacheHits   atomic.Int64
+	queryCacheMisses atomic.Int64
+
+	replicaLags map[string]replicaLag
+	replicaMu   sync.RWMutex
 
 	mqPublished     atomic.Int64
 	mqPublishErrors atomic.Int64
@@ -68,6 +95,7 @@ type DataCollector struct {
 	lastDBQuery      atomic.Value
 	lastRedisCommand atomic.Value
 	lastMongoOp      atomic.Value
+	lastClickHouseOp atomic.Value
 	lastSearchQuery  atomic.Value
 	lastMQOperation  atomic.Value
 
@@ -77,6 +105,11 @@ type DataCollector struct {
 	bufferMu  sync.Mutex
 }
 
+type replicaLag struct {
+	lag     time.Duration
+	healthy bool
+}
+
 type Metric struct {
 	Type      string    `json:"type"`
 	Value     int64     `json:"value"`
@@ -107,6 +140,7 @@ func NewDataCollector(batchSize int) *DataCollector {
 
 	c := &DataCollector{
 		healthChecks: make(map[string]*atomic.Bool),
+		replicaLags:  make(map[string]replicaLag),
 		storage:      NewMemoryStorage(),
 		batchSize:    batchSize,
 		buffer:       make([]Metric, 0, batchSize),
@@ -116,6 +150,7 @@ func NewDataCollector(batchSize int) *DataCollector {
 	c.lastDBQuery.Store(now)
 	c.lastRedisCommand.Store(now)
 	c.lastMongoOp.Store(now)
+	c.lastClickHouseOp.Store(now)
 	c.lastSearchQuery.Store(now)
 	c.lastMQOperation.Store(now)
 
@@ -189,6 +224,23 @@ func (c *DataCollector) MongoOperation(operation string, err error) {
 	})
 }
 
+func (c *DataCollector) ClickHouseOperation(operation string, duration time.Duration, err error) {
+	c.clickhouseOperations.Add(1)
+	c.lastClickHouseOp.Store(time.Now())
+
+	if err != nil {
+		c.clickhouseErrors.Add(1)
+	}
+	if duration > time.Second {
+		c.clickhouseSlowOps.Add(1)
+	}
+
+	c.recordMetric("clickhouse_operation", duration.Milliseconds(), Labels{
+		"operation": operation,
+		"success":   boolToString(err == nil),
+	})
+}
+
 func (c *DataCollector) SearchQuery(engine string, err error) {
 	c.searchQueries.Add(1)
 	c.lastSearchQuery.Store(time.Now())
@@ -212,6 +264,39 @@ func (c *DataCollector) SearchIndex(engine, operation string) {
 	})
 }
 
+func (c *DataCollector) SearchFailover(from, to, reason string) {
+	c.searchFailovers.Add(1)
+
+	c.recordMetric("search_failover", 1, Labels{
+		"from":   from,
+		"to":     to,
+		"reason": reason,
+	})
+}
+
+func (c *DataCollector) QueryCache(hit bool) {
+	if hit {
+		c.queryCacheHits.Add(1)
+	} else {
+		c.queryCacheMisses.Add(1)
+	}
+
+	c.recordMetric("query_cache", 1, Labels{
+		"hit": boolToString(hit),
+	})
+}
+
+func (c *DataCollector) ReplicaLag(replica string, lag time.Duration, err error) {
+	c.replicaMu.Lock()
+	c.replicaLags[replica] = replicaLag{lag: lag, healthy: err == nil}
+	c.replicaMu.Unlock()
+
+	c.recordMetric("replica_lag", lag.Milliseconds(), Labels{
+		"replica": replica,
+		"success": boolToString(err == nil),
+	})
+}
+
 func (c *DataCollector) MQPublish(system string, err error) {
 	c.mqPublished.Add(1)
 	c.lastMQOperation.Store(time.Now())
@@ -298,6 +383,16 @@ func (c *DataCollector) GetStats() map[string]any {
 	}
 	c.healthMu.RUnlock()
 
+	c.replicaMu.RLock()
+	replicas := make(map[string]any, len(c.replicaLags))
+	for replica, l := range c.replicaLags {
+		replicas[replica] = map[string]any{
+			"lag_ms":  l.lag.Milliseconds(),
+			"healthy": l.healthy,
+		}
+	}
+	c.replicaMu.RUnlock()
+
 	return map[string]any{
 		"database": map[string]any{
 			"connections":  c.dbConnections.Load(),
@@ -307,6 +402,7 @@ func (c *DataCollector) GetStats() map[string]any {
 			"transactions": c.dbTransactions.Load(),
 			"tx_errors":    c.dbTxErrors.Load(),
 			"last_query":   c.lastDBQuery.Load(),
+			"replicas":     replicas,
 		},
 		"redis": map[string]any{
 			"connections":  c.redisConnections.Load(),
@@ -319,12 +415,23 @@ func (c *DataCollector) GetStats() map[string]any {
 			"errors":         c.mongoErrors.Load(),
 			"last_operation": c.lastMongoOp.Load(),
 		},
+		"clickhouse": map[string]any{
+			"operations":     c.clickhouseOperations.Load(),
+			"errors":         c.clickhouseErrors.Load(),
+			"slow_ops":       c.clickhouseSlowOps.Load(),
+			"last_operation": c.lastClickHouseOp.Load(),
+		},
 		"search": map[string]any{
 			"queries":    c.searchQueries.Load(),
 			"errors":     c.searchErrors.Load(),
 			"index_ops":  c.searchIndexOps.Load(),
+			"failovers":  c.searchFailovers.Load(),
 			"last_query": c.lastSearchQuery.Load(),
 		},
+		"query_cache": map[string]any{
+			"hits":   c.queryCacheHits.Load(),
+			"misses": c.queryCacheMisses.Load(),
+		},
 		"messaging": map[string]any{
 			"published":      c.mqPublished.Load(),
 			"publish_errors": c.mqPublishErrors.Load(),
@@ -0,0 +1,387 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsafe is returned when the safety policy blocks a dangerous migration
+var ErrUnsafe = errors.New("migrate: unsafe migration")
+
+// allowUnsafeDirective in an up script skips its safety analysis, for
+// reviewed changes run in a maintenance window
+const allowUnsafeDirective = "-- migrate:allow-unsafe"
+
+// Policy decides what the safety analysis does with its findings
+type Policy string
+
+const (
+	PolicyOff   Policy = ""      // No analysis
+	PolicyWarn  Policy = "warn"  // Report findings and run the migrations
+	PolicyBlock Policy = "block" // Refuse to run migrations with dangerous findings
+)
+
+// Severity ranks a finding
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning" // Risky, e.g. on a table of unknown size
+	SeverityDanger  Severity = "danger"  // Locks or rewrites a large table
+)
+
+// Finding is a risky operation in a migration
+type Finding struct {
+	Version    int64    `json:"version"`
+	Name       string   `json:"name"`
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Table      string   `json:"table,omitempty"`
+	Rows       int64    `json:"rows,omitempty"` // Estimated rows of Table, -1 when unknown
+	Statement  string   `json:"statement"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion"`
+}
+
+// String formats a finding for logs
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %d_%s: %s (%s)", f.Severity, f.Version, f.Name, f.Message, f.Suggestion)
+}
+
+// table matches a possibly schema qualified and quoted table name
+const table = "(?P<table>[`\"]?[\\w$]+[`\"]?(?:\\.[`\"]?[\\w$]+[`\"]?)?)"
+
+// alterTable matches the start of an ALTER TABLE statement
+const alterTable = `^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?` + table
+
+// rule is a dangerous pattern of a dialect, "" for both
+type rule struct {
+	name       string
+	dialect    string
+	pattern    *regexp.Regexp
+	skip       *regexp.Regexp // Statements matching it are safe variants
+	large      bool           // Only dangerous on large tables
+	severity   Severity
+	message    string // %s is the table
+	suggestion string
+}
+
+var rules = []rule{
+	{
+		name:       "index-not-concurrent",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:\S+\s+)?ON\s+(?:ONLY\s+)?` + table),
+		skip:       regexp.MustCompile(`(?i)\bCONCURRENTLY\b`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "CREATE INDEX blocks writes to %s while the index builds",
+		suggestion: "use CREATE INDEX CONCURRENTLY in a migration starting with " + noTxDirective,
+	},
+	{
+		name:       "drop-index-not-concurrent",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)^DROP\s+INDEX\s+`),
+		skip:       regexp.MustCompile(`(?i)\bCONCURRENTLY\b`),
+		severity:   SeverityWarning,
+		message:    "DROP INDEX takes an exclusive lock on its table",
+		suggestion: "use DROP INDEX CONCURRENTLY in a migration starting with " + noTxDirective,
+	},
+	{
+		name:       "column-type-change",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\bALTER\s+(?:COLUMN\s+)?\S+\s+(?:SET\s+DATA\s+)?TYPE\b`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "changing a column type rewrites %s under an exclusive lock",
+		suggestion: "add a new column, backfill it in batches and switch the code before dropping the old one",
+	},
+	{
+		name:       "column-type-change",
+		dialect:    "mysql",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\b(?:MODIFY|CHANGE)\s+`),
+		skip:       regexp.MustCompile(`(?i)\bALGORITHM\s*=\s*(?:INSTANT|INPLACE)\b`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "changing a column copies %s and blocks writes",
+		suggestion: "add a new column and backfill it in batches, or use gh-ost or pt-online-schema-change",
+	},
+	{
+		name:       "alter-without-algorithm",
+		dialect:    "mysql",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s+(?:ADD|DROP)\s`),
+		skip:       regexp.MustCompile(`(?i)\bALGORITHM\s*=`),
+		large:      true,
+		severity:   SeverityWarning,
+		message:    "ALTER TABLE may copy %s and block writes, depending on the change",
+		suggestion: "state ALGORITHM=INSTANT or ALGORITHM=INPLACE, LOCK=NONE so MySQL refuses a blocking change",
+	},
+	{
+		name:       "set-not-null",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\bALTER\s+(?:COLUMN\s+)?\S+\s+SET\s+NOT\s+NULL\b`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "SET NOT NULL scans %s under an exclusive lock",
+		suggestion: "add CHECK (column IS NOT NULL) NOT VALID, validate it in a later migration, then SET NOT NULL",
+	},
+	{
+		name:       "constraint-validation",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\bADD\s+(?:CONSTRAINT\s+\S+\s+)?(?:FOREIGN\s+KEY|CHECK)\b`),
+		skip:       regexp.MustCompile(`(?i)\bNOT\s+VALID\b`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "adding a constraint scans %s while blocking writes",
+		suggestion: "add it with NOT VALID and run VALIDATE CONSTRAINT in a later migration",
+	},
+	{
+		name:       "volatile-default",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\bADD\s+(?:COLUMN\s+)?.*\bDEFAULT\s+(?:clock_timestamp|random|gen_random_uuid|uuid_generate_v[14]|timeofday)\s*\(`),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "a column with a volatile default rewrites %s",
+		suggestion: "add the column without a default, then set the default and backfill it in batches",
+	},
+	{
+		name:       "table-rewrite",
+		dialect:    "postgres",
+		pattern:    regexp.MustCompile(`(?i)^(?:VACUUM\s+(?:\([^)]*\bFULL\b[^)]*\)|FULL)|CLUSTER)\s+(?:VERBOSE\s+)?` + table),
+		large:      true,
+		severity:   SeverityDanger,
+		message:    "rewrites %s under an exclusive lock",
+		suggestion: "use pg_repack, or run it in a maintenance window",
+	},
+	{
+		name:       "rename",
+		pattern:    regexp.MustCompile(`(?i)(?:` + alterTable + `\s.*\bRENAME\b|^RENAME\s+TABLE\s+` + table + `)`),
+		severity:   SeverityWarning,
+		message:    "renaming breaks instances still using the old name during the deploy",
+		suggestion: "expand and contract: add the new name, switch the code, drop the old name in a later release",
+	},
+	{
+		name:       "drop-column",
+		pattern:    regexp.MustCompile(`(?i)` + alterTable + `\s.*\bDROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?[\w"` + "`" + `]+`),
+		skip:       regexp.MustCompile(`(?i)\bDROP\s+(?:CONSTRAINT|INDEX|KEY|PRIMARY|FOREIGN|CHECK|DEFAULT|NOT\s+NULL|IDENTITY|EXPRESSION)\b`),
+		severity:   SeverityWarning,
+		message:    "dropping a column breaks instances still reading it during the deploy",
+		suggestion: "stop using the column in a release first and drop it in a later one",
+	},
+	{
+		name:       "unbatched-dml",
+		pattern:    regexp.MustCompile(`(?i)^(?:UPDATE\s+(?:ONLY\s+)?|DELETE\s+FROM\s+(?:ONLY\s+)?)` + table),
+		skip:       regexp.MustCompile(`(?i)\bWHERE\b`),
+		large:      true,
+		severity:   SeverityWarning,
+		message:    "changes every row of %s in one transaction",
+		suggestion: "backfill in batches outside the migration, e.g. with data/bulk",
+	},
+}
+
+// createTable matches tables created by a statement, which are empty
+var createTable = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+|TEMPORARY\s+|TEMP\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + table)
+
+// RowEstimator returns the estimated rows of a table, ok false when unknown
+type RowEstimator func(ctx context.Context, table string) (rows int64, ok bool)
+
+// Analyze returns the risky operations of the up script of mig for driver,
+// "postgres", "pgx" or "mysql". With a nil estimator every table is of
+// unknown size, so size dependent findings are warnings.
+func Analyze(ctx context.Context, mig *Migration, driver string, largeTable int64, estimate RowEstimator) []Finding {
+	if strings.Contains(mig.Up, allowUnsafeDirective) {
+		return nil
+	}
+	if largeTable <= 0 {
+		largeTable = 100000
+	}
+	dialect := driver
+	if driver == "pgx" {
+		dialect = "postgres"
+	}
+
+	var (
+		findings []Finding
+		created  = make(map[string]bool)
+	)
+	for _, stmt := range splitStatements(mig.Up, dialect == "mysql") {
+		stmt = normalize(stmt)
+		if m := createTable.FindStringSubmatch(stmt); m != nil {
+			created[unquote(m[createTable.SubexpIndex("table")])] = true
+			continue
+		}
+
+		for _, r := range rules {
+			if r.dialect != "" && r.dialect != dialect {
+				continue
+			}
+			m := r.pattern.FindStringSubmatch(stmt)
+			if m == nil || (r.skip != nil && r.skip.MatchString(stmt)) {
+				continue
+			}
+
+			f := Finding{
+				Version:    mig.Version,
+				Name:       mig.Name,
+				Rule:       r.name,
+				Severity:   r.severity,
+				Rows:       -1,
+				Statement:  stmt,
+				Suggestion: r.suggestion,
+			}
+			if i := r.pattern.SubexpIndex("table"); i > 0 {
+				f.Table = unquote(m[i])
+			}
+			if f.Table == "" {
+				for _, n := range m[1:] {
+					if n != "" {
+						f.Table = unquote(n)
+						break
+					}
+				}
+			}
+			if created[f.Table] {
+				continue
+			}
+
+			if r.large {
+				if estimate != nil {
+					if rows, ok := estimate(ctx, f.Table); ok {
+						f.Rows = rows
+					}
+				}
+				switch {
+				case f.Rows < 0:
+					f.Severity = SeverityWarning
+				case f.Rows < largeTable:
+					continue
+				}
+			}
+			if strings.Contains(r.message, "%s") {
+				f.Message = fmt.Sprintf(r.message, f.Table)
+			} else {
+				f.Message = r.message
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Advise analyzes the pending migrations with row estimates from the database
+func (m *Migrator) Advise(ctx context.Context) ([]Finding, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration connection: %v", err)
+	}
+	defer conn.Close()
+
+	reset, err := m.useSchema(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer reset()
+
+	var findings []Finding
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			continue
+		}
+		if mig := m.find(s.Version); mig != nil {
+			findings = append(findings, Analyze(ctx, mig, m.driver(), m.largeTable, m.estimator(conn))...)
+		}
+	}
+	return findings, nil
+}
+
+// checkSafety applies the safety policy to migrations about to run
+func (m *Migrator) checkSafety(ctx context.Context, db execer, pending []*Migration) error {
+	if m.safety == PolicyOff {
+		return nil
+	}
+	var dangerous []string
+	for _, mig := range pending {
+		for _, f := range Analyze(ctx, mig, m.driver(), m.largeTable, m.estimator(db)) {
+			m.onFinding(f)
+			if f.Severity == SeverityDanger {
+				dangerous = append(dangerous, fmt.Sprintf("%d_%s: %s", f.Version, f.Name, f.Message))
+			}
+		}
+	}
+	if m.safety == PolicyBlock && len(dangerous) > 0 {
+		return fmt.Errorf("%w: %s; add %s to a reviewed script to run it anyway",
+			ErrUnsafe, strings.Join(dangerous, "; "), allowUnsafeDirective)
+	}
+	return nil
+}
+
+// driver returns the dialect of the migrator
+func (m *Migrator) driver() string {
+	switch {
+	case m.postgres:
+		return "postgres"
+	case m.mysql:
+		return "mysql"
+	}
+	return ""
+}
+
+// estimator returns row estimates from the catalog of db
+func (m *Migrator) estimator(db execer) RowEstimator {
+	return func(ctx context.Context, table string) (int64, bool) {
+		var query string
+		args := []any{table}
+		switch {
+		case m.postgres:
+			// reltuples is -1 for tables never analyzed
+			query = "SELECT COALESCE((SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)), 0)"
+		case m.mysql:
+			if _, name, ok := strings.Cut(table, "."); ok {
+				table = name
+			}
+			query = "SELECT COALESCE((SELECT table_rows FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?), 0)"
+			args = []any{table}
+		default:
+			return 0, false
+		}
+
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return 0, false
+		}
+		defer rows.Close()
+		var n int64
+		if !rows.Next() || rows.Scan(&n) != nil || n < 0 {
+			return 0, false
+		}
+		return n, true
+	}
+}
+
+// normalize drops comments and collapses whitespace of a statement
+func normalize(stmt string) string {
+	var lines []string
+	for _, line := range strings.Split(stmt, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// unquote strips identifier quotes from a table name
+func unquote(name string) string {
+	return strings.ToLower(strings.NewReplacer(`"`, "", "`", "").Replace(name))
+}
+
+// logFinding is the default finding handler
+func logFinding(f Finding) {
+	log.Printf("migrate: %s", f)
+}
@@ -0,0 +1,31 @@
+// Package migrate applies versioned SQL migrations, replacing ad hoc
+// CREATE TABLE IF NOT EXISTS statements in repositories.
+//
+// Migrations are files named <version>_<name>.up.sql with an optional
+// <version>_<name>.down.sql, usually embedded:
+//
+//	//go:embed *.sql
+//	var FS embed.FS
+//
+//	m, err := migrate.New(db, migrations.FS, migrate.Options{Driver: "postgres"})
+//	applied, err := m.Up(ctx)
+//
+// Or through the data layer, using the master database and its driver:
+//
+//	applied, err := d.Migrate(ctx, migrations.FS)
+//
+// Applied versions are recorded in schema_migrations with the checksum of their
+// up script; changing an applied script fails with ErrChecksumMismatch. Each
+// migration runs in its own transaction unless its first line is
+// "-- migrate:no-transaction". Concurrent runs, e.g. from several replicas
+// starting at once, are serialized by an advisory lock on Postgres and MySQL.
+// Options.Schema runs them in another Postgres schema or MySQL database, e.g.
+// the schema of a tenant, recording them in that schema.
+//
+// Options.Safety analyzes pending migrations for operations that lock or
+// rewrite large tables, such as CREATE INDEX without CONCURRENTLY or column
+// type changes, with row estimates from the catalog. PolicyWarn reports them
+// to OnFinding, PolicyBlock fails with ErrUnsafe on dangerous ones unless the
+// script contains "-- migrate:allow-unsafe". Analyze checks a migration
+// without a database.
+package migrate
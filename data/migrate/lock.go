@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+)
+
+// lock takes the migration lock on conn and returns its release. Drivers without
+// advisory locks rely on the migration transactions alone.
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	name := "ncore_migrate:" + m.table
+	if m.schema != "" {
+		name = "ncore_migrate:" + m.schema + "." + m.table
+	}
+
+	switch {
+	case m.postgres:
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		id := int64(h.Sum64())
+
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", id); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		return func() {
+			_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", id)
+		}, nil
+
+	case m.mysql:
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(m.timeout.Seconds())).Scan(&ok); err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		if ok.Int64 != 1 {
+			return nil, ErrLocked
+		}
+		return func() {
+			_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+		}, nil
+
+	default:
+		return func() {}, nil
+	}
+}
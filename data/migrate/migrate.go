@@ -0,0 +1,482 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration file was changed
+	ErrChecksumMismatch = errors.New("migrate: checksum mismatch")
+	// ErrNoDown is returned when rolling back a migration without a down script
+	ErrNoDown = errors.New("migrate: migration has no down script")
+	// ErrLocked is returned when another process holds the migration lock past the lock timeout
+	ErrLocked = errors.New("migrate: migration lock is held by another process")
+)
+
+// fileName matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_([^.]+)\.(up|down)\.sql$`)
+
+// noTxDirective on the first line of a script runs it outside a transaction,
+// e.g. for CREATE INDEX CONCURRENTLY
+const noTxDirective = "-- migrate:no-transaction"
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up script
+}
+
+// Status is the state of a migration
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Modified  bool       `json:"modified,omitempty"` // Applied, but the up script changed since
+	Missing   bool       `json:"missing,omitempty"`  // Applied, but the file is gone
+}
+
+// Options configures a migrator
+type Options struct {
+	Table  string // Defaults to "schema_migrations"
+	Driver string // "postgres" and "pgx" use $n placeholders, others use ?
+	// LockTimeout bounds the wait for the MySQL migration lock, defaults to 1m.
+	// Postgres waits until ctx is done.
+	LockTimeout time.Duration
+	// Schema runs the migrations in a Postgres schema or MySQL database other than
+	// the connection default, e.g. the schema of a tenant
+	Schema string
+	// Safety analyzes pending migrations for operations locking or rewriting
+	// large tables before Up runs them, see Analyze. Off by default.
+	Safety Policy
+	// LargeTable is the estimated row count from which a table is large, defaults to 100000
+	LargeTable int64
+	// OnFinding receives the findings of Safety, defaults to the standard logger
+	OnFinding func(Finding)
+}
+
+// Migrator applies migrations to a database, serialized across processes by an
+// advisory lock on Postgres and MySQL
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	table      string
+	schema     string
+	postgres   bool
+	mysql      bool
+	timeout    time.Duration
+	safety     Policy
+	largeTable int64
+	onFinding  func(Finding)
+}
+
+// Load reads migrations from the root of fsys, ordered by version. Files are
+// named <version>_<name>.up.sql with an optional <version>_<name>.down.sql.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// New creates a migrator for the migrations in fsys
+func New(db *sql.DB, fsys fs.FS, opts Options) (*Migrator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+	if opts.Table == "" {
+		opts.Table = "schema_migrations"
+	}
+	if !sqlq.ValidIdentifier(opts.Table) {
+		return nil, fmt.Errorf("invalid table name: %s", opts.Table)
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = time.Minute
+	}
+	for _, r := range opts.Schema {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return nil, fmt.Errorf("invalid schema name: %s", opts.Schema)
+		}
+	}
+	switch opts.Safety {
+	case PolicyOff, PolicyWarn, PolicyBlock:
+	default:
+		return nil, fmt.Errorf("invalid safety policy %q, expected warn or block", opts.Safety)
+	}
+	if opts.OnFinding == nil {
+		opts.OnFinding = logFinding
+	}
+	postgres := opts.Driver == "postgres" || opts.Driver == "pgx"
+	if opts.Schema != "" && !postgres && opts.Driver != "mysql" {
+		return nil, fmt.Errorf("schema %s needs the postgres or mysql driver", opts.Schema)
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		table:      opts.Table,
+		schema:     opts.Schema,
+		postgres:   postgres,
+		mysql:      opts.Driver == "mysql",
+		timeout:    opts.LockTimeout,
+		safety:     opts.Safety,
+		largeTable: opts.LargeTable,
+		onFinding:  opts.OnFinding,
+	}, nil
+}
+
+// Migrations returns the loaded migrations
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Up applies all pending migrations and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo applies pending migrations up to and including version, all of them if version is negative
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedRow) error {
+		var pending []*Migration
+		for _, mig := range m.migrations {
+			if version >= 0 && mig.Version > version {
+				break
+			}
+			if _, ok := applied[mig.Version]; !ok {
+				pending = append(pending, mig)
+			}
+		}
+		if err := m.checkSafety(ctx, conn, pending); err != nil {
+			return err
+		}
+
+		for _, mig := range pending {
+			if err := m.run(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedRow) error {
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, v := range versions[:min(steps, len(versions))] {
+			mig := m.find(v)
+			if mig == nil {
+				return fmt.Errorf("migration %d is applied but has no file", v)
+			}
+			if strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDown, mig.Version, mig.Name)
+			}
+			if err := m.run(ctx, conn, mig, false); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns the state of all migrations, known from files or applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration connection: %v", err)
+	}
+	defer conn.Close()
+
+	reset, err := m.useSchema(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer reset()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &row.appliedAt
+			s.Modified = row.checksum != mig.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	for v, row := range applied {
+		if m.find(v) == nil {
+			statuses = append(statuses, Status{Version: v, Name: row.name, AppliedAt: &row.appliedAt, Missing: true})
+		}
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return statuses, nil
+}
+
+// appliedRow is a row of the migrations table
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// execer runs statements, implemented by *sql.DB, *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// locked runs fn on a dedicated connection holding the migration lock, after
+// verifying the checksums of applied migrations
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]appliedRow) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration connection: %v", err)
+	}
+	defer conn.Close()
+
+	reset, err := m.useSchema(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer reset()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for v, row := range applied {
+		if mig := m.find(v); mig != nil && mig.Checksum != row.checksum {
+			return fmt.Errorf("%w: %d_%s was changed after it was applied", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+
+	return fn(conn, applied)
+}
+
+// run applies or rolls back a migration and records it
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig *Migration, up bool) error {
+	script, direction := mig.Up, "up"
+	record := m.rebind(fmt.Sprintf("INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", m.table))
+	args := []any{mig.Version, mig.Name, mig.Checksum, time.Now().UTC()}
+	if !up {
+		script, direction = mig.Down, "down"
+		record = m.rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.table))
+		args = []any{mig.Version}
+	}
+
+	fail := func(err error) error {
+		return fmt.Errorf("migration %d_%s %s failed: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(script), noTxDirective) {
+		if err := m.exec(ctx, conn, script); err != nil {
+			return fail(err)
+		}
+		if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+			return fail(err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fail(err)
+	}
+	if err := m.exec(ctx, tx, script); err != nil {
+		_ = tx.Rollback()
+		return fail(err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// exec runs the statements of a script one by one
+func (m *Migrator) exec(ctx context.Context, db execer, script string) error {
+	for _, stmt := range splitStatements(script, m.mysql) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureTable creates the migrations table if it does not exist
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`, m.table)); err != nil {
+		return fmt.Errorf("failed to create migrations table: %v", err)
+	}
+	return nil
+}
+
+// applied returns the applied migrations by version
+func (m *Migrator) applied(ctx context.Context, db execer) (map[int64]appliedRow, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s", m.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedRow)
+	for rows.Next() {
+		var (
+			version   int64
+			row       appliedRow
+			appliedAt any
+		)
+		if err := rows.Scan(&version, &row.name, &row.checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %v", err)
+		}
+		row.appliedAt = parseTime(appliedAt)
+		applied[version] = row
+	}
+	return applied, rows.Err()
+}
+
+// find returns the migration with version, nil if there is no file for it
+func (m *Migrator) find(version int64) *Migration {
+	i, ok := slices.BinarySearchFunc(m.migrations, version, func(mig *Migration, v int64) int {
+		return cmp.Compare(mig.Version, v)
+	})
+	if !ok {
+		return nil
+	}
+	return m.migrations[i]
+}
+
+// rebind converts ? placeholders to $n for Postgres
+func (m *Migrator) rebind(query string) string {
+	if !m.postgres {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
+
+// parseTime reads a timestamp column, returned as text by MySQL without parseTime
+func parseTime(v any) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case []byte:
+		return parseTime(string(t))
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadOrdersMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_tasks.up.sql":   {Data: []byte("CREATE TABLE tasks (id TEXT);")},
+		"0002_tasks.down.sql": {Data: []byte("DROP TABLE tasks;")},
+		"0010_index.up.sql":   {Data: []byte("CREATE INDEX idx ON tasks (id);")},
+		"0001_users.up.sql":   {Data: []byte("CREATE TABLE users (id TEXT);")},
+		"README.md":           {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var versions []int64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if !slices.Equal(versions, []int64{1, 2, 10}) {
+		t.Fatalf("versions = %v", versions)
+	}
+	if migrations[1].Name != "tasks" || migrations[1].Down != "DROP TABLE tasks;" {
+		t.Errorf("unexpected migration %+v", migrations[1])
+	}
+	if len(migrations[0].Checksum) != 64 || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("unexpected checksums %q, %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":     {"users.sql": {Data: []byte("SELECT 1")}},
+		"duplicate":    {"1_a.up.sql": {Data: []byte("SELECT 1")}, "1_b.up.sql": {Data: []byte("SELECT 2")}},
+		"missing up":   {"1_a.down.sql": {Data: []byte("SELECT 1")}},
+		"bad version":  {"99999999999999999999_a.up.sql": {Data: []byte("SELECT 1")}},
+		"no direction": {"1_a.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, fsys := range cases {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- create tables; with a comment
+CREATE TABLE a (note TEXT DEFAULT 'x;y');
+/* block; comment */
+CREATE FUNCTION f() RETURNS trigger AS $body$
+BEGIN
+  NEW.note := 'a;b';
+  RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+INSERT INTO a VALUES ('it''s; fine');
+
+SELECT 1`
+
+	got := splitStatements(script, false)
+	if len(got) != 4 {
+		t.Fatalf("got %d statements: %q", len(got), got)
+	}
+	if got[0] != "-- create tables; with a comment\nCREATE TABLE a (note TEXT DEFAULT 'x;y')" {
+		t.Errorf("statement 0 = %q", got[0])
+	}
+	if got[3] != "SELECT 1" {
+		t.Errorf("statement 3 = %q", got[3])
+	}
+}
+
+func TestSplitStatementsMySQLEscapes(t *testing.T) {
+	got := splitStatements(`INSERT INTO a VALUES ('it\'s; fine'); INSERT INTO b VALUES ("x");`, true)
+	if len(got) != 2 || got[0] != `INSERT INTO a VALUES ('it\'s; fine')` {
+		t.Errorf("got %q", got)
+	}
+	if got := splitStatements("-- only a comment\n;\n", false); len(got) != 0 {
+		t.Errorf("expected no statements, got %q", got)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	m := &Migrator{postgres: true}
+	if got := m.rebind("DELETE FROM t WHERE a = ? AND b = ?"); got != "DELETE FROM t WHERE a = $1 AND b = $2" {
+		t.Errorf("rebind = %q", got)
+	}
+}
+
+func TestAnalyzeFindsDangerousOperations(t *testing.T) {
+	mig := &Migration{Version: 3, Name: "orders", Up: `
+CREATE TABLE audit (id BIGINT);
+CREATE INDEX audit_id ON audit (id);
+CREATE INDEX orders_user ON public.orders (user_id);
+CREATE INDEX CONCURRENTLY orders_state ON orders (state);
+ALTER TABLE "orders" ALTER COLUMN total TYPE NUMERIC(12, 2);
+ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;
+ALTER TABLE small ALTER COLUMN note SET NOT NULL;
+ALTER TABLE orders DROP CONSTRAINT orders_old;
+ALTER TABLE orders DROP COLUMN legacy;
+UPDATE orders SET state = 'new';
+`}
+	rows := map[string]int64{"public.orders": 5000000, "orders": 5000000, "small": 10}
+	estimate := func(_ context.Context, table string) (int64, bool) {
+		n, ok := rows[table]
+		return n, ok
+	}
+
+	var got []string
+	for _, f := range Analyze(context.Background(), mig, "postgres", 0, estimate) {
+		got = append(got, f.Rule+":"+f.Table+":"+string(f.Severity))
+	}
+	want := []string{
+		"index-not-concurrent:public.orders:danger",
+		"column-type-change:orders:danger",
+		"drop-column:orders:warning",
+		"unbatched-dml:orders:warning",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("findings = %v, want %v", got, want)
+	}
+
+	// Without estimates the size of tables is unknown
+	for _, f := range Analyze(context.Background(), mig, "postgres", 0, nil) {
+		if f.Severity != SeverityWarning || f.Rows != -1 {
+			t.Errorf("expected warnings of unknown size, got %+v", f)
+		}
+	}
+
+	mig.Up = allowUnsafeDirective + "\n" + mig.Up
+	if findings := Analyze(context.Background(), mig, "postgres", 0, estimate); len(findings) != 0 {
+		t.Errorf("expected reviewed migration to be skipped, got %v", findings)
+	}
+}
+
+func TestAnalyzeMySQL(t *testing.T) {
+	mig := &Migration{Version: 1, Name: "users", Up: `
+ALTER TABLE users MODIFY COLUMN name VARCHAR(512);
+ALTER TABLE users ADD COLUMN age INT, ALGORITHM=INSTANT;
+ALTER TABLE users ADD INDEX users_age (age);
+`}
+	estimate := func(context.Context, string) (int64, bool) { return 1000000, true }
+
+	var got []string
+	for _, f := range Analyze(context.Background(), mig, "mysql", 0, estimate) {
+		got = append(got, f.Rule)
+	}
+	if !slices.Equal(got, []string{"column-type-change", "alter-without-algorithm"}) {
+		t.Fatalf("findings = %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// useSchema points conn at the migrator's schema and returns the reset restoring
+// the connection default before conn returns to the pool
+func (m *Migrator) useSchema(ctx context.Context, conn *sql.Conn) (func(), error) {
+	if m.schema == "" {
+		return func() {}, nil
+	}
+
+	if m.postgres {
+		if _, err := conn.ExecContext(ctx, `SET search_path TO "`+m.schema+`"`); err != nil {
+			return nil, fmt.Errorf("failed to use schema %s: %v", m.schema, err)
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), "RESET search_path"); err != nil {
+				discard(conn)
+			}
+		}, nil
+	}
+
+	var current sql.NullString
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+		return nil, fmt.Errorf("failed to read current database: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "USE `"+m.schema+"`"); err != nil {
+		return nil, fmt.Errorf("failed to use database %s: %v", m.schema, err)
+	}
+	return func() {
+		if !current.Valid {
+			discard(conn)
+			return
+		}
+		if _, err := conn.ExecContext(context.Background(), "USE `"+current.String+"`"); err != nil {
+			discard(conn)
+		}
+	}, nil
+}
+
+// discard closes conn instead of returning it to the pool pointing at the schema
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitStatements splits a script into statements on semicolons outside quotes,
+// comments and Postgres dollar quoted bodies. MySQL strings may escape quotes
+// with a backslash.
+func splitStatements(script string, mysql bool) []string {
+	var (
+		stmts   []string
+		start   int
+		content bool
+	)
+
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case strings.HasPrefix(script[i:], "--"):
+			i = skipPast(script, i, "\n")
+			continue
+		case strings.HasPrefix(script[i:], "/*"):
+			i = skipPast(script, i+2, "*/")
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(script, i, c, mysql && c != '`')
+			content = true
+			continue
+		case c == '$' && !mysql:
+			if tag := dollarTag(script[i:]); tag != "" {
+				i = skipPast(script, i+len(tag), tag)
+				content = true
+				continue
+			}
+		case c == ';':
+			if content {
+				stmts = append(stmts, strings.TrimSpace(script[start:i]))
+			}
+			start, content = i+1, false
+			i++
+			continue
+		}
+
+		if !unicode.IsSpace(rune(c)) {
+			content = true
+		}
+		i++
+	}
+
+	if content {
+		stmts = append(stmts, strings.TrimSpace(script[start:]))
+	}
+	return stmts
+}
+
+// skipPast returns the index after the next end at or after i, or the script length
+func skipPast(script string, i int, end string) int {
+	j := strings.Index(script[i:], end)
+	if j < 0 {
+		return len(script)
+	}
+	return i + j + len(end)
+}
+
+// skipQuoted returns the index after the quoted string starting at i. Doubled
+// quotes are escapes, as are backslashes when backslash is set.
+func skipQuoted(script string, i int, quote byte, backslash bool) int {
+	for j := i + 1; j < len(script); j++ {
+		switch script[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case quote:
+			if j+1 < len(script) && script[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(script)
+}
+
+// dollarTag returns the dollar quote tag s starts with, e.g. $$ or $body$, or ""
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || unicode.IsLetter(rune(c)) || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
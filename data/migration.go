@@ -0,0 +1,36 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"github.com/ncobase/ncore/data/migrate"
+)
+
+// Migrate applies the pending migrations in fsys to the master database. The
+// driver is taken from the master configuration unless opts sets one.
+func (d *Data) Migrate(ctx context.Context, fsys fs.FS, opts ...migrate.Options) ([]*migrate.Migration, error) {
+	m, err := d.Migrator(fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Up(ctx)
+}
+
+// Migrator returns a migrator for fsys on the master database, for rollbacks and status
+func (d *Data) Migrator(fsys fs.FS, opts ...migrate.Options) (*migrate.Migrator, error) {
+	db := d.GetMasterDB()
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+
+	var o migrate.Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Driver == "" {
+		o.Driver = d.masterDriver()
+	}
+	return migrate.New(db, fsys, o)
+}
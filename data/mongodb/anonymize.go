@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ncobase/ncore/data/anonymize"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AnonymizeStore reads and rewrites collections of a database for the
+// anonymizer. Table.Where is an extended JSON filter, e.g. {"deleted": false}.
+type AnonymizeStore struct {
+	db *mongo.Database
+}
+
+// NewAnonymizeStore creates an anonymizer store for db
+func NewAnonymizeStore(db *mongo.Database) *AnonymizeStore {
+	return &AnonymizeStore{db: db}
+}
+
+var _ anonymize.Store = (*AnonymizeStore)(nil)
+
+// Scan implements anonymize.Store
+func (s *AnonymizeStore) Scan(ctx context.Context, t *anonymize.Table, after any, limit int) ([]anonymize.Row, error) {
+	if s.db == nil {
+		return nil, errors.New("database is nil")
+	}
+	key := anonymizeKey(t)
+
+	filter := bson.D{}
+	if t.Where != "" {
+		if err := bson.UnmarshalExtJSON([]byte(t.Where), false, &filter); err != nil {
+			return nil, fmt.Errorf("invalid filter of %s: %v", t.Name, err)
+		}
+	}
+	if after != nil {
+		filter = append(filter, bson.E{Key: key, Value: bson.D{{Key: "$gt", Value: after}}})
+	}
+
+	projection := bson.D{{Key: key, Value: 1}}
+	for _, c := range t.Columns() {
+		projection = append(projection, bson.E{Key: c, Value: 1})
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: key, Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(projection)
+
+	cursor, err := s.db.Collection(t.Name).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []anonymize.Row
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		row := anonymize.Row{Key: doc[key], Values: make(map[string]any, len(t.Fields))}
+		for c := range t.Fields {
+			row.Values[c] = doc[c]
+		}
+		rows = append(rows, row)
+	}
+	return rows, cursor.Err()
+}
+
+// Update implements anonymize.Store with an ordered bulk write
+func (s *AnonymizeStore) Update(ctx context.Context, t *anonymize.Table, rows []anonymize.Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	key := anonymizeKey(t)
+
+	models := make([]mongo.WriteModel, len(rows))
+	for i, row := range rows {
+		set := bson.D{}
+		for _, c := range t.Columns() {
+			set = append(set, bson.E{Key: c, Value: row.Values[c]})
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: key, Value: row.Key}}).
+			SetUpdate(bson.D{{Key: "$set", Value: set}})
+	}
+
+	if _, err := s.db.Collection(t.Name).BulkWrite(ctx, models); err != nil {
+		return fmt.Errorf("bulk update of %s failed: %w", t.Name, err)
+	}
+	return nil
+}
+
+// anonymizeKey returns the key field of t
+func anonymizeKey(t *anonymize.Table) string {
+	if t.Key == "" {
+		return "_id"
+	}
+	return t.Key
+}
@@ -0,0 +1,59 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// BulkResult sums the counts of all bulk write batches
+type BulkResult struct {
+	Matched  int64
+	Modified int64
+	Upserted int64
+}
+
+// Affected returns the number of documents inserted or modified
+func (r *BulkResult) Affected() int64 {
+	return r.Modified + r.Upserted
+}
+
+// BulkUpsert replaces or inserts docs in unordered batches of batchSize (default 1000).
+// filter returns the unique key filter of a document, e.g. bson.M{"_id": doc.ID}.
+func BulkUpsert[T any](ctx context.Context, coll *mongo.Collection, docs []T, filter func(T) any, batchSize int) (*BulkResult, error) {
+	if coll == nil {
+		return nil, errors.New("collection is nil")
+	}
+	if filter == nil {
+		return nil, errors.New("upsert filter is required")
+	}
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	result := &BulkResult{}
+	for chunk := range slices.Chunk(docs, batchSize) {
+		models := make([]mongo.WriteModel, len(chunk))
+		for i, doc := range chunk {
+			models[i] = mongo.NewReplaceOneModel().
+				SetFilter(filter(doc)).
+				SetReplacement(doc).
+				SetUpsert(true)
+		}
+
+		res, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if res != nil {
+			result.Matched += res.MatchedCount
+			result.Modified += res.ModifiedCount
+			result.Upserted += res.UpsertedCount
+		}
+		if err != nil {
+			return result, fmt.Errorf("bulk upsert into %s failed: %w", coll.Name(), err)
+		}
+	}
+	return result, nil
+}
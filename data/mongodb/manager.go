@@ -264,7 +264,7 @@ func newMongoClient(conf *config.MongoNode) (*mongo.Client, error) {
 		return nil, errors.New("mongodb configuration is nil or empty")
 	}
 
-	clientOptions := options.Client().ApplyURI(conf.URI)
+	clientOptions := options.Client().ApplyURI(conf.URI).SetMonitor(NewCommandMonitor())
 
 	// v2: mongo.Connect no longer takes a context parameter
 	client, err := mongo.Connect(clientOptions)
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ncobase/ncore/data/tracing"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandKey identifies a command in flight
+type commandKey struct {
+	conn string
+	id   int64
+}
+
+// NewCommandMonitor returns a command monitor recording MongoDB commands as
+// spans. Clients created by the driver have it set, other clients take it
+// with options.Client().SetMonitor.
+func NewCommandMonitor() *event.CommandMonitor {
+	var spans sync.Map // commandKey to trace.Span
+
+	finish := func(conn string, id int64) trace.Span {
+		if s, ok := spans.LoadAndDelete(commandKey{conn, id}); ok {
+			return s.(trace.Span)
+		}
+		return nil
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			_, span := tracing.Start(ctx, e.CommandName,
+				tracing.DBSystem.String("mongodb"),
+				tracing.DBName.String(e.DatabaseName),
+				tracing.DBOperation.String(e.CommandName),
+			)
+			spans.Store(commandKey{e.ConnectionID, e.RequestID}, span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			if span := finish(e.ConnectionID, e.RequestID); span != nil {
+				span.End()
+			}
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			if span := finish(e.ConnectionID, e.RequestID); span != nil {
+				span.SetStatus(codes.Error, fmt.Sprint(e.Failure))
+				span.End()
+			}
+		},
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/ncobase/ncore/data"
 	"github.com/ncobase/ncore/data/config"
+	"github.com/ncobase/ncore/data/tracing"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
 )
@@ -52,7 +53,7 @@ func (d *driver) Connect(ctx context.Context, cfg any) (any, error) {
 	}
 
 	// Open connection using mysql driver
-	db, err := sql.Open("mysql", dbCfg.Source)
+	db, err := tracing.OpenDB("mysql", dbCfg.Source, "mysql")
 	if err != nil {
 		return nil, fmt.Errorf("mysql: failed to open connection: %w", err)
 	}
@@ -2,9 +2,11 @@ package opensearch
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ncobase/ncore/data/opensearch/client"
 	"github.com/ncobase/ncore/data/search"
@@ -21,6 +23,9 @@ func init() {
 	})
 }
 
+// searchableFields are the fields the query text is matched against
+var searchableFields = []string{"title^2", "content", "details", "name", "description"}
+
 type Adapter struct {
 	client *client.Client
 }
@@ -38,7 +43,13 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 		return nil, errors.New("opensearch client not available")
 	}
 
-	query := a.buildQuery(req)
+	if req.Explain || req.Debug {
+		return a.searchRaw(ctx, req)
+	}
+	query, err := a.buildQuery(req)
+	if err != nil {
+		return nil, err
+	}
 	osResp, err := a.client.Search(ctx, req.Index, query)
 	if err != nil {
 		return nil, err
@@ -52,9 +63,10 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 	for i, hit := range osResp.Hits.Hits {
 		source, _ := convert.ToJSONMap(hit.Source)
 		hits[i] = search.Hit{
-			ID:     hit.ID,
-			Score:  float64(hit.Score),
-			Source: source,
+			ID:        hit.ID,
+			Score:     float64(hit.Score),
+			Source:    source,
+			Highlight: hit.Highlight,
 		}
 	}
 
@@ -64,6 +76,60 @@ func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Resp
 	}, nil
 }
 
+// searchRaw runs a search reading the raw response, as the typed one omits
+// explanations and profiles, and traces it when debugged
+func (a *Adapter) searchRaw(ctx context.Context, req *search.Request) (*search.Response, error) {
+	trace := search.StartTrace(req)
+	body := search.QueryBody(req, searchableFields)
+	trace.Built()
+	query, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	trace.Serialized(query)
+
+	var raw json.RawMessage
+	if err := a.client.SearchInto(ctx, req.Index, string(query), &raw); err != nil {
+		return nil, err
+	}
+	trace.Executed()
+
+	var osResp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID          string              `json:"_id"`
+				Score       float64             `json:"_score"`
+				Source      map[string]any      `json:"_source"`
+				Highlight   map[string][]string `json:"highlight"`
+				Explanation *search.Explanation `json:"_explanation"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Profile json.RawMessage `json:"profile"`
+	}
+	if err := json.Unmarshal(raw, &osResp); err != nil {
+		return nil, err
+	}
+
+	hits := make([]search.Hit, len(osResp.Hits.Hits))
+	for i, hit := range osResp.Hits.Hits {
+		hits[i] = search.Hit{
+			ID:          hit.ID,
+			Score:       hit.Score,
+			Source:      hit.Source,
+			Highlight:   hit.Highlight,
+			Explanation: hit.Explanation,
+		}
+	}
+	trace.Parsed(osResp.Profile)
+
+	resp := &search.Response{Total: osResp.Hits.Total.Value, Hits: hits}
+	trace.Attach(resp)
+	return resp, nil
+}
+
 func (a *Adapter) Index(ctx context.Context, req *search.IndexRequest) error {
 	if a.client == nil {
 		return errors.New("opensearch client not available")
@@ -114,58 +180,84 @@ func (a *Adapter) CreateIndex(ctx context.Context, indexName string, settings *s
 	return a.client.CreateIndex(ctx, indexName, settingsBody)
 }
 
-func (a *Adapter) Health(ctx context.Context) error {
+// ApplyRelevance applies the stopwords and synonyms of r through the index's
+// default_search analyzer. Boosts and typo tolerance apply at query time.
+func (a *Adapter) ApplyRelevance(ctx context.Context, indexName string, r *search.Relevance) error {
 	if a.client == nil {
 		return errors.New("opensearch client not available")
 	}
-	_, err := a.client.Health(ctx)
-	return err
+	settings, err := search.AnalysisSettings(r)
+	if err != nil {
+		return err
+	}
+	return a.client.UpdateAnalysis(ctx, indexName, string(settings))
 }
 
-func (a *Adapter) buildQuery(req *search.Request) string {
-	searchableFields := []string{"title^2", "content", "details", "name", "description"}
+// Export streams the documents matching req from a point in time with search_after
+func (a *Adapter) Export(ctx context.Context, req *search.Request, fn func([]search.Hit) error) error {
+	if a.client == nil {
+		return errors.New("opensearch client not available")
+	}
+	return search.ExportPointInTime(ctx, &pointInTime{client: a.client}, req, searchableFields, "_id", time.Minute, fn)
+}
+
+// pointInTime reads OpenSearch points in time for exports
+type pointInTime struct {
+	client *client.Client
+}
+
+func (p *pointInTime) OpenPointInTime(ctx context.Context, index string, keepAlive time.Duration) (string, error) {
+	return p.client.OpenPointInTime(ctx, index, fmt.Sprintf("%ds", int(keepAlive.Seconds())))
+}
 
-	if len(req.Filter) == 0 {
-		return fmt.Sprintf(`{
-			"query": {
-				"multi_match": {
-					"query": "%s",
-					"fields": %s
-				}
-			},
-			"from": %d,
-			"size": %d
-		}`, req.Query, a.buildFieldsArray(searchableFields), req.From, req.Size)
+func (p *pointInTime) SearchPointInTime(ctx context.Context, body []byte) (*search.PITPage, error) {
+	var osResp struct {
+		PitID string `json:"pit_id"`
+		Hits  struct {
+			Hits []struct {
+				ID     string         `json:"_id"`
+				Score  float64        `json:"_score"`
+				Source map[string]any `json:"_source"`
+				// Kept raw, long sort values exceed float64 precision
+				Sort []json.RawMessage `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := p.client.SearchPointInTime(ctx, string(body), &osResp); err != nil {
+		return nil, err
 	}
 
-	filterQueries := make([]string, 0, len(req.Filter))
-	for field, value := range req.Filter {
-		filterQueries = append(filterQueries, fmt.Sprintf(`{"term": {"%s": "%v"}}`, field, value))
+	page := &search.PITPage{PitID: osResp.PitID, Hits: make([]search.Hit, len(osResp.Hits.Hits))}
+	for i, hit := range osResp.Hits.Hits {
+		page.Hits[i] = search.Hit{ID: hit.ID, Score: hit.Score, Source: hit.Source}
+	}
+	if n := len(osResp.Hits.Hits); n > 0 {
+		for _, v := range osResp.Hits.Hits[n-1].Sort {
+			page.SearchAfter = append(page.SearchAfter, v)
+		}
 	}
+	return page, nil
+}
 
-	return fmt.Sprintf(`{
-		"query": {
-			"bool": {
-				"must": {
-					"multi_match": {
-						"query": "%s",
-						"fields": %s
-					}
-				},
-				"filter": [%s]
-			}
-		},
-		"from": %d,
-		"size": %d
-	}`, req.Query, a.buildFieldsArray(searchableFields), strings.Join(filterQueries, ","), req.From, req.Size)
+func (p *pointInTime) ClosePointInTime(ctx context.Context, id string) error {
+	return p.client.ClosePointInTime(ctx, id)
+}
+
+func (a *Adapter) Health(ctx context.Context) error {
+	if a.client == nil {
+		return errors.New("opensearch client not available")
+	}
+	_, err := a.client.Health(ctx)
+	return err
 }
 
-func (a *Adapter) buildFieldsArray(fields []string) string {
-	quotedFields := make([]string, len(fields))
-	for i, field := range fields {
-		quotedFields[i] = fmt.Sprintf(`"%s"`, field)
+// buildQuery builds the search body, matching the query text against the default fields
+func (a *Adapter) buildQuery(req *search.Request) (string, error) {
+	body, err := search.BuildQuery(req, searchableFields)
+	if err != nil {
+		return "", fmt.Errorf("failed to build query: %w", err)
 	}
-	return fmt.Sprintf("[%s]", strings.Join(quotedFields, ","))
+	return string(body), nil
 }
 
 func (a *Adapter) buildSettings(settings *search.IndexSettings) string {
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -8,12 +9,19 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/ncobase/ncore/bytespool"
+	"github.com/ncobase/ncore/data/search"
 	"github.com/opensearch-project/opensearch-go/v4"
 	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
 )
 
+// bulkPool holds bulk request bodies, shared with the Elasticsearch driver
+var bulkPool = bytespool.New("search.bulk", 4<<20)
+
 // Client OpenSearch client
 type Client struct {
 	client *opensearchapi.Client
@@ -68,7 +76,7 @@ func (c *Client) Search(ctx context.Context, indexName, query string) (*opensear
 	res, err := c.client.Search(ctx, &searchReq)
 	if err != nil {
 		log.Printf("OpenSearch search error: %s", err)
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	return res, nil
@@ -132,27 +140,24 @@ func (c *Client) BulkIndex(ctx context.Context, indexName string, documents []an
 	}
 
 	// Prepare bulk request body
-	var bulkRequestBody strings.Builder
+	bulkRequestBody := bulkPool.Get()
+	defer bulkPool.Put(bulkRequestBody)
+
+	enc := json.NewEncoder(bulkRequestBody)
 	for _, doc := range documents {
-		// Create action line
-		actionLine := fmt.Sprintf(`{"index":{}}%s`, "\n")
-		bulkRequestBody.WriteString(actionLine)
+		// Action line
+		bulkRequestBody.WriteString(`{"index":{}}` + "\n")
 
-		// Marshal document
-		docBytes, err := json.Marshal(doc)
-		if err != nil {
+		// Document line, Encode appends the newline
+		if err := enc.Encode(doc); err != nil {
 			return fmt.Errorf("error encoding document: %w", err)
 		}
-
-		// Add document line
-		bulkRequestBody.Write(docBytes)
-		bulkRequestBody.WriteString("\n")
 	}
 
 	// Create bulk request
 	bulkReq := opensearchapi.BulkReq{
 		Index: indexName,
-		Body:  strings.NewReader(bulkRequestBody.String()),
+		Body:  bytes.NewReader(bulkRequestBody.Bytes()),
 	}
 
 	// Execute bulk request
@@ -244,6 +249,117 @@ func (c *Client) DeleteIndex(ctx context.Context, indexName string) error {
 	return nil
 }
 
+// OpenPointInTime opens a point in time on an index, returning its ID
+func (c *Client) OpenPointInTime(ctx context.Context, indexName, keepAlive string) (string, error) {
+	var body struct {
+		PitID string `json:"pit_id"`
+	}
+	path := "/" + url.PathEscape(indexName) + "/_search/point_in_time?keep_alive=" + url.QueryEscape(keepAlive)
+	if err := c.perform(ctx, http.MethodPost, path, "", &body); err != nil {
+		return "", fmt.Errorf("opensearch open point in time error: %w", err)
+	}
+	return body.PitID, nil
+}
+
+// SearchPointInTime searches a point in time referenced in the query body, decoding the response into result
+func (c *Client) SearchPointInTime(ctx context.Context, query string, result any) error {
+	if err := c.perform(ctx, http.MethodPost, "/_search", query, result); err != nil {
+		return fmt.Errorf("opensearch search error: %w", err)
+	}
+	return nil
+}
+
+// ClosePointInTime deletes a point in time
+func (c *Client) ClosePointInTime(ctx context.Context, id string) error {
+	body, err := json.Marshal(map[string][]string{"pit_id": {id}})
+	if err != nil {
+		return err
+	}
+	if err := c.perform(ctx, http.MethodDelete, "/_search/point_in_time", string(body), nil); err != nil {
+		return fmt.Errorf("opensearch close point in time error: %w", err)
+	}
+	return nil
+}
+
+// SearchInto searches an index, decoding the raw response into result, e.g. to read
+// fields the typed response omits
+func (c *Client) SearchInto(ctx context.Context, indexName, query string, result any) error {
+	if err := c.perform(ctx, http.MethodPost, "/"+url.PathEscape(indexName)+"/_search", query, result); err != nil {
+		return fmt.Errorf("opensearch search error: %w", err)
+	}
+	return nil
+}
+
+// UpdateAnalysis updates the analysis settings of an index, closing it while the
+// settings change as OpenSearch requires
+func (c *Client) UpdateAnalysis(ctx context.Context, indexName, settings string) error {
+	path := "/" + url.PathEscape(indexName)
+	if err := c.perform(ctx, http.MethodPost, path+"/_close", "", nil); err != nil {
+		return fmt.Errorf("opensearch close index error: %w", err)
+	}
+	if err := c.perform(ctx, http.MethodPut, path+"/_settings", settings, nil); err != nil {
+		// Reopen the index with its previous settings
+		_ = c.perform(ctx, http.MethodPost, path+"/_open", "", nil)
+		return fmt.Errorf("opensearch put settings error: %w", err)
+	}
+	if err := c.perform(ctx, http.MethodPost, path+"/_open", "", nil); err != nil {
+		return fmt.Errorf("opensearch open index error: %w", err)
+	}
+	return nil
+}
+
+// ForceMerge merges the segments of the indices matching pattern, down to
+// maxSegments when it is positive
+func (c *Client) ForceMerge(ctx context.Context, pattern string, maxSegments int) error {
+	path := "/" + url.PathEscape(pattern) + "/_forcemerge"
+	if maxSegments > 0 {
+		path += "?max_num_segments=" + strconv.Itoa(maxSegments)
+	}
+	if err := c.perform(ctx, http.MethodPost, path, "", nil); err != nil {
+		return fmt.Errorf("opensearch force merge error: %w", err)
+	}
+	return nil
+}
+
+// perform sends a raw request and decodes the JSON response into result if not nil
+func (c *Client) perform(ctx context.Context, method, path, body string, result any) error {
+	if c == nil || c.client == nil {
+		return errors.New("opensearch client is nil")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Client.Perform(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return &search.StatusError{Engine: search.OpenSearch, StatusCode: res.StatusCode}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// statusError returns err as a search.StatusError when OpenSearch answered with
+// an error status, so failover tells engine faults from rejected queries
+func statusError(err error) error {
+	var opensearchError *opensearch.StructError
+	if errors.As(err, &opensearchError) {
+		return &search.StatusError{Engine: search.OpenSearch, StatusCode: opensearchError.Status, Err: err}
+	}
+	return err
+}
+
 // GetClient returns the OpenSearch client
 func (c *Client) GetClient() *opensearchapi.Client {
 	return c.client
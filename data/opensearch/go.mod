@@ -3,6 +3,7 @@ module github.com/ncobase/ncore/data/opensearch
 go 1.25.3
 
 require (
+	github.com/ncobase/ncore/bytespool v0.2.2
 	github.com/ncobase/ncore/data v0.2.2
 	github.com/ncobase/ncore/utils v0.2.2
 	github.com/opensearch-project/opensearch-go/v4 v4.6.0
@@ -26,3 +27,5 @@ require (
 )
 
 replace github.com/ncobase/ncore/data => ../
+
+replace github.com/ncobase/ncore/bytespool => ../../bytespool
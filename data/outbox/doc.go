@@ -0,0 +1,32 @@
+// Package outbox implements the transactional outbox pattern, so events are
+// published if and only if the database changes that produced them commit.
+//
+//	box, err := outbox.New(d, outbox.Options{Driver: "postgres"})
+//	err = box.Migrate(ctx)
+//
+//	err = box.WithTxOutbox(ctx, func(ctx context.Context) error {
+//	    return orders.Create(ctx, order) // uses data.GetTx(ctx)
+//	}, &outbox.Event{Topic: "orders", Key: order.ID, Payload: order})
+//
+//	// Events known only inside the transaction
+//	err = d.WithTx(ctx, func(ctx context.Context) error {
+//	    id, err := payments.Capture(ctx, req)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return box.Add(ctx, &outbox.Event{Topic: "payments", Payload: map[string]any{"id": id}})
+//	})
+//
+// A relay polls the table and publishes pending events, retrying failures
+// with exponential backoff:
+//
+//	relay := box.NewRelay(outbox.KafkaPublisher(d), outbox.RelayOptions{})
+//	go relay.Run(ctx)
+//
+// Delivery is at-least-once: a relay that crashes after publishing but before
+// recording it publishes the batch again. Every message carries the event ID
+// as its deduplication key; consumers decode bodies with Decode and skip IDs
+// they have processed. Published rows are removed with Purge. With
+// Options.Compressor, large payloads are stored compressed and decompressed
+// by the relay.
+package outbox
@@ -0,0 +1,210 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/compress"
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// ErrNoTransaction is returned when events are added outside a transaction
+var ErrNoTransaction = errors.New("outbox: events must be added inside a transaction")
+
+// Event is an event to publish once the surrounding transaction commits
+type Event struct {
+	ID      string // Deduplication key, generated when empty
+	Topic   string // Kafka topic or RabbitMQ exchange
+	Key     string // Kafka message key or RabbitMQ routing key
+	Headers map[string]string
+	Payload any // Marshaled to JSON
+}
+
+// Message is the envelope published for an event, consumers deduplicate by ID
+type Message struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Payload   json.RawMessage   `json:"payload"`
+	CreatedAt time.Time         `json:"created_at"`
+	Attempts  int               `json:"-"`
+}
+
+// Decode parses a published message body
+func Decode(body []byte) (*Message, error) {
+	var m Message
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid outbox message: %v", err)
+	}
+	return &m, nil
+}
+
+// Options configures an outbox
+type Options struct {
+	Table  string // Defaults to "outbox_events"
+	Driver string // "postgres" and "pgx" use $n placeholders, others use ?
+	// Compressor compresses stored payloads over its threshold, e.g. d.Compressor()
+	Compressor *compress.Compressor
+}
+
+// Outbox writes events to an outbox table in the caller's transaction
+type Outbox struct {
+	d        *data.Data
+	table    string
+	driver   string
+	postgres bool
+	cp       *compress.Compressor
+}
+
+// New creates an outbox on the master database of d
+func New(d *data.Data, opts Options) (*Outbox, error) {
+	if d == nil {
+		return nil, fmt.Errorf("data layer is nil")
+	}
+	if opts.Table == "" {
+		opts.Table = "outbox_events"
+	}
+	if !sqlq.ValidIdentifier(opts.Table) {
+		return nil, fmt.Errorf("invalid table name: %s", opts.Table)
+	}
+
+	return &Outbox{
+		d:        d,
+		table:    opts.Table,
+		driver:   opts.Driver,
+		postgres: opts.Driver == "postgres" || opts.Driver == "pgx",
+		cp:       opts.Compressor,
+	}, nil
+}
+
+// Migrate creates the outbox table if it does not exist
+func (o *Outbox) Migrate(ctx context.Context) error {
+	db := o.d.GetMasterDB()
+	if db == nil {
+		return errors.New("database connection is nil")
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			topic VARCHAR(255) NOT NULL,
+			msg_key VARCHAR(255) NOT NULL DEFAULT '',
+			headers TEXT,
+			payload TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP NOT NULL,
+			last_error TEXT,
+			published_at TIMESTAMP NULL
+		)`, o.table)); err != nil {
+		return fmt.Errorf("failed to create outbox table: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS idx_%s_pending ON %s (published_at, next_attempt_at)",
+		strings.ReplaceAll(o.table, ".", "_"), o.table)); err != nil {
+		return fmt.Errorf("failed to create outbox index: %v", err)
+	}
+	return nil
+}
+
+// WithTxOutbox runs fn in a transaction and adds events to the outbox in the same transaction.
+// Events that depend on fn's results can be added from inside fn with Add.
+func (o *Outbox) WithTxOutbox(ctx context.Context, fn func(ctx context.Context) error, events ...*Event) error {
+	return o.d.WithTx(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return o.Add(ctx, events...)
+	})
+}
+
+// Add writes events to the outbox using the transaction in ctx
+func (o *Outbox) Add(ctx context.Context, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := data.GetTx(ctx)
+	if err != nil {
+		return ErrNoTransaction
+	}
+
+	query := o.rebind(fmt.Sprintf(
+		"INSERT INTO %s (id, topic, msg_key, headers, payload, created_at, attempts, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?, 0, ?)",
+		o.table))
+	now := time.Now().UTC()
+
+	for _, e := range events {
+		if e.Topic == "" {
+			return fmt.Errorf("outbox event topic is required")
+		}
+		if e.ID == "" {
+			if e.ID, err = newID(); err != nil {
+				return err
+			}
+		}
+
+		payload, err := json.Marshal(e.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox event %s: %v", e.ID, err)
+		}
+		stored, err := o.cp.EncodeString(payload)
+		if err != nil {
+			return fmt.Errorf("failed to compress outbox event %s: %v", e.ID, err)
+		}
+		var headers sql.NullString
+		if len(e.Headers) > 0 {
+			h, err := json.Marshal(e.Headers)
+			if err != nil {
+				return err
+			}
+			headers = sql.NullString{String: string(h), Valid: true}
+		}
+
+		if _, err := tx.ExecContext(ctx, query, e.ID, e.Topic, e.Key, headers, stored, now, now); err != nil {
+			return fmt.Errorf("failed to add outbox event %s: %v", e.ID, err)
+		}
+	}
+	return nil
+}
+
+// Purge removes events published before the given time
+func (o *Outbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	db := o.d.GetMasterDB()
+	if db == nil {
+		return 0, errors.New("database connection is nil")
+	}
+
+	res, err := db.ExecContext(ctx, o.rebind(fmt.Sprintf(
+		"DELETE FROM %s WHERE published_at IS NOT NULL AND published_at < ?", o.table)), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %v", err)
+	}
+	return res.RowsAffected()
+}
+
+// rebind converts ? placeholders to $n for Postgres
+func (o *Outbox) rebind(query string) string {
+	if !o.postgres {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
+
+// newID returns a random event ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
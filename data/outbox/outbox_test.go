@@ -0,0 +1,213 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/config"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver opens test databases with mattn/go-sqlite3
+type sqliteDriver struct{}
+
+func (sqliteDriver) Name() string { return "outbox-sqlite" }
+
+func (sqliteDriver) Connect(_ context.Context, cfg any) (any, error) {
+	db, err := sql.Open("sqlite3", cfg.(*config.DBNode).Source)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func (sqliteDriver) Close(conn any) error { return conn.(*sql.DB).Close() }
+
+func (sqliteDriver) Ping(ctx context.Context, conn any) error {
+	return conn.(*sql.DB).PingContext(ctx)
+}
+
+func init() {
+	data.RegisterDatabaseDriver(sqliteDriver{})
+}
+
+func newTestOutbox(t *testing.T) *Outbox {
+	t.Helper()
+	d, cleanup, err := data.New(&config.Config{
+		Database: &config.Database{Master: &config.DBNode{
+			Driver: "outbox-sqlite",
+			Source: filepath.Join(t.TempDir(), "outbox.db"),
+		}},
+		Search: &config.Search{},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cleanup() })
+
+	o, err := New(d, Options{Driver: "sqlite3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+// row returns the attempts, last error and next attempt of an event
+func row(t *testing.T, o *Outbox, id string) (attempts int, lastError string, next time.Time) {
+	t.Helper()
+	var e sql.NullString
+	err := o.d.GetMasterDB().QueryRow(
+		fmt.Sprintf("SELECT attempts, last_error, next_attempt_at FROM %s WHERE id = ?", o.table), id).
+		Scan(&attempts, &e, &next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attempts, e.String, next
+}
+
+func TestOutboxAddsEventsInTransaction(t *testing.T) {
+	o := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := o.Add(ctx, &Event{Topic: "orders"}); !errors.Is(err, ErrNoTransaction) {
+		t.Fatalf("Add outside a transaction = %v, want ErrNoTransaction", err)
+	}
+
+	failed := errors.New("failed")
+	if err := o.WithTxOutbox(ctx, func(context.Context) error { return failed }, &Event{Topic: "orders"}); !errors.Is(err, failed) {
+		t.Fatalf("WithTxOutbox = %v", err)
+	}
+	if err := o.WithTxOutbox(ctx, func(context.Context) error { return nil }, &Event{}); err == nil {
+		t.Fatal("expected an error for an event without topic")
+	}
+
+	relay := o.NewRelay(PublisherFunc(func(context.Context, *Message) error { return nil }), RelayOptions{})
+	if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 {
+		t.Fatalf("rolled back events were stored: %d, %v", n, err)
+	}
+}
+
+func TestRelayClaimsInOrder(t *testing.T) {
+	o := newTestOutbox(t)
+	ctx := context.Background()
+
+	err := o.WithTxOutbox(ctx, func(ctx context.Context) error {
+		return o.Add(ctx, &Event{ID: "e3", Topic: "orders", Payload: 3})
+	},
+		&Event{ID: "e1", Topic: "orders", Key: "o-1", Headers: map[string]string{"tenant": "t1"}, Payload: map[string]int{"n": 1}},
+		&Event{ID: "e2", Topic: "orders", Payload: 2},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var published []*Message
+	relay := o.NewRelay(PublisherFunc(func(_ context.Context, msg *Message) error {
+		published = append(published, msg)
+		return nil
+	}), RelayOptions{BatchSize: 2})
+
+	for _, want := range []int{2, 1, 0} {
+		if n, err := relay.ProcessBatch(ctx); err != nil || n != want {
+			t.Fatalf("ProcessBatch = %d, %v, want %d", n, err, want)
+		}
+	}
+
+	var ids []string
+	for _, msg := range published {
+		ids = append(ids, msg.ID)
+	}
+	// e3 was added by fn, before the events passed to WithTxOutbox
+	if !slices.Equal(ids, []string{"e3", "e1", "e2"}) {
+		t.Fatalf("published %v", ids)
+	}
+	first := published[1]
+	if first.Key != "o-1" || first.Headers["tenant"] != "t1" || string(first.Payload) != `{"n":1}` || first.Topic != "orders" {
+		t.Fatalf("unexpected message %+v", first)
+	}
+	if attempts, lastError, _ := row(t, o, "e1"); attempts != 1 || lastError != "" {
+		t.Fatalf("published event has %d attempts, error %q", attempts, lastError)
+	}
+
+	if n, err := o.Purge(ctx, time.Now().Add(time.Minute)); err != nil || n != 3 {
+		t.Fatalf("Purge = %d, %v, want 3", n, err)
+	}
+}
+
+func TestRelayRetriesWithBackoff(t *testing.T) {
+	o := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := o.WithTxOutbox(ctx, func(context.Context) error { return nil }, &Event{ID: "e1", Topic: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var failures []string
+	relay := o.NewRelay(PublisherFunc(func(context.Context, *Message) error {
+		return errors.New("broker unavailable")
+	}), RelayOptions{OnError: func(msg *Message, err error) {
+		failures = append(failures, msg.ID+": "+err.Error())
+	}})
+
+	before := time.Now().UTC()
+	if n, err := relay.ProcessBatch(ctx); err != nil || n != 1 {
+		t.Fatalf("ProcessBatch = %d, %v", n, err)
+	}
+	if !slices.Equal(failures, []string{"e1: broker unavailable"}) {
+		t.Fatalf("OnError calls %v", failures)
+	}
+	attempts, lastError, next := row(t, o, "e1")
+	if attempts != 1 || lastError != "broker unavailable" || next.Before(before.Add(time.Second)) {
+		t.Fatalf("failed event: attempts %d, error %q, next attempt %v", attempts, lastError, next)
+	}
+
+	// Not due again until the backoff has passed
+	if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 {
+		t.Fatalf("ProcessBatch during backoff = %d, %v", n, err)
+	}
+}
+
+func TestRelayStopsAtMaxAttempts(t *testing.T) {
+	o := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := o.WithTxOutbox(ctx, func(context.Context) error { return nil }, &Event{ID: "e1", Topic: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := true
+	relay := o.NewRelay(PublisherFunc(func(context.Context, *Message) error {
+		if fail {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}), RelayOptions{MaxAttempts: 3, MaxBackoff: time.Millisecond})
+
+	for i := range 3 {
+		if n, err := relay.ProcessBatch(ctx); err != nil || n != 1 {
+			t.Fatalf("attempt %d: ProcessBatch = %d, %v", i+1, n, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The event is left for inspection, even once the broker recovers
+	fail = false
+	if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 {
+		t.Fatalf("ProcessBatch after max attempts = %d, %v", n, err)
+	}
+	if attempts, lastError, _ := row(t, o, "e1"); attempts != 3 || lastError != "broker unavailable" {
+		t.Fatalf("dead event: attempts %d, error %q", attempts, lastError)
+	}
+}
@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ncobase/ncore/data"
+)
+
+// KafkaPublisher publishes messages to the Kafka topic named by the event,
+// keyed by the event key or, when empty, its ID
+func KafkaPublisher(d *data.Data) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg *Message) error {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		key := msg.Key
+		if key == "" {
+			key = msg.ID
+		}
+		return d.PublishToKafka(ctx, msg.Topic, []byte(key), body)
+	})
+}
+
+// RabbitMQPublisher publishes messages to the RabbitMQ exchange named by the
+// event topic, routed by the event key
+func RabbitMQPublisher(d *data.Data) Publisher {
+	return PublisherFunc(func(_ context.Context, msg *Message) error {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return d.PublishToRabbitMQ(msg.Topic, msg.Key, body)
+	})
+}
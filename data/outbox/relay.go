@@ -0,0 +1,184 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Publisher publishes outbox messages to a broker
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc adapts a function to Publisher
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// RelayOptions configures a relay
+type RelayOptions struct {
+	BatchSize   int                           // Events claimed per poll, defaults to 100
+	Interval    time.Duration                 // Poll interval when idle, defaults to 1s
+	MaxAttempts int                           // Attempts before an event is left for inspection, defaults to 10
+	MaxBackoff  time.Duration                 // Longest retry delay, defaults to 5m
+	OnError     func(msg *Message, err error) // msg is nil when a whole batch fails
+}
+
+// Relay publishes pending outbox events with at-least-once delivery.
+// Several relays may run at once; on Postgres and MySQL they claim disjoint
+// batches with SKIP LOCKED.
+type Relay struct {
+	o    *Outbox
+	pub  Publisher
+	opts RelayOptions
+}
+
+// NewRelay creates a relay publishing this outbox through pub
+func (o *Outbox) NewRelay(pub Publisher, opts RelayOptions) *Relay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	return &Relay{o: o, pub: pub, opts: opts}
+}
+
+// Run publishes events until ctx is done
+func (r *Relay) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		n, err := r.ProcessBatch(ctx)
+		if err != nil && r.opts.OnError != nil && ctx.Err() == nil {
+			r.opts.OnError(nil, err)
+		}
+
+		// Keep draining while batches are full
+		if err == nil && n == r.opts.BatchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.opts.Interval)
+		}
+	}
+}
+
+// ProcessBatch claims and publishes one batch, returning how many events were claimed
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	db := r.o.d.GetMasterDB()
+	if db == nil {
+		return 0, errors.New("database connection is nil")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	messages, err := r.claim(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		if err := r.pub.Publish(ctx, msg); err != nil {
+			if r.opts.OnError != nil {
+				r.opts.OnError(msg, err)
+			}
+			if err := r.markFailed(ctx, tx, msg, err); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := r.markPublished(ctx, tx, msg); err != nil {
+			return 0, err
+		}
+	}
+
+	// A crash before commit republishes the batch, consumers deduplicate by ID
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(messages), nil
+}
+
+// claim locks a batch of due events
+func (r *Relay) claim(ctx context.Context, tx *sql.Tx) ([]*Message, error) {
+	query := fmt.Sprintf(`SELECT id, topic, msg_key, headers, payload, created_at, attempts FROM %s
+		WHERE published_at IS NULL AND next_attempt_at <= ? AND attempts < ?
+		ORDER BY created_at, id LIMIT ?`, r.o.table)
+	switch r.o.driver {
+	case "postgres", "pgx", "mysql":
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+
+	rows, err := tx.QueryContext(ctx, r.o.rebind(query), time.Now().UTC(), r.opts.MaxAttempts, r.opts.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %v", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var (
+			msg     Message
+			headers sql.NullString
+			payload string
+		)
+		if err := rows.Scan(&msg.ID, &msg.Topic, &msg.Key, &headers, &payload, &msg.CreatedAt, &msg.Attempts); err != nil {
+			return nil, err
+		}
+		if headers.Valid && headers.String != "" {
+			if err := json.Unmarshal([]byte(headers.String), &msg.Headers); err != nil {
+				return nil, fmt.Errorf("invalid outbox headers for %s: %v", msg.ID, err)
+			}
+		}
+		body, err := r.o.cp.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid outbox payload for %s: %v", msg.ID, err)
+		}
+		msg.Payload = json.RawMessage(body)
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+// markPublished records a successful publish
+func (r *Relay) markPublished(ctx context.Context, tx *sql.Tx, msg *Message) error {
+	_, err := tx.ExecContext(ctx, r.o.rebind(fmt.Sprintf(
+		"UPDATE %s SET published_at = ?, attempts = attempts + 1, last_error = NULL WHERE id = ?", r.o.table)),
+		time.Now().UTC(), msg.ID)
+	return err
+}
+
+// markFailed schedules a retry with exponential backoff
+func (r *Relay) markFailed(ctx context.Context, tx *sql.Tx, msg *Message, cause error) error {
+	backoff := time.Second << min(msg.Attempts, 20)
+	backoff = min(backoff, r.opts.MaxBackoff)
+
+	_, err := tx.ExecContext(ctx, r.o.rebind(fmt.Sprintf(
+		"UPDATE %s SET attempts = attempts + 1, next_attempt_at = ?, last_error = ? WHERE id = ?", r.o.table)),
+		time.Now().UTC().Add(backoff), cause.Error(), msg.ID)
+	return err
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/ncobase/ncore/data"
 	"github.com/ncobase/ncore/data/config"
+	"github.com/ncobase/ncore/data/tracing"
 
 	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver
 )
@@ -52,7 +53,7 @@ func (d *driver) Connect(ctx context.Context, cfg any) (any, error) {
 	}
 
 	// Open connection using pgx driver
-	db, err := sql.Open("pgx", dbCfg.Source)
+	db, err := tracing.OpenDB("pgx", dbCfg.Source, "postgresql")
 	if err != nil {
 		return nil, fmt.Errorf("postgres: failed to open connection: %w", err)
 	}
@@ -162,6 +162,12 @@ func (s *RabbitMQ) PublishMessage(exchange, routingKey string, body []byte) erro
 
 // ConsumeMessages consumes messages from RabbitMQ
 func (s *RabbitMQ) ConsumeMessages(queue string, handler func([]byte) error) error {
+	return s.ConsumeMessagesContext(context.Background(), queue, handler)
+}
+
+// ConsumeMessagesContext consumes messages from RabbitMQ until ctx is done.
+// A delivery whose handler fails after ctx is done is requeued instead of acknowledged.
+func (s *RabbitMQ) ConsumeMessagesContext(ctx context.Context, queue string, handler func([]byte) error) error {
 	if !s.IsConnected() {
 		return fmt.Errorf("rabbitmq connection is not available")
 	}
@@ -209,8 +215,25 @@ func (s *RabbitMQ) ConsumeMessages(queue string, handler func([]byte) error) err
 			}
 		}()
 
-		for d := range msgs {
+		for {
+			var d amqp.Delivery
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case d, ok = <-msgs:
+				if !ok {
+					return
+				}
+			}
+
 			if err := handler(d.Body); err != nil {
+				if ctx.Err() != nil {
+					if err := d.Nack(false, true); err != nil {
+						fmt.Printf("Failed to requeue message: %v\n", err)
+					}
+					return
+				}
 				fmt.Printf("Failed to process message: %v\n", err)
 			}
 
@@ -37,6 +37,7 @@ func (d *driver) Connect(ctx context.Context, cfg any) (any, error) {
 		WriteTimeout: redisCfg.WriteTimeout,
 		DialTimeout:  redisCfg.DialTimeout,
 	})
+	client.AddHook(TracingHook{})
 
 	if err := client.Ping(ctx).Err(); err != nil {
 		client.Close()
@@ -269,6 +270,10 @@ func (c *RedisDataCollector) MongoOperation(operation string, err error) {
 	c.base.MongoOperation(operation, err)
 }
 
+func (c *RedisDataCollector) ClickHouseOperation(operation string, duration time.Duration, err error) {
+	c.base.ClickHouseOperation(operation, duration, err)
+}
+
 func (c *RedisDataCollector) SearchQuery(engine string, err error) {
 	c.base.SearchQuery(engine, err)
 }
@@ -277,6 +282,18 @@ func (c *RedisDataCollector) SearchIndex(engine, operation string) {
 	c.base.SearchIndex(engine, operation)
 }
 
+func (c *RedisDataCollector) SearchFailover(from, to, reason string) {
+	c.base.SearchFailover(from, to, reason)
+}
+
+func (c *RedisDataCollector) QueryCache(hit bool) {
+	c.base.QueryCache(hit)
+}
+
+func (c *RedisDataCollector) ReplicaLag(replica string, lag time.Duration, err error) {
+	c.base.ReplicaLag(replica, lag, err)
+}
+
 func (c *RedisDataCollector) MQPublish(system string, err error) {
 	c.base.MQPublish(system, err)
 }
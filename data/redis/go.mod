@@ -2,11 +2,19 @@ module github.com/ncobase/ncore/data/redis
 
 go 1.25.3
 
-require github.com/redis/go-redis/v9 v9.17.3
+require (
+	github.com/redis/go-redis/v9 v9.17.3
+	go.opentelemetry.io/otel v1.40.0
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.40.0 // indirect
+	go.opentelemetry.io/otel/trace v1.40.0 // indirect
 )
 
 replace github.com/ncobase/ncore/data => ../
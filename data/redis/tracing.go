@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/ncobase/ncore/data/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracingHook records Redis commands and pipelines as spans. Clients
+// created by the driver have it installed, add it to others with
+// client.AddHook(redis.TracingHook{}).
+type TracingHook struct{}
+
+var _ redis.Hook = TracingHook{}
+
+// DialHook implements redis.Hook
+func (TracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, span := tracing.Start(ctx, "redis.dial", tracing.DBSystem.String("redis"), attribute.String("server.address", addr))
+		conn, err := next(ctx, network, addr)
+		tracing.End(span, err)
+		return conn, err
+	}
+}
+
+// ProcessHook implements redis.Hook
+func (TracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		op := strings.ToUpper(cmd.Name())
+		ctx, span := tracing.Start(ctx, op, tracing.DBSystem.String("redis"), tracing.DBOperation.String(op))
+		err := next(ctx, cmd)
+		tracing.End(span, ignoreNil(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (TracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ops := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			ops[i] = strings.ToUpper(cmd.Name())
+		}
+		ctx, span := tracing.Start(ctx, "PIPELINE",
+			tracing.DBSystem.String("redis"),
+			tracing.DBOperation.String("PIPELINE"),
+			attribute.StringSlice("db.redis.commands", ops),
+		)
+		err := next(ctx, cmds)
+		tracing.End(span, ignoreNil(err))
+		return err
+	}
+}
+
+// ignoreNil hides redis.Nil, a missing key rather than a failure
+func ignoreNil(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ncobase/ncore/data/hedge"
+	"github.com/ncobase/ncore/data/metrics"
+)
+
+// WithSession marks ctx as belonging to session, e.g. a user or session ID, so that
+// reads after its writes stay on the master for the read-your-writes window
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, contextKeySession, session)
+}
+
+// SessionFromContext returns the session set by WithSession
+func SessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(contextKeySession).(string)
+	return session
+}
+
+// GetSlaveDBContext returns a slave database, or the master while the session in ctx
+// is pinned after a write
+func (d *Data) GetSlaveDBContext(ctx context.Context) (*sql.DB, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		return nil, errors.New("data layer is closed")
+	}
+	if d.Conn == nil || d.Conn.DBM == nil {
+		return nil, errors.New("no database connection available")
+	}
+	return d.Conn.DBM.SlaveFor(SessionFromContext(ctx))
+}
+
+// DBReadContext is an alias of GetSlaveDBContext
+func (d *Data) DBReadContext(ctx context.Context) (*sql.DB, error) {
+	return d.GetSlaveDBContext(ctx)
+}
+
+// ReadHedged runs read on a slave like GetSlaveDBContext and, with data.database.hedge
+// enabled, on a second slave as well when the first has not answered within the
+// hedging delay. The first result is returned and the other read is cancelled, so
+// read must finish with its rows before returning and have no side effects.
+func ReadHedged[T any](ctx context.Context, d *Data, read func(ctx context.Context, db *sql.DB) (T, error)) (T, error) {
+	db, err := d.GetSlaveDBContext(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	dbm := d.GetDBManager()
+	if d.hedger == nil || dbm == nil || db == dbm.Master() {
+		return read(ctx, db)
+	}
+	other := dbm.HedgeTarget(db)
+	if other == nil {
+		return read(ctx, db)
+	}
+
+	v, outcome, err := hedge.Do(ctx, d.hedger,
+		func(ctx context.Context) (T, error) { return read(ctx, db) },
+		func(ctx context.Context) (T, error) { return read(ctx, other) },
+	)
+	if outcome != hedge.NotHedged {
+		d.observeHedge(dbm.ReplicaName(db), dbm.ReplicaName(other), outcome == hedge.HedgeWon)
+	}
+	return v, err
+}
+
+// HedgeStats returns the counters of hedged database reads
+func (d *Data) HedgeStats() hedge.Stats {
+	return d.hedger.Stats()
+}
+
+// MarkWrite pins reads of the session in ctx to the master for the read-your-writes
+// window. WithTx calls it on commit, call it after writes made outside a transaction.
+func (d *Data) MarkWrite(ctx context.Context) {
+	if dbm := d.GetDBManager(); dbm != nil {
+		dbm.Pin(SessionFromContext(ctx))
+	}
+}
+
+// observeReplicaLag records replica lag probes if the collector supports it
+func (d *Data) observeReplicaLag(replica string, lag time.Duration, err error) {
+	d.mu.RLock()
+	collector := d.collector
+	d.mu.RUnlock()
+
+	if rc, ok := collector.(metrics.ReplicaLagCollector); ok {
+		rc.ReplicaLag(replica, lag, err)
+	}
+}
+
+// observeHedge records a hedged read if the collector supports it
+func (d *Data) observeHedge(primary, other string, won bool) {
+	d.mu.RLock()
+	collector := d.collector
+	d.mu.RUnlock()
+
+	if hc, ok := collector.(metrics.HedgeCollector); ok {
+		hc.DBHedge(primary, other, won)
+	}
+}
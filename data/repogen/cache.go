@@ -0,0 +1,313 @@
+package repogen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// cacheImportPath is the import path of the cache package used by generated code
+const cacheImportPath = "github.com/ncobase/ncore/data/cache"
+
+// CacheOptions configures caching decorator generation
+type CacheOptions struct {
+	Dir       string        // package directory of the repository interface
+	Interface string        // repository interface name, e.g. UserRepository
+	Output    string        // generated file, defaults to <dir>/<interface>_cache.go
+	Tag       string        // cache tag invalidated by writes, defaults to the entity name, e.g. user
+	TTL       time.Duration // default TTL of cached reads, 5m when zero
+	NoTests   bool          // skip the generated test file
+}
+
+// Cache is a generated caching decorator
+type Cache struct {
+	Interface *Interface
+	Output    string   // decorator file
+	TestFile  string   // test file, empty with NoTests
+	Reads     []string // methods read through the cache
+	Writes    []string // methods invalidating the cache
+}
+
+// GenerateCache writes a read-through caching decorator for a repository
+// interface, and a test covering it
+func GenerateCache(opts CacheOptions) (*Cache, error) {
+	if opts.Interface == "" {
+		return nil, fmt.Errorf("interface name is required")
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Output == "" {
+		opts.Output = filepath.Join(opts.Dir, snakeCase(baseName(opts.Interface))+"_cache.go")
+	}
+
+	iface, err := ParseInterface(opts.Dir, opts.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := RenderCache(iface, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(opts.Output, src, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write decorator: %v", err)
+	}
+
+	c := &Cache{Interface: iface, Output: opts.Output}
+	for _, m := range iface.Methods {
+		if m.Read {
+			c.Reads = append(c.Reads, m.Name)
+		} else {
+			c.Writes = append(c.Writes, m.Name)
+		}
+	}
+
+	if !opts.NoTests {
+		test, err := RenderCacheTest(iface, opts)
+		if err != nil {
+			return nil, err
+		}
+		c.TestFile = strings.TrimSuffix(opts.Output, ".go") + "_test.go"
+		if err := os.WriteFile(c.TestFile, test, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write decorator test: %v", err)
+		}
+	}
+	return c, nil
+}
+
+var cacheTemplate = template.Must(template.New("cache").Parse(`// Code generated by "ncore gen cache"; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range $i, $group := .Imports}}{{if $i}}
+{{end}}
+{{- range $group}}
+	{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+{{- end}}
+{{- end}}
+)
+
+// Default{{.Base}}CacheTTL is how long {{.Tag}} reads are cached when
+// NewCached{{.Base}} is given no TTL
+const Default{{.Base}}CacheTTL = {{.TTL}}
+
+// cached{{.Base}} reads through a query cache and invalidates it on writes
+type cached{{.Base}} struct {
+	next  {{.Interface}}
+	cache *{{.CachePkg}}.QueryCache
+	tags  []string
+}
+
+// NewCached{{.Base}} wraps next with read-through caching on store. Reads are
+// cached for ttl, Default{{.Base}}CacheTTL when zero, and successful writes
+// invalidate every cached {{.Tag}} read.
+func NewCached{{.Base}}(next {{.Interface}}, store {{.CachePkg}}.QueryStore, ttl time.Duration) {{.Interface}} {
+	if ttl <= 0 {
+		ttl = Default{{.Base}}CacheTTL
+	}
+	return &cached{{.Base}}{
+		next:  next,
+		cache: {{.CachePkg}}.NewQueryCache(store, {{.CachePkg}}.QueryCacheOptions{TTL: ttl}),
+		tags:  []string{ {{- printf "%q" .Tag -}} },
+	}
+}
+{{range .Methods}}
+{{.}}
+{{end}}`))
+
+// RenderCache returns the formatted decorator source for iface
+func RenderCache(iface *Interface, opts CacheOptions) ([]byte, error) {
+	cachePkg := cacheName(iface)
+	methods := make([]string, len(iface.Methods))
+	for i, m := range iface.Methods {
+		methods[i] = renderMethod(m, baseName(iface.Name), cachePkg, entityTag(iface.Name, opts.Tag))
+	}
+
+	extra := []Import{{Name: cachePkg, Path: cacheImportPath}, {Path: "time"}}
+	for _, m := range iface.Methods {
+		if !m.Context {
+			extra = append(extra, Import{Path: "context"})
+			break
+		}
+	}
+
+	return render(cacheTemplate, map[string]any{
+		"Package":   iface.Package,
+		"Imports":   iface.imports(extra...),
+		"Interface": iface.Name,
+		"Base":      baseName(iface.Name),
+		"Tag":       entityTag(iface.Name, opts.Tag),
+		"TTL":       durationExpr(opts.TTL),
+		"CachePkg":  cachePkg,
+		"Methods":   methods,
+	})
+}
+
+// renderMethod writes a decorator method, reading through the cache or
+// invalidating it
+func renderMethod(m Method, base, cachePkg, tag string) string {
+	var b strings.Builder
+	params, args, keys := signature(m)
+	results := resultList(m.Results)
+
+	if m.Read {
+		fmt.Fprintf(&b, "// %s reads through the cache\n", m.Name)
+		fmt.Fprintf(&b, "func (r *cached%s) %s(%s) %s {\n", base, m.Name, params, results)
+		fmt.Fprintf(&b, "\treturn %s.CachedQuery(ctx, r.cache, r.tags, %q, []any{%s}, func(ctx context.Context) (%s, error) {\n",
+			cachePkg, tag+":"+m.Name, strings.Join(keys, ", "), m.Results[0])
+		fmt.Fprintf(&b, "\t\treturn r.next.%s(%s)\n\t})\n}", m.Name, args)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "// %s invalidates cached reads\n", m.Name)
+	fmt.Fprintf(&b, "func (r *cached%s) %s(%s) %s {\n", base, m.Name, params, results)
+	ctx := "ctx"
+	if !m.Context {
+		ctx = "context.Background()"
+	}
+
+	vars := make([]string, len(m.Results))
+	for i := range vars {
+		vars[i] = fmt.Sprintf("r%d", i)
+	}
+	if m.Error {
+		vars[len(vars)-1] = "err"
+	}
+	call := fmt.Sprintf("r.next.%s(%s)", m.Name, args)
+	if len(vars) == 0 {
+		fmt.Fprintf(&b, "\t%s\n", call)
+	} else {
+		fmt.Fprintf(&b, "\t%s := %s\n", strings.Join(vars, ", "), call)
+	}
+	if m.Error {
+		fmt.Fprintf(&b, "\tif err == nil {\n\t\tr.cache.Invalidate(%s, r.tags...)\n\t}\n", ctx)
+	} else {
+		fmt.Fprintf(&b, "\tr.cache.Invalidate(%s, r.tags...)\n", ctx)
+	}
+	if len(vars) > 0 {
+		fmt.Fprintf(&b, "\treturn %s\n", strings.Join(vars, ", "))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// signature returns the parameter list, the call arguments and the cache key
+// arguments of a method
+func signature(m Method) (params, args string, keys []string) {
+	var ps, as []string
+	if m.Context {
+		ps = append(ps, "ctx context.Context")
+		as = append(as, "ctx")
+	}
+	for _, p := range m.Params {
+		ps = append(ps, p.Name+" "+p.Type)
+		if p.Variadic {
+			as = append(as, p.Name+"...")
+		} else {
+			as = append(as, p.Name)
+		}
+		keys = append(keys, p.Name)
+	}
+	return strings.Join(ps, ", "), strings.Join(as, ", "), keys
+}
+
+// resultList returns the result list of a signature
+func resultList(results []string) string {
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		return results[0]
+	default:
+		return "(" + strings.Join(results, ", ") + ")"
+	}
+}
+
+// cacheName returns the name the cache package is imported under, avoiding
+// a signature package of the same name
+func cacheName(iface *Interface) string {
+	if p, ok := iface.Imports["cache"]; ok && p != cacheImportPath {
+		return "ncorecache"
+	}
+	return "cache"
+}
+
+// render executes a template and formats its output
+func render(t *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %v", t.Name(), err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %v", t.Name(), err)
+	}
+	return src, nil
+}
+
+// baseName strips an Interface suffix, UserRepositoryInterface is UserRepository
+func baseName(name string) string {
+	if base := strings.TrimSuffix(name, "Interface"); base != "" {
+		name = base
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// entityTag returns tag, or the entity of a repository interface in snake
+// case, UserRepository is user
+func entityTag(name, tag string) string {
+	if tag != "" {
+		return tag
+	}
+	base := baseName(name)
+	for _, suffix := range []string{"Repository", "Repo", "Store"} {
+		if trimmed := strings.TrimSuffix(base, suffix); trimmed != "" && trimmed != base {
+			base = trimmed
+			break
+		}
+	}
+	return snakeCase(base)
+}
+
+// snakeCase converts a Go identifier to snake case
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// durationExpr writes d as a Go expression
+func durationExpr(d time.Duration) string {
+	if d <= 0 {
+		d = 5 * time.Minute
+	}
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{{time.Hour, "time.Hour"}, {time.Minute, "time.Minute"}, {time.Second, "time.Second"}, {time.Millisecond, "time.Millisecond"}} {
+		if d%unit.d == 0 {
+			if d == unit.d {
+				return unit.name
+			}
+			return fmt.Sprintf("%d * %s", d/unit.d, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
@@ -0,0 +1,154 @@
+package repogen
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var cacheTestTemplate = template.Must(template.New("cache test").Parse(`// Code generated by "ncore gen cache"; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range $i, $group := .Imports}}{{if $i}}
+{{end}}
+{{- range $group}}
+	{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+{{- end}}
+{{- end}}
+)
+
+// fake{{.Base}} counts the calls reaching the repository
+type fake{{.Base}} struct {
+	calls map[string]int
+}
+{{range .Fakes}}
+{{.}}
+{{end}}
+func TestCached{{.Base}}(t *testing.T) {
+{{- if .Context}}
+	ctx := context.Background()
+{{- end}}
+	next := &fake{{.Base}}{calls: map[string]int{}}
+	r := NewCached{{.Base}}(next, {{.CachePkg}}.NewMemoryQueryStore(128), time.Minute)
+
+{{.Body}}
+}
+`))
+
+// RenderCacheTest returns the formatted source of a test checking that the
+// decorator of iface reads through the cache and writes invalidate it
+func RenderCacheTest(iface *Interface, opts CacheOptions) ([]byte, error) {
+	base := baseName(iface.Name)
+	cachePkg := cacheName(iface)
+
+	fakes := make([]string, len(iface.Methods))
+	for i, m := range iface.Methods {
+		fakes[i] = renderFake(m, base)
+	}
+
+	var body strings.Builder
+	vars := 0
+	declare := func(m Method) string {
+		var args []string
+		if m.Context {
+			args = append(args, "ctx")
+		}
+		for _, p := range m.Params {
+			if p.Variadic {
+				continue
+			}
+			fmt.Fprintf(&body, "\tvar a%d %s\n", vars, p.Type)
+			args = append(args, fmt.Sprintf("a%d", vars))
+			vars++
+		}
+		return strings.Join(args, ", ")
+	}
+	call := func(m Method, args string) string {
+		blanks := make([]string, len(m.Results))
+		for i := range blanks {
+			blanks[i] = "_"
+		}
+		if len(blanks) == 0 {
+			return fmt.Sprintf("\tr.%s(%s)\n", m.Name, args)
+		}
+		return fmt.Sprintf("\t%s = r.%s(%s)\n", strings.Join(blanks, ", "), m.Name, args)
+	}
+
+	// Reads load once, then hit the cache
+	var first *Method
+	var firstArgs string
+	for i, m := range iface.Methods {
+		if !m.Read {
+			continue
+		}
+		args := declare(m)
+		body.WriteString(call(m, args) + call(m, args))
+		fmt.Fprintf(&body, "\tif got := next.calls[%q]; got != 1 {\n\t\tt.Errorf(\"%s: expected 1 load, got %%d\", got)\n\t}\n\n", m.Name, m.Name)
+		if first == nil {
+			first, firstArgs = &iface.Methods[i], args
+		}
+	}
+
+	// Each write invalidates, so the next read loads again
+	if first != nil {
+		loads := 1
+		for _, m := range iface.Methods {
+			if m.Read {
+				continue
+			}
+			loads++
+			body.WriteString(call(m, declare(m)) + call(*first, firstArgs))
+			fmt.Fprintf(&body, "\tif got := next.calls[%q]; got != %d {\n\t\tt.Errorf(\"%s: expected a reload after %s, got %%d loads\", got)\n\t}\n\n", first.Name, loads, first.Name, m.Name)
+		}
+	}
+
+	// Reads take a context, without them nothing is called
+	extra := []Import{{Path: "testing"}, {Path: "time"}, {Name: cachePkg, Path: cacheImportPath}}
+	hasContext := first != nil
+	if hasContext {
+		extra = append(extra, Import{Path: "context"})
+	} else {
+		body.WriteString("\t_ = r // no method is read through the cache\n")
+	}
+
+	return render(cacheTestTemplate, map[string]any{
+		"Package":  iface.Package,
+		"Imports":  iface.imports(extra...),
+		"Base":     base,
+		"CachePkg": cachePkg,
+		"Context":  hasContext,
+		"Fakes":    fakes,
+		"Body":     strings.TrimRight(body.String(), "\n"),
+	})
+}
+
+// renderFake writes a fake method counting its calls and returning zero values
+func renderFake(m Method, base string) string {
+	var params []string
+	if m.Context {
+		params = append(params, "context.Context")
+	}
+	for _, p := range m.Params {
+		params = append(params, p.Type)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func (f *fake%s) %s(%s) %s {\n", base, m.Name, strings.Join(params, ", "), resultList(m.Results))
+	fmt.Fprintf(&b, "\tf.calls[%q]++\n", m.Name)
+	if len(m.Results) > 0 {
+		vars := make([]string, len(m.Results))
+		for i, typ := range m.Results {
+			if m.Error && i == len(m.Results)-1 {
+				vars[i] = "nil"
+				continue
+			}
+			vars[i] = fmt.Sprintf("r%d", i)
+			fmt.Fprintf(&b, "\tvar r%d %s\n", i, typ)
+		}
+		fmt.Fprintf(&b, "\treturn %s\n", strings.Join(vars, ", "))
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -0,0 +1,22 @@
+// Package repogen generates repository code from Go source.
+//
+// GenerateCache wraps a repository interface with a read-through caching
+// decorator on data/cache, run by "ncore gen cache":
+//
+//	ncore gen cache -dir core/user/data/repository -type UserRepository -ttl 10m
+//
+// Methods taking a context, named Get, Find, List, Count, Search, Exists, Has,
+// Query, Load, Fetch, Lookup or Page and returning a value and an error are
+// read through a cache.QueryCache, keyed by their arguments. Every other
+// method is a write and invalidates the entity tag once it succeeds, after
+// the transaction in its context commits. Results are cached as JSON, so they
+// must round trip through encoding/json.
+//
+// The generated constructor takes the store and the TTL of the entity, e.g.
+// from the extension's config section:
+//
+//	repo = repository.NewCachedUserRepository(repo, cache.NewRedisQueryStore(rc, "users"), conf.CacheTTL)
+//
+// A test is generated next to the decorator, checking with a fake repository
+// that reads load once and that each write invalidates them.
+package repogen
@@ -0,0 +1,97 @@
+package repogen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const repositorySource = `package repository
+
+import (
+	"context"
+
+	"example.com/app/structs"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type UserRepositoryInterface interface {
+	Create(ctx context.Context, user *structs.User) (*structs.User, error)
+	FindByID(ctx context.Context, id string) (*structs.User, error)
+	List(ctx context.Context, limit, offset int) ([]*structs.User, error)
+	CountByRole(context.Context, ...string) (int, error)
+	Delete(ctx context.Context, id string) error
+	Watch(ctx context.Context, fn func(*structs.User)) error
+	Export(doc *yaml.Node)
+	Listener() string
+}
+`
+
+func TestGenerateCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "repository.go"), []byte(repositorySource), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := GenerateCache(CacheOptions{Dir: dir, Interface: "UserRepositoryInterface", TTL: 90 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(c.Reads, ","); got != "FindByID,List,CountByRole" {
+		t.Fatalf("unexpected reads %s", got)
+	}
+	if got := strings.Join(c.Writes, ","); got != "Create,Delete,Watch,Export,Listener" {
+		t.Fatalf("unexpected writes %s", got)
+	}
+	if c.Output != filepath.Join(dir, "user_repository_cache.go") {
+		t.Fatalf("unexpected output %s", c.Output)
+	}
+
+	src, err := os.ReadFile(c.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`const DefaultUserRepositoryCacheTTL = 90 * time.Second`,
+		`func NewCachedUserRepository(next UserRepositoryInterface, store cache.QueryStore, ttl time.Duration) UserRepositoryInterface`,
+		`tags:  []string{"user"}`,
+		`cache.CachedQuery(ctx, r.cache, r.tags, "user:CountByRole", []any{p1}, func(ctx context.Context) (int, error) {`,
+		`return r.next.CountByRole(ctx, p1...)`,
+		"\"time\"\n\n\t\"example.com/app/structs\"",
+		`"gopkg.in/yaml.v3"`,
+		`r.cache.Invalidate(context.Background(), r.tags...)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("decorator misses %q:\n%s", want, src)
+		}
+	}
+
+	test, err := os.ReadFile(c.TestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`func TestCachedUserRepository(t *testing.T) {`,
+		`func (f *fakeUserRepository) Watch(context.Context, func(*structs.User)) error {`,
+		`t.Errorf("FindByID: expected a reload after Listener, got %d loads", got)`,
+	} {
+		if !strings.Contains(string(test), want) {
+			t.Errorf("test misses %q:\n%s", want, test)
+		}
+	}
+}
+
+func TestEntityTag(t *testing.T) {
+	for name, want := range map[string]string{
+		"UserRepository":         "user",
+		"OrderItemRepoInterface": "order_item",
+		"HTTPSessionStore":       "http_session",
+		"Repository":             "repository",
+	} {
+		if got := entityTag(name, ""); got != want {
+			t.Errorf("entityTag(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
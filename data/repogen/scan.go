@@ -0,0 +1,272 @@
+package repogen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readPrefixes mark the methods cached by a decorator, other methods are writes
+var readPrefixes = []string{"Get", "Find", "List", "Count", "Search", "Exists", "Has", "Query", "Load", "Fetch", "Lookup", "Page"}
+
+var (
+	// versionSuffix matches the major version element of an import path
+	versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+	// gopkgSuffix matches the version of a gopkg.in path, e.g. yaml.v3
+	gopkgSuffix = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// Param is a parameter of a repository method
+type Param struct {
+	Name     string // name in generated code
+	Type     string // type as written in the interface, e.g. *structs.User
+	Variadic bool
+}
+
+// Method is a method of a repository interface
+type Method struct {
+	Name    string
+	Context bool     // the first parameter is a context.Context
+	Params  []Param  // parameters after the context
+	Results []string // result types
+	Read    bool     // cached on reads
+	Error   bool     // the last result is an error
+}
+
+// Interface is a repository interface found in a package
+type Interface struct {
+	Package string
+	Name    string
+	Methods []Method
+	Imports map[string]string // import path by the name used in the method signatures
+}
+
+// Import is an import of generated code
+type Import struct {
+	Name string // alias, empty when it matches the package name
+	Path string
+}
+
+// ParseInterface reads the interface named name from the Go files of dir
+func ParseInterface(dir, name string) (*Interface, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f, err := parser.ParseFile(fset, file, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					return nil, fmt.Errorf("%s is not an interface", name)
+				}
+				if ts.TypeParams != nil {
+					return nil, fmt.Errorf("generic interface %s is not supported", name)
+				}
+				return newInterface(fset, f, name, it)
+			}
+		}
+	}
+	return nil, fmt.Errorf("interface %s not found in %s", name, dir)
+}
+
+// newInterface collects the methods of it and the imports they use
+func newInterface(fset *token.FileSet, f *ast.File, name string, it *ast.InterfaceType) (*Interface, error) {
+	iface := &Interface{Package: f.Name.Name, Name: name, Imports: map[string]string{}}
+
+	fileImports := make(map[string]string, len(f.Imports))
+	for _, imp := range f.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		n := packageName(p)
+		if imp.Name != nil {
+			n = imp.Name.Name
+		}
+		fileImports[n] = p
+	}
+
+	for _, field := range it.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("embedded interface %s in %s is not supported, list its methods instead", exprString(fset, field.Type), name)
+		}
+
+		// Signature packages are imported by generated code as well
+		ast.Inspect(ft, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					if p, ok := fileImports[id.Name]; ok {
+						iface.Imports[id.Name] = p
+					}
+				}
+			}
+			return true
+		})
+
+		iface.Methods = append(iface.Methods, newMethod(fset, field.Names[0].Name, ft))
+	}
+	return iface, nil
+}
+
+// newMethod describes a method and whether its reads can be cached
+func newMethod(fset *token.FileSet, name string, ft *ast.FuncType) Method {
+	m := Method{Name: name}
+
+	var cacheable = true
+	index := 0
+	if ft.Params != nil {
+		for i, field := range ft.Params.List {
+			typ := exprString(fset, field.Type)
+			if i == 0 && typ == "context.Context" && len(field.Names) <= 1 {
+				m.Context = true
+				index++
+				continue
+			}
+			switch t := field.Type.(type) {
+			case *ast.FuncType, *ast.ChanType:
+				// Such arguments cannot key a cached read
+				cacheable = false
+			case *ast.Ellipsis:
+				if _, ok := t.Elt.(*ast.FuncType); ok {
+					cacheable = false
+				}
+			}
+
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{nil}
+			}
+			for _, n := range names {
+				p := Param{Name: fmt.Sprintf("p%d", index), Type: typ}
+				if n != nil && n.Name != "_" && !reserved[n.Name] {
+					p.Name = n.Name
+				}
+				if strings.HasPrefix(typ, "...") {
+					p.Variadic = true
+				}
+				m.Params = append(m.Params, p)
+				index++
+			}
+		}
+	}
+
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			typ := exprString(fset, field.Type)
+			for range max(len(field.Names), 1) {
+				m.Results = append(m.Results, typ)
+			}
+		}
+	}
+	m.Error = len(m.Results) > 0 && m.Results[len(m.Results)-1] == "error"
+	m.Read = cacheable && m.Context && m.Error && len(m.Results) == 2 && isRead(name)
+	return m
+}
+
+// reserved names are taken by generated code and renamed in parameters
+var reserved = map[string]bool{"r": true, "ctx": true, "cache": true, "time": true, "context": true, "err": true}
+
+// isRead reports whether a method name starts with a read prefix
+func isRead(name string) bool {
+	for _, prefix := range readPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			rest := name[len(prefix):]
+			if rest == "" || strings.ToUpper(rest[:1]) == rest[:1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// imports returns the imports of generated code, standard library packages
+// first, with extra added unless a signature already imports their path
+func (iface *Interface) imports(extra ...Import) [][]Import {
+	byPath := make(map[string]string, len(iface.Imports)+len(extra))
+	for n, p := range iface.Imports {
+		byPath[p] = n
+	}
+	for _, imp := range extra {
+		n := imp.Name
+		if n == "" {
+			n = packageName(imp.Path)
+		}
+		if _, ok := byPath[imp.Path]; !ok {
+			byPath[imp.Path] = n
+		}
+	}
+
+	out := make([]Import, 0, len(byPath))
+	for p, n := range byPath {
+		imp := Import{Path: p}
+		if n != packageName(p) {
+			imp.Name = n
+		}
+		out = append(out, imp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+
+	var std, others []Import
+	for _, imp := range out {
+		if first, _, _ := strings.Cut(imp.Path, "/"); strings.Contains(first, ".") {
+			others = append(others, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	groups := make([][]Import, 0, 2)
+	for _, g := range [][]Import{std, others} {
+		if len(g) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// packageName guesses the package name of an import path
+func packageName(importPath string) string {
+	base := path.Base(importPath)
+	if versionSuffix.MatchString(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = gopkgSuffix.ReplaceAllString(strings.TrimPrefix(base, "go-"), "")
+	return strings.NewReplacer("-", "", ".", "").Replace(base)
+}
+
+// exprString prints an expression as written in the source
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
@@ -0,0 +1,27 @@
+// Package saga orchestrates multi-step workflows across services, undoing
+// completed steps with compensating actions when a later step fails.
+//
+//	o := saga.NewOrchestrator(saga.Options{Store: store, Publish: bus.Publish})
+//	err := o.Register(saga.New("create_workspace",
+//	    saga.Step{Name: "workspace", Action: createWorkspace, Compensate: deleteWorkspace},
+//	    saga.Step{Name: "owner", Action: addOwner, Compensate: removeOwner, Retries: 2},
+//	    saga.Step{Name: "quota", Action: allocateQuota, Timeout: 5 * time.Second},
+//	))
+//
+//	state, err := o.Start(ctx, "create_workspace", map[string]any{"owner_id": uid})
+//	if errors.Is(err, saga.ErrCompensated) {
+//	    // A step failed and the completed ones were undone
+//	}
+//
+// Progress is persisted after every step. NewSQLStore keeps it in a table on
+// the data layer's master database; Recover resumes sagas interrupted by a
+// restart, so actions and compensations must be idempotent. Each step has its
+// own timeout and retry policy, and State.Data carries values between steps.
+//
+// Status changes are published as "saga.<name>.<status>" events. Listen
+// subscribes to failure events from other services and compensates the saga
+// they name:
+//
+//	o.Listen(bus.Subscribe, "billing.payment_failed")
+//	bus.Publish("billing.payment_failed", map[string]any{"saga_id": id, "error": "card declined"})
+package saga
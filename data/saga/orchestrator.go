@@ -0,0 +1,363 @@
+package saga
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCompensated is returned when a step failed and all completed steps were undone
+	ErrCompensated = errors.New("saga: compensated")
+	// ErrCompensationFailed is returned when a compensating action failed
+	ErrCompensationFailed = errors.New("saga: compensation failed")
+)
+
+// Event is published on every saga status change as "saga.<name>.<status>"
+type Event struct {
+	ID     string `json:"id"`
+	Saga   string `json:"saga"`
+	Status Status `json:"status"`
+	Step   int    `json:"step"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Options configures an orchestrator
+type Options struct {
+	Store Store // Defaults to a MemoryStore
+	// Publish emits saga events, e.g. the Publish method of an extension event bus
+	Publish func(eventName string, data any)
+	// OnError reports failures of sagas resumed by Recover or compensated by Listen
+	OnError func(id string, err error)
+}
+
+// Orchestrator runs registered sagas and persists their progress
+type Orchestrator struct {
+	store   Store
+	publish func(eventName string, data any)
+	onError func(id string, err error)
+
+	mu      sync.Mutex
+	sagas   map[string]*Saga
+	running map[string]*execution
+}
+
+// execution tracks a saga running in this process
+type execution struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// NewOrchestrator creates an orchestrator
+func NewOrchestrator(opts Options) *Orchestrator {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	return &Orchestrator{
+		store:   opts.Store,
+		publish: opts.Publish,
+		onError: opts.OnError,
+		sagas:   make(map[string]*Saga),
+		running: make(map[string]*execution),
+	}
+}
+
+// Register adds a saga definition, replacing one with the same name
+func (o *Orchestrator) Register(s *Saga) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	o.mu.Lock()
+	o.sagas[s.Name] = s
+	o.mu.Unlock()
+	return nil
+}
+
+// Start creates a saga instance and runs it to completion or compensation.
+// The returned error wraps ErrCompensated or ErrCompensationFailed when a step failed.
+func (o *Orchestrator) Start(ctx context.Context, name string, data map[string]any) (*State, error) {
+	def, err := o.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	ts := now()
+	state := &State{
+		ID:        id,
+		Saga:      name,
+		Status:    StatusRunning,
+		Data:      data,
+		CreatedAt: ts,
+		UpdatedAt: ts,
+		published: StatusRunning,
+	}
+	if err := o.store.Create(ctx, state); err != nil {
+		return nil, err
+	}
+	return o.execute(ctx, def, state)
+}
+
+// Resume continues a persisted saga from its last recorded step.
+// Steps interrupted by a crash run again, so actions must be idempotent.
+func (o *Orchestrator) Resume(ctx context.Context, id string) (*State, error) {
+	state, err := o.store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status.Done() {
+		return state, nil
+	}
+	def, err := o.lookup(state.Saga)
+	if err != nil {
+		return state, err
+	}
+	return o.execute(ctx, def, state)
+}
+
+// Recover resumes all pending sagas, typically once at startup
+func (o *Orchestrator) Recover(ctx context.Context) error {
+	states, err := o.store.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := o.Resume(ctx, state.ID); err != nil {
+			o.report(state.ID, err)
+		}
+	}
+	return nil
+}
+
+// Compensate undoes the completed steps of a saga. A saga running in this
+// process is cancelled with ErrAborted and compensated by its runner.
+func (o *Orchestrator) Compensate(ctx context.Context, id string, reason error) (*State, error) {
+	if reason == nil {
+		reason = ErrAborted
+	}
+
+	o.mu.Lock()
+	exec := o.running[id]
+	o.mu.Unlock()
+	if exec != nil {
+		exec.cancel(reason)
+		select {
+		case <-exec.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	state, err := o.store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch state.Status {
+	case StatusCompensated:
+		return state, nil
+	case StatusRunning, StatusCompleted, StatusFailed:
+		state.Status = StatusCompensating
+		state.Error = reason.Error()
+	}
+
+	def, err := o.lookup(state.Saga)
+	if err != nil {
+		return state, err
+	}
+	return o.execute(ctx, def, state)
+}
+
+// Listen compensates sagas named by failure events. The event data is a saga
+// ID, an Event or a map with a "saga_id" key. subscribe is typically the
+// Subscribe method of an extension event bus.
+func (o *Orchestrator) Listen(subscribe func(eventName string, handler func(any)), events ...string) {
+	for _, name := range events {
+		subscribe(name, func(data any) {
+			id, reason := eventSaga(data)
+			if id == "" {
+				return
+			}
+			if _, err := o.Compensate(context.Background(), id, fmt.Errorf("event %s: %s", name, reason)); err != nil &&
+				!errors.Is(err, ErrCompensated) {
+				o.report(id, err)
+			}
+		})
+	}
+}
+
+// execute runs the forward steps and, after a failure, the compensations
+func (o *Orchestrator) execute(ctx context.Context, def *Saga, state *State) (*State, error) {
+	if state.Data == nil {
+		state.Data = make(map[string]any)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	exec := &execution{cancel: cancel, done: make(chan struct{})}
+
+	o.mu.Lock()
+	if _, busy := o.running[state.ID]; busy {
+		o.mu.Unlock()
+		cancel(nil)
+		return state, fmt.Errorf("saga %s is already running", state.ID)
+	}
+	o.running[state.ID] = exec
+	o.mu.Unlock()
+
+	defer func() {
+		o.mu.Lock()
+		delete(o.running, state.ID)
+		o.mu.Unlock()
+		cancel(nil)
+		close(exec.done)
+	}()
+
+	for state.Status == StatusRunning && state.Step < len(def.Steps) {
+		step := &def.Steps[state.Step]
+		if err := step.run(ctx, step.Action, state); err != nil {
+			cause := context.Cause(ctx)
+			if cause != nil && errors.Is(cause, ctx.Err()) {
+				// Caller went away, leave the saga for Resume
+				return state, err
+			}
+			state.Status = StatusCompensating
+			state.Error = fmt.Sprintf("step %s: %v", step.Name, err)
+			if cause != nil {
+				state.Error = cause.Error()
+			}
+			break
+		}
+
+		state.Step++
+		if state.Step == len(def.Steps) {
+			state.Status = StatusCompleted
+		}
+		if err := o.save(ctx, state); err != nil {
+			return state, err
+		}
+	}
+
+	if state.Status == StatusCompleted {
+		return state, nil
+	}
+
+	// Compensation must finish even if the caller or Compensate cancelled ctx
+	ctx = context.WithoutCancel(ctx)
+	if err := o.save(ctx, state); err != nil {
+		return state, err
+	}
+
+	for state.Step > 0 {
+		step := &def.Steps[state.Step-1]
+		if step.Compensate != nil {
+			if err := step.run(ctx, step.Compensate, state); err != nil {
+				state.Status = StatusFailed
+				state.Error = fmt.Sprintf("%s; compensate %s: %v", state.Error, step.Name, err)
+				if err := o.save(ctx, state); err != nil {
+					return state, err
+				}
+				return state, fmt.Errorf("%w: %s", ErrCompensationFailed, state.Error)
+			}
+		}
+
+		state.Step--
+		if state.Step == 0 {
+			state.Status = StatusCompensated
+		}
+		if err := o.save(ctx, state); err != nil {
+			return state, err
+		}
+	}
+
+	if state.Status != StatusCompensated {
+		// Failed on the first step, nothing to undo
+		state.Status = StatusCompensated
+		if err := o.save(ctx, state); err != nil {
+			return state, err
+		}
+	}
+	return state, fmt.Errorf("%w: %s", ErrCompensated, state.Error)
+}
+
+// save persists state and publishes the status when it changed
+func (o *Orchestrator) save(ctx context.Context, state *State) error {
+	prev := state.UpdatedAt
+	state.UpdatedAt = now()
+	if err := o.store.Update(ctx, state); err != nil {
+		state.UpdatedAt = prev
+		return fmt.Errorf("failed to persist saga %s: %v", state.ID, err)
+	}
+	if state.Status == state.published {
+		return nil
+	}
+	state.published = state.Status
+	if o.publish != nil {
+		o.publish("saga."+state.Saga+"."+string(state.Status), &Event{
+			ID:     state.ID,
+			Saga:   state.Saga,
+			Status: state.Status,
+			Step:   state.Step,
+			Error:  state.Error,
+		})
+	}
+	return nil
+}
+
+// lookup returns a registered saga
+func (o *Orchestrator) lookup(name string) (*Saga, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	def, ok := o.sagas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSaga, name)
+	}
+	return def, nil
+}
+
+// report passes background failures to OnError
+func (o *Orchestrator) report(id string, err error) {
+	if o.onError != nil {
+		o.onError(id, err)
+	}
+}
+
+// eventSaga extracts the saga ID and failure reason from event data
+func eventSaga(data any) (string, string) {
+	switch v := data.(type) {
+	case string:
+		return v, "failed"
+	case Event:
+		return v.ID, v.Error
+	case *Event:
+		if v != nil {
+			return v.ID, v.Error
+		}
+	case map[string]any:
+		id, _ := v["saga_id"].(string)
+		reason, _ := v["error"].(string)
+		return id, reason
+	}
+	return "", ""
+}
+
+// newID returns a random saga ID
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// now returns the current UTC time
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,131 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a saga state does not exist
+	ErrNotFound = errors.New("saga: not found")
+	// ErrUnknownSaga is returned when a saga name is not registered
+	ErrUnknownSaga = errors.New("saga: unknown saga")
+	// ErrAborted is the cause of a running saga cancelled by Compensate
+	ErrAborted = errors.New("saga: aborted")
+)
+
+// Status is the lifecycle state of a saga instance
+type Status string
+
+const (
+	StatusRunning      Status = "running"
+	StatusCompleted    Status = "completed"
+	StatusCompensating Status = "compensating"
+	StatusCompensated  Status = "compensated"
+	StatusFailed       Status = "failed" // Compensation failed, needs manual repair
+)
+
+// Done reports whether the status is final
+func (s Status) Done() bool {
+	return s == StatusCompleted || s == StatusCompensated || s == StatusFailed
+}
+
+// StepFunc runs or compensates a step. Changes to state.Data are persisted.
+type StepFunc func(ctx context.Context, state *State) error
+
+// Step is one local transaction of a saga and the action that undoes it
+type Step struct {
+	Name       string
+	Action     StepFunc
+	Compensate StepFunc      // Optional, nil for steps with nothing to undo
+	Timeout    time.Duration // Per attempt, 0 for no timeout
+	Retries    int           // Extra attempts after the first failure
+	Backoff    time.Duration // Delay before the first retry, doubled on each retry, defaults to 100ms
+}
+
+// Saga is a named sequence of steps
+type Saga struct {
+	Name  string
+	Steps []Step
+}
+
+// New creates a saga definition
+func New(name string, steps ...Step) *Saga {
+	return &Saga{Name: name, Steps: steps}
+}
+
+// State is the persisted progress of a saga instance.
+// Step is the number of completed steps, and counts down while compensating.
+type State struct {
+	ID        string         `json:"id"`
+	Saga      string         `json:"saga"`
+	Status    Status         `json:"status"`
+	Step      int            `json:"step"`
+	Data      map[string]any `json:"data,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	published Status // Last status published by this process
+}
+
+// validate checks a definition before registration
+func (s *Saga) validate() error {
+	if s == nil || s.Name == "" {
+		return errors.New("saga name is required")
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("saga %s has no steps", s.Name)
+	}
+	for i, step := range s.Steps {
+		if step.Action == nil {
+			return fmt.Errorf("saga %s step %d (%s) has no action", s.Name, i, step.Name)
+		}
+	}
+	return nil
+}
+
+// run calls fn with the step's timeout and retry policy
+func (st *Step) run(ctx context.Context, fn StepFunc, state *State) error {
+	backoff := st.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	var err error
+	for attempt := 0; attempt <= st.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, context.Cause(ctx))
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = st.attempt(ctx, fn, state); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.Join(err, context.Cause(ctx))
+		}
+	}
+	return err
+}
+
+// attempt runs fn once, recovering panics
+func (st *Step) attempt(ctx context.Context, fn StepFunc, state *State) (err error) {
+	if st.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, st.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, state)
+}
@@ -0,0 +1,184 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	calls  []string
+	events []string
+}
+
+func (r *recorder) step(name string, err error) StepFunc {
+	return func(ctx context.Context, state *State) error {
+		r.mu.Lock()
+		r.calls = append(r.calls, name)
+		r.mu.Unlock()
+		state.Data[name] = true
+		return err
+	}
+}
+
+func (r *recorder) publish(eventName string, _ any) {
+	r.mu.Lock()
+	r.events = append(r.events, eventName)
+	r.mu.Unlock()
+}
+
+func TestSagaCompletes(t *testing.T) {
+	rec := &recorder{}
+	o := NewOrchestrator(Options{Publish: rec.publish})
+	if err := o.Register(New("order",
+		Step{Name: "reserve", Action: rec.step("reserve", nil), Compensate: rec.step("release", nil)},
+		Step{Name: "charge", Action: rec.step("charge", nil), Compensate: rec.step("refund", nil)},
+	)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	state, err := o.Start(context.Background(), "order", nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if state.Status != StatusCompleted || state.Step != 2 || state.Data["charge"] != true {
+		t.Errorf("unexpected state %+v", state)
+	}
+	if !slices.Equal(rec.events, []string{"saga.order.completed"}) {
+		t.Errorf("events = %v", rec.events)
+	}
+}
+
+func TestSagaCompensatesInReverse(t *testing.T) {
+	rec := &recorder{}
+	store := NewMemoryStore()
+	o := NewOrchestrator(Options{Store: store, Publish: rec.publish})
+	_ = o.Register(New("order",
+		Step{Name: "reserve", Action: rec.step("reserve", nil), Compensate: rec.step("release", nil)},
+		Step{Name: "notify", Action: rec.step("notify", nil)},
+		Step{Name: "charge", Action: rec.step("charge", errors.New("card declined")), Retries: 1, Backoff: time.Millisecond},
+	))
+
+	state, err := o.Start(context.Background(), "order", nil)
+	if !errors.Is(err, ErrCompensated) {
+		t.Fatalf("expected ErrCompensated, got %v", err)
+	}
+	if want := []string{"reserve", "notify", "charge", "charge", "release"}; !slices.Equal(rec.calls, want) {
+		t.Errorf("calls = %v, want %v", rec.calls, want)
+	}
+	if want := []string{"saga.order.compensating", "saga.order.compensated"}; !slices.Equal(rec.events, want) {
+		t.Errorf("events = %v, want %v", rec.events, want)
+	}
+
+	stored, err := store.Load(context.Background(), state.ID)
+	if err != nil || stored.Status != StatusCompensated || stored.Step != 0 || stored.Error == "" {
+		t.Errorf("stored state = %+v, %v", stored, err)
+	}
+}
+
+func TestSagaCompensationFailure(t *testing.T) {
+	rec := &recorder{}
+	o := NewOrchestrator(Options{})
+	_ = o.Register(New("order",
+		Step{Name: "reserve", Action: rec.step("reserve", nil), Compensate: rec.step("release", errors.New("down"))},
+		Step{Name: "charge", Action: rec.step("charge", errors.New("declined"))},
+	))
+
+	state, err := o.Start(context.Background(), "order", nil)
+	if !errors.Is(err, ErrCompensationFailed) || state.Status != StatusFailed || state.Step != 1 {
+		t.Errorf("got %+v, %v", state, err)
+	}
+}
+
+func TestStepTimeout(t *testing.T) {
+	o := NewOrchestrator(Options{})
+	_ = o.Register(New("slow", Step{
+		Name:    "wait",
+		Timeout: 10 * time.Millisecond,
+		Action: func(ctx context.Context, _ *State) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+
+	state, err := o.Start(context.Background(), "slow", nil)
+	if !errors.Is(err, ErrCompensated) || state.Status != StatusCompensated {
+		t.Errorf("got %+v, %v", state, err)
+	}
+}
+
+func TestResumeAndListen(t *testing.T) {
+	rec := &recorder{}
+	store := NewMemoryStore()
+	o := NewOrchestrator(Options{Store: store})
+	_ = o.Register(New("order",
+		Step{Name: "reserve", Action: rec.step("reserve", nil), Compensate: rec.step("release", nil)},
+		Step{Name: "charge", Action: rec.step("charge", nil), Compensate: rec.step("refund", nil)},
+	))
+
+	// A crash after the first step leaves a running state behind
+	ctx := context.Background()
+	if err := store.Create(ctx, &State{ID: "s1", Saga: "order", Status: StatusRunning, Step: 1, Data: map[string]any{}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := o.Recover(ctx); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if !slices.Equal(rec.calls, []string{"charge"}) {
+		t.Fatalf("calls after recover = %v", rec.calls)
+	}
+
+	var handler func(any)
+	o.Listen(func(_ string, h func(any)) { handler = h }, "shipping.failed")
+	handler(map[string]any{"saga_id": "s1", "error": "no courier"})
+
+	state, _ := store.Load(ctx, "s1")
+	if state.Status != StatusCompensated {
+		t.Errorf("status = %s, want compensated", state.Status)
+	}
+	if want := []string{"charge", "refund", "release"}; !slices.Equal(rec.calls, want) {
+		t.Errorf("calls = %v, want %v", rec.calls, want)
+	}
+}
+
+func TestCompensateRunningSaga(t *testing.T) {
+	started := make(chan struct{})
+	rec := &recorder{}
+	o := NewOrchestrator(Options{})
+	_ = o.Register(New("order",
+		Step{Name: "reserve", Action: rec.step("reserve", nil), Compensate: rec.step("release", nil)},
+		Step{Name: "wait", Action: func(ctx context.Context, _ *State) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	))
+
+	done := make(chan *State)
+	go func() {
+		state, _ := o.Start(context.Background(), "order", nil)
+		done <- state
+	}()
+	<-started
+
+	o.mu.Lock()
+	var id string
+	for id = range o.running {
+	}
+	o.mu.Unlock()
+
+	if _, err := o.Compensate(context.Background(), id, errors.New("cancelled by user")); err != nil {
+		t.Fatalf("Compensate: %v", err)
+	}
+	state := <-done
+	if state.Status != StatusCompensated || state.Error != "cancelled by user" {
+		t.Errorf("unexpected state %+v", state)
+	}
+	if !slices.Contains(rec.calls, "release") {
+		t.Errorf("calls = %v, expected release", rec.calls)
+	}
+}
@@ -0,0 +1,189 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// SQLOptions configures a SQL store
+type SQLOptions struct {
+	Table  string // Defaults to "saga_states"
+	Driver string // "postgres" and "pgx" use $n placeholders, others use ?
+}
+
+// SQLStore persists saga states in a table on the master database
+type SQLStore struct {
+	d        *data.Data
+	table    string
+	postgres bool
+}
+
+// NewSQLStore creates a SQL store on d
+func NewSQLStore(d *data.Data, opts SQLOptions) (*SQLStore, error) {
+	if d == nil {
+		return nil, fmt.Errorf("data layer is nil")
+	}
+	if opts.Table == "" {
+		opts.Table = "saga_states"
+	}
+	if !sqlq.ValidIdentifier(opts.Table) {
+		return nil, fmt.Errorf("invalid table name: %s", opts.Table)
+	}
+
+	return &SQLStore{
+		d:        d,
+		table:    opts.Table,
+		postgres: opts.Driver == "postgres" || opts.Driver == "pgx",
+	}, nil
+}
+
+// Migrate creates the saga table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	db := s.d.GetMasterDB()
+	if db == nil {
+		return errors.New("database connection is nil")
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(64) PRIMARY KEY,
+			saga VARCHAR(255) NOT NULL,
+			status VARCHAR(32) NOT NULL,
+			step INTEGER NOT NULL,
+			data TEXT,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`, s.table)); err != nil {
+		return fmt.Errorf("failed to create saga table: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS idx_%s_status ON %s (status)",
+		strings.ReplaceAll(s.table, ".", "_"), s.table)); err != nil {
+		return fmt.Errorf("failed to create saga index: %v", err)
+	}
+	return nil
+}
+
+// Create inserts a new state row
+func (s *SQLStore) Create(ctx context.Context, state *State) error {
+	db := s.d.GetMasterDB()
+	if db == nil {
+		return errors.New("database connection is nil")
+	}
+
+	payload, err := json.Marshal(state.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saga data %s: %v", state.ID, err)
+	}
+
+	if _, err := db.ExecContext(ctx, s.rebind(fmt.Sprintf(
+		"INSERT INTO %s (id, saga, status, step, data, error, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", s.table)),
+		state.ID, state.Saga, string(state.Status), state.Step, string(payload), state.Error,
+		state.CreatedAt.UTC(), state.UpdatedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to create saga %s: %v", state.ID, err)
+	}
+	return nil
+}
+
+// Update writes the progress of an existing state
+func (s *SQLStore) Update(ctx context.Context, state *State) error {
+	db := s.d.GetMasterDB()
+	if db == nil {
+		return errors.New("database connection is nil")
+	}
+
+	payload, err := json.Marshal(state.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saga data %s: %v", state.ID, err)
+	}
+
+	if _, err := db.ExecContext(ctx, s.rebind(fmt.Sprintf(
+		"UPDATE %s SET status = ?, step = ?, data = ?, error = ?, updated_at = ? WHERE id = ?", s.table)),
+		string(state.Status), state.Step, string(payload), state.Error, state.UpdatedAt.UTC(), state.ID); err != nil {
+		return fmt.Errorf("failed to update saga %s: %v", state.ID, err)
+	}
+	return nil
+}
+
+// Load returns a state by ID
+func (s *SQLStore) Load(ctx context.Context, id string) (*State, error) {
+	db := s.d.GetMasterDB()
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+
+	row := db.QueryRowContext(ctx, s.rebind(fmt.Sprintf(
+		"SELECT id, saga, status, step, data, error, created_at, updated_at FROM %s WHERE id = ?", s.table)), id)
+	state, err := scanState(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return state, err
+}
+
+// Pending returns states that are running or compensating
+func (s *SQLStore) Pending(ctx context.Context) ([]*State, error) {
+	db := s.d.GetMasterDB()
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+
+	rows, err := db.QueryContext(ctx, s.rebind(fmt.Sprintf(
+		"SELECT id, saga, status, step, data, error, created_at, updated_at FROM %s WHERE status IN (?, ?) ORDER BY created_at",
+		s.table)), string(StatusRunning), string(StatusCompensating))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending sagas: %v", err)
+	}
+	defer rows.Close()
+
+	var states []*State
+	for rows.Next() {
+		state, err := scanState(rows)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// scanState reads one state row
+func scanState(row interface{ Scan(dest ...any) error }) (*State, error) {
+	var (
+		state    State
+		status   string
+		payload  sql.NullString
+		stateErr sql.NullString
+	)
+	if err := row.Scan(&state.ID, &state.Saga, &status, &state.Step, &payload, &stateErr,
+		&state.CreatedAt, &state.UpdatedAt); err != nil {
+		return nil, err
+	}
+	state.Status = Status(status)
+	state.Error = stateErr.String
+	if payload.Valid && payload.String != "" {
+		if err := json.Unmarshal([]byte(payload.String), &state.Data); err != nil {
+			return nil, fmt.Errorf("invalid saga data for %s: %v", state.ID, err)
+		}
+	}
+	return &state, nil
+}
+
+// rebind converts ? placeholders to $n for Postgres
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
+
+var _ Store = (*SQLStore)(nil)
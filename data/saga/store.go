@@ -0,0 +1,93 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Store persists saga states
+type Store interface {
+	// Create inserts a new state
+	Create(ctx context.Context, state *State) error
+	// Update writes the progress of an existing state
+	Update(ctx context.Context, state *State) error
+	// Load returns a state by ID, or ErrNotFound
+	Load(ctx context.Context, id string) (*State, error)
+	// Pending returns states that are running or compensating
+	Pending(ctx context.Context) ([]*State, error)
+}
+
+// MemoryStore keeps states in memory, for tests and single process use
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+// NewMemoryStore creates an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string][]byte)}
+}
+
+// Create stores a copy of a new state
+func (m *MemoryStore) Create(_ context.Context, state *State) error {
+	return m.put(state, false)
+}
+
+// Update replaces the stored copy of state
+func (m *MemoryStore) Update(_ context.Context, state *State) error {
+	return m.put(state, true)
+}
+
+// put stores a copy of state, exists selects update or insert semantics
+func (m *MemoryStore) put(state *State, exists bool) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.states[state.ID]; ok != exists {
+		if exists {
+			return ErrNotFound
+		}
+		return fmt.Errorf("saga %s already exists", state.ID)
+	}
+	m.states[state.ID] = b
+	return nil
+}
+
+// Load returns a copy of the stored state
+func (m *MemoryStore) Load(_ context.Context, id string) (*State, error) {
+	m.mu.Lock()
+	b, ok := m.states[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var state State
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Pending returns copies of all unfinished states
+func (m *MemoryStore) Pending(_ context.Context) ([]*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var states []*State
+	for _, b := range m.states {
+		var state State
+		if err := json.Unmarshal(b, &state); err != nil {
+			return nil, err
+		}
+		if !state.Status.Done() {
+			states = append(states, &state)
+		}
+	}
+	return states, nil
+}
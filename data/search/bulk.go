@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BatchController sizes bulk requests from the outcome of earlier ones,
+// batch.Controller from the concurrency module implements it
+type BatchController interface {
+	Size() int
+	Parallelism() int
+	Observe(items int, latency time.Duration, err error)
+}
+
+// BulkIndexAdaptive indexes documents with BulkIndex in chunks of ctrl.Size(),
+// running ctrl.Parallelism() chunks at a time and reporting every chunk back to
+// ctrl. Chunks are sized again before each round, so a long import speeds up
+// while the engine keeps up and backs off when it slows down or rejects
+// requests. It stops after the first round with a failed chunk; the chunks
+// indexed before stay indexed. Share ctrl between calls for the same engine.
+func (c *Client) BulkIndexAdaptive(ctx context.Context, index string, documents []any, ctrl BatchController) error {
+	for start := 0; start < len(documents); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size, parallelism := max(ctrl.Size(), 1), max(ctrl.Parallelism(), 1)
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+		for range parallelism {
+			if start >= len(documents) {
+				break
+			}
+			end := min(start+size, len(documents))
+			chunk := documents[start:end:end]
+			start = end
+
+			wg.Go(func() {
+				begin := time.Now()
+				err := c.BulkIndex(ctx, index, chunk)
+				ctrl.Observe(len(chunk), time.Since(begin), err)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+	return nil
+}
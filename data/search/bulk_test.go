@@ -0,0 +1,75 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bulkAdapter records bulk request sizes and rejects those above limit
+type bulkAdapter struct {
+	*fakeAdapter
+	limit int
+
+	mu     sync.Mutex
+	chunks []int
+}
+
+func (a *bulkAdapter) BulkIndex(_ context.Context, _ string, docs []any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.chunks = append(a.chunks, len(docs))
+	if len(docs) > a.limit {
+		return errors.New("request too large")
+	}
+	return nil
+}
+
+// stepController doubles the size after a success and halves it after a failure
+type stepController struct {
+	mu       sync.Mutex
+	size     int
+	observed int
+}
+
+func (c *stepController) Size() int        { c.mu.Lock(); defer c.mu.Unlock(); return c.size }
+func (c *stepController) Parallelism() int { return 2 }
+
+func (c *stepController) Observe(items int, _ time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observed += items
+	if err != nil {
+		c.size = max(c.size/2, 1)
+	} else {
+		c.size *= 2
+	}
+}
+
+func TestBulkIndexAdaptive(t *testing.T) {
+	adapter := &bulkAdapter{fakeAdapter: newFakeAdapter(Meilisearch), limit: 100}
+	c := NewClient(nil, adapter)
+	defer c.Close()
+
+	ctrl := &stepController{size: 5}
+	docs := make([]any, 70)
+	if err := c.BulkIndexAdaptive(context.Background(), "docs", docs, ctrl); err != nil {
+		t.Fatal(err)
+	}
+	// Rounds of two chunks: 5+5, 20+20, then the 20 left
+	if len(adapter.chunks) != 5 || ctrl.observed != 70 {
+		t.Fatalf("chunks = %v, observed = %d", adapter.chunks, ctrl.observed)
+	}
+
+	adapter.chunks = nil
+	ctrl.size = 150
+	err := c.BulkIndexAdaptive(context.Background(), "docs", make([]any, 400), ctrl)
+	if err == nil || len(adapter.chunks) != 2 {
+		t.Fatalf("err = %v, chunks = %v, want the first round to fail", err, adapter.chunks)
+	}
+	if ctrl.Size() != 37 {
+		t.Fatalf("size after failures = %d, want 37", ctrl.Size())
+	}
+}
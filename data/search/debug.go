@@ -0,0 +1,100 @@
+package search
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Debug is the trace of a search run with Request.Debug, to diagnose relevance
+// and latency issues
+type Debug struct {
+	Engine  Engine          `json:"engine"`
+	Index   string          `json:"index"`           // Index searched, with its prefix
+	Query   json.RawMessage `json:"query,omitempty"` // Raw query sent to the engine
+	Timings Timings         `json:"timings"`
+	Profile json.RawMessage `json:"profile,omitempty"` // Profile reported by the engine
+}
+
+// Timings are the durations of the phases of a search
+type Timings struct {
+	Build     time.Duration `json:"build"`     // Building the engine query from the Request
+	Serialize time.Duration `json:"serialize"` // Encoding the engine query
+	Execute   time.Duration `json:"execute"`   // Engine round trip
+	Parse     time.Duration `json:"parse"`     // Decoding the engine response
+	Total     time.Duration `json:"total"`     // Whole search as seen by the client
+}
+
+// Trace records the Debug of a search in adapters. StartTrace returns nil unless
+// the request is debugged, and the methods of a nil Trace do nothing.
+type Trace struct {
+	debug *Debug
+	mark  time.Time
+}
+
+// StartTrace starts tracing req if req.Debug is set, timing the build phase
+func StartTrace(req *Request) *Trace {
+	if !req.Debug {
+		return nil
+	}
+	return &Trace{debug: &Debug{Index: req.Index}, mark: time.Now()}
+}
+
+// lap returns the time since the previous phase ended
+func (t *Trace) lap() time.Duration {
+	now := time.Now()
+	d := now.Sub(t.mark)
+	t.mark = now
+	return d
+}
+
+// Built ends the build phase
+func (t *Trace) Built() {
+	if t != nil {
+		t.debug.Timings.Build = t.lap()
+	}
+}
+
+// Serialized ends the serialize phase, recording the raw JSON query
+func (t *Trace) Serialized(query []byte) {
+	if t != nil {
+		t.debug.Timings.Serialize = t.lap()
+		t.debug.Query = append(json.RawMessage(nil), query...)
+	}
+}
+
+// Executed ends the execute phase
+func (t *Trace) Executed() {
+	if t != nil {
+		t.debug.Timings.Execute = t.lap()
+	}
+}
+
+// Parsed ends the parse phase, recording the engine's JSON profile if any
+func (t *Trace) Parsed(profile json.RawMessage) {
+	if t != nil {
+		t.debug.Timings.Parse = t.lap()
+		if len(profile) > 0 && string(profile) != "null" {
+			t.debug.Profile = profile
+		}
+	}
+}
+
+// Attach sets the trace as resp.Debug
+func (t *Trace) Attach(resp *Response) {
+	if t != nil && resp != nil {
+		resp.Debug = t.debug
+	}
+}
+
+// finishDebug completes resp.Debug of a debugged request, recording the execute
+// phase as a whole for adapters not tracing their phases
+func finishDebug(req *Request, resp *Response, engine Engine, total time.Duration) {
+	if !req.Debug || resp == nil {
+		return
+	}
+	if resp.Debug == nil {
+		resp.Debug = &Debug{Index: req.Index, Timings: Timings{Execute: total}}
+	}
+	resp.Debug.Engine = engine
+	resp.Debug.Timings.Total = total
+}
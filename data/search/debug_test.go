@@ -0,0 +1,56 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// traceAdapter traces its phases like the engine adapters
+type traceAdapter struct {
+	*fakeAdapter
+}
+
+func (a *traceAdapter) Search(_ context.Context, req *Request) (*Response, error) {
+	trace := StartTrace(req)
+	body := QueryBody(req, []string{"title"})
+	trace.Built()
+	query, _ := json.Marshal(body)
+	trace.Serialized(query)
+	trace.Executed()
+	trace.Parsed([]byte(`{"shards":[]}`))
+
+	resp := &Response{Total: 1}
+	trace.Attach(resp)
+	return resp, nil
+}
+
+func TestSearchDebug(t *testing.T) {
+	c := NewClientWithConfig(nil, &Config{IndexPrefix: "app", DefaultEngine: string(Elasticsearch)},
+		&traceAdapter{newFakeAdapter(Elasticsearch)}, newFakeAdapter(Meilisearch))
+	t.Cleanup(c.Close)
+	ctx := context.Background()
+
+	resp, err := c.Search(ctx, &Request{Index: "posts", Query: "go", Debug: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	d := resp.Debug
+	if d == nil || d.Engine != Elasticsearch || d.Index != "app-posts" || d.Timings.Total <= 0 {
+		t.Fatalf("debug = %+v", d)
+	}
+	if !strings.Contains(string(d.Query), `"profile":true`) || string(d.Profile) != `{"shards":[]}` {
+		t.Fatalf("query = %s, profile = %s", d.Query, d.Profile)
+	}
+
+	// Adapters not tracing report the engine round trip as a whole
+	resp, _ = c.SearchWith(ctx, Meilisearch, &Request{Index: "posts", Debug: true})
+	if d := resp.Debug; d == nil || d.Engine != Meilisearch || d.Timings.Execute != d.Timings.Total {
+		t.Fatalf("untraced debug = %+v", d)
+	}
+
+	if resp, _ = c.Search(ctx, &Request{Index: "posts"}); resp.Debug != nil {
+		t.Fatalf("debug without Request.Debug = %+v", resp.Debug)
+	}
+}
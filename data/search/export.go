@@ -0,0 +1,177 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"strconv"
+	"time"
+
+	"github.com/ncobase/ncore/bytespool"
+)
+
+// DefaultExportBatchSize is the number of documents fetched per request when exporting
+const DefaultExportBatchSize = 500
+
+// exportPool holds the point in time request bodies of running exports
+var exportPool = bytespool.New("search.export", 0)
+
+// errExportStopped aborts an export whose consumer stopped iterating
+var errExportStopped = errors.New("export stopped")
+
+// Exporter is an optional Adapter extension streaming every document matching a
+// request in batches of req.Size, beyond the engine's pagination window
+type Exporter interface {
+	Export(ctx context.Context, req *Request, fn func([]Hit) error) error
+}
+
+// PIT references a point in time in a search body
+type PIT struct {
+	ID        string `json:"id"`
+	KeepAlive string `json:"keep_alive"`
+}
+
+// PITPage is one page read from a point in time
+type PITPage struct {
+	PitID       string // Updated point in time ID, empty if unchanged
+	Hits        []Hit
+	SearchAfter []any // Sort values of the last hit
+}
+
+// PointInTime is implemented by Elasticsearch and OpenSearch adapters to export
+// with ExportPointInTime
+type PointInTime interface {
+	OpenPointInTime(ctx context.Context, index string, keepAlive time.Duration) (string, error)
+	SearchPointInTime(ctx context.Context, body []byte) (*PITPage, error)
+	ClosePointInTime(ctx context.Context, id string) error
+}
+
+// Export streams every document of index matching query, in batches of query.Size
+// (DefaultExportBatchSize when 0). Elasticsearch and OpenSearch read a point in time
+// with search_after, other engines page with From and Size, which Meilisearch bounds
+// by the index's maxTotalHits. query.From is ignored. The engine is chosen when the
+// export starts and does not fail over while streaming.
+func (c *Client) Export(ctx context.Context, index string, query *Request) iter.Seq2[Hit, error] {
+	return func(yield func(Hit, error) bool) {
+		adapter, err := c.getAdapter()
+		if err != nil {
+			yield(Hit{}, err)
+			return
+		}
+
+		var req Request
+		if query != nil {
+			req = *query
+		}
+		req.Index = c.buildIndexName(index)
+		c.tune(ctx, index, &req)
+		req.From = 0
+		if req.Size <= 0 {
+			req.Size = DefaultExportBatchSize
+		}
+
+		emit := func(hits []Hit) error {
+			for _, hit := range hits {
+				if !yield(hit, nil) {
+					return errExportStopped
+				}
+			}
+			return nil
+		}
+
+		if e, ok := adapter.(Exporter); ok {
+			err = e.Export(ctx, &req, emit)
+		} else {
+			err = exportPages(ctx, adapter, &req, emit)
+		}
+
+		if errors.Is(err, errExportStopped) {
+			err = nil
+		}
+		c.collector.SearchQuery(string(adapter.Type()), err)
+		if err != nil {
+			yield(Hit{}, err)
+		}
+	}
+}
+
+// exportPages exports by paging through search results
+func exportPages(ctx context.Context, adapter Adapter, req *Request, fn func([]Hit) error) error {
+	page := *req
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		resp, err := adapter.Search(ctx, &page)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp.Hits); err != nil {
+			return err
+		}
+		if len(resp.Hits) < page.Size {
+			return nil
+		}
+		page.From += len(resp.Hits)
+	}
+}
+
+// keepAliveParam formats a keep alive duration as seconds, e.g. "60s"
+func keepAliveParam(d time.Duration) string {
+	return strconv.Itoa(int(d.Seconds())) + "s"
+}
+
+// ExportPointInTime exports req from a point in time kept alive for keepAlive
+// between pages, paging with search_after. tiebreaker is appended to the sort to
+// order documents with equal sort values. The body passed to SearchPointInTime
+// is reused for the next page and must not be retained.
+func ExportPointInTime(ctx context.Context, p PointInTime, req *Request, fields []string, tiebreaker string, keepAlive time.Duration, fn func([]Hit) error) error {
+	id, err := p.OpenPointInTime(ctx, req.Index, keepAlive)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Release the point in time even if ctx is done
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = p.ClosePointInTime(closeCtx, id)
+	}()
+
+	body := QueryBody(req, fields)
+	body.From = 0
+	body.Highlight = nil
+	body.Sort = append(body.Sort, map[string]string{tiebreaker: "asc"})
+
+	// The body of every page is encoded into the same buffer
+	buf := exportPool.Get()
+	defer exportPool.Put(buf)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		body.PIT = &PIT{ID: id, KeepAlive: keepAliveParam(keepAlive)}
+		buf.Reset()
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return err
+		}
+
+		page, err := p.SearchPointInTime(ctx, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if page.PitID != "" {
+			id = page.PitID
+		}
+		if err := fn(page.Hits); err != nil {
+			return err
+		}
+		if len(page.Hits) < req.Size || len(page.SearchAfter) == 0 {
+			return nil
+		}
+		body.SearchAfter = page.SearchAfter
+	}
+}
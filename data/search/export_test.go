@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pagedAdapter serves n documents by From and Size
+type pagedAdapter struct {
+	*fakeAdapter
+	docs     int
+	requests int
+}
+
+func (a *pagedAdapter) Search(_ context.Context, req *Request) (*Response, error) {
+	a.requests++
+	var hits []Hit
+	for i := req.From; i < req.From+req.Size && i < a.docs; i++ {
+		hits = append(hits, Hit{ID: fmt.Sprint(i)})
+	}
+	return &Response{Total: int64(a.docs), Hits: hits}, nil
+}
+
+func TestExportPages(t *testing.T) {
+	adapter := &pagedAdapter{fakeAdapter: newFakeAdapter(Meilisearch), docs: 25}
+	c := NewClient(nil, adapter)
+	defer c.Close()
+
+	var ids []string
+	for hit, err := range c.Export(context.Background(), "docs", &Request{Size: 10, From: 7}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, hit.ID)
+	}
+	if len(ids) != 25 || ids[0] != "0" || ids[24] != "24" {
+		t.Fatalf("exported %v", ids)
+	}
+	if adapter.requests != 3 {
+		t.Fatalf("requests = %d, want 3", adapter.requests)
+	}
+}
+
+func TestExportStopsEarly(t *testing.T) {
+	adapter := &pagedAdapter{fakeAdapter: newFakeAdapter(Meilisearch), docs: 100}
+	c := NewClient(nil, adapter)
+	defer c.Close()
+
+	n := 0
+	for _, err := range c.Export(context.Background(), "docs", &Request{Size: 10}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n++; n == 15 {
+			break
+		}
+	}
+	if adapter.requests != 2 {
+		t.Fatalf("requests = %d, want 2", adapter.requests)
+	}
+}
+
+// fakePIT serves n documents from a point in time
+type fakePIT struct {
+	docs   int
+	open   int
+	closed []string
+	bodies []map[string]any
+}
+
+func (p *fakePIT) OpenPointInTime(context.Context, string, time.Duration) (string, error) {
+	p.open++
+	return "pit-0", nil
+}
+
+func (p *fakePIT) SearchPointInTime(_ context.Context, body []byte) (*PITPage, error) {
+	var b map[string]any
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, err
+	}
+	p.bodies = append(p.bodies, b)
+
+	start := 0
+	if after, ok := b["search_after"].([]any); ok {
+		start = int(after[0].(float64)) + 1
+	}
+	size := int(b["size"].(float64))
+
+	page := &PITPage{PitID: fmt.Sprintf("pit-%d", len(p.bodies))}
+	for i := start; i < start+size && i < p.docs; i++ {
+		page.Hits = append(page.Hits, Hit{ID: fmt.Sprint(i)})
+		page.SearchAfter = []any{i}
+	}
+	return page, nil
+}
+
+func (p *fakePIT) ClosePointInTime(_ context.Context, id string) error {
+	p.closed = append(p.closed, id)
+	return nil
+}
+
+func TestExportPointInTime(t *testing.T) {
+	p := &fakePIT{docs: 7}
+	req := &Request{Index: "docs", Size: 3, Sort: []SortField{{Field: "created_at", Desc: true}}}
+
+	var ids []string
+	err := ExportPointInTime(context.Background(), p, req, nil, "_shard_doc", time.Minute, func(hits []Hit) error {
+		for _, h := range hits {
+			ids = append(ids, h.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 7 || ids[6] != "6" {
+		t.Fatalf("exported %v", ids)
+	}
+	if len(p.bodies) != 3 {
+		t.Fatalf("searches = %d, want 3", len(p.bodies))
+	}
+
+	pit := p.bodies[1]["pit"].(map[string]any)
+	if pit["id"] != "pit-1" || pit["keep_alive"] != "60s" {
+		t.Fatalf("pit = %v", pit)
+	}
+	sort := fmt.Sprint(p.bodies[0]["sort"])
+	if sort != "[map[created_at:desc] map[_shard_doc:asc]]" {
+		t.Fatalf("sort = %s", sort)
+	}
+	if len(p.closed) != 1 || p.closed[0] != "pit-3" {
+		t.Fatalf("closed = %v", p.closed)
+	}
+}
+
+func TestExportPointInTimeError(t *testing.T) {
+	p := &fakePIT{docs: 10}
+	stop := errors.New("stop")
+
+	err := ExportPointInTime(context.Background(), p, &Request{Size: 2}, nil, "_id", time.Minute, func([]Hit) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v", err)
+	}
+	if len(p.closed) != 1 {
+		t.Fatalf("point in time not closed")
+	}
+}
@@ -0,0 +1,445 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// Failover controls runtime switching between search engines.
+//
+// Each probe scores every engine from a moving average of its health checks, scaled
+// down when checks are slower than SlowThreshold. The current engine is failed over
+// once its score drops below MinScore, to the best scored engine, and a more
+// preferred engine is failed back to once its score reaches FailbackScore. The gap
+// between both scores keeps a flapping engine from switching back and forth.
+type Failover struct {
+	Enabled        bool
+	ErrorThreshold int           // Consecutive engine faults before failing over, default 3
+	ProbeInterval  time.Duration // Health check and fail back interval, default 30s
+
+	MinScore      float64       // Score below which the current engine is failed over, default 0.5
+	FailbackScore float64       // Score a preferred engine needs to be failed back to, default 0.9
+	SlowThreshold time.Duration // Health check latency above which scores drop, default 1s
+}
+
+// StatusError is an error status answered by a search engine. Adapters return
+// it so failover tells engine faults from rejected requests.
+type StatusError struct {
+	Engine     Engine
+	StatusCode int
+	Err        error // Error decoded from the response, if any
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s returned status %d: %v", e.Engine, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s returned status %d", e.Engine, e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// engineFault reports whether err is a fault of the engine, counted toward the
+// error threshold: transport errors, timeouts and 5xx statuses. Requests the
+// engine rejected, such as malformed queries, missing indices or other 4xx
+// statuses, fail the same on every engine and are not failed over.
+func engineFault(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// FailoverCollector is an optional Collector extension notified when the client
+// switches engines
+type FailoverCollector interface {
+	SearchFailover(from, to, reason string)
+}
+
+// EngineSwitch is the event emitted when the client switches engines
+type EngineSwitch struct {
+	From   Engine             `json:"from"`
+	To     Engine             `json:"to"`
+	Reason string             `json:"reason"`
+	At     time.Time          `json:"at"`
+	Scores map[Engine]float64 `json:"scores"`
+}
+
+// EngineHealth is the health score of an engine
+type EngineHealth struct {
+	Score     float64       `json:"score"`      // 0 unhealthy to 1 healthy
+	Latency   time.Duration `json:"latency"`    // Moving average of health check latency
+	LastError string        `json:"last_error"` // Error of the last health check, if any
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// scoreWeight is the weight of the latest health check in moving averages
+const scoreWeight = 0.5
+
+// engineScore keeps the moving averages of an engine's health checks
+type engineScore struct {
+	availability float64
+	latency      time.Duration
+	lastErr      error
+	checkedAt    time.Time
+}
+
+// score combines availability and latency
+func (s *engineScore) score(slow time.Duration) float64 {
+	if s.latency <= slow {
+		return s.availability
+	}
+	return s.availability * float64(slow) / float64(s.latency)
+}
+
+func (f *Failover) errorThreshold() int {
+	if f.ErrorThreshold <= 0 {
+		return 3
+	}
+	return f.ErrorThreshold
+}
+
+func (f *Failover) probeInterval() time.Duration {
+	if f.ProbeInterval <= 0 {
+		return 30 * time.Second
+	}
+	return f.ProbeInterval
+}
+
+func (f *Failover) minScore() float64 {
+	if f.MinScore <= 0 {
+		return 0.5
+	}
+	return f.MinScore
+}
+
+func (f *Failover) failbackScore() float64 {
+	if f.FailbackScore <= 0 {
+		return max(0.9, f.minScore())
+	}
+	return max(f.FailbackScore, f.minScore())
+}
+
+func (f *Failover) slowThreshold() time.Duration {
+	if f.SlowThreshold <= 0 {
+		return time.Second
+	}
+	return f.SlowThreshold
+}
+
+// failoverConfig returns the failover settings, nil when failover is disabled
+func (c *Client) failoverConfig() *Failover {
+	if c.searchConfig == nil || c.searchConfig.Failover == nil || !c.searchConfig.Failover.Enabled {
+		return nil
+	}
+	return c.searchConfig.Failover
+}
+
+// enginePriority returns the configured engines in selection order:
+// the default engine, then OpenSearch > Elasticsearch > Meilisearch, then any other
+func (c *Client) enginePriority() []Engine {
+	var engines []Engine
+	seen := make(map[Engine]bool, len(c.adapters))
+	add := func(eng Engine) {
+		if _, ok := c.adapters[eng]; ok && !seen[eng] {
+			seen[eng] = true
+			engines = append(engines, eng)
+		}
+	}
+
+	if c.searchConfig != nil && c.searchConfig.DefaultEngine != "" {
+		add(Engine(c.searchConfig.DefaultEngine))
+	}
+	for _, eng := range []Engine{OpenSearch, Elasticsearch, Meilisearch} {
+		add(eng)
+	}
+
+	var rest []Engine
+	for eng := range c.adapters {
+		if !seen[eng] {
+			rest = append(rest, eng)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+	return append(engines, rest...)
+}
+
+// withFailover runs op on the current engine. With failover enabled, a fault of an
+// engine that fails its health check or reaches the error threshold switches to the
+// next healthy engine and retries op there, see engineFault. Other errors are
+// returned as is.
+func (c *Client) withFailover(ctx context.Context, op func(Engine) error) error {
+	if _, err := c.getAdapter(); err != nil {
+		return err
+	}
+
+	engine := c.GetEngine()
+	err := op(engine)
+
+	f := c.failoverConfig()
+	if f == nil {
+		return err
+	}
+
+	tried := map[Engine]bool{}
+	for err != nil && ctx.Err() == nil {
+		if !engineFault(err) {
+			return err
+		}
+		tried[engine] = true
+		reason, failed := c.engineFailed(ctx, engine, f)
+		if !failed {
+			return err
+		}
+
+		next := c.failover(ctx, engine, reason, tried)
+		if next == "" {
+			return err
+		}
+		engine = next
+		err = op(engine)
+	}
+
+	if err == nil {
+		c.mu.Lock()
+		c.failures[engine] = 0
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// engineFailed records a fault of an engine and reports whether it should be
+// failed over, with the reason
+func (c *Client) engineFailed(ctx context.Context, engine Engine, f *Failover) (string, bool) {
+	c.mu.Lock()
+	c.failures[engine]++
+	failures := c.failures[engine]
+	c.mu.Unlock()
+
+	if failures >= f.errorThreshold() {
+		return "error threshold reached", true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if err := c.checkHealth(ctx, engine); err != nil {
+		return "health check failed: " + err.Error(), true
+	}
+	return "", false
+}
+
+// failover switches away from an engine to the next healthy one not yet tried
+func (c *Client) failover(ctx context.Context, from Engine, reason string, tried map[Engine]bool) Engine {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	for _, eng := range c.enginePriority() {
+		if eng == from || tried[eng] {
+			continue
+		}
+		if c.checkHealth(ctx, eng) != nil {
+			continue
+		}
+		c.switchEngine(from, eng, reason)
+		return eng
+	}
+	return ""
+}
+
+// switchEngine makes to the current engine if from still is, and emits the switch
+func (c *Client) switchEngine(from, to Engine, reason string) {
+	c.mu.Lock()
+	if c.engine != from {
+		c.mu.Unlock()
+		return
+	}
+	c.engine = to
+	c.failures[to] = 0
+	c.mu.Unlock()
+
+	if fc, ok := c.collector.(FailoverCollector); ok {
+		fc.SearchFailover(string(from), string(to), reason)
+	}
+
+	c.hooksMu.RLock()
+	hooks := c.switchHooks
+	c.hooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+	event := EngineSwitch{From: from, To: to, Reason: reason, At: time.Now(), Scores: make(map[Engine]float64)}
+	for eng, h := range c.EngineHealth() {
+		event.Scores[eng] = h.Score
+	}
+	for _, fn := range hooks {
+		fn(event)
+	}
+}
+
+// OnEngineSwitch registers fn to be called when the client switches engines
+func (c *Client) OnEngineSwitch(fn func(EngineSwitch)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.switchHooks = append(c.switchHooks, fn)
+}
+
+// checkHealth runs the health check of an engine and records it in its score
+func (c *Client) checkHealth(ctx context.Context, engine Engine) error {
+	start := time.Now()
+	err := c.adapters[engine].Health(ctx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.scores[engine]
+	if !ok {
+		s = &engineScore{availability: 1, latency: latency}
+		c.scores[engine] = s
+	}
+	up := 0.0
+	if err == nil {
+		up = 1
+	}
+	s.availability = scoreWeight*up + (1-scoreWeight)*s.availability
+	s.latency = time.Duration(scoreWeight*float64(latency) + (1-scoreWeight)*float64(s.latency))
+	s.lastErr = err
+	s.checkedAt = time.Now()
+	return err
+}
+
+// score returns the health score of an engine, 1 until it is checked
+func (c *Client) score(engine Engine, f *Failover) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s, ok := c.scores[engine]; ok {
+		return s.score(f.slowThreshold())
+	}
+	return 1
+}
+
+// EngineHealth returns the health scores of the engines checked so far by the
+// failover probe
+func (c *Client) EngineHealth() map[Engine]EngineHealth {
+	slow := time.Second
+	if f := c.failoverConfig(); f != nil {
+		slow = f.slowThreshold()
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	health := make(map[Engine]EngineHealth, len(c.scores))
+	for eng, s := range c.scores {
+		h := EngineHealth{Score: s.score(slow), Latency: s.latency, CheckedAt: s.checkedAt}
+		if s.lastErr != nil {
+			h.LastError = s.lastErr.Error()
+		}
+		health[eng] = h
+	}
+	return health
+}
+
+// startProbe starts the periodic health probe if failover is enabled, replacing
+// a running one
+func (c *Client) startProbe() {
+	c.stopProbe()
+
+	f := c.failoverConfig()
+	if f == nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.mu.Lock()
+	c.probeStop = stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(f.probeInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.probe()
+			}
+		}
+	}()
+}
+
+// stopProbe stops the periodic health probe
+func (c *Client) stopProbe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.probeStop != nil {
+		close(c.probeStop)
+		c.probeStop = nil
+	}
+}
+
+// probe scores every engine, fails back to the most preferred engine scoring at
+// least FailbackScore and fails over from a current engine scoring below MinScore
+// to the best scored one
+func (c *Client) probe() {
+	f := c.failoverConfig()
+	priority := c.enginePriority()
+	if f == nil || len(priority) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for _, eng := range priority {
+		_ = c.checkHealth(ctx, eng)
+	}
+
+	current := c.GetEngine()
+	if current == "" {
+		c.setEngine()
+		return
+	}
+
+	for _, eng := range priority {
+		if eng == current {
+			break
+		}
+		if c.score(eng, f) >= f.failbackScore() {
+			c.switchEngine(current, eng, "preferred engine recovered")
+			return
+		}
+	}
+
+	score := c.score(current, f)
+	if score >= f.minScore() {
+		return
+	}
+	best, bestScore := Engine(""), f.minScore()
+	for _, eng := range priority {
+		if s := c.score(eng, f); eng != current && s >= bestScore && (best == "" || s > bestScore) {
+			best, bestScore = eng, s
+		}
+	}
+	if best != "" {
+		c.switchEngine(current, best, fmt.Sprintf("health score %.2f below %.2f", score, f.minScore()))
+	}
+}
+
+// Close stops the background failover probe
+func (c *Client) Close() {
+	c.stopProbe()
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// errRefused is the transport error of an engine that is down
+var errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+type fakeAdapter struct {
+	engine    Engine
+	healthy   atomic.Bool
+	searchErr atomic.Value
+	searches  atomic.Int64
+	delay     atomic.Int64 // Health check latency
+	latency   atomic.Int64 // Search latency
+}
+
+func newFakeAdapter(engine Engine) *fakeAdapter {
+	a := &fakeAdapter{engine: engine}
+	a.healthy.Store(true)
+	return a
+}
+
+func (a *fakeAdapter) fail(err error) { a.searchErr.Store(&err) }
+
+func (a *fakeAdapter) Search(ctx context.Context, _ *Request) (*Response, error) {
+	a.searches.Add(1)
+	select {
+	case <-time.After(time.Duration(a.latency.Load())):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err, _ := a.searchErr.Load().(*error); err != nil && *err != nil {
+		return nil, *err
+	}
+	return &Response{Total: 1}, nil
+}
+
+func (a *fakeAdapter) Index(context.Context, *IndexRequest) error                { return nil }
+func (a *fakeAdapter) Delete(context.Context, string, string) error              { return nil }
+func (a *fakeAdapter) BulkIndex(context.Context, string, []any) error            { return nil }
+func (a *fakeAdapter) BulkDelete(context.Context, string, []string) error        { return nil }
+func (a *fakeAdapter) IndexExists(context.Context, string) (bool, error)         { return true, nil }
+func (a *fakeAdapter) CreateIndex(context.Context, string, *IndexSettings) error { return nil }
+func (a *fakeAdapter) Type() Engine                                              { return a.engine }
+
+func (a *fakeAdapter) Health(context.Context) error {
+	time.Sleep(time.Duration(a.delay.Load()))
+	if a.healthy.Load() {
+		return nil
+	}
+	return errors.New("down")
+}
+
+type failoverRecorder struct {
+	NoOpCollector
+	mu       sync.Mutex
+	switches []string
+}
+
+func (r *failoverRecorder) SearchFailover(from, to, _ string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.switches = append(r.switches, from+"->"+to)
+}
+
+func newFailoverClient(t *testing.T, failover *Failover) (*Client, *fakeAdapter, *fakeAdapter, *failoverRecorder) {
+	es, meili := newFakeAdapter(Elasticsearch), newFakeAdapter(Meilisearch)
+	rec := &failoverRecorder{}
+	c := NewClientWithConfig(rec, &Config{DefaultEngine: string(Elasticsearch), Failover: failover}, es, meili)
+	t.Cleanup(c.Close)
+	return c, es, meili, rec
+}
+
+func TestFailoverOnUnhealthyEngine(t *testing.T) {
+	c, es, meili, rec := newFailoverClient(t, &Failover{Enabled: true, ProbeInterval: time.Hour})
+
+	es.fail(errRefused)
+	es.healthy.Store(false)
+
+	if _, err := c.Search(context.Background(), &Request{Index: "posts"}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if c.GetEngine() != Meilisearch || meili.searches.Load() != 1 {
+		t.Fatalf("engine = %s, meilisearch searches = %d", c.GetEngine(), meili.searches.Load())
+	}
+	if len(rec.switches) != 1 || rec.switches[0] != "elasticsearch->meilisearch" {
+		t.Fatalf("switches = %v", rec.switches)
+	}
+}
+
+func TestFailoverAfterErrorThreshold(t *testing.T) {
+	c, es, _, _ := newFailoverClient(t, &Failover{Enabled: true, ErrorThreshold: 2, ProbeInterval: time.Hour})
+
+	// Healthy engine returning errors keeps serving until the threshold
+	es.fail(&StatusError{Engine: Elasticsearch, StatusCode: http.StatusServiceUnavailable})
+	if _, err := c.Search(context.Background(), &Request{}); err == nil {
+		t.Fatal("expected first error to be returned")
+	}
+	if c.GetEngine() != Elasticsearch {
+		t.Fatalf("failed over after one error to %s", c.GetEngine())
+	}
+
+	if _, err := c.Search(context.Background(), &Request{}); err != nil {
+		t.Fatalf("Search after threshold: %v", err)
+	}
+	if c.GetEngine() != Meilisearch {
+		t.Fatalf("engine = %s, want meilisearch", c.GetEngine())
+	}
+}
+
+func TestFailoverIgnoresRejectedRequests(t *testing.T) {
+	c, es, meili, rec := newFailoverClient(t, &Failover{Enabled: true, ErrorThreshold: 1, ProbeInterval: time.Hour})
+
+	// Rejected requests fail the same on every engine, even an unhealthy one
+	es.healthy.Store(false)
+	for _, err := range []error{
+		&StatusError{Engine: Elasticsearch, StatusCode: http.StatusBadRequest, Err: errors.New("parsing_exception")},
+		fmt.Errorf("search posts: %w", &StatusError{Engine: Elasticsearch, StatusCode: http.StatusNotFound, Err: errors.New("index_not_found_exception")}),
+		errors.New("failed to build query: unsupported value"),
+	} {
+		es.fail(err)
+		if _, got := c.Search(context.Background(), &Request{Index: "posts"}); !errors.Is(got, err) {
+			t.Fatalf("Search() = %v, want %v", got, err)
+		}
+	}
+	if c.GetEngine() != Elasticsearch || meili.searches.Load() != 0 || len(rec.switches) != 0 {
+		t.Fatalf("failed over on rejected requests to %s, switches %v", c.GetEngine(), rec.switches)
+	}
+	if _, checked := c.EngineHealth()[Elasticsearch]; checked {
+		t.Fatal("rejected request ran a health check")
+	}
+}
+
+func TestEngineFault(t *testing.T) {
+	for _, tc := range []struct {
+		err   error
+		fault bool
+	}{
+		{errRefused, true},
+		{&net.DNSError{Err: "no such host", Name: "es", IsTimeout: true}, true},
+		{fmt.Errorf("search: %w", context.DeadlineExceeded), true},
+		{fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true},
+		{&StatusError{Engine: OpenSearch, StatusCode: http.StatusBadGateway}, true},
+		{&StatusError{Engine: OpenSearch, StatusCode: http.StatusTooManyRequests}, false},
+		{&StatusError{Engine: Meilisearch, StatusCode: http.StatusNotFound}, false},
+		{context.Canceled, false},
+		{errors.New("invalid query"), false},
+	} {
+		if got := engineFault(tc.err); got != tc.fault {
+			t.Errorf("engineFault(%v) = %v, want %v", tc.err, got, tc.fault)
+		}
+	}
+}
+
+func TestFailoverDisabled(t *testing.T) {
+	c, es, meili, _ := newFailoverClient(t, nil)
+
+	es.fail(errRefused)
+	es.healthy.Store(false)
+
+	if _, err := c.Search(context.Background(), &Request{}); err == nil {
+		t.Fatal("expected error without failover")
+	}
+	if meili.searches.Load() != 0 {
+		t.Fatal("request retried without failover")
+	}
+}
+
+func TestProbeFailsBack(t *testing.T) {
+	c, es, _, rec := newFailoverClient(t, &Failover{Enabled: true, ProbeInterval: 10 * time.Millisecond})
+
+	es.healthy.Store(false)
+	deadline := time.Now().Add(time.Second)
+	for c.GetEngine() != Meilisearch && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if c.GetEngine() != Meilisearch {
+		t.Fatal("probe did not fail over from unhealthy engine")
+	}
+
+	es.healthy.Store(true)
+	for c.GetEngine() != Elasticsearch && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if c.GetEngine() != Elasticsearch {
+		t.Fatal("probe did not fail back to preferred engine")
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.switches) != 2 {
+		t.Fatalf("switches = %v", rec.switches)
+	}
+}
+
+func TestProbeHysteresis(t *testing.T) {
+	c, es, _, _ := newFailoverClient(t, &Failover{Enabled: true, ProbeInterval: time.Hour})
+	var events []EngineSwitch
+	c.OnEngineSwitch(func(e EngineSwitch) { events = append(events, e) })
+
+	// A single failed check of a healthy engine is tolerated
+	es.healthy.Store(false)
+	c.probe()
+	if c.GetEngine() != Elasticsearch {
+		t.Fatalf("failed over after one failed probe to %s", c.GetEngine())
+	}
+	c.probe()
+	if c.GetEngine() != Meilisearch || len(events) != 1 {
+		t.Fatalf("engine = %s, events = %v", c.GetEngine(), events)
+	}
+	if e := events[0]; e.From != Elasticsearch || e.To != Meilisearch || e.Scores[Elasticsearch] != 0.25 {
+		t.Fatalf("event = %+v", e)
+	}
+
+	// A flapping engine is not failed back to until it stays healthy
+	for _, healthy := range []bool{true, false, true, true} {
+		es.healthy.Store(healthy)
+		c.probe()
+		if c.GetEngine() != Meilisearch {
+			t.Fatalf("failed back to a flapping engine, score %.3f", c.EngineHealth()[Elasticsearch].Score)
+		}
+	}
+	c.probe()
+	if c.GetEngine() != Elasticsearch || len(events) != 2 || events[1].Reason != "preferred engine recovered" {
+		t.Fatalf("engine = %s, events = %v", c.GetEngine(), events)
+	}
+}
+
+func TestProbeSlowEngine(t *testing.T) {
+	c, es, _, _ := newFailoverClient(t, &Failover{Enabled: true, ProbeInterval: time.Hour, SlowThreshold: time.Millisecond})
+
+	es.delay.Store(int64(5 * time.Millisecond))
+	for i := 0; i < 3 && c.GetEngine() == Elasticsearch; i++ {
+		c.probe()
+	}
+	if c.GetEngine() != Meilisearch {
+		t.Fatalf("engine = %s, health %+v", c.GetEngine(), c.EngineHealth())
+	}
+	if h := c.EngineHealth()[Elasticsearch]; h.LastError != "" || h.Latency < time.Millisecond {
+		t.Fatalf("slow engine health = %+v", h)
+	}
+}
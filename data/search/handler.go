@@ -0,0 +1,89 @@
+package search
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ecode"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// RegisterRelevanceRoutes mounts the relevance settings API of c's indexes:
+//
+//	GET  /:index                      current settings and versions
+//	PUT  /:index                      apply a Relevance as the next version
+//	POST /:index/rollback/:version    reapply a previous version
+//	POST /:index/explain              dry run a Request with score breakdowns
+//
+// Mount it on a group behind authorization.
+func (c *Client) RegisterRelevanceRoutes(g *gin.RouterGroup) {
+	g.GET("/:index", func(ctx *gin.Context) {
+		versions, err := c.RelevanceVersions(ctx.Request.Context(), ctx.Param("index"))
+		if err != nil {
+			resp.Fail(ctx.Writer, resp.InternalServer(err.Error()))
+			return
+		}
+		var current *Relevance
+		if n := len(versions); n > 0 {
+			current = versions[n-1]
+		}
+		resp.Success(ctx.Writer, map[string]any{"current": current, "versions": versions})
+	})
+
+	g.PUT("/:index", func(ctx *gin.Context) {
+		var rel Relevance
+		if err := ctx.ShouldBindJSON(&rel); err != nil {
+			resp.Fail(ctx.Writer, resp.BadRequest(err.Error()))
+			return
+		}
+		applied, err := c.SetRelevance(ctx.Request.Context(), ctx.Param("index"), rel)
+		if err != nil {
+			resp.Fail(ctx.Writer, resp.BadRequest(err.Error()))
+			return
+		}
+		resp.Success(ctx.Writer, applied)
+	})
+
+	g.POST("/:index/rollback/:version", func(ctx *gin.Context) {
+		version, err := strconv.Atoi(ctx.Param("version"))
+		if err != nil {
+			resp.Fail(ctx.Writer, resp.BadRequest(err.Error()))
+			return
+		}
+		applied, err := c.RollbackRelevance(ctx.Request.Context(), ctx.Param("index"), version)
+		switch {
+		case errors.Is(err, ErrRelevanceVersionNotFound):
+			resp.Fail(ctx.Writer, resp.NotFound(err.Error()))
+		case err != nil:
+			resp.Fail(ctx.Writer, resp.BadRequest(err.Error()))
+		default:
+			resp.Success(ctx.Writer, applied)
+		}
+	})
+
+	g.POST("/:index/explain", func(ctx *gin.Context) {
+		var req Request
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			resp.Fail(ctx.Writer, resp.BadRequest(err.Error()))
+			return
+		}
+		req.Index = ctx.Param("index")
+		explained, err := c.Explain(ctx.Request.Context(), &req)
+		if err != nil {
+			resp.Fail(ctx.Writer, explainFailure(err))
+			return
+		}
+		resp.Success(ctx.Writer, explained)
+	})
+}
+
+// explainFailure maps an explain error to a response, faults of the engine are
+// a bad gateway and queries it rejected a bad request
+func explainFailure(err error) *resp.Exception {
+	if engineFault(err) {
+		return &resp.Exception{Status: http.StatusBadGateway, Code: ecode.ServerErr, Message: err.Error()}
+	}
+	return resp.BadRequest(err.Error())
+}
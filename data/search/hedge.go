@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/data/hedge"
+)
+
+// HedgeCollector is an optional Collector extension notified of hedged searches,
+// won reports whether the hedge engine answered first
+type HedgeCollector interface {
+	SearchHedge(primary, hedge string, won bool)
+}
+
+// hedgeEngine returns the engine a search on engine is hedged with: the next one in
+// priority order, skipping engines the failover probe scored unhealthy. It returns
+// "" when hedging is disabled or there is no other engine.
+func (c *Client) hedgeEngine(engine Engine) Engine {
+	if c.hedger == nil {
+		return ""
+	}
+	f := c.failoverConfig()
+	for _, eng := range c.enginePriority() {
+		if eng == engine || (f != nil && c.score(eng, f) < f.minScore()) {
+			continue
+		}
+		return eng
+	}
+	return ""
+}
+
+// hedgedSearch runs a search on engine, hedged with a second engine when it is slow.
+// Debug searches are not hedged, their timings are of a single engine.
+func (c *Client) hedgedSearch(ctx context.Context, engine Engine, req *Request) (*Response, error) {
+	other := c.hedgeEngine(engine)
+	if other == "" || req.Debug {
+		return c.SearchWith(ctx, engine, req)
+	}
+
+	resp, outcome, err := hedge.Do(ctx, c.hedger,
+		func(ctx context.Context) (*Response, error) { return c.SearchWith(ctx, engine, req) },
+		func(ctx context.Context) (*Response, error) { return c.SearchWith(ctx, other, req) },
+	)
+	if outcome != hedge.NotHedged {
+		if hc, ok := c.collector.(HedgeCollector); ok {
+			hc.SearchHedge(string(engine), string(other), outcome == hedge.HedgeWon)
+		}
+	}
+	return resp, err
+}
+
+// HedgeStats returns the counters of hedged searches
+func (c *Client) HedgeStats() hedge.Stats {
+	return c.hedger.Stats()
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data/hedge"
+)
+
+type hedgeRecorder struct {
+	NoOpCollector
+	hedges []string
+	won    []bool
+}
+
+func (r *hedgeRecorder) SearchHedge(primary, hedge string, won bool) {
+	r.hedges = append(r.hedges, primary+"->"+hedge)
+	r.won = append(r.won, won)
+}
+
+func TestHedgedSearch(t *testing.T) {
+	es, meili := newFakeAdapter(Elasticsearch), newFakeAdapter(Meilisearch)
+	rec := &hedgeRecorder{}
+	c := NewClientWithConfig(rec, &Config{
+		DefaultEngine: string(Elasticsearch),
+		Hedge:         &hedge.Policy{Enabled: true, MinDelay: 10 * time.Millisecond, MaxRate: 1},
+	}, es, meili)
+	t.Cleanup(c.Close)
+
+	ctx := context.Background()
+	for range 30 {
+		if _, err := c.Search(ctx, &Request{Index: "posts"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rec.hedges) != 0 || meili.searches.Load() != 0 {
+		t.Fatalf("fast searches were hedged: %v", rec.hedges)
+	}
+
+	es.latency.Store(int64(time.Second))
+	start := time.Now()
+	resp, err := c.Search(ctx, &Request{Index: "posts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Engine != Meilisearch || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("search answered by %s after %v, want meilisearch after the hedging delay", resp.Engine, time.Since(start))
+	}
+	if len(rec.hedges) != 1 || rec.hedges[0] != "elasticsearch->meilisearch" || !rec.won[0] {
+		t.Errorf("hedges = %v won = %v", rec.hedges, rec.won)
+	}
+	if s := c.HedgeStats(); s.Hedged != 1 || s.Won != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
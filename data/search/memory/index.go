@@ -0,0 +1,696 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ncobase/ncore/data/search"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// defaultFields are the fields the query text is matched against, as for Elasticsearch
+var defaultFields = []string{"title^2", "content", "details", "name", "description"}
+
+// field is a searchable field and its boost
+type field struct {
+	Name  string  `json:"name"`
+	Boost float64 `json:"boost"`
+}
+
+// parseFields parses field names with optional ^boost suffixes
+func parseFields(names []string) []field {
+	fields := make([]field, 0, len(names))
+	for _, name := range names {
+		f := field{Name: name, Boost: 1}
+		if i := strings.LastIndexByte(name, '^'); i > 0 {
+			if boost, err := strconv.ParseFloat(name[i+1:], 64); err == nil && boost > 0 {
+				f = field{Name: name[:i], Boost: boost}
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// document is an indexed document
+type document struct {
+	source map[string]any
+	terms  map[string]float64 // boosted term frequencies
+	length float64
+}
+
+// index is an inverted index over the searchable fields of its documents
+type index struct {
+	base     []field // Fields the index was created with
+	fields   []field // Fields in use, from the relevance boosts if set
+	docs     map[string]*document
+	postings map[string]map[string]float64 // term -> document ID -> frequency
+	length   float64                       // sum of document lengths
+
+	relevance *search.Relevance
+	synonyms  map[string][]string // term -> equivalent terms
+	stopwords map[string]bool
+
+	version uint64 // bumped by every write
+	saved   uint64 // version of the last snapshot
+}
+
+func newIndex(fields []field) *index {
+	if len(fields) == 0 {
+		fields = parseFields(defaultFields)
+	}
+	return &index{
+		base:     fields,
+		fields:   fields,
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// put indexes source under id, replacing a previous version
+func (ix *index) put(id string, source map[string]any) {
+	ix.remove(id)
+
+	doc := &document{source: source, terms: make(map[string]float64)}
+	for _, f := range ix.fields {
+		for _, text := range fieldTexts(source, f.Name) {
+			for _, tok := range tokenize(text) {
+				doc.terms[tok.term] += f.Boost
+				doc.length += f.Boost
+			}
+		}
+	}
+	for term, freq := range doc.terms {
+		p := ix.postings[term]
+		if p == nil {
+			p = make(map[string]float64)
+			ix.postings[term] = p
+		}
+		p[id] = freq
+	}
+
+	ix.docs[id] = doc
+	ix.length += doc.length
+	ix.version++
+}
+
+// remove drops the document with id
+func (ix *index) remove(id string) {
+	doc, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.docs, id)
+	ix.length -= doc.length
+	ix.version++
+}
+
+// tune applies relevance settings, reindexing the documents if the boosted fields change
+func (ix *index) tune(r *search.Relevance) {
+	ix.relevance = r
+	ix.synonyms = nil
+	ix.stopwords = nil
+
+	fields := ix.base
+	if r != nil {
+		if len(r.Boosts) > 0 {
+			fields = parseFields(r.Fields())
+		}
+		ix.synonyms = make(map[string][]string)
+		for _, set := range r.Synonyms {
+			terms := singleTerms(set)
+			for _, term := range terms {
+				for _, other := range terms {
+					if other != term {
+						ix.synonyms[term] = append(ix.synonyms[term], other)
+					}
+				}
+			}
+		}
+		ix.stopwords = make(map[string]bool)
+		for _, term := range singleTerms(r.Stopwords) {
+			ix.stopwords[term] = true
+		}
+	}
+
+	if !sameFields(fields, ix.fields) {
+		ix.fields = fields
+		docs := ix.docs
+		ix.docs = make(map[string]*document, len(docs))
+		ix.postings = make(map[string]map[string]float64)
+		ix.length = 0
+		for id, doc := range docs {
+			ix.put(id, doc.source)
+		}
+	}
+	ix.version++
+}
+
+// singleTerms returns the words that tokenize to a single term, as terms
+func singleTerms(words []string) []string {
+	var terms []string
+	for _, w := range words {
+		if toks := tokenize(w); len(toks) == 1 {
+			terms = append(terms, toks[0].term)
+		}
+	}
+	return terms
+}
+
+func sameFields(a, b []field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// match is a document matching a request
+type match struct {
+	id          string
+	doc         *document
+	score       float64
+	explanation *search.Explanation
+}
+
+// expansion is an indexed term matched by a query term
+type expansion struct {
+	term   string
+	weight float64
+	reason string // Why a different term matches, e.g. `synonym of "go"`
+}
+
+// expand returns the indexed terms matching a query term: itself, its synonyms and,
+// with typo tolerance, terms within the allowed edits weighted down by distance
+func (ix *index) expand(term string, typo *search.TypoTolerance) []expansion {
+	exps := []expansion{{term: term, weight: 1}}
+	for _, syn := range ix.synonyms[term] {
+		exps = append(exps, expansion{term: syn, weight: 1, reason: fmt.Sprintf("synonym of %q", term)})
+	}
+	if typo == nil || !typo.Enabled {
+		return exps
+	}
+
+	one, two := typo.Sizes()
+	n := utf8.RuneCountInString(term)
+	edits := 0
+	switch {
+	case n >= two:
+		edits = 2
+	case n >= one:
+		edits = 1
+	}
+	if edits == 0 {
+		return exps
+	}
+	for candidate := range ix.postings {
+		if candidate == term {
+			continue
+		}
+		if d := editDistance(term, candidate, edits); d <= edits {
+			exps = append(exps, expansion{term: candidate, weight: 1 / float64(1+d), reason: fmt.Sprintf("typo of %q, %d edits", term, d)})
+		}
+	}
+	return exps
+}
+
+// search returns the documents matching req's query and filter in result order, and
+// the indexed terms matched
+func (ix *index) search(req *search.Request) ([]match, map[string]bool) {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, tok := range tokenize(req.Query) {
+		if !seen[tok.term] && !ix.stopwords[tok.term] {
+			seen[tok.term] = true
+			terms = append(terms, tok.term)
+		}
+	}
+
+	typo := req.Typo
+	if typo == nil && ix.relevance != nil {
+		typo = ix.relevance.Typo
+	}
+
+	matched := make(map[string]bool)
+	scores := make(map[string]float64)
+	details := make(map[string][]*search.Explanation)
+	if len(terms) == 0 {
+		for id := range ix.docs {
+			scores[id] = 0
+		}
+	} else {
+		n := float64(len(ix.docs))
+		avg := 1.0
+		if n > 0 && ix.length > 0 {
+			avg = ix.length / n
+		}
+		for _, term := range terms {
+			// A query term scores its best matching expansion in each document
+			best := make(map[string]float64)
+			bestDetail := make(map[string]*search.Explanation)
+			for _, exp := range ix.expand(term, typo) {
+				postings := ix.postings[exp.term]
+				if len(postings) > 0 {
+					matched[exp.term] = true
+				}
+				df := float64(len(postings))
+				idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+				for id, tf := range postings {
+					norm := tf + bm25K1*(1-bm25B+bm25B*ix.docs[id].length/avg)
+					score := exp.weight * idf * tf * (bm25K1 + 1) / norm
+					if score <= best[id] {
+						continue
+					}
+					best[id] = score
+					if req.Explain {
+						bestDetail[id] = explainTerm(exp, score, tf, idf, df, n, ix.docs[id].length, avg)
+					}
+				}
+			}
+			for id, score := range best {
+				scores[id] += score
+				if req.Explain {
+					details[id] = append(details[id], bestDetail[id])
+				}
+			}
+		}
+	}
+
+	filter := normalizeFilter(req.Filter)
+	matches := make([]match, 0, len(scores))
+	for id, score := range scores {
+		doc := ix.docs[id]
+		if !matchesFilter(doc.source, filter) {
+			continue
+		}
+		m := match{id: id, doc: doc, score: score}
+		if req.Explain {
+			m.explanation = &search.Explanation{Value: score, Description: "sum of:", Details: details[id]}
+			if len(terms) == 0 {
+				m.explanation.Description = "match all"
+			}
+		}
+		matches = append(matches, m)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		for _, s := range req.Sort {
+			av, aok := lookup(a.doc.source, s.Field)
+			bv, bok := lookup(b.doc.source, s.Field)
+			if aok != bok {
+				return aok // Missing values last
+			}
+			if c, ok := compare(av, bv); ok && c != 0 {
+				return (c < 0) != s.Desc
+			}
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.id < b.id
+	})
+	return matches, matched
+}
+
+// explainTerm breaks down the BM25 score of a matched term
+func explainTerm(exp expansion, score, tf, idf, df, n, length, avg float64) *search.Explanation {
+	desc := fmt.Sprintf("weight(%q)", exp.term)
+	if exp.reason != "" {
+		desc += ", " + exp.reason
+	}
+	e := &search.Explanation{
+		Value:       score,
+		Description: desc,
+		Details: []*search.Explanation{
+			{Value: idf, Description: fmt.Sprintf("idf, log(1 + (N - n + 0.5) / (n + 0.5)), n=%g N=%g", df, n)},
+			{Value: tf, Description: "tf, boosted term frequency"},
+			{Value: length / avg, Description: fmt.Sprintf("dl/avgdl, k1=%g b=%g", bm25K1, bm25B)},
+		},
+	}
+	if exp.weight != 1 {
+		e.Details = append(e.Details, &search.Explanation{Value: exp.weight, Description: "typo weight"})
+	}
+	return e
+}
+
+// editDistance returns the Levenshtein distance of a and b in runes, or limit+1 once
+// it exceeds limit
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// hit converts a match to a search hit, highlighting the matched terms
+func (ix *index) hit(m match, req *search.Request, terms map[string]bool) search.Hit {
+	hit := search.Hit{ID: m.id, Score: m.score, Source: m.doc.source, Explanation: m.explanation}
+	if len(req.Source) > 0 {
+		hit.Source = make(map[string]any, len(req.Source))
+		for _, name := range req.Source {
+			if v, ok := m.doc.source[name]; ok {
+				hit.Source[name] = v
+			}
+		}
+	}
+	if req.Highlight != nil && len(req.Highlight.Fields) > 0 {
+		hit.Highlight = highlight(m.doc.source, terms, req.Highlight)
+	}
+	return hit
+}
+
+// token is a term and its byte span in the text
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercased words. Han, Hiragana, Katakana and Hangul
+// characters are single terms, as those scripts do not separate words by spaces.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:end]), start: start, end: end})
+			start = -1
+		}
+	}
+
+	for i, r := range text {
+		switch {
+		case isIdeographic(r):
+			flush(i)
+			end := i + utf8.RuneLen(r)
+			tokens = append(tokens, token{term: text[i:end], start: i, end: end})
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// fieldTexts returns the string values of a field, including the elements of arrays
+func fieldTexts(source map[string]any, name string) []string {
+	v, ok := lookup(source, name)
+	if !ok {
+		return nil
+	}
+	switch x := v.(type) {
+	case string:
+		return []string{x}
+	case []any:
+		var texts []string
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				texts = append(texts, s)
+			}
+		}
+		return texts
+	}
+	return nil
+}
+
+// lookup reads a field by its dotted path
+func lookup(source map[string]any, path string) (any, bool) {
+	var v any = source
+	for part := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// filterCond is a filter condition on normalized values
+type filterCond struct {
+	field  string
+	values []any // Any of the values, when rng is nil
+	rng    *search.Range
+}
+
+// normalizeFilter converts filter values to their JSON form, as documents are stored
+func normalizeFilter(filter map[string]any) []filterCond {
+	conds := make([]filterCond, 0, len(filter))
+	for name, v := range filter {
+		c := filterCond{field: name}
+		switch x := v.(type) {
+		case search.Range:
+			c.rng = normalizeRange(x)
+		case *search.Range:
+			c.rng = normalizeRange(*x)
+		default:
+			var values []any
+			if err := roundTrip(v, &values); err != nil {
+				values = []any{normalize(v)}
+			}
+			c.values = values
+		}
+		conds = append(conds, c)
+	}
+	return conds
+}
+
+func normalizeRange(r search.Range) *search.Range {
+	return &search.Range{GT: normalize(r.GT), GTE: normalize(r.GTE), LT: normalize(r.LT), LTE: normalize(r.LTE)}
+}
+
+// normalize converts v to its JSON form
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	var out any
+	if err := roundTrip(v, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func roundTrip(v, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// matchesFilter reports whether source satisfies every condition. A field holding an
+// array matches if any element does.
+func matchesFilter(source map[string]any, conds []filterCond) bool {
+	for _, c := range conds {
+		v, ok := lookup(source, c.field)
+		if !ok {
+			return false
+		}
+		values := []any{v}
+		if arr, ok := v.([]any); ok {
+			values = arr
+		}
+
+		matched := false
+		for _, value := range values {
+			if c.rng != nil && inRange(value, c.rng) || c.rng == nil && equalsAny(value, c.values) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func equalsAny(v any, values []any) bool {
+	for _, want := range values {
+		if c, ok := compare(v, want); ok && c == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func inRange(v any, r *search.Range) bool {
+	for _, b := range []struct {
+		bound any
+		ok    func(int) bool
+	}{
+		{r.GT, func(c int) bool { return c > 0 }},
+		{r.GTE, func(c int) bool { return c >= 0 }},
+		{r.LT, func(c int) bool { return c < 0 }},
+		{r.LTE, func(c int) bool { return c <= 0 }},
+	} {
+		if b.bound == nil {
+			continue
+		}
+		if c, ok := compare(v, b.bound); !ok || !b.ok(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare orders two JSON values of the same kind. Strings holding RFC 3339
+// timestamps compare as times.
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			if tx, err := time.Parse(time.RFC3339Nano, x); err == nil {
+				if ty, err := time.Parse(time.RFC3339Nano, y); err == nil {
+					return tx.Compare(ty), true
+				}
+			}
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, true
+			case !x:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// highlight wraps the matched terms found in the requested string fields
+func highlight(source map[string]any, terms map[string]bool, h *search.Highlight) map[string][]string {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	pre, post := h.PreTag, h.PostTag
+	if pre == "" {
+		pre = "<em>"
+	}
+	if post == "" {
+		post = "</em>"
+	}
+
+	result := make(map[string][]string)
+	for _, name := range h.Fields {
+		for _, text := range fieldTexts(source, name) {
+			if fragment, ok := highlightText(text, terms, pre, post, h.FragmentSize); ok {
+				result[name] = append(result[name], fragment)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// highlightText wraps matching tokens of text, cut to a window of about size bytes
+// around the first match when size is positive
+func highlightText(text string, terms map[string]bool, pre, post string, size int) (string, bool) {
+	var spans []token
+	for _, tok := range tokenize(text) {
+		if terms[tok.term] {
+			spans = append(spans, tok)
+		}
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(text)
+	if size > 0 && len(text) > size {
+		from = max(0, spans[0].start-size/4)
+		to = min(len(text), from+size)
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+	}
+
+	var b strings.Builder
+	pos := from
+	for _, span := range spans {
+		if span.start < from || span.end > to {
+			continue
+		}
+		b.WriteString(text[pos:span.start])
+		b.WriteString(pre)
+		b.WriteString(text[span.start:span.end])
+		b.WriteString(post)
+		pos = span.end
+	}
+	b.WriteString(text[pos:to])
+	return b.String(), true
+}
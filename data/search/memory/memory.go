@@ -0,0 +1,498 @@
+// Package memory provides a pure Go in-memory search engine for development and small
+// deployments without Elasticsearch, OpenSearch or Meilisearch.
+//
+// Each index is an inverted index scored with BM25. Requests support filters, sorting,
+// highlighting, source fields, explanations, debug traces and exports like the other engines, and
+// ApplyRelevance tunes field boosts, synonyms, stopwords and typo tolerance. With Options.Dir
+// set, changed indexes are snapshotted to JSON files there and reloaded on start.
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/data/search"
+)
+
+// snapshotExt is the file extension of index snapshots
+const snapshotExt = ".json"
+
+// ErrClosed is returned by operations on a closed adapter
+var ErrClosed = errors.New("memory search engine is closed")
+
+func init() {
+	search.RegisterAdapterFactory(search.Memory, func(conn any) (search.Adapter, error) {
+		a, ok := conn.(*Adapter)
+		if !ok {
+			return nil, fmt.Errorf("expected *memory.Adapter, got %T", conn)
+		}
+		return a, nil
+	})
+}
+
+// Options configures the in-memory engine
+type Options struct {
+	Dir           string        // Directory of index snapshots, in memory only when empty
+	FlushInterval time.Duration // Interval between snapshots of changed indexes, default 30s
+}
+
+// Adapter is an in-process search engine keeping an inverted index per index
+type Adapter struct {
+	mu      sync.RWMutex
+	indexes map[string]*index
+	opts    Options
+	closed  bool
+
+	flushMu sync.Mutex // Serializes snapshots
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewAdapter creates an in-memory engine, loading the snapshots in opts.Dir
+func NewAdapter(opts Options) (*Adapter, error) {
+	a := &Adapter{
+		indexes: make(map[string]*index),
+		opts:    opts,
+	}
+	if opts.Dir == "" {
+		return a, nil
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create search snapshot directory: %w", err)
+	}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+
+	if a.opts.FlushInterval <= 0 {
+		a.opts.FlushInterval = 30 * time.Second
+	}
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.flushLoop()
+	return a, nil
+}
+
+func (a *Adapter) Type() search.Engine {
+	return search.Memory
+}
+
+func (a *Adapter) Search(ctx context.Context, req *search.Request) (*search.Response, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return nil, ErrClosed
+	}
+	ix, ok := a.indexes[req.Index]
+	if !ok {
+		return nil, fmt.Errorf("index not found: %s", req.Index)
+	}
+
+	// The engine runs the Request itself, traced as its query
+	trace := search.StartTrace(req)
+	trace.Built()
+	if trace != nil {
+		query, _ := json.Marshal(req)
+		trace.Serialized(query)
+	}
+
+	matches, terms := ix.search(req)
+	trace.Executed()
+
+	size := req.Size
+	if size <= 0 {
+		size = 10
+	}
+	from := min(max(req.From, 0), len(matches))
+	to := min(from+size, len(matches))
+
+	hits := make([]search.Hit, 0, to-from)
+	for _, m := range matches[from:to] {
+		hits = append(hits, ix.hit(m, req, terms))
+	}
+	if trace != nil {
+		matched := make([]string, 0, len(terms))
+		for term := range terms {
+			matched = append(matched, term)
+		}
+		sort.Strings(matched)
+		profile, _ := json.Marshal(map[string]any{
+			"documents":     len(ix.docs),
+			"matches":       len(matches),
+			"matched_terms": matched,
+		})
+		trace.Parsed(profile)
+	}
+
+	resp := &search.Response{
+		Total: int64(len(matches)),
+		Hits:  hits,
+	}
+	trace.Attach(resp)
+	return resp, nil
+}
+
+// Export streams every document matching req in batches of req.Size
+func (a *Adapter) Export(ctx context.Context, req *search.Request, fn func([]search.Hit) error) error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return ErrClosed
+	}
+	ix, ok := a.indexes[req.Index]
+	if !ok {
+		a.mu.RUnlock()
+		return fmt.Errorf("index not found: %s", req.Index)
+	}
+	matches, terms := ix.search(req)
+	hits := make([]search.Hit, len(matches))
+	for i, m := range matches {
+		hits[i] = ix.hit(m, req, terms)
+	}
+	a.mu.RUnlock()
+
+	size := req.Size
+	if size <= 0 {
+		size = search.DefaultExportBatchSize
+	}
+	for start := 0; start < len(hits); start += size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(hits[start:min(start+size, len(hits))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) Index(ctx context.Context, req *search.IndexRequest) error {
+	source, err := toSource(req.Document)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ix, err := a.writableIndex(req.Index)
+	if err != nil {
+		return err
+	}
+	ix.put(documentID(req.DocumentID, source), source)
+	return nil
+}
+
+func (a *Adapter) Delete(ctx context.Context, index, id string) error {
+	return a.BulkDelete(ctx, index, []string{id})
+}
+
+func (a *Adapter) BulkIndex(ctx context.Context, index string, documents []any) error {
+	sources := make([]map[string]any, len(documents))
+	for i, doc := range documents {
+		source, err := toSource(doc)
+		if err != nil {
+			return err
+		}
+		sources[i] = source
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ix, err := a.writableIndex(index)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		ix.put(documentID("", source), source)
+	}
+	return nil
+}
+
+func (a *Adapter) BulkDelete(ctx context.Context, index string, documentIDs []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+	ix, ok := a.indexes[index]
+	if !ok {
+		return nil
+	}
+	for _, id := range documentIDs {
+		ix.remove(id)
+	}
+	return nil
+}
+
+func (a *Adapter) IndexExists(ctx context.Context, indexName string) (bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return false, ErrClosed
+	}
+	_, ok := a.indexes[indexName]
+	return ok, nil
+}
+
+// CreateIndex creates an index matching query text against the searchable fields of
+// settings, which take ^boost suffixes
+func (a *Adapter) CreateIndex(ctx context.Context, indexName string, settings *search.IndexSettings) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+	if _, ok := a.indexes[indexName]; ok {
+		return nil
+	}
+
+	var fields []field
+	if settings != nil && len(settings.SearchableFields) > 0 {
+		fields = parseFields(settings.SearchableFields)
+	}
+	ix := newIndex(fields)
+	ix.version++ // Snapshot the empty index too
+	a.indexes[indexName] = ix
+	return nil
+}
+
+// ApplyRelevance sets the boosted fields, synonyms, stopwords and typo tolerance of an
+// index, reindexing its documents if the boosted fields change
+func (a *Adapter) ApplyRelevance(ctx context.Context, indexName string, r *search.Relevance) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ix, err := a.writableIndex(indexName)
+	if err != nil {
+		return err
+	}
+	ix.tune(r)
+	return nil
+}
+
+func (a *Adapter) Health(ctx context.Context) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Flush writes the snapshots of indexes changed since the last flush
+func (a *Adapter) Flush() error {
+	if a.opts.Dir == "" {
+		return nil
+	}
+
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	type pending struct {
+		ix      *index
+		name    string
+		data    []byte
+		version uint64
+	}
+
+	var (
+		writes []pending
+		errs   []error
+	)
+	a.mu.RLock()
+	for name, ix := range a.indexes {
+		if ix.version == ix.saved {
+			continue
+		}
+		data, err := json.Marshal(ix.snapshot())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to marshal index %s: %w", name, err))
+			continue
+		}
+		writes = append(writes, pending{ix: ix, name: name, data: data, version: ix.version})
+	}
+	a.mu.RUnlock()
+
+	for _, w := range writes {
+		if err := writeFile(filepath.Join(a.opts.Dir, url.PathEscape(w.name)+snapshotExt), w.data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write index %s: %w", w.name, err))
+			continue
+		}
+		a.mu.Lock()
+		w.ix.saved = w.version
+		a.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops background snapshots and flushes changed indexes
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.mu.Unlock()
+
+	if a.stop != nil {
+		close(a.stop)
+		<-a.done
+	}
+	return a.Flush()
+}
+
+// writableIndex returns the index to write to, created on first use like the
+// dynamic mapping of Elasticsearch
+func (a *Adapter) writableIndex(name string) (*index, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+	ix, ok := a.indexes[name]
+	if !ok {
+		ix = newIndex(nil)
+		a.indexes[name] = ix
+	}
+	return ix, nil
+}
+
+func (a *Adapter) flushLoop() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if err := a.Flush(); err != nil {
+				fmt.Printf("Warning: failed to flush memory search indexes: %v\n", err)
+			}
+		}
+	}
+}
+
+// snapshotFile is the persisted form of an index
+type snapshotFile struct {
+	Fields    []field                   `json:"fields"`
+	Relevance *search.Relevance         `json:"relevance,omitempty"`
+	Documents map[string]map[string]any `json:"documents"`
+}
+
+func (ix *index) snapshot() snapshotFile {
+	docs := make(map[string]map[string]any, len(ix.docs))
+	for id, doc := range ix.docs {
+		docs[id] = doc.source
+	}
+	return snapshotFile{Fields: ix.base, Relevance: ix.relevance, Documents: docs}
+}
+
+// load rebuilds the indexes from their snapshots
+func (a *Adapter) load() error {
+	entries, err := os.ReadDir(a.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read search snapshot directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotExt) {
+			continue
+		}
+		name, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), snapshotExt))
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(a.opts.Dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read index %s: %w", name, err)
+		}
+		var snap snapshotFile
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return fmt.Errorf("failed to parse index %s: %w", name, err)
+		}
+
+		ix := newIndex(snap.Fields)
+		if snap.Relevance != nil {
+			ix.tune(snap.Relevance)
+		}
+		for id, source := range snap.Documents {
+			ix.put(id, source)
+		}
+		ix.saved = ix.version
+		a.indexes[name] = ix
+	}
+	return nil
+}
+
+// writeFile replaces path with data atomically
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// toSource converts a document to its JSON object form
+func toSource(doc any) (map[string]any, error) {
+	var source map[string]any
+	if err := roundTrip(doc, &source); err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	if source == nil {
+		return nil, errors.New("document must be a JSON object")
+	}
+	return source, nil
+}
+
+// documentID returns id, the document's id field, or a random ID
+func documentID(id string, source map[string]any) string {
+	if id != "" {
+		return id
+	}
+	if v, ok := source["id"]; ok && v != nil {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ncobase/ncore/data/search"
+)
+
+func newTestAdapter(t *testing.T, opts Options) *Adapter {
+	t.Helper()
+	a, err := NewAdapter(opts)
+	if err != nil {
+		t.Fatalf("NewAdapter: %v", err)
+	}
+	t.Cleanup(func() { _ = a.Close() })
+	return a
+}
+
+func seed(t *testing.T, a *Adapter) {
+	t.Helper()
+	err := a.BulkIndex(context.Background(), "posts", []any{
+		map[string]any{"id": "1", "title": "Go search engine", "content": "An inverted index in Go", "status": "published", "views": 10, "tags": []string{"go", "search"}},
+		map[string]any{"id": "2", "title": "Cooking pasta", "content": "Boil water, add pasta", "status": "draft", "views": 5, "tags": []string{"food"}},
+		map[string]any{"id": "3", "title": "Intro", "content": "Learn the go language in depth with examples", "status": "published", "views": 20},
+		map[string]any{"id": "4", "title": "全文搜索", "content": "内存搜索引擎", "status": "published", "views": 1},
+	})
+	if err != nil {
+		t.Fatalf("BulkIndex: %v", err)
+	}
+}
+
+func ids(resp *search.Response) []string {
+	out := make([]string, len(resp.Hits))
+	for i, hit := range resp.Hits {
+		out[i] = hit.ID
+	}
+	return out
+}
+
+func TestSearchRanksByRelevance(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+
+	resp, err := a.Search(context.Background(), &search.Request{Index: "posts", Query: "GO"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	// Matches in the boosted title rank first
+	if got := ids(resp); len(got) != 2 || got[0] != "1" || got[1] != "3" || resp.Total != 2 {
+		t.Fatalf("hits = %v, total %d", got, resp.Total)
+	}
+
+	resp, _ = a.Search(context.Background(), &search.Request{Index: "posts", Query: "搜索"})
+	if got := ids(resp); len(got) != 1 || got[0] != "4" {
+		t.Fatalf("CJK hits = %v", got)
+	}
+}
+
+func TestSearchFilterSortAndPage(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+	ctx := context.Background()
+
+	resp, err := a.Search(ctx, &search.Request{
+		Index:  "posts",
+		Filter: map[string]any{"status": "published", "views": search.Range{GTE: 5}},
+		Sort:   []search.SortField{{Field: "views", Desc: true}},
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := ids(resp); len(got) != 2 || got[0] != "3" || got[1] != "1" {
+		t.Fatalf("filtered hits = %v", got)
+	}
+
+	resp, _ = a.Search(ctx, &search.Request{Index: "posts", Filter: map[string]any{"tags": []string{"food", "search"}}})
+	if resp.Total != 2 {
+		t.Fatalf("terms filter total = %d", resp.Total)
+	}
+
+	resp, _ = a.Search(ctx, &search.Request{
+		Index:  "posts",
+		Sort:   []search.SortField{{Field: "views"}},
+		From:   1,
+		Size:   2,
+		Source: []string{"title"},
+	})
+	if got := ids(resp); len(got) != 2 || got[0] != "2" || got[1] != "1" || resp.Total != 4 {
+		t.Fatalf("page = %v, total %d", got, resp.Total)
+	}
+	if len(resp.Hits[0].Source) != 1 || resp.Hits[0].Source["title"] != "Cooking pasta" {
+		t.Fatalf("source = %v", resp.Hits[0].Source)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+
+	resp, err := a.Search(context.Background(), &search.Request{
+		Index:     "posts",
+		Query:     "pasta",
+		Highlight: &search.Highlight{Fields: []string{"content"}, PreTag: "[", PostTag: "]"},
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := resp.Hits[0].Highlight["content"]; len(got) != 1 || got[0] != "Boil water, add [pasta]" {
+		t.Fatalf("highlight = %v", got)
+	}
+}
+
+func TestReindexAndDelete(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+	ctx := context.Background()
+
+	if err := a.Index(ctx, &search.IndexRequest{Index: "posts", DocumentID: "2", Document: map[string]any{"title": "Go pasta"}}); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	resp, _ := a.Search(ctx, &search.Request{Index: "posts", Query: "boil"})
+	if resp.Total != 0 {
+		t.Fatalf("stale terms still match: %v", ids(resp))
+	}
+
+	if err := a.Delete(ctx, "posts", "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	resp, _ = a.Search(ctx, &search.Request{Index: "posts", Query: "go"})
+	if got := ids(resp); len(got) != 2 || got[0] != "2" || got[1] != "3" {
+		t.Fatalf("hits after delete = %v", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+
+	var batches []int
+	err := a.Export(context.Background(), &search.Request{Index: "posts", Size: 3}, func(hits []search.Hit) error {
+		batches = append(batches, len(hits))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(batches) != 2 || batches[0] != 3 || batches[1] != 1 {
+		t.Fatalf("batches = %v", batches)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	a, err := NewAdapter(Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewAdapter: %v", err)
+	}
+	if err := a.CreateIndex(ctx, "app/users", &search.IndexSettings{SearchableFields: []string{"name"}}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if err := a.Index(ctx, &search.IndexRequest{Index: "app/users", Document: map[string]any{"id": 7, "name": "Ada Lovelace", "title": "countess"}}); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := a.Health(ctx); err != ErrClosed {
+		t.Fatalf("Health after Close = %v", err)
+	}
+
+	b := newTestAdapter(t, Options{Dir: dir})
+	resp, err := b.Search(ctx, &search.Request{Index: "app/users", Query: "ada"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := ids(resp); len(got) != 1 || got[0] != "7" {
+		t.Fatalf("reloaded hits = %v", got)
+	}
+	// Only the searchable fields of the index are matched
+	if resp, _ := b.Search(ctx, &search.Request{Index: "app/users", Query: "countess"}); resp.Total != 0 {
+		t.Fatalf("non searchable field matched")
+	}
+}
+
+func TestApplyRelevance(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	a, err := NewAdapter(Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewAdapter: %v", err)
+	}
+	seed(t, a)
+
+	err = a.ApplyRelevance(ctx, "posts", &search.Relevance{
+		Boosts:    map[string]float64{"content": 1},
+		Synonyms:  [][]string{{"golang", "go"}},
+		Stopwords: []string{"the"},
+		Typo:      &search.TypoTolerance{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ApplyRelevance: %v", err)
+	}
+
+	// Only the boosted fields are searched
+	resp, _ := a.Search(ctx, &search.Request{Index: "posts", Query: "intro"})
+	if resp.Total != 0 {
+		t.Fatalf("unboosted field matched: %v", ids(resp))
+	}
+	resp, _ = a.Search(ctx, &search.Request{Index: "posts", Query: "golang"})
+	if resp.Total != 2 {
+		t.Fatalf("synonym total = %d", resp.Total)
+	}
+	resp, _ = a.Search(ctx, &search.Request{Index: "posts", Query: "the", Filter: map[string]any{"id": "2"}})
+	if resp.Total != 1 || resp.Hits[0].Score != 0 {
+		t.Fatalf("stopword scored: %v", resp.Hits)
+	}
+	resp, _ = a.Search(ctx, &search.Request{
+		Index:     "posts",
+		Query:     "exmples",
+		Explain:   true,
+		Highlight: &search.Highlight{Fields: []string{"content"}},
+	})
+	if got := ids(resp); len(got) != 1 || got[0] != "3" {
+		t.Fatalf("typo hits = %v", got)
+	}
+	exp := resp.Hits[0].Explanation
+	if exp == nil || len(exp.Details) != 1 || exp.Details[0].Value != resp.Hits[0].Score {
+		t.Fatalf("explanation = %+v", exp)
+	}
+	if got := resp.Hits[0].Highlight["content"]; len(got) != 1 || got[0] != "Learn the go language in depth with <em>examples</em>" {
+		t.Fatalf("typo highlight = %v", got)
+	}
+
+	// Settings are persisted with the snapshot
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	b := newTestAdapter(t, Options{Dir: dir})
+	resp, _ = b.Search(ctx, &search.Request{Index: "posts", Query: "golang"})
+	if resp.Total != 2 {
+		t.Fatalf("reloaded total = %d", resp.Total)
+	}
+
+	// Clearing the boosts restores the index fields
+	if err := b.ApplyRelevance(ctx, "posts", &search.Relevance{}); err != nil {
+		t.Fatalf("ApplyRelevance: %v", err)
+	}
+	resp, _ = b.Search(ctx, &search.Request{Index: "posts", Query: "intro"})
+	if got := ids(resp); len(got) != 1 || got[0] != "3" {
+		t.Fatalf("reset hits = %v", got)
+	}
+}
+
+func TestSearchDebug(t *testing.T) {
+	a := newTestAdapter(t, Options{})
+	seed(t, a)
+
+	resp, err := a.Search(context.Background(), &search.Request{Index: "posts", Query: "go", Debug: true})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	d := resp.Debug
+	if d == nil || d.Index != "posts" || !strings.Contains(string(d.Query), `"query":"go"`) {
+		t.Fatalf("debug = %+v", d)
+	}
+	if want := `{"documents":4,"matched_terms":["go"],"matches":2}`; string(d.Profile) != want {
+		t.Fatalf("profile = %s, want %s", d.Profile, want)
+	}
+
+	resp, _ = a.Search(context.Background(), &search.Request{Index: "posts", Query: "go"})
+	if resp.Debug != nil {
+		t.Fatalf("debug without Request.Debug = %+v", resp.Debug)
+	}
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// SortField orders results by a field
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// Highlight requests highlighted fragments of matching fields
+type Highlight struct {
+	Fields       []string `json:"fields"`
+	PreTag       string   `json:"pre_tag,omitempty"`  // Default <em>
+	PostTag      string   `json:"post_tag,omitempty"` // Default </em>
+	FragmentSize int      `json:"fragment_size,omitempty"`
+}
+
+// Range is a Filter value matching a field between bounds, nil bounds are open
+type Range struct {
+	GT  any `json:"gt,omitempty"`
+	GTE any `json:"gte,omitempty"`
+	LT  any `json:"lt,omitempty"`
+	LTE any `json:"lte,omitempty"`
+}
+
+// Clause is a Query DSL clause for Elasticsearch and OpenSearch
+type Clause interface {
+	json.Marshaler
+}
+
+// MatchAll matches every document
+type MatchAll struct{}
+
+func (MatchAll) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"match_all": struct{}{}})
+}
+
+// MultiMatch runs a full text query over several fields
+type MultiMatch struct {
+	Query     string   `json:"query"`
+	Fields    []string `json:"fields,omitempty"`
+	Fuzziness string   `json:"fuzziness,omitempty"` // e.g. "AUTO"
+}
+
+func (q MultiMatch) MarshalJSON() ([]byte, error) {
+	type body MultiMatch
+	return json.Marshal(map[string]any{"multi_match": body(q)})
+}
+
+// Term matches an exact value
+type Term struct {
+	Field string
+	Value any
+}
+
+func (q Term) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"term": map[string]any{q.Field: q.Value}})
+}
+
+// Terms matches any of the values
+type Terms struct {
+	Field  string
+	Values []any
+}
+
+func (q Terms) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"terms": map[string]any{q.Field: q.Values}})
+}
+
+// RangeQuery matches values between bounds
+type RangeQuery struct {
+	Field string
+	Range Range
+}
+
+func (q RangeQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"range": map[string]any{q.Field: q.Range}})
+}
+
+// Bool combines clauses
+type Bool struct {
+	Must    []Clause `json:"must,omitempty"`
+	Filter  []Clause `json:"filter,omitempty"`
+	Should  []Clause `json:"should,omitempty"`
+	MustNot []Clause `json:"must_not,omitempty"`
+}
+
+func (q Bool) MarshalJSON() ([]byte, error) {
+	type body Bool
+	return json.Marshal(map[string]any{"bool": body(q)})
+}
+
+// Body is a Query DSL search request body
+type Body struct {
+	Query     Clause              `json:"query"`
+	From      int                 `json:"from,omitempty"`
+	Size      int                 `json:"size,omitempty"`
+	Sort      []map[string]string `json:"sort,omitempty"`
+	Highlight map[string]any      `json:"highlight,omitempty"`
+	Source    []string            `json:"_source,omitempty"`
+	Explain   bool                `json:"explain,omitempty"`
+	Profile   bool                `json:"profile,omitempty"`
+
+	PIT         *PIT  `json:"pit,omitempty"`          // Point in time to search instead of an index
+	SearchAfter []any `json:"search_after,omitempty"` // Sort values of the previous page's last hit
+}
+
+// BuildQuery builds the Query DSL body of req, matching the query text
+// against req.Fields, or fields when empty. Elasticsearch and OpenSearch
+// adapters share it.
+func BuildQuery(req *Request, fields []string) ([]byte, error) {
+	return json.Marshal(QueryBody(req, fields))
+}
+
+// QueryBody builds the Query DSL body of req, profiled when req.Debug is set
+func QueryBody(req *Request, fields []string) Body {
+	if len(req.Fields) > 0 {
+		fields = req.Fields
+	}
+	var match Clause = MatchAll{}
+	if req.Query != "" {
+		match = MultiMatch{Query: req.Query, Fields: fields, Fuzziness: req.Typo.Fuzziness()}
+	}
+
+	body := Body{
+		Query:   match,
+		From:    req.From,
+		Size:    req.Size,
+		Source:  req.Source,
+		Explain: req.Explain,
+		Profile: req.Debug,
+	}
+
+	if filters := FilterClauses(req.Filter); len(filters) > 0 {
+		body.Query = Bool{Must: []Clause{match}, Filter: filters}
+	}
+
+	for _, s := range req.Sort {
+		order := "asc"
+		if s.Desc {
+			order = "desc"
+		}
+		body.Sort = append(body.Sort, map[string]string{s.Field: order})
+	}
+
+	if h := req.Highlight; h != nil && len(h.Fields) > 0 {
+		hf := make(map[string]any, len(h.Fields))
+		for _, f := range h.Fields {
+			hf[f] = struct{}{}
+		}
+		body.Highlight = map[string]any{"fields": hf}
+		if h.PreTag != "" {
+			body.Highlight["pre_tags"] = []string{h.PreTag}
+		}
+		if h.PostTag != "" {
+			body.Highlight["post_tags"] = []string{h.PostTag}
+		}
+		if h.FragmentSize > 0 {
+			body.Highlight["fragment_size"] = h.FragmentSize
+		}
+	}
+
+	return body
+}
+
+// FilterClauses converts Request.Filter to filter clauses in field order
+func FilterClauses(filter map[string]any) []Clause {
+	fields := make([]string, 0, len(filter))
+	for field := range filter {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	clauses := make([]Clause, 0, len(fields))
+	for _, field := range fields {
+		switch v := filter[field].(type) {
+		case Range:
+			clauses = append(clauses, RangeQuery{Field: field, Range: v})
+		case *Range:
+			clauses = append(clauses, RangeQuery{Field: field, Range: *v})
+		default:
+			if values, ok := sliceValues(v); ok {
+				clauses = append(clauses, Terms{Field: field, Values: values})
+			} else {
+				clauses = append(clauses, Term{Field: field, Value: v})
+			}
+		}
+	}
+	return clauses
+}
+
+// sliceValues returns the elements of a slice or array filter value
+func sliceValues(v any) ([]any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false // []byte is a scalar
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildQueryEscapesInput(t *testing.T) {
+	body, err := BuildQuery(&Request{Query: `say "hi" \ }`, Size: 10}, []string{"title"})
+	if err != nil {
+		t.Fatalf("BuildQuery: %v", err)
+	}
+
+	var got struct {
+		Query struct {
+			MultiMatch struct {
+				Query string `json:"query"`
+			} `json:"multi_match"`
+		} `json:"query"`
+		Size int `json:"size"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", body, err)
+	}
+	if got.Query.MultiMatch.Query != `say "hi" \ }` || got.Size != 10 {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+func TestBuildQueryOptions(t *testing.T) {
+	body, err := BuildQuery(&Request{
+		Query: "go",
+		Filter: map[string]any{
+			"status":     "published",
+			"tags":       []string{"a", "b"},
+			"created_at": Range{GTE: 100, LT: 200},
+		},
+		Sort:      []SortField{{Field: "created_at", Desc: true}},
+		Highlight: &Highlight{Fields: []string{"title"}, PreTag: "<b>", PostTag: "</b>"},
+		Source:    []string{"id", "title"},
+	}, []string{"title"})
+	if err != nil {
+		t.Fatalf("BuildQuery: %v", err)
+	}
+
+	want := `{"query":{"bool":{"must":[{"multi_match":{"query":"go","fields":["title"]}}],` +
+		`"filter":[{"range":{"created_at":{"gte":100,"lt":200}}},{"term":{"status":"published"}},{"terms":{"tags":["a","b"]}}]}},` +
+		`"sort":[{"created_at":"desc"}],` +
+		`"highlight":{"fields":{"title":{}},"post_tags":["\u003c/b\u003e"],"pre_tags":["\u003cb\u003e"]},` +
+		`"_source":["id","title"]}`
+	if string(body) != want {
+		t.Fatalf("body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestBuildQueryMatchAll(t *testing.T) {
+	body, _ := BuildQuery(&Request{}, nil)
+	if string(body) != `{"query":{"match_all":{}}}` {
+		t.Fatalf("body = %s", body)
+	}
+}
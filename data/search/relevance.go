@@ -0,0 +1,302 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRelevanceVersionNotFound is returned when rolling back to an unknown version
+var ErrRelevanceVersionNotFound = errors.New("relevance version not found")
+
+// Relevance is the relevance configuration of an index
+type Relevance struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Boosts weights the searchable fields, e.g. {"title": 3, "content": 1}.
+	// Only these fields are searched, the engine defaults when empty.
+	Boosts map[string]float64 `json:"boosts,omitempty"`
+	// Synonyms are sets of equivalent terms, e.g. [["laptop", "notebook"]]
+	Synonyms [][]string `json:"synonyms,omitempty"`
+	// Stopwords are ignored in queries
+	Stopwords []string `json:"stopwords,omitempty"`
+	// Typo tolerates misspelled query terms, off when nil
+	Typo *TypoTolerance `json:"typo,omitempty"`
+}
+
+// TypoTolerance matches query terms within one or two edits of indexed terms
+type TypoTolerance struct {
+	Enabled             bool `json:"enabled"`
+	MinWordSizeOneTypo  int  `json:"min_word_size_one_typo,omitempty"`  // Default 5
+	MinWordSizeTwoTypos int  `json:"min_word_size_two_typos,omitempty"` // Default 9
+}
+
+// Sizes returns the minimum word sizes for one and two typos
+func (t *TypoTolerance) Sizes() (one, two int) {
+	one, two = 5, 9
+	if t.MinWordSizeOneTypo > 0 {
+		one = t.MinWordSizeOneTypo
+	}
+	if t.MinWordSizeTwoTypos > 0 {
+		two = t.MinWordSizeTwoTypos
+	}
+	return one, two
+}
+
+// Fuzziness returns the Query DSL fuzziness, e.g. "AUTO:5,9", empty when disabled
+func (t *TypoTolerance) Fuzziness() string {
+	if t == nil || !t.Enabled {
+		return ""
+	}
+	one, two := t.Sizes()
+	return fmt.Sprintf("AUTO:%d,%d", one, two)
+}
+
+// Fields returns the boosted fields in Query DSL form, e.g. "title^3", by
+// descending boost
+func (r *Relevance) Fields() []string {
+	if r == nil || len(r.Boosts) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(r.Boosts))
+	for name := range r.Boosts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bi, bj := r.Boosts[names[i]], r.Boosts[names[j]]; bi != bj {
+			return bi > bj
+		}
+		return names[i] < names[j]
+	})
+	fields := make([]string, len(names))
+	for i, name := range names {
+		if boost := r.Boosts[name]; boost != 1 {
+			fields[i] = name + "^" + strconv.FormatFloat(boost, 'f', -1, 64)
+		} else {
+			fields[i] = name
+		}
+	}
+	return fields
+}
+
+// validate checks the configuration
+func (r *Relevance) validate() error {
+	for field, boost := range r.Boosts {
+		if field == "" || boost <= 0 {
+			return fmt.Errorf("invalid boost %v for field %q", boost, field)
+		}
+	}
+	for _, set := range r.Synonyms {
+		if len(set) < 2 {
+			return fmt.Errorf("synonym set %v needs at least two terms", set)
+		}
+	}
+	return nil
+}
+
+// ParseField splits a Query DSL field into its name and boost, e.g. "title^2"
+func ParseField(field string) (string, float64) {
+	if name, boost, ok := strings.Cut(field, "^"); ok {
+		if b, err := strconv.ParseFloat(boost, 64); err == nil {
+			return name, b
+		}
+		return name, 1
+	}
+	return field, 1
+}
+
+// AnalysisSettings returns the Elasticsearch and OpenSearch index settings applying
+// r's stopwords and synonyms at search time through the default_search analyzer
+func AnalysisSettings(r *Relevance) ([]byte, error) {
+	var stopwords any = "_none_"
+	if len(r.Stopwords) > 0 {
+		stopwords = r.Stopwords
+	}
+	synonyms := make([]string, len(r.Synonyms))
+	for i, set := range r.Synonyms {
+		synonyms[i] = strings.Join(set, ", ")
+	}
+	return json.Marshal(map[string]any{
+		"analysis": map[string]any{
+			"filter": map[string]any{
+				"ncore_stop":     map[string]any{"type": "stop", "stopwords": stopwords},
+				"ncore_synonyms": map[string]any{"type": "synonym_graph", "synonyms": synonyms},
+			},
+			"analyzer": map[string]any{
+				"default_search": map[string]any{
+					"type":      "custom",
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "ncore_stop", "ncore_synonyms"},
+				},
+			},
+		},
+	})
+}
+
+// Explanation is a hit's score broken down by the engine
+type Explanation struct {
+	Value       float64        `json:"value"`
+	Description string         `json:"description"`
+	Details     []*Explanation `json:"details,omitempty"`
+}
+
+// RelevanceTuner is implemented by adapters applying relevance settings to an index
+type RelevanceTuner interface {
+	ApplyRelevance(ctx context.Context, index string, r *Relevance) error
+}
+
+// RelevanceStore keeps the relevance versions of indexes
+type RelevanceStore interface {
+	// Versions returns the versions of index, oldest first
+	Versions(ctx context.Context, index string) ([]*Relevance, error)
+	// Save appends a version
+	Save(ctx context.Context, index string, r *Relevance) error
+}
+
+// memoryRelevanceStore keeps relevance versions in process
+type memoryRelevanceStore struct {
+	mu       sync.RWMutex
+	versions map[string][]*Relevance
+}
+
+func (s *memoryRelevanceStore) Versions(_ context.Context, index string) ([]*Relevance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Relevance(nil), s.versions[index]...), nil
+}
+
+func (s *memoryRelevanceStore) Save(_ context.Context, index string, r *Relevance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[index] = append(s.versions[index], r)
+	return nil
+}
+
+// SetRelevanceStore sets where relevance versions are kept, in process by default
+func (c *Client) SetRelevanceStore(store RelevanceStore) {
+	c.relevanceMu.Lock()
+	defer c.relevanceMu.Unlock()
+	c.relevanceStore = store
+	c.relevance = make(map[string]*Relevance)
+}
+
+// SetRelevance applies r to index on every engine supporting it and saves it as
+// the next version. Nothing is saved if an engine fails.
+func (c *Client) SetRelevance(ctx context.Context, index string, r Relevance) (*Relevance, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	c.relevanceMu.Lock()
+	defer c.relevanceMu.Unlock()
+
+	versions, err := c.relevanceStore.Versions(ctx, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relevance versions: %w", err)
+	}
+	r.Version = 1
+	if n := len(versions); n > 0 {
+		r.Version = versions[n-1].Version + 1
+	}
+	r.UpdatedAt = time.Now()
+
+	var errs []error
+	for engine, adapter := range c.adapters {
+		if err := c.applyRelevance(ctx, engine, adapter, c.buildIndexName(index), &r); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", engine, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to apply relevance: %w", err)
+	}
+
+	if err := c.relevanceStore.Save(ctx, index, &r); err != nil {
+		return nil, fmt.Errorf("failed to save relevance: %w", err)
+	}
+	c.relevance[index] = &r
+	return &r, nil
+}
+
+// applyRelevance applies r to an existing index of an engine supporting it,
+// indexes created later get it from ensureIndex
+func (c *Client) applyRelevance(ctx context.Context, engine Engine, adapter Adapter, fullIndex string, r *Relevance) error {
+	tuner, ok := adapter.(RelevanceTuner)
+	if !ok {
+		return nil
+	}
+	exists, err := adapter.IndexExists(ctx, fullIndex)
+	if err != nil || !exists {
+		return err
+	}
+	return tuner.ApplyRelevance(ctx, fullIndex, r)
+}
+
+// GetRelevance returns the current relevance of index, nil if none is set
+func (c *Client) GetRelevance(ctx context.Context, index string) (*Relevance, error) {
+	c.relevanceMu.Lock()
+	defer c.relevanceMu.Unlock()
+
+	if r, ok := c.relevance[index]; ok {
+		return r, nil
+	}
+	versions, err := c.relevanceStore.Versions(ctx, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load relevance versions: %w", err)
+	}
+	var r *Relevance
+	if n := len(versions); n > 0 {
+		r = versions[n-1]
+	}
+	c.relevance[index] = r
+	return r, nil
+}
+
+// RelevanceVersions returns the relevance versions of index, oldest first
+func (c *Client) RelevanceVersions(ctx context.Context, index string) ([]*Relevance, error) {
+	c.relevanceMu.Lock()
+	defer c.relevanceMu.Unlock()
+	return c.relevanceStore.Versions(ctx, index)
+}
+
+// RollbackRelevance reapplies a previous version of index as a new version
+func (c *Client) RollbackRelevance(ctx context.Context, index string, version int) (*Relevance, error) {
+	versions, err := c.RelevanceVersions(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range versions {
+		if r.Version == version {
+			return c.SetRelevance(ctx, index, *r)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrRelevanceVersionNotFound, index, version)
+}
+
+// Explain runs req as a dry run returning each hit's score breakdown in
+// Hit.Explanation, on engines able to explain
+func (c *Client) Explain(ctx context.Context, req *Request) (*Response, error) {
+	explainReq := *req
+	explainReq.Explain = true
+	return c.Search(ctx, &explainReq)
+}
+
+// tune fills the query time relevance settings of req for index
+func (c *Client) tune(ctx context.Context, index string, req *Request) {
+	r, err := c.GetRelevance(ctx, index)
+	if err != nil || r == nil {
+		return
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = r.Fields()
+	}
+	if req.Typo == nil {
+		req.Typo = r.Typo
+	}
+}
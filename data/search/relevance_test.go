@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tunerAdapter records applied relevance settings and searched requests
+type tunerAdapter struct {
+	*fakeAdapter
+	applied  map[string]*Relevance
+	fail     error
+	requests []Request
+}
+
+func (a *tunerAdapter) ApplyRelevance(_ context.Context, index string, r *Relevance) error {
+	if a.fail != nil {
+		return a.fail
+	}
+	a.applied[index] = r
+	return nil
+}
+
+func (a *tunerAdapter) Search(_ context.Context, req *Request) (*Response, error) {
+	a.requests = append(a.requests, *req)
+	hit := Hit{ID: "1", Score: 2}
+	if req.Explain {
+		hit.Explanation = &Explanation{Value: 2, Description: "sum of:"}
+	}
+	return &Response{Total: 1, Hits: []Hit{hit}}, nil
+}
+
+func newTunerClient(t *testing.T) (*Client, *tunerAdapter) {
+	adapter := &tunerAdapter{fakeAdapter: newFakeAdapter(Elasticsearch), applied: make(map[string]*Relevance)}
+	c := NewClientWithPrefix(nil, "app", adapter)
+	t.Cleanup(c.Close)
+	return c, adapter
+}
+
+func TestSetRelevanceVersions(t *testing.T) {
+	c, adapter := newTunerClient(t)
+	ctx := context.Background()
+
+	if _, err := c.SetRelevance(ctx, "posts", Relevance{Boosts: map[string]float64{"title": 0}}); err == nil {
+		t.Fatal("expected invalid boost error")
+	}
+
+	v1, err := c.SetRelevance(ctx, "posts", Relevance{Boosts: map[string]float64{"title": 3, "content": 1}})
+	if err != nil {
+		t.Fatalf("SetRelevance: %v", err)
+	}
+	if v1.Version != 1 || adapter.applied["app-posts"] != v1 {
+		t.Fatalf("v1 = %+v, applied %v", v1, adapter.applied)
+	}
+	v2, _ := c.SetRelevance(ctx, "posts", Relevance{Typo: &TypoTolerance{Enabled: true}})
+	if v2.Version != 2 {
+		t.Fatalf("v2 version = %d", v2.Version)
+	}
+
+	// A failing engine leaves the current version in place
+	adapter.fail = errors.New("closed index")
+	if _, err := c.SetRelevance(ctx, "posts", Relevance{}); err == nil {
+		t.Fatal("expected apply error")
+	}
+	adapter.fail = nil
+	if cur, _ := c.GetRelevance(ctx, "posts"); cur != v2 {
+		t.Fatalf("current = %+v", cur)
+	}
+
+	v3, err := c.RollbackRelevance(ctx, "posts", 1)
+	if err != nil {
+		t.Fatalf("RollbackRelevance: %v", err)
+	}
+	if v3.Version != 3 || v3.Boosts["title"] != 3 {
+		t.Fatalf("rollback = %+v", v3)
+	}
+	if _, err := c.RollbackRelevance(ctx, "posts", 9); !errors.Is(err, ErrRelevanceVersionNotFound) {
+		t.Fatalf("unknown version error = %v", err)
+	}
+	if versions, _ := c.RelevanceVersions(ctx, "posts"); len(versions) != 3 {
+		t.Fatalf("versions = %d", len(versions))
+	}
+}
+
+func TestSearchAppliesRelevance(t *testing.T) {
+	c, adapter := newTunerClient(t)
+	ctx := context.Background()
+
+	_, _ = c.SetRelevance(ctx, "posts", Relevance{
+		Boosts: map[string]float64{"title": 3, "content": 1, "tags": 1.5},
+		Typo:   &TypoTolerance{Enabled: true},
+	})
+
+	resp, err := c.Explain(ctx, &Request{Index: "posts", Query: "go"})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if resp.Hits[0].Explanation == nil {
+		t.Fatal("missing explanation")
+	}
+	req := adapter.requests[0]
+	if strings.Join(req.Fields, ",") != "title^3,tags^1.5,content" || !req.Explain || req.Typo == nil {
+		t.Fatalf("tuned request = %+v", req)
+	}
+
+	body, _ := BuildQuery(&req, []string{"name"})
+	want := `{"query":{"multi_match":{"query":"go","fields":["title^3","tags^1.5","content"],"fuzziness":"AUTO:5,9"}},"explain":true}`
+	if string(body) != want {
+		t.Fatalf("body =\n%s\nwant\n%s", body, want)
+	}
+
+	// Request fields take precedence
+	_, _ = c.Search(ctx, &Request{Index: "posts", Query: "go", Fields: []string{"name"}})
+	if got := adapter.requests[1].Fields; len(got) != 1 || got[0] != "name" {
+		t.Fatalf("fields = %v", got)
+	}
+}
+
+func TestAnalysisSettings(t *testing.T) {
+	data, err := AnalysisSettings(&Relevance{Synonyms: [][]string{{"laptop", "notebook"}}})
+	if err != nil {
+		t.Fatalf("AnalysisSettings: %v", err)
+	}
+	want := `{"analysis":{"analyzer":{"default_search":{"filter":["lowercase","ncore_stop","ncore_synonyms"],"tokenizer":"standard","type":"custom"}},` +
+		`"filter":{"ncore_stop":{"stopwords":"_none_","type":"stop"},"ncore_synonyms":{"synonyms":["laptop, notebook"],"type":"synonym_graph"}}}}`
+	if string(data) != want {
+		t.Fatalf("settings =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestRelevanceRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := newTunerClient(t)
+	engine := gin.New()
+	c.RegisterRelevanceRoutes(engine.Group("/relevance"))
+
+	do := func(method, path, body string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var out map[string]any
+		_ = json.NewDecoder(w.Body).Decode(&out)
+		return w.Code, out
+	}
+
+	if status, out := do(http.MethodPut, "/relevance/posts", `{"stopwords":["the"]}`); status != http.StatusOK || out["version"] != 1.0 {
+		t.Fatalf("PUT = %d %v", status, out)
+	}
+	if status, out := do(http.MethodGet, "/relevance/posts", ""); status != http.StatusOK || len(out["versions"].([]any)) != 1 {
+		t.Fatalf("GET = %d %v", status, out)
+	}
+	if status, _ := do(http.MethodPost, "/relevance/posts/rollback/7", ""); status != http.StatusNotFound {
+		t.Fatalf("rollback status = %d", status)
+	}
+	if status, _ := do(http.MethodPut, "/relevance/posts", `{`); status != http.StatusBadRequest {
+		t.Fatalf("PUT with a malformed body = %d", status)
+	}
+	status, out := do(http.MethodPost, "/relevance/posts/explain", `{"query":"go"}`)
+	if status != http.StatusOK || out["hits"].([]any)[0].(map[string]any)["explanation"] == nil {
+		t.Fatalf("explain = %d %v", status, out)
+	}
+}
@@ -0,0 +1,387 @@
+package search
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSavedSearchNotFound is returned for an unknown saved search
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// SavedSearch is a persisted query alerting its owner on new matches
+type SavedSearch struct {
+	ID      string  `json:"id"`
+	Owner   string  `json:"owner"`
+	Name    string  `json:"name"`
+	Request Request `json:"request"` // Index is unprefixed, Size defaults to 100
+
+	// Interval runs the search on a schedule, not scheduled when zero
+	Interval time.Duration `json:"interval,omitempty"`
+	// OnUpdate runs the search when documents are indexed into Request.Index
+	OnUpdate bool `json:"on_update,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	LastRunAt time.Time `json:"last_run_at,omitempty"`
+}
+
+// Notifier delivers the new hits of a saved search, e.g. through email or an
+// in-app notification service
+type Notifier interface {
+	NotifySavedSearch(ctx context.Context, s *SavedSearch, hits []Hit) error
+}
+
+// NotifierFunc adapts a function to Notifier
+type NotifierFunc func(ctx context.Context, s *SavedSearch, hits []Hit) error
+
+// NotifySavedSearch calls f
+func (f NotifierFunc) NotifySavedSearch(ctx context.Context, s *SavedSearch, hits []Hit) error {
+	return f(ctx, s, hits)
+}
+
+// SavedSearchStore persists saved searches and the hits already notified
+type SavedSearchStore interface {
+	Save(ctx context.Context, s *SavedSearch) error
+	Get(ctx context.Context, id string) (*SavedSearch, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*SavedSearch, error)
+
+	// Unseen returns the hit IDs not yet marked seen for a saved search
+	Unseen(ctx context.Context, id string, hitIDs []string) ([]string, error)
+	// MarkSeen records hit IDs as seen for a saved search
+	MarkSeen(ctx context.Context, id string, hitIDs []string) error
+}
+
+// memorySavedSearchStore keeps saved searches in process
+type memorySavedSearchStore struct {
+	mu       sync.RWMutex
+	searches map[string]*SavedSearch
+	seen     map[string]map[string]struct{}
+}
+
+// NewMemorySavedSearchStore returns an in-process SavedSearchStore
+func NewMemorySavedSearchStore() SavedSearchStore {
+	return &memorySavedSearchStore{
+		searches: make(map[string]*SavedSearch),
+		seen:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (m *memorySavedSearchStore) Save(_ context.Context, s *SavedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *s
+	m.searches[s.ID] = &saved
+	return nil
+}
+
+func (m *memorySavedSearchStore) Get(_ context.Context, id string) (*SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.searches[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSavedSearchNotFound, id)
+	}
+	saved := *s
+	return &saved, nil
+}
+
+func (m *memorySavedSearchStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.searches, id)
+	delete(m.seen, id)
+	return nil
+}
+
+func (m *memorySavedSearchStore) List(_ context.Context) ([]*SavedSearch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]*SavedSearch, 0, len(m.searches))
+	for _, s := range m.searches {
+		saved := *s
+		list = append(list, &saved)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (m *memorySavedSearchStore) Unseen(_ context.Context, id string, hitIDs []string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var unseen []string
+	for _, hitID := range hitIDs {
+		if _, ok := m.seen[id][hitID]; !ok {
+			unseen = append(unseen, hitID)
+		}
+	}
+	return unseen, nil
+}
+
+func (m *memorySavedSearchStore) MarkSeen(_ context.Context, id string, hitIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen, ok := m.seen[id]
+	if !ok {
+		seen = make(map[string]struct{}, len(hitIDs))
+		m.seen[id] = seen
+	}
+	for _, hitID := range hitIDs {
+		seen[hitID] = struct{}{}
+	}
+	return nil
+}
+
+// SavedSearches runs saved searches on their schedule and on index updates and
+// notifies their owners of hits not notified before. The first run of a saved
+// search records its current hits without notifying.
+type SavedSearches struct {
+	client   *Client
+	store    SavedSearchStore
+	notifier Notifier
+	onError  func(s *SavedSearch, err error)
+
+	mu      sync.Mutex
+	running map[string]bool // Saved search ID to whether another run is pending
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewSavedSearches returns saved searches run against c, kept in store and
+// notified through notifier. A nil store keeps them in process.
+func NewSavedSearches(c *Client, store SavedSearchStore, notifier Notifier) *SavedSearches {
+	if store == nil {
+		store = NewMemorySavedSearchStore()
+	}
+	s := &SavedSearches{
+		client:   c,
+		store:    store,
+		notifier: notifier,
+		running:  make(map[string]bool),
+	}
+	c.OnIndexUpdate(s.indexUpdated)
+	return s
+}
+
+// OnError sets a callback for errors of background runs, which are otherwise dropped
+func (s *SavedSearches) OnError(fn func(saved *SavedSearch, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = fn
+}
+
+// Create validates and persists a saved search, assigning its ID
+func (s *SavedSearches) Create(ctx context.Context, saved *SavedSearch) error {
+	if saved.Request.Index == "" {
+		return errors.New("saved search needs an index")
+	}
+	if saved.Interval < 0 {
+		return fmt.Errorf("invalid saved search interval %s", saved.Interval)
+	}
+	if saved.ID == "" {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Errorf("failed to generate saved search id: %w", err)
+		}
+		saved.ID = hex.EncodeToString(b)
+	}
+	saved.CreatedAt = time.Now()
+	saved.LastRunAt = time.Time{}
+	return s.store.Save(ctx, saved)
+}
+
+// Get returns a saved search
+func (s *SavedSearches) Get(ctx context.Context, id string) (*SavedSearch, error) {
+	return s.store.Get(ctx, id)
+}
+
+// Delete removes a saved search and its seen hits
+func (s *SavedSearches) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// List returns the saved searches of owner, all when owner is empty
+func (s *SavedSearches) List(ctx context.Context, owner string) ([]*SavedSearch, error) {
+	all, err := s.store.List(ctx)
+	if err != nil || owner == "" {
+		return all, err
+	}
+	var list []*SavedSearch
+	for _, saved := range all {
+		if saved.Owner == owner {
+			list = append(list, saved)
+		}
+	}
+	return list, nil
+}
+
+// Run runs a saved search now, notifies its new hits and returns them. Hits are
+// marked seen only once the notification succeeded, so a failed one is retried
+// by the next run.
+func (s *SavedSearches) Run(ctx context.Context, id string) ([]Hit, error) {
+	saved, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := saved.Request
+	if req.Size <= 0 {
+		req.Size = 100
+	}
+	resp, err := s.client.Search(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run saved search %s: %w", id, err)
+	}
+
+	ids := make([]string, len(resp.Hits))
+	for i, hit := range resp.Hits {
+		ids[i] = hit.ID
+	}
+	unseen, err := s.store.Unseen(ctx, id, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load seen hits: %w", err)
+	}
+
+	var hits []Hit
+	if !saved.LastRunAt.IsZero() && len(unseen) > 0 {
+		isNew := make(map[string]bool, len(unseen))
+		for _, hitID := range unseen {
+			isNew[hitID] = true
+		}
+		for _, hit := range resp.Hits {
+			if isNew[hit.ID] {
+				hits = append(hits, hit)
+			}
+		}
+		if s.notifier != nil {
+			if err := s.notifier.NotifySavedSearch(ctx, saved, hits); err != nil {
+				return nil, fmt.Errorf("failed to notify saved search %s: %w", id, err)
+			}
+		}
+	}
+
+	if err := s.store.MarkSeen(ctx, id, unseen); err != nil {
+		return nil, fmt.Errorf("failed to mark hits seen: %w", err)
+	}
+	saved.LastRunAt = time.Now()
+	if err := s.store.Save(ctx, saved); err != nil {
+		return nil, fmt.Errorf("failed to save saved search: %w", err)
+	}
+	return hits, nil
+}
+
+// Start runs the scheduled saved searches that are due every tick, one minute
+// by default, until Stop
+func (s *SavedSearches) Start(tick time.Duration) {
+	if tick <= 0 {
+		tick = time.Minute
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for background runs to finish
+func (s *SavedSearches) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// runDue runs the scheduled saved searches whose interval elapsed
+func (s *SavedSearches) runDue(now time.Time) {
+	list, err := s.store.List(context.Background())
+	if err != nil {
+		s.reportError(nil, err)
+		return
+	}
+	for _, saved := range list {
+		if saved.Interval > 0 && now.Sub(saved.LastRunAt) >= saved.Interval {
+			s.runAsync(saved)
+		}
+	}
+}
+
+// indexUpdated runs the saved searches on index watching updates
+func (s *SavedSearches) indexUpdated(ctx context.Context, index string) {
+	list, err := s.store.List(context.WithoutCancel(ctx))
+	if err != nil {
+		s.reportError(nil, err)
+		return
+	}
+	for _, saved := range list {
+		if saved.OnUpdate && saved.Request.Index == index {
+			s.runAsync(saved)
+		}
+	}
+}
+
+// runAsync runs a saved search in the background. A search already running is
+// run once more when it finishes instead of concurrently.
+func (s *SavedSearches) runAsync(saved *SavedSearch) {
+	s.mu.Lock()
+	if _, running := s.running[saved.ID]; running {
+		s.running[saved.ID] = true
+		s.mu.Unlock()
+		return
+	}
+	s.running[saved.ID] = false
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		for {
+			if _, err := s.Run(context.Background(), saved.ID); err != nil && !errors.Is(err, ErrSavedSearchNotFound) {
+				s.reportError(saved, err)
+			}
+
+			s.mu.Lock()
+			if !s.running[saved.ID] {
+				delete(s.running, saved.ID)
+				s.mu.Unlock()
+				return
+			}
+			s.running[saved.ID] = false
+			s.mu.Unlock()
+		}
+	}()
+}
+
+func (s *SavedSearches) reportError(saved *SavedSearch, err error) {
+	s.mu.Lock()
+	fn := s.onError
+	s.mu.Unlock()
+	if fn != nil {
+		fn(saved, err)
+	}
+}
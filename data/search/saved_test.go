@@ -0,0 +1,144 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hitsAdapter returns the documents indexed so far as hits
+type hitsAdapter struct {
+	*fakeAdapter
+	mu   sync.Mutex
+	hits map[string][]Hit
+}
+
+func (a *hitsAdapter) Search(_ context.Context, req *Request) (*Response, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hits := append([]Hit(nil), a.hits[req.Index]...)
+	return &Response{Total: int64(len(hits)), Hits: hits}, nil
+}
+
+func (a *hitsAdapter) Index(_ context.Context, req *IndexRequest) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hits[req.Index] = append(a.hits[req.Index], Hit{ID: req.DocumentID})
+	return nil
+}
+
+// notifications records notified hit IDs
+type notifications struct {
+	mu   sync.Mutex
+	fail error
+	got  [][]string
+	sent chan struct{}
+}
+
+func (n *notifications) NotifySavedSearch(_ context.Context, _ *SavedSearch, hits []Hit) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.fail != nil {
+		return n.fail
+	}
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	n.got = append(n.got, ids)
+	if n.sent != nil {
+		n.sent <- struct{}{}
+	}
+	return nil
+}
+
+func newSavedSearches(t *testing.T) (*SavedSearches, *Client, *notifications) {
+	adapter := &hitsAdapter{fakeAdapter: newFakeAdapter(Elasticsearch), hits: make(map[string][]Hit)}
+	c := NewClientWithConfig(nil, &Config{DefaultEngine: string(Elasticsearch)}, adapter)
+	n := &notifications{}
+	s := NewSavedSearches(c, nil, n)
+	t.Cleanup(func() {
+		s.Stop()
+		c.Close()
+	})
+	return s, c, n
+}
+
+func TestSavedSearchDedup(t *testing.T) {
+	s, c, n := newSavedSearches(t)
+	ctx := context.Background()
+
+	_ = c.Index(ctx, &IndexRequest{Index: "posts", DocumentID: "1"})
+	saved := &SavedSearch{Owner: "u1", Name: "go posts", Request: Request{Index: "posts", Query: "go"}}
+	if err := s.Create(ctx, saved); err != nil || saved.ID == "" {
+		t.Fatalf("Create: %v, id %q", err, saved.ID)
+	}
+
+	// The first run records existing hits without notifying
+	if hits, err := s.Run(ctx, saved.ID); err != nil || len(hits) != 0 || len(n.got) != 0 {
+		t.Fatalf("first run = %v, %v, notified %v", hits, err, n.got)
+	}
+
+	_ = c.Index(ctx, &IndexRequest{Index: "posts", DocumentID: "2"})
+	n.fail = errors.New("smtp down")
+	if _, err := s.Run(ctx, saved.ID); err == nil {
+		t.Fatal("expected notify error")
+	}
+
+	// A failed notification is retried, then hits are not notified again
+	n.fail = nil
+	if hits, err := s.Run(ctx, saved.ID); err != nil || len(hits) != 1 || hits[0].ID != "2" {
+		t.Fatalf("retry = %v, %v", hits, err)
+	}
+	if hits, _ := s.Run(ctx, saved.ID); len(hits) != 0 {
+		t.Fatalf("seen hits notified again: %v", hits)
+	}
+
+	if list, _ := s.List(ctx, "u2"); len(list) != 0 {
+		t.Fatalf("other owner list = %v", list)
+	}
+	_ = s.Delete(ctx, saved.ID)
+	if _, err := s.Run(ctx, saved.ID); !errors.Is(err, ErrSavedSearchNotFound) {
+		t.Fatalf("deleted run error = %v", err)
+	}
+}
+
+func TestSavedSearchTriggers(t *testing.T) {
+	s, c, n := newSavedSearches(t)
+	n.sent = make(chan struct{}, 4)
+	ctx := context.Background()
+
+	onUpdate := &SavedSearch{Request: Request{Index: "posts"}, OnUpdate: true}
+	scheduled := &SavedSearch{Request: Request{Index: "events"}, Interval: time.Millisecond}
+	_ = s.Create(ctx, onUpdate)
+	_ = s.Create(ctx, scheduled)
+	_, _ = s.Run(ctx, onUpdate.ID)
+	_, _ = s.Run(ctx, scheduled.ID)
+
+	wait := func() {
+		t.Helper()
+		select {
+		case <-n.sent:
+		case <-time.After(2 * time.Second):
+			t.Fatal("no notification")
+		}
+	}
+
+	// Indexing runs the saved search watching the index
+	_ = c.Index(ctx, &IndexRequest{Index: "posts", DocumentID: "1"})
+	wait()
+
+	// The scheduler runs the due search, which sees the new document too
+	_ = c.Index(ctx, &IndexRequest{Index: "events", DocumentID: "2"})
+	s.Start(5 * time.Millisecond)
+	wait()
+	s.Stop()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.got) != 2 || n.got[0][0] != "1" || n.got[1][0] != "2" {
+		t.Fatalf("notified = %v", n.got)
+	}
+}
@@ -6,6 +6,11 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/ncobase/ncore/data/hedge"
+	"github.com/ncobase/ncore/data/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Search engine error definitions
@@ -22,6 +27,7 @@ const (
 	Elasticsearch Engine = "elasticsearch"
 	OpenSearch    Engine = "opensearch"
 	Meilisearch   Engine = "meilisearch"
+	Memory        Engine = "memory" // In-process engine, see data/search/memory
 )
 
 // Config represents search engine configuration
@@ -30,6 +36,8 @@ type Config struct {
 	DefaultEngine   string
 	AutoCreateIndex bool
 	IndexSettings   *IndexSettings
+	Failover        *Failover
+	Hedge           *hedge.Policy // Sends slow searches to a second engine as well
 }
 
 // IndexSettings represents default index configuration
@@ -57,9 +65,20 @@ func (NoOpCollector) SearchIndex(string, string) {}
 type Request struct {
 	Index  string         `json:"index"`
 	Query  string         `json:"query"`
-	Filter map[string]any `json:"filter,omitempty"`
+	Filter map[string]any `json:"filter,omitempty"` // Exact values, slices match any, Range bounds
 	From   int            `json:"from,omitempty"`
 	Size   int            `json:"size,omitempty"`
+
+	Sort      []SortField `json:"sort,omitempty"`      // Applied in order, relevance when empty
+	Highlight *Highlight  `json:"highlight,omitempty"` // Highlighted fragments returned in Hit.Highlight
+	Source    []string    `json:"source,omitempty"`    // Source fields to return, all when empty
+
+	// Relevance tuning, filled from the index's Relevance when empty
+	Fields  []string       `json:"fields,omitempty"`  // Fields matched with boosts, e.g. "title^2", engine defaults when empty
+	Typo    *TypoTolerance `json:"typo,omitempty"`    // Typo tolerance, off when nil
+	Explain bool           `json:"explain,omitempty"` // Return score breakdowns in Hit.Explanation
+
+	Debug bool `json:"debug,omitempty"` // Return the raw engine query, phase timings and profile in Response.Debug
 }
 
 // Response represents a search query response
@@ -68,13 +87,18 @@ type Response struct {
 	Hits     []Hit         `json:"hits"`
 	Duration time.Duration `json:"duration"`
 	Engine   Engine        `json:"engine"`
+
+	Debug *Debug `json:"debug,omitempty"` // Set when Request.Debug is
 }
 
 // Hit represents a single search result
 type Hit struct {
-	ID     string         `json:"id"`
-	Score  float64        `json:"score"`
-	Source map[string]any `json:"source"`
+	ID        string              `json:"id"`
+	Score     float64             `json:"score"`
+	Source    map[string]any      `json:"source"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+
+	Explanation *Explanation `json:"explanation,omitempty"` // Set when Request.Explain is
 }
 
 // IndexRequest represents a document indexing request
@@ -106,6 +130,24 @@ type Client struct {
 	cacheMu      sync.RWMutex
 	indexPrefix  string
 	searchConfig *Config
+
+	// Relevance versions and current settings by index
+	relevanceMu    sync.Mutex
+	relevanceStore RelevanceStore
+	relevance      map[string]*Relevance
+
+	// Listeners called after documents are indexed and on engine switches
+	hooksMu     sync.RWMutex
+	updateHooks []func(ctx context.Context, index string)
+	switchHooks []func(EngineSwitch)
+
+	// Engine selection and failover state
+	mu        sync.RWMutex
+	failures  map[Engine]int
+	scores    map[Engine]*engineScore
+	probeStop chan struct{}
+
+	hedger *hedge.Hedger // nil unless hedging is enabled
 }
 
 // NewClient creates a new search client with provided adapters
@@ -150,9 +192,17 @@ func NewClientWithConfig(collector Collector, searchConfig *Config, adapters ...
 		indexCache:   make(map[string]bool),
 		indexPrefix:  searchConfig.IndexPrefix,
 		searchConfig: searchConfig,
+		failures:     make(map[Engine]int),
+		scores:       make(map[Engine]*engineScore),
+
+		relevanceStore: &memoryRelevanceStore{versions: make(map[string][]*Relevance)},
+		relevance:      make(map[string]*Relevance),
+
+		hedger: hedge.New(searchConfig.Hedge),
 	}
 
 	c.setEngine()
+	c.startProbe()
 	return c
 }
 
@@ -180,6 +230,7 @@ func (c *Client) UpdateSearchConfig(searchConfig *Config) {
 		c.SetIndexPrefix(searchConfig.IndexPrefix)
 	}
 	c.setEngine()
+	c.startProbe()
 }
 
 func (c *Client) buildIndexName(index string) string {
@@ -193,59 +244,40 @@ func (c *Client) setEngine() {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	// Use configured default engine if specified and available
-	if c.searchConfig != nil && c.searchConfig.DefaultEngine != "" {
-		eng := Engine(c.searchConfig.DefaultEngine)
-		if adapter, ok := c.adapters[eng]; ok {
-			if adapter.Health(ctx) == nil {
-				c.engine = eng
-				return
-			}
-		}
-	}
-
-	// Fallback to priority order
-	// Priority: OpenSearch > Elasticsearch > Meilisearch
-	priority := []Engine{OpenSearch, Elasticsearch, Meilisearch}
-
-	for _, eng := range priority {
-		if adapter, ok := c.adapters[eng]; ok {
-			if adapter.Health(ctx) == nil {
-				c.engine = eng
-				return
-			}
-		}
-	}
-
-	// Fallback to any available
-	for eng, adapter := range c.adapters {
-		if adapter.Health(ctx) == nil {
+	// Default engine first, then OpenSearch > Elasticsearch > Meilisearch
+	for _, eng := range c.enginePriority() {
+		if c.adapters[eng].Health(ctx) == nil {
+			c.mu.Lock()
 			c.engine = eng
+			c.mu.Unlock()
 			return
 		}
 	}
 }
 
 func (c *Client) getAdapter() (Adapter, error) {
-	if c.engine == "" {
+	engine := c.GetEngine()
+	if engine == "" {
 		c.setEngine() // Try to set engine if not set
-		if c.engine == "" {
+		if engine = c.GetEngine(); engine == "" {
 			return nil, ErrNoEngineAvailable
 		}
 	}
 
-	if adapter, ok := c.adapters[c.engine]; ok {
+	if adapter, ok := c.adapters[engine]; ok {
 		return adapter, nil
 	}
 	return nil, ErrEngineNotFound
 }
 
 func (c *Client) Search(ctx context.Context, req *Request) (*Response, error) {
-	_, err := c.getAdapter()
-	if err != nil {
-		return nil, err
-	}
-	return c.SearchWith(ctx, c.engine, req)
+	var resp *Response
+	err := c.withFailover(ctx, func(engine Engine) error {
+		var err error
+		resp, err = c.hedgedSearch(ctx, engine, req)
+		return err
+	})
+	return resp, err
 }
 
 func (c *Client) SearchWith(ctx context.Context, engine Engine, req *Request) (*Response, error) {
@@ -259,8 +291,14 @@ func (c *Client) SearchWith(ctx context.Context, engine Engine, req *Request) (*
 	fullIndex := c.buildIndexName(req.Index)
 	prefixedReq := *req
 	prefixedReq.Index = fullIndex
+	c.tune(ctx, req.Index, &prefixedReq)
 
+	ctx, span := startSpan(ctx, engine, "search", fullIndex)
 	resp, err := adapter.Search(ctx, &prefixedReq)
+	if resp != nil {
+		span.SetAttributes(attribute.Int64("search.hits", resp.Total))
+	}
+	tracing.End(span, err)
 
 	// Collect metrics
 	duration := time.Since(start)
@@ -270,16 +308,15 @@ func (c *Client) SearchWith(ctx context.Context, engine Engine, req *Request) (*
 		resp.Duration = duration
 		resp.Engine = engine
 	}
+	finishDebug(&prefixedReq, resp, engine, duration)
 
 	return resp, err
 }
 
 func (c *Client) Index(ctx context.Context, req *IndexRequest) error {
-	_, err := c.getAdapter()
-	if err != nil {
-		return err
-	}
-	return c.IndexWith(ctx, c.engine, req)
+	return c.withFailover(ctx, func(engine Engine) error {
+		return c.IndexWith(ctx, engine, req)
+	})
 }
 
 func (c *Client) IndexWith(ctx context.Context, engine Engine, req *IndexRequest) error {
@@ -295,43 +332,47 @@ func (c *Client) IndexWith(ctx context.Context, engine Engine, req *IndexRequest
 	prefixedReq := *req
 	prefixedReq.Index = fullIndex
 
+	ctx, span := startSpan(ctx, engine, "index", fullIndex)
 	if c.shouldAutoCreateIndex() {
-		if err := c.ensureIndex(ctx, engine, fullIndex); err != nil {
-			return fmt.Errorf("failed to ensure index exists: %w", err)
+		if err := c.ensureIndex(ctx, engine, req.Index); err != nil {
+			err = fmt.Errorf("failed to ensure index exists: %w", err)
+			tracing.End(span, err)
+			return err
 		}
 	}
 
 	err := adapter.Index(ctx, &prefixedReq)
+	tracing.End(span, err)
 
 	// Collect metrics
 	duration := time.Since(start)
-	c.collectMetrics("index", err, duration)
+	c.collectMetrics(engine, "index", err, duration)
+	if err == nil {
+		c.indexUpdated(ctx, req.Index)
+	}
 	return err
 }
 
 func (c *Client) Delete(ctx context.Context, index, documentID string) error {
-	adapter, err := c.getAdapter()
-	if err != nil {
-		return err
-	}
+	return c.withFailover(ctx, func(engine Engine) error {
+		start := time.Now()
+		fullIndex := c.buildIndexName(index)
 
-	start := time.Now()
-	fullIndex := c.buildIndexName(index)
+		ctx, span := startSpan(ctx, engine, "delete", fullIndex)
+		err := c.adapters[engine].Delete(ctx, fullIndex, documentID)
+		tracing.End(span, err)
 
-	err = adapter.Delete(ctx, fullIndex, documentID)
-
-	// Collect metrics
-	duration := time.Since(start)
-	c.collectMetrics("delete", err, duration)
-	return err
+		// Collect metrics
+		duration := time.Since(start)
+		c.collectMetrics(engine, "delete", err, duration)
+		return err
+	})
 }
 
 func (c *Client) BulkIndex(ctx context.Context, index string, documents []any) error {
-	_, err := c.getAdapter()
-	if err != nil {
-		return err
-	}
-	return c.BulkIndexWith(ctx, c.engine, index, documents)
+	return c.withFailover(ctx, func(engine Engine) error {
+		return c.BulkIndexWith(ctx, engine, index, documents)
+	})
 }
 
 func (c *Client) BulkIndexWith(ctx context.Context, engine Engine, index string, documents []any) error {
@@ -343,35 +384,61 @@ func (c *Client) BulkIndexWith(ctx context.Context, engine Engine, index string,
 	start := time.Now()
 	fullIndex := c.buildIndexName(index)
 
+	ctx, span := startSpan(ctx, engine, "bulk_index", fullIndex)
+	span.SetAttributes(attribute.Int("search.documents", len(documents)))
 	if c.shouldAutoCreateIndex() {
-		if err := c.ensureIndex(ctx, engine, fullIndex); err != nil {
-			return fmt.Errorf("failed to ensure index exists: %w", err)
+		if err := c.ensureIndex(ctx, engine, index); err != nil {
+			err = fmt.Errorf("failed to ensure index exists: %w", err)
+			tracing.End(span, err)
+			return err
 		}
 	}
 
 	err := adapter.BulkIndex(ctx, fullIndex, documents)
+	tracing.End(span, err)
 
 	// Collect metrics
 	duration := time.Since(start)
-	c.collectMetrics("bulk_index", err, duration)
+	c.collectMetrics(engine, "bulk_index", err, duration)
+	if err == nil {
+		c.indexUpdated(ctx, index)
+	}
 	return err
 }
 
-func (c *Client) BulkDelete(ctx context.Context, index string, documentIDs []string) error {
-	adapter, err := c.getAdapter()
-	if err != nil {
-		return err
-	}
-
-	start := time.Now()
-	fullIndex := c.buildIndexName(index)
+// OnIndexUpdate registers fn to be called with the unprefixed index after
+// documents are indexed into it
+func (c *Client) OnIndexUpdate(fn func(ctx context.Context, index string)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.updateHooks = append(c.updateHooks, fn)
+}
 
-	err = adapter.BulkDelete(ctx, fullIndex, documentIDs)
+// indexUpdated calls the index update listeners
+func (c *Client) indexUpdated(ctx context.Context, index string) {
+	c.hooksMu.RLock()
+	hooks := c.updateHooks
+	c.hooksMu.RUnlock()
+	for _, fn := range hooks {
+		fn(ctx, index)
+	}
+}
 
-	// Collect metrics
-	duration := time.Since(start)
-	c.collectMetrics("bulk_delete", err, duration)
-	return err
+func (c *Client) BulkDelete(ctx context.Context, index string, documentIDs []string) error {
+	return c.withFailover(ctx, func(engine Engine) error {
+		start := time.Now()
+		fullIndex := c.buildIndexName(index)
+
+		ctx, span := startSpan(ctx, engine, "bulk_delete", fullIndex)
+		span.SetAttributes(attribute.Int("search.documents", len(documentIDs)))
+		err := c.adapters[engine].BulkDelete(ctx, fullIndex, documentIDs)
+		tracing.End(span, err)
+
+		// Collect metrics
+		duration := time.Since(start)
+		c.collectMetrics(engine, "bulk_delete", err, duration)
+		return err
+	})
 }
 
 func (c *Client) shouldAutoCreateIndex() bool {
@@ -393,6 +460,8 @@ func (c *Client) GetAvailableEngines() []Engine {
 }
 
 func (c *Client) GetEngine() Engine {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.engine
 }
 
@@ -406,18 +475,28 @@ func (c *Client) Health(ctx context.Context) map[Engine]error {
 	return results
 }
 
-func (c *Client) collectMetrics(operation string, err error, duration time.Duration) {
+func (c *Client) collectMetrics(engine Engine, operation string, err error, duration time.Duration) {
 	if c.collector == nil {
 		return
 	}
 
-	c.collector.SearchQuery(string(c.engine), err)
+	c.collector.SearchQuery(string(engine), err)
 	if err == nil {
-		c.collector.SearchIndex(string(c.engine), operation)
+		c.collector.SearchIndex(string(engine), operation)
 	}
 }
 
-func (c *Client) ensureIndex(ctx context.Context, engine Engine, indexName string) error {
+// startSpan starts the span of an engine operation on an index
+func startSpan(ctx context.Context, engine Engine, operation, index string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "search."+operation,
+		tracing.DBSystem.String(string(engine)),
+		tracing.DBOperation.String(operation),
+		attribute.String("search.index", index),
+	)
+}
+
+func (c *Client) ensureIndex(ctx context.Context, engine Engine, index string) error {
+	indexName := c.buildIndexName(index)
 	cacheKey := fmt.Sprintf("%s:%s", engine, indexName)
 
 	c.cacheMu.RLock()
@@ -449,6 +528,14 @@ func (c *Client) ensureIndex(ctx context.Context, engine Engine, indexName strin
 		return fmt.Errorf("failed to create index: %w", err)
 	}
 
+	if tuner, ok := adapter.(RelevanceTuner); ok {
+		if r, err := c.GetRelevance(ctx, index); err == nil && r != nil {
+			if err := tuner.ApplyRelevance(ctx, indexName, r); err != nil {
+				return fmt.Errorf("failed to apply relevance: %w", err)
+			}
+		}
+	}
+
 	c.cacheMu.Lock()
 	c.indexCache[cacheKey] = true
 	c.cacheMu.Unlock()
@@ -1,9 +1,13 @@
 package data
 
 import (
+	"fmt"
+
 	"github.com/ncobase/ncore/data/config"
+	"github.com/ncobase/ncore/data/hedge"
 	"github.com/ncobase/ncore/data/metrics"
 	"github.com/ncobase/ncore/data/search"
+	"github.com/ncobase/ncore/data/search/memory"
 )
 
 // SearchCollectorAdapter adapts data/metrics.Collector to data/search.Collector
@@ -21,9 +25,26 @@ func (a *SearchCollectorAdapter) SearchIndex(engine, operation string) {
 	a.collector.SearchIndex(engine, operation)
 }
 
+// SearchFailover records a search engine failover if the collector supports it
+func (a *SearchCollectorAdapter) SearchFailover(from, to, reason string) {
+	if fc, ok := a.collector.(search.FailoverCollector); ok {
+		fc.SearchFailover(from, to, reason)
+	}
+}
+
+// SearchHedge records a hedged search if the collector supports it
+func (a *SearchCollectorAdapter) SearchHedge(primary, hedge string, won bool) {
+	if hc, ok := a.collector.(search.HedgeCollector); ok {
+		hc.SearchHedge(primary, hedge, won)
+	}
+}
+
 // NewSearchClient creates a search client from ncore data layer.
 // It automatically detects and creates adapters for available search engines.
 //
+// The in-memory engine is used when no other engine is available or it is the default
+// engine, unless data.search.memory.enabled is false.
+//
 // Returns nil if no search engines are available.
 // Applications should check if the returned client is nil to support optional search functionality.
 func NewSearchClient(d *Data, collector ...metrics.Collector) *search.Client {
@@ -63,6 +84,17 @@ func NewSearchClient(d *Data, collector ...metrics.Collector) *search.Client {
 		}
 	}
 
+	// Fall back to the in-memory engine
+	if len(adapters) == 0 || d.conf != nil && d.conf.Search != nil && d.conf.Search.DefaultEngine == string(search.Memory) {
+		if factory, err := search.GetAdapterFactory(search.Memory); err == nil {
+			if ms := d.GetMemorySearch(); ms != nil {
+				if adapter, err := factory(ms); err == nil {
+					adapters = append(adapters, adapter)
+				}
+			}
+		}
+	}
+
 	// Return nil if no adapters are available
 	if len(adapters) == 0 {
 		return nil
@@ -78,6 +110,37 @@ func NewSearchClient(d *Data, collector ...metrics.Collector) *search.Client {
 	return client
 }
 
+// GetMemorySearch returns the in-memory search engine, created on first use.
+// Returns nil if it is disabled, fails to load its snapshots or the data layer is closed.
+func (d *Data) GetMemorySearch() *memory.Adapter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	if d.memorySearch != nil {
+		return d.memorySearch
+	}
+
+	opts := memory.Options{}
+	if d.conf != nil && d.conf.Search != nil && d.conf.Search.Memory != nil {
+		cfg := d.conf.Search.Memory
+		if !cfg.Enabled {
+			return nil
+		}
+		opts = memory.Options{Dir: cfg.Path, FlushInterval: cfg.FlushInterval}
+	}
+
+	ms, err := memory.NewAdapter(opts)
+	if err != nil {
+		fmt.Printf("failed to create memory search engine: %v\n", err)
+		return nil
+	}
+	d.memorySearch = ms
+	return ms
+}
+
 // adaptSearchConfig converts config layer search config to search module config
 func adaptSearchConfig(cfg *config.Search) *search.Config {
 	if cfg == nil {
@@ -88,6 +151,38 @@ func adaptSearchConfig(cfg *config.Search) *search.Config {
 		DefaultEngine:   cfg.DefaultEngine,
 		AutoCreateIndex: cfg.AutoCreateIndex,
 		IndexSettings:   adaptIndexSettings(cfg.IndexSettings),
+		Failover:        adaptFailover(cfg.Failover),
+		Hedge:           adaptHedge(cfg.Hedge),
+	}
+}
+
+// adaptFailover converts config layer failover settings to search module failover settings
+func adaptFailover(f *config.Failover) *search.Failover {
+	if f == nil {
+		return nil
+	}
+	return &search.Failover{
+		Enabled:        f.Enabled,
+		ErrorThreshold: f.ErrorThreshold,
+		ProbeInterval:  f.ProbeInterval,
+		MinScore:       f.MinScore,
+		FailbackScore:  f.FailbackScore,
+		SlowThreshold:  f.SlowThreshold,
+	}
+}
+
+// adaptHedge converts config layer hedge settings to a hedge policy
+func adaptHedge(h *config.Hedge) *hedge.Policy {
+	if h == nil {
+		return nil
+	}
+	return &hedge.Policy{
+		Enabled:    h.Enabled,
+		Percentile: h.Percentile,
+		MinDelay:   h.MinDelay,
+		MaxDelay:   h.MaxDelay,
+		MaxRate:    h.MaxRate,
+		Window:     h.Window,
 	}
 }
 
@@ -7,6 +7,9 @@
 //
 // The driver supports standard sql.DB connection pooling and configuration options
 // including max idle connections, max open connections, and connection lifetime.
+// Pragmas are set on every connection from config.DBNode.SQLite, see DSN, and
+// file databases default to WAL journaling with a 5s busy timeout. WriterFor
+// serializes writes within the process.
 //
 // Example usage:
 //
@@ -32,6 +35,7 @@ import (
 
 	"github.com/ncobase/ncore/data"
 	"github.com/ncobase/ncore/data/config"
+	"github.com/ncobase/ncore/data/tracing"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
@@ -51,6 +55,7 @@ func (d *driver) Name() string {
 //   - MaxIdleConn: Maximum number of idle connections (default: 2)
 //   - MaxOpenConn: Maximum number of open connections (recommended: 1 for SQLite)
 //   - ConnMaxLifetime: Maximum connection lifetime
+//   - SQLite: Journal mode, busy timeout, foreign keys, synchronous and cache size pragmas
 //
 // Example connection strings:
 //
@@ -77,7 +82,7 @@ func (d *driver) Connect(ctx context.Context, cfg any) (any, error) {
 	}
 
 	// Open connection using sqlite3 driver
-	db, err := sql.Open("sqlite3", dbCfg.Source)
+	db, err := tracing.OpenDB("sqlite3", DSN(dbCfg.Source, dbCfg.SQLite), "sqlite")
 	if err != nil {
 		return nil, fmt.Errorf("sqlite: failed to open connection: %w", err)
 	}
@@ -115,6 +120,7 @@ func (d *driver) Close(conn any) error {
 	if !ok {
 		return fmt.Errorf("sqlite: invalid connection type, expected *sql.DB")
 	}
+	release(db)
 
 	if err := db.Close(); err != nil {
 		return fmt.Errorf("sqlite: failed to close connection: %w", err)
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ncobase/ncore/data/config"
+)
+
+// Defaults applied to file databases, avoiding "database is locked" errors under concurrency
+const (
+	defaultJournalMode   = "WAL"
+	defaultBusyTimeoutMs = 5000
+)
+
+// pragmaParams are the go-sqlite3 connection parameters of each pragma, the first
+// one being set and the others aliases found in sources
+var pragmaParams = map[string][]string{
+	"journal_mode": {"_journal_mode", "_journal"},
+	"busy_timeout": {"_busy_timeout", "_timeout"},
+	"foreign_keys": {"_foreign_keys", "_fk"},
+	"synchronous":  {"_synchronous", "_sync"},
+	"cache_size":   {"_cache_size"},
+}
+
+// DSN returns source with the pragmas of opts added as go-sqlite3 connection
+// parameters, so they apply to every pooled connection. Parameters already in
+// source take precedence. File databases default to WAL journaling, a 5s busy
+// timeout and NORMAL synchronous in WAL mode; opts may be nil.
+func DSN(source string, opts *config.SQLite) string {
+	if opts == nil {
+		opts = &config.SQLite{}
+	}
+
+	path, rawQuery, _ := strings.Cut(source, "?")
+	existing, _ := url.ParseQuery(rawQuery)
+	memory := isMemory(path, existing)
+
+	journal := strings.ToUpper(opts.JournalMode)
+	if journal == "" && !memory {
+		journal = defaultJournalMode
+	}
+	if current := param(existing, "journal_mode"); current != "" {
+		journal = strings.ToUpper(current)
+	}
+
+	busyTimeout := defaultBusyTimeoutMs
+	if opts.BusyTimeout > 0 {
+		busyTimeout = int(opts.BusyTimeout.Milliseconds())
+	}
+
+	synchronous := strings.ToUpper(opts.Synchronous)
+	if synchronous == "" && journal == "WAL" {
+		synchronous = "NORMAL"
+	}
+
+	values := map[string]string{
+		"journal_mode": journal,
+		"busy_timeout": strconv.Itoa(busyTimeout),
+		"synchronous":  synchronous,
+	}
+	if opts.ForeignKeys {
+		values["foreign_keys"] = "true"
+	}
+	if opts.CacheSize != 0 {
+		values["cache_size"] = strconv.Itoa(opts.CacheSize)
+	}
+
+	var added []string
+	for _, pragma := range []string{"journal_mode", "busy_timeout", "foreign_keys", "synchronous", "cache_size"} {
+		if v := values[pragma]; v != "" && param(existing, pragma) == "" {
+			added = append(added, pragmaParams[pragma][0]+"="+url.QueryEscape(v))
+		}
+	}
+	if len(added) == 0 {
+		return source
+	}
+	if rawQuery != "" {
+		return source + "&" + strings.Join(added, "&")
+	}
+	return path + "?" + strings.Join(added, "&")
+}
+
+// param returns the value of a pragma's parameter or its aliases in query
+func param(query url.Values, pragma string) string {
+	for _, name := range pragmaParams[pragma] {
+		if v := query.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// isMemory reports whether a source opens an in-memory database, which has no WAL
+func isMemory(path string, query url.Values) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file::memory:") || query.Get("mode") == "memory"
+}
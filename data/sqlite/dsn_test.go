@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data/config"
+)
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		opts   *config.SQLite
+		want   string
+	}{
+		{"file defaults", "app.db", nil,
+			"app.db?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL"},
+		{"memory has no WAL", ":memory:", nil,
+			":memory:?_busy_timeout=5000"},
+		{"source params win", "file:app.db?cache=shared&_fk=1&_journal=DELETE",
+			&config.SQLite{ForeignKeys: true, BusyTimeout: 10 * time.Second},
+			"file:app.db?cache=shared&_fk=1&_journal=DELETE&_busy_timeout=10000"},
+		{"options", "file:app.db",
+			&config.SQLite{JournalMode: "truncate", ForeignKeys: true, Synchronous: "full", CacheSize: -20000},
+			"file:app.db?_journal_mode=TRUNCATE&_busy_timeout=5000&_foreign_keys=true&_synchronous=FULL&_cache_size=-20000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DSN(tt.source, tt.opts); got != tt.want {
+				t.Fatalf("DSN = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// writers are the shared Writer of each database
+var writers sync.Map // *sql.DB -> *Writer
+
+// Writer serializes the writes of a process to a SQLite database. SQLite allows a
+// single writer at a time, so concurrent writes through a pool wait on each other
+// in the busy handler and fail with "database is locked" once it times out, while
+// writes queued here wait on a mutex instead. Reads need no Writer.
+type Writer struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+// WriterFor returns the Writer shared by every caller writing to db
+func WriterFor(db *sql.DB) *Writer {
+	w, _ := writers.LoadOrStore(db, &Writer{db: db})
+	return w.(*Writer)
+}
+
+// Exec executes a write statement
+func (w *Writer) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.db.ExecContext(ctx, query, args...)
+}
+
+// Tx runs fn in a transaction, committed when fn returns nil and rolled back otherwise
+func (w *Writer) Tx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite: failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Do runs fn while holding the write lock, e.g. for writes through an ORM
+func (w *Writer) Do(fn func() error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return fn()
+}
+
+// release forgets the Writer of a closed database
+func release(db *sql.DB) {
+	writers.Delete(db)
+}
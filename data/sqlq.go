@@ -0,0 +1,34 @@
+package data
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// SQL returns a query runner on the master database, binding placeholders for the
+// configured driver. Returns nil if there is no database.
+func (d *Data) SQL() *sqlq.DB {
+	db := d.GetMasterDB()
+	if db == nil {
+		return nil
+	}
+	return sqlq.New(db, d.masterDriver())
+}
+
+// SQLRead returns a query runner on the database reads of ctx are routed to
+func (d *Data) SQLRead(ctx context.Context) (*sqlq.DB, error) {
+	db, err := d.DBReadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlq.New(db, d.masterDriver()), nil
+}
+
+// masterDriver returns the driver name of the master database configuration
+func (d *Data) masterDriver() string {
+	if d.conf != nil && d.conf.Database != nil && d.conf.Database.Master != nil {
+		return d.conf.Database.Master.Driver
+	}
+	return ""
+}
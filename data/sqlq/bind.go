@@ -0,0 +1,345 @@
+package sqlq
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ncobase/ncore/data/sqlscan"
+)
+
+var (
+	// ErrEmptySlice is returned when a slice bound to a placeholder has no elements
+	ErrEmptySlice = errors.New("sqlq: empty slice bound to placeholder")
+	// ErrArgCount is returned when the number of placeholders and arguments differ
+	ErrArgCount = errors.New("sqlq: placeholder and argument counts differ")
+)
+
+// Dialect is a placeholder style
+type Dialect int
+
+const (
+	// Question uses ? placeholders, for MySQL and SQLite
+	Question Dialect = iota
+	// Dollar uses $1, $2... placeholders, for Postgres
+	Dollar
+)
+
+// DialectOf returns the placeholder style of a database/sql driver name
+func DialectOf(driver string) Dialect {
+	switch driver {
+	case "postgres", "pgx", "pgx/v5":
+		return Dollar
+	default:
+		return Question
+	}
+}
+
+// ValidIdentifier reports whether name is a safe unquoted table or column name,
+// e.g. for names from configuration or requests spliced into a query
+func ValidIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '.' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Rebind converts the ? placeholders of query to dialect's style. ?? is a literal ?,
+// e.g. for Postgres JSON operators. Quoted strings, identifiers and comments are
+// left untouched.
+func Rebind(d Dialect, query string) string {
+	var sb strings.Builder
+	n := 0
+	for _, seg := range parse(query, false) {
+		switch seg.kind {
+		case segParam:
+			n++
+			sb.WriteString(placeholder(d, n))
+		case segEscape:
+			sb.WriteByte('?')
+		default:
+			sb.WriteString(seg.text)
+		}
+	}
+	return sb.String()
+}
+
+// Bind rebinds query to dialect and expands slice arguments into one placeholder per
+// element, so "id IN (?)" takes a slice. Byte slices and driver.Valuer values are
+// bound as single values.
+func Bind(d Dialect, query string, args ...any) (string, []any, error) {
+	return render(d, parse(query, false), args)
+}
+
+// BindNamed converts the :name parameters of query to dialect's placeholders, reading
+// values from arg, a map[string]any or a struct mapped with the sqlscan rules. Slice
+// values are expanded as with Bind. :: casts are left untouched.
+func BindNamed(d Dialect, query string, arg any) (string, []any, error) {
+	segs := parse(query, true)
+
+	lookup, err := namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var args []any
+	for i, seg := range segs {
+		switch seg.kind {
+		case segParam:
+			return "", nil, fmt.Errorf("sqlq: ? placeholder in named query")
+		case segNamed:
+			v, ok := lookup(seg.text)
+			if !ok {
+				return "", nil, fmt.Errorf("sqlq: missing named parameter %q", seg.text)
+			}
+			args = append(args, v)
+			segs[i].kind = segParam
+		}
+	}
+	return render(d, segs, args)
+}
+
+// render writes segs with dialect placeholders, expanding slice arguments
+func render(d Dialect, segs []segment, args []any) (string, []any, error) {
+	var (
+		sb  strings.Builder
+		out = make([]any, 0, len(args))
+		i   int
+	)
+	for _, seg := range segs {
+		switch seg.kind {
+		case segParam:
+			if i >= len(args) {
+				return "", nil, fmt.Errorf("%w: more placeholders than %d arguments", ErrArgCount, len(args))
+			}
+			var values []any
+			if s, ok := args[i].(scalar); ok {
+				values = []any{s.value}
+			} else if values, ok = expand(args[i]); !ok {
+				values = []any{args[i]}
+			} else if len(values) == 0 {
+				return "", nil, fmt.Errorf("%w: argument %d", ErrEmptySlice, i+1)
+			}
+			for j, v := range values {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				out = append(out, v)
+				sb.WriteString(placeholder(d, len(out)))
+			}
+			i++
+		case segEscape:
+			sb.WriteByte('?')
+		default:
+			sb.WriteString(seg.text)
+		}
+	}
+	if i != len(args) {
+		return "", nil, fmt.Errorf("%w: %d placeholders, %d arguments", ErrArgCount, i, len(args))
+	}
+	return sb.String(), out, nil
+}
+
+// scalar is an argument bound as one value even if it is a slice, e.g. a Postgres array
+// column value
+type scalar struct{ value any }
+
+// Build binds a statement to dialect
+func Build(d Dialect, s Statement) (string, []any, error) {
+	query, args := s.SQL()
+	return Bind(d, query, args...)
+}
+
+// expand returns the elements of a slice or array argument
+func expand(arg any) ([]any, bool) {
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false // []byte is a scalar
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
+
+// namedLookup returns a function reading named parameters from a map or struct
+func namedLookup(arg any) (func(name string) (any, bool), error) {
+	if m, ok := arg.(map[string]any); ok {
+		return func(name string) (any, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("sqlq: nil named argument")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlq: named argument must be a map[string]any or struct, got %T", arg)
+	}
+
+	fields := make(map[string][]int)
+	for _, c := range sqlscan.Columns(rv.Type()) {
+		fields[c.Name] = c.Index
+	}
+	return func(name string) (any, bool) {
+		index, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return nil, false
+		}
+		f, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			return nil, true // Behind a nil embedded pointer
+		}
+		return f.Interface(), true
+	}, nil
+}
+
+func placeholder(d Dialect, n int) string {
+	if d == Dollar {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+type segKind int
+
+const (
+	segText   segKind = iota
+	segParam          // ? placeholder
+	segNamed          // :name parameter, text holds the name
+	segEscape         // ?? literal question mark
+)
+
+type segment struct {
+	kind segKind
+	text string
+}
+
+// parse splits query into SQL text and parameters, skipping quoted strings and
+// identifiers, Postgres dollar-quoted strings and comments
+func parse(query string, named bool) []segment {
+	var (
+		segs  []segment
+		start int
+	)
+	flush := func(end int) {
+		if end > start {
+			segs = append(segs, segment{kind: segText, text: query[start:end]})
+		}
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i, c)
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		case c == '$':
+			if tag, ok := dollarTag(query[i:]); ok {
+				if end := strings.Index(query[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag)
+				} else {
+					i = len(query)
+				}
+			} else {
+				i++
+			}
+		case c == '?':
+			flush(i)
+			if strings.HasPrefix(query[i:], "??") {
+				segs = append(segs, segment{kind: segEscape})
+				i += 2
+			} else {
+				segs = append(segs, segment{kind: segParam})
+				i++
+			}
+			start = i
+		case c == ':' && named:
+			if strings.HasPrefix(query[i:], "::") {
+				i += 2 // Cast
+				continue
+			}
+			end := i + 1
+			for end < len(query) && isIdentByte(query[end], end > i+1) {
+				end++
+			}
+			if end == i+1 {
+				i++
+				continue
+			}
+			flush(i)
+			segs = append(segs, segment{kind: segNamed, text: query[i+1 : end]})
+			i, start = end, end
+		default:
+			i++
+		}
+	}
+	flush(len(query))
+	return segs
+}
+
+// skipQuoted returns the index after the quoted section starting at i. Doubled
+// quotes are escapes and continue the section.
+func skipQuoted(query string, i int, quote byte) int {
+	for j := i + 1; j < len(query); j++ {
+		if query[j] == quote {
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string, $$ or $name$
+func dollarTag(s string) (string, bool) {
+	for j := 1; j < len(s); j++ {
+		if s[j] == '$' {
+			return s[:j+1], true
+		}
+		if !isIdentByte(s[j], j > 1) {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isIdentByte(c byte, digits bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || digits && c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,273 @@
+package sqlq
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Statement is a statement written with ? placeholders and its arguments, bound to a
+// dialect with Build or when it runs on a DB
+type Statement interface {
+	SQL() (string, []any)
+}
+
+// clause is a condition or expression with its arguments
+type clause struct {
+	sql  string
+	args []any
+}
+
+// SelectBuilder builds a SELECT statement
+type SelectBuilder struct {
+	columns []string
+	from    string
+	joins   []clause
+	where   []clause
+	groupBy []string
+	having  []clause
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Select starts a SELECT of columns
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns, limit: -1}
+}
+
+// From sets the table
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Join adds a join, e.g. Join("LEFT JOIN users u ON u.id = t.user_id")
+func (b *SelectBuilder) Join(join string, args ...any) *SelectBuilder {
+	b.joins = append(b.joins, clause{join, args})
+	return b
+}
+
+// Where adds a condition, combined with AND. A slice argument expands, so
+// Where("status IN (?)", statuses) matches any of them.
+func (b *SelectBuilder) Where(cond string, args ...any) *SelectBuilder {
+	b.where = append(b.where, clause{cond, args})
+	return b
+}
+
+// GroupBy sets the grouping columns
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having adds a group condition, combined with AND
+func (b *SelectBuilder) Having(cond string, args ...any) *SelectBuilder {
+	b.having = append(b.having, clause{cond, args})
+	return b
+}
+
+// OrderBy adds sort expressions, e.g. OrderBy("created_at DESC", "id")
+func (b *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, exprs...)
+	return b
+}
+
+// Limit sets the maximum number of rows, negative for no limit
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset sets the number of rows to skip
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// SQL returns the statement with ? placeholders and its arguments
+func (b *SelectBuilder) SQL() (string, []any) {
+	var (
+		sb   strings.Builder
+		args []any
+	)
+	columns := "*"
+	if len(b.columns) > 0 {
+		columns = strings.Join(b.columns, ", ")
+	}
+	sb.WriteString("SELECT " + columns + " FROM " + b.from)
+	for _, j := range b.joins {
+		sb.WriteString(" " + j.sql)
+		args = append(args, j.args...)
+	}
+	args = writeConditions(&sb, " WHERE ", b.where, args)
+	if len(b.groupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+	args = writeConditions(&sb, " HAVING ", b.having, args)
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit >= 0 {
+		sb.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		sb.WriteString(" OFFSET " + strconv.Itoa(b.offset))
+	}
+	return sb.String(), args
+}
+
+// InsertBuilder builds an INSERT statement
+type InsertBuilder struct {
+	table   string
+	columns []string
+	rows    [][]any
+	suffix  clause
+}
+
+// Insert starts an INSERT into table
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns sets the inserted columns
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values adds a row, one value per column
+func (b *InsertBuilder) Values(values ...any) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Suffix appends e.g. "RETURNING id" or an upsert clause
+func (b *InsertBuilder) Suffix(sql string, args ...any) *InsertBuilder {
+	b.suffix = clause{sql, args}
+	return b
+}
+
+// SQL returns the statement with ? placeholders and its arguments
+func (b *InsertBuilder) SQL() (string, []any) {
+	var (
+		sb   strings.Builder
+		args []any
+	)
+	sb.WriteString("INSERT INTO " + b.table + " (" + strings.Join(b.columns, ", ") + ") VALUES ")
+	for i, row := range b.rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(" + strings.TrimSuffix(strings.Repeat("?, ", len(row)), ", ") + ")")
+		for _, v := range row {
+			args = append(args, scalar{v})
+		}
+	}
+	if b.suffix.sql != "" {
+		sb.WriteString(" " + b.suffix.sql)
+		args = append(args, b.suffix.args...)
+	}
+	return sb.String(), args
+}
+
+// UpdateBuilder builds an UPDATE statement
+type UpdateBuilder struct {
+	table  string
+	sets   []clause
+	where  []clause
+	suffix clause
+}
+
+// Update starts an UPDATE of table
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set assigns value to column
+func (b *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	b.sets = append(b.sets, clause{column + " = ?", []any{scalar{value}}})
+	return b
+}
+
+// SetExpr assigns an expression, e.g. SetExpr("version = version + 1")
+func (b *UpdateBuilder) SetExpr(expr string, args ...any) *UpdateBuilder {
+	b.sets = append(b.sets, clause{expr, args})
+	return b
+}
+
+// Where adds a condition, combined with AND
+func (b *UpdateBuilder) Where(cond string, args ...any) *UpdateBuilder {
+	b.where = append(b.where, clause{cond, args})
+	return b
+}
+
+// Suffix appends e.g. "RETURNING updated_at"
+func (b *UpdateBuilder) Suffix(sql string, args ...any) *UpdateBuilder {
+	b.suffix = clause{sql, args}
+	return b
+}
+
+// SQL returns the statement with ? placeholders and its arguments
+func (b *UpdateBuilder) SQL() (string, []any) {
+	var (
+		sb   strings.Builder
+		args []any
+	)
+	sb.WriteString("UPDATE " + b.table + " SET ")
+	for i, s := range b.sets {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(s.sql)
+		args = append(args, s.args...)
+	}
+	args = writeConditions(&sb, " WHERE ", b.where, args)
+	if b.suffix.sql != "" {
+		sb.WriteString(" " + b.suffix.sql)
+		args = append(args, b.suffix.args...)
+	}
+	return sb.String(), args
+}
+
+// DeleteBuilder builds a DELETE statement
+type DeleteBuilder struct {
+	table string
+	where []clause
+}
+
+// Delete starts a DELETE from table
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds a condition, combined with AND
+func (b *DeleteBuilder) Where(cond string, args ...any) *DeleteBuilder {
+	b.where = append(b.where, clause{cond, args})
+	return b
+}
+
+// SQL returns the statement with ? placeholders and its arguments
+func (b *DeleteBuilder) SQL() (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("DELETE FROM " + b.table)
+	args := writeConditions(&sb, " WHERE ", b.where, nil)
+	return sb.String(), args
+}
+
+// writeConditions writes conds joined by AND after keyword
+func writeConditions(sb *strings.Builder, keyword string, conds []clause, args []any) []any {
+	for i, c := range conds {
+		if i == 0 {
+			sb.WriteString(keyword)
+		} else {
+			sb.WriteString(" AND ")
+		}
+		if len(conds) > 1 {
+			sb.WriteString("(" + c.sql + ")")
+		} else {
+			sb.WriteString(c.sql)
+		}
+		args = append(args, c.args...)
+	}
+	return args
+}
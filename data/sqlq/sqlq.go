@@ -0,0 +1,138 @@
+// Package sqlq writes database/sql queries once for Postgres, MySQL and SQLite.
+//
+// Queries use ? placeholders, or :name parameters with the Named functions, and are
+// rebound to the driver's placeholder style when they run. Slice arguments expand
+// into one placeholder per element, so "id IN (?)" takes a slice. Rows are scanned
+// with the data/sqlscan rules.
+//
+//	db := sqlq.New(sqlDB, "postgres")
+//
+//	tasks, err := sqlq.Query[*structs.Task](ctx, db,
+//	    "SELECT * FROM tasks WHERE space_id = ? AND status IN (?)", spaceID, statuses)
+//
+//	_, err = db.NamedExec(ctx,
+//	    "UPDATE tasks SET title = :title, updated_at = :updated_at WHERE id = :id", task)
+//
+// The builders compose statements from parts, e.g. optional filters:
+//
+//	q := sqlq.Select("id", "title").From("tasks").Where("space_id = ?", spaceID)
+//	if len(statuses) > 0 {
+//	    q.Where("status IN (?)", statuses)
+//	}
+//	tasks, err := sqlq.QueryStmt[*structs.Task](ctx, db, q.OrderBy("created_at DESC").Limit(20))
+//
+// Literal question marks, e.g. in Postgres JSON operators, are written ??.
+package sqlq
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ncobase/ncore/data/sqlscan"
+)
+
+// Executor runs queries and statements, implemented by *sql.DB, *sql.Tx and *sql.Conn
+type Executor interface {
+	sqlscan.Querier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// DB runs queries written with ? placeholders or :name parameters on a database
+type DB struct {
+	exec    Executor
+	dialect Dialect
+}
+
+// New wraps db, using the placeholder style of driver, e.g. "postgres" or "mysql"
+func New(db Executor, driver string) *DB {
+	return &DB{exec: db, dialect: DialectOf(driver)}
+}
+
+// WithDB returns a copy running on db, e.g. a *sql.Tx
+func (db *DB) WithDB(exec Executor) *DB {
+	return &DB{exec: exec, dialect: db.dialect}
+}
+
+// Executor returns the wrapped database
+func (db *DB) Executor() Executor {
+	return db.exec
+}
+
+// Dialect returns the placeholder style
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Exec runs a statement
+func (db *DB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, args, err := Bind(db.dialect, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return db.exec.ExecContext(ctx, query, args...)
+}
+
+// NamedExec runs a statement with :name parameters read from arg
+func (db *DB) NamedExec(ctx context.Context, query string, arg any) (sql.Result, error) {
+	query, args, err := BindNamed(db.dialect, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return db.exec.ExecContext(ctx, query, args...)
+}
+
+// ExecStmt runs a built statement
+func (db *DB) ExecStmt(ctx context.Context, s Statement) (sql.Result, error) {
+	query, args := s.SQL()
+	return db.Exec(ctx, query, args...)
+}
+
+// Query runs a query and scans all rows into T
+func Query[T any](ctx context.Context, db *DB, query string, args ...any) ([]T, error) {
+	query, args, err := Bind(db.dialect, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return sqlscan.Query[T](ctx, db.exec, query, args...)
+}
+
+// Get runs a query and scans the first row into T, returning sql.ErrNoRows if there is none
+func Get[T any](ctx context.Context, db *DB, query string, args ...any) (T, error) {
+	query, args, err := Bind(db.dialect, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return sqlscan.Get[T](ctx, db.exec, query, args...)
+}
+
+// NamedQuery runs a query with :name parameters read from arg and scans all rows into T
+func NamedQuery[T any](ctx context.Context, db *DB, query string, arg any) ([]T, error) {
+	query, args, err := BindNamed(db.dialect, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return sqlscan.Query[T](ctx, db.exec, query, args...)
+}
+
+// NamedGet runs a query with :name parameters read from arg and scans the first row into T
+func NamedGet[T any](ctx context.Context, db *DB, query string, arg any) (T, error) {
+	query, args, err := BindNamed(db.dialect, query, arg)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return sqlscan.Get[T](ctx, db.exec, query, args...)
+}
+
+// QueryStmt runs a built query and scans all rows into T
+func QueryStmt[T any](ctx context.Context, db *DB, s Statement) ([]T, error) {
+	query, args := s.SQL()
+	return Query[T](ctx, db, query, args...)
+}
+
+// GetStmt runs a built query and scans the first row into T
+func GetStmt[T any](ctx context.Context, db *DB, s Statement) (T, error) {
+	query, args := s.SQL()
+	return Get[T](ctx, db, query, args...)
+}
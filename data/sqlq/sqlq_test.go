@@ -0,0 +1,154 @@
+package sqlq
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	query := `SELECT '?', "a?b", $tag$ ? $tag$ FROM t -- ?
+		WHERE a = ? AND data ?? 'key' /* ? */ AND b = $$?$$ AND c = ?`
+	want := `SELECT '?', "a?b", $tag$ ? $tag$ FROM t -- ?
+		WHERE a = $1 AND data ? 'key' /* ? */ AND b = $$?$$ AND c = $2`
+	if got := Rebind(Dollar, query); got != want {
+		t.Fatalf("Rebind =\n%s\nwant\n%s", got, want)
+	}
+	if got := Rebind(Question, "a = ? AND b = 'it''s ?'"); got != "a = ? AND b = 'it''s ?'" {
+		t.Fatalf("Rebind(Question) = %s", got)
+	}
+}
+
+func TestValidIdentifier(t *testing.T) {
+	for name, want := range map[string]bool{
+		"users":          true,
+		"public.users_2": true,
+		"_tmp":           true,
+		"":               false,
+		"2users":         false,
+		".users":         false,
+		"users;drop":     false,
+		"users name":     false,
+		`"users"`:        false,
+	} {
+		if got := ValidIdentifier(name); got != want {
+			t.Errorf("ValidIdentifier(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestBindExpandsSlices(t *testing.T) {
+	query, args, err := Bind(Dollar, "SELECT * FROM t WHERE id IN (?) AND data = ? AND s = ?",
+		[]string{"a", "b", "c"}, []byte("raw"), "x")
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if query != "SELECT * FROM t WHERE id IN ($1, $2, $3) AND data = $4 AND s = $5" {
+		t.Fatalf("query = %s", query)
+	}
+	if want := []any{"a", "b", "c", []byte("raw"), "x"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v", args)
+	}
+
+	if _, _, err := Bind(Question, "id IN (?)", []int{}); !errors.Is(err, ErrEmptySlice) {
+		t.Fatalf("empty slice error = %v", err)
+	}
+	if _, _, err := Bind(Question, "a = ? AND b = ?", 1); !errors.Is(err, ErrArgCount) {
+		t.Fatalf("missing argument error = %v", err)
+	}
+	if _, _, err := Bind(Question, "a = ?", 1, 2); !errors.Is(err, ErrArgCount) {
+		t.Fatalf("extra argument error = %v", err)
+	}
+}
+
+type task struct {
+	ID        string
+	Title     string `db:"title"`
+	Tags      []string
+	UpdatedAt time.Time
+}
+
+func TestBindNamed(t *testing.T) {
+	now := time.Now()
+	query, args, err := BindNamed(Dollar,
+		"UPDATE tasks SET title = :title, updated_at = :updated_at::timestamptz WHERE id = :ID AND tag IN (:tags) AND note <> ':skip'",
+		&task{ID: "1", Title: "t", Tags: []string{"x", "y"}, UpdatedAt: now})
+	if err != nil {
+		t.Fatalf("BindNamed: %v", err)
+	}
+	if query != "UPDATE tasks SET title = $1, updated_at = $2::timestamptz WHERE id = $3 AND tag IN ($4, $5) AND note <> ':skip'" {
+		t.Fatalf("query = %s", query)
+	}
+	if want := []any{"t", now, "1", "x", "y"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v", args)
+	}
+
+	query, args, err = BindNamed(Question, "SELECT * FROM t WHERE a = :a OR b = :a", map[string]any{"a": 1})
+	if err != nil || query != "SELECT * FROM t WHERE a = ? OR b = ?" || len(args) != 2 {
+		t.Fatalf("BindNamed(map) = %s %v %v", query, args, err)
+	}
+
+	if _, _, err := BindNamed(Question, "a = :missing", map[string]any{}); err == nil {
+		t.Fatal("expected missing parameter error")
+	}
+}
+
+func TestSelectBuilder(t *testing.T) {
+	q := Select("t.id", "t.title").
+		From("tasks t").
+		Join("JOIN spaces s ON s.id = t.space_id AND s.owner = ?", "u1").
+		Where("t.space_id = ?", "s1").
+		Where("t.status IN (?) OR t.priority > ?", []string{"open", "blocked"}, 3).
+		OrderBy("t.created_at DESC", "t.id").
+		Limit(20).
+		Offset(40)
+
+	query, args, err := Build(Dollar, q)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := "SELECT t.id, t.title FROM tasks t JOIN spaces s ON s.id = t.space_id AND s.owner = $1" +
+		" WHERE (t.space_id = $2) AND (t.status IN ($3, $4) OR t.priority > $5)" +
+		" ORDER BY t.created_at DESC, t.id LIMIT 20 OFFSET 40"
+	if query != want {
+		t.Fatalf("query =\n%s\nwant\n%s", query, want)
+	}
+	if want := []any{"u1", "s1", "open", "blocked", 3}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v", args)
+	}
+}
+
+func TestWriteBuilders(t *testing.T) {
+	query, args, err := Build(Question, Insert("tasks").
+		Columns("id", "tags").
+		Values("1", []string{"a", "b"}).
+		Values("2", nil).
+		Suffix("ON CONFLICT (id) DO NOTHING"))
+	if err != nil {
+		t.Fatalf("Build insert: %v", err)
+	}
+	if query != "INSERT INTO tasks (id, tags) VALUES (?, ?), (?, ?) ON CONFLICT (id) DO NOTHING" || len(args) != 4 {
+		t.Fatalf("insert = %s %v", query, args)
+	}
+	if !reflect.DeepEqual(args[1], []string{"a", "b"}) {
+		t.Fatalf("slice column value expanded: %v", args)
+	}
+
+	query, args, err = Build(Dollar, Update("tasks").
+		Set("title", "new").
+		SetExpr("version = version + ?", 1).
+		Where("id = ?", "1").
+		Suffix("RETURNING version"))
+	if err != nil {
+		t.Fatalf("Build update: %v", err)
+	}
+	if query != "UPDATE tasks SET title = $1, version = version + $2 WHERE id = $3 RETURNING version" || len(args) != 3 {
+		t.Fatalf("update = %s %v", query, args)
+	}
+
+	query, args, err = Build(Dollar, Delete("tasks").Where("id IN (?)", []int{1, 2}))
+	if err != nil || query != "DELETE FROM tasks WHERE id IN ($1, $2)" || len(args) != 2 {
+		t.Fatalf("delete = %s %v %v", query, args, err)
+	}
+}
@@ -0,0 +1,164 @@
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Audit columns, named as the consts metadata keys
+const (
+	createdAt = "created_at"
+	updatedAt = "updated_at"
+	createdBy = "created_by"
+	updatedBy = "updated_by"
+)
+
+var auditColumns = []string{createdAt, updatedAt, createdBy, updatedBy}
+
+// actor returns the user writing in ctx, empty without ActorFunc
+func (b *Base[T, ID]) actor(ctx context.Context) string {
+	if b.opts.ActorFunc == nil {
+		return ""
+	}
+	return b.opts.ActorFunc(ctx)
+}
+
+// stampCreate sets the unset audit fields of a new entity
+func (b *Base[T, ID]) stampCreate(ctx context.Context, v reflect.Value) {
+	if b.audit == nil {
+		return
+	}
+	now := time.Now().UTC()
+	actor := b.actor(ctx)
+	for name, index := range b.audit {
+		f, err := v.FieldByIndexErr(index)
+		if err != nil || !f.IsZero() {
+			continue
+		}
+		switch name {
+		case createdAt, updatedAt:
+			setField(f, timeValue(f.Type(), now))
+		case createdBy, updatedBy:
+			if actor != "" {
+				setField(f, actor)
+			}
+		}
+	}
+}
+
+// stampUpdate sets the update audit fields of an entity
+func (b *Base[T, ID]) stampUpdate(ctx context.Context, v reflect.Value) {
+	if b.audit == nil {
+		return
+	}
+	if index, ok := b.audit[updatedAt]; ok {
+		if f, err := v.FieldByIndexErr(index); err == nil {
+			setField(f, timeValue(f.Type(), time.Now().UTC()))
+		}
+	}
+	if index, ok := b.audit[updatedBy]; ok {
+		if actor := b.actor(ctx); actor != "" {
+			if f, err := v.FieldByIndexErr(index); err == nil {
+				setField(f, actor)
+			}
+		}
+	}
+}
+
+// auditSets returns the SET assignments stamping updated_at and updated_by, for
+// statements writing no entity
+func (b *Base[T, ID]) auditSets(ctx context.Context, now time.Time) ([]string, []any) {
+	var (
+		sets []string
+		args []any
+	)
+	if index, ok := b.audit[updatedAt]; ok {
+		sets = append(sets, updatedAt+" = ?")
+		args = append(args, timeValue(reflect.TypeFor[T]().FieldByIndex(index).Type, now))
+	}
+	if _, ok := b.audit[updatedBy]; ok {
+		if actor := b.actor(ctx); actor != "" {
+			sets = append(sets, updatedBy+" = ?")
+			args = append(args, actor)
+		}
+	}
+	return sets, args
+}
+
+// Purge permanently removes the rows soft deleted before cutoff, batch rows per
+// statement (1000 by default) until none is left, and returns how many it
+// removed. As a retention job it runs across tenants.
+func (b *Base[T, ID]) Purge(ctx context.Context, cutoff time.Time, batch int) (int64, error) {
+	if b.opts.SoftDelete == "" {
+		return 0, fmt.Errorf("%s does not use soft delete", b.opts.Table)
+	}
+	if batch <= 0 {
+		batch = 1000
+	}
+
+	cond := fmt.Sprintf("%s IS NOT NULL AND %s < ?", b.opts.SoftDelete, b.opts.SoftDelete)
+	// MySQL does not support LIMIT in IN subqueries, but supports it in DELETE
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (SELECT %s FROM %s WHERE %s LIMIT ?)",
+		b.opts.Table, b.opts.IDColumn, b.opts.IDColumn, b.opts.Table, cond)
+	if b.opts.Driver == "mysql" {
+		query = fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT ?", b.opts.Table, cond)
+	}
+	query = b.Rebind(query)
+	value := timeValue(b.deletedType, cutoff.UTC())
+
+	var purged int64
+	for {
+		result, err := b.db.ExecContext(ctx, query, value, batch)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", b.opts.Table, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		purged += affected
+		if affected < int64(batch) {
+			return purged, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+	}
+}
+
+// timeValue returns now as stored in a field of type t: Unix milliseconds for
+// integers and time.Time otherwise, including when T does not map the column
+func timeValue(t reflect.Type, now time.Time) any {
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int64:
+			return now.UnixMilli()
+		}
+	}
+	return now
+}
+
+// setField sets a field or the value it points to, if value converts to its type
+func setField(f reflect.Value, value any) {
+	if !f.CanSet() {
+		return
+	}
+	rv := reflect.ValueOf(value)
+	if f.Kind() == reflect.Pointer {
+		if !rv.Type().ConvertibleTo(f.Type().Elem()) {
+			return
+		}
+		p := reflect.New(f.Type().Elem())
+		p.Elem().Set(rv.Convert(f.Type().Elem()))
+		f.Set(p)
+		return
+	}
+	if rv.Type().ConvertibleTo(f.Type()) {
+		f.Set(rv.Convert(f.Type()))
+	}
+}
@@ -0,0 +1,44 @@
+// Package sqlrepo provides a generic CRUD repository base for database/sql,
+// mapping entities with the data/sqlscan rules.
+//
+// Repositories embed Base and add their own queries:
+//
+//	type taskRepository struct {
+//	    *sqlrepo.Base[structs.Task, string]
+//	}
+//
+//	func NewTaskRepository(db *sql.DB) (*taskRepository, error) {
+//	    base, err := sqlrepo.New[structs.Task, string](db, sqlrepo.Options{
+//	        Table:        "tasks",
+//	        Driver:       "postgres",
+//	        SoftDelete:   "deleted_at",
+//	        TenantColumn: "space_id",
+//	        TenantFunc:   ctxutil.GetSpaceID,
+//	        Audit:        true,
+//	        ActorFunc:    ctxutil.GetUserID,
+//	    })
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return &taskRepository{Base: base}, nil
+//	}
+//
+//	func (r *taskRepository) FindByAssignee(ctx context.Context, userID string) ([]*structs.Task, error) {
+//	    where, args, err := r.Scope(ctx, "assigned_to = ?", userID)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return sqlscan.Query[*structs.Task](ctx, r.DB(), r.Rebind(
+//	        "SELECT "+r.SelectList()+" FROM tasks"+where+" ORDER BY due_at"), args...)
+//	}
+//
+// Base provides Create, Get, Update, Delete, HardDelete, Restore, Count and List.
+// With SoftDelete set, Delete stamps the column and reads skip stamped rows; the
+// struct field for it should be a pointer so inserts write NULL. With TenantColumn
+// set, every statement is scoped to TenantFunc(ctx) and fails with ErrNoTenant
+// when it is empty. Scope and Find apply the same conditions to custom queries.
+// With Audit set, writes stamp the created_at, updated_at, created_by and
+// updated_by columns T maps, the users coming from ActorFunc(ctx). Purge, a
+// retention job running across tenants, removes rows soft deleted before a
+// cutoff in batches. Use WithDB to run a repository inside a transaction.
+package sqlrepo
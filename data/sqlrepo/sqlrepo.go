@@ -0,0 +1,542 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+	"github.com/ncobase/ncore/data/sqlscan"
+)
+
+var (
+	// ErrNotFound is returned when no record matches the ID in scope
+	ErrNotFound = errors.New("sqlrepo: record not found")
+	// ErrNoTenant is returned when tenant scoping is enabled but the context has no tenant
+	ErrNoTenant = errors.New("sqlrepo: tenant is required")
+)
+
+// DB runs queries and statements, implemented by *sql.DB, *sql.Tx and *sql.Conn
+type DB interface {
+	sqlscan.Querier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Options configures a repository
+type Options struct {
+	Table    string
+	IDColumn string // Defaults to "id"
+	Driver   string // "postgres" and "pgx" use $n placeholders, others use ?
+	// SoftDelete is the deletion timestamp column, e.g. "deleted_at". Empty deletes rows.
+	SoftDelete string
+	// TenantColumn scopes every query to the tenant returned by TenantFunc
+	TenantColumn string
+	TenantFunc   func(ctx context.Context) string
+	// Audit stamps the created_at, updated_at, created_by and updated_by columns T
+	// maps on writes, the consts metadata keys. Times are written as Unix
+	// milliseconds to integer fields, as by the entgo mixins, and as time.Time otherwise.
+	Audit bool
+	// ActorFunc returns the user stamped into created_by and updated_by, e.g. ctxutil.GetUserID
+	ActorFunc func(ctx context.Context) string
+}
+
+// Filter matches columns by equality, a nil value matches NULL
+type Filter map[string]any
+
+// ListOptions configures List
+type ListOptions struct {
+	Filter      Filter
+	OrderBy     string // e.g. "created_at DESC, id", defaults to the ID column
+	Limit       int    // Defaults to 20, capped at 1000
+	Offset      int
+	WithDeleted bool // Include soft deleted records
+	OnlyDeleted bool // Only soft deleted records, e.g. for a trash view
+}
+
+// Page is a page of List results
+type Page[T any] struct {
+	Items   []*T `json:"items"`
+	Total   int  `json:"total"`
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	HasMore bool `json:"has_more"`
+}
+
+// Base implements CRUD for entity T with ID type ID, meant to be embedded by repositories
+type Base[T any, ID comparable] struct {
+	db      DB
+	opts    Options
+	dialect sqlq.Dialect
+
+	columns     []sqlscan.Column
+	known       map[string]bool
+	tenantIndex []int
+	selectList  string
+	deletedType reflect.Type     // Type of the soft delete field, nil if T does not map it
+	audit       map[string][]int // Field index of the audit columns T maps, by name
+}
+
+// New creates a repository base for T, which must be a struct with a column for the ID
+func New[T any, ID comparable](db DB, opts Options) (*Base[T, ID], error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+	if opts.IDColumn == "" {
+		opts.IDColumn = "id"
+	}
+	for _, name := range []string{opts.Table, opts.IDColumn, opts.SoftDelete, opts.TenantColumn} {
+		if name != "" && !sqlq.ValidIdentifier(name) {
+			return nil, fmt.Errorf("invalid identifier: %q", name)
+		}
+	}
+	if opts.Table == "" {
+		return nil, fmt.Errorf("table is required")
+	}
+	if opts.TenantColumn != "" && opts.TenantFunc == nil {
+		return nil, fmt.Errorf("tenant func is required with tenant column %s", opts.TenantColumn)
+	}
+
+	columns := sqlscan.Columns(reflect.TypeFor[T]())
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no mapped fields", reflect.TypeFor[T]())
+	}
+
+	b := &Base[T, ID]{
+		db:      db,
+		opts:    opts,
+		dialect: sqlq.DialectOf(opts.Driver),
+		columns: columns,
+		known:   make(map[string]bool, len(columns)+2),
+	}
+
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+		b.known[c.Name] = true
+		if c.Name == strings.ToLower(opts.TenantColumn) {
+			b.tenantIndex = c.Index
+		}
+		if c.Name == strings.ToLower(opts.SoftDelete) {
+			b.deletedType = reflect.TypeFor[T]().FieldByIndex(c.Index).Type
+		}
+		if opts.Audit && slices.Contains(auditColumns, c.Name) {
+			if b.audit == nil {
+				b.audit = make(map[string][]int)
+			}
+			b.audit[c.Name] = c.Index
+		}
+	}
+	if !b.known[strings.ToLower(opts.IDColumn)] {
+		return nil, fmt.Errorf("%s has no field for column %s", reflect.TypeFor[T](), opts.IDColumn)
+	}
+	for _, name := range []string{opts.SoftDelete, opts.TenantColumn} {
+		if name != "" {
+			b.known[strings.ToLower(name)] = true
+		}
+	}
+	b.selectList = strings.Join(names, ", ")
+
+	return b, nil
+}
+
+// DB returns the database the repository runs on
+func (b *Base[T, ID]) DB() DB {
+	return b.db
+}
+
+// WithDB returns a copy of the repository running on db, e.g. a *sql.Tx
+func (b *Base[T, ID]) WithDB(db DB) *Base[T, ID] {
+	clone := *b
+	clone.db = db
+	return &clone
+}
+
+// Table returns the table name
+func (b *Base[T, ID]) Table() string {
+	return b.opts.Table
+}
+
+// Tenant returns the tenant statements in ctx are scoped to, empty without tenant scoping
+func (b *Base[T, ID]) Tenant(ctx context.Context) string {
+	if b.opts.TenantColumn == "" {
+		return ""
+	}
+	return b.opts.TenantFunc(ctx)
+}
+
+// SelectList returns the comma separated columns of T, for custom queries
+func (b *Base[T, ID]) SelectList() string {
+	return b.selectList
+}
+
+// Create inserts entity, setting its tenant field when tenant scoping is enabled
+// and its unset audit fields with Audit
+func (b *Base[T, ID]) Create(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+	b.stampCreate(ctx, v)
+
+	var (
+		names []string
+		args  []any
+	)
+	if b.opts.TenantColumn != "" {
+		tenant := b.opts.TenantFunc(ctx)
+		if tenant == "" {
+			return ErrNoTenant
+		}
+		if b.tenantIndex != nil {
+			if f, err := v.FieldByIndexErr(b.tenantIndex); err == nil && f.Kind() == reflect.String {
+				f.SetString(tenant)
+			}
+		} else {
+			names = append(names, b.opts.TenantColumn)
+			args = append(args, tenant)
+		}
+	}
+
+	for _, c := range b.columns {
+		names = append(names, c.Name)
+		args = append(args, fieldValue(v, c.Index))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		b.opts.Table, strings.Join(names, ", "), placeholders(len(names)))
+	if _, err := b.db.ExecContext(ctx, b.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to create %s: %w", b.opts.Table, err)
+	}
+	return nil
+}
+
+// Get returns the record with id, or ErrNotFound
+func (b *Base[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	where, args, err := b.scope(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, b.opts.IDColumn+" = ?")
+	args = append(args, id)
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", b.selectList, b.opts.Table, strings.Join(where, " AND "))
+	item, err := sqlscan.Get[*T](ctx, b.db, b.Rebind(query), args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", b.opts.Table, err)
+	}
+	return item, nil
+}
+
+// Update writes all fields of entity except the ID, tenant and soft delete
+// columns, and with Audit the creation columns, stamping updated_at and updated_by
+func (b *Base[T, ID]) Update(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+	b.stampUpdate(ctx, v)
+
+	var (
+		sets []string
+		args []any
+		id   any
+	)
+	for _, c := range b.columns {
+		switch c.Name {
+		case strings.ToLower(b.opts.IDColumn):
+			id = fieldValue(v, c.Index)
+		case strings.ToLower(b.opts.TenantColumn), strings.ToLower(b.opts.SoftDelete):
+		case createdAt, createdBy:
+			if b.audit == nil {
+				sets = append(sets, c.Name+" = ?")
+				args = append(args, fieldValue(v, c.Index))
+			}
+		default:
+			sets = append(sets, c.Name+" = ?")
+			args = append(args, fieldValue(v, c.Index))
+		}
+	}
+
+	where, scopeArgs, err := b.scope(ctx, false)
+	if err != nil {
+		return err
+	}
+	where = append(where, b.opts.IDColumn+" = ?")
+	args = append(append(args, scopeArgs...), id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", b.opts.Table, strings.Join(sets, ", "), strings.Join(where, " AND "))
+	return b.exec(ctx, "update", query, args...)
+}
+
+// Delete soft deletes the record with id when SoftDelete is set, otherwise removes it
+func (b *Base[T, ID]) Delete(ctx context.Context, id ID) error {
+	if b.opts.SoftDelete == "" {
+		return b.HardDelete(ctx, id)
+	}
+
+	where, args, err := b.scope(ctx, false)
+	if err != nil {
+		return err
+	}
+	where = append(where, b.opts.IDColumn+" = ?")
+
+	now := time.Now().UTC()
+	sets, setArgs := b.auditSets(ctx, now)
+	sets = append([]string{b.opts.SoftDelete + " = ?"}, sets...)
+	setArgs = append([]any{timeValue(b.deletedType, now)}, setArgs...)
+	args = append(setArgs, append(args, id)...)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", b.opts.Table, strings.Join(sets, ", "), strings.Join(where, " AND "))
+	return b.exec(ctx, "delete", query, args...)
+}
+
+// HardDelete removes the record with id, including soft deleted ones
+func (b *Base[T, ID]) HardDelete(ctx context.Context, id ID) error {
+	where, args, err := b.scope(ctx, true)
+	if err != nil {
+		return err
+	}
+	where = append(where, b.opts.IDColumn+" = ?")
+	args = append(args, id)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", b.opts.Table, strings.Join(where, " AND "))
+	return b.exec(ctx, "delete", query, args...)
+}
+
+// Restore undoes a soft delete
+func (b *Base[T, ID]) Restore(ctx context.Context, id ID) error {
+	if b.opts.SoftDelete == "" {
+		return fmt.Errorf("%s does not use soft delete", b.opts.Table)
+	}
+
+	where, args, err := b.scope(ctx, true)
+	if err != nil {
+		return err
+	}
+	where = append(where, b.opts.SoftDelete+" IS NOT NULL", b.opts.IDColumn+" = ?")
+
+	sets, setArgs := b.auditSets(ctx, time.Now().UTC())
+	sets = append([]string{b.opts.SoftDelete + " = NULL"}, sets...)
+	args = append(setArgs, append(args, id)...)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", b.opts.Table, strings.Join(sets, ", "), strings.Join(where, " AND "))
+	return b.exec(ctx, "restore", query, args...)
+}
+
+// Count counts records matching filter
+func (b *Base[T, ID]) Count(ctx context.Context, filter Filter) (int, error) {
+	where, args, err := b.where(ctx, filter, false)
+	if err != nil {
+		return 0, err
+	}
+	return b.count(ctx, where, args)
+}
+
+// List returns a page of records
+func (b *Base[T, ID]) List(ctx context.Context, opts *ListOptions) (*Page[T], error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, 1000)
+	offset := max(opts.Offset, 0)
+
+	orderBy, err := b.orderBy(opts.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OnlyDeleted && b.opts.SoftDelete == "" {
+		return nil, fmt.Errorf("%s does not use soft delete", b.opts.Table)
+	}
+	where, args, err := b.where(ctx, opts.Filter, opts.WithDeleted || opts.OnlyDeleted)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OnlyDeleted {
+		where = append(where, b.opts.SoftDelete+" IS NOT NULL")
+	}
+
+	total, err := b.count(ctx, where, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT ? OFFSET ?",
+		b.selectList, b.opts.Table, whereClause(where), orderBy)
+	items, err := sqlscan.Query[*T](ctx, b.db, b.Rebind(query), append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", b.opts.Table, err)
+	}
+	if items == nil {
+		items = []*T{}
+	}
+
+	return &Page[T]{
+		Items:   items,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: offset+len(items) < total,
+	}, nil
+}
+
+// Scope returns a WHERE clause joining cond, a condition with ? placeholders, to
+// the tenant and soft delete conditions of the repository, for custom queries:
+//
+//	where, args, err := r.Scope(ctx, "assigned_to = ?", userID)
+//	tasks, err := sqlscan.Query[*Task](ctx, r.DB(), r.Rebind("SELECT "+r.SelectList()+" FROM tasks"+where+" ORDER BY due_at"), args...)
+func (b *Base[T, ID]) Scope(ctx context.Context, cond string, args ...any) (string, []any, error) {
+	where, scopeArgs, err := b.scope(ctx, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if strings.TrimSpace(cond) != "" {
+		where = append(where, "("+cond+")")
+	}
+	return whereClause(where), append(scopeArgs, args...), nil
+}
+
+// Find returns the records matching cond, a condition with ? placeholders, in
+// the scope of the repository, ordered by ID
+func (b *Base[T, ID]) Find(ctx context.Context, cond string, args ...any) ([]*T, error) {
+	where, args, err := b.Scope(ctx, cond, args...)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s", b.selectList, b.opts.Table, where, b.opts.IDColumn)
+	items, err := sqlscan.Query[*T](ctx, b.db, b.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", b.opts.Table, err)
+	}
+	return items, nil
+}
+
+// Rebind converts ? placeholders to the driver's style
+func (b *Base[T, ID]) Rebind(query string) string {
+	return sqlq.Rebind(b.dialect, query)
+}
+
+// scope returns the tenant and soft delete conditions
+func (b *Base[T, ID]) scope(ctx context.Context, withDeleted bool) ([]string, []any, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if b.opts.TenantColumn != "" {
+		tenant := b.opts.TenantFunc(ctx)
+		if tenant == "" {
+			return nil, nil, ErrNoTenant
+		}
+		where = append(where, b.opts.TenantColumn+" = ?")
+		args = append(args, tenant)
+	}
+	if b.opts.SoftDelete != "" && !withDeleted {
+		where = append(where, b.opts.SoftDelete+" IS NULL")
+	}
+	return where, args, nil
+}
+
+// where returns the scope and filter conditions
+func (b *Base[T, ID]) where(ctx context.Context, filter Filter, withDeleted bool) ([]string, []any, error) {
+	where, args, err := b.scope(ctx, withDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(filter))
+	for k := range filter {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if !b.known[strings.ToLower(k)] {
+			return nil, nil, fmt.Errorf("unknown filter column: %s", k)
+		}
+		if filter[k] == nil {
+			where = append(where, k+" IS NULL")
+			continue
+		}
+		where = append(where, k+" = ?")
+		args = append(args, filter[k])
+	}
+	return where, args, nil
+}
+
+// orderBy validates an ORDER BY list against the known columns
+func (b *Base[T, ID]) orderBy(orderBy string) (string, error) {
+	if strings.TrimSpace(orderBy) == "" {
+		return b.opts.IDColumn, nil
+	}
+
+	parts := strings.Split(orderBy, ",")
+	for i, part := range parts {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || len(fields) > 2 || !b.known[strings.ToLower(fields[0])] {
+			return "", fmt.Errorf("invalid order by: %s", strings.TrimSpace(part))
+		}
+		if len(fields) == 2 {
+			dir := strings.ToUpper(fields[1])
+			if dir != "ASC" && dir != "DESC" {
+				return "", fmt.Errorf("invalid order by: %s", strings.TrimSpace(part))
+			}
+			fields[1] = dir
+		}
+		parts[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// count counts records matching where
+func (b *Base[T, ID]) count(ctx context.Context, where []string, args []any) (int, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", b.opts.Table, whereClause(where))
+	total, err := sqlscan.Get[int](ctx, b.db, b.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", b.opts.Table, err)
+	}
+	return total, nil
+}
+
+// exec runs a statement that must affect a row
+func (b *Base[T, ID]) exec(ctx context.Context, op, query string, args ...any) error {
+	result, err := b.db.ExecContext(ctx, b.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s: %w", op, b.opts.Table, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// fieldValue returns the value of a field, nil if it sits behind a nil embedded pointer
+func fieldValue(v reflect.Value, index []int) any {
+	f, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return nil
+	}
+	return f.Interface()
+}
+
+// whereClause joins conditions into a WHERE clause
+func whereClause(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(where, " AND ")
+}
+
+// placeholders returns n comma separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,383 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE tasks (id TEXT PRIMARY KEY, space_id TEXT, title TEXT, deleted_at TIMESTAMP);
+CREATE TABLE notes (id TEXT PRIMARY KEY, body TEXT, created_at INTEGER, updated_at INTEGER,
+	created_by TEXT, updated_by TEXT, deleted_at INTEGER);
+`
+
+// openMemory opens an in-memory sqlite database with the test tables
+func openMemory(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// recorder runs statements on a database and keeps the last one sent
+type recorder struct {
+	*sql.DB
+	last string
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r.last = query
+	return r.DB.QueryContext(ctx, query, args...)
+}
+
+func (r *recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.last = query
+	return r.DB.ExecContext(ctx, query, args...)
+}
+
+type Task struct {
+	ID        string
+	SpaceID   string
+	Title     string
+	DeletedAt *time.Time
+}
+
+type spaceKey struct{}
+
+func spaceOf(ctx context.Context) string {
+	s, _ := ctx.Value(spaceKey{}).(string)
+	return s
+}
+
+func inSpace(space string) context.Context {
+	return context.WithValue(context.Background(), spaceKey{}, space)
+}
+
+// newRepo creates a repository of T on a new database, recording its statements
+func newRepo[T any](t *testing.T, opts Options) (*Base[T, string], *recorder) {
+	t.Helper()
+	db := &recorder{DB: openMemory(t)}
+	repo, err := New[T, string](db, opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return repo, db
+}
+
+// seed creates tasks in the space of each, bypassing the scope of the test repository
+func seed(t *testing.T, db *recorder, tasks ...*Task) {
+	t.Helper()
+	repo, err := New[Task, string](db.DB, Options{Table: "tasks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range tasks {
+		if err := repo.Create(context.Background(), task); err != nil {
+			t.Fatalf("seed %s: %v", task.ID, err)
+		}
+	}
+}
+
+// title returns the stored title of a task, including soft deleted ones
+func title(t *testing.T, db *recorder, id string) string {
+	t.Helper()
+	var title string
+	if err := db.QueryRow("SELECT title FROM tasks WHERE id = ?", id).Scan(&title); err != nil {
+		t.Fatalf("task %s: %v", id, err)
+	}
+	return title
+}
+
+func TestCreateSetsTenant(t *testing.T) {
+	repo, db := newRepo[Task](t, Options{Table: "tasks", Driver: "postgres", TenantColumn: "space_id", TenantFunc: spaceOf})
+
+	task := &Task{ID: "t1", SpaceID: "forged", Title: "first"}
+	if err := repo.Create(inSpace("s1"), task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if task.SpaceID != "s1" {
+		t.Errorf("expected tenant to be set, got %q", task.SpaceID)
+	}
+	if want := "INSERT INTO tasks (id, space_id, title, deleted_at) VALUES ($1, $2, $3, $4)"; db.last != want {
+		t.Errorf("query = %q, want %q", db.last, want)
+	}
+
+	var space, title string
+	var deletedAt sql.NullTime
+	if err := db.QueryRow("SELECT space_id, title, deleted_at FROM tasks WHERE id = 't1'").Scan(&space, &title, &deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	if space != "s1" || title != "first" || deletedAt.Valid {
+		t.Errorf("stored %s %s %v", space, title, deletedAt)
+	}
+
+	if err := repo.Create(context.Background(), &Task{ID: "t2"}); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("expected ErrNoTenant, got %v", err)
+	}
+}
+
+func TestUpdateAndSoftDeleteAreScoped(t *testing.T) {
+	repo, db := newRepo[Task](t, Options{Table: "tasks", SoftDelete: "deleted_at", TenantColumn: "space_id", TenantFunc: spaceOf})
+	seed(t, db, &Task{ID: "t1", SpaceID: "s1", Title: "first"}, &Task{ID: "t2", SpaceID: "s2", Title: "second"})
+	ctx := inSpace("s1")
+
+	// The tenant is not moved by an update
+	if err := repo.Update(ctx, &Task{ID: "t1", SpaceID: "other", Title: "renamed"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if task, err := repo.Get(ctx, "t1"); err != nil || task.Title != "renamed" || task.SpaceID != "s1" {
+		t.Fatalf("Get = %+v, %v", task, err)
+	}
+
+	// Records of other tenants are not found
+	if err := repo.Update(ctx, &Task{ID: "t2", Title: "stolen"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update of another tenant = %v, want ErrNotFound", err)
+	}
+	if err := repo.HardDelete(ctx, "t2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("hard delete of another tenant = %v, want ErrNotFound", err)
+	}
+	if got := title(t, db, "t2"); got != "second" {
+		t.Errorf("task of another tenant changed to %q", got)
+	}
+
+	if err := repo.Delete(ctx, "t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, "t1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, "t1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.Restore(ctx, "t1"); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if task, err := repo.Get(ctx, "t1"); err != nil || task.DeletedAt != nil {
+		t.Fatalf("Get after Restore = %+v, %v", task, err)
+	}
+
+	if err := repo.HardDelete(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := repo.HardDelete(ctx, "t1"); err != nil {
+		t.Fatalf("HardDelete: %v", err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&n); err != nil || n != 1 {
+		t.Errorf("%d tasks left, want 1", n)
+	}
+}
+
+func TestGetAndList(t *testing.T) {
+	repo, db := newRepo[Task](t, Options{Table: "tasks", SoftDelete: "deleted_at"})
+	deleted := time.Now().UTC()
+	seed(t, db,
+		&Task{ID: "t1", SpaceID: "s1", Title: "a"},
+		&Task{ID: "t2", SpaceID: "s1", Title: "b"},
+		&Task{ID: "t3", SpaceID: "s1", Title: "c"},
+		&Task{ID: "t4", SpaceID: "s1", Title: "d", DeletedAt: &deleted},
+		&Task{ID: "t5", SpaceID: "s2", Title: "e"},
+	)
+	ctx := context.Background()
+
+	task, err := repo.Get(ctx, "t1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if task.ID != "t1" || task.Title != "a" || task.DeletedAt != nil {
+		t.Errorf("unexpected task %+v", task)
+	}
+	if _, err := repo.Get(ctx, "t4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a deleted task = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	page, err := repo.List(ctx, &ListOptions{Filter: Filter{"space_id": "s1"}, OrderBy: "title desc, id", Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if page.Total != 3 || len(page.Items) != 2 || !page.HasMore || page.Items[0].ID != "t3" || page.Items[1].ID != "t2" {
+		t.Errorf("unexpected page %+v", page)
+	}
+	page, err = repo.List(ctx, &ListOptions{Filter: Filter{"space_id": "s1"}, OrderBy: "title desc, id", Limit: 2, Offset: 2})
+	if err != nil || len(page.Items) != 1 || page.HasMore || page.Items[0].ID != "t1" {
+		t.Errorf("second page = %+v, %v", page, err)
+	}
+	if n, err := repo.Count(ctx, Filter{"space_id": "s1"}); err != nil || n != 3 {
+		t.Errorf("Count = %d, %v", n, err)
+	}
+	page, err = repo.List(ctx, &ListOptions{Filter: Filter{"space_id": "s1"}, WithDeleted: true})
+	if err != nil || page.Total != 4 {
+		t.Errorf("List with deleted = %+v, %v", page, err)
+	}
+
+	if _, err := repo.List(ctx, &ListOptions{OrderBy: "title; DROP TABLE tasks"}); err == nil {
+		t.Error("expected invalid order by to be rejected")
+	}
+	if _, err := repo.List(ctx, &ListOptions{Filter: Filter{"1=1 OR id": "x"}}); err == nil {
+		t.Error("expected unknown filter column to be rejected")
+	}
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	db := openMemory(t)
+
+	for name, opts := range map[string]Options{
+		"no table":       {},
+		"bad table":      {Table: "tasks; --"},
+		"no tenant func": {Table: "tasks", TenantColumn: "space_id"},
+		"no id field":    {Table: "tasks", IDColumn: "uuid"},
+	} {
+		if _, err := New[Task, string](db, opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+type Note struct {
+	ID        string
+	Body      string
+	CreatedAt int64
+	UpdatedAt int64
+	CreatedBy string
+	UpdatedBy *string
+	DeletedAt *int64
+}
+
+type userKey struct{}
+
+func userOf(ctx context.Context) string {
+	u, _ := ctx.Value(userKey{}).(string)
+	return u
+}
+
+func TestAuditColumns(t *testing.T) {
+	repo, db := newRepo[Note](t, Options{Table: "notes", SoftDelete: "deleted_at", Audit: true, ActorFunc: userOf})
+	ctx := context.WithValue(context.Background(), userKey{}, "u1")
+
+	note := &Note{ID: "n1", Body: "hi"}
+	if err := repo.Create(ctx, note); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if note.CreatedAt == 0 || note.UpdatedAt != note.CreatedAt || note.CreatedBy != "u1" || note.UpdatedBy == nil || *note.UpdatedBy != "u1" {
+		t.Errorf("audit fields not stamped: %+v", note)
+	}
+
+	// The creation columns are kept on update
+	editor := context.WithValue(context.Background(), userKey{}, "u2")
+	if err := repo.Update(editor, &Note{ID: "n1", Body: "edited", CreatedBy: "forged"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	stored, err := repo.Get(ctx, "n1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Body != "edited" || stored.CreatedBy != "u1" || stored.CreatedAt != note.CreatedAt || *stored.UpdatedBy != "u2" || stored.UpdatedAt < note.UpdatedAt {
+		t.Errorf("updated note %+v", stored)
+	}
+
+	if err := repo.Delete(ctx, "n1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	var (
+		deletedAt sql.NullInt64
+		updatedBy string
+	)
+	if err := db.QueryRow("SELECT deleted_at, updated_by FROM notes WHERE id = 'n1'").Scan(&deletedAt, &updatedBy); err != nil {
+		t.Fatal(err)
+	}
+	if !deletedAt.Valid || deletedAt.Int64 < note.CreatedAt || updatedBy != "u1" {
+		t.Errorf("expected Unix millisecond deletion by u1, got %v by %s", deletedAt, updatedBy)
+	}
+}
+
+func TestScopeAndPurge(t *testing.T) {
+	repo, db := newRepo[Task](t, Options{Table: "tasks", Driver: "postgres", SoftDelete: "deleted_at", TenantColumn: "space_id", TenantFunc: spaceOf})
+	now := time.Now().UTC()
+	old, recent := now.Add(-60*24*time.Hour), now.Add(-time.Hour)
+	seed(t, db,
+		&Task{ID: "t1", SpaceID: "s1", Title: "a"},
+		&Task{ID: "t2", SpaceID: "s1", Title: "b"},
+		&Task{ID: "t3", SpaceID: "s2", Title: "a"},
+		&Task{ID: "t4", SpaceID: "s1", Title: "a", DeletedAt: &old},
+		&Task{ID: "t5", SpaceID: "s1", Title: "c", DeletedAt: &old},
+		&Task{ID: "t6", SpaceID: "s2", Title: "d", DeletedAt: &old},
+		&Task{ID: "t7", SpaceID: "s1", Title: "e", DeletedAt: &recent},
+	)
+	ctx := inSpace("s1")
+
+	where, args, err := repo.Scope(ctx, "title = ? OR title = ?", "a", "b")
+	if err != nil {
+		t.Fatalf("Scope: %v", err)
+	}
+	if want := " WHERE space_id = ? AND deleted_at IS NULL AND (title = ? OR title = ?)"; where != want || len(args) != 3 {
+		t.Errorf("Scope = %q %v, want %q", where, args, want)
+	}
+
+	found, err := repo.Find(ctx, "title = ?", "a")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "t1" {
+		t.Errorf("Find = %+v, want only t1", found)
+	}
+	if want := "SELECT id, space_id, title, deleted_at FROM tasks WHERE space_id = $1 AND deleted_at IS NULL AND (title = $2) ORDER BY id"; db.last != want {
+		t.Errorf("find query = %q, want %q", db.last, want)
+	}
+
+	trash, err := repo.List(ctx, &ListOptions{OnlyDeleted: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if trash.Total != 3 || len(trash.Items) != 3 || trash.Items[0].ID != "t4" || trash.Items[0].DeletedAt == nil {
+		t.Errorf("trash = %+v", trash)
+	}
+
+	// Purging runs across tenants, in batches until none is left
+	purged, err := repo.Purge(context.Background(), now.Add(-30*24*time.Hour), 2)
+	if err != nil || purged != 3 {
+		t.Fatalf("Purge = %d, %v, want 3", purged, err)
+	}
+	if want := "DELETE FROM tasks WHERE id IN (SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1 LIMIT $2)"; db.last != want {
+		t.Errorf("query = %q, want %q", db.last, want)
+	}
+	rows, err := db.Query("SELECT id FROM tasks ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var left []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		left = append(left, id)
+	}
+	if len(left) != 4 || left[3] != "t7" {
+		t.Errorf("tasks left %v, want t1, t2, t3 and t7", left)
+	}
+
+	// MySQL limits the DELETE itself, which sqlite does not support
+	mysql, db := newRepo[Task](t, Options{Table: "tasks", Driver: "mysql", SoftDelete: "deleted_at"})
+	_, _ = mysql.Purge(context.Background(), now, 0)
+	if want := "DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ? LIMIT ?"; db.last != want {
+		t.Errorf("mysql purge query = %q, want %q", db.last, want)
+	}
+}
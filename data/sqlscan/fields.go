@@ -0,0 +1,149 @@
+package sqlscan
+
+import (
+	"database/sql"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// TagName is the struct tag holding column names
+const TagName = "db"
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// fieldMap maps lower-cased column names to struct field index paths
+type fieldMap map[string][]int
+
+var fieldCache sync.Map // reflect.Type -> fieldMap
+
+// fieldsOf returns the cached column mapping of a struct type
+func fieldsOf(t reflect.Type) fieldMap {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(fieldMap)
+	}
+
+	fields := make(fieldMap)
+	collectFields(t, nil, "", map[reflect.Type]bool{}, fields)
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.(fieldMap)
+}
+
+// Column is a struct field mapped to a column
+type Column struct {
+	Name  string // Lower-cased column name
+	Index []int  // Field index path, for reflect.Value.FieldByIndex
+}
+
+// Columns returns the columns a struct type maps to, in field declaration order
+func Columns(t reflect.Type) []Column {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !isStruct(t) {
+		return nil
+	}
+
+	fields := fieldsOf(t)
+	columns := make([]Column, 0, len(fields))
+	for name, index := range fields {
+		columns = append(columns, Column{Name: name, Index: index})
+	}
+	slices.SortFunc(columns, func(a, b Column) int {
+		return slices.Compare(a.Index, b.Index)
+	})
+	return columns
+}
+
+// collectFields walks a struct, flattening embedded structs and prefixing nested ones.
+// Shallower fields win over deeper ones with the same column name, recursive types are not followed.
+func collectFields(t reflect.Type, index []int, prefix string, parents map[reflect.Type]bool, fields fieldMap) {
+	parents[t] = true
+	defer delete(parents, t)
+
+	type nested struct {
+		t      reflect.Type
+		index  []int
+		prefix string
+	}
+	var deferred []nested
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get(TagName)
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		path := append(append([]int(nil), index...), i)
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if isStruct(ft) {
+			if parents[ft] {
+				continue
+			}
+			// Unexported embedded pointers cannot be allocated
+			if !f.IsExported() && f.Type.Kind() == reflect.Pointer {
+				continue
+			}
+			switch {
+			case f.Anonymous && tag == "":
+				deferred = append(deferred, nested{ft, path, prefix})
+			default:
+				name := tag
+				if name == "" {
+					name = toSnakeCase(f.Name)
+				}
+				deferred = append(deferred, nested{ft, path, prefix + name + "_"})
+			}
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = toSnakeCase(f.Name)
+		}
+		name = strings.ToLower(prefix + name)
+		if _, exists := fields[name]; !exists {
+			fields[name] = path
+		}
+	}
+
+	for _, n := range deferred {
+		collectFields(n.t, n.index, n.prefix, parents, fields)
+	}
+}
+
+// isStruct reports whether t is a struct mapped field by field rather than scanned as a value
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// toSnakeCase converts a Go field name to snake case, keeping acronyms together (UserID -> user_id)
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,235 @@
+// Package sqlscan maps database/sql rows into structs, replacing hand written Scan lists.
+//
+// Columns are matched case-insensitively against the `db` tag of exported fields,
+// or the snake case field name when untagged. Embedded structs are flattened,
+// nested struct fields are matched with their name as prefix (author_name for
+// Author.Name). NULL leaves pointer fields nil and other fields at their zero value.
+package sqlscan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrMissingField is returned when a column has no matching struct field
+var ErrMissingField = errors.New("sqlscan: no matching field")
+
+// Rows is the subset of *sql.Rows used by the scanner
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Columns() ([]string, error)
+	Err() error
+	Close() error
+}
+
+// Querier runs queries, implemented by *sql.DB, *sql.Tx and *sql.Conn
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Query runs a query and scans all rows into T
+func Query[T any](ctx context.Context, q Querier, query string, args ...any) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return All[T](rows)
+}
+
+// Get runs a query and scans the first row into T, returning sql.ErrNoRows if there is none
+func Get[T any](ctx context.Context, q Querier, query string, args ...any) (T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return One[T](rows)
+}
+
+// All scans all remaining rows into T and closes rows
+func All[T any](rows Rows) ([]T, error) {
+	defer rows.Close()
+
+	p, err := newPlan(reflect.TypeFor[T](), rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []T
+	for rows.Next() {
+		var item T
+		if err := p.scan(rows, reflect.ValueOf(&item).Elem()); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// One scans the next row into T and closes rows, returning sql.ErrNoRows if there is none
+func One[T any](rows Rows) (T, error) {
+	defer rows.Close()
+
+	var item T
+	p, err := newPlan(reflect.TypeFor[T](), rows)
+	if err != nil {
+		return item, err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return item, err
+		}
+		return item, sql.ErrNoRows
+	}
+
+	if err := p.scan(rows, reflect.ValueOf(&item).Elem()); err != nil {
+		return item, err
+	}
+	return item, rows.Err()
+}
+
+// Row scans the current row into dest, a pointer to a struct or value.
+// Unlike All and One it neither advances nor closes rows.
+func Row(rows Rows, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("sqlscan: destination must be a non-nil pointer, got %T", dest)
+	}
+
+	p, err := newPlan(v.Elem().Type(), rows)
+	if err != nil {
+		return err
+	}
+	return p.scan(rows, v.Elem())
+}
+
+// plan describes how the columns of a result set map onto a type
+type plan struct {
+	paths [][]int // field index path per column, nil when scanning a single value
+}
+
+// newPlan resolves the columns of rows against t
+func newPlan(t reflect.Type, rows Rows) (*plan, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{}
+	base := t
+	if base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+
+	if !isStruct(base) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("sqlscan: scanning into %s requires 1 column, got %d", t, len(columns))
+		}
+		return p, nil
+	}
+
+	fields := fieldsOf(base)
+	p.paths = make([][]int, len(columns))
+	var missing []string
+	for i, col := range columns {
+		path, ok := fields[strings.ToLower(col)]
+		if !ok {
+			missing = append(missing, col)
+			continue
+		}
+		p.paths[i] = path
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w in %s for columns: %s", ErrMissingField, base, strings.Join(missing, ", "))
+	}
+
+	return p, nil
+}
+
+// scan scans the current row into v
+func (p *plan) scan(rows Rows, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer && p.paths != nil {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if p.paths == nil {
+		d := newDest(v)
+		if err := rows.Scan(d.ptr); err != nil {
+			return fmt.Errorf("sqlscan: %w", err)
+		}
+		d.apply()
+		return nil
+	}
+
+	dests := make([]dest, len(p.paths))
+	ptrs := make([]any, len(p.paths))
+	for i, path := range p.paths {
+		dests[i] = newDest(fieldByIndexAlloc(v, path))
+		ptrs[i] = dests[i].ptr
+	}
+
+	if err := rows.Scan(ptrs...); err != nil {
+		return fmt.Errorf("sqlscan: %w", err)
+	}
+	for _, d := range dests {
+		d.apply()
+	}
+	return nil
+}
+
+// dest is a scan destination for a field, tolerating NULL for non-pointer fields
+type dest struct {
+	field reflect.Value
+	tmp   reflect.Value // **T holding the scanned value, invalid when scanning directly
+	ptr   any
+}
+
+// newDest creates the scan destination of a field
+func newDest(field reflect.Value) dest {
+	// Pointers are set to nil on NULL and scanners handle NULL themselves
+	if field.Kind() == reflect.Pointer || field.Addr().Type().Implements(scannerType) {
+		return dest{field: field, ptr: field.Addr().Interface()}
+	}
+
+	tmp := reflect.New(reflect.PointerTo(field.Type()))
+	return dest{field: field, tmp: tmp, ptr: tmp.Interface()}
+}
+
+// apply copies the scanned value into the field, the zero value for NULL
+func (d dest) apply() {
+	if !d.tmp.IsValid() {
+		return
+	}
+	if v := d.tmp.Elem(); v.IsNil() {
+		d.field.SetZero()
+	} else {
+		d.field.Set(v.Elem())
+	}
+}
+
+// fieldByIndexAlloc returns the nested field, allocating nil embedded or nested pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
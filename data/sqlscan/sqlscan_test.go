@@ -0,0 +1,202 @@
+package sqlscan
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeDriver returns canned result sets keyed by query
+type fakeDriver struct{}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var fakeResults = map[string]fakeResult{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	res, ok := fakeResults[s.query]
+	if !ok {
+		return nil, errors.New("unknown query")
+	}
+	return &fakeRows{res: res}, nil
+}
+
+type fakeRows struct {
+	res fakeResult
+	pos int
+}
+
+func (r *fakeRows) Columns() []string { return r.res.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.res.rows) {
+		return io.EOF
+	}
+	copy(dest, r.res.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func init() {
+	sql.Register("sqlscan-fake", fakeDriver{})
+}
+
+func openFake(t *testing.T, query string, res fakeResult) *sql.DB {
+	t.Helper()
+	fakeResults[query] = res
+	db, err := sql.Open("sqlscan-fake", "")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+type Audit struct {
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type Author struct {
+	ID   int64
+	Name string
+}
+
+type Task struct {
+	Audit
+	ID          string
+	WorkspaceID string
+	Title       string         `db:"name"`
+	Priority    int            `db:"prio"`
+	DueDate     *time.Time     `db:"due_date"`
+	Note        sql.NullString `db:"note"`
+	Author      Author
+	Internal    string `db:"-"`
+}
+
+func TestAllMapsColumnsToFields(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := openFake(t, "tasks", fakeResult{
+		columns: []string{"id", "workspace_id", "NAME", "prio", "due_date", "note", "created_at", "updated_at", "author_id", "author_name"},
+		rows: [][]driver.Value{
+			{"t1", "w1", "first", int64(2), now, "hello", now, now, int64(7), "ann"},
+			{"t2", "w1", "second", nil, nil, nil, now, nil, int64(8), nil},
+		},
+	})
+
+	tasks, err := Query[*Task](context.Background(), db, "tasks")
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+
+	first := tasks[0]
+	if first.ID != "t1" || first.WorkspaceID != "w1" || first.Title != "first" || first.Priority != 2 {
+		t.Errorf("unexpected first task: %+v", first)
+	}
+	if first.DueDate == nil || !first.DueDate.Equal(now) {
+		t.Errorf("expected due date %v, got %v", now, first.DueDate)
+	}
+	if !first.Note.Valid || first.Note.String != "hello" {
+		t.Errorf("expected valid note, got %+v", first.Note)
+	}
+	if !first.CreatedAt.Equal(now) || first.UpdatedAt == nil {
+		t.Errorf("expected embedded audit fields, got %+v", first.Audit)
+	}
+	if first.Author.ID != 7 || first.Author.Name != "ann" {
+		t.Errorf("expected nested author, got %+v", first.Author)
+	}
+
+	second := tasks[1]
+	if second.Priority != 0 || second.DueDate != nil || second.Note.Valid || second.UpdatedAt != nil || second.Author.Name != "" {
+		t.Errorf("expected NULLs to map to zero values, got %+v", second)
+	}
+}
+
+func TestAllRejectsUnknownColumns(t *testing.T) {
+	db := openFake(t, "unknown", fakeResult{
+		columns: []string{"id", "bogus"},
+		rows:    [][]driver.Value{{"t1", "x"}},
+	})
+
+	_, err := Query[Task](context.Background(), db, "unknown")
+	if !errors.Is(err, ErrMissingField) {
+		t.Fatalf("expected ErrMissingField, got %v", err)
+	}
+}
+
+func TestGetScalar(t *testing.T) {
+	db := openFake(t, "count", fakeResult{
+		columns: []string{"count"},
+		rows:    [][]driver.Value{{int64(42)}},
+	})
+
+	n, err := Get[int](context.Background(), db, "count")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42, got %d", n)
+	}
+}
+
+func TestGetNoRows(t *testing.T) {
+	db := openFake(t, "empty", fakeResult{columns: []string{"id"}})
+
+	_, err := Get[Task](context.Background(), db, "empty")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestColumnsInDeclarationOrder(t *testing.T) {
+	var names []string
+	for _, c := range Columns(reflect.TypeFor[*Task]()) {
+		names = append(names, c.Name)
+	}
+	want := []string{"created_at", "updated_at", "id", "workspace_id", "name", "prio", "due_date", "note", "author_id", "author_name"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Columns = %v, want %v", names, want)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ID":          "id",
+		"WorkspaceID": "workspace_id",
+		"HTTPServer":  "http_server",
+		"CreatedAt":   "created_at",
+		"Address2":    "address2",
+	}
+	for in, want := range cases {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/ncobase/ncore/data/tenancy"
+)
+
+// TenantRouter returns a router sending the queries of the tenant in the context
+// to its schema or database, provisioned from the master database. The driver is
+// taken from the master configuration unless opts sets one.
+func (d *Data) TenantRouter(opts tenancy.Options) (*tenancy.Router, error) {
+	db := d.GetMasterDB()
+	if db == nil {
+		return nil, errors.New("database connection is nil")
+	}
+	if opts.Driver == "" {
+		opts.Driver = d.masterDriver()
+	}
+	return tenancy.NewRouter(db, opts)
+}
@@ -0,0 +1,26 @@
+// Package tenancy isolates the data of tenants in the data layer.
+//
+// The tenant of a request is resolved from a header, the context set by the
+// auth middleware or a JWT claim and carried in the context:
+//
+//	resolve := tenancy.Chain(
+//		tenancy.Context(ctxutil.GetSpaceID),
+//		tenancy.Claim("tenant_id", tm.DecodeToken),
+//	)
+//	handler = tenancy.Middleware(resolve, true)(handler)
+//
+// A Router sends the queries of the tenant in the context to its Postgres schema
+// through search_path or its MySQL database, on the shared pool, or to its own
+// database through a pool opened from a DSN template:
+//
+//	router, err := d.TenantRouter(tenancy.Options{Strategy: tenancy.SchemaPerTenant})
+//	err = router.Tx(ctx, nil, func(tx *sql.Tx) error {
+//		return repo.WithDB(tx).Create(ctx, user)
+//	})
+//
+// MongoDB databases are named with Name, and caches are scoped to the tenant
+// with ScopeKey, which the tenant scoped caches of data/cache use.
+//
+// Tenant schemas are provisioned and migrated with Router.Provision and
+// Router.Migrate, or through the management API of Router.RegisterRoutes.
+package tenancy
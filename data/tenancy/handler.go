@@ -0,0 +1,100 @@
+package tenancy
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/data/migrate"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// RegisterRoutes mounts the tenant management API, migrating tenants with the
+// migrations in fsys:
+//
+//	GET  /                      provisioned tenants
+//	PUT  /:tenant               provision and migrate a tenant
+//	POST /migrate               migrate all tenants
+//	POST /:tenant/migrate       migrate a tenant
+//	GET  /:tenant/migrations    migration status of a tenant
+//
+// Mount it on a group behind authorization.
+func (r *Router) RegisterRoutes(g *gin.RouterGroup, fsys fs.FS, opts ...migrate.Options) {
+	g.GET("", func(c *gin.Context) {
+		ids, err := r.Tenants(c.Request.Context())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, map[string]any{"tenants": ids})
+	})
+
+	g.PUT("/:tenant", func(c *gin.Context) {
+		id := c.Param("tenant")
+		if err := r.Provision(c.Request.Context(), id); err != nil {
+			fail(c, err)
+			return
+		}
+		applied, err := r.Migrate(c.Request.Context(), id, fsys, opts...)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, map[string]any{"tenant": id, "applied": versions(applied)})
+	})
+
+	g.POST("/migrate", func(c *gin.Context) {
+		applied, err := r.MigrateAll(c.Request.Context(), fsys, opts...)
+		result := make(map[string][]int64, len(applied))
+		for id, done := range applied {
+			result[id] = versions(done)
+		}
+		if err != nil {
+			resp.Fail(c.Writer, resp.InternalServer(err.Error(), map[string]any{"applied": result}))
+			return
+		}
+		resp.Success(c.Writer, map[string]any{"applied": result})
+	})
+
+	g.POST("/:tenant/migrate", func(c *gin.Context) {
+		id := c.Param("tenant")
+		applied, err := r.Migrate(c.Request.Context(), id, fsys, opts...)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, map[string]any{"tenant": id, "applied": versions(applied)})
+	})
+
+	g.GET("/:tenant/migrations", func(c *gin.Context) {
+		m, err := r.Migrator(c.Param("tenant"), fsys, opts...)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		statuses, err := m.Status(c.Request.Context())
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		resp.Success(c.Writer, statuses)
+	})
+}
+
+// versions returns the versions of migrations
+func versions(migrations []*migrate.Migration) []int64 {
+	v := make([]int64, len(migrations))
+	for i, m := range migrations {
+		v[i] = m.Version
+	}
+	return v
+}
+
+// fail maps tenancy errors to responses
+func fail(c *gin.Context, err error) {
+	if errors.Is(err, ErrInvalidTenant) {
+		resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+		return
+	}
+	resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+}
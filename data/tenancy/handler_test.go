@@ -0,0 +1,75 @@
+package tenancy
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, r *Router, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	r.RegisterRoutes(engine.Group("/tenants"), fstest.MapFS{})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestRoutesListTenants(t *testing.T) {
+	db, rec := openRecorder(t)
+	rec.rows = []driver.Value{"app", "tenant_globex", "tenant_acme", "tenant_Bad!"}
+	r, err := NewRouter(db, Options{Driver: "mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(t, r, http.MethodGet, "/tenants")
+	var body struct {
+		Tenants []string `json:"tenants"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("GET /tenants = %d %s", w.Code, w.Body)
+	}
+	if !slices.Equal(body.Tenants, []string{"acme", "globex"}) {
+		t.Fatalf("tenants = %v", body.Tenants)
+	}
+}
+
+func TestRoutesMapErrors(t *testing.T) {
+	db, rec := openRecorder(t)
+	r, err := NewRouter(db, Options{Driver: "mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid tenant IDs are rejected before reaching the database
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPut, "/tenants/bad.tenant"},
+		{http.MethodPost, "/tenants/bad.tenant/migrate"},
+		{http.MethodGet, "/tenants/bad.tenant/migrations"},
+	} {
+		if w := serve(t, r, tc.method, tc.path); w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d %s, want 400", tc.method, tc.path, w.Code, w.Body)
+		}
+	}
+	if got := rec.statements(); len(got) != 0 {
+		t.Fatalf("statements = %q, want none", got)
+	}
+
+	// Listing needs the postgres or mysql driver
+	sqlite, err := NewRouter(db, Options{Strategy: DatabasePerTenant, Driver: "sqlite3", DSN: "file:{database}.db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(t, sqlite, http.MethodGet, "/tenants"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("GET /tenants on sqlite = %d %s, want 500", w.Code, w.Body)
+	}
+}
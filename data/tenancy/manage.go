@@ -0,0 +1,162 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/ncobase/ncore/data/migrate"
+)
+
+// Provision creates the schema or database of tenant id if it does not exist
+func (r *Router) Provision(ctx context.Context, id string) error {
+	name, err := r.Name(id)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case r.postgres && r.opts.Strategy == SchemaPerTenant:
+		_, err = r.db.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS "`+name+`"`)
+	case r.postgres:
+		// CREATE DATABASE has no IF NOT EXISTS on Postgres
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check tenant database %s: %w", name, err)
+		}
+		if !exists {
+			_, err = r.db.ExecContext(ctx, `CREATE DATABASE "`+name+`"`)
+		}
+	case r.mysql:
+		_, err = r.db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS `"+name+"`")
+	default:
+		return fmt.Errorf("provisioning tenants needs the postgres or mysql driver: %w", errors.ErrUnsupported)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to provision tenant %s: %w", id, err)
+	}
+	return nil
+}
+
+// Drop deletes the schema or database of tenant id and all its data
+func (r *Router) Drop(ctx context.Context, id string) error {
+	name, err := r.Name(id)
+	if err != nil {
+		return err
+	}
+	if err := r.Evict(id); err != nil {
+		return err
+	}
+
+	switch {
+	case r.postgres && r.opts.Strategy == SchemaPerTenant:
+		_, err = r.db.ExecContext(ctx, `DROP SCHEMA IF EXISTS "`+name+`" CASCADE`)
+	case r.postgres:
+		_, err = r.db.ExecContext(ctx, `DROP DATABASE IF EXISTS "`+name+`"`)
+	case r.mysql:
+		_, err = r.db.ExecContext(ctx, "DROP DATABASE IF EXISTS `"+name+"`")
+	default:
+		return fmt.Errorf("dropping tenants needs the postgres or mysql driver: %w", errors.ErrUnsupported)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drop tenant %s: %w", id, err)
+	}
+	return nil
+}
+
+// Tenants returns the IDs of the provisioned tenants, sorted
+func (r *Router) Tenants(ctx context.Context) ([]string, error) {
+	var query string
+	switch {
+	case r.postgres && r.opts.Strategy == SchemaPerTenant:
+		query = "SELECT schema_name FROM information_schema.schemata"
+	case r.postgres:
+		query = "SELECT datname FROM pg_database"
+	case r.mysql:
+		query = "SELECT schema_name FROM information_schema.schemata"
+	default:
+		return nil, fmt.Errorf("listing tenants needs the postgres or mysql driver: %w", errors.ErrUnsupported)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	// Filtered here, as _ in the prefix is a LIKE wildcard
+	var ids []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list tenants: %w", err)
+		}
+		if id, ok := strings.CutPrefix(name, r.opts.Prefix); ok && Validate(id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Migrator returns a migrator for fsys on the schema or database of tenant id
+func (r *Router) Migrator(id string, fsys fs.FS, opts ...migrate.Options) (*migrate.Migrator, error) {
+	name, err := r.Name(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var o migrate.Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Driver == "" {
+		o.Driver = r.opts.Driver
+	}
+
+	db := r.db
+	if r.opts.Strategy == DatabasePerTenant {
+		if db, err = r.pool(name); err != nil {
+			return nil, err
+		}
+	} else {
+		o.Schema = name
+	}
+	return migrate.New(db, fsys, o)
+}
+
+// Migrate applies the pending migrations in fsys to the schema or database of
+// tenant id
+func (r *Router) Migrate(ctx context.Context, id string, fsys fs.FS, opts ...migrate.Options) ([]*migrate.Migration, error) {
+	m, err := r.Migrator(id, fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return m.Up(ctx)
+}
+
+// MigrateAll migrates every provisioned tenant, carrying on past failures, and
+// returns the migrations applied by tenant
+func (r *Router) MigrateAll(ctx context.Context, fsys fs.FS, opts ...migrate.Options) (map[string][]*migrate.Migration, error) {
+	ids, err := r.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string][]*migrate.Migration, len(ids))
+	var errs []error
+	for _, id := range ids {
+		done, err := r.Migrate(ctx, id, fsys, opts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", id, err))
+		}
+		applied[id] = done
+	}
+	return applied, errors.Join(errs...)
+}
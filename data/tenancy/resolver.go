@@ -0,0 +1,99 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultHeader carries the tenant of a request for Header
+const DefaultHeader = "X-Tenant-ID"
+
+// Resolver finds the tenant of a request, empty when the request names none
+type Resolver func(r *http.Request) (string, error)
+
+// Header resolves the tenant from a request header, DefaultHeader if name is
+// empty. Clients choose the header freely, so use it behind a gateway setting it
+// or check that the user belongs to the tenant.
+func Header(name string) Resolver {
+	if name == "" {
+		name = DefaultHeader
+	}
+	return func(r *http.Request) (string, error) {
+		return strings.TrimSpace(r.Header.Get(name)), nil
+	}
+}
+
+// Context resolves the tenant from the request context, e.g. ctxutil.GetSpaceID
+// once the auth middleware ran
+func Context(fn func(ctx context.Context) string) Resolver {
+	return func(r *http.Request) (string, error) {
+		return fn(r.Context()), nil
+	}
+}
+
+// Claim resolves the tenant from a claim of the bearer token. decode must verify
+// the token, e.g. the DecodeToken method of a jwt.TokenManager.
+func Claim(claim string, decode func(token string) (map[string]any, error)) Resolver {
+	return func(r *http.Request) (string, error) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return "", nil
+		}
+		claims, err := decode(token)
+		if err != nil {
+			return "", fmt.Errorf("invalid token: %w", err)
+		}
+		switch v := claims[claim].(type) {
+		case nil:
+			return "", nil
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		default:
+			return "", fmt.Errorf("%w: claim %s is a %T", ErrInvalidTenant, claim, v)
+		}
+	}
+}
+
+// Chain returns the tenant of the first resolver finding one
+func Chain(resolvers ...Resolver) Resolver {
+	return func(r *http.Request) (string, error) {
+		for _, resolve := range resolvers {
+			id, err := resolve(r)
+			if err != nil || id != "" {
+				return id, err
+			}
+		}
+		return "", nil
+	}
+}
+
+// Middleware sets the tenant resolved for each request in its context. Requests
+// naming an invalid tenant are rejected, as are those naming none if required.
+func Middleware(resolve Resolver, required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := resolve(r)
+			if err == nil && id != "" {
+				err = Validate(id)
+			}
+			switch {
+			case errors.Is(err, ErrInvalidTenant):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			case id == "" && required:
+				http.Error(w, ErrNoTenant.Error(), http.StatusBadRequest)
+			case id == "":
+				next.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), id)))
+			}
+		})
+	}
+}
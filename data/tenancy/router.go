@@ -0,0 +1,266 @@
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrSharedPool is returned by Router.DB under SchemaPerTenant, whose tenants
+// share the pool and are only routed by WithConn and Tx
+var ErrSharedPool = errors.New("tenancy: schema per tenant shares the pool, use WithConn or Tx")
+
+// Strategy isolates the data of tenants
+type Strategy string
+
+const (
+	// SchemaPerTenant keeps each tenant in a Postgres schema or MySQL database of the shared server
+	SchemaPerTenant Strategy = "schema"
+	// DatabasePerTenant keeps each tenant in a database with its own pool
+	DatabasePerTenant Strategy = "database"
+)
+
+// Options configures a Router
+type Options struct {
+	Strategy Strategy // Defaults to SchemaPerTenant
+	Driver   string   // postgres, pgx or mysql, required by SchemaPerTenant
+	Prefix   string   // Prefixes tenant schema and database names, defaults to DefaultPrefix
+
+	// DSN of tenant databases under DatabasePerTenant, with {database} replaced by
+	// the name of the tenant database
+	DSN          string
+	MaxOpenConns int // Per tenant database, defaults to 10
+	MaxIdleConns int // Per tenant database, defaults to 2
+	// Open opens tenant databases, defaults to sql.Open
+	Open func(driver, dsn string) (*sql.DB, error)
+}
+
+// Router routes queries to the schema or database of the tenant in the context
+type Router struct {
+	db       *sql.DB
+	opts     Options
+	postgres bool
+	mysql    bool
+
+	mu     sync.Mutex
+	pools  map[string]*sql.DB // Tenant databases by name
+	closed bool
+}
+
+// NewRouter creates a router on db, the shared database holding tenant schemas
+// or used to provision tenant databases
+func NewRouter(db *sql.DB, opts Options) (*Router, error) {
+	if db == nil {
+		return nil, errors.New("database is nil")
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = SchemaPerTenant
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultPrefix
+	}
+	if opts.MaxOpenConns <= 0 {
+		opts.MaxOpenConns = 10
+	}
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = 2
+	}
+	if opts.Open == nil {
+		opts.Open = sql.Open
+	}
+
+	r := &Router{
+		db:       db,
+		opts:     opts,
+		postgres: opts.Driver == "postgres" || opts.Driver == "pgx",
+		mysql:    opts.Driver == "mysql",
+		pools:    make(map[string]*sql.DB),
+	}
+
+	switch opts.Strategy {
+	case SchemaPerTenant:
+		if !r.postgres && !r.mysql {
+			return nil, fmt.Errorf("schema per tenant needs the postgres or mysql driver, got %q", opts.Driver)
+		}
+	case DatabasePerTenant:
+		if !strings.Contains(opts.DSN, "{database}") {
+			return nil, errors.New("database per tenant needs a DSN with a {database} placeholder")
+		}
+	default:
+		return nil, fmt.Errorf("unknown tenancy strategy %q", opts.Strategy)
+	}
+	return r, nil
+}
+
+// Strategy returns the isolation strategy of the router
+func (r *Router) Strategy() Strategy {
+	return r.opts.Strategy
+}
+
+// Name returns the schema or database name of tenant id
+func (r *Router) Name(id string) (string, error) {
+	return Name(r.opts.Prefix, id)
+}
+
+// DB returns the pool of the database of the tenant in ctx under
+// DatabasePerTenant, and ErrSharedPool under SchemaPerTenant
+func (r *Router) DB(ctx context.Context) (*sql.DB, error) {
+	if r.opts.Strategy == SchemaPerTenant {
+		return nil, ErrSharedPool
+	}
+	name, err := r.tenantName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.pool(name)
+}
+
+// WithConn runs fn on a connection to the schema or database of the tenant in
+// ctx. Under SchemaPerTenant the connection is reset before returning to the
+// shared pool, so fn must not keep it.
+func (r *Router) WithConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	name, err := r.tenantName(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := r.db
+	if r.opts.Strategy == DatabasePerTenant {
+		if db, err = r.pool(name); err != nil {
+			return err
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get tenant connection: %w", err)
+	}
+	defer conn.Close()
+
+	if r.opts.Strategy == SchemaPerTenant {
+		reset, err := r.use(ctx, conn, name)
+		if err != nil {
+			return err
+		}
+		defer reset()
+	}
+	return fn(conn)
+}
+
+// Tx runs fn in a transaction in the schema or database of the tenant in ctx,
+// committed if fn succeeds and rolled back otherwise
+func (r *Router) Tx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	return r.WithConn(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to begin tenant transaction: %w", err)
+		}
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// Evict closes the pool of tenant id under DatabasePerTenant, reopened on next use
+func (r *Router) Evict(id string) error {
+	name, err := r.Name(id)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	db, ok := r.pools[name]
+	delete(r.pools, name)
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return db.Close()
+}
+
+// Close closes the tenant database pools, not the shared database
+func (r *Router) Close() error {
+	r.mu.Lock()
+	pools := r.pools
+	r.pools = make(map[string]*sql.DB)
+	r.closed = true
+	r.mu.Unlock()
+
+	var errs []error
+	for _, db := range pools {
+		errs = append(errs, db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// tenantName returns the schema or database name of the tenant in ctx
+func (r *Router) tenantName(ctx context.Context) (string, error) {
+	id := FromContext(ctx)
+	if id == "" {
+		return "", ErrNoTenant
+	}
+	return r.Name(id)
+}
+
+// pool returns the database pool named name, opening it on first use
+func (r *Router) pool(name string) (*sql.DB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, errors.New("tenant router is closed")
+	}
+	if db, ok := r.pools[name]; ok {
+		return db, nil
+	}
+
+	db, err := r.opts.Open(r.opts.Driver, strings.ReplaceAll(r.opts.DSN, "{database}", name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tenant database %s: %w", name, err)
+	}
+	db.SetMaxOpenConns(r.opts.MaxOpenConns)
+	db.SetMaxIdleConns(r.opts.MaxIdleConns)
+	r.pools[name] = db
+	return db, nil
+}
+
+// use points conn at schema name and returns the reset restoring the connection
+// default, discarding the connection if that fails
+func (r *Router) use(ctx context.Context, conn *sql.Conn, name string) (func(), error) {
+	if r.postgres {
+		if _, err := conn.ExecContext(ctx, `SET search_path TO "`+name+`"`); err != nil {
+			return nil, fmt.Errorf("failed to use schema %s: %w", name, err)
+		}
+		return func() {
+			if _, err := conn.ExecContext(context.Background(), "RESET search_path"); err != nil {
+				discard(conn)
+			}
+		}, nil
+	}
+
+	var current sql.NullString
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+		return nil, fmt.Errorf("failed to read current database: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "USE `"+name+"`"); err != nil {
+		return nil, fmt.Errorf("failed to use database %s: %w", name, err)
+	}
+	return func() {
+		if !current.Valid {
+			discard(conn)
+			return
+		}
+		if _, err := conn.ExecContext(context.Background(), "USE `"+current.String+"`"); err != nil {
+			discard(conn)
+		}
+	}, nil
+}
+
+// discard closes conn instead of returning it to the pool still routed to a tenant
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+}
@@ -0,0 +1,229 @@
+package tenancy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// recorder is a database/sql driver recording the statements it runs
+type recorder struct {
+	mu    sync.Mutex
+	stmts []string
+	rows  []driver.Value // Returned by queries, a single "app" by default
+}
+
+func (d *recorder) Open(string) (driver.Conn, error) { return &recordConn{d: d}, nil }
+
+func (d *recorder) record(query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stmts = append(d.stmts, query)
+}
+
+func (d *recorder) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.stmts)
+}
+
+type recordConn struct{ d *recorder }
+
+func (c *recordConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *recordConn) Close() error                        { return nil }
+func (c *recordConn) Begin() (driver.Tx, error)           { c.d.record("BEGIN"); return c, nil }
+func (c *recordConn) Commit() error                       { c.d.record("COMMIT"); return nil }
+func (c *recordConn) Rollback() error                     { c.d.record("ROLLBACK"); return nil }
+
+func (c *recordConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(query)
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	if c.d.rows != nil {
+		return &recordRows{values: slices.Clone(c.d.rows)}, nil
+	}
+	return &recordRows{values: []driver.Value{"app"}}, nil
+}
+
+type recordRows struct{ values []driver.Value }
+
+func (r *recordRows) Columns() []string { return []string{"name"} }
+func (r *recordRows) Close() error      { return nil }
+
+func (r *recordRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+var registerOnce sync.Once
+
+func openRecorder(t *testing.T) (*sql.DB, *recorder) {
+	t.Helper()
+	d := &recorder{}
+	registerOnce.Do(func() { sql.Register("tenancy_recorder", &dispatch{}) })
+	dispatchers.Store(t.Name(), d)
+	db, err := sql.Open("tenancy_recorder", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+// dispatch routes connections to the recorder registered under the DSN
+type dispatch struct{}
+
+var dispatchers sync.Map
+
+func (dispatch) Open(dsn string) (driver.Conn, error) {
+	d, ok := dispatchers.Load(dsn)
+	if !ok {
+		return nil, errors.New("unknown recorder " + dsn)
+	}
+	return d.(*recorder).Open(dsn)
+}
+
+func TestName(t *testing.T) {
+	if name, err := Name(DefaultPrefix, "acme-1"); err != nil || name != "tenant_acme-1" {
+		t.Fatalf("Name = %q, %v", name, err)
+	}
+	for _, id := range []string{"", "a;b", `a"b`, "a b", "-a", string(make([]byte, 64))} {
+		if _, err := Name(DefaultPrefix, id); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("Name(%q) error = %v", id, err)
+		}
+	}
+
+	ctx := context.Background()
+	if key := ScopeKey(ctx, "user:1"); key != "user:1" {
+		t.Fatalf("unscoped key = %q", key)
+	}
+	if key := ScopeKey(WithTenant(ctx, "acme"), "user:1"); key != "tenant:acme:user:1" {
+		t.Fatalf("scoped key = %q", key)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	decode := func(token string) (map[string]any, error) {
+		if token != "valid" {
+			return nil, errors.New("bad signature")
+		}
+		return map[string]any{"tenant_id": "from-claim"}, nil
+	}
+	resolve := Chain(Header(""), Claim("tenant_id", decode))
+	handler := Middleware(resolve, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, FromContext(r.Context()))
+	}))
+
+	cases := []struct {
+		header, token string
+		status        int
+		tenant        string
+	}{
+		{header: "acme", status: http.StatusOK, tenant: "acme"},
+		{token: "valid", status: http.StatusOK, tenant: "from-claim"},
+		{token: "forged", status: http.StatusUnauthorized},
+		{header: "acme;drop", status: http.StatusBadRequest},
+		{status: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(DefaultHeader, tc.header)
+		}
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status || (tc.status == http.StatusOK && rec.Body.String() != tc.tenant) {
+			t.Errorf("%+v: got %d %q", tc, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestRouterSchemaPerTenant(t *testing.T) {
+	db, rec := openRecorder(t)
+	ctx := context.Background()
+
+	r, err := NewRouter(db, Options{Driver: "mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WithConn(ctx, func(*sql.Conn) error { return nil }); !errors.Is(err, ErrNoTenant) {
+		t.Fatalf("WithConn without tenant error = %v", err)
+	}
+	if _, err := r.DB(ctx); !errors.Is(err, ErrSharedPool) {
+		t.Fatalf("DB error = %v", err)
+	}
+
+	ctx = WithTenant(ctx, "acme")
+	err = r.Tx(ctx, nil, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO users VALUES (1)")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"SELECT DATABASE()", "USE `tenant_acme`", "BEGIN", "INSERT INTO users VALUES (1)", "COMMIT", "USE `app`"}
+	if got := rec.statements(); !slices.Equal(got, want) {
+		t.Fatalf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestRouterDatabasePerTenant(t *testing.T) {
+	db, _ := openRecorder(t)
+	tenantDB, rec := openRecorder(t)
+
+	var opened []string
+	r, err := NewRouter(db, Options{
+		Strategy: DatabasePerTenant,
+		Driver:   "postgres",
+		DSN:      "postgres://localhost/{database}",
+		Open: func(_, dsn string) (*sql.DB, error) {
+			opened = append(opened, dsn)
+			return tenantDB, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	for range 2 {
+		if _, err := r.DB(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(opened, []string{"postgres://localhost/tenant_acme"}) {
+		t.Fatalf("opened = %q", opened)
+	}
+
+	// Tenant databases are not switched with search_path
+	if err := r.WithConn(ctx, func(*sql.Conn) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.statements(); len(got) != 0 {
+		t.Fatalf("statements = %q", got)
+	}
+
+	if _, err := NewRouter(db, Options{Strategy: DatabasePerTenant, DSN: "postgres://localhost/app"}); err == nil {
+		t.Fatal("expected error for a DSN without {database}")
+	}
+}
@@ -0,0 +1,69 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	// ErrNoTenant is returned when the context carries no tenant
+	ErrNoTenant = errors.New("tenancy: no tenant in context")
+	// ErrInvalidTenant is returned for a tenant ID unsafe as a schema or database name
+	ErrInvalidTenant = errors.New("tenancy: invalid tenant id")
+)
+
+// DefaultPrefix prefixes the schema and database names of tenants
+const DefaultPrefix = "tenant_"
+
+// maxNameLength is the identifier limit of Postgres, below MySQL and MongoDB's
+const maxNameLength = 63
+
+// validID matches tenant IDs usable in identifiers without escaping
+var validID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant id
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant in ctx, empty if none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// Validate checks that id is usable in schema, database and cache key names
+func Validate(id string) error {
+	if len(id) > maxNameLength || !validID.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+	}
+	return nil
+}
+
+// Name returns the schema or database name of tenant id, e.g. for MongoDB:
+//
+//	name, err := tenancy.Name(tenancy.DefaultPrefix, id)
+//	db := client.Database(name)
+func Name(prefix, id string) (string, error) {
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+	name := prefix + id
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidTenant, name, maxNameLength)
+	}
+	return name, nil
+}
+
+// ScopeKey prefixes a cache key with the tenant in ctx, leaving it unchanged
+// without a tenant
+func ScopeKey(ctx context.Context, key string) string {
+	if id := FromContext(ctx); id != "" {
+		return "tenant:" + id + ":" + key
+	}
+	return key
+}
@@ -0,0 +1,20 @@
+// Package tracing records OpenTelemetry spans for the data layer.
+//
+// The spans go to the global tracer provider, which the extension manager
+// sets up from observes.tracer, so nothing is exported until it is configured.
+// The SQL, Redis, MongoDB and search clients created by ncore are instrumented
+// already; OpenDB instruments other database/sql pools by intercepting the
+// driver:
+//
+//	db, err := tracing.OpenDB("pgx", dsn, "postgresql")
+//
+// Each query, statement and transaction end becomes a client span carrying
+// the db.system, db.operation and db.statement attributes. Argument values
+// are never recorded.
+//
+// Start and End build spans for other clients:
+//
+//	ctx, span := tracing.Start(ctx, "geocode", attribute.String("peer.service", "maps"))
+//	res, err := geocoder.Lookup(ctx, address)
+//	tracing.End(span, err)
+package tracing
@@ -0,0 +1,287 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenDB opens a database like sql.Open with its queries recorded as spans.
+// system is the db.system attribute, e.g. "postgresql", "mysql" or "sqlite".
+func OpenDB(driverName, dsn, system string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// sql.Open connects lazily, the handle is only needed for its driver
+	d := db.Driver()
+	_ = db.Close()
+
+	var c driver.Connector
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	} else {
+		c = dsnConnector{dsn: dsn, driver: d}
+	}
+	return sql.OpenDB(WrapConnector(c, system)), nil
+}
+
+// WrapConnector returns a connector whose connections record their queries
+// as spans
+func WrapConnector(c driver.Connector, system string) driver.Connector {
+	return &connector{Connector: c, system: system}
+}
+
+// dsnConnector is the connector of drivers not implementing DriverContext
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type connector struct {
+	driver.Connector
+	system string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, system: c.system}, nil
+}
+
+// start starts the span of a statement
+func start(ctx context.Context, system, statement string) (context.Context, trace.Span) {
+	op := Operation(statement)
+	name := op
+	if name == "" {
+		name = system + ".query"
+	}
+	return Start(ctx, name, DBSystem.String(system), DBOperation.String(op), DBStatement.String(statement))
+}
+
+// conn intercepts the statements of a driver connection. Optional
+// interfaces the driver lacks answer driver.ErrSkip or their default, so
+// database/sql falls back as it would without the wrapper.
+type conn struct {
+	driver.Conn
+	system string
+}
+
+var (
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.Validator          = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+)
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := start(ctx, c.system, query)
+	res, err := execer.ExecContext(ctx, query, args)
+	End(span, skipped(err))
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := start(ctx, c.system, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	End(span, skipped(err))
+	return rows, err
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		s   driver.Stmt
+		err error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, system: c.system}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		t   driver.Tx
+		err error
+	)
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		t, err = b.BeginTx(ctx, opts)
+	} else {
+		if opts.Isolation != 0 || opts.ReadOnly {
+			return nil, errors.New("sql: driver does not support non-default isolation level or read-only transactions")
+		}
+		t, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, ctx: ctx, system: c.system}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt records the executions of a prepared statement
+type stmt struct {
+	driver.Stmt
+	query  string
+	system string
+}
+
+var (
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+	_ driver.ColumnConverter   = (*stmt)(nil)
+)
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := start(ctx, s.system, s.query)
+	var (
+		res driver.Result
+		err error
+	)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = s.Stmt.Exec(values)
+		}
+	}
+	End(span, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := start(ctx, s.system, s.query)
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	End(span, err)
+	return rows, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s *stmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+// tx records the end of a transaction
+type tx struct {
+	driver.Tx
+	ctx    context.Context
+	system string
+}
+
+func (t *tx) Commit() error {
+	_, span := Start(t.ctx, "COMMIT", DBSystem.String(t.system), DBOperation.String("COMMIT"))
+	err := t.Tx.Commit()
+	End(span, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, span := Start(t.ctx, "ROLLBACK", DBSystem.String(t.system), DBOperation.String("ROLLBACK"))
+	err := t.Tx.Rollback()
+	End(span, err)
+	return err
+}
+
+// skipped hides driver.ErrSkip, which is a fallback rather than a failure
+func skipped(err error) error {
+	if errors.Is(err, driver.ErrSkip) {
+		return nil
+	}
+	return err
+}
+
+// namedValues converts arguments for drivers without context methods, which
+// take no named parameters
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
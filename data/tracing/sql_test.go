@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeDriver implements only the required driver interfaces, so every
+// statement goes through Prepare
+type fakeDriver struct{ execs []string }
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.d.execs = append(s.c.d.execs, s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(42)
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func TestOpenDB(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	defer otel.SetTracerProvider(prev)
+
+	d := &fakeDriver{}
+	sql.Register("tracing-fake", d)
+	db, err := OpenDB("tracing-fake", "", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "INSERT INTO t (n) VALUES (?)", 42); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, "  select n FROM t WHERE n = ?", 42).Scan(&n); err != nil || n != 42 {
+		t.Fatalf("scan = %d, %v", n, err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.execs) != 1 {
+		t.Fatalf("driver execs = %v", d.execs)
+	}
+	want := []string{"INSERT", "SELECT", "COMMIT"}
+	spans := rec.Ended()
+	if len(spans) != len(want) {
+		t.Fatalf("%d spans, want %d", len(spans), len(want))
+	}
+	for i, s := range spans {
+		if s.Name() != want[i] {
+			t.Errorf("span %d = %s, want %s", i, s.Name(), want[i])
+		}
+		var system string
+		for _, a := range s.Attributes() {
+			if a.Key == DBSystem {
+				system = a.Value.AsString()
+			}
+		}
+		if system != "fake" {
+			t.Errorf("span %s db.system = %q", s.Name(), system)
+		}
+	}
+}
+
+func TestOperation(t *testing.T) {
+	for statement, want := range map[string]string{
+		"SELECT 1":                      "SELECT",
+		"\n  with x AS (SELECT 1) ...":  "WITH",
+		"(SELECT 1) UNION (SELECT 2)":   "SELECT",
+		"":                              "",
+		"-- comment\nDELETE FROM tasks": "",
+	} {
+		if got := Operation(statement); got != want {
+			t.Errorf("Operation(%q) = %q, want %q", statement, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation scope of the data layer spans
+const Name = "github.com/ncobase/ncore/data"
+
+// Attribute keys of the data layer spans
+const (
+	DBSystem    = attribute.Key("db.system")
+	DBName      = attribute.Key("db.name")
+	DBOperation = attribute.Key("db.operation")
+	DBStatement = attribute.Key("db.statement")
+)
+
+// Tracer returns the data layer tracer of the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Start starts a client span named name
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Operation returns the leading keyword of a statement in upper case, e.g.
+// SELECT, or an empty string if there is none
+func Operation(statement string) string {
+	s := strings.TrimLeft(statement, " \t\r\n(")
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToUpper(s[:end])
+}
@@ -5,9 +5,49 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
+// txHooks holds callbacks run after a transaction commits
+type txHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context)
+}
+
+// withTxHooks returns a transaction context carrying tx and its commit hooks
+func withTxHooks(ctx context.Context, tx *sql.Tx) (context.Context, *txHooks) {
+	hooks := &txHooks{}
+	ctx = context.WithValue(ctx, ContextKeyTransaction, tx)
+	return context.WithValue(ctx, contextKeyTxHooks, hooks), hooks
+}
+
+// run calls the hooks in registration order
+func (h *txHooks) run(ctx context.Context) {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// AfterCommit runs fn once the transaction in ctx commits, or right away outside a
+// transaction. Callbacks of a rolled back transaction are dropped.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(contextKeyTxHooks).(*txHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
+
 // GetTx retrieves transaction from context
 func GetTx(ctx context.Context) (*sql.Tx, error) {
 	tx, ok := ctx.Value(ContextKeyTransaction).(*sql.Tx)
@@ -47,7 +87,8 @@ func (d *Data) WithTx(ctx context.Context, fn func(ctx context.Context) error) e
 		return err
 	}
 
-	err = fn(context.WithValue(ctx, ContextKeyTransaction, tx))
+	txCtx, hooks := withTxHooks(ctx, tx)
+	err = fn(txCtx)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -64,6 +105,10 @@ func (d *Data) WithTx(ctx context.Context, fn func(ctx context.Context) error) e
 	commitErr := tx.Commit()
 	collector.DBQuery(duration, commitErr)
 	collector.DBTransaction(commitErr)
+	if commitErr == nil {
+		d.MarkWrite(ctx)
+		hooks.run(ctx)
+	}
 	return commitErr
 }
 
@@ -82,7 +127,7 @@ func (d *Data) WithTxRead(ctx context.Context, fn func(ctx context.Context) erro
 		return err
 	}
 
-	dbRead, err := d.GetSlaveDB()
+	dbRead, err := d.GetSlaveDBContext(ctx)
 	if err != nil {
 		collector.DBTransaction(err)
 		return err
@@ -96,7 +141,8 @@ func (d *Data) WithTxRead(ctx context.Context, fn func(ctx context.Context) erro
 		return err
 	}
 
-	err = fn(context.WithValue(ctx, ContextKeyTransaction, tx))
+	txCtx, hooks := withTxHooks(ctx, tx)
+	err = fn(txCtx)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -113,5 +159,8 @@ func (d *Data) WithTxRead(ctx context.Context, fn func(ctx context.Context) erro
 	commitErr := tx.Commit()
 	collector.DBQuery(duration, commitErr)
 	collector.DBTransaction(commitErr)
+	if commitErr == nil {
+		hooks.run(ctx)
+	}
 	return commitErr
 }
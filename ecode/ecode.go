@@ -1,5 +1,7 @@
 package ecode
 
+import "maps"
+
 // Define all error codes
 var (
 	OK                      = 0    // Success
@@ -31,6 +33,7 @@ var (
 	MethodNotAllowed      = -405 // Method not allowed
 	Conflict              = -409 // Conflict
 	Gone                  = -410 // Gone
+	TooManyRequests       = -429 // Too many requests
 	ServerErr             = -500 // Server error
 	ServiceUnavailable    = -503 // Service unavailable
 	Deadline              = -504 // Service call timeout
@@ -86,6 +89,7 @@ var ecodeText = map[int]string{
 	NothingFound:          "Nothing found",
 	MethodNotAllowed:      "Method not allowed",
 	Conflict:              "Conflict",
+	TooManyRequests:       "Too many requests",
 	ServerErr:             "Server error",
 	ServiceUnavailable:    "Service unavailable",
 	Deadline:              "Service call timeout",
@@ -120,3 +124,8 @@ func Text(code int) string {
 	}
 	return "Unknown error"
 }
+
+// Texts returns a copy of the defined error codes and their texts
+func Texts() map[int]string {
+	return maps.Clone(ecodeText)
+}
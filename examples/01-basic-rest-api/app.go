@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ncobase/ncore/config"
 	"github.com/ncobase/ncore/examples/01-basic-rest-api/handler"
+	"github.com/ncobase/ncore/logging/accesslog"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
 )
@@ -55,7 +56,7 @@ func (a *App) Run() error {
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Recovery())
-	router.Use(a.loggerMiddleware())
+	router.Use(accesslog.Middleware(&accesslog.Options{Logger: a.logger}))
 
 	// Register routes
 	a.handler.RegisterRoutes(router)
@@ -102,25 +103,3 @@ func (a *App) Run() error {
 	a.logger.Info(context.Background(), "Server exited")
 	return nil
 }
-
-// loggerMiddleware creates a Gin middleware for request logging.
-func (a *App) loggerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		c.Next()
-
-		duration := time.Since(start)
-		status := c.Writer.Status()
-
-		a.logger.Info(context.Background(), "HTTP request",
-			"method", method,
-			"path", path,
-			"status", status,
-			"duration", duration.String(),
-			"ip", c.ClientIP(),
-		)
-	}
-}
@@ -16,6 +16,7 @@ import (
 	"github.com/ncobase/ncore/examples/02-mongodb-api/data"
 	"github.com/ncobase/ncore/examples/02-mongodb-api/handler"
 	"github.com/ncobase/ncore/examples/02-mongodb-api/service"
+	"github.com/ncobase/ncore/logging/accesslog"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
 
@@ -96,7 +97,7 @@ func (a *App) Run() error {
 	// Setup router
 	router := gin.New()
 	router.Use(gin.Recovery())
-	router.Use(a.loggerMiddleware())
+	router.Use(accesslog.Middleware(&accesslog.Options{Logger: a.logger}))
 
 	// Register routes
 	a.handler.RegisterRoutes(router)
@@ -144,28 +145,6 @@ func (a *App) Run() error {
 	return nil
 }
 
-// loggerMiddleware creates a Gin middleware for request logging.
-func (a *App) loggerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		c.Next()
-
-		duration := time.Since(start)
-		status := c.Writer.Status()
-
-		a.logger.Info(c.Request.Context(), "HTTP request",
-			"method", method,
-			"path", path,
-			"status", status,
-			"duration", duration.String(),
-			"ip", c.ClientIP(),
-		)
-	}
-}
-
 func main() {
 	// Initialize application with manual DI
 	app, cleanup, err := NewApp()
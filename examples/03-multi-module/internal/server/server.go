@@ -12,6 +12,7 @@ import (
 	_ "github.com/ncobase/ncore/examples/03-multi-module/core/post"
 	_ "github.com/ncobase/ncore/examples/03-multi-module/core/user"
 	"github.com/ncobase/ncore/extension/manager"
+	"github.com/ncobase/ncore/logging/accesslog"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -78,7 +79,7 @@ func (s *Server) SetupRouter() *gin.Engine {
 
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(s.loggerMiddleware())
+	r.Use(accesslog.Middleware(&accesslog.Options{Logger: s.logger}))
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -101,27 +102,6 @@ func (s *Server) SetupRouter() *gin.Engine {
 	return r
 }
 
-// loggerMiddleware creates request logging middleware.
-func (s *Server) loggerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		c.Next()
-
-		duration := time.Since(start)
-		status := c.Writer.Status()
-
-		s.logger.Info(c.Request.Context(), "HTTP request",
-			"method", method,
-			"path", path,
-			"status", status,
-			"duration", duration.String(),
-		)
-	}
-}
-
 // Cleanup performs cleanup.
 func (s *Server) Cleanup() {
 	s.manager.Cleanup()
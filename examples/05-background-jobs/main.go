@@ -13,14 +13,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ncobase/ncore/concurrency/worker"
 	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/data/sqlite"
 	"github.com/ncobase/ncore/examples/05-background-jobs/job"
 	"github.com/ncobase/ncore/examples/05-background-jobs/job/data"
 	jobRepo "github.com/ncobase/ncore/examples/05-background-jobs/job/data/repository"
 	"github.com/ncobase/ncore/examples/05-background-jobs/job/handler"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
-
-	_ "github.com/ncobase/ncore/data/sqlite"
 )
 
 func main() {
@@ -40,7 +39,8 @@ func main() {
 	defer logCleanup()
 	log := logger.StdLogger()
 
-	dataLayer, err := data.New(cfg.Data.Database.Master.Driver, cfg.Data.Database.Master.Source, log)
+	master := cfg.Data.Database.Master
+	dataLayer, err := data.New(master.Driver, sqlite.DSN(master.Source, master.SQLite), log)
 	if err != nil {
 		log.Error(context.Background(), "Failed to connect database", "error", err)
 		os.Exit(1)
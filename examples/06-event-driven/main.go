@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/data/sqlite"
 	"github.com/ncobase/ncore/examples/06-event-driven/data"
 	datarepo "github.com/ncobase/ncore/examples/06-event-driven/data/repository"
 	"github.com/ncobase/ncore/examples/06-event-driven/event"
@@ -19,8 +20,6 @@ import (
 	"github.com/ncobase/ncore/examples/06-event-driven/service"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
-
-	_ "github.com/ncobase/ncore/data/sqlite"
 )
 
 func main() {
@@ -40,7 +39,8 @@ func main() {
 	defer cleanup()
 	log := logger.StdLogger()
 
-	dataLayer, err := data.New(cfg.Data.Database.Master.Driver, cfg.Data.Database.Master.Source, log)
+	master := cfg.Data.Database.Master
+	dataLayer, err := data.New(master.Driver, sqlite.DSN(master.Source, master.SQLite), log)
 	if err != nil {
 		log.Error(context.Background(), "Failed to connect database", "error", err)
 		os.Exit(1)
@@ -90,7 +90,7 @@ func (m *Module) Init(conf *config.Config, em types.ManagerInterface) error {
 		return fmt.Errorf("app data type mismatch")
 	}
 
-	db := dataLayer.GetMasterDB()
+	db := dataLayer.SQL()
 	if db == nil {
 		return fmt.Errorf("master database not configured")
 	}
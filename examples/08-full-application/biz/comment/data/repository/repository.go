@@ -3,11 +3,11 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"sync"
 
+	"github.com/ncobase/ncore/data/sqlq"
 	"github.com/ncobase/ncore/examples/08-full-application/biz/comment/structs"
 )
 
@@ -19,65 +19,33 @@ type CommentRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// commentRepository writes its queries once with ? placeholders and :name
+// parameters, so it runs on any configured SQL driver
 type commentRepository struct {
-	db *sql.DB
+	db *sqlq.DB
 }
 
-func NewCommentRepository(db *sql.DB) (CommentRepository, error) {
+func NewCommentRepository(db *sqlq.DB) (CommentRepository, error) {
 	if db == nil {
 		return nil, errors.New("database is nil")
 	}
 
-	repo := &commentRepository{db: db}
-	if err := repo.initSchema(context.Background()); err != nil {
-		return nil, err
-	}
-
-	return repo, nil
-}
-
-func (r *commentRepository) initSchema(ctx context.Context) error {
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS comments (
-			id TEXT PRIMARY KEY,
-			workspace_id TEXT NOT NULL,
-			task_id TEXT NOT NULL,
-			content TEXT NOT NULL,
-			created_by TEXT NOT NULL,
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_comments_task_id ON comments(task_id);
-	`); err != nil {
-		return err
-	}
-
-	return nil
+	return &commentRepository{db: db}, nil
 }
 
 func (r *commentRepository) Create(ctx context.Context, comment *structs.Comment) error {
-	_, err := r.db.ExecContext(ctx, `
+	_, err := r.db.NamedExec(ctx, `
 		INSERT INTO comments (id, workspace_id, task_id, content, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, comment.ID, comment.WorkspaceID, comment.TaskID, comment.Content, comment.CreatedBy, comment.CreatedAt, comment.UpdatedAt)
+		VALUES (:id, :workspace_id, :task_id, :content, :created_by, :created_at, :updated_at)
+	`, comment)
 	return err
 }
 
 func (r *commentRepository) FindByID(ctx context.Context, id string) (*structs.Comment, error) {
-	row := r.db.QueryRowContext(ctx, `
+	return sqlq.Get[*structs.Comment](ctx, r.db, `
 		SELECT id, workspace_id, task_id, content, created_by, created_at, updated_at
-		FROM comments WHERE id = $1
+		FROM comments WHERE id = ?
 	`, id)
-	comment := &structs.Comment{}
-	if err := row.Scan(&comment.ID, &comment.WorkspaceID, &comment.TaskID, &comment.Content, &comment.CreatedBy, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
-		return nil, err
-	}
-	return comment, nil
 }
 
 func (r *commentRepository) FindByTask(ctx context.Context, taskID string, limit, offset int) ([]*structs.Comment, error) {
@@ -85,37 +53,19 @@ func (r *commentRepository) FindByTask(ctx context.Context, taskID string, limit
 		limit = 20
 	}
 
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, workspace_id, task_id, content, created_by, created_at, updated_at
-		FROM comments
-		WHERE task_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`, taskID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var comments []*structs.Comment
-	for rows.Next() {
-		comment := &structs.Comment{}
-		if err := rows.Scan(&comment.ID, &comment.WorkspaceID, &comment.TaskID, &comment.Content, &comment.CreatedBy, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
-			return nil, err
-		}
-		comments = append(comments, comment)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return comments, nil
+	return sqlq.QueryStmt[*structs.Comment](ctx, r.db, sqlq.
+		Select("id", "workspace_id", "task_id", "content", "created_by", "created_at", "updated_at").
+		From("comments").
+		Where("task_id = ?", taskID).
+		OrderBy("created_at DESC").
+		Limit(limit).
+		Offset(offset))
 }
 
 func (r *commentRepository) Update(ctx context.Context, comment *structs.Comment) error {
-	result, err := r.db.ExecContext(ctx, `
-		UPDATE comments SET content = $1, updated_at = $2 WHERE id = $3
-	`, comment.Content, comment.UpdatedAt, comment.ID)
+	result, err := r.db.NamedExec(ctx, `
+		UPDATE comments SET content = :content, updated_at = :updated_at WHERE id = :id
+	`, comment)
 	if err != nil {
 		return err
 	}
@@ -132,8 +82,8 @@ func (r *commentRepository) Update(ctx context.Context, comment *structs.Comment
 }
 
 func (r *commentRepository) Delete(ctx context.Context, id string) error {
-	result, err := r.db.ExecContext(ctx, `
-		DELETE FROM comments WHERE id = $1
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM comments WHERE id = ?
 	`, id)
 	if err != nil {
 		return err
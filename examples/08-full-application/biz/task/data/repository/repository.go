@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ncobase/ncore/data/sqlscan"
 	"github.com/ncobase/ncore/examples/08-full-application/biz/task/structs"
 )
 
@@ -32,46 +33,7 @@ func NewTaskRepository(db *sql.DB) (TaskRepository, error) {
 		return nil, errors.New("database is nil")
 	}
 
-	repo := &taskRepository{db: db}
-	if err := repo.initSchema(context.Background()); err != nil {
-		return nil, err
-	}
-
-	return repo, nil
-}
-
-func (r *taskRepository) initSchema(ctx context.Context) error {
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS tasks (
-			id TEXT PRIMARY KEY,
-			workspace_id TEXT NOT NULL,
-			title TEXT NOT NULL,
-			description TEXT NOT NULL,
-			status TEXT NOT NULL,
-			priority TEXT NOT NULL,
-			assigned_to TEXT NOT NULL,
-			created_by TEXT NOT NULL,
-			due_date TIMESTAMPTZ NULL,
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_tasks_workspace_id ON tasks(workspace_id);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_tasks_assigned_to ON tasks(assigned_to);
-	`); err != nil {
-		return err
-	}
-
-	return nil
+	return &taskRepository{db: db}, nil
 }
 
 func (r *taskRepository) Create(ctx context.Context, task *structs.Task) error {
@@ -83,11 +45,10 @@ func (r *taskRepository) Create(ctx context.Context, task *structs.Task) error {
 }
 
 func (r *taskRepository) FindByID(ctx context.Context, id string) (*structs.Task, error) {
-	row := r.db.QueryRowContext(ctx, `
+	return sqlscan.Get[*structs.Task](ctx, r.db, `
 		SELECT id, workspace_id, title, description, status, priority, assigned_to, created_by, due_date, created_at, updated_at
 		FROM tasks WHERE id = $1
 	`, id)
-	return scanTask(row)
 }
 
 func (r *taskRepository) FindByWorkspace(ctx context.Context, workspaceID string, limit, offset int) ([]*structs.Task, error) {
@@ -95,18 +56,12 @@ func (r *taskRepository) FindByWorkspace(ctx context.Context, workspaceID string
 		limit = 20
 	}
 
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Task](ctx, r.db, `
 		SELECT id, workspace_id, title, description, status, priority, assigned_to, created_by, due_date, created_at, updated_at
 		FROM tasks WHERE workspace_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`, workspaceID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return scanTasks(rows)
 }
 
 func (r *taskRepository) FindByAssignee(ctx context.Context, assigneeID string, limit, offset int) ([]*structs.Task, error) {
@@ -114,18 +69,12 @@ func (r *taskRepository) FindByAssignee(ctx context.Context, assigneeID string,
 		limit = 20
 	}
 
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Task](ctx, r.db, `
 		SELECT id, workspace_id, title, description, status, priority, assigned_to, created_by, due_date, created_at, updated_at
 		FROM tasks WHERE assigned_to = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`, assigneeID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return scanTasks(rows)
 }
 
 func (r *taskRepository) Update(ctx context.Context, task *structs.Task) error {
@@ -220,56 +169,7 @@ func (r *taskRepository) List(ctx context.Context, workspaceID string, filter ma
 	query = strings.TrimSpace(query) + fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
 	args = append(args, limit, offset)
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return scanTasks(rows)
-}
-
-func scanTask(scanner interface{ Scan(dest ...any) error }) (*structs.Task, error) {
-	var dueDate sql.NullTime
-	item := &structs.Task{}
-	if err := scanner.Scan(
-		&item.ID,
-		&item.WorkspaceID,
-		&item.Title,
-		&item.Description,
-		&item.Status,
-		&item.Priority,
-		&item.AssignedTo,
-		&item.CreatedBy,
-		&dueDate,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	); err != nil {
-		return nil, err
-	}
-
-	if dueDate.Valid {
-		item.DueDate = &dueDate.Time
-	}
-
-	return item, nil
-}
-
-func scanTasks(rows *sql.Rows) ([]*structs.Task, error) {
-	var tasks []*structs.Task
-	for rows.Next() {
-		task, err := scanTask(rows)
-		if err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return tasks, nil
+	return sqlscan.Query[*structs.Task](ctx, r.db, query, args...)
 }
 
 type MemoryTaskRepository struct {
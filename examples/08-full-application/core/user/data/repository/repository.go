@@ -77,24 +77,30 @@ func (r *userRepository) Create(ctx context.Context, user *structs.User) error {
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id string) (*structs.User, error) {
-	if r.cache != nil {
-		cached, err := r.cache.Get(ctx, id)
-		if err == nil && cached != nil {
-			return cached, nil
-		}
+	if r.cache == nil {
+		return r.findByID(ctx, id)
 	}
 
-	entUser, err := r.client.User.Get(ctx, id)
+	user, err := cache.GetOrLoad(ctx, r.cache, id, 10*time.Minute, func(ctx context.Context) (structs.User, error) {
+		user, err := r.findByID(ctx, id)
+		if err != nil {
+			return structs.User{}, err
+		}
+		return *user, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	user := toStruct(entUser)
-	if r.cache != nil {
-		_ = r.cache.Set(ctx, user.ID, user, 10*time.Minute)
-	}
+	return &user, nil
+}
 
-	return user, nil
+func (r *userRepository) findByID(ctx context.Context, id string) (*structs.User, error) {
+	entUser, err := r.client.User.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(entUser), nil
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*structs.User, error) {
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ncobase/ncore/data/cache"
+	"github.com/ncobase/ncore/data/sqlscan"
 	"github.com/ncobase/ncore/examples/08-full-application/core/workspace/structs"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/redis/go-redis/v9"
@@ -50,36 +51,9 @@ func NewWorkspaceRepository(db *sql.DB, logger *logger.Logger, rc *redis.Client)
 		repo.cache = cache.NewCache[structs.Workspace](rc, "workspaces")
 	}
 
-	if err := repo.initSchema(context.Background()); err != nil {
-		return nil, err
-	}
-
 	return repo, nil
 }
 
-func (r *workspaceRepository) initSchema(ctx context.Context) error {
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS workspaces (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			description TEXT NOT NULL,
-			owner_id TEXT NOT NULL,
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ NOT NULL
-		);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_workspaces_owner_id ON workspaces(owner_id);
-	`); err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (r *workspaceRepository) Create(ctx context.Context, workspace *structs.Workspace) error {
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO workspaces (id, name, description, owner_id, created_at, updated_at)
@@ -98,54 +72,38 @@ func (r *workspaceRepository) Create(ctx context.Context, workspace *structs.Wor
 }
 
 func (r *workspaceRepository) FindByID(ctx context.Context, id string) (*structs.Workspace, error) {
-	if r.cache != nil {
-		cached, err := r.cache.Get(ctx, id)
-		if err == nil && cached != nil {
-			return cached, nil
-		}
+	if r.cache == nil {
+		return r.findByID(ctx, id)
 	}
 
-	workspace := &structs.Workspace{}
-	row := r.db.QueryRowContext(ctx, `
-		SELECT id, name, description, owner_id, created_at, updated_at
-		FROM workspaces WHERE id = $1
-	`, id)
-	if err := row.Scan(&workspace.ID, &workspace.Name, &workspace.Description, &workspace.OwnerID, &workspace.CreatedAt, &workspace.UpdatedAt); err != nil {
+	workspace, err := cache.GetOrLoad(ctx, r.cache, id, 10*time.Minute, func(ctx context.Context) (structs.Workspace, error) {
+		workspace, err := r.findByID(ctx, id)
+		if err != nil {
+			return structs.Workspace{}, err
+		}
+		return *workspace, nil
+	}, cache.WithStaleWhileRevalidate(time.Minute))
+	if err != nil {
 		return nil, err
 	}
 
-	if r.cache != nil {
-		_ = r.cache.Set(ctx, workspace.ID, workspace, 10*time.Minute)
-	}
+	return &workspace, nil
+}
 
-	return workspace, nil
+func (r *workspaceRepository) findByID(ctx context.Context, id string) (*structs.Workspace, error) {
+	return sqlscan.Get[*structs.Workspace](ctx, r.db, `
+		SELECT id, name, description, owner_id, created_at, updated_at
+		FROM workspaces WHERE id = $1
+	`, id)
 }
 
 func (r *workspaceRepository) FindByOwner(ctx context.Context, ownerID string) ([]*structs.Workspace, error) {
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Workspace](ctx, r.db, `
 		SELECT id, name, description, owner_id, created_at, updated_at
 		FROM workspaces
 		WHERE owner_id = $1
 		ORDER BY created_at DESC
 	`, ownerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var workspaces []*structs.Workspace
-	for rows.Next() {
-		workspace := &structs.Workspace{}
-		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Description, &workspace.OwnerID, &workspace.CreatedAt, &workspace.UpdatedAt); err != nil {
-			return nil, err
-		}
-		workspaces = append(workspaces, workspace)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return workspaces, nil
 }
 
 func (r *workspaceRepository) Update(ctx context.Context, workspace *structs.Workspace) error {
@@ -203,7 +161,7 @@ func (r *workspaceRepository) List(ctx context.Context, userID string, limit, of
 		limit = 20
 	}
 
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Workspace](ctx, r.db, `
 		SELECT DISTINCT w.id, w.name, w.description, w.owner_id, w.created_at, w.updated_at
 		FROM workspaces w
 		LEFT JOIN workspace_members m ON w.id = m.workspace_id
@@ -211,24 +169,6 @@ func (r *workspaceRepository) List(ctx context.Context, userID string, limit, of
 		ORDER BY w.created_at DESC
 		LIMIT $2 OFFSET $3
 	`, userID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var workspaces []*structs.Workspace
-	for rows.Next() {
-		workspace := &structs.Workspace{}
-		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.Description, &workspace.OwnerID, &workspace.CreatedAt, &workspace.UpdatedAt); err != nil {
-			return nil, err
-		}
-		workspaces = append(workspaces, workspace)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return workspaces, nil
 }
 
 type PostgresMemberRepository struct {
@@ -241,40 +181,7 @@ func NewPostgresMemberRepository(db *sql.DB, logger *logger.Logger) (MemberRepos
 		return nil, errors.New("database is nil")
 	}
 
-	repo := &PostgresMemberRepository{db: db, logger: logger}
-	if err := repo.initSchema(context.Background()); err != nil {
-		return nil, err
-	}
-
-	return repo, nil
-}
-
-func (r *PostgresMemberRepository) initSchema(ctx context.Context) error {
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE TABLE IF NOT EXISTS workspace_members (
-			id TEXT PRIMARY KEY,
-			workspace_id TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			role TEXT NOT NULL,
-			created_at TIMESTAMPTZ NOT NULL
-		);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_members_unique ON workspace_members(workspace_id, user_id);
-	`); err != nil {
-		return err
-	}
-
-	if _, err := r.db.ExecContext(ctx, `
-		CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
-	`); err != nil {
-		return err
-	}
-
-	return nil
+	return &PostgresMemberRepository{db: db, logger: logger}, nil
 }
 
 func (r *PostgresMemberRepository) Add(ctx context.Context, member *structs.Member) error {
@@ -311,57 +218,21 @@ func (r *PostgresMemberRepository) Remove(ctx context.Context, workspaceID, user
 }
 
 func (r *PostgresMemberRepository) FindByWorkspace(ctx context.Context, workspaceID string) ([]*structs.Member, error) {
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Member](ctx, r.db, `
 		SELECT id, workspace_id, user_id, role, created_at
 		FROM workspace_members
 		WHERE workspace_id = $1
 		ORDER BY created_at DESC
 	`, workspaceID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var members []*structs.Member
-	for rows.Next() {
-		member := &structs.Member{}
-		if err := rows.Scan(&member.ID, &member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
-			return nil, err
-		}
-		members = append(members, member)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return members, nil
 }
 
 func (r *PostgresMemberRepository) FindByUser(ctx context.Context, userID string) ([]*structs.Member, error) {
-	rows, err := r.db.QueryContext(ctx, `
+	return sqlscan.Query[*structs.Member](ctx, r.db, `
 		SELECT id, workspace_id, user_id, role, created_at
 		FROM workspace_members
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var members []*structs.Member
-	for rows.Next() {
-		member := &structs.Member{}
-		if err := rows.Scan(&member.ID, &member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt); err != nil {
-			return nil, err
-		}
-		members = append(members, member)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return members, nil
 }
 
 func (r *PostgresMemberRepository) UpdateRole(ctx context.Context, workspaceID, userID, role string) error {
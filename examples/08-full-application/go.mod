@@ -11,6 +11,7 @@ require (
 	github.com/ncobase/ncore/concurrency v0.2.2
 	github.com/ncobase/ncore/config v0.2.2
 	github.com/ncobase/ncore/data v0.2.2
+	github.com/ncobase/ncore/data/cache v0.2.2
 	github.com/ncobase/ncore/data/meilisearch v0.2.2
 	github.com/ncobase/ncore/data/mongodb v0.2.2
 	github.com/ncobase/ncore/data/postgres v0.2.2
@@ -213,6 +214,7 @@ replace (
 	github.com/ncobase/ncore/consts => ../../consts
 	github.com/ncobase/ncore/ctxutil => ../../ctxutil
 	github.com/ncobase/ncore/data => ../../data
+	github.com/ncobase/ncore/data/cache => ../../data/cache
 	github.com/ncobase/ncore/data/meilisearch => ../../data/meilisearch
 	github.com/ncobase/ncore/data/mongodb => ../../data/mongodb
 	github.com/ncobase/ncore/data/mysql => ../../data/mysql
@@ -4,12 +4,12 @@ package server
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ncobase/ncore/config"
 	"github.com/ncobase/ncore/data"
 	"github.com/ncobase/ncore/extension/manager"
+	"github.com/ncobase/ncore/logging/accesslog"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
 	"github.com/ncobase/ncore/oss"
@@ -22,6 +22,7 @@ import (
 	_ "github.com/ncobase/ncore/examples/08-full-application/core/user"
 	_ "github.com/ncobase/ncore/examples/08-full-application/core/workspace"
 	"github.com/ncobase/ncore/examples/08-full-application/internal/event"
+	"github.com/ncobase/ncore/examples/08-full-application/migrations"
 	_ "github.com/ncobase/ncore/examples/08-full-application/plugin/export"
 	_ "github.com/ncobase/ncore/examples/08-full-application/plugin/notification"
 )
@@ -52,6 +53,14 @@ func NewServer(cfg *config.Config, log *logger.Logger) (*Server, error) {
 		return nil, fmt.Errorf("data layer not initialized")
 	}
 
+	applied, err := dataLayer.Migrate(context.Background(), migrations.FS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	for _, m := range applied {
+		log.Info(context.Background(), "Applied migration", "version", m.Version, "name", m.Name)
+	}
+
 	dbName := cfg.AppName
 	if dbName == "" {
 		dbName = "fullappdb"
@@ -113,7 +122,7 @@ func (s *Server) SetupRouter() *gin.Engine {
 
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(s.loggerMiddleware())
+	r.Use(accesslog.Middleware(&accesslog.Options{Logger: s.logger}))
 
 	r.GET("/health", func(c *gin.Context) {
 		resp.Success(c.Writer, map[string]string{"status": "healthy"})
@@ -142,26 +151,6 @@ func (s *Server) Cleanup(ctx context.Context) {
 	}
 }
 
-func (s *Server) loggerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-
-		c.Next()
-
-		duration := time.Since(start)
-		status := c.Writer.Status()
-
-		s.logger.Info(c.Request.Context(), "HTTP request",
-			"method", method,
-			"path", path,
-			"status", status,
-			"duration", duration.String(),
-		)
-	}
-}
-
 func (s *Server) handleEventStats(c *gin.Context) {
 	stats := s.eventBus.GetStats()
 	resp.Success(c.Writer, stats)
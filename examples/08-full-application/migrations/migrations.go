@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema of the full application example.
+package migrations
+
+import "embed"
+
+// FS holds the migration files, applied on startup with data.Migrate
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/data/anonymize"
+)
+
+// anonymizeData rewrites the personal data of data.database.master with a plan
+func anonymizeData(args []string) error {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	conf := fs.String("conf", "config.yaml", "configuration file with data.database.master")
+	planFile := fs.String("plan", "anonymize.yaml", "anonymization plan, YAML or JSON")
+	batch := fs.Int("batch", 0, "rows per batch (default: the plan batch_size)")
+	dryRun := fs.Bool("dry-run", false, "read and transform rows without writing them")
+	force := fs.Bool("force", false, "run even if the environment is production")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(*conf)
+	if err != nil {
+		return err
+	}
+	// An empty environment counts as production
+	if cfg.IsProd() && !*force && !*dryRun {
+		return fmt.Errorf("refusing to anonymize the %q environment of %s, it is production; pass -force to run anyway", cfg.Environment, *conf)
+	}
+
+	plan, err := anonymize.LoadPlan(*planFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	db, closeConn, err := masterDB(ctx, cfg, *conf)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	a, err := anonymize.New(anonymize.NewSQLStore(db, cfg.Data.Database.Master.Driver), plan, anonymize.Options{
+		BatchSize: *batch,
+		DryRun:    *dryRun,
+		Progress: func(table string, rows int64) {
+			fmt.Fprintf(os.Stderr, "\r  %s: %d rows", table, rows)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	results, err := a.Run(ctx)
+	fmt.Fprintln(os.Stderr)
+	verb := "anonymized"
+	if *dryRun {
+		verb = "would anonymize"
+	}
+	for _, r := range results {
+		fmt.Printf("  %s %s: %d rows in %d batches\n", verb, r.Table, r.Rows, r.Batches)
+	}
+	return err
+}
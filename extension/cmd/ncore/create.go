@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ncobase/ncore/extension/scaffold"
+)
+
+// createExtension scaffolds an extension and its manifest, with the options of
+// the flags or, with -interactive, of the answers to prompts defaulting to them
+func createExtension(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	interactive := fs.Bool("interactive", false, "prompt for the options, defaulting to the flags")
+	name := fs.String("name", "", "extension name in lower snake case")
+	module := fs.String("module", "", "import path of the extension (default: from the enclosing go.mod)")
+	typ := fs.String("type", "module", "extension type: "+strings.Join(scaffold.ExtensionTypes, ", "))
+	group := fs.String("group", "", "registry group")
+	orm := fs.String("orm", "sqlrepo", "data access: "+strings.Join(scaffold.ORMs, ", "))
+	driver := fs.String("driver", "postgres", "database driver of sqlrepo: "+strings.Join(scaffold.Drivers, ", "))
+	features := fs.String("features", "", "comma separated features of sqlrepo: "+strings.Join(scaffold.Features, ", "))
+	entity := fs.String("entity", "", "first entity of sqlrepo (default: the extension name)")
+	dir := fs.String("dir", "", "extension directory (default: the extension name)")
+	force := fs.Bool("force", false, "overwrite an existing extension")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m := scaffold.Manifest{Name: *name, Module: *module, Type: *typ, Group: *group, ORM: *orm}
+	if *features != "" {
+		m.Features = strings.Split(*features, ",")
+	}
+	if *interactive {
+		p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		if err := p.manifest(&m, driver, entity, dir); err != nil {
+			return err
+		}
+	}
+	if m.Name == "" {
+		return fmt.Errorf("usage: ncore create -name name [flags], or ncore create -interactive")
+	}
+	if *dir == "" {
+		*dir = m.Name
+	}
+	if m.Module == "" {
+		module, err := defaultModule(*dir)
+		if err != nil {
+			return err
+		}
+		m.Module = module
+	}
+	if m.ORM == "sqlrepo" {
+		m.Driver = *driver
+	}
+
+	files, err := scaffold.CreateExtension(scaffold.CreateOptions{Manifest: m, Dir: *dir, Entity: *entity, Force: *force})
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("  %s\n", f.Path)
+	}
+	fmt.Printf("created extension %s in %s, import %s to register it\n", m.Name, *dir, m.Module)
+	return nil
+}
+
+// addHandler adds a handler to an extension created by ncore create
+func addHandler(args []string) error {
+	fs := flag.NewFlagSet("add handler", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "extension directory, holding "+scaffold.ManifestFile)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: ncore add handler [-dir dir] <name>")
+	}
+
+	files, err := scaffold.AddHandler(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	printFiles(files)
+	return nil
+}
+
+// upgradeTemplates scaffolds an extension created by ncore create again with
+// the current templates
+func upgradeTemplates(args []string) error {
+	fs := flag.NewFlagSet("upgrade templates", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "extension directory, holding "+scaffold.ManifestFile)
+	force := fs.Bool("force", false, "also overwrite files that are not shared by the entities or handlers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	files, from, err := scaffold.UpgradeTemplates(*dir, *force)
+	if err != nil {
+		return err
+	}
+	printFiles(files)
+	fmt.Printf("upgraded templates %d to %d\n", from, scaffold.TemplateVersion)
+	return nil
+}
+
+// printFiles lists scaffolded files, shared files are always written
+func printFiles(files []*scaffold.File) {
+	for _, f := range files {
+		switch {
+		case f.Skipped:
+			fmt.Printf("  %s (exists, skipped)\n", f.Path)
+		case f.Shared:
+			fmt.Printf("  %s (updated)\n", f.Path)
+		default:
+			fmt.Printf("  %s\n", f.Path)
+		}
+	}
+}
+
+// defaultModule derives the import path of dir from the enclosing go.mod
+func defaultModule(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := filepath.Dir(abs); ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return "", err
+					}
+					return path.Join(strings.Trim(fields[1], `"`), filepath.ToSlash(rel)), nil
+				}
+			}
+			return "", fmt.Errorf("%s has no module directive", filepath.Join(root, "go.mod"))
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("no go.mod encloses %s, set -module", dir)
+		}
+	}
+}
+
+// prompter asks for the options of an extension
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// manifest prompts for the options of m and the directory, driver and first
+// entity, defaulting to their current values
+func (p *prompter) manifest(m *scaffold.Manifest, driver, entity, dir *string) error {
+	var err error
+	ask := func(v *string, question, def string, choices []string) {
+		if err == nil {
+			*v, err = p.ask(question, def, choices)
+		}
+	}
+
+	ask(&m.Name, "Extension name", m.Name, nil)
+	if err == nil && *dir == "" {
+		*dir = m.Name
+	}
+	ask(dir, "Directory", *dir, nil)
+	if err == nil && m.Module == "" {
+		m.Module, _ = defaultModule(*dir)
+	}
+	ask(&m.Module, "Module path", m.Module, nil)
+	ask(&m.Type, "Type", m.Type, scaffold.ExtensionTypes)
+	ask(&m.Group, "Registry group, empty for none", m.Group, nil)
+	ask(&m.ORM, "Data access", m.ORM, scaffold.ORMs)
+	if err != nil || m.ORM != "sqlrepo" {
+		m.Features = nil
+		return err
+	}
+	ask(driver, "Database driver", *driver, scaffold.Drivers)
+	if err == nil && *entity == "" {
+		*entity = m.Name
+	}
+	ask(entity, "First entity", *entity, nil)
+	if err != nil {
+		return err
+	}
+	m.Features, err = p.askList("Features, comma separated", m.Features, scaffold.Features)
+	return err
+}
+
+// ask prints a question and reads answers until one is empty, for the
+// default, or among the choices
+func (p *prompter) ask(question, def string, choices []string) (string, error) {
+	if len(choices) > 0 {
+		question += " (" + strings.Join(choices, ", ") + ")"
+	}
+	for {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		line, err := p.in.ReadString('\n')
+		if errors.Is(err, io.EOF) && line == "" {
+			return "", fmt.Errorf("no answer to %q", question)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if len(choices) == 0 || slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "  %q is not one of %s\n", answer, strings.Join(choices, ", "))
+	}
+}
+
+// askList asks for a comma separated subset of choices, "none" for none
+func (p *prompter) askList(question string, def, choices []string) ([]string, error) {
+	options := append(slices.Clone(choices), "none")
+	for {
+		answer, err := p.ask(question+" ("+strings.Join(options, ", ")+")", strings.Join(def, ","), nil)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "" || answer == "none" {
+			return nil, nil
+		}
+		var list []string
+		for _, v := range strings.Split(answer, ",") {
+			list = append(list, strings.TrimSpace(v))
+		}
+		if i := slices.IndexFunc(list, func(v string) bool { return !slices.Contains(choices, v) }); i >= 0 {
+			fmt.Fprintf(p.out, "  %q is not one of %s\n", list[i], strings.Join(choices, ", "))
+			continue
+		}
+		return list, nil
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/extension/security"
+	"github.com/ncobase/ncore/utils"
+)
+
+// checkStatus is the outcome of a doctor check
+type checkStatus string
+
+const (
+	statusOK   checkStatus = "ok"
+	statusWarn checkStatus = "warn"
+	statusFail checkStatus = "fail"
+	statusSkip checkStatus = "skip"
+)
+
+// ANSI colors of the statuses
+var statusColors = map[checkStatus]string{
+	statusOK:   "\033[32m",
+	statusWarn: "\033[33m",
+	statusFail: "\033[31m",
+	statusSkip: "\033[90m",
+}
+
+// checkResult is a line of the doctor report
+type checkResult struct {
+	Group    string        `json:"group"`
+	Name     string        `json:"name"`
+	Status   checkStatus   `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Hint     string        `json:"hint,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// doctor runs the checks of an environment
+type doctor struct {
+	cfg     *config.Config
+	timeout time.Duration
+	host    *debug.BuildInfo
+	hostSrc string
+	results []checkResult
+}
+
+// add records a check result
+func (d *doctor) add(group, name string, status checkStatus, detail, hint string) {
+	d.results = append(d.results, checkResult{Group: group, Name: name, Status: status, Detail: detail, Hint: hint})
+}
+
+// probe records the result of fn, failing it when it returns an error
+func (d *doctor) probe(ctx context.Context, group, name, hint string, fn func(ctx context.Context) (string, error)) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := fn(ctx)
+	r := checkResult{Group: group, Name: name, Status: statusOK, Detail: detail, Duration: time.Since(start).Round(time.Millisecond)}
+	if err != nil {
+		r.Status, r.Detail, r.Hint = statusFail, err.Error(), hint
+	}
+	d.results = append(d.results, r)
+}
+
+// doctorCheck diagnoses the configuration and the services it points to
+func doctorCheck(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	conf := fs.String("conf", "config.yaml", "base configuration file")
+	profile := fs.String("profile", config.Profile(), "profile overlay (default: $"+config.ProfileEnv+")")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout of each connectivity check")
+	binary := fs.String("binary", "", "application binary to check plugin ABI against (default: this ncore build)")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	noColor := fs.Bool("no-color", false, "disable colors (also with $NO_COLOR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Overlays are picked by the profile variable while loading
+	if err := os.Setenv(config.ProfileEnv, *profile); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	d := &doctor{timeout: *timeout}
+	if d.checkConfig(*conf) {
+		d.checkDatabase(ctx, *conf)
+		d.checkRedis(ctx)
+		d.checkSearch(ctx)
+		d.checkMessaging(ctx)
+		d.checkConsul(ctx)
+		d.checkPlugins(*binary)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d.results); err != nil {
+			return err
+		}
+	} else {
+		d.print(os.Stdout, useColor(*noColor))
+	}
+
+	var failed int
+	for _, r := range d.results {
+		if r.Status == statusFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(d.results))
+	}
+	return nil
+}
+
+// checkConfig loads the configuration and reports its issues, returning
+// whether the other checks can run
+func (d *doctor) checkConfig(path string) bool {
+	cfg, issues, err := config.Inspect(path)
+	if err != nil {
+		d.add("config", path, statusFail, err.Error(), "pass the configuration with -conf")
+		return false
+	}
+	d.cfg = cfg
+
+	if len(issues) == 0 {
+		d.add("config", path, statusOK, "valid", "")
+	}
+	for _, issue := range issues {
+		key := issue.Key
+		if key == "" {
+			key = "(config)"
+		}
+		d.add("config", key, statusFail, issue.Message, issue.Suggestion)
+	}
+
+	env := cfg.Environment
+	if env == "" {
+		env = "(empty, treated as production)"
+	}
+	d.add("config", "environment", statusOK, env, "")
+	return true
+}
+
+// checkDatabase pings the master database
+func (d *doctor) checkDatabase(ctx context.Context, path string) {
+	data := d.cfg.Data
+	if data == nil || data.Database == nil || data.Database.Master == nil || data.Database.Master.Source == "" {
+		d.add("database", "master", statusSkip, "data.database.master is not configured", "")
+		return
+	}
+
+	d.probe(ctx, "database", data.Database.Master.Driver, "check data.database.master.source and that the server accepts connections", func(ctx context.Context) (string, error) {
+		db, closeConn, err := masterDB(ctx, d.cfg, path)
+		if err != nil {
+			return "", err
+		}
+		defer closeConn()
+		if err := db.PingContext(ctx); err != nil {
+			return "", err
+		}
+		return "reachable", nil
+	})
+}
+
+// checkRedis sends a PING to Redis, authenticating first when configured
+func (d *doctor) checkRedis(ctx context.Context) {
+	if d.cfg.Data == nil || d.cfg.Data.Redis == nil || d.cfg.Data.Redis.Addr == "" {
+		d.add("redis", "redis", statusSkip, "data.redis.addr is not configured", "")
+		return
+	}
+	rc := d.cfg.Data.Redis
+
+	d.probe(ctx, "redis", rc.Addr, "check data.redis.addr and data.redis.password", func(ctx context.Context) (string, error) {
+		conn, err := dial(ctx, rc.Addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		if rc.Password != "" {
+			args := []string{"AUTH", rc.Password}
+			if rc.Username != "" {
+				args = []string{"AUTH", rc.Username, rc.Password}
+			}
+			if _, err := redisCommand(conn, r, args...); err != nil {
+				return "", err
+			}
+		}
+		reply, err := redisCommand(conn, r, "PING")
+		if err != nil {
+			return "", err
+		}
+		return "reachable, replied " + reply, nil
+	})
+}
+
+// checkSearch requests the configured search engines
+func (d *doctor) checkSearch(ctx context.Context) {
+	if d.cfg.Data == nil || d.cfg.Data.Search == nil {
+		d.add("search", "search", statusSkip, "data.search is not configured", "")
+		return
+	}
+	sc := d.cfg.Data.Search
+
+	var checked bool
+	if es := sc.Elasticsearch; es != nil {
+		for _, addr := range es.Addresses {
+			checked = true
+			d.probe(ctx, "search", "elasticsearch "+addr, "check data.search.elasticsearch", func(ctx context.Context) (string, error) {
+				return httpCheck(ctx, addr, es.Username, es.Password, nil)
+			})
+		}
+	}
+	if ops := sc.OpenSearch; ops != nil {
+		for _, addr := range ops.Addresses {
+			checked = true
+			d.probe(ctx, "search", "opensearch "+addr, "check data.search.opensearch", func(ctx context.Context) (string, error) {
+				return httpCheck(ctx, addr, ops.Username, ops.Password, nil)
+			})
+		}
+	}
+	if ms := sc.Meilisearch; ms != nil && ms.Host != "" {
+		checked = true
+		d.probe(ctx, "search", "meilisearch "+ms.Host, "check data.search.meilisearch.host", func(ctx context.Context) (string, error) {
+			return httpCheck(ctx, strings.TrimSuffix(ms.Host, "/")+"/health", "", "", nil)
+		})
+	}
+	if !checked {
+		d.add("search", "search", statusSkip, "no search engine is configured", "")
+	}
+}
+
+// checkMessaging dials the RabbitMQ and Kafka brokers
+func (d *doctor) checkMessaging(ctx context.Context) {
+	var checked bool
+	if d.cfg.Data != nil && d.cfg.Data.RabbitMQ != nil && d.cfg.Data.RabbitMQ.URL != "" {
+		checked = true
+		d.probe(ctx, "messaging", "rabbitmq", "check data.rabbitmq.url", func(ctx context.Context) (string, error) {
+			u, err := url.Parse(d.cfg.Data.RabbitMQ.URL)
+			if err != nil {
+				return "", fmt.Errorf("invalid url: %v", err)
+			}
+			addr := u.Host
+			if u.Port() == "" {
+				port := "5672"
+				if u.Scheme == "amqps" {
+					port = "5671"
+				}
+				addr = net.JoinHostPort(u.Hostname(), port)
+			}
+			conn, err := dial(ctx, addr)
+			if err != nil {
+				return "", err
+			}
+			conn.Close()
+			return addr + " reachable", nil
+		})
+	}
+	if d.cfg.Data != nil && d.cfg.Data.Kafka != nil {
+		for _, broker := range d.cfg.Data.Kafka.Brokers {
+			checked = true
+			d.probe(ctx, "messaging", "kafka "+broker, "check data.kafka.brokers", func(ctx context.Context) (string, error) {
+				conn, err := dial(ctx, broker)
+				if err != nil {
+					return "", err
+				}
+				conn.Close()
+				return "reachable", nil
+			})
+		}
+	}
+	if !checked {
+		d.add("messaging", "messaging", statusSkip, "no message queue is configured", "")
+	}
+}
+
+// checkConsul asks Consul for its raft leader
+func (d *doctor) checkConsul(ctx context.Context) {
+	if d.cfg.Consul == nil || d.cfg.Consul.Address == "" {
+		d.add("consul", "consul", statusSkip, "consul.address is not configured", "")
+		return
+	}
+	scheme := d.cfg.Consul.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	endpoint := scheme + "://" + d.cfg.Consul.Address + "/v1/status/leader"
+
+	d.probe(ctx, "consul", d.cfg.Consul.Address, "check consul.address and consul.scheme", func(ctx context.Context) (string, error) {
+		var leader string
+		if _, err := httpCheck(ctx, endpoint, "", "", &leader); err != nil {
+			return "", err
+		}
+		if leader == "" {
+			return "", fmt.Errorf("reachable but no leader is elected")
+		}
+		return "leader " + leader, nil
+	})
+}
+
+// checkPlugins checks the plugin directory, and the signature and build of
+// each plugin file in it
+func (d *doctor) checkPlugins(binary string) {
+	ext := d.cfg.Extension
+	if ext == nil || ext.IsBuiltInMode() || ext.Path == "" {
+		d.add("plugins", "plugins", statusSkip, "file plugins are not used", "")
+		return
+	}
+
+	info, err := os.Stat(ext.Path)
+	switch {
+	case err != nil:
+		d.add("plugins", ext.Path, statusFail, err.Error(), "create the directory or fix extension.path")
+		return
+	case !info.IsDir():
+		d.add("plugins", ext.Path, statusFail, "not a directory", "point extension.path to the plugin directory")
+		return
+	}
+	if _, err := os.ReadDir(ext.Path); err != nil {
+		d.add("plugins", ext.Path, statusFail, err.Error(), "grant the application user read access")
+		return
+	}
+	if info.Mode().Perm()&0o002 != 0 {
+		d.add("plugins", ext.Path, statusWarn, fmt.Sprintf("world-writable (%s)", info.Mode().Perm()), "chmod o-w the directory, anyone can drop plugins into it")
+	} else {
+		d.add("plugins", ext.Path, statusOK, fmt.Sprintf("readable (%s)", info.Mode().Perm()), "")
+	}
+
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join(ext.Path, "*"+utils.GetPlatformExt()),
+		filepath.Join(ext.Path, "plugins", "*"+utils.GetPlatformExt()),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		d.add("plugins", "files", statusWarn, "no *"+utils.GetPlatformExt()+" files found", "")
+		return
+	}
+
+	d.loadHost(binary)
+	var sandbox *security.Sandbox
+	if ext.Security != nil && ext.Security.RequireSignature {
+		sandbox = security.NewSandbox(ext.Security)
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		if sandbox != nil {
+			if err := sandbox.ValidatePluginSignature(file); err != nil {
+				d.add("plugins", name, statusFail, err.Error(), "write the SHA256 of the plugin to "+name+".sig")
+				continue
+			}
+		}
+		d.checkABI(file, name)
+	}
+}
+
+// loadHost reads the build of the binary plugins are loaded into
+func (d *doctor) loadHost(binary string) {
+	if binary == "" {
+		d.host, _ = debug.ReadBuildInfo()
+		d.hostSrc = "ncore " + runtime.Version()
+		return
+	}
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		d.add("plugins", binary, statusFail, err.Error(), "pass a Go binary with -binary")
+		return
+	}
+	d.host, d.hostSrc = info, filepath.Base(binary)+" "+info.GoVersion
+}
+
+// checkABI compares the Go version and the shared modules of a plugin with
+// the host build, the plugin package refuses plugins built differently
+func (d *doctor) checkABI(file, name string) {
+	info, err := buildinfo.ReadFile(file)
+	if err != nil {
+		d.add("plugins", name, statusFail, "no Go build information: "+err.Error(), "rebuild with go build -buildmode=plugin")
+		return
+	}
+	if d.host == nil {
+		d.add("plugins", name, statusWarn, "built with "+info.GoVersion+", host build unknown", "pass the application with -binary")
+		return
+	}
+	if info.GoVersion != d.host.GoVersion {
+		d.add("plugins", name, statusFail, fmt.Sprintf("built with %s, host %s", info.GoVersion, d.hostSrc), "rebuild the plugin with the Go version of the application")
+		return
+	}
+
+	hostDeps := make(map[string]string, len(d.host.Deps))
+	for _, dep := range d.host.Deps {
+		hostDeps[dep.Path] = dep.Version
+	}
+	var mismatches []string
+	for _, dep := range info.Deps {
+		if v, ok := hostDeps[dep.Path]; ok && v != dep.Version {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s (host %s)", dep.Path, dep.Version, v))
+		}
+	}
+	if len(mismatches) > 0 {
+		if len(mismatches) > 3 {
+			mismatches = append(mismatches[:3], fmt.Sprintf("and %d more", len(mismatches)-3))
+		}
+		d.add("plugins", name, statusFail, "module versions differ: "+strings.Join(mismatches, ", "), "rebuild the plugin against the go.mod of the application")
+		return
+	}
+	d.add("plugins", name, statusOK, "ABI matches "+d.hostSrc, "")
+}
+
+// print writes the report grouped by check
+func (d *doctor) print(w io.Writer, color bool) {
+	counts := make(map[checkStatus]int)
+	group := ""
+	for _, r := range d.results {
+		if r.Group != group {
+			group = r.Group
+			fmt.Fprintf(w, "\n%s\n", group)
+		}
+		counts[r.Status]++
+
+		tag := fmt.Sprintf("%-4s", strings.ToUpper(string(r.Status)))
+		if color {
+			tag = statusColors[r.Status] + tag + "\033[0m"
+		}
+		line := fmt.Sprintf("  [%s] %s", tag, r.Name)
+		if r.Detail != "" {
+			line += ": " + r.Detail
+		}
+		if r.Duration > 0 {
+			line += fmt.Sprintf(" (%s)", r.Duration)
+		}
+		fmt.Fprintln(w, line)
+		if r.Hint != "" && r.Status != statusOK {
+			fmt.Fprintf(w, "         hint: %s\n", r.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d ok, %d warnings, %d failed, %d skipped\n",
+		counts[statusOK], counts[statusWarn], counts[statusFail], counts[statusSkip])
+}
+
+// useColor reports whether stdout is a terminal that takes colors
+func useColor(disabled bool) bool {
+	if disabled || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// dial opens a TCP connection within the context deadline
+func dial(ctx context.Context, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// redisCommand sends a RESP command and returns its simple reply
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(conn, b.String()); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return "", fmt.Errorf("%s: %s", args[0], line[1:])
+	}
+	return strings.TrimPrefix(line, "+"), nil
+}
+
+// httpCheck requests endpoint and fails on error statuses, decoding the
+// JSON body into out when set
+func httpCheck(ctx context.Context, endpoint, username, password string, out any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("credentials rejected (%s)", resp.Status)
+	case resp.StatusCode >= 400:
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return "", fmt.Errorf("invalid response: %v", err)
+		}
+	}
+	return "reachable (" + resp.Status + ")", nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+)
+
+// newDoctor loads content as the configuration checked by a doctor
+func newDoctor(t *testing.T, content string) *doctor {
+	t.Helper()
+	t.Setenv(config.ProfileEnv, "")
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d := &doctor{timeout: 2 * time.Second}
+	if !d.checkConfig(file) {
+		t.Fatalf("config not loaded: %+v", d.results)
+	}
+	d.results = nil
+	return d
+}
+
+// result returns the result of a check by group and name
+func (d *doctor) result(t *testing.T, group, name string) checkResult {
+	t.Helper()
+	for _, r := range d.results {
+		if r.Group == group && r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no %s check %s in %+v", group, name, d.results)
+	return checkResult{}
+}
+
+// fakeRedis answers each RESP command with the reply returned for its arguments
+func fakeRedis(t *testing.T, reply func(args []string) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var n int
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if _, err := fmt.Sscanf(line, "*%d", &n); err != nil {
+						return
+					}
+					args := make([]string, 0, n)
+					for range n {
+						if _, err := r.ReadString('\n'); err != nil {
+							return
+						}
+						arg, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						args = append(args, strings.TrimRight(arg, "\r\n"))
+					}
+					if _, err := conn.Write([]byte(reply(args) + "\r\n")); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestDoctorConfig(t *testing.T) {
+	d := &doctor{}
+	if d.checkConfig(filepath.Join(t.TempDir(), "missing.yaml")) {
+		t.Fatal("checks continue without a configuration")
+	}
+	if r := d.results[0]; r.Status != statusFail || r.Hint == "" {
+		t.Fatalf("missing config result = %+v", r)
+	}
+
+	// Issues are reported one by one without stopping the other checks
+	d = &doctor{}
+	t.Setenv(config.ProfileEnv, "")
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("app_name: app\nenvironment: staging\nlogger:\n  output: stdot\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if !d.checkConfig(file) {
+		t.Fatal("config with issues stopped the other checks")
+	}
+	if r := d.result(t, "config", "logger.output"); r.Status != statusFail || r.Hint != `did you mean "stdout"?` {
+		t.Fatalf("issue result = %+v", r)
+	}
+	if r := d.result(t, "config", "environment"); r.Detail != "staging" {
+		t.Fatalf("environment result = %+v", r)
+	}
+}
+
+func TestDoctorRedis(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		auth []string
+	)
+	addr := fakeRedis(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			mu.Lock()
+			auth = args[1:]
+			mu.Unlock()
+			if args[len(args)-1] != "secret" {
+				return "-WRONGPASS invalid username-password pair"
+			}
+			return "+OK"
+		case "PING":
+			return "+PONG"
+		}
+		return "-ERR unknown command"
+	})
+
+	d := newDoctor(t, "data:\n  redis:\n    addr: "+addr+"\n    username: app\n    password: secret\n")
+	d.checkRedis(context.Background())
+	if r := d.result(t, "redis", addr); r.Status != statusOK || r.Detail != "reachable, replied PONG" {
+		t.Fatalf("redis result = %+v", r)
+	}
+	mu.Lock()
+	if len(auth) != 2 || auth[0] != "app" {
+		t.Errorf("AUTH arguments = %v, want the username and password", auth)
+	}
+	mu.Unlock()
+
+	d = newDoctor(t, "data:\n  redis:\n    addr: "+addr+"\n    password: wrong\n")
+	d.checkRedis(context.Background())
+	if r := d.result(t, "redis", addr); r.Status != statusFail || !strings.Contains(r.Detail, "AUTH: WRONGPASS") || r.Hint == "" {
+		t.Fatalf("redis result with a wrong password = %+v", r)
+	}
+
+	d = newDoctor(t, "app_name: app\n")
+	d.checkRedis(context.Background())
+	if r := d.result(t, "redis", "redis"); r.Status != statusSkip {
+		t.Fatalf("unconfigured redis result = %+v", r)
+	}
+}
+
+func TestDoctorConsulAndSearch(t *testing.T) {
+	leader := `"10.0.0.1:8300"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/status/leader":
+			_, _ = w.Write([]byte(leader))
+		case "/health":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	d := newDoctor(t, "consul:\n  address: "+host+"\ndata:\n  search:\n    meilisearch:\n      host: "+srv.URL+"/\n")
+	d.checkConsul(context.Background())
+	d.checkSearch(context.Background())
+	if r := d.result(t, "consul", host); r.Status != statusOK || r.Detail != "leader 10.0.0.1:8300" {
+		t.Fatalf("consul result = %+v", r)
+	}
+	if r := d.result(t, "search", "meilisearch "+srv.URL+"/"); r.Status != statusFail || !strings.HasPrefix(r.Detail, "credentials rejected") {
+		t.Fatalf("search result = %+v", r)
+	}
+
+	// A cluster without a leader cannot serve requests
+	leader = `""`
+	d.results = nil
+	d.checkConsul(context.Background())
+	if r := d.result(t, "consul", host); r.Status != statusFail || r.Detail != "reachable but no leader is elected" {
+		t.Fatalf("consul result without leader = %+v", r)
+	}
+}
+
+func TestDoctorPlugins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	d := newDoctor(t, "extension:\n  mode: file\n  path: "+dir+"\n")
+	d.checkPlugins("")
+	if r := d.result(t, "plugins", dir); r.Status != statusWarn || !strings.Contains(r.Detail, "world-writable") {
+		t.Fatalf("directory result = %+v", r)
+	}
+	if r := d.result(t, "plugins", "files"); r.Status != statusWarn {
+		t.Fatalf("files result = %+v", r)
+	}
+
+	// Files without Go build information cannot be loaded
+	if err := os.WriteFile(filepath.Join(dir, "notes.so"), []byte("not a plugin"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d.results = nil
+	d.checkPlugins("")
+	if r := d.result(t, "plugins", "notes.so"); r.Status != statusFail || !strings.HasPrefix(r.Detail, "no Go build information") {
+		t.Fatalf("plugin result = %+v", r)
+	}
+
+	d = newDoctor(t, "extension:\n  mode: file\n  path: "+filepath.Join(dir, "missing")+"\n")
+	d.checkPlugins("")
+	if r := d.results[0]; r.Status != statusFail || r.Hint != "create the directory or fix extension.path" {
+		t.Fatalf("missing directory result = %+v", r)
+	}
+}
+
+func TestDoctorPrint(t *testing.T) {
+	d := &doctor{results: []checkResult{
+		{Group: "config", Name: "config.yaml", Status: statusOK, Detail: "valid"},
+		{Group: "redis", Name: "localhost:6379", Status: statusFail, Detail: "connection refused", Hint: "check data.redis.addr", Duration: 3 * time.Millisecond},
+		{Group: "redis", Name: "cluster", Status: statusSkip},
+	}}
+
+	var b bytes.Buffer
+	d.print(&b, false)
+	want := `
+config
+  [OK  ] config.yaml: valid
+
+redis
+  [FAIL] localhost:6379: connection refused (3ms)
+         hint: check data.redis.addr
+  [SKIP] cluster
+
+1 ok, 0 warnings, 1 failed, 1 skipped
+`
+	if b.String() != want {
+		t.Fatalf("report =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,349 @@
+// Command ncore provides code generation and configuration tools for ncore applications.
+//
+// Usage:
+//
+//	ncore gen registry [-root dir] [-output file] [-package name] [-exclude dirs]
+//	ncore gen cache -type name [-dir dir] [-output file] [-tag name] [-ttl d] [-no-tests]
+//	ncore gen crud [-schema file] [-dir dir] [-force] [-with-grpc]
+//	ncore create [-interactive] [-name name] [-module path] [-type t] [-group g] [-orm o] [-driver d] [-features list] [-entity name] [-dir dir] [-force]
+//	ncore add handler [-dir dir] <name>
+//	ncore upgrade templates [-dir dir] [-force]
+//	ncore config resolve [-conf file] [-profile name] [-json]
+//	ncore config validate [-conf file] [-profile name] [-json]
+//	ncore migrate up|down|status [-conf file] [-dir dir] [-table name] [-safety warn|block|off]
+//	ncore migrate check [-conf file] [-dir dir] [-offline] [-driver name]
+//	ncore migrate create [-dir dir] <name>
+//	ncore anonymize [-conf file] [-plan file] [-batch n] [-dry-run] [-force]
+//	ncore doctor [-conf file] [-profile name] [-timeout d] [-binary file] [-json] [-no-color]
+//	ncore openapi [-url url] [-output file] [-title title] [-version v] [-servers urls] [-timeout d]
+//	ncore routes [-url url] [-format json|markdown] [-output file] [-timeout d]
+//	ncore version [-json] [-sbom]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/data/repogen"
+	"github.com/ncobase/ncore/extension/registry/gen"
+	"github.com/ncobase/ncore/extension/scaffold"
+	"github.com/ncobase/ncore/version"
+)
+
+const usage = `Usage: ncore <command> [arguments]
+
+Commands:
+  gen registry      generate a typed extension registry with explicit imports
+  gen cache         generate a read-through caching decorator for a repository interface
+  gen crud          generate structs, repositories, services, handlers and routes from an entity schema,
+                    with -with-grpc also a gRPC service
+  create            scaffold an extension and its .ncore.yaml manifest, -interactive prompts for the options
+  add handler       add a handler, or an entity with its CRUD, to an extension with the options of its manifest
+  upgrade templates scaffold an extension again with the current templates and the options of its manifest
+  config resolve    print the effective layered configuration with the source of each key
+  config validate   report misconfigured settings with suggestions
+  migrate up        apply pending SQL migrations to data.database.master
+  migrate down      roll back applied migrations, one by default
+  migrate status    list migrations and whether they are applied
+  migrate check     report operations of pending migrations that lock or rewrite large tables
+  migrate create    add empty up and down files for a new migration
+  anonymize         rewrite personal data of data.database.master with a plan, for staging copies
+  doctor            check the configuration, service connectivity and plugins of an environment
+  openapi           save the OpenAPI 3.1 document of the routes of a running application
+  routes            save the routes of a running application with their owner, middleware and auth
+  version           print the version of this build, with -sbom its CycloneDX SBOM
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ncore: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a command
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "anonymize":
+			return anonymizeData(args[1:])
+		case "create":
+			return createExtension(args[1:])
+		case "doctor":
+			return doctorCheck(args[1:])
+		case "openapi":
+			return exportOpenAPI(args[1:])
+		case "routes":
+			return exportRoutes(args[1:])
+		case "version":
+			return printVersion(args[1:])
+		}
+	}
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command")
+	}
+
+	switch args[0] + " " + args[1] {
+	case "gen registry":
+		return genRegistry(args[2:])
+	case "gen cache":
+		return genCache(args[2:])
+	case "gen crud":
+		return genCRUD(args[2:])
+	case "add handler":
+		return addHandler(args[2:])
+	case "upgrade templates":
+		return upgradeTemplates(args[2:])
+	case "config resolve":
+		return configResolve(args[2:])
+	case "config validate":
+		return configValidate(args[2:])
+	case "migrate up":
+		return migrateUp(args[2:])
+	case "migrate down":
+		return migrateDown(args[2:])
+	case "migrate status":
+		return migrateStatus(args[2:])
+	case "migrate check":
+		return migrateCheck(args[2:])
+	case "migrate create":
+		return migrateCreate(args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", strings.Join(args[:2], " "))
+	}
+}
+
+// printVersion prints the version information or the SBOM of this build
+func printVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	sbom := fs.Bool("sbom", false, "print a CycloneDX SBOM of the modules of this build")
+	asJSON := fs.Bool("json", false, "print version information as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case *sbom:
+		return version.PrintSBOM()
+	case *asJSON:
+		fmt.Println(version.GetVersionInfo().JSON())
+	default:
+		version.Print()
+	}
+	return nil
+}
+
+// genRegistry runs the registry generator
+func genRegistry(args []string) error {
+	fs := flag.NewFlagSet("gen registry", flag.ContinueOnError)
+	root := fs.String("root", ".", "module root to scan")
+	output := fs.String("output", "internal/registry/registry_gen.go", "generated file path")
+	pkg := fs.String("package", "", "package name of the generated file (default: output directory name)")
+	exclude := fs.String("exclude", "", "comma separated directories to skip, relative to root")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var excludes []string
+	if *exclude != "" {
+		excludes = strings.Split(*exclude, ",")
+	}
+
+	exts, err := gen.Generate(gen.Options{
+		Root:    *root,
+		Output:  *output,
+		Package: *pkg,
+		Exclude: excludes,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ext := range exts {
+		fmt.Printf("  %s.%s (group: %q)\n", ext.ImportPath, ext.Constructor, ext.Group)
+	}
+	fmt.Printf("generated %s with %d extensions\n", *output, len(exts))
+	return nil
+}
+
+// genCache runs the repository caching decorator generator
+func genCache(args []string) error {
+	fs := flag.NewFlagSet("gen cache", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "package directory of the repository interface")
+	typ := fs.String("type", "", "repository interface name, e.g. UserRepository")
+	output := fs.String("output", "", "generated file path (default: <dir>/<type>_cache.go)")
+	tag := fs.String("tag", "", "cache tag invalidated by writes (default: the entity name)")
+	ttl := fs.Duration("ttl", 5*time.Minute, "default TTL of cached reads")
+	noTests := fs.Bool("no-tests", false, "do not generate the decorator test")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := repogen.GenerateCache(repogen.CacheOptions{
+		Dir:       *dir,
+		Interface: *typ,
+		Output:    *output,
+		Tag:       *tag,
+		TTL:       *ttl,
+		NoTests:   *noTests,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  reads:  %s\n", strings.Join(c.Reads, ", "))
+	fmt.Printf("  writes: %s\n", strings.Join(c.Writes, ", "))
+	fmt.Printf("generated %s", c.Output)
+	if c.TestFile != "" {
+		fmt.Printf(" and %s", c.TestFile)
+	}
+	fmt.Println()
+	return nil
+}
+
+// genCRUD runs the CRUD scaffolder
+func genCRUD(args []string) error {
+	fs := flag.NewFlagSet("gen crud", flag.ContinueOnError)
+	schema := fs.String("schema", "schema.yaml", "entity schema file")
+	dir := fs.String("dir", "", "extension directory written to (default: the schema directory)")
+	force := fs.Bool("force", false, "overwrite existing files")
+	withGRPC := fs.Bool("with-grpc", false, "also generate a proto definition, buf configuration and gRPC server and client")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	files, err := scaffold.GenerateCRUD(scaffold.CRUDOptions{
+		Schema: *schema,
+		Dir:    *dir,
+		Force:  *force,
+		GRPC:   *withGRPC,
+	})
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	for _, f := range files {
+		if f.Skipped {
+			fmt.Printf("  %s (exists, skipped)\n", f.Path)
+			continue
+		}
+		fmt.Printf("  %s\n", f.Path)
+		written++
+	}
+	fmt.Printf("generated %d files from %s\n", written, *schema)
+	if *withGRPC {
+		fmt.Println("run buf generate in the extension directory to compile the proto definition")
+	}
+	return nil
+}
+
+// configResolve prints the merged configuration layers
+func configResolve(args []string) error {
+	fs := flag.NewFlagSet("config resolve", flag.ContinueOnError)
+	conf := fs.String("conf", "config.yaml", "base configuration file")
+	profile := fs.String("profile", config.Profile(), "profile overlay (default: $"+config.ProfileEnv+")")
+	asJSON := fs.Bool("json", false, "print settings and sources as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	resolved, err := config.Resolve(*conf, *profile)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resolved)
+	}
+
+	fmt.Printf("# layers: %s\n", strings.Join(resolved.Files, " < "))
+	keys := make([]string, 0, len(resolved.Sources))
+	for key := range resolved.Sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := json.Marshal(lookup(resolved.Settings, key))
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		fmt.Printf("%s = %s  # %s\n", key, value, filepath.Base(resolved.Sources[key]))
+	}
+	return nil
+}
+
+// configValidate prints the issues of a configuration
+func configValidate(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	conf := fs.String("conf", "config.yaml", "base configuration file")
+	profile := fs.String("profile", config.Profile(), "profile overlay (default: $"+config.ProfileEnv+")")
+	asJSON := fs.Bool("json", false, "print issues as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Overlays are picked by the profile variable while loading
+	if err := os.Setenv(config.ProfileEnv, *profile); err != nil {
+		return err
+	}
+	issues, err := config.Check(*conf)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if issues == nil {
+			issues = []config.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			key := issue.Key
+			if key == "" {
+				key = "(config)"
+			}
+			fmt.Printf("%s: %s\n", key, issue.Message)
+			if issue.Suggestion != "" {
+				fmt.Printf("  hint: %s\n", issue.Suggestion)
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%s has %d issues", *conf, len(issues))
+	}
+	if !*asJSON {
+		fmt.Printf("%s is valid\n", *conf)
+	}
+	return nil
+}
+
+// lookup returns the value at a dotted key
+func lookup(settings map[string]any, key string) any {
+	var cur any = settings
+	for part := range strings.SplitSeq(key, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
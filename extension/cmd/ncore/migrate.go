@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/migrate"
+
+	_ "github.com/ncobase/ncore/data/mysql"
+	_ "github.com/ncobase/ncore/data/postgres"
+)
+
+// migrationName matches names accepted by migrate create
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// migrateFlags are shared by the migrate commands
+type migrateFlags struct {
+	fs         *flag.FlagSet
+	conf       *string
+	dir        *string
+	table      *string
+	safety     *string
+	largeTable *int64
+}
+
+func newMigrateFlags(name string) *migrateFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &migrateFlags{
+		fs:         fs,
+		conf:       fs.String("conf", "config.yaml", "configuration file with data.database.master"),
+		dir:        fs.String("dir", "migrations", "directory of migration files"),
+		table:      fs.String("table", "", "migrations table (default: schema_migrations)"),
+		safety:     fs.String("safety", "warn", "safety policy for dangerous operations: warn, block or off"),
+		largeTable: fs.Int64("large-table", 100000, "estimated rows from which a table is large"),
+	}
+}
+
+// migrator connects to the master database of the configuration
+func (f *migrateFlags) migrator(ctx context.Context) (*migrate.Migrator, func(), error) {
+	cfg, err := config.LoadConfig(*f.conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, closeConn, err := masterDB(ctx, cfg, *f.conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	safety := migrate.Policy(*f.safety)
+	if safety == "off" {
+		safety = migrate.PolicyOff
+	}
+	m, err := migrate.New(db, os.DirFS(*f.dir), migrate.Options{
+		Driver:     cfg.Data.Database.Master.Driver,
+		Table:      *f.table,
+		Safety:     safety,
+		LargeTable: *f.largeTable,
+		OnFinding:  printFinding,
+	})
+	if err != nil {
+		closeConn()
+		return nil, nil, err
+	}
+	return m, closeConn, nil
+}
+
+// masterDB connects to data.database.master of cfg, loaded from path
+func masterDB(ctx context.Context, cfg *config.Config, path string) (*sql.DB, func(), error) {
+	if cfg.Data == nil || cfg.Data.Database == nil || cfg.Data.Database.Master == nil || cfg.Data.Database.Master.Source == "" {
+		return nil, nil, fmt.Errorf("%s has no data.database.master", path)
+	}
+	node := cfg.Data.Database.Master
+
+	driver, err := data.GetDatabaseDriver(node.Driver)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := driver.Connect(ctx, node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %v", node.Driver, err)
+	}
+	closeConn := func() { _ = driver.Close(conn) }
+
+	db, ok := conn.(*sql.DB)
+	if !ok {
+		closeConn()
+		return nil, nil, fmt.Errorf("driver %s returned invalid connection type, expected *sql.DB", node.Driver)
+	}
+	return db, closeConn, nil
+}
+
+// migrateUp applies pending migrations
+func migrateUp(args []string) error {
+	f := newMigrateFlags("migrate up")
+	to := f.fs.Int64("to", -1, "apply migrations up to this version (default: all)")
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	m, closeConn, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	applied, err := m.UpTo(ctx, *to)
+	printMigrations("applied", applied)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("applied %d migrations\n", len(applied))
+	return nil
+}
+
+// migrateDown rolls back applied migrations
+func migrateDown(args []string) error {
+	f := newMigrateFlags("migrate down")
+	steps := f.fs.Int("steps", 1, "number of migrations to roll back")
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	m, closeConn, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	rolledBack, err := m.Down(ctx, *steps)
+	printMigrations("rolled back", rolledBack)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rolled back %d migrations\n", len(rolledBack))
+	return nil
+}
+
+// migrateStatus prints the state of every migration
+func migrateStatus(args []string) error {
+	f := newMigrateFlags("migrate status")
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	m, closeConn, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case s.Missing:
+			state += " (file missing)"
+		case s.Modified:
+			state += " (modified)"
+		}
+		fmt.Printf("  %d_%s  %s\n", s.Version, s.Name, state)
+	}
+	return nil
+}
+
+// migrateCheck reports dangerous operations of pending migrations, or of all
+// migration files with -offline
+func migrateCheck(args []string) error {
+	f := newMigrateFlags("migrate check")
+	offline := f.fs.Bool("offline", false, "analyze all files without a database, table sizes unknown")
+	driver := f.fs.String("driver", "postgres", "SQL dialect with -offline: postgres or mysql")
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+
+	var findings []migrate.Finding
+	if *offline {
+		migrations, err := migrate.Load(os.DirFS(*f.dir))
+		if err != nil {
+			return err
+		}
+		for _, mig := range migrations {
+			findings = append(findings, migrate.Analyze(context.Background(), mig, *driver, *f.largeTable, nil)...)
+		}
+	} else {
+		*f.safety = "off"
+		ctx := context.Background()
+		m, closeConn, err := f.migrator(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+		if findings, err = m.Advise(ctx); err != nil {
+			return err
+		}
+	}
+
+	dangerous := 0
+	for _, finding := range findings {
+		printFinding(finding)
+		if finding.Severity == migrate.SeverityDanger {
+			dangerous++
+		}
+	}
+	if dangerous > 0 {
+		return fmt.Errorf("%d dangerous operations", dangerous)
+	}
+	fmt.Printf("%d findings, none dangerous\n", len(findings))
+	return nil
+}
+
+// printFinding prints a safety finding with its suggestion
+func printFinding(f migrate.Finding) {
+	size := "unknown size"
+	if f.Rows >= 0 {
+		size = fmt.Sprintf("~%d rows", f.Rows)
+	}
+	if f.Table == "" {
+		size = "-"
+	}
+	fmt.Printf("  %-7s %d_%s [%s] %s (%s)\n          %s\n          suggestion: %s\n",
+		f.Severity, f.Version, f.Name, f.Rule, f.Message, size, f.Statement, f.Suggestion)
+}
+
+// migrateCreate writes empty up and down files for the next version
+func migrateCreate(args []string) error {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := fs.String("dir", "migrations", "directory of migration files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || !migrationName.MatchString(fs.Arg(0)) {
+		return fmt.Errorf("usage: ncore migrate create [-dir dir] <name>, name in lower snake case")
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	existing, err := migrate.Load(os.DirFS(*dir))
+	if err != nil {
+		return err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(*dir, fmt.Sprintf("%04d_%s.%s.sql", version, fs.Arg(0), direction))
+		content := fmt.Sprintf("-- %s: %s\n", strings.ToUpper(direction), fs.Arg(0))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", path)
+	}
+	return nil
+}
+
+func printMigrations(verb string, migrations []*migrate.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("  %s %d_%s\n", verb, m.Version, m.Name)
+	}
+}
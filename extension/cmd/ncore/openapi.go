@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/extension/openapi"
+)
+
+// exportOpenAPI saves the OpenAPI document of a running application, built
+// from its registered routes by Manager.ExportOpenAPI
+func exportOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:8080/system/openapi.json", "document URL, the system routes of the extension manager")
+	output := fs.String("output", "", "output file, stdout when empty")
+	title := fs.String("title", "", "replace the document title")
+	version := fs.String("version", "", "replace the API version")
+	servers := fs.String("servers", "", "comma separated server URLs, replacing those of the document")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, err := fetch(*url, *timeout)
+	if err != nil {
+		return fmt.Errorf("failed to fetch document: %v", err)
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(body, &doc); err != nil || doc.OpenAPI == "" {
+		return fmt.Errorf("%s is not an OpenAPI document", *url)
+	}
+	if *title != "" {
+		doc.Info.Title = *title
+	}
+	if *version != "" {
+		doc.Info.Version = *version
+	}
+	if *servers != "" {
+		doc.Servers = nil
+		for _, s := range strings.Split(*servers, ",") {
+			doc.Servers = append(doc.Servers, openapi.Server{URL: s})
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	if *output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(*output, out, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s: %d paths\n", *output, len(doc.Paths))
+	return nil
+}
+
+// fetch returns the body of a GET request, failing on other statuses than 200
+func fetch(url string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// exportRoutes saves the route manifest of a running application, listed by
+// Manager.RouteManifest, as JSON or a markdown table
+func exportRoutes(args []string) error {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	rawURL := fs.String("url", "http://localhost:8080/system/routes", "manifest URL, the system routes of the extension manager")
+	format := fs.String("format", "json", "output format: json or markdown")
+	output := fs.String("output", "", "output file, stdout when empty")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "markdown" {
+		return fmt.Errorf("unknown format %q, expected json or markdown", *format)
+	}
+
+	u, err := url.Parse(*rawURL)
+	if err != nil {
+		return err
+	}
+	if *format == "markdown" {
+		q := u.Query()
+		q.Set("format", "markdown")
+		u.RawQuery = q.Encode()
+	}
+	body, err := fetch(u.String(), *timeout)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %v", err)
+	}
+
+	routes := -1
+	if *format == "json" {
+		var manifest struct {
+			Routes []json.RawMessage `json:"routes"`
+		}
+		if err := json.Unmarshal(body, &manifest); err != nil || manifest.Routes == nil {
+			return fmt.Errorf("%s is not a route manifest", *rawURL)
+		}
+		routes = len(manifest.Routes)
+
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err != nil {
+			return err
+		}
+		body = append(out.Bytes(), '\n')
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(body)
+		return err
+	}
+	if err := os.WriteFile(*output, body, 0644); err != nil {
+		return err
+	}
+	if routes >= 0 {
+		fmt.Printf("Wrote %s: %d routes\n", *output, routes)
+	} else {
+		fmt.Printf("Wrote %s\n", *output)
+	}
+	return nil
+}
@@ -4,25 +4,82 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 // Config extension config struct
 type Config struct {
-	Mode      string   `json:"mode" yaml:"mode"`
+	Mode      string   `json:"mode" yaml:"mode" validate:"omitempty,oneof=file c2hlbgo"`
 	Path      string   `json:"path" yaml:"path"`
 	Includes  []string `json:"includes" yaml:"includes"`
 	Excludes  []string `json:"excludes" yaml:"excludes"`
 	HotReload bool     `json:"hot_reload" yaml:"hot_reload"`
 
-	MaxPlugins   int            `json:"max_plugins" yaml:"max_plugins"`
+	MaxPlugins   int            `json:"max_plugins" yaml:"max_plugins" validate:"min=0"`
 	PluginConfig map[string]any `json:"plugin_config" yaml:"plugin_config"`
 
+	// Settings holds per-extension runtime settings keyed by extension name
+	Settings map[string]*ExtensionSettings `json:"settings" yaml:"settings"`
+
 	Security    *SecurityConfig    `json:"security" yaml:"security"`
 	Performance *PerformanceConfig `json:"performance" yaml:"performance"`
 	Metrics     *MetricsConfig     `json:"metrics" yaml:"metrics"`
+	Watcher     *WatcherConfig     `json:"watcher" yaml:"watcher"`
+	Probes      *ProbesConfig      `json:"probes" yaml:"probes"`
+	HealthCheck *HealthCheckConfig `json:"health_check" yaml:"health_check"`
+	Region      *RegionConfig      `json:"region" yaml:"region"`
+	Startup     *StartupConfig     `json:"startup" yaml:"startup"`
+	Tasks       *TasksConfig       `json:"tasks" yaml:"tasks"`
+	Usage       *UsageConfig       `json:"usage" yaml:"usage"`
+}
+
+// ExtensionSettings per-extension runtime settings
+type ExtensionSettings struct {
+	Lazy        bool   `json:"lazy" yaml:"lazy"`                 // Defer initialization until first use
+	RoutePrefix string `json:"route_prefix" yaml:"route_prefix"` // Route prefix that activates a lazy extension or is split by a canary
+	LogLevel    string `json:"log_level" yaml:"log_level"`       // Overrides the global log level for the extension
+	InitTimeout string `json:"init_timeout" yaml:"init_timeout"` // Overrides startup init_timeout for the extension
+	StopTimeout string `json:"stop_timeout" yaml:"stop_timeout"` // Overrides startup stop_timeout for the extension
+}
+
+// GetSettings returns the settings of an extension, or nil if none are configured
+func (c *Config) GetSettings(name string) *ExtensionSettings {
+	if c == nil || c.Settings == nil {
+		return nil
+	}
+	return c.Settings[name]
+}
+
+// IsLazy returns whether an extension is initialized on demand
+func (c *Config) IsLazy(name string) bool {
+	settings := c.GetSettings(name)
+	return settings != nil && settings.Lazy
+}
+
+// GetInitTimeout returns how long each init phase of an extension may run
+func (c *Config) GetInitTimeout(name string) time.Duration {
+	if settings := c.GetSettings(name); settings != nil && settings.InitTimeout != "" {
+		return durationOrDefault(settings.InitTimeout, 30*time.Second)
+	}
+	if c != nil && c.Startup != nil {
+		return c.Startup.GetInitTimeout()
+	}
+	return 30 * time.Second
+}
+
+// GetStopTimeout returns how long each cleanup phase of an extension may run
+func (c *Config) GetStopTimeout(name string) time.Duration {
+	if settings := c.GetSettings(name); settings != nil && settings.StopTimeout != "" {
+		return durationOrDefault(settings.StopTimeout, 10*time.Second)
+	}
+	if c != nil && c.Startup != nil {
+		return c.Startup.GetStopTimeout()
+	}
+	return 10 * time.Second
 }
 
 // SecurityConfig security settings
@@ -33,6 +90,16 @@ type SecurityConfig struct {
 	TrustedSources    []string `json:"trusted_sources" yaml:"trusted_sources"`
 	RequireSignature  bool     `json:"require_signature" yaml:"require_signature"`
 	AllowUnsafe       bool     `json:"allow_unsafe" yaml:"allow_unsafe"`
+
+	FileSystem *FileSystemConfig `json:"filesystem" yaml:"filesystem"`
+}
+
+// FileSystemConfig brokered filesystem settings for extensions
+type FileSystemConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	Root      string `json:"root" yaml:"root"`             // Base directory, one subdirectory per extension
+	QuotaMB   int    `json:"quota_mb" yaml:"quota_mb"`     // Per-extension quota, 0 for unlimited
+	AuditSize int    `json:"audit_size" yaml:"audit_size"` // Audit entries kept in memory
 }
 
 // PerformanceConfig performance settings
@@ -50,6 +117,22 @@ type MetricsConfig struct {
 	BatchSize     int            `json:"batch_size" yaml:"batch_size"`
 	Retention     string         `json:"retention" yaml:"retention"`
 	Storage       *StorageConfig `json:"storage" yaml:"storage"`
+
+	// AdaptiveBatch sizes storage flushes between BatchSize/10 and BatchSize*10,
+	// growing them while flushes stay under FlushLatency and halving them on errors
+	AdaptiveBatch bool   `json:"adaptive_batch" yaml:"adaptive_batch"`
+	FlushLatency  string `json:"flush_latency" yaml:"flush_latency"` // Target flush latency, default 100ms
+
+	Exporters []*ExporterConfig `json:"exporters" yaml:"exporters"`
+}
+
+// ExporterConfig metrics exporter configuration
+type ExporterConfig struct {
+	Type          string            `json:"type" yaml:"type"`                     // "statsd" or "dogstatsd"
+	Address       string            `json:"address" yaml:"address"`               // host:port for UDP, unix:///path for a Unix domain socket
+	Prefix        string            `json:"prefix" yaml:"prefix"`                 // Metric name prefix
+	Tags          map[string]string `json:"tags" yaml:"tags"`                     // Tags added to every metric, DogStatsD only
+	FlushInterval string            `json:"flush_interval" yaml:"flush_interval"` // Client-side aggregation window
 }
 
 // StorageConfig metrics storage configuration
@@ -59,6 +142,162 @@ type StorageConfig struct {
 	Options   map[string]string `json:"options" yaml:"options"`
 }
 
+// WatcherConfig plugin file watcher settings
+type WatcherConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Debounce string `json:"debounce" yaml:"debounce"`
+}
+
+// HealthCheckConfig extension health check settings
+type HealthCheckConfig struct {
+	Interval string `json:"interval" yaml:"interval"`
+	Timeout  string `json:"timeout" yaml:"timeout"`
+	CacheTTL string `json:"cache_ttl" yaml:"cache_ttl"`
+
+	SelfTestTimeout  string `json:"self_test_timeout" yaml:"self_test_timeout"`   // Timeout of a startup self-test
+	SelfTestRequired bool   `json:"self_test_required" yaml:"self_test_required"` // Failed self-tests block readiness
+}
+
+// StartupConfig extension startup and shutdown ordering settings
+type StartupConfig struct {
+	Parallel     bool   `json:"parallel" yaml:"parallel"`           // Run independent extensions concurrently
+	Concurrency  int    `json:"concurrency" yaml:"concurrency"`     // Max extensions running a phase at once
+	PhaseTimeout string `json:"phase_timeout" yaml:"phase_timeout"` // Timeout per lifecycle phase
+	InitTimeout  string `json:"init_timeout" yaml:"init_timeout"`   // Watchdog per extension and init phase
+	StopTimeout  string `json:"stop_timeout" yaml:"stop_timeout"`   // Watchdog per extension and cleanup phase
+}
+
+// GetConcurrency returns the concurrency limit
+func (s *StartupConfig) GetConcurrency() int {
+	if s.Concurrency <= 0 {
+		return 4
+	}
+	return s.Concurrency
+}
+
+// GetPhaseTimeout returns the timeout of a lifecycle phase
+func (s *StartupConfig) GetPhaseTimeout() time.Duration {
+	return durationOrDefault(s.PhaseTimeout, 60*time.Second)
+}
+
+// GetInitTimeout returns the default watchdog timeout of an init phase
+func (s *StartupConfig) GetInitTimeout() time.Duration {
+	return durationOrDefault(s.InitTimeout, 30*time.Second)
+}
+
+// GetStopTimeout returns the default watchdog timeout of a cleanup phase
+func (s *StartupConfig) GetStopTimeout() time.Duration {
+	return durationOrDefault(s.StopTimeout, 10*time.Second)
+}
+
+// TasksConfig settings of scheduled tasks declared in extension metadata
+type TasksConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Timezone    string `json:"timezone" yaml:"timezone"`         // Time zone of cron schedules, empty for local
+	LockPrefix  string `json:"lock_prefix" yaml:"lock_prefix"`   // Prefix of leader election locks
+	HistorySize int    `json:"history_size" yaml:"history_size"` // Finished runs kept per task
+}
+
+// IsEnabled returns whether declared tasks are scheduled
+func (t *TasksConfig) IsEnabled() bool {
+	return t == nil || t.Enabled
+}
+
+// UsageConfig API usage analytics of extension routes
+type UsageConfig struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	Bucket        string `json:"bucket" yaml:"bucket"`                 // Rollup granularity, default 1m
+	FlushInterval string `json:"flush_interval" yaml:"flush_interval"` // How often rollups are written, default 10s
+	Retention     string `json:"retention" yaml:"retention"`           // Supports d and w units, default 30d
+	Storage       string `json:"storage" yaml:"storage"`               // memory, redis or auto
+	KeyPrefix     string `json:"key_prefix" yaml:"key_prefix"`         // Redis key prefix
+	APIKeyHeader  string `json:"api_key_header" yaml:"api_key_header"` // Identifies consumers without a user, default X-API-Key
+}
+
+// IsEnabled returns whether usage of extension routes is recorded
+func (u *UsageConfig) IsEnabled() bool {
+	return u != nil && u.Enabled
+}
+
+// GetBucket returns the rollup granularity
+func (u *UsageConfig) GetBucket() time.Duration {
+	return durationOrDefault(u.Bucket, time.Minute)
+}
+
+// GetFlushInterval returns how often rollups are written to storage
+func (u *UsageConfig) GetFlushInterval() time.Duration {
+	return durationOrDefault(u.FlushInterval, 10*time.Second)
+}
+
+// GetRetention returns how long rollups are kept
+func (u *UsageConfig) GetRetention() time.Duration {
+	if d, err := parseDuration(u.Retention); err == nil && d > 0 {
+		return d
+	}
+	return 30 * 24 * time.Hour
+}
+
+// RegionConfig multi-region replication settings
+type RegionConfig struct {
+	Name          string   `json:"name" yaml:"name"`
+	Role          string   `json:"role" yaml:"role"` // active or passive
+	Peers         []string `json:"peers" yaml:"peers"`
+	Topic         string   `json:"topic" yaml:"topic"`
+	ForwardEvents []string `json:"forward_events" yaml:"forward_events"` // event name patterns, e.g. "exts.*"
+	PreferLocal   bool     `json:"prefer_local" yaml:"prefer_local"`
+}
+
+// IsEnabled returns whether multi-region replication is configured
+func (r *RegionConfig) IsEnabled() bool {
+	return r != nil && r.Name != ""
+}
+
+// IsActive returns whether this region is the active one
+func (r *RegionConfig) IsActive() bool {
+	return r.Role != "passive"
+}
+
+// ProbesConfig external dependency probe settings
+type ProbesConfig struct {
+	Enabled          bool           `json:"enabled" yaml:"enabled"`
+	Interval         string         `json:"interval" yaml:"interval"`
+	Timeout          string         `json:"timeout" yaml:"timeout"`
+	FailureThreshold int            `json:"failure_threshold" yaml:"failure_threshold"`
+	HistorySize      int            `json:"history_size" yaml:"history_size"`
+	Targets          []*ProbeTarget `json:"targets" yaml:"targets"`
+}
+
+// ProbeTarget a single external dependency to probe
+type ProbeTarget struct {
+	Name           string            `json:"name" yaml:"name"`
+	Type           string            `json:"type" yaml:"type"` // http, tcp, smtp or a registered type
+	Address        string            `json:"address" yaml:"address"`
+	Method         string            `json:"method" yaml:"method"`
+	ExpectedStatus int               `json:"expected_status" yaml:"expected_status"`
+	Headers        map[string]string `json:"headers" yaml:"headers"`
+	Interval       string            `json:"interval" yaml:"interval"`
+	Timeout        string            `json:"timeout" yaml:"timeout"`
+}
+
+var (
+	probeTypes   = map[string]bool{"http": true, "tcp": true, "smtp": true}
+	probeTypesMu sync.RWMutex
+)
+
+// RegisterProbeType allows a target type in probe settings, called by probe.RegisterProber
+func RegisterProbeType(targetType string) {
+	probeTypesMu.Lock()
+	defer probeTypesMu.Unlock()
+	probeTypes[targetType] = true
+}
+
+// isProbeType returns whether a target type has a registered prober
+func isProbeType(targetType string) bool {
+	probeTypesMu.RLock()
+	defer probeTypesMu.RUnlock()
+	return probeTypes[targetType]
+}
+
 // BuiltInMode represents a special build tag for built-in extension mode
 // To enable built-in mode, build or run with tag: go build/run -tags="c2hlbgo"
 const BuiltInMode = "c2hlbgo"
@@ -76,6 +315,31 @@ func (m *MetricsConfig) GetRetentionDuration() (time.Duration, error) {
 	return parseDuration(m.Retention)
 }
 
+// GetDebounceDuration returns the debounce duration for file events
+func (w *WatcherConfig) GetDebounceDuration() time.Duration {
+	return durationOrDefault(w.Debounce, 500*time.Millisecond)
+}
+
+// GetInterval returns the interval between background health checks
+func (h *HealthCheckConfig) GetInterval() time.Duration {
+	return durationOrDefault(h.Interval, 30*time.Second)
+}
+
+// GetTimeout returns the timeout of a single health check
+func (h *HealthCheckConfig) GetTimeout() time.Duration {
+	return durationOrDefault(h.Timeout, 5*time.Second)
+}
+
+// GetCacheTTL returns how long health reports are served from cache
+func (h *HealthCheckConfig) GetCacheTTL() time.Duration {
+	return durationOrDefault(h.CacheTTL, 10*time.Second)
+}
+
+// GetSelfTestTimeout returns the timeout of a startup self-test
+func (h *HealthCheckConfig) GetSelfTestTimeout() time.Duration {
+	return durationOrDefault(h.SelfTestTimeout, 30*time.Second)
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.MaxPlugins <= 0 {
@@ -94,6 +358,89 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Probes != nil {
+		if err := c.Probes.Validate(); err != nil {
+			return fmt.Errorf("probes config error: %v", err)
+		}
+	}
+
+	if c.Startup != nil {
+		for _, d := range []string{c.Startup.PhaseTimeout, c.Startup.InitTimeout, c.Startup.StopTimeout} {
+			if d == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(d); err != nil {
+				return fmt.Errorf("invalid startup timeout %s: %v", d, err)
+			}
+		}
+	}
+
+	if c.HealthCheck != nil {
+		for _, d := range []string{c.HealthCheck.Interval, c.HealthCheck.Timeout, c.HealthCheck.CacheTTL, c.HealthCheck.SelfTestTimeout} {
+			if d == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(d); err != nil {
+				return fmt.Errorf("invalid health_check duration %s: %v", d, err)
+			}
+		}
+	}
+
+	if c.Security != nil && c.Security.FileSystem != nil && c.Security.FileSystem.Enabled {
+		if c.Security.FileSystem.Root == "" {
+			return fmt.Errorf("filesystem root is required")
+		}
+		if c.Security.FileSystem.QuotaMB < 0 {
+			return fmt.Errorf("filesystem quota must be non-negative")
+		}
+	}
+
+	for name, settings := range c.Settings {
+		if settings != nil && settings.Lazy && strings.Trim(settings.RoutePrefix, "/") == "" && settings.RoutePrefix != "" {
+			return fmt.Errorf("invalid route prefix for lazy extension %s: %s", name, settings.RoutePrefix)
+		}
+		if settings != nil && settings.LogLevel != "" {
+			if _, err := logrus.ParseLevel(settings.LogLevel); err != nil {
+				return fmt.Errorf("invalid log level for extension %s: %v", name, err)
+			}
+		}
+		if settings == nil {
+			continue
+		}
+		for _, d := range []string{settings.InitTimeout, settings.StopTimeout} {
+			if d == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(d); err != nil {
+				return fmt.Errorf("invalid timeout %s of extension %s: %v", d, name, err)
+			}
+		}
+	}
+
+	if c.Tasks != nil && c.Tasks.Timezone != "" {
+		if _, err := time.LoadLocation(c.Tasks.Timezone); err != nil {
+			return fmt.Errorf("invalid tasks timezone: %v", err)
+		}
+	}
+
+	if c.Usage.IsEnabled() {
+		switch c.Usage.Storage {
+		case "", "memory", "redis", "auto":
+		default:
+			return fmt.Errorf("invalid usage storage: %s", c.Usage.Storage)
+		}
+	}
+
+	if c.Region.IsEnabled() && c.Region.Role != "active" && c.Region.Role != "passive" {
+		return fmt.Errorf("invalid region role: %s", c.Region.Role)
+	}
+
+	if c.Watcher != nil && c.Watcher.Debounce != "" {
+		if _, err := time.ParseDuration(c.Watcher.Debounce); err != nil {
+			return fmt.Errorf("invalid watcher debounce: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -119,9 +466,87 @@ func (m *MetricsConfig) Validate() error {
 		return fmt.Errorf("batch_size must be greater than 0")
 	}
 
+	if m.FlushLatency != "" {
+		if _, err := time.ParseDuration(m.FlushLatency); err != nil {
+			return fmt.Errorf("invalid flush_latency: %v", err)
+		}
+	}
+
+	for i, e := range m.Exporters {
+		if e.Type != "statsd" && e.Type != "dogstatsd" {
+			return fmt.Errorf("invalid type of exporter %d: %s", i, e.Type)
+		}
+		if e.Address == "" {
+			return fmt.Errorf("address of exporter %d is required", i)
+		}
+		if e.FlushInterval != "" {
+			if _, err := time.ParseDuration(e.FlushInterval); err != nil {
+				return fmt.Errorf("invalid flush_interval of exporter %d: %v", i, err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// Validate validates the probes configuration
+func (p *ProbesConfig) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+
+	for _, d := range []string{p.Interval, p.Timeout} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid duration %s: %v", d, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, t := range p.Targets {
+		if t.Name == "" {
+			return fmt.Errorf("target %d: name is required", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("target %s: duplicate name", t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Address == "" {
+			return fmt.Errorf("target %s: address is required", t.Name)
+		}
+
+		if !isProbeType(t.Type) {
+			return fmt.Errorf("target %s: unsupported type %s", t.Name, t.Type)
+		}
+
+		for _, d := range []string{t.Interval, t.Timeout} {
+			if d == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(d); err != nil {
+				return fmt.Errorf("target %s: invalid duration %s: %v", t.Name, d, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// durationOrDefault parses a positive duration with a fallback
+func durationOrDefault(s string, defaultValue time.Duration) time.Duration {
+	if s == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
 // parseDuration parses duration with support for days (d) and weeks (w)
 func parseDuration(s string) (time.Duration, error) {
 	if strings.HasSuffix(s, "d") {
@@ -160,10 +585,18 @@ func GetConfig(v *viper.Viper) *Config {
 
 		MaxPlugins:   getIntWithDefault(v, "extension.max_plugins", 20),
 		PluginConfig: v.GetStringMap("extension.plugin_config"),
+		Settings:     getExtensionSettings(v),
 
 		Security:    getSecurityConfig(v, isDev),
 		Performance: getPerformanceConfig(v, isDev),
 		Metrics:     getMetricsConfig(v, isDev),
+		Watcher:     getWatcherConfig(v),
+		Probes:      getProbesConfig(v),
+		HealthCheck: getHealthCheckConfig(v),
+		Region:      getRegionConfig(v),
+		Startup:     getStartupConfig(v),
+		Tasks:       getTasksConfig(v),
+		Usage:       getUsageConfig(v),
 	}
 
 	if err := config.Validate(); err != nil {
@@ -189,6 +622,7 @@ func getSecurityConfig(v *viper.Viper, isDev bool) *SecurityConfig {
 			TrustedSources:    []string{},
 			RequireSignature:  false,
 			AllowUnsafe:       isDev,
+			FileSystem:        getFileSystemConfig(v),
 		}
 	}
 
@@ -199,6 +633,16 @@ func getSecurityConfig(v *viper.Viper, isDev bool) *SecurityConfig {
 		TrustedSources:    v.GetStringSlice("extension.security.trusted_sources"),
 		RequireSignature:  getBoolWithDefault(v, "extension.security.require_signature", false),
 		AllowUnsafe:       getBoolWithDefault(v, "extension.security.allow_unsafe", isDev),
+		FileSystem:        getFileSystemConfig(v),
+	}
+}
+
+func getFileSystemConfig(v *viper.Viper) *FileSystemConfig {
+	return &FileSystemConfig{
+		Enabled:   getBoolWithDefault(v, "extension.security.filesystem.enabled", false),
+		Root:      getStringWithDefault(v, "extension.security.filesystem.root", "./data/extensions"),
+		QuotaMB:   getIntWithDefault(v, "extension.security.filesystem.quota_mb", 100),
+		AuditSize: getIntWithDefault(v, "extension.security.filesystem.audit_size", 1000),
 	}
 }
 
@@ -242,7 +686,141 @@ func getMetricsConfig(v *viper.Viper, isDev bool) *MetricsConfig {
 		BatchSize:     getIntWithDefault(v, "extension.metrics.batch_size", defaultBatch),
 		Retention:     getStringWithDefault(v, "extension.metrics.retention", defaultRetention),
 		Storage:       storage,
+		AdaptiveBatch: getBoolWithDefault(v, "extension.metrics.adaptive_batch", false),
+		FlushLatency:  getStringWithDefault(v, "extension.metrics.flush_latency", ""),
+		Exporters:     getMetricsExporters(v),
+	}
+}
+
+func getMetricsExporters(v *viper.Viper) []*ExporterConfig {
+	exporters, ok := v.Get("extension.metrics.exporters").([]any)
+	if !ok {
+		return nil
+	}
+
+	result := make([]*ExporterConfig, 0, len(exporters))
+	for i := range exporters {
+		prefix := fmt.Sprintf("extension.metrics.exporters.%d.", i)
+		result = append(result, &ExporterConfig{
+			Type:          getStringWithDefault(v, prefix+"type", "dogstatsd"),
+			Address:       getStringWithDefault(v, prefix+"address", "127.0.0.1:8125"),
+			Prefix:        getStringWithDefault(v, prefix+"prefix", "ncore.extension"),
+			Tags:          v.GetStringMapString(prefix + "tags"),
+			FlushInterval: getStringWithDefault(v, prefix+"flush_interval", "10s"),
+		})
+	}
+	return result
+}
+
+func getWatcherConfig(v *viper.Viper) *WatcherConfig {
+	return &WatcherConfig{
+		Enabled:  getBoolWithDefault(v, "extension.watcher.enabled", false),
+		Debounce: getStringWithDefault(v, "extension.watcher.debounce", "500ms"),
+	}
+}
+
+func getHealthCheckConfig(v *viper.Viper) *HealthCheckConfig {
+	return &HealthCheckConfig{
+		Interval: getStringWithDefault(v, "extension.health_check.interval", "30s"),
+		Timeout:  getStringWithDefault(v, "extension.health_check.timeout", "5s"),
+		CacheTTL: getStringWithDefault(v, "extension.health_check.cache_ttl", "10s"),
+
+		SelfTestTimeout:  getStringWithDefault(v, "extension.health_check.self_test_timeout", "30s"),
+		SelfTestRequired: getBoolWithDefault(v, "extension.health_check.self_test_required", false),
+	}
+}
+
+func getStartupConfig(v *viper.Viper) *StartupConfig {
+	return &StartupConfig{
+		Parallel:     getBoolWithDefault(v, "extension.startup.parallel", false),
+		Concurrency:  getIntWithDefault(v, "extension.startup.concurrency", 4),
+		PhaseTimeout: getStringWithDefault(v, "extension.startup.phase_timeout", "60s"),
+		InitTimeout:  getStringWithDefault(v, "extension.startup.init_timeout", "30s"),
+		StopTimeout:  getStringWithDefault(v, "extension.startup.stop_timeout", "10s"),
+	}
+}
+
+func getTasksConfig(v *viper.Viper) *TasksConfig {
+	return &TasksConfig{
+		Enabled:     getBoolWithDefault(v, "extension.tasks.enabled", true),
+		Timezone:    v.GetString("extension.tasks.timezone"),
+		LockPrefix:  getStringWithDefault(v, "extension.tasks.lock_prefix", "ncore:exts:tasks:"),
+		HistorySize: getIntWithDefault(v, "extension.tasks.history_size", 20),
+	}
+}
+
+func getUsageConfig(v *viper.Viper) *UsageConfig {
+	return &UsageConfig{
+		Enabled:       getBoolWithDefault(v, "extension.usage.enabled", false),
+		Bucket:        getStringWithDefault(v, "extension.usage.bucket", "1m"),
+		FlushInterval: getStringWithDefault(v, "extension.usage.flush_interval", "10s"),
+		Retention:     getStringWithDefault(v, "extension.usage.retention", "30d"),
+		Storage:       getStringWithDefault(v, "extension.usage.storage", "auto"),
+		KeyPrefix:     getStringWithDefault(v, "extension.usage.key_prefix", "ncore_ext"),
+		APIKeyHeader:  getStringWithDefault(v, "extension.usage.api_key_header", "X-API-Key"),
+	}
+}
+
+func getExtensionSettings(v *viper.Viper) map[string]*ExtensionSettings {
+	raw := v.GetStringMap("extension.settings")
+	if len(raw) == 0 {
+		return nil
+	}
+
+	settings := make(map[string]*ExtensionSettings, len(raw))
+	for name := range raw {
+		key := "extension.settings." + name
+		settings[name] = &ExtensionSettings{
+			Lazy:        v.GetBool(key + ".lazy"),
+			RoutePrefix: v.GetString(key + ".route_prefix"),
+			LogLevel:    v.GetString(key + ".log_level"),
+			InitTimeout: v.GetString(key + ".init_timeout"),
+			StopTimeout: v.GetString(key + ".stop_timeout"),
+		}
 	}
+	return settings
+}
+
+func getRegionConfig(v *viper.Viper) *RegionConfig {
+	return &RegionConfig{
+		Name:          v.GetString("extension.region.name"),
+		Role:          getStringWithDefault(v, "extension.region.role", "active"),
+		Peers:         v.GetStringSlice("extension.region.peers"),
+		Topic:         getStringWithDefault(v, "extension.region.topic", "ncore.region.events"),
+		ForwardEvents: v.GetStringSlice("extension.region.forward_events"),
+		PreferLocal:   getBoolWithDefault(v, "extension.region.prefer_local", true),
+	}
+}
+
+func getProbesConfig(v *viper.Viper) *ProbesConfig {
+	probes := &ProbesConfig{
+		Enabled:          getBoolWithDefault(v, "extension.probes.enabled", false),
+		Interval:         getStringWithDefault(v, "extension.probes.interval", "30s"),
+		Timeout:          getStringWithDefault(v, "extension.probes.timeout", "5s"),
+		FailureThreshold: getIntWithDefault(v, "extension.probes.failure_threshold", 3),
+		HistorySize:      getIntWithDefault(v, "extension.probes.history_size", 100),
+	}
+
+	targets, ok := v.Get("extension.probes.targets").([]any)
+	if !ok {
+		return probes
+	}
+
+	for i := range targets {
+		prefix := fmt.Sprintf("extension.probes.targets.%d.", i)
+		probes.Targets = append(probes.Targets, &ProbeTarget{
+			Name:           v.GetString(prefix + "name"),
+			Type:           getStringWithDefault(v, prefix+"type", "http"),
+			Address:        v.GetString(prefix + "address"),
+			Method:         v.GetString(prefix + "method"),
+			ExpectedStatus: v.GetInt(prefix + "expected_status"),
+			Headers:        v.GetStringMapString(prefix + "headers"),
+			Interval:       v.GetString(prefix + "interval"),
+			Timeout:        v.GetString(prefix + "timeout"),
+		})
+	}
+
+	return probes
 }
 
 func getStringWithDefault(v *viper.Viper, key, defaultValue string) string {
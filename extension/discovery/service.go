@@ -3,6 +3,8 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -127,7 +129,12 @@ type ServiceDiscovery struct {
 	consul       *api.Client
 	serviceCache *ServiceCache
 	config       *ConsulConfig
-	metrics      struct {
+
+	// Region awareness
+	region           string
+	preferredRegions []string
+
+	metrics struct {
 		registrations   atomic.Int64
 		deregistrations atomic.Int64
 		lookups         atomic.Int64
@@ -169,12 +176,17 @@ func (sd *ServiceDiscovery) RegisterService(name string, info *types.ServiceInfo
 		return fmt.Errorf("invalid service info")
 	}
 
+	tags, meta := info.Tags, info.Meta
+	if sd.region != "" {
+		tags, meta = withRegion(sd.region, tags, meta)
+	}
+
 	registration := &api.AgentServiceRegistration{
 		ID:      fmt.Sprintf("%s-%s", name, uuid.New().String()[:8]),
 		Name:    name,
 		Address: info.Address,
-		Tags:    info.Tags,
-		Meta:    info.Meta,
+		Tags:    tags,
+		Meta:    meta,
 	}
 
 	if sd.config.Discovery.HealthCheck {
@@ -306,9 +318,58 @@ func (sd *ServiceDiscovery) GetHealthyServices(name string) ([]*api.ServiceEntry
 		return nil, fmt.Errorf("failed to get healthy services: %w", err)
 	}
 
+	if len(sd.preferredRegions) > 0 {
+		sortByRegion(services, sd.preferredRegions)
+	}
+
 	return services, nil
 }
 
+// SetRegion sets the region registered services are tagged with
+func (sd *ServiceDiscovery) SetRegion(region string) {
+	sd.region = region
+}
+
+// SetPreferredRegions sets the region order used to sort healthy services
+func (sd *ServiceDiscovery) SetPreferredRegions(regions []string) {
+	sd.preferredRegions = regions
+}
+
+// withRegion returns copies of tags and meta including the region
+func withRegion(region string, tags []string, meta map[string]string) ([]string, map[string]string) {
+	regionTag := "region:" + region
+	newTags := append([]string(nil), tags...)
+	if !slices.Contains(newTags, regionTag) {
+		newTags = append(newTags, regionTag)
+	}
+
+	newMeta := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		newMeta[k] = v
+	}
+	if newMeta["region"] == "" {
+		newMeta["region"] = region
+	}
+
+	return newTags, newMeta
+}
+
+// sortByRegion orders services by region preference, unknown regions last
+func sortByRegion(services []*api.ServiceEntry, regions []string) {
+	rank := func(entry *api.ServiceEntry) int {
+		if entry.Service != nil {
+			if i := slices.Index(regions, entry.Service.Meta["region"]); i >= 0 {
+				return i
+			}
+		}
+		return len(regions)
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		return rank(services[i]) < rank(services[j])
+	})
+}
+
 // SetCacheTTL sets the service cache TTL
 func (sd *ServiceDiscovery) SetCacheTTL(ttl time.Duration) {
 	sd.serviceCache.mu.Lock()
@@ -1,6 +1,8 @@
 package event
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -47,13 +49,18 @@ func (d *Dispatcher) Subscribe(eventName string, handler func(any)) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	wrappedHandler := d.wrapHandler(handler)
+	wrappedHandler := d.wrapHandler(eventName, handler)
 	d.subscribers[eventName] = append(d.subscribers[eventName], wrappedHandler)
 	d.metrics.totalSubscribers.Add(1)
 }
 
 // Publish sends event to all subscribers
 func (d *Dispatcher) Publish(eventName string, data any) {
+	d.PublishContext(context.Background(), eventName, data)
+}
+
+// PublishContext sends event to all subscribers, in the trace of ctx
+func (d *Dispatcher) PublishContext(ctx context.Context, eventName string, data any) {
 	d.mu.RLock()
 	handlers, exists := d.subscribers[eventName]
 	handlerCount := len(handlers)
@@ -76,6 +83,8 @@ func (d *Dispatcher) Publish(eventName string, data any) {
 		EventType: eventName,
 		Data:      data,
 	}
+	_, span := StartPublish(ctx, &eventData, "memory")
+	defer span.End()
 
 	// Execute handlers concurrently
 	for _, handler := range handlers {
@@ -143,18 +152,21 @@ func (d *Dispatcher) recordRecentEvent(eventTime time.Time) {
 	}
 }
 
-// wrapHandler wraps user handler with metrics
-func (d *Dispatcher) wrapHandler(handler func(any)) func(any) {
+// wrapHandler wraps user handler with metrics and a consumer span
+func (d *Dispatcher) wrapHandler(eventName string, handler func(any)) func(any) {
 	return func(data any) {
 		d.metrics.activeHandlers.Add(1)
 		defer d.metrics.activeHandlers.Add(-1)
 
+		_, span := StartConsume(context.Background(), eventName, data, "memory")
 		defer func() {
 			if r := recover(); r != nil {
 				d.metrics.failed.Add(1)
+				EndSpan(span, fmt.Errorf("event handler panic: %v", r))
 				logger.Errorf(nil, "event handler panic: %v", r)
 				return
 			}
+			span.End()
 			d.metrics.delivered.Add(1)
 		}()
 
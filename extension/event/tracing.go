@@ -0,0 +1,79 @@
+package event
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/extension/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ncobase/ncore/extension/event"
+
+// StartPublish starts the producer span of an event and records its trace
+// context in e, so consumers continue the trace. system is the
+// messaging.system attribute, e.g. "memory".
+func StartPublish(ctx context.Context, e *types.EventData, system string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, e.EventType+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttributes(system, e.EventType, "publish")...),
+	)
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		e.Trace = carrier
+	}
+	return ctx, span
+}
+
+// StartConsume starts the consumer span of an event handed to a handler, in
+// the trace of its publisher when data is an EventData carrying one
+func StartConsume(ctx context.Context, eventName string, data any, system string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ContextOf(ctx, data), eventName+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingAttributes(system, eventName, "process")...),
+	)
+}
+
+// ContextOf returns ctx continuing the trace of the publisher of an event,
+// for handlers creating spans of their own:
+//
+//	em.SubscribeEvent("user.created", func(data any) {
+//		ctx := event.ContextOf(context.Background(), data)
+//		...
+//	})
+func ContextOf(ctx context.Context, data any) context.Context {
+	var carrier map[string]string
+	switch e := data.(type) {
+	case types.EventData:
+		carrier = e.Trace
+	case *types.EventData:
+		if e != nil {
+			carrier = e.Trace
+		}
+	}
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// EndSpan records err on span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func messagingAttributes(system, eventName, operation string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", system),
+		attribute.String("messaging.destination.name", eventName),
+		attribute.String("messaging.operation", operation),
+	}
+}
@@ -93,11 +93,13 @@ func (p *ClientPool) Close() {
 	p.connections = make(map[string]*grpc.ClientConn)
 }
 
-// clientUnaryInterceptor provides logging for client unary calls
+// clientUnaryInterceptor provides tracing and logging for client unary calls
 func clientUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, span := startClientSpan(ctx, method)
 	start := time.Now()
 	err := invoker(ctx, method, req, reply, cc, opts...)
 	duration := time.Since(start)
+	endSpan(span, err)
 
 	if err != nil {
 		logger.Errorf(ctx, "gRPC client call failed: %s, duration: %v, error: %v", method, duration, err)
@@ -108,17 +110,20 @@ func clientUnaryInterceptor(ctx context.Context, method string, req, reply any,
 	return err
 }
 
-// clientStreamInterceptor provides logging for client stream calls
+// clientStreamInterceptor provides tracing and logging for client stream calls,
+// the span ends when the stream does
 func clientStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, span := startClientSpan(ctx, method)
 	start := time.Now()
 	stream, err := streamer(ctx, desc, cc, method, opts...)
 	duration := time.Since(start)
 
 	if err != nil {
+		endSpan(span, err)
 		logger.Errorf(ctx, "gRPC client stream failed: %s, duration: %v, error: %v", method, duration, err)
-	} else {
-		logger.Debugf(ctx, "gRPC client stream: %s, duration: %v", method, duration)
+		return stream, err
 	}
+	logger.Debugf(ctx, "gRPC client stream: %s, duration: %v", method, duration)
 
-	return stream, err
+	return &tracedClientStream{ClientStream: stream, span: span}, nil
 }
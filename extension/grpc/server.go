@@ -95,11 +95,13 @@ func (s *Server) GetAddress() string {
 	return s.address
 }
 
-// unaryInterceptor provides logging for unary calls
+// unaryInterceptor provides tracing and logging for unary calls
 func unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
 	start := time.Now()
 	resp, err := handler(ctx, req)
 	duration := time.Since(start)
+	endSpan(span, err)
 
 	if err != nil {
 		logger.Errorf(ctx, "gRPC unary call failed: %s, duration: %v, error: %v", info.FullMethod, duration, err)
@@ -110,11 +112,14 @@ func unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
 	return resp, err
 }
 
-// streamInterceptor provides logging for stream calls
+// streamInterceptor provides tracing and logging for stream calls
 func streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	ss = &tracedServerStream{ServerStream: ss, ctx: ctx}
 	start := time.Now()
 	err := handler(srv, ss)
 	duration := time.Since(start)
+	endSpan(span, err)
 
 	if err != nil {
 		logger.Errorf(ss.Context(), "gRPC stream call failed: %s, duration: %v, error: %v", info.FullMethod, duration, err)
@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/logging/observes/apm"
+)
+
+// initAPM creates the APM agent selected in observes.apm. The agent's package must be
+// imported by the application, extensions need no changes.
+func (m *Manager) initAPM() {
+	conf := m.conf.Load()
+	if conf.Observes == nil || conf.Observes.APM == nil || conf.Observes.APM.Provider == "" {
+		return
+	}
+
+	c := conf.Observes.APM
+	name := c.ServiceName
+	if name == "" {
+		name = conf.AppName
+	}
+
+	agent, err := apm.New(&apm.Options{
+		Type:        apm.Type(c.Provider),
+		Name:        name,
+		Version:     c.ServiceVersion,
+		Environment: c.Environment,
+		ServerURL:   c.ServerURL,
+		LicenseKey:  c.LicenseKey,
+		SecretToken: c.SecretToken,
+		APIKey:      c.APIKey,
+		SampleRate:  c.SampleRate,
+	})
+	if err != nil {
+		logger.Errorf(nil, "Failed to initialize APM agent %s: %v", c.Provider, err)
+		return
+	}
+	m.apm = agent
+}
+
+// traceRequests records each request as an APM transaction named after its route
+// template, e.g. "GET /users/:id"
+func (m *Manager) traceRequests(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched route"
+	}
+
+	ctx, tx := m.apm.StartTransaction(c.Request.Context(), c.Request.Method+" "+route, c.Request)
+	defer tx.End()
+	c.Request = c.Request.WithContext(apm.ContextWithTransaction(ctx, tx))
+
+	c.Next()
+
+	for _, err := range c.Errors {
+		tx.NoticeError(err.Err)
+	}
+	tx.SetStatus(c.Writer.Status())
+}
+
+// shutdownAPM flushes and stops the APM agent
+func (m *Manager) shutdownAPM() {
+	if m.apm == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := m.apm.Shutdown(ctx); err != nil {
+		logger.Warnf(nil, "Failed to shut down APM agent: %v", err)
+	}
+	m.apm = nil
+}
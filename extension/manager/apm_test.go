@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/logging/observes/apm"
+)
+
+// recordingAgent keeps the transactions it started
+type recordingAgent struct {
+	opt      *apm.Options
+	mu       sync.Mutex
+	txs      []*recordingTx
+	shutdown bool
+}
+
+func (a *recordingAgent) StartTransaction(ctx context.Context, name string, _ *http.Request) (context.Context, apm.Transaction) {
+	tx := &recordingTx{name: name}
+	a.mu.Lock()
+	a.txs = append(a.txs, tx)
+	a.mu.Unlock()
+	return ctx, tx
+}
+
+func (a *recordingAgent) Shutdown(context.Context) error {
+	a.shutdown = true
+	return nil
+}
+
+type recordingTx struct {
+	name   string
+	status int
+	errs   []error
+	ended  bool
+}
+
+func (t *recordingTx) SetName(name string)   { t.name = name }
+func (t *recordingTx) SetStatus(code int)    { t.status = code }
+func (t *recordingTx) NoticeError(err error) { t.errs = append(t.errs, err) }
+func (t *recordingTx) End()                  { t.ended = true }
+
+func TestAPMRecordsRouteTransactions(t *testing.T) {
+	agent := &recordingAgent{}
+	apm.RegisterFactory("recording", func(opt *apm.Options) (apm.Agent, error) {
+		agent.opt = opt
+		return agent, nil
+	})
+
+	m := newTestManager(t, nil)
+	conf := m.GetConfig()
+	conf.AppName = "orders"
+	conf.Observes = &config.Observes{APM: &config.APM{Provider: "recording", Environment: "staging"}}
+	m.initAPM()
+	if m.apm != agent || agent.opt.Name != "orders" || agent.opt.Environment != "staging" {
+		t.Fatalf("agent options = %+v, want the app name as service name", agent.opt)
+	}
+
+	ext := &testExtension{name: "orders", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+		r.GET("/orders/:id", func(c *gin.Context) {
+			if apm.TransactionFromContext(c.Request.Context()) == nil {
+				t.Error("handler context carries no transaction")
+			}
+			_ = c.Error(errors.New("order not found"))
+			c.Status(http.StatusNotFound)
+		})
+	}}
+	if err := m.RegisterExtension(ext); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	m.RegisterRoutes(router)
+
+	get(router, "/orders/42")
+	get(router, "/nowhere")
+
+	if len(agent.txs) != 2 {
+		t.Fatalf("recorded %d transactions, want 2", len(agent.txs))
+	}
+	tx := agent.txs[0]
+	if tx.name != "GET /orders/:id" || tx.status != http.StatusNotFound || !tx.ended ||
+		len(tx.errs) != 1 || tx.errs[0].Error() != "order not found" {
+		t.Fatalf("transaction = %+v", tx)
+	}
+	if name := agent.txs[1].name; name != "GET unmatched route" {
+		t.Fatalf("unmatched transaction named %q", name)
+	}
+
+	m.shutdownAPM()
+	if !agent.shutdown || m.apm != nil {
+		t.Fatal("agent was not shut down")
+	}
+}
+
+func TestInitAPMWithUnregisteredProvider(t *testing.T) {
+	m := newTestManager(t, nil)
+	m.GetConfig().Observes = &config.Observes{APM: &config.APM{Provider: "newrelic"}}
+	m.initAPM()
+	if m.apm != nil {
+		t.Fatal("agent created for a provider whose package is not imported")
+	}
+}
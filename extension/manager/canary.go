@@ -0,0 +1,417 @@
+package manager
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/extension/plugin"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// Canary tracks
+const (
+	TrackStable = "stable"
+	TrackCanary = "canary"
+)
+
+// CanaryOptions configures a canary rollout of an extension
+type CanaryOptions struct {
+	Percent     int     // share of requests routed to the canary, 0-100
+	Header      string  // request header forcing a track, "stable" or "canary", default X-Canary
+	ErrorRate   float64 // canary 5xx rate that triggers a rollback, 0 disables
+	MinRequests int64   // canary requests before the error rate is checked, default 100
+}
+
+// CanaryStatus reports a canary rollout
+type CanaryStatus struct {
+	Name           string    `json:"name"`
+	Prefix         string    `json:"prefix"`
+	StableVersion  string    `json:"stable_version"`
+	CanaryVersion  string    `json:"canary_version"`
+	Percent        int       `json:"percent"`
+	Header         string    `json:"header"`
+	ErrorRate      float64   `json:"error_rate_threshold"`
+	StableRequests int64     `json:"stable_requests"`
+	StableErrors   int64     `json:"stable_errors"`
+	CanaryRequests int64     `json:"canary_requests"`
+	CanaryErrors   int64     `json:"canary_errors"`
+	Promoted       bool      `json:"promoted"`
+	StartedAt      time.Time `json:"started_at"`
+}
+
+// canary is a second version of an extension serving part of its routes
+type canary struct {
+	name    string
+	prefix  string
+	opts    CanaryOptions
+	ext     *types.Wrapper
+	engine  *gin.Engine
+	stable  string
+	started time.Time
+
+	percent  atomic.Int32
+	promoted atomic.Bool
+	closing  atomic.Bool
+
+	stableRequests atomic.Int64
+	stableErrors   atomic.Int64
+	canaryRequests atomic.Int64
+	canaryErrors   atomic.Int64
+}
+
+// StartCanary loads the plugin at path as a canary of the loaded extension
+// name and splits the requests under the extension's route prefix between
+// both versions. Services and events keep using the stable version.
+func (m *Manager) StartCanary(name, path string, opts CanaryOptions) error {
+	if m.isBuiltInMode() {
+		return fmt.Errorf("canary rollout requires file plugins")
+	}
+	if opts.Percent < 0 || opts.Percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got %d", opts.Percent)
+	}
+	settings := m.conf.Load().Extension.GetSettings(name)
+	if settings == nil || strings.Trim(settings.RoutePrefix, "/") == "" {
+		return fmt.Errorf("extension %s has no route prefix to split", name)
+	}
+	if opts.Header == "" {
+		opts.Header = "X-Canary"
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 100
+	}
+
+	stable, err := m.GetExtensionByName(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.getCanary(name); err == nil {
+		return fmt.Errorf("extension %s already has a canary", name)
+	}
+
+	// Validate like a hot reload so an untrusted file never serves traffic
+	if m.sandbox != nil {
+		if err := m.sandbox.ValidatePluginPath(path); err != nil {
+			return fmt.Errorf("security validation failed: %v", err)
+		}
+		if err := m.sandbox.ValidatePluginSignature(path); err != nil {
+			return fmt.Errorf("signature validation failed: %v", err)
+		}
+	}
+
+	w, err := plugin.Open(path, m)
+	if err != nil {
+		return fmt.Errorf("failed to load canary of %s: %v", name, err)
+	}
+	if w.Instance.Name() != name {
+		m.cleanupInstance(w.Instance)
+		return fmt.Errorf("canary plugin %s is not a version of %s", w.Instance.Name(), name)
+	}
+
+	return m.addCanary(name, settings.RoutePrefix, stable.Version(), w, opts)
+}
+
+// addCanary routes part of the requests under prefix to the loaded canary w
+func (m *Manager) addCanary(name, prefix, stable string, w *types.Wrapper, opts CanaryOptions) error {
+	// Canary panics are isolated but left to the error rate rollback, not the stable breaker
+	engine := gin.New()
+	w.Instance.RegisterRoutes(engine.Group("", m.routeMiddleware(name, nil)...))
+
+	cn := &canary{
+		name:    name,
+		prefix:  "/" + strings.Trim(prefix, "/"),
+		opts:    opts,
+		ext:     w,
+		engine:  engine,
+		stable:  stable,
+		started: time.Now(),
+	}
+	cn.percent.Store(int32(opts.Percent))
+
+	m.canaryMu.Lock()
+	if _, exists := m.canaries[name]; exists {
+		m.canaryMu.Unlock()
+		m.cleanupInstance(w.Instance)
+		return fmt.Errorf("extension %s already has a canary", name)
+	}
+	m.canaries[name] = cn
+	m.canaryMu.Unlock()
+
+	logger.Infof(nil, "canary %s %s started with %d%% of traffic", name, w.Instance.Version(), opts.Percent)
+	m.publishCanaryEvent(cn, "started", nil)
+	return nil
+}
+
+// SetCanaryPercent changes the share of requests routed to the canary
+func (m *Manager) SetCanaryPercent(name string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got %d", percent)
+	}
+	cn, err := m.getCanary(name)
+	if err != nil {
+		return err
+	}
+	if cn.promoted.Load() {
+		return fmt.Errorf("canary of %s is already promoted", name)
+	}
+	cn.percent.Store(int32(percent))
+	return nil
+}
+
+// PromoteCanary makes the canary the extension's only version. Its routes
+// keep serving all requests under the prefix and the old version is cleaned up.
+func (m *Manager) PromoteCanary(name string) error {
+	cn, err := m.getCanary(name)
+	if err != nil {
+		return err
+	}
+	if !cn.promoted.CompareAndSwap(false, true) {
+		return fmt.Errorf("canary of %s is already promoted", name)
+	}
+	cn.percent.Store(100)
+
+	m.mu.Lock()
+	old := m.extensions[name]
+	m.extensions[name] = cn.ext
+	if old != nil {
+		m.unscheduleTasksLocked(name, old.Instance)
+	}
+	m.mu.Unlock()
+
+	if old != nil {
+		m.cleanupInstance(old.Instance)
+	}
+	m.scheduleTasks(name, cn.ext.Instance)
+	m.autoRegisterExtensionServices(name)
+
+	logger.Infof(nil, "canary %s %s promoted", name, cn.ext.Instance.Version())
+	m.publishCanaryEvent(cn, "promoted", nil)
+	return nil
+}
+
+// RollbackCanary stops routing to the canary and unloads it
+func (m *Manager) RollbackCanary(name, reason string) error {
+	m.canaryMu.Lock()
+	cn, exists := m.canaries[name]
+	if exists && cn.promoted.Load() {
+		m.canaryMu.Unlock()
+		return fmt.Errorf("canary of %s is already promoted", name)
+	}
+	delete(m.canaries, name)
+	m.canaryMu.Unlock()
+	if !exists {
+		return fmt.Errorf("extension %s has no canary", name)
+	}
+
+	m.cleanupInstance(cn.ext.Instance)
+
+	logger.Warnf(nil, "canary %s %s rolled back: %s", name, cn.ext.Instance.Version(), reason)
+	m.publishCanaryEvent(cn, "rolled_back", map[string]any{"reason": reason})
+	return nil
+}
+
+// GetCanaries returns the status of all canary rollouts
+func (m *Manager) GetCanaries() []CanaryStatus {
+	m.canaryMu.RLock()
+	defer m.canaryMu.RUnlock()
+
+	result := make([]CanaryStatus, 0, len(m.canaries))
+	for _, cn := range m.canaries {
+		result = append(result, cn.status())
+	}
+	return result
+}
+
+// GetCanary returns the status of an extension's canary rollout
+func (m *Manager) GetCanary(name string) (*CanaryStatus, error) {
+	cn, err := m.getCanary(name)
+	if err != nil {
+		return nil, err
+	}
+	status := cn.status()
+	return &status, nil
+}
+
+// getCanary returns the canary of an extension
+func (m *Manager) getCanary(name string) (*canary, error) {
+	m.canaryMu.RLock()
+	defer m.canaryMu.RUnlock()
+
+	cn, exists := m.canaries[name]
+	if !exists {
+		return nil, fmt.Errorf("extension %s has no canary", name)
+	}
+	return cn, nil
+}
+
+// routeCanaries is a middleware sending requests under a canary's prefix to
+// the canary or the stable routes and recording the outcome per version
+func (m *Manager) routeCanaries(c *gin.Context) {
+	cn := m.matchCanary(c.Request.URL.Path)
+	if cn == nil {
+		c.Next()
+		return
+	}
+
+	track, version := TrackStable, cn.stable
+	if cn.pick(c) {
+		track, version = TrackCanary, cn.ext.Instance.Version()
+	}
+
+	start := time.Now()
+	if track == TrackCanary {
+		cn.engine.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+
+	status := c.Writer.Status()
+	if m.metricsCollector != nil {
+		m.metricsCollector.ExtensionRequest(cn.name, track, version, status, time.Since(start))
+	}
+	if cn.record(track, status) {
+		go func() {
+			reason := fmt.Sprintf("error rate %.2f exceeded threshold %.2f", cn.errorRate(), cn.opts.ErrorRate)
+			if err := m.RollbackCanary(cn.name, reason); err != nil {
+				logger.Errorf(nil, "automatic rollback of canary %s failed: %v", cn.name, err)
+			}
+		}()
+	}
+}
+
+// matchCanary returns the canary whose prefix contains path
+func (m *Manager) matchCanary(path string) *canary {
+	m.canaryMu.RLock()
+	defer m.canaryMu.RUnlock()
+
+	for _, cn := range m.canaries {
+		if path == cn.prefix || strings.HasPrefix(path, cn.prefix+"/") {
+			return cn
+		}
+	}
+	return nil
+}
+
+// pick reports whether a request goes to the canary
+func (cn *canary) pick(c *gin.Context) bool {
+	if cn.promoted.Load() {
+		return true
+	}
+	switch strings.ToLower(c.GetHeader(cn.opts.Header)) {
+	case TrackCanary, "true", "1":
+		return true
+	case TrackStable, "false", "0":
+		return false
+	}
+	percent := int(cn.percent.Load())
+	return percent > 0 && rand.IntN(100) < percent
+}
+
+// record counts a response and reports whether the canary should be rolled back
+func (cn *canary) record(track string, status int) bool {
+	failed := status >= 500
+	if track == TrackStable {
+		cn.stableRequests.Add(1)
+		if failed {
+			cn.stableErrors.Add(1)
+		}
+		return false
+	}
+
+	requests := cn.canaryRequests.Add(1)
+	if failed {
+		cn.canaryErrors.Add(1)
+	}
+	if cn.opts.ErrorRate <= 0 || cn.promoted.Load() || requests < cn.opts.MinRequests {
+		return false
+	}
+	return cn.errorRate() > cn.opts.ErrorRate && cn.closing.CompareAndSwap(false, true)
+}
+
+// errorRate returns the canary's share of failed requests
+func (cn *canary) errorRate() float64 {
+	requests := cn.canaryRequests.Load()
+	if requests == 0 {
+		return 0
+	}
+	return float64(cn.canaryErrors.Load()) / float64(requests)
+}
+
+// status returns a snapshot of the rollout
+func (cn *canary) status() CanaryStatus {
+	return CanaryStatus{
+		Name:           cn.name,
+		Prefix:         cn.prefix,
+		StableVersion:  cn.stable,
+		CanaryVersion:  cn.ext.Instance.Version(),
+		Percent:        int(cn.percent.Load()),
+		Header:         cn.opts.Header,
+		ErrorRate:      cn.opts.ErrorRate,
+		StableRequests: cn.stableRequests.Load(),
+		StableErrors:   cn.stableErrors.Load(),
+		CanaryRequests: cn.canaryRequests.Load(),
+		CanaryErrors:   cn.canaryErrors.Load(),
+		Promoted:       cn.promoted.Load(),
+		StartedAt:      cn.started,
+	}
+}
+
+// publishCanaryEvent publishes a canary lifecycle change
+func (m *Manager) publishCanaryEvent(cn *canary, status string, extra map[string]any) {
+	eventName := fmt.Sprintf("exts.%s.canary_%s", cn.name, status)
+	eventData := map[string]any{
+		"name":           cn.name,
+		"status":         status,
+		"stable_version": cn.stable,
+		"canary_version": cn.ext.Instance.Version(),
+	}
+	for k, v := range extra {
+		eventData[k] = v
+	}
+
+	m.eventDispatcher.Publish(eventName, eventData)
+
+	if m.isMessagingEnabled() {
+		go func() {
+			m.PublishEvent(eventName, eventData, types.EventTargetQueue)
+		}()
+	}
+}
+
+// removeCanary stops routing to an extension's canary when the extension is
+// unloaded. Caller must not hold canaryMu.
+func (m *Manager) removeCanary(name string) {
+	m.canaryMu.Lock()
+	cn, exists := m.canaries[name]
+	delete(m.canaries, name)
+	m.canaryMu.Unlock()
+
+	if exists && !cn.promoted.Load() {
+		m.cleanupInstance(cn.ext.Instance)
+	}
+}
+
+// cleanupCanaries unloads canaries that were not promoted
+func (m *Manager) cleanupCanaries() {
+	m.canaryMu.Lock()
+	canaries := m.canaries
+	m.canaries = make(map[string]*canary)
+	m.canaryMu.Unlock()
+
+	for _, cn := range canaries {
+		if !cn.promoted.Load() {
+			m.cleanupInstance(cn.ext.Instance)
+		}
+	}
+}
+
+// cleanupInstance runs the cleanup phases of an extension instance
+func (m *Manager) cleanupInstance(ext types.Interface) {
+	_ = m.runStopPhases(ext.Name(), ext)
+}
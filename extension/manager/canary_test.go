@@ -0,0 +1,232 @@
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+// versionRoutes serves the version of an extension, and a failing route
+func versionRoutes(version string) func(r *gin.RouterGroup) {
+	return func(r *gin.RouterGroup) {
+		r.GET("/notes/version", func(c *gin.Context) { c.String(http.StatusOK, version) })
+		r.GET("/notes/fail", func(c *gin.Context) { c.String(http.StatusInternalServerError, version) })
+	}
+}
+
+// startTestCanary serves notes 1.0.0 with a 2.0.0 canary taking percent of the requests
+func startTestCanary(t *testing.T, opts CanaryOptions) (*Manager, *gin.Engine, *testExtension, *testExtension) {
+	t.Helper()
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"notes": {RoutePrefix: "/notes"}},
+	})
+
+	stable := &testExtension{name: "notes", version: "1.0.0", routes: versionRoutes("1.0.0")}
+	if err := m.RegisterExtension(stable); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	m.RegisterRoutes(router)
+
+	next := &testExtension{name: "notes", version: "2.0.0", routes: versionRoutes("2.0.0")}
+	if opts.Header == "" {
+		opts.Header = "X-Canary"
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 100
+	}
+	if err := m.addCanary("notes", "notes/", "1.0.0", &types.Wrapper{Metadata: next.GetMetadata(), Instance: next}, opts); err != nil {
+		t.Fatal(err)
+	}
+	return m, router, stable, next
+}
+
+func get(r http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCanarySplitsTraffic(t *testing.T) {
+	m, router, _, _ := startTestCanary(t, CanaryOptions{Percent: 30})
+
+	const total = 2000
+	canaryHits := 0
+	for range total {
+		if get(router, "/notes/version").Body.String() == "2.0.0" {
+			canaryHits++
+		}
+	}
+	if share := float64(canaryHits) / total; share < 0.25 || share > 0.35 {
+		t.Fatalf("canary served %.2f of requests, want about 0.30", share)
+	}
+
+	// The header forces a track
+	for value, want := range map[string]string{"canary": "2.0.0", "1": "2.0.0", "stable": "1.0.0", "false": "1.0.0"} {
+		for range 20 {
+			if got := get(router, "/notes/version", "X-Canary", value).Body.String(); got != want {
+				t.Fatalf("X-Canary %s served %s, want %s", value, got, want)
+			}
+		}
+	}
+
+	status, err := m.GetCanary("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.StableRequests+status.CanaryRequests != total+80 || status.CanaryRequests != int64(canaryHits)+40 {
+		t.Fatalf("unexpected counts %+v", status)
+	}
+	if status.Prefix != "/notes" || status.StableVersion != "1.0.0" || status.CanaryVersion != "2.0.0" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	for percent, want := range map[int]string{0: "1.0.0", 100: "2.0.0"} {
+		if err := m.SetCanaryPercent("notes", percent); err != nil {
+			t.Fatal(err)
+		}
+		for range 50 {
+			if got := get(router, "/notes/version").Body.String(); got != want {
+				t.Fatalf("at %d%% a request was served by %s", percent, got)
+			}
+		}
+	}
+	if err := m.SetCanaryPercent("notes", 101); err == nil {
+		t.Fatal("expected an error for a percent over 100")
+	}
+
+	// Other routes are untouched
+	if w := get(router, "/notesx"); w.Code != http.StatusNotFound {
+		t.Fatalf("unrelated path status %d", w.Code)
+	}
+}
+
+func TestCanaryRollsBackOnErrorRate(t *testing.T) {
+	m, router, stable, next := startTestCanary(t, CanaryOptions{Percent: 100, ErrorRate: 0.5, MinRequests: 10})
+
+	var rolledBack atomic.Int32
+	m.eventDispatcher.Subscribe("exts.notes.canary_rolled_back", func(any) { rolledBack.Add(1) })
+
+	// Below the minimum number of requests the error rate is not checked
+	for range 9 {
+		get(router, "/notes/fail")
+	}
+	if _, err := m.GetCanary("notes"); err != nil {
+		t.Fatalf("canary rolled back before MinRequests: %v", err)
+	}
+
+	get(router, "/notes/fail")
+	waitFor(t, "the canary rollback", func() bool {
+		_, err := m.GetCanary("notes")
+		return err != nil && rolledBack.Load() == 1
+	})
+
+	if next.cleanups.Load() != 1 || stable.cleanups.Load() != 0 {
+		t.Fatalf("cleanups: canary %d, stable %d", next.cleanups.Load(), stable.cleanups.Load())
+	}
+	for range 20 {
+		if got := get(router, "/notes/version", "X-Canary", "canary").Body.String(); got != "1.0.0" {
+			t.Fatalf("request served by %s after rollback", got)
+		}
+	}
+}
+
+func TestCanaryKeepsHealthyRollout(t *testing.T) {
+	m, router, _, _ := startTestCanary(t, CanaryOptions{Percent: 100, ErrorRate: 0.5, MinRequests: 10})
+
+	for i := range 40 {
+		if i%4 == 0 {
+			get(router, "/notes/fail")
+		} else {
+			get(router, "/notes/version")
+		}
+	}
+	status, err := m.GetCanary("notes")
+	if err != nil {
+		t.Fatalf("a canary under the error rate was rolled back: %v", err)
+	}
+	if status.CanaryRequests != 40 || status.CanaryErrors != 10 {
+		t.Fatalf("unexpected counts %+v", status)
+	}
+}
+
+func TestCanaryPromote(t *testing.T) {
+	m, router, stable, next := startTestCanary(t, CanaryOptions{Percent: 10})
+
+	if err := m.PromoteCanary("notes"); err != nil {
+		t.Fatal(err)
+	}
+	if ext, _ := m.GetExtensionByName("notes"); ext != next {
+		t.Fatal("the promoted canary should replace the stable extension")
+	}
+	if stable.cleanups.Load() != 1 || next.cleanups.Load() != 0 {
+		t.Fatalf("cleanups: stable %d, canary %d", stable.cleanups.Load(), next.cleanups.Load())
+	}
+	for range 20 {
+		if got := get(router, "/notes/version", "X-Canary", "stable").Body.String(); got != "2.0.0" {
+			t.Fatalf("request served by %s after promotion", got)
+		}
+	}
+
+	if err := m.PromoteCanary("notes"); err == nil {
+		t.Fatal("expected an error promoting twice")
+	}
+	if err := m.SetCanaryPercent("notes", 50); err == nil {
+		t.Fatal("expected an error changing the percent after promotion")
+	}
+	if err := m.RollbackCanary("notes", "manual"); err == nil {
+		t.Fatal("expected an error rolling back a promoted canary")
+	}
+}
+
+func TestCanaryAbort(t *testing.T) {
+	m, router, stable, next := startTestCanary(t, CanaryOptions{Percent: 100})
+
+	other := &testExtension{name: "notes", version: "3.0.0"}
+	if err := m.addCanary("notes", "/notes", "1.0.0", &types.Wrapper{Instance: other}, CanaryOptions{}); err == nil {
+		t.Fatal("expected an error adding a second canary")
+	}
+	if other.cleanups.Load() != 1 {
+		t.Fatal("the rejected canary should be cleaned up")
+	}
+	if err := m.RollbackCanary("notes", "manual"); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(router, "/notes/version").Body.String(); got != "1.0.0" {
+		t.Fatalf("request served by %s after abort", got)
+	}
+	if next.cleanups.Load() != 1 || stable.cleanups.Load() != 0 {
+		t.Fatalf("cleanups: canary %d, stable %d", next.cleanups.Load(), stable.cleanups.Load())
+	}
+	if err := m.RollbackCanary("notes", "manual"); err == nil {
+		t.Fatal("expected an error without a canary")
+	}
+	if len(m.GetCanaries()) != 0 {
+		t.Fatal("no canary should be left")
+	}
+}
+
+func TestStartCanaryValidates(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"notes": {RoutePrefix: "/notes"}, "blog": {}},
+	})
+
+	for name, percent := range map[string]int{"notes": 101, "blog": 10, "missing": 10} {
+		if err := m.StartCanary(name, "/tmp/notes.so", CanaryOptions{Percent: percent}); err == nil {
+			t.Errorf("StartCanary(%s, %d%%) should fail", name, percent)
+		}
+	}
+	if err := m.StartCanary("notes", "/tmp/notes.so", CanaryOptions{Percent: -1}); err == nil {
+		t.Error("expected an error for a negative percent")
+	}
+}
@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/oss"
+)
+
+// attachClaimStore offloads large queue payloads to the storage section when
+// data.messaging.claim_check is set
+func (m *Manager) attachClaimStore() {
+	conf := m.conf.Load()
+	if conf.Data == nil || conf.Data.Messaging == nil || conf.Data.Messaging.ClaimCheck == nil {
+		return
+	}
+	if conf.Storage == nil || conf.Storage.Provider == "" {
+		logger.Warnf(nil, "claim check needs a storage provider, large payloads are published as is")
+		return
+	}
+
+	s, err := oss.NewStorage(conf.Storage)
+	if err != nil {
+		logger.Errorf(nil, "claim check storage unavailable, large payloads are published as is: %v", err)
+		return
+	}
+	m.data.SetClaimStore(ossClaimStore{s})
+}
+
+// ossClaimStore implements claimcheck.Store with object storage
+type ossClaimStore struct {
+	s oss.Interface
+}
+
+func (o ossClaimStore) Put(_ context.Context, key string, body []byte) error {
+	_, err := o.s.Put(key, bytes.NewReader(body))
+	return err
+}
+
+func (o ossClaimStore) Get(_ context.Context, key string) ([]byte, error) {
+	r, err := o.s.GetStream(key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"strings"
+
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+const (
+	// configChangedEvent is published on the event bus with the *config.Diff of a reload
+	configChangedEvent = "config.changed"
+	// configReloadFailedEvent is published on the event bus with the error of a rejected reload
+	configReloadFailedEvent = "config.reload_failed"
+)
+
+// ReloadConfig replaces the configuration of the manager, publishes the
+// changed settings as a config.changed event and notifies the extensions
+// whose section changed, see types.ConfigWatcher. Pass it to config.Watch to
+// apply hot reloads:
+//
+//	config.OnReloadError(m.ReloadFailed)
+//	config.Watch(m.ReloadConfig)
+func (m *Manager) ReloadConfig(conf *config.Config) {
+	if conf == nil {
+		return
+	}
+
+	m.mu.Lock()
+	prev := m.conf.Swap(conf)
+	// Sections are keyed by lowercased names once read by viper
+	owners := make(map[string]types.Interface, len(m.extensions))
+	for name, ext := range m.extensions {
+		owners[strings.ToLower(name)] = ext.Instance
+	}
+	m.mu.Unlock()
+
+	if diff := config.Compare(prev, conf); !diff.Empty() {
+		logger.Infof(nil, "config reloaded, changed: %v", diff.Keys())
+		m.eventDispatcher.Publish(configChangedEvent, diff)
+	}
+
+	for _, name := range config.ChangedExtensions(prev, conf) {
+		if m.pm != nil {
+			if section := conf.ExtensionSection(name); section != nil {
+				m.pm.SetPluginConfig(name, section)
+			} else {
+				m.pm.RemovePluginConfig(name)
+			}
+		}
+
+		w, ok := owners[name].(types.ConfigWatcher)
+		if !ok {
+			continue
+		}
+		if err := w.OnConfigChange(conf); err != nil {
+			logger.Errorf(nil, "extension %s rejected config change: %v", name, err)
+			continue
+		}
+		logger.Infof(nil, "extension %s applied config change", name)
+	}
+}
+
+// ReloadFailed logs a rejected reload and publishes its error as a
+// config.reload_failed event, the last known good configuration stays in
+// use. Pass it to config.OnReloadError next to ReloadConfig:
+//
+//	config.OnReloadError(m.ReloadFailed)
+func (m *Manager) ReloadFailed(err error) {
+	if err == nil {
+		return
+	}
+	logger.Errorf(nil, "config reload rejected: %v", err)
+	m.eventDispatcher.Publish(configReloadFailedEvent, err)
+}
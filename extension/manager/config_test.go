@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+func TestReloadConfigPublishesChanges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(content string) *config.Config {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		conf, err := config.LoadConfig(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conf
+	}
+	t.Setenv(config.ProfileEnv, "")
+
+	m := newTestManager(t, nil)
+	m.conf.Store(load("app_name: app\nserver:\n  port: 8000\nauth:\n  jwt:\n    secret: one\n"))
+
+	diffs := make(chan *config.Diff, 4)
+	m.eventDispatcher.Subscribe(configChangedEvent, func(data any) {
+		diffs <- data.(types.EventData).Data.(*config.Diff)
+	})
+
+	next := load("app_name: app\nserver:\n  port: 8100\nauth:\n  jwt:\n    secret: two\n")
+	m.ReloadConfig(next)
+	if m.conf.Load() != next {
+		t.Fatal("ReloadConfig did not replace the configuration")
+	}
+
+	var d *config.Diff
+	select {
+	case d = <-diffs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("changes were not published")
+	}
+	if !slices.Equal(d.Keys(), []string{"auth.jwt.secret", "server.port"}) {
+		t.Fatalf("published changes of %v", d.Keys())
+	}
+	if c := d.Under("auth.jwt.secret")[0]; !c.Masked || c.New != config.Masked {
+		t.Fatalf("secret change was not masked: %+v", c)
+	}
+
+	// An unchanged reload publishes nothing
+	m.ReloadConfig(load("app_name: app\nserver:\n  port: 8100\nauth:\n  jwt:\n    secret: two\n"))
+	time.Sleep(50 * time.Millisecond)
+	if len(diffs) != 0 {
+		t.Fatalf("unchanged reload published %v", (<-diffs).Keys())
+	}
+}
+
+func TestReloadConfigWhileRunning(t *testing.T) {
+	m := newTestManager(t, nil)
+	ext := &testExtension{name: "notes", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+		r.GET("/notes", func(c *gin.Context) { c.Status(http.StatusOK) })
+	}}
+	if err := m.RegisterExtension(ext); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	m.RegisterRoutes(router)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_ = m.startupConfig()
+				_ = m.healthCheckConfig()
+				_ = m.GetConfig().Extension.GetInitTimeout("notes")
+				if w := get(router, "/notes"); w.Code != http.StatusOK {
+					t.Errorf("request during reload got %d", w.Code)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 50 {
+		m.ReloadConfig(&config.Config{AppName: fmt.Sprintf("app-%d", i), Extension: &config.Extension{}})
+	}
+	close(stop)
+	wg.Wait()
+
+	if name := m.GetConfig().AppName; name != "app-49" {
+		t.Fatalf("config after reloads = %s, want app-49", name)
+	}
+}
+
+// watchingExtension records the config changes it is notified of
+type watchingExtension struct {
+	*testExtension
+	changes chan string
+}
+
+func (e *watchingExtension) OnConfigChange(conf *config.Config) error {
+	var section struct {
+		Provider string `yaml:"provider"`
+	}
+	if err := conf.UnmarshalExtension(e.name, &section); err != nil {
+		return err
+	}
+	e.changes <- section.Provider
+	return nil
+}
+
+func TestReloadConfigNotifiesChangedExtensions(t *testing.T) {
+	dir := t.TempDir()
+	load := func(content string) *config.Config {
+		t.Helper()
+		file := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		conf, err := config.LoadConfig(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conf
+	}
+	t.Setenv(config.ProfileEnv, "")
+
+	m := newTestManager(t, nil)
+	m.conf.Store(load("extension:\n  plugin_config:\n    payments:\n      provider: stripe\n    search:\n      engine: meili\n"))
+
+	payments := &watchingExtension{testExtension: &testExtension{name: "payments", version: "1.0.0"}, changes: make(chan string, 2)}
+	search := &watchingExtension{testExtension: &testExtension{name: "search", version: "1.0.0"}, changes: make(chan string, 2)}
+	for _, ext := range []*watchingExtension{payments, search} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.ReloadConfig(load("extension:\n  plugin_config:\n    payments:\n      provider: adyen\n    search:\n      engine: meili\n"))
+
+	if len(payments.changes) != 1 || <-payments.changes != "adyen" {
+		t.Fatal("payments was not notified of its new section")
+	}
+	if len(search.changes) != 0 {
+		t.Fatal("search was notified although its section did not change")
+	}
+}
+
+func TestReloadFailedPublishesError(t *testing.T) {
+	m := newTestManager(t, nil)
+	errs := make(chan error, 2)
+	m.eventDispatcher.Subscribe(configReloadFailedEvent, func(data any) {
+		errs <- data.(types.EventData).Data.(error)
+	})
+
+	rejected := fmt.Errorf("failed to reload config, keeping last known good: %w", os.ErrNotExist)
+	m.ReloadFailed(rejected)
+	select {
+	case err := <-errs:
+		if err != rejected {
+			t.Fatalf("published %v, want the rejected reload", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rejected reload was not published")
+	}
+
+	m.ReloadFailed(nil)
+	time.Sleep(50 * time.Millisecond)
+	if len(errs) != 0 {
+		t.Fatalf("nil error published %v", <-errs)
+	}
+}
@@ -0,0 +1,276 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/sony/gobreaker"
+)
+
+// consumerPollInterval is how often a consumer held by an open circuit breaker rechecks it
+const consumerPollInterval = time.Second
+
+// errConsumerStopped is returned for deliveries reaching a stopped consumer, they are redelivered
+var errConsumerStopped = errors.New("consumer stopped")
+
+// Consumer states
+const (
+	ConsumerPending = "pending"
+	ConsumerRunning = "running"
+	ConsumerPaused  = "paused"
+	ConsumerStopped = "stopped"
+)
+
+// ConsumerStatus describes a message queue consumer owned by an extension
+type ConsumerStatus struct {
+	Extension string `json:"extension"`
+	Queue     string `json:"queue"`
+	State     string `json:"state"`
+	InFlight  int64  `json:"in_flight"`
+	Handled   int64  `json:"handled"`
+}
+
+// consumer is a message queue subscription owned by an extension
+type consumer struct {
+	extension string
+	queue     string
+	handler   func([]byte) error
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	state   string
+	resumed chan struct{} // Closed when a paused consumer resumes or stops
+
+	wg       sync.WaitGroup
+	inFlight atomic.Int64
+	handled  atomic.Int64
+}
+
+// ConsumeMessages subscribes an extension to a queue. The consumer starts once the
+// extension is ready, is held while the extension's circuit breaker is open or the
+// manager drains, and stops before the extension is cleaned up.
+func (m *Manager) ConsumeMessages(extension, queue string, handler func([]byte) error) error {
+	if !m.isMessagingEnabled() {
+		return fmt.Errorf("messaging is disabled")
+	}
+
+	c := &consumer{
+		extension: extension,
+		queue:     queue,
+		handler:   handler,
+		state:     ConsumerPending,
+	}
+
+	m.consumersMu.Lock()
+	m.consumers[extension] = append(m.consumers[extension], c)
+	started := m.consumersStarted[extension]
+	m.consumersMu.Unlock()
+
+	if started {
+		return m.startConsumer(c)
+	}
+	return nil
+}
+
+// startConsumers starts the pending consumers of an extension that became ready
+func (m *Manager) startConsumers(extension string) {
+	m.consumersMu.Lock()
+	m.consumersStarted[extension] = true
+	consumers := append([]*consumer(nil), m.consumers[extension]...)
+	m.consumersMu.Unlock()
+
+	for _, c := range consumers {
+		if err := m.startConsumer(c); err != nil {
+			logger.Errorf(nil, "failed to start consumer of extension %s on %s: %v", extension, c.queue, err)
+		}
+	}
+}
+
+// startConsumer subscribes a pending consumer
+func (m *Manager) startConsumer(c *consumer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != ConsumerPending {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := m.subscribeMessages(ctx, c.queue, m.consumerHandler(c)); err != nil {
+		cancel()
+		return err
+	}
+	c.cancel = cancel
+	c.state = ConsumerRunning
+	return nil
+}
+
+// consumerHandler wraps a consumer's handler to hold deliveries while paused and
+// track the ones in flight
+func (m *Manager) consumerHandler(c *consumer) func([]byte) error {
+	return func(data []byte) error {
+		for {
+			c.mu.Lock()
+			if c.state == ConsumerStopped {
+				c.mu.Unlock()
+				return errConsumerStopped
+			}
+			if c.state == ConsumerRunning && !m.breakerOpen(c.extension) {
+				c.wg.Add(1)
+				c.mu.Unlock()
+				break
+			}
+			resumed := c.resumed
+			c.mu.Unlock()
+
+			select {
+			case <-resumed:
+			case <-time.After(consumerPollInterval):
+			}
+		}
+
+		c.inFlight.Add(1)
+		defer func() {
+			c.inFlight.Add(-1)
+			c.handled.Add(1)
+			c.wg.Done()
+		}()
+		return c.handler(data)
+	}
+}
+
+// breakerOpen reports whether an extension's circuit breaker rejects calls
+func (m *Manager) breakerOpen(extension string) bool {
+	m.mu.RLock()
+	cb := m.circuitBreakers[extension]
+	m.mu.RUnlock()
+	return cb != nil && cb.State() == gobreaker.StateOpen
+}
+
+// PauseConsumers holds new deliveries to an extension's consumers, messages in flight finish
+func (m *Manager) PauseConsumers(extension string) {
+	for _, c := range m.extensionConsumers(extension) {
+		c.pause()
+	}
+}
+
+// ResumeConsumers resumes the paused consumers of an extension
+func (m *Manager) ResumeConsumers(extension string) {
+	for _, c := range m.extensionConsumers(extension) {
+		c.resume()
+	}
+}
+
+// drainConsumers pauses all consumers ahead of shutdown
+func (m *Manager) drainConsumers() {
+	m.consumersMu.Lock()
+	defer m.consumersMu.Unlock()
+	for _, consumers := range m.consumers {
+		for _, c := range consumers {
+			c.pause()
+		}
+	}
+}
+
+// stopConsumers stops an extension's consumers and waits for messages in flight
+// within the extension's stop timeout
+func (m *Manager) stopConsumers(extension string) {
+	m.consumersMu.Lock()
+	consumers := m.consumers[extension]
+	delete(m.consumers, extension)
+	delete(m.consumersStarted, extension)
+	m.consumersMu.Unlock()
+	if len(consumers) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for _, c := range consumers {
+			c.stop()
+		}
+		for _, c := range consumers {
+			c.wg.Wait()
+		}
+		close(done)
+	}()
+
+	timeout := m.conf.Load().Extension.GetStopTimeout(extension)
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warnf(nil, "consumers of extension %s still handling messages after %v", extension, timeout)
+	}
+}
+
+// extensionConsumers returns the consumers of an extension
+func (m *Manager) extensionConsumers(extension string) []*consumer {
+	m.consumersMu.Lock()
+	defer m.consumersMu.Unlock()
+	return append([]*consumer(nil), m.consumers[extension]...)
+}
+
+// GetConsumers returns the message queue consumers owned by extensions
+func (m *Manager) GetConsumers() []ConsumerStatus {
+	m.consumersMu.Lock()
+	defer m.consumersMu.Unlock()
+
+	result := make([]ConsumerStatus, 0, len(m.consumers))
+	for _, consumers := range m.consumers {
+		for _, c := range consumers {
+			c.mu.Lock()
+			state := c.state
+			c.mu.Unlock()
+			result = append(result, ConsumerStatus{
+				Extension: c.extension,
+				Queue:     c.queue,
+				State:     state,
+				InFlight:  c.inFlight.Load(),
+				Handled:   c.handled.Load(),
+			})
+		}
+	}
+	return result
+}
+
+// pause holds new deliveries of a running consumer
+func (c *consumer) pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == ConsumerRunning {
+		c.state = ConsumerPaused
+		c.resumed = make(chan struct{})
+	}
+}
+
+// resume releases the deliveries held by a paused consumer
+func (c *consumer) resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == ConsumerPaused {
+		c.state = ConsumerRunning
+		close(c.resumed)
+		c.resumed = nil
+	}
+}
+
+// stop cancels the subscription, held deliveries are released and redelivered later
+func (c *consumer) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == ConsumerStopped {
+		return
+	}
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+	c.state = ConsumerStopped
+}
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsumersPauseResumeAndStop(t *testing.T) {
+	m := newTestManager(t, nil)
+	if err := m.ConsumeMessages("notes", "notes.events", func([]byte) error { return nil }); err == nil {
+		t.Fatal("ConsumeMessages should fail without messaging")
+	}
+
+	var mu sync.Mutex
+	var handled []string
+	c := &consumer{extension: "notes", queue: "notes.events", state: ConsumerRunning, handler: func(data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, string(data))
+		return nil
+	}}
+	m.consumers["notes"] = []*consumer{c}
+	deliver := m.consumerHandler(c)
+	handledCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled)
+	}
+
+	if err := deliver([]byte("1")); err != nil || handledCount() != 1 {
+		t.Fatalf("deliver = %v, handled %d", err, handledCount())
+	}
+
+	// Paused consumers hold deliveries until resumed
+	m.PauseConsumers("notes")
+	done := make(chan error, 1)
+	go func() { done <- deliver([]byte("2")) }()
+	time.Sleep(50 * time.Millisecond)
+	if n := handledCount(); n != 1 {
+		t.Fatalf("a paused consumer handled a message, handled %d", n)
+	}
+	if status := m.GetConsumers(); len(status) != 1 || status[0].State != ConsumerPaused || status[0].Queue != "notes.events" {
+		t.Fatalf("consumers = %+v", status)
+	}
+	m.ResumeConsumers("notes")
+	if err := <-done; err != nil || handledCount() != 2 {
+		t.Fatalf("held delivery = %v, handled %d", err, handledCount())
+	}
+	if status := m.GetConsumers(); status[0].State != ConsumerRunning || status[0].Handled != 2 || status[0].InFlight != 0 {
+		t.Fatalf("consumers = %+v", status)
+	}
+
+	// Stopping releases held deliveries for redelivery
+	m.PauseConsumers("notes")
+	go func() { done <- deliver([]byte("3")) }()
+	time.Sleep(50 * time.Millisecond)
+	m.stopConsumers("notes")
+	if err := <-done; !errors.Is(err, errConsumerStopped) {
+		t.Fatalf("held delivery after stop = %v, want errConsumerStopped", err)
+	}
+	if n := handledCount(); n != 2 {
+		t.Fatalf("a stopped consumer handled a message, handled %d", n)
+	}
+	if status := m.GetConsumers(); len(status) != 0 {
+		t.Fatalf("consumers after stop = %+v", status)
+	}
+}
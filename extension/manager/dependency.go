@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// checkDependencyVersions validates loaded versions against dependency constraints.
+// Unsatisfied strong constraints fail, weak ones only log a warning.
+// Caller must hold the lock.
+func (m *Manager) checkDependencyVersions() error {
+	names := make([]string, 0, len(m.extensions))
+	for name := range m.extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []string
+	for _, name := range names {
+		for _, req := range collectVersionRequirements(m.extensions[name]) {
+			reason := m.checkVersionRequirement(req)
+			if reason == "" {
+				continue
+			}
+
+			msg := fmt.Sprintf("%s requires %s %s (%s): %s", name, req.Name, req.Version, req.Type, reason)
+			if req.Type == types.WeakDependency {
+				logger.Warnf(nil, "Weak dependency constraint not satisfied: %s", msg)
+				continue
+			}
+			violations = append(violations, msg)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("unsatisfied dependency constraints:\n  - %s", strings.Join(violations, "\n  - "))
+	}
+	return nil
+}
+
+// checkVersionRequirement returns why a requirement is not satisfied, or empty if it is
+func (m *Manager) checkVersionRequirement(req types.DependencyEntry) string {
+	dep, exists := m.extensions[req.Name]
+	if !exists {
+		if req.Type == types.WeakDependency {
+			return ""
+		}
+		return "not loaded"
+	}
+
+	version := dep.Instance.Version()
+	ok, err := types.CheckVersion(version, req.Version)
+	if err != nil {
+		return err.Error()
+	}
+	if !ok {
+		return fmt.Sprintf("found %s", version)
+	}
+	return ""
+}
+
+// collectVersionRequirements gathers constrained dependencies from an extension.
+// Constraints from GetAllDependencies take precedence over Metadata.DependencyVersions.
+func collectVersionRequirements(ext *types.Wrapper) []types.DependencyEntry {
+	all := ext.Instance.GetAllDependencies()
+
+	strong := make(map[string]bool)
+	for _, dep := range ext.Instance.Dependencies() {
+		strong[dep] = true
+	}
+	for _, dep := range types.GetStrongDependencies(all) {
+		strong[dep] = true
+	}
+
+	var reqs []types.DependencyEntry
+	seen := make(map[string]bool)
+	for _, dep := range all {
+		if dep.Version == "" {
+			continue
+		}
+		if dep.Type == "" {
+			dep.Type = types.StrongDependency
+		}
+		reqs = append(reqs, dep)
+		seen[dep.Name] = true
+	}
+
+	depNames := make([]string, 0, len(ext.Metadata.DependencyVersions))
+	for dep := range ext.Metadata.DependencyVersions {
+		depNames = append(depNames, dep)
+	}
+	sort.Strings(depNames)
+
+	for _, dep := range depNames {
+		constraint := ext.Metadata.DependencyVersions[dep]
+		if seen[dep] || constraint == "" {
+			continue
+		}
+		depType := types.WeakDependency
+		if strong[dep] {
+			depType = types.StrongDependency
+		}
+		reqs = append(reqs, types.DependencyEntry{Name: dep, Type: depType, Version: constraint})
+	}
+
+	return reqs
+}
@@ -8,16 +8,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ncobase/ncore/extension/event"
 	"github.com/ncobase/ncore/extension/types"
 	"github.com/ncobase/ncore/logging/logger"
 )
 
 // PublishEvent publishes event
 func (m *Manager) PublishEvent(eventName string, data any, target ...types.EventTarget) {
+	m.PublishEventContext(context.Background(), eventName, data, target...)
+}
+
+// PublishEventContext publishes event in the trace of ctx, consumers continue it
+func (m *Manager) PublishEventContext(ctx context.Context, eventName string, data any, target ...types.EventTarget) {
 	// If messaging is disabled, skip all event publishing
 	if !m.isMessagingEnabled() {
 		return
 	}
+	ctx = context.WithoutCancel(ctx)
 
 	targetFlag := m.determineEventTarget(target...)
 
@@ -27,12 +34,17 @@ func (m *Manager) PublishEvent(eventName string, data any, target ...types.Event
 
 	// Publish to memory dispatcher if memory target is included
 	if targetFlag&types.EventTargetMemory != 0 {
-		m.eventDispatcher.Publish(eventName, data)
+		m.eventDispatcher.PublishContext(ctx, eventName, data)
 	}
 
 	// Publish to message queue async if queue target is included and queue is available
 	if targetFlag&types.EventTargetQueue != 0 && m.isQueueAvailable() {
-		go m.publishToQueue(eventName, data)
+		go m.publishToQueue(ctx, eventName, data)
+	}
+
+	// Replicate to peer regions
+	if m.shouldForwardEvent(eventName) {
+		go m.forwardToRegions(eventName, data)
 	}
 }
 
@@ -58,6 +70,11 @@ func (m *Manager) PublishEventWithRetry(eventName string, data any, maxRetries i
 	if targetFlag&types.EventTargetQueue != 0 && m.isQueueAvailable() {
 		go m.publishToQueueWithRetry(eventName, data, maxRetries)
 	}
+
+	// Replicate to peer regions
+	if m.shouldForwardEvent(eventName) {
+		go m.forwardToRegions(eventName, data)
+	}
 }
 
 // SubscribeEvent subscribes to events
@@ -196,7 +213,7 @@ func (m *Manager) isRegisteredExtension(name string) bool {
 }
 
 // publishToQueue publishes single event to queue
-func (m *Manager) publishToQueue(eventName string, data any) {
+func (m *Manager) publishToQueue(ctx context.Context, eventName string, data any) {
 	if !m.isQueueAvailable() {
 		return
 	}
@@ -207,14 +224,18 @@ func (m *Manager) publishToQueue(eventName string, data any) {
 		EventType: eventName,
 		Data:      data,
 	}
+	_, span := event.StartPublish(ctx, &eventData, "queue")
 
 	jsonData, err := json.Marshal(eventData)
 	if err != nil {
+		event.EndSpan(span, err)
 		logger.Errorf(nil, "Failed to serialize event: %v", err)
 		return
 	}
 
-	if err := m.PublishMessage(eventName, eventName, jsonData); err != nil {
+	err = m.PublishMessage(eventName, eventName, jsonData)
+	event.EndSpan(span, err)
+	if err != nil {
 		logger.Warnf(nil, "Failed to publish event %s to queue: %v", eventName, err)
 	}
 }
@@ -231,9 +252,11 @@ func (m *Manager) publishToQueueWithRetry(eventName string, data any, maxRetries
 		EventType: eventName,
 		Data:      data,
 	}
+	_, span := event.StartPublish(context.Background(), &eventData, "queue")
 
 	jsonData, err := json.Marshal(eventData)
 	if err != nil {
+		event.EndSpan(span, err)
 		logger.Errorf(nil, "Failed to serialize event: %v", err)
 		return
 	}
@@ -247,11 +270,13 @@ func (m *Manager) publishToQueueWithRetry(eventName string, data any, maxRetries
 			time.Sleep(backoff)
 		}
 
-		if err := m.PublishMessage(eventName, eventName, jsonData); err == nil {
+		if err = m.PublishMessage(eventName, eventName, jsonData); err == nil {
+			span.End()
 			return
 		}
 	}
 
+	event.EndSpan(span, err)
 	logger.Warnf(nil, "Failed to publish to queue after %d retries: %s", maxRetries, eventName)
 }
 
@@ -272,6 +297,8 @@ func (m *Manager) subscribeToQueue(eventName string, handler func(any)) {
 			return err
 		}
 
+		_, span := event.StartConsume(context.Background(), eventName, eventData, "queue")
+		defer span.End()
 		handler(eventData)
 		return nil
 	})
@@ -306,8 +333,14 @@ func (m *Manager) PublishMessage(exchange, routingKey string, body []byte) error
 	return nil
 }
 
-// SubscribeToMessages subscribes to messages from available queue system
+// SubscribeToMessages subscribes to messages from available queue system.
+// The subscription is not tied to an extension, see ConsumeMessages.
 func (m *Manager) SubscribeToMessages(queue string, handler func([]byte) error) error {
+	return m.subscribeMessages(context.Background(), queue, handler)
+}
+
+// subscribeMessages subscribes to messages until ctx is done
+func (m *Manager) subscribeMessages(ctx context.Context, queue string, handler func([]byte) error) error {
 	if m.data == nil {
 		return fmt.Errorf("data layer not initialized")
 	}
@@ -317,10 +350,10 @@ func (m *Manager) SubscribeToMessages(queue string, handler func([]byte) error)
 	}
 
 	// Try RabbitMQ first
-	if err := m.data.ConsumeFromRabbitMQ(queue, handler); err != nil {
+	if err := m.data.ConsumeFromRabbitMQContext(ctx, queue, handler); err != nil {
 		// If RabbitMQ fails, try Kafka (using queue as topic and default group)
 		groupID := fmt.Sprintf("ncore-extension-%s", queue)
-		if kafkaErr := m.data.ConsumeFromKafka(context.Background(), queue, groupID, handler); kafkaErr != nil {
+		if kafkaErr := m.data.ConsumeFromKafka(ctx, queue, groupID, handler); kafkaErr != nil {
 			return fmt.Errorf("failed to subscribe to both RabbitMQ (%v) and Kafka (%v)", err, kafkaErr)
 		}
 	}
@@ -15,12 +15,13 @@ type GRPCExtension interface {
 
 // initGRPCSupport initializes gRPC support if enabled
 func (m *Manager) initGRPCSupport() error {
-	if m.conf.GRPC == nil || !m.conf.GRPC.Enabled {
+	conf := m.conf.Load()
+	if conf.GRPC == nil || !conf.GRPC.Enabled {
 		return nil
 	}
 
 	// Create gRPC server
-	address := fmt.Sprintf("%s:%d", m.conf.GRPC.Host, m.conf.GRPC.Port)
+	address := fmt.Sprintf("%s:%d", conf.GRPC.Host, conf.GRPC.Port)
 	server, err := exgrpc.NewServer(address)
 	if err != nil {
 		return fmt.Errorf("failed to create gRPC server: %v", err)
@@ -28,8 +29,8 @@ func (m *Manager) initGRPCSupport() error {
 
 	// Create service registry
 	consulAddr := ""
-	if m.conf.Consul != nil {
-		consulAddr = m.conf.Consul.Address
+	if conf.Consul != nil {
+		consulAddr = conf.Consul.Address
 	}
 
 	registry, err := exgrpc.NewServiceRegistry(consulAddr)
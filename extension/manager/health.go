@@ -0,0 +1,215 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// healthCache caches extension health reports so slow checks never block readers
+type healthCache struct {
+	mu          sync.RWMutex
+	reports     map[string]types.HealthReport
+	selfTests   map[string]types.SelfTestResult
+	lastRefresh time.Time
+	refreshing  atomic.Bool
+}
+
+// newHealthCache creates an empty health cache
+func newHealthCache() *healthCache {
+	return &healthCache{
+		reports:   make(map[string]types.HealthReport),
+		selfTests: make(map[string]types.SelfTestResult),
+	}
+}
+
+// healthCheckConfig returns the health check config with defaults
+func (m *Manager) healthCheckConfig() *config.HealthCheckConfig {
+	conf := m.conf.Load()
+	if conf.Extension.HealthCheck != nil {
+		return conf.Extension.HealthCheck
+	}
+	return &config.HealthCheckConfig{}
+}
+
+// startHealthChecks runs extension health checks periodically in the background
+func (m *Manager) startHealthChecks() {
+	interval := m.healthCheckConfig().GetInterval()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.refreshExtensionHealth()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.refreshExtensionHealth()
+			}
+		}
+	}()
+}
+
+// refreshExtensionHealth checks all extensions concurrently and updates the cache
+func (m *Manager) refreshExtensionHealth() {
+	if !m.health.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	defer m.health.refreshing.Store(false)
+
+	m.mu.RLock()
+	extensions := make(map[string]types.Interface, len(m.extensions))
+	for name, ext := range m.extensions {
+		if lz, ok := m.lazy[name]; ok && !lz.activated.Load() {
+			continue
+		}
+		extensions[name] = ext.Instance
+	}
+	m.mu.RUnlock()
+
+	timeout := m.healthCheckConfig().GetTimeout()
+	reports := make(map[string]types.HealthReport, len(extensions))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, instance := range extensions {
+		wg.Add(1)
+		go func(name string, instance types.Interface) {
+			defer wg.Done()
+			report := m.checkExtensionHealth(name, instance, timeout)
+			mu.Lock()
+			reports[name] = report
+			mu.Unlock()
+		}(name, instance)
+	}
+	wg.Wait()
+
+	m.health.mu.Lock()
+	m.health.reports = reports
+	m.health.lastRefresh = time.Now()
+	m.health.mu.Unlock()
+}
+
+// checkExtensionHealth runs a single extension health check bounded by timeout
+func (m *Manager) checkExtensionHealth(name string, instance types.Interface, timeout time.Duration) types.HealthReport {
+	checker, ok := instance.(types.HealthChecker)
+	if !ok {
+		return statusHealthReport(instance.Status())
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan types.HealthReport, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf(nil, "health check of extension %s panicked: %v", name, r)
+				done <- types.HealthReport{
+					Status: types.HealthStatusUnhealthy,
+					Error:  fmt.Sprintf("health check panic: %v", r),
+				}
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var report types.HealthReport
+	select {
+	case report = <-done:
+	case <-ctx.Done():
+		report = types.HealthReport{
+			Status: types.HealthStatusUnhealthy,
+			Error:  fmt.Sprintf("health check timed out after %v", timeout),
+		}
+	}
+
+	if report.Status == "" {
+		report.Status = types.HealthStatusHealthy
+	}
+	report.Latency = time.Since(start)
+	report.CheckedAt = time.Now()
+	return report
+}
+
+// statusHealthReport derives a health report from an extension status string
+func statusHealthReport(status string) types.HealthReport {
+	report := types.HealthReport{
+		Details:   map[string]any{"extension_status": status},
+		CheckedAt: time.Now(),
+	}
+
+	switch status {
+	case types.StatusActive:
+		report.Status = types.HealthStatusHealthy
+	case types.StatusInitializing, types.StatusMaintenance:
+		report.Status = types.HealthStatusDegraded
+	default:
+		report.Status = types.HealthStatusUnhealthy
+	}
+
+	return report
+}
+
+// GetExtensionHealth returns cached health reports for all extensions.
+// Stale caches trigger a background refresh instead of blocking the caller.
+func (m *Manager) GetExtensionHealth() map[string]types.HealthReport {
+	m.health.mu.RLock()
+	stale := time.Since(m.health.lastRefresh) > m.healthCheckConfig().GetCacheTTL()
+	cached := make(map[string]types.HealthReport, len(m.health.reports))
+	for name, report := range m.health.reports {
+		cached[name] = report
+	}
+	selfTests := make(map[string]types.SelfTestResult, len(m.health.selfTests))
+	for name, result := range m.health.selfTests {
+		selfTests[name] = result
+	}
+	m.health.mu.RUnlock()
+
+	if stale {
+		go m.refreshExtensionHealth()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]types.HealthReport, len(m.extensions))
+	for name, ext := range m.extensions {
+		report, ok := cached[name]
+		if !ok {
+			report = statusHealthReport(ext.Instance.Status())
+		}
+		if st, ok := selfTests[name]; ok {
+			applySelfTest(&report, st)
+		}
+		result[name] = report
+	}
+	for name, f := range m.failed {
+		result[name] = types.HealthReport{
+			Status:    types.HealthStatusUnhealthy,
+			Error:     fmt.Sprintf("%s failed: %v", f.phase, f.err),
+			Details:   failedDetails(f),
+			CheckedAt: f.at,
+		}
+	}
+	return result
+}
+
+// GetExtensionHealthByName returns the cached health report of an extension
+func (m *Manager) GetExtensionHealthByName(name string) (types.HealthReport, error) {
+	report, ok := m.GetExtensionHealth()[name]
+	if !ok {
+		return types.HealthReport{}, fmt.Errorf("extension %s not found", name)
+	}
+	return report, nil
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+// checkedExtension reports its health with check
+type checkedExtension struct {
+	*testExtension
+	check func(ctx context.Context) types.HealthReport
+}
+
+func (e *checkedExtension) Check(ctx context.Context) types.HealthReport { return e.check(ctx) }
+
+func TestExtensionHealthReports(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		HealthCheck: &extconfig.HealthCheckConfig{Timeout: "50ms", CacheTTL: "1h"},
+	})
+
+	for _, ext := range []types.Interface{
+		&checkedExtension{&testExtension{name: "ok"}, func(context.Context) types.HealthReport {
+			return types.HealthReport{Details: map[string]any{"connections": 3}}
+		}},
+		&checkedExtension{&testExtension{name: "degraded"}, func(context.Context) types.HealthReport {
+			return types.HealthReport{Status: types.HealthStatusDegraded, Error: "replica lagging"}
+		}},
+		&checkedExtension{&testExtension{name: "slow"}, func(ctx context.Context) types.HealthReport {
+			<-ctx.Done()
+			return types.HealthReport{Status: types.HealthStatusHealthy}
+		}},
+		&checkedExtension{&testExtension{name: "panics"}, func(context.Context) types.HealthReport {
+			panic("boom")
+		}},
+		&testExtension{name: "plain"},
+	} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.refreshExtensionHealth()
+	reports := m.GetExtensionHealth()
+	for name, want := range map[string]struct{ status, err string }{
+		"ok":       {types.HealthStatusHealthy, ""},
+		"degraded": {types.HealthStatusDegraded, "replica lagging"},
+		"slow":     {types.HealthStatusUnhealthy, "timed out"},
+		"panics":   {types.HealthStatusUnhealthy, "panic"},
+		"plain":    {types.HealthStatusHealthy, ""},
+	} {
+		report, ok := reports[name]
+		if !ok {
+			t.Fatalf("no report of %s in %v", name, reports)
+		}
+		if report.Status != want.status || !strings.Contains(report.Error, want.err) || (want.err == "" && report.Error != "") {
+			t.Errorf("%s: status %q error %q, want %q and %q", name, report.Status, report.Error, want.status, want.err)
+		}
+	}
+	if reports["ok"].Details["connections"] != 3 || reports["ok"].CheckedAt.IsZero() {
+		t.Errorf("unexpected report of ok %+v", reports["ok"])
+	}
+	if reports["plain"].Details["extension_status"] != types.StatusActive {
+		t.Errorf("report of plain should come from its status, got %+v", reports["plain"])
+	}
+
+	if report, err := m.GetExtensionHealthByName("degraded"); err != nil || report.Status != types.HealthStatusDegraded {
+		t.Fatalf("GetExtensionHealthByName = %+v, %v", report, err)
+	}
+	if _, err := m.GetExtensionHealthByName("missing"); err == nil {
+		t.Fatal("GetExtensionHealthByName should fail for an unknown extension")
+	}
+}
@@ -2,13 +2,19 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"github.com/ncobase/ncore/bytespool"
+	"github.com/ncobase/ncore/concurrency/scheduler"
 	"github.com/ncobase/ncore/extension/metrics"
+	"github.com/ncobase/ncore/extension/openapi"
 	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
 	"github.com/ncobase/ncore/net/resp"
 	"github.com/ncobase/ncore/utils"
 
@@ -25,7 +31,7 @@ func (m *Manager) ManageRoutes(r *gin.RouterGroup) {
 	m.setupExtensionRoutes(apiGroup)
 
 	// Plugin management routes - only if hot reload is enabled
-	if m.conf.Extension.HotReload {
+	if m.conf.Load().Extension.HotReload {
 		m.setupPluginRoutes(apiGroup)
 	}
 
@@ -98,6 +104,7 @@ func (m *Manager) setupExtensionRoutes(r *gin.RouterGroup) {
 			resp.Success(c.Writer, map[string]any{
 				"summary":    summary,
 				"extensions": status,
+				"failed":     m.GetFailedExtensions(),
 			})
 		})
 
@@ -121,6 +128,73 @@ func (m *Manager) setupExtensionRoutes(r *gin.RouterGroup) {
 			metadata := m.GetMetadata()
 			resp.Success(c.Writer, metadata)
 		})
+
+		// List the tables and collections owned by extensions
+		extGroup.GET("/schemas", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetSchemas())
+		})
+
+		// Compare the declared schemas with the master database
+		extGroup.GET("/schemas/drift", func(c *gin.Context) {
+			drift, err := m.CheckSchemaDrift(c.Request.Context())
+			if err != nil {
+				resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+				return
+			}
+			resp.Success(c.Writer, drift)
+		})
+
+		// Render the declared schemas as a Mermaid ER diagram
+		extGroup.GET("/schemas/er", func(c *gin.Context) {
+			c.String(http.StatusOK, m.SchemaOverview())
+		})
+
+		// List message queue consumers owned by extensions
+		extGroup.GET("/consumers", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetConsumers())
+		})
+
+		// List scheduled extension tasks
+		extGroup.GET("/tasks", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetScheduledTasks())
+		})
+
+		// Get a scheduled task with its recent runs
+		extGroup.GET("/tasks/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			info, err := m.GetScheduledTask(name)
+			if err != nil {
+				resp.Fail(c.Writer, resp.NotFound(err.Error()))
+				return
+			}
+			history, _ := m.GetTaskHistory(name)
+
+			resp.Success(c.Writer, map[string]any{
+				"task":    info,
+				"history": history,
+			})
+		})
+
+		// Run a scheduled task now
+		extGroup.POST("/tasks/:name/trigger", func(c *gin.Context) {
+			name := c.Param("name")
+			if err := m.TriggerTask(name); err != nil {
+				switch {
+				case errors.Is(err, scheduler.ErrJobNotFound):
+					resp.Fail(c.Writer, resp.NotFound(err.Error()))
+				case errors.Is(err, scheduler.ErrJobRunning):
+					resp.Fail(c.Writer, resp.Conflict(err.Error()))
+				default:
+					resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+				}
+				return
+			}
+
+			resp.Success(c.Writer, map[string]any{
+				"task":    name,
+				"message": "task triggered",
+			})
+		})
 	}
 }
 
@@ -136,7 +210,7 @@ func (m *Manager) setupPluginRoutes(r *gin.RouterGroup) {
 				return
 			}
 
-			fc := m.conf.Extension
+			fc := m.conf.Load().Extension
 			fp := filepath.Join(fc.Path, name+utils.GetPlatformExt())
 
 			if err := m.LoadPlugin(fp); err != nil {
@@ -169,6 +243,74 @@ func (m *Manager) setupPluginRoutes(r *gin.RouterGroup) {
 			})
 		})
 
+		// Canary rollouts
+		pluginGroup.GET("/canary", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetCanaries())
+		})
+
+		pluginGroup.POST("/canary/start", func(c *gin.Context) {
+			name, file := c.Query("name"), c.Query("file")
+			if name == "" || file == "" {
+				resp.Fail(c.Writer, resp.BadRequest("Plugin name and canary file are required"))
+				return
+			}
+			percent, _ := strconv.Atoi(c.DefaultQuery("percent", "10"))
+			errorRate, _ := strconv.ParseFloat(c.DefaultQuery("error_rate", "0"), 64)
+
+			fp := filepath.Join(m.conf.Load().Extension.Path, filepath.Base(file)+utils.GetPlatformExt())
+			opts := CanaryOptions{Percent: percent, Header: c.Query("header"), ErrorRate: errorRate}
+			if err := m.StartCanary(name, fp, opts); err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Failed to start canary of %s: %v", name, err))
+				return
+			}
+
+			status, _ := m.GetCanary(name)
+			resp.Success(c.Writer, status)
+		})
+
+		pluginGroup.POST("/canary/percent", func(c *gin.Context) {
+			name := c.Query("name")
+			percent, err := strconv.Atoi(c.Query("percent"))
+			if name == "" || err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Plugin name and percent are required"))
+				return
+			}
+
+			if err := m.SetCanaryPercent(name, percent); err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Failed to update canary of %s: %v", name, err))
+				return
+			}
+
+			status, _ := m.GetCanary(name)
+			resp.Success(c.Writer, status)
+		})
+
+		pluginGroup.POST("/canary/promote", func(c *gin.Context) {
+			name := c.Query("name")
+			if err := m.PromoteCanary(name); err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Failed to promote canary of %s: %v", name, err))
+				return
+			}
+
+			resp.Success(c.Writer, map[string]any{
+				"message": fmt.Sprintf("Canary of %s promoted", name),
+				"plugin":  name,
+			})
+		})
+
+		pluginGroup.POST("/canary/rollback", func(c *gin.Context) {
+			name := c.Query("name")
+			if err := m.RollbackCanary(name, "manual rollback"); err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Failed to roll back canary of %s: %v", name, err))
+				return
+			}
+
+			resp.Success(c.Writer, map[string]any{
+				"message": fmt.Sprintf("Canary of %s rolled back", name),
+				"plugin":  name,
+			})
+		})
+
 		// Reload plugin
 		pluginGroup.POST("/reload", func(c *gin.Context) {
 			name := c.Query("name")
@@ -260,6 +402,16 @@ func (m *Manager) setupMetricsRoutes(r *gin.RouterGroup) {
 			resp.Success(c.Writer, eventMetrics)
 		})
 
+		// Extension log volume
+		metricsGroup.GET("/logs", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetLogStats())
+		})
+
+		// Buffer pool usage, a growing in_use count points at buffers never returned
+		metricsGroup.GET("/pools", func(c *gin.Context) {
+			resp.Success(c.Writer, bytespool.Stats())
+		})
+
 		// Service discovery metrics
 		metricsGroup.GET("/service-discovery", func(c *gin.Context) {
 			cacheStats := m.GetServiceCacheStats()
@@ -319,7 +471,7 @@ func (m *Manager) setupMetricsRoutes(r *gin.RouterGroup) {
 
 		// Configuration info
 		metricsGroup.GET("/config", func(c *gin.Context) {
-			metricsConfig := m.conf.Extension.Metrics
+			metricsConfig := m.conf.Load().Extension.Metrics
 			configInfo := map[string]any{
 				"enabled":        true,
 				"flush_interval": metricsConfig.FlushInterval,
@@ -377,12 +529,61 @@ func (m *Manager) setupHealthRoutes(r *gin.RouterGroup) {
 				}
 			}
 
+			reports := m.GetExtensionHealth()
+			healthSummary := map[string]int{
+				types.HealthStatusHealthy:   0,
+				types.HealthStatusDegraded:  0,
+				types.HealthStatusUnhealthy: 0,
+			}
+			for _, report := range reports {
+				healthSummary[report.Status]++
+			}
+
 			resp.Success(c.Writer, map[string]any{
 				"summary":    summary,
+				"health":     healthSummary,
 				"extensions": extensionStatus,
+				"reports":    reports,
 			})
 		})
 
+		// Specific extension health
+		healthGroup.GET("/extensions/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			report, err := m.GetExtensionHealthByName(name)
+			if err != nil {
+				resp.Fail(c.Writer, resp.NotFound("Extension '%s' not found", name))
+				return
+			}
+
+			if report.Status == types.HealthStatusUnhealthy {
+				c.Writer.WriteHeader(503)
+			}
+			resp.Success(c.Writer, report)
+		})
+
+		// Readiness, failed self-tests block it when self_test_required is set
+		healthGroup.GET("/ready", func(c *gin.Context) {
+			ready := m.IsReady()
+			if !ready {
+				c.Writer.WriteHeader(503)
+			}
+			resp.Success(c.Writer, map[string]any{
+				"ready":      ready,
+				"self_tests": m.GetSelfTestResults(),
+			})
+		})
+
+		// Extension self-test results
+		healthGroup.GET("/self-tests", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetSelfTestResults())
+		})
+
+		// Rerun extension self-tests
+		healthGroup.POST("/self-tests", func(c *gin.Context) {
+			resp.Success(c.Writer, m.RunSelfTests(c.Request.Context()))
+		})
+
 		// Data layer health
 		healthGroup.GET("/data", func(c *gin.Context) {
 			if m.data == nil {
@@ -406,6 +607,40 @@ func (m *Manager) setupHealthRoutes(r *gin.RouterGroup) {
 			breakerStatus := m.getCircuitBreakerStatus()
 			resp.Success(c.Writer, breakerStatus)
 		})
+
+		// External dependency probes
+		healthGroup.GET("/external", func(c *gin.Context) {
+			statuses := m.GetProbeStatuses()
+			summary := map[string]int{
+				"total":    len(statuses),
+				"up":       0,
+				"degraded": 0,
+				"down":     0,
+				"unknown":  0,
+			}
+
+			for _, status := range statuses {
+				summary[status.State]++
+			}
+
+			resp.Success(c.Writer, map[string]any{
+				"enabled": m.probeScheduler != nil,
+				"summary": summary,
+				"targets": statuses,
+			})
+		})
+
+		// External dependency probe history
+		healthGroup.GET("/external/:name", func(c *gin.Context) {
+			name := c.Param("name")
+			status, err := m.GetProbeStatus(name)
+			if err != nil {
+				resp.Fail(c.Writer, resp.NotFound("Probe '%s' not found", name))
+				return
+			}
+
+			resp.Success(c.Writer, status)
+		})
 	}
 }
 
@@ -415,6 +650,7 @@ func (m *Manager) setupSystemRoutes(r *gin.RouterGroup) {
 	{
 		// System info
 		systemGroup.GET("/info", func(c *gin.Context) {
+			conf := m.conf.Load()
 			startTime := time.Now().Add(-time.Since(time.Now())) // Placeholder - should use actual start time
 
 			info := map[string]any{
@@ -424,19 +660,124 @@ func (m *Manager) setupSystemRoutes(r *gin.RouterGroup) {
 				"extensions": map[string]any{
 					"total":      len(m.extensions),
 					"active":     m.countActiveExtensions(),
-					"hot_reload": m.conf.Extension.HotReload,
+					"hot_reload": conf.Extension.HotReload,
+					"lazy":       m.GetLazyExtensions(),
 				},
 				"features": map[string]any{
 					"metrics_enabled":    m.isMetricsEnabled(),
-					"hot_reload_enabled": m.conf.Extension.HotReload,
-					"grpc_enabled":       m.conf.GRPC != nil && m.conf.GRPC.Enabled,
-					"consul_enabled":     m.conf.Consul != nil,
+					"hot_reload_enabled": conf.Extension.HotReload,
+					"plugin_watcher":     m.IsPluginWatcherRunning(),
+					"grpc_enabled":       conf.GRPC != nil && conf.GRPC.Enabled,
+					"consul_enabled":     conf.Consul != nil,
+					"multi_region":       m.isRegionEnabled(),
 				},
 			}
 
 			resp.Success(c.Writer, info)
 		})
 
+		// OpenAPI document of the registered routes
+		systemGroup.GET("/openapi.json", func(c *gin.Context) {
+			doc, err := m.ExportOpenAPI(openapi.Info{})
+			if err != nil {
+				resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+				return
+			}
+			c.JSON(http.StatusOK, doc)
+		})
+
+		// Route manifest for security reviews and gateway configuration,
+		// as a markdown table with format=markdown
+		systemGroup.GET("/routes", func(c *gin.Context) {
+			manifest, err := m.RouteManifest()
+			if err != nil {
+				resp.Fail(c.Writer, resp.InternalServer(err.Error()))
+				return
+			}
+			if c.Query("format") == "markdown" {
+				c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(manifest.Markdown()))
+				return
+			}
+			c.JSON(http.StatusOK, manifest)
+		})
+
+		// Brokered filesystem usage and audit log
+		systemGroup.GET("/filesystem", func(c *gin.Context) {
+			if m.fileBroker == nil {
+				resp.Fail(c.Writer, resp.NotFound("filesystem broker not enabled"))
+				return
+			}
+
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+			resp.Success(c.Writer, map[string]any{
+				"usage": m.fileBroker.Usage(),
+				"audit": m.fileBroker.AuditLog(c.Query("extension"), limit),
+			})
+		})
+
+		// Multi-region replication status
+		systemGroup.GET("/region", func(c *gin.Context) {
+			resp.Success(c.Writer, m.GetRegionStats())
+		})
+
+		// Log levels of the global and named loggers
+		systemGroup.GET("/log-level", func(c *gin.Context) {
+			resp.Success(c.Writer, logger.Levels())
+		})
+
+		// Shipped, queued and dropped entries of the log sinks
+		systemGroup.GET("/log-sinks", func(c *gin.Context) {
+			resp.Success(c.Writer, logger.GetSinkStats())
+		})
+
+		// Change a log level at runtime, e.g. {"level": "debug", "duration": "15m"};
+		// "logger" selects a named logger, an empty level resets it to the global one
+		systemGroup.PUT("/log-level", func(c *gin.Context) {
+			var req struct {
+				Logger   string `json:"logger"`
+				Level    string `json:"level"`
+				Duration string `json:"duration"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Invalid request body: %v", err))
+				return
+			}
+
+			var err error
+			if req.Level == "" && req.Logger != "" {
+				err = logger.ResetLevel(req.Logger)
+			} else {
+				level, perr := logger.ParseLevel(req.Level)
+				if perr != nil {
+					resp.Fail(c.Writer, resp.BadRequest("Invalid log level: %v", perr))
+					return
+				}
+				var d time.Duration
+				if req.Duration != "" {
+					if d, err = time.ParseDuration(req.Duration); err != nil || d < 0 {
+						resp.Fail(c.Writer, resp.BadRequest("Invalid duration %q", req.Duration))
+						return
+					}
+				}
+				err = logger.SetLevel(req.Logger, level, d)
+			}
+			if errors.Is(err, logger.ErrUnknownLogger) {
+				resp.Fail(c.Writer, resp.NotFound("Logger '%s' not found", req.Logger))
+				return
+			}
+			if err != nil {
+				resp.Fail(c.Writer, resp.InternalServer("Failed to set log level: %v", err))
+				return
+			}
+
+			name := req.Logger
+			if name == "" {
+				name = "global"
+			}
+			logger.Warnf(c.Request.Context(), "Log level of the %s logger set to %q (duration %q)", name, req.Level, req.Duration)
+			resp.Success(c.Writer, logger.Levels())
+		})
+
 		// Cross services management
 		systemGroup.POST("/cross-services/refresh", func(c *gin.Context) {
 			m.refreshCrossServices()
@@ -447,24 +788,25 @@ func (m *Manager) setupSystemRoutes(r *gin.RouterGroup) {
 
 		// Configuration info (non-sensitive parts)
 		systemGroup.GET("/config", func(c *gin.Context) {
+			conf := m.conf.Load()
 			config := map[string]any{
 				"extension": map[string]any{
-					"mode":        m.conf.Extension.Mode,
-					"path":        m.conf.Extension.Path,
-					"hot_reload":  m.conf.Extension.HotReload,
-					"max_plugins": m.conf.Extension.MaxPlugins,
+					"mode":        conf.Extension.Mode,
+					"path":        conf.Extension.Path,
+					"hot_reload":  conf.Extension.HotReload,
+					"max_plugins": conf.Extension.MaxPlugins,
 				},
 				"features": map[string]any{
-					"grpc_enabled":       m.conf.GRPC != nil && m.conf.GRPC.Enabled,
-					"consul_enabled":     m.conf.Consul != nil,
+					"grpc_enabled":       conf.GRPC != nil && conf.GRPC.Enabled,
+					"consul_enabled":     conf.Consul != nil,
 					"metrics_enabled":    m.isMetricsEnabled(),
-					"hot_reload_enabled": m.conf.Extension.HotReload,
+					"hot_reload_enabled": conf.Extension.HotReload,
 				},
 			}
 
 			// Add metrics configuration if enabled
 			if m.isMetricsEnabled() {
-				metricsConfig := m.conf.Extension.Metrics
+				metricsConfig := conf.Extension.Metrics
 				config["metrics"] = map[string]any{
 					"extension": map[string]any{
 						"enabled":        true,
@@ -476,20 +818,23 @@ func (m *Manager) setupSystemRoutes(r *gin.RouterGroup) {
 				}
 			}
 
-			if m.conf.Data != nil && m.conf.Data.Metrics != nil && m.conf.Data.Metrics.Enabled {
+			if conf.Data != nil && conf.Data.Metrics != nil && conf.Data.Metrics.Enabled {
 				if config["metrics"] == nil {
 					config["metrics"] = make(map[string]any)
 				}
 				config["metrics"].(map[string]any)["data"] = map[string]any{
 					"enabled":        true,
-					"storage_type":   m.conf.Data.Metrics.StorageType,
-					"retention_days": m.conf.Data.Metrics.RetentionDays,
-					"batch_size":     m.conf.Data.Metrics.BatchSize,
+					"storage_type":   conf.Data.Metrics.StorageType,
+					"retention_days": conf.Data.Metrics.RetentionDays,
+					"batch_size":     conf.Data.Metrics.BatchSize,
 				}
 			}
 
 			resp.Success(c.Writer, config)
 		})
+
+		// API usage analytics
+		m.setupUsageRoutes(systemGroup)
 	}
 }
 
@@ -592,6 +937,15 @@ func (m *Manager) buildSystemHealth(ctx context.Context) map[string]any {
 		}
 	}
 
+	// External dependency health
+	if m.probeScheduler != nil {
+		components["external"] = m.probeScheduler.Statuses()
+
+		if !m.probeScheduler.IsHealthy() {
+			overallHealthy = false
+		}
+	}
+
 	// Metrics system health
 	metricsComponent := map[string]any{
 		"enabled": m.isMetricsEnabled(),
@@ -602,7 +956,7 @@ func (m *Manager) buildSystemHealth(ctx context.Context) map[string]any {
 		metricsComponent["stats"] = m.GetMetricsStorageStats()
 	} else {
 		metricsComponent["status"] = "disabled"
-		if m.conf.Extension.Metrics != nil {
+		if m.conf.Load().Extension.Metrics != nil {
 			metricsComponent["reason"] = "configured but disabled"
 		} else {
 			metricsComponent["reason"] = "not configured"
@@ -684,32 +1038,63 @@ func (m *Manager) getCircuitBreakerStatus() map[string]any {
 // RegisterRoutes registers all extension routes
 func (m *Manager) RegisterRoutes(router *gin.Engine) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	extensions := make(map[string]*types.Wrapper, len(m.extensions))
+	for name, ext := range m.extensions {
+		extensions[name] = ext
+	}
+	m.mu.RUnlock()
+
+	// Panics outside extension routes and server errors reach Sentry with the request context
+	if m.sentry {
+		router.Use(resp.Recovery())
+	}
+
+	// Spans and APM transactions cover canary and extension routes
+	if m.tracing {
+		router.Use(m.traceSpans)
+	}
+	if m.apm != nil {
+		router.Use(m.traceRequests)
+	}
+
+	// Canary routing runs ahead of the extension routes registered below
+	router.Use(m.routeCanaries)
+
+	m.routesMu.Lock()
+	m.engine = router
+	m.routesMu.Unlock()
+
+	for name, ext := range extensions {
+		if m.isLazyPending(name) {
+			if settings := m.conf.Load().Extension.GetSettings(name); settings.RoutePrefix != "" {
+				m.trackRoutes(router, name, func() gin.HandlersChain {
+					m.registerLazyRoutes(router, name, settings.RoutePrefix)
+					return router.Handlers
+				})
+			} else {
+				logger.Debugf(nil, "Lazy extension %s has no route prefix, skipping route registration", name)
+			}
+			continue
+		}
 
-	for _, ext := range m.extensions {
 		if ext.Instance.GetHandlers() != nil {
-			m.registerExtensionRoutes(router, ext)
+			m.trackRoutes(router, name, func() gin.HandlersChain { return m.registerExtensionRoutes(router, ext) })
 		}
 	}
 }
 
-// registerExtensionRoutes registers routes for a single extension with circuit breaker
-func (m *Manager) registerExtensionRoutes(router *gin.Engine, ext *types.Wrapper) {
+// registerExtensionRoutes registers routes for a single extension with circuit breaker,
+// returning the middleware of its group
+func (m *Manager) registerExtensionRoutes(router *gin.Engine, ext *types.Wrapper) gin.HandlersChain {
 	// Create circuit breaker for this extension
-	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-		Name:        ext.Metadata.Name,
-		MaxRequests: 100,
-		Interval:    5 * time.Second,
-		Timeout:     3 * time.Second,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
-			return counts.Requests >= 3 && failureRatio >= 0.6
-		},
-	})
+	cb := newCircuitBreaker(ext.Metadata.Name)
 
+	m.mu.Lock()
 	m.circuitBreakers[ext.Metadata.Name] = cb
+	m.mu.Unlock()
 
-	// Register extension routes
-	group := router.Group("")
+	// Register extension routes, panics are isolated to the extension
+	group := router.Group("", m.routeMiddleware(ext.Metadata.Name, cb)...)
 	ext.Instance.RegisterRoutes(group)
+	return group.Handlers
 }
@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// lazyExtension tracks the deferred initialization of an extension
+type lazyExtension struct {
+	mu        sync.Mutex // Serializes activation attempts
+	activated atomic.Bool
+}
+
+// splitLazyExtensions separates lazy extensions from the init order.
+// Lazy extensions required by an eager one are initialized eagerly.
+// Caller must hold the lock.
+func (m *Manager) splitLazyExtensions(initOrder []string) []string {
+	lazy := make(map[string]bool)
+	for _, name := range initOrder {
+		if m.conf.Load().Extension.IsLazy(name) {
+			lazy[name] = true
+		}
+	}
+	if len(lazy) == 0 {
+		return initOrder
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, name := range initOrder {
+			if lazy[name] {
+				continue
+			}
+			for _, dep := range strongDependencies(m.extensions[name].Instance) {
+				if lazy[dep] {
+					logger.Infof(nil, "Lazy extension %s is required by %s, initializing eagerly", dep, name)
+					delete(lazy, dep)
+					changed = true
+				}
+			}
+		}
+	}
+
+	eager := make([]string, 0, len(initOrder)-len(lazy))
+	for _, name := range initOrder {
+		if lazy[name] {
+			m.lazy[name] = &lazyExtension{}
+			continue
+		}
+		eager = append(eager, name)
+	}
+
+	if len(lazy) > 0 {
+		logger.Debugf(nil, "Deferred initialization of %d lazy extensions", len(lazy))
+	}
+	return eager
+}
+
+// strongDependencies returns the dependencies an extension cannot run without
+func strongDependencies(ext types.Interface) []string {
+	deps := append([]string(nil), ext.Dependencies()...)
+	return append(deps, types.GetStrongDependencies(ext.GetAllDependencies())...)
+}
+
+// isLazyPending reports whether an extension is lazy and not yet activated
+func (m *Manager) isLazyPending(name string) bool {
+	m.mu.RLock()
+	lz, ok := m.lazy[name]
+	m.mu.RUnlock()
+	return ok && !lz.activated.Load()
+}
+
+// activateLazyExtension initializes a lazy extension once, no-op for eager ones.
+// A failed activation is not remembered, the next call tries again.
+func (m *Manager) activateLazyExtension(name string) error {
+	m.mu.RLock()
+	lz, ok := m.lazy[name]
+	m.mu.RUnlock()
+
+	if !ok || lz.activated.Load() {
+		return nil
+	}
+
+	lz.mu.Lock()
+	defer lz.mu.Unlock()
+	if lz.activated.Load() {
+		return nil
+	}
+	return m.runLazyActivation(name, lz)
+}
+
+// runLazyActivation runs the init phases of a lazy extension and its lazy dependencies
+func (m *Manager) runLazyActivation(name string, lz *lazyExtension) error {
+	conf := m.conf.Load()
+	m.mu.RLock()
+	ext, exists := m.extensions[name]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("extension %s not found", name)
+	}
+
+	for _, dep := range strongDependencies(ext.Instance) {
+		if err := m.activateLazyExtension(dep); err != nil {
+			return fmt.Errorf("failed to activate dependency %s of extension %s: %w", dep, name, err)
+		}
+	}
+
+	m.injectLogger(name, ext.Instance)
+	if err := m.injectFileSystem(name, ext.Instance); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	phases := []struct {
+		name string
+		fn   func() error
+	}{
+		{"PreInit", ext.Instance.PreInit},
+		{"Init", func() error { return ext.Instance.Init(conf, m) }},
+		{"PostInit", ext.Instance.PostInit},
+	}
+
+	timeout := conf.Extension.GetInitTimeout(name)
+	for _, phase := range phases {
+		if err := runPhase(timeout, phase.fn); err != nil {
+			if errors.Is(err, errPhaseTimeout) {
+				m.markFailed(name, phase.name, err)
+			}
+			err = fmt.Errorf("%s of lazy extension %s failed: %w", phase.name, name, err)
+			logger.Errorf(nil, "%v", err)
+			m.trackExtensionLazyActivated(name, time.Since(start), err)
+			return err
+		}
+	}
+
+	duration := time.Since(start)
+	lz.activated.Store(true)
+	m.trackExtensionInitialized(name, duration, nil)
+	m.trackExtensionLazyActivated(name, duration, nil)
+	logger.Infof(nil, "Lazy extension %s activated in %v", name, duration)
+
+	m.autoRegisterExtensionServices(name)
+	m.scheduleTasks(name, ext.Instance)
+	m.publishExtensionReadyEvent(name, ext)
+	m.startConsumers(name)
+	go m.selfTestExtension(name, ext.Instance)
+	return nil
+}
+
+// GetLazyExtensions returns lazy extensions and whether they have been activated
+func (m *Manager) GetLazyExtensions() map[string]bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]bool, len(m.lazy))
+	for name, lz := range m.lazy {
+		result[name] = lz.activated.Load()
+	}
+	return result
+}
+
+// registerLazyRoutes serves a lazy extension's route prefix, activating it on first match.
+// Routes are registered on a dedicated engine once the extension is initialized.
+func (m *Manager) registerLazyRoutes(router *gin.Engine, name, prefix string) {
+	var (
+		once   sync.Once
+		engine *gin.Engine
+	)
+
+	handler := func(c *gin.Context) {
+		if err := m.activateLazyExtension(name); err != nil {
+			resp.Fail(c.Writer, resp.ServiceUnavailable(fmt.Sprintf("extension %s unavailable", name)))
+			c.Abort()
+			return
+		}
+
+		once.Do(func() {
+			engine = gin.New()
+			ext, err := m.GetExtensionByName(name)
+			if err == nil {
+				ext.RegisterRoutes(engine.Group("", m.routeMiddleware(name, m.circuitBreaker(name))...))
+			}
+		})
+
+		engine.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+
+	prefix = "/" + strings.Trim(prefix, "/")
+	router.Any(prefix, handler)
+	router.Any(strings.TrimSuffix(prefix, "/")+"/*path", handler)
+}
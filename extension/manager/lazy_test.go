@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+)
+
+func TestLazyActivationRetriesAfterFailure(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"notes": {RoutePrefix: "/notes", Lazy: true}},
+	})
+
+	ext := &testExtension{name: "notes", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+		r.GET("/notes/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	}}
+	ext.init = func() error {
+		if ext.inits.Load() == 1 {
+			return errors.New("database not ready")
+		}
+		return nil
+	}
+	if err := m.RegisterExtension(ext); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	if eager := m.splitLazyExtensions([]string{"notes"}); len(eager) != 0 {
+		t.Fatalf("eager extensions = %v, want none", eager)
+	}
+	m.mu.Unlock()
+
+	router := gin.New()
+	m.RegisterRoutes(router)
+
+	if w := get(router, "/notes/ping"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request got %d, want 503", w.Code)
+	}
+	if m.GetLazyExtensions()["notes"] {
+		t.Fatal("notes should not be activated after a failed init")
+	}
+
+	// The failure is not remembered, the next request activates the extension
+	for range 2 {
+		if w := get(router, "/notes/ping"); w.Code != http.StatusOK || w.Body.String() != "pong" {
+			t.Fatalf("got %d %q, want 200 pong", w.Code, w.Body.String())
+		}
+	}
+	if !m.GetLazyExtensions()["notes"] {
+		t.Fatal("notes should be activated")
+	}
+	if n := ext.inits.Load(); n != 2 {
+		t.Fatalf("Init ran %d times, want 2", n)
+	}
+}
+
+func TestCleanupSkipsPendingLazyExtensions(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"notes": {Lazy: true}},
+	})
+	notes := &testExtension{name: "notes", version: "1.0.0"}
+	tasks := &testExtension{name: "tasks", version: "1.0.0"}
+	for _, ext := range []*testExtension{notes, tasks} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.mu.Lock()
+	m.initLevels = [][]string{m.splitLazyExtensions([]string{"notes", "tasks"})}
+	m.mu.Unlock()
+
+	if groups := m.shutdownGroups(); len(groups) != 1 || !slices.Equal(groups[0], []string{"tasks"}) {
+		t.Fatalf("shutdown groups = %v, want [[tasks]]", groups)
+	}
+	m.cleanupExtensions()
+	m.cleanupExtension("notes")
+	if n := notes.cleanups.Load(); n != 0 {
+		t.Fatalf("pending lazy extension was cleaned up %d times", n)
+	}
+	if n := tasks.cleanups.Load(); n != 1 {
+		t.Fatalf("eager extension was cleaned up %d times, want 1", n)
+	}
+
+	// Once activated, the lazy extension shuts down before the startup ones
+	if err := m.activateLazyExtension("notes"); err != nil {
+		t.Fatal(err)
+	}
+	if groups := m.shutdownGroups(); len(groups) != 2 || !slices.Equal(groups[0], []string{"notes"}) {
+		t.Fatalf("shutdown groups = %v, want [[notes] [tasks]]", groups)
+	}
+	m.cleanupExtension("notes")
+	if n := notes.cleanups.Load(); n != 1 {
+		t.Fatalf("activated lazy extension was cleaned up %d times, want 1", n)
+	}
+}
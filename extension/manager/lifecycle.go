@@ -48,15 +48,33 @@ func (m *Manager) initExtensionsInternal(ctx context.Context) error {
 		return err
 	}
 
+	if _, err := schemaClaims(m.extensions); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
+	if err := m.checkDependencyVersions(); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+
 	initOrder, err := getInitOrder(m.extensions, dependencyGraph)
 	if err != nil {
 		m.mu.Unlock()
 		return err
 	}
+	initOrder = m.splitLazyExtensions(initOrder)
+	levels := buildInitLevels(initOrder, m.extensions, dependencyGraph)
+	m.initLevels = levels
 	m.mu.Unlock()
 
-	// Initialize extensions in phases
-	if err := m.initializeExtensionsInPhases(ctx, initOrder); err != nil {
+	// Initialize extensions in phases, independent extensions concurrently if enabled
+	if m.startupConfig().Parallel {
+		err = m.initializeExtensionsInParallel(ctx, levels)
+	} else {
+		err = m.initializeExtensionsInPhases(ctx, initOrder)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -69,10 +87,22 @@ func (m *Manager) initExtensionsInternal(ctx context.Context) error {
 	// Publish ready events
 	m.publishReadyEvents()
 
+	// Verify started extensions end to end before reporting ready
+	m.RunSelfTests(m.ctx)
+
 	m.mu.Lock()
 	m.initialized = true
 	m.mu.Unlock()
 
+	// Start periodic extension health checks
+	m.startHealthChecks()
+
+	// Schedule tasks declared by started extensions
+	m.startTasks()
+
+	// Consume events replicated from peer regions
+	m.startRegionReplication()
+
 	return nil
 }
 
@@ -102,19 +132,30 @@ func (m *Manager) initializeExtensionsInPhases(ctx context.Context, initOrder []
 		fn   func(types.Interface) error
 	}{
 		{"PreInit", func(ext types.Interface) error { return ext.PreInit() }},
-		{"Init", func(ext types.Interface) error { return ext.Init(m.conf, m) }},
+		{"Init", func(ext types.Interface) error { return ext.Init(m.conf.Load(), m) }},
 		{"PostInit", func(ext types.Interface) error { return ext.PostInit() }},
 	}
 
+	for _, name := range initOrder {
+		m.injectLogger(name, m.extensions[name].Instance)
+		if err := m.injectFileSystem(name, m.extensions[name].Instance); err != nil {
+			return err
+		}
+	}
+
 	for _, phase := range phases {
 		for _, name := range initOrder {
 			ext := m.extensions[name]
 			start := time.Now()
 
-			if err := phase.fn(ext.Instance); err != nil {
+			ran, err := m.runInitPhase(name, phase.name, phase.fn)
+			if err != nil {
 				logger.Errorf(nil, "Failed %s of extension %s: %v", phase.name, name, err)
 				return fmt.Errorf("%s of extension %s failed: %w", phase.name, name, err)
 			}
+			if !ran {
+				continue
+			}
 
 			duration := time.Since(start)
 			if phase.name == "Init" {
@@ -124,6 +165,7 @@ func (m *Manager) initializeExtensionsInPhases(ctx context.Context, initOrder []
 			// Publish extension ready event after PostInit
 			if phase.name == "PostInit" {
 				m.publishExtensionReadyEvent(name, ext)
+				m.startConsumers(name)
 			}
 		}
 	}
@@ -223,6 +265,9 @@ func (m *Manager) refreshCrossServices() {
 	m.mu.Unlock()
 
 	for name := range m.extensions {
+		if m.isLazyPending(name) {
+			continue
+		}
 		m.autoRegisterExtensionServices(name)
 	}
 }
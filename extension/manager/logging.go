@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// Fields added to every entry of an extension logger
+const (
+	logFieldExtension  = "extension"
+	logFieldVersion    = "extension_version"
+	logFieldInstanceID = "instance_id"
+)
+
+// GetLogger returns the scoped logger of an extension, creating it on first use
+func (m *Manager) GetLogger(extensionName string) *logger.ScopedLogger {
+	return m.scopedLogger(extensionName, "")
+}
+
+// scopedLogger returns the logger of an extension, creating it with the configured level
+func (m *Manager) scopedLogger(name, version string) *logger.ScopedLogger {
+	m.loggersMu.Lock()
+	defer m.loggersMu.Unlock()
+
+	if l, ok := m.loggers[name]; ok {
+		return l
+	}
+
+	fields := map[string]any{
+		logFieldExtension:  name,
+		logFieldInstanceID: m.instanceID,
+	}
+	if version != "" {
+		fields[logFieldVersion] = version
+	}
+	l := logger.NewScoped(fields)
+
+	if settings := m.conf.Load().Extension.GetSettings(name); settings != nil && settings.LogLevel != "" {
+		level, err := logger.ParseLevel(settings.LogLevel)
+		if err != nil {
+			logger.Warnf(nil, "Invalid log level %q for extension %s: %v", settings.LogLevel, name, err)
+		} else {
+			l.SetLevel(level)
+		}
+	}
+
+	m.loggers[name] = l
+	return l
+}
+
+// injectLogger passes the scoped logger to extensions that accept one
+func (m *Manager) injectLogger(name string, ext types.Interface) {
+	if aware, ok := ext.(types.LoggerAware); ok {
+		aware.SetLogger(m.scopedLogger(name, ext.Version()))
+	}
+}
+
+// removeLogger drops the logger of an unloaded extension so a reload picks up its new version
+func (m *Manager) removeLogger(name string) {
+	m.loggersMu.Lock()
+	delete(m.loggers, name)
+	m.loggersMu.Unlock()
+}
+
+// GetLogStats returns the effective level and log entry counts per extension
+func (m *Manager) GetLogStats() map[string]any {
+	m.loggersMu.Lock()
+	defer m.loggersMu.Unlock()
+
+	stats := make(map[string]any, len(m.loggers))
+	for name, l := range m.loggers {
+		stats[name] = map[string]any{
+			"level":  l.GetLevel().String(),
+			"counts": l.Counts(),
+		}
+	}
+	return stats
+}
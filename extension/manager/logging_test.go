@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExtensionLoggers(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{
+			"notes": {LogLevel: "debug"},
+			"blog":  {LogLevel: "loud"},
+		},
+	})
+
+	notes := m.GetLogger("notes")
+	if notes != m.GetLogger("notes") {
+		t.Fatal("GetLogger should return the same logger for an extension")
+	}
+	if notes.GetLevel() != logrus.DebugLevel {
+		t.Fatalf("level of notes = %v, want the configured debug", notes.GetLevel())
+	}
+	if f := notes.Fields(); f[logFieldExtension] != "notes" || f[logFieldInstanceID] != "test" {
+		t.Fatalf("fields of notes = %v", f)
+	}
+
+	// An invalid level keeps the global one
+	if blog := m.GetLogger("blog"); blog.GetLevel() != m.GetLogger("other").GetLevel() {
+		t.Fatalf("level of blog = %v, want the global level", blog.GetLevel())
+	}
+
+	notes.Debugf(nil, "loaded %d notes", 3)
+	stats, ok := m.GetLogStats()["notes"].(map[string]any)
+	if !ok || stats["level"] != "debug" || stats["counts"].(map[string]int64)["debug"] != 1 {
+		t.Fatalf("log stats of notes = %v", stats)
+	}
+
+	// Unloading drops the logger, a reload gets a fresh one with its version
+	m.removeLogger("notes")
+	ext := &testExtension{name: "notes", version: "2.0.0"}
+	if l := m.scopedLogger(ext.Name(), ext.Version()); l == notes || l.Fields()[logFieldVersion] != "2.0.0" {
+		t.Fatalf("logger after reload = %v", l.Fields())
+	}
+}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/concurrency/scheduler"
+	"github.com/ncobase/ncore/data/maintenance"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// maintenanceJobName returns the scheduler job name of a maintenance task
+func maintenanceJobName(task string) string {
+	return "maintenance." + task
+}
+
+// scheduleMaintenance registers the data layer maintenance tasks configured
+// under data.maintenance, run on the elected node
+func (m *Manager) scheduleMaintenance(s *scheduler.Scheduler) {
+	conf := m.conf.Load()
+	if m.data == nil || conf.Data == nil || conf.Data.Maintenance == nil {
+		return
+	}
+
+	src := maintenance.Sources{
+		DB: m.data.GetMasterDB(),
+		OnReport: func(r maintenance.MemoryReport) {
+			logger.Infof(nil, "redis memory: used %d of %d bytes, fragmentation %.2f, largest keys %v",
+				r.Used, r.Max, r.Fragmentation, r.Largest)
+		},
+	}
+	if db := conf.Data.Database; db != nil && db.Master != nil {
+		src.Driver = db.Master.Driver
+	}
+	if rc, ok := m.data.GetRedis().(*redis.Client); ok && rc != nil {
+		src.Redis = redisInspector{rc}
+	}
+	for _, engine := range []any{m.data.GetElasticsearch(), m.data.GetOpenSearch()} {
+		if o, ok := engine.(maintenance.IndexOptimizer); ok {
+			src.Search = append(src.Search, o)
+		}
+	}
+
+	m.mu.RLock()
+	elected := m.taskLocker != nil
+	m.mu.RUnlock()
+	if !elected {
+		logger.Warnf(nil, "no task locker, maintenance tasks run on every node")
+	}
+
+	for _, task := range maintenance.Tasks(conf.Data.Maintenance, src) {
+		job := scheduler.Job{
+			Name:      maintenanceJobName(task.Name),
+			Spec:      task.Schedule,
+			Func:      task.Run,
+			Timeout:   task.Timeout,
+			Singleton: elected,
+		}
+		if err := s.Add(job); err != nil {
+			logger.Errorf(nil, "failed to schedule maintenance task %s: %v", task.Name, err)
+		}
+	}
+}
+
+// redisInspector implements maintenance.MemoryInspector with a Redis client
+type redisInspector struct {
+	client *redis.Client
+}
+
+func (r redisInspector) MemoryInfo(ctx context.Context) (map[string]string, error) {
+	info, err := r.client.InfoMap(ctx, "memory").Result()
+	if err != nil {
+		return nil, err
+	}
+	return info["Memory"], nil
+}
+
+func (r redisInspector) SampleKeys(ctx context.Context, n int) ([]string, error) {
+	var (
+		keys   []string
+		cursor uint64
+	)
+	for len(keys) < n {
+		batch, next, err := r.client.Scan(ctx, cursor, "", int64(n-len(keys))).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys, nil
+}
+
+func (r redisInspector) KeyMemory(ctx context.Context, key string) (int64, error) {
+	return r.client.MemoryUsage(ctx, key).Result()
+}
@@ -4,18 +4,26 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/concurrency/scheduler"
 	"github.com/ncobase/ncore/config"
 	"github.com/ncobase/ncore/data"
+	"github.com/ncobase/ncore/data/lock"
 	"github.com/ncobase/ncore/extension/discovery"
 	"github.com/ncobase/ncore/extension/event"
 	"github.com/ncobase/ncore/extension/grpc"
 	"github.com/ncobase/ncore/extension/metrics"
 	"github.com/ncobase/ncore/extension/plugin"
+	"github.com/ncobase/ncore/extension/probe"
 	"github.com/ncobase/ncore/extension/security"
 	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/extension/usage"
 	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/logging/observes/apm"
+	"github.com/ncobase/ncore/utils/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/sony/gobreaker"
 )
@@ -24,7 +32,7 @@ import (
 type Manager struct {
 	// Core components
 	extensions  map[string]*types.Wrapper
-	conf        *config.Config
+	conf        atomic.Pointer[config.Config] // Replaced by ReloadConfig
 	mu          sync.RWMutex
 	initialized bool
 	ctx         context.Context
@@ -42,10 +50,63 @@ type Manager struct {
 	// Metrics system
 	metricsCollector *metrics.Collector
 
+	// Extension health reports
+	health *healthCache
+
+	// Lazy extensions pending or activated on first use
+	lazy map[string]*lazyExtension
+
+	// Dependency levels of eagerly initialized extensions
+	initLevels [][]string
+
+	// Extensions taken out of service by a lifecycle watchdog
+	failed map[string]*failedExtension
+
+	// Scheduled tasks declared by extensions
+	tasks      *scheduler.Scheduler
+	taskLocker lock.Locker
+
+	// Message queue consumers owned by extensions
+	consumers        map[string][]*consumer
+	consumersStarted map[string]bool
+	consumersMu      sync.Mutex
+
+	// Multi-region replication
+	region *regionReplicator
+
+	// APM agent recording requests as transactions
+	apm apm.Agent
+
+	// Whether an OpenTelemetry tracer provider was set up
+	tracing bool
+
+	// Whether server errors and panics are reported to Sentry
+	sentry bool
+
+	// Canary rollouts by extension name
+	canaries map[string]*canary
+	canaryMu sync.RWMutex
+
+	// Router of RegisterRoutes, the extension owning each route and the
+	// middleware it was registered with
+	engine      *gin.Engine
+	routeOwners map[string]string
+	routeChains map[string][]string
+	routesMu    sync.RWMutex
+
+	// Scoped extension loggers
+	instanceID string
+	loggers    map[string]*logger.ScopedLogger
+	loggersMu  sync.Mutex
+
 	// Optional components
 	sandbox         *security.Sandbox
+	fileBroker      *security.FileBroker
 	resourceMonitor *security.ResourceMonitor
 	pm              *plugin.Manager
+	watcher         *pluginWatcher
+	probeScheduler  *probe.Scheduler
+	usage           *usage.Recorder
 }
 
 // NewManager creates a new extension manager
@@ -53,14 +114,23 @@ func NewManager(conf *config.Config) (*Manager, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := &Manager{
-		extensions:      make(map[string]*types.Wrapper),
-		conf:            conf,
-		eventDispatcher: event.NewEventDispatcher(),
-		circuitBreakers: make(map[string]*gobreaker.CircuitBreaker),
-		crossServices:   make(map[string]any),
-		ctx:             ctx,
-		cancel:          cancel,
-	}
+		extensions:       make(map[string]*types.Wrapper),
+		eventDispatcher:  event.NewEventDispatcher(),
+		circuitBreakers:  make(map[string]*gobreaker.CircuitBreaker),
+		crossServices:    make(map[string]any),
+		health:           newHealthCache(),
+		lazy:             make(map[string]*lazyExtension),
+		failed:           make(map[string]*failedExtension),
+		region:           &regionReplicator{},
+		canaries:         make(map[string]*canary),
+		consumers:        make(map[string][]*consumer),
+		consumersStarted: make(map[string]bool),
+		instanceID:       uuid.New().String(),
+		loggers:          make(map[string]*logger.ScopedLogger),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+	m.conf.Store(conf)
 
 	if err := m.initSubsystems(); err != nil {
 		cancel()
@@ -73,7 +143,7 @@ func NewManager(conf *config.Config) (*Manager, error) {
 // initSubsystems initializes all manager subsystems
 func (m *Manager) initSubsystems() error {
 	// Initialize metrics system first
-	m.metricsCollector = metrics.NewCollector(m.conf.Extension.Metrics)
+	m.metricsCollector = metrics.NewCollector(m.conf.Load().Extension.Metrics)
 
 	// Initialize data layer with retry
 	if err := m.initDataLayerWithRetry(); err != nil {
@@ -95,9 +165,10 @@ func (m *Manager) initDataLayerWithRetry() error {
 	baseDelay := 2 * time.Second
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		d, _, err := data.New(m.conf.Data)
+		d, _, err := data.New(m.conf.Load().Data)
 		if err == nil {
 			m.data = d
+			m.attachClaimStore()
 			m.upgradeMetricsStorageIfAvailable()
 			return nil
 		}
@@ -119,7 +190,7 @@ func (m *Manager) upgradeMetricsStorageIfAvailable() {
 		return
 	}
 
-	metricsConfig := m.conf.Extension.Metrics
+	metricsConfig := m.conf.Load().Extension.Metrics
 	if metricsConfig == nil || metricsConfig.Storage == nil {
 		return
 	}
@@ -168,38 +239,54 @@ func (m *Manager) upgradeMetricsStorageIfAvailable() {
 
 // initServiceDiscovery initializes service discovery
 func (m *Manager) initServiceDiscovery() error {
-	if m.conf.Consul == nil {
+	conf := m.conf.Load()
+	if conf.Consul == nil {
 		return nil
 	}
 
 	consulConfig := &discovery.ConsulConfig{
-		Address: m.conf.Consul.Address,
-		Scheme:  m.conf.Consul.Scheme,
+		Address: conf.Consul.Address,
+		Scheme:  conf.Consul.Scheme,
 		Discovery: struct {
 			HealthCheck   bool
 			CheckInterval string
 			Timeout       string
 		}{
-			HealthCheck:   m.conf.Consul.Discovery.HealthCheck,
-			CheckInterval: m.conf.Consul.Discovery.CheckInterval,
-			Timeout:       m.conf.Consul.Discovery.Timeout,
+			HealthCheck:   conf.Consul.Discovery.HealthCheck,
+			CheckInterval: conf.Consul.Discovery.CheckInterval,
+			Timeout:       conf.Consul.Discovery.Timeout,
 		},
 	}
 
 	var err error
 	m.serviceDiscovery, err = discovery.NewServiceDiscovery(consulConfig)
-	return err
+	if err != nil || m.serviceDiscovery == nil {
+		return err
+	}
+
+	if region := conf.Extension.Region; region.IsEnabled() {
+		m.serviceDiscovery.SetRegion(region.Name)
+		if region.PreferLocal {
+			m.serviceDiscovery.SetPreferredRegions(append([]string{region.Name}, region.Peers...))
+		}
+	}
+	return nil
 }
 
 // initOptionalComponents initializes optional components
 func (m *Manager) initOptionalComponents() error {
-	extConf := m.conf.Extension
+	extConf := m.conf.Load().Extension
 
 	// Initialize security sandbox
 	if extConf.Security != nil && extConf.Security.EnableSandbox {
 		m.sandbox = security.NewSandbox(extConf.Security)
 	}
 
+	// Initialize brokered filesystem
+	if extConf.Security != nil && extConf.Security.FileSystem != nil && extConf.Security.FileSystem.Enabled {
+		m.fileBroker = security.NewFileBroker(extConf.Security.FileSystem)
+	}
+
 	// Initialize resource monitor
 	if extConf.Performance != nil {
 		m.resourceMonitor = security.NewResourceMonitor(extConf.Performance)
@@ -207,6 +294,22 @@ func (m *Manager) initOptionalComponents() error {
 
 	// Initialize plugin manager
 	m.pm = plugin.NewManager(extConf)
+
+	// Initialize APM agent, OpenTelemetry tracing and error reporting
+	m.initAPM()
+	m.initTracer()
+	m.initSentry()
+
+	// Initialize external dependency probes
+	if extConf.Probes != nil && extConf.Probes.Enabled && len(extConf.Probes.Targets) > 0 {
+		m.probeScheduler = probe.NewScheduler(extConf.Probes)
+		m.probeScheduler.OnStateChange(m.publishProbeStateEvent)
+		m.probeScheduler.Start(m.ctx)
+	}
+
+	// Initialize API usage analytics
+	m.initUsage()
+
 	return nil
 }
 
@@ -214,7 +317,7 @@ func (m *Manager) initOptionalComponents() error {
 
 // GetConfig returns the manager's config
 func (m *Manager) GetConfig() *config.Config {
-	return m.conf
+	return m.conf.Load()
 }
 
 // RegisterExtension registers an extension
@@ -230,6 +333,9 @@ func (m *Manager) RegisterExtension(ext types.Interface) error {
 	if _, exists := m.extensions[name]; exists {
 		return fmt.Errorf("extension %s already registered", name)
 	}
+	if err := m.checkSchemaClaimLocked(name, ext.GetMetadata().Schema); err != nil {
+		return err
+	}
 
 	m.extensions[name] = &types.Wrapper{
 		Metadata: ext.GetMetadata(),
@@ -266,13 +372,17 @@ func (m *Manager) ListExtensions() map[string]*types.Wrapper {
 // GetHandlerByName returns a specific handler from an extension
 func (m *Manager) GetHandlerByName(name string) (types.Handler, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	ext, exists := m.extensions[name]
+	m.mu.RUnlock()
+
 	if !exists {
 		return nil, fmt.Errorf("extension %s not found", name)
 	}
 
+	if err := m.activateLazyExtension(name); err != nil {
+		return nil, err
+	}
+
 	handler := ext.Instance.GetHandlers()
 	if handler == nil {
 		return nil, fmt.Errorf("no handler found in extension %s", name)
@@ -288,6 +398,9 @@ func (m *Manager) ListHandlers() map[string]types.Handler {
 
 	handlers := make(map[string]types.Handler)
 	for name, ext := range m.extensions {
+		if lz, ok := m.lazy[name]; ok && !lz.activated.Load() {
+			continue
+		}
 		if handler := ext.Instance.GetHandlers(); handler != nil {
 			handlers[name] = handler
 		}
@@ -305,6 +418,10 @@ func (m *Manager) GetServiceByName(extensionName string) (types.Service, error)
 		return nil, fmt.Errorf("extension %s not found", extensionName)
 	}
 
+	if err := m.activateLazyExtension(extensionName); err != nil {
+		return nil, err
+	}
+
 	service := ext.Instance.GetServices()
 	if service == nil {
 		return nil, fmt.Errorf("no service found in extension %s", extensionName)
@@ -320,6 +437,9 @@ func (m *Manager) ListServices() map[string]types.Service {
 
 	services := make(map[string]types.Service)
 	for name, ext := range m.extensions {
+		if lz, ok := m.lazy[name]; ok && !lz.activated.Load() {
+			continue
+		}
 		if service := ext.Instance.GetServices(); service != nil {
 			services[name] = service
 		}
@@ -348,9 +468,28 @@ func (m *Manager) GetStatus() map[string]string {
 	for name, ext := range m.extensions {
 		status[name] = ext.Instance.Status()
 	}
+	for name := range m.failed {
+		status[name] = types.StatusError
+	}
 	return status
 }
 
+// injectFileSystem passes an extension its own brokered filesystem, so no
+// extension can reach the directory of another
+func (m *Manager) injectFileSystem(name string, ext types.Interface) error {
+	aware, ok := ext.(types.FileSystemAware)
+	if !ok || m.fileBroker == nil {
+		return nil
+	}
+
+	efs, err := m.fileBroker.ForExtension(name)
+	if err != nil {
+		return fmt.Errorf("failed to open filesystem of extension %s: %v", name, err)
+	}
+	aware.SetFileSystem(efs)
+	return nil
+}
+
 // GetData returns the data layer instance
 func (m *Manager) GetData() *data.Data {
 	return m.data
@@ -391,9 +530,16 @@ func (m *Manager) Cleanup() {
 	m.extensions = make(map[string]*types.Wrapper)
 	m.circuitBreakers = make(map[string]*gobreaker.CircuitBreaker)
 	m.crossServices = make(map[string]any)
+	m.lazy = make(map[string]*lazyExtension)
+	m.initLevels = nil
 	m.initialized = false
 	m.mu.Unlock()
 
+	m.consumersMu.Lock()
+	m.consumers = make(map[string][]*consumer)
+	m.consumersStarted = make(map[string]bool)
+	m.consumersMu.Unlock()
+
 	if m.data != nil {
 		if errs := m.data.Close(); len(errs) > 0 {
 			logger.Errorf(nil, "errors closing data connections: %v", errs)
@@ -403,8 +549,27 @@ func (m *Manager) Cleanup() {
 
 // cleanupSubsystems cleans up all subsystems
 func (m *Manager) cleanupSubsystems() {
+	// Stop plugin watcher before extensions go away
+	m.StopPluginWatcher()
+
+	// Stop external dependency probes
+	if m.probeScheduler != nil {
+		m.probeScheduler.Stop()
+	}
+
+	// Hold new messages while extensions shut down
+	m.drainConsumers()
+
+	// Stop scheduled tasks before their extensions go away
+	m.stopTasks()
+
 	// Cleanup extensions first
+	m.cleanupCanaries()
 	m.cleanupExtensions()
+	m.cleanupFailedExtensions()
+
+	// Write pending API usage
+	m.stopUsage()
 
 	// Stop gRPC server before closing registry
 	if m.grpcServer != nil {
@@ -418,11 +583,21 @@ func (m *Manager) cleanupSubsystems() {
 		m.grpcRegistry = nil
 	}
 
+	// Release brokered extension directories
+	if m.fileBroker != nil {
+		m.fileBroker.Close()
+	}
+
 	// Clear service discovery cache
 	if m.serviceDiscovery != nil {
 		m.serviceDiscovery.ClearCache()
 	}
 
+	// Flush transactions and spans of the last requests
+	m.shutdownAPM()
+	m.shutdownTracer()
+	m.shutdownSentry()
+
 	// Cleanup optional components
 	if m.resourceMonitor != nil && m.pm != nil {
 		for pluginName := range m.extensions {
@@ -432,31 +607,55 @@ func (m *Manager) cleanupSubsystems() {
 	}
 }
 
-// cleanupExtensions cleans up all loaded extensions
+// cleanupExtensions cleans up all loaded extensions in reverse dependency order
 func (m *Manager) cleanupExtensions() {
-	m.mu.RLock()
-	extensions := make(map[string]*types.Wrapper)
-	for name, ext := range m.extensions {
-		extensions[name] = ext
-	}
-	m.mu.RUnlock()
+	cfg := m.startupConfig()
 
-	for _, ext := range extensions {
-		if err := ext.Instance.PreCleanup(); err != nil {
-			logger.Errorf(nil, "failed pre-cleanup of extension %s: %v", ext.Metadata.Name, err)
+	for _, group := range m.shutdownGroups() {
+		if !cfg.Parallel {
+			for _, name := range group {
+				m.cleanupExtension(name)
+			}
+			continue
 		}
-		if err := ext.Instance.Cleanup(); err != nil {
-			logger.Errorf(nil, "failed to cleanup extension %s: %v", ext.Metadata.Name, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.GetPhaseTimeout())
+		err := m.runParallel(ctx, group, cfg.GetConcurrency(), func(name string) error {
+			m.cleanupExtension(name)
+			return nil
+		})
+		cancel()
+		if err != nil {
+			logger.Errorf(nil, "failed to cleanup extensions: %v", err)
 		}
+	}
+}
 
-		// Track extension unloading
-		m.trackExtensionUnloaded(ext.Metadata.Name)
+// cleanupExtension cleans up a single extension, lazy extensions never activated are skipped
+func (m *Manager) cleanupExtension(name string) {
+	if m.isLazyPending(name) {
+		return
+	}
 
-		// Deregister from service discovery
-		if m.serviceDiscovery != nil && ext.Instance.NeedServiceDiscovery() {
-			if err := m.serviceDiscovery.DeregisterService(ext.Metadata.Name); err != nil {
-				logger.Errorf(nil, "failed to deregister service %s: %v", ext.Metadata.Name, err)
-			}
+	m.mu.RLock()
+	ext, exists := m.extensions[name]
+	m.mu.RUnlock()
+	if !exists {
+		return
+	}
+
+	// Finish messages in flight before the extension stops
+	m.stopConsumers(ext.Metadata.Name)
+
+	_ = m.runStopPhases(ext.Metadata.Name, ext.Instance)
+
+	// Track extension unloading
+	m.trackExtensionUnloaded(ext.Metadata.Name)
+
+	// Deregister from service discovery
+	if m.serviceDiscovery != nil && ext.Instance.NeedServiceDiscovery() {
+		if err := m.serviceDiscovery.DeregisterService(ext.Metadata.Name); err != nil {
+			logger.Errorf(nil, "failed to deregister service %s: %v", ext.Metadata.Name, err)
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/extension/event"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/sony/gobreaker"
+)
+
+// newTestManager creates a manager without the data layer and optional
+// subsystems, which need external services
+func newTestManager(t *testing.T, ext *config.Extension) *Manager {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if ext == nil {
+		ext = &config.Extension{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		extensions:       make(map[string]*types.Wrapper),
+		eventDispatcher:  event.NewEventDispatcher(),
+		circuitBreakers:  make(map[string]*gobreaker.CircuitBreaker),
+		crossServices:    make(map[string]any),
+		health:           newHealthCache(),
+		lazy:             make(map[string]*lazyExtension),
+		failed:           make(map[string]*failedExtension),
+		region:           &regionReplicator{},
+		canaries:         make(map[string]*canary),
+		consumers:        make(map[string][]*consumer),
+		consumersStarted: make(map[string]bool),
+		instanceID:       "test",
+		loggers:          make(map[string]*logger.ScopedLogger),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+	m.conf.Store(&config.Config{Extension: ext})
+	t.Cleanup(cancel)
+	return m
+}
+
+// testExtension is a minimal extension whose routes and init are set by the test
+type testExtension struct {
+	types.OptionalImpl
+	name     string
+	version  string
+	deps     []string
+	schema   *types.Schema
+	routes   func(r *gin.RouterGroup)
+	init     func() error
+	inits    atomic.Int32
+	cleanups atomic.Int32
+}
+
+func (e *testExtension) Name() string    { return e.name }
+func (e *testExtension) Version() string { return e.version }
+
+func (e *testExtension) Init(*config.Config, types.ManagerInterface) error {
+	e.inits.Add(1)
+	if e.init != nil {
+		return e.init()
+	}
+	return nil
+}
+
+func (e *testExtension) GetMetadata() types.Metadata {
+	return types.Metadata{Name: e.name, Version: e.version, Dependencies: e.deps, Schema: e.schema}
+}
+
+func (e *testExtension) GetHandlers() types.Handler { return e }
+func (e *testExtension) GetServices() types.Service { return nil }
+func (e *testExtension) Dependencies() []string     { return e.deps }
+
+func (e *testExtension) Cleanup() error {
+	e.cleanups.Add(1)
+	return nil
+}
+
+func (e *testExtension) RegisterRoutes(r *gin.RouterGroup) {
+	if e.routes != nil {
+		e.routes(r)
+	}
+}
+
+// waitFor polls cond until it holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for range 500 {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/ncobase/ncore/extension/openapi"
+	"github.com/ncobase/ncore/extension/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth requirements of manifest routes
+const (
+	AuthBearer       = "bearer"       // documented as requiring a bearer token
+	AuthNone         = "none"         // documented as public
+	AuthUndocumented = "undocumented" // no types.APIDocumenter operation
+)
+
+// RouteManifest lists the registered routes, for security reviews and the
+// generation of gateway configuration
+type RouteManifest struct {
+	App    string          `json:"app,omitempty"`
+	Routes []ManifestRoute `json:"routes"`
+}
+
+// ManifestRoute is a registered route. Middleware is the chain the manager
+// registered the routes of the extension with, middleware added by an
+// extension to its own groups is not visible. Routes of lazy extensions are
+// their documented operations, served behind a catch-all route until the
+// extension is activated.
+type ManifestRoute struct {
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Extension  string   `json:"extension,omitempty"` // empty for routes of the application
+	Handler    string   `json:"handler,omitempty"`
+	Middleware []string `json:"middleware,omitempty"`
+	Auth       string   `json:"auth"`
+	Summary    string   `json:"summary,omitempty"`
+	Lazy       bool     `json:"lazy,omitempty"`
+}
+
+// RouteManifest lists the routes registered by RegisterRoutes with their
+// owning extension, middleware and documented auth requirement, sorted by
+// path and method
+func (m *Manager) RouteManifest() (*RouteManifest, error) {
+	conf := m.conf.Load()
+	m.routesMu.RLock()
+	engine := m.engine
+	owners := maps.Clone(m.routeOwners)
+	chains := maps.Clone(m.routeChains)
+	m.routesMu.RUnlock()
+	if engine == nil {
+		return nil, fmt.Errorf("routes are not registered")
+	}
+
+	m.mu.RLock()
+	extensions := make(map[string]*types.Wrapper, len(m.extensions))
+	for name, ext := range m.extensions {
+		extensions[name] = ext
+	}
+	lazy := make(map[string]bool, len(m.lazy))
+	for name := range m.lazy {
+		lazy[name] = true
+	}
+	m.mu.RUnlock()
+
+	// Documented operations by method and OpenAPI path
+	ops := make(map[string]openapi.Operation)
+	documented := make(map[string]bool)
+	var lazyRoutes []ManifestRoute
+	for name, ext := range extensions {
+		documenter, ok := ext.Instance.(types.APIDocumenter)
+		if !ok {
+			continue
+		}
+		for _, op := range documenter.APIOperations() {
+			op.Method = strings.ToUpper(op.Method)
+			ops[routeKey(op.Method, openapi.Path(op.Path))] = op
+			if lazy[name] {
+				documented[name] = true
+				lazyRoutes = append(lazyRoutes, ManifestRoute{Method: op.Method, Path: op.Path, Extension: name, Lazy: true})
+			}
+		}
+	}
+
+	manifest := &RouteManifest{Routes: []ManifestRoute{}}
+	if conf != nil {
+		manifest.App = conf.AppName
+	}
+	for _, r := range engine.Routes() {
+		key := routeKey(r.Method, r.Path)
+		owner := owners[key]
+		if documented[owner] {
+			continue
+		}
+		manifest.Routes = append(manifest.Routes, ManifestRoute{
+			Method:     r.Method,
+			Path:       r.Path,
+			Extension:  owner,
+			Handler:    funcName(r.Handler),
+			Middleware: chains[key],
+			Lazy:       lazy[owner],
+		})
+	}
+	for _, r := range lazyRoutes {
+		// The catch-all route of the prefix holds the chain of the extension
+		for key, owner := range owners {
+			if owner == r.Extension {
+				r.Middleware = chains[key]
+				break
+			}
+		}
+		manifest.Routes = append(manifest.Routes, r)
+	}
+
+	for i := range manifest.Routes {
+		r := &manifest.Routes[i]
+		r.Auth = AuthUndocumented
+		if op, ok := ops[routeKey(r.Method, openapi.Path(r.Path))]; ok {
+			r.Auth = AuthNone
+			if op.Auth {
+				r.Auth = AuthBearer
+			}
+			r.Summary = op.Summary
+		}
+	}
+	sort.Slice(manifest.Routes, func(i, j int) bool {
+		a, b := manifest.Routes[i], manifest.Routes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return manifest, nil
+}
+
+// Markdown renders the manifest as a table
+func (rm *RouteManifest) Markdown() string {
+	var b strings.Builder
+	title := "Routes"
+	if rm.App != "" {
+		title = rm.App + " routes"
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("| Method | Path | Extension | Auth | Summary | Handler | Middleware |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, r := range rm.Routes {
+		ext := r.Extension
+		if ext == "" {
+			ext = "-"
+		}
+		if r.Lazy {
+			ext += " (lazy)"
+		}
+		middleware := make([]string, len(r.Middleware))
+		for i, mw := range r.Middleware {
+			middleware[i] = "`" + mw + "`"
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s | %s | %s |\n", r.Method, r.Path, ext, r.Auth,
+			markdownCell(r.Summary), markdownCell(r.Handler), strings.Join(middleware, ", "))
+	}
+	return b.String()
+}
+
+// markdownCell escapes pipes of a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// closureSuffix matches the suffixes of closures, nested closures and method values
+var closureSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*)+$|-fm$`)
+
+// funcName shortens a function name to its package and identifier, e.g.
+// manager.(*Manager).recordUsage for a closure returned by the method
+func funcName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for {
+		trimmed := closureSuffix.ReplaceAllString(name, "")
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
+}
+
+// handlerNames returns the short names of the handlers of a chain
+func handlerNames(chain gin.HandlersChain) []string {
+	names := make([]string, len(chain))
+	for i, h := range chain {
+		names[i] = funcName(runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name())
+	}
+	return names
+}
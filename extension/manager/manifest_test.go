@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/openapi"
+)
+
+// documentedExtension documents its routes, see types.APIDocumenter
+type documentedExtension struct {
+	*testExtension
+	ops []openapi.Operation
+}
+
+func (e *documentedExtension) APIOperations() []openapi.Operation { return e.ops }
+
+func TestRouteManifest(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"blog": {RoutePrefix: "/blog", Lazy: true}},
+	})
+	if _, err := m.RouteManifest(); err == nil {
+		t.Fatal("RouteManifest should fail before routes are registered")
+	}
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	notes := &documentedExtension{
+		testExtension: &testExtension{name: "notes", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+			r.GET("/notes/:id", ok)
+			r.POST("/notes", ok)
+		}},
+		ops: []openapi.Operation{{Method: "get", Path: "/notes/{id}", Summary: "Get a note", Auth: true}},
+	}
+	blog := &documentedExtension{
+		testExtension: &testExtension{name: "blog", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+			r.GET("/blog/posts", ok)
+		}},
+		ops: []openapi.Operation{{Method: "GET", Path: "/blog/posts", Summary: "List posts"}},
+	}
+	for _, ext := range []*documentedExtension{notes, blog} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.mu.Lock()
+	m.splitLazyExtensions([]string{"notes", "blog"})
+	m.mu.Unlock()
+
+	router := gin.New()
+	router.GET("/healthz", ok)
+	m.RegisterRoutes(router)
+
+	manifest, err := m.RouteManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := make(map[string]ManifestRoute)
+	for _, r := range manifest.Routes {
+		routes[r.Method+" "+r.Path] = r
+	}
+
+	get := routes["GET /notes/:id"]
+	if get.Extension != "notes" || get.Auth != AuthBearer || get.Summary != "Get a note" || get.Lazy ||
+		get.Handler != "manager.TestRouteManifest" || len(get.Middleware) == 0 {
+		t.Errorf("unexpected GET /notes/:id %+v", get)
+	}
+	if post := routes["POST /notes"]; post.Extension != "notes" || post.Auth != AuthUndocumented {
+		t.Errorf("unexpected POST /notes %+v", post)
+	}
+	if health := routes["GET /healthz"]; health.Path == "" || health.Extension != "" || health.Auth != AuthUndocumented {
+		t.Errorf("unexpected GET /healthz %+v", health)
+	}
+
+	// A pending lazy extension lists its documented operations, not its catch-all route
+	if posts := routes["GET /blog/posts"]; !posts.Lazy || posts.Extension != "blog" || posts.Auth != AuthNone || posts.Summary != "List posts" {
+		t.Errorf("unexpected GET /blog/posts %+v", posts)
+	}
+	for _, r := range manifest.Routes {
+		if r.Extension == "blog" && r.Path != "/blog/posts" {
+			t.Errorf("catch-all route of a lazy extension listed: %+v", r)
+		}
+	}
+
+	if !sort.SliceIsSorted(manifest.Routes, func(i, j int) bool {
+		a, b := manifest.Routes[i], manifest.Routes[j]
+		return a.Path < b.Path || a.Path == b.Path && a.Method < b.Method
+	}) {
+		t.Error("routes are not sorted by path and method")
+	}
+
+	md := manifest.Markdown()
+	for _, want := range []string{
+		"| GET | `/notes/:id` | notes | bearer | Get a note | manager.TestRouteManifest |",
+		"| GET | `/blog/posts` | blog (lazy) | none | List posts |",
+		"| GET | `/healthz` | - | undocumented |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	for in, want := range map[string]string{
+		"github.com/ncobase/ncore/extension/manager.(*Manager).recordUsage.func1.2": "manager.(*Manager).recordUsage",
+		"github.com/ncobase/ncore/extension/manager.(*Manager).handle-fm":           "manager.(*Manager).handle",
+		"github.com/gin-gonic/gin.LoggerWithConfig.func1":                           "gin.LoggerWithConfig",
+		"main.health": "main.health",
+	} {
+		if got := funcName(in); got != want {
+			t.Errorf("funcName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
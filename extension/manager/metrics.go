@@ -17,6 +17,7 @@ func (m *Manager) IsMetricsEnabled() bool {
 
 // GetMetrics returns comprehensive metrics
 func (m *Manager) GetMetrics() map[string]any {
+	conf := m.conf.Load()
 	if m.metricsCollector == nil {
 		return map[string]any{
 			"enabled":   false,
@@ -41,12 +42,12 @@ func (m *Manager) GetMetrics() map[string]any {
 		"storage":    m.metricsCollector.GetStorageStats(),
 	}
 
-	if m.conf.Extension.Metrics != nil {
+	if conf.Extension.Metrics != nil {
 		result["config"] = map[string]any{
-			"flush_interval": m.conf.Extension.Metrics.FlushInterval,
-			"batch_size":     m.conf.Extension.Metrics.BatchSize,
-			"retention":      m.conf.Extension.Metrics.Retention,
-			"storage_type":   m.conf.Extension.Metrics.Storage.Type,
+			"flush_interval": conf.Extension.Metrics.FlushInterval,
+			"batch_size":     conf.Extension.Metrics.BatchSize,
+			"retention":      conf.Extension.Metrics.Retention,
+			"storage_type":   conf.Extension.Metrics.Storage.Type,
 		}
 	}
 
@@ -303,6 +304,13 @@ func (m *Manager) trackExtensionInitialized(name string, duration time.Duration,
 	}
 }
 
+// trackExtensionLazyActivated tracks lazy extension activation
+func (m *Manager) trackExtensionLazyActivated(name string, duration time.Duration, err error) {
+	if m.metricsCollector != nil {
+		m.metricsCollector.ExtensionLazyActivated(name, duration, err)
+	}
+}
+
 // trackExtensionUnloaded tracks extension unloading
 func (m *Manager) trackExtensionUnloaded(name string) {
 	if m.metricsCollector != nil {
@@ -310,6 +318,13 @@ func (m *Manager) trackExtensionUnloaded(name string) {
 	}
 }
 
+// trackRoutePanic tracks a panic recovered in an extension route
+func (m *Manager) trackRoutePanic(name, route string) {
+	if m.metricsCollector != nil {
+		m.metricsCollector.RoutePanic(name, route)
+	}
+}
+
 // trackServiceCall tracks service call
 func (m *Manager) trackServiceCall(extensionName string, success bool) {
 	if m.metricsCollector != nil {
@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/ncobase/ncore/ecode"
+	"github.com/ncobase/ncore/extension/openapi"
+	"github.com/ncobase/ncore/extension/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportOpenAPI documents the routes registered by RegisterRoutes as an
+// OpenAPI 3.1 document. Routes are tagged by their owning extension, and
+// extensions implementing types.APIDocumenter add summaries, parameters and
+// bodies. Lazy extensions are served behind catch-all routes, so their
+// documented operations are listed instead. The title defaults to the
+// application name.
+func (m *Manager) ExportOpenAPI(info openapi.Info, servers ...string) (*openapi.Document, error) {
+	conf := m.conf.Load()
+	m.routesMu.RLock()
+	engine := m.engine
+	owners := maps.Clone(m.routeOwners)
+	m.routesMu.RUnlock()
+	if engine == nil {
+		return nil, fmt.Errorf("routes are not registered")
+	}
+
+	if info.Title == "" && conf != nil {
+		info.Title = conf.AppName
+	}
+
+	m.mu.RLock()
+	extensions := make(map[string]*types.Wrapper, len(m.extensions))
+	for name, ext := range m.extensions {
+		extensions[name] = ext
+	}
+	lazy := make(map[string]bool, len(m.lazy))
+	for name := range m.lazy {
+		lazy[name] = true
+	}
+	m.mu.RUnlock()
+
+	var routes []openapi.Route
+	for _, r := range engine.Routes() {
+		owner := owners[routeKey(r.Method, r.Path)]
+		if lazy[owner] {
+			continue
+		}
+		routes = append(routes, openapi.Route{Method: r.Method, Path: r.Path, Handler: r.Handler, Extension: owner})
+	}
+
+	var ops []openapi.Operation
+	for name, ext := range extensions {
+		documenter, ok := ext.Instance.(types.APIDocumenter)
+		if !ok {
+			continue
+		}
+		for _, op := range documenter.APIOperations() {
+			if len(op.Tags) == 0 {
+				op.Tags = []string{name}
+			}
+			ops = append(ops, op)
+			if lazy[name] {
+				routes = append(routes, openapi.Route{Method: op.Method, Path: op.Path, Extension: name})
+			}
+		}
+	}
+
+	return openapi.Build(routes, ops, openapi.Options{
+		Info:       info,
+		Servers:    servers,
+		ErrorCodes: ecode.Texts(),
+	}), nil
+}
+
+// trackRoutes records the routes register adds to router as owned by the
+// extension name, with the middleware chain register returns
+func (m *Manager) trackRoutes(router *gin.Engine, name string, register func() gin.HandlersChain) {
+	before := make(map[string]bool)
+	for _, r := range router.Routes() {
+		before[routeKey(r.Method, r.Path)] = true
+	}
+
+	chain := handlerNames(register())
+
+	m.routesMu.Lock()
+	defer m.routesMu.Unlock()
+	if m.routeOwners == nil {
+		m.routeOwners = make(map[string]string)
+		m.routeChains = make(map[string][]string)
+	}
+	for _, r := range router.Routes() {
+		if key := routeKey(r.Method, r.Path); !before[key] {
+			m.routeOwners[key] = name
+			m.routeChains[key] = chain
+		}
+	}
+}
+
+// routeKey identifies a route by method and gin path
+func routeKey(method, path string) string {
+	return method + " " + path
+}
@@ -19,12 +19,24 @@ func (m *Manager) LoadPlugins() error {
 	if m.isBuiltInMode() {
 		return m.loadBuiltInPlugins()
 	}
-	return m.loadFilePlugins()
+
+	if err := m.loadFilePlugins(); err != nil {
+		return err
+	}
+
+	// Start watching plugin files if enabled
+	if m.isPluginWatcherEnabled() {
+		if err := m.StartPluginWatcher(); err != nil {
+			logger.Warnf(nil, "failed to start plugin watcher: %v", err)
+		}
+	}
+
+	return nil
 }
 
 // loadFilePlugins loads plugins from files
 func (m *Manager) loadFilePlugins() error {
-	basePath := m.conf.Extension.Path
+	basePath := m.conf.Load().Extension.Path
 	if basePath == "" {
 		logger.Warnf(nil, "no plugin path configured, skipping file plugin loading")
 		return nil
@@ -81,6 +93,14 @@ func (m *Manager) loadBuiltInPlugins() error {
 			continue
 		}
 
+		m.mu.RLock()
+		err := m.checkSchemaClaimLocked(pluginName, pluginWrapper.Metadata.Schema)
+		m.mu.RUnlock()
+		if err != nil {
+			logger.Errorf(nil, "failed to load built-in plugin %s: %v", pluginName, err)
+			continue
+		}
+
 		if err := m.initializePlugin(pluginWrapper); err != nil {
 			logger.Errorf(nil, "failed to initialize built-in plugin %s: %v", pluginName, err)
 			continue
@@ -137,6 +157,15 @@ func (m *Manager) LoadPlugin(path string) error {
 	// Track successful load metrics
 	m.trackExtensionLoaded(pluginName, duration)
 
+	// Schedule tasks declared by the plugin
+	m.mu.RLock()
+	ext := m.extensions[pluginName]
+	m.mu.RUnlock()
+	if ext != nil {
+		m.scheduleTasks(pluginName, ext.Instance)
+		m.startConsumers(pluginName)
+	}
+
 	// Record metrics if monitoring enabled
 	if m.resourceMonitor != nil {
 		metrics := &security.PluginMetrics{
@@ -189,6 +218,10 @@ func (m *Manager) loadPluginInternal(path string) error {
 
 	loadedPlugin := plugin.GetPlugin(name)
 	if loadedPlugin != nil {
+		if err := m.checkSchemaClaimLocked(name, loadedPlugin.Metadata.Schema); err != nil {
+			_ = plugin.UnloadPlugin(name)
+			return fmt.Errorf("failed to load plugin %s: %w", name, err)
+		}
 		m.extensions[name] = loadedPlugin
 		logger.Infof(nil, "plugin %s loaded successfully", name)
 	}
@@ -198,7 +231,7 @@ func (m *Manager) loadPluginInternal(path string) error {
 
 // ReloadPlugin reloads a single plugin
 func (m *Manager) ReloadPlugin(name string) error {
-	basePath := m.conf.Extension.Path
+	basePath := m.conf.Load().Extension.Path
 	filePath := filepath.Join(basePath, name+utils.GetPlatformExt())
 
 	if err := m.UnloadPlugin(name); err != nil {
@@ -215,6 +248,11 @@ func (m *Manager) ReloadPlugin(name string) error {
 
 // UnloadPlugin unloads a single plugin
 func (m *Manager) UnloadPlugin(name string) error {
+	// Stop consumers first, their handlers may still call into the manager
+	if m.isRegisteredExtension(name) {
+		m.stopConsumers(name)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -223,22 +261,23 @@ func (m *Manager) UnloadPlugin(name string) error {
 		return fmt.Errorf("plugin %s not found", name)
 	}
 
-	// Cleanup extension
-	if err := ext.Instance.PreCleanup(); err != nil {
-		logger.Errorf(nil, "failed pre-cleanup of plugin %s: %v", name, err)
-	}
-
-	if err := ext.Instance.Cleanup(); err != nil {
-		logger.Errorf(nil, "failed cleanup of plugin %s: %v", name, err)
+	// Cleanup extension, bounded so a hanging plugin cannot hold the manager lock
+	if err := m.runStopPhases(name, ext.Instance); err != nil {
 		return err
 	}
 
 	// Remove from collections
+	m.unscheduleTasksLocked(name, ext.Instance)
 	delete(m.extensions, name)
 	delete(m.circuitBreakers, name)
+	m.removeCanary(name)
+
+	m.health.mu.Lock()
+	delete(m.health.selfTests, name)
+	m.health.mu.Unlock()
 
 	// Remove cross services for this extension
-	m.removeCrossServicesForExtension(name)
+	m.removeCrossServicesForExtensionLocked(name)
 
 	// Deregister from service discovery
 	if m.serviceDiscovery != nil && ext.Instance.NeedServiceDiscovery() {
@@ -257,6 +296,7 @@ func (m *Manager) UnloadPlugin(name string) error {
 
 	// Track unload
 	m.trackExtensionUnloaded(name)
+	m.removeLogger(name)
 
 	logger.Infof(nil, "plugin %s unloaded successfully", name)
 	return nil
@@ -264,17 +304,24 @@ func (m *Manager) UnloadPlugin(name string) error {
 
 // initializePlugin initializes a single plugin
 func (m *Manager) initializePlugin(pluginWrapper *types.Wrapper) error {
+	conf := m.conf.Load()
 	instance := pluginWrapper.Instance
+	m.injectLogger(pluginWrapper.Metadata.Name, instance)
+	if err := m.injectFileSystem(pluginWrapper.Metadata.Name, instance); err != nil {
+		return err
+	}
+
+	timeout := conf.Extension.GetInitTimeout(pluginWrapper.Metadata.Name)
 
-	if err := instance.PreInit(); err != nil {
+	if err := runPhase(timeout, instance.PreInit); err != nil {
 		return fmt.Errorf("pre-initialization failed: %v", err)
 	}
 
-	if err := instance.Init(m.conf, m); err != nil {
+	if err := runPhase(timeout, func() error { return instance.Init(conf, m) }); err != nil {
 		return fmt.Errorf("initialization failed: %v", err)
 	}
 
-	if err := instance.PostInit(); err != nil {
+	if err := runPhase(timeout, instance.PostInit); err != nil {
 		return fmt.Errorf("post-initialization failed: %v", err)
 	}
 
@@ -283,7 +330,7 @@ func (m *Manager) initializePlugin(pluginWrapper *types.Wrapper) error {
 
 // shouldLoadPlugin checks if a plugin should be loaded based on configuration
 func (m *Manager) shouldLoadPlugin(name string) bool {
-	fc := m.conf.Extension
+	fc := m.conf.Load().Extension
 
 	// If includes list is specified, only load plugins in the list
 	if len(fc.Includes) > 0 {
@@ -309,7 +356,13 @@ func (m *Manager) shouldLoadPlugin(name string) bool {
 
 // isBuiltInMode checks if we're in built-in plugin mode
 func (m *Manager) isBuiltInMode() bool {
-	return m.conf.Extension.IsBuiltInMode()
+	return m.conf.Load().Extension.IsBuiltInMode()
+}
+
+// isPluginWatcherEnabled checks if plugin files should be watched for changes
+func (m *Manager) isPluginWatcherEnabled() bool {
+	fc := m.conf.Load().Extension
+	return fc.HotReload && fc.Watcher != nil && fc.Watcher.Enabled
 }
 
 // removeCrossServicesForExtension removes all cross services for an extension
@@ -317,6 +370,11 @@ func (m *Manager) removeCrossServicesForExtension(extensionName string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.removeCrossServicesForExtensionLocked(extensionName)
+}
+
+// removeCrossServicesForExtensionLocked removes cross services, caller must hold the lock
+func (m *Manager) removeCrossServicesForExtensionLocked(extensionName string) {
 	keysToRemove := make([]string, 0)
 	prefix := extensionName + "."
 
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/ncobase/ncore/extension/probe"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+// GetProbeStatuses returns the status of all external dependency probes
+func (m *Manager) GetProbeStatuses() map[string]probe.Status {
+	if m.probeScheduler == nil {
+		return map[string]probe.Status{}
+	}
+	return m.probeScheduler.Statuses()
+}
+
+// GetProbeStatus returns the status and availability history of a probe
+func (m *Manager) GetProbeStatus(name string) (probe.Status, error) {
+	if m.probeScheduler == nil {
+		return probe.Status{}, fmt.Errorf("external probes not enabled")
+	}
+
+	status, ok := m.probeScheduler.GetStatus(name)
+	if !ok {
+		return probe.Status{}, fmt.Errorf("probe %s not found", name)
+	}
+	return status, nil
+}
+
+// publishProbeStateEvent publishes an event when a dependency goes down or recovers
+func (m *Manager) publishProbeStateEvent(status probe.Status) {
+	eventName := fmt.Sprintf("probes.%s.recovered", status.Name)
+	if status.State == probe.StateDown {
+		eventName = fmt.Sprintf("probes.%s.down", status.Name)
+	}
+
+	eventData := map[string]any{
+		"name":                 status.Name,
+		"type":                 status.Type,
+		"state":                status.State,
+		"consecutive_failures": status.ConsecutiveFailures,
+		"availability":         status.Availability,
+		"last_error":           status.LastError,
+	}
+
+	// Always publish to memory
+	m.eventDispatcher.Publish(eventName, eventData)
+
+	// Async publish to queue if messaging enabled
+	if m.isMessagingEnabled() {
+		go func() {
+			m.PublishEvent(eventName, eventData, types.EventTargetQueue)
+		}()
+	}
+}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/logging/observes/apm"
+	"github.com/ncobase/ncore/net/resp"
+	"github.com/sony/gobreaker"
+)
+
+// routePanicThreshold consecutive failures that open an extension's circuit breaker
+const routePanicThreshold = 5
+
+// errRoutePanic is reported to the circuit breaker for a panicking request
+var errRoutePanic = errors.New("route panicked")
+
+// newCircuitBreaker creates the circuit breaker guarding an extension's routes and service calls
+func newCircuitBreaker(name string) *gobreaker.CircuitBreaker {
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: 100,
+		Interval:    5 * time.Second,
+		Timeout:     3 * time.Second,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			if counts.ConsecutiveFailures >= routePanicThreshold {
+				return true
+			}
+			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+			return counts.Requests >= 3 && failureRatio >= 0.6
+		},
+	})
+}
+
+// circuitBreaker returns the circuit breaker of an extension, creating it if needed
+func (m *Manager) circuitBreaker(name string) *gobreaker.CircuitBreaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cb, ok := m.circuitBreakers[name]
+	if !ok {
+		cb = newCircuitBreaker(name)
+		m.circuitBreakers[name] = cb
+	}
+	return cb
+}
+
+// recoverRoutes returns middleware isolating panics in an extension's routes. A panic
+// is logged with its stack, counted against the extension and answered with a server
+// error. With a circuit breaker, panics count as failures and an open breaker rejects
+// the extension's requests until it half-opens.
+func (m *Manager) recoverRoutes(name string, cb *gobreaker.CircuitBreaker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var abort bool
+		serve := func() (_ any, err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				// Deliberate aborts are re-raised for the server to handle
+				if r == http.ErrAbortHandler {
+					abort = true
+					return
+				}
+
+				logger.Errorf(c.Request.Context(), "extension %s panicked on %s %s: %v\n%s",
+					name, c.Request.Method, c.Request.URL.Path, r, debug.Stack())
+				m.trackRoutePanic(name, c.FullPath())
+				apm.NoticeError(c.Request.Context(), fmt.Errorf("extension %s panicked: %v", name, r))
+				resp.ReportPanic(c.Writer, r)
+
+				if !c.Writer.Written() {
+					resp.Fail(c.Writer, resp.InternalServer(fmt.Sprintf("extension %s failed to handle the request", name)))
+				}
+				c.Abort()
+				err = errRoutePanic
+			}()
+			c.Next()
+			return nil, nil
+		}
+
+		if cb == nil {
+			_, _ = serve()
+		} else if _, err := cb.Execute(serve); errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			m.trackCircuitBreakerTripped(name)
+			resp.Fail(c.Writer, resp.ServiceUnavailable(fmt.Sprintf("extension %s unavailable", name)))
+			c.Abort()
+			return
+		}
+
+		if abort {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
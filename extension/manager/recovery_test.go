@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sony/gobreaker"
+)
+
+func TestRecoverRoutesIsolatesPanics(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	notes := &testExtension{name: "notes", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+		r.GET("/notes/ok", func(c *gin.Context) { c.String(http.StatusOK, "notes") })
+		r.GET("/notes/panic", func(*gin.Context) { panic("boom") })
+	}}
+	blog := &testExtension{name: "blog", version: "1.0.0", routes: func(r *gin.RouterGroup) {
+		r.GET("/blog/ok", func(c *gin.Context) { c.String(http.StatusOK, "blog") })
+	}}
+	for _, ext := range []*testExtension{notes, blog} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := gin.New()
+	m.RegisterRoutes(router)
+
+	if w := get(router, "/notes/ok"); w.Code != http.StatusOK || w.Body.String() != "notes" {
+		t.Fatalf("notes before panics: %d %q", w.Code, w.Body.String())
+	}
+
+	// Panics are answered with a server error, two of three failed requests open the breaker
+	for i := range 2 {
+		if w := get(router, "/notes/panic"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("panic %d answered with %d", i+1, w.Code)
+		}
+		if w := get(router, "/blog/ok"); w.Code != http.StatusOK || w.Body.String() != "blog" {
+			t.Fatalf("blog while notes panics: %d %q", w.Code, w.Body.String())
+		}
+	}
+
+	m.mu.RLock()
+	cb := m.circuitBreakers["notes"]
+	m.mu.RUnlock()
+	if cb == nil || cb.State() != gobreaker.StateOpen {
+		t.Fatalf("breaker of notes should be open")
+	}
+	for _, path := range []string{"/notes/ok", "/notes/panic"} {
+		if w := get(router, path); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s with an open breaker answered with %d", path, w.Code)
+		}
+	}
+	for range 10 {
+		if w := get(router, "/blog/ok"); w.Code != http.StatusOK {
+			t.Fatalf("blog answered with %d after notes tripped", w.Code)
+		}
+	}
+}
+
+func TestRecoverRoutesReraisesAbort(t *testing.T) {
+	m := newTestManager(t, nil)
+	router := gin.New()
+	router.GET("/abort", m.recoverRoutes("notes", newCircuitBreaker("notes")), func(*gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+	router.GET("/plain", m.recoverRoutes("notes", nil), func(*gin.Context) { panic("boom") })
+
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Fatalf("recovered %v, want http.ErrAbortHandler", r)
+			}
+		}()
+		get(router, "/abort")
+	}()
+
+	// Without a breaker panics are still answered
+	for range 10 {
+		if w := get(router, "/plain"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("panic without a breaker answered with %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// cacheInvalidationEvent is the event used to fan out cache invalidations across regions
+const cacheInvalidationEvent = "region.cache.invalidate"
+
+// CacheInvalidationHandler is called with keys invalidated locally or by a peer region
+type CacheInvalidationHandler func(ctx context.Context, keys []string)
+
+// regionReplicator tracks multi-region replication state
+type regionReplicator struct {
+	mu       sync.RWMutex
+	handlers []CacheInvalidationHandler
+
+	forwarded     atomic.Int64
+	received      atomic.Int64
+	dropped       atomic.Int64
+	forwardErrors atomic.Int64
+}
+
+// isRegionEnabled reports whether multi-region replication is configured
+func (m *Manager) isRegionEnabled() bool {
+	return m.conf.Load().Extension.Region.IsEnabled()
+}
+
+// startRegionReplication consumes events forwarded by peer regions
+func (m *Manager) startRegionReplication() {
+	if !m.isRegionEnabled() || !m.isQueueAvailable() {
+		return
+	}
+
+	region := m.conf.Load().Extension.Region
+	queue := regionQueue(region.Topic, region.Name)
+
+	handler := func(body []byte) error {
+		var eventData types.EventData
+		if err := json.Unmarshal(body, &eventData); err != nil {
+			logger.Errorf(nil, "Failed to unmarshal region event: %v", err)
+			return err
+		}
+		m.handleRegionEvent(eventData)
+		return nil
+	}
+
+	if err := m.data.ConsumeFromRabbitMQ(queue, handler); err != nil {
+		groupID := fmt.Sprintf("ncore-region-%s", region.Name)
+		if kafkaErr := m.data.ConsumeFromKafka(m.ctx, queue, groupID, handler); kafkaErr != nil {
+			logger.Warnf(nil, "Failed to subscribe to region events: RabbitMQ (%v), Kafka (%v)", err, kafkaErr)
+			return
+		}
+	}
+
+	logger.Infof(nil, "Region %s (%s) replication started on %s", region.Name, region.Role, queue)
+}
+
+// regionQueue returns the queue, or Kafka topic, a region consumes forwarded events from
+func regionQueue(topic, region string) string {
+	return fmt.Sprintf("%s.%s", topic, region)
+}
+
+// shouldForwardEvent reports whether an event is replicated to peer regions
+func (m *Manager) shouldForwardEvent(eventName string) bool {
+	region := m.conf.Load().Extension.Region
+	if !region.IsEnabled() || !region.IsActive() {
+		return false
+	}
+
+	for _, pattern := range region.ForwardEvents {
+		if ok, _ := path.Match(pattern, eventName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardToRegions publishes an event to the queue of each peer region, tagged with the local region
+func (m *Manager) forwardToRegions(eventName string, data any) {
+	if !m.isQueueAvailable() {
+		return
+	}
+
+	region := m.conf.Load().Extension.Region
+	eventData := types.EventData{
+		Time:      time.Now(),
+		Source:    "extension",
+		EventType: eventName,
+		Data:      data,
+		Region:    region.Name,
+		Regions:   []string{region.Name},
+	}
+
+	jsonData, err := json.Marshal(eventData)
+	if err != nil {
+		logger.Errorf(nil, "Failed to serialize region event: %v", err)
+		return
+	}
+
+	for _, queue := range peerQueues(region) {
+		if err := m.PublishMessage(queue, queue, jsonData); err != nil {
+			m.region.forwardErrors.Add(1)
+			logger.Warnf(nil, "Failed to forward event %s to %s: %v", eventName, queue, err)
+			continue
+		}
+		m.region.forwarded.Add(1)
+	}
+}
+
+// peerQueues returns the queues of the peer regions events are forwarded to
+func peerQueues(region *config.RegionConfig) []string {
+	queues := make([]string, 0, len(region.Peers))
+	for _, peer := range region.Peers {
+		if peer != region.Name {
+			queues = append(queues, regionQueue(region.Topic, peer))
+		}
+	}
+	return queues
+}
+
+// handleRegionEvent dispatches an event received from a peer region
+func (m *Manager) handleRegionEvent(eventData types.EventData) {
+	local := m.conf.Load().Extension.Region.Name
+
+	// Drop events that originated here or already passed through this region
+	if eventData.Region == local || slices.Contains(eventData.Regions, local) {
+		m.region.dropped.Add(1)
+		return
+	}
+	m.region.received.Add(1)
+
+	if eventData.EventType == cacheInvalidationEvent {
+		m.applyCacheInvalidation(m.ctx, invalidationKeys(eventData.Data))
+		return
+	}
+
+	// Dispatch locally only, events from peers are never forwarded again
+	m.eventDispatcher.Publish(eventData.EventType, eventData.Data)
+}
+
+// OnCacheInvalidation registers a handler for local and cross-region cache invalidations
+func (m *Manager) OnCacheInvalidation(handler CacheInvalidationHandler) {
+	m.region.mu.Lock()
+	defer m.region.mu.Unlock()
+	m.region.handlers = append(m.region.handlers, handler)
+}
+
+// InvalidateCache removes keys locally and fans the invalidation out to peer regions
+func (m *Manager) InvalidateCache(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := m.applyCacheInvalidation(ctx, keys); err != nil {
+		return err
+	}
+
+	if m.isRegionEnabled() {
+		go m.forwardToRegions(cacheInvalidationEvent, map[string]any{"keys": keys})
+	}
+	return nil
+}
+
+// applyCacheInvalidation deletes keys from the local Redis and notifies handlers
+func (m *Manager) applyCacheInvalidation(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var err error
+	if m.data != nil {
+		if rc, ok := m.data.GetRedis().(*redis.Client); ok && rc != nil {
+			if delErr := rc.Del(ctx, keys...).Err(); delErr != nil {
+				err = fmt.Errorf("failed to invalidate cache keys: %v", delErr)
+				logger.Warnf(nil, "%v", err)
+			}
+		}
+	}
+
+	m.region.mu.RLock()
+	handlers := slices.Clone(m.region.handlers)
+	m.region.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, keys)
+	}
+	return err
+}
+
+// invalidationKeys extracts the key list from a decoded invalidation payload
+func invalidationKeys(data any) []string {
+	payload, ok := data.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	raw, _ := payload["keys"].([]any)
+	keys := make([]string, 0, len(raw))
+	for _, k := range raw {
+		if s, ok := k.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	return keys
+}
+
+// GetRegionStats returns multi-region replication status
+func (m *Manager) GetRegionStats() map[string]any {
+	region := m.conf.Load().Extension.Region
+	if !region.IsEnabled() {
+		return map[string]any{"enabled": false}
+	}
+
+	return map[string]any{
+		"enabled":        true,
+		"region":         region.Name,
+		"role":           region.Role,
+		"peers":          region.Peers,
+		"topic":          region.Topic,
+		"forwarded":      m.region.forwarded.Load(),
+		"received":       m.region.received.Load(),
+		"dropped":        m.region.dropped.Load(),
+		"forward_errors": m.region.forwardErrors.Load(),
+	}
+}
@@ -0,0 +1,30 @@
+package manager
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+func TestRegionForwardingReachesPeerQueues(t *testing.T) {
+	const topic = "ncore.region.events"
+	east := &config.RegionConfig{Name: "us-east", Role: "active", Peers: []string{"eu-west", "ap-south", "us-east"}, Topic: topic}
+	west := &config.RegionConfig{Name: "eu-west", Role: "passive", Peers: []string{"us-east"}, Topic: topic}
+	south := &config.RegionConfig{Name: "ap-south", Role: "passive", Topic: topic}
+
+	// Each region consumes the queue its peers forward to, never its own
+	want := []string{regionQueue(west.Topic, west.Name), regionQueue(south.Topic, south.Name)}
+	if got := peerQueues(east); !slices.Equal(got, want) {
+		t.Fatalf("peerQueues(us-east) = %v, want %v", got, want)
+	}
+	if got := peerQueues(west); !slices.Equal(got, []string{regionQueue(east.Topic, east.Name)}) {
+		t.Fatalf("peerQueues(eu-west) = %v", got)
+	}
+	if got := peerQueues(south); len(got) != 0 {
+		t.Fatalf("peerQueues(ap-south) = %v, want none", got)
+	}
+	if got := regionQueue(topic, "eu-west"); got != "ncore.region.events.eu-west" {
+		t.Fatalf("regionQueue = %s", got)
+	}
+}
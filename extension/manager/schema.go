@@ -0,0 +1,322 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ncobase/ncore/extension/types"
+)
+
+// ErrSchemaConflict is returned when two extensions claim the same table or collection
+var ErrSchemaConflict = errors.New("schema object claimed by another extension")
+
+// schemaKeys returns the claim keys of the objects declared in schema
+func schemaKeys(schema *types.Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(schema.Tables)+len(schema.Collections))
+	for _, t := range schema.Tables {
+		keys = append(keys, "table "+strings.ToLower(t.Name))
+	}
+	for _, c := range schema.Collections {
+		keys = append(keys, "collection "+strings.ToLower(c.Name))
+	}
+	return keys
+}
+
+// schemaClaims maps the tables and collections claimed by extensions to their
+// owner, failing on the first object claimed twice in name order
+func schemaClaims(extensions map[string]*types.Wrapper) (map[string]string, error) {
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	claims := make(map[string]string)
+	for _, name := range names {
+		if err := claimSchema(claims, name, extensions[name].Metadata.Schema); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// claimSchema adds the objects of schema to claims for extension name
+func claimSchema(claims map[string]string, name string, schema *types.Schema) error {
+	for _, key := range schemaKeys(schema) {
+		if owner, ok := claims[key]; ok && owner != name {
+			return fmt.Errorf("%w: extension %s claims %s owned by %s", ErrSchemaConflict, name, key, owner)
+		}
+		claims[key] = name
+	}
+	return nil
+}
+
+// checkSchemaClaimLocked fails if extension name claims an object owned by a
+// registered extension. The caller holds m.mu.
+func (m *Manager) checkSchemaClaimLocked(name string, schema *types.Schema) error {
+	if schema == nil {
+		return nil
+	}
+	others := make(map[string]*types.Wrapper, len(m.extensions))
+	for other, ext := range m.extensions {
+		if other != name {
+			others[other] = ext
+		}
+	}
+	claims, err := schemaClaims(others)
+	if err != nil {
+		return err
+	}
+	return claimSchema(claims, name, schema)
+}
+
+// GetSchemas returns the schemas declared by extensions, by extension name
+func (m *Manager) GetSchemas() map[string]*types.Schema {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schemas := make(map[string]*types.Schema)
+	for name, ext := range m.extensions {
+		if ext.Metadata.Schema != nil {
+			schemas[name] = ext.Metadata.Schema
+		}
+	}
+	return schemas
+}
+
+// GetSchemaOwner returns the extension owning a table or collection
+func (m *Manager) GetSchemaOwner(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	claims, _ := schemaClaims(m.extensions)
+	name = strings.ToLower(name)
+	if owner, ok := claims["table "+name]; ok {
+		return owner, true
+	}
+	owner, ok := claims["collection "+name]
+	return owner, ok
+}
+
+// dbColumn is a column found in the database
+type dbColumn struct {
+	nullable bool
+}
+
+// dbTable is a table found in the database
+type dbTable struct {
+	columns map[string]dbColumn
+	indexes map[string]bool
+}
+
+// CheckSchemaDrift compares the tables declared by extensions with the master
+// database, on Postgres, MySQL and SQLite. Collections are not inspected.
+func (m *Manager) CheckSchemaDrift(ctx context.Context) ([]types.SchemaDrift, error) {
+	conf := m.conf.Load()
+	if m.data == nil || m.data.GetMasterDB() == nil {
+		return nil, errors.New("database is not available")
+	}
+	var driver string
+	if conf.Data != nil && conf.Data.Database != nil && conf.Data.Database.Master != nil {
+		driver = conf.Data.Database.Master.Driver
+	}
+
+	tables, err := inspectTables(ctx, m.data.GetMasterDB(), driver)
+	if err != nil {
+		return nil, err
+	}
+	return schemaDrift(m.GetSchemas(), tables), nil
+}
+
+// schemaDrift lists the differences between declared schemas and tables found
+func schemaDrift(schemas map[string]*types.Schema, tables map[string]*dbTable) []types.SchemaDrift {
+	var drift []types.SchemaDrift
+	owned := make(map[string]bool)
+
+	for owner, schema := range schemas {
+		for _, t := range schema.Tables {
+			name := strings.ToLower(t.Name)
+			owned[name] = true
+			found, ok := tables[name]
+			if !ok {
+				drift = append(drift, types.SchemaDrift{Kind: types.DriftMissingTable, Extension: owner, Table: t.Name})
+				continue
+			}
+
+			declared := make(map[string]bool, len(t.Columns))
+			for _, c := range t.Columns {
+				declared[strings.ToLower(c.Name)] = true
+				col, ok := found.columns[strings.ToLower(c.Name)]
+				switch {
+				case !ok:
+					drift = append(drift, types.SchemaDrift{Kind: types.DriftMissingColumn, Extension: owner, Table: t.Name, Column: c.Name})
+				case col.nullable != c.Nullable:
+					drift = append(drift, types.SchemaDrift{Kind: types.DriftNullability, Extension: owner, Table: t.Name, Column: c.Name})
+				}
+			}
+			if len(t.Columns) > 0 {
+				for col := range found.columns {
+					if !declared[col] {
+						drift = append(drift, types.SchemaDrift{Kind: types.DriftUndeclaredColumn, Extension: owner, Table: t.Name, Column: col})
+					}
+				}
+			}
+			for _, idx := range t.Indexes {
+				if !found.indexes[strings.ToLower(idx.Name)] {
+					drift = append(drift, types.SchemaDrift{Kind: types.DriftMissingIndex, Extension: owner, Table: t.Name, Index: idx.Name})
+				}
+			}
+		}
+	}
+
+	for name := range tables {
+		if !owned[name] {
+			drift = append(drift, types.SchemaDrift{Kind: types.DriftUnownedTable, Table: name})
+		}
+	}
+
+	slices.SortFunc(drift, func(a, b types.SchemaDrift) int {
+		return strings.Compare(a.Table+"\x00"+a.Kind+"\x00"+a.Column+a.Index, b.Table+"\x00"+b.Kind+"\x00"+b.Column+b.Index)
+	})
+	return drift
+}
+
+// inspectTables reads the tables, columns and indexes of the current schema
+func inspectTables(ctx context.Context, db *sql.DB, driver string) (map[string]*dbTable, error) {
+	var columnsQuery, indexesQuery string
+	switch driver {
+	case "postgres", "pgx":
+		columnsQuery = "SELECT table_name, column_name, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = current_schema()"
+		indexesQuery = "SELECT tablename, indexname FROM pg_indexes WHERE schemaname = current_schema()"
+	case "mysql":
+		columnsQuery = "SELECT table_name, column_name, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = DATABASE()"
+		indexesQuery = "SELECT DISTINCT table_name, index_name FROM information_schema.statistics WHERE table_schema = DATABASE()"
+	case "sqlite3", "sqlite":
+		columnsQuery = `SELECT m.name, p.name, p."notnull" = 0 FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'`
+		indexesQuery = "SELECT tbl_name, name FROM sqlite_master WHERE type = 'index'"
+	default:
+		return nil, fmt.Errorf("schema drift is not supported for driver %q", driver)
+	}
+
+	tables := make(map[string]*dbTable)
+	table := func(name string) *dbTable {
+		name = strings.ToLower(name)
+		t, ok := tables[name]
+		if !ok {
+			t = &dbTable{columns: make(map[string]dbColumn), indexes: make(map[string]bool)}
+			tables[name] = t
+		}
+		return t
+	}
+
+	rows, err := db.QueryContext(ctx, columnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			tableName, column string
+			nullable          bool
+		)
+		if err := rows.Scan(&tableName, &column, &nullable); err != nil {
+			return nil, fmt.Errorf("failed to read columns: %v", err)
+		}
+		table(tableName).columns[strings.ToLower(column)] = dbColumn{nullable: nullable}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read columns: %v", err)
+	}
+
+	idxRows, err := db.QueryContext(ctx, indexesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %v", err)
+	}
+	defer idxRows.Close()
+	for idxRows.Next() {
+		var tableName, index string
+		if err := idxRows.Scan(&tableName, &index); err != nil {
+			return nil, fmt.Errorf("failed to read indexes: %v", err)
+		}
+		if t, ok := tables[strings.ToLower(tableName)]; ok {
+			t.indexes[strings.ToLower(index)] = true
+		}
+	}
+	return tables, idxRows.Err()
+}
+
+// SchemaOverview renders the schemas declared by extensions as a Mermaid ER
+// diagram, with references across extensions as relationships
+func (m *Manager) SchemaOverview() string {
+	schemas := m.GetSchemas()
+	owners := make([]string, 0, len(schemas))
+	for name := range schemas {
+		owners = append(owners, name)
+	}
+	slices.Sort(owners)
+
+	var b, rels strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, owner := range owners {
+		schema := schemas[owner]
+		fmt.Fprintf(&b, "    %%%% extension %s\n", owner)
+
+		for _, t := range schema.Tables {
+			keys := make(map[string][]string)
+			for _, col := range t.PrimaryKey {
+				keys[col] = append(keys[col], "PK")
+			}
+			for _, ref := range t.References {
+				for _, col := range ref.Columns {
+					keys[col] = append(keys[col], "FK")
+				}
+				fmt.Fprintf(&rels, "    %s }o--|| %s : \"%s\"\n", erName(t.Name, false), erName(ref.Table, false), strings.Join(ref.Columns, ", "))
+			}
+
+			if len(t.Columns) == 0 {
+				fmt.Fprintf(&b, "    %s\n", erName(t.Name, false))
+				continue
+			}
+			fmt.Fprintf(&b, "    %s {\n", erName(t.Name, false))
+			for _, c := range t.Columns {
+				typ := erName(c.Type, true)
+				if typ == "" {
+					typ = "column"
+				}
+				fmt.Fprintf(&b, "        %s %s", typ, erName(c.Name, false))
+				if k := keys[c.Name]; len(k) > 0 {
+					b.WriteString(" " + strings.Join(slices.Compact(k), ", "))
+				}
+				b.WriteString("\n")
+			}
+			b.WriteString("    }\n")
+		}
+		for _, c := range schema.Collections {
+			fmt.Fprintf(&b, "    %s\n", erName(c.Name, false))
+		}
+	}
+	b.WriteString(rels.String())
+	return b.String()
+}
+
+// erName replaces the characters Mermaid does not accept in names, keeping
+// parentheses in attribute types
+func erName(name string, typ bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			return r
+		case typ && (r == '(' || r == ')'):
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
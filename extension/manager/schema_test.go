@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ncobase/ncore/extension/types"
+)
+
+func TestSchemaClaims(t *testing.T) {
+	m := newTestManager(t, nil)
+	users := &testExtension{name: "users", version: "1.0.0", schema: &types.Schema{
+		Tables:      []types.Table{{Name: "users"}, {Name: "user_roles"}},
+		Collections: []types.Collection{{Name: "sessions"}},
+	}}
+	if err := m.RegisterExtension(users); err != nil {
+		t.Fatal(err)
+	}
+
+	// Names are compared case-insensitively, tables and collections separately
+	conflict := &testExtension{name: "auth", version: "1.0.0", schema: &types.Schema{Tables: []types.Table{{Name: "Users"}}}}
+	err := m.RegisterExtension(conflict)
+	if !errors.Is(err, ErrSchemaConflict) || !strings.Contains(err.Error(), "extension auth claims table users owned by users") {
+		t.Fatalf("err = %v, want a schema conflict", err)
+	}
+	notes := &testExtension{name: "notes", version: "1.0.0", schema: &types.Schema{Tables: []types.Table{{Name: "sessions"}}}}
+	if err := m.RegisterExtension(notes); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"USERS": "users", "sessions": "notes", "user_roles": "users"} {
+		if owner, ok := m.GetSchemaOwner(name); !ok || owner != want {
+			t.Errorf("owner of %s = %q, %v, want %s", name, owner, ok, want)
+		}
+	}
+	if _, ok := m.GetSchemaOwner("orders"); ok {
+		t.Error("unclaimed table has an owner")
+	}
+	if schemas := m.GetSchemas(); len(schemas) != 2 || schemas["users"] != users.schema {
+		t.Fatalf("schemas = %v", schemas)
+	}
+}
+
+func TestSchemaDrift(t *testing.T) {
+	schemas := map[string]*types.Schema{
+		"users": {Tables: []types.Table{{
+			Name: "users",
+			Columns: []types.Column{
+				{Name: "id"},
+				{Name: "email"},
+				{Name: "nickname", Nullable: true},
+				{Name: "deleted_at", Nullable: true},
+			},
+			Indexes: []types.Index{{Name: "idx_users_email"}, {Name: "idx_users_nickname"}},
+		}}},
+		"notes": {Tables: []types.Table{{Name: "notes"}, {Name: "Tags"}}},
+	}
+	tables := map[string]*dbTable{
+		"users": {
+			columns: map[string]dbColumn{
+				"id":         {},
+				"email":      {nullable: true},
+				"nickname":   {nullable: true},
+				"avatar_url": {nullable: true},
+			},
+			indexes: map[string]bool{"idx_users_email": true},
+		},
+		// Tables without declared columns are only checked for existence
+		"notes":      {columns: map[string]dbColumn{"id": {}, "body": {}}, indexes: map[string]bool{}},
+		"migrations": {columns: map[string]dbColumn{"version": {}}, indexes: map[string]bool{}},
+	}
+
+	want := []types.SchemaDrift{
+		{Kind: types.DriftMissingTable, Extension: "notes", Table: "Tags"},
+		{Kind: types.DriftUnownedTable, Table: "migrations"},
+		{Kind: types.DriftMissingColumn, Extension: "users", Table: "users", Column: "deleted_at"},
+		{Kind: types.DriftMissingIndex, Extension: "users", Table: "users", Index: "idx_users_nickname"},
+		{Kind: types.DriftNullability, Extension: "users", Table: "users", Column: "email"},
+		{Kind: types.DriftUndeclaredColumn, Extension: "users", Table: "users", Column: "avatar_url"},
+	}
+	if drift := schemaDrift(schemas, tables); !reflect.DeepEqual(drift, want) {
+		t.Fatalf("drift =\n%v\nwant\n%v", drift, want)
+	}
+}
+
+func TestSchemaOverview(t *testing.T) {
+	m := newTestManager(t, nil)
+	for _, ext := range []*testExtension{
+		{name: "users", version: "1.0.0", schema: &types.Schema{Tables: []types.Table{{
+			Name:       "users",
+			Columns:    []types.Column{{Name: "id", Type: "bigint"}, {Name: "email", Type: "varchar(255)"}},
+			PrimaryKey: []string{"id"},
+		}}}},
+		{name: "notes", version: "1.0.0", schema: &types.Schema{
+			Tables: []types.Table{{
+				Name:       "notes",
+				Columns:    []types.Column{{Name: "id", Type: "bigint"}, {Name: "user_id", Type: "bigint"}, {Name: "body"}},
+				PrimaryKey: []string{"id"},
+				References: []types.Reference{{Columns: []string{"user_id"}, Table: "users"}},
+			}},
+			Collections: []types.Collection{{Name: "note.revisions"}},
+		}},
+	} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `erDiagram
+    %% extension notes
+    notes {
+        bigint id PK
+        bigint user_id FK
+        column body
+    }
+    note_revisions
+    %% extension users
+    users {
+        bigint id PK
+        varchar(255) email
+    }
+    notes }o--|| users : "user_id"
+`
+	if got := m.SchemaOverview(); got != want {
+		t.Fatalf("overview =\n%s\nwant\n%s", got, want)
+	}
+}
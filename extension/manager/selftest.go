@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// RunSelfTests runs the self-tests of all started extensions concurrently
+// and records the results for health and readiness
+func (m *Manager) RunSelfTests(ctx context.Context) map[string]types.SelfTestResult {
+	m.mu.RLock()
+	testers := make(map[string]types.SelfTester)
+	for name, ext := range m.extensions {
+		if lz, ok := m.lazy[name]; ok && !lz.activated.Load() {
+			continue
+		}
+		if tester, ok := ext.Instance.(types.SelfTester); ok {
+			testers[name] = tester
+		}
+	}
+	m.mu.RUnlock()
+
+	results := make(map[string]types.SelfTestResult, len(testers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, tester := range testers {
+		wg.Add(1)
+		go func(name string, tester types.SelfTester) {
+			defer wg.Done()
+			result := m.runSelfTest(ctx, name, tester)
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, tester)
+	}
+	wg.Wait()
+
+	if failed := countFailedSelfTests(results); failed > 0 {
+		logger.Warnf(nil, "%d of %d extension self-tests failed", failed, len(results))
+	}
+	return results
+}
+
+// runSelfTest runs one extension self-test bounded by the configured timeout
+func (m *Manager) runSelfTest(ctx context.Context, name string, tester types.SelfTester) types.SelfTestResult {
+	timeout := m.healthCheckConfig().GetSelfTestTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("self-test panic: %v", r)
+			}
+		}()
+		done <- tester.SelfTest(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("self-test timed out after %v", timeout)
+	}
+
+	result := types.SelfTestResult{
+		Passed:   err == nil,
+		Duration: time.Since(start),
+		RanAt:    time.Now(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		logger.Errorf(nil, "self-test of extension %s failed: %v", name, err)
+	} else {
+		logger.Debugf(nil, "self-test of extension %s passed in %v", name, result.Duration)
+	}
+
+	m.health.mu.Lock()
+	m.health.selfTests[name] = result
+	m.health.mu.Unlock()
+	return result
+}
+
+// selfTestExtension runs the self-test of a single extension if it has one
+func (m *Manager) selfTestExtension(name string, instance types.Interface) {
+	if tester, ok := instance.(types.SelfTester); ok {
+		m.runSelfTest(m.ctx, name, tester)
+	}
+}
+
+// GetSelfTestResults returns the latest self-test result of every extension that has one
+func (m *Manager) GetSelfTestResults() map[string]types.SelfTestResult {
+	m.health.mu.RLock()
+	defer m.health.mu.RUnlock()
+
+	result := make(map[string]types.SelfTestResult, len(m.health.selfTests))
+	for name, r := range m.health.selfTests {
+		result[name] = r
+	}
+	return result
+}
+
+// IsReady reports whether extensions are initialized and, when self-tests
+// are required, every self-test passed
+func (m *Manager) IsReady() bool {
+	m.mu.RLock()
+	initialized := m.initialized
+	m.mu.RUnlock()
+	if !initialized {
+		return false
+	}
+
+	if !m.healthCheckConfig().SelfTestRequired {
+		return true
+	}
+	return countFailedSelfTests(m.GetSelfTestResults()) == 0
+}
+
+// applySelfTest marks a healthy extension with a failed self-test degraded
+func applySelfTest(report *types.HealthReport, result types.SelfTestResult) {
+	if result.Passed {
+		return
+	}
+	if report.Status == types.HealthStatusHealthy {
+		report.Status = types.HealthStatusDegraded
+	}
+
+	details := make(map[string]any, len(report.Details)+1)
+	for k, v := range report.Details {
+		details[k] = v
+	}
+	details["self_test"] = result
+	report.Details = details
+}
+
+// countFailedSelfTests counts failed self-tests
+func countFailedSelfTests(results map[string]types.SelfTestResult) int {
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	return failed
+}
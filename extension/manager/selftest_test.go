@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+// testedExtension runs selfTest as its self-test
+type testedExtension struct {
+	*testExtension
+	selfTest func(ctx context.Context) error
+}
+
+func (e *testedExtension) SelfTest(ctx context.Context) error { return e.selfTest(ctx) }
+
+func TestSelfTestsGateReadiness(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		HealthCheck: &extconfig.HealthCheckConfig{SelfTestTimeout: "50ms", SelfTestRequired: true, CacheTTL: "1h"},
+	})
+
+	var broken atomic.Bool
+	broken.Store(true)
+	for _, ext := range []types.Interface{
+		&testedExtension{&testExtension{name: "ok"}, func(context.Context) error { return nil }},
+		&testedExtension{&testExtension{name: "flaky"}, func(context.Context) error {
+			if broken.Load() {
+				return errors.New("table missing")
+			}
+			return nil
+		}},
+		&testExtension{name: "plain"},
+	} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.IsReady() {
+		t.Fatal("manager should not be ready before initialization")
+	}
+	m.initialized = true
+
+	results := m.RunSelfTests(context.Background())
+	if len(results) != 2 || !results["ok"].Passed || results["flaky"].Passed || results["flaky"].Error != "table missing" {
+		t.Fatalf("results = %+v", results)
+	}
+	if m.IsReady() {
+		t.Fatal("a failed required self-test should block readiness")
+	}
+
+	// A failed self-test degrades an otherwise healthy extension
+	report, err := m.GetExtensionHealthByName("flaky")
+	if err != nil || report.Status != types.HealthStatusDegraded || report.Details["self_test"] == nil {
+		t.Fatalf("health of flaky = %+v, %v", report, err)
+	}
+	if report, _ := m.GetExtensionHealthByName("ok"); report.Status != types.HealthStatusHealthy {
+		t.Fatalf("health of ok = %+v", report)
+	}
+
+	broken.Store(false)
+	m.RunSelfTests(context.Background())
+	if !m.IsReady() {
+		t.Fatalf("manager should be ready once self-tests pass, results %+v", m.GetSelfTestResults())
+	}
+}
+
+func TestSelfTestTimeoutsAndPanics(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		HealthCheck: &extconfig.HealthCheckConfig{SelfTestTimeout: "50ms"},
+	})
+	for _, ext := range []types.Interface{
+		&testedExtension{&testExtension{name: "slow"}, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}},
+		&testedExtension{&testExtension{name: "panics"}, func(context.Context) error { panic("boom") }},
+	} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.initialized = true
+
+	results := m.RunSelfTests(context.Background())
+	for name, want := range map[string]string{"slow": "timed out", "panics": "panic"} {
+		if r := results[name]; r.Passed || !strings.Contains(r.Error, want) {
+			t.Errorf("%s: result %+v, want an error containing %q", name, r, want)
+		}
+	}
+
+	// Failures only block readiness when self-tests are required
+	if !m.IsReady() {
+		t.Fatal("optional self-tests should not block readiness")
+	}
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/logging/observes"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// initSentry sets up Sentry from observes.sentry. Server errors written with
+// resp.Fail, recovered panics and log entries at observes.sentry.log_level or
+// above are reported with the user and trace of their request.
+func (m *Manager) initSentry() {
+	conf := m.conf.Load()
+	if conf.Observes == nil || conf.Observes.Sentry == nil || conf.Observes.Sentry.Endpoint == "" {
+		return
+	}
+
+	c := conf.Observes.Sentry
+	err := observes.NewSentry(&observes.SentryOptions{
+		Dsn:         c.Endpoint,
+		Name:        conf.AppName,
+		Release:     c.Release,
+		Environment: c.Environment,
+		SampleRate:  c.SampleRate,
+	})
+	if err != nil {
+		logger.Errorf(nil, "Failed to initialize Sentry: %v", err)
+		return
+	}
+
+	resp.SetErrorReporter(observes.SentryReporter{})
+	if c.LogLevel != "" && c.LogLevel != "none" {
+		hook, err := observes.NewSentryHook(c.LogLevel)
+		if err != nil {
+			logger.Warnf(nil, "Log entries are not sent to Sentry: %v", err)
+		} else {
+			logger.AddHook(hook)
+		}
+	}
+	m.sentry = true
+}
+
+// shutdownSentry sends the events still queued
+func (m *Manager) shutdownSentry() {
+	if !m.sentry {
+		return
+	}
+
+	resp.SetErrorReporter(nil)
+	if !observes.FlushSentry(5 * time.Second) {
+		logger.Warnf(nil, "Timed out sending queued Sentry events")
+	}
+	m.sentry = false
+}
@@ -0,0 +1,239 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// startupConfig returns the startup config with defaults
+func (m *Manager) startupConfig() *config.StartupConfig {
+	conf := m.conf.Load()
+	if conf.Extension.Startup != nil {
+		return conf.Extension.Startup
+	}
+	return &config.StartupConfig{}
+}
+
+// buildInitLevels groups extensions into levels of the dependency DAG.
+// Extensions in a level only depend on extensions in earlier levels.
+func buildInitLevels(order []string, extensions map[string]*types.Wrapper, dependencyGraph map[string][]string) [][]string {
+	level := make(map[string]int, len(order))
+	var levels [][]string
+
+	// order is topological, so dependencies are always assigned first
+	for _, name := range order {
+		deps := dependencyGraph[name]
+		if dependencyGraph == nil {
+			deps = extensions[name].Instance.Dependencies()
+		}
+
+		l := 0
+		for _, dep := range deps {
+			if dl, ok := level[dep]; ok && dl+1 > l {
+				l = dl + 1
+			}
+		}
+		level[name] = l
+
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], name)
+	}
+
+	for _, names := range levels {
+		sort.Strings(names)
+	}
+	return levels
+}
+
+// initializeExtensionsInParallel runs each phase level by level, extensions within a level concurrently
+func (m *Manager) initializeExtensionsInParallel(ctx context.Context, levels [][]string) error {
+	cfg := m.startupConfig()
+	phases := []struct {
+		name string
+		fn   func(types.Interface) error
+	}{
+		{"PreInit", func(ext types.Interface) error { return ext.PreInit() }},
+		{"Init", func(ext types.Interface) error { return ext.Init(m.conf.Load(), m) }},
+		{"PostInit", func(ext types.Interface) error { return ext.PostInit() }},
+	}
+
+	total := 0
+	for _, level := range levels {
+		total += len(level)
+		for _, name := range level {
+			m.mu.RLock()
+			ext := m.extensions[name]
+			m.mu.RUnlock()
+			m.injectLogger(name, ext.Instance)
+			if err := m.injectFileSystem(name, ext.Instance); err != nil {
+				return err
+			}
+		}
+	}
+
+	start := time.Now()
+	for _, phase := range phases {
+		phaseCtx, cancel := context.WithTimeout(ctx, cfg.GetPhaseTimeout())
+
+		for _, level := range levels {
+			err := m.runParallel(phaseCtx, level, cfg.GetConcurrency(), func(name string) error {
+				m.mu.RLock()
+				ext := m.extensions[name]
+				m.mu.RUnlock()
+				phaseStart := time.Now()
+
+				ran, err := m.runInitPhase(name, phase.name, phase.fn)
+				if err != nil {
+					logger.Errorf(nil, "Failed %s of extension %s: %v", phase.name, name, err)
+					return fmt.Errorf("%s of extension %s failed: %w", phase.name, name, err)
+				}
+				if !ran {
+					return nil
+				}
+
+				if phase.name == "Init" {
+					m.trackExtensionInitialized(name, time.Since(phaseStart), nil)
+				}
+				if phase.name == "PostInit" {
+					m.publishExtensionReadyEvent(name, ext)
+					m.startConsumers(name)
+				}
+				return nil
+			})
+			if err != nil {
+				cancel()
+				return fmt.Errorf("%s phase failed: %w", phase.name, err)
+			}
+		}
+
+		cancel()
+	}
+
+	logger.Debugf(nil, "Initialized %d extensions in %d levels in %v", total, len(levels), time.Since(start))
+	return nil
+}
+
+// runParallel runs fn for each name with a concurrency limit until all finish or ctx is done.
+// Functions still running when ctx is done are abandoned.
+func (m *Manager) runParallel(ctx context.Context, names []string, concurrency int, fn func(name string) error) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var (
+		mu      sync.Mutex
+		errs    []error
+		pending = make(map[string]bool, len(names))
+		wg      sync.WaitGroup
+	)
+	for _, name := range names {
+		pending[name] = true
+	}
+
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			err := func() (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("extension %s panicked: %v", name, r)
+					}
+				}()
+				return fn(name)
+			}()
+
+			mu.Lock()
+			delete(pending, name)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			mu.Unlock()
+		}(name)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		mu.Lock()
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("timed out waiting for extensions: %s", strings.Join(names, ", "))
+	}
+
+	return errors.Join(errs...)
+}
+
+// shutdownGroups returns extensions grouped in reverse initialization order.
+// Extensions loaded after startup, e.g. plugins or lazy extensions, are shut down first.
+// Lazy extensions never activated have nothing to shut down and are left out.
+func (m *Manager) shutdownGroups() [][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Pending lazy extensions count as known, so they join no group
+	known := make(map[string]bool, len(m.extensions))
+	for name, lz := range m.lazy {
+		if !lz.activated.Load() {
+			known[name] = true
+		}
+	}
+
+	var groups [][]string
+	for i := len(m.initLevels) - 1; i >= 0; i-- {
+		var group []string
+		for _, name := range m.initLevels[i] {
+			if _, ok := m.extensions[name]; ok && !known[name] {
+				group = append(group, name)
+				known[name] = true
+			}
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	var extras []string
+	for name := range m.extensions {
+		if !known[name] {
+			extras = append(extras, name)
+		}
+	}
+	if len(extras) > 0 {
+		sort.Strings(extras)
+		groups = slices.Insert(groups, 0, extras)
+	}
+
+	return groups
+}
@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+)
+
+func TestBuildInitLevels(t *testing.T) {
+	graph := map[string][]string{
+		"audit":  {"users"},
+		"notes":  {"users", "files"},
+		"search": {"notes", "audit"},
+	}
+	levels := buildInitLevels([]string{"users", "files", "audit", "notes", "search"}, nil, graph)
+
+	want := [][]string{{"files", "users"}, {"audit", "notes"}, {"search"}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Fatalf("levels = %v, want %v", levels, want)
+	}
+}
+
+func TestInitializeExtensionsInParallel(t *testing.T) {
+	m := newTestManager(t, &config.Extension{Startup: &extconfig.StartupConfig{Parallel: true, Concurrency: 2}})
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	// Both extensions of the first level must run Init at the same time to pass
+	barrier := make(chan struct{}, 2)
+	level0 := func() error {
+		barrier <- struct{}{}
+		for len(barrier) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+	exts := []*testExtension{
+		{name: "users", version: "1.0.0", init: level0},
+		{name: "files", version: "1.0.0", init: level0},
+		{name: "notes", version: "1.0.0", deps: []string{"users", "files"}},
+	}
+	for _, ext := range exts {
+		init := ext.init
+		ext.init = func() error {
+			mu.Lock()
+			order = append(order, ext.name)
+			mu.Unlock()
+			if init != nil {
+				return init()
+			}
+			return nil
+		}
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	levels := [][]string{{"files", "users"}, {"notes"}}
+	if err := m.initializeExtensionsInParallel(context.Background(), levels); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[2] != "notes" {
+		t.Fatalf("init order = %v, want notes after its dependencies", order)
+	}
+	for _, ext := range exts {
+		if n := ext.inits.Load(); n != 1 {
+			t.Fatalf("%s initialized %d times, want 1", ext.name, n)
+		}
+	}
+}
+
+func TestInitializeExtensionsInParallelStopsAtFailedLevel(t *testing.T) {
+	m := newTestManager(t, &config.Extension{Startup: &extconfig.StartupConfig{Parallel: true}})
+
+	users := &testExtension{name: "users", version: "1.0.0", init: func() error { return errors.New("database not ready") }}
+	notes := &testExtension{name: "notes", version: "1.0.0", deps: []string{"users"}}
+	for _, ext := range []*testExtension{users, notes} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := m.initializeExtensionsInParallel(context.Background(), [][]string{{"users"}, {"notes"}})
+	if err == nil || !strings.Contains(err.Error(), "Init of extension users failed") {
+		t.Fatalf("err = %v, want the failed Init of users", err)
+	}
+	if n := notes.inits.Load(); n != 0 {
+		t.Fatalf("dependent initialized %d times after its dependency failed", n)
+	}
+}
+
+func TestRunParallelNamesTimedOutExtensions(t *testing.T) {
+	m := newTestManager(t, nil)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.runParallel(ctx, []string{"fast", "slow"}, 2, func(name string) error {
+		if name == "slow" {
+			<-release
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "timed out waiting for extensions: slow" {
+		t.Fatalf("err = %v, want a timeout naming slow", err)
+	}
+}
+
+func TestShutdownGroupsReverseInitLevels(t *testing.T) {
+	m := newTestManager(t, nil)
+	for _, name := range []string{"users", "files", "notes", "plugin"} {
+		if err := m.RegisterExtension(&testExtension{name: name, version: "1.0.0"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.initLevels = [][]string{{"files", "users"}, {"notes"}, {"removed"}}
+
+	// Extensions registered after startup go first, levels no longer registered are left out
+	want := [][]string{{"plugin"}, {"notes"}, {"files", "users"}}
+	if groups := m.shutdownGroups(); !reflect.DeepEqual(groups, want) {
+		t.Fatalf("shutdown groups = %v, want %v", groups, want)
+	}
+}
@@ -0,0 +1,222 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/concurrency/scheduler"
+	"github.com/ncobase/ncore/data/lock"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// taskJobName returns the scheduler job name of an extension task
+func taskJobName(extension, task string) string {
+	return extension + "." + task
+}
+
+// SetTaskLocker sets the locker electing the node that runs scheduled tasks.
+// Call before InitExtensions, Redis is used by default when configured.
+func (m *Manager) SetTaskLocker(l lock.Locker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.taskLocker = l
+}
+
+// taskScheduler returns the scheduler of extension tasks, nil before startup or when disabled
+func (m *Manager) taskScheduler() *scheduler.Scheduler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tasks
+}
+
+// startTasks schedules the tasks declared by started extensions and the data
+// layer maintenance tasks
+func (m *Manager) startTasks() {
+	cfg := m.conf.Load().Extension.Tasks
+	if !cfg.IsEnabled() {
+		return
+	}
+
+	opts := scheduler.Options{
+		OnError: func(job string, err error) {
+			logger.Errorf(nil, "scheduled task %s failed: %v", job, err)
+		},
+	}
+	if cfg != nil {
+		opts.LockPrefix = cfg.LockPrefix
+		opts.HistorySize = cfg.HistorySize
+		if cfg.Timezone != "" {
+			loc, err := time.LoadLocation(cfg.Timezone)
+			if err != nil {
+				logger.Errorf(nil, "invalid tasks timezone %s: %v", cfg.Timezone, err)
+				return
+			}
+			opts.Location = loc
+		}
+	}
+
+	m.mu.Lock()
+	if m.taskLocker == nil {
+		m.taskLocker = m.defaultTaskLocker()
+	}
+	opts.Locker = m.taskLocker
+	s := scheduler.New(opts)
+	m.tasks = s
+	extensions := make(map[string]types.Interface, len(m.extensions))
+	for name, ext := range m.extensions {
+		if lz, ok := m.lazy[name]; ok && !lz.activated.Load() {
+			continue
+		}
+		extensions[name] = ext.Instance
+	}
+	m.mu.Unlock()
+
+	for name, instance := range extensions {
+		m.scheduleTasks(name, instance)
+	}
+	m.scheduleMaintenance(s)
+	s.Start()
+}
+
+// defaultTaskLocker returns a Redis locker if Redis is available
+func (m *Manager) defaultTaskLocker() lock.Locker {
+	if m.data == nil {
+		return nil
+	}
+	rc, ok := m.data.GetRedis().(*redis.Client)
+	if !ok || rc == nil {
+		return nil
+	}
+	l, err := lock.NewRedisLocker("", rc)
+	if err != nil {
+		logger.Warnf(nil, "failed to create task locker: %v", err)
+		return nil
+	}
+	return l
+}
+
+// scheduleTasks registers the tasks declared in an extension's metadata
+func (m *Manager) scheduleTasks(name string, instance types.Interface) {
+	s := m.taskScheduler()
+	tasks := instance.GetMetadata().Tasks
+	if s == nil || len(tasks) == 0 {
+		return
+	}
+
+	var handlers map[string]types.TaskHandler
+	if provider, ok := instance.(types.TaskProvider); ok {
+		handlers = provider.TaskHandlers()
+	}
+
+	m.mu.RLock()
+	elected := m.taskLocker != nil
+	m.mu.RUnlock()
+
+	for _, task := range tasks {
+		handler := handlers[task.Handler]
+		if handler == nil {
+			logger.Errorf(nil, "task %s of extension %s references unknown handler %s", task.Name, name, task.Handler)
+			continue
+		}
+
+		var timeout time.Duration
+		if task.Timeout != "" {
+			d, err := time.ParseDuration(task.Timeout)
+			if err != nil {
+				logger.Errorf(nil, "task %s of extension %s has invalid timeout: %v", task.Name, name, err)
+				continue
+			}
+			timeout = d
+		}
+
+		singleton := !task.AllNodes
+		if singleton && !elected {
+			logger.Warnf(nil, "no task locker, task %s of extension %s runs on every node", task.Name, name)
+			singleton = false
+		}
+
+		job := scheduler.Job{
+			Name:      taskJobName(name, task.Name),
+			Spec:      task.Schedule,
+			Func:      handler,
+			Timeout:   timeout,
+			Singleton: singleton,
+		}
+		if err := s.Add(job); err != nil {
+			logger.Errorf(nil, "failed to schedule task %s of extension %s: %v", task.Name, name, err)
+		}
+	}
+}
+
+// unscheduleTasksLocked removes the tasks of an extension instance, runs in progress
+// finish. Caller must hold m.mu.
+func (m *Manager) unscheduleTasksLocked(name string, instance types.Interface) {
+	if m.tasks == nil {
+		return
+	}
+	for _, task := range instance.GetMetadata().Tasks {
+		_ = m.tasks.Remove(taskJobName(name, task.Name))
+	}
+}
+
+// stopTasks stops scheduling and waits for running tasks within the stop timeout
+func (m *Manager) stopTasks() {
+	conf := m.conf.Load()
+	m.mu.Lock()
+	s := m.tasks
+	m.tasks = nil
+	m.mu.Unlock()
+	if s == nil {
+		return
+	}
+
+	timeout := time.Minute
+	if conf.Extension.Startup != nil {
+		timeout = conf.Extension.Startup.GetStopTimeout()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		logger.Warnf(nil, "scheduled tasks still running after %v: %v", timeout, err)
+	}
+}
+
+// GetScheduledTasks returns the state of all scheduled extension and maintenance tasks
+func (m *Manager) GetScheduledTasks() []scheduler.JobInfo {
+	s := m.taskScheduler()
+	if s == nil {
+		return []scheduler.JobInfo{}
+	}
+	return s.Jobs()
+}
+
+// GetScheduledTask returns the state of a task, named "<extension>.<task>" or
+// "maintenance.<task>"
+func (m *Manager) GetScheduledTask(name string) (scheduler.JobInfo, error) {
+	s := m.taskScheduler()
+	if s == nil {
+		return scheduler.JobInfo{}, fmt.Errorf("%w: %s", scheduler.ErrJobNotFound, name)
+	}
+	return s.Job(name)
+}
+
+// GetTaskHistory returns the most recent runs of a task, newest first
+func (m *Manager) GetTaskHistory(name string) ([]scheduler.Run, error) {
+	s := m.taskScheduler()
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s", scheduler.ErrJobNotFound, name)
+	}
+	return s.History(name)
+}
+
+// TriggerTask runs a task now on this node
+func (m *Manager) TriggerTask(name string) error {
+	s := m.taskScheduler()
+	if s == nil {
+		return fmt.Errorf("%w: %s", scheduler.ErrJobNotFound, name)
+	}
+	return s.Trigger(name)
+}
@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/logging/observes"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// initTracer sets up the OpenTelemetry tracer provider from observes.tracer,
+// which the data, event and gRPC spans are recorded with. Tracing is off
+// without an endpoint unless spans are written to stdout.
+func (m *Manager) initTracer() {
+	conf := m.conf.Load()
+	if conf.Observes == nil || conf.Observes.Tracer == nil {
+		return
+	}
+
+	c := conf.Observes.Tracer
+	if c.Exporter == "none" || c.Endpoint == "" && c.Exporter != "stdout" {
+		return
+	}
+	name := c.ServiceName
+	if name == "" {
+		name = conf.AppName
+	}
+
+	err := observes.NewTracer(&observes.TracerOption{
+		URL:                c.Endpoint,
+		Name:               name,
+		Version:            c.ServiceVersion,
+		Environment:        c.Environment,
+		Exporter:           c.Exporter,
+		Sampler:            c.Sampler,
+		SamplingRate:       c.SamplingRate,
+		MaxExportBatchSize: c.MaxExportBatchSize,
+		BatchTimeout:       c.BatchTimeout,
+		ExportTimeout:      c.ExportTimeout,
+		MaxQueueSize:       c.MaxQueueSize,
+		MaxAttributes:      c.MaxAttributes,
+		MaxAttributeLength: c.MaxAttributeLength,
+		MaxEventsPerSpan:   c.MaxEventsPerSpan,
+		MaxLinksPerSpan:    c.MaxLinksPerSpan,
+		Headers:            c.Headers,
+		TLSEnabled:         c.TLSEnabled,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		TLSCertFile:        c.TLSCertFile,
+		TLSKeyFile:         c.TLSKeyFile,
+		TLSCAFile:          c.TLSCAFile,
+	})
+	if err != nil {
+		logger.Errorf(nil, "Failed to initialize tracer: %v", err)
+		return
+	}
+	m.tracing = true
+}
+
+// traceSpans records each request as a server span named after its route
+// template, continuing the trace of the caller's traceparent header. The
+// request context carries the span IDs, so log entries match the trace.
+func (m *Manager) traceSpans(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched route"
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := otel.Tracer("github.com/ncobase/ncore/extension").Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", c.Request.URL.Path),
+		),
+	)
+	defer span.End()
+	if sc := span.SpanContext(); sc.IsValid() {
+		ctx = ctxutil.SetTraceID(ctx, sc.TraceID().String())
+		ctx = ctxutil.SetSpanID(ctx, sc.SpanID().String())
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	for _, err := range c.Errors {
+		span.RecordError(err.Err)
+	}
+	if status >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+}
+
+// shutdownTracer exports the spans still queued
+func (m *Manager) shutdownTracer() {
+	if !m.tracing {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := observes.ShutdownTracer(ctx); err != nil {
+		logger.Warnf(nil, "Failed to shut down tracer: %v", err)
+	}
+	m.tracing = false
+}
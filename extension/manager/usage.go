@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/extension/usage"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/net/resp"
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker"
+)
+
+// initUsage creates the recorder of extension API usage, stored in Redis when
+// available unless storage is memory
+func (m *Manager) initUsage() {
+	cfg := m.conf.Load().Extension.Usage
+	if !cfg.IsEnabled() {
+		return
+	}
+
+	opts := usage.Options{
+		Bucket:        cfg.GetBucket(),
+		FlushInterval: cfg.GetFlushInterval(),
+		Retention:     cfg.GetRetention(),
+	}
+	if cfg.Storage != "memory" && m.data != nil {
+		if rc, ok := m.data.GetRedis().(*redis.Client); ok && rc != nil {
+			opts.Store = usage.NewRedisStore(rc, cfg.KeyPrefix, opts.Retention)
+		} else if cfg.Storage == "redis" {
+			logger.Warnf(nil, "Redis usage storage requested but not available, keeping usage in memory")
+		}
+	}
+	m.usage = usage.NewRecorder(opts)
+}
+
+// stopUsage writes the pending usage rollups
+func (m *Manager) stopUsage() {
+	if m.usage == nil {
+		return
+	}
+	if err := m.usage.Close(); err != nil {
+		logger.Warnf(nil, "Failed to flush API usage: %v", err)
+	}
+	m.usage = nil
+}
+
+// routeMiddleware returns the middleware of an extension's routes: usage
+// recording when enabled, then panic isolation
+func (m *Manager) routeMiddleware(name string, cb *gobreaker.CircuitBreaker) []gin.HandlerFunc {
+	if m.usage == nil {
+		return []gin.HandlerFunc{m.recoverRoutes(name, cb)}
+	}
+	return []gin.HandlerFunc{m.recordUsage(name), m.recoverRoutes(name, cb)}
+}
+
+// recordUsage counts calls of an extension's routes by route template and consumer
+func (m *Manager) recordUsage(name string) gin.HandlerFunc {
+	recorder := m.usage
+	header := m.conf.Load().Extension.Usage.APIKeyHeader
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched route"
+		}
+		recorder.Record(name, c.Request.Method+" "+route, usageConsumer(c, header), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// usageConsumer identifies the caller by user ID, then by a hash of its API key
+func usageConsumer(c *gin.Context, header string) string {
+	if uid := ctxutil.GetUserID(ctxutil.WithGinContext(c.Request.Context(), c)); uid != "" {
+		return "user:" + uid
+	}
+	if header != "" {
+		if apiKey := c.GetHeader(header); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(sum[:6])
+		}
+	}
+	return usage.Anonymous
+}
+
+// setupUsageRoutes serves usage analytics. Every endpoint takes the extension,
+// route and consumer filters and a from/to range (RFC 3339, default the last 24h).
+func (m *Manager) setupUsageRoutes(r *gin.RouterGroup) {
+	usageGroup := r.Group("/usage")
+
+	// Usage per extension, e.g. for chargeback
+	usageGroup.GET("", func(c *gin.Context) {
+		rollups, ok := m.queryUsage(c)
+		if ok {
+			resp.Success(c.Writer, usage.Top(rollups, usage.ByExtension, 0))
+		}
+	})
+
+	// Most called routes, e.g. to find callers of a route before deprecating it
+	usageGroup.GET("/routes", func(c *gin.Context) {
+		rollups, ok := m.queryUsage(c)
+		if ok {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+			resp.Success(c.Writer, usage.Top(rollups, usage.ByRoute, limit))
+		}
+	})
+
+	// Top consumers
+	usageGroup.GET("/consumers", func(c *gin.Context) {
+		rollups, ok := m.queryUsage(c)
+		if ok {
+			limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+			resp.Success(c.Writer, usage.Top(rollups, usage.ByConsumer, limit))
+		}
+	})
+
+	// Calls per interval, default 1h
+	usageGroup.GET("/trend", func(c *gin.Context) {
+		interval, err := time.ParseDuration(c.DefaultQuery("interval", "1h"))
+		if err != nil || interval <= 0 {
+			resp.Fail(c.Writer, resp.BadRequest("Invalid interval: %s", c.Query("interval")))
+			return
+		}
+		rollups, ok := m.queryUsage(c)
+		if ok {
+			resp.Success(c.Writer, usage.Trend(rollups, interval))
+		}
+	})
+}
+
+// queryUsage returns the rollups selected by the request query, writing the
+// error response when it fails
+func (m *Manager) queryUsage(c *gin.Context) ([]*usage.Rollup, bool) {
+	if m.usage == nil {
+		resp.Fail(c.Writer, resp.NotFound("usage analytics not enabled"))
+		return nil, false
+	}
+
+	q := &usage.Query{
+		Extension: c.Query("extension"),
+		Route:     c.Query("route"),
+		Consumer:  c.Query("consumer"),
+		From:      time.Now().Add(-24 * time.Hour),
+		To:        time.Now(),
+	}
+	for param, t := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		if v := c.Query(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				resp.Fail(c.Writer, resp.BadRequest("Invalid %s: %v", param, err))
+				return nil, false
+			}
+			*t = parsed
+		}
+	}
+
+	rollups, err := m.usage.Query(c.Request.Context(), q)
+	if err != nil {
+		resp.Fail(c.Writer, resp.InternalServer("Failed to query usage: %v", err))
+		return nil, false
+	}
+	return rollups, true
+}
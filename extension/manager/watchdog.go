@@ -0,0 +1,176 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// errPhaseTimeout is returned when a lifecycle phase outlives its watchdog
+var errPhaseTimeout = errors.New("timed out")
+
+// failedExtension an extension taken out of service after a lifecycle phase timed out
+type failedExtension struct {
+	ext   *types.Wrapper
+	phase string
+	err   error
+	at    time.Time
+}
+
+// runPhase runs a lifecycle phase under a watchdog. A phase exceeding timeout is
+// abandoned, its goroutine keeps running but no longer blocks the caller.
+func runPhase(timeout time.Duration, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w after %v", errPhaseTimeout, timeout)
+	}
+}
+
+// runInitPhase runs an init phase of an extension under its watchdog and reports
+// whether it ran. Extensions timing out, or depending on one that did, are marked
+// failed instead of returning an error so the remaining extensions keep booting.
+func (m *Manager) runInitPhase(name, phase string, fn func(types.Interface) error) (bool, error) {
+	m.mu.RLock()
+	ext, exists := m.extensions[name]
+	m.mu.RUnlock()
+	if !exists {
+		return false, nil
+	}
+
+	if dep := m.failedDependency(ext.Instance); dep != "" {
+		m.markFailed(name, phase, fmt.Errorf("dependency %s failed", dep))
+		return false, nil
+	}
+
+	err := runPhase(m.conf.Load().Extension.GetInitTimeout(name), func() error { return fn(ext.Instance) })
+	if errors.Is(err, errPhaseTimeout) {
+		m.markFailed(name, phase, err)
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// runStopPhases runs the cleanup phases of an extension instance under its watchdog
+func (m *Manager) runStopPhases(name string, ext types.Interface) error {
+	timeout := m.conf.Load().Extension.GetStopTimeout(name)
+	if err := runPhase(timeout, ext.PreCleanup); err != nil {
+		logger.Errorf(nil, "failed pre-cleanup of extension %s %s: %v", name, ext.Version(), err)
+	}
+	if err := runPhase(timeout, ext.Cleanup); err != nil {
+		logger.Errorf(nil, "failed cleanup of extension %s %s: %v", name, ext.Version(), err)
+		return err
+	}
+	return nil
+}
+
+// failedDependency returns a strong dependency of an extension that failed
+func (m *Manager) failedDependency(ext types.Interface) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, dep := range strongDependencies(ext) {
+		if _, ok := m.failed[dep]; ok {
+			return dep
+		}
+	}
+	return ""
+}
+
+// markFailed takes an extension out of service and reports it errored
+func (m *Manager) markFailed(name, phase string, err error) {
+	m.mu.Lock()
+	ext, exists := m.extensions[name]
+	if exists {
+		delete(m.extensions, name)
+		m.failed[name] = &failedExtension{ext: ext, phase: phase, err: err, at: time.Now()}
+	}
+	m.mu.Unlock()
+	if !exists {
+		return
+	}
+	m.stopConsumers(name)
+
+	logger.Errorf(nil, "Extension %s marked errored, %s failed: %v", name, phase, err)
+	if phase == "Init" {
+		m.trackExtensionInitialized(name, 0, err)
+	}
+
+	eventName := fmt.Sprintf("exts.%s.failed", name)
+	eventData := map[string]any{
+		"name":   name,
+		"status": types.StatusError,
+		"phase":  phase,
+		"error":  err.Error(),
+	}
+
+	// Always publish to memory
+	m.eventDispatcher.Publish(eventName, eventData)
+
+	// Async publish to queue if messaging enabled
+	if m.isMessagingEnabled() {
+		go func() {
+			m.PublishEvent(eventName, eventData, types.EventTargetQueue)
+		}()
+	}
+}
+
+// GetFailedExtensions returns extensions taken out of service by a lifecycle
+// watchdog, with the phase and error that caused it
+func (m *Manager) GetFailedExtensions() map[string]map[string]any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]map[string]any, len(m.failed))
+	for name, f := range m.failed {
+		result[name] = failedDetails(f)
+	}
+	return result
+}
+
+// cleanupFailedExtensions runs the cleanup phases of failed extensions, which
+// may hold resources acquired before their phase timed out
+func (m *Manager) cleanupFailedExtensions() {
+	m.mu.Lock()
+	failed := m.failed
+	m.failed = make(map[string]*failedExtension)
+	m.mu.Unlock()
+
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_ = m.runStopPhases(name, failed[name].ext.Instance)
+	}
+}
+
+// failedDetails describes a failed extension for status and health reports
+func failedDetails(f *failedExtension) map[string]any {
+	return map[string]any{
+		"extension_status": types.StatusError,
+		"phase":            f.phase,
+		"error":            f.err.Error(),
+		"failed_at":        f.at,
+	}
+}
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+func TestRunPhase(t *testing.T) {
+	boom := errors.New("boom")
+	if err := runPhase(time.Second, func() error { return boom }); err != boom {
+		t.Fatalf("err = %v, want the phase error", err)
+	}
+	if err := runPhase(time.Second, func() error { panic("nil map") }); err == nil || err.Error() != "panic: nil map" {
+		t.Fatalf("err = %v, want the recovered panic", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	start := time.Now()
+	err := runPhase(20*time.Millisecond, func() error { <-release; return nil })
+	if !errors.Is(err, errPhaseTimeout) {
+		t.Fatalf("err = %v, want errPhaseTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("blocked phase held the caller for %v", elapsed)
+	}
+}
+
+func TestInitTimeoutMarksExtensionFailed(t *testing.T) {
+	m := newTestManager(t, &config.Extension{
+		Settings: map[string]*extconfig.ExtensionSettings{"notes": {InitTimeout: "20ms"}},
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	notes := &testExtension{name: "notes", version: "1.0.0", init: func() error { <-release; return nil }}
+	search := &testExtension{name: "search", version: "1.0.0", deps: []string{"notes"}}
+	users := &testExtension{name: "users", version: "1.0.0"}
+	for _, ext := range []*testExtension{notes, search, users} {
+		if err := m.RegisterExtension(ext); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events := make(chan map[string]any, 1)
+	m.eventDispatcher.Subscribe("exts.notes.failed", func(data any) {
+		events <- data.(types.EventData).Data.(map[string]any)
+	})
+
+	init := func(ext types.Interface) error { return ext.Init(m.GetConfig(), m) }
+	for _, name := range []string{"notes", "search"} {
+		if ran, err := m.runInitPhase(name, "Init", init); ran || err != nil {
+			t.Fatalf("runInitPhase(%s) = %v, %v, want the extension marked failed", name, ran, err)
+		}
+	}
+	if ran, err := m.runInitPhase("users", "Init", init); !ran || err != nil {
+		t.Fatalf("runInitPhase(users) = %v, %v, want it to run", ran, err)
+	}
+	if n := search.inits.Load(); n != 0 {
+		t.Fatalf("dependent of a failed extension initialized %d times", n)
+	}
+
+	failed := m.GetFailedExtensions()
+	if len(failed) != 2 || failed["notes"]["phase"] != "Init" || failed["notes"]["extension_status"] != types.StatusError ||
+		failed["search"]["error"] != "dependency notes failed" {
+		t.Fatalf("failed extensions = %v", failed)
+	}
+	if _, err := m.GetExtensionByName("notes"); err == nil {
+		t.Fatal("failed extension is still in service")
+	}
+
+	select {
+	case e := <-events:
+		if e["phase"] != "Init" || e["status"] != types.StatusError {
+			t.Fatalf("failed event = %v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed event was not published")
+	}
+
+	// Failed extensions may hold resources acquired before the timeout
+	m.cleanupFailedExtensions()
+	if n := notes.cleanups.Load(); n != 1 {
+		t.Fatalf("failed extension cleaned up %d times, want 1", n)
+	}
+	if failed := m.GetFailedExtensions(); len(failed) != 0 {
+		t.Fatalf("failed extensions after cleanup = %v", failed)
+	}
+}
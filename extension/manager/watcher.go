@@ -0,0 +1,253 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ncobase/ncore/extension/types"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/utils"
+)
+
+// pluginWatcher watches plugin directories and reloads changed plugin files
+type pluginWatcher struct {
+	fsw      *fsnotify.Watcher
+	debounce time.Duration
+	mu       sync.Mutex
+	pending  map[string]*time.Timer
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// StartPluginWatcher starts watching the plugin path for new or changed plugin files
+func (m *Manager) StartPluginWatcher() error {
+	conf := m.conf.Load()
+	if m.isBuiltInMode() {
+		return fmt.Errorf("plugin watcher is not available in built-in mode")
+	}
+
+	basePath := conf.Extension.Path
+	if basePath == "" {
+		return fmt.Errorf("no plugin path configured")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.watcher != nil {
+		return nil
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %v", err)
+	}
+
+	var watched []string
+	for _, dir := range []string{basePath, filepath.Join(basePath, "plugins")} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := fsw.Add(dir); err != nil {
+			logger.Warnf(nil, "failed to watch plugin directory %s: %v", dir, err)
+			continue
+		}
+		watched = append(watched, dir)
+	}
+
+	if len(watched) == 0 {
+		_ = fsw.Close()
+		return fmt.Errorf("no plugin directory to watch under %s", basePath)
+	}
+
+	debounce := 500 * time.Millisecond
+	if conf.Extension.Watcher != nil {
+		debounce = conf.Extension.Watcher.GetDebounceDuration()
+	}
+
+	w := &pluginWatcher{
+		fsw:      fsw,
+		debounce: debounce,
+		pending:  make(map[string]*time.Timer),
+		done:     make(chan struct{}),
+	}
+	m.watcher = w
+
+	w.wg.Add(1)
+	go m.watchPluginFiles(w)
+
+	logger.Infof(nil, "plugin watcher started on %v (debounce %v)", watched, debounce)
+	return nil
+}
+
+// StopPluginWatcher stops the plugin file watcher
+func (m *Manager) StopPluginWatcher() {
+	m.mu.Lock()
+	w := m.watcher
+	m.watcher = nil
+	m.mu.Unlock()
+
+	if w == nil {
+		return
+	}
+
+	close(w.done)
+	_ = w.fsw.Close()
+	w.wg.Wait()
+
+	w.mu.Lock()
+	for path, timer := range w.pending {
+		timer.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+
+	logger.Infof(nil, "plugin watcher stopped")
+}
+
+// IsPluginWatcherRunning returns whether the plugin file watcher is running
+func (m *Manager) IsPluginWatcherRunning() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.watcher != nil
+}
+
+// watchPluginFiles consumes file system events until the watcher is stopped
+func (m *Manager) watchPluginFiles(w *pluginWatcher) {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			if filepath.Ext(event.Name) != utils.GetPlatformExt() {
+				continue
+			}
+			w.schedule(event.Name, func(path string) {
+				m.reloadPluginFile(path)
+			})
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			logger.Warnf(nil, "plugin watcher error: %v", err)
+		}
+	}
+}
+
+// schedule debounces events for a path so a file being written is reloaded once
+func (w *pluginWatcher) schedule(path string, fn func(string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if timer, exists := w.pending[path]; exists {
+		timer.Stop()
+	}
+
+	w.pending[path] = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.pending, path)
+		w.mu.Unlock()
+
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		fn(path)
+	})
+}
+
+// reloadPluginFile validates and (re)loads a changed plugin file
+func (m *Manager) reloadPluginFile(path string) {
+	name := extractPluginName(path)
+
+	if !m.shouldLoadPlugin(name) {
+		logger.Debugf(nil, "ignoring change of plugin %s based on configuration", name)
+		return
+	}
+
+	start := time.Now()
+	err := m.hotReloadPlugin(name, path)
+	duration := time.Since(start)
+
+	if m.metricsCollector != nil {
+		m.metricsCollector.ExtensionReloaded(name, duration, err)
+	}
+
+	if err != nil {
+		logger.Errorf(nil, "hot reload of plugin %s failed: %v", name, err)
+	} else {
+		logger.Infof(nil, "plugin %s hot reloaded (took %v)", name, duration)
+		if ext, err := m.GetExtensionByName(name); err == nil {
+			go m.selfTestExtension(name, ext)
+		}
+	}
+
+	m.publishPluginReloadEvent(name, path, duration, err)
+}
+
+// hotReloadPlugin validates the new plugin file before replacing the loaded one
+func (m *Manager) hotReloadPlugin(name, path string) error {
+	// Validate before unloading so a bad file never replaces a working plugin
+	if m.sandbox != nil {
+		if err := m.sandbox.ValidatePluginPath(path); err != nil {
+			return fmt.Errorf("security validation failed: %v", err)
+		}
+		if err := m.sandbox.ValidatePluginSignature(path); err != nil {
+			return fmt.Errorf("signature validation failed: %v", err)
+		}
+	}
+
+	m.mu.RLock()
+	_, loaded := m.extensions[name]
+	m.mu.RUnlock()
+
+	if loaded {
+		if err := m.UnloadPlugin(name); err != nil {
+			return fmt.Errorf("failed to unload plugin: %v", err)
+		}
+	}
+
+	return m.LoadPlugin(path)
+}
+
+// publishPluginReloadEvent publishes the result of a plugin hot reload
+func (m *Manager) publishPluginReloadEvent(name, path string, duration time.Duration, err error) {
+	eventName := fmt.Sprintf("exts.%s.reloaded", name)
+	eventData := map[string]any{
+		"name":     name,
+		"path":     path,
+		"status":   "reloaded",
+		"duration": duration.String(),
+	}
+
+	if err != nil {
+		eventName = fmt.Sprintf("exts.%s.reload_failed", name)
+		eventData["status"] = "failed"
+		eventData["error"] = err.Error()
+	}
+
+	// Always publish to memory
+	m.eventDispatcher.Publish(eventName, eventData)
+
+	// Async publish to queue if messaging enabled
+	if m.isMessagingEnabled() {
+		go func() {
+			m.PublishEvent(eventName, eventData, types.EventTargetQueue)
+		}()
+	}
+}
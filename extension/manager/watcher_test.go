@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/config"
+	extconfig "github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/utils"
+)
+
+func TestPluginWatcherScheduleDebounces(t *testing.T) {
+	w := &pluginWatcher{
+		debounce: 30 * time.Millisecond,
+		pending:  make(map[string]*time.Timer),
+		done:     make(chan struct{}),
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	fn := func(path string) {
+		mu.Lock()
+		calls[path]++
+		mu.Unlock()
+	}
+
+	for range 5 {
+		w.schedule("a.so", fn)
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.schedule("b.so", fn)
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	if calls["a.so"] != 1 || calls["b.so"] != 1 {
+		t.Fatalf("expected one call per path, got %v", calls)
+	}
+	mu.Unlock()
+
+	// Nothing runs once the watcher is stopped
+	w.schedule("a.so", fn)
+	close(w.done)
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["a.so"] != 1 {
+		t.Fatalf("reload ran after stop: %v", calls)
+	}
+}
+
+func TestPluginWatcherReloadsChangedPlugins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "plugins"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestManager(t, &config.Extension{
+		Path:     dir,
+		Excludes: []string{"skipped"},
+		Watcher:  &extconfig.WatcherConfig{Enabled: true, Debounce: "50ms"},
+	})
+
+	// The reload of an invalid file fails, either way the watcher reported it
+	var reloads, skipped atomic.Int32
+	for _, name := range []string{"exts.notes.reloaded", "exts.notes.reload_failed"} {
+		m.eventDispatcher.Subscribe(name, func(any) { reloads.Add(1) })
+	}
+	for _, name := range []string{"exts.skipped.reloaded", "exts.skipped.reload_failed", "exts.readme.reload_failed"} {
+		m.eventDispatcher.Subscribe(name, func(any) { skipped.Add(1) })
+	}
+
+	if err := m.StartPluginWatcher(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.StopPluginWatcher()
+	if !m.IsPluginWatcherRunning() {
+		t.Fatal("watcher should be running")
+	}
+	if err := m.StartPluginWatcher(); err != nil {
+		t.Fatalf("starting twice: %v", err)
+	}
+
+	ext := utils.GetPlatformExt()
+	plugin := filepath.Join(dir, "plugins", "notes"+ext)
+	for i := range 3 {
+		if err := os.WriteFile(plugin, []byte{byte(i)}, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(dir, "skipped"+ext), filepath.Join(dir, "readme.txt")} {
+		if err := os.WriteFile(file, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for reloads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := reloads.Load(); got != 1 {
+		t.Fatalf("expected one debounced reload of notes, got %d", got)
+	}
+	if got := skipped.Load(); got != 0 {
+		t.Fatalf("excluded or unrelated files were reloaded %d times", got)
+	}
+
+	m.StopPluginWatcher()
+	if m.IsPluginWatcherRunning() {
+		t.Fatal("watcher should be stopped")
+	}
+	if err := os.WriteFile(plugin, []byte("again"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := reloads.Load(); got != 1 {
+		t.Fatalf("reload after stop, got %d", got)
+	}
+}
+
+func TestStartPluginWatcherRequiresDirectory(t *testing.T) {
+	m := newTestManager(t, &config.Extension{})
+	if err := m.StartPluginWatcher(); err == nil {
+		t.Fatal("expected an error without a plugin path")
+	}
+
+	m = newTestManager(t, &config.Extension{Path: filepath.Join(t.TempDir(), "missing")})
+	if err := m.StartPluginWatcher(); err == nil {
+		t.Fatal("expected an error without a plugin directory")
+	}
+	if m.IsPluginWatcherRunning() {
+		t.Fatal("watcher should not be running")
+	}
+}
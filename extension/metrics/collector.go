@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ncobase/ncore/concurrency/batch"
 	"github.com/ncobase/ncore/extension/config"
 	"github.com/ncobase/ncore/logging/logger"
 	"github.com/redis/go-redis/v9"
@@ -20,10 +21,12 @@ type Collector struct {
 	storage    Storage
 	enabled    bool
 	startTime  time.Time
+	exporters  []Exporter
 
 	// Background processing
 	batchBuffer []*Snapshot
 	batchSize   int
+	adaptive    *batch.Controller // Sizes flushes when adaptive batching is enabled
 	lastFlush   time.Time
 	flushTicker *time.Ticker
 	stopChan    chan struct{}
@@ -71,6 +74,28 @@ func NewCollector(cfg *config.MetricsConfig) *Collector {
 		},
 	}
 
+	if cfg.AdaptiveBatch {
+		latency := 100 * time.Millisecond
+		if d, err := time.ParseDuration(cfg.FlushLatency); err == nil && d > 0 {
+			latency = d
+		}
+		c.adaptive = batch.NewController(batch.AdaptiveOptions{
+			MinSize:       max(batchSize/10, 1),
+			MaxSize:       batchSize * 10,
+			InitialSize:   batchSize,
+			TargetLatency: latency,
+		})
+	}
+
+	for _, ec := range cfg.Exporters {
+		exporter, err := NewExporter(ec)
+		if err != nil {
+			logger.Errorf(nil, "Failed to create metrics exporter: %v", err)
+			continue
+		}
+		c.exporters = append(c.exporters, exporter)
+	}
+
 	// Start background flush routine
 	c.flushTicker = time.NewTicker(flushInterval)
 	c.wg.Add(1)
@@ -135,6 +160,13 @@ func (c *Collector) migrateFromMemoryToRedis(memStorage *MemoryStorage, redisSto
 	return nil
 }
 
+// AddExporter forwards subsequent snapshots to an exporter
+func (c *Collector) AddExporter(exporter Exporter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exporters = append(c.exporters, exporter)
+}
+
 // Stop gracefully stops the collector
 func (c *Collector) Stop() {
 	c.mu.Lock()
@@ -164,6 +196,12 @@ func (c *Collector) Stop() {
 	if c.storage != nil {
 		c.flushUnsafe()
 	}
+
+	for _, exporter := range c.exporters {
+		if err := exporter.Close(); err != nil {
+			logger.Warnf(nil, "Failed to close metrics exporter: %v", err)
+		}
+	}
 }
 
 // IsEnabled returns whether metrics collection is enabled
@@ -246,6 +284,48 @@ func (c *Collector) ExtensionUnloaded(name string) {
 	})
 }
 
+func (c *Collector) ExtensionReloaded(name string, duration time.Duration, err error) {
+	if !c.IsEnabled() || name == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.system.PluginReloads++
+	if err != nil {
+		c.system.PluginReloadFailures++
+	}
+
+	c.storeSnapshotUnsafe(&Snapshot{
+		ExtensionName: name,
+		MetricType:    "reload",
+		Value:         duration.Milliseconds(),
+		Labels:        map[string]string{"success": fmt.Sprintf("%t", err == nil)},
+		Timestamp:     time.Now(),
+	})
+}
+
+func (c *Collector) ExtensionLazyActivated(name string, duration time.Duration, err error) {
+	if !c.IsEnabled() || name == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics := c.getOrCreateExtensionMetrics(name)
+	metrics.LazyActivation = duration.Milliseconds()
+
+	c.storeSnapshotUnsafe(&Snapshot{
+		ExtensionName: name,
+		MetricType:    "lazy_activation",
+		Value:         duration.Milliseconds(),
+		Labels:        map[string]string{"success": fmt.Sprintf("%t", err == nil)},
+		Timestamp:     time.Now(),
+	})
+}
+
 // Service and event metrics
 
 func (c *Collector) ServiceCall(extensionName string, success bool) {
@@ -359,6 +439,54 @@ func (c *Collector) CircuitBreakerTripped(extensionName string) {
 	})
 }
 
+// RoutePanic records a panic recovered in an extension's HTTP route
+func (c *Collector) RoutePanic(extensionName, route string) {
+	if !c.IsEnabled() || extensionName == "" {
+		return
+	}
+
+	c.mu.RLock()
+	metrics, exists := c.extensions[extensionName]
+	c.mu.RUnlock()
+
+	if !exists {
+		c.mu.Lock()
+		metrics = c.getOrCreateExtensionMetrics(extensionName)
+		c.mu.Unlock()
+	}
+
+	metrics.routePanics.Add(1)
+	metrics.serviceErrors.Add(1)
+
+	c.storeSnapshot(&Snapshot{
+		ExtensionName: extensionName,
+		MetricType:    "route_panic",
+		Value:         1,
+		Labels:        map[string]string{"route": route},
+		Timestamp:     time.Now(),
+	})
+}
+
+// ExtensionRequest records an HTTP request served by one version of an
+// extension during a canary rollout
+func (c *Collector) ExtensionRequest(extensionName, track, version string, status int, duration time.Duration) {
+	if !c.IsEnabled() || extensionName == "" {
+		return
+	}
+
+	c.storeSnapshot(&Snapshot{
+		ExtensionName: extensionName,
+		MetricType:    "request",
+		Value:         duration.Milliseconds(),
+		Labels: map[string]string{
+			"track":   track,
+			"version": version,
+			"success": fmt.Sprintf("%t", status < 500),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
 // System metrics collection
 
 func (c *Collector) UpdateSystemMetrics() {
@@ -464,7 +592,11 @@ func (c *Collector) GetStorageStats() map[string]any {
 	if c.storage == nil {
 		return map[string]any{"status": "not_configured"}
 	}
-	return c.storage.GetStats()
+	stats := c.storage.GetStats()
+	if c.adaptive != nil {
+		stats["adaptive_batch"] = c.adaptive.Stats()
+	}
+	return stats
 }
 
 // Real-time access methods
@@ -494,6 +626,7 @@ func (c *Collector) GetExtensionMetrics(name string) *ExtensionMetrics {
 		EventsPublished:     metrics.eventsPublished.Load(),
 		EventsReceived:      metrics.eventsReceived.Load(),
 		CircuitBreakerTrips: metrics.circuitBreakerTrips.Load(),
+		RoutePanics:         metrics.routePanics.Load(),
 	}
 }
 
@@ -506,13 +639,15 @@ func (c *Collector) GetSystemMetrics() SystemMetrics {
 	defer c.mu.RUnlock()
 
 	return SystemMetrics{
-		StartTime:          c.system.StartTime,
-		MemoryUsageMB:      c.system.MemoryUsageMB,
-		GoroutineCount:     c.system.GoroutineCount,
-		GCCycles:           c.system.GCCycles,
-		ServicesRegistered: c.system.ServicesRegistered,
-		ServiceCacheHits:   c.system.ServiceCacheHits,
-		ServiceCacheMisses: c.system.ServiceCacheMisses,
+		StartTime:            c.system.StartTime,
+		MemoryUsageMB:        c.system.MemoryUsageMB,
+		GoroutineCount:       c.system.GoroutineCount,
+		GCCycles:             c.system.GCCycles,
+		ServicesRegistered:   c.system.ServicesRegistered,
+		ServiceCacheHits:     c.system.ServiceCacheHits,
+		ServiceCacheMisses:   c.system.ServiceCacheMisses,
+		PluginReloads:        c.system.PluginReloads,
+		PluginReloadFailures: c.system.PluginReloadFailures,
 	}
 }
 
@@ -533,11 +668,13 @@ func (c *Collector) GetAllExtensionMetrics() map[string]*ExtensionMetrics {
 			LoadedAt:            metrics.LoadedAt,
 			InitializedAt:       metrics.InitializedAt,
 			Status:              metrics.Status,
+			LazyActivation:      metrics.LazyActivation,
 			ServiceCalls:        metrics.serviceCalls.Load(),
 			ServiceErrors:       metrics.serviceErrors.Load(),
 			EventsPublished:     metrics.eventsPublished.Load(),
 			EventsReceived:      metrics.eventsReceived.Load(),
 			CircuitBreakerTrips: metrics.circuitBreakerTrips.Load(),
+			RoutePanics:         metrics.routePanics.Load(),
 		}
 	}
 
@@ -566,13 +703,21 @@ func (c *Collector) storeSnapshot(snapshot *Snapshot) {
 }
 
 func (c *Collector) storeSnapshotUnsafe(snapshot *Snapshot) {
-	if c.storage == nil || snapshot == nil || c.stopped {
+	if snapshot == nil || c.stopped {
+		return
+	}
+
+	for _, exporter := range c.exporters {
+		exporter.Export(snapshot)
+	}
+
+	if c.storage == nil {
 		return
 	}
 
 	c.batchBuffer = append(c.batchBuffer, snapshot)
 
-	if len(c.batchBuffer) >= c.batchSize {
+	if len(c.batchBuffer) >= c.flushSize() {
 		c.flushUnsafe()
 	}
 }
@@ -582,7 +727,12 @@ func (c *Collector) flushUnsafe() {
 		return
 	}
 
-	if err := c.storage.StoreBatch(c.batchBuffer); err != nil {
+	start := time.Now()
+	err := c.storage.StoreBatch(c.batchBuffer)
+	if c.adaptive != nil {
+		c.adaptive.Observe(len(c.batchBuffer), time.Since(start), err)
+	}
+	if err != nil {
 		logger.Errorf(nil, "Failed to flush metrics batch: %v", err)
 	}
 
@@ -590,6 +740,14 @@ func (c *Collector) flushUnsafe() {
 	c.lastFlush = time.Now()
 }
 
+// flushSize returns the number of buffered snapshots that triggers a flush
+func (c *Collector) flushSize() int {
+	if c.adaptive != nil {
+		return c.adaptive.Size()
+	}
+	return c.batchSize
+}
+
 func (c *Collector) flushRoutine() {
 	defer c.wg.Done()
 
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+// Exporter forwards collected snapshots to an external metrics backend
+type Exporter interface {
+	Export(snapshot *Snapshot)
+	Close() error
+}
+
+// NewExporter creates an exporter from its configuration
+func NewExporter(cfg *config.ExporterConfig) (Exporter, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("exporter config is nil")
+	}
+
+	switch cfg.Type {
+	case "statsd", "dogstatsd":
+		return NewStatsDExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported exporter type: %s", cfg.Type)
+	}
+}
@@ -0,0 +1,279 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/bytespool"
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+const (
+	// statsdUDPPacketSize keeps UDP packets within a typical MTU
+	statsdUDPPacketSize = 1432
+	// statsdUDSPacketSize is the DogStatsD agent's default Unix socket buffer
+	statsdUDSPacketSize = 8192
+)
+
+// statsdTypes maps snapshot metric types to StatsD types, others are gauges
+var statsdTypes = map[string]string{
+	"load_time":            "ms",
+	"init_time":            "ms",
+	"reload":               "ms",
+	"lazy_activation":      "ms",
+	"request":              "ms",
+	"unload_event":         "c",
+	"service_call":         "c",
+	"event_published":      "c",
+	"event_received":       "c",
+	"circuit_breaker_trip": "c",
+	"route_panic":          "c",
+}
+
+var (
+	// packetPool holds the packets assembled on flush
+	packetPool = bytespool.New("metrics.statsd", 0)
+
+	statsdNameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "\n", "_", " ", "_")
+	statsdTagReplacer  = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+)
+
+// statsdKey identifies an aggregated metric
+type statsdKey struct {
+	name string
+	tags string
+}
+
+// StatsDExporter aggregates snapshots client side and flushes them to a StatsD or
+// DogStatsD agent over UDP or a Unix domain socket. Counters are summed, gauges keep
+// their last value and timings are sent as recorded. Tags are only sent to DogStatsD.
+type StatsDExporter struct {
+	conn       net.Conn
+	packetSize int
+	prefix     string
+	dogstatsd  bool
+	tags       []string
+
+	mu       sync.Mutex
+	counters map[statsdKey]int64
+	gauges   map[statsdKey]int64
+	timings  map[statsdKey][]int64
+
+	ticker    *time.Ticker
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewStatsDExporter dials the agent and starts the flush routine
+func NewStatsDExporter(cfg *config.ExporterConfig) (*StatsDExporter, error) {
+	network, address, packetSize := "udp", cfg.Address, statsdUDPPacketSize
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address, packetSize = "unixgram", strings.TrimPrefix(address, "unix://"), statsdUDSPacketSize
+	case strings.HasPrefix(address, "udp://"):
+		address = strings.TrimPrefix(address, "udp://")
+	}
+
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial statsd agent %s: %v", cfg.Address, err)
+	}
+
+	flushInterval := 10 * time.Second
+	if cfg.FlushInterval != "" {
+		if interval, err := time.ParseDuration(cfg.FlushInterval); err == nil && interval > 0 {
+			flushInterval = interval
+		}
+	}
+
+	e := &StatsDExporter{
+		conn:       conn,
+		packetSize: packetSize,
+		prefix:     strings.TrimSuffix(cfg.Prefix, "."),
+		dogstatsd:  cfg.Type == "dogstatsd",
+		tags:       sortedTags(cfg.Tags),
+		counters:   make(map[statsdKey]int64),
+		gauges:     make(map[statsdKey]int64),
+		timings:    make(map[statsdKey][]int64),
+		ticker:     time.NewTicker(flushInterval),
+		stopChan:   make(chan struct{}),
+	}
+
+	e.wg.Add(1)
+	go e.flushRoutine()
+
+	return e, nil
+}
+
+// Export aggregates a snapshot until the next flush
+func (e *StatsDExporter) Export(snapshot *Snapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	key := statsdKey{name: e.metricName(snapshot.MetricType), tags: e.snapshotTags(snapshot)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch statsdTypes[snapshot.MetricType] {
+	case "c":
+		e.counters[key]++
+	case "ms":
+		e.timings[key] = append(e.timings[key], snapshot.Value)
+	default:
+		e.gauges[key] = snapshot.Value
+	}
+}
+
+// Close flushes pending metrics and closes the connection
+func (e *StatsDExporter) Close() error {
+	var err error
+	e.closeOnce.Do(func() {
+		e.ticker.Stop()
+		close(e.stopChan)
+		e.wg.Wait()
+		e.Flush()
+		err = e.conn.Close()
+	})
+	return err
+}
+
+// Flush sends the aggregated metrics and resets them
+func (e *StatsDExporter) Flush() {
+	e.mu.Lock()
+	counters, gauges, timings := e.counters, e.gauges, e.timings
+	e.counters = make(map[statsdKey]int64)
+	e.gauges = make(map[statsdKey]int64)
+	e.timings = make(map[statsdKey][]int64)
+	e.mu.Unlock()
+
+	var lines []string
+	for key, value := range counters {
+		lines = append(lines, e.line(key, strconv.FormatInt(value, 10), "c"))
+	}
+	for key, value := range gauges {
+		lines = append(lines, e.line(key, strconv.FormatInt(value, 10), "g"))
+	}
+	for key, values := range timings {
+		lines = append(lines, e.timingLines(key, values)...)
+	}
+
+	e.send(lines)
+}
+
+func (e *StatsDExporter) flushRoutine() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ticker.C:
+			e.Flush()
+		case <-e.stopChan:
+			return
+		}
+	}
+}
+
+// timingLines formats timing values, DogStatsD packs several values per line
+func (e *StatsDExporter) timingLines(key statsdKey, values []int64) []string {
+	var lines []string
+	if !e.dogstatsd {
+		for _, v := range values {
+			lines = append(lines, e.line(key, strconv.FormatInt(v, 10), "ms"))
+		}
+		return lines
+	}
+
+	var packed []string
+	size := 0
+	for _, v := range values {
+		s := strconv.FormatInt(v, 10)
+		if len(packed) > 0 && size+len(s)+len(key.name)+len(key.tags)+16 > e.packetSize {
+			lines = append(lines, e.line(key, strings.Join(packed, ":"), "ms"))
+			packed, size = nil, 0
+		}
+		packed = append(packed, s)
+		size += len(s) + 1
+	}
+	if len(packed) > 0 {
+		lines = append(lines, e.line(key, strings.Join(packed, ":"), "ms"))
+	}
+	return lines
+}
+
+// line formats a metric line
+func (e *StatsDExporter) line(key statsdKey, value, typ string) string {
+	l := key.name + ":" + value + "|" + typ
+	if key.tags != "" {
+		l += "|#" + key.tags
+	}
+	return l
+}
+
+// send writes lines in packets up to the packet size
+func (e *StatsDExporter) send(lines []string) {
+	buf := packetPool.Get()
+	defer packetPool.Put(buf)
+
+	for _, l := range lines {
+		if buf.Len() > 0 && buf.Len()+1+len(l) > e.packetSize {
+			e.write(buf.Bytes())
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(l)
+	}
+	if buf.Len() > 0 {
+		e.write(buf.Bytes())
+	}
+}
+
+func (e *StatsDExporter) write(packet []byte) {
+	if _, err := e.conn.Write(packet); err != nil {
+		logger.Warnf(nil, "Failed to send metrics to statsd agent: %v", err)
+	}
+}
+
+// metricName prefixes and sanitizes a metric type
+func (e *StatsDExporter) metricName(metricType string) string {
+	name := statsdNameReplacer.Replace(metricType)
+	if e.prefix != "" {
+		name = e.prefix + "." + name
+	}
+	return name
+}
+
+// snapshotTags maps a snapshot's extension and labels to DogStatsD tags
+func (e *StatsDExporter) snapshotTags(snapshot *Snapshot) string {
+	if !e.dogstatsd {
+		return ""
+	}
+
+	tags := make([]string, 0, 1+len(snapshot.Labels)+len(e.tags))
+	if snapshot.ExtensionName != "" {
+		tags = append(tags, "extension:"+statsdTagReplacer.Replace(snapshot.ExtensionName))
+	}
+	tags = append(tags, sortedTags(snapshot.Labels)...)
+	tags = append(tags, e.tags...)
+	return strings.Join(tags, ",")
+}
+
+// sortedTags formats a label map as sorted key:value tags
+func sortedTags(labels map[string]string) []string {
+	tags := make([]string, 0, len(labels))
+	for k, v := range labels {
+		tags = append(tags, statsdTagReplacer.Replace(k)+":"+statsdTagReplacer.Replace(v))
+	}
+	sort.Strings(tags)
+	return tags
+}
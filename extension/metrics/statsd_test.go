@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+// listenStatsD starts a UDP agent and returns its address and a function reading
+// the lines of the packets it received
+func listenStatsD(t *testing.T) (string, func() []string) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	read := func() []string {
+		var lines []string
+		buf := make([]byte, 65536)
+		for {
+			_ = pc.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := pc.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		}
+		slices.Sort(lines)
+		return lines
+	}
+	return pc.LocalAddr().String(), read
+}
+
+func TestStatsDExporterAggregates(t *testing.T) {
+	addr, read := listenStatsD(t)
+	exp, err := NewExporter(&config.ExporterConfig{Type: "statsd", Address: "udp://" + addr, Prefix: "ncore.", FlushInterval: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exp.Close()
+
+	for _, s := range []*Snapshot{
+		{ExtensionName: "notes", MetricType: "service_call", Value: 1},
+		{ExtensionName: "notes", MetricType: "service_call", Value: 1},
+		{ExtensionName: "notes", MetricType: "request", Value: 12},
+		{ExtensionName: "notes", MetricType: "request", Value: 30},
+		{ExtensionName: "notes", MetricType: "memory", Value: 5},
+		{ExtensionName: "notes", MetricType: "memory", Value: 7},
+	} {
+		exp.Export(s)
+	}
+	exp.(*StatsDExporter).Flush()
+
+	want := []string{"ncore.memory:7|g", "ncore.request:12|ms", "ncore.request:30|ms", "ncore.service_call:2|c"}
+	if got := read(); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+
+	// Flushing resets the aggregates
+	exp.(*StatsDExporter).Flush()
+	if got := read(); len(got) != 0 {
+		t.Fatalf("lines after an empty flush = %q", got)
+	}
+}
+
+func TestDogStatsDExporterTags(t *testing.T) {
+	addr, read := listenStatsD(t)
+	exp, err := NewExporter(&config.ExporterConfig{Type: "dogstatsd", Address: addr, Tags: map[string]string{"env": "prod"}, FlushInterval: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp.Export(&Snapshot{ExtensionName: "notes", MetricType: "request", Value: 12, Labels: map[string]string{"route": "/a,b"}})
+	exp.Export(&Snapshot{ExtensionName: "notes", MetricType: "request", Value: 30, Labels: map[string]string{"route": "/a,b"}})
+	exp.Export(&Snapshot{ExtensionName: "blog", MetricType: "route_panic", Value: 1})
+
+	// Close flushes what is pending
+	if err := exp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"request:12:30|ms|#extension:notes,route:/a_b,env:prod",
+		"route_panic:1|c|#extension:blog,env:prod",
+	}
+	if got := read(); !slices.Equal(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestNewExporterRejectsUnknownTypes(t *testing.T) {
+	if _, err := NewExporter(nil); err == nil {
+		t.Error("NewExporter(nil) should fail")
+	}
+	if _, err := NewExporter(&config.ExporterConfig{Type: "graphite", Address: "127.0.0.1:2003"}); err == nil {
+		t.Error("NewExporter should reject an unknown type")
+	}
+}
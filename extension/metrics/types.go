@@ -18,12 +18,13 @@ const (
 
 // ExtensionMetrics tracks real-time metrics for a single extension
 type ExtensionMetrics struct {
-	Name          string    `json:"name"`
-	LoadTime      int64     `json:"load_time_ms"`   // Load time in milliseconds
-	InitTime      int64     `json:"init_time_ms"`   // Init time in milliseconds
-	LoadedAt      time.Time `json:"loaded_at"`      // When extension was loaded
-	InitializedAt time.Time `json:"initialized_at"` // When extension was initialized
-	Status        string    `json:"status"`         // "loading", "active", "failed", "stopped"
+	Name           string    `json:"name"`
+	LoadTime       int64     `json:"load_time_ms"`                 // Load time in milliseconds
+	InitTime       int64     `json:"init_time_ms"`                 // Init time in milliseconds
+	LoadedAt       time.Time `json:"loaded_at"`                    // When extension was loaded
+	InitializedAt  time.Time `json:"initialized_at"`               // When extension was initialized
+	Status         string    `json:"status"`                       // "loading", "active", "failed", "stopped"
+	LazyActivation int64     `json:"lazy_activation_ms,omitempty"` // Lazy activation latency in milliseconds
 
 	// Atomic counters for concurrent access (use atomic.Int64 internally but convert for JSON)
 	ServiceCalls        int64 `json:"service_calls"`
@@ -31,6 +32,7 @@ type ExtensionMetrics struct {
 	EventsPublished     int64 `json:"events_published"`
 	EventsReceived      int64 `json:"events_received"`
 	CircuitBreakerTrips int64 `json:"circuit_breaker_trips"`
+	RoutePanics         int64 `json:"route_panics"`
 
 	// Internal atomic counters (not exported for JSON)
 	serviceCalls        atomic.Int64 `json:"-"`
@@ -38,17 +40,20 @@ type ExtensionMetrics struct {
 	eventsPublished     atomic.Int64 `json:"-"`
 	eventsReceived      atomic.Int64 `json:"-"`
 	circuitBreakerTrips atomic.Int64 `json:"-"`
+	routePanics         atomic.Int64 `json:"-"`
 }
 
 // SystemMetrics tracks system-wide metrics
 type SystemMetrics struct {
-	StartTime          time.Time `json:"start_time"`
-	MemoryUsageMB      int64     `json:"memory_usage_mb"`
-	GoroutineCount     int       `json:"goroutine_count"`
-	GCCycles           uint32    `json:"gc_cycles"`
-	ServicesRegistered int       `json:"services_registered"`
-	ServiceCacheHits   int64     `json:"service_cache_hits"`
-	ServiceCacheMisses int64     `json:"service_cache_misses"`
+	StartTime            time.Time `json:"start_time"`
+	MemoryUsageMB        int64     `json:"memory_usage_mb"`
+	GoroutineCount       int       `json:"goroutine_count"`
+	GCCycles             uint32    `json:"gc_cycles"`
+	ServicesRegistered   int       `json:"services_registered"`
+	ServiceCacheHits     int64     `json:"service_cache_hits"`
+	ServiceCacheMisses   int64     `json:"service_cache_misses"`
+	PluginReloads        int64     `json:"plugin_reloads"`
+	PluginReloadFailures int64     `json:"plugin_reload_failures"`
 }
 
 // Snapshot represents a point-in-time metric measurement
@@ -0,0 +1,31 @@
+// Package openapi builds OpenAPI 3.1 documents from registered gin routes,
+// so clients can generate SDKs.
+//
+// Every route is documented with its path parameters, the net/resp success
+// body and the Error envelope of failures, or RFC 9457 problem details. The
+// business codes of ecode are listed in the Error schema. Extensions add
+// summaries, query parameters and bodies by implementing
+// types.APIDocumenter:
+//
+//	func (m *Module) APIOperations() []openapi.Operation {
+//	    return []openapi.Operation{{
+//	        Method:   "POST",
+//	        Path:     "/users",
+//	        Summary:  "Create a user",
+//	        Request:  structs.CreateUserBody{},
+//	        Response: structs.User{},
+//	        Status:   201,
+//	        Errors:   []int{409},
+//	        Auth:     true,
+//	    }}
+//	}
+//
+// Request and response types are described by reflection as encoding/json
+// writes them: named structs become component schemas, fields without
+// omitempty or with a required binding are required, and a description tag
+// documents a field. Query parameters are named by their form tags.
+//
+// The extension manager serves the document at /system/openapi.json, built
+// by Manager.ExportOpenAPI, and "ncore openapi" saves it from a running
+// application.
+package openapi
@@ -0,0 +1,417 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.1.0"
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations, one per extension
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components"`
+}
+
+// PathItem holds the operations of a path by lower-case method
+type PathItem map[string]*OperationObject
+
+// OperationObject is an operation of a path
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// MediaType holds the schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response is a response of an operation, or a reference to a shared one
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// SecurityScheme is an authentication method
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Components holds the schemas and responses operations refer to
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// Operation documents a route, extensions return them from
+// types.APIDocumenter. Values are examples of the types, e.g.
+// structs.CreateUserBody{}, and are only inspected by reflection.
+type Operation struct {
+	Method      string   // HTTP method, e.g. GET
+	Path        string   // Route path, gin or OpenAPI syntax, e.g. /users/:id
+	Summary     string   // One line description
+	Description string   // Longer description, may be markdown
+	Tags        []string // Tags, the owning extension when empty
+	Query       any      // Struct whose form or json tags are the query parameters
+	Request     any      // JSON request body
+	Response    any      // Data of a success response, nil for a message
+	Status      int      // Success status, 200 when zero
+	Errors      []int    // Documented failure statuses besides 400 and 500
+	Auth        bool     // Requires a bearer token
+	Deprecated  bool
+}
+
+// Route is a registered route
+type Route struct {
+	Method    string // HTTP method
+	Path      string // gin path
+	Handler   string // handler function name
+	Extension string // owning extension, empty for routes of the application
+}
+
+// Options configures document generation
+type Options struct {
+	Info       Info
+	Servers    []string
+	ErrorCodes map[int]string // business codes listed in the Error schema, e.g. ecode.Texts()
+}
+
+// Error responses added to every operation
+var defaultErrors = []int{http.StatusBadRequest, http.StatusInternalServerError}
+
+// Build documents routes, taking summaries, parameters and bodies from the
+// operations matching them by method and path
+func Build(routes []Route, ops []Operation, opts Options) *Document {
+	if opts.Info.Title == "" {
+		opts.Info.Title = "API"
+	}
+	if opts.Info.Version == "" {
+		opts.Info.Version = "1.0.0"
+	}
+
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       opts.Info,
+		Paths:      map[string]*PathItem{},
+		Components: standardComponents(opts.ErrorCodes),
+	}
+	for _, url := range opts.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+
+	byRoute := make(map[string]*Operation, len(ops))
+	for i := range ops {
+		byRoute[strings.ToUpper(ops[i].Method)+" "+Path(ops[i].Path)] = &ops[i]
+	}
+
+	schemas := newSchemaSet(doc.Components.Schemas)
+	tags := map[string]bool{}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	for _, route := range routes {
+		method := strings.ToUpper(route.Method)
+		path := Path(route.Path)
+		op := byRoute[method+" "+path]
+		if op == nil {
+			op = &Operation{}
+		}
+
+		o := buildOperation(schemas, method, path, route, op)
+		for _, tag := range o.Tags {
+			tags[tag] = true
+		}
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(method)] = o
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc
+}
+
+// buildOperation documents a route with its operation
+func buildOperation(schemas *schemaSet, method, path string, route Route, op *Operation) *OperationObject {
+	o := &OperationObject{
+		OperationID: operationID(method, path),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   map[string]*Response{},
+		Deprecated:  op.Deprecated,
+	}
+	if len(o.Tags) == 0 {
+		o.Tags = []string{routeTag(route, path)}
+	}
+	if o.Summary == "" && route.Handler != "" {
+		o.Summary = handlerSummary(route.Handler)
+	}
+
+	for _, name := range pathParams(path) {
+		o.Parameters = append(o.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	if op.Query != nil {
+		o.Parameters = append(o.Parameters, schemas.queryParams(reflect.TypeOf(op.Query))...)
+	}
+	if op.Request != nil {
+		o.RequestBody = &RequestBody{Required: true, Content: jsonContent(schemas.of(reflect.TypeOf(op.Request)))}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case status == http.StatusNoContent:
+	case op.Response != nil:
+		success.Content = jsonContent(schemas.of(reflect.TypeOf(op.Response)))
+	default:
+		success.Content = jsonContent(&Schema{Ref: schemaRef("Message")})
+	}
+	o.Responses[strconv.Itoa(status)] = success
+
+	errs := append(append([]int(nil), defaultErrors...), op.Errors...)
+	if op.Auth {
+		errs = append(errs, http.StatusUnauthorized)
+		o.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+	for _, code := range errs {
+		o.Responses[strconv.Itoa(code)] = errorResponse(code)
+	}
+	return o
+}
+
+// errorResponse refers to the shared response of a failure status
+func errorResponse(status int) *Response {
+	if name, ok := errorResponses[status]; ok {
+		return &Response{Ref: "#/components/responses/" + name}
+	}
+	return &Response{Description: http.StatusText(status), Content: errorContent()}
+}
+
+// Path converts a gin path to OpenAPI syntax, /users/:id is /users/{id}
+func Path(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParams returns the parameter names of an OpenAPI path
+func pathParams(path string) []string {
+	var names []string
+	for _, s := range strings.Split(path, "/") {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			names = append(names, s[1:len(s)-1])
+		}
+	}
+	return names
+}
+
+// operationID derives an id from the method and path, GET /users/{id} is get_users_id
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, s := range strings.Split(path, "/") {
+		s = strings.Trim(s, "{}")
+		if s == "" {
+			continue
+		}
+		id += "_" + strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, s)
+	}
+	return id
+}
+
+// routeTag tags a route with its extension, or the first path segment
+func routeTag(route Route, path string) string {
+	if route.Extension != "" {
+		return route.Extension
+	}
+	for _, s := range strings.Split(path, "/") {
+		if s != "" && !strings.HasPrefix(s, "{") {
+			return s
+		}
+	}
+	return "default"
+}
+
+// handlerSummary names an undocumented operation after its handler,
+// github.com/app/user/handler.(*userHandler).Get-fm is "userHandler.Get"
+func handlerSummary(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(handler, "/"); i >= 0 {
+		handler = handler[i+1:]
+	}
+	if _, rest, ok := strings.Cut(handler, "."); ok {
+		handler = rest
+	}
+	handler = strings.NewReplacer("(*", "", ")", "").Replace(handler)
+	if strings.Contains(handler, "func") {
+		return ""
+	}
+	return handler
+}
+
+// jsonContent is JSON content of schema
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// errorContent is the content of failures, the Error envelope or problem details
+func errorContent() map[string]*MediaType {
+	return map[string]*MediaType{
+		"application/json":         {Schema: &Schema{Ref: schemaRef("Error")}},
+		"application/problem+json": {Schema: &Schema{Ref: schemaRef("Problem")}},
+	}
+}
+
+// errorResponses are the shared responses of common failure statuses
+var errorResponses = map[int]string{
+	http.StatusBadRequest:          "BadRequest",
+	http.StatusUnauthorized:        "Unauthorized",
+	http.StatusForbidden:           "Forbidden",
+	http.StatusNotFound:            "NotFound",
+	http.StatusConflict:            "Conflict",
+	http.StatusTooManyRequests:     "TooManyRequests",
+	http.StatusInternalServerError: "InternalServerError",
+	http.StatusServiceUnavailable:  "ServiceUnavailable",
+}
+
+// standardComponents returns the schemas of the net/resp bodies and the
+// shared failure responses
+func standardComponents(codes map[int]string) *Components {
+	c := &Components{
+		Schemas: map[string]*Schema{
+			"Message": {
+				Type:        "object",
+				Description: "Success response without data",
+				Properties:  map[string]*Schema{"message": {Type: "string"}},
+				Required:    []string{"message"},
+			},
+			"Error": {
+				Type:        "object",
+				Description: "Failure response",
+				Properties: map[string]*Schema{
+					"code":    {Type: "integer", Description: codeDescription(codes)},
+					"message": {Type: "string"},
+					"errors":  {Description: "Validation errors, each with field, key, params and message, or other details"},
+				},
+				Required: []string{"code", "message"},
+			},
+			"Problem": {
+				Type:        "object",
+				Description: "RFC 9457 problem details, written instead of Error when enabled",
+				Properties: map[string]*Schema{
+					"type":     {Type: "string", Format: "uri-reference"},
+					"title":    {Type: "string"},
+					"status":   {Type: "integer"},
+					"detail":   {Type: "string"},
+					"instance": {Type: "string", Format: "uri-reference"},
+					"code":     {Type: "integer", Description: "Business code"},
+					"errors":   {Description: "Validation errors, each with field, key, params and message, or other details"},
+				},
+				Required: []string{"type", "title", "status"},
+			},
+		},
+		Responses: map[string]*Response{},
+		SecuritySchemes: map[string]SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+	}
+	for status, name := range errorResponses {
+		c.Responses[name] = &Response{Description: http.StatusText(status), Content: errorContent()}
+	}
+	return c
+}
+
+// codeDescription lists the business codes in the Error schema
+func codeDescription(codes map[int]string) string {
+	if len(codes) == 0 {
+		return "Business code"
+	}
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+
+	var b strings.Builder
+	b.WriteString("Business code:\n")
+	for _, code := range keys {
+		fmt.Fprintf(&b, "\n- `%d` %s", code, codes[code])
+	}
+	return b.String()
+}
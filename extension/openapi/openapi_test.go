@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	base
+	Name    string   `json:"name" description:"Display name"`
+	Email   string   `json:"email,omitempty" binding:"required,email"`
+	Manager *User    `json:"manager,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	secret  string
+}
+
+type createUserBody struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required"`
+}
+
+type listQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"required"`
+	Ignore string `form:"-"`
+}
+
+func TestBuild(t *testing.T) {
+	routes := []Route{
+		{Method: "POST", Path: "/users", Extension: "user"},
+		{Method: "GET", Path: "/users/:id", Extension: "user", Handler: "github.com/app/user/handler.(*userHandler).Get-fm"},
+		{Method: "GET", Path: "/users", Extension: "user"},
+		{Method: "GET", Path: "/system/info"},
+		{Method: "GET", Path: "/files/*filepath"},
+	}
+	ops := []Operation{
+		{Method: "POST", Path: "/users", Summary: "Create a user", Request: createUserBody{}, Response: User{}, Status: 201, Errors: []int{409}, Auth: true},
+		{Method: "get", Path: "/users", Query: listQuery{}, Response: []User{}},
+	}
+
+	doc := Build(routes, ops, Options{Info: Info{Title: "app"}, ErrorCodes: map[int]string{-404: "Nothing found", -400: "Request error"}})
+	if doc.OpenAPI != "3.1.0" || doc.Info.Version != "1.0.0" {
+		t.Fatalf("unexpected header %+v", doc)
+	}
+
+	create := (*doc.Paths["/users"])["post"]
+	if create == nil || create.Summary != "Create a user" || create.OperationID != "post_users" {
+		t.Fatalf("unexpected create operation %+v", create)
+	}
+	if create.Responses["201"].Content["application/json"].Schema.Ref != "#/components/schemas/User" {
+		t.Errorf("expected User response, got %+v", create.Responses["201"])
+	}
+	if create.Responses["409"].Ref != "#/components/responses/Conflict" || create.Responses["401"] == nil || len(create.Security) != 1 {
+		t.Errorf("expected conflict, auth and security, got %+v", create.Responses)
+	}
+	if body := doc.Components.Schemas["createUserBody"]; body == nil || len(body.Required) != 2 {
+		t.Errorf("unexpected body schema %+v", body)
+	}
+
+	get := (*doc.Paths["/users/{id}"])["get"]
+	if get == nil || get.Summary != "userHandler.Get" || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Fatalf("unexpected get operation %+v", get)
+	}
+	if get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/Message" {
+		t.Errorf("expected message response for undocumented route")
+	}
+
+	list := (*doc.Paths["/users"])["get"]
+	if len(list.Parameters) != 2 || list.Parameters[1].Name != "limit" || !list.Parameters[1].Required {
+		t.Errorf("unexpected query parameters %+v", list.Parameters)
+	}
+	if items := list.Responses["200"].Content["application/json"].Schema; items.Type != "array" || items.Items.Ref == "" {
+		t.Errorf("expected array of users, got %+v", items)
+	}
+
+	user := doc.Components.Schemas["User"]
+	if user == nil || user.Properties["manager"].Ref != "#/components/schemas/User" || user.Properties["created_at"].Format != "date-time" {
+		t.Fatalf("unexpected user schema %+v", user)
+	}
+	if _, ok := user.Properties["secret"]; ok {
+		t.Error("unexported field documented")
+	}
+	if strings.Join(user.Required, ",") != "id,created_at,name,email" {
+		t.Errorf("unexpected required %v", user.Required)
+	}
+	if user.Properties["name"].Description != "Display name" {
+		t.Error("expected field description")
+	}
+
+	if _, ok := doc.Paths["/files/{filepath}"]; !ok {
+		t.Error("expected wildcard path converted")
+	}
+	if (*doc.Paths["/system/info"])["get"].Tags[0] != "system" {
+		t.Error("expected route without extension tagged by path")
+	}
+	if !strings.Contains(doc.Components.Schemas["Error"].Properties["code"].Description, "`-404` Nothing found") {
+		t.Error("expected business codes in the error schema")
+	}
+	if len(doc.Tags) != 3 {
+		t.Errorf("unexpected tags %+v", doc.Tags)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+}
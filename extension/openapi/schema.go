@@ -0,0 +1,239 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaSet registers named struct types as component schemas
+type schemaSet struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaSet(schemas map[string]*Schema) *schemaSet {
+	return &schemaSet{schemas: schemas, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema of t, referring to components for named structs
+func (s *schemaSet) of(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case t == rawType:
+		return &Schema{}
+	case t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler):
+		// Written as JSON strings, e.g. UUIDs and decimals
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: schemaRef(s.register(t))}
+	default:
+		// Interfaces hold any value
+		return &Schema{}
+	}
+}
+
+// register adds a named struct to the components and returns its name
+func (s *schemaSet) register(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if i := strings.Index(name, "["); i >= 0 {
+		// Instantiated generics, Page[pkg.User] is Page_User
+		args := strings.NewReplacer("*", "", "]", "").Replace(name[i+1:])
+		parts := strings.Split(args, ",")
+		for j, p := range parts {
+			if k := strings.LastIndex(p, "."); k >= 0 {
+				parts[j] = p[k+1:]
+			}
+		}
+		name = name[:i] + "_" + strings.Join(parts, "_")
+	}
+	if _, taken := s.schemas[name]; taken {
+		pkg := t.PkgPath()
+		if k := strings.LastIndex(pkg, "/"); k >= 0 {
+			pkg = pkg[k+1:]
+		}
+		name = pkg + "." + name
+	}
+
+	// Registered before its fields, so recursive types refer to themselves
+	s.names[t] = name
+	s.schemas[name] = &Schema{}
+	*s.schemas[name] = *s.object(t)
+	return name
+}
+
+// object describes the exported fields of a struct as encoding/json writes them
+func (s *schemaSet) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(t, schema)
+	return schema
+}
+
+func (s *schemaSet) fields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, ok := jsonField(f)
+		if !ok {
+			continue
+		}
+
+		// Untagged embedded structs are inlined
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, schema)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		prop := s.of(f.Type)
+		if desc := f.Tag.Get("description"); desc != "" {
+			if prop.Ref != "" {
+				// Siblings of $ref are allowed in 3.1
+				prop = &Schema{Ref: prop.Ref, Description: desc}
+			} else {
+				prop.Description = desc
+			}
+		}
+		schema.Properties[name] = prop
+		optional := omitempty || f.Type.Kind() == reflect.Pointer
+		if !optional || strings.Contains(f.Tag.Get("binding"), "required") || strings.Contains(f.Tag.Get("validate"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// queryParams describes the fields of a struct as query parameters, named
+// by their form tag as gin binds them, or json tag
+func (s *schemaSet) queryParams(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Anonymous {
+			params = append(params, s.queryParams(f.Type)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" {
+			name, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: f.Tag.Get("description"),
+			Required:    strings.Contains(f.Tag.Get("binding"), "required"),
+			Schema:      s.of(f.Type),
+		})
+	}
+	return params
+}
+
+// jsonField returns the JSON name of a field and whether it is omitted when empty
+func jsonField(f reflect.StructField) (name string, omitempty, ok bool) {
+	if !f.IsExported() && !f.Anonymous {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// schemaRef refers to a component schema
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
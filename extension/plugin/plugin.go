@@ -36,49 +36,62 @@ func GetRegisteredPlugins() []*types.Wrapper {
 
 // LoadPlugin loads a single plugin
 func LoadPlugin(path string, m types.ManagerInterface) error {
+	w, err := Open(path, m)
+	if err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	name := w.Instance.Name()
+	if _, exists := registry.plugins[name]; exists {
+		logger.Warnf(nil, "Plugin %s is being overwritten", name)
+	}
+	registry.plugins[name] = w
+	logger.Debugf(nil, "Plugin %s loaded and initialized successfully", name)
+
+	return nil
+}
+
+// Open loads and initializes a plugin without registering it, e.g. to run a
+// second version next to the registered one
+func Open(path string, m types.ManagerInterface) (*types.Wrapper, error) {
 	p, err := plg.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open plugin %s: %v", path, err)
+		return nil, fmt.Errorf("failed to open plugin %s: %v", path, err)
 	}
 
 	symPlugin, err := p.Lookup("Instance")
 	if err != nil {
-		return fmt.Errorf("plugin %s does not export 'Instance' symbol: %v", path, err)
+		return nil, fmt.Errorf("plugin %s does not export 'Instance' symbol: %v", path, err)
 	}
 
 	sc, ok := symPlugin.(types.Interface)
 	if !ok {
-		return fmt.Errorf("plugin %s does not implement interface, got %T", path, sc)
+		return nil, fmt.Errorf("plugin %s does not implement interface, got %T", path, sc)
+	}
+
+	if aware, ok := sc.(types.LoggerAware); ok {
+		aware.SetLogger(m.GetLogger(sc.Name()).WithFields(map[string]any{"extension_version": sc.Version()}))
 	}
 
 	if err := sc.PreInit(); err != nil {
-		return fmt.Errorf("failed pre-initialization of plugin %s: %v", path, err)
+		return nil, fmt.Errorf("failed pre-initialization of plugin %s: %v", path, err)
 	}
 
 	if err := sc.Init(m.GetConfig(), m); err != nil {
-		return fmt.Errorf("failed to initialize plugin %s: %v", path, err)
+		return nil, fmt.Errorf("failed to initialize plugin %s: %v", path, err)
 	}
 
 	if err := sc.PostInit(); err != nil {
-		return fmt.Errorf("failed post-initialization of plugin %s: %v", path, err)
+		return nil, fmt.Errorf("failed post-initialization of plugin %s: %v", path, err)
 	}
 
-	metadata := sc.GetMetadata()
-
-	registry.mu.Lock()
-	defer registry.mu.Unlock()
-
-	name := sc.Name()
-	if _, exists := registry.plugins[name]; exists {
-		logger.Warnf(nil, "Plugin %s is being overwritten", name)
-	}
-	registry.plugins[name] = &types.Wrapper{
-		Metadata: metadata,
+	return &types.Wrapper{
+		Metadata: sc.GetMetadata(),
 		Instance: sc,
-	}
-	logger.Debugf(nil, "Plugin %s loaded and initialized successfully", name)
-
-	return nil
+	}, nil
 }
 
 // UnloadPlugin unloads a single plugin
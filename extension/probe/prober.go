@@ -0,0 +1,121 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+// Prober checks the availability of a single target
+type Prober interface {
+	Probe(ctx context.Context, target *config.ProbeTarget) error
+}
+
+// ProberFunc adapts a function to the Prober interface
+type ProberFunc func(ctx context.Context, target *config.ProbeTarget) error
+
+// Probe calls f(ctx, target)
+func (f ProberFunc) Probe(ctx context.Context, target *config.ProbeTarget) error {
+	return f(ctx, target)
+}
+
+var (
+	probers = map[string]Prober{
+		"http": ProberFunc(probeHTTP),
+		"tcp":  ProberFunc(probeTCP),
+		"smtp": ProberFunc(probeSMTP),
+	}
+	probersMu sync.RWMutex
+)
+
+// RegisterProber registers a prober for a target type, replacing any existing one,
+// and allows the type in probe configurations
+func RegisterProber(targetType string, p Prober) {
+	probersMu.Lock()
+	defer probersMu.Unlock()
+	probers[targetType] = p
+	config.RegisterProbeType(targetType)
+}
+
+// getProber returns the prober for a target type
+func getProber(targetType string) (Prober, bool) {
+	probersMu.RLock()
+	defer probersMu.RUnlock()
+	p, ok := probers[targetType]
+	return p, ok
+}
+
+// probeHTTP sends a request and checks the response status
+func probeHTTP(ctx context.Context, target *config.ProbeTarget) error {
+	method := target.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.Address, nil)
+	if err != nil {
+		return fmt.Errorf("invalid request: %v", err)
+	}
+	for k, v := range target.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if target.ExpectedStatus > 0 {
+		if res.StatusCode != target.ExpectedStatus {
+			return fmt.Errorf("unexpected status %d, want %d", res.StatusCode, target.ExpectedStatus)
+		}
+		return nil
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// probeTCP checks that a TCP connection can be established
+func probeTCP(ctx context.Context, target *config.ProbeTarget) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeSMTP connects and waits for the 220 service ready greeting
+func probeSMTP(ctx context.Context, target *config.ProbeTarget) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read greeting: %v", err)
+	}
+	if !strings.HasPrefix(line, "220") {
+		return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(line))
+	}
+
+	_, _ = conn.Write([]byte("QUIT\r\n"))
+	return nil
+}
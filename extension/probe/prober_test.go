@@ -0,0 +1,144 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+func TestRegisterProberAllowsType(t *testing.T) {
+	cfg := &config.ProbesConfig{
+		Enabled: true,
+		Targets: []*config.ProbeTarget{{Name: "cache", Type: "memcached", Address: "127.0.0.1:11211"}},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate should reject a type without a prober")
+	}
+
+	RegisterProber("memcached", ProberFunc(func(context.Context, *config.ProbeTarget) error { return nil }))
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate of a registered type: %v", err)
+	}
+	if _, ok := getProber("memcached"); !ok {
+		t.Fatal("prober was not registered")
+	}
+
+	cfg.Targets[0].Type = "unknown"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate should still reject an unknown type")
+	}
+}
+
+func TestBuiltInProbers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	if err := ln.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, tc := range []struct {
+		target config.ProbeTarget
+		ok     bool
+	}{
+		{config.ProbeTarget{Type: "http", Address: srv.URL}, true},
+		{config.ProbeTarget{Type: "http", Address: srv.URL, ExpectedStatus: http.StatusAccepted}, true},
+		{config.ProbeTarget{Type: "http", Address: srv.URL, ExpectedStatus: http.StatusOK}, false},
+		{config.ProbeTarget{Type: "http", Address: srv.URL + "/missing"}, false},
+		{config.ProbeTarget{Type: "tcp", Address: ln.Addr().String()}, true},
+		{config.ProbeTarget{Type: "tcp", Address: closed}, false},
+	} {
+		p, ok := getProber(tc.target.Type)
+		if !ok {
+			t.Fatalf("no prober for %s", tc.target.Type)
+		}
+		if err := p.Probe(ctx, &tc.target); (err == nil) != tc.ok {
+			t.Errorf("Probe(%s %s, status %d) = %v, want success %v",
+				tc.target.Type, tc.target.Address, tc.target.ExpectedStatus, err, tc.ok)
+		}
+	}
+}
+
+func TestSchedulerStateChanges(t *testing.T) {
+	var failing atomic.Bool
+	RegisterProber("flaky", ProberFunc(func(context.Context, *config.ProbeTarget) error {
+		if failing.Load() {
+			return errors.New("unavailable")
+		}
+		return nil
+	}))
+
+	s := NewScheduler(&config.ProbesConfig{
+		Enabled:          true,
+		Interval:         "10ms",
+		FailureThreshold: 2,
+		HistorySize:      4,
+		Targets:          []*config.ProbeTarget{{Name: "dep", Type: "flaky", Address: "dep"}},
+	})
+	changes := make(chan Status, 16)
+	s.OnStateChange(func(st Status) { changes <- st })
+
+	wait := func(state string) Status {
+		t.Helper()
+		select {
+		case st := <-changes:
+			if st.State != state {
+				t.Fatalf("state changed to %s, want %s", st.State, state)
+			}
+			return st
+		case <-time.After(5 * time.Second):
+			t.Fatalf("target did not become %s", state)
+		}
+		return Status{}
+	}
+
+	failing.Store(true)
+	s.Start(context.Background())
+	defer s.Stop()
+
+	down := wait(StateDown)
+	if down.ConsecutiveFailures < 2 || down.LastError != "unavailable" {
+		t.Fatalf("unexpected down status %+v", down)
+	}
+	if s.IsHealthy() {
+		t.Fatal("scheduler should be unhealthy while a target is down")
+	}
+
+	failing.Store(false)
+	wait(StateUp)
+	if !s.IsHealthy() {
+		t.Fatal("scheduler should be healthy after recovery")
+	}
+	st, ok := s.GetStatus("dep")
+	if !ok || len(st.History) == 0 || len(st.History) > 4 {
+		t.Fatalf("unexpected history %+v", st.History)
+	}
+	if _, ok := s.GetStatus("missing"); ok {
+		t.Fatal("GetStatus of an unknown target should fail")
+	}
+}
@@ -0,0 +1,298 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// Target states
+const (
+	StateUnknown  = "unknown"
+	StateUp       = "up"
+	StateDegraded = "degraded"
+	StateDown     = "down"
+)
+
+// Result is the outcome of a single probe run
+type Result struct {
+	Timestamp time.Time `json:"timestamp"`
+	Success   bool      `json:"success"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Status is the availability summary of a target
+type Status struct {
+	Name                string    `json:"name"`
+	Type                string    `json:"type"`
+	Address             string    `json:"address"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Availability        float64   `json:"availability"` // percentage over recorded history
+	LastCheck           time.Time `json:"last_check,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	History             []Result  `json:"history,omitempty"`
+}
+
+// targetState tracks the runtime state of a target
+type targetState struct {
+	target   *config.ProbeTarget
+	interval time.Duration
+	timeout  time.Duration
+	status   Status
+}
+
+// Scheduler periodically probes external dependencies and records availability history
+type Scheduler struct {
+	mu            sync.RWMutex
+	targets       map[string]*targetState
+	threshold     int
+	historySize   int
+	onStateChange func(Status)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a new probe scheduler from config
+func NewScheduler(cfg *config.ProbesConfig) *Scheduler {
+	defaultInterval := parseDurationOr(cfg.Interval, 30*time.Second)
+	defaultTimeout := parseDurationOr(cfg.Timeout, 5*time.Second)
+
+	s := &Scheduler{
+		targets:     make(map[string]*targetState),
+		threshold:   cfg.FailureThreshold,
+		historySize: cfg.HistorySize,
+	}
+	if s.threshold <= 0 {
+		s.threshold = 3
+	}
+	if s.historySize <= 0 {
+		s.historySize = 100
+	}
+
+	for _, t := range cfg.Targets {
+		s.targets[t.Name] = &targetState{
+			target:   t,
+			interval: parseDurationOr(t.Interval, defaultInterval),
+			timeout:  parseDurationOr(t.Timeout, defaultTimeout),
+			status: Status{
+				Name:    t.Name,
+				Type:    t.Type,
+				Address: t.Address,
+				State:   StateUnknown,
+			},
+		}
+	}
+
+	return s
+}
+
+// OnStateChange sets a callback invoked when a target goes down or recovers
+func (s *Scheduler) OnStateChange(fn func(Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStateChange = fn
+}
+
+// Start starts probing all targets in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	states := make([]*targetState, 0, len(s.targets))
+	for _, ts := range s.targets {
+		states = append(states, ts)
+	}
+	s.mu.Unlock()
+
+	for _, ts := range states {
+		s.wg.Add(1)
+		go s.run(ctx, ts)
+	}
+}
+
+// Stop stops all probes and waits for running checks to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		s.wg.Wait()
+	}
+}
+
+// Statuses returns the current status of all targets, without history
+func (s *Scheduler) Statuses() map[string]Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]Status, len(s.targets))
+	for name, ts := range s.targets {
+		status := ts.status
+		status.History = nil
+		result[name] = status
+	}
+	return result
+}
+
+// GetStatus returns the status of a target including its history
+func (s *Scheduler) GetStatus(name string) (Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, exists := s.targets[name]
+	if !exists {
+		return Status{}, false
+	}
+
+	status := ts.status
+	status.History = append([]Result(nil), ts.status.History...)
+	return status, true
+}
+
+// IsHealthy reports whether no target is down
+func (s *Scheduler) IsHealthy() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ts := range s.targets {
+		if ts.status.State == StateDown {
+			return false
+		}
+	}
+	return true
+}
+
+// run probes a single target until the context is cancelled
+func (s *Scheduler) run(ctx context.Context, ts *targetState) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(ts.interval)
+	defer ticker.Stop()
+
+	s.check(ctx, ts)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx, ts)
+		}
+	}
+}
+
+// check runs one probe and records the result
+func (s *Scheduler) check(ctx context.Context, ts *targetState) {
+	var err error
+	start := time.Now()
+
+	if p, ok := getProber(ts.target.Type); ok {
+		probeCtx, cancel := context.WithTimeout(ctx, ts.timeout)
+		err = p.Probe(probeCtx, ts.target)
+		cancel()
+	} else {
+		err = fmt.Errorf("no prober registered for type %s", ts.target.Type)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	s.record(ts, Result{
+		Timestamp: start,
+		Success:   err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Error:     errString(err),
+	})
+}
+
+// record appends a result and updates the target state
+func (s *Scheduler) record(ts *targetState, r Result) {
+	s.mu.Lock()
+
+	st := &ts.status
+	prevState := st.State
+
+	st.History = append(st.History, r)
+	if len(st.History) > s.historySize {
+		st.History = st.History[len(st.History)-s.historySize:]
+	}
+	st.LastCheck = r.Timestamp
+
+	if r.Success {
+		st.ConsecutiveFailures = 0
+		st.LastError = ""
+		st.State = StateUp
+	} else {
+		st.ConsecutiveFailures++
+		st.LastError = r.Error
+		if st.ConsecutiveFailures >= s.threshold {
+			st.State = StateDown
+		} else if prevState != StateDown {
+			st.State = StateDegraded
+		}
+	}
+
+	succeeded := 0
+	for _, h := range st.History {
+		if h.Success {
+			succeeded++
+		}
+	}
+	st.Availability = float64(succeeded) / float64(len(st.History)) * 100
+
+	changed := (st.State == StateDown && prevState != StateDown) ||
+		(st.State == StateUp && prevState == StateDown)
+	status := *st
+	status.History = nil
+	callback := s.onStateChange
+
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if status.State == StateDown {
+		logger.Errorf(nil, "external dependency %s is down after %d consecutive failures: %s",
+			status.Name, status.ConsecutiveFailures, status.LastError)
+	} else {
+		logger.Infof(nil, "external dependency %s recovered", status.Name)
+	}
+
+	if callback != nil {
+		callback(status)
+	}
+}
+
+// parseDurationOr parses a duration string with a fallback
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// errString returns the error message or empty string
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
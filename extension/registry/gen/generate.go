@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+)
+
+// registryAlias is the import alias of the runtime registry in generated code
+const registryAlias = "extregistry"
+
+// Options configures registry generation
+type Options struct {
+	Root    string   // module root to scan
+	Output  string   // generated file path
+	Package string   // package name of the generated file, defaults to the output directory name
+	Exclude []string // directories to skip, relative to root
+}
+
+var registryTemplate = template.Must(template.New("registry").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+}).Parse(`// Code generated by "ncore gen registry"; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	{{.RegistryAlias}} "github.com/ncobase/ncore/extension/registry"
+{{range .Extensions}}
+	{{.Alias}} {{quote .ImportPath}}{{end}}
+)
+
+// RegisterExtensions registers all extensions found at generation time.
+// Removing an extension package breaks the build until the registry is regenerated.
+func RegisterExtensions() {
+{{- range .Extensions}}
+	{{- if .WeakDeps}}
+	{{$.RegistryAlias}}.RegisterToGroupWithWeakDeps({{.Alias}}.{{.Constructor}}(), {{quote .Group}}, []string{ {{- range $i, $d := .WeakDeps}}{{if $i}}, {{end}}{{quote $d}}{{end -}} })
+	{{- else}}
+	{{$.RegistryAlias}}.RegisterToGroup({{.Alias}}.{{.Constructor}}(), {{quote .Group}})
+	{{- end}}
+{{- end}}
+}
+`))
+
+// Render returns the formatted registry source for the given extensions
+func Render(pkg string, exts []Extension) ([]byte, error) {
+	var buf bytes.Buffer
+	err := registryTemplate.Execute(&buf, map[string]any{
+		"Package":       pkg,
+		"RegistryAlias": registryAlias,
+		"Extensions":    exts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render registry: %v", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format registry: %v", err)
+	}
+	return src, nil
+}
+
+// Generate scans the module and writes the registry file
+func Generate(opts Options) ([]Extension, error) {
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+	if opts.Output == "" {
+		return nil, fmt.Errorf("output file is required")
+	}
+
+	outDir, err := filepath.Abs(filepath.Dir(opts.Output))
+	if err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = sanitizeIdent(filepath.Base(outDir))
+	}
+
+	// Never pick up the generated package itself
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	exclude := append([]string(nil), opts.Exclude...)
+	if rel, err := filepath.Rel(root, outDir); err == nil && rel != "." {
+		exclude = append(exclude, rel)
+	}
+
+	exts, err := Scan(root, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := Render(opts.Package, exts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	if err := os.WriteFile(opts.Output, src, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write registry: %v", err)
+	}
+
+	return exts, nil
+}
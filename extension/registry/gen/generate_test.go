@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeModule writes files, relative to a new module root, and returns the root
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	files["go.mod"] = "module example.com/app\n\ngo 1.25\n"
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestGenerate(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"plugins/blog/blog.go": `package blog
+
+import reg "github.com/ncobase/ncore/extension/registry"
+
+func init() { reg.RegisterToGroupWithWeakDeps(New(), "cms", []string{"user", "auth"}) }
+
+func New() any { return nil }
+`,
+		"plugins/notes/notes.go": `package notes
+
+//ncore:extension group=core weak=user
+func New() any { return nil }
+`,
+		"legacy/blog/blog.go": `package blog
+
+import "github.com/ncobase/ncore/extension/registry"
+
+func init() { registry.Register(NewBlog()) }
+
+func NewBlog() any { return nil }
+`,
+		"plugins/util/util.go":      "package util\n\nfunc New() any { return nil }\n",
+		"plugins/util/util_test.go": "package util\n\n//ncore:extension\nfunc NewTest() any { return nil }\n",
+		"tools/go.mod":              "module example.com/tools\n",
+		"tools/ext/ext.go":          "package ext\n\n//ncore:extension\nfunc New() any { return nil }\n",
+		"_skip/ext/ext.go":          "package ext\n\n//ncore:extension\nfunc New() any { return nil }\n",
+		"cmd/app/main.go":           "package main\n\n//ncore:extension\nfunc New() any { return nil }\n\nfunc main() {}\n",
+	})
+
+	output := filepath.Join(root, "internal", "registry", "registry_gen.go")
+	exts, err := Generate(Options{Root: root, Output: output, Exclude: []string{"cmd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Extension
+	for _, ext := range exts {
+		ext.Position = ""
+		got = append(got, ext)
+	}
+	want := []Extension{
+		{ImportPath: "example.com/app/legacy/blog", Package: "blog", Alias: "blog", Constructor: "NewBlog"},
+		{ImportPath: "example.com/app/plugins/blog", Package: "blog", Alias: "plugins_blog", Constructor: "New", Group: "cms", WeakDeps: []string{"user", "auth"}},
+		{ImportPath: "example.com/app/plugins/notes", Package: "notes", Alias: "notes", Constructor: "New", Group: "core", WeakDeps: []string{"user"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extensions = %+v\nwant %+v", got, want)
+	}
+
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"package registry",
+		`extregistry "github.com/ncobase/ncore/extension/registry"`,
+		`plugins_blog "example.com/app/plugins/blog"`,
+		`extregistry.RegisterToGroup(blog.NewBlog(), "")`,
+		`extregistry.RegisterToGroupWithWeakDeps(plugins_blog.New(), "cms", []string{"user", "auth"})`,
+		`extregistry.RegisterToGroupWithWeakDeps(notes.New(), "core", []string{"user"})`,
+	} {
+		if !strings.Contains(string(src), line) {
+			t.Errorf("generated registry lacks %q:\n%s", line, src)
+		}
+	}
+
+	// Regenerating skips the generated package
+	if again, err := Generate(Options{Root: root, Output: output, Exclude: []string{"cmd"}}); err != nil || len(again) != len(exts) {
+		t.Fatalf("regenerate = %d extensions, %v", len(again), err)
+	}
+}
+
+func TestScanRejectsInvalidRegistrations(t *testing.T) {
+	for name, src := range map[string]string{
+		"parameters": "package ext\n\n//ncore:extension\nfunc New(name string) any { return nil }\n",
+		"unexported": "package ext\n\n//ncore:extension\nfunc newExt() any { return nil }\n",
+		"option":     "package ext\n\n//ncore:extension tier=gold\nfunc New() any { return nil }\n",
+		"twice":      "package ext\n\n//ncore:extension\nfunc New() any { return nil }\n\n//ncore:extension\nfunc NewOther() any { return nil }\n",
+		"variable": `package ext
+
+import "github.com/ncobase/ncore/extension/registry"
+
+var ext any
+
+func init() { registry.Register(ext) }
+`,
+	} {
+		root := writeModule(t, map[string]string{"ext/ext.go": src})
+		if exts, err := Scan(root, nil); err == nil {
+			t.Errorf("%s: Scan = %+v, want an error", name, exts)
+		}
+	}
+}
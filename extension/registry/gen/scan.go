@@ -0,0 +1,399 @@
+// Package gen generates a typed extension registry from source,
+// replacing init() based registration with explicit imports.
+package gen
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// registryImportPath is the import path of the runtime registry
+const registryImportPath = "github.com/ncobase/ncore/extension/registry"
+
+// directive marks a constructor for registration, e.g. "//ncore:extension group=core weak=user,auth"
+const directive = "//ncore:extension"
+
+// Extension describes an extension package discovered by the scanner
+type Extension struct {
+	ImportPath  string   // package import path
+	Package     string   // package name
+	Alias       string   // import alias used in generated code
+	Constructor string   // exported constructor, e.g. New
+	Group       string   // registry group
+	WeakDeps    []string // weak dependencies
+	Position    string   // source position of the registration
+}
+
+// Scan walks root and returns all extension packages, sorted by import path.
+// Packages register either via registry calls in init() or a //ncore:extension directive.
+func Scan(root string, exclude []string) ([]Extension, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, dir := range exclude {
+		abs, err := filepath.Abs(filepath.Join(root, dir))
+		if err != nil {
+			return nil, err
+		}
+		skip[abs] = true
+	}
+
+	var exts []Extension
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		if p != root {
+			name := d.Name()
+			if skip[p] || name == "vendor" || name == "testdata" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			// Nested modules are scanned separately
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		importPath := modulePath
+		if rel != "." {
+			importPath = path.Join(modulePath, filepath.ToSlash(rel))
+		}
+
+		ext, found, err := scanPackage(p, importPath)
+		if err != nil {
+			return err
+		}
+		if found {
+			exts = append(exts, ext)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(exts, func(i, j int) bool {
+		return exts[i].ImportPath < exts[j].ImportPath
+	})
+	assignAliases(exts)
+
+	return exts, nil
+}
+
+// scanPackage parses the Go files of a directory and finds its registration
+func scanPackage(dir, importPath string) (Extension, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return Extension{}, false, err
+	}
+
+	fset := token.NewFileSet()
+	var found []Extension
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return Extension{}, false, fmt.Errorf("failed to parse %s: %v", filepath.Join(dir, name), err)
+		}
+		if file.Name.Name == "main" {
+			return Extension{}, false, nil
+		}
+
+		exts, err := scanFile(fset, file)
+		if err != nil {
+			return Extension{}, false, err
+		}
+		for _, ext := range exts {
+			ext.ImportPath = importPath
+			ext.Package = file.Name.Name
+			found = append(found, ext)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Extension{}, false, nil
+	case 1:
+		return found[0], true, nil
+	}
+
+	positions := make([]string, len(found))
+	for i, ext := range found {
+		positions[i] = ext.Position
+	}
+	return Extension{}, false, fmt.Errorf("package %s registers more than one extension: %s",
+		importPath, strings.Join(positions, ", "))
+}
+
+// scanFile finds directive constructors and registry calls inside init()
+func scanFile(fset *token.FileSet, file *ast.File) ([]Extension, error) {
+	registryName := importName(file, registryImportPath)
+
+	var exts []Extension
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil {
+			continue
+		}
+
+		if ext, ok, err := parseDirective(fset, fn); err != nil {
+			return nil, err
+		} else if ok {
+			exts = append(exts, ext)
+			continue
+		}
+
+		if fn.Name.Name != "init" || registryName == "" || fn.Body == nil {
+			continue
+		}
+
+		var scanErr error
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || scanErr != nil {
+				return scanErr == nil
+			}
+			ext, ok, err := parseRegistration(fset, call, registryName)
+			if err != nil {
+				scanErr = err
+				return false
+			}
+			if ok {
+				exts = append(exts, ext)
+			}
+			return true
+		})
+		if scanErr != nil {
+			return nil, scanErr
+		}
+	}
+
+	return exts, nil
+}
+
+// parseDirective reads a //ncore:extension directive on a constructor
+func parseDirective(fset *token.FileSet, fn *ast.FuncDecl) (Extension, bool, error) {
+	if fn.Doc == nil {
+		return Extension{}, false, nil
+	}
+
+	for _, c := range fn.Doc.List {
+		if c.Text != directive && !strings.HasPrefix(c.Text, directive+" ") {
+			continue
+		}
+
+		pos := fset.Position(fn.Pos()).String()
+		if !fn.Name.IsExported() || fn.Type.Params.NumFields() > 0 {
+			return Extension{}, false, fmt.Errorf("%s: %s must be placed on an exported constructor without parameters", pos, directive)
+		}
+
+		ext := Extension{Constructor: fn.Name.Name, Position: pos}
+		for _, field := range strings.Fields(strings.TrimPrefix(c.Text, directive)) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "group":
+				ext.Group = value
+			case "weak":
+				ext.WeakDeps = strings.Split(value, ",")
+			default:
+				return Extension{}, false, fmt.Errorf("%s: unknown directive option %q", pos, key)
+			}
+		}
+		return ext, true, nil
+	}
+
+	return Extension{}, false, nil
+}
+
+// parseRegistration reads a registry.Register* call
+func parseRegistration(fset *token.FileSet, call *ast.CallExpr, registryName string) (Extension, bool, error) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return Extension{}, false, nil
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != registryName {
+		return Extension{}, false, nil
+	}
+
+	var hasGroup, hasWeak bool
+	switch sel.Sel.Name {
+	case "Register":
+	case "RegisterToGroup":
+		hasGroup = true
+	case "RegisterWithWeakDeps":
+		hasWeak = true
+	case "RegisterToGroupWithWeakDeps":
+		hasGroup, hasWeak = true, true
+	default:
+		return Extension{}, false, nil
+	}
+
+	pos := fset.Position(call.Pos()).String()
+	want := 1
+	if hasGroup {
+		want++
+	}
+	if hasWeak {
+		want++
+	}
+	if len(call.Args) != want {
+		return Extension{}, false, fmt.Errorf("%s: unexpected arguments to %s.%s", pos, registryName, sel.Sel.Name)
+	}
+
+	ctor := constructorName(call.Args[0])
+	if ctor == "" {
+		return Extension{}, false, fmt.Errorf("%s: registration must call an exported constructor without arguments, e.g. New()", pos)
+	}
+
+	ext := Extension{Constructor: ctor, Position: pos}
+
+	if hasGroup {
+		group, err := stringLiteral(call.Args[1])
+		if err != nil {
+			return Extension{}, false, fmt.Errorf("%s: group %v", pos, err)
+		}
+		ext.Group = group
+	}
+
+	if hasWeak {
+		lit, ok := call.Args[len(call.Args)-1].(*ast.CompositeLit)
+		if !ok {
+			return Extension{}, false, fmt.Errorf("%s: weak dependencies must be a []string literal", pos)
+		}
+		for _, elt := range lit.Elts {
+			dep, err := stringLiteral(elt)
+			if err != nil {
+				return Extension{}, false, fmt.Errorf("%s: weak dependency %v", pos, err)
+			}
+			ext.WeakDeps = append(ext.WeakDeps, dep)
+		}
+	}
+
+	return ext, true, nil
+}
+
+// constructorName returns the function name of a call like New(), or empty otherwise
+func constructorName(expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) > 0 {
+		return ""
+	}
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok || !ident.IsExported() {
+		return ""
+	}
+	return ident.Name
+}
+
+// stringLiteral returns the value of a string literal expression
+func stringLiteral(expr ast.Expr) (string, error) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", fmt.Errorf("must be a string literal")
+	}
+	return strconv.Unquote(lit.Value)
+}
+
+// importName returns the local name of an import, or empty if not imported
+func importName(file *ast.File, importPath string) string {
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || p != importPath {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return path.Base(p)
+	}
+	return ""
+}
+
+// readModulePath reads the module path from go.mod
+func readModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", fmt.Errorf("failed to open go.mod: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("module path not found in %s", goMod)
+}
+
+// assignAliases gives each package a unique import alias
+func assignAliases(exts []Extension) {
+	used := map[string]bool{registryAlias: true}
+	for i := range exts {
+		alias := exts[i].Package
+		if used[alias] {
+			parent := path.Base(path.Dir(exts[i].ImportPath))
+			alias = sanitizeIdent(parent) + "_" + exts[i].Package
+		}
+		for n := 2; used[alias]; n++ {
+			alias = fmt.Sprintf("%s%d", exts[i].Package, n)
+		}
+		used[alias] = true
+		exts[i].Alias = alias
+	}
+}
+
+// sanitizeIdent turns a path element into a valid identifier
+func sanitizeIdent(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	if out := b.String(); out != "" && (out[0] < '0' || out[0] > '9') {
+		return out
+	}
+	return "p" + b.String()
+}
@@ -0,0 +1,161 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// CRUDOptions configures CRUD scaffolding
+type CRUDOptions struct {
+	Schema string // schema file
+	Dir    string // extension directory written to, the directory of the schema by default
+	Force  bool   // overwrite existing files
+	GRPC   bool   // also scaffold a gRPC service with its proto definition
+}
+
+// File is a scaffolded source file
+type File struct {
+	Path    string // slash separated, relative to the output directory
+	Content []byte
+	Skipped bool // not written as it exists
+	Shared  bool // generated from every entity, e.g. crud.go, rather than one
+}
+
+// GenerateCRUD writes the structs, repositories, services, handlers and route
+// registration of the schema entities. Existing files are kept unless Force
+// is set, as scaffolded code is meant to be edited.
+func GenerateCRUD(opts CRUDOptions) ([]*File, error) {
+	if opts.Schema == "" {
+		return nil, fmt.Errorf("schema file is required")
+	}
+	if opts.Dir == "" {
+		opts.Dir = filepath.Dir(opts.Schema)
+	}
+
+	s, err := LoadSchema(opts.Schema)
+	if err != nil {
+		return nil, err
+	}
+	files, err := RenderCRUD(s, filepath.Base(opts.Schema), opts.GRPC)
+	if err != nil {
+		return nil, err
+	}
+	if err := Write(opts.Dir, files, opts.Force); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// RenderCRUD returns the CRUD sources of a normalized schema, source names the
+// schema file in headers. With withGRPC, the entities are also served by a
+// gRPC service.
+func RenderCRUD(s *Schema, source string, withGRPC bool) ([]*File, error) {
+	var files []*File
+	add := func(path string, t *template.Template, data any) error {
+		src, err := render(t, data, strings.HasSuffix(path, ".go"))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		_, entity := data.(entityData)
+		files = append(files, &File{Path: path, Content: src, Shared: !entity})
+		return nil
+	}
+
+	schemaData := struct {
+		*Schema
+		Source string
+		GRPC   bool
+	}{s, source, withGRPC}
+	if err := add("crud.go", crudTemplate, schemaData); err != nil {
+		return nil, err
+	}
+	if err := add("data/repository/repository.go", repositoryCommonTemplate, schemaData); err != nil {
+		return nil, err
+	}
+	if err := add("handler/handler.go", handlerCommonTemplate, schemaData); err != nil {
+		return nil, err
+	}
+	if s.Searchable() {
+		if err := add("service/indexer.go", indexerTemplate, schemaData); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range s.Entities {
+		data := entityData{Entity: e, Schema: s, Source: source, Var: e.VarName(), Recv: strings.ToLower(e.GoName()[:1])}
+		for _, f := range e.Fields {
+			data.Time = data.Time || f.Type == "time"
+		}
+		for _, target := range []struct {
+			dir string
+			t   *template.Template
+		}{
+			{"structs", structsTemplate},
+			{"data/repository", repositoryTemplate},
+			{"service", serviceTemplate},
+			{"handler", handlerTemplate},
+		} {
+			if err := add(target.dir+"/"+e.File(), target.t, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if withGRPC {
+		if err := renderGRPC(s, source, add); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// entityData is the template data of the files of an entity
+type entityData struct {
+	*Entity
+	Schema *Schema
+	Source string
+	Var    string // variable holding an entity
+	Recv   string // receiver of entity methods
+	Time   bool   // whether a field is a time.Time
+}
+
+// Write writes files under dir, skipping existing files unless force is set
+func Write(dir string, files []*File, force bool) error {
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if !force {
+			if _, err := os.Stat(path); err == nil {
+				f.Skipped = true
+				continue
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// render executes a template and formats its output as Go source when gofmt is set
+func render(t *template.Template, data any, gofmt bool) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %v", t.Name(), err)
+	}
+	if !gofmt {
+		return buf.Bytes(), nil
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %v", t.Name(), err)
+	}
+	return src, nil
+}
@@ -0,0 +1,28 @@
+// Package scaffold generates the code of extensions from schema definitions.
+//
+// GenerateCRUD reads a YAML schema of entities and writes, for each of them,
+// the struct and request bodies in structs, a sqlrepo repository in
+// data/repository, a service in service and gin handlers in handler. A
+// crud.go at the extension root creates them on a database and registers
+// their routes and OpenAPI operations:
+//
+//	module: example.com/app/plugin/blog
+//	tenant: space_id
+//	entities:
+//	  - name: post
+//	    soft_delete: true
+//	    fields:
+//	      - {name: title, type: string, required: true, max: 200, search: true}
+//	      - {name: status, enum: [draft, published], default: draft, filter: true}
+//
+// With CRUDOptions.GRPC, the entities are also served over gRPC: a proto
+// definition with its buf configuration, and a server and client helpers in
+// rpc.
+//
+// CreateExtension scaffolds a whole extension and records its options in a
+// Manifest, so AddHandler and UpgradeTemplates later generate consistently
+// with them.
+//
+// The output is a starting point to edit; existing files are not overwritten
+// unless forced.
+package scaffold
@@ -0,0 +1,191 @@
+package scaffold
+
+import (
+	"slices"
+	"text/template"
+)
+
+// protoTypes maps field types to protobuf types
+var protoTypes = map[string]string{
+	"string": "string",
+	"text":   "string",
+	"email":  "string",
+	"url":    "string",
+	"int":    "int64",
+	"int64":  "int64",
+	"float":  "double",
+	"bool":   "bool",
+	"time":   "google.protobuf.Timestamp",
+}
+
+// ProtoPackage is the protobuf package of the gRPC service, e.g. blog.v1
+func (s *Schema) ProtoPackage() string { return s.Package + ".v1" }
+
+// ProtoDir is the directory of the proto file and generated code
+func (s *Schema) ProtoDir() string { return "proto/" + s.Package + "/v1" }
+
+// ProtoGoPackage is the name of the generated Go package, e.g. blogv1
+func (s *Schema) ProtoGoPackage() string { return s.Package + "v1" }
+
+// ProtoService is the name of the gRPC service, e.g. BlogService
+func (s *Schema) ProtoService() string { return goName(s.Package) + "Service" }
+
+// Identity reports whether services read the tenant or user from the context
+func (s *Schema) Identity() bool { return s.Tenant != "" || s.Audit }
+
+// hasType reports whether a field of an entity has the type
+func (s *Schema) hasType(typ string) bool {
+	for _, e := range s.Entities {
+		for _, f := range e.Fields {
+			if f.Type == typ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasTime reports whether a field is a time, a Timestamp in messages
+func (s *Schema) HasTime() bool { return s.hasType("time") }
+
+// HasInt reports whether a field is an int, an int64 in messages
+func (s *Schema) HasInt() bool { return s.hasType("int") }
+
+// ProtoPlural is the plural name of the entity in RPCs, e.g. BlogPosts
+func (e *Entity) ProtoPlural() string { return goName(plural(e.Name)) }
+
+// ProtoType is the protobuf type of the field
+func (f *Field) ProtoType() string { return protoTypes[f.Type] }
+
+// ProtoName is the name of the field in generated messages, following
+// protoc-gen-go, e.g. Id for id
+func (f *Field) ProtoName() string {
+	name := protoGoName(f.Name)
+	if slices.Contains(messageMethods, name) {
+		// protoc-gen-go suffixes names taken by methods of messages
+		name += "_"
+	}
+	return name
+}
+
+// messageMethods are the methods of generated messages
+var messageMethods = []string{"Reset", "String", "ProtoMessage", "Marshal", "Unmarshal", "ExtensionRangeArray", "ExtensionMap", "Descriptor"}
+
+// ProtoLabel labels the field of a message optional when pointer is set,
+// Timestamps are messages and always tell unset from zero
+func (f *Field) ProtoLabel(pointer bool) string {
+	if pointer && f.Type != "time" {
+		return "optional "
+	}
+	return ""
+}
+
+// EntityLabel is the label of the field in the entity message
+func (f *Field) EntityLabel() string { return f.ProtoLabel(f.Nullable) }
+
+// CreateLabel is the label of the field in the create request
+func (f *Field) CreateLabel() string { return f.ProtoLabel(f.CreateType()[0] == '*') }
+
+// ToProto converts the entity field of v to its message value
+func (f *Field) ToProto(v string) string {
+	expr := v + "." + f.GoName()
+	switch {
+	case f.Type == "time" && f.Nullable:
+		return "timestampPtr(" + expr + ")"
+	case f.Type == "time":
+		return "timestamp(" + expr + ")"
+	case f.Type == "int" && f.Nullable:
+		return "int64Ptr(" + expr + ")"
+	case f.Type == "int":
+		return "int64(" + expr + ")"
+	default:
+		return expr
+	}
+}
+
+// FromProto converts the field of the request message req to a body or
+// params field, a pointer when pointer is set
+func (f *Field) FromProto(req string, pointer bool) string {
+	switch {
+	case pointer && f.Type == "time":
+		return "timePtr(" + req + "." + f.ProtoName() + ")"
+	case pointer && f.Type == "int":
+		return "intPtr(" + req + "." + f.ProtoName() + ")"
+	case pointer:
+		return req + "." + f.ProtoName()
+	case f.Type == "time":
+		return "timeValue(" + req + ".Get" + f.ProtoName() + "())"
+	case f.Type == "int":
+		return "int(" + req + ".Get" + f.ProtoName() + "())"
+	default:
+		return req + ".Get" + f.ProtoName() + "()"
+	}
+}
+
+// CreateFromProto converts the field of a create request to the body field
+func (f *Field) CreateFromProto(req string) string {
+	return f.FromProto(req, f.CreateType()[0] == '*')
+}
+
+// protoGoName is the Go name protoc-gen-go gives a snake case field, the
+// GoCamelCase of google.golang.org/protobuf/internal/strs
+func protoGoName(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_' && i == 0:
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+			// the next letter is capitalized
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+
+// grpcFuncs are the functions of the gRPC templates
+var grpcFuncs = template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}
+
+// renderGRPC adds the proto definition, buf configuration and gRPC server
+// and client of the schema
+func renderGRPC(s *Schema, source string, add func(string, *template.Template, any) error) error {
+	schemaData := struct {
+		*Schema
+		Source string
+	}{s, source}
+	for _, f := range []struct {
+		path string
+		t    *template.Template
+	}{
+		{s.ProtoDir() + "/" + s.Package + ".proto", protoTemplate},
+		{"buf.yaml", bufTemplate},
+		{"buf.gen.yaml", bufGenTemplate},
+		{"rpc/server.go", rpcServerTemplate},
+		{"rpc/client.go", rpcClientTemplate},
+	} {
+		if err := add(f.path, f.t, schemaData); err != nil {
+			return err
+		}
+	}
+	for _, e := range s.Entities {
+		data := entityData{Entity: e, Schema: s, Source: source, Var: e.VarName()}
+		if err := add("rpc/"+e.File(), rpcTemplate, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
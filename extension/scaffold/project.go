@@ -0,0 +1,378 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ManifestFile records the options an extension was created with, in its
+// directory
+const ManifestFile = ".ncore.yaml"
+
+// TemplateVersion is the version of the templates, bumped when scaffolded
+// files change so UpgradeTemplates can tell outdated extensions
+const TemplateVersion = 1
+
+// Choices of extension manifests
+var (
+	ExtensionTypes = []string{"module", "core", "business", "plugin"}
+	ORMs           = []string{"sqlrepo", "none"} // sqlrepo scaffolds entities from a schema, none plain handlers
+	Drivers        = []string{"postgres", "mysql", "sqlite"}
+	Features       = []string{"grpc", "tenant", "audit"}
+)
+
+// Manifest is the content of ManifestFile, the options later generation
+// reuses so added handlers and upgraded templates match the original ones
+type Manifest struct {
+	Name      string   `yaml:"name"`               // extension name, e.g. blog
+	Module    string   `yaml:"module"`             // import path of the extension package
+	Type      string   `yaml:"type"`               // one of ExtensionTypes
+	Group     string   `yaml:"group,omitempty"`    // registry group
+	ORM       string   `yaml:"orm"`                // one of ORMs
+	Driver    string   `yaml:"driver,omitempty"`   // database driver of sqlrepo
+	Features  []string `yaml:"features,omitempty"` // of Features
+	Schema    string   `yaml:"schema,omitempty"`   // entity schema of sqlrepo, relative to the manifest
+	Handlers  []string `yaml:"handlers,omitempty"` // handlers added without an ORM
+	Templates int      `yaml:"templates"`          // TemplateVersion the files were scaffolded with
+}
+
+// Normalize checks the manifest and fills in defaults
+func (m *Manifest) Normalize() error {
+	if !isSnake(m.Name) {
+		return fmt.Errorf("invalid extension name %q, expected lower snake case", m.Name)
+	}
+	if m.Module == "" {
+		return fmt.Errorf("module is required")
+	}
+	m.Module = strings.TrimSuffix(m.Module, "/")
+	if m.Type == "" {
+		m.Type = "module"
+	}
+	if !slices.Contains(ExtensionTypes, m.Type) {
+		return fmt.Errorf("unknown type %q, expected one of %s", m.Type, strings.Join(ExtensionTypes, ", "))
+	}
+	if m.ORM == "" {
+		m.ORM = "sqlrepo"
+	}
+	if !slices.Contains(ORMs, m.ORM) {
+		return fmt.Errorf("unknown orm %q, expected one of %s", m.ORM, strings.Join(ORMs, ", "))
+	}
+	for _, f := range m.Features {
+		if !slices.Contains(Features, f) {
+			return fmt.Errorf("unknown feature %q, expected some of %s", f, strings.Join(Features, ", "))
+		}
+	}
+
+	if m.ORM != "sqlrepo" {
+		if len(m.Features) > 0 {
+			return fmt.Errorf("features %s require the sqlrepo orm", strings.Join(m.Features, ", "))
+		}
+		m.Driver, m.Schema = "", ""
+		return nil
+	}
+	if m.Driver == "" {
+		m.Driver = "postgres"
+	}
+	if !slices.Contains(Drivers, m.Driver) {
+		return fmt.Errorf("unknown driver %q, expected one of %s", m.Driver, strings.Join(Drivers, ", "))
+	}
+	if m.Schema == "" {
+		m.Schema = "schema.yaml"
+	}
+	if len(m.Handlers) > 0 {
+		return fmt.Errorf("handlers of the sqlrepo orm are the entities of %s", m.Schema)
+	}
+	return nil
+}
+
+// Has reports whether a feature was chosen
+func (m *Manifest) Has(feature string) bool { return slices.Contains(m.Features, feature) }
+
+// Package is the name of the extension package
+func (m *Manifest) Package() string {
+	return strings.NewReplacer("-", "", ".", "").Replace(strings.ToLower(m.Module[strings.LastIndex(m.Module, "/")+1:]))
+}
+
+// LoadManifest reads and checks the manifest of the extension in dir
+func LoadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found, the extension was not created by ncore create", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if err := m.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if m.Templates > TemplateVersion {
+		return nil, fmt.Errorf("%s: templates %d are newer than this ncore, which has %d", path, m.Templates, TemplateVersion)
+	}
+	return &m, nil
+}
+
+// CreateOptions configures the creation of an extension
+type CreateOptions struct {
+	Manifest Manifest
+	Dir      string // extension directory, the extension name by default
+	Entity   string // first entity of the sqlrepo schema, the extension name by default
+	Force    bool   // overwrite an existing extension
+}
+
+// CreateExtension writes the manifest, the extension implementing
+// types.Interface and, for the sqlrepo orm, an entity schema with its CRUD
+// layers
+func CreateExtension(opts CreateOptions) ([]*File, error) {
+	m := opts.Manifest
+	if err := m.Normalize(); err != nil {
+		return nil, err
+	}
+	m.Templates = TemplateVersion
+	if opts.Dir == "" {
+		opts.Dir = m.Name
+	}
+	if _, err := os.Stat(filepath.Join(opts.Dir, ManifestFile)); err == nil && !opts.Force {
+		return nil, fmt.Errorf("%s already holds an extension", opts.Dir)
+	}
+
+	var files []*File
+	if m.ORM == "sqlrepo" {
+		if opts.Entity == "" {
+			opts.Entity = m.Name
+		}
+		src, err := render(schemaTemplate, struct {
+			*Manifest
+			Entity string
+		}{&m, snakeCase(opts.Entity)}, false)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &File{Path: m.Schema, Content: src})
+	}
+	rendered, err := renderProject(opts.Dir, &m, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := Write(opts.Dir, rendered, opts.Force); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// AddHandler adds a handler named name to the extension in dir, with the
+// options of its manifest. For the sqlrepo orm, name is added as an entity
+// of the schema and its CRUD layers are scaffolded. Files shared by the
+// entities or handlers, e.g. crud.go, are rewritten; others are kept.
+func AddHandler(dir, name string) ([]*File, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	name = snakeCase(name)
+	if !isSnake(name) {
+		return nil, fmt.Errorf("invalid handler name %q", name)
+	}
+
+	var files []*File
+	if m.ORM == "sqlrepo" {
+		path := filepath.Join(dir, m.Schema)
+		src, err := addEntity(path, name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &File{Path: m.Schema, Content: src, Shared: true})
+	} else {
+		e := &Entity{Name: name}
+		if slices.Contains(reservedNames, e.VarName()) || token.IsKeyword(e.VarName()) {
+			return nil, fmt.Errorf("handler %s: name is reserved", name)
+		}
+		if slices.Contains(m.Handlers, name) {
+			return nil, fmt.Errorf("handler %s already exists", name)
+		}
+		m.Handlers = append(m.Handlers, name)
+	}
+
+	rendered, err := renderProject(dir, m, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeProject(dir, rendered, false); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// UpgradeTemplates scaffolds the extension in dir again with the current
+// templates and the options of its manifest. Files shared by the entities or
+// handlers and missing files are written, others are kept unless force is
+// set.
+func UpgradeTemplates(dir string, force bool) (files []*File, from int, err error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	from, m.Templates = m.Templates, TemplateVersion
+	files, err = renderProject(dir, m, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := writeProject(dir, files, force); err != nil {
+		return nil, 0, err
+	}
+	return files, from, nil
+}
+
+// renderProject returns the manifest, extension and handler sources of the
+// extension in dir, after files rendered by the caller. The sqlrepo schema is
+// read from files or dir.
+func renderProject(dir string, m *Manifest, files []*File) ([]*File, error) {
+	manifest, err := marshalYAML(m)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, &File{Path: ManifestFile, Content: append([]byte(manifestHeader), manifest...), Shared: true})
+
+	src, err := render(extensionTemplate, m, true)
+	if err != nil {
+		return nil, fmt.Errorf("extension.go: %v", err)
+	}
+	files = append(files, &File{Path: "extension.go", Content: src})
+
+	if m.ORM != "sqlrepo" {
+		src, err := render(handlersTemplate, m, true)
+		if err != nil {
+			return nil, fmt.Errorf("handlers.go: %v", err)
+		}
+		files = append(files, &File{Path: "handlers.go", Content: src, Shared: true})
+		for _, name := range m.Handlers {
+			e := &Entity{Name: name}
+			e.Table, e.Route = plural(name), "/"+strings.ReplaceAll(plural(name), "_", "-")
+			src, err := render(plainHandlerTemplate, e, true)
+			if err != nil {
+				return nil, fmt.Errorf("handler/%s: %v", e.File(), err)
+			}
+			files = append(files, &File{Path: "handler/" + e.File(), Content: src})
+		}
+		return files, nil
+	}
+
+	var s Schema
+	i := slices.IndexFunc(files, func(f *File) bool { return f.Path == m.Schema })
+	if i >= 0 {
+		err = yaml.Unmarshal(files[i].Content, &s)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(filepath.Join(dir, m.Schema)); err == nil {
+			err = yaml.Unmarshal(data, &s)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %v", err)
+	}
+	if err := s.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %v", m.Schema, err)
+	}
+	crud, err := RenderCRUD(&s, filepath.Base(m.Schema), m.Has("grpc"))
+	if err != nil {
+		return nil, err
+	}
+	return append(files, crud...), nil
+}
+
+// addEntity returns the schema at path with an entity named name appended,
+// holding a name field to edit
+func addEntity(path, name string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %v", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a schema", path)
+	}
+	root := doc.Content[0]
+	var entities *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "entities" {
+			entities = root.Content[i+1]
+		}
+	}
+	if entities == nil {
+		entities = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "entities"}, entities)
+	}
+	if entities.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: entities is not a list", path)
+	}
+
+	var entity yaml.Node
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(newEntity, name)), &entity); err != nil {
+		return nil, err
+	}
+	entities.Style = 0
+	entities.Content = append(entities.Content, entity.Content[0])
+
+	src, err := marshalYAML(&doc)
+	if err != nil {
+		return nil, err
+	}
+	// Check the entity before anything is written
+	var s Schema
+	if err := yaml.Unmarshal(src, &s); err != nil {
+		return nil, err
+	}
+	if err := s.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return src, nil
+}
+
+// marshalYAML encodes v indented by two spaces, as schemas are written
+func marshalYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newEntity is the entity added by AddHandler
+const newEntity = `name: %s
+fields:
+  - {name: name, type: string, required: true, max: 200}
+`
+
+// writeProject writes files under dir, rewriting the shared ones. Other
+// existing files are kept unless force is set.
+func writeProject(dir string, files []*File, force bool) error {
+	var shared, own []*File
+	for _, f := range files {
+		if f.Shared {
+			shared = append(shared, f)
+		} else {
+			own = append(own, f)
+		}
+	}
+	if err := Write(dir, shared, true); err != nil {
+		return err
+	}
+	return Write(dir, own, force)
+}
@@ -0,0 +1,373 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const blogSchema = `module: example.com/app/plugin/blog
+tenant: space_id
+audit: true
+entities:
+  - name: BlogPost
+    soft_delete: true
+    fields:
+      - {name: title, type: string, required: true, max: 200, search: true, description: Post title}
+      - {name: body, type: text, search: true}
+      - {name: status, type: string, enum: [draft, published], default: draft, filter: true}
+      - {name: pinned, type: bool, required: true}
+      - {name: views, type: int, min: 0}
+      - {name: published_at, type: time, nullable: true}
+  - name: category
+    fields:
+      - {name: name, required: true}
+`
+
+func writeSchema(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// squash collapses runs of spaces, gofmt aligns fields
+func squash(s string) string {
+	return regexp.MustCompile(`[ \t]+`).ReplaceAllString(s, " ")
+}
+
+func TestGenerateCRUD(t *testing.T) {
+	path := writeSchema(t, blogSchema)
+	files, err := GenerateCRUD(CRUDOptions{Schema: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Dir(path)
+	got := map[string]string{}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.Path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Path] = string(data)
+	}
+
+	for path, want := range map[string][]string{
+		"crud.go": {
+			"package blog",
+			"func NewCRUD(db sqlrepo.DB, indexer service.Indexer) (*CRUD, error)",
+			"service.NewCategoryService(categoryRepo)",
+		},
+		"structs/blog_post.go": {
+			"Title string `db:\"title\" json:\"title\" description:\"Post title\"`",
+			"PublishedAt *time.Time `db:\"published_at\" json:\"published_at,omitempty\"`",
+			"Title string `json:\"title\" validate:\"required,max=200\" description:\"Post title\"`",
+			"Status *string `json:\"status,omitempty\" validate:\"omitempty,oneof=draft published\"`",
+			"Pinned *bool `json:\"pinned\" validate:\"required\"`",
+			"Status *string `form:\"status\" json:\"status,omitempty\"`",
+			"DeletedAt *int64 `db:\"deleted_at\" json:\"-\"`",
+			"CreatedBy string",
+		},
+		"data/repository/blog_post.go": {
+			"TenantColumn: \"space_id\"",
+			"SoftDelete: \"deleted_at\"",
+			"filter[\"status\"] = *params.Status",
+		},
+		"service/blog_post.go": {
+			"Status: \"draft\"",
+			"Pinned: *body.Pinned",
+			"blogPost.PublishedAt = body.PublishedAt",
+			"Fields: []string{\"title\", \"body\"}",
+		},
+		"handler/blog_post.go": {
+			"group := r.Group(\"/blog-posts\")",
+			"group.GET(\"/search\", h.Search)",
+		},
+		"handler/handler.go": {"sqlrepo.ErrNoTenant", "service.ErrSearchDisabled"},
+	} {
+		src, ok := got[path]
+		if !ok {
+			t.Errorf("%s was not generated", path)
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(squash(src), w) {
+				t.Errorf("%s does not contain %s:\n%s", path, w, src)
+			}
+		}
+	}
+
+	if strings.Contains(got["service/category.go"], "index") || strings.Contains(got["handler/category.go"], "Search") {
+		t.Error("category has no searchable fields")
+	}
+	if _, ok := got["service/indexer.go"]; !ok {
+		t.Error("service/indexer.go was not generated")
+	}
+}
+
+func TestGenerateCRUDKeepsFiles(t *testing.T) {
+	path := writeSchema(t, blogSchema)
+	edited := filepath.Join(filepath.Dir(path), "service", "category.go")
+	if err := os.MkdirAll(filepath.Dir(edited), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(edited, []byte("package service\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := GenerateCRUD(CRUDOptions{Schema: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Skipped != (f.Path == "service/category.go") {
+			t.Errorf("%s skipped: %v", f.Path, f.Skipped)
+		}
+	}
+	if data, _ := os.ReadFile(edited); string(data) != "package service\n" {
+		t.Error("edited file was overwritten")
+	}
+
+	if _, err := GenerateCRUD(CRUDOptions{Schema: path, Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(edited); !strings.Contains(string(data), "CategoryService") {
+		t.Error("edited file was not overwritten with force")
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		schema string
+		err    string
+	}{
+		"no module":       {"entities: [{name: post, fields: [{name: title}]}]", "module is required"},
+		"reserved name":   {"module: a/b\nentities: [{name: page, fields: [{name: title}]}]", "reserved"},
+		"keyword":         {"module: a/b\nentities: [{name: type, fields: [{name: title}]}]", "reserved"},
+		"reserved column": {"module: a/b\nentities: [{name: post, fields: [{name: created_at}]}]", "added to every entity"},
+		"unknown type":    {"module: a/b\nentities: [{name: post, fields: [{name: title, type: blob}]}]", "unknown type"},
+		"enum default":    {"module: a/b\nentities: [{name: post, fields: [{name: s, enum: [a, b], default: c}]}]", "not in the enum"},
+		"int default":     {"module: a/b\nentities: [{name: post, fields: [{name: n, type: int, default: x}]}]", "invalid default"},
+		"search int":      {"module: a/b\nentities: [{name: post, fields: [{name: n, type: int, search: true}]}]", "searchable"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadSchema(writeSchema(t, tc.schema))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	e := &Entity{Name: "blog_category"}
+	if e.GoName() != "BlogCategory" || e.VarName() != "blogCategory" || plural(e.Name) != "blog_categories" {
+		t.Errorf("unexpected names %s %s %s", e.GoName(), e.VarName(), plural(e.Name))
+	}
+	if goName("author_id") != "AuthorID" || lowerFirst("URLPath") != "urlPath" || lowerFirst("ID") != "id" {
+		t.Error("unexpected initialisms")
+	}
+	if snakeCase("BlogPost") != "blog_post" || snakeCase("blog_post") != "blog_post" {
+		t.Error("unexpected snake case")
+	}
+}
+
+func TestGenerateCRUDWithGRPC(t *testing.T) {
+	path := writeSchema(t, blogSchema)
+	files, err := GenerateCRUD(CRUDOptions{Schema: path, GRPC: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Dir(path)
+	got := map[string]string{}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.Path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Path] = string(data)
+	}
+
+	for path, want := range map[string][]string{
+		"proto/blog/v1/blog.proto": {
+			"package blog.v1;",
+			"import \"google/protobuf/timestamp.proto\";",
+			"option go_package = \"example.com/app/plugin/blog/proto/blog/v1;blogv1\";",
+			"rpc CreateBlogPost(CreateBlogPostRequest) returns (BlogPost);",
+			"rpc SearchBlogPosts(SearchBlogPostsRequest) returns (SearchBlogPostsResponse);",
+			"rpc ListCategories(ListCategoriesRequest) returns (ListCategoriesResponse);",
+			"string created_by = 4;",
+			"string title = 6;",
+			"google.protobuf.Timestamp published_at = 11;",
+			"optional bool pinned = 4;",
+			"optional string status = 6;",
+		},
+		"buf.gen.yaml": {"buf.build/grpc/go"},
+		"rpc/server.go": {
+			"blogv1.UnimplementedBlogServiceServer",
+			"func NewServer(blogPost *service.BlogPostService, category *service.CategoryService) *Server",
+			"ctx = ctxutil.SetSpaceID(ctx, v[0])",
+			"case errors.Is(err, sqlrepo.ErrNoTenant):",
+		},
+		"rpc/client.go": {
+			"const ServiceName = \"blog.v1.BlogService\"",
+			"metadata.AppendToOutgoingContext(ctx, userIDKey, id)",
+		},
+		"rpc/blog_post.go": {
+			"Title: req.GetTitle(),",
+			"Views: intPtr(req.Views),",
+			"PublishedAt: timestampPtr(v.PublishedAt),",
+			"s.blogPost.Update(ctx, req.GetId(), body)",
+		},
+		"crud.go": {"rpc.NewServer(c.BlogPostService, c.CategoryService).Register(server)"},
+	} {
+		src, ok := got[path]
+		if !ok {
+			t.Errorf("%s was not generated", path)
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(squash(src), w) {
+				t.Errorf("%s does not contain %s:\n%s", path, w, src)
+			}
+		}
+	}
+	if strings.Contains(got["rpc/category.go"], "Search") {
+		t.Error("category has no searchable fields")
+	}
+}
+
+func TestProtoNames(t *testing.T) {
+	for name, want := range map[string]string{
+		"id":           "Id",
+		"author_id":    "AuthorId",
+		"published_at": "PublishedAt",
+		"v2_name":      "V2Name",
+		"string":       "String_",
+	} {
+		if got := (&Field{Name: name}).ProtoName(); got != want {
+			t.Errorf("ProtoName(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestCreateExtension(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shop")
+	m := Manifest{Name: "shop", Module: "example.com/app/plugin/shop", Type: "plugin", Group: "plug", Driver: "mysql", Features: []string{"tenant", "grpc"}}
+	if _, err := CreateExtension(CreateOptions{Manifest: m, Dir: dir, Entity: "product"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateExtension(CreateOptions{Manifest: m, Dir: dir}); err == nil {
+		t.Error("expected an error creating over an extension")
+	}
+
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ORM != "sqlrepo" || loaded.Schema != "schema.yaml" || !loaded.Has("grpc") || loaded.Templates != TemplateVersion {
+		t.Errorf("unexpected manifest %+v", loaded)
+	}
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	for _, want := range []string{"registry.RegisterToGroup(New(), \"plug\")", "m.crud, err = NewCRUD(db)", "m.crud.RegisterGRPCServices(server)"} {
+		if !strings.Contains(read("extension.go"), want) {
+			t.Errorf("extension.go does not contain %s", want)
+		}
+	}
+
+	edited := filepath.Join(dir, "service", "product.go")
+	if err := os.WriteFile(edited, []byte("package service\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := AddHandler(dir, "Review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Skipped != (f.Path == "extension.go" || strings.HasSuffix(f.Path, "/product.go")) {
+			t.Errorf("%s skipped: %v", f.Path, f.Skipped)
+		}
+	}
+	if !strings.Contains(read("schema.yaml"), "- name: review") || !strings.Contains(read("crud.go"), "c.ReviewService") ||
+		!strings.Contains(read("rpc/server.go"), "review *service.ReviewService") {
+		t.Error("review was not added")
+	}
+	if read("service/product.go") != "package service\n" {
+		t.Error("edited file was overwritten")
+	}
+	if _, err := AddHandler(dir, "review"); err == nil || !strings.Contains(err.Error(), "duplicate entity") {
+		t.Errorf("expected a duplicate entity error, got %v", err)
+	}
+
+	if _, from, err := UpgradeTemplates(dir, true); err != nil || from != TemplateVersion {
+		t.Fatalf("upgrade from %d: %v", from, err)
+	}
+	if !strings.Contains(read("service/product.go"), "ProductService") {
+		t.Error("edited file was not overwritten with force")
+	}
+}
+
+func TestCreateExtensionWithoutORM(t *testing.T) {
+	dir := t.TempDir()
+	m := Manifest{Name: "pages", Module: "example.com/app/plugin/pages", ORM: "none"}
+	if _, err := CreateExtension(CreateOptions{Manifest: m, Dir: dir, Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddHandler(dir, "note"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddHandler(dir, "note"); err == nil {
+		t.Error("expected an error adding a handler twice")
+	}
+
+	loaded, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.Handlers, []string{"note"}) {
+		t.Errorf("unexpected handlers %v", loaded.Handlers)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "handlers.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(squash(string(data)), "Note: handler.NewNoteHandler(),") {
+		t.Errorf("handlers.go does not create the note handler:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "handler", "note.go")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestManifestErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		m   Manifest
+		err string
+	}{
+		"name":     {Manifest{Name: "Shop", Module: "m"}, "invalid extension name"},
+		"module":   {Manifest{Name: "shop"}, "module is required"},
+		"type":     {Manifest{Name: "shop", Module: "m", Type: "app"}, "unknown type"},
+		"driver":   {Manifest{Name: "shop", Module: "m", Driver: "oracle"}, "unknown driver"},
+		"feature":  {Manifest{Name: "shop", Module: "m", Features: []string{"cache"}}, "unknown feature"},
+		"features": {Manifest{Name: "shop", Module: "m", ORM: "none", Features: []string{"grpc"}}, "require the sqlrepo orm"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := tc.m.Normalize(); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
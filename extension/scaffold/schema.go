@@ -0,0 +1,506 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Schema describes the entities CRUD code is generated for
+//
+//	module: github.com/acme/app/plugin/blog
+//	driver: postgres
+//	tenant: space_id
+//	audit: true
+//	entities:
+//	  - name: post
+//	    soft_delete: true
+//	    fields:
+//	      - {name: title, type: string, required: true, max: 200, search: true}
+//	      - {name: status, type: string, enum: [draft, published], default: draft, filter: true}
+//	      - {name: published_at, type: time, nullable: true}
+type Schema struct {
+	// Module is the import path of the output directory, the extension package
+	Module string `yaml:"module"`
+	// Package is the name of the extension package, the last element of Module by default
+	Package string `yaml:"package"`
+	// Driver selects the placeholder style of the repositories, postgres by default
+	Driver string `yaml:"driver"`
+	// Tenant is the tenant column scoping every statement to ctxutil.GetSpaceID
+	Tenant string `yaml:"tenant"`
+	// Audit adds created_by and updated_by, stamped with ctxutil.GetUserID
+	Audit    bool      `yaml:"audit"`
+	Entities []*Entity `yaml:"entities"`
+}
+
+// Entity is a table with CRUD routes
+type Entity struct {
+	Name       string   `yaml:"name"`        // singular snake or camel case, e.g. blog_post
+	Table      string   `yaml:"table"`       // defaults to the plural name, e.g. blog_posts
+	Route      string   `yaml:"route"`       // defaults to the plural name, e.g. /blog-posts
+	SoftDelete bool     `yaml:"soft_delete"` // deletes stamp deleted_at
+	Fields     []*Field `yaml:"fields"`
+}
+
+// Field is a column of an entity, besides the id and timestamp columns added to every entity
+type Field struct {
+	Name        string   `yaml:"name"` // snake case column and JSON name
+	Type        string   `yaml:"type"` // string, text, email, url, int, int64, float, bool or time
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"` // required on create
+	Nullable    bool     `yaml:"nullable"` // NULL when unset, a pointer field
+	Min         *float64 `yaml:"min"`      // minimum length of strings, value of numbers
+	Max         *float64 `yaml:"max"`
+	Enum        []string `yaml:"enum"`    // allowed string values
+	Default     string   `yaml:"default"` // value on create when unset
+	Filter      bool     `yaml:"filter"`  // exact match list filter
+	Search      bool     `yaml:"search"`  // matched by full text search
+}
+
+// fieldTypes maps field types to Go types
+var fieldTypes = map[string]string{
+	"string": "string",
+	"text":   "string",
+	"email":  "string",
+	"url":    "string",
+	"int":    "int",
+	"int64":  "int64",
+	"float":  "float64",
+	"bool":   "bool",
+	"time":   "time.Time",
+}
+
+// reservedColumns are added to every entity
+var reservedColumns = []string{"id", "created_at", "updated_at", "deleted_at", "created_by", "updated_by"}
+
+// reservedNames would shadow packages or variables of the generated code
+var reservedNames = []string{
+	"args", "base", "body", "c", "cond", "ctx", "err", "filter", "gin", "group", "h", "handler", "hit", "http",
+	"id", "item", "items", "limit", "logger", "op", "order", "p", "page", "paging", "params", "query", "r",
+	"repository", "req", "res", "resp", "result", "s", "search", "service", "sqlrepo", "sqlscan", "structs",
+	"svc", "tags", "total", "v", "where",
+}
+
+// LoadSchema reads and checks a schema file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %v", err)
+	}
+	var s Schema
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %v", path, err)
+	}
+	if err := s.Normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Normalize checks the schema and fills in defaults
+func (s *Schema) Normalize() error {
+	if s.Module == "" {
+		return fmt.Errorf("module is required")
+	}
+	s.Module = strings.TrimSuffix(s.Module, "/")
+	if s.Package == "" {
+		s.Package = s.Module[strings.LastIndex(s.Module, "/")+1:]
+		s.Package = strings.NewReplacer("-", "", ".", "").Replace(strings.ToLower(s.Package))
+	}
+	if !isIdentifier(s.Package) {
+		return fmt.Errorf("invalid package name %q", s.Package)
+	}
+	if s.Driver == "" {
+		s.Driver = "postgres"
+	}
+	if s.Tenant != "" && !isSnake(s.Tenant) {
+		return fmt.Errorf("invalid tenant column %q", s.Tenant)
+	}
+	if len(s.Entities) == 0 {
+		return fmt.Errorf("no entities")
+	}
+
+	names := map[string]bool{}
+	for _, e := range s.Entities {
+		if err := e.normalize(s); err != nil {
+			return err
+		}
+		if names[e.GoName()] {
+			return fmt.Errorf("duplicate entity %s", e.Name)
+		}
+		names[e.GoName()] = true
+	}
+	return nil
+}
+
+func (e *Entity) normalize(s *Schema) error {
+	e.Name = snakeCase(e.Name)
+	if !isSnake(e.Name) {
+		return fmt.Errorf("invalid entity name %q", e.Name)
+	}
+	if slices.Contains(reservedNames, e.VarName()) || token.IsKeyword(e.VarName()) {
+		return fmt.Errorf("entity %s: name is reserved", e.Name)
+	}
+	if e.Table == "" {
+		e.Table = plural(e.Name)
+	}
+	if !isSnake(e.Table) {
+		return fmt.Errorf("entity %s: invalid table %q", e.Name, e.Table)
+	}
+	if e.Route == "" {
+		e.Route = strings.ReplaceAll(plural(e.Name), "_", "-")
+	}
+	e.Route = "/" + strings.Trim(e.Route, "/")
+	if len(e.Fields) == 0 {
+		return fmt.Errorf("entity %s: no fields", e.Name)
+	}
+
+	seen := map[string]bool{}
+	for _, f := range e.Fields {
+		if err := f.normalize(); err != nil {
+			return fmt.Errorf("entity %s: %v", e.Name, err)
+		}
+		if slices.Contains(reservedColumns, f.Name) || f.Name == s.Tenant {
+			return fmt.Errorf("entity %s: field %s is added to every entity", e.Name, f.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("entity %s: duplicate field %s", e.Name, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+func (f *Field) normalize() error {
+	if !isSnake(f.Name) {
+		return fmt.Errorf("invalid field name %q", f.Name)
+	}
+	if strings.ContainsAny(f.Description, "`\n") {
+		return fmt.Errorf("field %s: description contains a backquote or newline", f.Name)
+	}
+	if f.Type == "" {
+		f.Type = "string"
+	}
+	if _, ok := fieldTypes[f.Type]; !ok {
+		return fmt.Errorf("field %s: unknown type %q", f.Name, f.Type)
+	}
+
+	switch {
+	case len(f.Enum) > 0 && !f.stringType():
+		return fmt.Errorf("field %s: enum requires a string type", f.Name)
+	case (f.Min != nil || f.Max != nil) && (f.Type == "bool" || f.Type == "time"):
+		return fmt.Errorf("field %s: min and max do not apply to %s", f.Name, f.Type)
+	case f.Search && !f.stringType():
+		return fmt.Errorf("field %s: only string fields are searchable", f.Name)
+	case f.Filter && (f.Type == "text" || f.Type == "time"):
+		return fmt.Errorf("field %s: %s fields cannot be filters", f.Name, f.Type)
+	case f.Default != "" && f.Nullable:
+		return fmt.Errorf("field %s: nullable fields have no default", f.Name)
+	}
+	for _, v := range f.Enum {
+		if strings.ContainsAny(v, " \t,") {
+			return fmt.Errorf("field %s: enum value %q contains a separator", f.Name, v)
+		}
+	}
+	if f.Default != "" {
+		if _, err := f.defaultExpr(); err != nil {
+			return fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		if len(f.Enum) > 0 && !slices.Contains(f.Enum, f.Default) {
+			return fmt.Errorf("field %s: default %q is not in the enum", f.Name, f.Default)
+		}
+	}
+	return nil
+}
+
+// Searchable reports whether any entity has searchable fields
+func (s *Schema) Searchable() bool {
+	for _, e := range s.Entities {
+		if e.Searchable() {
+			return true
+		}
+	}
+	return false
+}
+
+// GoName is the exported Go name of the entity, BlogPost
+func (e *Entity) GoName() string { return goName(e.Name) }
+
+// VarName is the unexported Go name of the entity, blogPost
+func (e *Entity) VarName() string { return lowerFirst(e.GoName()) }
+
+// Human is the name of the entity in comments, blog post
+func (e *Entity) Human() string { return strings.ReplaceAll(e.Name, "_", " ") }
+
+// HumanPlural is the plural name of the entity in comments, blog posts
+func (e *Entity) HumanPlural() string { return strings.ReplaceAll(plural(e.Name), "_", " ") }
+
+// File is the name of the files of the entity, blog_post.go
+func (e *Entity) File() string { return e.Name + ".go" }
+
+// Searchable reports whether the entity has searchable fields
+func (e *Entity) Searchable() bool { return len(e.SearchFields()) > 0 }
+
+// SearchFields returns the names of the searchable fields
+func (e *Entity) SearchFields() []string {
+	var names []string
+	for _, f := range e.Fields {
+		if f.Search {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Filters returns the fields listing can be filtered by
+func (e *Entity) Filters() []*Field {
+	var fields []*Field
+	for _, f := range e.Fields {
+		if f.Filter {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Index is the position of the field in the entity
+func (e *Entity) Index(f *Field) int { return slices.Index(e.Fields, f) }
+
+// GoName is the exported Go name of the field
+func (f *Field) GoName() string { return goName(f.Name) }
+
+// BaseType is the Go type of the field values
+func (f *Field) BaseType() string { return fieldTypes[f.Type] }
+
+// GoType is the Go type of the entity field
+func (f *Field) GoType() string {
+	if f.Nullable {
+		return "*" + f.BaseType()
+	}
+	return f.BaseType()
+}
+
+// CreateType is the Go type of the field in the create body, pointers for
+// optional fields so unset can be told from zero
+func (f *Field) CreateType() string {
+	if f.Required && !f.Nullable && f.Type != "bool" {
+		return fieldTypes[f.Type]
+	}
+	return "*" + fieldTypes[f.Type]
+}
+
+// CreateValue is the expression setting the entity field from the create
+// body, empty when the body field is optional and set only when present
+func (f *Field) CreateValue() string {
+	switch {
+	case f.Nullable:
+		return "body." + f.GoName()
+	case f.Required && f.Type == "bool":
+		return "*body." + f.GoName()
+	case f.Required:
+		return "body." + f.GoName()
+	default:
+		return ""
+	}
+}
+
+// DefaultValue is the default as a Go expression
+func (f *Field) DefaultValue() string {
+	expr, _ := f.defaultExpr()
+	return expr
+}
+
+// CreateTag is the struct tag of the field in the create body
+func (f *Field) CreateTag() string {
+	tag := fmt.Sprintf(`json:"%s%s"`, f.Name, omitempty(!f.Required))
+	if rules := f.rules(f.Required); rules != "" {
+		tag += fmt.Sprintf(` validate:"%s"`, rules)
+	}
+	return tag + f.descriptionTag()
+}
+
+// UpdateTag is the struct tag of the field in the update body, where every field is optional
+func (f *Field) UpdateTag() string {
+	tag := fmt.Sprintf(`json:"%s,omitempty"`, f.Name)
+	if rules := f.rules(false); rules != "" {
+		tag += fmt.Sprintf(` validate:"%s"`, rules)
+	}
+	return tag + f.descriptionTag()
+}
+
+// EntityTag is the struct tag of the entity field
+func (f *Field) EntityTag() string {
+	return fmt.Sprintf(`db:"%s" json:"%s%s"`, f.Name, f.Name, omitempty(f.Nullable)) + f.descriptionTag()
+}
+
+// descriptionTag documents the field in OpenAPI schemas
+func (f *Field) descriptionTag() string {
+	if f.Description == "" {
+		return ""
+	}
+	return " description:" + strconv.Quote(f.Description)
+}
+
+// rules returns the validation rules of the field
+func (f *Field) rules(required bool) string {
+	var rules []string
+	if required {
+		rules = append(rules, "required")
+	} else {
+		rules = append(rules, "omitempty")
+	}
+	switch f.Type {
+	case "email":
+		rules = append(rules, "email")
+	case "url":
+		rules = append(rules, "url")
+	}
+	if f.Min != nil {
+		rules = append(rules, "min="+formatNumber(*f.Min))
+	}
+	if f.Max != nil {
+		rules = append(rules, "max="+formatNumber(*f.Max))
+	}
+	if len(f.Enum) > 0 {
+		rules = append(rules, "oneof="+strings.Join(f.Enum, " "))
+	}
+	if len(rules) == 1 && rules[0] == "omitempty" {
+		return ""
+	}
+	return strings.Join(rules, ",")
+}
+
+// defaultExpr returns the default value as a Go expression
+func (f *Field) defaultExpr() (string, error) {
+	switch fieldTypes[f.Type] {
+	case "string":
+		return strconv.Quote(f.Default), nil
+	case "int", "int64":
+		if _, err := strconv.ParseInt(f.Default, 10, 64); err != nil {
+			return "", fmt.Errorf("invalid default %q", f.Default)
+		}
+		return f.Default, nil
+	case "float64":
+		if _, err := strconv.ParseFloat(f.Default, 64); err != nil {
+			return "", fmt.Errorf("invalid default %q", f.Default)
+		}
+		return f.Default, nil
+	case "bool":
+		b, err := strconv.ParseBool(f.Default)
+		if err != nil {
+			return "", fmt.Errorf("invalid default %q", f.Default)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return "", fmt.Errorf("%s fields have no default", f.Type)
+	}
+}
+
+// stringType reports whether the field is a string
+func (f *Field) stringType() bool {
+	return fieldTypes[f.Type] == "string"
+}
+
+func omitempty(ok bool) string {
+	if ok {
+		return ",omitempty"
+	}
+	return ""
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// initialisms are upper-cased in Go names
+var initialisms = map[string]bool{"api": true, "html": true, "http": true, "id": true, "ip": true, "json": true, "sql": true, "uri": true, "url": true, "uuid": true}
+
+// goName converts a snake case name to an exported Go name, author_id is AuthorID
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// lowerFirst lower-cases the first word of a Go name, IDCard is idCard
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	if i > 1 && i < len(runes) {
+		i-- // keep the first letter of the next word, URLPath is urlPath
+	}
+	for j := 0; j < i || j == 0; j++ {
+		runes[j] = unicode.ToLower(runes[j])
+	}
+	return string(runes)
+}
+
+// snakeCase converts a camel case name to snake case, leaving snake case as is
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// plural returns the English plural of a snake case name, for its last word
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+func isSnake(s string) bool {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
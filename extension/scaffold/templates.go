@@ -0,0 +1,1487 @@
+package scaffold
+
+import "text/template"
+
+// header starts every scaffolded file, which is meant to be edited
+const header = `// Scaffolded by "ncore gen crud" from {{.Source}}.
+`
+
+var structsTemplate = template.Must(template.New("structs").Parse(header + `
+package structs
+
+import (
+	"fmt"
+{{- if .Time}}
+	"time"
+{{- end}}
+
+	"github.com/ncobase/ncore/paging"
+)
+
+// {{.GoName}} is a {{.Human}} stored in {{.Table}}
+type {{.GoName}} struct {
+	ID string ` + "`" + `db:"id" json:"id"` + "`" + `
+{{- range .Fields}}
+	{{.GoName}} {{.GoType}} ` + "`{{.EntityTag}}`" + `
+{{- end}}
+{{- if .Schema.Audit}}
+	CreatedBy string ` + "`" + `db:"created_by" json:"created_by,omitempty"` + "`" + `
+	UpdatedBy string ` + "`" + `db:"updated_by" json:"updated_by,omitempty"` + "`" + `
+{{- end}}
+	CreatedAt int64 ` + "`" + `db:"created_at" json:"created_at"` + "`" + `
+	UpdatedAt int64 ` + "`" + `db:"updated_at" json:"updated_at"` + "`" + `
+{{- if .SoftDelete}}
+	DeletedAt *int64 ` + "`" + `db:"deleted_at" json:"-"` + "`" + `
+{{- end}}
+}
+
+// GetCursorValue returns the pagination cursor of the {{.Human}}, its ID and creation time
+func ({{.Recv}} *{{.GoName}}) GetCursorValue() string {
+	return fmt.Sprintf("%s:%d", {{.Recv}}.ID, {{.Recv}}.CreatedAt)
+}
+
+// Create{{.GoName}}Body is the request body creating a {{.Human}}
+type Create{{.GoName}}Body struct {
+{{- range .Fields}}
+	{{.GoName}} {{.CreateType}} ` + "`{{.CreateTag}}`" + `
+{{- end}}
+}
+
+// Update{{.GoName}}Body is the request body updating a {{.Human}}, absent fields are kept
+type Update{{.GoName}}Body struct {
+{{- range .Fields}}
+	{{.GoName}} *{{.BaseType}} ` + "`{{.UpdateTag}}`" + `
+{{- end}}
+}
+
+// List{{.GoName}}Params are the query parameters listing {{.HumanPlural}}, a page
+// around a cursor, newest first{{if .Filters}}, filtered by field values{{end}}
+type List{{.GoName}}Params struct {
+	Cursor    string ` + "`" + `form:"cursor" json:"cursor,omitempty"` + "`" + `
+	Limit     int    ` + "`" + `form:"limit" json:"limit,omitempty" validate:"omitempty,min=1,max=1000"` + "`" + `
+	Direction string ` + "`" + `form:"direction" json:"direction,omitempty" validate:"omitempty,oneof=forward backward"` + "`" + `
+{{- range .Filters}}
+	{{.GoName}} *{{.BaseType}} ` + "`" + `form:"{{.Name}}" json:"{{.Name}},omitempty"` + "`" + `
+{{- end}}
+}
+
+// Paging returns the pagination parameters
+func (p *List{{.GoName}}Params) Paging() paging.Params {
+	return paging.Params{Cursor: p.Cursor, Limit: p.Limit, Direction: p.Direction}
+}
+{{- if .Searchable}}
+
+// Search{{.GoName}}Params are the query parameters searching {{.HumanPlural}}
+type Search{{.GoName}}Params struct {
+	Query string ` + "`" + `form:"q" json:"q" validate:"required"` + "`" + `
+	Limit int    ` + "`" + `form:"limit" json:"limit,omitempty" validate:"omitempty,min=1,max=100"` + "`" + `
+}
+
+// {{.GoName}}SearchResult holds the {{.HumanPlural}} matching a search, by relevance
+type {{.GoName}}SearchResult struct {
+	Items []*{{.GoName}} ` + "`" + `json:"items"` + "`" + `
+	Total int64 ` + "`" + `json:"total"` + "`" + `
+}
+{{- end}}
+`))
+
+var repositoryCommonTemplate = template.Must(template.New("repository").Parse(header + `
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ncobase/ncore/data/sqlrepo"
+	"github.com/ncobase/ncore/paging"
+)
+
+// conditions returns the equality conditions of filter, ordered by column
+func conditions(filter sqlrepo.Filter) ([]string, []any) {
+	columns := slices.Sorted(maps.Keys(filter))
+	cond := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for _, column := range columns {
+		cond = append(cond, column+" = ?")
+		args = append(args, filter[column])
+	}
+	return cond, args
+}
+
+// keyset returns the condition of a page after cursor in creation order,
+// newest first, or before it backward, and the order of the page
+func keyset(cursor, direction string) (cond string, args []any, order string, err error) {
+	op, order := "<", "DESC"
+	if direction == "backward" {
+		op, order = ">", "ASC"
+	}
+	if cursor == "" {
+		return "", nil, order, nil
+	}
+
+	id, createdAt, err := paging.DecodeCursor(cursor)
+	if err != nil {
+		return "", nil, "", err
+	}
+	cond = fmt.Sprintf("(created_at %s ? OR (created_at = ? AND id %s ?))", op, op)
+	return cond, []any{createdAt, createdAt, id}, order, nil
+}
+`))
+
+var repositoryTemplate = template.Must(template.New("repository").Parse(header + `
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	{{if or .Schema.Tenant .Schema.Audit}}"github.com/ncobase/ncore/ctxutil"
+	{{end}}"github.com/ncobase/ncore/data/sqlrepo"
+	"github.com/ncobase/ncore/data/sqlscan"
+	"github.com/ncobase/ncore/utils/nanoid"
+	"{{.Schema.Module}}/structs"
+)
+
+// {{.GoName}}Repository stores {{.HumanPlural}}
+type {{.GoName}}Repository interface {
+	Create(ctx context.Context, {{.Var}} *structs.{{.GoName}}) error
+	Get(ctx context.Context, id string) (*structs.{{.GoName}}, error)
+	Update(ctx context.Context, {{.Var}} *structs.{{.GoName}}) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, params *structs.List{{.GoName}}Params) ([]*structs.{{.GoName}}, error)
+	Count(ctx context.Context, params *structs.List{{.GoName}}Params) (int, error)
+}
+
+type {{.Var}}Repository struct {
+	*sqlrepo.Base[structs.{{.GoName}}, string]
+}
+
+// New{{.GoName}}Repository creates a {{.Human}} repository on db
+func New{{.GoName}}Repository(db sqlrepo.DB) ({{.GoName}}Repository, error) {
+	base, err := sqlrepo.New[structs.{{.GoName}}, string](db, sqlrepo.Options{
+		Table:  "{{.Table}}",
+		Driver: "{{.Schema.Driver}}",
+{{- if .SoftDelete}}
+		SoftDelete: "deleted_at",
+{{- end}}
+{{- if .Schema.Tenant}}
+		TenantColumn: "{{.Schema.Tenant}}",
+		TenantFunc:   ctxutil.GetSpaceID,
+{{- end}}
+		Audit: true,
+{{- if .Schema.Audit}}
+		ActorFunc: ctxutil.GetUserID,
+{{- end}}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Var}}Repository{Base: base}, nil
+}
+
+// Create inserts {{.Var}}, with a new ID when it has none
+func (r *{{.Var}}Repository) Create(ctx context.Context, {{.Var}} *structs.{{.GoName}}) error {
+	if {{.Var}}.ID == "" {
+		{{.Var}}.ID = nanoid.PrimaryKey()()
+	}
+	return r.Base.Create(ctx, {{.Var}})
+}
+
+// List returns a page of {{.HumanPlural}} around params.Cursor, see keyset
+func (r *{{.Var}}Repository) List(ctx context.Context, params *structs.List{{.GoName}}Params) ([]*structs.{{.GoName}}, error) {
+	cond, args := conditions({{.Var}}Filter(params))
+	page, pageArgs, order, err := keyset(params.Cursor, params.Direction)
+	if err != nil {
+		return nil, err
+	}
+	if page != "" {
+		cond = append(cond, page)
+		args = append(args, pageArgs...)
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	where, args, err := r.Scope(ctx, strings.Join(cond, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY created_at %s, id %s LIMIT ?", r.SelectList(), r.Table(), where, order, order)
+	return sqlscan.Query[*structs.{{.GoName}}](ctx, r.DB(), r.Rebind(query), append(args, limit)...)
+}
+
+// Count counts the {{.HumanPlural}} matching the filters of params
+func (r *{{.Var}}Repository) Count(ctx context.Context, params *structs.List{{.GoName}}Params) (int, error) {
+	return r.Base.Count(ctx, {{.Var}}Filter(params))
+}
+
+// {{.Var}}Filter returns the filters set in params
+func {{.Var}}Filter(params *structs.List{{.GoName}}Params) sqlrepo.Filter {
+{{- if .Filters}}
+	filter := sqlrepo.Filter{}
+{{- range .Filters}}
+	if params.{{.GoName}} != nil {
+		filter["{{.Name}}"] = *params.{{.GoName}}
+	}
+{{- end}}
+	return filter
+{{- else}}
+	return sqlrepo.Filter{}
+{{- end}}
+}
+`))
+
+var indexerTemplate = template.Must(template.New("indexer").Parse(header + `
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ncobase/ncore/data/search"
+)
+
+// ErrSearchDisabled is returned by searches of services without an indexer
+var ErrSearchDisabled = errors.New("search is not enabled")
+
+// Indexer keeps the search indexes of entities in sync with the database,
+// implemented by *search.Client
+type Indexer interface {
+	Index(ctx context.Context, req *search.IndexRequest) error
+	Delete(ctx context.Context, index, documentID string) error
+	Search(ctx context.Context, req *search.Request) (*search.Response, error)
+}
+`))
+
+var serviceTemplate = template.Must(template.New("service").Parse(header + `
+package service
+
+import (
+	"context"
+{{- if .Searchable}}
+	"errors"
+{{- end}}
+
+	{{if .Searchable}}"github.com/ncobase/ncore/data/search"
+	"github.com/ncobase/ncore/data/sqlrepo"
+	"github.com/ncobase/ncore/logging/logger"
+	{{end}}"github.com/ncobase/ncore/paging"
+	"{{.Schema.Module}}/data/repository"
+	"{{.Schema.Module}}/structs"
+)
+
+// {{.GoName}}Service manages {{.HumanPlural}}{{if .Searchable}}, keeping their search index in sync{{end}}
+type {{.GoName}}Service struct {
+	repo repository.{{.GoName}}Repository
+{{- if .Searchable}}
+	indexer Indexer
+{{- end}}
+}
+
+// New{{.GoName}}Service creates a {{.Human}} service{{if .Searchable}}, indexing {{.HumanPlural}} with
+// indexer when not nil{{end}}
+func New{{.GoName}}Service(repo repository.{{.GoName}}Repository{{if .Searchable}}, indexer Indexer{{end}}) *{{.GoName}}Service {
+	return &{{.GoName}}Service{repo: repo{{if .Searchable}}, indexer: indexer{{end}}}
+}
+
+// Create creates a {{.Human}} from body
+func (s *{{.GoName}}Service) Create(ctx context.Context, body *structs.Create{{.GoName}}Body) (*structs.{{.GoName}}, error) {
+	{{.Var}} := &structs.{{.GoName}}{
+{{- range .Fields}}
+{{- if .CreateValue}}
+		{{.GoName}}: {{.CreateValue}},
+{{- else if .Default}}
+		{{.GoName}}: {{.DefaultValue}},
+{{- end}}
+{{- end}}
+	}
+{{- range .Fields}}
+{{- if not .CreateValue}}
+	if body.{{.GoName}} != nil {
+		{{$.Var}}.{{.GoName}} = *body.{{.GoName}}
+	}
+{{- end}}
+{{- end}}
+
+	if err := s.repo.Create(ctx, {{.Var}}); err != nil {
+		return nil, err
+	}
+{{- if .Searchable}}
+	s.index(ctx, {{.Var}})
+{{- end}}
+	return {{.Var}}, nil
+}
+
+// Get returns the {{.Human}} with id
+func (s *{{.GoName}}Service) Get(ctx context.Context, id string) (*structs.{{.GoName}}, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Update updates the fields of the {{.Human}} with id present in body
+func (s *{{.GoName}}Service) Update(ctx context.Context, id string, body *structs.Update{{.GoName}}Body) (*structs.{{.GoName}}, error) {
+	{{.Var}}, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+{{- range .Fields}}
+	if body.{{.GoName}} != nil {
+		{{$.Var}}.{{.GoName}} = {{if not .Nullable}}*{{end}}body.{{.GoName}}
+	}
+{{- end}}
+
+	if err := s.repo.Update(ctx, {{.Var}}); err != nil {
+		return nil, err
+	}
+{{- if .Searchable}}
+	s.index(ctx, {{.Var}})
+{{- end}}
+	return {{.Var}}, nil
+}
+
+// Delete deletes the {{.Human}} with id
+func (s *{{.GoName}}Service) Delete(ctx context.Context, id string) error {
+{{- if .Searchable}}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if s.indexer != nil {
+		if err := s.indexer.Delete(ctx, "{{.Table}}", id); err != nil {
+			logger.Warnf(ctx, "failed to remove {{.Human}} %s from the search index: %v", id, err)
+		}
+	}
+	return nil
+{{- else}}
+	return s.repo.Delete(ctx, id)
+{{- end}}
+}
+
+// List returns a page of {{.HumanPlural}}, newest first
+func (s *{{.GoName}}Service) List(ctx context.Context, params *structs.List{{.GoName}}Params) (paging.Result[*structs.{{.GoName}}], error) {
+	return paging.Paginate(params.Paging(), func(cursor string, limit int, direction string) ([]*structs.{{.GoName}}, int, error) {
+		page := *params
+		page.Cursor, page.Limit, page.Direction = cursor, limit, direction
+		items, err := s.repo.List(ctx, &page)
+		if err != nil {
+			return nil, 0, err
+		}
+		total, err := s.repo.Count(ctx, &page)
+		return items, total, err
+	})
+}
+{{- if .Searchable}}
+
+// Search returns the {{.HumanPlural}} matching params.Query by relevance, loaded
+// from the repository so deleted and out of scope hits are dropped
+func (s *{{.GoName}}Service) Search(ctx context.Context, params *structs.Search{{.GoName}}Params) (*structs.{{.GoName}}SearchResult, error) {
+	if s.indexer == nil {
+		return nil, ErrSearchDisabled
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	res, err := s.indexer.Search(ctx, &search.Request{
+		Index:  "{{.Table}}",
+		Query:  params.Query,
+		Fields: []string{ {{- range $i, $f := .SearchFields}}{{if $i}}, {{end}}"{{$f}}"{{end -}} },
+		Size:   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &structs.{{.GoName}}SearchResult{Items: make([]*structs.{{.GoName}}, 0, len(res.Hits)), Total: res.Total}
+	for _, hit := range res.Hits {
+		{{.Var}}, err := s.repo.Get(ctx, hit.ID)
+		if errors.Is(err, sqlrepo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, {{.Var}})
+	}
+	return result, nil
+}
+
+// index writes {{.Var}} to the search index. Failures are logged rather than
+// failing the write, the index is rebuilt from the database.
+func (s *{{.GoName}}Service) index(ctx context.Context, {{.Var}} *structs.{{.GoName}}) {
+	if s.indexer == nil {
+		return
+	}
+	err := s.indexer.Index(ctx, &search.IndexRequest{Index: "{{.Table}}", DocumentID: {{.Var}}.ID, Document: {{.Var}}})
+	if err != nil {
+		logger.Warnf(ctx, "failed to index {{.Human}} %s: %v", {{.Var}}.ID, err)
+	}
+}
+{{- end}}
+`))
+
+var handlerCommonTemplate = template.Must(template.New("handler").Parse(header + `
+package handler
+
+import (
+	"errors"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/data/sqlrepo"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/net/resp"
+	"github.com/ncobase/ncore/paging"
+	"github.com/ncobase/ncore/validation"
+{{- if .Searchable}}
+	"{{.Module}}/service"
+{{- end}}
+)
+
+// bindJSON binds and validates a JSON body, failing the request when invalid
+func bindJSON(c *gin.Context, body any) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+		return false
+	}
+	return validate(c, body)
+}
+
+// bindQuery binds and validates query parameters, failing the request when invalid
+func bindQuery(c *gin.Context, params any) bool {
+	if err := c.ShouldBindQuery(params); err != nil {
+		resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+		return false
+	}
+	return validate(c, params)
+}
+
+// validate checks the validate tags of v, listing the invalid fields in the
+// language of the request
+func validate(c *gin.Context, v any) bool {
+	lang := validation.Language(c)
+	if errs := validation.ValidateFields(v, lang); len(errs) > 0 {
+		resp.Fail(c.Writer, resp.BadRequest(validation.Message(lang, "validation.failed"), errs))
+		return false
+	}
+	return true
+}
+
+// fail writes err, missing records and invalid cursors are client errors
+func fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sqlrepo.ErrNotFound):
+		resp.Fail(c.Writer, resp.NotFound("not found"))
+	case errors.Is(err, paging.ErrInvalidCursor):
+		resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+{{- if .Tenant}}
+	case errors.Is(err, sqlrepo.ErrNoTenant):
+		resp.Fail(c.Writer, resp.Forbidden(err.Error()))
+{{- end}}
+{{- if .Searchable}}
+	case errors.Is(err, service.ErrSearchDisabled):
+		resp.Fail(c.Writer, resp.ServiceUnavailable(err.Error()))
+{{- end}}
+	default:
+		logger.Errorf(c.Request.Context(), "request failed: %v", err)
+		resp.Fail(c.Writer, resp.InternalServer("internal error"))
+	}
+}
+
+// route joins a route to the base path of the group it is registered on
+func route(base, p string) string {
+	return path.Join("/", base, p)
+}
+`))
+
+var handlerTemplate = template.Must(template.New("handler").Parse(header + `
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/extension/openapi"
+	"github.com/ncobase/ncore/net/resp"
+	"github.com/ncobase/ncore/paging"
+	"{{.Schema.Module}}/service"
+	"{{.Schema.Module}}/structs"
+)
+
+// {{.GoName}}Handler serves the {{.Human}} routes
+type {{.GoName}}Handler struct {
+	service  *service.{{.GoName}}Service
+	basePath string
+}
+
+// New{{.GoName}}Handler creates a {{.Human}} handler
+func New{{.GoName}}Handler(svc *service.{{.GoName}}Service) *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{service: svc}
+}
+
+// RegisterRoutes registers the {{.Human}} routes on r
+func (h *{{.GoName}}Handler) RegisterRoutes(r *gin.RouterGroup) {
+	h.basePath = r.BasePath()
+	group := r.Group("{{.Route}}")
+	{
+		group.POST("", h.Create)
+		group.GET("", h.List)
+{{- if .Searchable}}
+		group.GET("/search", h.Search)
+{{- end}}
+		group.GET("/:id", h.Get)
+		group.PATCH("/:id", h.Update)
+		group.DELETE("/:id", h.Delete)
+	}
+}
+
+// Create creates a {{.Human}}
+func (h *{{.GoName}}Handler) Create(c *gin.Context) {
+	var body structs.Create{{.GoName}}Body
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	{{.Var}}, err := h.service.Create(c.Request.Context(), &body)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.WithStatusCode(c.Writer, http.StatusCreated, {{.Var}})
+}
+
+// Get returns a {{.Human}}
+func (h *{{.GoName}}Handler) Get(c *gin.Context) {
+	{{.Var}}, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.Success(c.Writer, {{.Var}})
+}
+
+// Update updates the fields of a {{.Human}} present in the body
+func (h *{{.GoName}}Handler) Update(c *gin.Context) {
+	var body structs.Update{{.GoName}}Body
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	{{.Var}}, err := h.service.Update(c.Request.Context(), c.Param("id"), &body)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.Success(c.Writer, {{.Var}})
+}
+
+// Delete deletes a {{.Human}}
+func (h *{{.GoName}}Handler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		fail(c, err)
+		return
+	}
+	resp.WithStatusCode(c.Writer, http.StatusNoContent)
+}
+
+// List returns a page of {{.HumanPlural}}
+func (h *{{.GoName}}Handler) List(c *gin.Context) {
+	var params structs.List{{.GoName}}Params
+	if !bindQuery(c, &params) {
+		return
+	}
+
+	page, err := h.service.List(c.Request.Context(), &params)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.Success(c.Writer, page)
+}
+{{- if .Searchable}}
+
+// Search returns the {{.HumanPlural}} matching a full text query
+func (h *{{.GoName}}Handler) Search(c *gin.Context) {
+	var params structs.Search{{.GoName}}Params
+	if !bindQuery(c, &params) {
+		return
+	}
+
+	result, err := h.service.Search(c.Request.Context(), &params)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	resp.Success(c.Writer, result)
+}
+{{- end}}
+
+// APIOperations documents the {{.Human}} routes, for types.APIDocumenter
+func (h *{{.GoName}}Handler) APIOperations() []openapi.Operation {
+	base := route(h.basePath, "{{.Route}}")
+	item := route(base, "/:id")
+	tags := []string{"{{.Table}}"}
+	return []openapi.Operation{
+		{Method: http.MethodPost, Path: base, Summary: "Create a {{.Human}}", Tags: tags, Request: structs.Create{{.GoName}}Body{}, Response: structs.{{.GoName}}{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: base, Summary: "List {{.HumanPlural}}", Tags: tags, Query: structs.List{{.GoName}}Params{}, Response: paging.Result[*structs.{{.GoName}}]{}},
+{{- if .Searchable}}
+		{Method: http.MethodGet, Path: route(base, "/search"), Summary: "Search {{.HumanPlural}}", Tags: tags, Query: structs.Search{{.GoName}}Params{}, Response: structs.{{.GoName}}SearchResult{}, Errors: []int{http.StatusServiceUnavailable}},
+{{- end}}
+		{Method: http.MethodGet, Path: item, Summary: "Get a {{.Human}}", Tags: tags, Response: structs.{{.GoName}}{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPatch, Path: item, Summary: "Update a {{.Human}}", Tags: tags, Request: structs.Update{{.GoName}}Body{}, Response: structs.{{.GoName}}{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: item, Summary: "Delete a {{.Human}}", Tags: tags, Status: http.StatusNoContent, Errors: []int{http.StatusNotFound}},
+	}
+}
+`))
+
+var crudTemplate = template.Must(template.New("crud").Parse(header + `
+package {{.Package}}
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/data/sqlrepo"
+{{- if .GRPC}}
+	exgrpc "github.com/ncobase/ncore/extension/grpc"
+{{- end}}
+	"github.com/ncobase/ncore/extension/openapi"
+	"{{.Module}}/data/repository"
+	"{{.Module}}/handler"
+{{- if .GRPC}}
+	"{{.Module}}/rpc"
+{{- end}}
+	"{{.Module}}/service"
+)
+
+// CRUD holds the services and handlers of the entities of {{.Source}}
+type CRUD struct {
+{{- range .Entities}}
+	{{.GoName}}Service *service.{{.GoName}}Service
+	{{.GoName}}Handler *handler.{{.GoName}}Handler
+{{- end}}
+}
+
+// NewCRUD creates the repositories, services and handlers of the entities on db
+{{- if .Searchable}}.
+// Searchable entities are indexed with indexer, e.g. a *search.Client, when it
+// is a non-nil interface.
+{{- end}}
+func NewCRUD(db sqlrepo.DB{{if .Searchable}}, indexer service.Indexer{{end}}) (*CRUD, error) {
+	c := &CRUD{}
+{{- range .Entities}}
+
+	{{.VarName}}Repo, err := repository.New{{.GoName}}Repository(db)
+	if err != nil {
+		return nil, err
+	}
+	c.{{.GoName}}Service = service.New{{.GoName}}Service({{.VarName}}Repo{{if .Searchable}}, indexer{{end}})
+	c.{{.GoName}}Handler = handler.New{{.GoName}}Handler(c.{{.GoName}}Service)
+{{- end}}
+
+	return c, nil
+}
+
+// RegisterRoutes registers the routes of every entity on r
+func (c *CRUD) RegisterRoutes(r *gin.RouterGroup) {
+{{- range .Entities}}
+	c.{{.GoName}}Handler.RegisterRoutes(r)
+{{- end}}
+}
+
+// APIOperations documents the routes of every entity, for types.APIDocumenter
+func (c *CRUD) APIOperations() []openapi.Operation {
+	var ops []openapi.Operation
+{{- range .Entities}}
+	ops = append(ops, c.{{.GoName}}Handler.APIOperations()...)
+{{- end}}
+	return ops
+}
+{{- if .GRPC}}
+
+// RegisterGRPCServices registers the gRPC service of the entities, for
+// manager.GRPCExtension
+func (c *CRUD) RegisterGRPCServices(server *exgrpc.Server) {
+	rpc.NewServer({{range $i, $e := .Entities}}{{if $i}}, {{end}}c.{{.GoName}}Service{{end}}).Register(server)
+}
+{{- end}}
+`))
+
+// yamlHeader starts scaffolded YAML files
+const yamlHeader = `# Scaffolded by "ncore gen crud" from {{.Source}}.
+`
+
+var protoTemplate = template.Must(template.New("proto").Funcs(grpcFuncs).Parse(header + `
+syntax = "proto3";
+
+package {{.ProtoPackage}};
+{{- if .HasTime}}
+
+import "google/protobuf/timestamp.proto";
+{{- end}}
+
+option go_package = "{{.Module}}/{{.ProtoDir}};{{.ProtoGoPackage}}";
+
+// {{.ProtoService}} serves the entities of the {{.Package}} extension
+service {{.ProtoService}} {
+{{- range .Entities}}
+  // Create{{.GoName}} creates a {{.Human}}
+  rpc Create{{.GoName}}(Create{{.GoName}}Request) returns ({{.GoName}});
+  // Get{{.GoName}} returns a {{.Human}}
+  rpc Get{{.GoName}}(Get{{.GoName}}Request) returns ({{.GoName}});
+  // Update{{.GoName}} updates the fields set in the request
+  rpc Update{{.GoName}}(Update{{.GoName}}Request) returns ({{.GoName}});
+  // Delete{{.GoName}} deletes a {{.Human}}
+  rpc Delete{{.GoName}}(Delete{{.GoName}}Request) returns (Delete{{.GoName}}Response);
+  // List{{.ProtoPlural}} returns a page of {{.HumanPlural}}, newest first
+  rpc List{{.ProtoPlural}}(List{{.ProtoPlural}}Request) returns (List{{.ProtoPlural}}Response);
+{{- if .Searchable}}
+  // Search{{.ProtoPlural}} returns the {{.HumanPlural}} matching a full text query
+  rpc Search{{.ProtoPlural}}(Search{{.ProtoPlural}}Request) returns (Search{{.ProtoPlural}}Response);
+{{- end}}
+{{- end}}
+}
+{{- range $e := .Entities}}
+
+// {{.GoName}} is a {{.Human}}. Fields are numbered by their position in the
+// schema, add new fields last.
+message {{.GoName}} {
+  string id = 1;
+  int64 created_at = 2; // Unix milliseconds
+  int64 updated_at = 3;
+{{- if $.Audit}}
+  string created_by = 4;
+  string updated_by = 5;
+{{- end}}
+{{- range $i, $f := .Fields}}
+{{- with .Description}}
+  // {{.}}
+{{- end}}
+  {{.EntityLabel}}{{.ProtoType}} {{.Name}} = {{add $i 6}};
+{{- end}}
+}
+
+message Create{{.GoName}}Request {
+{{- range $i, $f := .Fields}}
+  {{.CreateLabel}}{{.ProtoType}} {{.Name}} = {{add $i 1}};
+{{- end}}
+}
+
+message Get{{.GoName}}Request {
+  string id = 1;
+}
+
+// Update{{.GoName}}Request sets the fields present, others are kept
+message Update{{.GoName}}Request {
+  string id = 1;
+{{- range $i, $f := .Fields}}
+  {{.ProtoLabel true}}{{.ProtoType}} {{.Name}} = {{add $i 2}};
+{{- end}}
+}
+
+message Delete{{.GoName}}Request {
+  string id = 1;
+}
+
+message Delete{{.GoName}}Response {}
+
+message List{{.ProtoPlural}}Request {
+  string cursor = 1;
+  int32 limit = 2;
+  string direction = 3; // forward or backward
+{{- range .Filters}}
+  {{.ProtoLabel true}}{{.ProtoType}} {{.Name}} = {{add ($e.Index .) 4}};
+{{- end}}
+}
+
+message List{{.ProtoPlural}}Response {
+  repeated {{.GoName}} items = 1;
+  int64 total = 2;
+  string next_cursor = 3;
+  string prev_cursor = 4;
+  bool has_next = 5;
+  bool has_prev = 6;
+}
+{{- if .Searchable}}
+
+message Search{{.ProtoPlural}}Request {
+  string query = 1;
+  int32 limit = 2;
+}
+
+message Search{{.ProtoPlural}}Response {
+  repeated {{.GoName}} items = 1; // by relevance
+  int64 total = 2;
+}
+{{- end}}
+{{- end}}
+`))
+
+var bufTemplate = template.Must(template.New("buf").Parse(yamlHeader + `version: v2
+modules:
+  - path: proto
+lint:
+  use:
+    - STANDARD
+  except:
+    # RPCs return the entity rather than a wrapping response
+    - RPC_REQUEST_RESPONSE_UNIQUE
+    - RPC_RESPONSE_STANDARD_NAME
+breaking:
+  use:
+    - FILE
+`))
+
+var bufGenTemplate = template.Must(template.New("buf.gen").Parse(yamlHeader + `version: v2
+plugins:
+  - remote: buf.build/protocolbuffers/go
+    out: proto
+    opt: paths=source_relative
+  - remote: buf.build/grpc/go
+    out: proto
+    opt: paths=source_relative
+`))
+
+var rpcServerTemplate = template.Must(template.New("rpc").Parse(header + `
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+{{- if .HasTime}}
+	"time"
+{{- end}}
+
+	{{if .Identity}}"github.com/ncobase/ncore/ctxutil"
+	{{end}}"github.com/ncobase/ncore/data/sqlrepo"
+	exgrpc "github.com/ncobase/ncore/extension/grpc"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/ncobase/ncore/paging"
+	"github.com/ncobase/ncore/validation"
+	"github.com/ncobase/ncore/validation/validator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+{{- if .HasTime}}
+	"google.golang.org/protobuf/types/known/timestamppb"
+{{- end}}
+	{{.ProtoGoPackage}} "{{.Module}}/{{.ProtoDir}}"
+	"{{.Module}}/service"
+)
+
+// Server serves {{.ProtoGoPackage}}.{{.ProtoService}} with the services of the entities
+type Server struct {
+	{{.ProtoGoPackage}}.Unimplemented{{.ProtoService}}Server
+{{- range .Entities}}
+	{{.VarName}} *service.{{.GoName}}Service
+{{- end}}
+}
+
+// NewServer creates the gRPC server of the services
+func NewServer({{range $i, $e := .Entities}}{{if $i}}, {{end}}{{.VarName}} *service.{{.GoName}}Service{{end}}) *Server {
+	return &Server{
+{{- range .Entities}}
+		{{.VarName}}: {{.VarName}},
+{{- end}}
+	}
+}
+
+// Register registers the server on the gRPC server of the extension manager,
+// from RegisterGRPCServices of a manager.GRPCExtension
+func (s *Server) Register(server *exgrpc.Server) {
+	server.RegisterService(ServiceName, s, func(gs *grpc.Server, impl any) {
+		{{.ProtoGoPackage}}.Register{{.ProtoService}}Server(gs, impl.({{.ProtoGoPackage}}.{{.ProtoService}}Server))
+	})
+}
+{{- if .Identity}}
+
+// incoming sets the tenant and user forwarded by Client on the context of a
+// call. They are trusted as sent, the server must only be reachable by other
+// instances of the application.
+func incoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+{{- if .Tenant}}
+	if v := md.Get(spaceIDKey); len(v) > 0 {
+		ctx = ctxutil.SetSpaceID(ctx, v[0])
+	}
+{{- end}}
+{{- if .Audit}}
+	if v := md.Get(userIDKey); len(v) > 0 {
+		ctx = ctxutil.SetUserID(ctx, v[0])
+	}
+{{- end}}
+	return ctx
+}
+{{- end}}
+
+// validate checks the validate tags of v, failing with the messages of the
+// invalid fields in the language of the caller's accept-language metadata
+func validate(ctx context.Context, v any) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	lang := validator.MatchLanguage(strings.Join(md.Get("accept-language"), ","))
+	if errs := validation.ValidateFields(v, lang); len(errs) > 0 {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+	return nil
+}
+
+// statusError converts a service error to a status, missing records and
+// invalid cursors are client errors
+func statusError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, sqlrepo.ErrNotFound):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, paging.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+{{- if .Tenant}}
+	case errors.Is(err, sqlrepo.ErrNoTenant):
+		return status.Error(codes.PermissionDenied, err.Error())
+{{- end}}
+{{- if .Searchable}}
+	case errors.Is(err, service.ErrSearchDisabled):
+		return status.Error(codes.Unavailable, err.Error())
+{{- end}}
+	default:
+		logger.Errorf(ctx, "call failed: %v", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+{{- if .HasInt}}
+
+// intPtr converts an optional int64 of a message
+func intPtr(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+// int64Ptr converts an optional int to a message value
+func int64Ptr(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+	i := int64(*v)
+	return &i
+}
+{{- end}}
+{{- if .HasTime}}
+
+// timeValue converts a Timestamp, the zero time when unset
+func timeValue(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// timePtr converts an optional Timestamp
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// timestamp converts a time to a message value
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	return timestamppb.New(t)
+}
+
+// timestampPtr converts an optional time to a message value
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+{{- end}}
+`))
+
+var rpcClientTemplate = template.Must(template.New("rpc").Parse(header + `
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	{{if .Identity}}"github.com/ncobase/ncore/ctxutil"
+	{{end}}exgrpc "github.com/ncobase/ncore/extension/grpc"
+	"google.golang.org/grpc"
+{{- if .Identity}}
+	"google.golang.org/grpc/metadata"
+{{- end}}
+	{{.ProtoGoPackage}} "{{.Module}}/{{.ProtoDir}}"
+)
+
+// ServiceName is the full name of the gRPC service, the name of its health
+// status and of its discovery
+const ServiceName = "{{.ProtoPackage}}.{{.ProtoService}}"
+{{- if .Identity}}
+
+// Metadata keys forwarding the tenant and user of the calling context
+const (
+	spaceIDKey = "x-space-id"
+	userIDKey  = "x-user-id"
+)
+{{- end}}
+
+// NewClient returns a client of the service served at conn, for calls from
+// extensions of other instances{{if .Identity}}. The tenant and user of the calling context
+// are forwarded.{{end}}
+func NewClient(conn grpc.ClientConnInterface) {{.ProtoGoPackage}}.{{.ProtoService}}Client {
+{{- if .Identity}}
+	return {{.ProtoGoPackage}}.New{{.ProtoService}}Client(callerConn{conn})
+{{- else}}
+	return {{.ProtoGoPackage}}.New{{.ProtoService}}Client(conn)
+{{- end}}
+}
+
+// Connect returns a client of the service discovered as ServiceName
+func Connect(ctx context.Context, registry *exgrpc.ServiceRegistry) ({{.ProtoGoPackage}}.{{.ProtoService}}Client, error) {
+	conn, err := registry.GetConnection(ctx, ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", ServiceName, err)
+	}
+	return NewClient(conn), nil
+}
+{{- if .Identity}}
+
+// callerConn forwards the tenant and user of the calling context
+type callerConn struct {
+	grpc.ClientConnInterface
+}
+
+func (c callerConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.ClientConnInterface.Invoke(outgoing(ctx), method, args, reply, opts...)
+}
+
+func (c callerConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.ClientConnInterface.NewStream(outgoing(ctx), desc, method, opts...)
+}
+
+// outgoing adds the tenant and user of ctx to its outgoing metadata
+func outgoing(ctx context.Context) context.Context {
+{{- if .Tenant}}
+	if id := ctxutil.GetSpaceID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, spaceIDKey, id)
+	}
+{{- end}}
+{{- if .Audit}}
+	if id := ctxutil.GetUserID(ctx); id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, userIDKey, id)
+	}
+{{- end}}
+	return ctx
+}
+{{- end}}
+`))
+
+var rpcTemplate = template.Must(template.New("rpc").Parse(header + `
+package rpc
+
+import (
+	"context"
+
+	{{.Schema.ProtoGoPackage}} "{{.Schema.Module}}/{{.Schema.ProtoDir}}"
+	"{{.Schema.Module}}/structs"
+)
+
+// Create{{.GoName}} creates a {{.Human}}
+func (s *Server) Create{{.GoName}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.Create{{.GoName}}Request) (*{{.Schema.ProtoGoPackage}}.{{.GoName}}, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	body := &structs.Create{{.GoName}}Body{
+{{- range .Fields}}
+		{{.GoName}}: {{.CreateFromProto "req"}},
+{{- end}}
+	}
+	if err := validate(ctx, body); err != nil {
+		return nil, err
+	}
+	{{.Var}}, err := s.{{.Var}}.Create(ctx, body)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return to{{.GoName}}({{.Var}}), nil
+}
+
+// Get{{.GoName}} returns a {{.Human}}
+func (s *Server) Get{{.GoName}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.Get{{.GoName}}Request) (*{{.Schema.ProtoGoPackage}}.{{.GoName}}, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	{{.Var}}, err := s.{{.Var}}.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return to{{.GoName}}({{.Var}}), nil
+}
+
+// Update{{.GoName}} updates the fields set in the request
+func (s *Server) Update{{.GoName}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.Update{{.GoName}}Request) (*{{.Schema.ProtoGoPackage}}.{{.GoName}}, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	body := &structs.Update{{.GoName}}Body{
+{{- range .Fields}}
+		{{.GoName}}: {{.FromProto "req" true}},
+{{- end}}
+	}
+	if err := validate(ctx, body); err != nil {
+		return nil, err
+	}
+	{{.Var}}, err := s.{{.Var}}.Update(ctx, req.GetId(), body)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return to{{.GoName}}({{.Var}}), nil
+}
+
+// Delete{{.GoName}} deletes a {{.Human}}
+func (s *Server) Delete{{.GoName}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.Delete{{.GoName}}Request) (*{{.Schema.ProtoGoPackage}}.Delete{{.GoName}}Response, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	if err := s.{{.Var}}.Delete(ctx, req.GetId()); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &{{.Schema.ProtoGoPackage}}.Delete{{.GoName}}Response{}, nil
+}
+
+// List{{.ProtoPlural}} returns a page of {{.HumanPlural}}, newest first
+func (s *Server) List{{.ProtoPlural}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.List{{.ProtoPlural}}Request) (*{{.Schema.ProtoGoPackage}}.List{{.ProtoPlural}}Response, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	params := &structs.List{{.GoName}}Params{
+		Cursor:    req.GetCursor(),
+		Limit:     int(req.GetLimit()),
+		Direction: req.GetDirection(),
+{{- range .Filters}}
+		{{.GoName}}: {{.FromProto "req" true}},
+{{- end}}
+	}
+	if err := validate(ctx, params); err != nil {
+		return nil, err
+	}
+	result, err := s.{{.Var}}.List(ctx, params)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	res := &{{.Schema.ProtoGoPackage}}.List{{.ProtoPlural}}Response{
+		Total:      int64(result.Total),
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
+		HasNext:    result.HasNext,
+		HasPrev:    result.HasPrev,
+	}
+	for _, item := range result.Items {
+		res.Items = append(res.Items, to{{.GoName}}(item))
+	}
+	return res, nil
+}
+{{- if .Searchable}}
+
+// Search{{.ProtoPlural}} returns the {{.HumanPlural}} matching a full text query
+func (s *Server) Search{{.ProtoPlural}}(ctx context.Context, req *{{.Schema.ProtoGoPackage}}.Search{{.ProtoPlural}}Request) (*{{.Schema.ProtoGoPackage}}.Search{{.ProtoPlural}}Response, error) {
+{{- if .Schema.Identity}}
+	ctx = incoming(ctx)
+{{- end}}
+	params := &structs.Search{{.GoName}}Params{Query: req.GetQuery(), Limit: int(req.GetLimit())}
+	if err := validate(ctx, params); err != nil {
+		return nil, err
+	}
+	result, err := s.{{.Var}}.Search(ctx, params)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	res := &{{.Schema.ProtoGoPackage}}.Search{{.ProtoPlural}}Response{Total: result.Total}
+	for _, item := range result.Items {
+		res.Items = append(res.Items, to{{.GoName}}(item))
+	}
+	return res, nil
+}
+{{- end}}
+
+// to{{.GoName}} converts a {{.Human}} to its message
+func to{{.GoName}}(v *structs.{{.GoName}}) *{{.Schema.ProtoGoPackage}}.{{.GoName}} {
+	return &{{.Schema.ProtoGoPackage}}.{{.GoName}}{
+		Id:        v.ID,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+{{- if .Schema.Audit}}
+		CreatedBy: v.CreatedBy,
+		UpdatedBy: v.UpdatedBy,
+{{- end}}
+{{- range .Fields}}
+		{{.ProtoName}}: {{.ToProto "v"}},
+{{- end}}
+	}
+}
+`))
+
+// projectHeader starts the files scaffolded from an extension manifest
+const projectHeader = "// Scaffolded by ncore from " + ManifestFile + ".\n"
+
+// manifestHeader starts manifests
+const manifestHeader = `# Options "ncore create" scaffolded the extension with, reused by
+# "ncore add handler" and "ncore upgrade templates".
+`
+
+var schemaTemplate = template.Must(template.New("schema").Parse(`# Entities of the {{.Name}} extension, see "ncore gen crud". "ncore add handler"
+# appends entities.
+module: {{.Module}}
+driver: {{.Driver}}
+{{- if .Has "tenant"}}
+tenant: space_id
+{{- end}}
+{{- if .Has "audit"}}
+audit: true
+{{- end}}
+entities:
+  - name: {{.Entity}}
+    fields:
+      - {name: name, type: string, required: true, max: 200}
+`))
+
+var extensionTemplate = template.Must(template.New("extension").Parse(projectHeader + `
+package {{.Package}}
+
+import (
+	{{if eq .ORM "sqlrepo"}}"fmt"
+
+	{{end}}"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/config"
+{{- if eq .ORM "sqlrepo"}}
+	"github.com/ncobase/ncore/data"
+{{- end}}
+{{- if .Has "grpc"}}
+	exgrpc "github.com/ncobase/ncore/extension/grpc"
+{{- end}}
+	"github.com/ncobase/ncore/extension/openapi"
+	"github.com/ncobase/ncore/extension/registry"
+	"github.com/ncobase/ncore/extension/types"
+)
+
+func init() {
+{{- if .Group}}
+	registry.RegisterToGroup(New(), "{{.Group}}")
+{{- else}}
+	registry.Register(New())
+{{- end}}
+}
+
+// Module is the {{.Name}} extension
+type Module struct {
+	types.OptionalImpl
+
+	{{if eq .ORM "sqlrepo"}}crud *CRUD{{else}}handlers *Handlers{{end}}
+}
+
+// New creates the {{.Name}} extension
+func New() types.Interface {
+	return &Module{}
+}
+
+// Name returns the name of the extension
+func (m *Module) Name() string {
+	return "{{.Name}}"
+}
+
+// Version returns the version of the extension
+func (m *Module) Version() string {
+	return "0.1.0"
+}
+
+// Dependencies returns the extensions initialized before this one
+func (m *Module) Dependencies() []string {
+	return nil
+}
+
+// GetMetadata returns the metadata of the extension
+func (m *Module) GetMetadata() types.Metadata {
+	return types.Metadata{
+		Name:         m.Name(),
+		Version:      m.Version(),
+		Type:         "{{.Type}}",
+{{- if .Group}}
+		Group:        "{{.Group}}",
+{{- end}}
+		Dependencies: m.Dependencies(),
+	}
+}
+{{- if eq .ORM "sqlrepo"}}
+
+// Init creates the CRUD of the entities of {{.Schema}} on the master database
+// of the application data, registered as the app.Data cross service
+func (m *Module) Init(conf *config.Config, em types.ManagerInterface) error {
+	dataAny, err := em.GetCrossService("app", "Data")
+	if err != nil {
+		return err
+	}
+	d, ok := dataAny.(*data.Data)
+	if !ok {
+		return fmt.Errorf("app data type mismatch")
+	}
+	db := d.GetMasterDB()
+	if db == nil {
+		return fmt.Errorf("master database not configured")
+	}
+
+	m.crud, err = NewCRUD(db)
+	return err
+}
+
+// GetHandlers returns the CRUD of the entities
+func (m *Module) GetHandlers() types.Handler {
+	return m.crud
+}
+
+// GetServices returns the CRUD of the entities, holding their services
+func (m *Module) GetServices() types.Service {
+	return m.crud
+}
+
+// RegisterRoutes registers the routes of the entities
+func (m *Module) RegisterRoutes(r *gin.RouterGroup) {
+	m.crud.RegisterRoutes(r)
+}
+
+// APIOperations documents the routes, for types.APIDocumenter
+func (m *Module) APIOperations() []openapi.Operation {
+	return m.crud.APIOperations()
+}
+{{- if .Has "grpc"}}
+
+// RegisterGRPCServices registers the gRPC service of the entities, for
+// manager.GRPCExtension
+func (m *Module) RegisterGRPCServices(server *exgrpc.Server) {
+	m.crud.RegisterGRPCServices(server)
+}
+{{- end}}
+{{- else}}
+
+// Init creates the handlers
+func (m *Module) Init(conf *config.Config, em types.ManagerInterface) error {
+	m.handlers = NewHandlers()
+	return nil
+}
+
+// GetHandlers returns the handlers
+func (m *Module) GetHandlers() types.Handler {
+	return m.handlers
+}
+
+// GetServices returns nil, the extension has no services
+func (m *Module) GetServices() types.Service {
+	return nil
+}
+
+// RegisterRoutes registers the routes of the handlers
+func (m *Module) RegisterRoutes(r *gin.RouterGroup) {
+	m.handlers.RegisterRoutes(r)
+}
+
+// APIOperations documents the routes, for types.APIDocumenter
+func (m *Module) APIOperations() []openapi.Operation {
+	return m.handlers.APIOperations()
+}
+{{- end}}
+`))
+
+var handlersTemplate = template.Must(template.New("handlers").Funcs(template.FuncMap{"goName": goName}).Parse(projectHeader + `
+package {{.Package}}
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/extension/openapi"
+{{- if .Handlers}}
+	"{{.Module}}/handler"
+{{- end}}
+)
+
+// Handlers holds the handlers listed in the manifest, it is rewritten when
+// one is added
+type Handlers struct {
+{{- range .Handlers}}
+	{{goName .}} *handler.{{goName .}}Handler
+{{- end}}
+}
+
+// NewHandlers creates the handlers
+func NewHandlers() *Handlers {
+	return &Handlers{
+{{- range .Handlers}}
+		{{goName .}}: handler.New{{goName .}}Handler(),
+{{- end}}
+	}
+}
+
+// RegisterRoutes registers the routes of every handler on r
+func (h *Handlers) RegisterRoutes(r *gin.RouterGroup) {
+{{- range .Handlers}}
+	h.{{goName .}}.RegisterRoutes(r)
+{{- end}}
+}
+
+// APIOperations documents the routes of every handler, for types.APIDocumenter
+func (h *Handlers) APIOperations() []openapi.Operation {
+	var ops []openapi.Operation
+{{- range .Handlers}}
+	ops = append(ops, h.{{goName .}}.APIOperations()...)
+{{- end}}
+	return ops
+}
+`))
+
+var plainHandlerTemplate = template.Must(template.New("handler").Parse(projectHeader + `
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/extension/openapi"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// {{.GoName}}Handler serves the {{.Human}} routes
+type {{.GoName}}Handler struct {
+	basePath string
+}
+
+// New{{.GoName}}Handler creates a {{.Human}} handler
+func New{{.GoName}}Handler() *{{.GoName}}Handler {
+	return &{{.GoName}}Handler{}
+}
+
+// RegisterRoutes registers the {{.Human}} routes on r
+func (h *{{.GoName}}Handler) RegisterRoutes(r *gin.RouterGroup) {
+	h.basePath = r.BasePath()
+	group := r.Group("{{.Route}}")
+	{
+		group.GET("", h.List)
+	}
+}
+
+// List returns the {{.HumanPlural}}
+func (h *{{.GoName}}Handler) List(c *gin.Context) {
+	resp.Success(c.Writer, []any{})
+}
+
+// APIOperations documents the {{.Human}} routes, for types.APIDocumenter
+func (h *{{.GoName}}Handler) APIOperations() []openapi.Operation {
+	base := strings.TrimSuffix(h.basePath, "/") + "{{.Route}}"
+	tags := []string{"{{.Table}}"}
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: base, Summary: "List {{.HumanPlural}}", Tags: tags},
+	}
+}
+`))
@@ -93,6 +93,29 @@
 //	// Blocks dangerous file extensions
 //	cfg.BlockedExtensions = []string{".exe", ".sh", ".bat", ".cmd"}
 //
+// # Brokered File Access
+//
+// Hand extensions a filesystem scoped to their own directory with a quota:
+//
+//	broker := security.NewFileBroker(&config.FileSystemConfig{
+//	    Root:    "./data/extensions",
+//	    QuotaMB: 100,
+//	})
+//
+//	fsys, err := broker.ForExtension("my-plugin")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	// Paths outside ./data/extensions/my-plugin are rejected
+//	err = fsys.WriteFile("cache/state.json", data, 0644)
+//	if errors.Is(err, security.ErrQuotaExceeded) {
+//	    // Quota reached
+//	}
+//
+//	// Every operation is recorded
+//	entries := broker.AuditLog("my-plugin", 50)
+//
 // # Development vs Production
 //
 // Use AllowUnsafe for development only:
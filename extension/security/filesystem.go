@@ -0,0 +1,332 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/extension/config"
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed the extension quota
+var ErrQuotaExceeded = errors.New("filesystem quota exceeded")
+
+// AuditEntry records a brokered filesystem operation
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Extension string    `json:"extension"`
+	Op        string    `json:"op"`
+	Path      string    `json:"path"`
+	Bytes     int64     `json:"bytes,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// FileBroker mediates extension file access to per-extension directories
+type FileBroker struct {
+	root      string
+	quota     int64
+	auditSize int
+
+	mu    sync.Mutex
+	fs    map[string]*ExtensionFS
+	audit []AuditEntry
+}
+
+// NewFileBroker creates a new filesystem broker
+func NewFileBroker(cfg *config.FileSystemConfig) *FileBroker {
+	auditSize := cfg.AuditSize
+	if auditSize <= 0 {
+		auditSize = 1000
+	}
+
+	return &FileBroker{
+		root:      cfg.Root,
+		quota:     int64(cfg.QuotaMB) * 1024 * 1024,
+		auditSize: auditSize,
+		fs:        make(map[string]*ExtensionFS),
+	}
+}
+
+// ForExtension returns the scoped filesystem of an extension, creating its directory if needed
+func (b *FileBroker) ForExtension(name string) (*ExtensionFS, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid extension name: %q", name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if efs, ok := b.fs[name]; ok {
+		return efs, nil
+	}
+
+	dir := filepath.Join(b.root, name)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create directory for extension %s: %v", name, err)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open directory for extension %s: %v", name, err)
+	}
+
+	used, err := dirSize(root)
+	if err != nil {
+		_ = root.Close()
+		return nil, fmt.Errorf("failed to compute usage of extension %s: %v", name, err)
+	}
+
+	efs := &ExtensionFS{name: name, broker: b, root: root, used: used}
+	b.fs[name] = efs
+	return efs, nil
+}
+
+// AuditLog returns the most recent audit entries, optionally filtered by extension
+func (b *FileBroker) AuditLog(extension string, limit int) []AuditEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []AuditEntry
+	for i := len(b.audit) - 1; i >= 0; i-- {
+		if extension != "" && b.audit[i].Extension != extension {
+			continue
+		}
+		entries = append(entries, b.audit[i])
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+	return entries
+}
+
+// Usage returns bytes used per extension
+func (b *FileBroker) Usage() map[string]int64 {
+	b.mu.Lock()
+	extensions := make(map[string]*ExtensionFS, len(b.fs))
+	for name, efs := range b.fs {
+		extensions[name] = efs
+	}
+	b.mu.Unlock()
+
+	usage := make(map[string]int64, len(extensions))
+	for name, efs := range extensions {
+		used, _ := efs.Usage()
+		usage[name] = used
+	}
+	return usage
+}
+
+// Close releases all extension directories
+func (b *FileBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for name, efs := range b.fs {
+		if err := efs.root.Close(); err != nil {
+			logger.Warnf(nil, "failed to close filesystem of extension %s: %v", name, err)
+		}
+	}
+	b.fs = make(map[string]*ExtensionFS)
+}
+
+// record appends an audit entry
+func (b *FileBroker) record(extension, op, path string, n int64, err error) {
+	entry := AuditEntry{
+		Time:      time.Now(),
+		Extension: extension,
+		Op:        op,
+		Path:      path,
+		Bytes:     n,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		logger.Warnf(nil, "extension %s filesystem %s %s failed: %v", extension, op, path, err)
+	}
+
+	b.mu.Lock()
+	b.audit = append(b.audit, entry)
+	if len(b.audit) > b.auditSize {
+		b.audit = b.audit[len(b.audit)-b.auditSize:]
+	}
+	b.mu.Unlock()
+}
+
+// ExtensionFS is a filesystem scoped to a single extension directory.
+// Paths are relative to the directory and cannot escape it, including through symlinks.
+type ExtensionFS struct {
+	name   string
+	broker *FileBroker
+	root   *os.Root
+
+	mu   sync.Mutex
+	used int64
+}
+
+// Open opens a file for reading
+func (e *ExtensionFS) Open(name string) (fs.File, error) {
+	f, err := e.root.Open(name)
+	e.broker.record(e.name, "open", name, 0, err)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadFile reads a whole file
+func (e *ExtensionFS) ReadFile(name string) ([]byte, error) {
+	data, err := e.root.ReadFile(name)
+	e.broker.record(e.name, "read", name, int64(len(data)), err)
+	return data, err
+}
+
+// WriteFile writes a whole file, replacing any existing content
+func (e *ExtensionFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delta := int64(len(data)) - e.fileSize(name)
+	err := e.reserve(delta)
+	if err == nil {
+		if err = e.root.WriteFile(name, data, perm); err == nil {
+			e.used += delta
+		}
+	}
+
+	e.broker.record(e.name, "write", name, int64(len(data)), err)
+	return err
+}
+
+// Create creates or truncates a file for writing, writes count against the quota
+func (e *ExtensionFS) Create(name string) (io.WriteCloser, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	size := e.fileSize(name)
+	f, err := e.root.Create(name)
+	e.broker.record(e.name, "create", name, 0, err)
+	if err != nil {
+		return nil, err
+	}
+
+	e.used -= size
+	return &quotaWriter{fs: e, file: f, name: name}, nil
+}
+
+// Remove removes a file or empty directory
+func (e *ExtensionFS) Remove(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	size := e.fileSize(name)
+	err := e.root.Remove(name)
+	if err == nil {
+		e.used -= size
+	}
+
+	e.broker.record(e.name, "remove", name, size, err)
+	return err
+}
+
+// MkdirAll creates a directory and any missing parents
+func (e *ExtensionFS) MkdirAll(name string, perm fs.FileMode) error {
+	err := e.root.MkdirAll(name, perm)
+	e.broker.record(e.name, "mkdir", name, 0, err)
+	return err
+}
+
+// ReadDir lists a directory
+func (e *ExtensionFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(e.root.FS(), filepath.ToSlash(filepath.Clean(name)))
+	e.broker.record(e.name, "readdir", name, 0, err)
+	return entries, err
+}
+
+// Stat returns file info
+func (e *ExtensionFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := e.root.Stat(name)
+	e.broker.record(e.name, "stat", name, 0, err)
+	return info, err
+}
+
+// Usage returns the bytes used and the quota, 0 for unlimited
+func (e *ExtensionFS) Usage() (used, quota int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.used, e.broker.quota
+}
+
+// fileSize returns the size of a regular file, 0 if it does not exist.
+// Caller must hold the lock.
+func (e *ExtensionFS) fileSize(name string) int64 {
+	info, err := e.root.Lstat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// reserve checks that delta more bytes fit in the quota.
+// Caller must hold the lock.
+func (e *ExtensionFS) reserve(delta int64) error {
+	if e.broker.quota > 0 && delta > 0 && e.used+delta > e.broker.quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// quotaWriter counts written bytes against the extension quota
+type quotaWriter struct {
+	fs      *ExtensionFS
+	file    *os.File
+	name    string
+	written int64
+}
+
+// Write writes to the file if the quota allows it
+func (w *quotaWriter) Write(p []byte) (int, error) {
+	w.fs.mu.Lock()
+	defer w.fs.mu.Unlock()
+
+	if err := w.fs.reserve(int64(len(p))); err != nil {
+		w.fs.broker.record(w.fs.name, "write", w.name, int64(len(p)), err)
+		return 0, err
+	}
+
+	n, err := w.file.Write(p)
+	w.fs.used += int64(n)
+	w.written += int64(n)
+	return n, err
+}
+
+// Close closes the file and records the write
+func (w *quotaWriter) Close() error {
+	err := w.file.Close()
+	w.fs.broker.record(w.fs.name, "write", w.name, w.written, err)
+	return err
+}
+
+// dirSize sums the size of all regular files under root
+func dirSize(root *os.Root) (int64, error) {
+	var size int64
+	err := fs.WalkDir(root.FS(), ".", func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
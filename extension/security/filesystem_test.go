@@ -0,0 +1,137 @@
+package security
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ncobase/ncore/extension/config"
+)
+
+func newTestBroker(t *testing.T, quotaMB int) (*FileBroker, string) {
+	t.Helper()
+	root := t.TempDir()
+	b := NewFileBroker(&config.FileSystemConfig{Enabled: true, Root: root, QuotaMB: quotaMB})
+	t.Cleanup(b.Close)
+	return b, root
+}
+
+func TestExtensionFSConfinesPaths(t *testing.T) {
+	b, root := newTestBroker(t, 0)
+	efs, err := b.ForExtension("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(root, "notes", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../secret.txt", "sub/../../secret.txt", "/etc/passwd", filepath.Join(root, "secret.txt"), "link"} {
+		if _, err := efs.ReadFile(name); err == nil {
+			t.Errorf("ReadFile(%q) escaped the extension directory", name)
+		}
+		if _, err := efs.Stat(name); err == nil {
+			t.Errorf("Stat(%q) escaped the extension directory", name)
+		}
+		if err := efs.WriteFile(name, []byte("x"), 0o600); err == nil {
+			t.Errorf("WriteFile(%q) escaped the extension directory", name)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "secret.txt")); string(data) != "secret" {
+		t.Fatalf("file outside the extension directory was changed: %q", data)
+	}
+
+	for _, name := range []string{"", ".", "..", "../notes", "a/b", `a\b`} {
+		if _, err := b.ForExtension(name); err == nil {
+			t.Errorf("ForExtension(%q) should fail", name)
+		}
+	}
+}
+
+func TestExtensionFSIsolatesExtensions(t *testing.T) {
+	b, _ := newTestBroker(t, 0)
+	notes, err := b.ForExtension("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blog, err := b.ForExtension("blog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notes.WriteFile("state.json", []byte(`{"n":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blog.ReadFile("state.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("blog read the file of notes: %v", err)
+	}
+	if _, err := blog.ReadFile("../notes/state.json"); err == nil {
+		t.Fatal("blog reached the directory of notes")
+	}
+	if data, err := notes.ReadFile("state.json"); err != nil || string(data) != `{"n":1}` {
+		t.Fatalf("ReadFile = %q, %v", data, err)
+	}
+
+	if again, _ := b.ForExtension("notes"); again != notes {
+		t.Fatal("ForExtension should return the same filesystem for an extension")
+	}
+}
+
+func TestExtensionFSQuotaAndAudit(t *testing.T) {
+	b, _ := newTestBroker(t, 1)
+	efs, err := b.ForExtension("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	half := make([]byte, 512*1024)
+	if err := efs.WriteFile("a", half, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := efs.WriteFile("b", half, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := efs.WriteFile("c", []byte("x"), 0o600); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Replacing a file only counts the difference
+	if err := efs.WriteFile("a", half[:1024], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := efs.Create("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(half); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded from Create, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := efs.Remove("b"); err != nil {
+		t.Fatal(err)
+	}
+	if used, quota := efs.Usage(); used != 1024 || quota != 1024*1024 {
+		t.Fatalf("Usage = %d, %d", used, quota)
+	}
+
+	if _, err := efs.Stat("a"); err != nil {
+		t.Fatal(err)
+	}
+	entries := b.AuditLog("notes", 2)
+	if len(entries) != 2 || entries[0].Op != "stat" || entries[1].Op != "remove" || entries[1].Bytes != int64(len(half)) {
+		t.Fatalf("unexpected audit log %+v", entries)
+	}
+	if got := b.AuditLog("blog", 0); len(got) != 0 {
+		t.Fatalf("unexpected audit entries of blog %+v", got)
+	}
+	if usage := b.Usage(); usage["notes"] != 1024 {
+		t.Fatalf("Usage = %v", usage)
+	}
+}
@@ -0,0 +1,10 @@
+package types
+
+import "github.com/ncobase/ncore/config"
+
+// ConfigWatcher is an optional interface for extensions reacting to changes
+// of their section under extension.plugin_config on hot reload. conf is the
+// reloaded configuration, decode the section with conf.UnmarshalExtension.
+type ConfigWatcher interface {
+	OnConfigChange(conf *config.Config) error
+}
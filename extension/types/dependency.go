@@ -14,6 +14,8 @@ const (
 type DependencyEntry struct {
 	Name string
 	Type DependencyType
+	// Version is an optional semver constraint, e.g. ">=1.2.0 <2.0.0"
+	Version string
 }
 
 // GetStrongDependencies filters and returns only strong dependencies
@@ -21,6 +21,10 @@ type EventData struct {
 	Source    string    `json:"source"`
 	EventType string    `json:"event_type"`
 	Data      any       `json:"data"`
+	Region    string    `json:"region,omitempty"`  // Origin region for cross-region events
+	Regions   []string  `json:"regions,omitempty"` // Regions the event has passed through
+
+	Trace map[string]string `json:"trace,omitempty"` // Trace context of the publisher, e.g. traceparent
 }
 
 // ExtractEventPayload Extract payload from event data
@@ -8,10 +8,17 @@ type Metadata struct {
 	Version string `json:"version,omitempty"`
 	// Dependencies are the dependencies of the extension
 	Dependencies []string `json:"dependencies,omitempty"`
+	// DependencyVersions maps dependency names to semver constraints, e.g. {"auth": ">=1.2.0 <2.0.0"}
+	DependencyVersions map[string]string `json:"dependency_versions,omitempty"`
 	// Description is the description of the extension
 	Description string `json:"description,omitempty"`
 	// Type is the type of the extension, e.g. core, business, plugin, module, etc
 	Type string `json:"type,omitempty"`
 	// Group is the belong group of the extension, e.g. iam, res, flow, sys, org, rt, plug, etc
 	Group string `json:"group,omitempty"`
+	// Tasks are scheduled tasks run by the manager, handlers are resolved by TaskProvider
+	Tasks []ScheduledTask `json:"tasks,omitempty"`
+	// Schema declares the tables and collections the extension owns, no two
+	// extensions may claim the same one
+	Schema *Schema `json:"schema,omitempty"`
 }
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"io"
+	"io/fs"
+)
+
+// FileSystem is a brokered filesystem scoped to a single extension directory.
+// Access is confined to the directory, counted against a quota and audited.
+type FileSystem interface {
+	Open(name string) (fs.File, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Create(name string) (io.WriteCloser, error)
+	Remove(name string) error
+	MkdirAll(name string, perm fs.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	Usage() (used, quota int64)
+}
+
+// FileSystemAware is implemented by extensions that access files. When
+// extension.security.filesystem is enabled, the manager calls SetFileSystem
+// with the extension's own filesystem before PreInit.
+type FileSystemAware interface {
+	SetFileSystem(fs FileSystem)
+}
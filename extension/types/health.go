@@ -0,0 +1,41 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Extension health status constants
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// HealthReport represents the result of an extension health check
+type HealthReport struct {
+	Status    string         `json:"status"`
+	Details   map[string]any `json:"details,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Latency   time.Duration  `json:"latency"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// HealthChecker is an optional interface for extensions reporting structured health
+type HealthChecker interface {
+	Check(ctx context.Context) HealthReport
+}
+
+// SelfTester is an optional interface for extensions verifying themselves
+// end to end once started, e.g. a write-read roundtrip to their tables
+type SelfTester interface {
+	SelfTest(ctx context.Context) error
+}
+
+// SelfTestResult represents the outcome of an extension self-test
+type SelfTestResult struct {
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	RanAt    time.Time     `json:"ran_at"`
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/consul/api"
 	"github.com/ncobase/ncore/config"
+	"github.com/ncobase/ncore/logging/logger"
 )
 
 // Handler represents the handler for an extension
@@ -144,6 +145,7 @@ type ManagerInterface interface {
 	GetExtensionPublisher(name string, publisherType reflect.Type) (any, error)
 	GetExtensionSubscriber(name string, subscriberType reflect.Type) (any, error)
 	PublishEvent(eventName string, data any, target ...EventTarget)
+	PublishEventContext(ctx context.Context, eventName string, data any, target ...EventTarget)
 	PublishEventWithRetry(eventName string, data any, maxRetries int, target ...EventTarget)
 	SubscribeEvent(eventName string, handler func(any), source ...EventTarget)
 
@@ -169,6 +171,11 @@ type ManagerInterface interface {
 
 	PublishMessage(exchange, routingKey string, body []byte) error
 	SubscribeToMessages(queue string, handler func([]byte) error) error
+	ConsumeMessages(extension, queue string, handler func([]byte) error) error
+
+	// Logging
+
+	GetLogger(extensionName string) *logger.ScopedLogger
 
 	// Metrics and status
 
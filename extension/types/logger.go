@@ -0,0 +1,9 @@
+package types
+
+import "github.com/ncobase/ncore/logging/logger"
+
+// LoggerAware is implemented by extensions that accept a scoped logger.
+// The manager calls SetLogger before PreInit.
+type LoggerAware interface {
+	SetLogger(l *logger.ScopedLogger)
+}
@@ -0,0 +1,9 @@
+package types
+
+import "github.com/ncobase/ncore/extension/openapi"
+
+// APIDocumenter is an optional interface for extensions documenting their
+// routes in the OpenAPI document, with summaries, parameters and bodies
+type APIDocumenter interface {
+	APIOperations() []openapi.Operation
+}
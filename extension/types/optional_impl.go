@@ -1,9 +1,27 @@
 package types
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/logging/logger"
+)
 
 // OptionalImpl implements the optional methods
-type OptionalImpl struct{}
+type OptionalImpl struct {
+	logger *logger.ScopedLogger
+}
+
+// SetLogger sets the scoped logger injected by the manager
+func (o *OptionalImpl) SetLogger(l *logger.ScopedLogger) {
+	o.logger = l
+}
+
+// Logger returns the scoped logger of the extension, or an unscoped one before injection
+func (o *OptionalImpl) Logger() *logger.ScopedLogger {
+	if o.logger == nil {
+		return logger.NewScoped(nil)
+	}
+	return o.logger
+}
 
 // PreInit performs any necessary setup before initialization
 func (o *OptionalImpl) PreInit() error {
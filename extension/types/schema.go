@@ -0,0 +1,62 @@
+package types
+
+// Schema lists the database objects an extension owns
+type Schema struct {
+	Tables      []Table      `json:"tables,omitempty"`      // Tables of the master SQL database
+	Collections []Collection `json:"collections,omitempty"` // MongoDB collections
+}
+
+// Table is a SQL table owned by an extension
+type Table struct {
+	Name       string      `json:"name"`
+	Columns    []Column    `json:"columns,omitempty"` // Drift of undeclared columns is only reported when set
+	PrimaryKey []string    `json:"primary_key,omitempty"`
+	Indexes    []Index     `json:"indexes,omitempty"`
+	References []Reference `json:"references,omitempty"`
+}
+
+// Column is a column of a table
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"` // e.g. varchar, bigint, shown in the ER overview
+	Nullable bool   `json:"nullable,omitempty"`
+}
+
+// Index is an index of a table or collection
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns,omitempty"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// Reference is a foreign key to another table, possibly owned by another extension
+type Reference struct {
+	Columns    []string `json:"columns"`
+	Table      string   `json:"table"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+}
+
+// Collection is a MongoDB collection owned by an extension
+type Collection struct {
+	Name    string  `json:"name"`
+	Indexes []Index `json:"indexes,omitempty"`
+}
+
+// Schema drift kinds
+const (
+	DriftMissingTable     = "missing_table"
+	DriftMissingColumn    = "missing_column"
+	DriftUndeclaredColumn = "undeclared_column"
+	DriftNullability      = "nullability"
+	DriftMissingIndex     = "missing_index"
+	DriftUnownedTable     = "unowned_table"
+)
+
+// SchemaDrift is a difference between the declared schemas and the database
+type SchemaDrift struct {
+	Kind      string `json:"kind"`
+	Extension string `json:"extension,omitempty"` // Owner, empty for unowned tables
+	Table     string `json:"table"`
+	Column    string `json:"column,omitempty"`
+	Index     string `json:"index,omitempty"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import "context"
+
+// ScheduledTask is a recurring task declared in extension metadata
+type ScheduledTask struct {
+	// Name is unique within the extension, the task runs as job "<extension>.<name>"
+	Name string `json:"name"`
+	// Schedule is a cron expression, a descriptor like @daily, or "@every 30s"
+	Schedule string `json:"schedule"`
+	// Handler references a handler returned by TaskProvider.TaskHandlers
+	Handler string `json:"handler"`
+	// Timeout cancels a run after this duration, e.g. "5m", empty for no limit
+	Timeout string `json:"timeout,omitempty"`
+	// AllNodes runs the task on every node instead of the elected leader only
+	AllNodes bool `json:"all_nodes,omitempty"`
+}
+
+// TaskHandler runs one scheduled task
+type TaskHandler func(ctx context.Context) error
+
+// TaskProvider is an optional interface for extensions declaring scheduled
+// tasks, resolving handler references in Metadata.Tasks
+type TaskProvider interface {
+	TaskHandlers() map[string]TaskHandler
+}
@@ -0,0 +1,319 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SemVer represents a parsed semantic version
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseSemVer parses a semantic version such as "1.2.3", "v1.2" or "1.2.3-beta.1"
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if raw == "" {
+		return v, fmt.Errorf("empty version")
+	}
+
+	// Build metadata is ignored for precedence
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		v.Prerelease = raw[i+1:]
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+
+	nums := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// String returns the version string
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than o
+func (v SemVer) Compare(o SemVer) int {
+	for _, d := range [3]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// comparePrerelease compares prerelease identifiers, a release ranks above any prerelease
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// versionCondition is a single comparison such as ">=1.2.0"
+type versionCondition struct {
+	op      string
+	version SemVer
+}
+
+// check reports whether v satisfies the condition
+func (c versionCondition) check(v SemVer) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=", "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// VersionConstraint is a parsed semver constraint.
+// Conditions separated by spaces or commas must all match, "||" separates alternatives.
+type VersionConstraint struct {
+	raw  string
+	sets [][]versionCondition
+}
+
+// versionOperators are the constraint operators, longest first
+var versionOperators = []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"}
+
+// ParseVersionConstraint parses constraints like ">=1.2.0 <2.0.0", ">= 1.2.0", "^1.4",
+// "~1.2.3" or "1.x || >=3.0"
+func ParseVersionConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{raw: strings.TrimSpace(s)}
+	if c.raw == "" || c.raw == "*" {
+		return c, nil
+	}
+
+	for _, alt := range strings.Split(c.raw, "||") {
+		var (
+			set   []versionCondition
+			terms []string
+			op    string
+		)
+		// An operator may be separated from its version by spaces
+		for _, field := range strings.Fields(strings.ReplaceAll(alt, ",", " ")) {
+			if slices.Contains(versionOperators, field) {
+				if op != "" {
+					return nil, fmt.Errorf("invalid constraint %q: operator %s without version", s, op)
+				}
+				op = field
+				continue
+			}
+			terms = append(terms, op+field)
+			op = ""
+		}
+		if op != "" {
+			return nil, fmt.Errorf("invalid constraint %q: operator %s without version", s, op)
+		}
+		for _, term := range terms {
+			conds, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %v", s, err)
+			}
+			set = append(set, conds...)
+		}
+		if len(set) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty alternative", s)
+		}
+		c.sets = append(c.sets, set)
+	}
+
+	return c, nil
+}
+
+// parseVersionTerm expands a single term into comparison conditions
+func parseVersionTerm(term string) ([]versionCondition, error) {
+	op := ""
+	for _, candidate := range versionOperators {
+		if strings.HasPrefix(term, candidate) {
+			op = candidate
+			break
+		}
+	}
+	raw := strings.TrimSpace(strings.TrimPrefix(term, op))
+	if raw == "*" {
+		return nil, nil
+	}
+
+	// Wildcards like "1.x" or "1.2.*" behave like a tilde/caret range
+	parts := strings.Split(strings.TrimPrefix(raw, "v"), ".")
+	wildcardAt := len(parts)
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			wildcardAt = i
+			break
+		}
+	}
+	if wildcardAt < len(parts) {
+		if op != "" && op != "=" && op != "==" {
+			return nil, fmt.Errorf("wildcard not allowed with operator %s", op)
+		}
+		raw = strings.Join(parts[:wildcardAt], ".")
+		if raw == "" {
+			return nil, nil
+		}
+		op = "~"
+		if wildcardAt == 1 {
+			op = "^"
+		}
+	}
+
+	v, err := ParseSemVer(raw)
+	if err != nil {
+		return nil, err
+	}
+	specified := len(strings.Split(strings.SplitN(strings.TrimPrefix(raw, "v"), "-", 2)[0], "."))
+
+	switch op {
+	case "", "=", "==":
+		if specified < 3 {
+			return tildeRange(v, specified), nil
+		}
+		return []versionCondition{{op: "=", version: v}}, nil
+	case "~":
+		return tildeRange(v, specified), nil
+	case "^":
+		return caretRange(v, specified), nil
+	}
+
+	return []versionCondition{{op: op, version: v}}, nil
+}
+
+// tildeRange allows patch-level changes, or minor-level if only the major is given
+func tildeRange(v SemVer, specified int) []versionCondition {
+	upper := SemVer{Major: v.Major, Minor: v.Minor + 1}
+	if specified == 1 {
+		upper = SemVer{Major: v.Major + 1}
+	}
+	return []versionCondition{{op: ">=", version: v}, {op: "<", version: upper}}
+}
+
+// caretRange allows changes that do not modify the left-most non-zero component
+func caretRange(v SemVer, specified int) []versionCondition {
+	var upper SemVer
+	switch {
+	case v.Major > 0 || specified == 1:
+		upper = SemVer{Major: v.Major + 1}
+	case v.Minor > 0 || specified == 2:
+		upper = SemVer{Minor: v.Minor + 1}
+	default:
+		upper = SemVer{Patch: v.Patch + 1}
+	}
+	return []versionCondition{{op: ">=", version: v}, {op: "<", version: upper}}
+}
+
+// Check reports whether the version satisfies the constraint
+func (c *VersionConstraint) Check(v SemVer) bool {
+	if len(c.sets) == 0 {
+		return true
+	}
+
+	for _, set := range c.sets {
+		ok := true
+		for _, cond := range set {
+			if !cond.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the original constraint string
+func (c *VersionConstraint) String() string {
+	return c.raw
+}
+
+// CheckVersion reports whether a version string satisfies a constraint string
+func CheckVersion(version, constraint string) (bool, error) {
+	c, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	v, err := ParseSemVer(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
@@ -0,0 +1,96 @@
+package types
+
+import "testing"
+
+func TestParseSemVer(t *testing.T) {
+	for in, want := range map[string]SemVer{
+		"1.2.3":              {Major: 1, Minor: 2, Patch: 3},
+		"v1.2":               {Major: 1, Minor: 2},
+		" 2 ":                {Major: 2},
+		"1.2.3-beta.1":       {Major: 1, Minor: 2, Patch: 3, Prerelease: "beta.1"},
+		"1.2.3-rc.1+build.5": {Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1"},
+	} {
+		got, err := ParseSemVer(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSemVer(%q) = %+v, %v, want %+v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "v", "1.2.3.4", "1.a", "-1.0.0", "1..2"} {
+		if v, err := ParseSemVer(in); err == nil {
+			t.Errorf("ParseSemVer(%q) = %+v, want an error", in, v)
+		}
+	}
+}
+
+func TestSemVerCompare(t *testing.T) {
+	// Ascending precedence, as in the semver specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseSemVer(ordered[i])
+			b, _ := ParseSemVer(ordered[j])
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := a.Compare(b); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		match      []string
+		reject     []string
+	}{
+		{"", []string{"0.0.1", "9.9.9"}, nil},
+		{"*", []string{"1.0.0"}, nil},
+		{">=1.2.0", []string{"1.2.0", "2.0.0"}, []string{"1.1.9", "1.2.0-rc.1"}},
+		{">= 1.2.0", []string{"1.2.0", "3.0.0"}, []string{"1.1.0"}},
+		{">= 1.2.0, < 2.0.0", []string{"1.2.0", "1.9.9"}, []string{"2.0.0", "1.0.0"}},
+		{">1.0.0 <=1.5.0", []string{"1.0.1", "1.5.0"}, []string{"1.0.0", "1.5.1"}},
+		{"!= 1.3.0", []string{"1.2.0"}, []string{"1.3.0"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"= 1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"^1.4", []string{"1.4.0", "1.9.0"}, []string{"1.3.9", "2.0.0"}},
+		{"^ 1.4.2", []string{"1.4.2", "1.5.0"}, []string{"1.4.1", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.x || >= 3.0", []string{"1.5.0", "3.1.0"}, []string{"2.0.0", "0.9.0"}},
+		{"1.2.*", []string{"1.2.7"}, []string{"1.3.0"}},
+		{">=1.0.0-beta <1.0.0", []string{"1.0.0-beta", "1.0.0-rc.1"}, []string{"1.0.0", "1.0.0-alpha"}},
+	} {
+		for _, v := range tc.match {
+			if ok, err := CheckVersion(v, tc.constraint); err != nil || !ok {
+				t.Errorf("CheckVersion(%q, %q) = %v, %v, want a match", v, tc.constraint, ok, err)
+			}
+		}
+		for _, v := range tc.reject {
+			if ok, err := CheckVersion(v, tc.constraint); err != nil || ok {
+				t.Errorf("CheckVersion(%q, %q) = %v, %v, want no match", v, tc.constraint, ok, err)
+			}
+		}
+	}
+}
+
+func TestParseVersionConstraintErrors(t *testing.T) {
+	for _, in := range []string{">=", ">= >= 1.0", "1.0 <", "^", ">=abc", "1.2.3.4", ">1.x", "1.0 ||", "|| 2.0"} {
+		if c, err := ParseVersionConstraint(in); err == nil {
+			t.Errorf("ParseVersionConstraint(%q) = %v, want an error", in, c)
+		}
+	}
+	if _, err := CheckVersion("not-a-version", ">=1.0"); err == nil {
+		t.Error("CheckVersion should reject an invalid version")
+	}
+}
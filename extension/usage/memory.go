@@ -0,0 +1,59 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps rollups in memory, dropping them after the retention
+type MemoryStore struct {
+	mu        sync.RWMutex
+	rollups   map[key]*Rollup
+	retention time.Duration
+}
+
+// NewMemoryStore creates a memory store keeping rollups for retention
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{rollups: make(map[key]*Rollup), retention: retention}
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(_ context.Context, rollups []*Rollup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range rollups {
+		if cur, ok := s.rollups[r.key()]; ok {
+			cur.add(r)
+			continue
+		}
+		c := *r
+		s.rollups[r.key()] = &c
+	}
+
+	if s.retention > 0 {
+		cutoff := time.Now().Add(-s.retention)
+		for k, r := range s.rollups {
+			if r.Bucket.Before(cutoff) {
+				delete(s.rollups, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Query implements Store
+func (s *MemoryStore) Query(_ context.Context, q *Query) ([]*Rollup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*Rollup
+	for _, r := range s.rollups {
+		if q.Match(r) {
+			c := *r
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps rollups in Redis, one hash per bucket expiring after the
+// retention, so nodes sharing the Redis instance report combined usage
+type RedisStore struct {
+	client    redis.UniversalClient
+	prefix    string
+	retention time.Duration
+}
+
+// NewRedisStore creates a Redis store with keys under prefix
+func NewRedisStore(client redis.UniversalClient, prefix string, retention time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix + ":usage:", retention: retention}
+}
+
+// fieldSep separates the parts of a hash field, it cannot appear in routes
+const fieldSep = "\x1f"
+
+// bucketsKey is the sorted set of bucket times, scored by Unix time
+func (s *RedisStore) bucketsKey() string { return s.prefix + "buckets" }
+
+func (s *RedisStore) bucketKey(bucket int64) string {
+	return s.prefix + strconv.FormatInt(bucket, 10)
+}
+
+// Add implements Store
+func (s *RedisStore) Add(ctx context.Context, rollups []*Rollup) error {
+	pipe := s.client.TxPipeline()
+	buckets := make(map[int64]bool)
+	for _, r := range rollups {
+		k := r.key()
+		hash := s.bucketKey(k.bucket)
+		field := strings.Join([]string{k.extension, k.route, k.consumer}, fieldSep) + fieldSep
+		pipe.HIncrBy(ctx, hash, field+"calls", r.Calls)
+		pipe.HIncrBy(ctx, hash, field+"errors", r.Errors)
+		pipe.HIncrBy(ctx, hash, field+"latency_ms", r.LatencyMs)
+		buckets[k.bucket] = true
+	}
+	for b := range buckets {
+		pipe.ZAdd(ctx, s.bucketsKey(), redis.Z{Score: float64(b), Member: b})
+		if s.retention > 0 {
+			pipe.ExpireAt(ctx, s.bucketKey(b), time.Unix(b, 0).Add(s.retention))
+		}
+	}
+	if s.retention > 0 {
+		cutoff := time.Now().Add(-s.retention).Unix()
+		pipe.ZRemRangeByScore(ctx, s.bucketsKey(), "-inf", "("+strconv.FormatInt(cutoff, 10))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store usage rollups: %v", err)
+	}
+	return nil
+}
+
+// Query implements Store
+func (s *RedisStore) Query(ctx context.Context, q *Query) ([]*Rollup, error) {
+	lo, hi := "-inf", "+inf"
+	if !q.From.IsZero() {
+		lo = strconv.FormatInt(q.From.Unix(), 10)
+	}
+	if !q.To.IsZero() {
+		hi = "(" + strconv.FormatInt(q.To.Unix(), 10)
+	}
+	buckets, err := s.client.ZRangeByScore(ctx, s.bucketsKey(), &redis.ZRangeBy{Min: lo, Max: hi}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage buckets: %v", err)
+	}
+
+	var out []*Rollup
+	for _, b := range buckets {
+		bucket, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			continue
+		}
+		fields, err := s.client.HGetAll(ctx, s.bucketKey(bucket)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query usage bucket %s: %v", b, err)
+		}
+
+		rollups := make(map[key]*Rollup)
+		for field, value := range fields {
+			parts := strings.Split(field, fieldSep)
+			if len(parts) != 4 {
+				continue
+			}
+			k := key{bucket, parts[0], parts[1], parts[2]}
+			r, ok := rollups[k]
+			if !ok {
+				r = &Rollup{Bucket: time.Unix(bucket, 0).UTC(), Extension: k.extension, Route: k.route, Consumer: k.consumer}
+				rollups[k] = r
+			}
+			n, _ := strconv.ParseInt(value, 10, 64)
+			switch parts[3] {
+			case "calls":
+				r.Calls = n
+			case "errors":
+				r.Errors = n
+			case "latency_ms":
+				r.LatencyMs = n
+			}
+		}
+		for _, r := range rollups {
+			if q.Match(r) {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,305 @@
+// Package usage records calls to extension routes per extension, route and
+// consumer, rolled up in time buckets, and answers analytics queries over the
+// rollups such as top consumers and call trends.
+//
+// Calls are counted in memory and written to a Store every flush interval, so
+// queries lag behind by up to that interval.
+package usage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger"
+)
+
+// Anonymous is the consumer of calls without a user or API key
+const Anonymous = "anonymous"
+
+// Rollup is the usage of a route by a consumer within a bucket
+type Rollup struct {
+	Bucket    time.Time `json:"bucket"`
+	Extension string    `json:"extension"`
+	Route     string    `json:"route"`    // Method and route template, e.g. "GET /users/:id"
+	Consumer  string    `json:"consumer"` // "user:<id>", "key:<hash>" or "anonymous"
+	Calls     int64     `json:"calls"`
+	Errors    int64     `json:"errors"`     // Calls answered with a 5xx status
+	LatencyMs int64     `json:"latency_ms"` // Total latency of the calls
+}
+
+// key identifies the rollup of a call
+type key struct {
+	bucket                     int64
+	extension, route, consumer string
+}
+
+func (r *Rollup) key() key {
+	return key{r.Bucket.Unix(), r.Extension, r.Route, r.Consumer}
+}
+
+// add merges the counters of o into r
+func (r *Rollup) add(o *Rollup) {
+	r.Calls += o.Calls
+	r.Errors += o.Errors
+	r.LatencyMs += o.LatencyMs
+}
+
+// Query selects rollups, empty fields match any
+type Query struct {
+	Extension string    `json:"extension,omitempty"`
+	Route     string    `json:"route,omitempty"`
+	Consumer  string    `json:"consumer,omitempty"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// Match reports whether a rollup is selected by the query
+func (q *Query) Match(r *Rollup) bool {
+	return (q.Extension == "" || q.Extension == r.Extension) &&
+		(q.Route == "" || q.Route == r.Route) &&
+		(q.Consumer == "" || q.Consumer == r.Consumer) &&
+		(q.From.IsZero() || !r.Bucket.Before(q.From)) &&
+		(q.To.IsZero() || r.Bucket.Before(q.To))
+}
+
+// Store persists rollups
+type Store interface {
+	// Add merges rollups into the stored ones
+	Add(ctx context.Context, rollups []*Rollup) error
+	// Query returns the rollups selected by q
+	Query(ctx context.Context, q *Query) ([]*Rollup, error)
+}
+
+// Options configures a Recorder
+type Options struct {
+	Store         Store         // Defaults to a MemoryStore keeping Retention
+	Bucket        time.Duration // Rollup granularity, default 1m
+	FlushInterval time.Duration // How often rollups are written to the store, default 10s
+	Retention     time.Duration // How long the default store keeps rollups, default 30 days
+}
+
+// Recorder counts calls and writes them to a store in the background
+type Recorder struct {
+	store  Store
+	bucket time.Duration
+
+	mu      sync.Mutex
+	pending map[key]*Rollup
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewRecorder creates a recorder and starts flushing it
+func NewRecorder(opts Options) *Recorder {
+	if opts.Bucket <= 0 {
+		opts.Bucket = time.Minute
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 10 * time.Second
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 30 * 24 * time.Hour
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(opts.Retention)
+	}
+
+	r := &Recorder{
+		store:   opts.Store,
+		bucket:  opts.Bucket,
+		pending: make(map[key]*Rollup),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run(opts.FlushInterval)
+	return r
+}
+
+// Record counts a call of a route
+func (r *Recorder) Record(extension, route, consumer string, status int, latency time.Duration) {
+	if consumer == "" {
+		consumer = Anonymous
+	}
+	call := &Rollup{
+		Bucket:    time.Now().Truncate(r.bucket).UTC(),
+		Extension: extension,
+		Route:     route,
+		Consumer:  consumer,
+		Calls:     1,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if status >= 500 {
+		call.Errors = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.pending[call.key()]; ok {
+		p.add(call)
+		return
+	}
+	r.pending[call.key()] = call
+}
+
+// Flush writes the pending rollups to the store, keeping them on failure
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[key]*Rollup)
+	r.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	rollups := make([]*Rollup, 0, len(pending))
+	for _, p := range pending {
+		rollups = append(rollups, p)
+	}
+	if err := r.store.Add(ctx, rollups); err != nil {
+		r.mu.Lock()
+		for k, p := range pending {
+			if cur, ok := r.pending[k]; ok {
+				p.add(cur)
+			}
+			r.pending[k] = p
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (r *Recorder) run(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			if err := r.Flush(ctx); err != nil {
+				logger.Warnf(nil, "Failed to flush API usage: %v", err)
+			}
+			cancel()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Close stops flushing and writes the pending rollups
+func (r *Recorder) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err = r.Flush(ctx)
+	})
+	return err
+}
+
+// Query returns the stored rollups selected by q
+func (r *Recorder) Query(ctx context.Context, q *Query) ([]*Rollup, error) {
+	return r.store.Query(ctx, q)
+}
+
+// Dimension groups rollups in a summary
+type Dimension string
+
+const (
+	ByExtension Dimension = "extension"
+	ByRoute     Dimension = "route"
+	ByConsumer  Dimension = "consumer"
+)
+
+// Usage is the usage of an extension, route or consumer
+type Usage struct {
+	Key          string  `json:"key"`
+	Calls        int64   `json:"calls"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// Top returns the usage of the rollups grouped by a dimension, most called first,
+// at most n entries unless n is 0
+func Top(rollups []*Rollup, by Dimension, n int) []*Usage {
+	groups := make(map[string]*Rollup)
+	for _, r := range rollups {
+		var k string
+		switch by {
+		case ByRoute:
+			k = r.Extension + " " + r.Route
+		case ByConsumer:
+			k = r.Consumer
+		default:
+			k = r.Extension
+		}
+		if g, ok := groups[k]; ok {
+			g.add(r)
+		} else {
+			g := *r
+			groups[k] = &g
+		}
+	}
+
+	usage := make([]*Usage, 0, len(groups))
+	for k, g := range groups {
+		usage = append(usage, &Usage{Key: k, Calls: g.Calls, Errors: g.Errors, AvgLatencyMs: avg(g)})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Calls != usage[j].Calls {
+			return usage[i].Calls > usage[j].Calls
+		}
+		return strings.Compare(usage[i].Key, usage[j].Key) < 0
+	})
+	if n > 0 && len(usage) > n {
+		usage = usage[:n]
+	}
+	return usage
+}
+
+// Point is the usage within an interval of a trend
+type Point struct {
+	Time         time.Time `json:"time"`
+	Calls        int64     `json:"calls"`
+	Errors       int64     `json:"errors"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"`
+}
+
+// Trend sums the rollups per interval, in time order. Intervals without calls
+// are left out.
+func Trend(rollups []*Rollup, interval time.Duration) []*Point {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	sums := make(map[int64]*Rollup)
+	for _, r := range rollups {
+		t := r.Bucket.Truncate(interval)
+		if s, ok := sums[t.Unix()]; ok {
+			s.add(r)
+		} else {
+			sums[t.Unix()] = &Rollup{Bucket: t, Calls: r.Calls, Errors: r.Errors, LatencyMs: r.LatencyMs}
+		}
+	}
+
+	points := make([]*Point, 0, len(sums))
+	for _, s := range sums {
+		points = append(points, &Point{Time: s.Bucket, Calls: s.Calls, Errors: s.Errors, AvgLatencyMs: avg(s)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points
+}
+
+func avg(r *Rollup) float64 {
+	if r.Calls == 0 {
+		return 0
+	}
+	return float64(r.LatencyMs) / float64(r.Calls)
+}
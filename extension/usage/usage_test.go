@@ -0,0 +1,169 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+// failingStore fails Add until ok is set
+type failingStore struct {
+	*MemoryStore
+	ok bool
+}
+
+func (s *failingStore) Add(ctx context.Context, rollups []*Rollup) error {
+	if !s.ok {
+		return errors.New("store unavailable")
+	}
+	return s.MemoryStore.Add(ctx, rollups)
+}
+
+// sortRollups orders rollups by route and consumer
+func sortRollups(rollups []*Rollup) {
+	sort.Slice(rollups, func(i, j int) bool {
+		if rollups[i].Route != rollups[j].Route {
+			return rollups[i].Route < rollups[j].Route
+		}
+		return rollups[i].Consumer < rollups[j].Consumer
+	})
+}
+
+func TestRecorderRollsUpCalls(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecorder(Options{Bucket: time.Hour, FlushInterval: time.Hour})
+	defer r.Close()
+
+	r.Record("users", "GET /users/:id", "user:1", 200, 10*time.Millisecond)
+	r.Record("users", "GET /users/:id", "user:1", 503, 30*time.Millisecond)
+	r.Record("users", "GET /users/:id", "", 404, 5*time.Millisecond)
+	r.Record("notes", "POST /notes", "key:abc", 201, 8*time.Millisecond)
+
+	// Calls are not visible before they are flushed
+	if rollups, _ := r.Query(ctx, &Query{}); len(rollups) != 0 {
+		t.Fatalf("unflushed rollups = %v", rollups)
+	}
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rollups, err := r.Query(ctx, &Query{Extension: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortRollups(rollups)
+	if len(rollups) != 2 {
+		t.Fatalf("got %d rollups of users, want 2", len(rollups))
+	}
+	if a := rollups[0]; a.Consumer != Anonymous || a.Calls != 1 || a.Errors != 0 {
+		t.Fatalf("anonymous rollup = %+v", a)
+	}
+	if u := rollups[1]; u.Consumer != "user:1" || u.Calls != 2 || u.Errors != 1 || u.LatencyMs != 40 ||
+		!u.Bucket.Equal(time.Now().Truncate(time.Hour).UTC()) {
+		t.Fatalf("user rollup = %+v", u)
+	}
+
+	// Buckets before From or at To are left out
+	next := time.Now().Truncate(time.Hour).Add(time.Hour)
+	if rollups, _ := r.Query(ctx, &Query{From: next}); len(rollups) != 0 {
+		t.Fatalf("rollups from the next bucket = %v", rollups)
+	}
+	if rollups, _ := r.Query(ctx, &Query{To: next, Consumer: "key:abc"}); len(rollups) != 1 || rollups[0].Route != "POST /notes" {
+		t.Fatalf("rollups of key:abc = %v", rollups)
+	}
+}
+
+func TestRecorderKeepsRollupsOnFailedFlush(t *testing.T) {
+	ctx := context.Background()
+	store := &failingStore{MemoryStore: NewMemoryStore(0)}
+	r := NewRecorder(Options{Store: store, FlushInterval: time.Hour})
+	defer r.Close()
+
+	r.Record("users", "GET /users", "user:1", 200, time.Millisecond)
+	if err := r.Flush(ctx); err == nil {
+		t.Fatal("Flush should report the store error")
+	}
+
+	// Calls recorded after the failure are merged with the kept ones
+	r.Record("users", "GET /users", "user:1", 500, time.Millisecond)
+	store.ok = true
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rollups, _ := store.Query(ctx, &Query{})
+	if len(rollups) != 1 || rollups[0].Calls != 2 || rollups[0].Errors != 1 {
+		t.Fatalf("rollups = %v, want one rollup of 2 calls", rollups)
+	}
+
+	// Nothing is written twice
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rollups, _ := store.Query(ctx, &Query{}); rollups[0].Calls != 2 {
+		t.Fatalf("calls after an empty flush = %d", rollups[0].Calls)
+	}
+}
+
+func TestCloseFlushesPendingCalls(t *testing.T) {
+	store := NewMemoryStore(0)
+	r := NewRecorder(Options{Store: store, FlushInterval: time.Hour})
+	r.Record("users", "GET /users", "", 200, time.Millisecond)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("second Close() = %v", err)
+	}
+	if rollups, _ := store.Query(context.Background(), &Query{}); len(rollups) != 1 {
+		t.Fatalf("rollups after Close = %v", rollups)
+	}
+}
+
+func TestMemoryStoreDropsExpiredRollups(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Hour)
+	now := time.Now().UTC()
+	if err := store.Add(ctx, []*Rollup{
+		{Bucket: now.Add(-2 * time.Hour), Extension: "users", Calls: 1},
+		{Bucket: now, Extension: "users", Calls: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if rollups, _ := store.Query(ctx, &Query{}); len(rollups) != 1 || !rollups[0].Bucket.Equal(now) {
+		t.Fatalf("rollups = %v, want only the recent one", rollups)
+	}
+}
+
+func TestTopAndTrend(t *testing.T) {
+	base := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	rollups := []*Rollup{
+		{Bucket: base, Extension: "users", Route: "GET /users", Consumer: "user:1", Calls: 3, Errors: 1, LatencyMs: 30},
+		{Bucket: base.Add(10 * time.Minute), Extension: "users", Route: "GET /users/:id", Consumer: "user:2", Calls: 1, LatencyMs: 50},
+		{Bucket: base.Add(time.Hour), Extension: "notes", Route: "POST /notes", Consumer: "user:2", Calls: 4, LatencyMs: 20},
+	}
+
+	top := Top(rollups, ByConsumer, 0)
+	if len(top) != 2 || top[0].Key != "user:2" || top[0].Calls != 5 || top[0].AvgLatencyMs != 14 ||
+		top[1].Key != "user:1" || top[1].Errors != 1 {
+		t.Fatalf("top consumers = %+v", top)
+	}
+	// Ties are ordered by key
+	if top := Top(rollups, ByExtension, 1); len(top) != 1 || top[0].Key != "notes" || top[0].Calls != 4 {
+		t.Fatalf("top extension = %+v", top)
+	}
+	if top := Top(rollups, ByRoute, 0); len(top) != 3 || top[0].Key != "notes POST /notes" {
+		t.Fatalf("top routes = %+v", top)
+	}
+	// Top does not change the rollups it groups
+	if rollups[0].Calls != 3 {
+		t.Fatalf("rollup changed to %d calls", rollups[0].Calls)
+	}
+
+	trend := Trend(rollups, time.Hour)
+	if len(trend) != 2 || !trend[0].Time.Equal(base) || trend[0].Calls != 4 || trend[0].AvgLatencyMs != 20 ||
+		!trend[1].Time.Equal(base.Add(time.Hour)) || trend[1].Calls != 4 {
+		t.Fatalf("trend = %+v", trend)
+	}
+}
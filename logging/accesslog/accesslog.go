@@ -0,0 +1,289 @@
+// Package accesslog provides gin middleware writing one log entry per request
+// through the ncore logger.
+//
+// Entries carry a configurable set of fields, go to the warning level when a
+// request is slow or fails with a client error and to the error level on server
+// errors. Request and response bodies can be captured up to a size limit;
+// credentials in logged headers are redacted.
+//
+//	r.Use(accesslog.Middleware(&accesslog.Options{
+//	    SlowThreshold: time.Second,
+//	    Headers:       []string{"User-Agent", "Authorization"}, // Authorization is logged as [REDACTED]
+//	}))
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Field is an access log field
+type Field string
+
+// Access log fields, named as they appear in entries
+const (
+	FieldMethod       Field = "method"
+	FieldPath         Field = "path"
+	FieldRoute        Field = "route" // Route pattern, e.g. /users/:id
+	FieldQuery        Field = "query"
+	FieldStatus       Field = "status"
+	FieldLatency      Field = "latency_ms"
+	FieldClientIP     Field = "client_ip"
+	FieldUserAgent    Field = "user_agent"
+	FieldReferer      Field = "referer"
+	FieldRequestSize  Field = "request_size"
+	FieldResponseSize Field = "response_size"
+	FieldUserID       Field = "user_id"
+	FieldTraceID      Field = ctxutil.TraceIDKey
+	FieldErrors       Field = "errors" // Errors handlers added with c.Error
+)
+
+// DefaultFields are logged when Options.Fields is empty
+var DefaultFields = []Field{
+	FieldMethod, FieldPath, FieldRoute, FieldStatus, FieldLatency, FieldClientIP,
+	FieldRequestSize, FieldResponseSize, FieldUserID, FieldTraceID, FieldErrors,
+}
+
+// DefaultRedactHeaders are redacted when Options.RedactHeaders is empty
+var DefaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token",
+}
+
+// Redacted replaces the value of redacted headers
+const Redacted = "[REDACTED]"
+
+// Options configures the access log middleware
+type Options struct {
+	Logger  *logger.Logger // Defaults to the global logger
+	Message string         // Entry message, default "HTTP request"
+	Fields  []Field        // Defaults to DefaultFields
+
+	SkipPaths     []string      // Paths not logged, e.g. health checks
+	SlowThreshold time.Duration // Requests slower are logged as warnings with slow=true, 0 disables
+
+	// Headers lists the request headers to log, "*" logs all of them
+	Headers       []string
+	RedactHeaders []string // Defaults to DefaultRedactHeaders
+
+	// Body capture, only of JSON, XML, form and text content
+	RequestBody  bool
+	ResponseBody bool
+	MaxBodySize  int // Bytes captured of each body, default 4096
+}
+
+// Middleware creates gin middleware logging every request
+func Middleware(opts *Options) gin.HandlerFunc {
+	if opts == nil {
+		opts = &Options{}
+	}
+	message := opts.Message
+	if message == "" {
+		message = "HTTP request"
+	}
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	maxBody := opts.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = 4096
+	}
+	skip := make(map[string]bool, len(opts.SkipPaths))
+	for _, path := range opts.SkipPaths {
+		skip[path] = true
+	}
+	redact := make(map[string]bool)
+	redactHeaders := opts.RedactHeaders
+	if len(redactHeaders) == 0 {
+		redactHeaders = DefaultRedactHeaders
+	}
+	for _, h := range redactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		l := opts.Logger
+		if l == nil {
+			l = logger.StdLogger()
+		}
+
+		start := time.Now()
+		path, query := c.Request.URL.Path, c.Request.URL.RawQuery
+
+		var reqBody []byte
+		if opts.RequestBody && c.Request.Body != nil && textual(c.Request.Header.Get("Content-Type")) {
+			reqBody = peekBody(c.Request, maxBody)
+		}
+		var resBody *bodyWriter
+		if opts.ResponseBody {
+			resBody = &bodyWriter{ResponseWriter: c.Writer, max: maxBody}
+			c.Writer = resBody
+		}
+
+		c.Next()
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		ctx := ctxutil.WithGinContext(c.Request.Context(), c)
+
+		entry := logrus.Fields{}
+		for _, f := range fields {
+			if v, ok := fieldValue(ctx, c, f, path, query, latency); ok {
+				entry[string(f)] = v
+			}
+		}
+		if h := headers(c.Request.Header, opts.Headers, redact); len(h) > 0 {
+			entry["headers"] = h
+		}
+		if len(reqBody) > 0 {
+			entry["request_body"] = string(reqBody)
+		}
+		if resBody != nil && resBody.buf.Len() > 0 && textual(c.Writer.Header().Get("Content-Type")) {
+			entry["response_body"] = resBody.buf.String()
+		}
+
+		level := logrus.InfoLevel
+		if opts.SlowThreshold > 0 && latency > opts.SlowThreshold {
+			entry["slow"] = true
+			level = logrus.WarnLevel
+		}
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = logrus.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = logrus.WarnLevel
+		}
+
+		l.EntryWithFields(ctx, entry).Log(level, message)
+	}
+}
+
+// fieldValue returns the value of a field, false when it has none
+func fieldValue(ctx context.Context, c *gin.Context, f Field, path, query string, latency time.Duration) (any, bool) {
+	switch f {
+	case FieldMethod:
+		return c.Request.Method, true
+	case FieldPath:
+		return path, true
+	case FieldRoute:
+		route := c.FullPath()
+		return route, route != ""
+	case FieldQuery:
+		return query, query != ""
+	case FieldStatus:
+		return c.Writer.Status(), true
+	case FieldLatency:
+		return float64(latency.Microseconds()) / 1000, true
+	case FieldClientIP:
+		return c.ClientIP(), true
+	case FieldUserAgent:
+		ua := c.Request.UserAgent()
+		return ua, ua != ""
+	case FieldReferer:
+		ref := c.Request.Referer()
+		return ref, ref != ""
+	case FieldRequestSize:
+		return max(c.Request.ContentLength, 0), true
+	case FieldResponseSize:
+		return max(c.Writer.Size(), 0), true
+	case FieldUserID:
+		uid := ctxutil.GetUserID(ctx)
+		return uid, uid != ""
+	case FieldTraceID:
+		id := ctxutil.GetTraceID(ctx)
+		return id, id != ""
+	case FieldErrors:
+		errs := c.Errors.ByType(gin.ErrorTypeAny).String()
+		return strings.TrimSpace(errs), len(c.Errors) > 0
+	}
+	return nil, false
+}
+
+// headers returns the listed request headers with sensitive values redacted
+func headers(h http.Header, names []string, redact map[string]bool) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	if len(names) == 1 && names[0] == "*" {
+		names = make([]string, 0, len(h))
+		for name := range h {
+			names = append(names, name)
+		}
+	}
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		v := h.Values(name)
+		if len(v) == 0 {
+			continue
+		}
+		if redact[name] {
+			out[name] = Redacted
+		} else {
+			out[name] = strings.Join(v, ", ")
+		}
+	}
+	return out
+}
+
+// peekBody reads up to limit bytes of the request body and puts them back
+func peekBody(r *http.Request, limit int) []byte {
+	buf, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	if err != nil {
+		return nil
+	}
+	return buf
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// textual reports whether a content type is worth logging as text
+func textual(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mt, "text/") || strings.HasSuffix(mt, "json") || strings.HasSuffix(mt, "xml") ||
+		mt == "application/x-www-form-urlencoded"
+}
+
+// bodyWriter keeps the first bytes of a response body
+type bodyWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+	max int
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(b []byte) {
+	if n := w.max - w.buf.Len(); n > 0 {
+		w.buf.Write(b[:min(n, len(b))])
+	}
+}
@@ -0,0 +1,124 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/logging/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func newRouter(t *testing.T, opts *Options) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	l := &logger.Logger{Logger: logrus.New()}
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	opts.Logger = l
+
+	r := gin.New()
+	r.Use(Middleware(opts))
+	r.POST("/users/:id", func(c *gin.Context) {
+		c.Set("x-md-uid", "u1")
+		body, _ := c.GetRawData()
+		c.JSON(http.StatusCreated, gin.H{"echo": string(body)})
+	})
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		_ = c.Error(http.ErrHandlerTimeout)
+		c.Status(http.StatusBadGateway)
+	})
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, &buf
+}
+
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid entry %q: %v", line, err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestMiddleware(t *testing.T) {
+	r, buf := newRouter(t, &Options{
+		SkipPaths:     []string{"/health"},
+		SlowThreshold: 10 * time.Millisecond,
+		Headers:       []string{"Authorization", "X-Client"},
+		RequestBody:   true,
+		ResponseBody:  true,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7?expand=1", strings.NewReader(`{"name":"ann"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Client", "web")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `{\"name\":\"ann\"}`) {
+		t.Fatalf("handler did not read the captured body: %s", w.Body)
+	}
+	for _, path := range []string{"/slow", "/fail", "/health"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	logged := entries(t, buf)
+	if len(logged) != 3 {
+		t.Fatalf("got %d entries, want 3 without /health", len(logged))
+	}
+
+	e := logged[0]
+	if e["level"] != "info" || e["method"] != "POST" || e["route"] != "/users/:id" || e["status"] != float64(201) || e["user_id"] != "u1" {
+		t.Errorf("entry = %v", e)
+	}
+	if h := e["headers"].(map[string]any); h["Authorization"] != Redacted || h["X-Client"] != "web" {
+		t.Errorf("headers = %v", h)
+	}
+	if e["request_body"] != `{"name":"ann"}` || !strings.Contains(e["response_body"].(string), "echo") {
+		t.Errorf("bodies = %v, %v", e["request_body"], e["response_body"])
+	}
+	if _, ok := e["query"]; ok {
+		t.Error("query logged without FieldQuery")
+	}
+
+	if slow := logged[1]; slow["level"] != "warning" || slow["slow"] != true {
+		t.Errorf("slow entry = %v", slow)
+	}
+	if fail := logged[2]; fail["level"] != "error" || !strings.Contains(fail["errors"].(string), "timeout") {
+		t.Errorf("failed entry = %v", fail)
+	}
+}
+
+func TestMiddlewareFields(t *testing.T) {
+	r, buf := newRouter(t, &Options{Fields: []Field{FieldPath, FieldQuery}, Message: "access"})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/7?expand=1", nil))
+
+	e := entries(t, buf)[0]
+	if e["msg"] != "access" || e["path"] != "/users/7" || e["query"] != "expand=1" {
+		t.Errorf("entry = %v", e)
+	}
+	for _, f := range []Field{FieldMethod, FieldStatus, FieldLatency} {
+		if _, ok := e[string(f)]; ok {
+			t.Errorf("field %s logged without being selected", f)
+		}
+	}
+}
@@ -10,10 +10,10 @@ import (
 
 // Config configuration struct
 type Config struct {
-	Level           int              `json:"level" yaml:"level"`
+	Level           int              `json:"level" yaml:"level" validate:"min=0,max=6"`
 	Path            string           `json:"path" yaml:"path"`
-	Format          string           `json:"format" yaml:"format"`
-	Output          string           `json:"output" yaml:"output"`
+	Format          string           `json:"format" yaml:"format" validate:"omitempty,oneof=json text"`
+	Output          string           `json:"output" yaml:"output" validate:"omitempty,oneof=stdout stderr file"`
 	OutputFile      string           `json:"output_file" yaml:"output_file"`
 	IndexName       string           `json:"index_name" yaml:"index_name"`
 	DateSuffix      string           `json:"date_suffix" yaml:"date_suffix"`
@@ -22,6 +22,12 @@ type Config struct {
 	Meilisearch     *Meilisearch     `json:"meilisearch" yaml:"meilisearch"`
 	Elasticsearch   *Elasticsearch   `json:"elasticsearch" yaml:"elasticsearch"`
 	OpenSearch      *OpenSearch      `json:"opensearch" yaml:"opensearch"`
+	// Sampling limits identical entries of the global logger
+	Sampling *Sampling `json:"sampling" yaml:"sampling"`
+	// Loggers sets the level and sampling of loggers created with logger.Named
+	Loggers map[string]*Named `json:"loggers" yaml:"loggers"`
+	// Sinks ship entries in batches to log aggregators such as Loki
+	Sinks []*Sink `json:"sinks" yaml:"sinks"`
 }
 
 // GetConfig returns the logger configuration with date suffix support
@@ -59,6 +65,9 @@ func GetConfig(v *viper.Viper) *Config {
 		Meilisearch:     getMeilisearchConfigs(v),
 		Elasticsearch:   getElasticsearchConfigs(v),
 		OpenSearch:      getOpenSearchConfigs(v),
+		Sampling:        getSamplingConfig(v, "logger.sampling"),
+		Loggers:         getNamedConfigs(v),
+		Sinks:           getSinkConfigs(v),
 	}
 }
 
@@ -10,6 +10,7 @@ type Desensitization struct {
 	PreservePrefix        int      `json:"preserve_prefix" yaml:"preserve_prefix"`
 	PreserveSuffix        int      `json:"preserve_suffix" yaml:"preserve_suffix"`
 	MaskChar              string   `json:"mask_char" yaml:"mask_char"`
+	MaskStyle             string   `json:"mask_style" yaml:"mask_style"` // fixed, partial, hash or label, see the redact package
 	UseFixedLength        bool     `json:"use_fixed_length" yaml:"use_fixed_length"`
 	FixedMaskLength       int      `json:"fixed_mask_length" yaml:"fixed_mask_length"`
 	ExactFieldMatch       bool     `json:"exact_field_match" yaml:"exact_field_match"`
@@ -59,6 +60,7 @@ func getDesensitizationConfigs(v *viper.Viper) *Desensitization {
 		PreservePrefix:        v.GetInt("logger.desensitization.preserve_prefix"),
 		PreserveSuffix:        v.GetInt("logger.desensitization.preserve_suffix"),
 		MaskChar:              v.GetString("logger.desensitization.mask_char"),
+		MaskStyle:             v.GetString("logger.desensitization.mask_style"),
 		UseFixedLength:        v.GetBool("logger.desensitization.use_fixed_length"),
 		FixedMaskLength:       v.GetInt("logger.desensitization.fixed_mask_length"),
 		ExactFieldMatch:       v.GetBool("logger.desensitization.exact_field_match"),
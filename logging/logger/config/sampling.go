@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Sampling limits identical entries: per tick, the first First entries with
+// the same level and message are logged, then one in every Thereafter
+type Sampling struct {
+	// Level is the least verbose level sampled, defaults to "debug"; entries
+	// at more severe levels are always logged
+	Level      string        `json:"level" yaml:"level"`
+	First      int           `json:"first" yaml:"first"`
+	Thereafter int           `json:"thereafter" yaml:"thereafter"` // 0 drops the rest of the tick
+	Tick       time.Duration `json:"tick" yaml:"tick"`             // Defaults to 1s
+}
+
+// Named configures a logger created with logger.Named
+type Named struct {
+	Level    string    `json:"level" yaml:"level"` // Empty inherits the global level
+	Sampling *Sampling `json:"sampling" yaml:"sampling"`
+}
+
+// getSamplingConfig reads a sampling section, nil when it is not set
+func getSamplingConfig(v *viper.Viper, key string) *Sampling {
+	if !v.IsSet(key) {
+		return nil
+	}
+	return &Sampling{
+		Level:      v.GetString(key + ".level"),
+		First:      v.GetInt(key + ".first"),
+		Thereafter: v.GetInt(key + ".thereafter"),
+		Tick:       v.GetDuration(key + ".tick"),
+	}
+}
+
+// getNamedConfigs reads logger.loggers, the settings of named loggers
+func getNamedConfigs(v *viper.Viper) map[string]*Named {
+	loggers := v.GetStringMap("logger.loggers")
+	if len(loggers) == 0 {
+		return nil
+	}
+	named := make(map[string]*Named, len(loggers))
+	for name := range loggers {
+		key := "logger.loggers." + name
+		named[name] = &Named{
+			Level:    v.GetString(key + ".level"),
+			Sampling: getSamplingConfig(v, key+".sampling"),
+		}
+	}
+	return named
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Sink configures a buffered log sink shipping entries in batches, e.g. to
+// Loki or Elasticsearch
+type Sink struct {
+	Type string `json:"type" yaml:"type"` // "loki", "elasticsearch" or a registered type
+	Name string `json:"name" yaml:"name"` // Defaults to the type
+	// URL is the Loki base URL or the Elasticsearch address
+	URL      string            `json:"url" yaml:"url"`
+	Username string            `json:"username" yaml:"username"`
+	Password string            `json:"password" yaml:"password"`
+	Headers  map[string]string `json:"headers" yaml:"headers"`
+	// Labels are the Loki stream labels, the entry level is added as "level"
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	// TenantID is sent as X-Scope-OrgID to multi-tenant Loki
+	TenantID string `json:"tenant_id" yaml:"tenant_id"`
+	// Index is the Elasticsearch index, defaults to the logger index name
+	// with its date suffix
+	Index string `json:"index" yaml:"index"`
+	// Level is the least severe level shipped, defaults to "info"
+	Level         string        `json:"level" yaml:"level"`
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`         // Defaults to 500
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"` // Defaults to 1s
+	BufferSize    int           `json:"buffer_size" yaml:"buffer_size"`       // Defaults to 10000
+	// Overflow is what happens when the buffer is full: "drop" the entry
+	// (default) or "block" the logging goroutine up to BlockTimeout first
+	Overflow     string        `json:"overflow" yaml:"overflow"`
+	BlockTimeout time.Duration `json:"block_timeout" yaml:"block_timeout"` // Defaults to 100ms
+	Timeout      time.Duration `json:"timeout" yaml:"timeout"`             // Per request, defaults to 10s
+	MaxRetries   int           `json:"max_retries" yaml:"max_retries"`     // Defaults to 3
+}
+
+// getSinkConfigs reads logger.sinks
+func getSinkConfigs(v *viper.Viper) []*Sink {
+	if !v.IsSet("logger.sinks") {
+		return nil
+	}
+	var sinks []*Sink
+	if err := v.UnmarshalKey("logger.sinks", &sinks, func(c *mapstructure.DecoderConfig) {
+		c.TagName = "yaml"
+	}); err != nil {
+		return nil
+	}
+	return sinks
+}
@@ -6,13 +6,27 @@ import (
 	"github.com/ncobase/ncore/ctxutil"
 )
 
-var traceKey = ctxutil.TraceIDKey
+var (
+	traceKey   = ctxutil.TraceIDKey
+	spanKey    = ctxutil.SpanIDKey
+	requestKey = ctxutil.RequestIDKey
+)
 
 // getTraceID gets a trace ID from the context.
 func getTraceID(ctx context.Context) string {
 	return ctxutil.GetTraceID(ctx)
 }
 
+// getSpanID gets a span ID from the context.
+func getSpanID(ctx context.Context) string {
+	return ctxutil.GetSpanID(ctx)
+}
+
+// getRequestID gets a request ID from the context.
+func getRequestID(ctx context.Context) string {
+	return ctxutil.GetRequestID(ctx)
+}
+
 // EnsureTraceID ensures that a trace ID exists in the context.
 func EnsureTraceID(ctx context.Context) (context.Context, string) {
 	return ctxutil.EnsureTraceID(ctx)
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDesensitizer(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.desensitizer = NewDesensitizer(&config.Desensitization{
+		Enabled:         true,
+		SensitiveFields: []string{"password"},
+		MaskChar:        "*",
+		FixedMaskLength: 4,
+		UseFixedLength:  true,
+		CustomPatterns:  []string{`ORD-\d+`},
+	})
+	l.AddHook(desensitizeHook{l: l})
+	l.AddHook(desensitizeHook{l: l})
+
+	ctx := context.Background()
+	l.EntryWithFields(ctx, logrus.Fields{
+		"user":  map[string]any{"password": "secret", "name": "ann"},
+		"order": "ORD-42",
+	}).Infof("charged card 4111 1111 1111 1111 for %s", "ORD-7")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "charged card **** for ****" {
+		t.Errorf("msg = %q", entry["msg"])
+	}
+	user := entry["user"].(map[string]any)
+	if user["password"] != "****" || user["name"] != "ann" || entry["order"] != "****" {
+		t.Errorf("fields = %v", entry)
+	}
+	if n := len(l.Hooks[logrus.InfoLevel]); n != 1 {
+		t.Errorf("%d desensitize hooks, want 1", n)
+	}
+}
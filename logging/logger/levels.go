@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+// LoggerField is the field naming the logger of entries from Named loggers
+const LoggerField = "logger"
+
+// ErrUnknownLogger is returned for a name no Named logger was created with
+var ErrUnknownLogger = errors.New("logger: unknown logger")
+
+// named holds the loggers created with Named, their configuration and the
+// level changes waiting to be reverted
+var named = struct {
+	mu      sync.Mutex
+	loggers map[string]*ScopedLogger
+	configs map[string]*config.Named
+	reverts map[string]*levelRevert
+}{
+	loggers: make(map[string]*ScopedLogger),
+	reverts: make(map[string]*levelRevert),
+}
+
+// levelRevert restores a level when a temporary change expires
+type levelRevert struct {
+	timer   *time.Timer
+	restore func()
+	at      time.Time
+}
+
+// LevelInfo is the level of the global logger or of a named logger
+type LevelInfo struct {
+	Logger    string     `json:"logger"` // Empty for the global logger
+	Level     string     `json:"level"`
+	Inherited bool       `json:"inherited,omitempty"` // The level is the global one
+	RevertAt  *time.Time `json:"revert_at,omitempty"` // End of a temporary change
+	Dropped   int64      `json:"dropped,omitempty"`   // Entries dropped by sampling
+}
+
+// Named returns the logger registered under name, created on first use with
+// a "logger" field and the level and sampling of logger.loggers.<name>. Its
+// level can be changed at runtime with SetLevel.
+func Named(name string) *ScopedLogger {
+	named.mu.Lock()
+	defer named.mu.Unlock()
+
+	if s, ok := named.loggers[name]; ok {
+		return s
+	}
+	s := StdLogger().Scoped(logrus.Fields{LoggerField: name})
+	// Configurations are checked when they are set
+	_ = applyNamed(s, named.configs[name])
+	named.loggers[name] = s
+	return s
+}
+
+// configureNamed sets the configuration of named loggers, applied to the
+// loggers already created and to those created later
+func configureNamed(configs map[string]*config.Named) error {
+	for name, c := range configs {
+		if err := checkNamed(c); err != nil {
+			return fmt.Errorf("logger %s: %w", name, err)
+		}
+	}
+
+	named.mu.Lock()
+	defer named.mu.Unlock()
+	named.configs = configs
+	for name, s := range named.loggers {
+		_ = applyNamed(s, configs[name])
+	}
+	return nil
+}
+
+// checkNamed validates the configuration of a named logger
+func checkNamed(c *config.Named) error {
+	if c == nil {
+		return nil
+	}
+	if c.Level != "" {
+		if _, err := logrus.ParseLevel(c.Level); err != nil {
+			return err
+		}
+	}
+	_, err := newSampler(c.Sampling)
+	return err
+}
+
+// applyNamed applies a configuration to a named logger
+func applyNamed(s *ScopedLogger, c *config.Named) error {
+	if c == nil {
+		return nil
+	}
+	if c.Level != "" {
+		level, err := logrus.ParseLevel(c.Level)
+		if err != nil {
+			return err
+		}
+		s.SetLevel(level)
+	}
+	return s.SetSampling(c.Sampling)
+}
+
+// SetLevel sets the level of the named logger, or of the global logger when
+// name is empty. A positive d makes the change temporary: the level in place
+// before the first temporary change is restored after d, e.g. to debug an
+// incident for 15 minutes.
+func SetLevel(name string, level logrus.Level, d time.Duration) error {
+	named.mu.Lock()
+	defer named.mu.Unlock()
+
+	var restore func()
+	if name == "" {
+		l := StdLogger()
+		prev := l.GetLevel()
+		restore = func() { l.SetLevel(prev) }
+		l.SetLevel(level)
+	} else {
+		s, ok := named.loggers[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownLogger, name)
+		}
+		prev := s.level.Load()
+		restore = func() { s.level.Store(prev) }
+		s.SetLevel(level)
+	}
+
+	if r := named.reverts[name]; r != nil {
+		r.timer.Stop()
+		delete(named.reverts, name)
+		restore = r.restore
+	}
+	if d > 0 {
+		r := &levelRevert{restore: restore, at: time.Now().Add(d)}
+		r.timer = time.AfterFunc(d, func() {
+			named.mu.Lock()
+			defer named.mu.Unlock()
+			if named.reverts[name] == r {
+				r.restore()
+				delete(named.reverts, name)
+			}
+		})
+		named.reverts[name] = r
+	}
+	return nil
+}
+
+// ResetLevel removes the level override of a named logger, which then follows
+// the global level, and cancels its temporary change
+func ResetLevel(name string) error {
+	named.mu.Lock()
+	defer named.mu.Unlock()
+
+	s, ok := named.loggers[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownLogger, name)
+	}
+	if r := named.reverts[name]; r != nil {
+		r.timer.Stop()
+		delete(named.reverts, name)
+	}
+	s.ResetLevel()
+	return nil
+}
+
+// Levels returns the level of the global logger followed by the named
+// loggers, sorted by name
+func Levels() []LevelInfo {
+	named.mu.Lock()
+	defer named.mu.Unlock()
+
+	l := StdLogger()
+	infos := []LevelInfo{{Level: l.GetLevel().String(), Dropped: dropped(l.sampler.Load())}}
+	names := make([]string, 0, len(named.loggers))
+	for name := range named.loggers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		s := named.loggers[name]
+		infos = append(infos, LevelInfo{
+			Logger:    name,
+			Level:     s.GetLevel().String(),
+			Inherited: s.level.Load() < 0,
+			Dropped:   dropped(s.sampler.Load()),
+		})
+	}
+	for i := range infos {
+		if r := named.reverts[infos[i].Logger]; r != nil {
+			at := r.at
+			infos[i].RevertAt = &at
+		}
+	}
+	return infos
+}
+
+// dropped returns the entries a sampler dropped, 0 for none
+func dropped(s *sampler) int64 {
+	if s == nil {
+		return 0
+	}
+	return s.dropped.Load()
+}
@@ -8,8 +8,10 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ncobase/ncore/bytespool"
 	"github.com/ncobase/ncore/logging/logger/config"
 	"github.com/sirupsen/logrus"
 )
@@ -28,9 +30,13 @@ type Logger struct {
 	logFile      *os.File
 	logPath      string
 	desensitizer *Desensitizer
+	sampler      atomic.Pointer[sampler]
+	sinks        []*sinkHook
 }
 
 var (
+	// bufferPool recycles the buffers entries are formatted into
+	bufferPool = bytespool.New("logging", 0)
 	// stdLogger is the global logger
 	stdLogger *Logger
 	// once ensures that the logger is initialized only once
@@ -44,6 +50,8 @@ func StdLogger() *Logger {
 			Logger: logrus.New(),
 		}
 		stdLogger.SetFormatter(&logrus.JSONFormatter{})
+		// Formatters write entries into buffers from the shared pool
+		stdLogger.SetBufferPool(bufferPool)
 	})
 	return stdLogger
 }
@@ -83,9 +91,21 @@ func (l *Logger) Init(c *config.Config) (func(), error) {
 		}
 	}
 
-	// Initialize desensitizer
+	// Initialize desensitizer, its hook runs before sinks and search hooks
 	if c.Desensitization != nil {
 		l.desensitizer = NewDesensitizer(c.Desensitization)
+		l.AddHook(desensitizeHook{l: l})
+	}
+
+	if err := l.SetSampling(c.Sampling); err != nil {
+		return nil, err
+	}
+	if err := configureNamed(c.Loggers); err != nil {
+		return nil, err
+	}
+
+	if err := l.initSinks(c); err != nil {
+		return nil, err
 	}
 
 	// Initialize search engine hooks (optional, requires driver imports)
@@ -95,6 +115,8 @@ func (l *Logger) Init(c *config.Config) (func(), error) {
 	}
 
 	return func() {
+		// Ship what is queued before the log file closes
+		l.closeSinks()
 		if l.logFile != nil {
 			_ = l.logFile.Close()
 		}
@@ -148,12 +170,19 @@ func (l *Logger) entryFromContext(ctx context.Context) *logrus.Entry {
 	if traceID != "" {
 		fields[traceKey] = traceID
 	}
+	if spanID := getSpanID(ctx); spanID != "" {
+		fields[spanKey] = spanID
+	}
+	if requestID := getRequestID(ctx); requestID != "" {
+		fields[requestKey] = requestID
+	}
 
 	if l.version != "" {
 		fields[VersionKey] = l.version
 	}
 
-	return l.WithFields(fields)
+	// The context stays on the entry for hooks, e.g. the user for Sentry
+	return l.WithFields(fields).WithContext(ctx)
 }
 
 // processFields applies desensitization to fields if enabled
@@ -164,16 +193,31 @@ func (l *Logger) processFields(fields logrus.Fields) logrus.Fields {
 	return fields
 }
 
+// EntryWithFields returns an entry with the fields of ctx and the given fields,
+// desensitized if enabled
+func (l *Logger) EntryWithFields(ctx context.Context, fields logrus.Fields) *logrus.Entry {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.entryFromContext(ctx).WithFields(l.processFields(fields))
+}
+
 // Log methods implementation below
 // -----------------------------
 
 // log logs a message with the given level
 func (l *Logger) log(ctx context.Context, level logrus.Level, args ...any) {
+	if !l.IsLevelEnabled(level) || !l.sampled(level, "", args) {
+		return
+	}
 	l.entryFromContext(ctx).Log(level, args...)
 }
 
 // logf logs a formatted message
 func (l *Logger) logf(ctx context.Context, level logrus.Level, format string, args ...any) {
+	if !l.IsLevelEnabled(level) || !l.sampled(level, format, args) {
+		return
+	}
 	l.entryFromContext(ctx).Logf(level, format, args...)
 }
 
@@ -288,9 +332,7 @@ func WithFields(ctx context.Context, fields logrus.Fields) *logrus.Entry {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	entry := StdLogger().entryFromContext(ctx)
-	processedFields := StdLogger().processFields(fields)
-	return entry.WithFields(processedFields)
+	return StdLogger().EntryWithFields(ctx, fields)
 }
 
 // Trace logs trace message
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+// sampler drops identical entries past the first of each tick, keyed by
+// level and message, the format for formatted entries
+type sampler struct {
+	level      logrus.Level
+	first      int64
+	thereafter int64
+	tick       int64
+
+	mu      sync.Mutex
+	window  int64 // Start of the current tick, in nanoseconds
+	counts  map[uint64]int64
+	seed    maphash.Seed
+	dropped atomic.Int64
+}
+
+// newSampler creates a sampler from its configuration, nil for none
+func newSampler(c *config.Sampling) (*sampler, error) {
+	if c == nil {
+		return nil, nil
+	}
+	level := logrus.DebugLevel
+	if c.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(c.Level); err != nil {
+			return nil, fmt.Errorf("invalid sampling level: %w", err)
+		}
+	}
+	if c.First < 0 || c.Thereafter < 0 {
+		return nil, fmt.Errorf("sampling first and thereafter must not be negative")
+	}
+	tick := c.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &sampler{
+		level:      level,
+		first:      int64(c.First),
+		thereafter: int64(c.Thereafter),
+		tick:       int64(tick),
+		counts:     make(map[uint64]int64),
+		seed:       maphash.MakeSeed(),
+	}, nil
+}
+
+// allow reports whether an entry is logged, counting the dropped ones
+func (s *sampler) allow(level logrus.Level, msg string) bool {
+	if level < s.level {
+		return true
+	}
+
+	var h maphash.Hash
+	h.SetSeed(s.seed)
+	_ = h.WriteByte(byte(level))
+	_, _ = h.WriteString(msg)
+	key := h.Sum64()
+
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	// Counts start over each tick, which also bounds the map to a tick of keys
+	if now-s.window >= s.tick {
+		s.window = now
+		clear(s.counts)
+	}
+	s.counts[key]++
+	n := s.counts[key]
+	s.mu.Unlock()
+
+	if n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0) {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// SetSampling samples identical entries of the logger, nil stops sampling
+func (l *Logger) SetSampling(c *config.Sampling) error {
+	s, err := newSampler(c)
+	if err != nil {
+		return err
+	}
+	l.sampler.Store(s)
+	return nil
+}
+
+// sampled reports whether an entry passes the logger's sampling. Formatted
+// entries are keyed by format, others by their message.
+func (l *Logger) sampled(level logrus.Level, format string, args []any) bool {
+	s := l.sampler.Load()
+	return s == nil || s.allow(level, sampleKey(format, args))
+}
+
+// sampleKey is the message identifying identical entries
+func sampleKey(format string, args []any) string {
+	if format != "" {
+		return format
+	}
+	return fmt.Sprint(args...)
+}
+
+// SetSampling samples identical entries of this logger and its children
+// separately from the parent, nil falls back to the parent's sampling
+func (s *ScopedLogger) SetSampling(c *config.Sampling) error {
+	sm, err := newSampler(c)
+	if err != nil {
+		return err
+	}
+	s.sampler.Store(sm)
+	return nil
+}
+
+// sampled reports whether an entry passes the sampling of the logger, or of
+// its parent when it has none
+func (s *ScopedLogger) sampled(level logrus.Level, format string, args []any) bool {
+	if sm := s.sampler.Load(); sm != nil {
+		return sm.allow(level, sampleKey(format, args))
+	}
+	return s.parent.sampled(level, format, args)
+}
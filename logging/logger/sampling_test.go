@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLogger(buf *bytes.Buffer) *Logger {
+	l := &Logger{Logger: logrus.New()}
+	l.SetOutput(buf)
+	l.SetLevel(logrus.DebugLevel)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	return l
+}
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	if err := l.SetSampling(&config.Sampling{First: 2, Thereafter: 5, Tick: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := range 12 {
+		l.Debugf(ctx, "cache miss %d", i)
+		l.Warn(ctx, "disk almost full")
+	}
+	l.Debug(ctx, "other line")
+
+	out := buf.String()
+	// First 2, then the 7th and 12th of the same format
+	if got := strings.Count(out, "cache miss"); got != 4 {
+		t.Errorf("logged %d cache misses, want 4:\n%s", got, out)
+	}
+	for _, want := range []string{"cache miss 0", "cache miss 1", "cache miss 6", "cache miss 11"} {
+		if !strings.Contains(out, want+"\"") {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if got := strings.Count(out, "disk almost full"); got != 12 {
+		t.Errorf("logged %d warnings, want all 12", got)
+	}
+	if !strings.Contains(out, "other line") {
+		t.Error("a different message was sampled with the others")
+	}
+	if got := dropped(l.sampler.Load()); got != 8 {
+		t.Errorf("dropped = %d, want 8", got)
+	}
+
+	if err := l.SetSampling(&config.Sampling{Level: "loud"}); err == nil {
+		t.Error("invalid sampling level accepted")
+	}
+}
+
+func TestScopedSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	s := l.Scoped(logrus.Fields{"component": "db"})
+	if err := s.SetSampling(&config.Sampling{First: 1, Tick: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for range 3 {
+		s.WithFields(logrus.Fields{"q": 1}).Debug(ctx, "slow query")
+		l.Debug(ctx, "slow query")
+	}
+	if got := strings.Count(buf.String(), "component=db"); got != 1 {
+		t.Errorf("scoped logger logged %d entries, want 1", got)
+	}
+	if got := strings.Count(buf.String(), "slow query"); got != 4 {
+		t.Errorf("logged %d entries, want 1 scoped and 3 unsampled", got)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	std := StdLogger()
+	defer std.SetLevel(std.GetLevel())
+	std.SetLevel(logrus.InfoLevel)
+
+	db := Named("test-db")
+	if err := SetLevel("test-db", logrus.TraceLevel, 0); err != nil {
+		t.Fatal(err)
+	}
+	if db.GetLevel() != logrus.TraceLevel {
+		t.Errorf("named level = %v, want trace", db.GetLevel())
+	}
+	if err := ResetLevel("test-db"); err != nil || db.GetLevel() != logrus.InfoLevel {
+		t.Errorf("reset level = %v (%v), want the global info", db.GetLevel(), err)
+	}
+
+	// Temporary changes revert to the level before the first of them
+	if err := SetLevel("", logrus.DebugLevel, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevel("", logrus.TraceLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	levels := Levels()
+	if levels[0].Level != "trace" || levels[0].RevertAt == nil {
+		t.Errorf("global level = %+v, want trace with a revert time", levels[0])
+	}
+	time.Sleep(100 * time.Millisecond)
+	if std.GetLevel() != logrus.InfoLevel {
+		t.Errorf("level after revert = %v, want info", std.GetLevel())
+	}
+
+	if err := SetLevel("missing", logrus.DebugLevel, 0); !errors.Is(err, ErrUnknownLogger) {
+		t.Errorf("SetLevel(missing) error = %v, want ErrUnknownLogger", err)
+	}
+}
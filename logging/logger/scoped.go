@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ScopedLogger is a logger with fixed fields, an optional level override and per-level counters
+type ScopedLogger struct {
+	parent *Logger
+	fields logrus.Fields
+	// level is the overridden level, -1 inherits the parent level
+	level   *atomic.Int32
+	counts  *[logrus.TraceLevel + 1]atomic.Int64
+	sampler *atomic.Pointer[sampler]
+}
+
+// Scoped returns a logger that adds the given fields to every entry
+func (l *Logger) Scoped(fields logrus.Fields) *ScopedLogger {
+	s := &ScopedLogger{
+		parent:  l,
+		fields:  l.processFields(fields),
+		level:   &atomic.Int32{},
+		counts:  &[logrus.TraceLevel + 1]atomic.Int64{},
+		sampler: &atomic.Pointer[sampler]{},
+	}
+	s.level.Store(-1)
+	return s
+}
+
+// ParseLevel parses a level name such as "debug" or "warn"
+func ParseLevel(level string) (logrus.Level, error) { return logrus.ParseLevel(level) }
+
+// NewScoped returns a scoped logger on the global logger
+func NewScoped(fields logrus.Fields) *ScopedLogger { return StdLogger().Scoped(fields) }
+
+// WithFields returns a child logger with additional fields.
+// The child shares the level override, counters and sampling of its parent.
+func (s *ScopedLogger) WithFields(fields logrus.Fields) *ScopedLogger {
+	merged := make(logrus.Fields, len(s.fields)+len(fields))
+	for k, v := range s.fields {
+		merged[k] = v
+	}
+	for k, v := range s.parent.processFields(fields) {
+		merged[k] = v
+	}
+	return &ScopedLogger{parent: s.parent, fields: merged, level: s.level, counts: s.counts, sampler: s.sampler}
+}
+
+// Fields returns a copy of the fixed fields
+func (s *ScopedLogger) Fields() logrus.Fields {
+	fields := make(logrus.Fields, len(s.fields))
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// SetLevel overrides the level of this logger
+func (s *ScopedLogger) SetLevel(level logrus.Level) {
+	s.level.Store(int32(level))
+}
+
+// ResetLevel removes the level override
+func (s *ScopedLogger) ResetLevel() {
+	s.level.Store(-1)
+}
+
+// GetLevel returns the effective level
+func (s *ScopedLogger) GetLevel() logrus.Level {
+	if level := s.level.Load(); level >= 0 {
+		return logrus.Level(level)
+	}
+	return s.parent.GetLevel()
+}
+
+// IsLevelEnabled reports whether entries of the level are logged
+func (s *ScopedLogger) IsLevelEnabled(level logrus.Level) bool {
+	return s.GetLevel() >= level
+}
+
+// Counts returns the number of logged entries per level
+func (s *ScopedLogger) Counts() map[string]int64 {
+	counts := make(map[string]int64, len(logrus.AllLevels))
+	for _, level := range logrus.AllLevels {
+		counts[level.String()] = s.counts[level].Load()
+	}
+	return counts
+}
+
+// entry creates a log entry with the scoped fields
+func (s *ScopedLogger) entry(ctx context.Context, level logrus.Level) *logrus.Entry {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	entry := s.parent.entryFromContext(ctx).WithFields(s.fields)
+
+	// The parent filters by its own level, use a detached logger for a more verbose override
+	if !s.parent.IsLevelEnabled(level) {
+		p := s.parent.Logger
+		entry.Logger = &logrus.Logger{
+			Out:          p.Out,
+			Hooks:        p.Hooks,
+			Formatter:    p.Formatter,
+			BufferPool:   p.BufferPool,
+			ReportCaller: p.ReportCaller,
+			ExitFunc:     p.ExitFunc,
+			Level:        s.GetLevel(),
+		}
+	}
+	return entry
+}
+
+// log logs a message with the given level
+func (s *ScopedLogger) log(ctx context.Context, level logrus.Level, args ...any) {
+	if !s.IsLevelEnabled(level) || !s.sampled(level, "", args) {
+		return
+	}
+	s.counts[level].Add(1)
+	s.entry(ctx, level).Log(level, args...)
+}
+
+// logf logs a formatted message
+func (s *ScopedLogger) logf(ctx context.Context, level logrus.Level, format string, args ...any) {
+	if !s.IsLevelEnabled(level) || !s.sampled(level, format, args) {
+		return
+	}
+	s.counts[level].Add(1)
+	s.entry(ctx, level).Logf(level, format, args...)
+}
+
+// Trace logs a trace message
+func (s *ScopedLogger) Trace(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.TraceLevel, args...)
+}
+
+// Debug logs a debug message
+func (s *ScopedLogger) Debug(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.DebugLevel, args...)
+}
+
+// Info logs an info message
+func (s *ScopedLogger) Info(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.InfoLevel, args...)
+}
+
+// Warn logs a warn message
+func (s *ScopedLogger) Warn(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.WarnLevel, args...)
+}
+
+// Error logs an error message
+func (s *ScopedLogger) Error(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.ErrorLevel, args...)
+}
+
+// Fatal logs a fatal message
+func (s *ScopedLogger) Fatal(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.FatalLevel, args...)
+}
+
+// Panic logs a panic message
+func (s *ScopedLogger) Panic(ctx context.Context, args ...any) {
+	s.log(ctx, logrus.PanicLevel, args...)
+}
+
+// Tracef logs a trace message with format
+func (s *ScopedLogger) Tracef(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.TraceLevel, format, args...)
+}
+
+// Debugf logs a debug message with format
+func (s *ScopedLogger) Debugf(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.DebugLevel, format, args...)
+}
+
+// Infof logs an info message with format
+func (s *ScopedLogger) Infof(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.InfoLevel, format, args...)
+}
+
+// Warnf logs a warn message with format
+func (s *ScopedLogger) Warnf(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.WarnLevel, format, args...)
+}
+
+// Errorf logs an error message with format
+func (s *ScopedLogger) Errorf(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.ErrorLevel, format, args...)
+}
+
+// Fatalf logs a fatal message with format
+func (s *ScopedLogger) Fatalf(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.FatalLevel, format, args...)
+}
+
+// Panicf logs a panic message with format
+func (s *ScopedLogger) Panicf(ctx context.Context, format string, args ...any) {
+	s.logf(ctx, logrus.PanicLevel, format, args...)
+}
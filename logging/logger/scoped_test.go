@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestScopedLoggerFieldsAndLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetLevel(logrus.InfoLevel)
+	ctx := context.Background()
+
+	s := l.Scoped(logrus.Fields{"extension": "notes"})
+	s.Debug(ctx, "hidden debug")
+	s.Info(ctx, "started")
+	if out := buf.String(); strings.Contains(out, "hidden debug") || !strings.Contains(out, "extension=notes") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	// A more verbose override than the parent still logs
+	s.SetLevel(logrus.DebugLevel)
+	s.Debugf(ctx, "query took %dms", 12)
+	if !strings.Contains(buf.String(), "query took 12ms") {
+		t.Fatalf("debug entry missing with a debug override:\n%s", buf.String())
+	}
+	if l.IsLevelEnabled(logrus.DebugLevel) {
+		t.Fatal("the override changed the parent level")
+	}
+
+	// Children share the override and the counters
+	child := s.WithFields(logrus.Fields{"request": "r1"})
+	child.Debug(ctx, "child debug")
+	if out := buf.String(); !strings.Contains(out, "child debug") || !strings.Contains(out, "request=r1") {
+		t.Fatalf("unexpected child output:\n%s", out)
+	}
+	if _, ok := s.Fields()["request"]; ok {
+		t.Fatal("child fields leaked into the parent")
+	}
+
+	s.SetLevel(logrus.WarnLevel)
+	child.Info(ctx, "quiet info")
+	child.Warn(ctx, "loud warning")
+	if out := buf.String(); strings.Contains(out, "quiet info") || !strings.Contains(out, "loud warning") {
+		t.Fatalf("a quieter override was not applied:\n%s", out)
+	}
+
+	s.ResetLevel()
+	if s.GetLevel() != logrus.InfoLevel {
+		t.Fatalf("level after reset = %v, want info", s.GetLevel())
+	}
+
+	counts := s.Counts()
+	if counts["info"] != 1 || counts["debug"] != 2 || counts["warning"] != 1 {
+		t.Fatalf("counts = %v", counts)
+	}
+}
@@ -0,0 +1,343 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+// SinkEntry is a log entry queued for a sink
+type SinkEntry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  map[string]any
+}
+
+// Sink ships batches of entries to a log aggregator
+type Sink interface {
+	// Write sends a batch of entries, in the order they were logged
+	Write(ctx context.Context, entries []*SinkEntry) error
+}
+
+// SinkFactory creates a sink from its configuration and the logger's
+type SinkFactory func(c *config.Sink, base *config.Config) (Sink, error)
+
+var (
+	sinkFactories = map[string]SinkFactory{
+		"loki":          newLokiSink,
+		"elasticsearch": newElasticsearchSink,
+	}
+	sinkMu sync.RWMutex
+)
+
+// RegisterSinkFactory registers the factory of a logger.sinks type
+func RegisterSinkFactory(sinkType string, factory SinkFactory) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	sinkFactories[sinkType] = factory
+}
+
+// SinkStats are the counters of a sink
+type SinkStats struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Queued    int    `json:"queued"`
+	Sent      int64  `json:"sent"`
+	Dropped   int64  `json:"dropped"` // Entries dropped because the buffer was full
+	Failed    int64  `json:"failed"`  // Entries of batches that failed after retries
+	LastError string `json:"last_error,omitempty"`
+}
+
+// sinkHook queues entries for a sink, which a goroutine writes in batches.
+// Logging never waits for the sink: a full buffer drops entries, or blocks
+// up to a timeout first.
+type sinkHook struct {
+	name, kind   string
+	sink         Sink
+	levels       []logrus.Level
+	queue        chan *SinkEntry
+	batchSize    int
+	interval     time.Duration
+	block        bool
+	blockTimeout time.Duration
+	timeout      time.Duration
+	retries      int
+
+	sent, dropped, failed atomic.Int64
+	lastError             atomic.Pointer[string]
+	closed                atomic.Bool
+	done                  chan struct{}
+	stopped               chan struct{}
+}
+
+// newSinkHook creates the sink of c and starts shipping its entries
+func newSinkHook(c *config.Sink, base *config.Config) (*sinkHook, error) {
+	sinkMu.RLock()
+	factory, ok := sinkFactories[c.Type]
+	sinkMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log sink type %q", c.Type)
+	}
+
+	level := logrus.InfoLevel
+	if c.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(c.Level); err != nil {
+			return nil, fmt.Errorf("invalid level of log sink %s: %w", c.Type, err)
+		}
+	}
+	switch c.Overflow {
+	case "", "drop", "block":
+	default:
+		return nil, fmt.Errorf("invalid overflow %q of log sink %s, want drop or block", c.Overflow, c.Type)
+	}
+
+	sink, err := factory(c, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log sink %s: %w", c.Type, err)
+	}
+
+	h := &sinkHook{
+		name:         c.Name,
+		kind:         c.Type,
+		sink:         sink,
+		levels:       slices.Clone(logrus.AllLevels[:level+1]),
+		queue:        make(chan *SinkEntry, positive(c.BufferSize, 10000)),
+		batchSize:    positive(c.BatchSize, 500),
+		interval:     positive(c.FlushInterval, time.Second),
+		block:        c.Overflow == "block",
+		blockTimeout: positive(c.BlockTimeout, 100*time.Millisecond),
+		timeout:      positive(c.Timeout, 10*time.Second),
+		retries:      positive(c.MaxRetries, 3),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	if h.name == "" {
+		h.name = c.Type
+	}
+	go h.run()
+	return h, nil
+}
+
+// Levels implements logrus.Hook
+func (h *sinkHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook, queueing a copy of the entry
+func (h *sinkHook) Fire(entry *logrus.Entry) error {
+	if h.closed.Load() {
+		h.dropped.Add(1)
+		return nil
+	}
+
+	e := &SinkEntry{Time: entry.Time, Level: entry.Level, Message: entry.Message, Fields: make(map[string]any, len(entry.Data))}
+	for k, v := range entry.Data {
+		// Errors marshal to {} otherwise
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		e.Fields[k] = v
+	}
+
+	select {
+	case h.queue <- e:
+		return nil
+	default:
+	}
+	if h.block {
+		timer := time.NewTimer(h.blockTimeout)
+		defer timer.Stop()
+		select {
+		case h.queue <- e:
+			return nil
+		case <-timer.C:
+		}
+	}
+	h.dropped.Add(1)
+	return nil
+}
+
+// run writes batches when they are full or every interval
+func (h *sinkHook) run() {
+	defer close(h.stopped)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	batch := make([]*SinkEntry, 0, h.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			h.write(batch)
+			batch = make([]*SinkEntry, 0, h.batchSize)
+		}
+	}
+
+	for {
+		select {
+		case e := <-h.queue:
+			batch = append(batch, e)
+			if len(batch) >= h.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-h.done:
+			for {
+				select {
+				case e := <-h.queue:
+					batch = append(batch, e)
+					if len(batch) >= h.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write sends a batch, retrying with backoff
+func (h *sinkHook) write(batch []*SinkEntry) {
+	var err error
+	for attempt := 0; attempt <= h.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(1<<(attempt-1)) * 100 * time.Millisecond):
+			case <-h.done:
+				// Shutting down, one last try without waiting
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		err = h.sink.Write(ctx, batch)
+		cancel()
+		if err == nil {
+			h.sent.Add(int64(len(batch)))
+			return
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			break
+		}
+	}
+	h.failed.Add(int64(len(batch)))
+	msg := err.Error()
+	h.lastError.Store(&msg)
+}
+
+// close stops queueing and waits for the queued entries to be written
+func (h *sinkHook) close() {
+	if h.closed.Swap(true) {
+		return
+	}
+	close(h.done)
+	<-h.stopped
+}
+
+// stats returns the counters of the sink
+func (h *sinkHook) stats() SinkStats {
+	s := SinkStats{
+		Name:    h.name,
+		Type:    h.kind,
+		Queued:  len(h.queue),
+		Sent:    h.sent.Load(),
+		Dropped: h.dropped.Load(),
+		Failed:  h.failed.Load(),
+	}
+	if msg := h.lastError.Load(); msg != nil {
+		s.LastError = *msg
+	}
+	return s
+}
+
+// initSinks starts the sinks of logger.sinks
+func (l *Logger) initSinks(c *config.Config) error {
+	for _, sc := range c.Sinks {
+		if sc == nil {
+			continue
+		}
+		h, err := newSinkHook(sc, c)
+		if err != nil {
+			l.closeSinks()
+			return err
+		}
+		l.sinks = append(l.sinks, h)
+		l.Logger.AddHook(h)
+	}
+	return nil
+}
+
+// closeSinks flushes and stops the sinks
+func (l *Logger) closeSinks() {
+	for _, h := range l.sinks {
+		h.close()
+	}
+}
+
+// GetSinkStats returns the counters of the logger's sinks, sorted by name
+func (l *Logger) GetSinkStats() []SinkStats {
+	stats := make([]SinkStats, len(l.sinks))
+	for i, h := range l.sinks {
+		stats[i] = h.stats()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// GetSinkStats returns the counters of the global logger's sinks
+func GetSinkStats() []SinkStats { return StdLogger().GetSinkStats() }
+
+// permanentError is a sink error retrying does not fix, e.g. a rejected request
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// checkResponse turns an unsuccessful response into an error, permanent for
+// client errors other than 429
+func checkResponse(res *http.Response, body []byte) error {
+	if res.StatusCode < 300 {
+		return nil
+	}
+	err := fmt.Errorf("%s: %s", res.Status, truncate(string(body), 512))
+	if res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// setHeaders sets the authentication and custom headers of a sink request
+func setHeaders(req *http.Request, c *config.Sink) {
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// positive returns v, or def when v is not positive
+func positive[T int | time.Duration](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+)
+
+// elasticsearchSink indexes entries with the Elasticsearch bulk API, which
+// OpenSearch serves as well
+type elasticsearchSink struct {
+	c      *config.Sink
+	base   *config.Config
+	url    string
+	client *http.Client
+}
+
+func newElasticsearchSink(c *config.Sink, base *config.Config) (Sink, error) {
+	if c.URL == "" {
+		return nil, errors.New("elasticsearch url is required")
+	}
+	if c.Index == "" && (base == nil || base.IndexName == "") {
+		return nil, errors.New("elasticsearch index is required")
+	}
+	return &elasticsearchSink{
+		c:      c,
+		base:   base,
+		url:    strings.TrimSuffix(c.URL, "/") + "/_bulk",
+		client: &http.Client{},
+	}, nil
+}
+
+// index returns the index of an entry
+func (s *elasticsearchSink) index(t time.Time) string {
+	if s.c.Index != "" {
+		return s.c.Index
+	}
+	return s.base.BuildIndexName(t)
+}
+
+// Write implements Sink. Documents are created, so data streams accept them.
+func (s *elasticsearchSink) Write(ctx context.Context, entries []*SinkEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		doc := make(map[string]any, len(e.Fields)+3)
+		maps.Copy(doc, e.Fields)
+		doc["@timestamp"] = e.Time.UTC().Format(time.RFC3339Nano)
+		doc["level"] = e.Level.String()
+		doc["message"] = e.Message
+
+		if err := enc.Encode(map[string]any{"create": map[string]string{"_index": s.index(e.Time)}}); err != nil {
+			return &permanentError{err}
+		}
+		if err := enc.Encode(doc); err != nil {
+			// Keep the entry, without the fields that do not marshal
+			_ = enc.Encode(map[string]any{"@timestamp": doc["@timestamp"], "level": doc["level"], "message": e.Message})
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &buf)
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	setHeaders(req, s.c)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(res, body); err != nil {
+		return err
+	}
+
+	// The bulk API answers 200 with per item errors
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || !result.Errors {
+		return nil
+	}
+	failed, first := 0, ""
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Error != nil {
+				if failed == 0 {
+					first = r.Error.Type + ": " + r.Error.Reason
+				}
+				failed++
+			}
+		}
+	}
+	// Retrying would duplicate the documents that were indexed
+	return &permanentError{fmt.Errorf("%d of %d entries rejected, first: %s", failed, len(entries), first)}
+}
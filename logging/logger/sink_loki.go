@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+)
+
+// lokiSink pushes entries to the Loki push API, one stream per level
+type lokiSink struct {
+	c      *config.Sink
+	url    string
+	client *http.Client
+}
+
+func newLokiSink(c *config.Sink, _ *config.Config) (Sink, error) {
+	if c.URL == "" {
+		return nil, errors.New("loki url is required")
+	}
+	url := strings.TrimSuffix(c.URL, "/")
+	if !strings.HasSuffix(url, "/loki/api/v1/push") {
+		url += "/loki/api/v1/push"
+	}
+	return &lokiSink{c: c, url: url, client: &http.Client{}}, nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Write implements Sink. Lines are the message and fields as JSON.
+func (s *lokiSink) Write(ctx context.Context, entries []*SinkEntry) error {
+	streams := make(map[string]*lokiStream)
+	for _, e := range entries {
+		level := e.Level.String()
+		st, ok := streams[level]
+		if !ok {
+			labels := make(map[string]string, len(s.c.Labels)+1)
+			maps.Copy(labels, s.c.Labels)
+			labels["level"] = level
+			st = &lokiStream{Stream: labels}
+			streams[level] = st
+		}
+
+		line := make(map[string]any, len(e.Fields)+1)
+		maps.Copy(line, e.Fields)
+		line["msg"] = e.Message
+		b, err := json.Marshal(line)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"msg": e.Message, "error": err.Error()})
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), string(b)})
+	}
+
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, level := range slices.Sorted(maps.Keys(streams)) {
+		push.Streams = append(push.Streams, streams[level])
+	}
+	body, err := json.Marshal(push)
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.c.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.c.TenantID)
+	}
+	setHeaders(req, s.c)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	return checkResponse(res, b)
+}
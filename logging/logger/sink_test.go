@@ -0,0 +1,160 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/logging/logger/config"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLokiSink(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushes []map[string]any
+		tenant string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var push map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&push)
+		mu.Lock()
+		pushes = append(pushes, push)
+		tenant = r.Header.Get("X-Scope-OrgID")
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	err := l.initSinks(&config.Config{Sinks: []*config.Sink{{
+		Type: "loki", URL: srv.URL, TenantID: "acme", Labels: map[string]string{"app": "api"},
+		Level: "warn", FlushInterval: time.Hour,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	l.Info(ctx, "not shipped")
+	l.entryFromContext(ctx).WithError(errors.New("disk full")).Warn("write failed")
+	l.Error(ctx, "request failed")
+	l.closeSinks()
+
+	if len(pushes) != 1 || tenant != "acme" {
+		t.Fatalf("got %d pushes for tenant %q, want 1 for acme", len(pushes), tenant)
+	}
+	streams := pushes[0]["streams"].([]any)
+	if len(streams) != 2 {
+		t.Fatalf("streams = %v, want error and warning", streams)
+	}
+	warn := streams[1].(map[string]any)
+	if labels := warn["stream"].(map[string]any); labels["level"] != "warning" || labels["app"] != "api" {
+		t.Errorf("labels = %v", labels)
+	}
+	line := warn["values"].([]any)[0].([]any)[1].(string)
+	if !strings.Contains(line, `"msg":"write failed"`) || !strings.Contains(line, `"error":"disk full"`) {
+		t.Errorf("line = %s", line)
+	}
+	if stats := l.GetSinkStats(); stats[0].Sent != 2 || stats[0].Dropped != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		_, _ = io.WriteString(w, `{"errors":true,"items":[{"create":{"status":201}},{"create":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad field"}}}]}`)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	err := l.initSinks(&config.Config{
+		IndexName: "app-logs", DateSuffix: "2006.01", RotateDaily: true,
+		Sinks: []*config.Sink{{Type: "elasticsearch", URL: srv.URL, FlushInterval: time.Hour}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	l.Info(ctx, "one")
+	l.Info(ctx, "two")
+	l.closeSinks()
+
+	if len(lines) != 4 || !strings.Contains(lines[0], `"_index":"app-logs-`+time.Now().Format("2006.01")+`"`) {
+		t.Fatalf("bulk body = %v", lines)
+	}
+	if !strings.Contains(lines[1], `"message":"one"`) || !strings.Contains(lines[1], `"level":"info"`) {
+		t.Errorf("document = %s", lines[1])
+	}
+	stats := l.GetSinkStats()[0]
+	if stats.Failed != 2 || !strings.Contains(stats.LastError, "1 of 2 entries rejected") {
+		t.Errorf("stats = %+v, want the rejected batch counted as failed", stats)
+	}
+}
+
+func TestSinkBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	err := l.initSinks(&config.Config{Sinks: []*config.Sink{{
+		Type: "loki", URL: srv.URL, BatchSize: 1, BufferSize: 2, FlushInterval: time.Hour,
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	for range 10 {
+		l.Info(ctx, "entry")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("logging waited for the sink")
+	}
+	close(release)
+	l.closeSinks()
+
+	stats := l.GetSinkStats()[0]
+	if stats.Dropped == 0 || stats.Sent+stats.Dropped != 10 {
+		t.Errorf("stats = %+v, want dropped entries and the rest sent", stats)
+	}
+}
+
+func TestSinkConfigErrors(t *testing.T) {
+	for _, c := range []*config.Sink{
+		{Type: "syslog"},
+		{Type: "loki"},
+		{Type: "loki", URL: "http://loki", Overflow: "wait"},
+		{Type: "elasticsearch", URL: "http://es"},
+	} {
+		l := &Logger{Logger: logrus.New()}
+		if err := l.initSinks(&config.Config{Sinks: []*config.Sink{c}}); err == nil {
+			t.Errorf("sink %+v accepted", c)
+		}
+	}
+}
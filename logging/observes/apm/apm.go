@@ -0,0 +1,103 @@
+// Package apm defines the agent abstraction behind New Relic and Elastic APM
+// adapters, which register themselves when imported.
+package apm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Type represents the type of an APM agent
+type Type string
+
+const (
+	NewRelic Type = "newrelic"
+	Elastic  Type = "elastic"
+)
+
+// Options configures an APM agent
+type Options struct {
+	Type        Type
+	Name        string
+	Version     string
+	Environment string
+	ServerURL   string  // Elastic APM server URL
+	LicenseKey  string  // New Relic license key
+	SecretToken string  // Elastic APM secret token
+	APIKey      string  // Elastic APM API key
+	SampleRate  float64 // 0.0 to 1.0, agents without sampling ignore it
+}
+
+// Agent records requests as transactions in an APM backend
+type Agent interface {
+	// StartTransaction starts a transaction for a request, the returned context carries
+	// the agent's native transaction for its own instrumentation
+	StartTransaction(ctx context.Context, name string, r *http.Request) (context.Context, Transaction)
+	// Shutdown flushes pending data and stops the agent
+	Shutdown(ctx context.Context) error
+}
+
+// Transaction is a unit of work recorded by an APM agent
+type Transaction interface {
+	SetName(name string)
+	SetStatus(code int)
+	NoticeError(err error)
+	End()
+}
+
+// Factory creates an agent from options
+type Factory func(opt *Options) (Agent, error)
+
+var (
+	factories = make(map[Type]Factory)
+	mu        sync.RWMutex
+)
+
+// RegisterFactory registers an APM agent factory for a given type.
+// This is called by agent packages in their init() functions.
+func RegisterFactory(agentType Type, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[agentType] = factory
+}
+
+// New creates the agent selected by opt.Type, nil if no type is set
+func New(opt *Options) (Agent, error) {
+	if opt == nil || opt.Type == "" {
+		return nil, nil
+	}
+
+	mu.RLock()
+	factory, ok := factories[opt.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("apm agent %s is not registered, import its package from github.com/ncobase/ncore/logging/observes", opt.Type)
+	}
+
+	return factory(opt)
+}
+
+type transactionKey struct{}
+
+// ContextWithTransaction returns a context carrying a transaction
+func ContextWithTransaction(ctx context.Context, tx Transaction) context.Context {
+	return context.WithValue(ctx, transactionKey{}, tx)
+}
+
+// TransactionFromContext returns the transaction carried by ctx, nil if none
+func TransactionFromContext(ctx context.Context) Transaction {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(transactionKey{}).(Transaction)
+	return tx
+}
+
+// NoticeError records an error on the transaction carried by ctx, if any
+func NoticeError(ctx context.Context, err error) {
+	if tx := TransactionFromContext(ctx); tx != nil && err != nil {
+		tx.NoticeError(err)
+	}
+}
@@ -0,0 +1,62 @@
+package apm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type nopAgent struct{ opt *Options }
+
+func (a *nopAgent) StartTransaction(ctx context.Context, _ string, _ *http.Request) (context.Context, Transaction) {
+	return ctx, &recordingTx{}
+}
+
+func (a *nopAgent) Shutdown(context.Context) error { return nil }
+
+type recordingTx struct{ errs []error }
+
+func (t *recordingTx) SetName(string)        {}
+func (t *recordingTx) SetStatus(int)         {}
+func (t *recordingTx) NoticeError(err error) { t.errs = append(t.errs, err) }
+func (t *recordingTx) End()                  {}
+
+func TestNewUsesRegisteredFactory(t *testing.T) {
+	RegisterFactory("nop", func(opt *Options) (Agent, error) { return &nopAgent{opt: opt}, nil })
+
+	agent, err := New(&Options{Type: "nop", Name: "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := agent.(*nopAgent); !ok || a.opt.Name != "orders" {
+		t.Fatalf("New() = %#v, want the agent of the nop factory", agent)
+	}
+
+	if agent, err := New(&Options{}); agent != nil || err != nil {
+		t.Fatalf("New() without a type = %v, %v, want nil, nil", agent, err)
+	}
+	if _, err := New(&Options{Type: Elastic}); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("New() of an unregistered type = %v", err)
+	}
+}
+
+func TestNoticeErrorUsesContextTransaction(t *testing.T) {
+	ctx := context.Background()
+	if TransactionFromContext(ctx) != nil || TransactionFromContext(nil) != nil {
+		t.Fatal("empty context carries a transaction")
+	}
+	NoticeError(ctx, errors.New("ignored without a transaction"))
+
+	tx := &recordingTx{}
+	ctx = ContextWithTransaction(ctx, tx)
+	if TransactionFromContext(ctx) != tx {
+		t.Fatal("transaction not carried by context")
+	}
+	NoticeError(ctx, nil)
+	NoticeError(ctx, errors.New("payment declined"))
+	if len(tx.errs) != 1 || tx.errs[0].Error() != "payment declined" {
+		t.Fatalf("noticed errors = %v", tx.errs)
+	}
+}
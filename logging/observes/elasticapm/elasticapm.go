@@ -0,0 +1,108 @@
+// Package elasticapm provides an Elastic APM agent for the apm package.
+package elasticapm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ncobase/ncore/logging/observes/apm"
+	"go.elastic.co/apm/module/apmhttp/v2"
+	elastic "go.elastic.co/apm/v2"
+	"go.elastic.co/apm/v2/transport"
+)
+
+func init() {
+	apm.RegisterFactory(apm.Elastic, New)
+}
+
+// Agent records transactions with the Elastic APM agent
+type Agent struct {
+	tracer *elastic.Tracer
+}
+
+// New creates an Elastic APM agent from options, unset options fall back to the
+// agent's ELASTIC_APM_* environment variables
+func New(opt *apm.Options) (apm.Agent, error) {
+	transportOpts := transport.HTTPTransportOptions{
+		SecretToken: opt.SecretToken,
+		APIKey:      opt.APIKey,
+	}
+	if opt.ServerURL != "" {
+		u, err := url.Parse(opt.ServerURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid elastic apm server url: %w", err)
+		}
+		transportOpts.ServerURLs = []*url.URL{u}
+	}
+
+	tr, err := transport.NewHTTPTransport(transportOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create elastic apm transport: %w", err)
+	}
+
+	tracer, err := elastic.NewTracerOptions(elastic.TracerOptions{
+		ServiceName:        opt.Name,
+		ServiceVersion:     opt.Version,
+		ServiceEnvironment: opt.Environment,
+		Transport:          tr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create elastic apm tracer: %w", err)
+	}
+	if opt.SampleRate > 0 && opt.SampleRate < 1 {
+		tracer.SetSampler(elastic.NewRatioSampler(opt.SampleRate))
+	}
+
+	return &Agent{tracer: tracer}, nil
+}
+
+// StartTransaction starts a request transaction for r, continuing the trace of its
+// traceparent header
+func (a *Agent) StartTransaction(ctx context.Context, name string, r *http.Request) (context.Context, apm.Transaction) {
+	var opts elastic.TransactionOptions
+	if r != nil {
+		if tc, err := apmhttp.ParseTraceparentHeader(r.Header.Get(apmhttp.W3CTraceparentHeader)); err == nil {
+			tc.State, _ = apmhttp.ParseTracestateHeader(r.Header[apmhttp.TracestateHeader]...)
+			opts.TraceContext = tc
+		}
+	}
+
+	tx := a.tracer.StartTransactionOptions(name, "request", opts)
+	if r != nil {
+		tx.Context.SetHTTPRequest(r)
+	}
+	return elastic.ContextWithTransaction(ctx, tx), &transaction{tracer: a.tracer, tx: tx}
+}
+
+// Shutdown flushes pending data until ctx is done and stops the agent
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.tracer.Flush(ctx.Done())
+	a.tracer.Close()
+	return nil
+}
+
+type transaction struct {
+	tracer *elastic.Tracer
+	tx     *elastic.Transaction
+}
+
+func (t *transaction) SetName(name string) {
+	t.tx.Name = name
+}
+
+func (t *transaction) SetStatus(code int) {
+	t.tx.Context.SetHTTPStatusCode(code)
+	t.tx.Result = apmhttp.StatusCodeResult(code)
+}
+
+func (t *transaction) NoticeError(err error) {
+	e := t.tracer.NewError(err)
+	e.SetTransaction(t.tx)
+	e.Send()
+}
+
+func (t *transaction) End() {
+	t.tx.End()
+}
@@ -0,0 +1,11 @@
+module github.com/ncobase/ncore/logging/observes/elasticapm
+
+go 1.25.3
+
+require (
+	github.com/ncobase/ncore/logging v0.2.2
+	go.elastic.co/apm/module/apmhttp/v2 v2.7.1
+	go.elastic.co/apm/v2 v2.7.1
+)
+
+replace github.com/ncobase/ncore/logging => ../../
@@ -0,0 +1,10 @@
+module github.com/ncobase/ncore/logging/observes/newrelic
+
+go 1.25.3
+
+require (
+	github.com/ncobase/ncore/logging v0.2.2
+	github.com/newrelic/go-agent/v3 v3.40.1
+)
+
+replace github.com/ncobase/ncore/logging => ../../
@@ -0,0 +1,88 @@
+// Package newrelic provides a New Relic agent for the apm package.
+package newrelic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ncobase/ncore/logging/observes/apm"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() {
+	apm.RegisterFactory(apm.NewRelic, New)
+}
+
+// Agent records transactions with the New Relic agent
+type Agent struct {
+	app *newrelic.Application
+}
+
+// New creates a New Relic agent from options
+func New(opt *apm.Options) (apm.Agent, error) {
+	if opt.LicenseKey == "" {
+		return nil, fmt.Errorf("new relic license key is required")
+	}
+
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName(opt.Name),
+		newrelic.ConfigLicense(opt.LicenseKey),
+		newrelic.ConfigDistributedTracerEnabled(true),
+		func(cfg *newrelic.Config) {
+			cfg.Labels = map[string]string{}
+			if opt.Environment != "" {
+				cfg.Labels["environment"] = opt.Environment
+			}
+			if opt.Version != "" {
+				cfg.Labels["version"] = opt.Version
+			}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new relic application: %w", err)
+	}
+
+	return &Agent{app: app}, nil
+}
+
+// StartTransaction starts a web transaction for r
+func (a *Agent) StartTransaction(ctx context.Context, name string, r *http.Request) (context.Context, apm.Transaction) {
+	txn := a.app.StartTransaction(name)
+	if r != nil {
+		txn.SetWebRequestHTTP(r)
+	}
+	return newrelic.NewContext(ctx, txn), &transaction{txn: txn}
+}
+
+// Shutdown flushes pending data within ctx's deadline, 10s without one
+func (a *Agent) Shutdown(ctx context.Context) error {
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	a.app.Shutdown(timeout)
+	return nil
+}
+
+type transaction struct {
+	txn *newrelic.Transaction
+}
+
+func (t *transaction) SetName(name string) {
+	t.txn.SetName(name)
+}
+
+// SetStatus records the response status without writing a response
+func (t *transaction) SetStatus(code int) {
+	t.txn.SetWebResponse(nil).WriteHeader(code)
+}
+
+func (t *transaction) NoticeError(err error) {
+	t.txn.NoticeError(err)
+}
+
+func (t *transaction) End() {
+	t.txn.End()
+}
@@ -1,7 +1,13 @@
 package observes
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/getsentry/sentry-go"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/sirupsen/logrus"
 )
 
 type SentryOptions struct {
@@ -9,6 +15,7 @@ type SentryOptions struct {
 	Name        string
 	Release     string
 	Environment string
+	SampleRate  float64 // Share of error events sent, 0 sends all
 }
 
 // NewSentry is the register sentry
@@ -21,6 +28,7 @@ func NewSentry(opt *SentryOptions) error {
 	return sentry.Init(sentry.ClientOptions{
 		Dsn:              opt.Dsn,
 		AttachStacktrace: true,
+		SampleRate:       opt.SampleRate,
 		// Set TracesSampleRate to 1.0 to capture 100%
 		// of transactions for performance monitoring.
 		// We recommend adjusting this value in production,
@@ -30,3 +38,122 @@ func NewSentry(opt *SentryOptions) error {
 		Environment:      opt.Environment,
 	})
 }
+
+// FlushSentry waits up to timeout for queued events to be sent
+func FlushSentry(timeout time.Duration) bool {
+	return sentry.Flush(timeout)
+}
+
+// SentryReporter sends server errors and recovered panics to Sentry, it
+// implements resp.ErrorReporter:
+//
+//	resp.SetErrorReporter(observes.SentryReporter{})
+type SentryReporter struct{}
+
+// ReportError captures err with the user and trace of ctx
+func (SentryReporter) ReportError(ctx context.Context, err error) {
+	sentryHub(ctx).CaptureException(err)
+}
+
+// ReportPanic captures a recovered panic with the user and trace of ctx
+func (SentryReporter) ReportPanic(ctx context.Context, recovered any) {
+	sentryHub(ctx).RecoverWithContext(ctx, recovered)
+}
+
+// SentryHook is a logrus hook sending entries at or above its level to
+// Sentry, with the user and trace of the entry context
+type SentryHook struct {
+	levels []logrus.Level
+}
+
+// NewSentryHook creates a hook for entries at level or above, e.g. "error"
+func NewSentryHook(level string) (*SentryHook, error) {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry log level: %w", err)
+	}
+	levels := make([]logrus.Level, 0, lvl+1)
+	for _, l := range logrus.AllLevels {
+		if l <= lvl {
+			levels = append(levels, l)
+		}
+	}
+	return &SentryHook{levels: levels}, nil
+}
+
+// Levels implements logrus.Hook
+func (h *SentryHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook
+func (h *SentryHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hub := sentryHub(ctx)
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentryLevel(entry.Level))
+		for k, v := range entry.Data {
+			if k != logrus.ErrorKey {
+				scope.SetExtra(k, v)
+			}
+		}
+		if err, ok := entry.Data[logrus.ErrorKey].(error); ok {
+			scope.SetExtra("message", entry.Message)
+			hub.CaptureException(err)
+			return
+		}
+		hub.CaptureMessage(entry.Message)
+	})
+	return nil
+}
+
+// sentryHub returns a hub for ctx, scoped to its user and trace
+func sentryHub(ctx context.Context) *sentry.Hub {
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub = hub.Clone()
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		user := sentry.User{
+			ID:        ctxutil.GetUserID(ctx),
+			Username:  ctxutil.GetUsername(ctx),
+			Email:     ctxutil.GetUserEmail(ctx),
+			IPAddress: ctxutil.GetClientIP(ctx),
+		}
+		if !user.IsEmpty() {
+			scope.SetUser(user)
+		}
+		for tag, value := range map[string]string{
+			"trace_id":   ctxutil.GetTraceID(ctx),
+			"span_id":    ctxutil.GetSpanID(ctx),
+			"request_id": ctxutil.GetRequestID(ctx),
+			"space_id":   ctxutil.GetSpaceID(ctx),
+		} {
+			if value != "" {
+				scope.SetTag(tag, value)
+			}
+		}
+	})
+	return hub
+}
+
+// sentryLevel maps a logrus level to the Sentry level
+func sentryLevel(level logrus.Level) sentry.Level {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return sentry.LevelFatal
+	case logrus.ErrorLevel:
+		return sentry.LevelError
+	case logrus.WarnLevel:
+		return sentry.LevelWarning
+	case logrus.InfoLevel:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
@@ -2,21 +2,29 @@ package observes
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 )
 
+// TracerOption configures the tracer provider
 type TracerOption struct {
 	URL                string
 	Name               string
@@ -29,18 +37,53 @@ type TracerOption struct {
 	BatchTimeout       time.Duration
 	ExportTimeout      time.Duration
 	MaxExportBatchSize int
+
+	// Exporter is where spans go: "otlp" over gRPC (the default), "otlphttp",
+	// "stdout" or "none"
+	Exporter string
+	// Sampler is one of the OTEL_TRACES_SAMPLER values: "always_on",
+	// "always_off", "traceidratio", "parentbased_always_on",
+	// "parentbased_always_off" or "parentbased_traceidratio" (the default).
+	// The ratio is SamplingRate.
+	Sampler string
+
+	MaxQueueSize       int
+	MaxAttributeLength int
+	MaxEventsPerSpan   int
+	MaxLinksPerSpan    int
+
+	// Headers are sent with each export, e.g. an API key
+	Headers map[string]string
+
+	// TLS of the OTLP exporters, plain text unless TLSEnabled
+	TLSEnabled         bool
+	InsecureSkipVerify bool
+	TLSCertFile        string
+	TLSKeyFile         string
+	TLSCAFile          string
 }
 
+var (
+	providerMu sync.Mutex
+	provider   *sdktrace.TracerProvider
+)
+
+// NewTracer sets up the global tracer provider and the W3C trace context
+// propagator. Spans are exported in batches, flush them with ShutdownTracer.
 func NewTracer(opt *TracerOption) error {
 	if opt == nil {
 		return fmt.Errorf("tracer config is nil")
 	}
+	if opt.Exporter == "none" {
+		return nil
+	}
 
-	exp, err := otlptracegrpc.New(
-		context.Background(),
-		otlptracegrpc.WithEndpoint(opt.URL),
-		otlptracegrpc.WithInsecure(),
-	)
+	sampler, err := newSampler(opt.Sampler, opt.SamplingRate)
+	if err != nil {
+		return err
+	}
+
+	exp, err := newExporter(opt)
 	if err != nil {
 		return fmt.Errorf("failed to create exporter: %w", err)
 	}
@@ -59,21 +102,146 @@ func NewTracer(opt *TracerOption) error {
 		return fmt.Errorf("failed to create resource: %w", err)
 	}
 
+	batch := []sdktrace.BatchSpanProcessorOption{
+		sdktrace.WithMaxExportBatchSize(opt.MaxExportBatchSize),
+		sdktrace.WithBatchTimeout(opt.BatchTimeout),
+		sdktrace.WithExportTimeout(opt.ExportTimeout),
+	}
+	if opt.MaxQueueSize > 0 {
+		batch = append(batch, sdktrace.WithMaxQueueSize(opt.MaxQueueSize))
+	}
+
+	limits := sdktrace.NewSpanLimits()
+	if opt.MaxAttributes > 0 {
+		limits.AttributeCountLimit = opt.MaxAttributes
+	}
+	if opt.MaxAttributeLength > 0 {
+		limits.AttributeValueLengthLimit = opt.MaxAttributeLength
+	}
+	if opt.MaxEventsPerSpan > 0 {
+		limits.EventCountLimit = opt.MaxEventsPerSpan
+	}
+	if opt.MaxLinksPerSpan > 0 {
+		limits.LinkCountLimit = opt.MaxLinksPerSpan
+	}
+
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opt.SamplingRate))),
-		sdktrace.WithBatcher(exp,
-			sdktrace.WithMaxExportBatchSize(opt.MaxExportBatchSize),
-			sdktrace.WithBatchTimeout(opt.BatchTimeout),
-			sdktrace.WithExportTimeout(opt.ExportTimeout),
-		),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithBatcher(exp, batch...),
 		sdktrace.WithResource(res),
+		sdktrace.WithRawSpanLimits(limits),
 	)
 
+	providerMu.Lock()
+	prev := provider
+	provider = tp
+	providerMu.Unlock()
+	if prev != nil {
+		_ = prev.Shutdown(context.Background())
+	}
+
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	return nil
 }
 
+// ShutdownTracer exports the spans still queued and stops the provider set
+// up by NewTracer
+func ShutdownTracer(ctx context.Context) error {
+	providerMu.Lock()
+	tp := provider
+	provider = nil
+	providerMu.Unlock()
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
+
+// newSampler creates a sampler from its OTEL_TRACES_SAMPLER name
+func newSampler(name string, ratio float64) (sdktrace.Sampler, error) {
+	switch name {
+	case "", "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	default:
+		return nil, fmt.Errorf("unknown sampler %q", name)
+	}
+}
+
+// newExporter creates the span exporter selected by opt.Exporter
+func newExporter(opt *TracerOption) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+	switch opt.Exporter {
+	case "", "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opt.URL)}
+		if len(opt.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(opt.Headers))
+		}
+		if opt.TLSEnabled {
+			tlsConfig, err := opt.tlsConfig()
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case "otlphttp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opt.URL)}
+		if len(opt.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(opt.Headers))
+		}
+		if opt.TLSEnabled {
+			tlsConfig, err := opt.tlsConfig()
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", opt.Exporter)
+	}
+}
+
+// tlsConfig loads the CA and client certificate of the OTLP exporters
+func (opt *TracerOption) tlsConfig() (*tls.Config, error) {
+	c := &tls.Config{InsecureSkipVerify: opt.InsecureSkipVerify}
+	if opt.TLSCAFile != "" {
+		pem, err := os.ReadFile(opt.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opt.TLSCAFile)
+		}
+	}
+	if opt.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opt.TLSCertFile, opt.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
 type Layer int
 
 const (
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// APNsConfig holds the configuration for the Apple Push Notification service,
+// authenticated with a token signing key
+type APNsConfig struct {
+	KeyID      string `json:"key_id" yaml:"key_id"`
+	TeamID     string `json:"team_id" yaml:"team_id"`
+	Topic      string `json:"topic" yaml:"topic"`             // App bundle ID
+	KeyFile    string `json:"key_file" yaml:"key_file"`       // Path of the .p8 key, or
+	PrivateKey string `json:"private_key" yaml:"private_key"` // its PEM content
+	Production bool   `json:"production" yaml:"production"`   // Use the production environment instead of the sandbox
+	Endpoint   string `json:"endpoint" yaml:"endpoint"`       // For tests, overrides the environment
+}
+
+// apnsTokenTTL is how long a provider token is reused; Apple rejects tokens
+// older than an hour and refreshed more often than every 20 minutes
+const apnsTokenTTL = 50 * time.Minute
+
+// APNsProvider sends push notifications to Apple devices
+type APNsProvider struct {
+	Config *APNsConfig
+	// Client must speak HTTP/2, which the default transport negotiates
+	Client *http.Client
+
+	key crypto.Signer
+
+	mu     sync.Mutex
+	token  string
+	issued time.Time
+}
+
+// NewAPNsProvider creates an APNsProvider
+func NewAPNsProvider(config *APNsConfig) (*APNsProvider, error) {
+	if err := validateAPNsConfig(config); err != nil {
+		return nil, err
+	}
+	data := []byte(config.PrivateKey)
+	if config.KeyFile != "" {
+		var err error
+		if data, err = os.ReadFile(config.KeyFile); err != nil {
+			return nil, fmt.Errorf("apns: read key: %w", err)
+		}
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("apns: %w", err)
+	}
+	return &APNsProvider{Config: config, Client: &http.Client{Timeout: 10 * time.Second}, key: key}, nil
+}
+
+// Name implements Provider
+func (p *APNsProvider) Name() string { return "apns" }
+
+// Channel implements Provider
+func (p *APNsProvider) Channel() Channel { return ChannelPush }
+
+// Platforms implements PlatformProvider
+func (p *APNsProvider) Platforms() []string { return []string{"ios"} }
+
+// Send implements Provider
+func (p *APNsProvider) Send(ctx context.Context, msg *Message) (string, error) {
+	token, err := p.providerToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Params ride along as custom keys next to aps
+	payload := make(map[string]any, len(msg.Params)+1)
+	for k, v := range msg.Params {
+		payload[k] = v
+	}
+	payload["aps"] = map[string]any{
+		"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+		"sound": "default",
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := p.Config.Endpoint
+	switch {
+	case endpoint != "":
+	case p.Config.Production:
+		endpoint = "https://api.push.apple.com"
+	default:
+		endpoint = "https://api.sandbox.push.apple.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/3/device/"+url.PathEscape(msg.To), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", p.Config.Topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("apns: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return res.Header.Get("apns-id"), nil
+	}
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&result)
+	err = fmt.Errorf("apns: %d %s", res.StatusCode, result.Reason)
+	switch {
+	case res.StatusCode == http.StatusGone, result.Reason == "BadDeviceToken", result.Reason == "DeviceTokenNotForTopic":
+		return "", errors.Join(ErrInvalidAddress, err)
+	case result.Reason == "ExpiredProviderToken":
+		p.mu.Lock()
+		p.token = ""
+		p.mu.Unlock()
+	}
+	return "", err
+}
+
+// providerToken returns the ES256 provider token, signing a new one every apnsTokenTTL
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Since(p.issued) < apnsTokenTTL {
+		return p.token, nil
+	}
+	now := time.Now()
+	token, err := signJWT(p.key,
+		map[string]any{"alg": "ES256", "kid": p.Config.KeyID},
+		map[string]any{"iss": p.Config.TeamID, "iat": now.Unix()})
+	if err != nil {
+		return "", fmt.Errorf("apns: sign provider token: %w", err)
+	}
+	p.token, p.issued = token, now
+	return token, nil
+}
+
+func validateAPNsConfig(config *APNsConfig) error {
+	if config == nil || config.KeyID == "" || config.TeamID == "" || config.Topic == "" {
+		return errors.New("invalid APNs configuration")
+	}
+	if config.KeyFile == "" && config.PrivateKey == "" {
+		return errors.New("invalid APNs configuration: key_file or private_key is required")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Package callbacks serves the delivery reports of notify providers over gin.
+// It is apart from notify as net/resp depends on config, which imports notify.
+package callbacks
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/messaging/notify"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// RegisterRoutes mounts the delivery report callbacks of n's providers:
+//
+//	POST /:provider    delivery report in the provider's format
+//
+// Providers authenticate their callbacks and answer in their own format,
+// unknown providers get a 404.
+func RegisterRoutes(g *gin.RouterGroup, n *notify.Notifier) {
+	g.POST("/:provider", func(c *gin.Context) {
+		if err := n.HandleCallback(c.Param("provider"), c.Writer, c.Request); err != nil {
+			resp.Fail(c.Writer, resp.NotFound(err.Error()))
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package callbacks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/messaging/notify"
+)
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p, err := notify.NewAliyunSMSProvider(&notify.AliyunSMSConfig{AccessKeyID: "id", AccessKeySecret: "secret", SignName: "ncore", CallbackToken: "tok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := notify.New(notify.Options{}, p)
+	var got []notify.Receipt
+	n.OnStatus(func(_ context.Context, r notify.Receipt) { got = append(got, r) })
+
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/notify/callbacks"), n)
+	post := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := `[{"phone_number":"13800138000","success":true,"biz_id":"b1","report_time":"2026-01-02 03:04:05"}]`
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	// The provider answers in its own format
+	if w := post("/notify/callbacks/aliyun?token=tok"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"code":0`) {
+		t.Fatalf("aliyun callback = %d %s", w.Code, w.Body)
+	}
+	if len(got) != 1 || got[0].ID != "b1" || got[0].Status != notify.StatusDelivered {
+		t.Fatalf("receipts = %+v", got)
+	}
+
+	if w := post("/notify/callbacks/twilio"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown provider status = %d", w.Code)
+	}
+	if w := post("/notify/callbacks/aliyun"); w.Code != http.StatusForbidden {
+		t.Fatalf("callback without token status = %d", w.Code)
+	}
+}
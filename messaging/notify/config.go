@@ -0,0 +1,73 @@
+package notify
+
+// Config holds the configuration of the notification providers, a nil
+// provider configuration leaves the provider out
+type Config struct {
+	Twilio    *TwilioConfig       `json:"twilio" yaml:"twilio"`
+	AliyunSMS *AliyunSMSConfig    `json:"aliyun_sms" yaml:"aliyun_sms"`
+	FCM       *FCMConfig          `json:"fcm" yaml:"fcm"`
+	APNs      *APNsConfig         `json:"apns" yaml:"apns"`
+	Limits    map[Channel]Limit   `json:"limits" yaml:"limits"`
+	Templates map[string]Template `json:"templates" yaml:"templates"`
+	Rules     []Rule              `json:"rules" yaml:"rules"`
+}
+
+// NewProviders creates the configured providers. APNs comes before FCM, so
+// iOS devices go to APNs when both are configured.
+func NewProviders(cfg *Config) ([]Provider, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	var providers []Provider
+	if cfg.Twilio != nil {
+		p, err := NewTwilioProvider(cfg.Twilio)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	if cfg.AliyunSMS != nil {
+		p, err := NewAliyunSMSProvider(cfg.AliyunSMS)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	if cfg.APNs != nil {
+		p, err := NewAPNsProvider(cfg.APNs)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	if cfg.FCM != nil {
+		p, err := NewFCMProvider(cfg.FCM)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// NewFromConfig creates a Notifier with the configured providers, limits,
+// templates and routing rules
+func NewFromConfig(cfg *Config, directory Directory) (*Notifier, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	providers, err := NewProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	n := New(Options{Directory: directory, Limits: cfg.Limits}, providers...)
+	for name, t := range cfg.Templates {
+		if err := n.AddTemplate(name, t); err != nil {
+			return nil, err
+		}
+	}
+	if err := n.SetRules(cfg.Rules); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnknownUser is returned by a Directory for users it has no addresses for
+var ErrUnknownUser = errors.New("notify: unknown user")
+
+// Device is a push notification target
+type Device struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`           // ios, android or web
+	Provider string `json:"provider,omitempty"` // Pins a push provider by name, e.g. fcm for an iOS app using Firebase
+}
+
+// Recipient holds the addresses and channel preference of a user
+type Recipient struct {
+	UserID  string   `json:"user_id"`
+	Phone   string   `json:"phone,omitempty"` // E.164, e.g. +8613800138000
+	Email   string   `json:"email,omitempty"`
+	Devices []Device `json:"devices,omitempty"`
+	// Channels is the preferred channel order, channels not listed are not
+	// used. Empty means push, sms, then email.
+	Channels []Channel `json:"channels,omitempty"`
+	// Preferences replaces Channels for notifications routed from an event
+	// type by rules, an empty list mutes the event
+	Preferences map[string][]Channel `json:"preferences,omitempty"`
+}
+
+// reachable reports whether r has an address for ch
+func (r *Recipient) reachable(ch Channel) bool {
+	switch ch {
+	case ChannelSMS:
+		return r.Phone != ""
+	case ChannelEmail:
+		return r.Email != ""
+	case ChannelPush:
+		return len(r.Devices) > 0
+	}
+	return false
+}
+
+// Directory resolves users to their addresses and preferences, usually backed
+// by the user and device tables of the application
+type Directory interface {
+	Recipient(ctx context.Context, userID string) (*Recipient, error)
+}
+
+// MemoryDirectory is an in-memory Directory
+type MemoryDirectory struct {
+	mu         sync.RWMutex
+	recipients map[string]*Recipient
+}
+
+// NewMemoryDirectory creates an empty MemoryDirectory
+func NewMemoryDirectory() *MemoryDirectory {
+	return &MemoryDirectory{recipients: make(map[string]*Recipient)}
+}
+
+// Set stores the addresses of a user
+func (d *MemoryDirectory) Set(r *Recipient) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recipients[r.UserID] = r
+}
+
+// Delete removes a user
+func (d *MemoryDirectory) Delete(userID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.recipients, userID)
+}
+
+// Recipient implements Directory
+func (d *MemoryDirectory) Recipient(_ context.Context, userID string) (*Recipient, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	r, ok := d.recipients[userID]
+	if !ok {
+		return nil, ErrUnknownUser
+	}
+	return r, nil
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/ncobase/ncore/messaging/email"
+)
+
+// EmailProvider sends the email channel through an email.Sender, so the
+// providers of the email package serve notifications too
+type EmailProvider struct {
+	Sender email.Sender
+}
+
+// NewEmailProvider creates an EmailProvider
+func NewEmailProvider(sender email.Sender) *EmailProvider {
+	return &EmailProvider{Sender: sender}
+}
+
+// Name implements Provider
+func (p *EmailProvider) Name() string { return "email" }
+
+// Channel implements Provider
+func (p *EmailProvider) Channel() Channel { return ChannelEmail }
+
+// Send implements Provider. The rendered body is passed to the email template
+// as "body" next to the notification data.
+func (p *EmailProvider) Send(ctx context.Context, msg *Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	data := make(map[string]string, len(msg.Params)+1)
+	for k, v := range msg.Params {
+		data[k] = v
+	}
+	data["body"] = msg.Body
+	return p.Sender.SendTemplateEmail(msg.To, email.Template{
+		Subject:  msg.Title,
+		Template: msg.Template,
+		Data:     data,
+	})
+}
@@ -0,0 +1,226 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FCMConfig holds the configuration for Firebase Cloud Messaging (HTTP v1)
+type FCMConfig struct {
+	// CredentialsFile is the path of a service account key JSON file, or
+	CredentialsFile string `json:"credentials_file" yaml:"credentials_file"`
+	// CredentialsJSON is its content
+	CredentialsJSON string `json:"credentials_json" yaml:"credentials_json"`
+	// ProjectID defaults to the project of the service account
+	ProjectID string   `json:"project_id" yaml:"project_id"`
+	Platforms []string `json:"platforms" yaml:"platforms"` // Device platforms served, defaults to android and web
+	Endpoint  string   `json:"endpoint" yaml:"endpoint"`   // For tests, defaults to https://fcm.googleapis.com
+}
+
+// fcmScope is the OAuth scope of the FCM send API
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// serviceAccount is the part of a Google service account key used here
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	ProjectID   string `json:"project_id"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMProvider sends push notifications through Firebase Cloud Messaging
+type FCMProvider struct {
+	Config *FCMConfig
+	Client *http.Client
+
+	account   serviceAccount
+	key       crypto.Signer
+	projectID string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewFCMProvider creates an FCMProvider
+func NewFCMProvider(config *FCMConfig) (*FCMProvider, error) {
+	if err := validateFCMConfig(config); err != nil {
+		return nil, err
+	}
+	data := []byte(config.CredentialsJSON)
+	if config.CredentialsFile != "" {
+		var err error
+		if data, err = os.ReadFile(config.CredentialsFile); err != nil {
+			return nil, fmt.Errorf("fcm: read credentials: %w", err)
+		}
+	}
+	p := &FCMProvider{Config: config, Client: &http.Client{Timeout: 10 * time.Second}}
+	if err := json.Unmarshal(data, &p.account); err != nil {
+		return nil, fmt.Errorf("fcm: parse credentials: %w", err)
+	}
+	key, err := parsePrivateKey([]byte(p.account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("fcm: %w", err)
+	}
+	p.key = key
+	if p.account.TokenURI == "" {
+		p.account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	p.projectID = config.ProjectID
+	if p.projectID == "" {
+		p.projectID = p.account.ProjectID
+	}
+	if p.projectID == "" {
+		return nil, errors.New("fcm: project_id is required")
+	}
+	return p, nil
+}
+
+// Name implements Provider
+func (p *FCMProvider) Name() string { return "fcm" }
+
+// Channel implements Provider
+func (p *FCMProvider) Channel() Channel { return ChannelPush }
+
+// Platforms implements PlatformProvider
+func (p *FCMProvider) Platforms() []string {
+	if len(p.Config.Platforms) > 0 {
+		return p.Config.Platforms
+	}
+	return []string{"android", "web"}
+}
+
+// Send implements Provider
+func (p *FCMProvider) Send(ctx context.Context, msg *Message) (string, error) {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]any{
+		"message": map[string]any{
+			"token":        msg.To,
+			"notification": map[string]string{"title": msg.Title, "body": msg.Body},
+			"data":         msg.Params,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := p.Config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://fcm.googleapis.com"
+	}
+	endpoint = fmt.Sprintf("%s/v1/projects/%s/messages:send", endpoint, url.PathEscape(p.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm: %w", err)
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Name  string `json:"name"`
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("fcm: status %d: %w", res.StatusCode, err)
+	}
+	if res.StatusCode >= 300 {
+		err := fmt.Errorf("fcm: %s %s", result.Error.Status, result.Error.Message)
+		for _, d := range result.Error.Details {
+			if d.ErrorCode == "UNREGISTERED" {
+				return "", errors.Join(ErrInvalidAddress, err)
+			}
+		}
+		return "", err
+	}
+	// projects/{project}/messages/{id}
+	return result.Name[strings.LastIndexByte(result.Name, '/')+1:], nil
+}
+
+// accessToken returns a cached OAuth token, exchanging a signed JWT for a new
+// one shortly before it expires
+func (p *FCMProvider) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Until(p.expires) > time.Minute {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(p.key,
+		map[string]any{"alg": "RS256", "typ": "JWT"},
+		map[string]any{
+			"iss":   p.account.ClientEmail,
+			"scope": fcmScope,
+			"aud":   p.account.TokenURI,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		})
+	if err != nil {
+		return "", fmt.Errorf("fcm: sign token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm: token: %w", err)
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("fcm: token: status %d: %w", res.StatusCode, err)
+	}
+	if res.StatusCode >= 300 || result.AccessToken == "" {
+		return "", fmt.Errorf("fcm: token: status %d %s", res.StatusCode, result.Error)
+	}
+	p.token = result.AccessToken
+	p.expires = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return p.token, nil
+}
+
+func validateFCMConfig(config *FCMConfig) error {
+	if config == nil || (config.CredentialsFile == "" && config.CredentialsJSON == "") {
+		return errors.New("invalid FCM configuration")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit caps the messages sent on a channel in a fixed window
+type Limit struct {
+	Limit  int           `json:"limit" yaml:"limit"`
+	Window time.Duration `json:"window" yaml:"window"`
+	// PerRecipient counts messages per user instead of for the whole channel,
+	// e.g. to stop SMS floods to one phone
+	PerRecipient bool `json:"per_recipient" yaml:"per_recipient"`
+}
+
+// window is a fixed window counter
+type window struct {
+	start time.Time
+	count int
+}
+
+// limiter applies the per-channel limits and the throttles of rules
+type limiter struct {
+	limits map[Channel]Limit
+
+	mu      sync.Mutex
+	windows map[string]*window
+	longest time.Duration
+	sweep   time.Time
+}
+
+func newLimiter(limits map[Channel]Limit) *limiter {
+	l := &limiter{limits: limits, windows: make(map[string]*window)}
+	for _, limit := range limits {
+		l.longest = max(l.longest, limit.Window)
+	}
+	return l
+}
+
+// allow counts a message to userID on ch, reporting whether it is under the limit
+func (l *limiter) allow(ch Channel, userID string, now time.Time) bool {
+	limit, ok := l.limits[ch]
+	if !ok {
+		return true
+	}
+	key := string(ch)
+	if limit.PerRecipient {
+		key += "\x00" + userID
+	}
+	return l.allowKey(key, limit, now)
+}
+
+// allowKey counts a message in the window of key, reporting whether it is
+// under limit
+func (l *limiter) allowKey(key string, limit Limit, now time.Time) bool {
+	if limit.Limit <= 0 || limit.Window <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.longest = max(l.longest, limit.Window)
+	l.evict(now)
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= limit.Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= limit.Limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// evict drops expired per-recipient windows once a minute, so the map does
+// not grow with every user ever notified
+func (l *limiter) evict(now time.Time) {
+	if now.Sub(l.sweep) < time.Minute {
+		return
+	}
+	l.sweep = now
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.longest {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package notify sends notifications over SMS, push and email behind one
+// Provider interface, routing each Notification by the recipient's channel
+// preference with templating, rate limits and delivery receipts. Events are
+// turned into notifications by routing rules, see Notifier.Notify.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ncobase/ncore/validation/expression"
+)
+
+// Channel is a delivery channel
+type Channel string
+
+// Delivery channels
+const (
+	ChannelPush  Channel = "push"
+	ChannelSMS   Channel = "sms"
+	ChannelEmail Channel = "email"
+)
+
+// defaultChannels is the routing order for recipients without a preference
+var defaultChannels = []Channel{ChannelPush, ChannelSMS, ChannelEmail}
+
+// Status is the delivery status of a message
+type Status string
+
+// Delivery statuses
+const (
+	StatusSent      Status = "sent"      // Accepted by the provider
+	StatusDelivered Status = "delivered" // Reported delivered by the provider
+	StatusFailed    Status = "failed"    // Rejected by the provider or reported undelivered
+	StatusInvalid   Status = "invalid"   // The address is invalid, e.g. an unregistered device token
+	StatusSkipped   Status = "skipped"   // Not sent because of a rate limit
+)
+
+var (
+	ErrNoRecipient     = errors.New("notify: notification has no recipient")
+	ErrNoChannel       = errors.New("notify: no channel reaches the recipient")
+	ErrNoProvider      = errors.New("notify: no provider for channel")
+	ErrUnknownTemplate = errors.New("notify: unknown template")
+	ErrRateLimited     = errors.New("notify: rate limited")
+	ErrInvalidAddress  = errors.New("notify: invalid address")
+	ErrNotDelivered    = errors.New("notify: notification was not sent on any channel")
+	ErrNoCallback      = errors.New("notify: provider has no delivery callbacks")
+)
+
+// Notification is a message to a user, rendered and routed to the channels
+// the user prefers
+type Notification struct {
+	ID        string         // Passed to providers and receipts, optional
+	UserID    string         // Looked up in the Directory unless Recipient is set
+	Recipient *Recipient     // Addresses to use instead of the Directory
+	Template  string         // Registered template name, Title and Body are used when empty
+	Data      map[string]any // Template data, and parameters of provider side templates
+	Title     string
+	Body      string
+	Channels  []Channel // Restricts delivery to these channels
+	Fallback  bool      // Stop at the first channel that sends, instead of sending on every channel
+}
+
+// Message is a notification rendered for one channel and address
+type Message struct {
+	NotificationID string
+	UserID         string
+	Channel        Channel
+	To             string // Phone number in E.164 format, device token or email address
+	Platform       string // Device platform for push
+	Provider       string // Provider pinned by the device, optional
+	Title          string
+	Body           string
+	Template       string            // Provider side template, e.g. an Aliyun SMS template code or an email template
+	Params         map[string]string // Parameters of the provider side template, also the push data payload
+}
+
+// Provider delivers messages of one channel
+type Provider interface {
+	Name() string
+	Channel() Channel
+	// Send sends msg and returns the provider's message ID
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+// PlatformProvider is implemented by push providers limited to some device platforms
+type PlatformProvider interface {
+	Platforms() []string
+}
+
+// CallbackProvider is implemented by providers reporting delivery statuses
+// through HTTP callbacks. HandleCallback writes the response and returns the
+// receipts carried by the request.
+type CallbackProvider interface {
+	HandleCallback(w http.ResponseWriter, r *http.Request) []Receipt
+}
+
+// Receipt is the status of a message
+type Receipt struct {
+	NotificationID string    `json:"notification_id,omitempty"`
+	UserID         string    `json:"user_id,omitempty"`
+	ID             string    `json:"id,omitempty"` // Provider message ID
+	Provider       string    `json:"provider"`
+	Channel        Channel   `json:"channel"`
+	To             string    `json:"to,omitempty"`
+	Status         Status    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// Options configures a Notifier
+type Options struct {
+	Directory Directory         // Resolves user IDs, defaults to an empty MemoryDirectory
+	Limits    map[Channel]Limit // Rate limits per channel
+}
+
+// Notifier renders notifications and routes them to providers
+type Notifier struct {
+	directory Directory
+	limiter   *limiter
+
+	mu        sync.RWMutex
+	providers map[Channel][]Provider
+	byName    map[string]Provider
+	templates map[string]*compiledTemplate
+
+	hooksMu     sync.RWMutex
+	statusHooks []func(ctx context.Context, r Receipt)
+
+	rulesMu sync.RWMutex
+	rules   []Rule
+	engine  *expression.Expression
+}
+
+// New creates a Notifier
+func New(opts Options, providers ...Provider) *Notifier {
+	if opts.Directory == nil {
+		opts.Directory = NewMemoryDirectory()
+	}
+	n := &Notifier{
+		directory: opts.Directory,
+		limiter:   newLimiter(opts.Limits),
+		providers: make(map[Channel][]Provider),
+		byName:    make(map[string]Provider),
+		templates: make(map[string]*compiledTemplate),
+		engine:    newRuleEngine(),
+	}
+	for _, p := range providers {
+		n.Register(p)
+	}
+	return n
+}
+
+// Register adds a provider. Push providers are chosen by device platform, in
+// registration order, unless a device names its provider.
+func (n *Notifier) Register(p Provider) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.providers[p.Channel()] = append(n.providers[p.Channel()], p)
+	n.byName[p.Name()] = p
+}
+
+// OnStatus registers fn to be called with every receipt, when a message is
+// sent and when a provider reports its delivery
+func (n *Notifier) OnStatus(fn func(ctx context.Context, r Receipt)) {
+	n.hooksMu.Lock()
+	defer n.hooksMu.Unlock()
+	n.statusHooks = append(n.statusHooks, fn)
+}
+
+// emit calls the status hooks
+func (n *Notifier) emit(ctx context.Context, receipts ...Receipt) {
+	n.hooksMu.RLock()
+	hooks := n.statusHooks
+	n.hooksMu.RUnlock()
+	for _, r := range receipts {
+		for _, fn := range hooks {
+			fn(ctx, r)
+		}
+	}
+}
+
+// Send renders note and sends it on the channels the recipient prefers. It
+// returns a receipt per message, and ErrNotDelivered joined with the causes
+// when nothing was sent.
+func (n *Notifier) Send(ctx context.Context, note *Notification) ([]Receipt, error) {
+	recipient := note.Recipient
+	if recipient == nil {
+		if note.UserID == "" {
+			return nil, ErrNoRecipient
+		}
+		var err error
+		if recipient, err = n.directory.Recipient(ctx, note.UserID); err != nil {
+			return nil, err
+		}
+	}
+	userID := note.UserID
+	if userID == "" {
+		userID = recipient.UserID
+	}
+
+	channels := route(recipient, note.Channels)
+	if len(channels) == 0 {
+		return nil, ErrNoChannel
+	}
+
+	var (
+		receipts []Receipt
+		errs     []error
+		sent     bool
+	)
+	for _, ch := range channels {
+		content, err := n.render(note, ch)
+		if err != nil {
+			return receipts, err
+		}
+
+		for _, msg := range messages(recipient, ch, content) {
+			msg.NotificationID = note.ID
+			msg.UserID = userID
+			r, err := n.deliver(ctx, msg)
+			receipts = append(receipts, r)
+			n.emit(ctx, r)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s to %s: %w", ch, msg.To, err))
+				continue
+			}
+			sent = true
+		}
+		if sent && note.Fallback {
+			break
+		}
+	}
+
+	if !sent {
+		return receipts, errors.Join(append([]error{ErrNotDelivered}, errs...)...)
+	}
+	return receipts, nil
+}
+
+// deliver sends a message through the provider for its channel
+func (n *Notifier) deliver(ctx context.Context, msg *Message) (Receipt, error) {
+	r := Receipt{
+		NotificationID: msg.NotificationID,
+		UserID:         msg.UserID,
+		Channel:        msg.Channel,
+		To:             msg.To,
+		Time:           time.Now(),
+	}
+
+	p := n.provider(msg)
+	if p == nil {
+		r.Status, r.Error = StatusFailed, ErrNoProvider.Error()
+		return r, ErrNoProvider
+	}
+	r.Provider = p.Name()
+
+	if !n.limiter.allow(msg.Channel, msg.UserID, time.Now()) {
+		r.Status, r.Error = StatusSkipped, ErrRateLimited.Error()
+		return r, ErrRateLimited
+	}
+
+	id, err := p.Send(ctx, msg)
+	r.ID = id
+	switch {
+	case errors.Is(err, ErrInvalidAddress):
+		r.Status, r.Error = StatusInvalid, err.Error()
+	case err != nil:
+		r.Status, r.Error = StatusFailed, err.Error()
+	default:
+		r.Status = StatusSent
+	}
+	return r, err
+}
+
+// provider returns the provider for a message, nil if none
+func (n *Notifier) provider(msg *Message) Provider {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if msg.Provider != "" {
+		if p, ok := n.byName[msg.Provider]; ok && p.Channel() == msg.Channel {
+			return p
+		}
+		return nil
+	}
+	for _, p := range n.providers[msg.Channel] {
+		pp, ok := p.(PlatformProvider)
+		if !ok || msg.Platform == "" || slices.Contains(pp.Platforms(), msg.Platform) {
+			return p
+		}
+	}
+	return nil
+}
+
+// HandleCallback passes a delivery report to the named provider, which writes
+// the response, and emits the receipts it carries. It returns ErrNoCallback
+// without writing for unknown providers, see callbacks.RegisterRoutes.
+func (n *Notifier) HandleCallback(provider string, w http.ResponseWriter, r *http.Request) error {
+	n.mu.RLock()
+	p, ok := n.byName[provider]
+	n.mu.RUnlock()
+
+	cp, isCallback := p.(CallbackProvider)
+	if !ok || !isCallback {
+		return fmt.Errorf("%w: %s", ErrNoCallback, provider)
+	}
+	n.emit(r.Context(), cp.HandleCallback(w, r)...)
+	return nil
+}
+
+// route returns the channels to try in order: the recipient's preference, or
+// every channel it has an address for, restricted to requested if not empty
+func route(r *Recipient, requested []Channel) []Channel {
+	preferred := r.Channels
+	if len(preferred) == 0 {
+		preferred = defaultChannels
+	}
+	var out []Channel
+	for _, ch := range preferred {
+		if !r.reachable(ch) || slices.Contains(out, ch) {
+			continue
+		}
+		if len(requested) > 0 && !slices.Contains(requested, ch) {
+			continue
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+// messages returns the messages of a channel, one per device for push
+func messages(r *Recipient, ch Channel, c *content) []*Message {
+	newMsg := func(to string) *Message {
+		return &Message{
+			Channel:  ch,
+			To:       to,
+			Title:    c.title,
+			Body:     c.body,
+			Template: c.template,
+			Params:   c.params,
+		}
+	}
+	switch ch {
+	case ChannelSMS:
+		return []*Message{newMsg(r.Phone)}
+	case ChannelEmail:
+		return []*Message{newMsg(r.Email)}
+	case ChannelPush:
+		msgs := make([]*Message, 0, len(r.Devices))
+		for _, d := range r.Devices {
+			msg := newMsg(d.Token)
+			msg.Platform, msg.Provider = d.Platform, d.Provider
+			msgs = append(msgs, msg)
+		}
+		return msgs
+	}
+	return nil
+}
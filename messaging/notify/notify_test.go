@@ -0,0 +1,350 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name      string
+	channel   Channel
+	platforms []string
+	err       error
+
+	mu   sync.Mutex
+	sent []*Message
+}
+
+func (p *fakeProvider) Name() string     { return p.name }
+func (p *fakeProvider) Channel() Channel { return p.channel }
+
+func (p *fakeProvider) Send(_ context.Context, msg *Message) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return "", p.err
+	}
+	p.sent = append(p.sent, msg)
+	return p.name + "-id", nil
+}
+
+type platformProvider struct{ *fakeProvider }
+
+func (p platformProvider) Platforms() []string { return p.platforms }
+
+func TestSendRouting(t *testing.T) {
+	apns := &fakeProvider{name: "apns", channel: ChannelPush, platforms: []string{"ios"}}
+	fcm := &fakeProvider{name: "fcm", channel: ChannelPush, platforms: []string{"android"}}
+	sms := &fakeProvider{name: "twilio", channel: ChannelSMS}
+
+	dir := NewMemoryDirectory()
+	dir.Set(&Recipient{
+		UserID: "u1",
+		Phone:  "+15550100",
+		Devices: []Device{
+			{Token: "ios-token", Platform: "ios"},
+			{Token: "android-token", Platform: "android"},
+			{Token: "pinned", Platform: "ios", Provider: "fcm"},
+		},
+		Channels: []Channel{ChannelSMS, ChannelPush},
+	})
+	n := New(Options{Directory: dir}, platformProvider{apns}, platformProvider{fcm}, sms)
+
+	var statuses []Receipt
+	n.OnStatus(func(_ context.Context, r Receipt) { statuses = append(statuses, r) })
+
+	receipts, err := n.Send(context.Background(), &Notification{ID: "n1", UserID: "u1", Title: "Hi", Body: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 4 || len(statuses) != 4 {
+		t.Fatalf("receipts = %d, statuses = %d, want 4", len(receipts), len(statuses))
+	}
+	if receipts[0].Channel != ChannelSMS {
+		t.Errorf("first channel = %s, want the preferred sms", receipts[0].Channel)
+	}
+	if len(apns.sent) != 1 || apns.sent[0].To != "ios-token" {
+		t.Errorf("apns sent %+v", apns.sent)
+	}
+	if len(fcm.sent) != 2 || fcm.sent[1].To != "pinned" {
+		t.Errorf("fcm sent %+v, want the android device and the pinned one", fcm.sent)
+	}
+	for _, r := range receipts {
+		if r.Status != StatusSent || r.NotificationID != "n1" || r.UserID != "u1" {
+			t.Errorf("receipt %+v", r)
+		}
+	}
+
+	// Channels restricts the preference
+	receipts, err = n.Send(context.Background(), &Notification{UserID: "u1", Body: "x", Channels: []Channel{ChannelSMS, ChannelEmail}})
+	if err != nil || len(receipts) != 1 || receipts[0].Provider != "twilio" {
+		t.Fatalf("restricted send = %+v, %v", receipts, err)
+	}
+
+	if _, err := n.Send(context.Background(), &Notification{UserID: "u1", Channels: []Channel{ChannelEmail}}); !errors.Is(err, ErrNoChannel) {
+		t.Errorf("unreachable channel err = %v", err)
+	}
+	if _, err := n.Send(context.Background(), &Notification{UserID: "nobody"}); !errors.Is(err, ErrUnknownUser) {
+		t.Errorf("unknown user err = %v", err)
+	}
+}
+
+func TestSendFallback(t *testing.T) {
+	push := &fakeProvider{name: "fcm", channel: ChannelPush, err: errors.Join(ErrInvalidAddress, errors.New("unregistered"))}
+	sms := &fakeProvider{name: "twilio", channel: ChannelSMS}
+	mail := &fakeProvider{name: "email", channel: ChannelEmail}
+	n := New(Options{}, push, sms, mail)
+
+	recipient := &Recipient{UserID: "u1", Phone: "+15550100", Email: "u1@example.com", Devices: []Device{{Token: "t", Platform: "android"}}}
+	receipts, err := n.Send(context.Background(), &Notification{Recipient: recipient, Body: "code 1234", Fallback: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 2 || receipts[0].Status != StatusInvalid || receipts[1].Status != StatusSent {
+		t.Fatalf("receipts = %+v, want invalid push then sms", receipts)
+	}
+	if len(mail.sent) != 0 {
+		t.Error("fallback went past the first channel that sent")
+	}
+
+	sms.err = errors.New("down")
+	_, err = n.Send(context.Background(), &Notification{Recipient: recipient, Body: "x", Channels: []Channel{ChannelPush, ChannelSMS}})
+	if !errors.Is(err, ErrNotDelivered) || !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("err = %v, want ErrNotDelivered with the causes", err)
+	}
+}
+
+func TestSendRateLimit(t *testing.T) {
+	sms := &fakeProvider{name: "twilio", channel: ChannelSMS}
+	n := New(Options{Limits: map[Channel]Limit{
+		ChannelSMS: {Limit: 2, Window: time.Hour, PerRecipient: true},
+	}}, sms)
+
+	send := func(user string) Status {
+		receipts, _ := n.Send(context.Background(), &Notification{Recipient: &Recipient{UserID: user, Phone: "+1555"}, Body: "x"})
+		return receipts[0].Status
+	}
+	for range 2 {
+		if s := send("a"); s != StatusSent {
+			t.Fatalf("status = %s, want sent", s)
+		}
+	}
+	if s := send("a"); s != StatusSkipped {
+		t.Errorf("third message status = %s, want skipped", s)
+	}
+	if s := send("b"); s != StatusSent {
+		t.Errorf("other recipient status = %s, want sent", s)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	push := &fakeProvider{name: "fcm", channel: ChannelPush}
+	sms := &fakeProvider{name: "aliyun", channel: ChannelSMS}
+	n := New(Options{}, push, sms)
+
+	if err := n.AddTemplate("login", Template{
+		Title: "Sign in to {{.app}}",
+		Body:  "Your code is {{.code}}, it expires in 5 minutes.",
+		SMS:   "{{.code}}",
+		Code:  map[Channel]string{ChannelSMS: "SMS_123"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.AddTemplate("bad", Template{Body: "{{.x"}); err == nil {
+		t.Error("AddTemplate accepted an invalid template")
+	}
+
+	recipient := &Recipient{Phone: "+8613800138000", Devices: []Device{{Token: "t"}}}
+	_, err := n.Send(context.Background(), &Notification{
+		Recipient: recipient,
+		Template:  "login",
+		Data:      map[string]any{"app": "ncore", "code": 1234},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := push.sent[0]; m.Title != "Sign in to ncore" || m.Body != "Your code is 1234, it expires in 5 minutes." || m.Template != "" {
+		t.Errorf("push message = %+v", m)
+	}
+	if m := sms.sent[0]; m.Body != "1234" || m.Template != "SMS_123" || m.Params["code"] != "1234" {
+		t.Errorf("sms message = %+v", m)
+	}
+
+	if _, err := n.Send(context.Background(), &Notification{Recipient: recipient, Template: "missing"}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("err = %v, want ErrUnknownTemplate", err)
+	}
+}
+
+func TestNotifyRules(t *testing.T) {
+	sms := &fakeProvider{name: "twilio", channel: ChannelSMS}
+	email := &fakeProvider{name: "smtp", channel: ChannelEmail}
+
+	dir := NewMemoryDirectory()
+	dir.Set(&Recipient{
+		UserID:      "u1",
+		Phone:       "+15550100",
+		Email:       "u1@example.com",
+		Preferences: map[string][]Channel{"promo.sale": {}},
+	})
+	n := New(Options{Directory: dir}, sms, email)
+	if err := n.AddTemplate("big-order", Template{Body: "Order {{.id}} paid"}); err != nil {
+		t.Fatal(err)
+	}
+	err := n.SetRules([]Rule{
+		{Name: "big", Event: "order.paid", Condition: "amount >= 1000 && tier == 'gold'", Channels: []Channel{ChannelSMS}, Template: "big-order",
+			Throttle: &Limit{Limit: 1, Window: time.Hour, PerRecipient: true}},
+		{Name: "orders", Event: "order.*", Channels: []Channel{ChannelEmail}},
+		{Name: "promo", Event: "promo.*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := n.Notify(ctx, "order.paid", "u1", map[string]any{"id": "o1", "amount": 1500, "tier": "gold"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sms.sent) != 1 || sms.sent[0].Body != "Order o1 paid" {
+		t.Fatalf("sms sent = %v, want the big order template", sms.sent)
+	}
+	if _, err := n.Notify(ctx, "order.paid", "u1", map[string]any{"id": "o2", "amount": 2000, "tier": "gold"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second big order err = %v, want ErrRateLimited", err)
+	}
+
+	if _, err := n.Notify(ctx, "order.paid", "u1", map[string]any{"id": "o3", "amount": 10, "tier": "gold", "title": "Paid"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(email.sent) != 1 {
+		t.Errorf("small order emails = %d, want 1 by the order.* rule", len(email.sent))
+	}
+
+	if _, err := n.Notify(ctx, "promo.sale", "u1", nil); !errors.Is(err, ErrMuted) {
+		t.Errorf("muted event err = %v, want ErrMuted", err)
+	}
+	if _, err := n.Notify(ctx, "user.created", "u1", nil); !errors.Is(err, ErrNoRule) {
+		t.Errorf("unrouted event err = %v, want ErrNoRule", err)
+	}
+
+	if err := n.SetRules([]Rule{{Name: "bad", Event: "x", Condition: "amount >"}}); err == nil {
+		t.Error("SetRules accepted a condition that does not parse")
+	}
+	if got := n.Rules(); len(got) != 3 {
+		t.Errorf("rules after a failed update = %d, want the 3 in use", len(got))
+	}
+}
+
+func TestTwilioCallback(t *testing.T) {
+	p, err := NewTwilioProvider(&TwilioConfig{
+		AccountSID:     "AC1",
+		AuthToken:      "secret",
+		From:           "+15550000",
+		StatusCallback: "https://api.example.com/notify/callbacks/twilio",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := New(Options{}, p)
+	var got []Receipt
+	n.OnStatus(func(_ context.Context, r Receipt) { got = append(got, r) })
+
+	form := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}, "To": {"+15550100"}, "ErrorCode": {"30003"}}
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(p.Config.StatusCallback + "ErrorCode30003MessageSidSM1MessageStatusundeliveredTo+15550100"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	post := func(signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/twilio", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", signature)
+		rec := httptest.NewRecorder()
+		if err := n.HandleCallback("twilio", rec, req); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code
+	}
+	if code := post("forged"); code != http.StatusForbidden {
+		t.Errorf("forged signature status = %d", code)
+	}
+	if code := post(signature); code != http.StatusNoContent {
+		t.Fatalf("status = %d", code)
+	}
+	if len(got) != 1 || got[0].ID != "SM1" || got[0].Status != StatusFailed || got[0].Error != "twilio error 30003" {
+		t.Errorf("receipts = %+v", got)
+	}
+}
+
+func TestAliyunCallback(t *testing.T) {
+	p, err := NewAliyunSMSProvider(&AliyunSMSConfig{AccessKeyID: "id", AccessKeySecret: "secret", SignName: "ncore", CallbackToken: "tok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := New(Options{}, p)
+	var got []Receipt
+	n.OnStatus(func(_ context.Context, r Receipt) { got = append(got, r) })
+
+	body := `[{"phone_number":"13800138000","success":true,"biz_id":"b1","out_id":"n1","report_time":"2026-01-02 03:04:05"},
+		{"phone_number":"13800138001","success":false,"err_code":"DELIVRD","err_msg":"failed","biz_id":"b2"}]`
+	req := httptest.NewRequest(http.MethodPost, "/aliyun?token=tok", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	_ = n.HandleCallback("aliyun", rec, req)
+
+	if !strings.Contains(rec.Body.String(), `"code":0`) {
+		t.Errorf("response = %s", rec.Body)
+	}
+	if len(got) != 2 || got[0].Status != StatusDelivered || got[0].NotificationID != "n1" || got[1].Status != StatusFailed {
+		t.Fatalf("receipts = %+v", got)
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, aliyunLocation); !got[0].Time.Equal(want) {
+		t.Errorf("time = %v, want %v", got[0].Time, want)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/aliyun", strings.NewReader(body))
+	rec = httptest.NewRecorder()
+	_ = n.HandleCallback("aliyun", rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("missing token status = %d", rec.Code)
+	}
+
+	if err := n.HandleCallback("twilio", httptest.NewRecorder(), req); !errors.Is(err, ErrNoCallback) {
+		t.Errorf("unknown provider err = %v", err)
+	}
+}
+
+func TestSignJWTES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signJWT(key, map[string]any{"alg": "ES256", "kid": "K"}, map[string]any{"iss": "T"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token = %s", token)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		t.Fatalf("signature length %d, %v", len(sig), err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&key.PublicKey, digest[:], r, s) {
+		t.Error("signature does not verify")
+	}
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"github.com/google/wire"
+)
+
+// ProviderSet is the wire provider set for the notify package.
+// It provides a *Notifier with the configured SMS and push providers.
+// The Directory is bound by the application, the email channel is added with
+// Register(NewEmailProvider(sender)).
+//
+// Usage:
+//
+//	wire.Build(
+//	    notify.ProviderSet,
+//	    wire.Bind(new(notify.Directory), new(*UserDirectory)),
+//	    // ... other providers
+//	)
+var ProviderSet = wire.NewSet(
+	ProvideNotifier,
+)
+
+// ProvideNotifier creates a Notifier from the notify configuration.
+func ProvideNotifier(cfg *Config, directory Directory) (*Notifier, error) {
+	return NewFromConfig(cfg, directory)
+}
@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/validation/expression"
+)
+
+var (
+	ErrNoRule = errors.New("notify: no routing rule matches the event")
+	ErrMuted  = errors.New("notify: the recipient muted the event")
+)
+
+// Rule routes the notifications of an event type. Rules are tried in order
+// and the first one whose Event and Condition match decides the channels,
+// template and throttling.
+type Rule struct {
+	Name string `json:"name" yaml:"name"`
+	// Event is the event type, a prefix ending in ".*", e.g. "order.*", or
+	// "*" for every event
+	Event string `json:"event" yaml:"event"`
+	// Condition is a validation/expression evaluated with the event data,
+	// event and user_id, e.g. "amount > 1000 && tier == 'gold'"; empty matches
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"`
+	// Channels restricts delivery to these channels, in the recipient's order
+	Channels []Channel `json:"channels,omitempty" yaml:"channels,omitempty"`
+	Template string    `json:"template,omitempty" yaml:"template,omitempty"`
+	Fallback bool      `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	// Throttle caps the notifications the rule sends, per recipient when
+	// PerRecipient is set
+	Throttle *Limit `json:"throttle,omitempty" yaml:"throttle,omitempty"`
+	Disabled bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// matchEvent reports whether the rule applies to event
+func (r *Rule) matchEvent(event string) bool {
+	switch {
+	case r.Event == "*":
+		return true
+	case strings.HasSuffix(r.Event, ".*"):
+		return strings.HasPrefix(event, strings.TrimSuffix(r.Event, "*"))
+	}
+	return r.Event == event
+}
+
+// newRuleEngine creates the expression engine evaluating rule conditions.
+// Results depend on the event data, so they are not cached.
+func newRuleEngine() *expression.Expression {
+	cfg := expression.DefaultConfig()
+	cfg.CacheEnabled = false
+	cfg.Timeout = 100
+	return expression.NewExpression(cfg)
+}
+
+// SetRules replaces the routing rules, e.g. when the configuration is
+// reloaded. Conditions are checked first, the rules in use stay in place
+// when one does not parse.
+func (n *Notifier) SetRules(rules []Rule) error {
+	for i := range rules {
+		r := &rules[i]
+		if r.Event == "" {
+			return fmt.Errorf("notify: rule %d (%s) has no event", i, r.Name)
+		}
+		if r.Condition == "" {
+			continue
+		}
+		if err := n.engine.ValidateSyntax(r.Condition); err != nil {
+			return fmt.Errorf("notify: rule %d (%s) condition: %w", i, r.Name, err)
+		}
+	}
+
+	rules = slices.Clone(rules)
+	n.rulesMu.Lock()
+	defer n.rulesMu.Unlock()
+	n.rules = rules
+	return nil
+}
+
+// Rules returns the routing rules in use
+func (n *Notifier) Rules() []Rule {
+	n.rulesMu.RLock()
+	defer n.rulesMu.RUnlock()
+	return slices.Clone(n.rules)
+}
+
+// Notify routes an event to a user by the first matching rule, honoring the
+// channels the user chose for the event type. It returns ErrNoRule when no
+// rule matches, ErrMuted when the user muted the event and ErrRateLimited
+// when the rule's throttle is exhausted.
+func (n *Notifier) Notify(ctx context.Context, event, userID string, data map[string]any) ([]Receipt, error) {
+	if userID == "" {
+		return nil, ErrNoRecipient
+	}
+	recipient, err := n.directory.Recipient(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := n.match(ctx, event, userID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The channels chosen for the event replace the general preference
+	if preferred, ok := recipient.Preferences[event]; ok {
+		if len(preferred) == 0 {
+			return nil, ErrMuted
+		}
+		r := *recipient
+		r.Channels = preferred
+		recipient = &r
+	}
+
+	if rule.Throttle != nil && !n.limiter.allowKey(ruleKey(rule, userID), *rule.Throttle, time.Now()) {
+		return nil, ErrRateLimited
+	}
+
+	return n.Send(ctx, &Notification{
+		UserID:    userID,
+		Recipient: recipient,
+		Template:  rule.Template,
+		Data:      data,
+		Channels:  rule.Channels,
+		Fallback:  rule.Fallback,
+	})
+}
+
+// match returns the first enabled rule matching the event, with the errors
+// of conditions that failed to evaluate when none does
+func (n *Notifier) match(ctx context.Context, event, userID string, data map[string]any) (*Rule, error) {
+	n.rulesMu.RLock()
+	rules := n.rules
+	n.rulesMu.RUnlock()
+
+	var (
+		vars map[string]any
+		errs []error
+	)
+	for i := range rules {
+		r := &rules[i]
+		if r.Disabled || !r.matchEvent(event) {
+			continue
+		}
+		if r.Condition == "" {
+			return r, nil
+		}
+		if vars == nil {
+			vars = ruleVariables(event, userID, data)
+		}
+		v, err := n.engine.Evaluate(ctx, r.Condition, vars)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Name, err))
+			continue
+		}
+		if ok, _ := v.(bool); ok {
+			return r, nil
+		}
+	}
+	return nil, errors.Join(append([]error{fmt.Errorf("%w: %s", ErrNoRule, event)}, errs...)...)
+}
+
+// ruleVariables returns the variables of rule conditions. Numbers are
+// float64, so they compare equal to the literals in conditions.
+func ruleVariables(event, userID string, data map[string]any) map[string]any {
+	vars := make(map[string]any, len(data)+2)
+	for k, v := range data {
+		switch x := v.(type) {
+		case int:
+			v = float64(x)
+		case int32:
+			v = float64(x)
+		case int64:
+			v = float64(x)
+		case uint:
+			v = float64(x)
+		case uint32:
+			v = float64(x)
+		case uint64:
+			v = float64(x)
+		case float32:
+			v = float64(x)
+		}
+		vars[k] = v
+	}
+	vars["event"] = event
+	vars["user_id"] = userID
+	return vars
+}
+
+// ruleKey is the throttle window key of a rule
+func ruleKey(r *Rule, userID string) string {
+	key := "rule\x00" + r.Name + "\x00" + r.Event
+	if r.Throttle.PerRecipient {
+		key += "\x00" + userID
+	}
+	return key
+}
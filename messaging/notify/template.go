@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template renders notifications. Title and Body are text/template sources
+// executed with Notification.Data.
+type Template struct {
+	Title string `json:"title" yaml:"title"`
+	Body  string `json:"body" yaml:"body"`
+	// SMS replaces Body for text messages, which are billed by length
+	SMS string `json:"sms,omitempty" yaml:"sms,omitempty"`
+	// Code is the provider side template per channel, e.g. the Aliyun SMS
+	// template code; providers requiring one send it with Data as parameters
+	Code map[Channel]string `json:"code,omitempty" yaml:"code,omitempty"`
+}
+
+// compiledTemplate is a parsed Template
+type compiledTemplate struct {
+	title, body, sms *template.Template
+	code             map[Channel]string
+}
+
+// content is a notification rendered for a channel
+type content struct {
+	title, body string
+	template    string
+	params      map[string]string
+}
+
+// AddTemplate parses and registers a template
+func (n *Notifier) AddTemplate(name string, t Template) error {
+	ct := &compiledTemplate{code: t.Code}
+	var err error
+	if ct.title, err = parseTemplate(name+".title", t.Title); err != nil {
+		return err
+	}
+	if ct.body, err = parseTemplate(name+".body", t.Body); err != nil {
+		return err
+	}
+	if ct.sms, err = parseTemplate(name+".sms", t.SMS); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.templates[name] = ct
+	return nil
+}
+
+// parseTemplate parses src, nil when empty
+func parseTemplate(name, src string) (*template.Template, error) {
+	if src == "" {
+		return nil, nil
+	}
+	t, err := template.New(name).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("notify: parse template %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// render renders note for a channel
+func (n *Notifier) render(note *Notification, ch Channel) (*content, error) {
+	c := &content{title: note.Title, body: note.Body, params: params(note.Data)}
+	if note.Template == "" {
+		return c, nil
+	}
+
+	n.mu.RLock()
+	t, ok := n.templates[note.Template]
+	n.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, note.Template)
+	}
+
+	body := t.body
+	if ch == ChannelSMS && t.sms != nil {
+		body = t.sms
+	}
+	var err error
+	if c.title, err = execute(t.title, note.Data, c.title); err != nil {
+		return nil, err
+	}
+	if c.body, err = execute(body, note.Data, c.body); err != nil {
+		return nil, err
+	}
+	c.template = t.code[ch]
+	return c, nil
+}
+
+// execute runs t, returning fallback when t is nil
+func execute(t *template.Template, data map[string]any, fallback string) (string, error) {
+	if t == nil {
+		return fallback, nil
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("notify: render template %s: %w", t.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// params formats data as provider template parameters
+func params(data map[string]any) map[string]string {
+	if len(data) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(data))
+	for k, v := range data {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
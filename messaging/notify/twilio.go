@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TwilioConfig holds the configuration for Twilio Programmable Messaging
+type TwilioConfig struct {
+	AccountSID          string `json:"account_sid" yaml:"account_sid"`
+	AuthToken           string `json:"auth_token" yaml:"auth_token"`
+	From                string `json:"from" yaml:"from"`                                   // Sender number, or
+	MessagingServiceSID string `json:"messaging_service_sid" yaml:"messaging_service_sid"` // a messaging service
+	// StatusCallback is the public URL of the callback handler for this
+	// provider, e.g. https://api.example.com/notify/callbacks/twilio. Twilio
+	// signs callbacks against it, so it must match what Twilio requests.
+	StatusCallback string `json:"status_callback" yaml:"status_callback"`
+	BaseURL        string `json:"base_url" yaml:"base_url"` // For tests, defaults to https://api.twilio.com
+}
+
+// twilioInvalidNumber are the Twilio error codes for numbers that can never
+// receive messages
+var twilioInvalidNumber = []int{21211, 21214, 21610, 21612, 21614}
+
+// TwilioProvider sends SMS through Twilio
+type TwilioProvider struct {
+	Config *TwilioConfig
+	Client *http.Client
+}
+
+// NewTwilioProvider creates a TwilioProvider
+func NewTwilioProvider(config *TwilioConfig) (*TwilioProvider, error) {
+	if err := validateTwilioConfig(config); err != nil {
+		return nil, err
+	}
+	return &TwilioProvider{Config: config, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Name implements Provider
+func (p *TwilioProvider) Name() string { return "twilio" }
+
+// Channel implements Provider
+func (p *TwilioProvider) Channel() Channel { return ChannelSMS }
+
+// Send implements Provider
+func (p *TwilioProvider) Send(ctx context.Context, msg *Message) (string, error) {
+	form := url.Values{"To": {msg.To}, "Body": {msg.Body}}
+	if p.Config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.Config.MessagingServiceSID)
+	} else {
+		form.Set("From", p.Config.From)
+	}
+	if p.Config.StatusCallback != "" {
+		form.Set("StatusCallback", p.Config.StatusCallback)
+	}
+
+	base := p.Config.BaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", base, url.PathEscape(p.Config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.Config.AccountSID, p.Config.AuthToken)
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("twilio: %w", err)
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("twilio: status %d: %w", res.StatusCode, err)
+	}
+	if res.StatusCode >= 300 {
+		err := fmt.Errorf("twilio: %d %s", body.Code, body.Message)
+		if slices.Contains(twilioInvalidNumber, body.Code) {
+			err = errors.Join(ErrInvalidAddress, err)
+		}
+		return "", err
+	}
+	return body.SID, nil
+}
+
+// HandleCallback implements CallbackProvider for Twilio status callbacks
+func (p *TwilioProvider) HandleCallback(w http.ResponseWriter, r *http.Request) []Receipt {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return nil
+	}
+	if !p.validSignature(r.Header.Get("X-Twilio-Signature"), r.PostForm) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return nil
+	}
+
+	var status Status
+	switch r.PostForm.Get("MessageStatus") {
+	case "delivered":
+		status = StatusDelivered
+	case "failed", "undelivered":
+		status = StatusFailed
+	default:
+		// queued, sending and sent add nothing to the receipt of Send
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	receipt := Receipt{
+		ID:       r.PostForm.Get("MessageSid"),
+		Provider: p.Name(),
+		Channel:  ChannelSMS,
+		To:       r.PostForm.Get("To"),
+		Status:   status,
+		Time:     time.Now(),
+	}
+	if code := r.PostForm.Get("ErrorCode"); code != "" {
+		receipt.Error = "twilio error " + code
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return []Receipt{receipt}
+}
+
+// validSignature checks the X-Twilio-Signature header: the base64 HMAC-SHA1
+// of the callback URL followed by the sorted form keys and values
+func (p *TwilioProvider) validSignature(signature string, form url.Values) bool {
+	if signature == "" || p.Config.StatusCallback == "" {
+		return false
+	}
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	mac := hmac.New(sha1.New, []byte(p.Config.AuthToken))
+	mac.Write([]byte(p.Config.StatusCallback))
+	for _, k := range keys {
+		for _, v := range form[k] {
+			mac.Write([]byte(k))
+			mac.Write([]byte(v))
+		}
+	}
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func validateTwilioConfig(config *TwilioConfig) error {
+	if config == nil || config.AccountSID == "" || config.AuthToken == "" {
+		return errors.New("invalid Twilio configuration")
+	}
+	if config.From == "" && config.MessagingServiceSID == "" {
+		return errors.New("invalid Twilio configuration: from or messaging_service_sid is required")
+	}
+	return nil
+}
@@ -3,10 +3,12 @@ package messaging
 import (
 	"github.com/google/wire"
 	"github.com/ncobase/ncore/messaging/email"
+	"github.com/ncobase/ncore/messaging/notify"
 )
 
 // ProviderSet is the wire provider set for the messaging package.
-// It provides email Sender and other messaging-related components.
+// It provides email Sender, the notification Notifier and other
+// messaging-related components.
 //
 // Usage:
 //
@@ -16,4 +18,5 @@ import (
 //	)
 var ProviderSet = wire.NewSet(
 	email.ProviderSet,
+	notify.ProviderSet,
 )
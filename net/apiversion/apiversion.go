@@ -0,0 +1,284 @@
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// Response headers
+const (
+	HeaderVersion     = "API-Version"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+// Other groups the consumers of a route beyond MaxConsumers
+const Other = "other"
+
+// State is the lifecycle state of a policy
+type State string
+
+const (
+	StateActive     State = "active"     // Not deprecated, or deprecated at a future date
+	StateDeprecated State = "deprecated" // Deprecated and still served
+	StateSunset     State = "sunset"     // Past the sunset date
+)
+
+// Policy declares the version of routes and their deprecation
+type Policy struct {
+	Version    string    `json:"version"`
+	Deprecated time.Time `json:"deprecated,omitzero"` // When the routes are deprecated, zero if they are not
+	Sunset     time.Time `json:"sunset,omitzero"`     // When the routes stop being served, zero if unknown
+	Link       string    `json:"link,omitempty"`      // Deprecation documentation, e.g. a migration guide
+	Successor  string    `json:"successor,omitempty"` // URL of the version replacing the routes
+}
+
+// IsDeprecated reports whether the routes have a deprecation date
+func (p *Policy) IsDeprecated() bool {
+	return !p.Deprecated.IsZero()
+}
+
+// State returns the state of the policy at a time
+func (p *Policy) State(now time.Time) State {
+	switch {
+	case !p.Sunset.IsZero() && !now.Before(p.Sunset):
+		return StateSunset
+	case p.IsDeprecated() && !now.Before(p.Deprecated):
+		return StateDeprecated
+	default:
+		return StateActive
+	}
+}
+
+// ConsumerFunc identifies the caller of a route
+type ConsumerFunc func(c *gin.Context) string
+
+// ConsumerByUserOrIP identifies callers by user ID, falling back to client IP
+func ConsumerByUserOrIP(c *gin.Context) string {
+	ctx := ctxutil.WithGinContext(c.Request.Context(), c)
+	if uid := ctxutil.GetUserID(ctx); uid != "" {
+		return "user:" + uid
+	}
+	return "ip:" + ctxutil.GetClientIP(ctx)
+}
+
+// Logger receives deprecated route usage, *logger.Logger implements it
+type Logger interface {
+	Warnf(ctx context.Context, format string, args ...any)
+}
+
+// Options configures a Registry
+type Options struct {
+	Consumer      ConsumerFunc  // Defaults to ConsumerByUserOrIP
+	Logger        Logger        // Logs calls of deprecated routes, nil disables logging
+	LogInterval   time.Duration // How often a consumer of a route is logged, default 1h
+	MaxConsumers  int           // Consumers tracked per route before they count as Other, default 1000
+	EnforceSunset bool          // Answer 410 Gone after the sunset date
+}
+
+// Registry holds declared policies and the usage of deprecated routes
+type Registry struct {
+	opts Options
+
+	mu       sync.Mutex
+	policies []*Policy
+	routes   map[*Policy]map[string]*routeUsage
+}
+
+type routeUsage struct {
+	calls     int64
+	last      time.Time
+	consumers map[string]*consumerUsage
+}
+
+type consumerUsage struct {
+	calls  int64
+	last   time.Time
+	logged time.Time
+}
+
+// NewRegistry creates a registry, a nil opts uses the defaults
+func NewRegistry(opts *Options) *Registry {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Consumer == nil {
+		o.Consumer = ConsumerByUserOrIP
+	}
+	if o.LogInterval <= 0 {
+		o.LogInterval = time.Hour
+	}
+	if o.MaxConsumers <= 0 {
+		o.MaxConsumers = 1000
+	}
+	return &Registry{opts: o, routes: make(map[*Policy]map[string]*routeUsage)}
+}
+
+// Declare returns middleware applying a policy to the routes it is attached to
+func (r *Registry) Declare(p *Policy) gin.HandlerFunc {
+	policy := *p
+	r.mu.Lock()
+	r.policies = append(r.policies, &policy)
+	r.routes[&policy] = make(map[string]*routeUsage)
+	r.mu.Unlock()
+
+	links := linkHeaders(&policy)
+	return func(c *gin.Context) {
+		if policy.Version != "" {
+			c.Header(HeaderVersion, policy.Version)
+		}
+		if !policy.IsDeprecated() {
+			c.Next()
+			return
+		}
+
+		c.Header(HeaderDeprecation, "@"+strconv.FormatInt(policy.Deprecated.Unix(), 10))
+		if !policy.Sunset.IsZero() {
+			c.Header(HeaderSunset, policy.Sunset.UTC().Format(http.TimeFormat))
+		}
+		for _, link := range links {
+			c.Writer.Header().Add(HeaderLink, link)
+		}
+
+		now := time.Now()
+		if c.FullPath() != "" {
+			r.record(c, &policy, now)
+		}
+		if r.opts.EnforceSunset && policy.State(now) == StateSunset {
+			resp.Fail(c.Writer, resp.Gone("%s %s was removed on %s", c.Request.Method, c.FullPath(), policy.Sunset.UTC().Format(time.DateOnly)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// linkHeaders returns the Link header values of a policy
+func linkHeaders(p *Policy) []string {
+	var links []string
+	if p.Link != "" {
+		links = append(links, "<"+p.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+	if p.Successor != "" {
+		links = append(links, "<"+p.Successor+`>; rel="successor-version"`)
+	}
+	return links
+}
+
+// record counts a call of a deprecated route, logging new consumers
+func (r *Registry) record(c *gin.Context, p *Policy, now time.Time) {
+	route := c.Request.Method + " " + c.FullPath()
+	consumer := r.opts.Consumer(c)
+
+	r.mu.Lock()
+	usage, ok := r.routes[p][route]
+	if !ok {
+		usage = &routeUsage{consumers: make(map[string]*consumerUsage)}
+		r.routes[p][route] = usage
+	}
+	usage.calls++
+	usage.last = now
+
+	cu, ok := usage.consumers[consumer]
+	if !ok {
+		if len(usage.consumers) >= r.opts.MaxConsumers {
+			consumer = Other
+			cu = usage.consumers[Other]
+		}
+		if cu == nil {
+			cu = &consumerUsage{}
+			usage.consumers[consumer] = cu
+		}
+	}
+	cu.calls++
+	cu.last = now
+	log := r.opts.Logger != nil && now.Sub(cu.logged) >= r.opts.LogInterval
+	if log {
+		cu.logged = now
+	}
+	r.mu.Unlock()
+
+	if log {
+		sunset := "none"
+		if !p.Sunset.IsZero() {
+			sunset = p.Sunset.UTC().Format(time.DateOnly)
+		}
+		r.opts.Logger.Warnf(c.Request.Context(), "Deprecated route %s (version %s, sunset %s) called by %s",
+			route, p.Version, sunset, consumer)
+	}
+}
+
+// PolicyReport is a declared policy with the usage of its deprecated routes
+type PolicyReport struct {
+	*Policy
+	State  State          `json:"state"`
+	Calls  int64          `json:"calls"`
+	Routes []*RouteReport `json:"routes"`
+}
+
+// RouteReport is the usage of a deprecated route
+type RouteReport struct {
+	Route     string            `json:"route"` // Method and route template, e.g. "GET /v1/users/:id"
+	Calls     int64             `json:"calls"`
+	LastCall  time.Time         `json:"last_call"`
+	Consumers []*ConsumerReport `json:"consumers"`
+}
+
+// ConsumerReport is the usage of a deprecated route by a consumer
+type ConsumerReport struct {
+	Consumer string    `json:"consumer"`
+	Calls    int64     `json:"calls"`
+	LastCall time.Time `json:"last_call"`
+}
+
+// Report returns the declared policies in declaration order, with routes and
+// consumers most called first
+func (r *Registry) Report() []*PolicyReport {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reports := make([]*PolicyReport, 0, len(r.policies))
+	for _, p := range r.policies {
+		report := &PolicyReport{Policy: p, State: p.State(now), Routes: []*RouteReport{}}
+		for route, usage := range r.routes[p] {
+			rr := &RouteReport{Route: route, Calls: usage.calls, LastCall: usage.last}
+			for consumer, cu := range usage.consumers {
+				rr.Consumers = append(rr.Consumers, &ConsumerReport{Consumer: consumer, Calls: cu.calls, LastCall: cu.last})
+			}
+			sort.Slice(rr.Consumers, func(i, j int) bool {
+				if rr.Consumers[i].Calls != rr.Consumers[j].Calls {
+					return rr.Consumers[i].Calls > rr.Consumers[j].Calls
+				}
+				return rr.Consumers[i].Consumer < rr.Consumers[j].Consumer
+			})
+			report.Calls += usage.calls
+			report.Routes = append(report.Routes, rr)
+		}
+		sort.Slice(report.Routes, func(i, j int) bool {
+			if report.Routes[i].Calls != report.Routes[j].Calls {
+				return report.Routes[i].Calls > report.Routes[j].Calls
+			}
+			return report.Routes[i].Route < report.Routes[j].Route
+		})
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// Handler serves the report
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp.Success(c.Writer, r.Report())
+	}
+}
@@ -0,0 +1,112 @@
+package apiversion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testLogger struct{ lines []string }
+
+func (l *testLogger) Warnf(_ context.Context, format string, args ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func newRouter(r *Registry, v1 *Policy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	e.Group("/v1", r.Declare(v1)).GET("/users/:id", ok)
+	e.Group("/v2", r.Declare(&Policy{Version: "v2"})).GET("/users/:id", ok)
+	return e
+}
+
+func call(e *gin.Engine, path, consumer string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Consumer", consumer)
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func byHeader(c *gin.Context) string { return c.GetHeader("X-Consumer") }
+
+func TestHeaders(t *testing.T) {
+	deprecated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRegistry(&Options{Consumer: byHeader})
+	e := newRouter(r, &Policy{
+		Version:    "v1",
+		Deprecated: deprecated,
+		Sunset:     time.Now().Add(24 * time.Hour),
+		Link:       "https://docs.example.com/v2",
+		Successor:  "https://api.example.com/v2",
+	})
+
+	w := call(e, "/v1/users/1", "a")
+	h := w.Header()
+	if w.Code != http.StatusOK || h.Get(HeaderVersion) != "v1" || h.Get(HeaderDeprecation) != "@1735689600" {
+		t.Errorf("status %d, headers %v", w.Code, h)
+	}
+	if _, err := http.ParseTime(h.Get(HeaderSunset)); err != nil {
+		t.Errorf("Sunset = %q: %v", h.Get(HeaderSunset), err)
+	}
+	if links := h.Values(HeaderLink); len(links) != 2 || links[1] != `<https://api.example.com/v2>; rel="successor-version"` {
+		t.Errorf("Link = %v", links)
+	}
+
+	h = call(e, "/v2/users/1", "a").Header()
+	if h.Get(HeaderVersion) != "v2" || h.Get(HeaderDeprecation) != "" || h.Get(HeaderSunset) != "" {
+		t.Errorf("active version headers %v", h)
+	}
+}
+
+func TestEnforceSunset(t *testing.T) {
+	policy := &Policy{Version: "v1", Deprecated: time.Now().Add(-48 * time.Hour), Sunset: time.Now().Add(-time.Hour)}
+
+	e := newRouter(NewRegistry(&Options{Consumer: byHeader}), policy)
+	if w := call(e, "/v1/users/1", "a"); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want sunset routes served without enforcement", w.Code)
+	}
+
+	e = newRouter(NewRegistry(&Options{Consumer: byHeader, EnforceSunset: true}), policy)
+	if w := call(e, "/v1/users/1", "a"); w.Code != http.StatusGone {
+		t.Errorf("status = %d, want 410", w.Code)
+	}
+}
+
+func TestReport(t *testing.T) {
+	log := &testLogger{}
+	r := NewRegistry(&Options{Consumer: byHeader, Logger: log, MaxConsumers: 2})
+	e := newRouter(r, &Policy{Version: "v1", Deprecated: time.Now().Add(-time.Hour)})
+
+	for _, consumer := range []string{"a", "a", "b", "c", "d"} {
+		call(e, "/v1/users/1", consumer)
+	}
+	call(e, "/v2/users/1", "a")
+
+	reports := r.Report()
+	if len(reports) != 2 || reports[0].State != StateDeprecated || reports[1].State != StateActive {
+		t.Fatalf("reports = %+v", reports)
+	}
+	if reports[0].Calls != 5 || len(reports[0].Routes) != 1 || reports[1].Calls != 0 {
+		t.Fatalf("calls = %d and %d", reports[0].Calls, reports[1].Calls)
+	}
+	route := reports[0].Routes[0]
+	if route.Route != "GET /v1/users/:id" || len(route.Consumers) != 3 {
+		t.Fatalf("route = %+v", route)
+	}
+	if c := route.Consumers[0]; c.Consumer != "a" || c.Calls != 2 {
+		t.Errorf("top consumer = %+v", c)
+	}
+	if c := route.Consumers[1]; c.Consumer != Other || c.Calls != 2 {
+		t.Errorf("consumers beyond the limit = %+v", c)
+	}
+	if len(log.lines) != 3 {
+		t.Errorf("logged %v, want each consumer once", log.lines)
+	}
+}
@@ -0,0 +1,38 @@
+// Package apiversion declares the version and deprecation of gin routes, so
+// clients learn about a deprecation from the responses they already receive
+// and operators know who still calls a route before it is removed.
+//
+// # Declaring routes
+//
+//	versions := apiversion.NewRegistry(&apiversion.Options{
+//	    Logger: logger.StdLogger(),
+//	})
+//
+//	v1 := r.Group("/v1", versions.Declare(&apiversion.Policy{
+//	    Version:    "v1",
+//	    Deprecated: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+//	    Sunset:     time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+//	    Link:       "https://docs.example.com/migrate-to-v2",
+//	    Successor:  "https://api.example.com/v2",
+//	}))
+//	v2 := r.Group("/v2", versions.Declare(&apiversion.Policy{Version: "v2"}))
+//
+//	r.GET("/admin/deprecations", versions.Handler())
+//
+// A policy can be declared on a group or a single route, but a route should be
+// covered by one declaration only.
+//
+// # Headers
+//
+// Responses carry API-Version and, for deprecated routes, the Deprecation
+// header of RFC 9745, the Sunset header of RFC 8594 and Link headers to the
+// deprecation documentation and the successor version. With EnforceSunset,
+// requests after the sunset date get 410 Gone.
+//
+// # Usage
+//
+// Calls of deprecated routes are counted per route and consumer, by default
+// the user ID or else the client IP, and the first call of a consumer is logged
+// once per LogInterval. Report, also served by Handler, lists every declared
+// policy with its state and the routes and consumers still calling it.
+package apiversion
@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"sync"
+
+	"github.com/sony/gobreaker"
+)
+
+// breakers holds one circuit breaker per host
+type breakers struct {
+	cfg Breaker
+
+	mu    sync.Mutex
+	hosts map[string]*gobreaker.TwoStepCircuitBreaker
+}
+
+func newBreakers(cfg Breaker) *breakers {
+	return &breakers{cfg: cfg, hosts: make(map[string]*gobreaker.TwoStepCircuitBreaker)}
+}
+
+// get returns the breaker of host, creating it if needed
+func (b *breakers) get(host string) *gobreaker.TwoStepCircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb, ok := b.hosts[host]
+	if !ok {
+		cb = gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+			Name:        host,
+			MaxRequests: b.cfg.HalfOpenMax,
+			Interval:    b.cfg.Interval,
+			Timeout:     b.cfg.OpenTimeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				if counts.ConsecutiveFailures >= b.cfg.ConsecutiveFailures {
+					return true
+				}
+				failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+				return counts.Requests >= b.cfg.MinRequests && failureRatio >= b.cfg.FailureRatio
+			},
+		})
+		b.hosts[host] = cb
+	}
+	return cb
+}
+
+// states reports the state of every host's breaker
+func (b *breakers) states() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]string, len(b.hosts))
+	for host, cb := range b.hosts {
+		states[host] = cb.State().String()
+	}
+	return states
+}
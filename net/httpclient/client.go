@@ -0,0 +1,323 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+// ErrCircuitOpen is returned without a request while the breaker of a host is open
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker open")
+
+// StatusError is returned by the JSON helpers for non-2xx responses
+type StatusError struct {
+	StatusCode int
+	Body       []byte // Up to Log.MaxBodySize bytes of the response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httpclient: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Logger receives request logs, *logger.Logger implements it
+type Logger interface {
+	Infof(ctx context.Context, format string, args ...any)
+	Warnf(ctx context.Context, format string, args ...any)
+}
+
+// Client sends outbound HTTP requests with retries, per-host circuit
+// breakers, timeout budgets and trace propagation
+type Client struct {
+	cfg      Config
+	hc       *http.Client
+	breakers *breakers
+	logger   Logger
+}
+
+type Option func(*Client)
+
+// WithTransport sets the transport, defaults to http.DefaultTransport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		if rt != nil {
+			c.hc.Transport = rt
+		}
+	}
+}
+
+// WithLogger sets the logger of Log
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// New creates a Client, a nil cfg uses the defaults
+func New(cfg *Config, opts ...Option) *Client {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	c := &Client{
+		cfg: cfg.withDefaults(),
+		hc:  &http.Client{Transport: http.DefaultTransport},
+	}
+	c.breakers = newBreakers(c.cfg.Breaker)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// StandardClient returns an *http.Client sending through c, for SDKs that
+// take one
+func (c *Client) StandardClient() *http.Client {
+	return &http.Client{
+		Transport: c,
+		// c already followed the redirects
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.Do(req)
+}
+
+// Stats reports the circuit breaker state of every host
+func (c *Client) Stats() map[string]any {
+	return map[string]any{"breakers": c.breakers.states()}
+}
+
+// Do sends a request, retrying failed attempts within the Timeout budget.
+// The budget ends when the response body is closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, _ := ctxutil.EnsureTraceID(req.Context())
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+
+	retryable := c.cfg.MaxRetries > 0 && idempotent(req) &&
+		(req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		resp, err = c.attempt(ctx, req, attempt)
+		if !retryable || attempt >= c.cfg.MaxRetries || !c.shouldRetry(ctx, resp, err) {
+			break
+		}
+		wait := c.backoff(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			break // Hand out the last result rather than a budget error
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			cancel()
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// attempt sends one attempt through the host's circuit breaker
+func (c *Client) attempt(ctx context.Context, req *http.Request, n int) (*http.Response, error) {
+	host := req.URL.Host
+	var done func(bool)
+	if !c.cfg.Breaker.Disabled {
+		var err error
+		if done, err = c.breakers.get(host).Allow(); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+	}
+
+	actx, cancel := context.WithTimeout(ctx, c.cfg.AttemptTimeout)
+	r := req.Clone(actx)
+	if n > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			if done != nil {
+				done(true)
+			}
+			return nil, fmt.Errorf("httpclient: rewind body: %w", err)
+		}
+		r.Body = body
+	}
+	if c.cfg.UserAgent != "" && r.Header.Get("User-Agent") == "" {
+		r.Header.Set("User-Agent", c.cfg.UserAgent)
+	}
+	for k, v := range c.cfg.Headers {
+		if r.Header.Get(k) == "" {
+			r.Header.Set(k, v)
+		}
+	}
+	injectTrace(ctx, r)
+
+	start := time.Now()
+	resp, err := c.hc.Do(r)
+	if done != nil {
+		// The caller giving up says nothing about the host
+		done(ctx.Err() != nil || (err == nil && resp.StatusCode < http.StatusInternalServerError))
+	}
+	if c.cfg.Log.Enabled && c.logger != nil {
+		resp = c.log(ctx, req, resp, err, n, time.Since(start))
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying
+func (c *Client) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	return slices.Contains(c.cfg.RetryStatus, resp.StatusCode)
+}
+
+// backoff returns the wait before the next attempt: Retry-After when the
+// server sent it, exponential backoff with jitter otherwise
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait := retryAfter(resp.Header.Get("Retry-After")); wait > 0 {
+			return wait
+		}
+	}
+	wait := c.cfg.RetryWaitMin << attempt
+	if wait <= 0 || wait > c.cfg.RetryWaitMax {
+		wait = c.cfg.RetryWaitMax
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// idempotent reports whether a request can be sent again
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// cancelBody releases a context when the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Get sends a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post sends a POST request, which is not retried without an Idempotency-Key
+func (c *Client) Post(ctx context.Context, url, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// GetJSON sends a GET request and decodes the JSON response into out
+func (c *Client) GetJSON(ctx context.Context, url string, out any) error {
+	return c.DoJSON(ctx, http.MethodGet, url, nil, out)
+}
+
+// PostJSON posts in as JSON and decodes the JSON response into out
+func (c *Client) PostJSON(ctx context.Context, url string, in, out any) error {
+	return c.DoJSON(ctx, http.MethodPost, url, in, out)
+}
+
+// DoJSON sends in as the JSON body, if not nil, and decodes the response into
+// out, if not nil. Non-2xx responses return a *StatusError.
+func (c *Client) DoJSON(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("httpclient: encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(c.cfg.Log.MaxBodySize)))
+		return &StatusError{StatusCode: resp.StatusCode, Body: data}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("httpclient: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Infof(_ context.Context, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(ctx context.Context, format string, args ...any) {
+	l.Infof(ctx, format, args...)
+}
+
+func fastConfig() *Config {
+	return &Config{
+		Timeout:      2 * time.Second,
+		MaxRetries:   2,
+		RetryWaitMin: time.Millisecond,
+		RetryWaitMax: 5 * time.Millisecond,
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"ncore"}`))
+	}))
+	defer srv.Close()
+
+	c := New(fastConfig())
+	var out struct{ Name string }
+	if err := c.GetJSON(context.Background(), srv.URL, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "ncore" || calls.Load() != 3 {
+		t.Fatalf("name %q after %d calls", out.Name, calls.Load())
+	}
+}
+
+func TestPostNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(fastConfig())
+	err := c.PostJSON(context.Background(), srv.URL, map[string]string{"a": "b"}, nil)
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("POST sent %d times", calls.Load())
+	}
+
+	// An Idempotency-Key makes it retryable, with the body replayed
+	calls.Store(0)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "k1")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 3 {
+		t.Fatalf("keyed POST sent %d times", calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := fastConfig()
+	cfg.MaxRetries = -1
+	cfg.Breaker = Breaker{ConsecutiveFailures: 3, OpenTimeout: time.Minute}
+	c := New(cfg)
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.Get(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := c.Get(context.Background(), srv.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("server saw %d calls", calls.Load())
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	if state := c.Stats()["breakers"].(map[string]string)[host]; state != "open" {
+		t.Fatalf("state = %q", state)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := New(fastConfig())
+	start := time.Now()
+	resp, err := c.Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || time.Since(start) > time.Second {
+		t.Fatalf("status %d after %s", resp.StatusCode, time.Since(start))
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	ctx := ctxutil.SetTraceID(context.Background(), "4bf92f35-77b3-4da6-a3ce-929d0e0e4736")
+	resp, err := New(nil).Get(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	h := <-headers
+	if h.Get(HeaderTraceID) != "4bf92f35-77b3-4da6-a3ce-929d0e0e4736" {
+		t.Fatalf("trace id = %q", h.Get(HeaderTraceID))
+	}
+	parts := strings.Split(h.Get(HeaderTraceParent), "-")
+	if len(parts) != 4 || parts[1] != "4bf92f3577b34da6a3ce929d0e0e4736" || len(parts[2]) != 16 {
+		t.Fatalf("traceparent = %q", h.Get(HeaderTraceParent))
+	}
+}
+
+func TestLogBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	l := &recordingLogger{}
+	c := New(&Config{Log: Log{Enabled: true, Bodies: true, MaxBodySize: 10}}, WithLogger(l))
+	resp, err := c.Post(context.Background(), srv.URL, "text/plain", []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) != 100 {
+		t.Fatalf("caller read %d bytes", len(body))
+	}
+	if len(l.lines) != 1 || !strings.Contains(l.lines[0], "request hello") || !strings.Contains(l.lines[0], "xxxxxxxxxx...(truncated)") {
+		t.Fatalf("log = %v", l.lines)
+	}
+}
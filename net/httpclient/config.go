@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Config configures a Client
+type Config struct {
+	// Timeout is the budget of a whole call, retries and backoff included.
+	// Defaults to 30s.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// AttemptTimeout bounds a single attempt, defaults to 10s
+	AttemptTimeout time.Duration `json:"attempt_timeout" yaml:"attempt_timeout"`
+
+	// MaxRetries defaults to 2, -1 disables retries. Only idempotent methods
+	// and requests with an Idempotency-Key header are retried.
+	MaxRetries   int           `json:"max_retries" yaml:"max_retries"`
+	RetryWaitMin time.Duration `json:"retry_wait_min" yaml:"retry_wait_min"` // First backoff, defaults to 100ms
+	RetryWaitMax time.Duration `json:"retry_wait_max" yaml:"retry_wait_max"` // Largest backoff, defaults to 2s
+	RetryStatus  []int         `json:"retry_status" yaml:"retry_status"`     // Defaults to 429, 502, 503 and 504
+
+	Breaker Breaker `json:"breaker" yaml:"breaker"`
+	Log     Log     `json:"log" yaml:"log"`
+
+	UserAgent string            `json:"user_agent" yaml:"user_agent"`
+	Headers   map[string]string `json:"headers" yaml:"headers"` // Set on every request that lacks them
+}
+
+// Breaker configures the per-host circuit breakers
+type Breaker struct {
+	Disabled bool `json:"disabled" yaml:"disabled"`
+	// ConsecutiveFailures trips the breaker, defaults to 5
+	ConsecutiveFailures uint32 `json:"consecutive_failures" yaml:"consecutive_failures"`
+	// FailureRatio trips the breaker once MinRequests were seen in Interval,
+	// defaults to 0.6 of 10 requests
+	FailureRatio float64       `json:"failure_ratio" yaml:"failure_ratio"`
+	MinRequests  uint32        `json:"min_requests" yaml:"min_requests"`
+	Interval     time.Duration `json:"interval" yaml:"interval"`           // Counts reset period while closed, defaults to 60s
+	OpenTimeout  time.Duration `json:"open_timeout" yaml:"open_timeout"`   // Open period before probing, defaults to 30s
+	HalfOpenMax  uint32        `json:"half_open_max" yaml:"half_open_max"` // Probes while half-open, defaults to 1
+}
+
+// Log configures request and response logging
+type Log struct {
+	Enabled     bool `json:"enabled" yaml:"enabled"`
+	Bodies      bool `json:"bodies" yaml:"bodies"`               // Log request and response bodies
+	MaxBodySize int  `json:"max_body_size" yaml:"max_body_size"` // Bytes of a logged body, defaults to 4KB
+}
+
+// withDefaults returns a copy of c with defaults applied
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = 10 * time.Second
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 2
+	} else if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.RetryWaitMin <= 0 {
+		c.RetryWaitMin = 100 * time.Millisecond
+	}
+	if c.RetryWaitMax < c.RetryWaitMin {
+		c.RetryWaitMax = max(2*time.Second, c.RetryWaitMin)
+	}
+	if len(c.RetryStatus) == 0 {
+		c.RetryStatus = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+
+	b := &c.Breaker
+	if b.ConsecutiveFailures == 0 {
+		b.ConsecutiveFailures = 5
+	}
+	if b.FailureRatio <= 0 {
+		b.FailureRatio = 0.6
+	}
+	if b.MinRequests == 0 {
+		b.MinRequests = 10
+	}
+	if b.Interval <= 0 {
+		b.Interval = 60 * time.Second
+	}
+	if b.OpenTimeout <= 0 {
+		b.OpenTimeout = 30 * time.Second
+	}
+	if b.HalfOpenMax == 0 {
+		b.HalfOpenMax = 1
+	}
+
+	if c.Log.MaxBodySize <= 0 {
+		c.Log.MaxBodySize = 4 << 10
+	}
+	return c
+}
@@ -0,0 +1,43 @@
+// Package httpclient provides an outbound HTTP client with retries, per-host
+// circuit breakers, timeout budgets, trace propagation and request logging.
+//
+// # Usage
+//
+//	client := httpclient.New(&httpclient.Config{
+//	    Timeout:        10 * time.Second, // Whole call, retries included
+//	    AttemptTimeout: 3 * time.Second,
+//	    MaxRetries:     3,
+//	    Log:            httpclient.Log{Enabled: true, Bodies: true, MaxBodySize: 2048},
+//	}, httpclient.WithLogger(logger.StdLogger()))
+//
+//	var user User
+//	err := client.GetJSON(ctx, "https://api.example.com/users/1", &user)
+//
+//	var status *httpclient.StatusError
+//	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound {
+//	    // ...
+//	}
+//
+//	// For SDKs taking an *http.Client
+//	sdk := example.NewClient(example.WithHTTPClient(client.StandardClient()))
+//
+// # Retries
+//
+// Idempotent methods, and other requests carrying an Idempotency-Key header,
+// are retried after transport errors and 429, 502, 503 and 504 responses.
+// Backoff is exponential with jitter, or follows Retry-After. A retry that
+// would not fit in the remaining Timeout is not attempted and the last result
+// is returned.
+//
+// # Circuit breakers
+//
+// Each host has a breaker counting transport errors and 5xx responses. While
+// it is open, requests to the host fail with ErrCircuitOpen without being
+// sent.
+//
+// # Tracing
+//
+// The ctxutil trace ID, created when the context has none, is sent as
+// X-Trace-Id and as a W3C traceparent header with a new span ID per attempt.
+// Headers already set by the caller or an instrumented transport are kept.
+package httpclient
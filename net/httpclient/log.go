@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// log logs an attempt, returning resp with its body still readable in full
+// when a prefix was read for the log
+func (c *Client) log(ctx context.Context, req *http.Request, resp *http.Response, err error, attempt int, took time.Duration) *http.Response {
+	url := req.URL.Redacted()
+	if err != nil {
+		c.logger.Warnf(ctx, "httpclient: %s %s attempt %d failed after %s: %v", req.Method, url, attempt+1, took, err)
+		return resp
+	}
+
+	if !c.cfg.Log.Bodies {
+		c.logf(ctx, resp.StatusCode, "httpclient: %s %s attempt %d: %d in %s", req.Method, url, attempt+1, resp.StatusCode, took)
+		return resp
+	}
+
+	var reqBody []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, int64(c.cfg.Log.MaxBodySize)+1))
+			_ = body.Close()
+		}
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, int64(c.cfg.Log.MaxBodySize)+1))
+	resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(respBody), resp.Body), Closer: resp.Body}
+
+	c.logf(ctx, resp.StatusCode, "httpclient: %s %s attempt %d: %d in %s, request %s, response %s",
+		req.Method, url, attempt+1, resp.StatusCode, took, c.capped(reqBody), c.capped(respBody))
+	return resp
+}
+
+// logf logs server errors as warnings
+func (c *Client) logf(ctx context.Context, status int, format string, args ...any) {
+	if status >= http.StatusInternalServerError {
+		c.logger.Warnf(ctx, format, args...)
+		return
+	}
+	c.logger.Infof(ctx, format, args...)
+}
+
+// capped returns a logged body, marking bodies longer than MaxBodySize
+func (c *Client) capped(body []byte) string {
+	if len(body) > c.cfg.Log.MaxBodySize {
+		return string(body[:c.cfg.Log.MaxBodySize]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// readCloser joins a replayed body prefix with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+const (
+	// HeaderTraceID carries the ctxutil trace ID
+	HeaderTraceID = "X-Trace-Id"
+	// HeaderTraceParent is the W3C trace context header
+	HeaderTraceParent = "traceparent"
+)
+
+// injectTrace sets the trace headers of an attempt, keeping headers set by
+// the caller or an instrumented transport. Every attempt is a new span of the
+// caller's trace.
+func injectTrace(ctx context.Context, req *http.Request) {
+	traceID := ctxutil.GetTraceID(ctx)
+	if traceID == "" {
+		return
+	}
+	if req.Header.Get(HeaderTraceID) == "" {
+		req.Header.Set(HeaderTraceID, traceID)
+	}
+	if req.Header.Get(HeaderTraceParent) == "" {
+		req.Header.Set(HeaderTraceParent, "00-"+w3cTraceID(traceID)+"-"+newSpanID()+"-01")
+	}
+}
+
+// w3cTraceID turns a trace ID into the 32 hex digits of a W3C trace ID. UUIDs
+// keep their digits, other IDs are hashed.
+func w3cTraceID(traceID string) string {
+	id := strings.ToLower(strings.ReplaceAll(traceID, "-", ""))
+	if len(id) == 32 && strings.Trim(id, "0123456789abcdef") == "" && strings.Trim(id, "0") != "" {
+		return id
+	}
+	sum := sha256.Sum256([]byte(traceID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// newSpanID returns 16 random hex digits
+func newSpanID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
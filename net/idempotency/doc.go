@@ -0,0 +1,29 @@
+// Package idempotency provides gin middleware honoring the Idempotency-Key
+// header, so retried requests replay the first response instead of creating
+// duplicate orders or payments.
+//
+// # Stores
+//
+//	// Redis, records expire with key TTLs
+//	store := idempotency.NewRedisStore(redisClient, "idempotency")
+//
+//	// SQL, expired rows are removed by Cleanup
+//	store, err := idempotency.NewSQLStore(db, "postgres", "idempotency_keys")
+//	err = store.Migrate(ctx)
+//	go idempotency.RunCleanup(ctx, store, time.Hour)
+//
+// # Middleware
+//
+//	mw, err := idempotency.Middleware(&idempotency.Options{
+//	    Store: store,
+//	    TTL:   24 * time.Hour,
+//	    Scope: idempotency.ScopeUserRoute,
+//	})
+//	r.Use(mw)
+//
+// The first request with a key is reserved and its status, headers and body
+// are stored once it completes. Retries with the same key get the stored
+// response with an Idempotent-Replayed header. A retry while the first request
+// is still running gets 409, and reusing a key for a different request gets 422.
+// Responses with status 5xx are not stored so the client can retry.
+package idempotency
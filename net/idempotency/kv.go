@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/data/kv"
+)
+
+// KVStore stores records in an embedded kv store, for single-binary
+// deployments without Redis
+type KVStore struct {
+	db     *kv.DB
+	prefix string
+}
+
+// NewKVStore creates a kv backed store
+func NewKVStore(db *kv.DB, prefix string) *KVStore {
+	if prefix == "" {
+		prefix = "idempotency"
+	}
+	return &KVStore{db: db, prefix: prefix}
+}
+
+// Reserve records key as in progress unless it exists
+func (s *KVStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	record := &Record{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing *Record
+	err = s.db.Update(ctx, s.key(key), func(value []byte, found bool) ([]byte, time.Duration, error) {
+		if !found {
+			return data, ttl, nil
+		}
+		existing = &Record{}
+		if err := json.Unmarshal(value, existing); err != nil {
+			return nil, 0, fmt.Errorf("invalid idempotency record: %v", err)
+		}
+		return nil, 0, kv.ErrKeep
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %v", err)
+	}
+	if existing != nil {
+		return existing, false, nil
+	}
+	return record, true, nil
+}
+
+// Complete stores the response of a reserved key
+func (s *KVStore) Complete(ctx context.Context, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Set(ctx, s.key(record.Key), data, ttl); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release removes a reservation
+func (s *KVStore) Release(ctx context.Context, key string) error {
+	return s.db.Delete(ctx, s.key(key))
+}
+
+// Cleanup is a no-op, the kv store sweeps expired keys itself
+func (s *KVStore) Cleanup(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// key returns the kv key of an idempotency key
+func (s *KVStore) key(key string) string {
+	return s.prefix + ":" + key
+}
@@ -0,0 +1,237 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/ecode"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// Idempotency headers
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// Scope decides which requests share an idempotency key namespace
+type Scope string
+
+const (
+	// ScopeGlobal shares keys across all users and routes
+	ScopeGlobal Scope = "global"
+	// ScopeUser isolates keys per authenticated user
+	ScopeUser Scope = "user"
+	// ScopeRoute isolates keys per route
+	ScopeRoute Scope = "route"
+	// ScopeUserRoute isolates keys per user and route
+	ScopeUserRoute Scope = "user_route"
+)
+
+// Options configures the idempotency middleware
+type Options struct {
+	Store       Store
+	TTL         time.Duration // How long responses are replayed, defaults to 24h
+	Header      string        // Defaults to Idempotency-Key
+	Scope       Scope         // Defaults to ScopeUserRoute
+	Methods     []string      // Defaults to POST and PATCH
+	Required    bool          // Reject requests without a key
+	MaxKeyLen   int           // Defaults to 255
+	MaxBodySize int64         // Largest request body hashed, defaults to 1MB
+	FailOpen    bool          // Process requests without idempotency when the store fails
+}
+
+// Middleware creates gin middleware that replays responses of retried requests
+func Middleware(opts *Options) (gin.HandlerFunc, error) {
+	if opts == nil || opts.Store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	o := *opts
+	if o.TTL <= 0 {
+		o.TTL = 24 * time.Hour
+	}
+	if o.Header == "" {
+		o.Header = HeaderKey
+	}
+	if o.Scope == "" {
+		o.Scope = ScopeUserRoute
+	}
+	if len(o.Methods) == 0 {
+		o.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if o.MaxKeyLen <= 0 {
+		o.MaxKeyLen = 255
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = 1 << 20
+	}
+
+	methods := make(map[string]bool, len(o.Methods))
+	for _, m := range o.Methods {
+		methods[m] = true
+	}
+
+	return func(c *gin.Context) {
+		if !methods[c.Request.Method] {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(o.Header)
+		if key == "" {
+			if o.Required {
+				resp.Fail(c.Writer, resp.BadRequest(o.Header+" header is required"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > o.MaxKeyLen {
+			resp.Fail(c.Writer, resp.BadRequest(o.Header+" header is too long"))
+			c.Abort()
+			return
+		}
+
+		requestHash, err := hashRequest(c, o.MaxBodySize)
+		if err != nil {
+			resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+			c.Abort()
+			return
+		}
+
+		storeKey := scopedKey(c, o.Scope, key)
+		// Keep storing the outcome even if the client disconnects
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		record, reserved, err := o.Store.Reserve(ctx, storeKey, requestHash, o.TTL)
+		if err != nil {
+			if o.FailOpen {
+				c.Next()
+				return
+			}
+			resp.Fail(c.Writer, resp.ServiceUnavailable("idempotency store unavailable"))
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				resp.Fail(c.Writer, &resp.Exception{
+					Status:  http.StatusUnprocessableEntity,
+					Code:    ecode.RequestErr,
+					Message: o.Header + " was already used with a different request",
+				})
+			case !record.Completed:
+				resp.Fail(c.Writer, resp.Conflict("a request with this "+o.Header+" is still in progress"))
+			default:
+				replay(c, record)
+			}
+			c.Abort()
+			return
+		}
+
+		w := &recorder{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		defer func() {
+			if r := recover(); r != nil {
+				_ = o.Store.Release(ctx, storeKey)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			// Server errors are not final, let the client retry
+			_ = o.Store.Release(ctx, storeKey)
+			return
+		}
+
+		sum := sha256.Sum256(w.body.Bytes())
+		record.Completed = true
+		record.Status = status
+		record.Header = w.Header().Clone()
+		record.Body = w.body.Bytes()
+		record.BodyHash = hex.EncodeToString(sum[:])
+		if err := o.Store.Complete(ctx, record, o.TTL); err != nil {
+			_ = o.Store.Release(ctx, storeKey)
+		}
+	}, nil
+}
+
+// hashRequest hashes method, path and body, restoring the body for handlers
+func hashRequest(c *gin.Context, maxBodySize int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + "\n" + c.Request.URL.Path + "\n"))
+
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBodySize+1))
+		if err != nil {
+			return "", err
+		}
+		if int64(len(body)) > maxBodySize {
+			return "", fmt.Errorf("request body exceeds %d bytes", maxBodySize)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// scopedKey prefixes the client key according to scope
+func scopedKey(c *gin.Context, scope Scope, key string) string {
+	var prefix string
+	if scope == ScopeUser || scope == ScopeUserRoute {
+		prefix += "user:" + ctxutil.GetUserID(ctxutil.WithGinContext(c.Request.Context(), c)) + ":"
+	}
+	if scope == ScopeRoute || scope == ScopeUserRoute {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		prefix += "route:" + c.Request.Method + " " + route + ":"
+	}
+	return prefix + key
+}
+
+// replay writes a stored response
+func replay(c *gin.Context, record *Record) {
+	header := c.Writer.Header()
+	for k, v := range record.Header {
+		header[k] = v
+	}
+	header.Set(HeaderReplayed, "true")
+	c.Writer.WriteHeader(record.Status)
+	_, _ = c.Writer.Write(record.Body)
+}
+
+// recorder captures the response body while writing it
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes and captures data
+func (w *recorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes and captures s
+func (w *recorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,150 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/data/kv"
+)
+
+func newTestRouter(t *testing.T, opts *Options, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if opts.Store == nil {
+		db, err := kv.Open(filepath.Join(t.TempDir(), "idempotency.db"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+		opts.Store = NewKVStore(db, "")
+	}
+	mw, err := Middleware(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(mw)
+	r.Any("/orders", handler)
+	return r
+}
+
+func send(r http.Handler, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareRequiresStore(t *testing.T) {
+	for _, opts := range []*Options{nil, {}} {
+		if mw, err := Middleware(opts); err == nil || mw != nil {
+			t.Errorf("Middleware(%+v) = %v, want an error", opts, err)
+		}
+	}
+}
+
+func TestMiddlewareReplaysResponse(t *testing.T) {
+	var calls atomic.Int32
+	r := newTestRouter(t, &Options{Scope: ScopeGlobal}, func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("X-Order", strconv.Itoa(int(n)))
+		c.String(http.StatusCreated, "order %d", n)
+	})
+
+	first := send(r, http.MethodPost, "k1", `{"amount":1}`)
+	if first.Code != http.StatusCreated || first.Body.String() != "order 1" {
+		t.Fatalf("first response %d %q", first.Code, first.Body.String())
+	}
+
+	again := send(r, http.MethodPost, "k1", `{"amount":1}`)
+	if again.Code != http.StatusCreated || again.Body.String() != "order 1" {
+		t.Fatalf("replayed response %d %q", again.Code, again.Body.String())
+	}
+	if again.Header().Get(HeaderReplayed) != "true" || again.Header().Get("X-Order") != "1" {
+		t.Fatalf("unexpected replay headers %v", again.Header())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+
+	// A new key and requests without a key reach the handler
+	if w := send(r, http.MethodPost, "k2", `{"amount":1}`); w.Body.String() != "order 2" {
+		t.Fatalf("new key response %q", w.Body.String())
+	}
+	if w := send(r, http.MethodPost, "", `{"amount":1}`); w.Body.String() != "order 3" {
+		t.Fatalf("response without key %q", w.Body.String())
+	}
+	// Methods other than POST and PATCH are not deduplicated
+	send(r, http.MethodPut, "k1", `{"amount":1}`)
+	if calls.Load() != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestMiddlewareRejectsConflicts(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := newTestRouter(t, &Options{Scope: ScopeGlobal}, func(c *gin.Context) {
+		if c.GetHeader(HeaderKey) == "slow" {
+			close(started)
+			<-release
+		}
+		c.String(http.StatusOK, "done")
+	})
+
+	send(r, http.MethodPost, "k1", `{"amount":1}`)
+	if w := send(r, http.MethodPost, "k1", `{"amount":2}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reusing a key for another request: status %d, want 422", w.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send(r, http.MethodPost, "slow", `{}`) }()
+	<-started
+	if w := send(r, http.MethodPost, "slow", `{}`); w.Code != http.StatusConflict {
+		t.Fatalf("retry while in progress: status %d, want 409", w.Code)
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Fatalf("first request status %d", w.Code)
+	}
+	if w := send(r, http.MethodPost, "slow", `{}`); w.Code != http.StatusOK || w.Header().Get(HeaderReplayed) != "true" {
+		t.Fatalf("retry after completion: status %d, headers %v", w.Code, w.Header())
+	}
+}
+
+func TestMiddlewareRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	r := newTestRouter(t, &Options{Scope: ScopeGlobal, Required: true}, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.String(http.StatusInternalServerError, "failed")
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := send(r, http.MethodPost, "k1", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first status %d", w.Code)
+	}
+	if w := send(r, http.MethodPost, "k1", `{}`); w.Code != http.StatusOK || w.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("retry after a server error: status %d, headers %v", w.Code, w.Header())
+	}
+	if w := send(r, http.MethodPost, "", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("missing required key: status %d, want 400", w.Code)
+	}
+	if w := send(r, http.MethodPost, strings.Repeat("k", 256), `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("long key: status %d, want 400", w.Code)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls.Load())
+	}
+}
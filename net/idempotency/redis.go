@@ -0,0 +1,100 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore stores records in Redis, expiring them with key TTLs
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis backed store
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "idempotency"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Reserve records key as in progress unless it exists
+func (s *RedisStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	record := &Record{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Retry once in case the existing key expires between SETNX and GET
+	for range 2 {
+		ok, err := s.client.SetNX(ctx, s.key(key), data, ttl).Result()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %v", err)
+		}
+		if ok {
+			return record, true, nil
+		}
+
+		existing, err := s.get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+
+	return nil, false, fmt.Errorf("failed to reserve idempotency key %s", key)
+}
+
+// Complete stores the response of a reserved key
+func (s *RedisStore) Complete(ctx context.Context, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, s.key(record.Key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release removes a reservation
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.key(key)).Err()
+}
+
+// Cleanup is a no-op, Redis expires keys itself
+func (s *RedisStore) Cleanup(context.Context) (int64, error) {
+	return 0, nil
+}
+
+// get loads a record
+func (s *RedisStore) get(ctx context.Context, key string) (*Record, error) {
+	data, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %v", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid idempotency record: %v", err)
+	}
+	return &record, nil
+}
+
+// key returns the Redis key of an idempotency key
+func (s *RedisStore) key(key string) string {
+	return s.prefix + ":" + key
+}
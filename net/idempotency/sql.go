@@ -0,0 +1,168 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// SQLStore stores records in a SQL table, expired rows are removed by Cleanup
+type SQLStore struct {
+	db       *sql.DB
+	table    string
+	postgres bool
+}
+
+// NewSQLStore creates a SQL backed store.
+// driver selects the placeholder style: "postgres" uses $n, others use ?.
+func NewSQLStore(db *sql.DB, driver, table string) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+	if table == "" {
+		table = "idempotency_keys"
+	}
+	if !sqlq.ValidIdentifier(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+
+	return &SQLStore{
+		db:       db,
+		table:    table,
+		postgres: driver == "postgres" || driver == "pgx",
+	}, nil
+}
+
+// Migrate creates the table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	body := "BLOB"
+	if s.postgres {
+		body = "BYTEA"
+	}
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR(255) PRIMARY KEY,
+			request_hash VARCHAR(64) NOT NULL,
+			completed BOOLEAN NOT NULL DEFAULT FALSE,
+			status INTEGER NOT NULL DEFAULT 0,
+			header TEXT,
+			body %s,
+			body_hash VARCHAR(64),
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL
+		)`, s.table, body))
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency table: %v", err)
+	}
+	return nil
+}
+
+// Reserve records key as in progress unless it exists
+func (s *SQLStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now().UTC()
+	record := &Record{Key: key, RequestHash: requestHash, CreatedAt: now}
+
+	for range 2 {
+		_, insertErr := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf(
+			"INSERT INTO %s (id, request_hash, completed, status, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)", s.table)),
+			key, requestHash, false, 0, now, now.Add(ttl))
+		if insertErr == nil {
+			return record, true, nil
+		}
+
+		// The insert failed, most likely on the primary key
+		existing, expiresAt, err := s.get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %v", insertErr)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if expiresAt.After(now) {
+			return existing, false, nil
+		}
+
+		// Expired but not cleaned up yet, drop it and try again
+		if err := s.Release(ctx, key); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to reserve idempotency key %s", key)
+}
+
+// Complete stores the response of a reserved key
+func (s *SQLStore) Complete(ctx context.Context, record *Record, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.rebind(fmt.Sprintf(
+		"UPDATE %s SET completed = ?, status = ?, header = ?, body = ?, body_hash = ?, expires_at = ? WHERE id = ?", s.table)),
+		true, record.Status, string(header), record.Body, record.BodyHash, time.Now().UTC().Add(ttl), record.Key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release removes a reservation
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.table)), key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
+
+// Cleanup removes expired records
+func (s *SQLStore) Cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", s.table)), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up idempotency keys: %v", err)
+	}
+	return res.RowsAffected()
+}
+
+// get loads a record and its expiry
+func (s *SQLStore) get(ctx context.Context, key string) (*Record, time.Time, error) {
+	var (
+		record    = &Record{Key: key}
+		header    sql.NullString
+		bodyHash  sql.NullString
+		expiresAt time.Time
+	)
+
+	err := s.db.QueryRowContext(ctx, s.rebind(fmt.Sprintf(
+		"SELECT request_hash, completed, status, header, body, body_hash, created_at, expires_at FROM %s WHERE id = ?", s.table)), key).
+		Scan(&record.RequestHash, &record.Completed, &record.Status, &header, &record.Body, &bodyHash, &record.CreatedAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load idempotency key: %v", err)
+	}
+
+	record.BodyHash = bodyHash.String
+	if header.Valid && header.String != "" {
+		if err := json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid idempotency record header: %v", err)
+		}
+	}
+	return record, expiresAt, nil
+}
+
+// rebind converts ? placeholders to $n for Postgres
+func (s *SQLStore) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := NewSQLStore(db, "sqlite3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewSQLStoreValidates(t *testing.T) {
+	if _, err := NewSQLStore(nil, "sqlite3", ""); err == nil {
+		t.Error("expected an error for a nil database")
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := NewSQLStore(db, "sqlite3", "keys; DROP TABLE users"); err == nil {
+		t.Error("expected an error for an invalid table name")
+	}
+}
+
+func TestSQLStoreReserveAndComplete(t *testing.T) {
+	s := newTestSQLStore(t)
+	ctx := context.Background()
+
+	record, reserved, err := s.Reserve(ctx, "k1", "hash", time.Hour)
+	if err != nil || !reserved {
+		t.Fatalf("Reserve = %v, %v", reserved, err)
+	}
+
+	existing, reserved, err := s.Reserve(ctx, "k1", "hash", time.Hour)
+	if err != nil || reserved || existing.Completed || existing.RequestHash != "hash" {
+		t.Fatalf("second Reserve = %+v, %v, %v", existing, reserved, err)
+	}
+
+	record.Completed = true
+	record.Status = http.StatusCreated
+	record.Header = http.Header{"Content-Type": {"application/json"}}
+	record.Body = []byte(`{"id":1}`)
+	record.BodyHash = "body"
+	if err := s.Complete(ctx, record, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	existing, reserved, err = s.Reserve(ctx, "k1", "hash", time.Hour)
+	if err != nil || reserved {
+		t.Fatalf("Reserve of a completed key = %v, %v", reserved, err)
+	}
+	if !existing.Completed || existing.Status != http.StatusCreated || string(existing.Body) != `{"id":1}` ||
+		existing.Header.Get("Content-Type") != "application/json" || existing.BodyHash != "body" {
+		t.Fatalf("unexpected stored record %+v", existing)
+	}
+
+	if err := s.Release(ctx, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, reserved, err := s.Reserve(ctx, "k1", "other", time.Hour); err != nil || !reserved {
+		t.Fatalf("Reserve after Release = %v, %v", reserved, err)
+	}
+}
+
+func TestSQLStoreExpiry(t *testing.T) {
+	s := newTestSQLStore(t)
+	ctx := context.Background()
+
+	if _, _, err := s.Reserve(ctx, "old", "first", -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// An expired record that was not cleaned up yet is replaced
+	record, reserved, err := s.Reserve(ctx, "old", "second", time.Hour)
+	if err != nil || !reserved || record.RequestHash != "second" {
+		t.Fatalf("Reserve of an expired key = %+v, %v, %v", record, reserved, err)
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if _, _, err := s.Reserve(ctx, key, "hash", -time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := s.Cleanup(ctx); err != nil || n != 2 {
+		t.Fatalf("Cleanup = %d, %v, want 2", n, err)
+	}
+	if _, reserved, _ := s.Reserve(ctx, "old", "second", time.Hour); reserved {
+		t.Fatal("Cleanup removed a live record")
+	}
+}
@@ -0,0 +1,51 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned when a key has no record
+var ErrNotFound = errors.New("idempotency key not found")
+
+// Record is the stored outcome of the first request with a key
+type Record struct {
+	Key         string      `json:"key"`
+	RequestHash string      `json:"request_hash"` // Hash of method, path and body of the first request
+	Completed   bool        `json:"completed"`    // False while the first request is still running
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	BodyHash    string      `json:"body_hash,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Store persists idempotency records
+type Store interface {
+	// Reserve records key as in progress. If the key already exists it returns
+	// the existing record and false.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error)
+	// Complete stores the response of a reserved key
+	Complete(ctx context.Context, record *Record, ttl time.Duration) error
+	// Release removes a reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+	// Cleanup removes expired records, returning how many were removed
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+// RunCleanup calls store.Cleanup every interval until ctx is done
+func RunCleanup(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = store.Cleanup(ctx)
+		}
+	}
+}
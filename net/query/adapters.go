@@ -0,0 +1,140 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SQL is a spec rendered as SQL fragments with ? placeholders
+type SQL struct {
+	Where   string // Conditions joined by AND, without the WHERE keyword, empty if none
+	Args    []any
+	OrderBy string // Without the ORDER BY keyword, empty if none
+	Limit   int
+	Offset  int
+}
+
+// SQL renders the spec for database/sql. Like matches substrings, escaping % and _.
+func (s *Spec) SQL() *SQL {
+	out := &SQL{Limit: s.PageSize, Offset: s.Offset()}
+
+	var where []string
+	for _, c := range s.Filters {
+		switch c.Op {
+		case In:
+			values := c.Value.([]any)
+			where = append(where, c.Column+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")+")")
+			out.Args = append(out.Args, values...)
+		case Like:
+			where = append(where, c.Column+" LIKE ? ESCAPE '!'")
+			out.Args = append(out.Args, "%"+escapeLike(c.Value.(string))+"%")
+		default:
+			where = append(where, c.Column+" "+sqlOperators[c.Op]+" ?")
+			out.Args = append(out.Args, c.Value)
+		}
+	}
+	out.Where = strings.Join(where, " AND ")
+
+	order := make([]string, len(s.Sort))
+	for i, o := range s.Sort {
+		order[i] = o.Column
+		if o.Desc {
+			order[i] += " DESC"
+		}
+	}
+	out.OrderBy = strings.Join(order, ", ")
+
+	return out
+}
+
+var sqlOperators = map[Operator]string{
+	Eq:  "=",
+	Ne:  "<>",
+	Lt:  "<",
+	Lte: "<=",
+	Gt:  ">",
+	Gte: ">=",
+}
+
+// escapeLike escapes LIKE wildcards with !
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// MongoSort is one sort key, convert with bson.E{Key: s.Key, Value: s.Order}
+type MongoSort struct {
+	Key   string
+	Order int // 1 ascending, -1 descending
+}
+
+// Mongo is a spec rendered for MongoDB
+type Mongo struct {
+	Filter map[string]any // Usable as bson.M
+	Sort   []MongoSort
+	Skip   int64
+	Limit  int64
+}
+
+// Mongo renders the spec for the MongoDB driver. Like matches substrings.
+func (s *Spec) Mongo() *Mongo {
+	out := &Mongo{
+		Filter: make(map[string]any, len(s.Filters)),
+		Skip:   int64(s.Offset()),
+		Limit:  int64(s.PageSize),
+	}
+
+	for _, c := range s.Filters {
+		var expr map[string]any
+		switch c.Op {
+		case Like:
+			expr = map[string]any{"$regex": regexp.QuoteMeta(c.Value.(string))}
+		default:
+			expr = map[string]any{"$" + string(c.Op): c.Value}
+		}
+
+		// Several conditions on one field combine into one document
+		if existing, ok := out.Filter[c.Column].(map[string]any); ok {
+			for k, v := range expr {
+				existing[k] = v
+			}
+			continue
+		}
+		out.Filter[c.Column] = expr
+	}
+
+	for _, o := range s.Sort {
+		order := 1
+		if o.Desc {
+			order = -1
+		}
+		out.Sort = append(out.Sort, MongoSort{Key: o.Column, Order: order})
+	}
+
+	return out
+}
+
+// Search is a spec rendered for data/search requests
+type Search struct {
+	Query  string
+	Filter map[string]any
+	From   int
+	Size   int
+}
+
+// SearchRequest renders the spec for search engines, which only support equality filters
+func (s *Spec) SearchRequest() (*Search, error) {
+	out := &Search{
+		Query:  s.Search,
+		Filter: make(map[string]any, len(s.Filters)),
+		From:   s.Offset(),
+		Size:   s.PageSize,
+	}
+	for _, c := range s.Filters {
+		if c.Op != Eq {
+			return nil, fmt.Errorf("%w: operator %s on %s is not supported by search", ErrInvalidQuery, c.Op, c.Field)
+		}
+		out.Filter[c.Column] = c.Value
+	}
+	return out, nil
+}
@@ -0,0 +1,36 @@
+// Package query parses list endpoint query strings into a typed Spec with
+// per-field allowlists, and renders it for SQL, MongoDB and search engines so
+// list endpoints share the same semantics.
+//
+// # Syntax
+//
+//	?filter[status]=active              equality
+//	?filter[created_at][gte]=2024-01-01 operator: eq, ne, lt, lte, gt, gte, like, in
+//	?filter[status][in]=open,pending    comma separated values
+//	?sort=-created_at,title             - for descending
+//	?q=invoice&page=2&page_size=50      full text query and paging
+//
+// # Usage
+//
+//	var taskQuery, _ = query.NewParser(query.Schema{
+//	    Fields: []query.Field{
+//	        {Name: "status", Ops: []query.Operator{query.Eq, query.In}},
+//	        {Name: "title", Ops: []query.Operator{query.Like}, Sortable: true},
+//	        {Name: "created", Column: "created_at", Type: query.Time, Ops: []query.Operator{query.Gte, query.Lt}, Sortable: true},
+//	    },
+//	    DefaultSort: "-created",
+//	})
+//
+//	spec, err := taskQuery.Parse(c.Request.URL.Query())
+//	if err != nil {
+//	    resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+//	    return
+//	}
+//
+//	q := spec.SQL()
+//	// SELECT ... WHERE <q.Where> ORDER BY <q.OrderBy> LIMIT q.Limit OFFSET q.Offset, args q.Args
+//
+// Fields, operators and sort keys outside the schema are rejected with
+// ErrInvalidQuery; values are converted to the field type. Columns come from
+// the schema only, so rendered queries never contain user supplied identifiers.
+package query
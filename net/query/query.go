@@ -0,0 +1,278 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// ErrInvalidQuery is wrapped by all parse errors, handlers can map it to 400
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Operator is a filter comparison
+type Operator string
+
+const (
+	Eq   Operator = "eq"
+	Ne   Operator = "ne"
+	Lt   Operator = "lt"
+	Lte  Operator = "lte"
+	Gt   Operator = "gt"
+	Gte  Operator = "gte"
+	Like Operator = "like" // Substring match
+	In   Operator = "in"   // Comma separated values
+)
+
+// FieldType is the type filter values are converted to
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	Bool
+	Time // RFC 3339 or 2006-01-02
+)
+
+// Field declares a filterable or sortable field
+type Field struct {
+	Name     string     // Name in the query string
+	Column   string     // Storage column or document key, defaults to Name
+	Type     FieldType  // Defaults to String
+	Ops      []Operator // Allowed operators, defaults to Eq
+	Sortable bool
+}
+
+// Schema is the allowlist of a list endpoint
+type Schema struct {
+	Fields          []Field
+	DefaultSort     string // e.g. "-created_at"
+	DefaultPageSize int    // Defaults to 20
+	MaxPageSize     int    // Defaults to 100
+	MaxOffset       int    // Pages past it are capped, defaults to 1000000
+}
+
+// Condition is a parsed filter
+type Condition struct {
+	Field  string // Field name
+	Column string
+	Op     Operator
+	Value  any // Converted to the field type, []any for In
+}
+
+// Sort is a parsed sort key
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Spec is a parsed list query
+type Spec struct {
+	Filters  []Condition
+	Sort     []Sort
+	Search   string // Full text query from q
+	Page     int    // 1-based
+	PageSize int
+}
+
+// Offset returns the number of items before the page
+func (s *Spec) Offset() int {
+	if s.Page <= 1 || s.PageSize <= 0 {
+		return 0
+	}
+	return min(s.Page-1, math.MaxInt/s.PageSize) * s.PageSize
+}
+
+// Parser parses query strings against a schema
+type Parser struct {
+	schema Schema
+	fields map[string]*Field
+	sort   []Sort
+}
+
+// NewParser validates schema and creates a parser
+func NewParser(schema Schema) (*Parser, error) {
+	if schema.DefaultPageSize <= 0 {
+		schema.DefaultPageSize = 20
+	}
+	if schema.MaxPageSize <= 0 {
+		schema.MaxPageSize = 100
+	}
+	schema.DefaultPageSize = min(schema.DefaultPageSize, schema.MaxPageSize)
+	if schema.MaxOffset <= 0 {
+		schema.MaxOffset = 1000000
+	}
+
+	p := &Parser{schema: schema, fields: make(map[string]*Field, len(schema.Fields))}
+	for i := range schema.Fields {
+		f := &p.schema.Fields[i]
+		if f.Column == "" {
+			f.Column = f.Name
+		}
+		if !sqlq.ValidIdentifier(f.Name) || !sqlq.ValidIdentifier(f.Column) {
+			return nil, fmt.Errorf("invalid field %q", f.Name)
+		}
+		if len(f.Ops) == 0 {
+			f.Ops = []Operator{Eq}
+		}
+		p.fields[f.Name] = f
+	}
+
+	sort, err := p.parseSort(schema.DefaultSort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default sort: %w", err)
+	}
+	p.sort = sort
+	return p, nil
+}
+
+// Parse parses filter[field]=v, filter[field][op]=v, sort=-a,b, q, page and page_size
+func (p *Parser) Parse(values url.Values) (*Spec, error) {
+	spec := &Spec{Search: strings.TrimSpace(values.Get("q")), Sort: p.sort}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "filter[") {
+			continue
+		}
+		cond, err := p.parseFilter(k, values.Get(k))
+		if err != nil {
+			return nil, err
+		}
+		spec.Filters = append(spec.Filters, *cond)
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		sort, err := p.parseSort(raw)
+		if err != nil {
+			return nil, err
+		}
+		spec.Sort = sort
+	}
+
+	var err error
+	if spec.Page, err = positiveInt(values.Get("page"), 1); err != nil {
+		return nil, fmt.Errorf("%w: page: %v", ErrInvalidQuery, err)
+	}
+	if spec.PageSize, err = positiveInt(values.Get("page_size"), p.schema.DefaultPageSize); err != nil {
+		return nil, fmt.Errorf("%w: page_size: %v", ErrInvalidQuery, err)
+	}
+	spec.PageSize = min(spec.PageSize, p.schema.MaxPageSize)
+	spec.Page = min(spec.Page, p.schema.MaxOffset/spec.PageSize+1)
+
+	return spec, nil
+}
+
+// parseFilter parses one filter[field] or filter[field][op] parameter
+func (p *Parser) parseFilter(key, raw string) (*Condition, error) {
+	rest := strings.TrimPrefix(key, "filter[")
+	name, rest, ok := strings.Cut(rest, "]")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed parameter %s", ErrInvalidQuery, key)
+	}
+
+	op := Eq
+	if rest != "" {
+		if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+			return nil, fmt.Errorf("%w: malformed parameter %s", ErrInvalidQuery, key)
+		}
+		op = Operator(rest[1 : len(rest)-1])
+	}
+
+	f, ok := p.fields[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown filter field %s", ErrInvalidQuery, name)
+	}
+	if !slices.Contains(f.Ops, op) {
+		return nil, fmt.Errorf("%w: operator %s not allowed on %s", ErrInvalidQuery, op, name)
+	}
+
+	cond := &Condition{Field: name, Column: f.Column, Op: op}
+	if op == In {
+		var list []any
+		for _, part := range strings.Split(raw, ",") {
+			v, err := convert(f.Type, strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, name, err)
+			}
+			list = append(list, v)
+		}
+		cond.Value = list
+		return cond, nil
+	}
+
+	if op == Like {
+		cond.Value = raw
+		return cond, nil
+	}
+	v, err := convert(f.Type, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuery, name, err)
+	}
+	cond.Value = v
+	return cond, nil
+}
+
+// parseSort parses a comma separated sort list, - prefixes descending keys
+func (p *Parser) parseSort(raw string) ([]Sort, error) {
+	var sort []Sort
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+
+		f, ok := p.fields[name]
+		if !ok || !f.Sortable {
+			return nil, fmt.Errorf("%w: cannot sort by %s", ErrInvalidQuery, name)
+		}
+		sort = append(sort, Sort{Field: name, Column: f.Column, Desc: desc})
+	}
+	return sort, nil
+}
+
+// convert converts a raw value to the field type
+func convert(t FieldType, raw string) (any, error) {
+	switch t {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Float:
+		return strconv.ParseFloat(raw, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Time:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	default:
+		return raw, nil
+	}
+}
+
+// positiveInt parses a positive integer, returning def when raw is empty
+func positiveInt(raw string, def int) (int, error) {
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("must be a positive integer")
+	}
+	return n, nil
+}
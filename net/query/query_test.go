@@ -0,0 +1,149 @@
+package query
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestParser(t *testing.T) *Parser {
+	t.Helper()
+	p, err := NewParser(Schema{
+		Fields: []Field{
+			{Name: "status", Ops: []Operator{Eq, In}},
+			{Name: "title", Ops: []Operator{Like}, Sortable: true},
+			{Name: "priority", Type: Int, Ops: []Operator{Gte, Lt}, Sortable: true},
+			{Name: "created", Column: "created_at", Type: Time, Ops: []Operator{Gte}, Sortable: true},
+		},
+		DefaultSort: "-created",
+		MaxPageSize: 50,
+	})
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	return p
+}
+
+func parse(t *testing.T, p *Parser, raw string) (*Spec, error) {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	return p.Parse(values)
+}
+
+func TestParse(t *testing.T) {
+	p := newTestParser(t)
+	spec, err := parse(t, p, "filter[status][in]=open,done&filter[priority][gte]=2&filter[created][gte]=2026-01-02&sort=title,-priority&page=3&page_size=500&q=report")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Condition{
+		{Field: "created", Column: "created_at", Op: Gte, Value: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Field: "priority", Column: "priority", Op: Gte, Value: int64(2)},
+		{Field: "status", Column: "status", Op: In, Value: []any{"open", "done"}},
+	}
+	if !reflect.DeepEqual(spec.Filters, want) {
+		t.Errorf("filters = %+v, want %+v", spec.Filters, want)
+	}
+	if len(spec.Sort) != 2 || spec.Sort[0].Desc || !spec.Sort[1].Desc {
+		t.Errorf("unexpected sort %+v", spec.Sort)
+	}
+	if spec.Page != 3 || spec.PageSize != 50 || spec.Offset() != 100 || spec.Search != "report" {
+		t.Errorf("unexpected paging %+v", spec)
+	}
+}
+
+func TestParseDefaultsAndErrors(t *testing.T) {
+	p := newTestParser(t)
+	spec, err := parse(t, p, "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if spec.Page != 1 || spec.PageSize != 20 || len(spec.Sort) != 1 || spec.Sort[0].Column != "created_at" {
+		t.Errorf("unexpected defaults %+v", spec)
+	}
+
+	for _, raw := range []string{
+		"filter[secret]=x",
+		"filter[status][lt]=x",
+		"filter[priority][gte]=high",
+		"filter[status=x",
+		"sort=status",
+		"page=0",
+	} {
+		if _, err := parse(t, p, raw); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%s: expected ErrInvalidQuery, got %v", raw, err)
+		}
+	}
+}
+
+func TestParseCapsPage(t *testing.T) {
+	p := newTestParser(t)
+	spec, err := parse(t, p, "page=9223372036854775807&page_size=50")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if spec.Page != 20001 || spec.Offset() != 1000000 {
+		t.Errorf("page %d offset %d, want 20001 and 1000000", spec.Page, spec.Offset())
+	}
+
+	// Specs built by hand do not overflow either
+	spec = &Spec{Page: math.MaxInt, PageSize: 50}
+	if off := spec.Offset(); off <= 0 {
+		t.Errorf("Offset = %d, want a positive offset", off)
+	}
+}
+
+func TestAdapters(t *testing.T) {
+	p := newTestParser(t)
+	spec, err := parse(t, p, "filter[status][in]=open,done&filter[title][like]=50%25_off&filter[priority][gte]=2&filter[priority][lt]=5&sort=-priority&page=2&page_size=10")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	q := spec.SQL()
+	if want := "priority >= ? AND priority < ? AND status IN (?, ?) AND title LIKE ? ESCAPE '!'"; q.Where != want {
+		t.Errorf("where = %q, want %q", q.Where, want)
+	}
+	if want := []any{int64(2), int64(5), "open", "done", "%50!%!_off%"}; !reflect.DeepEqual(q.Args, want) {
+		t.Errorf("args = %v, want %v", q.Args, want)
+	}
+	if q.OrderBy != "priority DESC" || q.Limit != 10 || q.Offset != 10 {
+		t.Errorf("unexpected order or paging %+v", q)
+	}
+
+	m := spec.Mongo()
+	if want := map[string]any{"$gte": int64(2), "$lt": int64(5)}; !reflect.DeepEqual(m.Filter["priority"], want) {
+		t.Errorf("mongo priority = %v, want %v", m.Filter["priority"], want)
+	}
+	if want := map[string]any{"$regex": `50%_off`}; !reflect.DeepEqual(m.Filter["title"], want) {
+		t.Errorf("mongo title = %v, want %v", m.Filter["title"], want)
+	}
+	if len(m.Sort) != 1 || m.Sort[0] != (MongoSort{Key: "priority", Order: -1}) || m.Skip != 10 {
+		t.Errorf("unexpected mongo sort or paging %+v", m)
+	}
+
+	if _, err := spec.SearchRequest(); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("search should reject non-equality filters, got %v", err)
+	}
+	eq, _ := parse(t, p, "filter[status]=open&q=report")
+	s, err := eq.SearchRequest()
+	if err != nil || s.Query != "report" || s.Filter["status"] != "open" || s.Size != 20 {
+		t.Errorf("unexpected search %+v, %v", s, err)
+	}
+}
+
+func TestNewParserRejectsUnsafeColumns(t *testing.T) {
+	if _, err := NewParser(Schema{Fields: []Field{{Name: "x", Column: "x; DROP TABLE t"}}}); err == nil {
+		t.Error("expected unsafe column to be rejected")
+	}
+	if _, err := NewParser(Schema{DefaultSort: "missing"}); err == nil {
+		t.Error("expected unknown default sort to be rejected")
+	}
+}
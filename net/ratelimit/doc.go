@@ -0,0 +1,36 @@
+// Package ratelimit provides request rate limiting with token bucket and
+// sliding window strategies, in-memory and Redis backends, and gin middleware.
+//
+// # Strategies
+//
+// TokenBucket refills Limit tokens per Window and allows bursts up to Burst.
+// SlidingWindow allows Limit requests per rolling Window, approximated from the
+// current and previous fixed windows.
+//
+// # Backends
+//
+//	// Single instance
+//	limiter, err := ratelimit.NewMemoryLimiter(ratelimit.Config{
+//	    Strategy: ratelimit.TokenBucket,
+//	    Limit:    100,
+//	    Window:   time.Minute,
+//	    Burst:    20,
+//	})
+//
+//	// Shared across instances
+//	limiter, err := ratelimit.NewRedisLimiter(redisClient, ratelimit.Config{
+//	    Strategy: ratelimit.SlidingWindow,
+//	    Limit:    1000,
+//	    Window:   time.Hour,
+//	})
+//
+// # Middleware
+//
+//	r.Use(ratelimit.Middleware(limiter, &ratelimit.MiddlewareOptions{
+//	    KeyFunc:   ratelimit.KeyByUserOrIP,
+//	    SkipPaths: []string{"/health"},
+//	}))
+//
+// Responses carry RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers. Rejected requests get 429 with ecode.TooManyRequests and Retry-After.
+package ratelimit
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/data/kv"
+)
+
+// kvLimiter keeps limiter state in an embedded kv store, for single-binary
+// deployments that should survive restarts without Redis
+type kvLimiter struct {
+	cfg Config
+	db  *kv.DB
+	now func() time.Time
+}
+
+// kvState is the stored state of a bucket or window
+type kvState struct {
+	Tokens float64   `json:"tokens,omitempty"`
+	Start  time.Time `json:"start"` // Last refill of a bucket, start of a window
+	Curr   int64     `json:"curr,omitempty"`
+	Prev   int64     `json:"prev,omitempty"`
+}
+
+// NewKVLimiter creates a limiter keeping state in an embedded kv store
+func NewKVLimiter(db *kv.DB, cfg Config) (Limiter, error) {
+	if db == nil {
+		return nil, fmt.Errorf("kv store is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &kvLimiter{cfg: cfg, db: db, now: time.Now}, nil
+}
+
+// Allow checks and records a request
+func (l *kvLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	key = l.cfg.Prefix + ":" + string(l.cfg.Strategy) + ":" + key
+	now := l.now()
+
+	var result *Result
+	err := l.db.Update(ctx, key, func(value []byte, found bool) ([]byte, time.Duration, error) {
+		var st kvState
+		if found {
+			if err := json.Unmarshal(value, &st); err != nil {
+				found = false
+			}
+		}
+
+		var ttl time.Duration
+		if l.cfg.Strategy == SlidingWindow {
+			w := &window{start: now.Truncate(l.cfg.Window)}
+			if found {
+				w = &window{start: st.Start, curr: st.Curr, prev: st.Prev}
+			}
+			allowed, estimate, elapsed := w.add(&l.cfg, now)
+			result = slidingWindowResult(&l.cfg, allowed, estimate, w.prev, elapsed)
+			st = kvState{Start: w.start, Curr: w.curr, Prev: w.prev}
+			ttl = 2 * l.cfg.Window
+		} else {
+			b := &bucket{tokens: float64(l.cfg.Burst), last: now}
+			if found {
+				b = &bucket{tokens: st.Tokens, last: st.Start}
+			}
+			allowed := b.take(&l.cfg, now)
+			result = tokenBucketResult(&l.cfg, allowed, b.tokens)
+			st = kvState{Tokens: b.tokens, Start: b.last}
+			ttl = time.Duration(float64(l.cfg.Burst)/float64(l.cfg.Limit)*float64(l.cfg.Window)) + time.Second
+		}
+
+		data, err := json.Marshal(st)
+		return data, ttl, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rate limit check failed: %v", err)
+	}
+	return result, nil
+}
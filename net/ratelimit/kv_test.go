@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/data/kv"
+)
+
+func TestKVLimiter(t *testing.T) {
+	db, err := kv.Open(filepath.Join(t.TempDir(), "ratelimit.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	for _, strategy := range []Strategy{TokenBucket, SlidingWindow} {
+		l, err := NewKVLimiter(db, Config{Strategy: strategy, Limit: 3, Window: time.Minute})
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		l.(*kvLimiter).now = func() time.Time { return now }
+
+		for i := range 4 {
+			r, err := l.Allow(ctx, "k")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Allowed != (i < 3) {
+				t.Fatalf("%s request %d: allowed = %v", strategy, i, r.Allowed)
+			}
+		}
+		now = now.Add(2 * time.Minute)
+		if r, _ := l.Allow(ctx, "k"); !r.Allowed {
+			t.Errorf("%s: not allowed after the window", strategy)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryLimiter keeps limiter state in process memory
+type memoryLimiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+// bucket is the state of a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// window is the state of a sliding window counter
+type window struct {
+	start time.Time
+	curr  int64
+	prev  int64
+}
+
+// NewMemoryLimiter creates a limiter keeping state in memory, suitable for a single instance
+func NewMemoryLimiter(cfg Config) (Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &memoryLimiter{
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+		windows: make(map[string]*window),
+	}, nil
+}
+
+// Allow checks and records a request
+func (l *memoryLimiter) Allow(_ context.Context, key string) (*Result, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	if l.cfg.Strategy == SlidingWindow {
+		return l.allowWindow(key, now), nil
+	}
+	return l.allowBucket(key, now), nil
+}
+
+// allowBucket applies the token bucket algorithm
+func (l *memoryLimiter) allowBucket(key string, now time.Time) *Result {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), last: now}
+		l.buckets[key] = b
+	}
+	allowed := b.take(&l.cfg, now)
+	return tokenBucketResult(&l.cfg, allowed, b.tokens)
+}
+
+// take refills the bucket up to now and takes a token if one is available
+func (b *bucket) take(cfg *Config, now time.Time) bool {
+	rate := float64(cfg.Limit) / float64(cfg.Window)
+	b.tokens = math.Min(float64(cfg.Burst), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return allowed
+}
+
+// allowWindow applies the sliding window counter algorithm
+func (l *memoryLimiter) allowWindow(key string, now time.Time) *Result {
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: now.Truncate(l.cfg.Window)}
+		l.windows[key] = w
+	}
+	allowed, estimate, elapsed := w.add(&l.cfg, now)
+	return slidingWindowResult(&l.cfg, allowed, estimate, w.prev, elapsed)
+}
+
+// add moves the window to now and counts a request if the weighted count
+// allows it, returning the estimate and the time elapsed in the window
+func (w *window) add(cfg *Config, now time.Time) (bool, float64, time.Duration) {
+	start := now.Truncate(cfg.Window)
+	switch {
+	case w.start.Equal(start):
+	case w.start.Add(cfg.Window).Equal(start):
+		w.start, w.prev, w.curr = start, w.curr, 0
+	default:
+		w.start, w.prev, w.curr = start, 0, 0
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(cfg.Window)
+	estimate := float64(w.prev)*weight + float64(w.curr)
+
+	allowed := estimate+1 <= float64(cfg.Limit)
+	if allowed {
+		w.curr++
+		estimate++
+	}
+	return allowed, estimate, elapsed
+}
+
+// sweep drops idle keys at most once per window.
+// Caller must hold the lock.
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.cfg.Window {
+		return
+	}
+	l.lastSweep = now
+
+	full := float64(l.cfg.Burst) * float64(l.cfg.Window) / float64(l.cfg.Limit)
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= time.Duration(full) {
+			delete(l.buckets, key)
+		}
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= 2*l.cfg.Window {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T, cfg Config) (*memoryLimiter, *time.Time) {
+	t.Helper()
+	l, err := NewMemoryLimiter(cfg)
+	if err != nil {
+		t.Fatalf("NewMemoryLimiter: %v", err)
+	}
+	ml := l.(*memoryLimiter)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ml.now = func() time.Time { return now }
+	return ml, &now
+}
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	l, now := newTestLimiter(t, Config{Strategy: TokenBucket, Limit: 10, Window: 10 * time.Second, Burst: 3})
+	ctx := context.Background()
+
+	for i := range 3 {
+		r, _ := l.Allow(ctx, "k")
+		if !r.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+		if r.Remaining != 2-i {
+			t.Errorf("request %d: expected remaining %d, got %d", i, 2-i, r.Remaining)
+		}
+	}
+
+	r, _ := l.Allow(ctx, "k")
+	if r.Allowed {
+		t.Fatal("request beyond burst should be rejected")
+	}
+	if r.RetryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %v", r.RetryAfter)
+	}
+
+	*now = now.Add(time.Second)
+	if r, _ := l.Allow(ctx, "k"); !r.Allowed {
+		t.Fatal("request after refill should be allowed")
+	}
+
+	if r, _ := l.Allow(ctx, "other"); !r.Allowed {
+		t.Fatal("keys should be limited independently")
+	}
+}
+
+func TestSlidingWindowWeightsPreviousWindow(t *testing.T) {
+	l, now := newTestLimiter(t, Config{Strategy: SlidingWindow, Limit: 4, Window: time.Minute})
+	ctx := context.Background()
+
+	for i := range 4 {
+		if r, _ := l.Allow(ctx, "k"); !r.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	r, _ := l.Allow(ctx, "k")
+	if r.Allowed || r.Remaining != 0 {
+		t.Fatalf("fifth request should be rejected, got %+v", r)
+	}
+
+	// Halfway through the next window the previous 4 requests weigh 2
+	*now = now.Add(90 * time.Second)
+	for i := range 2 {
+		if r, _ := l.Allow(ctx, "k"); !r.Allowed {
+			t.Fatalf("request %d in next window should be allowed", i)
+		}
+	}
+	r, _ = l.Allow(ctx, "k")
+	if r.Allowed {
+		t.Fatal("request over the weighted limit should be rejected")
+	}
+	if r.RetryAfter <= 0 || r.RetryAfter > 30*time.Second {
+		t.Errorf("unexpected retry after %v", r.RetryAfter)
+	}
+
+	// Two windows later the counts are forgotten
+	*now = now.Add(2 * time.Minute)
+	if r, _ := l.Allow(ctx, "k"); !r.Allowed || r.Remaining != 3 {
+		t.Fatalf("expected fresh window, got %+v", r)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{Limit: 5, Window: time.Second}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if cfg.Strategy != TokenBucket || cfg.Burst != 5 {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+
+	for _, bad := range []Config{
+		{Limit: 0, Window: time.Second},
+		{Limit: 1},
+		{Strategy: "leaky", Limit: 1, Window: time.Second},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
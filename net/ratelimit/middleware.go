@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+// Standard rate limit response headers
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// KeyFunc returns the key a request is limited by, empty to skip limiting
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP limits by client IP
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + ctxutil.GetClientIP(ctxutil.WithGinContext(c.Request.Context(), c))
+}
+
+// KeyByUser limits by authenticated user ID, skipping anonymous requests
+func KeyByUser(c *gin.Context) string {
+	if uid := ctxutil.GetUserID(ctxutil.WithGinContext(c.Request.Context(), c)); uid != "" {
+		return "user:" + uid
+	}
+	return ""
+}
+
+// KeyByUserOrIP limits by user ID, falling back to client IP for anonymous requests
+func KeyByUserOrIP(c *gin.Context) string {
+	if key := KeyByUser(c); key != "" {
+		return key
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey limits by the API key in the given header, skipping requests without one
+func KeyByAPIKey(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		if key := c.GetHeader(header); key != "" {
+			return "apikey:" + key
+		}
+		return ""
+	}
+}
+
+// MiddlewareOptions configures the rate limit middleware
+type MiddlewareOptions struct {
+	KeyFunc   KeyFunc  // Defaults to KeyByIP
+	SkipPaths []string // Paths exempt from limiting
+	FailOpen  bool     // Allow requests when the limiter backend fails
+	// Scope prefixes keys so limiters with different quotas sharing a backend do not collide
+	Scope string
+}
+
+// Middleware creates gin middleware enforcing the limiter
+func Middleware(limiter Limiter, opts *MiddlewareOptions) gin.HandlerFunc {
+	if opts == nil {
+		opts = &MiddlewareOptions{}
+	}
+	keyFunc := opts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+
+	skip := make(map[string]bool, len(opts.SkipPaths))
+	for _, path := range opts.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if opts.Scope != "" {
+			key = opts.Scope + ":" + key
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			if opts.FailOpen {
+				c.Next()
+				return
+			}
+			resp.Fail(c.Writer, resp.ServiceUnavailable("rate limiter unavailable"))
+			c.Abort()
+			return
+		}
+
+		setHeaders(c, result)
+		if !result.Allowed {
+			c.Header(HeaderRetryAfter, strconv.Itoa(seconds(result.RetryAfter)))
+			resp.Fail(c.Writer, resp.TooManyRequests("rate limit exceeded"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// setHeaders writes the RateLimit-* headers
+func setHeaders(c *gin.Context, r *Result) {
+	c.Header(HeaderLimit, strconv.Itoa(r.Limit))
+	c.Header(HeaderRemaining, strconv.Itoa(r.Remaining))
+	c.Header(HeaderReset, strconv.Itoa(seconds(r.Reset)))
+}
+
+// seconds rounds a duration up to whole seconds
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Strategy selects the limiting algorithm
+type Strategy string
+
+const (
+	// TokenBucket refills tokens at a steady rate and allows bursts up to the bucket size
+	TokenBucket Strategy = "token_bucket"
+	// SlidingWindow counts requests over a rolling window, weighting the previous window
+	SlidingWindow Strategy = "sliding_window"
+)
+
+// Config configures a limiter
+type Config struct {
+	Strategy Strategy      `json:"strategy" yaml:"strategy"`
+	Limit    int           `json:"limit" yaml:"limit"`   // Requests allowed per window
+	Window   time.Duration `json:"window" yaml:"window"` // Window length, or refill period of Limit tokens
+	Burst    int           `json:"burst" yaml:"burst"`   // Token bucket size, defaults to Limit
+	Prefix   string        `json:"prefix" yaml:"prefix"` // Key prefix for shared backends
+}
+
+// Validate validates the config and applies defaults
+func (c *Config) Validate() error {
+	if c.Strategy == "" {
+		c.Strategy = TokenBucket
+	}
+	if c.Strategy != TokenBucket && c.Strategy != SlidingWindow {
+		return fmt.Errorf("unknown rate limit strategy: %s", c.Strategy)
+	}
+	if c.Limit <= 0 {
+		return fmt.Errorf("rate limit must be positive")
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("rate limit window must be positive")
+	}
+	if c.Burst <= 0 {
+		c.Burst = c.Limit
+	}
+	if c.Prefix == "" {
+		c.Prefix = "ratelimit"
+	}
+	return nil
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the quota is fully restored
+	RetryAfter time.Duration // Until the next request is allowed, zero if allowed
+}
+
+// Limiter checks whether a request identified by key is allowed
+type Limiter interface {
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// tokenBucketResult builds the result of a token bucket check
+func tokenBucketResult(cfg *Config, allowed bool, tokens float64) *Result {
+	perToken := cfg.Window / time.Duration(cfg.Limit)
+	r := &Result{
+		Allowed:   allowed,
+		Limit:     cfg.Burst,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(cfg.Burst) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return r
+}
+
+// slidingWindowResult builds the result of a sliding window check.
+// estimate is the weighted request count including the current request if allowed.
+func slidingWindowResult(cfg *Config, allowed bool, estimate float64, prev int64, elapsed time.Duration) *Result {
+	remaining := cfg.Limit - int(estimate+0.999999)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	r := &Result{
+		Allowed:   allowed,
+		Limit:     cfg.Limit,
+		Remaining: remaining,
+		Reset:     cfg.Window - elapsed,
+	}
+	if !allowed {
+		// The previous window's weight decays linearly, wait until one slot frees up
+		r.RetryAfter = r.Reset
+		if prev > 0 {
+			excess := estimate - float64(cfg.Limit-1)
+			wait := time.Duration(excess / float64(prev) * float64(cfg.Window))
+			if wait < r.RetryAfter {
+				r.RetryAfter = wait
+			}
+		}
+	}
+	return r
+}
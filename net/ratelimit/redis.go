@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes a token atomically using the server clock.
+// Returns {allowed, tokens}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript counts a request in the current window if the weighted count allows it.
+// Returns {allowed, estimate, prev, elapsed}.
+var slidingWindowScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+
+local state = redis.call('HMGET', KEYS[1], 'idx', 'curr', 'prev')
+local sidx = tonumber(state[1])
+local curr = tonumber(state[2]) or 0
+local prev = tonumber(state[3]) or 0
+if sidx == nil or sidx < idx - 1 then
+  curr = 0
+  prev = 0
+elseif sidx == idx - 1 then
+  prev = curr
+  curr = 0
+end
+
+local estimate = prev * (1 - elapsed / window) + curr
+local allowed = 0
+if estimate + 1 <= limit then
+  curr = curr + 1
+  estimate = estimate + 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'idx', idx, 'curr', curr, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {allowed, tostring(estimate), prev, elapsed}
+`)
+
+// redisLimiter keeps limiter state in Redis, shared by all instances
+type redisLimiter struct {
+	cfg    Config
+	client redis.UniversalClient
+}
+
+// NewRedisLimiter creates a limiter keeping state in Redis
+func NewRedisLimiter(client redis.UniversalClient, cfg Config) (Limiter, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &redisLimiter{cfg: cfg, client: client}, nil
+}
+
+// Allow checks and records a request
+func (l *redisLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	key = l.cfg.Prefix + ":" + string(l.cfg.Strategy) + ":" + key
+	if l.cfg.Strategy == SlidingWindow {
+		return l.allowWindow(ctx, key)
+	}
+	return l.allowBucket(ctx, key)
+}
+
+// allowBucket applies the token bucket algorithm
+func (l *redisLimiter) allowBucket(ctx context.Context, key string) (*Result, error) {
+	rate := float64(l.cfg.Limit) / float64(l.cfg.Window.Milliseconds())
+	ttl := time.Duration(float64(l.cfg.Burst)/float64(l.cfg.Limit)*float64(l.cfg.Window)) + time.Second
+
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{key}, rate, l.cfg.Burst, ttl.Milliseconds()).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("rate limit check failed: %v", err)
+	}
+	if len(res) != 2 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	tokens, err := strconv.ParseFloat(fmt.Sprint(res[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token count: %v", err)
+	}
+	return tokenBucketResult(&l.cfg, toInt64(res[0]) == 1, tokens), nil
+}
+
+// allowWindow applies the sliding window counter algorithm
+func (l *redisLimiter) allowWindow(ctx context.Context, key string) (*Result, error) {
+	res, err := slidingWindowScript.Run(ctx, l.client, []string{key}, l.cfg.Window.Milliseconds(), l.cfg.Limit).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("rate limit check failed: %v", err)
+	}
+	if len(res) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	estimate, err := strconv.ParseFloat(fmt.Sprint(res[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request estimate: %v", err)
+	}
+	elapsed := time.Duration(toInt64(res[3])) * time.Millisecond
+	return slidingWindowResult(&l.cfg, toInt64(res[0]) == 1, estimate, toInt64(res[2]), elapsed), nil
+}
+
+// toInt64 converts a Lua integer reply
+func toInt64(v any) int64 {
+	n, _ := v.(int64)
+	return n
+}
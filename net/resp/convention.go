@@ -0,0 +1,534 @@
+package resp
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Naming is a JSON object key convention
+type Naming string
+
+// Key conventions, keys are written as tagged when empty
+const (
+	SnakeCase Naming = "snake_case"
+	CamelCase Naming = "camelCase"
+)
+
+// Time formats besides Go layouts such as time.RFC3339
+const (
+	TimeUnix      = "unix"       // Seconds since the epoch
+	TimeUnixMilli = "unix_milli" // Milliseconds since the epoch
+)
+
+// Convention controls how response bodies are serialized, so consumers
+// expecting other key or time conventions are served from the same DTOs.
+// The zero Convention writes bodies as encoding/json does.
+type Convention struct {
+	Naming     Naming `json:"naming" yaml:"naming"`           // Object key convention, applied to struct fields and string map keys
+	TimeFormat string `json:"time_format" yaml:"time_format"` // TimeUnix, TimeUnixMilli or a time layout, RFC 3339 with nanoseconds when empty
+}
+
+// IsZero reports whether c leaves bodies unchanged
+func (c Convention) IsZero() bool { return c.Naming == "" && c.TimeFormat == "" }
+
+var globalConvention atomic.Pointer[Convention]
+
+// SetConvention sets the convention of responses not covered by a route
+// group convention, and of static responses created afterwards
+func SetConvention(c Convention) { globalConvention.Store(&c) }
+
+// GlobalConvention returns the convention set by SetConvention
+func GlobalConvention() Convention {
+	if c := globalConvention.Load(); c != nil {
+		return *c
+	}
+	return Convention{}
+}
+
+// conventionWriter carries a route group convention to the resp writers
+type conventionWriter struct {
+	gin.ResponseWriter
+	convention Convention
+}
+
+// httpConventionWriter is conventionWriter for net/http handlers
+type httpConventionWriter struct {
+	http.ResponseWriter
+	convention Convention
+}
+
+func (w *conventionWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *httpConventionWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+type conventionKey struct{}
+
+// WithConvention returns a context carrying c, for request decoding
+func WithConvention(ctx context.Context, c Convention) context.Context {
+	return context.WithValue(ctx, conventionKey{}, c)
+}
+
+// ConventionFromContext returns the convention of a request, the global one if
+// no route group set one
+func ConventionFromContext(ctx context.Context) Convention {
+	if c, ok := ctx.Value(conventionKey{}).(Convention); ok {
+		return c
+	}
+	return GlobalConvention()
+}
+
+// UseConvention is gin middleware applying c to the responses of a route group
+//
+//	v2 := r.Group("/v2", resp.UseConvention(resp.Convention{Naming: resp.CamelCase, TimeFormat: resp.TimeUnixMilli}))
+func UseConvention(c Convention) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer = &conventionWriter{ResponseWriter: ctx.Writer, convention: c}
+		ctx.Request = ctx.Request.WithContext(WithConvention(ctx.Request.Context(), c))
+		ctx.Next()
+	}
+}
+
+// ConventionHandler applies c to the responses of a net/http handler
+func ConventionHandler(c Convention, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&httpConventionWriter{ResponseWriter: w, convention: c}, r.WithContext(WithConvention(r.Context(), c)))
+	})
+}
+
+// conventionOf returns the convention responses written to w follow, looking
+// through other wrappers such as the problem details middleware
+func conventionOf(w http.ResponseWriter) Convention {
+	for w != nil {
+		switch cw := w.(type) {
+		case *conventionWriter:
+			return cw.convention
+		case *httpConventionWriter:
+			return cw.convention
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return GlobalConvention()
+}
+
+// Apply returns v rewritten to follow c, ready to be encoded as JSON. Struct
+// fields are named after their json tags, then converted to c.Naming.
+func (c Convention) Apply(v any) any {
+	if c.IsZero() {
+		return v
+	}
+	return c.convert(reflect.ValueOf(v), 0)
+}
+
+// Decode unmarshals a JSON body written in convention c into v, renaming keys
+// back to snake_case, the convention of ncore DTO tags. Times are decoded as
+// tagged, whatever c.TimeFormat is.
+func (c Convention) Decode(data []byte, v any) error {
+	if c.Naming == "" || c.Naming == SnakeCase {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return err
+	}
+	data, err := json.Marshal(renameKeys(tree, SnakeCase))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// maxConvertDepth stops the conversion of cyclic values, which the encoder reports
+const maxConvertDepth = 1000
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// convert rewrites a value into objects, slices and scalars following c
+func (c Convention) convert(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxConvertDepth {
+		return v.Interface()
+	}
+
+	if v.Type() == timeType {
+		return c.formatTime(v.Interface().(time.Time))
+	}
+	if v.Kind() == reflect.Pointer && v.Type().Elem() == timeType {
+		if v.IsNil() {
+			return nil
+		}
+		return c.formatTime(v.Elem().Interface().(time.Time))
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil
+		}
+		return c.convertMarshaler(v)
+	}
+	if v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return c.convert(v.Elem(), depth+1)
+	case reflect.Struct:
+		return c.convertStruct(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		return c.convertMap(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = c.convert(v.Index(i), depth+1)
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// formatTime writes t in c.TimeFormat, zero times are null for epoch formats
+func (c Convention) formatTime(t time.Time) any {
+	switch c.TimeFormat {
+	case "":
+		return t
+	case TimeUnix:
+		if t.IsZero() {
+			return nil
+		}
+		return t.Unix()
+	case TimeUnixMilli:
+		if t.IsZero() {
+			return nil
+		}
+		return t.UnixMilli()
+	}
+	return t.Format(c.TimeFormat)
+}
+
+// convertMarshaler renames the keys of a value encoding itself
+func (c Convention) convertMarshaler(v reflect.Value) any {
+	data, err := v.Interface().(json.Marshaler).MarshalJSON()
+	if err != nil {
+		// Left to the encoder, which reports the error
+		return v.Interface()
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return v.Interface()
+	}
+	return renameKeys(tree, c.Naming)
+}
+
+func (c Convention) convertStruct(v reflect.Value, depth int) any {
+	fields := cachedFields(v.Type())
+	obj := make(object, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) || f.omitZero && fv.IsZero() {
+			continue
+		}
+		obj = append(obj, member{key: convertKey(f.name, c.Naming), value: c.convert(fv, depth+1)})
+	}
+	return obj
+}
+
+func (c Convention) convertMap(v reflect.Value, depth int) any {
+	obj := make(object, 0, v.Len())
+	stringKeys := v.Type().Key().Kind() == reflect.String
+	iter := v.MapRange()
+	for iter.Next() {
+		var key string
+		if stringKeys {
+			key = convertKey(iter.Key().String(), c.Naming)
+		} else {
+			var ok bool
+			if key, ok = mapKeyString(iter.Key()); !ok {
+				// Unsupported key type, left to the encoder
+				return v.Interface()
+			}
+		}
+		obj = append(obj, member{key: key, value: c.convert(iter.Value(), depth+1)})
+	}
+	// Keys are sorted, as encoding/json does for maps
+	slices.SortFunc(obj, func(a, b member) int { return strings.Compare(a.key, b.key) })
+	return obj
+}
+
+// mapKeyString formats a non string map key as encoding/json does
+func mapKeyString(k reflect.Value) (string, bool) {
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", true
+		}
+		b, err := tm.MarshalText()
+		return string(b), err == nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	}
+	return "", false
+}
+
+// renameKeys renames the object keys of a decoded JSON tree
+func renameKeys(v any, naming Naming) any {
+	switch t := v.(type) {
+	case map[string]any:
+		obj := make(object, 0, len(t))
+		for k, val := range t {
+			obj = append(obj, member{key: convertKey(k, naming), value: renameKeys(val, naming)})
+		}
+		slices.SortFunc(obj, func(a, b member) int { return strings.Compare(a.key, b.key) })
+		return obj
+	case []any:
+		for i := range t {
+			t[i] = renameKeys(t[i], naming)
+		}
+	}
+	return v
+}
+
+// member is a key and value of an object
+type member struct {
+	key   string
+	value any
+}
+
+// object is a JSON object keeping the order of its members
+type object []member
+
+// MarshalJSON implements json.Marshaler
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSON(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := marshalJSON(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// convertKey converts a key to naming
+func convertKey(key string, naming Naming) string {
+	switch naming {
+	case SnakeCase:
+		return toSnake(key)
+	case CamelCase:
+		return toCamel(key)
+	}
+	return key
+}
+
+// toSnake converts userId and UserID to user_id
+func toSnake(s string) string {
+	if !strings.ContainsFunc(s, unicode.IsUpper) {
+		return s
+	}
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toCamel converts user_id to userId
+func toCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	var b strings.Builder
+	upper := false
+	for i, r := range s {
+		switch {
+		case r == '_' && i > 0:
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// field is an encoded struct field
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the encoded fields of a struct type, with embedded
+// struct fields promoted unless an outer field has the same name
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	fields := typeFields(t, nil, map[reflect.Type]bool{})
+	seen := map[string]bool{}
+	out := fields[:0]
+	for _, f := range fields {
+		if !seen[f.name] {
+			seen[f.name] = true
+			out = append(out, f)
+		}
+	}
+	f, _ := fieldCache.LoadOrStore(t, out)
+	return f.([]field)
+}
+
+func typeFields(t reflect.Type, index []int, visited map[reflect.Type]bool) []field {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	var direct, promoted []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(slices.Clone(index), i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			promoted = append(promoted, typeFields(ft, idx, visited)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" || !validTagName(name) {
+			name = sf.Name
+		}
+		direct = append(direct, field{
+			name:      name,
+			index:     idx,
+			omitEmpty: hasOption(opts, "omitempty"),
+			omitZero:  hasOption(opts, "omitzero"),
+		})
+	}
+	return append(direct, promoted...)
+}
+
+func hasOption(opts, name string) bool {
+	for opt := range strings.SplitSeq(opts, ",") {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validTagName(s string) bool {
+	return utf8.ValidString(s) && !strings.ContainsAny(s, "\"\\")
+}
+
+// fieldByIndex follows index through embedded pointers, false if one is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether omitempty skips v
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package resp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type auditInfo struct {
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type userDTO struct {
+	ID        string            `json:"id"`
+	FirstName string            `json:"first_name"`
+	Nickname  string            `json:"nickname,omitempty"`
+	LastLogin *time.Time        `json:"last_login"`
+	Secret    string            `json:"-"`
+	Labels    map[string]string `json:"labels"`
+	auditInfo
+}
+
+func sampleUser() userDTO {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return userDTO{
+		ID:        "u1",
+		FirstName: "Ada",
+		LastLogin: &at,
+		Secret:    "s",
+		Labels:    map[string]string{"team_name": "core"},
+		auditInfo: auditInfo{CreatedBy: "root", CreatedAt: at},
+	}
+}
+
+func TestConventionApply(t *testing.T) {
+	c := Convention{Naming: CamelCase, TimeFormat: TimeUnixMilli}
+	body, err := json.Marshal(c.Apply(sampleUser()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"u1","firstName":"Ada","lastLogin":1740830400000,"labels":{"teamName":"core"},"createdBy":"root","createdAt":1740830400000}`
+	if string(body) != want {
+		t.Fatalf("body = %s\nwant   %s", body, want)
+	}
+
+	body, _ = json.Marshal(Convention{TimeFormat: time.DateOnly}.Apply(map[string]any{"day": sampleUser().CreatedAt, "n": []int{1}}))
+	if string(body) != `{"day":"2025-03-01","n":[1]}` {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestConventionDecode(t *testing.T) {
+	var u userDTO
+	err := Convention{Naming: CamelCase}.Decode([]byte(`{"id":"u1","firstName":"Ada","createdBy":"root"}`), &u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.FirstName != "Ada" || u.CreatedBy != "root" {
+		t.Fatalf("decoded %+v", u)
+	}
+}
+
+func TestKeyConversion(t *testing.T) {
+	for in, want := range map[string]string{"userId": "user_id", "UserID": "user_id", "HTTPStatus": "http_status", "user_id": "user_id", "v2Name": "v2_name"} {
+		if got := toSnake(in); got != want {
+			t.Errorf("toSnake(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{"user_id": "userId", "id": "id", "created_at_ms": "createdAtMs", "_id": "_id"} {
+		if got := toCamel(in); got != want {
+			t.Errorf("toCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestConventionHandler(t *testing.T) {
+	h := ConventionHandler(Convention{Naming: CamelCase}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ConventionFromContext(r.Context()).Naming != CamelCase {
+			t.Error("convention missing from the request context")
+		}
+		Success(w, map[string]any{"total_count": 2})
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Body.String() != "{\"totalCount\":2}\n" {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+
+	// Other writers keep the global convention
+	w = httptest.NewRecorder()
+	Success(w, map[string]any{"total_count": 2})
+	if w.Body.String() != "{\"total_count\":2}\n" {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+}
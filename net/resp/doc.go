@@ -47,11 +47,115 @@
 //	    Errors:  conflictDetails,
 //	})
 //
+// Validation errors are listed in Errors as validation.Errors, each with the
+// field, message key, parameters and the message in the request language:
+//
+//	lang := validation.Language(c)
+//	if errs := validation.ValidateFields(&body, lang); len(errs) > 0 {
+//	    resp.Fail(c.Writer, resp.BadRequest(validation.Message(lang, "validation.failed"), errs))
+//	    return
+//	}
+//
 // # Content Types
 //
 // The package supports JSON (default), XML, and plain text responses.
 // Content type is automatically set based on the response format.
 //
+// # JSON Encoder
+//
+// Responses are encoded with encoding/json into pooled buffers. Build with
+// -tags sonic or -tags go_json to switch to github.com/bytedance/sonic or
+// github.com/goccy/go-json, the same tags gin uses. Encoder reports the
+// implementation in use. Sonic runs in its encoding/json compatible mode, so
+// response bodies do not change.
+//
+//	go build -tags sonic ./...
+//
+// # Static Responses
+//
+// Payloads that never change can be encoded once at startup and written with
+// no encoding or allocation per request. ServeHTTP also answers If-None-Match
+// with 304 Not Modified:
+//
+//	var enums = resp.MustStatic(http.StatusOK, statusEnums)
+//
+//	r.GET("/enums", gin.WrapH(enums))
+//	// or inside a handler
+//	enums.Write(c.Writer)
+//
+// Run go test -bench . ./resp to compare the encoders and static responses.
+//
+// # Conventions
+//
+// A Convention rewrites keys and times of JSON bodies so one set of DTOs serves
+// consumers with different expectations. Set it globally, or per route group
+// with UseConvention (ConventionHandler for net/http):
+//
+//	resp.SetConvention(resp.Convention{TimeFormat: time.RFC3339})
+//
+//	v2 := r.Group("/v2", resp.UseConvention(resp.Convention{
+//	    Naming:     resp.CamelCase,
+//	    TimeFormat: resp.TimeUnixMilli,
+//	}))
+//
+// Struct fields are named after their json tags, honoring "-", omitempty and
+// embedded structs, then converted; string map keys are converted as well.
+// Request bodies in the same convention are read with Convention.Decode, using
+// ConventionFromContext(r.Context()) for the route group's convention.
+//
+// # Problem Details
+//
+// Failures can be written as RFC 9457 application/problem+json instead of
+// Exception bodies, globally or per route group. Business codes map to type
+// URIs under TypeBase, their text becomes the title and the message the
+// detail; the code and errors are kept as extension members:
+//
+//	resp.SetProblemDetails(resp.ProblemConfig{
+//	    Enabled:  true,
+//	    TypeBase: "https://api.example.com/problems",
+//	})
+//
+//	api := r.Group("/api", resp.UseProblemDetails(resp.ProblemConfig{Enabled: true}))
+//
+// The middleware also records the request path as instance. FailProblem
+// writes a single response as problem details whatever the mode.
+//
+// # Error Reporting
+//
+// An ErrorReporter set with SetErrorReporter receives the failures Fail writes
+// with a 5xx status, and the panics Recovery (RecoveryHandler for net/http)
+// recovers and answers with 500. Behind Recovery, reports carry the request
+// context, so reporters such as observes.SentryReporter attach its user and
+// trace:
+//
+//	resp.SetErrorReporter(observes.SentryReporter{})
+//	r.Use(resp.Recovery())
+//
+// ReportPanic reports panics recovered by other middleware.
+//
+// # Server-Sent Events
+//
+// SSEStream streams events from a channel with id, event and data framing,
+// sends heartbeat comments while idle and stops when the client disconnects.
+// SSEWriter writes events one by one for custom loops:
+//
+//	r.GET("/events", func(c *gin.Context) {
+//	    events := hub.Subscribe(c, resp.LastEventID(c.Request))
+//	    _ = resp.SSEStream(c.Writer, c.Request, events)
+//	})
+//
+// # Files and Streams
+//
+// File and Attachment serve files from disk with range and conditional
+// request support. Attachment sets Content-Disposition with an RFC 5987
+// encoded name, ContentDisposition formats the header for other downloads.
+// Stream sends a reader, with ranges and a generated ETag when it can seek:
+//
+//	resp.Attachment(c.Writer, c.Request, path, "报告.xlsx")
+//
+//	w.Header().Set("Content-Disposition", resp.ContentDisposition("attachment", "export.csv"))
+//	_ = resp.Stream(w, r, bytes.NewReader(data), "text/csv; charset=utf-8")
+//
 // # Error Codes
 //
 // Business error codes are defined in the ecode package and provide
@@ -70,3 +70,8 @@ func NotAllowed(message string, data ...any) *Exception {
 func Gone(message string, data ...any) *Exception {
 	return newResponse(http.StatusGone, ecode.Gone, message, data...)
 }
+
+// TooManyRequests indicates the request was rate limited.
+func TooManyRequests(message string, data ...any) *Exception {
+	return newResponse(http.StatusTooManyRequests, ecode.TooManyRequests, message, data...)
+}
@@ -0,0 +1,165 @@
+package resp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File serves the file at path inline, with its content type guessed from the
+// extension. Range, If-Range, If-None-Match and If-Modified-Since requests are
+// answered, the ETag is derived from the modification time and size. Missing
+// files and directories are answered with 404.
+func File(w http.ResponseWriter, r *http.Request, path string) {
+	serveFile(w, r, path, "")
+}
+
+// Attachment serves the file at path as a download saved under filename, or
+// the base name of path if empty. Names outside ASCII are sent RFC 5987
+// encoded, with an ASCII fallback for old clients.
+func Attachment(w http.ResponseWriter, r *http.Request, path, filename string) {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	serveFile(w, r, path, filename)
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, path, attachment string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			Fail(w, NotFound("File not found"))
+			return
+		}
+		Fail(w, InternalServer("Failed to open file"))
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		Fail(w, InternalServer("Failed to open file"))
+		return
+	}
+	if info.IsDir() {
+		Fail(w, NotFound("File not found"))
+		return
+	}
+
+	h := w.Header()
+	if h.Get("Etag") == "" {
+		h.Set("Etag", `"`+strconv.FormatInt(info.ModTime().UnixNano(), 36)+"-"+strconv.FormatInt(info.Size(), 36)+`"`)
+	}
+	if attachment != "" {
+		h.Set("Content-Disposition", ContentDisposition("attachment", attachment))
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// Stream writes content with the given content type. If content is an
+// io.ReadSeeker, range and conditional requests are answered and a strong ETag
+// is generated from the content unless one is already set; hashing reads the
+// content once before it is sent. Other readers are copied as they are read,
+// without ranges. The returned error is a read or write failure after the
+// headers were sent.
+//
+//	w.Header().Set("Content-Disposition", resp.ContentDisposition("attachment", "report.csv"))
+//	_ = resp.Stream(w, r, reader, "text/csv; charset=utf-8")
+func Stream(w http.ResponseWriter, r *http.Request, content io.Reader, contentType string) error {
+	h := w.Header()
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+
+	rs, ok := content.(io.ReadSeeker)
+	if !ok {
+		h.Set("Accept-Ranges", "none")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return nil
+		}
+		_, err := io.Copy(w, content)
+		return err
+	}
+
+	if h.Get("Etag") == "" {
+		etag, err := contentETag(rs)
+		if err != nil {
+			Fail(w, InternalServer("Failed to read content"))
+			return err
+		}
+		h.Set("Etag", etag)
+	}
+	http.ServeContent(w, r, "", time.Time{}, rs)
+	return nil
+}
+
+// contentETag hashes the remaining content of rs and seeks back
+func contentETag(rs io.ReadSeeker) (string, error) {
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	if _, err := io.Copy(sum, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`, nil
+}
+
+// ContentDisposition formats a Content-Disposition header value, "inline" or
+// "attachment", for filename as in RFC 6266. Names that are not plain ASCII
+// get an ASCII filename fallback and an RFC 5987 encoded filename*.
+func ContentDisposition(disposition, filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if filename == "." || filename == "/" {
+		return disposition
+	}
+
+	fallback := make([]byte, 0, len(filename))
+	plain := true
+	for _, c := range filename {
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '%' {
+			fallback = append(fallback, '_')
+			plain = false
+			continue
+		}
+		fallback = append(fallback, byte(c))
+	}
+
+	v := disposition + `; filename="` + string(fallback) + `"`
+	if !plain {
+		v += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return v
+}
+
+// encodeRFC5987 percent-encodes s except for the attr-char set of RFC 5987
+func encodeRFC5987(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0x0f])
+	}
+	return b.String()
+}
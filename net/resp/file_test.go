@@ -0,0 +1,101 @@
+package resp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"report.csv", `attachment; filename="report.csv"`},
+		{"../../etc/passwd", `attachment; filename="passwd"`},
+		{`say "hi".txt`, `attachment; filename="say _hi_.txt"; filename*=UTF-8''say%20%22hi%22.txt`},
+		{"résumé.pdf", `attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`},
+		{"报告.xlsx", `attachment; filename="__.xlsx"; filename*=UTF-8''%E6%8A%A5%E5%91%8A.xlsx`},
+	}
+	for _, tt := range tests {
+		if got := ContentDisposition("attachment", tt.name); got != tt.want {
+			t.Errorf("ContentDisposition(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/download", nil)
+	r.Header.Set("Range", "bytes=2-4")
+	Attachment(w, r, path, "")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Fatalf("range: status %d, body %q", w.Code, w.Body)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="data.txt"` {
+		t.Errorf("Content-Disposition = %s", cd)
+	}
+	etag := w.Header().Get("Etag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/download", nil)
+	r.Header.Set("If-None-Match", etag)
+	File(w, r, path)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	File(w, httptest.NewRequest(http.MethodGet, "/download", nil), filepath.Dir(path))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("directory: status %d", w.Code)
+	}
+}
+
+func TestStream(t *testing.T) {
+	content := []byte("hello streaming world")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	r.Header.Set("Range", "bytes=6-14")
+	if err := Stream(w, r, bytes.NewReader(content), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusPartialContent || w.Body.String() != "streaming" {
+		t.Fatalf("range: status %d, body %q", w.Code, w.Body)
+	}
+	etag := w.Header().Get("Etag")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	r.Header.Set("If-None-Match", etag)
+	_ = Stream(w, r, bytes.NewReader(content), "text/plain")
+	if w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: status %d", w.Code)
+	}
+
+	// Plain readers are copied without range support
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	r.Header.Set("Range", "bytes=0-4")
+	if err := Stream(w, r, io.MultiReader(bytes.NewReader(content)), ""); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != string(content) {
+		t.Errorf("reader: status %d, body %q", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/octet-stream" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !sonic && !go_json
+
+package resp
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Encoder names the JSON implementation selected at build time.
+const Encoder = "encoding/json"
+
+func marshalJSON(v any) ([]byte, error) { return json.Marshal(v) }
+
+func encodeJSON(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
@@ -0,0 +1,16 @@
+//go:build go_json
+
+package resp
+
+import (
+	"io"
+
+	json "github.com/goccy/go-json"
+)
+
+// Encoder names the JSON implementation selected at build time.
+const Encoder = "go-json"
+
+func marshalJSON(v any) ([]byte, error) { return json.Marshal(v) }
+
+func encodeJSON(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
@@ -0,0 +1,20 @@
+//go:build sonic && !go_json
+
+package resp
+
+import (
+	"io"
+
+	"github.com/bytedance/sonic"
+)
+
+// Encoder names the JSON implementation selected at build time.
+const Encoder = "sonic"
+
+// api keeps the encoding/json output (HTML escaping, sorted map keys) so
+// switching the build tag does not change response bodies.
+var api = sonic.ConfigStd
+
+func marshalJSON(v any) ([]byte, error) { return api.Marshal(v) }
+
+func encodeJSON(w io.Writer, v any) error { return api.NewEncoder(w).Encode(v) }
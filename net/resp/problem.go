@@ -0,0 +1,159 @@
+package resp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ecode"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object. Code and Errors are
+// extension members carrying the business code and validation errors.
+type Problem struct {
+	Type     string `json:"type"`               // URI identifying the problem type
+	Title    string `json:"title"`              // Summary of the problem type
+	Status   int    `json:"status"`             // HTTP status
+	Detail   string `json:"detail,omitempty"`   // Explanation of this occurrence
+	Instance string `json:"instance,omitempty"` // URI of this occurrence, the request path
+	Code     int    `json:"code,omitempty"`
+	Errors   any    `json:"errors,omitempty"`
+}
+
+// ProblemConfig controls problem details output of failure responses
+type ProblemConfig struct {
+	// Enabled writes failures as problem details instead of Exception bodies
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// TypeBase is the base URI of problem types, the business code is
+	// appended, e.g. https://api.example.com/problems/-404. Types are
+	// about:blank when empty.
+	TypeBase string `json:"type_base" yaml:"type_base"`
+	// Types overrides the type URI of specific business codes
+	Types map[int]string `json:"types" yaml:"types"`
+}
+
+var globalProblem atomic.Pointer[ProblemConfig]
+
+// SetProblemDetails sets the problem details mode of responses not covered by
+// UseProblemDetails or ProblemHandler
+func SetProblemDetails(c ProblemConfig) { globalProblem.Store(&c) }
+
+// GlobalProblemDetails returns the configuration set by SetProblemDetails
+func GlobalProblemDetails() ProblemConfig {
+	if c := globalProblem.Load(); c != nil {
+		return *c
+	}
+	return ProblemConfig{}
+}
+
+// problemWriter carries a route group problem configuration and the request
+// path to Fail
+type problemWriter struct {
+	gin.ResponseWriter
+	config   ProblemConfig
+	instance string
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// httpProblemWriter is problemWriter for net/http handlers
+type httpProblemWriter struct {
+	http.ResponseWriter
+	config   ProblemConfig
+	instance string
+}
+
+func (w *httpProblemWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// UseProblemDetails is gin middleware applying c to the failures of a route
+// group, with the request path as instance
+//
+//	api := r.Group("/api", resp.UseProblemDetails(resp.ProblemConfig{Enabled: true}))
+func UseProblemDetails(c ProblemConfig) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Writer = &problemWriter{ResponseWriter: ctx.Writer, config: c, instance: ctx.Request.URL.Path}
+		ctx.Next()
+	}
+}
+
+// ProblemHandler applies c to the failures of a net/http handler
+func ProblemHandler(c ProblemConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&httpProblemWriter{ResponseWriter: w, config: c, instance: r.URL.Path}, r)
+	})
+}
+
+// problemOf returns the problem configuration of w and the request path if a
+// middleware recorded it
+func problemOf(w http.ResponseWriter) (ProblemConfig, string) {
+	for w != nil {
+		switch pw := w.(type) {
+		case *problemWriter:
+			return pw.config, pw.instance
+		case *httpProblemWriter:
+			return pw.config, pw.instance
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return GlobalProblemDetails(), ""
+}
+
+// TypeURI returns the problem type URI of a business code
+func (c ProblemConfig) TypeURI(code int) string {
+	if t, ok := c.Types[code]; ok {
+		return t
+	}
+	if c.TypeBase == "" {
+		return "about:blank"
+	}
+	return strings.TrimSuffix(c.TypeBase, "/") + "/" + strconv.Itoa(code)
+}
+
+// Problem converts a failure into problem details. The title is the text of
+// the business code, or the status text for about:blank types as RFC 9457
+// asks; the message becomes the detail when it says more than the title.
+func (c ProblemConfig) Problem(r *Exception, instance string) *Problem {
+	status, result := buildFailureResponse(r)
+	e := result.(*Exception)
+
+	p := &Problem{
+		Type:     c.TypeURI(e.Code),
+		Status:   status,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Errors,
+	}
+	if text := ecode.Text(e.Code); p.Type != "about:blank" && text != "Unknown error" {
+		p.Title = text
+	} else {
+		p.Title = http.StatusText(status)
+	}
+	if e.Message != p.Title {
+		p.Detail = e.Message
+	}
+	return p
+}
+
+// FailProblem writes a failure as problem details whatever the configured
+// mode, with the request path as instance
+func FailProblem(w http.ResponseWriter, req *http.Request, r *Exception) {
+	if r == nil {
+		r = &Exception{Status: http.StatusInternalServerError, Code: ecode.ServerErr}
+	}
+	r = redacted(r)
+	c, _ := problemOf(w)
+	p := c.Problem(r, req.URL.Path)
+	writeResponse(w, "Problem", p.Status, p)
+	if p.Status >= http.StatusInternalServerError {
+		reportFailure(w, r)
+	}
+}
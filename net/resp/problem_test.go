@@ -0,0 +1,77 @@
+package resp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ncobase/ncore/ecode"
+)
+
+func TestProblemHandler(t *testing.T) {
+	cfg := ProblemConfig{
+		Enabled:  true,
+		TypeBase: "https://api.example.com/problems/",
+		Types:    map[int]string{ecode.Conflict: "https://api.example.com/problems/conflict"},
+	}
+	tests := []struct {
+		exception *Exception
+		want      Problem
+	}{
+		{
+			NotFound("User 42 not found"),
+			Problem{Type: "https://api.example.com/problems/-404", Title: "Nothing found", Status: 404, Detail: "User 42 not found", Code: ecode.NothingFound},
+		},
+		{
+			Conflict("Conflict"),
+			Problem{Type: "https://api.example.com/problems/conflict", Title: "Conflict", Status: 409, Code: ecode.Conflict},
+		},
+		{
+			&Exception{Status: http.StatusTeapot, Code: 12345, Message: "short and stout"},
+			Problem{Type: "https://api.example.com/problems/12345", Title: "I'm a teapot", Status: 418, Detail: "short and stout", Code: 12345},
+		},
+	}
+	for _, tt := range tests {
+		h := ProblemHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Fail(w, tt.exception)
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+		if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Fatalf("content type = %q", ct)
+		}
+		var got Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		tt.want.Instance = "/users/42"
+		if w.Code != tt.want.Status || got != tt.want {
+			t.Errorf("got %d %+v, want %+v", w.Code, got, tt.want)
+		}
+	}
+}
+
+func TestProblemAboutBlank(t *testing.T) {
+	w := httptest.NewRecorder()
+	FailProblem(w, httptest.NewRequest(http.MethodPost, "/orders", nil), BadRequest("Missing quantity", map[string]string{"quantity": "required"}))
+
+	var got map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["type"] != "about:blank" || got["title"] != "Bad Request" || got["detail"] != "Missing quantity" || got["instance"] != "/orders" {
+		t.Errorf("problem = %v", got)
+	}
+	if errs, _ := got["errors"].(map[string]any); errs["quantity"] != "required" {
+		t.Errorf("errors = %v", got["errors"])
+	}
+
+	// Without the mode, Fail keeps the Exception body
+	w = httptest.NewRecorder()
+	Fail(w, BadRequest("Missing quantity"))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("content type = %q", ct)
+	}
+}
@@ -0,0 +1,30 @@
+package resp
+
+import (
+	"sync/atomic"
+
+	"github.com/ncobase/ncore/utils/redact"
+)
+
+var failureRedactor atomic.Pointer[redact.Redactor]
+
+// SetRedactor sets the redactor masking sensitive values in the message,
+// errors and data of failures before they are written or reported, nil stops
+// redacting. redact.Default() masks the registered fields and patterns.
+func SetRedactor(r *redact.Redactor) { failureRedactor.Store(r) }
+
+// redacted returns the failure with sensitive values masked, r itself when no
+// redactor is set
+func redacted(r *Exception) *Exception {
+	rd := failureRedactor.Load()
+	if rd == nil {
+		return r
+	}
+	return &Exception{
+		Status:  r.Status,
+		Code:    r.Code,
+		Message: rd.String(r.Message),
+		Errors:  rd.Value(r.Errors),
+		Data:    rd.Value(r.Data),
+	}
+}
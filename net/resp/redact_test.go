@@ -0,0 +1,39 @@
+package resp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ncobase/ncore/utils/redact"
+)
+
+func TestFailRedacted(t *testing.T) {
+	failure := BadRequest("invalid card 4111 1111 1111 1111", map[string]any{"password": "hunter2", "field": "card"})
+
+	SetRedactor(redact.Default())
+	defer SetRedactor(nil)
+
+	w := httptest.NewRecorder()
+	Fail(w, failure)
+	var body struct {
+		Message string         `json:"message"`
+		Errors  map[string]any `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Message != "invalid card ******" || body.Errors["password"] != "******" || body.Errors["field"] != "card" {
+		t.Errorf("body = %s", w.Body.String())
+	}
+	if failure.Message != "invalid card 4111 1111 1111 1111" {
+		t.Error("Fail changed the failure")
+	}
+
+	SetRedactor(nil)
+	w = httptest.NewRecorder()
+	Fail(w, failure)
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Errors["password"] != "hunter2" {
+		t.Errorf("body without redactor = %s", w.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+package resp
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+// ErrorReporter forwards server errors to an error tracker such as Sentry
+type ErrorReporter interface {
+	// ReportError reports a failure answered with a 5xx status
+	ReportError(ctx context.Context, err error)
+	// ReportPanic reports a panic recovered while serving a request
+	ReportPanic(ctx context.Context, recovered any)
+}
+
+type reporterHolder struct{ ErrorReporter }
+
+var errorReporter atomic.Pointer[reporterHolder]
+
+// SetErrorReporter sets the reporter receiving the failures Fail answers with
+// a 5xx status and the panics Recovery recovers, nil stops reporting
+func SetErrorReporter(r ErrorReporter) {
+	if r == nil {
+		errorReporter.Store(nil)
+		return
+	}
+	errorReporter.Store(&reporterHolder{r})
+}
+
+// reporter returns the error reporter, nil when none is set
+func reporter() ErrorReporter {
+	if h := errorReporter.Load(); h != nil {
+		return h.ErrorReporter
+	}
+	return nil
+}
+
+// reportWriter carries the request context to Fail and records whether a
+// panic of the request was reported, so the failure answering it is not
+type reportWriter struct {
+	gin.ResponseWriter
+	ctx      func() context.Context
+	reported bool
+}
+
+func (w *reportWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// httpReportWriter is reportWriter for net/http handlers
+type httpReportWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	reported bool
+}
+
+func (w *httpReportWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Recovery is gin middleware recovering panics of later handlers. A panic is
+// reported with the request context and answered with 500 unless a response
+// was written; failures written by Fail are reported with the request context
+// as well, so the user and trace of the request reach the reporter.
+//
+//	r.Use(resp.Recovery())
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &reportWriter{ResponseWriter: c.Writer}
+		// Middleware may replace the request to add values, read it when reporting
+		w.ctx = func() context.Context { return ctxutil.WithGinContext(c.Request.Context(), c) }
+		c.Writer = w
+
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// Deliberate aborts are re-raised for the server to handle
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			ReportPanic(c.Writer, r)
+			if !c.Writer.Written() {
+				Fail(c.Writer, InternalServer(http.StatusText(http.StatusInternalServerError)))
+			}
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
+
+// RecoveryHandler is Recovery for net/http handlers
+func RecoveryHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &httpReportWriter{ResponseWriter: w, ctx: r.Context()}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			ReportPanic(rw, v)
+			Fail(rw, InternalServer(http.StatusText(http.StatusInternalServerError)))
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// ReportPanic reports a panic recovered while writing to w, for recovery code
+// of its own. A failure written to w afterwards is not reported again.
+func ReportPanic(w http.ResponseWriter, recovered any) {
+	r := reporter()
+	if r == nil {
+		return
+	}
+	ctx := context.Background()
+	switch rw := findReportWriter(w).(type) {
+	case *reportWriter:
+		ctx, rw.reported = rw.ctx(), true
+	case *httpReportWriter:
+		ctx, rw.reported = rw.ctx, true
+	}
+	r.ReportPanic(ctx, recovered)
+}
+
+// reportFailure reports a failure Fail answered with a 5xx status
+func reportFailure(w http.ResponseWriter, e *Exception) {
+	r := reporter()
+	if r == nil {
+		return
+	}
+	ctx := context.Background()
+	switch rw := findReportWriter(w).(type) {
+	case *reportWriter:
+		if rw.reported {
+			return
+		}
+		ctx = rw.ctx()
+	case *httpReportWriter:
+		if rw.reported {
+			return
+		}
+		ctx = rw.ctx
+	}
+	r.ReportError(ctx, e)
+}
+
+// findReportWriter returns the reportWriter or httpReportWriter w wraps, if any
+func findReportWriter(w http.ResponseWriter) http.ResponseWriter {
+	for w != nil {
+		switch w.(type) {
+		case *reportWriter, *httpReportWriter:
+			return w
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package resp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+type recordingReporter struct {
+	errors  []error
+	panics  []any
+	userIDs []string
+}
+
+func (r *recordingReporter) ReportError(ctx context.Context, err error) {
+	r.errors = append(r.errors, err)
+	r.userIDs = append(r.userIDs, ctxutil.GetUserID(ctx))
+}
+
+func (r *recordingReporter) ReportPanic(ctx context.Context, recovered any) {
+	r.panics = append(r.panics, recovered)
+	r.userIDs = append(r.userIDs, ctxutil.GetUserID(ctx))
+}
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rep := &recordingReporter{}
+	SetErrorReporter(rep)
+	defer SetErrorReporter(nil)
+
+	r := gin.New()
+	r.Use(Recovery(), func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ctxutil.SetUserID(c.Request.Context(), "u1"))
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	r.GET("/fail", func(c *gin.Context) { Fail(c.Writer, InternalServer("db down")) })
+	r.GET("/bad", func(c *gin.Context) { Fail(c.Writer, BadRequest("bad input")) })
+
+	for _, path := range []string{"/panic", "/fail", "/bad"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if len(rep.panics) != 1 || rep.panics[0] != "boom" {
+		t.Errorf("panics = %v, want [boom]", rep.panics)
+	}
+	if len(rep.errors) != 1 || rep.errors[0].(*Exception).Message != "db down" {
+		t.Errorf("errors = %v, want the db down failure only", rep.errors)
+	}
+	for _, id := range rep.userIDs {
+		if id != "u1" {
+			t.Errorf("reported user ID = %q, want u1", id)
+		}
+	}
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	rep := &recordingReporter{}
+	SetErrorReporter(rep)
+	defer SetErrorReporter(nil)
+
+	h := RecoveryHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+	if len(rep.panics) != 1 || len(rep.errors) != 0 {
+		t.Errorf("got %d panics and %d errors, want 1 panic only", len(rep.panics), len(rep.errors))
+	}
+}
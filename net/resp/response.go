@@ -1,10 +1,11 @@
 package resp
 
 import (
-	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"net/http"
 
+	"github.com/ncobase/ncore/bytespool"
 	"github.com/ncobase/ncore/ecode"
 )
 
@@ -17,6 +18,14 @@ type Exception struct {
 	Data    any    `json:"data,omitempty"`    // Response data
 }
 
+// Error implements error, so failures can be reported
+func (e *Exception) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("%d %s (code %d)", e.Status, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
 // newResponse creates a new response.
 func newResponse(status, code int, message string, data ...any) *Exception {
 	var responseData any
@@ -88,7 +97,10 @@ func buildSuccessResponse(r *Exception) (int, any) {
 	return status, map[string]any{"message": message}
 }
 
-// Fail handles failure responses.
+// Fail handles failure responses. They are written as RFC 9457 problem
+// details when enabled globally or for the route group. Failures with a 5xx
+// status are passed to the ErrorReporter, if one is set. Sensitive values are
+// masked first when a redactor is set.
 func Fail(w http.ResponseWriter, r *Exception, abort ...bool) {
 	if r == nil {
 		r = &Exception{
@@ -97,8 +109,20 @@ func Fail(w http.ResponseWriter, r *Exception, abort ...bool) {
 			Message: ecode.Text(ecode.ServerErr),
 		}
 	}
-	statusCode, result := buildFailureResponse(r)
-	writeResponse(w, "JSON", statusCode, result)
+	r = redacted(r)
+	var statusCode int
+	if c, instance := problemOf(w); c.Enabled {
+		p := c.Problem(r, instance)
+		statusCode = p.Status
+		writeResponse(w, "Problem", statusCode, p)
+	} else {
+		var result any
+		statusCode, result = buildFailureResponse(r)
+		writeResponse(w, "JSON", statusCode, result)
+	}
+	if statusCode >= http.StatusInternalServerError {
+		reportFailure(w, r)
+	}
 
 	if len(abort) > 0 && abort[0] {
 		http.Error(w, "", statusCode)
@@ -128,47 +152,59 @@ func buildFailureResponse(r *Exception) (int, any) {
 	}
 }
 
+// bufPool recycles the buffers responses are encoded into, buffers grown by
+// unusually large responses are left to the garbage collector.
+var bufPool = bytespool.New("resp", 64<<10)
+
 // writeResponse writes the response based on the specified status code.
+// The body is encoded into a pooled buffer first, so headers are set before
+// the status is written and an encoding failure can still be answered with 500.
 func writeResponse(w http.ResponseWriter, contextType string, code int, res any) {
-	w.WriteHeader(code)
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var contentType string
 	switch contextType {
-	case "JSON":
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		if err := json.NewEncoder(w).Encode(res); err != nil {
-			http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
-		}
 	case "XML":
-		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
-		if err := xml.NewEncoder(w).Encode(res); err != nil {
+		contentType = "application/xml; charset=utf-8"
+		if err := xml.NewEncoder(buf).Encode(res); err != nil {
 			http.Error(w, "Failed to encode XML response", http.StatusInternalServerError)
+			return
 		}
 	case "Text":
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		var text string
+		contentType = "text/plain; charset=utf-8"
 		switch v := res.(type) {
 		case string:
-			text = v
+			buf.WriteString(v)
 		case []byte:
-			text = string(v)
+			buf.Write(v)
 		case error:
-			text = v.Error()
+			buf.WriteString(v.Error())
 		default:
 			// Fallback to JSON representation for complex types
-			data, err := json.Marshal(v)
+			data, err := marshalJSON(v)
 			if err != nil {
 				http.Error(w, "Failed to convert response to text", http.StatusInternalServerError)
 				return
 			}
-			text = string(data)
+			buf.Write(data)
 		}
-		if _, err := w.Write([]byte(text)); err != nil {
-			http.Error(w, "Failed to write text response", http.StatusInternalServerError)
+	case "Problem":
+		contentType = ProblemContentType
+		if err := encodeJSON(buf, conventionOf(w).Apply(res)); err != nil {
+			http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
+			return
 		}
 	default:
-		// Default to JSON if no contextType matches
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		if err := json.NewEncoder(w).Encode(res); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		// JSON, also used if no contextType matches
+		contentType = "application/json; charset=utf-8"
+		if err := encodeJSON(buf, conventionOf(w).Apply(res)); err != nil {
+			http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
+			return
 		}
 	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, _ = w.Write(buf.Bytes())
 }
@@ -0,0 +1,155 @@
+package resp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type item struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags"`
+	Score float64  `json:"score"`
+}
+
+func listPayload(n int) map[string]any {
+	items := make([]item, n)
+	for i := range items {
+		items[i] = item{ID: strconv.Itoa(i), Name: "item <" + strconv.Itoa(i) + ">", Tags: []string{"a", "b"}, Score: float64(i) / 3}
+	}
+	return map[string]any{"items": items, "total": n, "has_next": false}
+}
+
+func TestSuccessHeadersAndBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	WithStatusCode(w, http.StatusCreated, map[string]any{"id": "1"})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("content type = %q", ct)
+	}
+	if got := w.Body.String(); got != "{\"id\":\"1\"}\n" {
+		t.Fatalf("body = %q", got)
+	}
+}
+
+func TestFailBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	Fail(w, NotFound("missing"))
+
+	var body Exception
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound || body.Message != "missing" || body.Code == 0 {
+		t.Fatalf("got %d %+v", w.Code, body)
+	}
+}
+
+func TestEncodeErrorAnswers500(t *testing.T) {
+	w := httptest.NewRecorder()
+	Success(w, map[string]any{"ch": make(chan int)})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
+
+func TestTextResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeResponse(w, "Text", http.StatusOK, errors.New("plain"))
+
+	if w.Body.String() != "plain" || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("got %q %q", w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
+
+func TestStaticMatchesSuccess(t *testing.T) {
+	payload := listPayload(3)
+
+	want := httptest.NewRecorder()
+	Success(want, payload)
+
+	s := MustStatic(http.StatusOK, payload)
+	got := httptest.NewRecorder()
+	s.Write(got)
+
+	if got.Body.String() != want.Body.String() || got.Code != want.Code {
+		t.Fatalf("static %d %q, success %d %q", got.Code, got.Body.String(), want.Code, want.Body.String())
+	}
+	if got.Header().Get("ETag") == "" {
+		t.Fatal("missing ETag")
+	}
+
+	failed, err := NewStaticFail(BadRequest("nope"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw := httptest.NewRecorder()
+	Fail(fw, BadRequest("nope"))
+	if string(failed.Body()) != fw.Body.String() || failed.Status() != fw.Code {
+		t.Fatalf("static fail %q, fail %q", failed.Body(), fw.Body.String())
+	}
+}
+
+func TestStaticNotModified(t *testing.T) {
+	s := MustStatic(http.StatusOK, map[string]string{"k": "v"})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", s.ETag())
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// discardWriter is a reusable ResponseWriter so benchmarks measure encoding,
+// not the recorder.
+type discardWriter struct{ h http.Header }
+
+func (d *discardWriter) Header() http.Header         { return d.h }
+func (d *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardWriter) WriteHeader(int)             {}
+
+func benchmarkWriter() *discardWriter { return &discardWriter{h: make(http.Header)} }
+
+// BenchmarkStdEncoder is the baseline: a fresh encoding/json encoder writing
+// straight to the response, as resp did before buffers were pooled.
+func BenchmarkStdEncoder(b *testing.B) {
+	payload := listPayload(50)
+	w := benchmarkWriter()
+	b.ReportAllocs()
+	for b.Loop() {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSuccess(b *testing.B) {
+	payload := listPayload(50)
+	w := benchmarkWriter()
+	b.ReportAllocs()
+	for b.Loop() {
+		Success(w, payload)
+	}
+}
+
+func BenchmarkStatic(b *testing.B) {
+	s := MustStatic(http.StatusOK, listPayload(50))
+	w := benchmarkWriter()
+	b.ReportAllocs()
+	for b.Loop() {
+		s.Write(w)
+	}
+}
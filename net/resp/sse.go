@@ -0,0 +1,176 @@
+package resp
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a server-sent event.
+type Event struct {
+	ID    string        // Sent back by reconnecting clients as Last-Event-ID
+	Event string        // Event type, clients default to "message"
+	Data  any           // Strings and bytes are sent as is, other values as JSON
+	Retry time.Duration // Reconnection delay advised to the client, if set
+}
+
+// SSEOptions configures SSEStream.
+type SSEOptions struct {
+	// Heartbeat is the interval of keep-alive comments sent while no event is,
+	// so proxies keep idle streams open. Defaults to 15s, negative disables.
+	Heartbeat time.Duration
+}
+
+// SSEWriter writes server-sent events to a response.
+type SSEWriter struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	buf bytes.Buffer
+}
+
+// NewSSEWriter sets the event stream headers and writes the 200 status. It
+// fails with http.ErrNotSupported if the response cannot be flushed.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	s := &SSEWriter{w: w, rc: http.NewResponseController(w)}
+	// Streams outlive the server's write timeout
+	_ = s.rc.SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	if err := s.rc.Flush(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Send writes and flushes an event.
+func (s *SSEWriter) Send(e Event) error {
+	s.buf.Reset()
+	if e.ID != "" {
+		s.field("id", e.ID)
+	}
+	if e.Event != "" {
+		s.field("event", e.Event)
+	}
+	if e.Retry > 0 {
+		s.field("retry", strconv.FormatInt(e.Retry.Milliseconds(), 10))
+	}
+
+	var data string
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := marshalJSON(conventionOf(s.w).Apply(v))
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	// Each line is its own data field, clients join them with newlines
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		s.buf.WriteString("data: ")
+		s.buf.WriteString(line)
+		s.buf.WriteByte('\n')
+	}
+	s.buf.WriteByte('\n')
+	return s.flush()
+}
+
+// Comment writes and flushes a comment, which clients ignore.
+func (s *SSEWriter) Comment(text string) error {
+	s.buf.Reset()
+	for _, line := range strings.Split(text, "\n") {
+		s.buf.WriteString(": ")
+		s.buf.WriteString(line)
+		s.buf.WriteByte('\n')
+	}
+	s.buf.WriteByte('\n')
+	return s.flush()
+}
+
+// field writes a single line field, dropping line breaks that would end it
+func (s *SSEWriter) field(name, value string) {
+	s.buf.WriteString(name)
+	s.buf.WriteString(": ")
+	s.buf.WriteString(strings.NewReplacer("\r", "", "\n", "").Replace(value))
+	s.buf.WriteByte('\n')
+}
+
+func (s *SSEWriter) flush() error {
+	if _, err := s.w.Write(s.buf.Bytes()); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// SSEStream streams the events of ch until ch is closed, returning nil, or
+// the client disconnects, returning the request context's error. Heartbeat
+// comments are sent while ch is idle.
+//
+//	events := make(chan resp.Event)
+//	go produce(ctx, events) // closes events when done
+//	if err := resp.SSEStream(w, r, events); err != nil {
+//	    // client gone or write failed
+//	}
+func SSEStream(w http.ResponseWriter, r *http.Request, ch <-chan Event, opts ...SSEOptions) error {
+	var opt SSEOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Heartbeat == 0 {
+		opt.Heartbeat = 15 * time.Second
+	}
+
+	s, err := NewSSEWriter(w)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ticker    *time.Ticker
+		heartbeat <-chan time.Time
+	)
+	if opt.Heartbeat > 0 {
+		ticker = time.NewTicker(opt.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(e); err != nil {
+				return err
+			}
+			if ticker != nil {
+				ticker.Reset(opt.Heartbeat)
+			}
+		case <-heartbeat:
+			if err := s.Comment("ping"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// LastEventID returns the ID of the last event a reconnecting client
+// received, empty on the first connection.
+func LastEventID(r *http.Request) string {
+	return r.Header.Get("Last-Event-ID")
+}
@@ -0,0 +1,50 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEStream(t *testing.T) {
+	ch := make(chan Event, 3)
+	ch <- Event{ID: "1", Event: "greeting", Data: "hello\nworld"}
+	ch <- Event{ID: "2", Data: map[string]any{"n": 2}, Retry: 3 * time.Second}
+	ch <- Event{Event: "bad\nname"}
+	close(ch)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	if err := SSEStream(w, r, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+	want := "id: 1\nevent: greeting\ndata: hello\ndata: world\n\n" +
+		"id: 2\nretry: 3000\ndata: {\"n\":2}\n\n" +
+		"event: badname\ndata: \n\n"
+	if got := w.Body.String(); got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+func TestSSEStreamDisconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	err := SSEStream(w, r, make(chan Event), SSEOptions{Heartbeat: 10 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(w.Body.String(), ": ping\n\n") {
+		t.Fatalf("no heartbeat in %q", w.Body.String())
+	}
+}
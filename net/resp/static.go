@@ -0,0 +1,100 @@
+package resp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Static is a JSON response encoded once and written many times, for payloads
+// that do not change between requests such as enums, feature flags or
+// configuration served to clients.
+type Static struct {
+	status int
+	body   []byte
+	etag   string
+	// etagValue is shared by every write so the headers cost no allocation.
+	etagValue []string
+}
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// NewStatic pre-encodes a success response, with the same body Success and
+// WithStatusCode would write. The global convention at the time of the call
+// applies, route group conventions do not.
+func NewStatic(statusCode int, data ...any) (*Static, error) {
+	var message string
+	var responseData any
+
+	if len(data) > 0 {
+		responseData = data[0]
+		if strData, ok := responseData.(string); ok {
+			message = strData
+			responseData = nil
+		}
+	}
+
+	statusCode, result := buildSuccessResponse(newResponse(statusCode, 0, message, responseData))
+	return newStatic(statusCode, result)
+}
+
+// NewStaticFail pre-encodes a failure response, with the same body Fail would write.
+func NewStaticFail(r *Exception) (*Static, error) {
+	statusCode, result := buildFailureResponse(r)
+	return newStatic(statusCode, result)
+}
+
+// MustStatic is like NewStatic but panics if the payload cannot be encoded.
+// It is meant for package level variables.
+func MustStatic(statusCode int, data ...any) *Static {
+	s, err := NewStatic(statusCode, data...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func newStatic(statusCode int, result any) (*Static, error) {
+	body, err := marshalJSON(GlobalConvention().Apply(result))
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	return &Static{
+		status:    statusCode,
+		body:      body,
+		etag:      etag,
+		etagValue: []string{etag},
+	}, nil
+}
+
+// Status returns the HTTP status code of the response.
+func (s *Static) Status() int { return s.status }
+
+// Body returns the encoded body. It must not be modified.
+func (s *Static) Body() []byte { return s.body }
+
+// ETag returns the strong entity tag of the body.
+func (s *Static) ETag() string { return s.etag }
+
+// Write writes the pre-encoded response without encoding anything.
+func (s *Static) Write(w http.ResponseWriter) {
+	h := w.Header()
+	h["Content-Type"] = jsonContentType
+	h["Etag"] = s.etagValue
+	w.WriteHeader(s.status)
+	_, _ = w.Write(s.body)
+}
+
+// ServeHTTP writes the response, answering a matching If-None-Match on
+// successful responses with 304 Not Modified.
+func (s *Static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.status >= 200 && s.status < 300 && r.Header.Get("If-None-Match") == s.etag {
+		w.Header()["Etag"] = s.etagValue
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.Write(w)
+}
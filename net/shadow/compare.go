@@ -0,0 +1,94 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+)
+
+// maxFields bounds the paths listed in a diff
+const maxFields = 20
+
+// compare returns the paths at which two response bodies differ, nil when they
+// are equal. Fields in ignore are skipped by name or path.
+func compare(primary, shadow []byte, ignore map[string]bool) []string {
+	if bytes.Equal(primary, shadow) {
+		return nil
+	}
+	a, errA := decode(primary)
+	b, errB := decode(shadow)
+	if errA != nil || errB != nil {
+		return []string{"body"}
+	}
+	var fields []string
+	diffValue("", a, b, ignore, &fields)
+	return fields
+}
+
+// diffValue appends the paths at which a and b differ
+func diffValue(path string, a, b any, ignore map[string]bool, fields *[]string) {
+	if len(*fields) >= maxFields {
+		return
+	}
+	switch va := a.(type) {
+	case map[string]any:
+		vb, ok := b.(map[string]any)
+		if !ok {
+			*fields = append(*fields, pathOrBody(path))
+			return
+		}
+		keys := make([]string, 0, len(va)+len(vb))
+		for k := range va {
+			keys = append(keys, k)
+		}
+		for k := range vb {
+			if _, ok := va[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if ignore[k] || ignore[p] {
+				continue
+			}
+			diffValue(p, va[k], vb[k], ignore, fields)
+		}
+	case []any:
+		vb, ok := b.([]any)
+		if !ok || len(va) != len(vb) {
+			*fields = append(*fields, pathOrBody(path))
+			return
+		}
+		for i := range va {
+			diffValue(path+"["+strconv.Itoa(i)+"]", va[i], vb[i], ignore, fields)
+		}
+	default:
+		// Decoded scalars are comparable, and a scalar never equals a map or slice
+		if a != b {
+			*fields = append(*fields, pathOrBody(path))
+		}
+	}
+}
+
+func pathOrBody(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
+
+// decode parses a JSON document, keeping numbers exact
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
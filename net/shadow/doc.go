@@ -0,0 +1,41 @@
+// Package shadow mirrors a share of live requests to a second environment and
+// compares its responses with the ones served, to validate a new version of a
+// service or extension against real traffic before cutting over to it.
+//
+// # Mirroring
+//
+//	sh := shadow.New(&shadow.Options{
+//	    Target:  "http://api-canary.internal:8080",
+//	    Percent: 5,
+//	    Header:  http.Header{"Authorization": {"Bearer " + canaryToken}},
+//	    Logger:  logger.StdLogger(),
+//	})
+//	defer sh.Close()
+//
+//	r.Use(sh.Middleware())
+//	r.GET("/admin/shadow", sh.Handler())
+//
+// Mirrored requests are sent after the primary response is written, in the
+// background, so they never add latency to the client. At most Concurrency
+// requests are in flight; requests beyond that are dropped and counted. Only
+// GET and HEAD requests are mirrored by default, because writes replayed on an
+// environment that shares downstream systems would apply twice. The target
+// receives the X-Shadow-Request header and requests carrying it are never
+// mirrored again.
+//
+// # Scrubbing
+//
+// Mirrored requests are scrubbed with a redact.Redactor before they leave:
+// sensitive headers such as Authorization and Cookie are removed, and
+// sensitive query parameters, JSON and form fields and patterns such as card
+// numbers are masked. Credentials for the target go in Header.
+//
+// # Diffs
+//
+// The status and body of the shadow response are compared with the primary
+// response. JSON bodies are compared value by value, ignoring IgnoreFields such
+// as generated IDs and timestamps, and a diff lists the paths that differ, not
+// their values. Report, also served by Handler, has the counters per route and
+// the most recent diffs. Replay sends a previously captured request and compares
+// its response the same way.
+package shadow
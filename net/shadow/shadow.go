@@ -0,0 +1,573 @@
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/net/resp"
+	"github.com/ncobase/ncore/utils/redact"
+)
+
+// HeaderShadow marks mirrored requests
+const HeaderShadow = "X-Shadow-Request"
+
+// ErrNotScrubbable is returned by Replay for bodies it cannot scrub, such as
+// multipart uploads and binary data
+var ErrNotScrubbable = errors.New("shadow: request body cannot be scrubbed")
+
+// hopHeaders are not forwarded to the target
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length",
+}
+
+// Logger receives diffs, *logger.Logger implements it
+type Logger interface {
+	Warnf(ctx context.Context, format string, args ...any)
+}
+
+// Options configures a Shadow
+type Options struct {
+	Target       string           // Base URL of the shadow environment, required
+	Percent      float64          // Share of requests mirrored, 0 to 100
+	Methods      []string         // Defaults to GET and HEAD
+	SkipPaths    []string         // Path prefixes never mirrored
+	Header       http.Header      // Set on every mirrored request, e.g. credentials for the target
+	IgnoreFields []string         // JSON fields or paths left out of comparisons, e.g. "id" or "data.updated_at"
+	Redactor     *redact.Redactor // Scrubs mirrored requests, defaults to redact.Default()
+	Client       *http.Client     // Defaults to http.DefaultClient
+	Timeout      time.Duration    // Timeout of a mirrored request, default 5s
+	Concurrency  int              // Mirrored requests in flight, default 10
+	MaxBodySize  int64            // Larger bodies are neither mirrored nor compared, default 1MB
+	MaxDiffs     int              // Recent diffs kept for the report, default 100
+	Logger       Logger           // Logs diffs, nil disables logging
+	OnDiff       func(d *Diff)    // Called with every diff, from the mirroring goroutine
+}
+
+// Request is a request with the response the primary environment served
+type Request struct {
+	Method   string
+	URL      string // Path and query, e.g. "/users/1?fields=name"
+	Route    string // Method and route template, e.g. "GET /users/:id", defaults to method and path
+	Header   http.Header
+	Body     []byte
+	Status   int    // Status of the primary response
+	Response []byte // Body of the primary response
+	Latency  time.Duration
+}
+
+// Diff is a shadow response that differs from the primary one, or a mirrored
+// request that failed. It holds where the responses differ, not their values.
+type Diff struct {
+	Time          time.Time     `json:"time"`
+	Route         string        `json:"route"`
+	Path          string        `json:"path"`
+	Status        int           `json:"status"`
+	ShadowStatus  int           `json:"shadow_status,omitempty"`
+	Fields        []string      `json:"fields,omitempty"` // JSON paths that differ, "body" when a body is not JSON
+	Latency       time.Duration `json:"latency"`
+	ShadowLatency time.Duration `json:"shadow_latency"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// Stats are the counters of mirrored requests
+type Stats struct {
+	Mirrored int64 `json:"mirrored"`
+	Matched  int64 `json:"matched"`
+	Differed int64 `json:"differed"`
+	Failed   int64 `json:"failed"`  // Requests the target did not answer
+	Dropped  int64 `json:"dropped"` // Requests over the concurrency limit
+	Skipped  int64 `json:"skipped"` // Requests with bodies too large or not scrubbable
+}
+
+func (s *Stats) add(o *Stats) {
+	s.Mirrored += o.Mirrored
+	s.Matched += o.Matched
+	s.Differed += o.Differed
+	s.Failed += o.Failed
+	s.Dropped += o.Dropped
+	s.Skipped += o.Skipped
+}
+
+// Shadow mirrors requests to a target environment and compares the responses
+type Shadow struct {
+	opts    Options
+	target  *url.URL
+	client  *http.Client
+	methods map[string]bool
+	ignore  map[string]bool
+	percent atomic.Uint64
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	routes map[string]*Stats
+	diffs  []*Diff // Ring buffer of recent diffs
+	next   int
+}
+
+// New creates a Shadow, panicking without a valid target
+func New(opts *Options) *Shadow {
+	if opts == nil || opts.Target == "" {
+		panic("shadow: target is required")
+	}
+	target, err := url.Parse(strings.TrimSuffix(opts.Target, "/"))
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		panic(fmt.Sprintf("shadow: invalid target %q", opts.Target))
+	}
+
+	o := *opts
+	if len(o.Methods) == 0 {
+		o.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if o.Redactor == nil {
+		o.Redactor = redact.Default()
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = 1 << 20
+	}
+	if o.MaxDiffs <= 0 {
+		o.MaxDiffs = 100
+	}
+
+	s := &Shadow{
+		opts:    o,
+		target:  target,
+		client:  o.Client,
+		methods: make(map[string]bool, len(o.Methods)),
+		ignore:  make(map[string]bool, len(o.IgnoreFields)),
+		sem:     make(chan struct{}, o.Concurrency),
+		routes:  make(map[string]*Stats),
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	for _, m := range o.Methods {
+		s.methods[strings.ToUpper(m)] = true
+	}
+	for _, f := range o.IgnoreFields {
+		s.ignore[f] = true
+	}
+	s.SetPercent(o.Percent)
+	return s
+}
+
+// Percent returns the share of requests mirrored
+func (s *Shadow) Percent() float64 {
+	return math.Float64frombits(s.percent.Load())
+}
+
+// SetPercent changes the share of requests mirrored, e.g. to ramp up shadowing
+func (s *Shadow) SetPercent(p float64) {
+	s.percent.Store(math.Float64bits(min(max(p, 0), 100)))
+}
+
+// Close stops mirroring and waits for the requests in flight
+func (s *Shadow) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Middleware returns gin middleware mirroring the sampled requests after they
+// are served
+func (s *Shadow) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.sample(c.Request) {
+			c.Next()
+			return
+		}
+
+		route := routeOf(c)
+		body, ok := readBody(c.Request, s.opts.MaxBodySize)
+		if !ok || (len(body) > 0 && !scrubbable(c.ContentType())) {
+			s.count(route, func(st *Stats) { st.Skipped++ })
+			c.Next()
+			return
+		}
+
+		w := &recorder{ResponseWriter: c.Writer, max: s.opts.MaxBodySize}
+		c.Writer = w
+		start := time.Now()
+		c.Next()
+
+		if w.over {
+			s.count(route, func(st *Stats) { st.Skipped++ })
+			return
+		}
+		s.mirror(c.Request.Context(), &Request{
+			Method:   c.Request.Method,
+			URL:      c.Request.URL.RequestURI(),
+			Route:    route,
+			Header:   c.Request.Header.Clone(),
+			Body:     body,
+			Status:   w.Status(),
+			Response: w.body.Bytes(),
+			Latency:  time.Since(start),
+		})
+	}
+}
+
+// sample decides whether a request is mirrored
+func (s *Shadow) sample(r *http.Request) bool {
+	if !s.methods[r.Method] || r.Header.Get(HeaderShadow) != "" {
+		return false
+	}
+	for _, p := range s.opts.SkipPaths {
+		if strings.HasPrefix(r.URL.Path, p) {
+			return false
+		}
+	}
+	p := s.Percent()
+	return p >= 100 || (p > 0 && rand.Float64()*100 < p)
+}
+
+// mirror replays a request in the background unless too many are in flight
+func (s *Shadow) mirror(ctx context.Context, req *Request) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		s.mu.Unlock()
+		s.count(req.Route, func(st *Stats) { st.Dropped++ })
+		return
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	// Keep the values of the request context, such as the trace ID, but not its
+	// cancellation
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			<-s.sem
+			s.wg.Done()
+		}()
+		_, _ = s.Replay(ctx, req)
+	}()
+}
+
+// Replay sends a scrubbed copy of a request to the target and compares the
+// response with the primary one. It returns the diff, nil when the responses
+// match, and counts the outcome in the report.
+func (s *Shadow) Replay(ctx context.Context, req *Request) (*Diff, error) {
+	if req.Route == "" {
+		u, _ := url.Parse(req.URL)
+		if u != nil {
+			req.Route = req.Method + " " + u.Path
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+	hreq, err := s.newRequest(ctx, req)
+	if errors.Is(err, ErrNotScrubbable) {
+		s.count(req.Route, func(st *Stats) { st.Skipped++ })
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{Time: time.Now(), Route: req.Route, Path: hreq.URL.Path, Status: req.Status, Latency: req.Latency}
+	res, err := s.client.Do(hreq)
+	if err != nil {
+		d.Error = err.Error()
+		s.record(ctx, d, err)
+		return d, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, s.opts.MaxBodySize+1))
+	d.ShadowStatus = res.StatusCode
+	d.ShadowLatency = time.Since(d.Time)
+	if err != nil {
+		d.Error = err.Error()
+		s.record(ctx, d, err)
+		return d, err
+	}
+
+	if int64(len(body)) > s.opts.MaxBodySize {
+		d.Fields = []string{"body"}
+	} else {
+		d.Fields = compare(req.Response, body, s.ignore)
+	}
+	if d.ShadowStatus == d.Status && len(d.Fields) == 0 {
+		s.count(req.Route, func(st *Stats) { st.Mirrored++; st.Matched++ })
+		return nil, nil
+	}
+	s.record(ctx, d, nil)
+	return d, nil
+}
+
+// newRequest builds the scrubbed request sent to the target
+func (s *Shadow) newRequest(ctx context.Context, req *Request) (*http.Request, error) {
+	u, err := url.ParseRequestURI(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("shadow: invalid request URL: %w", err)
+	}
+	target := *s.target
+	target.Path += u.Path
+	target.RawPath = ""
+	target.RawQuery = s.scrubValues(u.Query()).Encode()
+
+	header := make(http.Header, len(req.Header)+len(s.opts.Header)+1)
+	for k, vs := range req.Header {
+		if s.opts.Redactor.IsSensitive(k) {
+			continue
+		}
+		for _, v := range vs {
+			header.Add(k, s.opts.Redactor.String(v))
+		}
+	}
+	for _, k := range hopHeaders {
+		header.Del(k)
+	}
+
+	var body io.Reader
+	if len(req.Body) > 0 {
+		scrubbed, err := s.scrubBody(header.Get("Content-Type"), req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(scrubbed)
+	}
+	for k, vs := range s.opts.Header {
+		header[http.CanonicalHeaderKey(k)] = vs
+	}
+	header.Set(HeaderShadow, "1")
+
+	hreq, err := http.NewRequestWithContext(ctx, req.Method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+	hreq.Header = header
+	return hreq, nil
+}
+
+// scrubValues masks sensitive query or form values
+func (s *Shadow) scrubValues(values url.Values) url.Values {
+	r := s.opts.Redactor
+	for k, vs := range values {
+		for i, v := range vs {
+			if r.IsSensitive(k) {
+				vs[i] = r.Mask(v)
+			} else {
+				vs[i] = r.String(v)
+			}
+		}
+	}
+	return values
+}
+
+// scrubbable reports whether bodies of a content type can be scrubbed
+func scrubbable(contentType string) bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded" ||
+		strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "xml")
+}
+
+// scrubBody masks sensitive fields and patterns in a request body
+func (s *Shadow) scrubBody(contentType string, body []byte) ([]byte, error) {
+	if !scrubbable(contentType) {
+		return nil, ErrNotScrubbable
+	}
+	r := s.opts.Redactor
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch mediaType = strings.TrimSpace(mediaType); {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, ErrNotScrubbable
+		}
+		return []byte(s.scrubValues(values).Encode()), nil
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		v, err := decode(body)
+		if err != nil {
+			return []byte(r.String(string(body))), nil
+		}
+		return json.Marshal(r.Value(v))
+	default:
+		return []byte(r.String(string(body))), nil
+	}
+}
+
+// count updates the counters of a route
+func (s *Shadow) count(route string, fn func(st *Stats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.routes[route]
+	if !ok {
+		st = &Stats{}
+		s.routes[route] = st
+	}
+	fn(st)
+}
+
+// record counts a diff, keeps it for the report and reports it
+func (s *Shadow) record(ctx context.Context, d *Diff, err error) {
+	s.count(d.Route, func(st *Stats) {
+		st.Mirrored++
+		if err != nil {
+			st.Failed++
+		} else {
+			st.Differed++
+		}
+	})
+
+	s.mu.Lock()
+	if len(s.diffs) < s.opts.MaxDiffs {
+		s.diffs = append(s.diffs, d)
+	} else {
+		s.diffs[s.next] = d
+	}
+	s.next = (s.next + 1) % s.opts.MaxDiffs
+	s.mu.Unlock()
+
+	if s.opts.Logger != nil {
+		if err != nil {
+			s.opts.Logger.Warnf(ctx, "Shadow request %s %s failed: %v", d.Route, d.Path, err)
+		} else {
+			s.opts.Logger.Warnf(ctx, "Shadow response of %s %s differs: status %d, shadow status %d, fields %v",
+				d.Route, d.Path, d.Status, d.ShadowStatus, d.Fields)
+		}
+	}
+	if s.opts.OnDiff != nil {
+		s.opts.OnDiff(d)
+	}
+}
+
+// RouteReport is the counters of a route
+type RouteReport struct {
+	Route string `json:"route"`
+	Stats
+}
+
+// Report is the state of a Shadow
+type Report struct {
+	Target  string         `json:"target"`
+	Percent float64        `json:"percent"`
+	Stats   Stats          `json:"stats"`
+	Routes  []*RouteReport `json:"routes"` // Most mirrored first
+	Diffs   []*Diff        `json:"diffs"`  // Newest first
+}
+
+// Report returns the counters and the most recent diffs
+func (s *Shadow) Report() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &Report{
+		Target:  s.target.Redacted(),
+		Percent: s.Percent(),
+		Routes:  make([]*RouteReport, 0, len(s.routes)),
+		Diffs:   make([]*Diff, 0, len(s.diffs)),
+	}
+	for route, st := range s.routes {
+		report.Stats.add(st)
+		report.Routes = append(report.Routes, &RouteReport{Route: route, Stats: *st})
+	}
+	sort.Slice(report.Routes, func(i, j int) bool {
+		a, b := report.Routes[i], report.Routes[j]
+		if a.Mirrored != b.Mirrored {
+			return a.Mirrored > b.Mirrored
+		}
+		return a.Route < b.Route
+	})
+	for i := range s.diffs {
+		// Walk the ring backwards from the newest diff
+		idx := (s.next - 1 - i + 2*len(s.diffs)) % len(s.diffs)
+		report.Diffs = append(report.Diffs, s.diffs[idx])
+	}
+	return report
+}
+
+// Handler serves the report
+func (s *Shadow) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp.Success(c.Writer, s.Report())
+	}
+}
+
+// routeOf returns the method and route template of a request
+func routeOf(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched route"
+	}
+	return c.Request.Method + " " + route
+}
+
+// readBody reads a request body up to limit, restoring it for handlers. It
+// returns false when the body is larger.
+func readBody(r *http.Request, limit int64) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	rest := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), rest), rest}
+	return body, err == nil && int64(len(body)) <= limit
+}
+
+// recorder captures the response body while writing it, up to max bytes
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	max  int64
+	over bool
+}
+
+// Write writes and captures data
+func (w *recorder) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes and captures s
+func (w *recorder) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recorder) capture(data []byte) {
+	if w.over {
+		return
+	}
+	if int64(w.body.Len()+len(data)) > w.max {
+		w.over = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
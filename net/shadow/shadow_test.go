@@ -0,0 +1,133 @@
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got *http.Request
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"b2","name":"Ada","roles":["admin"]}`)
+	}))
+	defer target.Close()
+
+	sh := New(&Options{
+		Target:       target.URL,
+		Percent:      100,
+		Header:       http.Header{"Authorization": {"Bearer canary"}},
+		IgnoreFields: []string{"id"},
+	})
+	r := gin.New()
+	r.Use(sh.Middleware())
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": "a1", "name": "Ada", "roles": []string{"user"}})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?token=s3cr3t&fields=name", nil)
+	req.Header.Set("Authorization", "Bearer live")
+	req.Header.Set("Cookie", "session=abc")
+	req.Header.Set("Accept", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	sh.Close()
+
+	if got == nil {
+		t.Fatal("request was not mirrored")
+	}
+	if got.URL.Path != "/users/1" || got.URL.Query().Get("fields") != "name" || got.URL.Query().Get("token") == "s3cr3t" {
+		t.Errorf("mirrored URL = %s, want the token masked", got.URL)
+	}
+	if got.Header.Get("Authorization") != "Bearer canary" || got.Header.Get("Cookie") != "" ||
+		got.Header.Get("Accept") != "application/json" || got.Header.Get(HeaderShadow) != "1" {
+		t.Errorf("mirrored headers = %v", got.Header)
+	}
+
+	report := sh.Report()
+	if report.Stats.Mirrored != 1 || report.Stats.Differed != 1 || len(report.Diffs) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if d := report.Diffs[0]; d.Route != "GET /users/:id" || !reflect.DeepEqual(d.Fields, []string{"roles[0]"}) {
+		t.Errorf("diff = %+v, want roles[0] to differ", d)
+	}
+
+	// Mirrored requests are not mirrored again
+	again := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	again.Header.Set(HeaderShadow, "1")
+	if sh.sample(again) {
+		t.Error("mirrored request sampled")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var body map[string]any
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer target.Close()
+
+	sh := New(&Options{Target: target.URL, Methods: []string{http.MethodPost}})
+	d, err := sh.Replay(context.Background(), &Request{
+		Method: http.MethodPost,
+		URL:    "/users",
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   []byte(`{"name":"Ada","password":"hunter2","card":"4111 1111 1111 1111"}`),
+		Status: http.StatusCreated,
+	})
+	if err != nil || d != nil {
+		t.Fatalf("Replay() = %+v, %v, want a match", d, err)
+	}
+	if body["name"] != "Ada" || body["password"] == "hunter2" || strings.Contains(body["card"].(string), "4111 1111") {
+		t.Errorf("mirrored body = %v, want password and card masked", body)
+	}
+
+	_, err = sh.Replay(context.Background(), &Request{
+		Method: http.MethodPost,
+		URL:    "/upload",
+		Header: http.Header{"Content-Type": {"application/octet-stream"}},
+		Body:   []byte{0, 1, 2},
+	})
+	if err != ErrNotScrubbable {
+		t.Errorf("binary body error = %v", err)
+	}
+	if st := sh.Report().Stats; st.Matched != 1 || st.Skipped != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	ignore := map[string]bool{"updated_at": true, "data.etag": true}
+	tests := []struct {
+		name            string
+		primary, shadow string
+		want            []string
+	}{
+		{"equal", `{"a":1}`, `{"a":1}`, nil},
+		{"key order", `{"a":1,"b":2}`, `{"b":2,"a":1}`, nil},
+		{"number", `{"a":1}`, `{"a":1.0}`, []string{"a"}},
+		{"missing key", `{"a":1}`, `{"a":1,"b":null,"c":true}`, []string{"c"}},
+		{"nested", `{"data":{"items":[{"n":"x"},{"n":"y"}]}}`, `{"data":{"items":[{"n":"x"},{"n":"z"}]}}`, []string{"data.items[1].n"}},
+		{"length", `[1,2]`, `[1]`, []string{"body"}},
+		{"ignored", `{"updated_at":1,"data":{"etag":"a","v":1}}`, `{"updated_at":2,"data":{"etag":"b","v":1}}`, nil},
+		{"type", `{"a":{"b":1}}`, `{"a":"b"}`, []string{"a"}},
+		{"not json", `ok`, `OK`, []string{"body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compare([]byte(tt.primary), []byte(tt.shadow), ignore); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
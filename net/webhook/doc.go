@@ -0,0 +1,43 @@
+// Package webhook receives webhooks: it verifies sender signatures, rejects
+// stale and replayed deliveries, decodes payloads into typed events and
+// dispatches them onto the extension event bus.
+//
+// # Providers
+//
+//	stripe := webhook.NewStripe(os.Getenv("STRIPE_WEBHOOK_SECRET"))
+//	github := webhook.NewGitHub(os.Getenv("GITHUB_WEBHOOK_SECRET"))
+//	wechat, err := webhook.NewWeChatPay(apiV3Key, map[string]string{serial: platformCertPEM})
+//
+// Stripe signs a timestamp with the body, WeChat Pay signs a timestamp and
+// nonce and encrypts the resource, which Event.Payload holds decrypted.
+// GitHub signs the body only. Custom senders implement Provider.
+//
+// # Receiving
+//
+//	rcv := webhook.NewReceiver(webhook.Options{
+//	    Publisher: em.EventBus(),                               // any Publish(name, data)
+//	    Replay:    webhook.NewRedisReplayStore(redisClient, "webhook"),
+//	}, stripe, github, wechat)
+//
+//	// Every provider at POST /webhooks/{provider}
+//	r.Any("/webhooks/*provider", gin.WrapH(http.StripPrefix("/webhooks", rcv.Handler())))
+//
+//	// Or a route of its own, handled before the event is published
+//	r.POST("/billing/stripe", rcv.Middleware("stripe"), func(c *gin.Context) {
+//	    event, _ := webhook.EventFromContext(c)
+//	    ...
+//	})
+//
+// Verified events are published as *Event named webhook.<provider>.<type>,
+// e.g. webhook.stripe.invoice.paid. Signed timestamps older than Tolerance are
+// rejected, and an event ID seen within ReplayTTL is acknowledged without
+// being published again, so sender retries are harmless.
+//
+// # Typed events
+//
+//	webhook.RegisterEvent[stripeInvoiceEvent](rcv, "stripe", "invoice.paid")
+//	webhook.RegisterEvent[github.PushEvent](rcv, "github", "push")
+//
+// Event.Data then holds a *stripeInvoiceEvent, and payloads that do not decode
+// are rejected with 400 so the sender retries after a fix.
+package webhook
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayStore remembers received event IDs
+type ReplayStore interface {
+	// Seen records key for ttl, reporting whether it was already recorded
+	Seen(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Forget removes key
+	Forget(ctx context.Context, key string) error
+}
+
+// MemoryReplayStore is an in-memory ReplayStore for single instances
+type MemoryReplayStore struct {
+	mu    sync.Mutex
+	keys  map[string]time.Time // key to expiry
+	sweep time.Time
+}
+
+// NewMemoryReplayStore creates a MemoryReplayStore
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{keys: make(map[string]time.Time)}
+}
+
+// Seen implements ReplayStore
+func (s *MemoryReplayStore) Seen(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweep) >= time.Minute {
+		s.sweep = now
+		for k, expiry := range s.keys {
+			if now.After(expiry) {
+				delete(s.keys, k)
+			}
+		}
+	}
+
+	if expiry, ok := s.keys[key]; ok && now.Before(expiry) {
+		return true, nil
+	}
+	s.keys[key] = now.Add(ttl)
+	return false, nil
+}
+
+// Forget implements ReplayStore
+func (s *MemoryReplayStore) Forget(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
+
+// RedisReplayStore is a ReplayStore shared across instances, keys expire with Redis TTLs
+type RedisReplayStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisReplayStore creates a RedisReplayStore
+func NewRedisReplayStore(client redis.UniversalClient, prefix string) *RedisReplayStore {
+	if prefix == "" {
+		prefix = "webhook"
+	}
+	return &RedisReplayStore{client: client, prefix: prefix}
+}
+
+// Seen implements ReplayStore
+func (s *RedisReplayStore) Seen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, s.prefix+":"+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook event: %v", err)
+	}
+	return !ok, nil
+}
+
+// Forget implements ReplayStore
+func (s *RedisReplayStore) Forget(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+":"+key).Err()
+}
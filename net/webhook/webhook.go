@@ -0,0 +1,283 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ncobase/ncore/ecode"
+	"github.com/ncobase/ncore/net/resp"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("webhook: unknown provider")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrExpired          = errors.New("webhook: timestamp outside tolerance")
+	ErrDuplicate        = errors.New("webhook: event already received")
+	ErrTooLarge         = errors.New("webhook: payload too large")
+	ErrMalformed        = errors.New("webhook: malformed payload")
+)
+
+// Event is a verified webhook delivery
+type Event struct {
+	Provider  string          `json:"provider"`
+	ID        string          `json:"id,omitempty"` // Delivery or event ID, used for replay protection
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`         // Signed send time, zero if the provider signs none
+	Payload   json.RawMessage `json:"payload"`           // Event body, decrypted for providers that encrypt it
+	Data      any             `json:"data,omitempty"`    // Payload decoded into the type registered with RegisterEvent
+	Header    http.Header     `json:"-"`                 // Request headers
+	Received  time.Time       `json:"received,omitzero"` // Time of receipt
+}
+
+// Provider verifies the requests of a webhook sender
+type Provider interface {
+	Name() string
+	// Verify authenticates a request and parses its event envelope. It returns
+	// an error wrapping ErrInvalidSignature for unauthenticated requests and
+	// ErrMalformed for payloads it cannot parse.
+	Verify(r *http.Request, body []byte) (*Event, error)
+}
+
+// Responder is implemented by providers expecting a specific response body
+type Responder interface {
+	// Respond writes the response, err is nil on success
+	Respond(w http.ResponseWriter, err error)
+}
+
+// Publisher receives verified events, the extension event bus implements it
+type Publisher interface {
+	Publish(eventName string, data any)
+}
+
+// PublisherFunc adapts a function to Publisher, e.g. Manager.PublishEvent
+type PublisherFunc func(eventName string, data any)
+
+// Publish implements Publisher
+func (f PublisherFunc) Publish(eventName string, data any) { f(eventName, data) }
+
+// Options configures a Receiver
+type Options struct {
+	Publisher   Publisher     // Receives every new event as *Event, optional
+	Replay      ReplayStore   // Remembers event IDs, defaults to a MemoryReplayStore
+	ReplayTTL   time.Duration // How long IDs are remembered, defaults to 72h to outlast sender retries
+	Tolerance   time.Duration // Largest age of signed timestamps, defaults to 5m
+	MaxBodySize int64         // Defaults to 1MB
+	// EventName names published events, defaults to webhook.<provider>.<type>
+	EventName func(e *Event) string
+}
+
+// Receiver verifies webhook deliveries and dispatches them as events
+type Receiver struct {
+	opts Options
+
+	mu        sync.RWMutex
+	providers map[string]Provider
+	decoders  map[string]func(payload []byte) (any, error)
+}
+
+// NewReceiver creates a Receiver
+func NewReceiver(opts Options, providers ...Provider) *Receiver {
+	if opts.Replay == nil {
+		opts.Replay = NewMemoryReplayStore()
+	}
+	if opts.ReplayTTL <= 0 {
+		opts.ReplayTTL = 72 * time.Hour
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	if opts.EventName == nil {
+		opts.EventName = func(e *Event) string { return "webhook." + e.Provider + "." + e.Type }
+	}
+	r := &Receiver{
+		opts:      opts,
+		providers: make(map[string]Provider),
+		decoders:  make(map[string]func([]byte) (any, error)),
+	}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds a provider
+func (r *Receiver) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.Name()] = p
+}
+
+// RegisterEvent decodes the payload of provider events of eventType into a
+// *T, set as Event.Data. Events of other types keep a nil Data.
+func RegisterEvent[T any](r *Receiver, provider, eventType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[provider+"\x00"+eventType] = func(payload []byte) (any, error) {
+		v := new(T)
+		if err := json.Unmarshal(payload, v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// Receive verifies a delivery for provider, rejects replays and decodes the
+// payload. It does not publish the event.
+func (r *Receiver) Receive(req *http.Request, provider string) (*Event, error) {
+	r.mu.RLock()
+	p, ok := r.providers[provider]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, r.opts.MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if int64(len(body)) > r.opts.MaxBodySize {
+		return nil, ErrTooLarge
+	}
+
+	event, err := p.Verify(req, body)
+	if err != nil {
+		return nil, err
+	}
+	event.Provider = provider
+	event.Header = req.Header
+	event.Received = time.Now()
+	if !event.Timestamp.IsZero() {
+		if age := event.Received.Sub(event.Timestamp); age > r.opts.Tolerance || age < -r.opts.Tolerance {
+			return nil, ErrExpired
+		}
+	}
+
+	r.mu.RLock()
+	decode := r.decoders[provider+"\x00"+event.Type]
+	r.mu.RUnlock()
+	if decode != nil {
+		if event.Data, err = decode(event.Payload); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, event.Type, err)
+		}
+	}
+
+	// Checked last, so a rejected delivery does not burn its ID
+	if event.ID != "" {
+		seen, err := r.opts.Replay.Seen(req.Context(), provider+":"+event.ID, r.opts.ReplayTTL)
+		if err != nil {
+			return nil, err
+		}
+		if seen {
+			return event, ErrDuplicate
+		}
+	}
+	return event, nil
+}
+
+// publish dispatches an event to the publisher
+func (r *Receiver) publish(e *Event) {
+	if r.opts.Publisher != nil {
+		r.opts.Publisher.Publish(r.opts.EventName(e), e)
+	}
+}
+
+// Forget removes the ID of an event, so the sender's retry is accepted
+func (r *Receiver) Forget(ctx context.Context, e *Event) error {
+	if e.ID == "" {
+		return nil
+	}
+	return r.opts.Replay.Forget(ctx, e.Provider+":"+e.ID)
+}
+
+// Handler returns the handler receiving deliveries at POST /{provider},
+// e.g. mounted under /webhooks/. New events are published and acknowledged,
+// duplicates are acknowledged without publishing so the sender stops retrying.
+func (r *Receiver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /{provider}", func(w http.ResponseWriter, req *http.Request) {
+		provider := req.PathValue("provider")
+		event, err := r.Receive(req, provider)
+		if err == nil {
+			r.publish(event)
+		}
+		r.respond(w, provider, err)
+	})
+	return mux
+}
+
+// eventKey is the gin context key of the verified event
+const eventKey = "webhook.event"
+
+// Middleware verifies deliveries for provider before the route handler, which
+// finds the event with EventFromContext. Duplicates are acknowledged without
+// calling the handler. Events the handler answers with a 2xx status are
+// published; otherwise their ID is forgotten so the sender's retry gets through.
+func (r *Receiver) Middleware(provider string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		event, err := r.Receive(c.Request, provider)
+		if err != nil {
+			r.respond(c.Writer, provider, err)
+			c.Abort()
+			return
+		}
+		c.Set(eventKey, event)
+		c.Next()
+
+		if status := c.Writer.Status(); status >= 200 && status < 300 {
+			r.publish(event)
+			return
+		}
+		_ = r.Forget(context.WithoutCancel(c.Request.Context()), event)
+	}
+}
+
+// EventFromContext returns the event verified by Middleware
+func EventFromContext(c *gin.Context) (*Event, bool) {
+	v, ok := c.Get(eventKey)
+	if !ok {
+		return nil, false
+	}
+	e, ok := v.(*Event)
+	return e, ok
+}
+
+// respond writes the outcome of a delivery
+func (r *Receiver) respond(w http.ResponseWriter, provider string, err error) {
+	if errors.Is(err, ErrDuplicate) {
+		err = nil
+	}
+
+	r.mu.RLock()
+	p := r.providers[provider]
+	r.mu.RUnlock()
+	if responder, ok := p.(Responder); ok {
+		responder.Respond(w, err)
+		return
+	}
+
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, ErrUnknownProvider):
+		resp.Fail(w, resp.NotFound(err.Error()))
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrExpired):
+		resp.Fail(w, &resp.Exception{Status: http.StatusUnauthorized, Code: ecode.SignCheckErr, Message: err.Error()})
+	case errors.Is(err, ErrTooLarge):
+		resp.Fail(w, &resp.Exception{Status: http.StatusRequestEntityTooLarge, Code: ecode.RequestErr, Message: err.Error()})
+	case errors.Is(err, ErrMalformed):
+		resp.Fail(w, resp.BadRequest(err.Error()))
+	default:
+		// Replay store failures, the sender retries later
+		resp.Fail(w, resp.ServiceUnavailable("webhook receiver unavailable"))
+	}
+}
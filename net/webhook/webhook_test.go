@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	names  []string
+	events []*Event
+}
+
+func (p *recordingPublisher) Publish(name string, data any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.names = append(p.names, name)
+	p.events = append(p.events, data.(*Event))
+}
+
+func post(h http.Handler, path string, body []byte, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func stripeSignature(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + string(body)))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestStripe(t *testing.T) {
+	pub := &recordingPublisher{}
+	rcv := NewReceiver(Options{Publisher: pub}, NewStripe("whsec_old", "whsec_new"))
+	h := rcv.Handler()
+
+	type invoicePaid struct {
+		Data struct {
+			Object struct {
+				AmountPaid int `json:"amount_paid"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	RegisterEvent[invoicePaid](rcv, "stripe", "invoice.paid")
+
+	body := []byte(`{"id":"evt_1","type":"invoice.paid","data":{"object":{"amount_paid":1200}}}`)
+	sig := stripeSignature("whsec_new", time.Now(), body)
+
+	if rec := post(h, "/stripe", body, map[string]string{"Stripe-Signature": sig}); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if len(pub.events) != 1 || pub.names[0] != "webhook.stripe.invoice.paid" {
+		t.Fatalf("published %v", pub.names)
+	}
+	data, ok := pub.events[0].Data.(*invoicePaid)
+	if !ok || data.Data.Object.AmountPaid != 1200 {
+		t.Errorf("data = %#v", pub.events[0].Data)
+	}
+
+	// A retry of the same event is acknowledged without publishing
+	if rec := post(h, "/stripe", body, map[string]string{"Stripe-Signature": sig}); rec.Code != http.StatusNoContent {
+		t.Errorf("duplicate status = %d", rec.Code)
+	}
+	if len(pub.events) != 1 {
+		t.Errorf("duplicate was published")
+	}
+
+	tests := []struct {
+		name   string
+		body   []byte
+		header string
+		want   int
+	}{
+		{"wrong secret", body, stripeSignature("whsec_other", time.Now(), body), http.StatusUnauthorized},
+		{"tampered", []byte(`{"id":"evt_2","type":"invoice.paid"}`), sig, http.StatusUnauthorized},
+		{"stale", body, stripeSignature("whsec_new", time.Now().Add(-time.Hour), body), http.StatusUnauthorized},
+		{"missing", body, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := post(h, "/stripe", tt.body, map[string]string{"Stripe-Signature": tt.header}); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// A delivery rejected for its payload does not burn the ID
+	bad := []byte(`{"id":"evt_3","type":"invoice.paid","data":{"object":{"amount_paid":"x"}}}`)
+	if rec := post(h, "/stripe", bad, map[string]string{"Stripe-Signature": stripeSignature("whsec_old", time.Now(), bad)}); rec.Code != http.StatusBadRequest {
+		t.Errorf("undecodable payload status = %d", rec.Code)
+	}
+	if seen, _ := rcv.opts.Replay.Seen(t.Context(), "stripe:evt_3", time.Minute); seen {
+		t.Error("rejected delivery was recorded as seen")
+	}
+
+	if rec := post(h, "/paypal", body, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown provider status = %d", rec.Code)
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	pub := &recordingPublisher{}
+	h := NewReceiver(Options{Publisher: pub}, NewGitHub("s3cret")).Handler()
+
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	header := map[string]string{
+		"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		"X-GitHub-Event":      "push",
+		"X-GitHub-Delivery":   "d-1",
+	}
+
+	if rec := post(h, "/github", body, header); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if len(pub.events) != 1 || pub.names[0] != "webhook.github.push" || pub.events[0].ID != "d-1" {
+		t.Fatalf("published %v", pub.names)
+	}
+
+	header["X-Hub-Signature-256"] = "sha256=00"
+	if rec := post(h, "/github", body, header); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature status = %d", rec.Code)
+	}
+}
+
+func TestWeChatPay(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiV3Key := "0123456789abcdef0123456789abcdef"
+	p := &WeChatPay{APIv3Key: apiV3Key, PlatformKeys: map[string]*rsa.PublicKey{"SERIAL1": &key.PublicKey}}
+	pub := &recordingPublisher{}
+	h := NewReceiver(Options{Publisher: pub}, p).Handler()
+
+	// Encrypt the resource as WeChat Pay does
+	resource := `{"out_trade_no":"order-1","trade_state":"SUCCESS"}`
+	block, _ := aes.NewCipher([]byte(apiV3Key))
+	gcm, _ := cipher.NewGCMWithNonceSize(block, 12)
+	nonce, ad := "abcdefghijkl", "transaction"
+	ciphertext := gcm.Seal(nil, []byte(nonce), []byte(resource), []byte(ad))
+	body, _ := json.Marshal(map[string]any{
+		"id":         "EV-1",
+		"event_type": "TRANSACTION.SUCCESS",
+		"resource": map[string]string{
+			"algorithm":       "AEAD_AES_256_GCM",
+			"ciphertext":      base64.StdEncoding.EncodeToString(ciphertext),
+			"associated_data": ad,
+			"nonce":           nonce,
+		},
+	})
+
+	sign := func(body []byte) map[string]string {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		digest := sha256.Sum256(fmt.Appendf(nil, "%s\n%s\n%s\n", ts, "n0nce", body))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]string{
+			"Wechatpay-Timestamp": ts,
+			"Wechatpay-Nonce":     "n0nce",
+			"Wechatpay-Serial":    "SERIAL1",
+			"Wechatpay-Signature": base64.StdEncoding.EncodeToString(sig),
+		}
+	}
+
+	if rec := post(h, "/wechatpay", body, sign(body)); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if len(pub.events) != 1 || string(pub.events[0].Payload) != resource {
+		t.Fatalf("payload = %s", pub.events[0].Payload)
+	}
+
+	header := sign(body)
+	header["Wechatpay-Serial"] = "UNKNOWN"
+	rec := post(h, "/wechatpay", body, header)
+	var failure map[string]string
+	_ = json.Unmarshal(rec.Body.Bytes(), &failure)
+	if rec.Code != http.StatusUnauthorized || failure["code"] != "FAIL" {
+		t.Errorf("unknown serial response = %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WeChatPay verifies WeChat Pay API v3 notifications. Requests are signed with
+// the platform key named by the Wechatpay-Serial header, and the resource is
+// encrypted with the merchant's APIv3 key; Event.Payload is the decrypted
+// resource, e.g. the transaction of a TRANSACTION.SUCCESS event.
+type WeChatPay struct {
+	APIv3Key     string                    // 32 byte APIv3 key
+	PlatformKeys map[string]*rsa.PublicKey // Platform certificate public keys by serial number
+}
+
+// NewWeChatPay creates a WeChatPay provider from PEM encoded platform
+// certificates or public keys, keyed by serial number
+func NewWeChatPay(apiV3Key string, platformKeys map[string]string) (*WeChatPay, error) {
+	if len(apiV3Key) != 32 {
+		return nil, errors.New("webhook: WeChat Pay APIv3 key must be 32 bytes")
+	}
+	p := &WeChatPay{APIv3Key: apiV3Key, PlatformKeys: make(map[string]*rsa.PublicKey, len(platformKeys))}
+	for serial, data := range platformKeys {
+		key, err := parseRSAPublicKey([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("webhook: WeChat Pay platform key %s: %w", serial, err)
+		}
+		p.PlatformKeys[serial] = key
+	}
+	return p, nil
+}
+
+// Name implements Provider
+func (p *WeChatPay) Name() string { return "wechatpay" }
+
+// wechatNotification is the envelope of a WeChat Pay notification
+type wechatNotification struct {
+	ID        string `json:"id"`
+	EventType string `json:"event_type"`
+	Resource  struct {
+		Algorithm      string `json:"algorithm"`
+		Ciphertext     string `json:"ciphertext"`
+		AssociatedData string `json:"associated_data"`
+		Nonce          string `json:"nonce"`
+	} `json:"resource"`
+}
+
+// Verify implements Provider. The signed message is
+// "timestamp\nnonce\nbody\n", signed with RSA-SHA256.
+func (p *WeChatPay) Verify(r *http.Request, body []byte) (*Event, error) {
+	timestamp := r.Header.Get("Wechatpay-Timestamp")
+	nonce := r.Header.Get("Wechatpay-Nonce")
+	key, ok := p.PlatformKeys[r.Header.Get("Wechatpay-Serial")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown Wechatpay-Serial", ErrInvalidSignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("Wechatpay-Signature"))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	digest := sha256.Sum256([]byte(timestamp + "\n" + nonce + "\n" + string(body) + "\n"))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Wechatpay-Timestamp", ErrInvalidSignature)
+	}
+
+	var n wechatNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	payload, err := p.decrypt(&n)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return &Event{
+		ID:        n.ID,
+		Type:      n.EventType,
+		Timestamp: time.Unix(unix, 0),
+		Payload:   payload,
+	}, nil
+}
+
+// decrypt opens the AEAD_AES_256_GCM resource
+func (p *WeChatPay) decrypt(n *wechatNotification) ([]byte, error) {
+	if n.Resource.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, fmt.Errorf("unsupported algorithm %q", n.Resource.Algorithm)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(n.Resource.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher([]byte(p.APIv3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(n.Resource.Nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, []byte(n.Resource.Nonce), ciphertext, []byte(n.Resource.AssociatedData))
+}
+
+// Respond implements Responder. WeChat Pay expects an empty 2xx on success
+// and a JSON code and message otherwise.
+func (p *WeChatPay) Respond(w http.ResponseWriter, err error) {
+	if err == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	status := http.StatusBadRequest
+	if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrExpired) {
+		status = http.StatusUnauthorized
+	} else if !errors.Is(err, ErrMalformed) && !errors.Is(err, ErrTooLarge) {
+		status = http.StatusInternalServerError
+	}
+	body, _ := json.Marshal(map[string]string{"code": "FAIL", "message": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// parseRSAPublicKey parses a PEM certificate, PKIX or PKCS#1 RSA public key
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	var pub any
+	var err error
+	switch block.Type {
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pub = cert.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an RSA key: %T", pub)
+	}
+	return key, nil
+}
@@ -3,9 +3,17 @@ module github.com/ncobase/ncore/security
 go 1.25.3
 
 require (
+	github.com/crewjam/saml v0.4.14
+	github.com/go-ldap/ldap/v3 v3.4.11
 	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/google/wire v0.7.0
+	github.com/ncobase/ncore/config v0.2.2
+	github.com/ncobase/ncore/ctxutil v0.2.2
+	github.com/ncobase/ncore/data v0.2.2
+	github.com/ncobase/ncore/data/kv v0.2.2
 	github.com/ncobase/ncore/logging v0.2.2
+	github.com/redis/go-redis/v9 v9.17.3
+	github.com/russellhaering/goxmldsig v1.4.0
 	github.com/spf13/viper v1.21.0
 	golang.org/x/crypto v0.48.0
 )
@@ -54,6 +62,7 @@ require (
 	github.com/clbanning/mxj v1.8.4 // indirect
 	github.com/cloudwego/base64x v0.1.6 // indirect
 	github.com/cncf/xds/go v0.0.0-20260202195803-dba9d589def2 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/envoyproxy/go-control-plane/envoy v1.36.0 // indirect
 	github.com/envoyproxy/protoc-gen-validate v1.3.0 // indirect
@@ -95,10 +104,7 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/mozillazg/go-httpheader v0.4.0 // indirect
-	github.com/ncobase/ncore/config v0.2.2 // indirect
 	github.com/ncobase/ncore/consts v0.2.2 // indirect
-	github.com/ncobase/ncore/ctxutil v0.2.2 // indirect
-	github.com/ncobase/ncore/data v0.2.2 // indirect
 	github.com/ncobase/ncore/extension v0.2.2 // indirect
 	github.com/ncobase/ncore/messaging v0.2.2 // indirect
 	github.com/ncobase/ncore/oss v0.2.3 // indirect
@@ -123,6 +129,7 @@ require (
 	github.com/tinylib/msgp v1.6.3 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.3.1 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
 	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
 	go.opentelemetry.io/contrib/detectors/gcp v1.40.0 // indirect
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.65.0 // indirect
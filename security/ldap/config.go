@@ -0,0 +1,152 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config holds the LDAP / Active Directory configuration
+type Config struct {
+	URL      string `json:"url" yaml:"url"`             // ldap://host:389 or ldaps://host:636
+	StartTLS bool   `json:"start_tls" yaml:"start_tls"` // Upgrade ldap:// connections with StartTLS
+	TLS      TLS    `json:"tls" yaml:"tls"`
+
+	// BindDN and BindPassword are the service account used to look up users
+	// and groups
+	BindDN       string `json:"bind_dn" yaml:"bind_dn"`
+	BindPassword string `json:"bind_password" yaml:"bind_password"`
+
+	BaseDN string `json:"base_dn" yaml:"base_dn"`
+	// UserFilter finds a user by login name, {username} is replaced with the
+	// escaped name. Defaults to (uid={username}), or (sAMAccountName={username})
+	// for Active Directory.
+	UserFilter string `json:"user_filter" yaml:"user_filter"`
+	// GroupBaseDN defaults to BaseDN
+	GroupBaseDN string `json:"group_base_dn" yaml:"group_base_dn"`
+	// GroupFilter finds the groups with a member, {dn} is replaced with the
+	// escaped member DN. Defaults to (|(member={dn})(uniqueMember={dn})).
+	GroupFilter string `json:"group_filter" yaml:"group_filter"`
+	// NestedGroups resolves groups of groups, up to MaxNestingDepth levels
+	NestedGroups    bool `json:"nested_groups" yaml:"nested_groups"`
+	MaxNestingDepth int  `json:"max_nesting_depth" yaml:"max_nesting_depth"` // Defaults to 10
+	// ActiveDirectory switches the defaults to AD attributes and resolves
+	// nested groups in one query with LDAP_MATCHING_RULE_IN_CHAIN
+	ActiveDirectory bool `json:"active_directory" yaml:"active_directory"`
+
+	Attributes Attributes `json:"attributes" yaml:"attributes"`
+
+	// RoleMapping maps group DNs or CNs, compared case-insensitively, to roles
+	RoleMapping  map[string][]string `json:"role_mapping" yaml:"role_mapping"`
+	DefaultRoles []string            `json:"default_roles" yaml:"default_roles"` // Roles of every authenticated user
+
+	PoolSize    int           `json:"pool_size" yaml:"pool_size"`       // Most open connections, defaults to 10
+	DialTimeout time.Duration `json:"dial_timeout" yaml:"dial_timeout"` // Defaults to 5s
+	Timeout     time.Duration `json:"timeout" yaml:"timeout"`           // Per request, defaults to 10s
+	IdleTimeout time.Duration `json:"idle_timeout" yaml:"idle_timeout"` // Idle connections older than this are closed, defaults to 5m
+}
+
+// TLS configures server certificate verification
+type TLS struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	ServerName         string `json:"server_name" yaml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// Attributes names the user attributes read into an Identity
+type Attributes struct {
+	ID       string   `json:"id" yaml:"id"`             // Defaults to entryUUID, objectGUID for AD
+	Username string   `json:"username" yaml:"username"` // Defaults to uid, sAMAccountName for AD
+	Email    string   `json:"email" yaml:"email"`       // Defaults to mail
+	Name     string   `json:"name" yaml:"name"`         // Defaults to cn, displayName for AD
+	MemberOf string   `json:"member_of" yaml:"member_of"`
+	Extra    []string `json:"extra" yaml:"extra"` // Further attributes kept in Identity.Attributes
+}
+
+// withDefaults returns a copy of c with defaults applied
+func (c Config) withDefaults() Config {
+	if c.UserFilter == "" {
+		c.UserFilter = "(uid={username})"
+		if c.ActiveDirectory {
+			c.UserFilter = "(&(objectClass=user)(sAMAccountName={username}))"
+		}
+	}
+	if c.GroupBaseDN == "" {
+		c.GroupBaseDN = c.BaseDN
+	}
+	if c.GroupFilter == "" {
+		c.GroupFilter = "(|(member={dn})(uniqueMember={dn}))"
+		if c.ActiveDirectory {
+			c.GroupFilter = "(&(objectClass=group)(member={dn}))"
+		}
+	}
+	if c.MaxNestingDepth <= 0 {
+		c.MaxNestingDepth = 10
+	}
+
+	a := &c.Attributes
+	if c.ActiveDirectory {
+		a.ID = orDefault(a.ID, "objectGUID")
+		a.Username = orDefault(a.Username, "sAMAccountName")
+		a.Name = orDefault(a.Name, "displayName")
+	} else {
+		a.ID = orDefault(a.ID, "entryUUID")
+		a.Username = orDefault(a.Username, "uid")
+		a.Name = orDefault(a.Name, "cn")
+	}
+	a.Email = orDefault(a.Email, "mail")
+	a.MemberOf = orDefault(a.MemberOf, "memberOf")
+
+	if c.PoolSize <= 0 {
+		c.PoolSize = 10
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = 5 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = 5 * time.Minute
+	}
+	return c
+}
+
+// orDefault returns v, or def when v is empty
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// tlsConfig builds the TLS configuration for ldaps:// and StartTLS
+func (c *Config) tlsConfig(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         orDefault(c.TLS.ServerName, host),
+		InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.TLS.CAFile != "" {
+		pem, err := os.ReadFile(c.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: read CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ldap: no certificates in CA file")
+		}
+	}
+	return cfg, nil
+}
+
+// validate checks the required settings
+func (c *Config) validate() error {
+	if c.URL == "" || c.BaseDN == "" {
+		return errors.New("invalid LDAP configuration: url and base_dn are required")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package ldap authenticates users against an LDAP directory or Active
+// Directory, mapping their groups to roles for the access control engine and
+// the token payload.
+//
+// # Authentication
+//
+//	provider, err := ldap.New(&ldap.Config{
+//	    URL:          "ldap://dc1.corp.example.com:389",
+//	    StartTLS:     true,
+//	    BindDN:       "CN=svc-app,OU=Service,DC=corp,DC=example,DC=com",
+//	    BindPassword: os.Getenv("LDAP_BIND_PASSWORD"),
+//	    BaseDN:       "DC=corp,DC=example,DC=com",
+//	    ActiveDirectory: true,
+//	    NestedGroups:    true,
+//	    RoleMapping: map[string][]string{
+//	        "Domain Admins": {"admin"},
+//	        "CN=Engineering,OU=Groups,DC=corp,DC=example,DC=com": {"developer"},
+//	    },
+//	})
+//	defer provider.Close()
+//
+//	identity, err := provider.Authenticate(ctx, username, password)
+//	if errors.Is(err, ldap.ErrInvalidCredentials) {
+//	    // unknown user or wrong password
+//	}
+//
+// Users are found with the service account, then their password is checked
+// by binding as them; group lookups go back to the service account.
+// Connections are pooled, bound as the service account while idle.
+//
+// # Groups
+//
+// Direct groups come from memberOf, or from GroupFilter when the directory has
+// no memberOf overlay. With NestedGroups, parent groups are followed up to
+// MaxNestingDepth levels; Active Directory resolves the whole chain in one
+// query with LDAP_MATCHING_RULE_IN_CHAIN. RoleMapping matches groups by DN or
+// CN, and DefaultRoles apply to every user.
+//
+// # Sessions and tokens
+//
+//	// Casbin roles follow the directory at every login
+//	err = ldap.SyncRoles(enforcer, identity.ID, identity)
+//
+//	// Tokens carry user_id, username, email, name and roles
+//	access, refresh, err := ldap.IssueTokens(tokenManager, sessionID, identity)
+package ldap
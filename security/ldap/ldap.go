@@ -0,0 +1,275 @@
+package ldap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// ErrInvalidCredentials is returned for unknown users and wrong passwords
+	// alike, so logins cannot probe for accounts
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+	// ErrAmbiguousUser is returned when the user filter matches several entries
+	ErrAmbiguousUser = errors.New("ldap: user filter matched several entries")
+)
+
+// inChainRule is LDAP_MATCHING_RULE_IN_CHAIN, which makes Active Directory
+// follow group membership transitively
+const inChainRule = "1.2.840.113556.1.4.1941"
+
+// Identity is an authenticated directory user
+type Identity struct {
+	DN         string              `json:"dn"`
+	ID         string              `json:"id"`
+	Username   string              `json:"username"`
+	Email      string              `json:"email,omitempty"`
+	Name       string              `json:"name,omitempty"`
+	Groups     []string            `json:"groups,omitempty"` // Group DNs, nested ones included when enabled
+	Roles      []string            `json:"roles,omitempty"`  // Mapped from Groups with RoleMapping
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// Provider authenticates users against an LDAP directory or Active Directory
+type Provider struct {
+	cfg  Config
+	pool *pool
+}
+
+// New creates a Provider. Connections are opened on demand.
+func New(cfg *Config) (*Provider, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid LDAP configuration")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	p := &Provider{cfg: cfg.withDefaults()}
+	pool, err := newPool(&p.cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = pool
+	return p, nil
+}
+
+// Name returns the provider name, used as the login source
+func (p *Provider) Name() string { return "ldap" }
+
+// Close closes the idle connections
+func (p *Provider) Close() error {
+	p.pool.close()
+	return nil
+}
+
+// Stats reports the open and idle pooled connections
+func (p *Provider) Stats() map[string]any {
+	open, idle := p.pool.stats()
+	return map[string]any{"open": open, "idle": idle, "size": p.cfg.PoolSize}
+}
+
+// Authenticate verifies username and password with a bind as the user, then
+// resolves the user's groups and roles
+func (p *Provider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	// An empty password would be an unauthenticated bind, which succeeds
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	c, err := p.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	broken := false
+	defer func() { p.pool.put(c, broken) }()
+
+	entry, err := p.findUser(c, username)
+	if err != nil {
+		broken = !errors.Is(err, ErrInvalidCredentials) && !errors.Is(err, ErrAmbiguousUser)
+		return nil, err
+	}
+
+	if err := c.Bind(entry.DN, password); err != nil {
+		// Whatever happened, the connection is no longer bound as the service
+		if rebindErr := c.bindService(&p.cfg); rebindErr != nil {
+			broken = true
+		}
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ldap: bind: %w", err)
+	}
+	// Group lookups run as the service account, users may not read groups
+	if err := c.bindService(&p.cfg); err != nil {
+		broken = true
+		return nil, err
+	}
+
+	identity := p.identity(entry)
+	if identity.Groups, err = p.groups(c, entry); err != nil {
+		broken = true
+		return nil, err
+	}
+	identity.Roles = p.roles(identity.Groups)
+	return identity, nil
+}
+
+// Lookup returns a user's identity without checking a password, e.g. to
+// refresh the roles of a session
+func (p *Provider) Lookup(ctx context.Context, username string) (*Identity, error) {
+	c, err := p.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	broken := false
+	defer func() { p.pool.put(c, broken) }()
+
+	entry, err := p.findUser(c, username)
+	if err != nil {
+		broken = !errors.Is(err, ErrInvalidCredentials) && !errors.Is(err, ErrAmbiguousUser)
+		return nil, err
+	}
+	identity := p.identity(entry)
+	if identity.Groups, err = p.groups(c, entry); err != nil {
+		broken = true
+		return nil, err
+	}
+	identity.Roles = p.roles(identity.Groups)
+	return identity, nil
+}
+
+// findUser searches the entry of a login name
+func (p *Provider) findUser(c *conn, username string) (*ldap.Entry, error) {
+	a := p.cfg.Attributes
+	attrs := append([]string{a.ID, a.Username, a.Email, a.Name, a.MemberOf}, a.Extra...)
+	filter := strings.ReplaceAll(p.cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+
+	res, err := c.Search(ldap.NewSearchRequest(
+		p.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(p.cfg.Timeout.Seconds()), false, filter, attrs, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap: search user: %w", err)
+	}
+	switch {
+	case res == nil || len(res.Entries) == 0:
+		return nil, ErrInvalidCredentials
+	case len(res.Entries) > 1:
+		return nil, ErrAmbiguousUser
+	}
+	return res.Entries[0], nil
+}
+
+// identity reads the attributes of a user entry
+func (p *Provider) identity(entry *ldap.Entry) *Identity {
+	a := p.cfg.Attributes
+	id := &Identity{
+		DN:       entry.DN,
+		ID:       entry.GetAttributeValue(a.ID),
+		Username: entry.GetAttributeValue(a.Username),
+		Email:    entry.GetAttributeValue(a.Email),
+		Name:     entry.GetAttributeValue(a.Name),
+	}
+	if strings.EqualFold(a.ID, "objectGUID") {
+		id.ID = formatGUID(entry.GetRawAttributeValue(a.ID))
+	}
+	if id.ID == "" {
+		id.ID = entry.DN
+	}
+	if len(a.Extra) > 0 {
+		id.Attributes = make(map[string][]string, len(a.Extra))
+		for _, name := range a.Extra {
+			if values := entry.GetAttributeValues(name); len(values) > 0 {
+				id.Attributes[name] = values
+			}
+		}
+	}
+	return id
+}
+
+// groups resolves the group DNs of a user
+func (p *Provider) groups(c *conn, entry *ldap.Entry) ([]string, error) {
+	if p.cfg.ActiveDirectory && p.cfg.NestedGroups {
+		// One query walks the whole membership chain
+		filter := fmt.Sprintf("(&(objectClass=group)(member:%s:=%s))", inChainRule, ldap.EscapeFilter(entry.DN))
+		return p.searchGroups(c, filter)
+	}
+
+	direct := entry.GetAttributeValues(p.cfg.Attributes.MemberOf)
+	if len(direct) == 0 {
+		var err error
+		if direct, err = p.memberOf(c, entry.DN); err != nil {
+			return nil, err
+		}
+	}
+	if !p.cfg.NestedGroups {
+		return sortedUnique(direct), nil
+	}
+
+	// Breadth first through parent groups, the visited set breaks cycles
+	visited := make(map[string]bool, len(direct))
+	all := make([]string, 0, len(direct))
+	level := direct
+	for depth := 0; len(level) > 0 && depth < p.cfg.MaxNestingDepth; depth++ {
+		var next []string
+		for _, dn := range level {
+			key := strings.ToLower(dn)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			all = append(all, dn)
+
+			parents, err := p.memberOf(c, dn)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, parents...)
+		}
+		level = next
+	}
+	return sortedUnique(all), nil
+}
+
+// memberOf returns the groups that list dn as a member
+func (p *Provider) memberOf(c *conn, dn string) ([]string, error) {
+	return p.searchGroups(c, strings.ReplaceAll(p.cfg.GroupFilter, "{dn}", ldap.EscapeFilter(dn)))
+}
+
+// searchGroups returns the DNs of the groups matching filter
+func (p *Provider) searchGroups(c *conn, filter string) ([]string, error) {
+	res, err := c.SearchWithPaging(ldap.NewSearchRequest(
+		p.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(p.cfg.Timeout.Seconds()), false, filter, []string{"cn"}, nil), 500)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: search groups: %w", err)
+	}
+	dns := make([]string, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		dns = append(dns, e.DN)
+	}
+	return dns, nil
+}
+
+// sortedUnique sorts DNs and drops case-insensitive duplicates
+func sortedUnique(dns []string) []string {
+	slices.SortFunc(dns, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	return slices.CompactFunc(dns, strings.EqualFold)
+}
+
+// formatGUID formats an Active Directory objectGUID, whose first three
+// fields are little-endian, as a UUID string
+func formatGUID(b []byte) string {
+	if len(b) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10], b[10:16])
+}
@@ -0,0 +1,119 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+type fakeRoleManager struct {
+	roles map[string][]string
+}
+
+func (m *fakeRoleManager) DeleteRolesForUser(user string, _ ...string) (bool, error) {
+	delete(m.roles, user)
+	return true, nil
+}
+
+func (m *fakeRoleManager) AddRolesForUser(user string, roles []string, _ ...string) (bool, error) {
+	m.roles[user] = append(m.roles[user], roles...)
+	return true, nil
+}
+
+func TestDefaults(t *testing.T) {
+	c := Config{URL: "ldap://localhost", BaseDN: "dc=example,dc=com"}.withDefaults()
+	if c.UserFilter != "(uid={username})" || c.GroupBaseDN != c.BaseDN || c.Attributes.Username != "uid" {
+		t.Fatalf("openldap defaults: %+v", c)
+	}
+	if c.PoolSize != 10 || c.MaxNestingDepth != 10 {
+		t.Fatalf("pool size %d, nesting depth %d", c.PoolSize, c.MaxNestingDepth)
+	}
+
+	ad := Config{URL: "ldap://dc1", BaseDN: "DC=corp", ActiveDirectory: true}.withDefaults()
+	if ad.Attributes.ID != "objectGUID" || ad.Attributes.Username != "sAMAccountName" {
+		t.Fatalf("active directory attributes: %+v", ad.Attributes)
+	}
+
+	if _, err := New(&Config{URL: "ldap://localhost"}); err == nil {
+		t.Fatal("expected an error without base_dn")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	p := &Provider{cfg: Config{
+		RoleMapping: map[string][]string{
+			"domain admins": {"admin"},
+			"CN=Engineering,OU=Groups,DC=corp,DC=example,DC=com": {"developer", "viewer"},
+		},
+		DefaultRoles: []string{"viewer"},
+	}}
+
+	roles := p.roles([]string{
+		"CN=Domain Admins,CN=Users,DC=corp,DC=example,DC=com",
+		"cn=engineering,ou=groups,dc=corp,dc=example,dc=com",
+		"CN=Unmapped,DC=corp,DC=example,DC=com",
+	})
+	if want := []string{"admin", "developer", "viewer"}; !slices.Equal(roles, want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+
+	if roles := p.roles(nil); !slices.Equal(roles, []string{"viewer"}) {
+		t.Fatalf("default roles = %v", roles)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	p := &Provider{cfg: Config{URL: "ldap://dc1", BaseDN: "DC=corp", ActiveDirectory: true,
+		Attributes: Attributes{Extra: []string{"department"}}}.withDefaults()}
+
+	guid := []byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78}
+	entry := ldap.NewEntry("CN=Jane Doe,OU=Users,DC=corp", map[string][]string{
+		"sAMAccountName": {"jdoe"},
+		"mail":           {"jdoe@corp.example.com"},
+		"displayName":    {"Jane Doe"},
+		"department":     {"Engineering"},
+	})
+	entry.Attributes = append(entry.Attributes, &ldap.EntryAttribute{
+		Name: "objectGUID", Values: []string{string(guid)}, ByteValues: [][]byte{guid},
+	})
+
+	id := p.identity(entry)
+	if id.ID != "12345678-1234-5678-9abc-def012345678" {
+		t.Fatalf("id = %q", id.ID)
+	}
+	if id.Username != "jdoe" || id.Name != "Jane Doe" || id.Attributes["department"][0] != "Engineering" {
+		t.Fatalf("identity = %+v", id)
+	}
+}
+
+func TestSortedUnique(t *testing.T) {
+	got := sortedUnique([]string{"CN=b,DC=x", "cn=A,dc=x", "CN=a,DC=x"})
+	if len(got) != 2 || got[1] != "CN=b,DC=x" {
+		t.Fatalf("sortedUnique = %v", got)
+	}
+}
+
+func TestSyncRoles(t *testing.T) {
+	rm := &fakeRoleManager{roles: map[string][]string{"u1": {"stale"}}}
+	if err := SyncRoles(rm, "u1", &Identity{Roles: []string{"admin", "viewer"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rm.roles["u1"], []string{"admin", "viewer"}) {
+		t.Fatalf("roles = %v", rm.roles["u1"])
+	}
+}
+
+func TestAuthenticateEmptyPassword(t *testing.T) {
+	p, err := New(&Config{URL: "ldap://127.0.0.1:1", BaseDN: "dc=example,dc=com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Authenticate(context.Background(), "jdoe", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+	}
+}
@@ -0,0 +1,180 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrPoolClosed is returned after Close
+var ErrPoolClosed = errors.New("ldap: connection pool closed")
+
+// conn is a pooled connection, bound as the service account when idle
+type conn struct {
+	*ldap.Conn
+	used time.Time
+}
+
+// pool keeps up to size connections open
+type pool struct {
+	cfg       *Config
+	url       *url.URL
+	tlsConfig *tls.Config
+	idle      chan *conn
+	sem       chan struct{} // One slot per open connection
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newPool(cfg *Config) (*pool, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid url: %w", err)
+	}
+	tlsConfig, err := cfg.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	return &pool{
+		cfg:       cfg,
+		url:       u,
+		tlsConfig: tlsConfig,
+		idle:      make(chan *conn, cfg.PoolSize),
+		sem:       make(chan struct{}, cfg.PoolSize),
+	}, nil
+}
+
+// get returns an idle connection or dials a new one, waiting for a free slot
+// until ctx is done
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	for {
+		p.mu.Lock()
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			return nil, ErrPoolClosed
+		}
+
+		// Prefer an idle connection over dialing while a slot is free
+		select {
+		case c := <-p.idle:
+			if p.usable(c) {
+				return c, nil
+			}
+			continue
+		default:
+		}
+
+		select {
+		case c := <-p.idle:
+			if p.usable(c) {
+				return c, nil
+			}
+		case p.sem <- struct{}{}:
+			c, err := p.dial()
+			if err != nil {
+				<-p.sem
+				return nil, err
+			}
+			return c, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// usable reports whether an idle connection can be reused, discarding it otherwise
+func (p *pool) usable(c *conn) bool {
+	if c.IsClosing() || time.Since(c.used) > p.cfg.IdleTimeout {
+		p.discard(c)
+		return false
+	}
+	return true
+}
+
+// put returns a connection to the pool, closing it if broken
+func (p *pool) put(c *conn, broken bool) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if broken || closed || c.IsClosing() {
+		p.discard(c)
+		return
+	}
+	c.used = time.Now()
+	select {
+	case p.idle <- c:
+	default:
+		p.discard(c)
+	}
+}
+
+// discard closes a connection and frees its slot
+func (p *pool) discard(c *conn) {
+	_ = c.Close()
+	<-p.sem
+}
+
+// dial opens a connection, upgrades it with StartTLS if configured and binds
+// the service account
+func (p *pool) dial() (*conn, error) {
+	lc, err := ldap.DialURL(p.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: p.cfg.DialTimeout}),
+		ldap.DialWithTLSConfig(p.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: dial: %w", err)
+	}
+	lc.SetTimeout(p.cfg.Timeout)
+
+	if p.cfg.StartTLS && p.url.Scheme == "ldap" {
+		if err := lc.StartTLS(p.tlsConfig); err != nil {
+			_ = lc.Close()
+			return nil, fmt.Errorf("ldap: start tls: %w", err)
+		}
+	}
+	c := &conn{Conn: lc, used: time.Now()}
+	if err := c.bindService(p.cfg); err != nil {
+		_ = lc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// bindService binds the service account, or stays anonymous without one
+func (c *conn) bindService(cfg *Config) error {
+	if cfg.BindDN == "" {
+		return c.UnauthenticatedBind("")
+	}
+	if err := c.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+		return fmt.Errorf("ldap: service bind: %w", err)
+	}
+	return nil
+}
+
+// close closes the idle connections; connections in use close when returned
+func (p *pool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	for {
+		select {
+		case c := <-p.idle:
+			p.discard(c)
+		default:
+			return
+		}
+	}
+}
+
+// stats reports open and idle connections
+func (p *pool) stats() (open, idle int) {
+	return len(p.sem), len(p.idle)
+}
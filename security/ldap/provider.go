@@ -0,0 +1,32 @@
+package ldap
+
+import (
+	"github.com/google/wire"
+)
+
+// ProviderSet is the wire provider set for the ldap package.
+// It provides *Provider for directory logins.
+//
+// Usage:
+//
+//	wire.Build(
+//	    config.ProviderSet,
+//	    ldap.ProviderSet,
+//	    // ... other providers
+//	)
+var ProviderSet = wire.NewSet(
+	ProvideProvider,
+)
+
+// ProvideProvider creates an LDAP Provider from configuration.
+// Returns nil if LDAP is not configured.
+func ProvideProvider(cfg *Config) (*Provider, func(), error) {
+	if cfg == nil || cfg.URL == "" {
+		return nil, func() {}, nil
+	}
+	p, err := New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, func() { _ = p.Close() }, nil
+}
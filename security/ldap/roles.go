@@ -0,0 +1,88 @@
+package ldap
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/ncobase/ncore/security/jwt"
+)
+
+// roles maps group DNs to roles through RoleMapping, matching a group by its
+// DN or its CN
+func (p *Provider) roles(groups []string) []string {
+	mapping := make(map[string][]string, len(p.cfg.RoleMapping))
+	for k, v := range p.cfg.RoleMapping {
+		mapping[strings.ToLower(k)] = v
+	}
+
+	roles := slices.Clone(p.cfg.DefaultRoles)
+	for _, dn := range groups {
+		roles = append(roles, mapping[strings.ToLower(dn)]...)
+		if cn := commonName(dn); cn != "" {
+			roles = append(roles, mapping[strings.ToLower(cn)]...)
+		}
+	}
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// commonName returns the CN of the first RDN of a DN
+func commonName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return ""
+	}
+	for _, attr := range parsed.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// RoleManager assigns roles to users in the access control engine.
+// *casbin.Enforcer implements it.
+type RoleManager interface {
+	DeleteRolesForUser(user string, domain ...string) (bool, error)
+	AddRolesForUser(user string, roles []string, domain ...string) (bool, error)
+}
+
+// SyncRoles replaces the roles of subject in the access control engine with
+// the directory roles of identity, so group changes apply at the next login
+func SyncRoles(rm RoleManager, subject string, identity *Identity, domain ...string) error {
+	if _, err := rm.DeleteRolesForUser(subject, domain...); err != nil {
+		return err
+	}
+	if len(identity.Roles) == 0 {
+		return nil
+	}
+	_, err := rm.AddRolesForUser(subject, identity.Roles, domain...)
+	return err
+}
+
+// Payload returns the token payload of an identity, for
+// jwt.TokenManager.GenerateAccessToken
+func (i *Identity) Payload() map[string]any {
+	return map[string]any{
+		"user_id":  i.ID,
+		"username": i.Username,
+		"email":    i.Email,
+		"name":     i.Name,
+		"roles":    i.Roles,
+		"provider": "ldap",
+	}
+}
+
+// IssueTokens issues an access token with the identity payload and a refresh
+// token for an authenticated identity, both with the token ID jti
+func IssueTokens(tm *jwt.TokenManager, jti string, identity *Identity) (access, refresh string, err error) {
+	payload := identity.Payload()
+	if access, err = tm.GenerateAccessToken(jti, payload); err != nil {
+		return "", "", err
+	}
+	if refresh, err = tm.GenerateRefreshToken(jti, map[string]any{"user_id": identity.ID, "provider": "ldap"}); err != nil {
+		return "", "", err
+	}
+	return access, refresh, nil
+}
@@ -3,10 +3,13 @@ package security
 import (
 	"github.com/google/wire"
 	"github.com/ncobase/ncore/security/jwt"
+	"github.com/ncobase/ncore/security/ldap"
+	"github.com/ncobase/ncore/security/saml"
 )
 
 // ProviderSet is the wire provider set for the security package.
-// It provides JWT TokenManager and other security-related components.
+// It provides JWT TokenManager, the LDAP and SAML Providers and other
+// security-related components.
 //
 // Usage:
 //
@@ -17,4 +20,6 @@ import (
 //	)
 var ProviderSet = wire.NewSet(
 	jwt.ProviderSet,
+	ldap.ProviderSet,
+	saml.ProviderSet,
 )
@@ -0,0 +1,42 @@
+package refresh
+
+import (
+	"time"
+
+	"github.com/ncobase/ncore/config"
+)
+
+// DefaultTTL is the lifetime of each refresh token
+const DefaultTTL = 7 * 24 * time.Hour
+
+// Config holds the refresh token rotation settings
+type Config struct {
+	// TTL is the lifetime of each refresh token, defaults to 7 days
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+	// MaxLifetime caps a token family from its first token, after which the
+	// user must log in again. 0 lets families live as long as they rotate.
+	MaxLifetime time.Duration `json:"max_lifetime" yaml:"max_lifetime"`
+	// ReuseGrace tolerates a rotated token presented again this soon after
+	// its rotation, e.g. by concurrent requests of one client, with
+	// ErrRotated instead of revoking the family. 0 treats any reuse as theft.
+	ReuseGrace time.Duration `json:"reuse_grace" yaml:"reuse_grace"`
+}
+
+// FromConfig converts the auth.refresh configuration, nil when it is nil
+func FromConfig(c *config.Refresh) *Config {
+	if c == nil {
+		return nil
+	}
+	return &Config{TTL: c.TTL, MaxLifetime: c.MaxLifetime, ReuseGrace: c.ReuseGrace}
+}
+
+func (c *Config) withDefaults() Config {
+	var cfg Config
+	if c != nil {
+		cfg = *c
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	return cfg
+}
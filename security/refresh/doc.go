@@ -0,0 +1,34 @@
+// Package refresh rotates refresh tokens with reuse detection. Every refresh
+// exchanges the presented token for a new one of the same family, the chain
+// of tokens descending from one login. An exchanged token presented again
+// means two parties hold tokens of the family, one of them having stolen it,
+// so the whole family is revoked and EventReused is published.
+//
+// # Usage
+//
+//	rotator := refresh.New(refresh.FromConfig(cfg.Auth.Refresh), tokenManager, refresh.NewRedisStore(rdb, "refresh"),
+//	    refresh.WithPublisher(refresh.PublisherFunc(em.PublishEvent)))
+//
+//	// On login, the session ID names the family
+//	token, _, err := rotator.Issue(ctx, user.ID, s.ID, payload)
+//
+//	// On refresh
+//	token, rec, err := rotator.Rotate(ctx, refreshToken)
+//	switch {
+//	case errors.Is(err, refresh.ErrRotated):
+//	    // concurrent refresh within ReuseGrace, retry with the newer token
+//	case err != nil:
+//	    // reused, revoked, expired or invalid: log in again
+//	}
+//	access, _ := tokenManager.GenerateAccessToken(rec.FamilyID, payload)
+//
+//	// On logout
+//	_ = rotator.Revoke(ctx, s.ID)
+//
+// # Stores
+//
+// MemoryStore suits single instances and tests. RedisStore shares lineage
+// across instances and expires it with the tokens. SQLStore keeps lineage
+// in a table created from Schema, queryable for audits until DeleteExpired
+// purges it.
+package refresh
@@ -0,0 +1,83 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncobase/ncore/security/jwt"
+)
+
+type recorder struct{ events []string }
+
+func (r *recorder) Publish(name string, data any) {
+	e := data.(*Event)
+	r.events = append(r.events, name+":"+e.Token.FamilyID)
+}
+
+func TestRotator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	events := &recorder{}
+	r := New(nil, jwt.NewTokenManager("secret"), nil,
+		WithPublisher(events), WithClock(func() time.Time { return now }))
+
+	first, rec, err := r.Issue(ctx, "u1", "s1", map[string]any{"user_id": "u1"})
+	if err != nil || rec.FamilyID != "s1" {
+		t.Fatalf("issue = %+v, %v", rec, err)
+	}
+	now = now.Add(time.Minute)
+	second, next, err := r.Rotate(ctx, first)
+	if err != nil || next.ParentID != rec.ID || next.FamilyID != "s1" {
+		t.Fatalf("rotate = %+v, %v", next, err)
+	}
+	if payload, _ := jwt.NewTokenManager("secret").GetPayload(second); payload["user_id"] != "u1" {
+		t.Errorf("payload = %v", payload)
+	}
+	if _, err := r.Validate(ctx, first); !errors.Is(err, ErrRotated) {
+		t.Errorf("validate rotated: %v", err)
+	}
+
+	// The stolen first token is replayed: the family is revoked, so the
+	// legitimate holder of the second token is logged out too
+	if _, _, err := r.Rotate(ctx, first); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reuse: %v", err)
+	}
+	if len(events.events) != 1 || events.events[0] != EventReused+":s1" {
+		t.Errorf("events = %v", events.events)
+	}
+	if _, _, err := r.Rotate(ctx, second); !errors.Is(err, ErrFamilyRevoked) {
+		t.Errorf("rotate after reuse: %v", err)
+	}
+
+	access, _ := jwt.NewTokenManager("secret").GenerateAccessToken("x", nil)
+	if _, _, err := r.Rotate(ctx, access); !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Errorf("access token: %v", err)
+	}
+}
+
+func TestRotatorGrace(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	r := New(&Config{ReuseGrace: 10 * time.Second, MaxLifetime: time.Hour}, jwt.NewTokenManager("secret"), nil,
+		WithClock(func() time.Time { return now }))
+
+	first, rec, err := r.Issue(ctx, "u1", "", nil)
+	if err != nil || rec.FamilyID == "" || !rec.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("issue = %+v, %v", rec, err)
+	}
+	second, _, err := r.Rotate(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A concurrent refresh with the same token is not theft
+	if _, _, err := r.Rotate(ctx, first); !errors.Is(err, ErrRotated) {
+		t.Errorf("within grace: %v", err)
+	}
+	now = now.Add(30 * time.Minute)
+	_, next, err := r.Rotate(ctx, second)
+	if err != nil || !next.ExpiresAt.Equal(rec.FamilyCreatedAt.Add(time.Hour)) {
+		t.Errorf("capped expiry = %+v, %v", next, err)
+	}
+}
@@ -0,0 +1,245 @@
+package refresh
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/ncobase/ncore/ctxutil"
+	"github.com/ncobase/ncore/security/jwt"
+)
+
+// Event names published by the Rotator
+const (
+	// EventReused is published with *Event when a rotated token is presented
+	// again, a sign it was stolen. The family is revoked by then; notify the
+	// user and record it in the audit log.
+	EventReused = "refresh.reused"
+	// EventRevoked is published with *Event when a family is revoked by
+	// Revoke
+	EventRevoked = "refresh.revoked"
+)
+
+// Event is the payload of published refresh token events
+type Event struct {
+	Token     *Token `json:"token"`                // Presented token, only FamilyID set for EventRevoked
+	IP        string `json:"ip,omitempty"`         // Client presenting it
+	UserAgent string `json:"user_agent,omitempty"` // Client presenting it
+}
+
+// Publisher receives refresh token events, the extension event bus
+// implements it
+type Publisher interface {
+	Publish(eventName string, data any)
+}
+
+// PublisherFunc adapts a function to Publisher, e.g. the extension manager's
+// PublishEvent
+type PublisherFunc func(eventName string, data any)
+
+// Publish implements Publisher
+func (f PublisherFunc) Publish(eventName string, data any) { f(eventName, data) }
+
+// Option configures a Rotator
+type Option func(*Rotator)
+
+// WithPublisher publishes refresh token events to p
+func WithPublisher(p Publisher) Option {
+	return func(r *Rotator) { r.publisher = p }
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(r *Rotator) { r.now = now }
+}
+
+// Rotator issues single-use refresh tokens. Each refresh exchanges a token
+// for a new one of the same family; presenting an exchanged token again
+// revokes the whole family, cutting off whoever holds the latest token.
+type Rotator struct {
+	cfg       Config
+	tokens    *jwt.TokenManager
+	store     Store
+	publisher Publisher
+	now       func() time.Time
+}
+
+// New creates a Rotator signing tokens with tokens. store defaults to a
+// MemoryStore.
+func New(cfg *Config, tokens *jwt.TokenManager, store Store, opts ...Option) *Rotator {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	r := &Rotator{cfg: cfg.withDefaults(), tokens: tokens, store: store, now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Issue starts a token family on login and returns its first refresh token.
+// familyID may be the session ID so that revoking either is easy to
+// correlate, a random ID is used when empty. payload is carried into every
+// token of the family.
+func (r *Rotator) Issue(ctx context.Context, userID, familyID string, payload map[string]any) (string, *Token, error) {
+	if userID == "" {
+		return "", nil, errors.New("refresh: empty user ID")
+	}
+	if familyID == "" {
+		familyID = rand.Text()
+	}
+	now := r.now()
+	t := &Token{
+		ID:              rand.Text(),
+		FamilyID:        familyID,
+		UserID:          userID,
+		IssuedAt:        now,
+		FamilyCreatedAt: now,
+	}
+	t.ExpiresAt = r.expiry(t, now)
+	if err := r.store.Create(ctx, t); err != nil {
+		return "", nil, err
+	}
+	signed, err := r.sign(t, now, payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, t, nil
+}
+
+// Rotate exchanges a refresh token for a new one of its family carrying the
+// same payload, returning the new token with its record.
+//
+// A token rotated before fails with ErrTokenReused after revoking the family
+// and publishing EventReused, or with ErrRotated within ReuseGrace. Tokens of
+// revoked families fail with ErrFamilyRevoked.
+func (r *Rotator) Rotate(ctx context.Context, refreshToken string) (string, *Token, error) {
+	claims, err := r.tokens.DecodeToken(refreshToken)
+	if err != nil {
+		return "", nil, err
+	}
+	if !jwt.IsRefreshToken(claims) {
+		return "", nil, jwt.ErrInvalidToken
+	}
+	t, err := r.store.Get(ctx, jwt.GetTokenID(claims))
+	if err != nil {
+		return "", nil, err
+	}
+	if err := r.checkFamily(ctx, t); err != nil {
+		return "", nil, err
+	}
+
+	now := r.now()
+	if t.Rotated() {
+		return "", nil, r.reused(ctx, t, now)
+	}
+	next := &Token{
+		ID:              rand.Text(),
+		FamilyID:        t.FamilyID,
+		ParentID:        t.ID,
+		UserID:          t.UserID,
+		IssuedAt:        now,
+		FamilyCreatedAt: t.FamilyCreatedAt,
+	}
+	next.ExpiresAt = r.expiry(next, now)
+	if !next.ExpiresAt.After(now) {
+		return "", nil, jwt.ErrTokenExpired
+	}
+	ok, err := r.store.Rotate(ctx, t.ID, next, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		// Another request rotated it first
+		if current, err := r.store.Get(ctx, t.ID); err == nil {
+			t = current
+		}
+		return "", nil, r.reused(ctx, t, now)
+	}
+	signed, err := r.sign(next, now, jwt.GetPayload(claims))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, next, nil
+}
+
+// Revoke ends a token family, e.g. on logout or when its session is revoked
+func (r *Rotator) Revoke(ctx context.Context, familyID string) error {
+	if err := r.store.RevokeFamily(ctx, familyID, r.now().Add(r.cfg.TTL)); err != nil {
+		return err
+	}
+	r.publish(ctx, EventRevoked, &Token{FamilyID: familyID})
+	return nil
+}
+
+// Validate checks a refresh token without rotating it, returning its record
+func (r *Rotator) Validate(ctx context.Context, refreshToken string) (*Token, error) {
+	claims, err := r.tokens.DecodeToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if !jwt.IsRefreshToken(claims) {
+		return nil, jwt.ErrInvalidToken
+	}
+	t, err := r.store.Get(ctx, jwt.GetTokenID(claims))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkFamily(ctx, t); err != nil {
+		return nil, err
+	}
+	if t.Rotated() {
+		return nil, ErrRotated
+	}
+	return t, nil
+}
+
+func (r *Rotator) checkFamily(ctx context.Context, t *Token) error {
+	revoked, err := r.store.FamilyRevoked(ctx, t.FamilyID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrFamilyRevoked
+	}
+	return nil
+}
+
+// reused handles a rotated token presented again
+func (r *Rotator) reused(ctx context.Context, t *Token, now time.Time) error {
+	if r.cfg.ReuseGrace > 0 && t.Rotated() && now.Sub(t.RotatedAt) < r.cfg.ReuseGrace {
+		return ErrRotated
+	}
+	if err := r.store.RevokeFamily(ctx, t.FamilyID, now.Add(r.cfg.TTL)); err != nil {
+		return err
+	}
+	r.publish(ctx, EventReused, t)
+	return ErrTokenReused
+}
+
+// expiry returns the expiry of a token issued at now, capped by MaxLifetime
+func (r *Rotator) expiry(t *Token, now time.Time) time.Time {
+	expires := now.Add(r.cfg.TTL)
+	if r.cfg.MaxLifetime > 0 {
+		if limit := t.FamilyCreatedAt.Add(r.cfg.MaxLifetime); limit.Before(expires) {
+			expires = limit
+		}
+	}
+	return expires
+}
+
+func (r *Rotator) sign(t *Token, now time.Time, payload map[string]any) (string, error) {
+	return r.tokens.GenerateRefreshToken(t.ID, payload, &jwt.TokenConfig{Expiry: t.ExpiresAt.Sub(now)})
+}
+
+func (r *Rotator) publish(ctx context.Context, name string, t *Token) {
+	if r.publisher == nil {
+		return
+	}
+	r.publisher.Publish(name, &Event{
+		Token:     t,
+		IP:        ctxutil.GetClientIP(ctx),
+		UserAgent: ctxutil.GetUserAgent(ctx),
+	})
+}
@@ -0,0 +1,167 @@
+package refresh
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ncobase/ncore/data/sqlq"
+)
+
+// Schema creates the table of SQLStore under its default name, portable
+// across Postgres, MySQL and SQLite
+const Schema = `CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id VARCHAR(64) PRIMARY KEY,
+	family_id VARCHAR(64) NOT NULL,
+	parent_id VARCHAR(64) NOT NULL DEFAULT '',
+	user_id VARCHAR(64) NOT NULL,
+	issued_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	family_created_at TIMESTAMP NOT NULL,
+	rotated_at TIMESTAMP NULL,
+	successor_id VARCHAR(64) NOT NULL DEFAULT '',
+	revoked_at TIMESTAMP NULL
+);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_expires ON refresh_tokens (expires_at);`
+
+// SQLStore is a Store on a database/sql table created from Schema. The
+// lineage stays queryable for audits until DeleteExpired purges it.
+type SQLStore struct {
+	db     *sql.DB
+	table  string
+	dollar bool
+}
+
+// NewSQLStore creates a SQLStore. driver is the database/sql driver name,
+// "postgres" and "pgx" use $n placeholders, others use ?. table defaults to
+// refresh_tokens.
+func NewSQLStore(db *sql.DB, driver, table string) *SQLStore {
+	if table == "" {
+		table = "refresh_tokens"
+	}
+	dollar := false
+	switch driver {
+	case "postgres", "pgx", "pgx/v5":
+		dollar = true
+	}
+	return &SQLStore{db: db, table: table, dollar: dollar}
+}
+
+// rebind converts the ? placeholders of query to the driver's style
+func (s *SQLStore) rebind(query string) string {
+	if !s.dollar {
+		return query
+	}
+	return sqlq.Rebind(sqlq.Dollar, query)
+}
+
+const tokenColumns = "id, family_id, parent_id, user_id, issued_at, expires_at, family_created_at, rotated_at, successor_id"
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (s *SQLStore) insert(ctx context.Context, db execer, t *Token) error {
+	query := s.rebind("INSERT INTO " + s.table + " (" + tokenColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	var rotatedAt sql.NullTime
+	if t.Rotated() {
+		rotatedAt = sql.NullTime{Time: t.RotatedAt.UTC(), Valid: true}
+	}
+	_, err := db.ExecContext(ctx, query, t.ID, t.FamilyID, t.ParentID, t.UserID,
+		t.IssuedAt.UTC(), t.ExpiresAt.UTC(), t.FamilyCreatedAt.UTC(), rotatedAt, t.SuccessorID)
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %v", err)
+	}
+	return nil
+}
+
+// Create implements Store
+func (s *SQLStore) Create(ctx context.Context, t *Token) error {
+	return s.insert(ctx, s.db, t)
+}
+
+// Get implements Store
+func (s *SQLStore) Get(ctx context.Context, id string) (*Token, error) {
+	query := s.rebind("SELECT " + tokenColumns + " FROM " + s.table + " WHERE id = ?")
+	var (
+		t         Token
+		rotatedAt sql.NullTime
+	)
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&t.ID, &t.FamilyID, &t.ParentID, &t.UserID,
+		&t.IssuedAt, &t.ExpiresAt, &t.FamilyCreatedAt, &rotatedAt, &t.SuccessorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+	if rotatedAt.Valid {
+		t.RotatedAt = rotatedAt.Time
+	}
+	return &t, nil
+}
+
+// Rotate implements Store
+func (s *SQLStore) Rotate(ctx context.Context, id string, next *Token, at time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Only one UPDATE can match a token not rotated yet
+	query := s.rebind("UPDATE " + s.table + " SET rotated_at = ?, successor_id = ? WHERE id = ? AND rotated_at IS NULL")
+	result, err := tx.ExecContext(ctx, query, at.UTC(), next.ID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	if err := s.insert(ctx, tx, next); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	return true, nil
+}
+
+// RevokeFamily implements Store. The revocation is kept with the family's
+// rows, until is not needed.
+func (s *SQLStore) RevokeFamily(ctx context.Context, familyID string, _ time.Time) error {
+	query := s.rebind("UPDATE " + s.table + " SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL")
+	if _, err := s.db.ExecContext(ctx, query, time.Now().UTC(), familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %v", err)
+	}
+	return nil
+}
+
+// FamilyRevoked implements Store
+func (s *SQLStore) FamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	query := s.rebind("SELECT COUNT(*) FROM " + s.table + " WHERE family_id = ? AND revoked_at IS NOT NULL")
+	var n int
+	if err := s.db.QueryRowContext(ctx, query, familyID).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to check token family: %v", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteExpired purges tokens expired before the given time, returning the
+// number of deleted rows. Keep a margin over TTL to retain lineage for
+// audits.
+func (s *SQLStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := s.rebind("DELETE FROM " + s.table + " WHERE expires_at < ?")
+	result, err := s.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %v", err)
+	}
+	return result.RowsAffected()
+}
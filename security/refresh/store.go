@@ -0,0 +1,250 @@
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store persists the lineage of refresh tokens
+type Store interface {
+	// Create records a newly issued token
+	Create(ctx context.Context, t *Token) error
+	// Get returns a token, ErrNotFound if unknown
+	Get(ctx context.Context, id string) (*Token, error)
+	// Rotate marks the token id rotated into next at time at and records
+	// next, atomically. It reports false, recording nothing, when id was
+	// already rotated.
+	Rotate(ctx context.Context, id string, next *Token, at time.Time) (bool, error)
+	// RevokeFamily revokes every token of a family, the revocation must be
+	// kept at least until the given time
+	RevokeFamily(ctx context.Context, familyID string, until time.Time) error
+	// FamilyRevoked reports whether a family was revoked
+	FamilyRevoked(ctx context.Context, familyID string) (bool, error)
+}
+
+// MemoryStore is an in-memory Store for single instances
+type MemoryStore struct {
+	mu       sync.Mutex
+	tokens   map[string]*Token
+	families map[string]time.Time // revoked family ID to expiry
+	sweep    time.Time
+}
+
+// NewMemoryStore creates a MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens:   make(map[string]*Token),
+		families: make(map[string]time.Time),
+	}
+}
+
+// Create implements Store
+func (m *MemoryStore) Create(_ context.Context, t *Token) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.sweep) >= time.Minute {
+		m.sweep = now
+		m.sweepExpired(now)
+	}
+	c := *t
+	m.tokens[t.ID] = &c
+	return nil
+}
+
+// sweepExpired drops expired tokens and revocations
+func (m *MemoryStore) sweepExpired(now time.Time) {
+	for id, t := range m.tokens {
+		if now.After(t.ExpiresAt) {
+			delete(m.tokens, id)
+		}
+	}
+	for id, expiry := range m.families {
+		if now.After(expiry) {
+			delete(m.families, id)
+		}
+	}
+}
+
+// Get implements Store
+func (m *MemoryStore) Get(_ context.Context, id string) (*Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *t
+	return &c, nil
+}
+
+// Rotate implements Store
+func (m *MemoryStore) Rotate(_ context.Context, id string, next *Token, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if t.Rotated() {
+		return false, nil
+	}
+	t.RotatedAt = at
+	t.SuccessorID = next.ID
+	c := *next
+	m.tokens[next.ID] = &c
+	return true, nil
+}
+
+// RevokeFamily implements Store
+func (m *MemoryStore) RevokeFamily(_ context.Context, familyID string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if until.After(m.families[familyID]) {
+		m.families[familyID] = until
+	}
+	return nil
+}
+
+// FamilyRevoked implements Store
+func (m *MemoryStore) FamilyRevoked(_ context.Context, familyID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiry, ok := m.families[familyID]
+	return ok && time.Now().Before(expiry), nil
+}
+
+// RedisStore is a Store shared across instances, including Redis Cluster.
+// Records expire with their tokens; rotation is claimed with SET NX on a
+// marker key, so it needs no script spanning slots.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore, keys are prefixed with prefix
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "refresh"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (r *RedisStore) tokenKey(id string) string        { return r.prefix + ":t:" + id }
+func (r *RedisStore) rotationKey(id string) string     { return r.prefix + ":r:" + id }
+func (r *RedisStore) familyKey(familyID string) string { return r.prefix + ":f:" + familyID }
+
+// rotation is the value of a rotation marker
+type rotation struct {
+	At          time.Time `json:"at"`
+	SuccessorID string    `json:"successor_id"`
+}
+
+// Create implements Store
+func (r *RedisStore) Create(ctx context.Context, t *Token) error {
+	ttl := time.Until(t.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(ctx, r.tokenKey(t.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save refresh token: %v", err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (r *RedisStore) Get(ctx context.Context, id string) (*Token, error) {
+	var tokenCmd, rotationCmd *redis.StringCmd
+	_, err := r.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		tokenCmd = p.Get(ctx, r.tokenKey(id))
+		rotationCmd = p.Get(ctx, r.rotationKey(id))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+	data, err := tokenCmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %v", err)
+	}
+	var t Token
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh token: %v", err)
+	}
+	if data, err := rotationCmd.Bytes(); err == nil {
+		var rot rotation
+		if err := json.Unmarshal(data, &rot); err != nil {
+			return nil, fmt.Errorf("failed to decode refresh token: %v", err)
+		}
+		t.RotatedAt, t.SuccessorID = rot.At, rot.SuccessorID
+	}
+	return &t, nil
+}
+
+// Rotate implements Store
+func (r *RedisStore) Rotate(ctx context.Context, id string, next *Token, at time.Time) (bool, error) {
+	t, err := r.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(rotation{At: at, SuccessorID: next.ID})
+	if err != nil {
+		return false, err
+	}
+	// The marker lives as long as the rotated token, so reuse is detected
+	// until it would have expired anyway
+	ttl := time.Until(t.ExpiresAt)
+	if ttl <= 0 {
+		return false, ErrNotFound
+	}
+	ok, err := r.client.SetNX(ctx, r.rotationKey(id), data, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+	if !ok {
+		return false, nil
+	}
+	if err := r.Create(ctx, next); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeFamily implements Store
+func (r *RedisStore) RevokeFamily(ctx context.Context, familyID string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := r.client.Set(ctx, r.familyKey(familyID), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token family: %v", err)
+	}
+	return nil
+}
+
+// FamilyRevoked implements Store
+func (r *RedisStore) FamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	n, err := r.client.Exists(ctx, r.familyKey(familyID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token family: %v", err)
+	}
+	return n > 0, nil
+}
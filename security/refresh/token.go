@@ -0,0 +1,38 @@
+package refresh
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for refresh tokens without a lineage record,
+	// never issued by a Rotator or expired
+	ErrNotFound = errors.New("refresh: token not found")
+	// ErrTokenReused is returned when a rotated token is presented again, its
+	// family has been revoked
+	ErrTokenReused = errors.New("refresh: token reused")
+	// ErrFamilyRevoked is returned for tokens of a revoked family
+	ErrFamilyRevoked = errors.New("refresh: token family revoked")
+	// ErrRotated is returned for a token presented again within ReuseGrace of
+	// its rotation, the family stays valid and the client should retry with
+	// the token of the winning request
+	ErrRotated = errors.New("refresh: token already rotated")
+)
+
+// Token is the lineage record of an issued refresh token. A family is the
+// chain of tokens descending from one login.
+type Token struct {
+	ID              string    `json:"id"` // jti of the token
+	FamilyID        string    `json:"family_id"`
+	ParentID        string    `json:"parent_id,omitempty"` // Token rotated into this one, empty for the first
+	UserID          string    `json:"user_id"`
+	IssuedAt        time.Time `json:"issued_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	FamilyCreatedAt time.Time `json:"family_created_at"`
+	RotatedAt       time.Time `json:"rotated_at,omitzero"` // Zero until rotated
+	SuccessorID     string    `json:"successor_id,omitempty"`
+}
+
+// Rotated reports whether the token has been exchanged for a successor
+func (t *Token) Rotated() bool { return !t.RotatedAt.IsZero() }
@@ -0,0 +1,126 @@
+package saml
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the SAML 2.0 service provider configuration
+type Config struct {
+	// EntityID defaults to the metadata URL
+	EntityID string `json:"entity_id" yaml:"entity_id"`
+	// RootURL is where Handler is mounted, e.g. https://app.example.com/saml.
+	// Metadata is served at {RootURL}/metadata, the ACS at {RootURL}/acs.
+	RootURL string `json:"root_url" yaml:"root_url"`
+
+	// CertFile and KeyFile hold the PEM encoded SP certificate and RSA key,
+	// used to sign requests and decrypt assertions
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+
+	// IdP metadata, from the first one set
+	IDPMetadataURL  string `json:"idp_metadata_url" yaml:"idp_metadata_url"`
+	IDPMetadataFile string `json:"idp_metadata_file" yaml:"idp_metadata_file"`
+	IDPMetadataXML  string `json:"idp_metadata_xml" yaml:"idp_metadata_xml"`
+
+	SignRequests      bool   `json:"sign_requests" yaml:"sign_requests"`
+	AllowIDPInitiated bool   `json:"allow_idp_initiated" yaml:"allow_idp_initiated"`
+	NameIDFormat      string `json:"name_id_format" yaml:"name_id_format"` // Defaults to the IdP's choice
+
+	// ClockSkew tolerated on assertion validity windows, defaults to 3m
+	ClockSkew time.Duration `json:"clock_skew" yaml:"clock_skew"`
+	// RequestTTL is how long a login may take at the IdP, defaults to 10m
+	RequestTTL time.Duration `json:"request_ttl" yaml:"request_ttl"`
+
+	Attributes Attributes `json:"attributes" yaml:"attributes"`
+
+	// RoleMapping maps group attribute values, compared case-insensitively, to roles
+	RoleMapping  map[string][]string `json:"role_mapping" yaml:"role_mapping"`
+	DefaultRoles []string            `json:"default_roles" yaml:"default_roles"` // Roles of every authenticated user
+}
+
+// Attributes names the assertion attributes read into an Identity, matched
+// by Name or FriendlyName
+type Attributes struct {
+	ID       string   `json:"id" yaml:"id"`             // Defaults to the NameID
+	Username string   `json:"username" yaml:"username"` // Defaults to uid, then the NameID
+	Email    string   `json:"email" yaml:"email"`       // Defaults to mail
+	Name     string   `json:"name" yaml:"name"`         // Defaults to displayName
+	Groups   string   `json:"groups" yaml:"groups"`     // Defaults to groups
+	Extra    []string `json:"extra" yaml:"extra"`       // Further attributes kept in Identity.Attributes
+}
+
+// withDefaults returns a copy of c with defaults applied
+func (c Config) withDefaults() Config {
+	c.RootURL = strings.TrimSuffix(c.RootURL, "/")
+	if c.EntityID == "" {
+		c.EntityID = c.RootURL + "/metadata"
+	}
+	if c.ClockSkew <= 0 {
+		c.ClockSkew = 3 * time.Minute
+	}
+	if c.RequestTTL <= 0 {
+		c.RequestTTL = 10 * time.Minute
+	}
+
+	a := &c.Attributes
+	a.Username = orDefault(a.Username, "uid")
+	a.Email = orDefault(a.Email, "mail")
+	a.Name = orDefault(a.Name, "displayName")
+	a.Groups = orDefault(a.Groups, "groups")
+	return c
+}
+
+// orDefault returns v, or def when v is empty
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// validate checks the required settings
+func (c *Config) validate() error {
+	if c.RootURL == "" || c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("invalid SAML configuration: root_url, cert_file and key_file are required")
+	}
+	if c.IDPMetadataURL == "" && c.IDPMetadataFile == "" && c.IDPMetadataXML == "" {
+		return errors.New("invalid SAML configuration: idp metadata is required")
+	}
+	if _, err := url.Parse(c.RootURL); err != nil {
+		return fmt.Errorf("invalid SAML root_url: %w", err)
+	}
+	return nil
+}
+
+// keyPair loads the SP certificate and RSA key
+func (c *Config) keyPair() (*x509.Certificate, *rsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(c.CertFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml: read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml: read key: %w", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml: load key pair: %w", err)
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("saml: the SP key must be an RSA key")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml: parse certificate: %w", err)
+	}
+	return cert, key, nil
+}
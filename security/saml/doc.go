@@ -0,0 +1,44 @@
+// Package saml implements a SAML 2.0 service provider for SP-initiated
+// single sign-on, mapping assertion attributes to users and roles.
+//
+// # Setup
+//
+//	provider, err := saml.New(ctx, &saml.Config{
+//	    RootURL:        "https://app.example.com/saml",
+//	    CertFile:       "/etc/app/saml.crt",
+//	    KeyFile:        "/etc/app/saml.key",
+//	    IDPMetadataURL: "https://idp.example.com/metadata",
+//	    SignRequests:   true,
+//	    Attributes:     saml.Attributes{Groups: "memberOf"},
+//	    RoleMapping: map[string][]string{
+//	        "app-admins": {"admin"},
+//	    },
+//	})
+//
+//	router.Any("/saml/*path", gin.WrapH(provider.Handler(
+//	    func(w http.ResponseWriter, r *http.Request, identity *saml.Identity, relayState string) {
+//	        access, _ := tokenManager.GenerateAccessToken(sessionID, identity.Payload())
+//	        // set the session, then
+//	        http.Redirect(w, r, cmp.Or(relayState, "/"), http.StatusFound)
+//	    })))
+//
+// Register {RootURL}/metadata with the IdP. Users start at
+// {RootURL}/login?return_to=/page and come back through {RootURL}/acs.
+//
+// # Validation
+//
+// Responses must be signed by the IdP, addressed to this SP and answer a
+// request it sent within RequestTTL; each request is answered once. Keep
+// request IDs in a shared RequestStore when running several instances.
+// IdP-initiated logins are refused unless AllowIDPInitiated is set.
+//
+// Assertion validity windows tolerate ClockSkew. The underlying library keeps
+// its tolerance process-wide, so the largest ClockSkew of all providers
+// applies.
+//
+// # Attributes
+//
+// Attributes are matched by Name or FriendlyName. ID and Username fall back
+// to the NameID, and values of the Groups attribute are mapped to roles with
+// RoleMapping, plus DefaultRoles for every user.
+package saml
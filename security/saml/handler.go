@@ -0,0 +1,63 @@
+package saml
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// LoginFunc completes a login, e.g. by issuing tokens and redirecting to
+// relayState, which is a local path or empty
+type LoginFunc func(w http.ResponseWriter, r *http.Request, identity *Identity, relayState string)
+
+// Handler serves the SP endpoints under RootURL:
+//
+//	GET  {RootURL}/metadata           SP metadata
+//	GET  {RootURL}/login?return_to=/  Redirect to the IdP
+//	POST {RootURL}/acs                Assertion consumer service, calls onLogin
+func (p *Provider) Handler(onLogin LoginFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/metadata"):
+			data, err := p.Metadata()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/samlmetadata+xml")
+			_, _ = w.Write(data)
+
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/login"):
+			target, err := p.LoginURL(r.Context(), localPath(r.URL.Query().Get("return_to")))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, target, http.StatusFound)
+
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/acs"):
+			identity, err := p.ParseResponse(r)
+			if err != nil {
+				status := http.StatusUnauthorized
+				if !errors.Is(err, ErrInvalidResponse) && !errors.Is(err, ErrUnknownRequest) && !errors.Is(err, ErrUnsolicited) {
+					status = http.StatusInternalServerError
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			onLogin(w, r, identity, localPath(r.PostForm.Get("RelayState")))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// localPath returns path if it stays on this site, so relay states cannot
+// redirect elsewhere
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+	return path
+}
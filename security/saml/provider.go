@@ -0,0 +1,33 @@
+package saml
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// ProviderSet is the wire provider set for the saml package.
+// It provides *Provider for SAML single sign-on.
+//
+// Usage:
+//
+//	wire.Build(
+//	    config.ProviderSet,
+//	    saml.ProviderSet,
+//	    // ... other providers
+//	)
+var ProviderSet = wire.NewSet(
+	ProvideProvider,
+)
+
+// ProvideProvider creates a SAML Provider from configuration.
+// Returns nil if SAML is not configured.
+func ProvideProvider(cfg *Config) (*Provider, error) {
+	if cfg == nil || cfg.RootURL == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return New(ctx, cfg)
+}
@@ -0,0 +1,343 @@
+package saml
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var (
+	// ErrInvalidResponse is returned for responses failing validation: bad
+	// signatures, wrong audience or destination, or expired assertions
+	ErrInvalidResponse = errors.New("saml: invalid response")
+	// ErrUnknownRequest is returned for responses to requests this SP did not
+	// send, already answered or expired
+	ErrUnknownRequest = errors.New("saml: response to an unknown request")
+	// ErrUnsolicited is returned for IdP-initiated responses unless
+	// AllowIDPInitiated is set
+	ErrUnsolicited = errors.New("saml: unsolicited response")
+)
+
+// Identity is a user authenticated by the IdP
+type Identity struct {
+	NameID       string              `json:"name_id"`
+	SessionIndex string              `json:"session_index,omitempty"` // For single logout
+	ID           string              `json:"id"`
+	Username     string              `json:"username"`
+	Email        string              `json:"email,omitempty"`
+	Name         string              `json:"name,omitempty"`
+	Groups       []string            `json:"groups,omitempty"`
+	Roles        []string            `json:"roles,omitempty"` // Mapped from Groups with RoleMapping
+	Attributes   map[string][]string `json:"attributes,omitempty"`
+}
+
+// Payload returns the token payload of an identity, for
+// jwt.TokenManager.GenerateAccessToken
+func (i *Identity) Payload() map[string]any {
+	return map[string]any{
+		"user_id":  i.ID,
+		"username": i.Username,
+		"email":    i.Email,
+		"name":     i.Name,
+		"roles":    i.Roles,
+		"provider": "saml",
+	}
+}
+
+// Provider is a SAML 2.0 service provider for SP-initiated single sign-on
+type Provider struct {
+	cfg      Config
+	sp       *saml.ServiceProvider
+	requests RequestStore
+	client   *http.Client
+}
+
+type Option func(*Provider)
+
+// WithRequestStore sets where AuthnRequest IDs are kept until answered,
+// defaults to a MemoryRequestStore. Share one across instances behind a load
+// balancer.
+func WithRequestStore(s RequestStore) Option {
+	return func(p *Provider) {
+		if s != nil {
+			p.requests = s
+		}
+	}
+}
+
+// WithHTTPClient sets the client fetching IdP metadata
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *Provider) {
+		if c != nil {
+			p.client = c
+		}
+	}
+}
+
+// New creates a Provider, loading the SP key pair and the IdP metadata
+func New(ctx context.Context, cfg *Config, opts ...Option) (*Provider, error) {
+	if cfg == nil {
+		return nil, errors.New("invalid SAML configuration")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	p := &Provider{cfg: cfg.withDefaults(), client: &http.Client{Timeout: 10 * time.Second}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.requests == nil {
+		p.requests = NewMemoryRequestStore()
+	}
+
+	cert, key, err := p.cfg.keyPair()
+	if err != nil {
+		return nil, err
+	}
+	idp, err := p.idpMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadataURL, _ := url.Parse(p.cfg.RootURL + "/metadata")
+	acsURL, _ := url.Parse(p.cfg.RootURL + "/acs")
+
+	p.sp = &saml.ServiceProvider{
+		EntityID:          p.cfg.EntityID,
+		Key:               key,
+		Certificate:       cert,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idp,
+		AllowIDPInitiated: p.cfg.AllowIDPInitiated,
+		AuthnNameIDFormat: saml.NameIDFormat(p.cfg.NameIDFormat),
+	}
+	if p.cfg.SignRequests {
+		p.sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	}
+
+	// The library's tolerances are process-wide, only ever widen them
+	if p.cfg.ClockSkew > saml.MaxClockSkew {
+		saml.MaxClockSkew = p.cfg.ClockSkew
+	}
+	if delay := 90*time.Second + p.cfg.ClockSkew; delay > saml.MaxIssueDelay {
+		saml.MaxIssueDelay = delay
+	}
+	return p, nil
+}
+
+// Name returns the provider name, used as the login source
+func (p *Provider) Name() string { return "saml" }
+
+// Metadata returns the SP metadata XML to register with the IdP
+func (p *Provider) Metadata() ([]byte, error) {
+	data, err := xml.MarshalIndent(p.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("saml: encode metadata: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// LoginURL creates an AuthnRequest and returns the IdP URL to redirect the
+// user to. relayState comes back with the response, typically the page to
+// return to.
+func (p *Provider) LoginURL(ctx context.Context, relayState string) (string, error) {
+	location := p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if location == "" {
+		return "", errors.New("saml: the IdP has no HTTP-Redirect SSO endpoint")
+	}
+	req, err := p.sp.MakeAuthenticationRequest(location, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", fmt.Errorf("saml: create request: %w", err)
+	}
+	if err := p.requests.Save(ctx, req.ID, p.cfg.RequestTTL); err != nil {
+		return "", err
+	}
+	u, err := req.Redirect(relayState, p.sp)
+	if err != nil {
+		return "", fmt.Errorf("saml: encode request: %w", err)
+	}
+	return u.String(), nil
+}
+
+// ParseResponse validates the SAMLResponse posted to the ACS and returns the
+// authenticated identity. Each request ID is accepted once.
+func (p *Provider) ParseResponse(r *http.Request) (*Identity, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	inResponseTo, err := responseInResponseTo(r.PostForm.Get("SAMLResponse"))
+	if err != nil {
+		return nil, err
+	}
+
+	var requestIDs []string
+	switch {
+	case inResponseTo != "":
+		ok, err := p.requests.Consume(r.Context(), inResponseTo)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrUnknownRequest
+		}
+		requestIDs = []string{inResponseTo}
+	case !p.cfg.AllowIDPInitiated:
+		return nil, ErrUnsolicited
+	}
+
+	assertion, err := p.sp.ParseResponse(r, requestIDs)
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, invalid.PrivateErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return p.identity(assertion), nil
+}
+
+// responseInResponseTo reads the InResponseTo of an encoded response, before
+// its signature is checked, to find the request it answers
+func responseInResponseTo(encoded string) (string, error) {
+	if encoded == "" {
+		return "", fmt.Errorf("%w: missing SAMLResponse", ErrInvalidResponse)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	var resp struct {
+		InResponseTo string `xml:"InResponseTo,attr"`
+	}
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return resp.InResponseTo, nil
+}
+
+// identity reads the subject and attributes of an assertion
+func (p *Provider) identity(assertion *saml.Assertion) *Identity {
+	attrs := make(map[string][]string)
+	for _, stmt := range assertion.AttributeStatements {
+		for _, attr := range stmt.Attributes {
+			values := make([]string, 0, len(attr.Values))
+			for _, v := range attr.Values {
+				values = append(values, v.Value)
+			}
+			attrs[attr.Name] = append(attrs[attr.Name], values...)
+			if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+				attrs[attr.FriendlyName] = append(attrs[attr.FriendlyName], values...)
+			}
+		}
+	}
+	first := func(name string) string {
+		if v := attrs[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	id := &Identity{Groups: attrs[p.cfg.Attributes.Groups]}
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		id.NameID = assertion.Subject.NameID.Value
+	}
+	for _, stmt := range assertion.AuthnStatements {
+		if stmt.SessionIndex != "" {
+			id.SessionIndex = stmt.SessionIndex
+			break
+		}
+	}
+
+	a := p.cfg.Attributes
+	id.ID = orDefault(first(a.ID), id.NameID)
+	id.Username = orDefault(first(a.Username), id.NameID)
+	id.Email = first(a.Email)
+	id.Name = first(a.Name)
+	if len(a.Extra) > 0 {
+		id.Attributes = make(map[string][]string, len(a.Extra))
+		for _, name := range a.Extra {
+			if values := attrs[name]; len(values) > 0 {
+				id.Attributes[name] = values
+			}
+		}
+	}
+	id.Roles = p.roles(id.Groups)
+	return id
+}
+
+// roles maps group values to roles through RoleMapping
+func (p *Provider) roles(groups []string) []string {
+	mapping := make(map[string][]string, len(p.cfg.RoleMapping))
+	for k, v := range p.cfg.RoleMapping {
+		mapping[strings.ToLower(k)] = v
+	}
+
+	roles := slices.Clone(p.cfg.DefaultRoles)
+	for _, g := range groups {
+		roles = append(roles, mapping[strings.ToLower(g)]...)
+	}
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// idpMetadata loads the IdP metadata from the configured source
+func (p *Provider) idpMetadata(ctx context.Context) (*saml.EntityDescriptor, error) {
+	var data []byte
+	switch {
+	case p.cfg.IDPMetadataURL != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.IDPMetadataURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("saml: idp metadata: %w", err)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("saml: fetch idp metadata: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("saml: fetch idp metadata: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, 4<<20)); err != nil {
+			return nil, fmt.Errorf("saml: fetch idp metadata: %w", err)
+		}
+	case p.cfg.IDPMetadataFile != "":
+		var err error
+		if data, err = os.ReadFile(p.cfg.IDPMetadataFile); err != nil {
+			return nil, fmt.Errorf("saml: read idp metadata: %w", err)
+		}
+	default:
+		data = []byte(p.cfg.IDPMetadataXML)
+	}
+	return parseMetadata(data)
+}
+
+// parseMetadata parses an EntityDescriptor, or the first IdP of an
+// EntitiesDescriptor as published by federations
+func parseMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	var entity saml.EntityDescriptor
+	if err := xml.Unmarshal(data, &entity); err == nil {
+		return &entity, nil
+	}
+	var entities saml.EntitiesDescriptor
+	if err := xml.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("saml: parse idp metadata: %w", err)
+	}
+	for i := range entities.EntityDescriptors {
+		if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, errors.New("saml: no IdP in metadata")
+}
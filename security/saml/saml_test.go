@@ -0,0 +1,99 @@
+package saml
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/crewjam/saml"
+)
+
+func TestInResponseTo(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(
+		`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="r1" InResponseTo="id-42"></samlp:Response>`))
+	id, err := responseInResponseTo(encoded)
+	if err != nil || id != "id-42" {
+		t.Fatalf("id = %q, err = %v", id, err)
+	}
+	if _, err := responseInResponseTo(""); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestRequestStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRequestStore()
+	_ = s.Save(ctx, "a", time.Minute)
+	_ = s.Save(ctx, "b", -time.Second)
+
+	if ok, _ := s.Consume(ctx, "a"); !ok {
+		t.Fatal("saved request not found")
+	}
+	if ok, _ := s.Consume(ctx, "a"); ok {
+		t.Fatal("request answered twice")
+	}
+	if ok, _ := s.Consume(ctx, "b"); ok {
+		t.Fatal("expired request accepted")
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/dashboard?tab=1":     "/dashboard?tab=1",
+		"https://evil.example": "",
+		"//evil.example/path":  "",
+		"/\\evil.example":      "",
+		"":                     "",
+	} {
+		if got := localPath(path); got != want {
+			t.Errorf("localPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	p := &Provider{cfg: Config{
+		Attributes:   Attributes{Extra: []string{"department"}},
+		RoleMapping:  map[string][]string{"App-Admins": {"admin"}},
+		DefaultRoles: []string{"viewer"},
+	}.withDefaults()}
+
+	assertion := &saml.Assertion{
+		Subject:         &saml.Subject{NameID: &saml.NameID{Value: "jdoe@example.com"}},
+		AuthnStatements: []saml.AuthnStatement{{SessionIndex: "s1"}},
+		AttributeStatements: []saml.AttributeStatement{{Attributes: []saml.Attribute{
+			{Name: "urn:oid:0.9.2342.19200300.100.1.3", FriendlyName: "mail", Values: []saml.AttributeValue{{Value: "jdoe@example.com"}}},
+			{Name: "displayName", Values: []saml.AttributeValue{{Value: "Jane Doe"}}},
+			{Name: "groups", Values: []saml.AttributeValue{{Value: "app-admins"}, {Value: "staff"}}},
+			{Name: "department", Values: []saml.AttributeValue{{Value: "Engineering"}}},
+		}}},
+	}
+
+	id := p.identity(assertion)
+	if id.ID != "jdoe@example.com" || id.Username != "jdoe@example.com" || id.SessionIndex != "s1" {
+		t.Fatalf("identity = %+v", id)
+	}
+	if id.Email != "jdoe@example.com" || id.Name != "Jane Doe" || id.Attributes["department"][0] != "Engineering" {
+		t.Fatalf("attributes = %+v", id)
+	}
+	if want := []string{"admin", "viewer"}; !slices.Equal(id.Roles, want) {
+		t.Fatalf("roles = %v, want %v", id.Roles, want)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	entities := `<EntitiesDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata">
+  <EntityDescriptor entityID="https://sp.example.com"><SPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol"/></EntityDescriptor>
+  <EntityDescriptor entityID="https://idp.example.com"><IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol"/></EntityDescriptor>
+</EntitiesDescriptor>`
+	entity, err := parseMetadata([]byte(entities))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.EntityID != "https://idp.example.com" {
+		t.Fatalf("entity = %q", entity.EntityID)
+	}
+}
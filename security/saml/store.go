@@ -0,0 +1,55 @@
+package saml
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestStore keeps the IDs of sent AuthnRequests until they are answered
+type RequestStore interface {
+	// Save records id for ttl
+	Save(ctx context.Context, id string, ttl time.Duration) error
+	// Consume removes id, reporting whether it was recorded and not expired
+	Consume(ctx context.Context, id string) (bool, error)
+}
+
+// MemoryRequestStore is an in-memory RequestStore for single instances
+type MemoryRequestStore struct {
+	mu    sync.Mutex
+	ids   map[string]time.Time // id to expiry
+	sweep time.Time
+}
+
+// NewMemoryRequestStore creates a MemoryRequestStore
+func NewMemoryRequestStore() *MemoryRequestStore {
+	return &MemoryRequestStore{ids: make(map[string]time.Time)}
+}
+
+// Save implements RequestStore
+func (s *MemoryRequestStore) Save(_ context.Context, id string, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweep) >= time.Minute {
+		s.sweep = now
+		for k, expiry := range s.ids {
+			if now.After(expiry) {
+				delete(s.ids, k)
+			}
+		}
+	}
+	s.ids[id] = now.Add(ttl)
+	return nil
+}
+
+// Consume implements RequestStore
+func (s *MemoryRequestStore) Consume(_ context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.ids[id]
+	delete(s.ids, id)
+	return ok && time.Now().Before(expiry), nil
+}
@@ -0,0 +1,44 @@
+// Package scim implements a SCIM 2.0 server (RFC 7643, RFC 7644) so identity
+// providers such as Entra ID, Okta or OneLogin can provision and deprovision
+// users and groups automatically.
+//
+// # Setup
+//
+// Implement UserStore, and GroupStore if groups are provisioned, over the
+// application's user service, then mount the handler:
+//
+//	server := scim.NewServer(userStore, groupStore, scim.Options{
+//	    BaseURL: "https://app.example.com/scim/v2",
+//	    Token:   os.Getenv("SCIM_TOKEN"),
+//	})
+//	router.Any("/scim/v2/*path", gin.WrapH(
+//	    http.StripPrefix("/scim/v2", server.Handler())))
+//
+// Configure the same URL and token in the IdP. MemoryStore implements both
+// stores for tests.
+//
+// # Endpoints
+//
+//	GET/POST                /Users, /Groups
+//	GET/PUT/PATCH/DELETE    /Users/{id}, /Groups/{id}
+//	GET                     /ServiceProviderConfig, /ResourceTypes
+//
+// Lists support filter, startIndex, count, sortBy and sortOrder; responses
+// support attributes and excludedAttributes on top-level attributes.
+//
+// # Filtering
+//
+// Filters are parsed into an Expr tree. Stores backed by a database can
+// translate it into a query; stores that cannot evaluate every expression
+// may call Match on candidate resources:
+//
+//	expr, _ := scim.ParseFilter(`userName eq "bjensen" and emails[type eq "work"]`)
+//
+// # PATCH
+//
+// PATCH operations are applied to the current resource, which is then saved
+// with ReplaceUser or ReplaceGroup, so stores only implement full
+// replacement. Paths of the forms attr, attr.sub, attr[filter] and
+// attr[filter].sub are supported, as well as the value-only operations and
+// "True"/"False" strings some IdPs send.
+package scim
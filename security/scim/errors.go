@@ -0,0 +1,71 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// ErrNotFound is returned by stores for unknown resource IDs
+	ErrNotFound = errors.New("scim: resource not found")
+	// ErrConflict is returned by stores when a unique attribute, such as
+	// userName, is already taken
+	ErrConflict = errors.New("scim: resource already exists")
+)
+
+// SCIM error types, sent as scimType
+const (
+	ErrTypeInvalidFilter = "invalidFilter"
+	ErrTypeInvalidSyntax = "invalidSyntax"
+	ErrTypeInvalidPath   = "invalidPath"
+	ErrTypeInvalidValue  = "invalidValue"
+	ErrTypeNoTarget      = "noTarget"
+	ErrTypeUniqueness    = "uniqueness"
+	ErrTypeMutability    = "mutability"
+)
+
+// Error is a SCIM error response
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+
+	status int
+}
+
+// newError creates an Error
+func newError(status int, scimType, format string, args ...any) *Error {
+	return &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   fmt.Sprintf(format, args...),
+		status:   status,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.ScimType != "" {
+		return fmt.Sprintf("scim: %s: %s", e.ScimType, e.Detail)
+	}
+	return "scim: " + e.Detail
+}
+
+// toError converts a store or handler error into an Error
+func toError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, ErrNotFound):
+		return newError(http.StatusNotFound, "", "%v", err)
+	case errors.Is(err, ErrConflict):
+		return newError(http.StatusConflict, ErrTypeUniqueness, "%v", err)
+	default:
+		// Store errors may carry internal details
+		return newError(http.StatusInternalServerError, "", "internal error")
+	}
+}
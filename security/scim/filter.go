@@ -0,0 +1,405 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Expr is a parsed filter expression. Stores backed by a database can walk
+// it to build their query, others can call Match on each resource.
+type Expr interface {
+	// Match evaluates the expression against a resource decoded from JSON
+	Match(resource map[string]any) bool
+}
+
+// Compare compares an attribute with a value: eq, ne, co, sw, ew, gt, ge, lt
+// or le. Strings compare case-insensitively.
+type Compare struct {
+	Attr  string // Attribute path, e.g. userName or name.familyName
+	Op    string
+	Value any // string, float64, bool or nil
+}
+
+// Present matches resources with a non-empty attribute
+type Present struct {
+	Attr string
+}
+
+// And matches when both expressions match
+type And struct{ Left, Right Expr }
+
+// Or matches when either expression matches
+type Or struct{ Left, Right Expr }
+
+// Not negates an expression
+type Not struct{ Expr Expr }
+
+// ValuePath matches when an element of a multi-valued attribute matches
+// Filter, e.g. emails[type eq "work"]
+type ValuePath struct {
+	Attr   string
+	Filter Expr
+}
+
+// ParseFilter parses a filter as defined in RFC 7644 section 3.4.2.2
+func ParseFilter(filter string) (Expr, error) {
+	p := &filterParser{tokens: tokenize(filter)}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, filterError("unexpected %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// MatchResource evaluates expr against a resource struct
+func MatchResource(expr Expr, resource any) bool {
+	m, err := toMap(resource)
+	return err == nil && expr.Match(m)
+}
+
+func filterError(format string, args ...any) *Error {
+	return newError(http.StatusBadRequest, ErrTypeInvalidFilter, format, args...)
+}
+
+// Match implements Expr
+func (c Compare) Match(r map[string]any) bool {
+	if c.Op == "ne" {
+		return !Compare{Attr: c.Attr, Op: "eq", Value: c.Value}.Match(r)
+	}
+	values := resolve(r, c.Attr)
+	if c.Value == nil {
+		return c.Op == "eq" && len(values) == 0
+	}
+	for _, v := range values {
+		if compare(v, c.Op, c.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match implements Expr
+func (p Present) Match(r map[string]any) bool {
+	for _, v := range resolve(r, p.Attr) {
+		if s, ok := v.(string); !ok || s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match implements Expr
+func (a And) Match(r map[string]any) bool { return a.Left.Match(r) && a.Right.Match(r) }
+
+// Match implements Expr
+func (o Or) Match(r map[string]any) bool { return o.Left.Match(r) || o.Right.Match(r) }
+
+// Match implements Expr
+func (n Not) Match(r map[string]any) bool { return !n.Expr.Match(r) }
+
+// Match implements Expr
+func (v ValuePath) Match(r map[string]any) bool {
+	for _, elem := range resolve(r, v.Attr) {
+		if m, ok := elem.(map[string]any); ok && v.Filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies a comparison operator
+func compare(v any, op string, want any) bool {
+	// Complex values without a sub-attribute compare their value
+	if m, ok := v.(map[string]any); ok {
+		v = m["value"]
+	}
+	switch want := want.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		s, want = strings.ToLower(s), strings.ToLower(want)
+		switch op {
+		case "eq":
+			return s == want
+		case "co":
+			return strings.Contains(s, want)
+		case "sw":
+			return strings.HasPrefix(s, want)
+		case "ew":
+			return strings.HasSuffix(s, want)
+		case "gt":
+			return s > want
+		case "ge":
+			return s >= want
+		case "lt":
+			return s < want
+		case "le":
+			return s <= want
+		}
+	case float64:
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return n == want
+		case "gt":
+			return n > want
+		case "ge":
+			return n >= want
+		case "lt":
+			return n < want
+		case "le":
+			return n <= want
+		}
+	case bool:
+		b, ok := v.(bool)
+		return ok && op == "eq" && b == want
+	}
+	return false
+}
+
+// resolve returns the values at an attribute path, flattening multi-valued
+// attributes. Names match case-insensitively and schema URN prefixes select
+// extensions.
+func resolve(r map[string]any, path string) []any {
+	current := []any{r}
+	schema, attr := splitURN(path)
+	if schema != "" && !isCoreSchema(schema) {
+		current = lookup(current, schema)
+	}
+	for _, name := range strings.Split(attr, ".") {
+		current = lookup(current, name)
+	}
+	return current
+}
+
+// lookup returns the name attribute of each map among values
+func lookup(values []any, name string) []any {
+	var out []any
+	for _, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		key, ok := findKey(m, name)
+		if !ok {
+			continue
+		}
+		switch child := m[key].(type) {
+		case nil:
+		case []any:
+			out = append(out, child...)
+		default:
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// findKey finds the key of m matching name case-insensitively
+func findKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return name, false
+}
+
+// splitURN splits a schema URN prefix from an attribute path
+func splitURN(path string) (schema, attr string) {
+	if !strings.HasPrefix(strings.ToLower(path), "urn:") {
+		return "", path
+	}
+	i := strings.LastIndex(path, ":")
+	return path[:i], path[i+1:]
+}
+
+func isCoreSchema(schema string) bool {
+	return strings.EqualFold(schema, SchemaUser) || strings.EqualFold(schema, SchemaGroup)
+}
+
+// toMap converts a resource to its JSON object form
+func toMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	return m, json.Unmarshal(data, &m)
+}
+
+// tokenize splits a filter into parentheses, brackets, string literals and words
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			tokens = append(tokens, s[i:min(j+1, len(s))])
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *filterParser) expect(t string) error {
+	if got := p.next(); got != t {
+		return filterError("expected %q, got %q", t, got)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (Expr, error) {
+	if !strings.EqualFold(p.peek(), "not") {
+		return p.parsePrimary()
+	}
+	p.pos++
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return Not{Expr: expr}, nil
+}
+
+func (p *filterParser) parsePrimary() (Expr, error) {
+	if p.peek() == "(" {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+	}
+
+	attr := p.next()
+	if attr == "" || strings.ContainsAny(attr[:1], "()[]\"") {
+		return nil, filterError("expected an attribute, got %q", attr)
+	}
+	if p.peek() == "[" {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return ValuePath{Attr: attr, Filter: inner}, nil
+	}
+
+	op := strings.ToLower(p.next())
+	switch op {
+	case "pr":
+		return Present{Attr: attr}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, filterError("unknown operator %q", op)
+	}
+	value, err := parseValue(p.next())
+	if err != nil {
+		return nil, err
+	}
+	return Compare{Attr: attr, Op: op, Value: value}, nil
+}
+
+// parseValue parses a JSON literal: a string, number, true, false or null
+func parseValue(token string) (any, error) {
+	if token == "" {
+		return nil, filterError("missing comparison value")
+	}
+	var v any
+	if err := json.Unmarshal([]byte(token), &v); err != nil {
+		return nil, filterError("invalid value %s", token)
+	}
+	switch v.(type) {
+	case nil, string, float64, bool:
+		return v, nil
+	}
+	return nil, filterError("invalid value %s", token)
+}
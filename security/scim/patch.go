@@ -0,0 +1,230 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// patchPath is a parsed PATCH path: attr, attr.sub, attr[filter] or
+// attr[filter].sub, optionally prefixed with a schema URN
+type patchPath struct {
+	schema string // Extension schema, empty for core attributes
+	attr   string
+	filter Expr
+	sub    string
+}
+
+// parsePatchPath parses the path of a PATCH operation
+func parsePatchPath(path string) (*patchPath, error) {
+	p := &patchPath{}
+	if open := strings.IndexByte(path, '['); open >= 0 {
+		end := strings.LastIndexByte(path, ']')
+		if end < open {
+			return nil, newError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path %q", path)
+		}
+		filter, err := ParseFilter(path[open+1 : end])
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path %q: %v", path, err)
+		}
+		p.filter = filter
+		p.sub = strings.TrimPrefix(path[end+1:], ".")
+		path = path[:open]
+	}
+
+	schema, attr := splitURN(path)
+	if schema != "" && !isCoreSchema(schema) {
+		p.schema = schema
+	}
+	if p.filter == nil {
+		attr, p.sub, _ = strings.Cut(attr, ".")
+	}
+	if attr == "" {
+		return nil, newError(http.StatusBadRequest, ErrTypeInvalidPath, "invalid path %q", path)
+	}
+	p.attr = attr
+	return p, nil
+}
+
+// applyPatch applies PATCH operations to a resource in its JSON object form
+func applyPatch(doc map[string]any, ops []PatchOperation) error {
+	for _, op := range ops {
+		var value any
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return newError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid value: %v", err)
+			}
+		}
+
+		kind := strings.ToLower(op.Op)
+		switch kind {
+		case "add", "replace", "remove":
+		default:
+			return newError(http.StatusBadRequest, ErrTypeInvalidSyntax, "unknown operation %q", op.Op)
+		}
+
+		if op.Path == "" {
+			if kind == "remove" {
+				return newError(http.StatusBadRequest, ErrTypeNoTarget, "remove requires a path")
+			}
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return newError(http.StatusBadRequest, ErrTypeInvalidValue, "%s without a path requires an object", kind)
+			}
+			// Keys may be paths themselves, as sent by Entra ID
+			for k, v := range obj {
+				if err := applyPath(doc, kind, k, v); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := applyPath(doc, kind, op.Path, value); err != nil {
+			return err
+		}
+	}
+	normalizeBool(doc, "active")
+	return nil
+}
+
+// applyPath applies one operation at a path
+func applyPath(doc map[string]any, kind, path string, value any) error {
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	target := doc
+	if p.schema != "" {
+		key, _ := findKey(doc, p.schema)
+		ext, ok := doc[key].(map[string]any)
+		if !ok {
+			if kind == "remove" {
+				return nil
+			}
+			ext = make(map[string]any)
+			doc[key] = ext
+		}
+		target = ext
+	}
+	key, _ := findKey(target, p.attr)
+
+	if p.filter != nil {
+		return applyFiltered(target, key, kind, p, value)
+	}
+	if p.sub != "" {
+		parent, ok := target[key].(map[string]any)
+		if !ok {
+			if kind == "remove" {
+				return nil
+			}
+			parent = make(map[string]any)
+			target[key] = parent
+		}
+		target, key = parent, p.sub
+		key, _ = findKey(target, key)
+	}
+
+	switch kind {
+	case "remove":
+		delete(target, key)
+	case "add":
+		if existing, ok := target[key].([]any); ok {
+			target[key] = appendUnique(existing, value)
+			return nil
+		}
+		if obj, ok := value.(map[string]any); ok {
+			if existing, ok := target[key].(map[string]any); ok {
+				for k, v := range obj {
+					existing[k] = v
+				}
+				return nil
+			}
+		}
+		target[key] = value
+	case "replace":
+		target[key] = value
+	}
+	return nil
+}
+
+// applyFiltered applies an operation to the elements of a multi-valued
+// attribute matching the path filter
+func applyFiltered(target map[string]any, key, kind string, p *patchPath, value any) error {
+	elems, _ := target[key].([]any)
+	matched := false
+	kept := elems[:0:0]
+	for _, elem := range elems {
+		m, ok := elem.(map[string]any)
+		if !ok || !p.filter.Match(m) {
+			kept = append(kept, elem)
+			continue
+		}
+		matched = true
+		switch {
+		case kind == "remove" && p.sub == "":
+			continue
+		case kind == "remove":
+			subKey, _ := findKey(m, p.sub)
+			delete(m, subKey)
+		case p.sub != "":
+			subKey, _ := findKey(m, p.sub)
+			m[subKey] = value
+		default:
+			if obj, ok := value.(map[string]any); ok {
+				for k, v := range obj {
+					m[k] = v
+				}
+			}
+		}
+		kept = append(kept, m)
+	}
+	if !matched && kind != "remove" {
+		return newError(http.StatusBadRequest, ErrTypeNoTarget, "no %s value matches the path filter", p.attr)
+	}
+	target[key] = kept
+	return nil
+}
+
+// appendUnique appends values to a multi-valued attribute, skipping
+// elements with a value already present
+func appendUnique(existing []any, value any) []any {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		if m, ok := e.(map[string]any); ok {
+			if v, ok := m["value"].(string); ok {
+				seen[v] = true
+			}
+		}
+	}
+	for _, v := range values {
+		if m, ok := v.(map[string]any); ok {
+			if s, ok := m["value"].(string); ok {
+				if seen[s] {
+					continue
+				}
+				seen[s] = true
+			}
+		}
+		existing = append(existing, v)
+	}
+	return existing
+}
+
+// normalizeBool turns a boolean sent as a string, "True" or "False" as some
+// IdPs do, into a bool
+func normalizeBool(doc map[string]any, name string) {
+	key, ok := findKey(doc, name)
+	if !ok {
+		return
+	}
+	if s, ok := doc[key].(string); ok {
+		if b, err := strconv.ParseBool(s); err == nil {
+			doc[key] = b
+		}
+	}
+}
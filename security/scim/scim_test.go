@@ -0,0 +1,194 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	user := map[string]any{
+		"userName": "BJensen",
+		"active":   true,
+		"name":     map[string]any{"familyName": "Jensen"},
+		"emails": []any{
+			map[string]any{"value": "bjensen@example.com", "type": "work"},
+			map[string]any{"value": "babs@home.example", "type": "home"},
+		},
+		SchemaEnterpriseUser: map[string]any{"department": "Sales"},
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "bjensen"`, true},
+		{`userName ne "bjensen"`, false},
+		{`USERNAME sw "bj"`, true},
+		{`name.familyName co "ens"`, true},
+		{`active eq true`, true},
+		{`title pr`, false},
+		{`emails pr and not (active eq false)`, true},
+		{`emails[type eq "work" and value ew "example.com"]`, true},
+		{`emails[type eq "other"] or userName eq "x"`, false},
+		{`emails.value eq "babs@home.example"`, true},
+		{SchemaEnterpriseUser + `:department eq "sales"`, true},
+		{SchemaUser + `:userName eq "bjensen"`, true},
+	}
+	for _, tt := range tests {
+		expr, err := ParseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", tt.filter, err)
+		}
+		if got := expr.Match(user); got != tt.want {
+			t.Errorf("%q matched %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{``, `userName`, `userName eq`, `userName xx "a"`, `(userName eq "a"`, `userName eq bjensen`} {
+		if _, err := ParseFilter(bad); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := map[string]any{
+		"displayName": "Admins",
+		"members": []any{
+			map[string]any{"value": "u1"},
+			map[string]any{"value": "u2"},
+		},
+	}
+	ops := []PatchOperation{
+		{Op: "Add", Path: "members", Value: json.RawMessage(`[{"value":"u2"},{"value":"u3"}]`)},
+		{Op: "Remove", Path: `members[value eq "u1"]`},
+		{Op: "Replace", Value: json.RawMessage(`{"displayName":"Operators"}`)},
+	}
+	if err := applyPatch(doc, ops); err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, m := range doc["members"].([]any) {
+		values = append(values, m.(map[string]any)["value"].(string))
+	}
+	if got := strings.Join(values, ","); got != "u2,u3" {
+		t.Errorf("members = %s, want u2,u3", got)
+	}
+	if doc["displayName"] != "Operators" {
+		t.Errorf("displayName = %v", doc["displayName"])
+	}
+
+	user := map[string]any{
+		"active": true,
+		"emails": []any{map[string]any{"value": "a@example.com", "type": "work"}},
+	}
+	ops = []PatchOperation{
+		{Op: "replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"b@example.com"`)},
+		{Op: "add", Path: "name.givenName", Value: json.RawMessage(`"Barbara"`)},
+		{Op: "add", Path: SchemaEnterpriseUser + ":department", Value: json.RawMessage(`"Sales"`)},
+	}
+	if err := applyPatch(user, ops); err != nil {
+		t.Fatal(err)
+	}
+	if user["active"] != false {
+		t.Errorf("active = %v", user["active"])
+	}
+	if v := user["emails"].([]any)[0].(map[string]any)["value"]; v != "b@example.com" {
+		t.Errorf("email = %v", v)
+	}
+	if v := user["name"].(map[string]any)["givenName"]; v != "Barbara" {
+		t.Errorf("givenName = %v", v)
+	}
+	if v := user[SchemaEnterpriseUser].(map[string]any)["department"]; v != "Sales" {
+		t.Errorf("department = %v", v)
+	}
+
+	err := applyPatch(user, []PatchOperation{{Op: "replace", Path: `emails[type eq "home"].value`, Value: json.RawMessage(`"x"`)}})
+	if e, ok := err.(*Error); !ok || e.ScimType != ErrTypeNoTarget {
+		t.Errorf("replace without target: %v", err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	store := NewMemoryStore()
+	srv := httptest.NewServer(http.StripPrefix("/scim/v2", NewServer(store, store, Options{
+		BaseURL: "https://app.example.com/scim/v2",
+		Token:   "secret",
+	}).Handler()))
+	defer srv.Close()
+
+	do := func(method, path, token, body string, out any) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+"/scim/v2"+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("Content-Type", contentType)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if out != nil {
+			if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return res.StatusCode
+	}
+
+	if status := do("GET", "/Users", "wrong", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("bad token: status %d", status)
+	}
+
+	var user User
+	status := do("POST", "/Users", "secret", `{"schemas":["`+SchemaUser+`"],"userName":"bjensen","emails":[{"value":"b@example.com","primary":true}]}`, &user)
+	if status != http.StatusCreated || user.ID == "" || !user.Active {
+		t.Fatalf("create: status %d, user %+v", status, user)
+	}
+	if user.Meta == nil || user.Meta.Location != "https://app.example.com/scim/v2/Users/"+user.ID {
+		t.Errorf("meta = %+v", user.Meta)
+	}
+
+	var scimErr Error
+	if status := do("POST", "/Users", "secret", `{"userName":"BJENSEN"}`, &scimErr); status != http.StatusConflict || scimErr.ScimType != ErrTypeUniqueness {
+		t.Errorf("duplicate: status %d, error %+v", status, scimErr)
+	}
+
+	var group Group
+	if status := do("POST", "/Groups", "secret", `{"displayName":"Admins"}`, &group); status != http.StatusCreated {
+		t.Fatalf("create group: status %d", status)
+	}
+	patch := `{"schemas":["` + SchemaPatchOp + `"],"Operations":[{"op":"add","path":"members","value":[{"value":"` + user.ID + `"}]}]}`
+	if status := do("PATCH", "/Groups/"+group.ID, "secret", patch, &group); status != http.StatusOK || len(group.Members) != 1 {
+		t.Fatalf("patch group: status %d, group %+v", status, group)
+	}
+
+	var list ListResponse
+	if status := do("GET", `/Users?filter=userName+eq+"bjensen"&attributes=userName,groups`, "secret", "", &list); status != http.StatusOK || list.TotalResults != 1 {
+		t.Fatalf("list: status %d, %+v", status, list)
+	}
+	listed := list.Resources[0].(map[string]any)
+	if _, ok := listed["emails"]; ok {
+		t.Error("emails not excluded by attributes")
+	}
+	if groups, _ := listed["groups"].([]any); len(groups) != 1 {
+		t.Errorf("groups = %v", listed["groups"])
+	}
+
+	patch = `{"schemas":["` + SchemaPatchOp + `"],"Operations":[{"op":"Replace","value":{"active":"False"}}]}`
+	if status := do("PATCH", "/Users/"+user.ID, "secret", patch, &user); status != http.StatusOK || user.Active {
+		t.Errorf("deactivate: status %d, active %v", status, user.Active)
+	}
+
+	if status := do("DELETE", "/Users/"+user.ID, "secret", "", nil); status != http.StatusNoContent {
+		t.Errorf("delete: status %d", status)
+	}
+	if status := do("GET", "/Users/"+user.ID, "secret", "", &scimErr); status != http.StatusNotFound {
+		t.Errorf("get deleted: status %d", status)
+	}
+}
@@ -0,0 +1,456 @@
+package scim
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Options configures a Server
+type Options struct {
+	// BaseURL is the public URL the server is mounted at, used for
+	// meta.location, e.g. https://app.example.com/scim/v2
+	BaseURL string
+	// Token is the bearer token configured in the IdP
+	Token string
+	// Authenticate replaces Token for custom schemes. Without either, every
+	// request is refused.
+	Authenticate func(r *http.Request) bool
+	// MaxResults caps the page size of list requests, default 200
+	MaxResults int
+}
+
+// Server serves the SCIM 2.0 protocol over user and group stores
+type Server struct {
+	users  UserStore
+	groups GroupStore
+	opts   Options
+}
+
+// NewServer creates a Server. groups may be nil to only provision users.
+func NewServer(users UserStore, groups GroupStore, opts Options) *Server {
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 200
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &Server{users: users, groups: groups, opts: opts}
+}
+
+// maxBodySize limits request bodies
+const maxBodySize = 1 << 20
+
+// resource is implemented by *User and *Group
+type resource interface {
+	id() *string
+	meta() **Meta
+	// normalize sets schemas and checks required attributes
+	normalize() error
+}
+
+func (u *User) id() *string   { return &u.ID }
+func (u *User) meta() **Meta  { return &u.Meta }
+func (g *Group) id() *string  { return &g.ID }
+func (g *Group) meta() **Meta { return &g.Meta }
+
+func (u *User) normalize() error {
+	if u.UserName == "" {
+		return newError(http.StatusBadRequest, ErrTypeInvalidValue, "userName is required")
+	}
+	u.Schemas = []string{SchemaUser}
+	if u.Enterprise != nil {
+		u.Schemas = append(u.Schemas, SchemaEnterpriseUser)
+	}
+	return nil
+}
+
+func (g *Group) normalize() error {
+	if g.DisplayName == "" {
+		return newError(http.StatusBadRequest, ErrTypeInvalidValue, "displayName is required")
+	}
+	g.Schemas = []string{SchemaGroup}
+	return nil
+}
+
+// endpoint serves one resource type
+type endpoint[T any, P interface {
+	*T
+	resource
+}] struct {
+	srv     *Server
+	path    string // Users or Groups
+	typ     string
+	init    func() P // New resource with defaults
+	create  func(context.Context, P) (P, error)
+	get     func(context.Context, string) (P, error)
+	list    func(context.Context, *Query) ([]P, int, error)
+	replace func(context.Context, P) (P, error)
+	delete  func(context.Context, string) error
+}
+
+// Handler returns the HTTP handler. Mount it at BaseURL with the prefix
+// stripped, so that it sees /Users and /Groups.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	register(mux, &endpoint[User, *User]{
+		srv:     s,
+		path:    "Users",
+		typ:     resourceTypeUser,
+		init:    func() *User { return &User{Active: true} },
+		create:  s.users.CreateUser,
+		get:     s.users.GetUser,
+		list:    s.users.ListUsers,
+		replace: s.users.ReplaceUser,
+		delete:  s.users.DeleteUser,
+	})
+	if s.groups != nil {
+		register(mux, &endpoint[Group, *Group]{
+			srv:     s,
+			path:    "Groups",
+			typ:     resourceTypeGroup,
+			init:    func() *Group { return &Group{} },
+			create:  s.groups.CreateGroup,
+			get:     s.groups.GetGroup,
+			list:    s.groups.ListGroups,
+			replace: s.groups.ReplaceGroup,
+			delete:  s.groups.DeleteGroup,
+		})
+	}
+	mux.HandleFunc("GET /ServiceProviderConfig", s.serviceProviderConfig)
+	mux.HandleFunc("GET /ResourceTypes", s.resourceTypes)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, newError(http.StatusNotFound, "", "no endpoint %s %s", r.Method, r.URL.Path))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authenticate(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			writeError(w, newError(http.StatusUnauthorized, "", "authentication required"))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func register[T any, P interface {
+	*T
+	resource
+}](mux *http.ServeMux, e *endpoint[T, P]) {
+	mux.HandleFunc("GET /"+e.path, e.handleList)
+	mux.HandleFunc("POST /"+e.path, e.handleCreate)
+	mux.HandleFunc("GET /"+e.path+"/{id}", e.handleGet)
+	mux.HandleFunc("PUT /"+e.path+"/{id}", e.handleReplace)
+	mux.HandleFunc("PATCH /"+e.path+"/{id}", e.handlePatch)
+	mux.HandleFunc("DELETE /"+e.path+"/{id}", e.handleDelete)
+}
+
+func (s *Server) authenticate(r *http.Request) bool {
+	if s.opts.Authenticate != nil {
+		return s.opts.Authenticate(r)
+	}
+	if s.opts.Token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+func (e *endpoint[T, P]) handleList(w http.ResponseWriter, r *http.Request) {
+	q, err := e.srv.parseQuery(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	items, total, err := e.list(r.Context(), q)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	list := &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   q.StartIndex,
+		ItemsPerPage: len(items),
+		Resources:    make([]any, 0, len(items)),
+	}
+	for _, item := range items {
+		doc, err := e.present(r, item)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		list.Resources = append(list.Resources, doc)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (e *endpoint[T, P]) handleCreate(w http.ResponseWriter, r *http.Request) {
+	item := e.init()
+	if err := decode(w, r, item); err != nil {
+		writeError(w, err)
+		return
+	}
+	*item.id() = ""
+	if err := item.normalize(); err != nil {
+		writeError(w, err)
+		return
+	}
+	created, err := e.create(r.Context(), item)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	e.respond(w, r, http.StatusCreated, created)
+}
+
+func (e *endpoint[T, P]) handleGet(w http.ResponseWriter, r *http.Request) {
+	item, err := e.get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	e.respond(w, r, http.StatusOK, item)
+}
+
+func (e *endpoint[T, P]) handleReplace(w http.ResponseWriter, r *http.Request) {
+	item := e.init()
+	if err := decode(w, r, item); err != nil {
+		writeError(w, err)
+		return
+	}
+	e.save(w, r, item)
+}
+
+func (e *endpoint[T, P]) handlePatch(w http.ResponseWriter, r *http.Request) {
+	var req PatchRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	current, err := e.get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	doc, err := toMap(current)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := applyPatch(doc, req.Operations); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	item := P(new(T))
+	if err := json.Unmarshal(data, item); err != nil {
+		writeError(w, newError(http.StatusBadRequest, ErrTypeInvalidValue, "patched resource is invalid: %v", err))
+		return
+	}
+	e.save(w, r, item)
+}
+
+// save replaces the resource at the request path with item
+func (e *endpoint[T, P]) save(w http.ResponseWriter, r *http.Request, item P) {
+	// id and meta are read only
+	*item.id() = r.PathValue("id")
+	*item.meta() = nil
+	if err := item.normalize(); err != nil {
+		writeError(w, err)
+		return
+	}
+	replaced, err := e.replace(r.Context(), item)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	e.respond(w, r, http.StatusOK, replaced)
+}
+
+func (e *endpoint[T, P]) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := e.delete(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *endpoint[T, P]) respond(w http.ResponseWriter, r *http.Request, status int, item P) {
+	doc, err := e.present(r, item)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if status == http.StatusCreated {
+		if meta := *item.meta(); meta != nil && meta.Location != "" {
+			w.Header().Set("Location", meta.Location)
+		}
+	}
+	writeJSON(w, status, doc)
+}
+
+// present sets schemas and meta on a resource and applies the attributes
+// and excludedAttributes parameters
+func (e *endpoint[T, P]) present(r *http.Request, item P) (map[string]any, error) {
+	_ = item.normalize()
+	meta := *item.meta()
+	if meta == nil {
+		meta = &Meta{}
+	} else {
+		copied := *meta
+		meta = &copied
+	}
+	meta.ResourceType = e.typ
+	if meta.Location == "" && e.srv.opts.BaseURL != "" {
+		meta.Location = e.srv.opts.BaseURL + "/" + e.path + "/" + *item.id()
+	}
+	*item.meta() = meta
+
+	doc, err := toMap(item)
+	if err != nil {
+		return nil, err
+	}
+	project(doc, r.URL.Query().Get("attributes"), r.URL.Query().Get("excludedAttributes"))
+	return doc, nil
+}
+
+// project keeps only the requested top-level attributes, or drops the
+// excluded ones. id and schemas are always returned.
+func project(doc map[string]any, attributes, excluded string) {
+	top := func(list string) map[string]bool {
+		set := make(map[string]bool)
+		for _, a := range strings.Split(list, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				_, a = splitURN(a)
+				name, _, _ := strings.Cut(a, ".")
+				set[strings.ToLower(name)] = true
+			}
+		}
+		return set
+	}
+	switch {
+	case attributes != "":
+		keep := top(attributes)
+		for k := range doc {
+			if k != "id" && k != "schemas" && !keep[strings.ToLower(k)] {
+				delete(doc, k)
+			}
+		}
+	case excluded != "":
+		drop := top(excluded)
+		for k := range doc {
+			if k != "id" && k != "schemas" && drop[strings.ToLower(k)] {
+				delete(doc, k)
+			}
+		}
+	}
+}
+
+// parseQuery reads the list parameters of a request
+func (s *Server) parseQuery(r *http.Request) (*Query, error) {
+	params := r.URL.Query()
+	q := &Query{
+		StartIndex: 1,
+		Count:      s.opts.MaxResults,
+		SortBy:     params.Get("sortBy"),
+		SortOrder:  params.Get("sortOrder"),
+	}
+	if f := params.Get("filter"); f != "" {
+		expr, err := ParseFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		q.Filter = expr
+	}
+	if v := params.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid startIndex %q", v)
+		}
+		q.StartIndex = max(n, 1)
+	}
+	if v := params.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, ErrTypeInvalidValue, "invalid count %q", v)
+		}
+		q.Count = min(max(n, 0), s.opts.MaxResults)
+	}
+	return q, nil
+}
+
+func (s *Server) serviceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{SchemaServiceProvider},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": s.opts.MaxResults},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": true},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with a bearer token",
+		}},
+	})
+}
+
+func (s *Server) resourceTypes(w http.ResponseWriter, _ *http.Request) {
+	types := []any{map[string]any{
+		"schemas":          []string{SchemaResourceType},
+		"id":               resourceTypeUser,
+		"name":             resourceTypeUser,
+		"endpoint":         "/Users",
+		"schema":           SchemaUser,
+		"schemaExtensions": []map[string]any{{"schema": SchemaEnterpriseUser, "required": false}},
+	}}
+	if s.groups != nil {
+		types = append(types, map[string]any{
+			"schemas":  []string{SchemaResourceType},
+			"id":       resourceTypeGroup,
+			"name":     resourceTypeGroup,
+			"endpoint": "/Groups",
+			"schema":   SchemaGroup,
+		})
+	}
+	writeJSON(w, http.StatusOK, &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(types),
+		StartIndex:   1,
+		ItemsPerPage: len(types),
+		Resources:    types,
+	})
+}
+
+// decode reads a JSON request body into v
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return newError(http.StatusRequestEntityTooLarge, "", "request body too large")
+		}
+		return newError(http.StatusBadRequest, ErrTypeInvalidSyntax, "invalid JSON: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e := toError(err)
+	writeJSON(w, e.status, e)
+}
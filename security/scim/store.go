@@ -0,0 +1,326 @@
+package scim
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query selects a page of resources
+type Query struct {
+	Filter     Expr   // nil matches everything
+	StartIndex int    // 1-based index of the first result
+	Count      int    // Maximum results, 0 returns only the total
+	SortBy     string // Attribute path, empty for store order
+	SortOrder  string // ascending or descending
+}
+
+// UserStore maps SCIM users onto the application's user store. Create and
+// Replace return the stored user with its ID and meta set; Get, Replace and
+// Delete return ErrNotFound for unknown IDs, Create and Replace ErrConflict
+// for a taken userName.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *User) (*User, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	// ListUsers returns the page of users selected by q and the total number
+	// of matches
+	ListUsers(ctx context.Context, q *Query) ([]*User, int, error)
+	ReplaceUser(ctx context.Context, user *User) (*User, error)
+	// DeleteUser deprovisions a user; stores may deactivate instead of delete
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// GroupStore maps SCIM groups onto the application's groups or roles, with
+// the same error contract as UserStore
+type GroupStore interface {
+	CreateGroup(ctx context.Context, group *Group) (*Group, error)
+	GetGroup(ctx context.Context, id string) (*Group, error)
+	ListGroups(ctx context.Context, q *Query) ([]*Group, int, error)
+	ReplaceGroup(ctx context.Context, group *Group) (*Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// MemoryStore is an in-memory UserStore and GroupStore for tests and
+// prototypes
+type MemoryStore struct {
+	mu     sync.RWMutex
+	users  map[string]*User
+	groups map[string]*Group
+	order  []string // Creation order of IDs
+}
+
+// NewMemoryStore creates a MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:  make(map[string]*User),
+		groups: make(map[string]*Group),
+	}
+}
+
+// CreateUser implements UserStore
+func (s *MemoryStore) CreateUser(_ context.Context, user *User) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userNameTaken(user.UserName, "") {
+		return nil, fmt.Errorf("%w: userName %q", ErrConflict, user.UserName)
+	}
+	u := clone(user)
+	u.ID = newID()
+	u.Groups = nil
+	now := time.Now().UTC()
+	u.Meta = &Meta{ResourceType: resourceTypeUser, Created: now, LastModified: now}
+	s.users[u.ID] = u
+	s.order = append(s.order, u.ID)
+	return s.userView(u), nil
+}
+
+// GetUser implements UserStore
+func (s *MemoryStore) GetUser(_ context.Context, id string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.userView(u), nil
+}
+
+// ListUsers implements UserStore
+func (s *MemoryStore) ListUsers(_ context.Context, q *Query) ([]*User, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var all []*User
+	for _, id := range s.order {
+		if u, ok := s.users[id]; ok {
+			all = append(all, s.userView(u))
+		}
+	}
+	return page(all, q)
+}
+
+// ReplaceUser implements UserStore
+func (s *MemoryStore) ReplaceUser(_ context.Context, user *User) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users[user.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.userNameTaken(user.UserName, user.ID) {
+		return nil, fmt.Errorf("%w: userName %q", ErrConflict, user.UserName)
+	}
+	u := clone(user)
+	u.Groups = nil
+	u.Meta = &Meta{ResourceType: resourceTypeUser, Created: old.Meta.Created, LastModified: time.Now().UTC()}
+	s.users[u.ID] = u
+	return s.userView(u), nil
+}
+
+// DeleteUser implements UserStore
+func (s *MemoryStore) DeleteUser(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.users, id)
+	// Drop the user from its groups
+	for _, g := range s.groups {
+		g.Members = slices.DeleteFunc(g.Members, func(m Member) bool { return m.Value == id })
+	}
+	return nil
+}
+
+// CreateGroup implements GroupStore
+func (s *MemoryStore) CreateGroup(_ context.Context, group *Group) (*Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.displayNameTaken(group.DisplayName, "") {
+		return nil, fmt.Errorf("%w: displayName %q", ErrConflict, group.DisplayName)
+	}
+	g := clone(group)
+	g.ID = newID()
+	now := time.Now().UTC()
+	g.Meta = &Meta{ResourceType: resourceTypeGroup, Created: now, LastModified: now}
+	s.groups[g.ID] = g
+	s.order = append(s.order, g.ID)
+	return clone(g), nil
+}
+
+// GetGroup implements GroupStore
+func (s *MemoryStore) GetGroup(_ context.Context, id string) (*Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(g), nil
+}
+
+// ListGroups implements GroupStore
+func (s *MemoryStore) ListGroups(_ context.Context, q *Query) ([]*Group, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var all []*Group
+	for _, id := range s.order {
+		if g, ok := s.groups[id]; ok {
+			all = append(all, clone(g))
+		}
+	}
+	return page(all, q)
+}
+
+// ReplaceGroup implements GroupStore
+func (s *MemoryStore) ReplaceGroup(_ context.Context, group *Group) (*Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.groups[group.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.displayNameTaken(group.DisplayName, group.ID) {
+		return nil, fmt.Errorf("%w: displayName %q", ErrConflict, group.DisplayName)
+	}
+	g := clone(group)
+	g.Meta = &Meta{ResourceType: resourceTypeGroup, Created: old.Meta.Created, LastModified: time.Now().UTC()}
+	s.groups[g.ID] = g
+	return clone(g), nil
+}
+
+// DeleteGroup implements GroupStore
+func (s *MemoryStore) DeleteGroup(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.groups, id)
+	return nil
+}
+
+func (s *MemoryStore) userNameTaken(name, exceptID string) bool {
+	for id, u := range s.users {
+		if id != exceptID && strings.EqualFold(u.UserName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) displayNameTaken(name, exceptID string) bool {
+	for id, g := range s.groups {
+		if id != exceptID && strings.EqualFold(g.DisplayName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// userView copies a user, deriving its groups from group members
+func (s *MemoryStore) userView(u *User) *User {
+	out := clone(u)
+	for _, id := range s.order {
+		g, ok := s.groups[id]
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(g.Members, func(m Member) bool { return m.Value == u.ID }) {
+			out.Groups = append(out.Groups, MultiValue{Value: g.ID, Display: g.DisplayName})
+		}
+	}
+	return out
+}
+
+// page filters, sorts and slices resources according to q
+func page[T any](all []*T, q *Query) ([]*T, int, error) {
+	type entry struct {
+		item *T
+		doc  map[string]any
+	}
+	var matched []entry
+	for _, item := range all {
+		doc, err := toMap(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		if q.Filter == nil || q.Filter.Match(doc) {
+			matched = append(matched, entry{item, doc})
+		}
+	}
+
+	if q.SortBy != "" {
+		desc := strings.EqualFold(q.SortOrder, "descending")
+		slices.SortStableFunc(matched, func(a, b entry) int {
+			c := cmp.Compare(sortKey(a.doc, q.SortBy), sortKey(b.doc, q.SortBy))
+			if desc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	total := len(matched)
+	start := max(q.StartIndex, 1) - 1
+	if start > total {
+		start = total
+	}
+	end := min(start+max(q.Count, 0), total)
+	items := make([]*T, 0, end-start)
+	for _, e := range matched[start:end] {
+		items = append(items, e.item)
+	}
+	return items, total, nil
+}
+
+// sortKey returns the lowercased first value at path
+func sortKey(doc map[string]any, path string) string {
+	values := resolve(doc, path)
+	if len(values) == 0 {
+		return ""
+	}
+	v := values[0]
+	if m, ok := v.(map[string]any); ok {
+		v = m["value"]
+	}
+	if s, ok := v.(string); ok {
+		return strings.ToLower(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// clone deep copies a resource
+func clone[T any](v *T) *T {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	out := new(T)
+	if err := json.Unmarshal(data, out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"time"
+
+	"github.com/ncobase/ncore/config"
+)
+
+// Default session settings
+const (
+	DefaultTTL           = 7 * 24 * time.Hour
+	DefaultTouchInterval = time.Minute
+	DefaultDeviceTTL     = 180 * 24 * time.Hour
+)
+
+// Config holds the session settings
+type Config struct {
+	// TTL is the absolute lifetime of a session, defaults to 7 days
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+	// IdleTimeout expires sessions unused for this long, 0 disables
+	IdleTimeout time.Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	// TouchInterval is the least time between last seen updates, so busy
+	// sessions do not write on every request. Defaults to 1m.
+	TouchInterval time.Duration `json:"touch_interval" yaml:"touch_interval"`
+	// MaxSessions caps the sessions of a user, the least recently seen are
+	// revoked beyond it. 0 is unlimited.
+	MaxSessions int `json:"max_sessions" yaml:"max_sessions"`
+	// DeviceTTL is how long a device is remembered after its last login,
+	// logins from other devices publish EventNewDevice. Defaults to 180 days.
+	DeviceTTL time.Duration `json:"device_ttl" yaml:"device_ttl"`
+}
+
+// FromConfig converts the auth.session configuration, nil when it is nil
+func FromConfig(c *config.Session) *Config {
+	if c == nil {
+		return nil
+	}
+	return &Config{
+		TTL:           c.TTL,
+		IdleTimeout:   c.IdleTimeout,
+		TouchInterval: c.TouchInterval,
+		MaxSessions:   c.MaxSessions,
+		DeviceTTL:     c.DeviceTTL,
+	}
+}
+
+func (c *Config) withDefaults() Config {
+	var cfg Config
+	if c != nil {
+		cfg = *c
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.TouchInterval <= 0 {
+		cfg.TouchInterval = DefaultTouchInterval
+	}
+	if cfg.DeviceTTL <= 0 {
+		cfg.DeviceTTL = DefaultDeviceTTL
+	}
+	return cfg
+}
@@ -0,0 +1,37 @@
+// Package session tracks the active sessions of users per device, with the
+// device, IP and last activity of each, so users can review where they are
+// logged in and sign out other devices.
+//
+// # Usage
+//
+//	sessions := session.New(session.FromConfig(cfg.Auth.Session), session.NewRedisStore(rdb, "session"),
+//	    session.WithPublisher(session.PublisherFunc(em.PublishEvent)))
+//
+//	// On login, the session ID becomes the token jti
+//	s, err := sessions.Create(ctx, user.ID, session.ClientFromContext(ctx), nil)
+//	access, _ := tokenManager.GenerateAccessToken(s.ID, payload)
+//
+//	// On each authenticated request
+//	if _, err := sessions.Touch(ctx, jwt.GetTokenID(claims), session.ClientFromContext(ctx)); err != nil {
+//	    // revoked or expired, refuse the token
+//	}
+//
+//	// Device management
+//	list, _ := sessions.ListFor(ctx, userID, currentID)
+//	_ = sessions.Revoke(ctx, userID, sessionID)
+//	_, _ = sessions.RevokeOthers(ctx, userID, currentID)
+//
+// # Devices
+//
+// A device is identified by Client.DeviceID when the client keeps one,
+// otherwise by its browser and OS. A login from a device the user has not
+// used within DeviceTTL publishes EventNewDevice, which the notification
+// subsystem can turn into a "new sign-in" message. Revocations publish
+// EventRevoked with the reason.
+//
+// # Expiry
+//
+// Sessions end after TTL, or after IdleTimeout without a Touch. Touch writes
+// at most once per TouchInterval, or when the IP changes. MaxSessions revokes
+// the least recently seen sessions when a user logs in once more.
+package session
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/data/kv"
+)
+
+// KVStore is a Store on an embedded kv store, for single-binary deployments
+// that keep sessions across restarts without Redis
+type KVStore struct {
+	db     *kv.DB
+	prefix string
+}
+
+// NewKVStore creates a KVStore, keys are prefixed with prefix
+func NewKVStore(db *kv.DB, prefix string) *KVStore {
+	if prefix == "" {
+		prefix = "session"
+	}
+	return &KVStore{db: db, prefix: prefix}
+}
+
+func (k *KVStore) sessionKey(id string) string      { return k.prefix + ":s:" + id }
+func (k *KVStore) userKey(userID, id string) string { return k.userPrefix(userID) + id }
+func (k *KVStore) userPrefix(userID string) string  { return k.prefix + ":u:" + userID + ":" }
+func (k *KVStore) deviceKey(userID, deviceID string) string {
+	return k.prefix + ":d:" + userID + ":" + deviceID
+}
+
+// Save implements Store
+func (k *KVStore) Save(ctx context.Context, s *Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(s.ExpiresAt)
+	if ttl <= 0 {
+		return k.Delete(ctx, s.UserID, s.ID)
+	}
+	if err := k.db.Set(ctx, k.sessionKey(s.ID), data, ttl); err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+	if err := k.db.Set(ctx, k.userKey(s.UserID, s.ID), nil, ttl); err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (k *KVStore) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := k.db.Get(ctx, k.sessionKey(id))
+	if errors.Is(err, kv.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %v", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %v", err)
+	}
+	return &s, nil
+}
+
+// List implements Store
+func (k *KVStore) List(ctx context.Context, userID string) ([]*Session, error) {
+	prefix := k.userPrefix(userID)
+	var ids []string
+	err := k.db.Scan(ctx, prefix, func(key string, _ []byte) bool {
+		// Skip the index of users whose ID extends userID past a colon
+		if id := strings.TrimPrefix(key, prefix); !strings.Contains(id, ":") {
+			ids = append(ids, id)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	var out []*Session
+	for _, id := range ids {
+		s, err := k.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			_ = k.db.Delete(ctx, k.userKey(userID, id))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Delete implements Store
+func (k *KVStore) Delete(ctx context.Context, userID string, ids ...string) error {
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, k.sessionKey(id), k.userKey(userID, id))
+	}
+	if err := k.db.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to delete sessions: %v", err)
+	}
+	return nil
+}
+
+// RememberDevice implements Store
+func (k *KVStore) RememberDevice(ctx context.Context, userID, deviceID string, ttl time.Duration) (bool, error) {
+	known := false
+	err := k.db.Update(ctx, k.deviceKey(userID, deviceID), func(_ []byte, found bool) ([]byte, time.Duration, error) {
+		known = found
+		return []byte{1}, ttl, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record device: %v", err)
+	}
+	return known, nil
+}
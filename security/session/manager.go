@@ -0,0 +1,261 @@
+package session
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"time"
+)
+
+// Event names published by the Manager
+const (
+	// EventNewDevice is published with *Event when a user logs in from a
+	// device not seen within DeviceTTL, e.g. to send a security notification
+	EventNewDevice = "session.new_device"
+	// EventRevoked is published with *Event for each revoked session
+	EventRevoked = "session.revoked"
+)
+
+// Revocation reasons
+const (
+	ReasonLogout  = "logout"  // Revoke
+	ReasonOthers  = "others"  // RevokeOthers
+	ReasonAll     = "all"     // RevokeAll
+	ReasonLimit   = "limit"   // MaxSessions exceeded
+	ReasonExpired = "expired" // Found expired
+)
+
+// Event is the payload of published session events
+type Event struct {
+	Session *Session `json:"session"`
+	Reason  string   `json:"reason,omitempty"` // Revocation reason
+}
+
+// Publisher receives session events, the extension event bus implements it
+type Publisher interface {
+	Publish(eventName string, data any)
+}
+
+// PublisherFunc adapts a function to Publisher, e.g. the extension manager's
+// PublishEvent
+type PublisherFunc func(eventName string, data any)
+
+// Publish implements Publisher
+func (f PublisherFunc) Publish(eventName string, data any) { f(eventName, data) }
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithPublisher publishes session events to p
+func WithPublisher(p Publisher) Option {
+	return func(m *Manager) { m.publisher = p }
+}
+
+// WithClock replaces time.Now, for tests
+func WithClock(now func() time.Time) Option {
+	return func(m *Manager) { m.now = now }
+}
+
+// Manager tracks the active sessions of users
+type Manager struct {
+	cfg       Config
+	store     Store
+	publisher Publisher
+	now       func() time.Time
+}
+
+// New creates a Manager. store defaults to a MemoryStore.
+func New(cfg *Config, store Store, opts ...Option) *Manager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	m := &Manager{cfg: cfg.withDefaults(), store: store, now: time.Now}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Create starts a session for a user on client. Use the session ID as the
+// jti of issued tokens or the session cookie. metadata is optional.
+func (m *Manager) Create(ctx context.Context, userID string, client Client, metadata map[string]string) (*Session, error) {
+	if userID == "" {
+		return nil, errors.New("session: empty user ID")
+	}
+	now := m.now()
+	s := &Session{
+		ID:        rand.Text(),
+		UserID:    userID,
+		Device:    client.device(),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(m.cfg.TTL),
+		Metadata:  metadata,
+	}
+
+	if m.cfg.MaxSessions > 0 {
+		active, err := m.List(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		// List returns the most recently seen first
+		if excess := len(active) - m.cfg.MaxSessions + 1; excess > 0 {
+			if err := m.revoke(ctx, userID, active[len(active)-excess:], ReasonLimit); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := m.store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+
+	known, err := m.store.RememberDevice(ctx, userID, s.Device.ID, m.cfg.DeviceTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		m.publish(EventNewDevice, &Event{Session: s})
+	}
+	return s, nil
+}
+
+// Touch validates a session and records its use by client, at most once per
+// TouchInterval unless the IP changes. It returns ErrNotFound or ErrExpired
+// for sessions that may not be used.
+func (m *Manager) Touch(ctx context.Context, id string, client Client) (*Session, error) {
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	if s.expired(now, m.cfg.IdleTimeout) {
+		_ = m.revoke(ctx, s.UserID, []*Session{s}, ReasonExpired)
+		return nil, ErrExpired
+	}
+
+	ipChanged := client.IP != "" && client.IP != s.IP
+	if now.Sub(s.LastSeen) < m.cfg.TouchInterval && !ipChanged {
+		return s, nil
+	}
+	s.LastSeen = now
+	if client.IP != "" {
+		s.IP = client.IP
+	}
+	if client.UserAgent != "" {
+		s.UserAgent = client.UserAgent
+	}
+	if err := m.store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns a session without recording its use
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.expired(m.now(), m.cfg.IdleTimeout) {
+		return nil, ErrExpired
+	}
+	return s, nil
+}
+
+// List returns the active sessions of a user, most recently seen first.
+// Expired sessions found on the way are removed.
+func (m *Manager) List(ctx context.Context, userID string) ([]*Session, error) {
+	all, err := m.store.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	var expired []string
+	active := all[:0]
+	for _, s := range all {
+		if s.expired(now, m.cfg.IdleTimeout) {
+			expired = append(expired, s.ID)
+			continue
+		}
+		active = append(active, s)
+	}
+	if len(expired) > 0 {
+		_ = m.store.Delete(ctx, userID, expired...)
+	}
+	slices.SortFunc(active, func(a, b *Session) int {
+		return cmp.Or(b.LastSeen.Compare(a.LastSeen), b.CreatedAt.Compare(a.CreatedAt))
+	})
+	return active, nil
+}
+
+// ListFor is List with the session currentID marked as Current, for "your
+// devices" pages
+func (m *Manager) ListFor(ctx context.Context, userID, currentID string) ([]*Session, error) {
+	sessions, err := m.List(ctx, userID)
+	for _, s := range sessions {
+		s.Current = s.ID == currentID
+	}
+	return sessions, err
+}
+
+// Revoke ends a session of a user. Sessions of other users are reported as
+// ErrNotFound, so users cannot probe session IDs.
+func (m *Manager) Revoke(ctx context.Context, userID, id string) error {
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.UserID != userID {
+		return ErrNotFound
+	}
+	return m.revoke(ctx, userID, []*Session{s}, ReasonLogout)
+}
+
+// RevokeOthers ends every session of a user but currentID, e.g. after a
+// password change, returning the number of revoked sessions
+func (m *Manager) RevokeOthers(ctx context.Context, userID, currentID string) (int, error) {
+	sessions, err := m.store.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	sessions = slices.DeleteFunc(sessions, func(s *Session) bool { return s.ID == currentID })
+	return len(sessions), m.revoke(ctx, userID, sessions, ReasonOthers)
+}
+
+// RevokeAll ends every session of a user, returning the number of revoked
+// sessions
+func (m *Manager) RevokeAll(ctx context.Context, userID string) (int, error) {
+	sessions, err := m.store.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), m.revoke(ctx, userID, sessions, ReasonAll)
+}
+
+func (m *Manager) revoke(ctx context.Context, userID string, sessions []*Session, reason string) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	if err := m.store.Delete(ctx, userID, ids...); err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		m.publish(EventRevoked, &Event{Session: s, Reason: reason})
+	}
+	return nil
+}
+
+func (m *Manager) publish(name string, e *Event) {
+	if m.publisher != nil {
+		m.publisher.Publish(name, e)
+	}
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ncobase/ncore/ctxutil"
+)
+
+var (
+	// ErrNotFound is returned for unknown or revoked sessions, and sessions
+	// of another user
+	ErrNotFound = errors.New("session: not found")
+	// ErrExpired is returned for sessions past their TTL or idle timeout
+	ErrExpired = errors.New("session: expired")
+)
+
+// Session is an active login of a user on a device
+type Session struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"user_id"`
+	Device    Device            `json:"device"`
+	IP        string            `json:"ip,omitempty"`         // Last seen IP
+	UserAgent string            `json:"user_agent,omitempty"` // Last seen user agent
+	CreatedAt time.Time         `json:"created_at"`
+	LastSeen  time.Time         `json:"last_seen"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"` // Application data, e.g. the login method
+	// Current marks the session of the caller in List results
+	Current bool `json:"current,omitempty"`
+}
+
+// Device describes the device of a session
+type Device struct {
+	ID      string `json:"id"`   // Client device ID, or derived from the user agent
+	Name    string `json:"name"` // Display name, e.g. "Google Chrome on macOS"
+	Browser string `json:"browser,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Mobile  bool   `json:"mobile,omitempty"`
+}
+
+// Client identifies the client of a request
+type Client struct {
+	IP        string
+	UserAgent string
+	// DeviceID is a stable ID the client keeps, e.g. in a long-lived cookie or
+	// app storage. Without it, devices are told apart by browser and OS only.
+	DeviceID string
+}
+
+// ClientFromContext reads the client IP and user agent set by the ctxutil
+// middleware or found on the request in ctx
+func ClientFromContext(ctx context.Context) Client {
+	ip, ua := ctxutil.GetClientIP(ctx), ctxutil.GetUserAgent(ctx)
+	if ip == "unknown" {
+		ip = ""
+	}
+	if ua == "unknown" {
+		ua = ""
+	}
+	return Client{IP: ip, UserAgent: ua}
+}
+
+// device describes the client's device
+func (c Client) device() Device {
+	info := ctxutil.ParseUserAgent(c.UserAgent)
+	d := Device{ID: c.DeviceID, Browser: info.Browser, OS: info.OS, Mobile: info.Mobile}
+
+	switch {
+	case d.Browser != "" && d.OS != "":
+		d.Name = d.Browser + " on " + d.OS
+	case d.Browser != "" || d.OS != "":
+		d.Name = d.Browser + d.OS
+	default:
+		d.Name = "Unknown device"
+	}
+	if d.ID == "" {
+		sum := sha256.Sum256([]byte(strings.Join([]string{d.Browser, d.OS, c.userAgentFamily()}, "|")))
+		d.ID = hex.EncodeToString(sum[:8])
+	}
+	return d
+}
+
+// userAgentFamily returns the user agent without version numbers, so browser
+// updates do not make a known device look new
+func (c Client) userAgentFamily() string {
+	var b strings.Builder
+	for _, field := range strings.FieldsFunc(c.UserAgent, func(r rune) bool { return r == ' ' || r == ';' || r == '(' || r == ')' }) {
+		name, _, _ := strings.Cut(field, "/")
+		if name == "" || strings.IndexFunc(name, func(r rune) bool { return r >= '0' && r <= '9' }) >= 0 {
+			continue
+		}
+		b.WriteString(name)
+		b.WriteByte(' ')
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// expired reports whether s is past its TTL or idle timeout
+func (s *Session) expired(now time.Time, idle time.Duration) bool {
+	if !now.Before(s.ExpiresAt) {
+		return true
+	}
+	return idle > 0 && now.Sub(s.LastSeen) >= idle
+}
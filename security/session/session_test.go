@@ -0,0 +1,99 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	macChrome  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	macChrome2 = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/127.0.0.0 Safari/537.36"
+	iPhone     = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+)
+
+type recorder struct{ events []string }
+
+func (r *recorder) Publish(name string, data any) {
+	e := data.(*Event)
+	r.events = append(r.events, name+":"+e.Reason)
+}
+
+func TestDevice(t *testing.T) {
+	d := Client{UserAgent: macChrome}.device()
+	if d.Name != "Google Chrome on macOS" || d.Mobile {
+		t.Errorf("device = %+v", d)
+	}
+	if d2 := (Client{UserAgent: macChrome2}).device(); d2.ID != d.ID {
+		t.Error("browser update changed the device ID")
+	}
+	if d3 := (Client{UserAgent: iPhone}).device(); d3.Name != "Safari on iOS" || !d3.Mobile || d3.ID == d.ID {
+		t.Errorf("iPhone device = %+v", d3)
+	}
+	if d4 := (Client{UserAgent: macChrome, DeviceID: "abc"}).device(); d4.ID != "abc" {
+		t.Errorf("device ID = %s", d4.ID)
+	}
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := &recorder{}
+	m := New(&Config{IdleTimeout: time.Hour, MaxSessions: 2}, nil,
+		WithPublisher(events), WithClock(func() time.Time { return now }))
+
+	laptop, err := m.Create(ctx, "u1", Client{IP: "203.0.113.1", UserAgent: macChrome}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := m.Create(ctx, "u1", Client{IP: "203.0.113.1", UserAgent: macChrome2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	phone, err := m.Create(ctx, "u1", Client{IP: "198.51.100.7", UserAgent: iPhone}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Known device on the second login, the oldest session revoked by the limit
+	want := []string{EventNewDevice + ":", EventRevoked + ":" + ReasonLimit, EventNewDevice + ":"}
+	if len(events.events) != len(want) {
+		t.Fatalf("events = %v, want %v", events.events, want)
+	}
+	for i := range want {
+		if events.events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events.events, want)
+		}
+	}
+	if _, err := m.Get(ctx, laptop.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("oldest session: %v", err)
+	}
+
+	list, err := m.ListFor(ctx, "u1", phone.ID)
+	if err != nil || len(list) != 2 || list[0].ID != phone.ID || !list[0].Current || list[1].Current {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	if err := m.Revoke(ctx, "u2", phone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoke by another user: %v", err)
+	}
+	if n, err := m.RevokeOthers(ctx, "u1", phone.ID); err != nil || n != 1 {
+		t.Errorf("revoke others = %d, %v", n, err)
+	}
+
+	// Touch records activity and IP changes, idle sessions expire
+	now = now.Add(30 * time.Minute)
+	s, err := m.Touch(ctx, phone.ID, Client{IP: "198.51.100.8"})
+	if err != nil || s.IP != "198.51.100.8" || !s.LastSeen.Equal(now) {
+		t.Fatalf("touch = %+v, %v", s, err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := m.Touch(ctx, phone.ID, Client{}); !errors.Is(err, ErrExpired) {
+		t.Errorf("idle session: %v", err)
+	}
+	if n, err := m.RevokeAll(ctx, "u1"); err != nil || n != 0 {
+		t.Errorf("revoke all = %d, %v", n, err)
+	}
+}
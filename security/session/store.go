@@ -0,0 +1,283 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store persists sessions and the known devices of users
+type Store interface {
+	// Save creates or updates a session
+	Save(ctx context.Context, s *Session) error
+	// Get returns a session, ErrNotFound if unknown
+	Get(ctx context.Context, id string) (*Session, error)
+	// List returns the sessions of a user, expired ones may be included
+	List(ctx context.Context, userID string) ([]*Session, error)
+	// Delete removes sessions of a user
+	Delete(ctx context.Context, userID string, ids ...string) error
+	// RememberDevice records a device of a user for ttl, reporting whether it
+	// was already known
+	RememberDevice(ctx context.Context, userID, deviceID string, ttl time.Duration) (bool, error)
+}
+
+// MemoryStore is an in-memory Store for single instances
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	users    map[string]map[string]struct{} // user ID to session IDs
+	devices  map[string]time.Time           // user ID and device ID to expiry
+	sweep    time.Time
+}
+
+// NewMemoryStore creates a MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]*Session),
+		users:    make(map[string]map[string]struct{}),
+		devices:  make(map[string]time.Time),
+	}
+}
+
+// Save implements Store
+func (m *MemoryStore) Save(_ context.Context, s *Session) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.sweep) >= time.Minute {
+		m.sweep = now
+		m.sweepExpired(now)
+	}
+	m.sessions[s.ID] = clone(s)
+	ids, ok := m.users[s.UserID]
+	if !ok {
+		ids = make(map[string]struct{})
+		m.users[s.UserID] = ids
+	}
+	ids[s.ID] = struct{}{}
+	return nil
+}
+
+// sweepExpired drops expired sessions and devices
+func (m *MemoryStore) sweepExpired(now time.Time) {
+	for id, s := range m.sessions {
+		if !now.Before(s.ExpiresAt) {
+			delete(m.sessions, id)
+			delete(m.users[s.UserID], id)
+		}
+	}
+	for key, expiry := range m.devices {
+		if now.After(expiry) {
+			delete(m.devices, key)
+		}
+	}
+}
+
+// Get implements Store
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(s), nil
+}
+
+// List implements Store
+func (m *MemoryStore) List(_ context.Context, userID string) ([]*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]*Session, 0, len(m.users[userID]))
+	for id := range m.users[userID] {
+		if s, ok := m.sessions[id]; ok {
+			out = append(out, clone(s))
+		}
+	}
+	return out, nil
+}
+
+// Delete implements Store
+func (m *MemoryStore) Delete(_ context.Context, userID string, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		if s, ok := m.sessions[id]; ok && s.UserID == userID {
+			delete(m.sessions, id)
+		}
+		delete(m.users[userID], id)
+	}
+	if len(m.users[userID]) == 0 {
+		delete(m.users, userID)
+	}
+	return nil
+}
+
+// RememberDevice implements Store
+func (m *MemoryStore) RememberDevice(_ context.Context, userID, deviceID string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	key := userID + "\x00" + deviceID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiry, ok := m.devices[key]
+	m.devices[key] = now.Add(ttl)
+	return ok && now.Before(expiry), nil
+}
+
+func clone(s *Session) *Session {
+	c := *s
+	if s.Metadata != nil {
+		c.Metadata = make(map[string]string, len(s.Metadata))
+		for k, v := range s.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
+
+// RedisStore is a Store shared across instances, including Redis Cluster.
+// Sessions expire with Redis TTLs and the per-user index is pruned as it is
+// listed.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore, keys are prefixed with prefix
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "session"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (r *RedisStore) sessionKey(id string) string  { return r.prefix + ":s:" + id }
+func (r *RedisStore) userKey(userID string) string { return r.prefix + ":u:" + userID }
+func (r *RedisStore) deviceKey(userID, deviceID string) string {
+	return r.prefix + ":d:" + userID + ":" + deviceID
+}
+
+// Save implements Store
+func (r *RedisStore) Save(ctx context.Context, s *Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(s.ExpiresAt)
+	if ttl <= 0 {
+		return r.Delete(ctx, s.UserID, s.ID)
+	}
+	_, err = r.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, r.sessionKey(s.ID), data, ttl)
+		p.SAdd(ctx, r.userKey(s.UserID), s.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session: %v", err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (r *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := r.client.Get(ctx, r.sessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %v", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %v", err)
+	}
+	return &s, nil
+}
+
+// List implements Store
+func (r *RedisStore) List(ctx context.Context, userID string) ([]*Session, error) {
+	ids, err := r.client.SMembers(ctx, r.userKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// One GET per session rather than MGET, which cluster mode refuses across slots
+	cmds := make([]*redis.StringCmd, len(ids))
+	_, err = r.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = p.Get(ctx, r.sessionKey(id))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to list sessions: %v", err)
+	}
+
+	var (
+		out   []*Session
+		stale []any
+	)
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				stale = append(stale, ids[i])
+			}
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil {
+			stale = append(stale, ids[i])
+			continue
+		}
+		out = append(out, &s)
+	}
+	if len(stale) > 0 {
+		_ = r.client.SRem(ctx, r.userKey(userID), stale...).Err()
+	}
+	return out, nil
+}
+
+// Delete implements Store
+func (r *RedisStore) Delete(ctx context.Context, userID string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]any, len(ids))
+	_, err := r.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, id := range ids {
+			p.Del(ctx, r.sessionKey(id))
+			members[i] = id
+		}
+		p.SRem(ctx, r.userKey(userID), members...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions: %v", err)
+	}
+	return nil
+}
+
+// RememberDevice implements Store
+func (r *RedisStore) RememberDevice(ctx context.Context, userID, deviceID string, ttl time.Duration) (bool, error) {
+	err := r.client.SetArgs(ctx, r.deviceKey(userID, deviceID), 1, redis.SetArgs{TTL: ttl, Get: true}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record device: %v", err)
+	}
+	return true, nil
+}
@@ -138,6 +138,23 @@
 //	    {Label: "Inactive", Value: "inactive", Icon: "x"},
 //	}
 //
+// # Field Selection
+//
+// Selection prunes JSON payloads to the fields a client asks for with a
+// ?select= parameter. Paths use dots or braces and apply to every array element:
+//
+//	sel, err := types.ParseSelection(c.Query("select")) // "items{id,title,author.name},total"
+//	if err != nil {
+//	    resp.Fail(c.Writer, resp.BadRequest(err.Error()))
+//	    return
+//	}
+//	resp.Success(c.Writer, sel.Apply(payload))
+//
+// Apply works on JSON, JSONArray and []any values directly and only visits
+// selected fields, so payloads are not re-marshaled. Structs and typed maps or
+// slices are converted to their JSON form first and selected by JSON name. An
+// empty selection keeps everything.
+//
 // # Best Practices
 //
 //   - Use type aliases for consistency across codebase
@@ -0,0 +1,229 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	maxSelectionLength = 2048 // Longest accepted selection expression
+	maxSelectionDepth  = 16   // Deepest accepted nesting
+)
+
+// Selection is a parsed field selection used to prune JSON payloads.
+// A nil or empty selection keeps everything.
+type Selection struct {
+	fields map[string]*Selection // nil keeps the whole value
+}
+
+// ParseSelection parses a field selection expression.
+//
+// Fields are comma separated, nested fields use dots or braces, and paths
+// apply to every element of arrays they pass through:
+//
+//	id,name,author.name,items{id,title,tags}
+func ParseSelection(expr string) (*Selection, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	if len(expr) > maxSelectionLength {
+		return nil, fmt.Errorf("invalid selection: longer than %d characters", maxSelectionLength)
+	}
+
+	p := &selectionParser{expr: expr}
+	s := &Selection{fields: map[string]*Selection{}}
+	if err := p.parseList(s, 0); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.expr) {
+		return nil, fmt.Errorf("invalid selection: unexpected %q at %d", p.expr[p.pos], p.pos)
+	}
+	return s, nil
+}
+
+// Has reports whether the top level field name is selected.
+func (s *Selection) Has(name string) bool {
+	if s == nil || s.fields == nil {
+		return true
+	}
+	_, ok := s.fields[name]
+	return ok
+}
+
+// Apply returns v pruned to the selected fields. Objects are copied shallowly
+// and unselected values are never visited. Structs, typed maps and slices are
+// converted to their JSON form first, so fields are selected by JSON name.
+// Scalars and values that cannot be marshaled are returned as is.
+func (s *Selection) Apply(v any) any {
+	if s == nil || s.fields == nil {
+		return v
+	}
+
+	switch x := v.(type) {
+	case JSON:
+		return s.applyObject(x)
+	case JSONArray:
+		out := make(JSONArray, len(x))
+		for i, item := range x {
+			out[i] = s.applyObject(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, item := range x {
+			out[i] = s.Apply(item)
+		}
+		return out
+	default:
+		if generic, ok := toGeneric(v); ok {
+			return s.Apply(generic)
+		}
+		return v
+	}
+}
+
+// toGeneric converts structs, maps and slices to JSON objects and arrays.
+func toGeneric(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// applyObject copies the selected fields of an object.
+func (s *Selection) applyObject(obj JSON) JSON {
+	if obj == nil {
+		return nil
+	}
+	out := make(JSON, len(s.fields))
+	for name, child := range s.fields {
+		if value, ok := obj[name]; ok {
+			out[name] = child.Apply(value)
+		}
+	}
+	return out
+}
+
+// merge adds name with its sub-selection, a whole value selection wins over a partial one.
+func (s *Selection) merge(name string, child *Selection) {
+	existing, ok := s.fields[name]
+	switch {
+	case !ok:
+		s.fields[name] = child
+	case existing.fields == nil:
+	case child.fields == nil:
+		s.fields[name] = child
+	default:
+		for k, v := range child.fields {
+			existing.merge(k, v)
+		}
+	}
+}
+
+// selectionParser is a recursive descent parser over a selection expression.
+type selectionParser struct {
+	expr string
+	pos  int
+}
+
+// parseList parses items separated by commas into s.
+func (p *selectionParser) parseList(s *Selection, depth int) error {
+	if depth > maxSelectionDepth {
+		return fmt.Errorf("invalid selection: nested deeper than %d", maxSelectionDepth)
+	}
+	for {
+		name, child, err := p.parseItem(depth)
+		if err != nil {
+			return err
+		}
+		s.merge(name, child)
+
+		p.skipSpace()
+		if p.pos >= len(p.expr) || p.expr[p.pos] != ',' {
+			return nil
+		}
+		p.pos++
+	}
+}
+
+// parseItem parses name, name.item or name{list}.
+func (p *selectionParser) parseItem(depth int) (string, *Selection, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(",.{} ", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	name := p.expr[start:p.pos]
+	if name == "" {
+		return "", nil, fmt.Errorf("invalid selection: expected field name at %d", start)
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.expr) {
+		return name, &Selection{}, nil
+	}
+
+	switch p.expr[p.pos] {
+	case '.':
+		p.pos++
+		if depth+1 > maxSelectionDepth {
+			return "", nil, fmt.Errorf("invalid selection: nested deeper than %d", maxSelectionDepth)
+		}
+		childName, grandchild, err := p.parseItem(depth + 1)
+		if err != nil {
+			return "", nil, err
+		}
+		child := &Selection{fields: map[string]*Selection{}}
+		child.merge(childName, grandchild)
+		return name, child, nil
+	case '{':
+		p.pos++
+		child := &Selection{fields: map[string]*Selection{}}
+		if err := p.parseList(child, depth+1); err != nil {
+			return "", nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.expr) || p.expr[p.pos] != '}' {
+			return "", nil, fmt.Errorf("invalid selection: missing } for %s", name)
+		}
+		p.pos++
+		return name, child, nil
+	default:
+		return name, &Selection{}, nil
+	}
+}
+
+// skipSpace skips whitespace.
+func (p *selectionParser) skipSpace() {
+	for p.pos < len(p.expr) && p.expr[p.pos] == ' ' {
+		p.pos++
+	}
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSelection(t *testing.T) {
+	for expr, want := range map[string]string{
+		"id,name":                      `{"id":{},"name":{}}`,
+		" id , author.name ":           `{"author":{"name":{}},"id":{}}`,
+		"items{id,tags},items.title":   `{"items":{"id":{},"tags":{},"title":{}}}`,
+		"author,author.name":           `{"author":{}}`,
+		"author.name,author":           `{"author":{}}`,
+		"a.b.c,a{b{d}}":                `{"a":{"b":{"c":{},"d":{}}}}`,
+		"items { id , meta { kind } }": `{"items":{"id":{},"meta":{"kind":{}}}}`,
+	} {
+		s, err := ParseSelection(expr)
+		if err != nil {
+			t.Errorf("ParseSelection(%q): %v", expr, err)
+			continue
+		}
+		if got := selectionString(s); got != want {
+			t.Errorf("ParseSelection(%q) = %s, want %s", expr, got, want)
+		}
+	}
+
+	if s, err := ParseSelection("  "); err != nil || s != nil {
+		t.Errorf("empty selection = %v, %v, want nil", s, err)
+	}
+
+	deep := "a"
+	for range maxSelectionDepth + 1 {
+		deep += ".a"
+	}
+	long := make([]byte, maxSelectionLength+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	for _, expr := range []string{",", "id,", "a..b", "a{b", "a{}", "a}", "{a}", "a.", deep, string(long)} {
+		if s, err := ParseSelection(expr); err == nil {
+			t.Errorf("ParseSelection(%q) = %s, want an error", expr, selectionString(s))
+		}
+	}
+}
+
+// selectionString renders a selection as JSON for comparison
+func selectionString(s *Selection) string {
+	var render func(s *Selection) any
+	render = func(s *Selection) any {
+		out := map[string]any{}
+		for name, child := range s.fields {
+			out[name] = render(child)
+		}
+		return out
+	}
+	data, _ := json.Marshal(render(s))
+	return string(data)
+}
+
+func TestSelectionApply(t *testing.T) {
+	s, err := ParseSelection("id,author.name,items{id,tags}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := JSON{
+		"id":     1,
+		"secret": "x",
+		"author": JSON{"name": "ann", "email": "a@example.com"},
+		"items": []any{
+			JSON{"id": 1, "tags": []string{"a"}, "body": "long"},
+			JSON{"id": 2},
+			"scalar",
+		},
+	}
+	want := JSON{
+		"id":     1,
+		"author": JSON{"name": "ann"},
+		"items":  []any{JSON{"id": 1, "tags": []string{"a"}}, JSON{"id": 2}, "scalar"},
+	}
+	if got := s.Apply(v); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Apply = %v, want %v", got, want)
+	}
+	if _, ok := v["secret"]; !ok {
+		t.Fatal("Apply modified its input")
+	}
+
+	arr := s.Apply(JSONArray{{"id": 1, "secret": "x"}, nil})
+	if !reflect.DeepEqual(arr, JSONArray{{"id": 1}, nil}) {
+		t.Fatalf("Apply of an array = %v", arr)
+	}
+
+	var none *Selection
+	if got := none.Apply(v); !reflect.DeepEqual(got, v) {
+		t.Fatal("a nil selection should keep everything")
+	}
+	if got := s.Apply(42); got != 42 {
+		t.Fatalf("Apply of a scalar = %v", got)
+	}
+	if !s.Has("id") || s.Has("secret") || !none.Has("secret") {
+		t.Fatal("unexpected Has results")
+	}
+}
+
+func TestSelectionApplyStructs(t *testing.T) {
+	type author struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	type post struct {
+		ID        int64     `json:"id"`
+		Title     string    `json:"title"`
+		Author    *author   `json:"author"`
+		Tags      []string  `json:"tags"`
+		CreatedAt time.Time `json:"created_at"`
+		Raw       []byte    `json:"raw"`
+	}
+	p := &post{
+		ID:        9007199254740993,
+		Title:     "hello",
+		Author:    &author{Name: "ann", Email: "a@example.com"},
+		Tags:      []string{"a"},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	s, err := ParseSelection("id,author.name,created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(s.Apply(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"author":{"name":"ann"},"created_at":"2026-01-02T03:04:05Z","id":9007199254740993}`; string(data) != want {
+		t.Fatalf("Apply of a struct = %s, want %s", data, want)
+	}
+
+	data, err = json.Marshal(s.Apply([]post{*p, {ID: 2}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"author":{"name":"ann"},"created_at":"2026-01-02T03:04:05Z","id":9007199254740993},{"author":null,"created_at":"0001-01-01T00:00:00Z","id":2}]`; string(data) != want {
+		t.Fatalf("Apply of a struct slice = %s, want %s", data, want)
+	}
+
+	data, _ = json.Marshal(s.Apply(map[string]int{"id": 1, "n": 2}))
+	if string(data) != `{"id":1}` {
+		t.Fatalf("Apply of a typed map = %s", data)
+	}
+	if raw := []byte("x"); !reflect.DeepEqual(s.Apply(raw), raw) {
+		t.Fatal("byte slices should be returned as is")
+	}
+	if got := s.Apply((*post)(nil)); got != (*post)(nil) {
+		t.Fatalf("Apply of a nil pointer = %v", got)
+	}
+}
@@ -0,0 +1,29 @@
+// Package redact masks sensitive data before it reaches logs or responses.
+//
+// Values are sensitive by field name, e.g. "password" or "api_key", or by
+// matching a pattern, e.g. card numbers or bearer tokens inside free text.
+// Fields and patterns registered with RegisterFields and RegisterPattern apply
+// to every Redactor, including the one of the logger:
+//
+//	redact.RegisterFields("id_number", "bank_account")
+//	redact.RegisterPattern(redact.Email)
+//
+// # Scrubbing
+//
+//	redact.String("paid with 4111 1111 1111 1111")  // "paid with ******"
+//	redact.Map(map[string]any{"password": "secret"}) // {"password": "******"}
+//	redact.Value(user)                              // map of the user's JSON fields, masked
+//
+// Struct fields are named by their json tag and can be marked sensitive with a
+// redact:"true" tag whatever their name.
+//
+// # Mask styles
+//
+//	r := redact.New(&redact.Options{Style: redact.StylePartial, KeepSuffix: 4})
+//	r.String("card 4111111111111111") // "card ******1111"
+//
+// StyleFixed replaces values with a fixed-length mask that does not leak their
+// length, StylePartial keeps a prefix and suffix, StyleHash replaces values with
+// a short hash so equal values can still be correlated, and StyleLabel writes
+// "[REDACTED]".
+package redact
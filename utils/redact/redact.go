@@ -0,0 +1,476 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Style is how sensitive values are masked
+type Style string
+
+const (
+	StyleFixed   Style = "fixed"   // MaskLength mask characters
+	StylePartial Style = "partial" // KeepPrefix and KeepSuffix characters around the mask
+	StyleHash    Style = "hash"    // Short hash of the value
+	StyleLabel   Style = "label"   // The Label
+)
+
+// Label replaces values masked with StyleLabel
+const Label = "[REDACTED]"
+
+// Pattern finds sensitive values in text
+type Pattern struct {
+	Name string
+	// Regexp masks its first capturing group if it has one, else the whole match
+	Regexp *regexp.Regexp
+	// Valid, if set, checks a match before it is masked, e.g. a checksum
+	Valid func(match string) bool
+}
+
+// Built-in patterns, CardNumber, JWT and BearerToken are registered by default
+var (
+	CardNumber = &Pattern{
+		Name:   "card_number",
+		Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Valid:  luhn,
+	}
+	JWT = &Pattern{
+		Name:   "jwt",
+		Regexp: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+	BearerToken = &Pattern{
+		Name:   "bearer_token",
+		Regexp: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`),
+	}
+	Email = &Pattern{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	}
+	CNMobile = &Pattern{
+		Name:   "cn_mobile",
+		Regexp: regexp.MustCompile(`\b1[3-9]\d{9}\b`),
+	}
+)
+
+// DefaultFields are the field names registered by default
+var DefaultFields = []string{
+	"password", "passwd", "pwd",
+	"secret", "token", "api_key", "apikey", "private_key",
+	"authorization", "cookie",
+	"credit_card", "card_number", "cvv", "ssn",
+}
+
+// rules are the registered fields and patterns
+type rules struct {
+	fields   []string
+	patterns []*Pattern
+}
+
+var (
+	registered   atomic.Pointer[rules]
+	registeredMu sync.Mutex
+)
+
+func init() {
+	registered.Store(&rules{
+		fields:   lower(DefaultFields),
+		patterns: []*Pattern{CardNumber, JWT, BearerToken},
+	})
+}
+
+// RegisterFields marks field names as sensitive for every Redactor
+func RegisterFields(names ...string) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	cur := registered.Load()
+	registered.Store(&rules{fields: append(cur.fields[:len(cur.fields):len(cur.fields)], lower(names)...), patterns: cur.patterns})
+}
+
+// RegisterPattern adds a pattern to every Redactor
+func RegisterPattern(p *Pattern) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	cur := registered.Load()
+	registered.Store(&rules{fields: cur.fields, patterns: append(cur.patterns[:len(cur.patterns):len(cur.patterns)], p)})
+}
+
+// RegisterRegexp compiles and registers a pattern
+func RegisterRegexp(name, expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("redact: pattern %s: %w", name, err)
+	}
+	RegisterPattern(&Pattern{Name: name, Regexp: re})
+	return nil
+}
+
+// Options configures a Redactor
+type Options struct {
+	Style      Style  // Defaults to StyleFixed
+	MaskChar   string // Defaults to "*"
+	MaskLength int    // Length of the mask, default 6
+	KeepPrefix int    // Characters kept before the mask with StylePartial
+	KeepSuffix int    // Characters kept after the mask with StylePartial
+	HashKey    []byte // Keys the StyleHash hash, so short values cannot be guessed from it
+	// ExactFieldMatch matches field names exactly instead of by substring,
+	// e.g. "token" then no longer matches "access_token"
+	ExactFieldMatch bool
+	Fields          []string   // Sensitive field names besides the registered ones
+	Patterns        []*Pattern // Patterns besides the registered ones
+	MaxDepth        int        // Nesting below which values are left as is, default 10
+}
+
+// Redactor masks sensitive values
+type Redactor struct {
+	opts   Options
+	fields []string
+	mask   string
+}
+
+// New creates a Redactor, a nil opts uses the defaults
+func New(opts *Options) *Redactor {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Style == "" {
+		o.Style = StyleFixed
+	}
+	if o.MaskChar == "" {
+		o.MaskChar = "*"
+	}
+	if o.MaskLength <= 0 {
+		o.MaskLength = 6
+	}
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = 10
+	}
+	return &Redactor{opts: o, fields: lower(o.Fields), mask: strings.Repeat(o.MaskChar, o.MaskLength)}
+}
+
+var std = New(nil)
+
+// Default returns the Redactor with default options used by the package functions
+func Default() *Redactor { return std }
+
+// IsSensitive reports whether values of a field are masked
+func (r *Redactor) IsSensitive(field string) bool {
+	if field == "" {
+		return false
+	}
+	name := strings.ToLower(field)
+	return r.matchField(name, r.fields) || r.matchField(name, registered.Load().fields)
+}
+
+func (r *Redactor) matchField(name string, fields []string) bool {
+	for _, f := range fields {
+		if name == f || (!r.opts.ExactFieldMatch && strings.Contains(name, f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask masks a whole value in the redactor's style
+func (r *Redactor) Mask(s string) string {
+	switch r.opts.Style {
+	case StyleLabel:
+		return Label
+	case StyleHash:
+		var sum []byte
+		if len(r.opts.HashKey) > 0 {
+			h := hmac.New(sha256.New, r.opts.HashKey)
+			h.Write([]byte(s))
+			sum = h.Sum(nil)
+		} else {
+			s := sha256.Sum256([]byte(s))
+			sum = s[:]
+		}
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	case StylePartial:
+		runes := []rune(s)
+		if len(runes) <= r.opts.KeepPrefix+r.opts.KeepSuffix {
+			return r.mask
+		}
+		return string(runes[:r.opts.KeepPrefix]) + r.mask + string(runes[len(runes)-r.opts.KeepSuffix:])
+	default:
+		return r.mask
+	}
+}
+
+// String masks the matches of the patterns in s
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	for _, p := range r.opts.Patterns {
+		s = r.replace(p, s)
+	}
+	for _, p := range registered.Load().patterns {
+		s = r.replace(p, s)
+	}
+	return s
+}
+
+func (r *Redactor) replace(p *Pattern, s string) string {
+	matches := p.Regexp.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if p.Valid != nil && !p.Valid(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.Mask(s[start:end]))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Map returns a copy of m with sensitive fields masked and the other values
+// scrubbed with Value
+func (r *Redactor) Map(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = r.Field(k, v)
+	}
+	return out
+}
+
+// Field masks v if the field is sensitive, else scrubs it with Value
+func (r *Redactor) Field(name string, v any) any {
+	if r.IsSensitive(name) {
+		return r.maskAny(reflect.ValueOf(v))
+	}
+	return r.Value(v)
+}
+
+// Value scrubs strings, errors, maps, slices and structs recursively. Maps and
+// structs are returned as map[string]any keyed like their JSON encoding, slices
+// as []any, errors as their scrubbed message. Other values are returned as is.
+func (r *Redactor) Value(v any) any {
+	return r.value(reflect.ValueOf(v), 0)
+}
+
+// Struct scrubs a struct, or a pointer to one, into a map keyed like its JSON
+// encoding. It returns nil for other values.
+func (r *Redactor) Struct(v any) map[string]any {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	out := make(map[string]any)
+	r.structInto(out, rv, 0)
+	return out
+}
+
+var (
+	errorType         = reflect.TypeFor[error]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (r *Redactor) value(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > r.opts.MaxDepth || !v.CanInterface() {
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	if v.Type().Implements(errorType) {
+		return r.String(v.Interface().(error).Error())
+	}
+
+	if k := v.Kind(); k != reflect.Pointer && k != reflect.Interface {
+		// Values with their own text form, e.g. time.Time, are kept, values
+		// with their own JSON form are scrubbed as encoded
+		if k == reflect.Struct && (v.Type().Implements(textMarshalerType) || reflect.PointerTo(v.Type()).Implements(textMarshalerType)) {
+			return v.Interface()
+		}
+		if v.Type().Implements(jsonMarshalerType) {
+			return r.viaJSON(v, depth)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return r.value(v.Elem(), depth+1)
+	case reflect.String:
+		return r.String(v.String())
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if r.IsSensitive(k) {
+				out[k] = r.maskAny(iter.Value())
+			} else {
+				out[k] = r.value(iter.Value(), depth+1)
+			}
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = r.value(v.Index(i), depth+1)
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]any)
+		r.structInto(out, v, depth)
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// viaJSON scrubs a value with its own JSON encoding through its decoded form
+func (r *Redactor) viaJSON(v reflect.Value, depth int) any {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return v.Interface()
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v.Interface()
+	}
+	return r.value(reflect.ValueOf(decoded), depth+1)
+}
+
+// structInto adds the exported fields of a struct to out
+func (r *Redactor) structInto(out map[string]any, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+
+		// Embedded structs are flattened, as by encoding/json
+		if f.Anonymous && name == "" {
+			if e := reflect.Indirect(fv); e.Kind() == reflect.Struct {
+				r.structInto(out, e, depth+1)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		if f.Tag.Get("redact") == "true" || r.IsSensitive(name) {
+			out[name] = r.maskAny(fv)
+		} else {
+			out[name] = r.value(fv, depth+1)
+		}
+	}
+}
+
+// maskAny masks a value of a sensitive field, keeping nil and empty values
+func (r *Redactor) maskAny(v reflect.Value) any {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.String && v.Len() == 0 {
+		return ""
+	}
+	if !v.CanInterface() {
+		return r.mask
+	}
+	return r.Mask(fmt.Sprint(v.Interface()))
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+func lower(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != "" {
+			out = append(out, strings.ToLower(n))
+		}
+	}
+	return out
+}
+
+// String masks the patterns in s with the default Redactor
+func String(s string) string { return std.String(s) }
+
+// Map masks the sensitive fields of m with the default Redactor
+func Map(m map[string]any) map[string]any { return std.Map(m) }
+
+// Value scrubs v with the default Redactor
+func Value(v any) any { return std.Value(v) }
+
+// Struct scrubs a struct into a map with the default Redactor
+func Struct(v any) map[string]any { return std.Struct(v) }
+
+// IsSensitive reports whether values of a field are masked by default
+func IsSensitive(field string) bool { return std.IsSensitive(field) }
@@ -0,0 +1,131 @@
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"paid with 4111 1111 1111 1111 today", "paid with ****** today"},
+		{"order 4111111111111112", "order 4111111111111112"},
+		{"Authorization: Bearer abc.def-123", "Authorization: Bearer ******"},
+		{"jwt eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig", "jwt ******"},
+		{"trace 0123456789abcdef", "trace 0123456789abcdef"},
+	}
+	for _, tt := range tests {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStyles(t *testing.T) {
+	card := "4111111111111111"
+	if got := New(&Options{Style: StylePartial, KeepSuffix: 4}).String("card " + card); got != "card ******1111" {
+		t.Errorf("partial = %q", got)
+	}
+	if got := New(&Options{Style: StyleLabel}).Mask(card); got != Label {
+		t.Errorf("label = %q", got)
+	}
+	h := New(&Options{Style: StyleHash})
+	if a, b := h.Mask(card), h.Mask(card); a != b || len(a) != len("sha256:")+12 {
+		t.Errorf("hash = %q, %q", a, b)
+	}
+	if New(&Options{Style: StyleHash, HashKey: []byte("k")}).Mask(card) == h.Mask(card) {
+		t.Error("keyed hash equals unkeyed hash")
+	}
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type user struct {
+	Base
+	Name     string            `json:"name"`
+	Password string            `json:"password"`
+	National string            `json:"national" redact:"true"`
+	Notes    []string          `json:"notes"`
+	Meta     map[string]string `json:"meta"`
+	Created  time.Time         `json:"created"`
+	Err      error             `json:"err"`
+	Hidden   string            `json:"-"`
+	internal string
+}
+
+func TestValue(t *testing.T) {
+	created := time.Unix(0, 0)
+	u := &user{
+		Base:     Base{ID: 7},
+		Name:     "ann",
+		Password: "secret",
+		National: "X123",
+		Notes:    []string{"card 4111 1111 1111 1111"},
+		Meta:     map[string]string{"api_key": "k", "plan": "pro"},
+		Created:  created,
+		Err:      errors.New("bad token Bearer xyz"),
+		Hidden:   "h",
+		internal: "i",
+	}
+	want := map[string]any{
+		"id":       7,
+		"name":     "ann",
+		"password": "******",
+		"national": "******",
+		"notes":    []any{"card ******"},
+		"meta":     map[string]any{"api_key": "******", "plan": "pro"},
+		"created":  created,
+		"err":      "bad token Bearer ******",
+	}
+	if got := Value(u); !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() = %#v", got)
+	}
+	if got := Struct(*u); !reflect.DeepEqual(got, want) {
+		t.Errorf("Struct() = %#v", got)
+	}
+
+	m := map[string]any{"user_password": "p", "empty_token": "", "nil_secret": nil, "n": 1}
+	got := Map(m)
+	if got["user_password"] != "******" || got["empty_token"] != "" || got["nil_secret"] != nil || got["n"] != 1 {
+		t.Errorf("Map() = %v", got)
+	}
+	if m["user_password"] != "p" {
+		t.Error("Map() changed its input")
+	}
+	if New(&Options{ExactFieldMatch: true}).IsSensitive("user_password") {
+		t.Error("exact match matched a substring")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	RegisterFields("iban")
+	if !IsSensitive("IBAN") {
+		t.Error("registered field not sensitive")
+	}
+	if err := RegisterRegexp("order", `ORD-\d+`); err != nil {
+		t.Fatal(err)
+	}
+	if got := New(nil).String("see ORD-42"); got != "see ******" {
+		t.Errorf("registered pattern not applied: %q", got)
+	}
+	if RegisterRegexp("bad", "(") == nil {
+		t.Error("invalid pattern registered")
+	}
+}
+
+type card string
+
+func (c card) MarshalJSON() ([]byte, error) {
+	return []byte(`{"number":"` + string(c) + `","brand":"visa"}`), nil
+}
+
+func TestValueJSONMarshaler(t *testing.T) {
+	got := Value(map[string]any{"payment": card("4111111111111111")})
+	want := map[string]any{"payment": map[string]any{"number": "******", "brand": "visa"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Value() = %#v", got)
+	}
+}
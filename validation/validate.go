@@ -9,16 +9,52 @@ import (
 // Validate is a wrapper around validator.Validate that returns a map of JSON field names to friendly error messages.
 var Validate = validator.ValidateStruct
 
-// ShouldBindAndValidateStruct binds and validates struct
+// ValidateFields validates a struct and returns its localized field errors,
+// the errors member of resp failures.
+var ValidateFields = validator.ValidateFields
+
+// FieldError is a localized validation failure of a field
+type FieldError = validator.FieldError
+
+// Errors are the field errors of a validated value
+type Errors = validator.Errors
+
+// Language returns the language of the validation messages of a request,
+// matched from its Accept-Language header.
+func Language(c *gin.Context) string {
+	return validator.MatchLanguage(c.GetHeader("Accept-Language"))
+}
+
+// Message returns the localized message of key, e.g. validation.failed for
+// the message of a response listing field errors.
+func Message(lang, key string, params ...map[string]string) string {
+	var p map[string]string
+	if len(params) > 0 {
+		p = params[0]
+	}
+	return validator.Message(lang, key, p)
+}
+
+// ShouldBindAndValidateStruct binds and validates struct. Messages are in
+// lang, or the language of the request when omitted. Failed binding tags are
+// reported with the validation errors rather than as an error.
 func ShouldBindAndValidateStruct(c *gin.Context, obj any, lang ...string) (map[string]string, error) {
 	contentType := c.GetHeader("Content-Type")
 	if contentType == "" {
 		contentType = "application/json;charset=utf-8"
 	}
 
+	l := Language(c)
+	if len(lang) > 0 && lang[0] != "" {
+		l = lang[0]
+	}
+
 	if err := c.ShouldBind(obj); err != nil {
+		if errs := validator.Translate(err, obj, l); len(errs) > 0 {
+			return errs.Map(), nil
+		}
 		return nil, err
 	}
 
-	return Validate(obj, lang...), nil
+	return Validate(obj, l), nil
 }
@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShouldBindAndValidateStruct(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var body struct {
+		Name  string `json:"name" binding:"required"`
+		Email string `json:"email" validate:"email"`
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", strings.NewReader(`{"email": "x"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Accept-Language", "zh-CN,en;q=0.5")
+	errs, err := ShouldBindAndValidateStruct(c, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs["name"] != "字段 'name' 为必填项。" {
+		t.Errorf("binding errors not localized: %v", errs)
+	}
+
+	c.Request = httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "a", "email": "x"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	errs, err = ShouldBindAndValidateStruct(c, &body, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs["email"] != "The field 'email' must be a valid email address." {
+		t.Errorf("unexpected validation errors: %v", errs)
+	}
+}
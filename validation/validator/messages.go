@@ -0,0 +1,189 @@
+package validator
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language of messages when the requested one has no
+// catalog or lacks a key
+const DefaultLanguage = "en"
+
+// FieldError is a localized validation failure of a field. Key and Params let
+// clients render their own message.
+type FieldError struct {
+	Field   string            `json:"field"`            // JSON path, e.g. items[0].name
+	Key     string            `json:"key"`              // message key, e.g. validation.max
+	Params  map[string]string `json:"params,omitempty"` // interpolated into the message
+	Message string            `json:"message"`
+}
+
+// Errors are the field errors of a validated value
+type Errors []FieldError
+
+// Error joins the messages
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, " ")
+}
+
+// Map returns the messages by field
+func (e Errors) Map() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, fe := range e {
+		m[fe.Field] = fe.Message
+	}
+	return m
+}
+
+var (
+	catalogsMu sync.RWMutex
+	// catalogs maps lower-case languages to message keys to messages. Messages
+	// interpolate params with {name}: {field} is the field, {param} the rule
+	// parameter and {tag} the rule.
+	catalogs = map[string]map[string]string{
+		"en": {
+			"validation.failed":   "The request is invalid.",
+			"validation.invalid":  "Field '{field}' is invalid: {tag}",
+			"validation.required": "The field '{field}' is required.",
+			"validation.email":    "The field '{field}' must be a valid email address.",
+			"validation.url":      "The field '{field}' must be a valid URL.",
+			"validation.min":      "The field '{field}' must be at least {param} characters long.",
+			"validation.max":      "The field '{field}' must be no longer than {param} characters.",
+			"validation.len":      "The field '{field}' must be {param} characters long.",
+			"validation.lte":      "The field '{field}' must be less than or equal to {param}.",
+			"validation.gte":      "The field '{field}' must be greater than or equal to {param}.",
+			"validation.unique":   "The field '{field}' must be unique.",
+			"validation.gt":       "The field '{field}' must be greater than {param}.",
+			"validation.lt":       "The field '{field}' must be less than {param}.",
+			"validation.enum":     "The field '{field}' must be one of {param}.",
+			"validation.oneof":    "The field '{field}' must be one of {param}.",
+		},
+		"zh": {
+			"validation.failed":   "请求参数无效。",
+			"validation.invalid":  "字段 '{field}' 无效：{tag}",
+			"validation.required": "字段 '{field}' 为必填项。",
+			"validation.email":    "字段 '{field}' 必须是有效的电子邮箱地址。",
+			"validation.url":      "字段 '{field}' 必须是有效的 URL。",
+			"validation.min":      "字段 '{field}' 的长度不能少于 {param} 个字符。",
+			"validation.max":      "字段 '{field}' 的长度不能超过 {param} 个字符。",
+			"validation.len":      "字段 '{field}' 的长度必须为 {param} 个字符。",
+			"validation.lte":      "字段 '{field}' 的值必须小于或等于 {param}。",
+			"validation.gte":      "字段 '{field}' 的值必须大于或等于 {param}。",
+			"validation.unique":   "字段 '{field}' 的值必须唯一。",
+			"validation.gt":       "字段 '{field}' 的值必须大于 {param}。",
+			"validation.lt":       "字段 '{field}' 的值必须小于 {param}。",
+			"validation.enum":     "字段 '{field}' 的值必须是 {param} 之一。",
+			"validation.oneof":    "字段 '{field}' 的值必须是 {param} 之一。",
+		},
+	}
+)
+
+// RegisterMessages adds or replaces messages of a language, e.g. those of a
+// custom rule or a new language loaded from an i18n catalog
+func RegisterMessages(lang string, messages map[string]string) {
+	lang = strings.ToLower(lang)
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if catalogs[lang] == nil {
+		catalogs[lang] = make(map[string]string, len(messages))
+	}
+	maps.Copy(catalogs[lang], messages)
+}
+
+// Languages lists the languages with messages
+func Languages() []string {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	return slices.Sorted(maps.Keys(catalogs))
+}
+
+// Message returns the message of key in lang with params interpolated. A
+// regional language falls back to its base language, e.g. zh-CN to zh, then
+// to DefaultLanguage. The key itself is returned when no catalog has it.
+func Message(lang, key string, params map[string]string) string {
+	msg, ok := lookup(lang, key)
+	if !ok {
+		return key
+	}
+	if len(params) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// lookup finds the message of key for lang and its fallbacks
+func lookup(lang, key string) (string, bool) {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	for _, l := range append(subtags(lang), DefaultLanguage) {
+		if msg, ok := catalogs[l][key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// subtags lists lang and its shorter prefixes in lower case, e.g. zh-hans-cn,
+// zh-hans and zh
+func subtags(lang string) []string {
+	lang = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+	var langs []string
+	for lang != "" {
+		langs = append(langs, lang)
+		i := strings.LastIndex(lang, "-")
+		if i < 0 {
+			break
+		}
+		lang = lang[:i]
+	}
+	return langs
+}
+
+// MatchLanguage returns the preferred language of an Accept-Language header
+// having messages, DefaultLanguage when none has
+func MatchLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	for _, c := range candidates {
+		for _, l := range subtags(c.lang) {
+			if _, ok := catalogs[l]; ok {
+				return l
+			}
+		}
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type order struct {
+	Email  string  `json:"email" validate:"required,email"`
+	Status string  `json:"status" validate:"oneof=draft paid"`
+	Note   string  `validate:"max=3"`
+	Items  []*item `json:"items" validate:"dive"`
+}
+
+func TestValidateFields(t *testing.T) {
+	o := &order{Email: "x", Status: "lost", Note: "long", Items: []*item{{Name: "a"}, {}}}
+	errs := ValidateFields(o, "zh-CN")
+	got := map[string]FieldError{}
+	for _, e := range errs {
+		got[e.Field] = e
+	}
+
+	for field, want := range map[string]struct{ key, msg string }{
+		"email":         {"validation.email", "字段 'email' 必须是有效的电子邮箱地址。"},
+		"status":        {"validation.oneof", "字段 'status' 的值必须是 draft paid 之一。"},
+		"Note":          {"validation.max", "字段 'Note' 的长度不能超过 3 个字符。"},
+		"items[1].name": {"validation.required", "字段 'items[1].name' 为必填项。"},
+	} {
+		e, ok := got[field]
+		if !ok {
+			t.Errorf("no error for %s in %+v", field, errs)
+			continue
+		}
+		if e.Key != want.key || e.Message != want.msg {
+			t.Errorf("%s: got %s %q, want %s %q", field, e.Key, e.Message, want.key, want.msg)
+		}
+	}
+	if got["Note"].Params["param"] != "3" {
+		t.Errorf("params not exposed: %v", got["Note"].Params)
+	}
+
+	if msg := ValidateStruct(o)["email"]; msg != "The field 'email' must be a valid email address." {
+		t.Errorf("unexpected default language message %q", msg)
+	}
+}
+
+func TestMessageFallback(t *testing.T) {
+	RegisterMessages("fr", map[string]string{"validation.required": "Le champ '{field}' est obligatoire."})
+
+	for _, tc := range []struct{ lang, key, want string }{
+		{"fr-CA", "validation.required", "Le champ 'x' est obligatoire."},
+		{"fr", "validation.email", "The field 'x' must be a valid email address."},
+		{"de", "validation.required", "The field 'x' is required."},
+		{"en", "validation.custom", "validation.custom"},
+	} {
+		if got := Message(tc.lang, tc.key, map[string]string{"field": "x"}); got != tc.want {
+			t.Errorf("Message(%s, %s) = %q, want %q", tc.lang, tc.key, got, tc.want)
+		}
+	}
+
+	errs := ValidateFields(&struct {
+		N int    `json:"n" validate:"oneof=1 2,required"`
+		S string `json:"s" validate:"uuid"`
+	}{N: 3, S: "x"}, "fr")
+	if len(errs) != 2 || errs[1].Key != "validation.invalid" || errs[1].Message != "Field 's' is invalid: uuid" {
+		t.Errorf("unexpected errors of rules without messages: %+v", errs)
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                        "en",
+		"zh-CN,zh;q=0.9,en;q=0.8": "zh",
+		"de-DE, en-GB;q=0.5":      "en",
+		"de, zh-Hans-CN;q=0.7":    "zh",
+		"en;q=0.4, zh-TW;q=0.6":   "zh",
+		"zh;q=0, en-US":           "en",
+		"*":                       "en",
+	} {
+		if got := MatchLanguage(header); got != want {
+			t.Errorf("MatchLanguage(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
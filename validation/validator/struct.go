@@ -2,7 +2,6 @@ package validator
 
 import (
 	"errors"
-	"fmt"
 	"reflect"
 	"strings"
 
@@ -15,79 +14,86 @@ func init() {
 	validate = validator.New()
 }
 
-// errorMessages is a nested map of languages to validation tags to custom error messages.
-var errorMessages = map[string]map[string]string{
-	"en": {
-		"required": "The field '%s' is required.",
-		"email":    "The field '%s' must be a valid email address.",
-		"min":      "The field '%s' must be at least %s characters long.",
-		"max":      "The field '%s' must be no longer than %s characters.",
-		"lte":      "The field '%s' must be less than or equal to %s.",
-		"gte":      "The field '%s' must be greater than or equal to %s.",
-		"unique":   "The field '%s' must be unique.",
-		"gt":       "The field '%s' must be greater than %s.",
-		"lt":       "The field '%s' must be less than %s.",
-		"enum":     "The field '%s' must be one of %s.",
-	},
-	"zh": {
-		"required": "字段 '%s' 为必填项。",
-		"email":    "字段 '%s' 必须是有效的电子邮箱地址。",
-		"min":      "字段 '%s' 的长度不能少于 %s 个字符。",
-		"max":      "字段 '%s' 的长度不能超过 %s 个字符。",
-		"lte":      "字段 '%s' 的值必须小于或等于 %s。",
-		"gte":      "字段 '%s' 的值必须大于或等于 %s。",
-		"unique":   "字段 '%s' 的值必须唯一。",
-		"gt":       "字段 '%s' 的值必须大于 %s。",
-		"lt":       "字段 '%s' 的值必须小于 %s。",
-		"enum":     "字段 '%s' 的值必须是 %s 之一。",
-	},
-	// Add more languages as needed.
+// ValidateStruct validates a struct and returns a map of JSON field names to friendly error messages.
+func ValidateStruct(s any, lang ...string) map[string]string {
+	return ValidateFields(s, lang...).Map()
 }
 
-// parseMessage constructs a friendly error message based on the validation tag and custom messages.
-func parseMessage(jsonTag string, e validator.FieldError, lang ...string) string {
-	var msgLang string
-	if len(lang) > 0 {
-		msgLang = lang[0]
-	} else {
-		msgLang = "en"
+// ValidateFields validates a struct and returns its field errors localized in
+// lang, DefaultLanguage by default.
+func ValidateFields(s any, lang ...string) Errors {
+	return Translate(validate.Struct(s), s, lang...)
+}
+
+// Translate converts the validation errors of s in err, from ValidateFields or
+// the binding of a gin request, to field errors localized in lang. Other
+// errors yield nil.
+func Translate(err error, s any, lang ...string) Errors {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+	l := DefaultLanguage
+	if len(lang) > 0 && lang[0] != "" {
+		l = lang[0]
 	}
-	if msgs, exists := errorMessages[msgLang]; exists {
-		if msg, exists := msgs[e.Tag()]; exists {
-			// Check the number of %s placeholders in the custom message
-			placeholderCount := strings.Count(msg, "%s")
-			if placeholderCount == 1 {
-				return fmt.Sprintf(msg, jsonTag)
-			} else if placeholderCount == 2 {
-				return fmt.Sprintf(msg, jsonTag, e.Param())
-			}
+
+	errs := make(Errors, 0, len(validationErrs))
+	for _, e := range validationErrs {
+		field := jsonPath(reflect.TypeOf(s), e.StructNamespace())
+		if field == "" {
+			field = e.Field()
+		}
+		params := map[string]string{"field": field, "tag": e.Tag()}
+		if e.Param() != "" {
+			params["param"] = e.Param()
 		}
+		key := "validation." + e.Tag()
+		if _, ok := lookup(l, key); !ok {
+			key = "validation.invalid"
+		}
+		errs = append(errs, FieldError{
+			Field:   field,
+			Key:     key,
+			Params:  params,
+			Message: Message(l, key, params),
+		})
 	}
-	// Default error message if no custom message is defined for the tag or language.
-	return fmt.Sprintf("Field '%s' is invalid: %s", jsonTag, e.Tag())
+	return errs
 }
 
-// ValidateStruct validates a struct and returns a map of JSON field names to friendly error messages.
-func ValidateStruct(s any, lang ...string) map[string]string {
-	validationErrors := make(map[string]string)
-
-	err := validate.Struct(s)
-	if err != nil {
-		var validationErrs validator.ValidationErrors
-		if errors.As(err, &validationErrs) {
-			structType := reflect.TypeOf(s).Elem()
-			for _, e := range validationErrs {
-				field, _ := structType.FieldByName(e.StructField())
-				jsonTag := field.Tag.Get("json")
-				if jsonTag == "" {
-					jsonTag = e.StructField()
-				} else {
-					jsonTag = strings.Split(jsonTag, ",")[0]
-				}
-				validationErrors[jsonTag] = parseMessage(jsonTag, e, lang...)
-			}
+// jsonPath converts a struct namespace, e.g. Order.Items[0].Name, to the JSON
+// path of the field in t, e.g. items[0].name
+func jsonPath(t reflect.Type, namespace string) string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	parts := strings.Split(namespace, ".")
+	if t != nil && t.Name() != "" {
+		// named types prefix the namespace
+		parts = parts[1:]
+	}
+	var path []string
+	for _, part := range parts {
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
 		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return ""
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return ""
+		}
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			name = tag
+		}
+		path = append(path, name+index)
+		t = f.Type
 	}
-
-	return validationErrors
+	return strings.Join(path, ".")
 }
@@ -98,6 +98,19 @@
 //	    // Application code...
 //	}
 //
+// # SBOM
+//
+// The module list embedded by the Go toolchain is exposed as a CycloneDX
+// SBOM, so deployed instances can be audited without rebuilding:
+//
+//	app -version -sbom > sbom.json
+//	ncore version -sbom > ncore-sbom.json
+//
+//	// Management endpoint, refused without Token or Authenticate
+//	mux.Handle("GET /manage/sbom", version.SBOMHandler(version.SBOMOptions{Token: os.Getenv("MANAGE_TOKEN")}))
+//
+// BuildSBOM describes another binary from debug/buildinfo.ReadFile.
+//
 // # Best Practices
 //
 //   - Always set version info in production builds
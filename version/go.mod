@@ -0,0 +1,3 @@
+module github.com/ncobase/ncore/version
+
+go 1.25.3
@@ -0,0 +1,237 @@
+package version
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// SBOMContentType is the media type of CycloneDX JSON documents
+const SBOMContentType = "application/vnd.cyclonedx+json"
+
+// ErrNoBuildInfo is returned when the binary carries no module information,
+// e.g. built without module support
+var ErrNoBuildInfo = errors.New("version: build info not available")
+
+// SBOM is a CycloneDX 1.5 software bill of materials
+type SBOM struct {
+	BOMFormat    string           `json:"bomFormat"`
+	SpecVersion  string           `json:"specVersion"`
+	SerialNumber string           `json:"serialNumber"`
+	Version      int              `json:"version"`
+	Metadata     SBOMMetadata     `json:"metadata"`
+	Components   []SBOMComponent  `json:"components"`
+	Dependencies []SBOMDependency `json:"dependencies,omitempty"`
+}
+
+// SBOMMetadata describes the application the SBOM is about
+type SBOMMetadata struct {
+	Timestamp  string         `json:"timestamp"`
+	Component  SBOMComponent  `json:"component"`
+	Properties []SBOMProperty `json:"properties,omitempty"`
+}
+
+// SBOMComponent is an application or a Go module it depends on
+type SBOMComponent struct {
+	Type    string     `json:"type"`
+	BOMRef  string     `json:"bom-ref"`
+	Name    string     `json:"name"`
+	Version string     `json:"version,omitempty"`
+	PURL    string     `json:"purl,omitempty"`
+	Hashes  []SBOMHash `json:"hashes,omitempty"`
+}
+
+// SBOMHash is a component checksum
+type SBOMHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// SBOMProperty is a name-value pair, build settings and version info
+type SBOMProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SBOMDependency lists the components a component depends on
+type SBOMDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// GetSBOM returns the SBOM of the running binary from its embedded build info
+func GetSBOM() (*SBOM, error) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, ErrNoBuildInfo
+	}
+	return BuildSBOM(bi, GetVersionInfo()), nil
+}
+
+// BuildSBOM converts build info into an SBOM, info supplies the application
+// version and build metadata. Use debug/buildinfo.ReadFile to describe
+// another binary.
+func BuildSBOM(bi *debug.BuildInfo, info Info) *SBOM {
+	main := SBOMComponent{
+		Type:    "application",
+		Name:    bi.Main.Path,
+		Version: info.Version,
+	}
+	if main.Name == "" {
+		main.Name = bi.Path
+	}
+	if main.Version == "" || main.Version == "0.0.0" || main.Version == "unknown" {
+		main.Version = bi.Main.Version
+	}
+	main.PURL = purl(main.Name, main.Version)
+	main.BOMRef = main.PURL
+
+	props := []SBOMProperty{
+		{Name: "ncore:go_version", Value: bi.GoVersion},
+		{Name: "ncore:branch", Value: info.Branch},
+		{Name: "ncore:revision", Value: info.Revision},
+		{Name: "ncore:built_at", Value: info.BuiltAt},
+	}
+	for _, s := range bi.Settings {
+		props = append(props, SBOMProperty{Name: "go:build:" + s.Key, Value: s.Value})
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	if t, err := time.Parse(time.RFC3339, info.BuiltAt); err == nil {
+		timestamp = t.UTC().Format(time.RFC3339)
+	}
+
+	s := &SBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid4(),
+		Version:      1,
+		Metadata:     SBOMMetadata{Timestamp: timestamp, Component: main, Properties: props},
+		Components:   make([]SBOMComponent, 0, len(bi.Deps)),
+	}
+	refs := make([]string, 0, len(bi.Deps))
+	for _, dep := range bi.Deps {
+		// Replaced modules are what the binary actually contains
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		c := SBOMComponent{
+			Type:    "library",
+			Name:    mod.Path,
+			Version: mod.Version,
+			PURL:    purl(mod.Path, mod.Version),
+		}
+		c.BOMRef = c.PURL
+		if sum, ok := h1Hex(mod.Sum); ok {
+			c.Hashes = []SBOMHash{{Alg: "SHA-256", Content: sum}}
+		}
+		s.Components = append(s.Components, c)
+		refs = append(refs, c.BOMRef)
+	}
+	s.Dependencies = []SBOMDependency{{Ref: main.BOMRef, DependsOn: refs}}
+	return s
+}
+
+// JSON returns the SBOM as indented CycloneDX JSON
+func (s *SBOM) JSON() string {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return string(data)
+}
+
+// PrintSBOM prints the SBOM of the running binary to stdout
+func PrintSBOM() error {
+	s, err := GetSBOM()
+	if err != nil {
+		return err
+	}
+	fmt.Println(s.JSON())
+	return nil
+}
+
+// SBOMOptions configures SBOMHandler
+type SBOMOptions struct {
+	// Token is the bearer token of the management endpoint
+	Token string
+	// Authenticate replaces Token for custom schemes. Without either, every
+	// request is refused, dependency lists help attackers find vulnerable
+	// versions.
+	Authenticate func(r *http.Request) bool
+}
+
+// SBOMHandler serves the SBOM of the running binary for auditing deployed
+// instances, e.g. mounted at /manage/sbom
+//
+//	r.GET("/manage/sbom", gin.WrapH(version.SBOMHandler(version.SBOMOptions{Token: os.Getenv("MANAGE_TOKEN")})))
+func SBOMHandler(opts SBOMOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.authenticate(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="manage"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s, err := GetSBOM()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", SBOMContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(s)
+	})
+}
+
+func (o SBOMOptions) authenticate(r *http.Request) bool {
+	if o.Authenticate != nil {
+		return o.Authenticate(r)
+	}
+	if o.Token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) == 1
+}
+
+// purl returns the package URL of a Go module
+func purl(path, version string) string {
+	if version == "" || version == "(devel)" {
+		return "pkg:golang/" + path
+	}
+	return "pkg:golang/" + path + "@" + version
+}
+
+// h1Hex converts a go.sum h1: hash, a base64 SHA-256 of the module tree, to
+// hex
+func h1Hex(sum string) (string, bool) {
+	b64, ok := strings.CutPrefix(sum, "h1:")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(raw) != 32 {
+		return "", false
+	}
+	return hex.EncodeToString(raw), true
+}
+
+// uuid4 returns a random RFC 4122 UUID
+func uuid4() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,64 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+)
+
+func TestBuildSBOM(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.25.3",
+		Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "github.com/gin-gonic/gin", Version: "v1.11.0", Sum: "h1:OW/6PLjyusp2PPXtyxKHU0RbX6I/l28FTdDlae5ueWk="},
+			{Path: "github.com/ncobase/ncore/ecode", Version: "v0.2.2", Replace: &debug.Module{Path: "../ecode"}},
+		},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}},
+	}
+	s := BuildSBOM(bi, Info{Version: "1.2.3", BuiltAt: "2026-01-02T03:04:05Z"})
+
+	if s.Metadata.Component.PURL != "pkg:golang/example.com/app@1.2.3" || s.Metadata.Timestamp != "2026-01-02T03:04:05Z" {
+		t.Errorf("metadata = %+v", s.Metadata)
+	}
+	if len(s.Components) != 2 {
+		t.Fatalf("components = %+v", s.Components)
+	}
+	gin := s.Components[0]
+	if gin.PURL != "pkg:golang/github.com/gin-gonic/gin@v1.11.0" || len(gin.Hashes) != 1 || len(gin.Hashes[0].Content) != 64 {
+		t.Errorf("gin = %+v", gin)
+	}
+	if s.Components[1].Name != "../ecode" {
+		t.Errorf("replaced module = %+v", s.Components[1])
+	}
+	if deps := s.Dependencies[0]; deps.Ref != s.Metadata.Component.BOMRef || len(deps.DependsOn) != 2 {
+		t.Errorf("dependencies = %+v", deps)
+	}
+}
+
+func TestSBOMHandler(t *testing.T) {
+	h := SBOMHandler(SBOMOptions{Token: "secret"})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/manage/sbom", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without token = %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/manage/sbom", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.ServeHTTP(w, req)
+	var s SBOM
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &s) != nil || s.BOMFormat != "CycloneDX" {
+		t.Errorf("with token = %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	SBOMHandler(SBOMOptions{}).ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unconfigured = %d", w.Code)
+	}
+}
@@ -32,6 +32,7 @@ var (
 
 	// Flag variables
 	showVersion bool
+	showSBOM    bool
 )
 
 // Info contains version information
@@ -45,10 +46,18 @@ type Info struct {
 
 func init() {
 	flag.BoolVar(&showVersion, "version", false, "show version information")
+	flag.BoolVar(&showSBOM, "sbom", false, "with -version, print a CycloneDX SBOM of the build")
 }
 
-// Flags handle version flags
+// Flags handle version flags, -version -sbom prints the SBOM instead
 func Flags() {
+	if showVersion && showSBOM {
+		if err := PrintSBOM(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	if showVersion {
 		Print()
 		os.Exit(0)